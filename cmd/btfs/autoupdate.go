@@ -3,7 +3,11 @@ package main
 
 import (
 	"bufio"
+	"crypto/ed25519"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -18,6 +22,9 @@ import (
 	"time"
 
 	btfs_version "github.com/TRON-US/go-btfs"
+	corecmds "github.com/TRON-US/go-btfs/core/commands"
+	"github.com/TRON-US/go-btfs/repo"
+
 	"github.com/TRON-US/go-btfs-api"
 	"github.com/mholt/archiver/v3"
 	"github.com/pkg/errors"
@@ -38,8 +45,17 @@ type Config struct {
 	SleepTimeSeconds int    `yaml:"sleepTimeSeconds"`
 	BeginNumber      int    `yaml:"beginNumber"`
 	EndNumber        int    `yaml:"endNumber"`
+	// Rollout is the share of nodes in percent the release is offered to.
+	// Releases without it are offered by BeginNumber and EndNumber.
+	Rollout *int `yaml:"rollout"`
 }
 
+// releasePublicKey is the base64 ed25519 key release binaries are signed
+// with, set at build time with -ldflags "-X main.releasePublicKey=<key>".
+// The Update.PublicKey config overrides it. Without either, the binaries
+// cannot be verified and no update is installed.
+var releasePublicKey = ""
+
 const (
 	UPGRADE_FLAG_LATEST = iota
 	UPGRADE_FLAG_OUT_OF_DATE
@@ -53,7 +69,7 @@ type Repo struct {
 }
 
 // Auto update function.
-func update(url, hval string) {
+func update(url, hval string, r repo.Repo) {
 	// Get current program execution path.
 	defaultBtfsPath, err := getCurrentPath()
 	if err != nil {
@@ -62,9 +78,11 @@ func update(url, hval string) {
 	}
 
 	configRepo := Repo{
-		url:        "https://dist.btfs.io/release/",
+		url:        corecmds.UpdateChannels[corecmds.DefaultUpdateChannel],
 		compressed: true,
 	}
+	channel := corecmds.DefaultUpdateChannel
+	publicKey := releasePublicKey
 
 	var latestConfigFile string
 	var updateBinary string
@@ -113,7 +131,14 @@ func update(url, hval string) {
 	routePath := fmt.Sprint(runtime.GOOS, "/", runtime.GOARCH, "/")
 
 	for {
-		time.Sleep(time.Second * time.Duration(sleepTimeSeconds))
+		// A check requested with 'btfs update now' skips the schedule and
+		// the rollout share.
+		forced := false
+		select {
+		case <-time.After(time.Second * time.Duration(sleepTimeSeconds)):
+		case <-corecmds.UpdateNowRequests:
+			forced = true
+		}
 
 		// Get current config file.
 		currentConfig, err := getConfigure(currentConfigPath)
@@ -125,10 +150,22 @@ func update(url, hval string) {
 			version = btfs_version.CurrentVersionNumber
 		}
 
-		if !autoupdateFlg {
+		if !autoupdateFlg && !forced {
 			continue
 		}
 
+		// Follow the configured channel, it may change while running.
+		if uc, err := corecmds.LoadUpdateConfig(r); err != nil {
+			log.Errorf("Read update config error, reasons: [%v]", err)
+		} else {
+			channel = uc.Channel
+			configRepo.url = corecmds.UpdateChannels[channel]
+			publicKey = releasePublicKey
+			if uc.PublicKey != "" {
+				publicKey = uc.PublicKey
+			}
+		}
+
 		if pathExists(latestConfigPath) {
 			// Delete the latest btfs config file.
 			err = os.Remove(latestConfigPath)
@@ -168,13 +205,14 @@ func update(url, hval string) {
 		//      0            1       [0, 1) 1% updated
 		//      0           100      [0, 100)100% updated
 		//     100          100      no nodes updated
-		if convertStringToInt(idOutput.ID)%100 < latestConfig.BeginNumber || convertStringToInt(idOutput.ID)%100 >= latestConfig.EndNumber {
+		if !forced && !inRollout(latestConfig, idOutput.ID) {
 			fmt.Println("This node is not in the scope of this automatic update.")
 			continue
 		}
 
-		// Compare version.
-		upgradeFlg, err := versionCompare(latestConfig.Version, version)
+		// Compare version. Only the beta and nightly channels install
+		// pre-release versions.
+		upgradeFlg, err := versionCompare(latestConfig.Version, version, channel != corecmds.DefaultUpdateChannel)
 		if err != nil {
 			log.Errorf("Version compare error, reasons: [%v]", err)
 			continue
@@ -192,6 +230,13 @@ func update(url, hval string) {
 			continue
 		}
 
+		if publicKey == "" {
+			log.Errorf("No release signing key known, not installing the unverified version %s. "+
+				"Set Update.PublicKey in the config to update.", latestConfig.Version)
+			sleepTimeSeconds = latestConfig.SleepTimeSeconds
+			continue
+		}
+
 		// Determine if the btfs latest binary file exists.
 		if pathExists(latestBtfsBinaryPath) {
 			// Delete the btfs latest binary file.
@@ -269,6 +314,13 @@ func update(url, hval string) {
 
 		fmt.Println("Md5 check btfs binary file success!")
 
+		err = checkSignature(latestBtfsBinaryPath, fmt.Sprint(configRepo.url, routePath, latestBtfsBinary), publicKey)
+		if err != nil {
+			log.Errorf("Verify btfs binary signature error, reasons: [%v]", err)
+			continue
+		}
+		fmt.Println("Signature check btfs binary file success!")
+
 		// Delete the update binary file if exists.
 		if pathExists(updateBinaryPath) {
 			err = os.Remove(updateBinaryPath)
@@ -309,6 +361,12 @@ func update(url, hval string) {
 				continue
 			}
 		}
+		err = checkSignature(updateBinaryPath, fmt.Sprint(configRepo.url, routePath, updateBinary), publicKey)
+		if err != nil {
+			log.Errorf("Verify update binary signature error, reasons: [%v]", err)
+			continue
+		}
+
 		// Add executable permissions to update binary file.
 		err = os.Chmod(updateBinaryPath, 0775)
 		if err != nil {
@@ -360,22 +418,29 @@ func getConfigure(fileName string) (*Config, error) {
 	return conf, nil
 }
 
-// Compare version.
-func versionCompare(version1, version2 string) (int, error) {
+// Compare version. With allowPrerelease, versions with a pre-release
+// suffix like 1.2.0-beta.1 are compared instead of skipped.
+func versionCompare(version1, version2 string, allowPrerelease bool) (int, error) {
 	// Split string of version1.
-	s1 := strings.Split(version1, ".")
+	s1 := strings.SplitN(version1, ".", 3)
 	if s1 == nil || len(s1) != 3 {
 		log.Error("String fo version1 has wrong format.")
 		return UPGRADE_FLAG_ERR, errors.New("string fo version1 has wrong format")
 	}
 
 	// Split string of version2.
-	s2 := strings.Split(version2, ".")
+	s2 := strings.SplitN(version2, ".", 3)
 	if s2 == nil || len(s2) != 3 {
 		log.Error("String fo version2 has wrong format.")
 		return UPGRADE_FLAG_ERR, errors.New("string fo version2 has wrong format")
 	}
 
+	var pre1, pre2 string
+	if allowPrerelease {
+		s1[2], pre1 = splitPrerelease(s1[2])
+		s2[2], pre2 = splitPrerelease(s2[2])
+	}
+
 	// If the current config.yaml contains a dash in the last section
 	// then do not automatic update.
 	if strings.Contains(s2[2], "-") {
@@ -410,7 +475,111 @@ func versionCompare(version1, version2 string) (int, error) {
 			return UPGRADE_FLAG_LATEST, nil
 		}
 	}
-	return UPGRADE_FLAG_LATEST, nil
+	return comparePrerelease(pre1, pre2), nil
+}
+
+// splitPrerelease splits the patch part of a version into its number and
+// pre-release suffix.
+func splitPrerelease(patch string) (string, string) {
+	if i := strings.Index(patch, "-"); i >= 0 {
+		return patch[:i], patch[i+1:]
+	}
+	return patch, ""
+}
+
+// comparePrerelease compares the pre-release suffixes of two versions with
+// the same number. A release is newer than its pre-releases, which are
+// compared by their dot-separated identifiers as in semver: numerically
+// when both are numbers, e.g. beta.10 after beta.9, as strings otherwise.
+func comparePrerelease(pre1, pre2 string) int {
+	switch {
+	case pre1 == pre2, pre2 == "":
+		return UPGRADE_FLAG_LATEST
+	case pre1 == "":
+		return UPGRADE_FLAG_OUT_OF_DATE
+	}
+	ids1, ids2 := strings.Split(pre1, "."), strings.Split(pre2, ".")
+	for i := 0; i < len(ids1) && i < len(ids2); i++ {
+		if c := compareIdentifier(ids1[i], ids2[i]); c > 0 {
+			return UPGRADE_FLAG_OUT_OF_DATE
+		} else if c < 0 {
+			return UPGRADE_FLAG_LATEST
+		}
+	}
+	// a longer set of identifiers is newer when the others are equal
+	if len(ids1) > len(ids2) {
+		return UPGRADE_FLAG_OUT_OF_DATE
+	}
+	return UPGRADE_FLAG_LATEST
+}
+
+// compareIdentifier compares two pre-release identifiers, numbers sorting
+// before the others.
+func compareIdentifier(id1, id2 string) int {
+	n1, err1 := strconv.ParseUint(id1, 10, 64)
+	n2, err2 := strconv.ParseUint(id2, 10, 64)
+	switch {
+	case err1 == nil && err2 == nil:
+		if n1 > n2 {
+			return 1
+		} else if n1 < n2 {
+			return -1
+		}
+		return 0
+	case err1 == nil:
+		return -1
+	case err2 == nil:
+		return 1
+	}
+	return strings.Compare(id1, id2)
+}
+
+// inRollout reports whether the node with the given peer id is offered the
+// release. Nodes are spread over 100 buckets by a hash of the id salted
+// with the version, so every release starts on a different set of nodes
+// and a growing rollout keeps the nodes it already included.
+func inRollout(release *Config, id string) bool {
+	if release.Rollout != nil {
+		sum := sha256.Sum256([]byte(release.Version + "/" + id))
+		return int(binary.BigEndian.Uint32(sum[:4])%100) < *release.Rollout
+	}
+	n := convertStringToInt(id) % 100
+	return n >= release.BeginNumber && n < release.EndNumber
+}
+
+// checkSignature downloads the signature published next to the binary at
+// url and verifies the binary at path against it.
+func checkSignature(path, url, publicKey string) error {
+	sigPath := path + ".sig"
+	if err := download(sigPath, url+".sig"); err != nil {
+		return err
+	}
+	defer os.Remove(sigPath)
+	sig, err := ioutil.ReadFile(sigPath)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return verifySignature(data, sig, publicKey)
+}
+
+// verifySignature checks the base64 ed25519 signature sig of data.
+func verifySignature(data, sig []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid release public key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return errors.Wrap(err, "invalid signature file")
+	}
+	if !ed25519.Verify(key, data, raw) {
+		return errors.New("signature mismatch")
+	}
+	return nil
 }
 
 // Get current program execution path.
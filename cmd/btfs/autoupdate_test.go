@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+func TestVersionComparePrerelease(t *testing.T) {
+	cases := []struct {
+		latest, current string
+		allowPre        bool
+		want            int
+	}{
+		{"1.2.0", "1.1.9", false, UPGRADE_FLAG_OUT_OF_DATE},
+		{"1.2.0-beta.1", "1.1.9", false, UPGRADE_FLAG_SKIP},
+		{"1.2.0-beta.1", "1.1.9", true, UPGRADE_FLAG_OUT_OF_DATE},
+		{"1.2.0-beta.2", "1.2.0-beta.1", true, UPGRADE_FLAG_OUT_OF_DATE},
+		{"1.2.0-beta.10", "1.2.0-beta.9", true, UPGRADE_FLAG_OUT_OF_DATE},
+		{"1.2.0-beta.9", "1.2.0-beta.10", true, UPGRADE_FLAG_LATEST},
+		{"1.2.0-beta.1.1", "1.2.0-beta.1", true, UPGRADE_FLAG_OUT_OF_DATE},
+		{"1.2.0-beta", "1.2.0-beta.1", true, UPGRADE_FLAG_LATEST},
+		{"1.2.0-rc.1", "1.2.0-beta.10", true, UPGRADE_FLAG_OUT_OF_DATE},
+		{"1.2.0-beta.1", "1.2.0-1", true, UPGRADE_FLAG_OUT_OF_DATE},
+		{"1.2.0-beta.1", "1.2.0-beta.1", true, UPGRADE_FLAG_LATEST},
+		{"1.2.0", "1.2.0-beta.2", true, UPGRADE_FLAG_OUT_OF_DATE},
+		{"1.2.0-nightly.20201014", "1.2.0", true, UPGRADE_FLAG_LATEST},
+		{"1.1.0", "1.2.0-beta.1", true, UPGRADE_FLAG_LATEST},
+	}
+	for _, c := range cases {
+		got, err := versionCompare(c.latest, c.current, c.allowPre)
+		if err != nil || got != c.want {
+			t.Errorf("versionCompare(%s, %s, %t) = %d, %v, want %d", c.latest, c.current, c.allowPre, got, err, c.want)
+		}
+	}
+}
+
+func TestInRollout(t *testing.T) {
+	rollout := func(p int) *Config { return &Config{Version: "1.2.0", Rollout: &p} }
+	in := func(c *Config) int {
+		n := 0
+		for i := 0; i < 1000; i++ {
+			if inRollout(c, fmt.Sprintf("16Uiu2HAm%d", i)) {
+				n++
+			}
+		}
+		return n
+	}
+	if n := in(rollout(0)); n != 0 {
+		t.Errorf("0%% rollout included %d nodes", n)
+	}
+	if n := in(rollout(100)); n != 1000 {
+		t.Errorf("100%% rollout included %d nodes", n)
+	}
+	if n := in(rollout(25)); n < 180 || n > 320 {
+		t.Errorf("25%% rollout included %d of 1000 nodes", n)
+	}
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("16Uiu2HAm%d", i)
+		if inRollout(rollout(10), id) && !inRollout(rollout(50), id) {
+			t.Fatalf("%s left the rollout when it grew", id)
+		}
+	}
+	if n := in(&Config{BeginNumber: 0, EndNumber: 0}); n != 0 {
+		t.Errorf("empty range included %d nodes", n)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := base64.StdEncoding.EncodeToString(pub)
+	data := []byte("btfs binary")
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)) + "\n")
+
+	if err := verifySignature(data, sig, key); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := verifySignature([]byte("tampered"), sig, key); err == nil {
+		t.Error("signature of other data accepted")
+	}
+	if err := verifySignature(data, []byte("<html>404</html>"), key); err == nil {
+		t.Error("garbage signature accepted")
+	}
+	if err := verifySignature(data, sig, "short"); err == nil {
+		t.Error("invalid key accepted")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/TRON-US/go-btfs/core/commands"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 )
@@ -37,6 +38,7 @@ func init() {
 			Root.Subcommands[k] = v
 		}
 	}
+	cmdenv.WithErrorCodes(Root)
 }
 
 // NB: when necessary, properties are described using negatives in order to
@@ -84,15 +86,23 @@ func (d *cmdDetails) usesRepo() bool          { return !d.doesNotUseRepo }
 // properties so that other code can make decisions about whether to invoke a
 // command or return an error to the user.
 var cmdDetailsMap = map[string]cmdDetails{
-	"init":           {doesNotUseConfigAsInput: true, cannotRunOnDaemon: true, doesNotUseRepo: true},
-	"daemon":         {doesNotUseConfigAsInput: true, cannotRunOnDaemon: true},
-	"commands":       {doesNotUseRepo: true},
-	"version":        {doesNotUseConfigAsInput: true, doesNotUseRepo: true}, // must be permitted to run before init
-	"log":            {cannotRunOnClient: true},
-	"diag/cmds":      {cannotRunOnClient: true},
-	"repo/fsck":      {cannotRunOnDaemon: true},
-	"config/edit":    {cannotRunOnDaemon: true, doesNotUseRepo: true},
-	"cid":            {doesNotUseRepo: true},
-	"rm":             {cannotRunOnClient: false, cannotRunOnDaemon: false},
-	"storage/upload": {cannotRunOnClient: true},
+	"init":             {doesNotUseConfigAsInput: true, cannotRunOnDaemon: true, doesNotUseRepo: true},
+	"daemon":           {doesNotUseConfigAsInput: true, cannotRunOnDaemon: true},
+	"commands":         {doesNotUseRepo: true},
+	"version":          {doesNotUseConfigAsInput: true, doesNotUseRepo: true}, // must be permitted to run before init
+	"log":              {cannotRunOnClient: true},
+	"diag/cmds":        {cannotRunOnClient: true},
+	"repo/fsck":        {cannotRunOnDaemon: true},
+	"config/edit":      {cannotRunOnDaemon: true, doesNotUseRepo: true},
+	"config/validate":  {cannotRunOnDaemon: true, doesNotUseConfigAsInput: true, doesNotUseRepo: true},
+	"config/migrate":   {cannotRunOnDaemon: true, doesNotUseConfigAsInput: true, doesNotUseRepo: true},
+	"cid":              {doesNotUseRepo: true},
+	"update/rollback":  {cannotRunOnDaemon: true, doesNotUseRepo: true},
+	"rm":               {cannotRunOnClient: false, cannotRunOnDaemon: false},
+	"storage/upload":   {cannotRunOnClient: true},
+	"completion":       {doesNotUseConfigAsInput: true, doesNotUseRepo: true},
+	"remote":           {cannotRunOnDaemon: true, doesNotUseConfigAsInput: true, doesNotUseRepo: true},
+	"fleet":            {cannotRunOnDaemon: true, doesNotUseConfigAsInput: true, doesNotUseRepo: true},
+	"completion/peers": {},
+	"completion/cids":  {},
 }
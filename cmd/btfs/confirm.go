@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"github.com/TRON-US/go-btfs-cmds/cli"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var errNotConfirmed = errors.New("aborted")
+
+// withConfirmation wraps the executors of commands taking --yes, so that
+// the confirmations they ask for are prompted on the terminal.
+func withConfirmation(makeExecutor cmds.MakeExecutor) cmds.MakeExecutor {
+	return func(req *cmds.Request, env interface{}) (cmds.Executor, error) {
+		exe, err := makeExecutor(req, env)
+		if err != nil || !cmdenv.HasYesOption(req.Command) {
+			return exe, err
+		}
+		return &confirmExecutor{Executor: exe, in: os.Stdin, out: os.Stderr}, nil
+	}
+}
+
+// confirmExecutor runs a command without --yes first. If the command asks
+// for a confirmation, it shows what is affected, asks, and runs the command
+// again with --yes.
+type confirmExecutor struct {
+	cmds.Executor
+	in  *os.File
+	out io.Writer
+}
+
+func (x *confirmExecutor) Execute(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+	if yes, _ := req.Options[cmdenv.YesOptionName].(bool); yes {
+		return x.Executor.Execute(req, re, env)
+	}
+	lower, ok := re.(cli.ResponseEmitter)
+	if !ok {
+		return x.Executor.Execute(req, re, env)
+	}
+	// An explicit false tells the command that it may ask.
+	req.Options[cmdenv.YesOptionName] = false
+
+	cre := &confirmEmitter{ResponseEmitter: lower}
+	if err := x.Executor.Execute(req, cre, env); err != nil {
+		return err
+	}
+	if cre.summary == "" {
+		return nil
+	}
+	if !terminal.IsTerminal(int(x.in.Fd())) {
+		return re.CloseWithError(fmt.Errorf("%s\nrerun with --yes to proceed", cre.summary))
+	}
+	if !askConfirmation(x.in, x.out, cre.summary) {
+		return re.CloseWithError(errNotConfirmed)
+	}
+	req.Options[cmdenv.YesOptionName] = true
+	return x.Executor.Execute(req, re, env)
+}
+
+func askConfirmation(in io.Reader, out io.Writer, summary string) bool {
+	fmt.Fprintf(out, "%s\nProceed? [y/N] ", summary)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// confirmEmitter holds back the confirmation errors of a command instead
+// of printing them.
+type confirmEmitter struct {
+	cli.ResponseEmitter
+	summary string
+}
+
+func (re *confirmEmitter) CloseWithError(err error) error {
+	if err != nil {
+		if summary, ok := cmdenv.ConfirmationSummary(err.Error()); ok {
+			re.summary = summary
+			return nil
+		}
+	}
+	return re.ResponseEmitter.CloseWithError(err)
+}
+
+// Type forwards the emitter type used to pick the PostRun of a command.
+func (re *confirmEmitter) Type() cmds.PostRunType {
+	if typer, ok := re.ResponseEmitter.(interface{ Type() cmds.PostRunType }); ok {
+		return typer.Type()
+	}
+	return cmds.CLI
+}
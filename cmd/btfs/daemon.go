@@ -22,10 +22,16 @@ import (
 	commands "github.com/TRON-US/go-btfs/core/commands"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	corehttp "github.com/TRON-US/go-btfs/core/corehttp"
+	"github.com/TRON-US/go-btfs/core/corehttp/compression"
 	httpremote "github.com/TRON-US/go-btfs/core/corehttp/remote"
 	corerepo "github.com/TRON-US/go-btfs/core/corerepo"
+	"github.com/TRON-US/go-btfs/core/hostgossip"
+	"github.com/TRON-US/go-btfs/core/netproxy"
 	libp2p "github.com/TRON-US/go-btfs/core/node/libp2p"
+	"github.com/TRON-US/go-btfs/core/power"
+	"github.com/TRON-US/go-btfs/core/wallet"
 	nodeMount "github.com/TRON-US/go-btfs/fuse/node"
+	"github.com/TRON-US/go-btfs/repo/configschema"
 	fsrepo "github.com/TRON-US/go-btfs/repo/fsrepo"
 	migrate "github.com/TRON-US/go-btfs/repo/fsrepo/migrations"
 	"github.com/TRON-US/go-btfs/spin"
@@ -139,6 +145,21 @@ You can setup CORS headers the same way:
   btfs config --json API.HTTPHeaders.Access-Control-Allow-Methods '["PUT", "GET", "POST"]'
   btfs config --json API.HTTPHeaders.Access-Control-Allow-Credentials '["true"]'
 
+CORS settings can also be managed per route group (API, Gateway, Wallet).
+Wallet routes only allow localhost origins unless configured otherwise:
+
+  btfs config cors set API --origin=https://app.example.com --method=POST
+  btfs config cors show
+
+Named Gateways
+
+Additional gateways with their own settings can be served on separate
+addresses, e.g. a public read-only gateway limited to a few roots and a
+private writable one protected by a token:
+
+  btfs config --json Gateways.public '{"Address": "/ip4/0.0.0.0/tcp/8081", "AllowedCIDs": ["<cid>"]}'
+  btfs config --json Gateways.private '{"Address": "/ip4/127.0.0.1/tcp/8082", "Writable": true, "AuthTokens": ["<token>"]}'
+
 Shutdown
 
 To shut down the daemon, send a SIGINT signal to it (e.g. by pressing 'Ctrl-C')
@@ -274,7 +295,7 @@ func daemonFunc(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment
 			}
 
 			if err = doInit(os.Stdout, cfg, false, utilmain.NBitsForKeypairDefault, profiles, conf,
-				keyTypeDefault, "", "", false); err != nil {
+				keyTypeDefault, "", "", false, nil); err != nil {
 				return err
 			}
 
@@ -282,6 +303,14 @@ func daemonFunc(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment
 		}
 	}
 
+	// check the config before opening the repo, which would otherwise fail
+	// on the first value it cannot decode.
+	if fsrepo.IsInitialized(cctx.ConfigRoot) {
+		if err := checkConfigSchema(cctx.ConfigRoot); err != nil {
+			return err
+		}
+	}
+
 	// acquire the repo lock _before_ constructing a node. we need to make
 	// sure we are permitted to access the resources (datastore, etc.)
 	repo, err := fsrepo.Open(cctx.ConfigRoot)
@@ -349,9 +378,29 @@ func daemonFunc(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment
 		}
 	}
 
+	// The proxy is set up before any outbound call, the HTTP and gRPC
+	// clients read HTTPS_PROXY once.
+	proxy, err := netproxy.Load(repo)
+	if err != nil {
+		return err
+	}
+	if proxy != nil {
+		netproxy.SetActive(proxy)
+		if proxy.Enabled(netproxy.Wallet) {
+			proxy.Tunnel(cfg.Services.ExchangeDomain, cfg.Services.SolidityDomain, cfg.Services.FullnodeDomain)
+			if err := proxy.StartBridge(req.Context); err != nil {
+				return err
+			}
+		}
+	}
+
 	offline, _ := req.Options[offlineKwd].(bool)
 	ipnsps, _ := req.Options[enableIPNSPubSubKwd].(bool)
 	pubsub, _ := req.Options[enablePubSubKwd].(bool)
+	// the host gossip runs over pubsub
+	if c, err := hostgossip.Load(repo); err == nil && c.Enabled {
+		pubsub = true
+	}
 	if _, hasMplex := req.Options[enableMultiplexKwd]; hasMplex {
 		log.Errorf("The mplex multiplexer has been enabled by default and the experimental %s flag has been removed.")
 		log.Errorf("To disable this multiplexer, please configure `Swarm.Transports.Multiplexers'.")
@@ -361,11 +410,15 @@ func daemonFunc(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment
 	url := fmt.Sprint(strings.Split(cfg.Addresses.API[0], "/")[2], ":", strings.Split(cfg.Addresses.API[0], "/")[4])
 
 	if !cfg.Experimental.DisableAutoUpdate {
-		go update(url, hValue)
+		go update(url, hValue, repo)
 	} else {
 		fmt.Println("Auto-update was disabled as config Experimental.DisableAutoUpdate was set as True")
 	}
 
+	if err := commands.UnlockRepo(req.Context, repo); err != nil {
+		return err
+	}
+
 	// Start assembling node config
 	ncfg := &core.BuildCfg{
 		Repo:                        repo,
@@ -411,6 +464,14 @@ func daemonFunc(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment
 		if routingOption == "" {
 			routingOption = routingOptionDHTKwd
 		}
+		// a node in low-power mode answers no DHT query
+		pcfg, err := power.Load(repo)
+		if err != nil {
+			return err
+		}
+		if pcfg.IsLow() && (routingOption == routingOptionDHTKwd || routingOption == routingOptionDHTServerKwd) {
+			routingOption = routingOptionDHTClientKwd
+		}
 	}
 	switch routingOption {
 	case routingOptionSupernodeKwd:
@@ -496,6 +557,12 @@ func daemonFunc(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment
 		return err
 	}
 
+	// construct named http gateways - if any are set in the config
+	namedGwErrc, err := serveHTTPNamedGateways(req, cctx)
+	if err != nil {
+		return err
+	}
+
 	// construct http remote api - if it is set in the config
 	var rapiErrc <-chan error
 	if len(cfg.Addresses.RemoteAPI) > 0 {
@@ -541,9 +608,40 @@ func daemonFunc(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment
 	spin.Analytics(cctx.ConfigRoot, node, version.CurrentVersionNumber, hValue)
 	spin.Hosts(node, env)
 	spin.Contracts(node, req, env, nodepb.ContractStat_HOST.String())
+	spin.History(node)
+	spin.Maintenance(node)
+	spin.Latency(node)
+	spin.Clock(node)
+	spin.Trash(node)
+	spin.DiskHealth(node)
+	spin.Renegotiations(req, env)
+	spin.Probes(req, env)
+	spin.Digests(node)
+	spin.Settlements(node)
+	spin.Access(node)
+	spin.Transfers(node)
+	spin.Expiries(req, env)
+	spin.Collateral(node)
+	spin.GuardQueue(node)
+	spin.Bandwidth(req, env)
+	spin.PriorityProviding(node)
+	spin.HostGossip(node)
 	if params, err := helper.ExtractContextParams(req, env); err == nil {
 		spin.NewWalletWrap(params).UpdateStatus()
 	}
+	if conn, err := wallet.LoadConnector(node.Repo); err != nil {
+		log.Errorf("Failed to load the exchange connector: %s", err)
+	} else {
+		wallet.SetConnector(conn)
+	}
+	if s, err := wallet.LoadSigner(node.Repo); err != nil {
+		log.Errorf("Failed to load the wallet signer: %s", err)
+	} else {
+		wallet.SetSigner(s)
+	}
+	if err := wallet.ApplyKDF(node.Repo); err != nil {
+		log.Errorf("Failed to load the wallet key derivation: %s", err)
+	}
 
 	// Give the user some immediate feedback when they hit C-c
 	go func() {
@@ -556,7 +654,7 @@ func daemonFunc(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment
 	// collect long-running errors and block for shutdown
 	// TODO(cryptix): our fuse currently doesn't follow this pattern for graceful shutdown
 	var errs error
-	for err := range merge(apiErrc, gwErrc, rapiErrc, gcErrc) {
+	for err := range merge(apiErrc, gwErrc, namedGwErrc, rapiErrc, gcErrc) {
 		if err != nil {
 			errs = multierror.Append(errs, err)
 		}
@@ -631,6 +729,7 @@ func serveHTTPApi(req *cmds.Request, cctx *oldcmds.Context) (<-chan error, error
 
 	var opts = []corehttp.ServeOption{
 		corehttp.MetricsCollectionOption("api"),
+		corehttp.CompressionOption(compression.API),
 		corehttp.CheckVersionOption(),
 		corehttp.CommandsOption(*cctx),
 		corehttp.WebUIOption,
@@ -761,7 +860,9 @@ func serveHTTPGateway(req *cmds.Request, cctx *oldcmds.Context) (<-chan error, e
 
 	var opts = []corehttp.ServeOption{
 		corehttp.MetricsCollectionOption("gateway"),
+		corehttp.CompressionOption(compression.Gateway),
 		corehttp.HostnameOption(),
+		corehttp.PaywallOption(),
 		corehttp.GatewayOption(writable, "/btfs", "/btns"),
 		corehttp.VersionOption(),
 		corehttp.CheckVersionOption(),
@@ -799,6 +900,62 @@ func serveHTTPGateway(req *cmds.Request, cctx *oldcmds.Context) (<-chan error, e
 	return errc, nil
 }
 
+// serveHTTPNamedGateways starts one listener for every named gateway in the
+// Gateways config section, each with its own write and access settings.
+func serveHTTPNamedGateways(req *cmds.Request, cctx *oldcmds.Context) (<-chan error, error) {
+	node, err := cctx.ConstructNode()
+	if err != nil {
+		return nil, fmt.Errorf("serveHTTPNamedGateways: ConstructNode() failed: %s", err)
+	}
+
+	gateways, err := corehttp.NamedGateways(node.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("serveHTTPNamedGateways: %s", err)
+	}
+
+	errc := make(chan error)
+	var wg sync.WaitGroup
+	for name, gw := range gateways {
+		gwMaddr, err := ma.NewMultiaddr(gw.Address)
+		if err != nil {
+			return nil, fmt.Errorf("serveHTTPNamedGateways: invalid address for gateway %q: %q (err: %s)", name, gw.Address, err)
+		}
+
+		gwLis, err := manet.Listen(gwMaddr)
+		if err != nil {
+			return nil, fmt.Errorf("serveHTTPNamedGateways: manet.Listen(%s) failed: %s", gwMaddr, err)
+		}
+
+		gwType := "readonly"
+		if gw.Writable {
+			gwType = "writable"
+		}
+		fmt.Printf("Gateway %q (%s) server listening on %s\n", name, gwType, gwLis.Multiaddr())
+
+		opts := []corehttp.ServeOption{
+			corehttp.MetricsCollectionOption("gateway_" + name),
+			corehttp.CompressionOption(compression.Gateway),
+			corehttp.GatewayAccessOption(gw),
+			corehttp.PaywallOption(),
+			corehttp.GatewayOption(gw.Writable, "/btfs", "/btns"),
+			corehttp.VersionOption(),
+		}
+
+		wg.Add(1)
+		go func(lis manet.Listener) {
+			defer wg.Done()
+			errc <- corehttp.Serve(node, manet.NetListener(lis), opts...)
+		}(gwLis)
+	}
+
+	go func() {
+		wg.Wait()
+		close(errc)
+	}()
+
+	return errc, nil
+}
+
 // serveHTTPRemoteApi collects options, creates listener, prints status message and starts serving requests
 func serveHTTPRemoteApi(req *cmds.Request, cctx *oldcmds.Context) (<-chan error, error) {
 	cfg, err := cctx.GetConfig()
@@ -831,8 +988,10 @@ func serveHTTPRemoteApi(req *cmds.Request, cctx *oldcmds.Context) (<-chan error,
 
 	var opts = []corehttp.ServeOption{
 		corehttp.MetricsCollectionOption("remote_api"),
+		corehttp.CompressionOption(compression.API),
 		corehttp.VersionOption(),
 		corehttp.CheckVersionOption(),
+		corehttp.ProtocolVersionOption(),
 		corehttp.CommandsRemoteOption(*cctx),
 	}
 
@@ -1037,3 +1196,20 @@ func functest(statusServerDomain, peerId, hValue string) {
 		fmt.Printf("BTFS daemon test skipped\n")
 	}
 }
+
+// checkConfigSchema prints the problems found in the config and fails on
+// errors.
+func checkConfigSchema(cfgRoot string) error {
+	raw, err := configschema.ReadConfig(cfgRoot)
+	if err != nil {
+		return err
+	}
+	problems := configschema.Validate(raw)
+	for _, p := range problems {
+		fmt.Printf("config %s\n", p)
+	}
+	if configschema.HasErrors(problems) {
+		return errors.New("invalid config, fix the errors above or run 'btfs config validate' for details")
+	}
+	return nil
+}
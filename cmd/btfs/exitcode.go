@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/e"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"github.com/TRON-US/go-btfs-cmds/cli"
+)
+
+// withExitCodes wraps the executors so that the exit status of the CLI
+// reflects the error code of a failed command.
+func withExitCodes(makeExecutor cmds.MakeExecutor) cmds.MakeExecutor {
+	return func(req *cmds.Request, env interface{}) (cmds.Executor, error) {
+		exe, err := makeExecutor(req, env)
+		if err != nil {
+			return nil, err
+		}
+		return &exitCodeExecutor{Executor: exe}, nil
+	}
+}
+
+type exitCodeExecutor struct {
+	cmds.Executor
+}
+
+func (x *exitCodeExecutor) Execute(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+	if lower, ok := re.(cli.ResponseEmitter); ok {
+		re = &exitCodeEmitter{ResponseEmitter: lower, req: req}
+	}
+	return cmdenv.DeadlineError(req, x.Executor.Execute(req, re, env))
+}
+
+// exitCodeEmitter sets the exit status from the error a command fails
+// with, before it is printed. A command that ran out of time fails with
+// a timed out error naming its timeout.
+type exitCodeEmitter struct {
+	cli.ResponseEmitter
+	req *cmds.Request
+}
+
+func (re *exitCodeEmitter) CloseWithError(err error) error {
+	if err != nil {
+		err = cmdenv.DeadlineError(re.req, err)
+		re.SetStatus(e.ExitCode(err))
+	}
+	return re.ResponseEmitter.CloseWithError(err)
+}
+
+// Type forwards the emitter type used to pick the PostRun of a command.
+func (re *exitCodeEmitter) Type() cmds.PostRunType {
+	if typer, ok := re.ResponseEmitter.(interface{ Type() cmds.PostRunType }); ok {
+		return typer.Type()
+	}
+	return cmds.CLI
+}
+
+// exitCode returns the exit status for the error returned by cli.Run.
+func exitCode(err error) int {
+	if code, ok := err.(cli.ExitError); ok {
+		return int(code)
+	}
+	return e.ExitCode(err)
+}
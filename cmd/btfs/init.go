@@ -15,6 +15,7 @@ import (
 	"github.com/TRON-US/go-btfs/cmd/btfs/util"
 	oldcmds "github.com/TRON-US/go-btfs/commands"
 	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/storage/path"
 	"github.com/TRON-US/go-btfs/namesys"
 	"github.com/TRON-US/go-btfs/repo/fsrepo"
 
@@ -50,6 +51,32 @@ located at ~/.btfs. To change the repo location, set the $BTFS_PATH
 environment variable:
 
     export BTFS_PATH=/path/to/btfsrepo
+
+New users can run 'btfs init --interactive', which asks for the node role
+(renter, host or gateway), the seed phrase, a wallet password and the
+storage location and capacity, and prints a summary at the end.
+
+Fleets of nodes are provisioned with 'btfs init --from-spec node.yaml', the
+spec declaring the identity, the services enabled, the config keys set, the
+name keys imported, the upload policies and the pins of the node:
+
+    identity:
+      seedPhraseFile: /run/secrets/btfs-seed
+      passwordEnv: BTFS_WALLET_PASSWORD
+    profiles: [server]
+    services: [host]
+    config:
+      Datastore.StorageMax: 500GB
+    keys:
+      - name: site
+        privateKeyFile: /run/secrets/btfs-site-key
+    uploadPolicies:
+      Default: archive
+    pins:
+      - /btfs/QmPChd2hVbrJ6bfo3WBcTW4iZnpHm8TEzWkLHmLpXhF68A
+
+Applying a spec again to its repo only changes what differs from it, and
+fails if the identity of the spec is not that of the repo.
 `,
 	},
 	Arguments: []cmds.Argument{
@@ -63,6 +90,8 @@ environment variable:
 		cmds.StringOption(importKeyOptionName, "i", "Import TRON private key to generate btfs PeerID."),
 		cmds.BoolOption(rmOnUnpinOptionName, "r", "Remove unpinned files.").WithDefault(false),
 		cmds.StringOption(seedOptionName, "s", "Import seed phrase"),
+		cmds.BoolOption(interactiveOptionName, "I", "Set up the node with an interactive wizard."),
+		cmds.StringOption(fromSpecOptionName, "Set up the node, or bring it in line, with a YAML spec file."),
 
 		// TODO need to decide whether to expose the override as a file or a
 		// directory. That is: should we allow the user to also specify the
@@ -117,8 +146,53 @@ environment variable:
 		importKey, _ := req.Options[importKeyOptionName].(string)
 		keyType, _ := req.Options[keyTypeOptionName].(string)
 		seedPhrase, _ := req.Options[seedOptionName].(string)
+		interactive, _ := req.Options[interactiveOptionName].(bool)
+		specFile, _ := req.Options[fromSpecOptionName].(string)
+
+		if specFile != "" {
+			if interactive || conf != nil || importKey != "" || seedPhrase != "" {
+				return fmt.Errorf("--%s can't be combined with --%s, a config file, --%s or --%s",
+					fromSpecOptionName, interactiveOptionName, importKeyOptionName, seedOptionName)
+			}
+			spec, err := readInitSpec(specFile)
+			if err != nil {
+				return err
+			}
+			return applyInitSpec(os.Stdout, cctx.ConfigRoot, spec, empty, nBitsForKeypair, profile, keyType, rmOnUnpin)
+		}
+		if !interactive {
+			return doInit(os.Stdout, cctx.ConfigRoot, empty, nBitsForKeypair, profile, conf, keyType, importKey, seedPhrase, rmOnUnpin, nil)
+		}
 
-		return doInit(os.Stdout, cctx.ConfigRoot, empty, nBitsForKeypair, profile, conf, keyType, importKey, seedPhrase, rmOnUnpin)
+		if conf != nil || importKey != "" || seedPhrase != "" {
+			return fmt.Errorf("--%s can't be combined with a config file, --%s or --%s",
+				interactiveOptionName, importKeyOptionName, seedOptionName)
+		}
+		if fsrepo.IsInitialized(cctx.ConfigRoot) {
+			return errRepoExists
+		}
+		answers, err := newInitWizard(os.Stdin, os.Stdout).run(cctx.ConfigRoot)
+		if err != nil {
+			return err
+		}
+		var initialized *config.Config
+		err = doInit(os.Stdout, answers.RepoRoot, empty, nBitsForKeypair, profile, nil, keyType, "", answers.SeedPhrase, rmOnUnpin,
+			func(c *config.Config) error {
+				initialized = c
+				return answers.apply(c)
+			})
+		if err != nil {
+			return err
+		}
+		if answers.RepoRoot != cctx.ConfigRoot {
+			// Remember the location like 'btfs storage path' does
+			path.StorePath = answers.RepoRoot
+			if err := path.WriteProperties(); err != nil {
+				return err
+			}
+		}
+		answers.printSummary(os.Stdout, initialized)
+		return nil
 	},
 }
 
@@ -127,7 +201,7 @@ Reinitializing would overwrite your keys.
 `)
 
 func doInit(out io.Writer, repoRoot string, empty bool, nBitsForKeypair int, confProfiles string, conf *config.Config,
-	keyType string, importKey string, mnemonic string, rmOnUnpin bool) error {
+	keyType string, importKey string, mnemonic string, rmOnUnpin bool, customize func(*config.Config) error) error {
 
 	importKey, mnemonic, err := util.GenerateKey(importKey, keyType, mnemonic)
 	if err != nil {
@@ -163,6 +237,12 @@ func doInit(out io.Writer, repoRoot string, empty bool, nBitsForKeypair int, con
 		return err
 	}
 
+	if customize != nil {
+		if err := customize(conf); err != nil {
+			return err
+		}
+	}
+
 	if err := fsrepo.Init(repoRoot, conf); err != nil {
 		return err
 	}
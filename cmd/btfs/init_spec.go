@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/cmd/btfs/util"
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/coreapi"
+	"github.com/TRON-US/go-btfs/keystore"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	config "github.com/TRON-US/go-btfs-config"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+	"gopkg.in/yaml.v2"
+)
+
+const fromSpecOptionName = "from-spec"
+
+// specPinTimeout bounds how long the pins of a spec are fetched.
+var specPinTimeout = 10 * time.Minute
+
+// initSpec declares a node for 'btfs init --from-spec', so that a fleet of
+// nodes is provisioned the same way by configuration management. Applying
+// a spec again to its repo only changes what differs from it.
+type initSpec struct {
+	Identity specIdentity `yaml:"identity"`
+	// Profiles are applied when the repo is created, see 'btfs config
+	// profile'.
+	Profiles []string `yaml:"profiles"`
+	// Services are the roles enabled: renter, host or gateway, as in
+	// 'btfs init --interactive'.
+	Services []string `yaml:"services"`
+	// UploadPolicies is the UploadPolicies config section, see 'btfs
+	// storage upload policies'.
+	UploadPolicies interface{} `yaml:"uploadPolicies"`
+	// Config sets the config keys, e.g. Datastore.StorageMax, after the
+	// services and policies, as 'btfs config --json' does.
+	Config map[string]interface{} `yaml:"config"`
+	// Keys are the BTNS name keys imported into the keystore.
+	Keys []specKey `yaml:"keys"`
+	// Pins are the paths pinned, fetched from the network.
+	Pins []string `yaml:"pins"`
+}
+
+// specIdentity is the source of the identity of the node, a new one when
+// empty. The secrets may be read from a file or an environment variable
+// rather than written in the spec.
+type specIdentity struct {
+	KeyType        string `yaml:"keyType"`
+	SeedPhrase     string `yaml:"seedPhrase"`
+	SeedPhraseFile string `yaml:"seedPhraseFile"`
+	SeedPhraseEnv  string `yaml:"seedPhraseEnv"`
+	PrivateKey     string `yaml:"privateKey"`
+	PrivateKeyFile string `yaml:"privateKeyFile"`
+	PrivateKeyEnv  string `yaml:"privateKeyEnv"`
+	// The wallet password is only set when the repo is created.
+	PasswordFile string `yaml:"passwordFile"`
+	PasswordEnv  string `yaml:"passwordEnv"`
+}
+
+// specKey is a key imported into the keystore, its private key encoded in
+// base64 like Identity.PrivKey.
+type specKey struct {
+	Name           string `yaml:"name"`
+	PrivateKeyFile string `yaml:"privateKeyFile"`
+	PrivateKeyEnv  string `yaml:"privateKeyEnv"`
+}
+
+// serviceSettings are the config keys set by each service.
+var serviceSettings = map[string]map[string]interface{}{
+	roleRenter: {
+		"Experimental.StorageClientEnabled": true,
+	},
+	roleHost: {
+		"Experimental.StorageHostEnabled":   true,
+		"Experimental.HostRepairEnabled":    true,
+		"Experimental.HostChallengeEnabled": true,
+	},
+	roleGateway: {
+		"Addresses.Gateway": []interface{}{"/ip4/0.0.0.0/tcp/8080"},
+	},
+}
+
+// readInitSpec reads the spec in the YAML file name, rejecting unknown
+// fields.
+func readInitSpec(name string) (*initSpec, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	s := &initSpec{}
+	if err := yaml.UnmarshalStrict(b, s); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %s", name, err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %s", name, err)
+	}
+	return s, nil
+}
+
+func (s *initSpec) validate() error {
+	for _, svc := range s.Services {
+		if _, ok := serviceSettings[svc]; !ok {
+			return fmt.Errorf("unknown service %q, must be %s, %s or %s", svc, roleRenter, roleHost, roleGateway)
+		}
+	}
+	for _, p := range s.Profiles {
+		if _, ok := config.Profiles[p]; !ok {
+			return fmt.Errorf("unknown profile %q", p)
+		}
+	}
+	names := map[string]bool{}
+	for _, k := range s.Keys {
+		if k.Name == "" || k.Name == "self" || names[k.Name] {
+			return fmt.Errorf("keys need distinct names other than self")
+		}
+		names[k.Name] = true
+		if (k.PrivateKeyFile == "") == (k.PrivateKeyEnv == "") {
+			return fmt.Errorf("key %s needs one of privateKeyFile and privateKeyEnv", k.Name)
+		}
+	}
+	id := s.Identity
+	seeds, keys := count(id.SeedPhrase, id.SeedPhraseFile, id.SeedPhraseEnv),
+		count(id.PrivateKey, id.PrivateKeyFile, id.PrivateKeyEnv)
+	if seeds+keys > 1 {
+		return fmt.Errorf("the identity needs at most one seed phrase or private key")
+	}
+	if count(id.PasswordFile, id.PasswordEnv) > 1 {
+		return fmt.Errorf("the identity needs at most one of passwordFile and passwordEnv")
+	}
+	return nil
+}
+
+func count(values ...string) int {
+	n := 0
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// secret returns value, or the content of the file name, or the value of the
+// environment variable env, trimmed.
+func secret(value, name, env string) (string, error) {
+	switch {
+	case name != "":
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	case env != "":
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", env)
+		}
+		return strings.TrimSpace(v), nil
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// settings returns the config keys set by the spec, in the order they are
+// applied: those of the services, the upload policies, then Config.
+func (s *initSpec) settings() ([]string, map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	var keys []string
+	set := func(k string, v interface{}) error {
+		v, err := jsonValue(v)
+		if err != nil {
+			return fmt.Errorf("config key %s: %s", k, err)
+		}
+		if _, ok := values[k]; !ok {
+			keys = append(keys, k)
+		}
+		values[k] = v
+		return nil
+	}
+	for _, svc := range s.Services {
+		svcKeys := make([]string, 0, len(serviceSettings[svc]))
+		for k := range serviceSettings[svc] {
+			svcKeys = append(svcKeys, k)
+		}
+		sort.Strings(svcKeys)
+		for _, k := range svcKeys {
+			if err := set(k, serviceSettings[svc][k]); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if s.UploadPolicies != nil {
+		if err := set("UploadPolicies", s.UploadPolicies); err != nil {
+			return nil, nil, err
+		}
+	}
+	cfgKeys := make([]string, 0, len(s.Config))
+	for k := range s.Config {
+		cfgKeys = append(cfgKeys, k)
+	}
+	sort.Strings(cfgKeys)
+	for _, k := range cfgKeys {
+		if k == config.PrivKeySelector || strings.HasPrefix(k, "Identity.") {
+			return nil, nil, fmt.Errorf("config key %s is set by the identity of the spec", k)
+		}
+		if err := set(k, s.Config[k]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return keys, values, nil
+}
+
+// jsonValue converts v decoded from YAML to the value decoded from its JSON
+// encoding, as the config file holds.
+func jsonValue(v interface{}) (interface{}, error) {
+	var convert func(v interface{}) (interface{}, error)
+	convert = func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case map[interface{}]interface{}:
+			m := make(map[string]interface{}, len(v))
+			for k, e := range v {
+				ks, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("non string key %v", k)
+				}
+				c, err := convert(e)
+				if err != nil {
+					return nil, err
+				}
+				m[ks] = c
+			}
+			return m, nil
+		case []interface{}:
+			l := make([]interface{}, len(v))
+			for i, e := range v {
+				c, err := convert(e)
+				if err != nil {
+					return nil, err
+				}
+				l[i] = c
+			}
+			return l, nil
+		}
+		return v, nil
+	}
+	c, err := convert(v)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+// identity returns the TRON private key and the seed phrase of the spec,
+// both empty for a new identity.
+func (s *initSpec) identity() (importKey string, seedPhrase string, err error) {
+	id := s.Identity
+	if seedPhrase, err = secret(id.SeedPhrase, id.SeedPhraseFile, id.SeedPhraseEnv); err != nil {
+		return "", "", err
+	}
+	if importKey, err = secret(id.PrivateKey, id.PrivateKeyFile, id.PrivateKeyEnv); err != nil {
+		return "", "", err
+	}
+	// the words of a seed phrase are separated by commas, as with --seed
+	return importKey, strings.Join(strings.Fields(strings.Replace(seedPhrase, ",", " ", -1)), ","), nil
+}
+
+// applyInitSpec creates the repo at repoRoot as declared by s, or brings the
+// existing one in line with it, and prints what it changed to out.
+// The profiles and key type of the options are used along with those of s.
+func applyInitSpec(out io.Writer, repoRoot string, s *initSpec, empty bool, nBitsForKeypair int,
+	profile string, keyType string, rmOnUnpin bool) error {
+	importKey, seedPhrase, err := s.identity()
+	if err != nil {
+		return err
+	}
+	if s.Identity.KeyType != "" {
+		keyType = s.Identity.KeyType
+	}
+	profiles := s.Profiles
+	if profile != "" {
+		profiles = append(profiles, strings.Split(profile, ",")...)
+	}
+	if !fsrepo.IsInitialized(repoRoot) {
+		password, err := secret("", s.Identity.PasswordFile, s.Identity.PasswordEnv)
+		if err != nil {
+			return err
+		}
+		err = doInit(out, repoRoot, empty, nBitsForKeypair, strings.Join(profiles, ","), nil, keyType, importKey,
+			seedPhrase, rmOnUnpin, func(c *config.Config) error {
+				if password == "" {
+					return nil
+				}
+				return encryptIdentity(c, password)
+			})
+		if err != nil {
+			return err
+		}
+	} else if err := checkSpecIdentity(repoRoot, keyType, importKey, seedPhrase); err != nil {
+		return err
+	}
+
+	r, err := fsrepo.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	keys, values, err := s.settings()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if cur, err := r.GetConfigKey(k); err == nil && reflect.DeepEqual(cur, values[k]) {
+			continue
+		}
+		if err := r.SetConfigKey(k, values[k]); err != nil {
+			return fmt.Errorf("config key %s: %s", k, err)
+		}
+		fmt.Fprintf(out, "set config %s\n", k)
+	}
+	raw, err := configschema.ReadConfig(repoRoot)
+	if err != nil {
+		return err
+	}
+	if problems := configschema.Validate(raw); configschema.HasErrors(problems) {
+		for _, p := range problems {
+			fmt.Fprintln(out, p)
+		}
+		return fmt.Errorf("the config of the spec is invalid, see 'btfs config check'")
+	}
+
+	for _, k := range s.Keys {
+		imported, err := importSpecKey(r.Keystore(), k)
+		if err != nil {
+			return err
+		}
+		if imported {
+			fmt.Fprintf(out, "imported key %s\n", k.Name)
+		}
+	}
+	r.Close()
+
+	if len(s.Pins) > 0 {
+		return pinSpec(out, repoRoot, s.Pins)
+	}
+	return nil
+}
+
+// checkSpecIdentity fails unless the identity of the spec, when it has one,
+// is that of the repo at repoRoot.
+func checkSpecIdentity(repoRoot, keyType, importKey, seedPhrase string) error {
+	if importKey == "" && seedPhrase == "" {
+		return nil
+	}
+	importKey, mnemonic, err := util.GenerateKey(importKey, keyType, seedPhrase)
+	if err != nil {
+		return err
+	}
+	want, err := config.Init(ioutil.Discard, util.NBitsForKeypairDefault, keyType, importKey, mnemonic, false)
+	if err != nil {
+		return err
+	}
+	r, err := fsrepo.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+	if cfg.Identity.PeerID != want.Identity.PeerID {
+		return fmt.Errorf("the repo at %s has the identity %s, not %s of the spec", repoRoot,
+			cfg.Identity.PeerID, want.Identity.PeerID)
+	}
+	return nil
+}
+
+// importSpecKey imports k into ks unless it holds it already, and fails if
+// ks holds another key of its name.
+func importSpecKey(ks keystore.Keystore, k specKey) (bool, error) {
+	encoded, err := secret("", k.PrivateKeyFile, k.PrivateKeyEnv)
+	if err != nil {
+		return false, err
+	}
+	b, err := ci.ConfigDecodeKey(encoded)
+	if err != nil {
+		return false, fmt.Errorf("key %s: %s", k.Name, err)
+	}
+	sk, err := ci.UnmarshalPrivateKey(b)
+	if err != nil {
+		return false, fmt.Errorf("key %s: %s", k.Name, err)
+	}
+	has, err := ks.Has(k.Name)
+	if err != nil {
+		return false, err
+	}
+	if has {
+		cur, err := ks.Get(k.Name)
+		if err != nil {
+			return false, err
+		}
+		if !cur.Equals(sk) {
+			return false, fmt.Errorf("the keystore holds another key %s", k.Name)
+		}
+		return false, nil
+	}
+	return true, ks.Put(k.Name, sk)
+}
+
+// pinSpec pins paths on the repo at repoRoot, fetching them from the
+// network. Pinning a path again does nothing.
+func pinSpec(out io.Writer, repoRoot string, paths []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), specPinTimeout)
+	defer cancel()
+
+	r, err := fsrepo.Open(repoRoot)
+	if err != nil { // NB: repo is owned by the node
+		return err
+	}
+	nd, err := core.NewNode(ctx, &core.BuildCfg{Repo: r, Online: true})
+	if err != nil {
+		return err
+	}
+	defer nd.Close()
+	api, err := coreapi.NewCoreAPI(nd)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		rp, err := api.ResolvePath(ctx, path.New(p))
+		if err != nil {
+			return fmt.Errorf("pin %s: %s", p, err)
+		}
+		if _, pinned, err := nd.Pinning.IsPinned(ctx, rp.Cid()); err == nil && pinned {
+			continue
+		}
+		if err := api.Pin().Add(ctx, rp); err != nil {
+			return fmt.Errorf("pin %s: %s", p, err)
+		}
+		fmt.Fprintf(out, "pinned %s\n", p)
+	}
+	return nil
+}
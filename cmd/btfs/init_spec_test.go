@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeSpec(t *testing.T, spec string) string {
+	dir, err := ioutil.TempDir("", "btfs-spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	name := filepath.Join(dir, "node.yaml")
+	if err := ioutil.WriteFile(name, []byte(spec), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReadInitSpec(t *testing.T) {
+	for spec, want := range map[string]string{
+		"services: [miner]":                        "unknown service",
+		"profiles: [fastest]":                      "unknown profile",
+		"pinned: [/btfs/Qm]":                       "field pinned not found",
+		"keys: [{name: self}]":                     "distinct names",
+		"keys: [{name: site}]":                     "needs one of",
+		"identity: {seedPhrase: a, privateKey: b}": "at most one",
+	} {
+		_, err := readInitSpec(writeSpec(t, spec))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("spec %q: expected an error with %q, got %v", spec, want, err)
+		}
+	}
+
+	s, err := readInitSpec(writeSpec(t, `
+identity:
+  seedPhraseEnv: BTFS_TEST_SEED
+services: [host]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("BTFS_TEST_SEED", " "+mnemonic+"\n")
+	defer os.Unsetenv("BTFS_TEST_SEED")
+	importKey, seedPhrase, err := s.identity()
+	if err != nil || importKey != "" || seedPhrase != strings.Replace(mnemonic, " ", ",", -1) {
+		t.Fatalf("unexpected identity %q %q %v", importKey, seedPhrase, err)
+	}
+}
+
+func TestInitSpecSettings(t *testing.T) {
+	s, err := readInitSpec(writeSpec(t, `
+services: [gateway, host]
+uploadPolicies:
+  Default: archive
+  Locked: true
+config:
+  Datastore.StorageMax: 500GB
+  Experimental.HostRepairEnabled: false
+  Swarm.ConnMgr: {LowWater: 100, HighWater: 200}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, values, err := s.settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantKeys := []string{
+		"Addresses.Gateway",
+		"Experimental.HostChallengeEnabled",
+		"Experimental.HostRepairEnabled",
+		"Experimental.StorageHostEnabled",
+		"UploadPolicies",
+		"Datastore.StorageMax",
+		"Swarm.ConnMgr",
+	}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Fatalf("expected the keys %v, got %v", wantKeys, keys)
+	}
+	// the config overrides the services
+	if values["Experimental.HostRepairEnabled"] != false {
+		t.Fatalf("unexpected value %v", values["Experimental.HostRepairEnabled"])
+	}
+	want := map[string]interface{}{"LowWater": float64(100), "HighWater": float64(200)}
+	if !reflect.DeepEqual(values["Swarm.ConnMgr"], want) {
+		t.Fatalf("unexpected value %#v", values["Swarm.ConnMgr"])
+	}
+
+	s.Config = map[string]interface{}{"Identity.PeerID": "Qm"}
+	if _, _, err := s.settings(); err == nil {
+		t.Fatal("expected the identity keys rejected")
+	}
+}
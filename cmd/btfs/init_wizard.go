@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	config "github.com/TRON-US/go-btfs-config"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const interactiveOptionName = "interactive"
+
+// Node roles offered by the init wizard.
+const (
+	roleRenter  = "renter"
+	roleHost    = "host"
+	roleGateway = "gateway"
+)
+
+var roleDescriptions = []struct {
+	role, desc string
+}{
+	{roleRenter, "store files on the BTFS network"},
+	{roleHost, "rent out disk space to renters and earn BTT"},
+	{roleGateway, "serve BTFS content over HTTP to the public"},
+}
+
+// initAnswers holds the choices made in the init wizard.
+type initAnswers struct {
+	Role       string
+	SeedPhrase string // comma separated, empty to generate a new one
+	Password   string
+	RepoRoot   string
+	StorageMax string
+}
+
+// initWizard asks the init questions on a terminal, or on any reader for
+// scripted setups.
+type initWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	fd  int // terminal used to read secrets without echo, -1 if none
+}
+
+func newInitWizard(in io.Reader, out io.Writer) *initWizard {
+	fd := -1
+	if f, ok := in.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		fd = int(f.Fd())
+	}
+	return &initWizard{in: bufio.NewReader(in), out: out, fd: fd}
+}
+
+func (w *initWizard) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("init wizard aborted: %s", err)
+	}
+	if line = strings.TrimSpace(line); line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+func (w *initWizard) askSecret(question string) (string, error) {
+	if w.fd < 0 {
+		return w.ask(question, "")
+	}
+	fmt.Fprintf(w.out, "%s: ", question)
+	b, err := terminal.ReadPassword(w.fd)
+	fmt.Fprintln(w.out)
+	if err != nil {
+		return "", fmt.Errorf("init wizard aborted: %s", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// run walks through the questions, re-asking until each answer is valid.
+func (w *initWizard) run(defaultRoot string) (*initAnswers, error) {
+	a := &initAnswers{}
+	fmt.Fprintln(w.out, "Welcome to BTFS! A few questions will set up your node.")
+	fmt.Fprintln(w.out)
+
+	fmt.Fprintln(w.out, "Which role should this node have?")
+	for _, r := range roleDescriptions {
+		fmt.Fprintf(w.out, "  %-8s %s\n", r.role, r.desc)
+	}
+	for {
+		role, err := w.ask("Role", roleRenter)
+		if err != nil {
+			return nil, err
+		}
+		if validRole(role) {
+			a.Role = strings.ToLower(role)
+			break
+		}
+		fmt.Fprintf(w.out, "unknown role %q\n", role)
+	}
+
+	for {
+		choice, err := w.ask("Generate a new seed phrase or import one? (generate/import)", "generate")
+		if err != nil {
+			return nil, err
+		}
+		if choice == "generate" {
+			break
+		}
+		if choice != "import" {
+			continue
+		}
+		phrase, err := w.askSecret("Seed phrase (12 words)")
+		if err != nil {
+			return nil, err
+		}
+		if words := strings.Fields(phrase); len(words) == 12 {
+			a.SeedPhrase = strings.Join(words, ",")
+			break
+		}
+		fmt.Fprintln(w.out, "the seed phrase needs to contain 12 words")
+	}
+
+	for {
+		pw, err := w.askSecret("Wallet password (empty to set it later)")
+		if err != nil {
+			return nil, err
+		}
+		if pw == "" {
+			break
+		}
+		confirm, err := w.askSecret("Repeat wallet password")
+		if err != nil {
+			return nil, err
+		}
+		if pw == confirm {
+			a.Password = pw
+			break
+		}
+		fmt.Fprintln(w.out, "passwords do not match")
+	}
+
+	root, err := w.ask("Repository location", defaultRoot)
+	if err != nil {
+		return nil, err
+	}
+	if a.RepoRoot, err = homedir.Expand(root); err != nil {
+		return nil, err
+	}
+	if a.RepoRoot, err = filepath.Abs(a.RepoRoot); err != nil {
+		return nil, err
+	}
+
+	capacityQuestion := "Maximum storage capacity"
+	if a.Role == roleHost {
+		capacityQuestion = "Storage capacity to offer to renters"
+	}
+	for {
+		max, err := w.ask(capacityQuestion, "10GB")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := humanize.ParseBytes(max); err == nil {
+			a.StorageMax = max
+			break
+		}
+		fmt.Fprintf(w.out, "invalid capacity %q, expected e.g. 500GB or 2TB\n", max)
+	}
+	fmt.Fprintln(w.out)
+	return a, nil
+}
+
+func validRole(role string) bool {
+	for _, r := range roleDescriptions {
+		if strings.ToLower(role) == r.role {
+			return true
+		}
+	}
+	return false
+}
+
+// apply sets the config values for the chosen answers.
+func (a *initAnswers) apply(conf *config.Config) error {
+	switch a.Role {
+	case roleHost:
+		conf.Experimental.StorageHostEnabled = true
+		conf.Experimental.HostRepairEnabled = true
+		conf.Experimental.HostChallengeEnabled = true
+	case roleGateway:
+		conf.Experimental.StorageClientEnabled = false
+		conf.Addresses.Gateway = config.Strings{"/ip4/0.0.0.0/tcp/8080"}
+	}
+	conf.Datastore.StorageMax = a.StorageMax
+
+	if a.Password != "" {
+		return encryptIdentity(conf, a.Password)
+	}
+	return nil
+}
+
+// encryptIdentity sets the wallet password of conf, encrypting its keys.
+func encryptIdentity(conf *config.Config, password string) error {
+	cipherMnemonic, err := wallet.EncryptWithAES(password, conf.Identity.Mnemonic)
+	if err != nil {
+		return err
+	}
+	cipherPrivKey, err := wallet.EncryptWithAES(password, conf.Identity.PrivKey)
+	if err != nil {
+		return err
+	}
+	conf.Identity.EncryptedMnemonic = cipherMnemonic
+	conf.Identity.EncryptedPrivKey = cipherPrivKey
+	return nil
+}
+
+// printSummary prints the outcome of the wizard and the next steps.
+func (a *initAnswers) printSummary(out io.Writer, conf *config.Config) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Your BTFS node is ready:")
+	fmt.Fprintf(out, "  Role:        %s\n", a.Role)
+	fmt.Fprintf(out, "  Peer ID:     %s\n", conf.Identity.PeerID)
+	fmt.Fprintf(out, "  Repository:  %s\n", a.RepoRoot)
+	fmt.Fprintf(out, "  Capacity:    %s\n", conf.Datastore.StorageMax)
+	if a.Role == roleGateway {
+		fmt.Fprintf(out, "  Gateway:     %s\n", strings.Join(conf.Addresses.Gateway, ", "))
+	}
+	if a.Password != "" {
+		fmt.Fprintln(out, "  Wallet:      password set")
+	} else {
+		fmt.Fprintln(out, "  Wallet:      no password, set one with 'btfs wallet password'")
+	}
+	if a.SeedPhrase == "" && conf.Identity.Mnemonic != "" {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Write down your seed phrase and keep it safe, it is the only way to")
+		fmt.Fprintln(out, "recover your wallet:")
+		fmt.Fprintf(out, "\n  %s\n", conf.Identity.Mnemonic)
+	}
+	fmt.Fprintln(out)
+	switch a.Role {
+	case roleHost:
+		fmt.Fprintln(out, "Start hosting with 'btfs daemon' and check 'btfs storage announce --help'.")
+	default:
+		fmt.Fprintln(out, "Start your node with 'btfs daemon'.")
+	}
+}
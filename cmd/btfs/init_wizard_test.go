@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	config "github.com/TRON-US/go-btfs-config"
+)
+
+func TestInitWizard(t *testing.T) {
+	input := strings.Join([]string{
+		"farmer", // invalid role, asked again
+		"host",
+		"import",
+		mnemonic,
+		"secret",
+		"secret",
+		"/tmp/btfs-wizard",
+		"lots", // invalid capacity, asked again
+		"2TB",
+	}, "\n") + "\n"
+
+	a, err := newInitWizard(strings.NewReader(input), ioutil.Discard).run("/tmp/default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Role != roleHost || a.Password != "secret" || a.RepoRoot != "/tmp/btfs-wizard" || a.StorageMax != "2TB" {
+		t.Fatalf("unexpected answers %+v", a)
+	}
+	if a.SeedPhrase != strings.Replace(mnemonic, " ", ",", -1) {
+		t.Fatalf("unexpected seed phrase %q", a.SeedPhrase)
+	}
+
+	conf := &config.Config{Identity: config.Identity{PrivKey: privateKey, Mnemonic: mnemonic}}
+	if err := a.apply(conf); err != nil {
+		t.Fatal(err)
+	}
+	if !conf.Experimental.StorageHostEnabled || conf.Datastore.StorageMax != "2TB" {
+		t.Fatal("expected the host settings to be applied")
+	}
+	if pk, err := wallet.DecryptWithAES("secret", conf.Identity.EncryptedPrivKey); err != nil || pk != privateKey {
+		t.Fatal("expected the private key to be encrypted with the wallet password")
+	}
+}
+
+func TestInitWizardDefaults(t *testing.T) {
+	a, err := newInitWizard(strings.NewReader("\n\n\n\n\n"), ioutil.Discard).run("/tmp/default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Role != roleRenter || a.SeedPhrase != "" || a.Password != "" || a.RepoRoot != "/tmp/default" || a.StorageMax != "10GB" {
+		t.Fatalf("unexpected answers %+v", a)
+	}
+	if _, err := newInitWizard(strings.NewReader(""), ioutil.Discard).run("/tmp/default"); err == nil {
+		t.Fatal("expected closed input to abort the wizard")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	oldcmds "github.com/TRON-US/go-btfs/commands"
 	core "github.com/TRON-US/go-btfs/core"
 	corecmds "github.com/TRON-US/go-btfs/core/commands"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
 	corehttp "github.com/TRON-US/go-btfs/core/corehttp"
 	loader "github.com/TRON-US/go-btfs/plugin/loader"
 	repo "github.com/TRON-US/go-btfs/repo"
@@ -118,6 +119,19 @@ func mainRet() int {
 		os.Args = append(os.Args, "daemon", "--init")
 	}
 
+	// Expand a user-defined alias used as the command
+	if len(os.Args) > 1 {
+		args, err := expandAlias(os.Args[1:])
+		if err != nil {
+			printErr(err)
+			return 1
+		}
+		os.Args = append(os.Args[:1], args...)
+	}
+
+	// Translate the help text to the configured or environment locale
+	corecmds.Localize(Root, cliRepoPath(os.Args[1:]))
+
 	// output depends on executable name passed in os.Args
 	// so we need to make sure it's stable
 	//os.Args[0] = "ipfs"
@@ -151,6 +165,10 @@ func mainRet() int {
 				if err != nil { // repo is owned by the node
 					return nil, err
 				}
+				if err := corecmds.UnlockRepo(ctx, r); err != nil {
+					r.Close()
+					return nil, err
+				}
 
 				// ok everything is good. set it on the invocation (for ownership)
 				// and return it.
@@ -166,15 +184,43 @@ func mainRet() int {
 		}, nil
 	}
 
-	err = cli.Run(ctx, Root, os.Args, os.Stdin, os.Stdout, os.Stderr, buildEnv, makeExecutor)
+	err = cli.Run(ctx, Root, os.Args, os.Stdin, os.Stdout, os.Stderr, buildEnv, withExitCodes(withConfirmation(makeExecutor)))
 	if err != nil {
-		return 1
+		return exitCode(err)
 	}
 
 	// everything went better than expected :)
 	return 0
 }
 
+// expandAlias expands an alias from the config of the repo the command
+// line points at. Problems reading the config are left to the command.
+func expandAlias(args []string) ([]string, error) {
+	repoPath := cliRepoPath(args)
+	if repoPath == "" {
+		return args, nil
+	}
+	aliases, err := corecmds.LoadAliases(repoPath)
+	if err != nil {
+		log.Debugf("cannot load aliases: %s", err)
+		return args, nil
+	}
+	return corecmds.ExpandAlias(Root, args, aliases)
+}
+
+// cliRepoPath returns the repo path given by the command line args, or
+// the default one. It is "" when neither is known.
+func cliRepoPath(args []string) string {
+	if repoPath := corecmds.ConfigRootFromArgs(Root, args); repoPath != "" {
+		return repoPath
+	}
+	repoPath, err := fsrepo.BestKnownPath()
+	if err != nil {
+		return ""
+	}
+	return repoPath
+}
+
 func insideGUI() bool {
 	return util.InsideGUI()
 }
@@ -200,6 +246,11 @@ func apiAddrOption(req *cmds.Request) (ma.Multiaddr, error) {
 }
 
 func makeExecutor(req *cmds.Request, env interface{}) (cmds.Executor, error) {
+	// Pick the encoding before the response emitter is created
+	if err := cmdenv.ApplyOutputOptions(req); err != nil {
+		return nil, err
+	}
+
 	exe := cmds.NewExecutor(req.Root)
 	cctx := env.(*oldcmds.Context)
 	details := commandDetails(req.Path)
@@ -220,9 +271,18 @@ func makeExecutor(req *cmds.Request, env interface{}) (cmds.Executor, error) {
 		return nil, err
 	}
 
+	// A remote replaces the API address.
+	remote, err := remoteOption(req, cctx.ConfigRoot)
+	if err != nil {
+		return nil, err
+	}
+	if remote != nil && apiAddr != nil {
+		return nil, errors.New("the api and remote flags cannot be used together")
+	}
+
 	// Require that the command be run on the daemon when the API flag is
 	// passed (unless we're trying to _run_ the daemon).
-	daemonRequested := apiAddr != nil && req.Command != daemonCmd
+	daemonRequested := (apiAddr != nil || remote != nil) && req.Command != daemonCmd
 
 	// Run this on the client if required.
 	if details.cannotRunOnDaemon || req.Command.External {
@@ -234,6 +294,10 @@ func makeExecutor(req *cmds.Request, env interface{}) (cmds.Executor, error) {
 		return exe, nil
 	}
 
+	if remote != nil {
+		return remoteClient(remote)
+	}
+
 	// Finally, look in the repo for an API file.
 	if apiAddr == nil {
 		var err error
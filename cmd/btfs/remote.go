@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	corecmds "github.com/TRON-US/go-btfs/core/commands"
+	corehttp "github.com/TRON-US/go-btfs/core/corehttp"
+	"github.com/TRON-US/go-btfs/core/remotes"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	cmdhttp "github.com/TRON-US/go-btfs-cmds/http"
+)
+
+func init() {
+	// the remote is resolved by the CLI, the daemon has no use for it
+	cmdhttp.OptionSkipMap[corecmds.RemoteOption] = true
+}
+
+// remoteOption returns the remote given with --remote, from the remotes of
+// the btfs directory root.
+func remoteOption(req *cmds.Request, root string) (*remotes.Profile, error) {
+	name, _ := req.Options[corecmds.RemoteOption].(string)
+	if name == "" {
+		return nil, nil
+	}
+	p, err := remotes.Open(root).Get(name)
+	if err == remotes.ErrNotFound {
+		return nil, fmt.Errorf("no remote %q, add it with 'btfs remote add'", name)
+	}
+	return p, err
+}
+
+// remoteClient returns the executor running the commands on the daemon of
+// p, never falling back to the local repo.
+func remoteClient(p *remotes.Profile) (cmds.Executor, error) {
+	u, err := p.Endpoint()
+	if err != nil {
+		return nil, err
+	}
+	t, err := remotes.NewTransport(p)
+	if err != nil {
+		return nil, err
+	}
+	return cmdhttp.NewClient(u.Host,
+		cmdhttp.ClientWithAPIPrefix(u.Path+corehttp.APIPath),
+		cmdhttp.ClientWithHTTPClient(&http.Client{Transport: t}),
+	), nil
+}
@@ -0,0 +1,324 @@
+// Package access counts the retrievals of the files a node stores as a
+// host, or owns as a renter, from the blocks bitswap sends to its peers.
+//
+// The blocks are mapped to their files by an index of the DAGs of these
+// files, rebuilt periodically from the local blockstore. A retrieval is a
+// send of the root block of a file, or of a shard of it for a host; every
+// block of the file sent counts towards the bytes served.
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	bsmsg "github.com/ipfs/go-bitswap/message"
+	bsnet "github.com/ipfs/go-bitswap/network"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Roles of the node for a file.
+const (
+	RoleHost   = "host"
+	RoleRenter = "renter"
+)
+
+// MaxRequesters bounds the distinct requesters remembered per file, the
+// count of requesters is a lower bound past it.
+const MaxRequesters = 10000
+
+// accessKey is the datastore key of the stats of a file for a role.
+const accessKey = "/btfs/%s/access/%s/%s"
+
+// Stats are the retrievals of a file from this node.
+type Stats struct {
+	File string
+	Role string
+	// Retrievals counts the sends of the root blocks of the file.
+	Retrievals uint64
+	Blocks     uint64
+	Bytes      uint64
+	// Requesters counts the distinct peers the file was sent to.
+	Requesters  int
+	FirstServed time.Time
+	LastServed  time.Time
+	// Peers are the requesters, up to MaxRequesters.
+	Peers []string `json:",omitempty"`
+
+	peers map[string]bool
+}
+
+type fileKey struct {
+	role, file string
+}
+
+// Index maps the blocks of the files to them.
+type Index struct {
+	blocks map[cid.Cid][]fileKey
+	roots  map[cid.Cid][]fileKey
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{blocks: map[cid.Cid][]fileKey{}, roots: map[cid.Cid][]fileKey{}}
+}
+
+// Add indexes the blocks of the DAG of root, read from ng, as blocks of
+// file for role. The blocks missing from ng end the walk with an error,
+// the ones walked so far stay indexed.
+func (i *Index) Add(ctx context.Context, ng ipld.NodeGetter, role, file string, root cid.Cid) error {
+	k := fileKey{role: role, file: file}
+	i.roots[root] = appendKey(i.roots[root], k)
+	seen := cid.NewSet()
+	return merkledag.Walk(ctx, merkledag.GetLinksDirect(ng), root, func(c cid.Cid) bool {
+		if !seen.Visit(c) {
+			return false
+		}
+		i.blocks[c] = appendKey(i.blocks[c], k)
+		return true
+	})
+}
+
+// Len returns the number of blocks indexed.
+func (i *Index) Len() int {
+	return len(i.blocks)
+}
+
+func appendKey(ks []fileKey, k fileKey) []fileKey {
+	for _, o := range ks {
+		if o == k {
+			return ks
+		}
+	}
+	return append(ks, k)
+}
+
+// Tracker counts the retrievals of the files of its index.
+type Tracker struct {
+	mu     sync.Mutex
+	index  *Index
+	stats  map[fileKey]*Stats
+	dirty  map[fileKey]bool
+	loaded bool
+}
+
+// NewTracker returns a tracker with an empty index.
+func NewTracker() *Tracker {
+	return &Tracker{index: NewIndex(), stats: map[fileKey]*Stats{}, dirty: map[fileKey]bool{}}
+}
+
+// Default is the tracker of the node.
+var Default = NewTracker()
+
+// SetIndex replaces the index of t.
+func (t *Tracker) SetIndex(i *Index) {
+	t.mu.Lock()
+	t.index = i
+	t.mu.Unlock()
+}
+
+// Served records the block c of size bytes sent to p at now.
+func (t *Tracker) Served(p peer.ID, c cid.Cid, size int, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ks := t.index.blocks[c]
+	if len(ks) == 0 {
+		return
+	}
+	root := t.index.roots[c]
+	for _, k := range ks {
+		s := t.get(k)
+		s.Blocks++
+		s.Bytes += uint64(size)
+		for _, r := range root {
+			if r == k {
+				s.Retrievals++
+			}
+		}
+		if id := p.Pretty(); !s.peers[id] {
+			if len(s.peers) < MaxRequesters {
+				s.peers[id] = true
+			}
+			s.Requesters++
+		}
+		if s.FirstServed.IsZero() {
+			s.FirstServed = now
+		}
+		s.LastServed = now
+		t.dirty[k] = true
+	}
+}
+
+func (t *Tracker) get(k fileKey) *Stats {
+	s, ok := t.stats[k]
+	if !ok {
+		s = &Stats{File: k.file, Role: k.role, peers: map[string]bool{}}
+		t.stats[k] = s
+	}
+	return s
+}
+
+// Load loads the stats of the node peerID recorded in d, once.
+func (t *Tracker) Load(d ds.Datastore, peerID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.loaded {
+		return nil
+	}
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(accessKey, peerID, "", "")})
+	if err != nil {
+		return err
+	}
+	for r := range results.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+		s := &Stats{}
+		if err := json.Unmarshal(r.Value, s); err != nil {
+			return fmt.Errorf("invalid access stats %s: %s", r.Key, err)
+		}
+		s.peers = map[string]bool{}
+		for _, p := range s.Peers {
+			s.peers[p] = true
+		}
+		k := fileKey{role: s.Role, file: s.File}
+		// keep what was served before the load
+		if o, ok := t.stats[k]; ok {
+			s.merge(o)
+		}
+		t.stats[k] = s
+	}
+	t.loaded = true
+	return nil
+}
+
+func (s *Stats) merge(o *Stats) {
+	s.Retrievals += o.Retrievals
+	s.Blocks += o.Blocks
+	s.Bytes += o.Bytes
+	for p := range o.peers {
+		if !s.peers[p] {
+			if len(s.peers) < MaxRequesters {
+				s.peers[p] = true
+			}
+			s.Requesters++
+		}
+	}
+	if s.FirstServed.IsZero() || (!o.FirstServed.IsZero() && o.FirstServed.Before(s.FirstServed)) {
+		s.FirstServed = o.FirstServed
+	}
+	if o.LastServed.After(s.LastServed) {
+		s.LastServed = o.LastServed
+	}
+}
+
+// Flush records the stats changed since the last flush in d, under the
+// node peerID.
+func (t *Tracker) Flush(d ds.Datastore, peerID string) error {
+	t.mu.Lock()
+	var changed []*Stats
+	for k := range t.dirty {
+		changed = append(changed, t.stats[k].copy())
+	}
+	t.dirty = map[fileKey]bool{}
+	t.mu.Unlock()
+
+	for _, s := range changed {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		if err := d.Put(ds.NewKey(fmt.Sprintf(accessKey, peerID, s.Role, s.File)), b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Stats) copy() *Stats {
+	c := *s
+	c.Peers = make([]string, 0, len(s.peers))
+	for p := range s.peers {
+		c.Peers = append(c.Peers, p)
+	}
+	sort.Strings(c.Peers)
+	c.peers = nil
+	return &c
+}
+
+// List returns the stats of the files served, of file only when not
+// empty, the most bytes served first.
+func (t *Tracker) List(file string) []*Stats {
+	t.mu.Lock()
+	ss := make([]*Stats, 0, len(t.stats))
+	for k, s := range t.stats {
+		if file == "" || k.file == file {
+			c := s.copy()
+			c.Peers = nil
+			ss = append(ss, c)
+		}
+	}
+	t.mu.Unlock()
+	sort.Slice(ss, func(i, j int) bool {
+		if ss[i].Bytes != ss[j].Bytes {
+			return ss[i].Bytes > ss[j].Bytes
+		}
+		return ss[i].File+ss[i].Role < ss[j].File+ss[j].Role
+	})
+	return ss
+}
+
+// WrapNetwork returns n recording the blocks it sends in t.
+func WrapNetwork(n bsnet.BitSwapNetwork, t *Tracker) bsnet.BitSwapNetwork {
+	return &network{BitSwapNetwork: n, t: t}
+}
+
+type network struct {
+	bsnet.BitSwapNetwork
+	t *Tracker
+}
+
+func (n *network) SendMessage(ctx context.Context, p peer.ID, m bsmsg.BitSwapMessage) error {
+	if err := n.BitSwapNetwork.SendMessage(ctx, p, m); err != nil {
+		return err
+	}
+	n.t.sent(p, m)
+	return nil
+}
+
+func (n *network) NewMessageSender(ctx context.Context, p peer.ID, opts *bsnet.MessageSenderOpts) (bsnet.MessageSender, error) {
+	s, err := n.BitSwapNetwork.NewMessageSender(ctx, p, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &sender{MessageSender: s, p: p, t: n.t}, nil
+}
+
+type sender struct {
+	bsnet.MessageSender
+	p peer.ID
+	t *Tracker
+}
+
+func (s *sender) SendMsg(ctx context.Context, m bsmsg.BitSwapMessage) error {
+	if err := s.MessageSender.SendMsg(ctx, m); err != nil {
+		return err
+	}
+	s.t.sent(s.p, m)
+	return nil
+}
+
+func (t *Tracker) sent(p peer.ID, m bsmsg.BitSwapMessage) {
+	now := time.Now()
+	for _, b := range m.Blocks() {
+		t.Served(p, b.Cid(), len(b.RawData()), now)
+	}
+}
@@ -0,0 +1,70 @@
+package access
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestServed(t *testing.T) {
+	ctx := context.Background()
+	dag := mdtest.Mock()
+	leaf := merkledag.NodeWithData([]byte("leaf"))
+	root := merkledag.NodeWithData([]byte("root"))
+	if err := root.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []*merkledag.ProtoNode{leaf, root} {
+		if err := dag.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index := NewIndex()
+	if err := index.Add(ctx, dag, RoleRenter, root.Cid().String(), root.Cid()); err != nil {
+		t.Fatal(err)
+	}
+	if index.Len() != 2 {
+		t.Fatalf("expected 2 blocks indexed, got %d", index.Len())
+	}
+	tr := NewTracker()
+	tr.SetIndex(index)
+	now := time.Now()
+	tr.Served(peer.ID("a"), root.Cid(), 10, now)
+	tr.Served(peer.ID("a"), leaf.Cid(), 5, now)
+	tr.Served(peer.ID("b"), root.Cid(), 10, now.Add(time.Minute))
+	// not a block of the file
+	tr.Served(peer.ID("c"), merkledag.NodeWithData([]byte("other")).Cid(), 100, now)
+
+	ss := tr.List("")
+	if len(ss) != 1 {
+		t.Fatalf("expected the stats of 1 file, got %d", len(ss))
+	}
+	s := ss[0]
+	if s.Retrievals != 2 || s.Blocks != 3 || s.Bytes != 25 || s.Requesters != 2 ||
+		!s.FirstServed.Equal(now) || !s.LastServed.Equal(now.Add(time.Minute)) {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+
+	// the stats flushed are added to those served before a load
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	if err := tr.Flush(d, "self"); err != nil {
+		t.Fatal(err)
+	}
+	tr2 := NewTracker()
+	tr2.SetIndex(index)
+	tr2.Served(peer.ID("c"), root.Cid(), 10, now)
+	if err := tr2.Load(d, "self"); err != nil {
+		t.Fatal(err)
+	}
+	s = tr2.List(root.Cid().String())[0]
+	if s.Retrievals != 3 || s.Bytes != 35 || s.Requesters != 3 {
+		t.Fatalf("unexpected stats after load %+v", s)
+	}
+}
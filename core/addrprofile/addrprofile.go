@@ -0,0 +1,280 @@
+// Package addrprofile rewrites the swarm addresses a node announces, for
+// hosts behind NAT: it replaces their IP with a static public IP, or with
+// the addresses of a DDNS hostname resolved on an interval, and filters out
+// the private ranges.
+//
+// It is configured in the AnnounceProfile config section, and applied on
+// top of Addresses.Announce and before Addresses.NoAnnounce.
+package addrprofile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	logging "github.com/ipfs/go-log"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr-net"
+)
+
+var log = logging.Logger("addrprofile")
+
+// ConfigKey is the config section of the announce profile.
+const ConfigKey = "AnnounceProfile"
+
+// Intervals of the DDNS resolution.
+const (
+	DefaultDDNSInterval = 5 * time.Minute
+	MinDDNSInterval     = 30 * time.Second
+)
+
+// Config configures the rewriting of the announced addresses.
+type Config struct {
+	// PublicIP replaces the IP of the announced addresses of its family.
+	PublicIP string `json:",omitempty"`
+	// DDNS is a hostname whose addresses replace the IP of the announced
+	// addresses of their family, resolved every DDNSInterval (5m by
+	// default).
+	DDNS         string `json:",omitempty"`
+	DDNSInterval string `json:",omitempty"`
+	// AnnounceDNS announces the DDNS hostname itself, as /dns4 and /dns6
+	// addresses, rather than its resolved addresses.
+	AnnounceDNS bool `json:",omitempty"`
+	// FilterPrivate leaves out the private, loopback and link-local
+	// addresses.
+	FilterPrivate bool `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the announce profile configured in r, nil when it rewrites
+// nothing.
+func Load(r repo.Repo) (*Profile, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if c.PublicIP == "" && c.DDNS == "" && !c.FilterPrivate {
+		return nil, nil
+	}
+	p, err := New(c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return p, nil
+}
+
+// Profile rewrites the announced addresses.
+type Profile struct {
+	c        Config
+	publicIP net.IP
+	interval time.Duration
+	lookup   func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	mu         sync.RWMutex
+	resolved   []net.IP
+	resolvedAt time.Time
+	resolveErr string
+}
+
+// New returns the profile of c.
+func New(c *Config) (*Profile, error) {
+	p := &Profile{c: *c, interval: DefaultDDNSInterval, lookup: net.DefaultResolver.LookupIPAddr}
+	if c.PublicIP != "" {
+		if p.publicIP = net.ParseIP(c.PublicIP); p.publicIP == nil {
+			return nil, fmt.Errorf("invalid PublicIP %q", c.PublicIP)
+		}
+		if c.DDNS != "" {
+			return nil, errors.New("PublicIP and DDNS are exclusive")
+		}
+	}
+	if c.DDNSInterval != "" {
+		i, err := time.ParseDuration(c.DDNSInterval)
+		if err != nil || i < MinDDNSInterval {
+			return nil, fmt.Errorf("invalid DDNSInterval %q, must be at least %s", c.DDNSInterval, MinDDNSInterval)
+		}
+		p.interval = i
+	}
+	if c.AnnounceDNS && c.DDNS == "" {
+		return nil, errors.New("AnnounceDNS requires DDNS")
+	}
+	return p, nil
+}
+
+var (
+	activeLk sync.RWMutex
+	active   *Profile
+)
+
+// SetActive makes p the profile of the node. Nil disables it.
+func SetActive(p *Profile) {
+	activeLk.Lock()
+	defer activeLk.Unlock()
+	active = p
+}
+
+// Active returns the profile of the node, nil when disabled.
+func Active() *Profile {
+	activeLk.RLock()
+	defer activeLk.RUnlock()
+	return active
+}
+
+// Resolve resolves the DDNS hostname of the profile. The addresses last
+// resolved are kept when it fails.
+func (p *Profile) Resolve(ctx context.Context) error {
+	if p.c.DDNS == "" || p.c.AnnounceDNS {
+		return nil
+	}
+	addrs, err := p.lookup(ctx, p.c.DDNS)
+	if err == nil && len(addrs) == 0 {
+		err = fmt.Errorf("no address for %s", p.c.DDNS)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.resolveErr = err.Error()
+		return err
+	}
+	p.resolved = p.resolved[:0]
+	for _, a := range addrs {
+		p.resolved = append(p.resolved, a.IP)
+	}
+	p.resolvedAt, p.resolveErr = time.Now(), ""
+	return nil
+}
+
+// Run resolves the DDNS hostname of the profile every interval until ctx
+// is done.
+func (p *Profile) Run(ctx context.Context) {
+	if p.c.DDNS == "" || p.c.AnnounceDNS {
+		return
+	}
+	for {
+		if err := p.Resolve(ctx); err != nil {
+			log.Warnf("cannot resolve %s: %s", p.c.DDNS, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.interval):
+		}
+	}
+}
+
+// Apply returns the addresses announced for addrs.
+func (p *Profile) Apply(addrs []ma.Multiaddr) []ma.Multiaddr {
+	overrides := p.overrides()
+	out := make([]ma.Multiaddr, 0, len(addrs))
+	seen := make(map[string]bool)
+	add := func(a ma.Multiaddr) {
+		if p.c.FilterPrivate && isPrivate(a) {
+			return
+		}
+		if k := string(a.Bytes()); !seen[k] {
+			seen[k] = true
+			out = append(out, a)
+		}
+	}
+	for _, a := range addrs {
+		first, rest := ma.SplitFirst(a)
+		if first == nil || len(overrides) == 0 || manet.IsIPLoopback(a) {
+			add(a)
+			continue
+		}
+		code := first.Protocol().Code
+		if code != ma.P_IP4 && code != ma.P_IP6 {
+			add(a)
+			continue
+		}
+		replaced := false
+		for _, o := range overrides {
+			oc := o.Protocols()[0].Code
+			if oc != code && !(p.c.AnnounceDNS && dnsFamily(code) == oc) {
+				continue
+			}
+			replaced = true
+			if rest == nil {
+				add(o)
+			} else {
+				add(o.Encapsulate(rest))
+			}
+		}
+		if !replaced {
+			add(a)
+		}
+	}
+	return out
+}
+
+// overrides returns the components replacing the IP of the announced
+// addresses.
+func (p *Profile) overrides() []ma.Multiaddr {
+	var ips []net.IP
+	switch {
+	case p.publicIP != nil:
+		ips = []net.IP{p.publicIP}
+	case p.c.AnnounceDNS:
+		dns4, err4 := ma.NewMultiaddr("/dns4/" + p.c.DDNS)
+		dns6, err6 := ma.NewMultiaddr("/dns6/" + p.c.DDNS)
+		if err4 != nil || err6 != nil {
+			return nil
+		}
+		return []ma.Multiaddr{dns4, dns6}
+	default:
+		p.mu.RLock()
+		ips = append(ips, p.resolved...)
+		p.mu.RUnlock()
+	}
+	out := make([]ma.Multiaddr, 0, len(ips))
+	for _, ip := range ips {
+		a, err := manet.FromIP(ip)
+		if err != nil {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func dnsFamily(code int) int {
+	if code == ma.P_IP4 {
+		return ma.P_DNS4
+	}
+	return ma.P_DNS6
+}
+
+func isPrivate(a ma.Multiaddr) bool {
+	return manet.IsPrivateAddr(a) || manet.IsIPLoopback(a) || manet.IsIP6LinkLocal(a)
+}
+
+// Status is what the profile rewrites the announced addresses with.
+type Status struct {
+	PublicIP      string   `json:",omitempty"`
+	DDNS          string   `json:",omitempty"`
+	AnnounceDNS   bool     `json:",omitempty"`
+	Resolved      []string `json:",omitempty"`
+	ResolvedAt    time.Time
+	ResolveError  string `json:",omitempty"`
+	FilterPrivate bool
+}
+
+// Status returns the status of the profile.
+func (p *Profile) Status() *Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	s := &Status{PublicIP: p.c.PublicIP, DDNS: p.c.DDNS, AnnounceDNS: p.c.AnnounceDNS,
+		ResolvedAt: p.resolvedAt, ResolveError: p.resolveErr, FilterPrivate: p.c.FilterPrivate}
+	for _, ip := range p.resolved {
+		s.Resolved = append(s.Resolved, ip.String())
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package addrprofile
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func addrs(t *testing.T, ss ...string) []ma.Multiaddr {
+	out := make([]ma.Multiaddr, 0, len(ss))
+	for _, s := range ss {
+		a, err := ma.NewMultiaddr(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func strs(as []ma.Multiaddr) []string {
+	out := make([]string, 0, len(as))
+	for _, a := range as {
+		out = append(out, a.String())
+	}
+	return out
+}
+
+var local = []string{
+	"/ip4/127.0.0.1/tcp/4001",
+	"/ip4/192.168.1.10/tcp/4001",
+	"/ip4/10.0.0.2/udp/4001/quic",
+	"/ip6/::1/tcp/4001",
+	"/ip6/fe80::1/tcp/4001",
+}
+
+func TestNew(t *testing.T) {
+	for _, c := range []*Config{
+		{PublicIP: "not an ip"},
+		{PublicIP: "203.0.113.7", DDNS: "host.example.com"},
+		{DDNS: "host.example.com", DDNSInterval: "1s"},
+		{DDNS: "host.example.com", DDNSInterval: "soon"},
+		{AnnounceDNS: true},
+	} {
+		if _, err := New(c); err == nil {
+			t.Fatalf("%+v: expected an error", c)
+		}
+	}
+	p, err := New(&Config{DDNS: "host.example.com"})
+	if err != nil || p.interval != DefaultDDNSInterval {
+		t.Fatalf("unexpected profile %+v (%v)", p, err)
+	}
+}
+
+func TestApplyPublicIP(t *testing.T) {
+	p, err := New(&Config{PublicIP: "203.0.113.7", FilterPrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strs(p.Apply(addrs(t, local...)))
+	want := []string{"/ip4/203.0.113.7/tcp/4001", "/ip4/203.0.113.7/udp/4001/quic"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestApplyFilterPrivate(t *testing.T) {
+	p, err := New(&Config{FilterPrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strs(p.Apply(addrs(t, append(local, "/ip4/198.51.100.3/tcp/4001")...)))
+	want := []string{"/ip4/198.51.100.3/tcp/4001"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestApplyDDNS(t *testing.T) {
+	p, err := New(&Config{DDNS: "host.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fail := true
+	p.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if fail {
+			return nil, errors.New("no such host")
+		}
+		return []net.IPAddr{{IP: net.ParseIP("203.0.113.7")}, {IP: net.ParseIP("2001:db8::7")}}, nil
+	}
+
+	// the addresses are kept until the hostname resolves
+	if err := p.Resolve(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	in := addrs(t, "/ip4/192.168.1.10/tcp/4001", "/ip6/2001:db8::10/tcp/4001", "/ip4/127.0.0.1/tcp/4001")
+	if got := strs(p.Apply(in)); !reflect.DeepEqual(got, strs(in)) {
+		t.Fatalf("addresses rewritten before resolution: %v", got)
+	}
+	if s := p.Status(); s.ResolveError == "" || !s.ResolvedAt.IsZero() {
+		t.Fatalf("unexpected status %+v", s)
+	}
+
+	fail = false
+	if err := p.Resolve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := strs(p.Apply(in))
+	want := []string{"/ip4/203.0.113.7/tcp/4001", "/ip6/2001:db8::7/tcp/4001", "/ip4/127.0.0.1/tcp/4001"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// a failed resolution keeps the last addresses
+	fail = true
+	p.Resolve(context.Background())
+	if got := strs(p.Apply(in)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if s := p.Status(); len(s.Resolved) != 2 || s.ResolveError == "" {
+		t.Fatalf("unexpected status %+v", s)
+	}
+}
+
+func TestApplyAnnounceDNS(t *testing.T) {
+	p, err := New(&Config{DDNS: "host.example.com", AnnounceDNS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strs(p.Apply(addrs(t, "/ip4/192.168.1.10/tcp/4001", "/ip4/10.0.0.2/tcp/4001", "/ip6/2001:db8::10/udp/4001/quic")))
+	want := []string{"/dns4/host.example.com/tcp/4001", "/dns6/host.example.com/udp/4001/quic"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
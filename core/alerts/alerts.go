@@ -0,0 +1,288 @@
+// Package alerts notifies the operator of a node of the alerts it raises,
+// such as a failing disk or files at risk, by email, Telegram or Slack.
+//
+// The channels are notifiers configured by name in the Alerts config
+// section. Rules route the alerts to channels by source and severity, and
+// every alert goes to every channel when there are no rules.
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("core/alerts")
+
+// ConfigKey is the config section of the alerts.
+const ConfigKey = "Alerts"
+
+// notifyTimeout bounds the notification of an alert to a channel.
+const notifyTimeout = 30 * time.Second
+
+// Alert severities, least urgent first.
+const (
+	Info     = "info"
+	Warning  = "warning"
+	Critical = "critical"
+)
+
+var severityRank = map[string]int{
+	Info:     0,
+	Warning:  1,
+	Critical: 2,
+}
+
+// Alert is an event the operator of a node is notified of.
+type Alert struct {
+	Time time.Time
+	// Peer is the node raising the alert.
+	Peer string
+	// Source is the subsystem raising the alert, e.g. "diskhealth".
+	Source   string
+	Severity string
+	Title    string
+	Text     string `json:",omitempty"`
+}
+
+// Subject is the one line summary of a.
+func (a *Alert) Subject() string {
+	return fmt.Sprintf("[btfs %s] %s", a.Severity, a.Title)
+}
+
+// Body is the text of a, with where and when it was raised.
+func (a *Alert) Body() string {
+	var b strings.Builder
+	if a.Text != "" {
+		b.WriteString(a.Text)
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "Raised by %s of node %s at %s.", a.Source, a.Peer, a.Time.Format(time.RFC3339))
+	return b.String()
+}
+
+// Notifier sends alerts to a channel.
+type Notifier interface {
+	Notify(ctx context.Context, a *Alert) error
+}
+
+// NotifierFactory returns the notifier configured with options.
+type NotifierFactory func(options map[string]string) (Notifier, error)
+
+var (
+	notifiersLk sync.RWMutex
+	notifiers   = map[string]NotifierFactory{}
+)
+
+// RegisterNotifier makes the notifier type available to the config, it
+// panics when typ is registered already.
+func RegisterNotifier(typ string, f NotifierFactory) {
+	notifiersLk.Lock()
+	defer notifiersLk.Unlock()
+	if _, ok := notifiers[typ]; ok {
+		panic("alert notifier registered twice: " + typ)
+	}
+	notifiers[typ] = f
+}
+
+// Notifiers returns the notifier types registered.
+func Notifiers() []string {
+	notifiersLk.RLock()
+	defer notifiersLk.RUnlock()
+	types := make([]string, 0, len(notifiers))
+	for typ := range notifiers {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Config configures the alert channels and their rules.
+type Config struct {
+	// Channels are the channels alerts are sent to, by name.
+	Channels map[string]*Channel `json:",omitempty"`
+	// Rules route the alerts to the channels, an alert goes to the channels
+	// of all the rules it matches.
+	Rules []*Rule `json:",omitempty"`
+}
+
+// Channel is a notifier and its options, see the notifier documentation.
+type Channel struct {
+	Type    string
+	Options map[string]string `json:",omitempty"`
+}
+
+// Rule routes the alerts of some sources and severities to channels.
+type Rule struct {
+	// Sources are the sources matched, all when empty.
+	Sources []string `json:",omitempty"`
+	// MinSeverity is the least severity matched, info when empty.
+	MinSeverity string `json:",omitempty"`
+	// Channels are the names of the channels the alerts go to.
+	Channels []string
+}
+
+func (r *Rule) matches(a *Alert) bool {
+	if severityRank[a.Severity] < severityRank[r.MinSeverity] {
+		return false
+	}
+	if len(r.Sources) == 0 {
+		return true
+	}
+	for _, s := range r.Sources {
+		if s == a.Source {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Dispatcher sends the alerts to the channels of the rules they match.
+type Dispatcher struct {
+	channels map[string]Notifier
+	rules    []*Rule
+}
+
+// LoadConfig returns the alerts config of r.
+func LoadConfig(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+// Load returns the dispatcher configured in r.
+func Load(r repo.Repo) (*Dispatcher, error) {
+	c, err := LoadConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	d, err := New(c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return d, nil
+}
+
+// New returns the dispatcher configured by c.
+func New(c *Config) (*Dispatcher, error) {
+	d := &Dispatcher{channels: map[string]Notifier{}, rules: c.Rules}
+	for name, ch := range c.Channels {
+		notifiersLk.RLock()
+		f, ok := notifiers[ch.Type]
+		notifiersLk.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("channel %s: unknown type %q, expected one of %s",
+				name, ch.Type, strings.Join(Notifiers(), ", "))
+		}
+		n, err := f(ch.Options)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %s", name, err)
+		}
+		d.channels[name] = n
+	}
+	for i, r := range c.Rules {
+		if _, ok := severityRank[r.MinSeverity]; !ok && r.MinSeverity != "" {
+			return nil, fmt.Errorf("rule %d: unknown severity %q", i, r.MinSeverity)
+		}
+		if len(r.Channels) == 0 {
+			return nil, fmt.Errorf("rule %d: no channels", i)
+		}
+		for _, name := range r.Channels {
+			if _, ok := d.channels[name]; !ok {
+				return nil, fmt.Errorf("rule %d: unknown channel %q", i, name)
+			}
+		}
+	}
+	return d, nil
+}
+
+// Channels returns the names of all the channels of d.
+func (d *Dispatcher) Channels() []string {
+	names := make([]string, 0, len(d.channels))
+	for name := range d.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Route returns the names of the channels a goes to.
+func (d *Dispatcher) Route(a *Alert) []string {
+	if len(d.rules) == 0 {
+		return d.Channels()
+	}
+	matched := map[string]bool{}
+	for _, r := range d.rules {
+		if !r.matches(a) {
+			continue
+		}
+		for _, name := range r.Channels {
+			matched[name] = true
+		}
+	}
+	names := make([]string, 0, len(matched))
+	for name := range matched {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send notifies a to the channels it is routed to, and to the channels
+// names instead when given.
+func (d *Dispatcher) Send(ctx context.Context, a *Alert, names ...string) error {
+	if len(names) == 0 {
+		names = d.Route(a)
+	}
+	var errs []string
+	for _, name := range names {
+		n, ok := d.channels[name]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("no channel %q", name))
+			continue
+		}
+		cctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := n.Notify(cctx, a)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("channel %s: %s", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Raise notifies a in the background to the channels configured in r,
+// logging the failures.
+func Raise(r repo.Repo, a *Alert) {
+	d, err := Load(r)
+	if err != nil {
+		log.Errorf("cannot notify the %s alert %q: %s", a.Source, a.Title, err)
+		return
+	}
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	go func() {
+		if err := d.Send(context.Background(), a); err != nil {
+			log.Errorf("cannot notify the %s alert %q: %s", a.Source, a.Title, err)
+		}
+	}()
+}
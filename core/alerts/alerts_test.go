@@ -0,0 +1,174 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	alerts []*Alert
+}
+
+func (r *recorder) Notify(ctx context.Context, a *Alert) error {
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	for _, c := range []*Config{
+		{Channels: map[string]*Channel{"x": {Type: "pager"}}},
+		{Channels: map[string]*Channel{"x": {Type: TypeSlack}}},
+		{Channels: map[string]*Channel{"x": {Type: TypeSlack, Options: map[string]string{"url": "u", "token": "t"}}}},
+		{Channels: map[string]*Channel{"x": {Type: TypeEmail, Options: map[string]string{"addr": "smtp", "from": "f", "to": "t"}}}},
+		{Rules: []*Rule{{Channels: []string{"missing"}}}},
+		{Channels: map[string]*Channel{"x": {Type: TypeSlack, Options: map[string]string{"url": "u"}}},
+			Rules: []*Rule{{MinSeverity: "fatal", Channels: []string{"x"}}}},
+	} {
+		if _, err := New(c); err == nil {
+			t.Errorf("accepted %+v", c)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	d := &Dispatcher{channels: map[string]Notifier{"ops": &recorder{}, "oncall": &recorder{}, "chat": &recorder{}}}
+	a := &Alert{Source: "probe", Severity: Warning}
+	if got := d.Route(a); strings.Join(got, ",") != "chat,oncall,ops" {
+		t.Fatalf("expected all channels without rules, got %v", got)
+	}
+	d.rules = []*Rule{
+		{Channels: []string{"ops"}},
+		{MinSeverity: Critical, Channels: []string{"oncall"}},
+		{Sources: []string{"probe"}, Channels: []string{"chat", "ops"}},
+	}
+	if got := d.Route(a); strings.Join(got, ",") != "chat,ops" {
+		t.Fatalf("unexpected channels %v", got)
+	}
+	a = &Alert{Source: "diskhealth", Severity: Critical}
+	if got := d.Route(a); strings.Join(got, ",") != "oncall,ops" {
+		t.Fatalf("unexpected channels %v", got)
+	}
+	if err := d.Send(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(d.channels["chat"].(*recorder).alerts); n != 0 {
+		t.Fatalf("chat notified %d times", n)
+	}
+	if n := len(d.channels["ops"].(*recorder).alerts); n != 1 {
+		t.Fatalf("ops notified %d times", n)
+	}
+	if err := d.Send(context.Background(), a, "chat", "pager"); err == nil || !strings.Contains(err.Error(), "pager") {
+		t.Fatalf("expected the unknown channel reported, got %v", err)
+	}
+	if n := len(d.channels["chat"].(*recorder).alerts); n != 1 {
+		t.Fatalf("chat notified %d times", n)
+	}
+}
+
+var testAlert = &Alert{
+	Time:     time.Date(2020, 5, 10, 3, 0, 0, 0, time.UTC),
+	Peer:     "peer",
+	Source:   "diskhealth",
+	Severity: Critical,
+	Title:    "disk failing",
+	Text:     "5 reallocated sectors",
+}
+
+func TestSlack(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		if r.URL.Path == "/gone" {
+			http.Error(w, "no_service", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	n, err := newSlackNotifier(map[string]string{"url": srv.URL + "/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), testAlert); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got["text"], "*[btfs critical] disk failing*\n5 reallocated sectors") {
+		t.Fatalf("unexpected message %q", got["text"])
+	}
+	n, _ = newSlackNotifier(map[string]string{"url": srv.URL + "/gone"})
+	err = n.Notify(context.Background(), testAlert)
+	if err == nil || strings.Contains(err.Error(), srv.URL) {
+		t.Fatalf("expected an error without the webhook, got %v", err)
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	var path string
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		if got["chat_id"] != "42" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	n, err := newTelegramNotifier(map[string]string{"token": "123:abc", "chat": "42", "api": srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), testAlert); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/bot123:abc/sendMessage" || !strings.HasPrefix(got["text"], "[btfs critical] disk failing") {
+		t.Fatalf("unexpected request to %s: %v", path, got)
+	}
+	n, _ = newTelegramNotifier(map[string]string{"token": "123:abc", "chat": "7", "api": srv.URL})
+	if err := n.Notify(context.Background(), testAlert); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("expected the error of the bot API, got %v", err)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	var addr, from string
+	var to []string
+	var msg []byte
+	sendMail = func(a string, auth smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, m
+		return nil
+	}
+	defer func() { sendMail = smtp.SendMail }()
+
+	n, err := newEmailNotifier(map[string]string{
+		"addr": "smtp.example.com:587", "from": "btfs@example.com", "to": "a@example.com, b@example.com",
+		"username": "btfs", "password": "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), testAlert); err != nil {
+		t.Fatal(err)
+	}
+	if addr != "smtp.example.com:587" || from != "btfs@example.com" || len(to) != 2 || to[1] != "b@example.com" {
+		t.Fatalf("unexpected envelope %s %s %v", addr, from, to)
+	}
+	for _, h := range []string{"To: a@example.com, b@example.com\r\n", "Subject: [btfs critical] disk failing\r\n",
+		"\r\n\r\n5 reallocated sectors\r\n\r\nRaised by diskhealth of node peer"} {
+		if !strings.Contains(string(msg), h) {
+			t.Errorf("%q not in the message:\n%s", h, msg)
+		}
+	}
+}
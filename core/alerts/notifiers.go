@@ -0,0 +1,203 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Types of the notifiers shipped with the node.
+const (
+	// TypeEmail sends the alerts by email. Options: addr, the host:port of
+	// the SMTP server, from, to, a comma separated list of addresses, and
+	// username and password when the server requires authentication.
+	TypeEmail = "email"
+	// TypeTelegram sends the alerts with a Telegram bot. Options: token,
+	// the token of the bot, chat, the chat ID it writes in, and api, the
+	// Bot API server, https://api.telegram.org by default.
+	TypeTelegram = "telegram"
+	// TypeSlack posts the alerts to a Slack incoming webhook. Options: url,
+	// the URL of the webhook.
+	TypeSlack = "slack"
+)
+
+const defaultTelegramAPI = "https://api.telegram.org"
+
+func init() {
+	RegisterNotifier(TypeEmail, newEmailNotifier)
+	RegisterNotifier(TypeTelegram, newTelegramNotifier)
+	RegisterNotifier(TypeSlack, newSlackNotifier)
+}
+
+// checkOptions checks that options has the required options, and no other
+// than the optional ones.
+func checkOptions(typ string, options map[string]string, required, optional []string) error {
+	known := map[string]bool{}
+	for _, k := range required {
+		if options[k] == "" {
+			return fmt.Errorf("the %s notifier requires the %s option", typ, k)
+		}
+		known[k] = true
+	}
+	for _, k := range optional {
+		known[k] = true
+	}
+	for k := range options {
+		if !known[k] {
+			return fmt.Errorf("unknown %s notifier option %q", typ, k)
+		}
+	}
+	return nil
+}
+
+// sendMail is smtp.SendMail, replaced by the tests.
+var sendMail = smtp.SendMail
+
+type emailNotifier struct {
+	addr string
+	from string
+	to   []string
+	auth smtp.Auth
+}
+
+func newEmailNotifier(options map[string]string) (Notifier, error) {
+	err := checkOptions(TypeEmail, options, []string{"addr", "from", "to"}, []string{"username", "password"})
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(options["addr"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid addr %q: %s", options["addr"], err)
+	}
+	n := &emailNotifier{addr: options["addr"], from: options["from"]}
+	for _, to := range strings.Split(options["to"], ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			n.to = append(n.to, to)
+		}
+	}
+	if options["username"] != "" {
+		// only sent over TLS, or to localhost
+		n.auth = smtp.PlainAuth("", options["username"], options["password"], host)
+	}
+	return n, nil
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, a *Alert) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", a.Subject())
+	fmt.Fprintf(&msg, "Date: %s\r\n", a.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(a.Body(), "\n", "\r\n", -1))
+	msg.WriteString("\r\n")
+
+	// smtp.SendMail does not take a context, stop waiting for it instead
+	done := make(chan error, 1)
+	go func() {
+		done <- sendMail(n.addr, n.auth, n.from, n.to, msg.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type telegramNotifier struct {
+	url  string
+	chat string
+}
+
+func newTelegramNotifier(options map[string]string) (Notifier, error) {
+	if err := checkOptions(TypeTelegram, options, []string{"token", "chat"}, []string{"api"}); err != nil {
+		return nil, err
+	}
+	api := options["api"]
+	if api == "" {
+		api = defaultTelegramAPI
+	}
+	return &telegramNotifier{
+		url:  strings.TrimSuffix(api, "/") + "/bot" + options["token"] + "/sendMessage",
+		chat: options["chat"],
+	}, nil
+}
+
+func (n *telegramNotifier) Notify(ctx context.Context, a *Alert) error {
+	var out struct {
+		Ok          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	err := postJSON(ctx, n.url, map[string]string{
+		"chat_id": n.chat,
+		"text":    a.Subject() + "\n\n" + a.Body(),
+	}, &out)
+	if err != nil {
+		// the URL has the token of the bot
+		return fmt.Errorf("telegram: %s", strings.Replace(err.Error(), n.url, "sendMessage", -1))
+	}
+	if !out.Ok {
+		return fmt.Errorf("telegram: %s", out.Description)
+	}
+	return nil
+}
+
+type slackNotifier struct {
+	url string
+}
+
+func newSlackNotifier(options map[string]string) (Notifier, error) {
+	if err := checkOptions(TypeSlack, options, []string{"url"}, nil); err != nil {
+		return nil, err
+	}
+	return &slackNotifier{url: options["url"]}, nil
+}
+
+func (n *slackNotifier) Notify(ctx context.Context, a *Alert) error {
+	err := postJSON(ctx, n.url, map[string]string{
+		"text": "*" + a.Subject() + "*\n" + a.Body(),
+	}, nil)
+	if err != nil {
+		// the URL of the webhook is its secret
+		return fmt.Errorf("slack: %s", strings.Replace(err.Error(), n.url, "webhook", -1))
+	}
+	return nil
+}
+
+// postJSON posts in to url, and decodes the response into out when not
+// nil, the errors the API describes in it included.
+func postJSON(ctx context.Context, url string, in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		// the API may describe the error in out
+		if out != nil && json.NewDecoder(resp.Body).Decode(out) == nil {
+			return nil
+		}
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package applog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	ipld "github.com/ipfs/go-ipld-format"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func newLogKey(t *testing.T) (ic.PrivKey, peer.ID) {
+	priv, pub, err := ic.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, id
+}
+
+func TestAppendWalk(t *testing.T) {
+	ctx := context.Background()
+	dag := mdtest.Mock()
+	s := NewStore(dssync.MutexWrap(ds.NewMapDatastore()), "QmPeer")
+	priv, id := newLogKey(t)
+
+	if _, err := s.Create("feed", id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("feed", id); err != ErrExists {
+		t.Fatalf("created twice: %v", err)
+	}
+
+	mustAppend(t, s, dag, priv, "one")
+	second := mustAppend(t, s, dag, priv, "two")
+	head := mustAppend(t, s, dag, priv, "three")
+
+	cids, entries, err := Walk(ctx, dag, id, head, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || cids[2] != head || cids[1] != second {
+		t.Fatalf("walked %v", cids)
+	}
+	for i, want := range []string{"one", "two", "three"} {
+		if string(entries[i].Data) != want || entries[i].Seq != uint64(i+1) {
+			t.Errorf("entry %d: %+v", i, entries[i])
+		}
+	}
+
+	_, entries, err = Walk(ctx, dag, id, head, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || string(entries[0].Data) != "three" {
+		t.Fatalf("walked from 2: %+v", entries)
+	}
+
+	l, err := s.Get("feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Seq != 3 || l.Head != head.String() {
+		t.Fatalf("log %+v", l)
+	}
+}
+
+func mustAppend(t *testing.T, s *Store, dag ipld.DAGService, priv ic.PrivKey, data string) cid.Cid {
+	c, _, err := s.Append(context.Background(), dag, "feed", priv, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	dag := mdtest.Mock()
+	s := NewStore(dssync.MutexWrap(ds.NewMapDatastore()), "QmPeer")
+	priv, id := newLogKey(t)
+	other, otherID := newLogKey(t)
+
+	if _, err := s.Create("feed", id); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Append(ctx, dag, "feed", other, []byte("x")); err == nil {
+		t.Fatal("appended with the key of another log")
+	}
+	head, _, err := s.Append(ctx, dag, "feed", priv, []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get(ctx, dag, otherID, head); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("read as an entry of another log: %v", err)
+	}
+
+	// an entry signed by another key
+	e := &Entry{Log: id.Pretty(), Seq: 2, Prev: &head, Data: []byte("forged")}
+	if err := e.Sign(other); err != nil {
+		t.Fatal(err)
+	}
+	nd, err := e.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dag.Add(ctx, nd); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Walk(ctx, dag, id, nd.Cid(), 0); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("walked a forged entry: %v", err)
+	}
+
+	if _, _, err := s.Append(ctx, dag, "feed", priv, make([]byte, MaxDataSize+1)); err == nil {
+		t.Fatal("appended an entry over the limit")
+	}
+}
@@ -0,0 +1,148 @@
+// Package applog implements signed append-only logs, a building block for
+// feeds and audit trails.
+//
+// A log is named by the peer ID of the key signing its entries. Each entry
+// is a dag-cbor node linking to the previous one, so the head of a log
+// references its whole history. The writer announces every new head on the
+// pubsub topic of the log (see Topic) and publishes it under the BTNS name
+// of the key, readers verify the signature and the sequence of every entry
+// they walk.
+package applog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	cid "github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	ipld "github.com/ipfs/go-ipld-format"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// MaxDataSize is the largest payload of an entry, so that an entry fits in
+// a block.
+const MaxDataSize = 1 << 20
+
+// ErrInvalidEntry is returned for an entry that is not a valid entry of the
+// log read.
+var ErrInvalidEntry = errors.New("invalid log entry")
+
+func init() {
+	cbornode.RegisterCborType(Entry{})
+}
+
+// Entry is an entry of a log.
+type Entry struct {
+	// Log is the ID of the log.
+	Log string
+	// Seq is the position of the entry in the log, starting at 1.
+	Seq uint64
+	// Prev links to the previous entry, nil for the first one.
+	Prev *cid.Cid `refmt:",omitempty"`
+	// Time is when the entry was appended, in unix seconds.
+	Time int64
+	Data []byte
+	// Sig is the signature of the entry without Sig by the key of the log.
+	Sig []byte `refmt:",omitempty"`
+}
+
+func (e *Entry) signedBytes() ([]byte, error) {
+	unsigned := *e
+	unsigned.Sig = nil
+	return cbornode.DumpObject(&unsigned)
+}
+
+// Sign sets the signature of e by priv, the key of the log.
+func (e *Entry) Sign(priv ic.PrivKey) error {
+	b, err := e.signedBytes()
+	if err != nil {
+		return err
+	}
+	e.Sig, err = priv.Sign(b)
+	return err
+}
+
+// Verify checks that e is signed by the key of the log id.
+func (e *Entry) Verify(id peer.ID) error {
+	if e.Log != id.Pretty() {
+		return fmt.Errorf("%w: entry of log %s", ErrInvalidEntry, e.Log)
+	}
+	pub, err := id.ExtractPublicKey()
+	if err != nil {
+		return fmt.Errorf("cannot get the key of log %s: %s", id.Pretty(), err)
+	}
+	b, err := e.signedBytes()
+	if err != nil {
+		return err
+	}
+	ok, err := pub.Verify(b, e.Sig)
+	if err != nil || !ok {
+		return fmt.Errorf("%w: bad signature", ErrInvalidEntry)
+	}
+	return nil
+}
+
+// Node returns the dag-cbor node of e.
+func (e *Entry) Node() (ipld.Node, error) {
+	return cbornode.WrapObject(e, mh.SHA2_256, -1)
+}
+
+// Get returns the entry c of the log id, verified.
+func Get(ctx context.Context, dag ipld.NodeGetter, id peer.ID, c cid.Cid) (*Entry, error) {
+	nd, err := dag.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{}
+	if err := cbornode.DecodeInto(nd.RawData(), e); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEntry, err)
+	}
+	if err := e.Verify(id); err != nil {
+		return nil, err
+	}
+	if e.Seq == 0 || (e.Seq == 1) != (e.Prev == nil) {
+		return nil, fmt.Errorf("%w: entry %d links to no previous entry", ErrInvalidEntry, e.Seq)
+	}
+	return e, nil
+}
+
+// Walk returns the entries of the log id from head back to the entry after
+// seq, oldest first. It fails if an entry does not follow the one it links
+// to.
+func Walk(ctx context.Context, dag ipld.NodeGetter, id peer.ID, head cid.Cid, seq uint64) ([]cid.Cid, []*Entry, error) {
+	var (
+		cids    []cid.Cid
+		entries []*Entry
+	)
+	for c := head; ; {
+		e, err := Get(ctx, dag, id, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n := len(entries); n > 0 && entries[n-1].Seq != e.Seq+1 {
+			return nil, nil, fmt.Errorf("%w: entry %d follows entry %d", ErrInvalidEntry, entries[n-1].Seq, e.Seq)
+		}
+		if e.Seq <= seq {
+			break
+		}
+		cids = append(cids, c)
+		entries = append(entries, e)
+		if e.Prev == nil {
+			break
+		}
+		c = *e.Prev
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		cids[i], cids[j] = cids[j], cids[i]
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return cids, entries, nil
+}
+
+// Topic returns the pubsub topic announcing the heads of the log id.
+func Topic(id peer.ID) string {
+	return "/btfs/log/" + id.Pretty()
+}
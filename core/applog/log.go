@@ -0,0 +1,164 @@
+package applog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	ipld "github.com/ipfs/go-ipld-format"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const keyPrefix = "/btfs/%s/logs/"
+
+var (
+	// ErrNotFound is returned for a log this node does not write.
+	ErrNotFound = errors.New("no such log")
+	// ErrExists is returned when creating a log whose name is taken.
+	ErrExists = errors.New("log already exists")
+)
+
+// Log is a log written by this node.
+type Log struct {
+	// Name is the name of the log, and of its key in the keystore.
+	Name string
+	// ID is the peer ID of the key of the log.
+	ID string
+	// Head is the last entry, "" for an empty log.
+	Head string
+	Seq  uint64
+}
+
+// Store keeps the logs a node writes in its datastore.
+type Store struct {
+	d      ds.Datastore
+	prefix string
+
+	// serializes the appends
+	mu sync.Mutex
+}
+
+// NewStore returns the logs written by the node peerID kept in d.
+func NewStore(d ds.Datastore, peerID string) *Store {
+	return &Store{d: d, prefix: fmt.Sprintf(keyPrefix, peerID)}
+}
+
+// ForNode returns the logs written by n.
+func ForNode(n *core.IpfsNode) *Store {
+	return NewStore(n.Repo.Datastore(), n.Identity.Pretty())
+}
+
+func (s *Store) key(name string) ds.Key {
+	return ds.NewKey(s.prefix + name)
+}
+
+// Get returns the log called name.
+func (s *Store) Get(name string) (*Log, error) {
+	b, err := s.d.Get(s.key(name))
+	if err == ds.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{}
+	if err := json.Unmarshal(b, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (s *Store) put(l *Log) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return s.d.Put(s.key(l.Name), b)
+}
+
+// List returns the logs sorted by name.
+func (s *Store) List() ([]*Log, error) {
+	res, err := s.d.Query(query.Query{Prefix: s.prefix})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+	ls := make([]*Log, 0, len(entries))
+	for _, en := range entries {
+		l := &Log{}
+		if err := json.Unmarshal(en.Value, l); err != nil {
+			return nil, fmt.Errorf("invalid log %s: %s", en.Key, err)
+		}
+		ls = append(ls, l)
+	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].Name < ls[j].Name })
+	return ls, nil
+}
+
+// Create records the empty log name, signed by the key id.
+func (s *Store) Create(name string, id peer.ID) (*Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Get(name); err == nil {
+		return nil, ErrExists
+	} else if err != ErrNotFound {
+		return nil, err
+	}
+	l := &Log{Name: name, ID: id.Pretty()}
+	return l, s.put(l)
+}
+
+// Append adds an entry with data to the log name signed by priv, stores it
+// in dag and returns it with its CID. The log is updated once the entry is
+// stored.
+func (s *Store) Append(ctx context.Context, dag ipld.DAGService, name string, priv ic.PrivKey, data []byte) (cid.Cid, *Entry, error) {
+	if len(data) > MaxDataSize {
+		return cid.Undef, nil, fmt.Errorf("entry of %d bytes, the limit is %d", len(data), MaxDataSize)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, err := s.Get(name)
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	if id.Pretty() != l.ID {
+		return cid.Undef, nil, fmt.Errorf("the key %s does not sign log %s", id.Pretty(), name)
+	}
+	e := &Entry{Log: l.ID, Seq: l.Seq + 1, Time: time.Now().Unix(), Data: data}
+	if l.Head != "" {
+		prev, err := cid.Decode(l.Head)
+		if err != nil {
+			return cid.Undef, nil, err
+		}
+		e.Prev = &prev
+	}
+	if err := e.Sign(priv); err != nil {
+		return cid.Undef, nil, err
+	}
+	nd, err := e.Node()
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	if err := dag.Add(ctx, nd); err != nil {
+		return cid.Undef, nil, err
+	}
+	l.Head, l.Seq = nd.Cid().String(), e.Seq
+	return nd.Cid(), e, s.put(l)
+}
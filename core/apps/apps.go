@@ -0,0 +1,284 @@
+// Package apps isolates the applications sharing the files API of a node.
+// Each application works in its own MFS namespace, /apps/<id>, and
+// authenticates to the API with its own token, which only runs the files
+// commands, resolved in the namespace, and the snapshots of its namespace.
+//
+// A namespace may be given a quota of bytes, checked after each files
+// command: a command leaving the namespace over its quota is undone.
+// Snapshots of a namespace are kept in SnapshotDir, out of reach of the
+// application, so that it can be rolled back without touching the others.
+package apps
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/users"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+const (
+	keyPrefix = "/btfs/%s/apps/"
+	// NamespaceRoot is the MFS directory holding the namespaces.
+	NamespaceRoot = "/apps"
+	// SnapshotDir is the MFS directory holding the snapshots of the
+	// namespaces, SnapshotDir/<id>/<name>.
+	SnapshotDir = "/.snapshots/apps"
+)
+
+var (
+	// ErrNotFound is returned for an application that is not registered.
+	ErrNotFound = errors.New("no such app")
+	// ErrExists is returned when registering an application whose ID is
+	// taken.
+	ErrExists = errors.New("app already exists")
+	// ErrInvalidToken is returned for a token of no application.
+	ErrInvalidToken = errors.New("invalid app token")
+	// ErrQuotaExceeded is returned when a command leaves a namespace over
+	// its quota.
+	ErrQuotaExceeded = errors.New("app quota exceeded")
+	// ErrNoSnapshot is returned for a snapshot that does not exist.
+	ErrNoSnapshot = errors.New("no such snapshot")
+)
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+// commandPrefixes are the commands an application token may run.
+var commandPrefixes = []string{"files", "apps/snapshot"}
+
+// App is an application registered on the node.
+type App struct {
+	ID string
+	// TokenHash is the hex sha256 of the API token of the application, the
+	// token itself is only shown when it is created.
+	TokenHash string
+	// Quota is the number of bytes the namespace may hold, 0 for no limit.
+	Quota     int64
+	Created   time.Time
+	Snapshots []*Snapshot `json:",omitempty"`
+}
+
+// Snapshot is a copy of the namespace of an application.
+type Snapshot struct {
+	Name    string
+	Cid     string
+	Size    uint64
+	Created time.Time
+}
+
+// Namespace returns the MFS directory the application works in.
+func (a *App) Namespace() string {
+	return NamespaceRoot + "/" + a.ID
+}
+
+// SnapshotPath returns the MFS path of the snapshot name of the
+// application.
+func (a *App) SnapshotPath(name string) string {
+	return SnapshotDir + "/" + a.ID + "/" + name
+}
+
+// Snapshot returns the snapshot name of the application.
+func (a *App) Snapshot(name string) (*Snapshot, error) {
+	for _, s := range a.Snapshots {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, ErrNoSnapshot
+}
+
+// Allows reports whether an application token may run the command at path,
+// e.g. []string{"files", "ls"}.
+func Allows(path []string) bool {
+	p := strings.Join(path, "/")
+	for _, prefix := range commandPrefixes {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Store keeps the applications of a node in its datastore.
+type Store struct {
+	d      ds.Datastore
+	prefix string
+
+	// serializes the read-modify-write of applications
+	mu sync.Mutex
+}
+
+// NewStore returns the applications of the node peerID kept in d.
+func NewStore(d ds.Datastore, peerID string) *Store {
+	return &Store{d: d, prefix: fmt.Sprintf(keyPrefix, peerID)}
+}
+
+// ForNode returns the applications of n.
+func ForNode(n *core.IpfsNode) *Store {
+	return NewStore(n.Repo.Datastore(), n.Identity.Pretty())
+}
+
+func (s *Store) key(id string) ds.Key {
+	return ds.NewKey(s.prefix + id)
+}
+
+// Get returns the application id.
+func (s *Store) Get(id string) (*App, error) {
+	b, err := s.d.Get(s.key(id))
+	if err == ds.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	a := &App{}
+	if err := json.Unmarshal(b, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *Store) put(a *App) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return s.d.Put(s.key(a.ID), b)
+}
+
+// List returns the applications sorted by ID.
+func (s *Store) List() ([]*App, error) {
+	res, err := s.d.Query(query.Query{Prefix: s.prefix})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+	as := make([]*App, 0, len(entries))
+	for _, en := range entries {
+		a := &App{}
+		if err := json.Unmarshal(en.Value, a); err != nil {
+			return nil, fmt.Errorf("invalid app %s: %s", en.Key, err)
+		}
+		as = append(as, a)
+	}
+	sort.Slice(as, func(i, j int) bool { return as[i].ID < as[j].ID })
+	return as, nil
+}
+
+// Enabled reports whether an application is registered.
+func (s *Store) Enabled() (bool, error) {
+	res, err := s.d.Query(query.Query{Prefix: s.prefix, KeysOnly: true, Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return false, err
+	}
+	return len(entries) > 0, nil
+}
+
+// Add registers the application id and returns it with its API token.
+func (s *Store) Add(id string, quota int64, now time.Time) (*App, string, error) {
+	if !validName.MatchString(id) {
+		return nil, "", fmt.Errorf("invalid app id %q: use up to 32 lowercase letters, digits, '.', '_' or '-'", id)
+	}
+	if quota < 0 {
+		return nil, "", errors.New("the quota cannot be negative")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Get(id); err == nil {
+		return nil, "", ErrExists
+	} else if err != ErrNotFound {
+		return nil, "", err
+	}
+	token, err := users.NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+	a := &App{ID: id, TokenHash: users.HashToken(token), Quota: quota, Created: now}
+	return a, token, s.put(a)
+}
+
+// Update lets f change the application id and saves it unless f fails.
+func (s *Store) Update(id string, f func(a *App) error) (*App, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := f(a); err != nil {
+		return nil, err
+	}
+	return a, s.put(a)
+}
+
+// ResetToken replaces the API token of the application id, the previous
+// one stops working.
+func (s *Store) ResetToken(id string) (string, error) {
+	token, err := users.NewToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = s.Update(id, func(a *App) error {
+		a.TokenHash = users.HashToken(token)
+		return nil
+	})
+	return token, err
+}
+
+// Remove unregisters the application id. Its namespace and snapshots are
+// left in place.
+func (s *Store) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	return s.d.Delete(s.key(id))
+}
+
+// Authenticate returns the application whose API token is token.
+func (s *Store) Authenticate(token string) (*App, error) {
+	as, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	h := []byte(users.HashToken(token))
+	for _, a := range as {
+		if subtle.ConstantTimeCompare(h, []byte(a.TokenHash)) == 1 {
+			return a, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+type ctxKey struct{}
+
+// WithApp returns a context of a request made by a.
+func WithApp(ctx context.Context, a *App) context.Context {
+	return context.WithValue(ctx, ctxKey{}, a)
+}
+
+// FromContext returns the application which made a request, nil when the
+// request was not made with an application token.
+func FromContext(ctx context.Context) *App {
+	a, _ := ctx.Value(ctxKey{}).(*App)
+	return a
+}
@@ -0,0 +1,184 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-mfs"
+	ft "github.com/TRON-US/go-unixfs"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	dag "github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+)
+
+func newRoot(t *testing.T) *mfs.Root {
+	pub := func(context.Context, cid.Cid) error { return nil }
+	root, err := mfs.NewRoot(context.Background(), mdtest.Mock(), ft.EmptyDirNode(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// putFile adds a file of size bytes at the MFS path p.
+func putFile(t *testing.T, root *mfs.Root, p string, size int) {
+	nd := dag.NodeWithData(ft.FilePBData(make([]byte, size), uint64(size)))
+	if err := mfs.PutNode(root, p, nd); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore(dssync.MutexWrap(ds.NewMapDatastore()), "QmPeer")
+	if on, err := s.Enabled(); err != nil || on {
+		t.Fatalf("enabled without apps: %v %v", on, err)
+	}
+	if _, _, err := s.Add("Bad App", 0, time.Now()); err == nil {
+		t.Fatal("accepted an invalid id")
+	}
+	a, token, err := s.Add("dapp", 1<<20, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Namespace() != "/apps/dapp" {
+		t.Fatalf("unexpected namespace %s", a.Namespace())
+	}
+	if _, _, err := s.Add("dapp", 0, time.Now()); err != ErrExists {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if got, err := s.Authenticate(token); err != nil || got.ID != "dapp" {
+		t.Fatalf("authenticate: %v %v", got, err)
+	}
+	newToken, err := s.ResetToken("dapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(token); err != ErrInvalidToken {
+		t.Fatalf("old token still valid: %v", err)
+	}
+	if _, err := s.Authenticate(newToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove("dapp"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("dapp"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAllows(t *testing.T) {
+	for _, tc := range []struct {
+		path []string
+		ok   bool
+	}{
+		{[]string{"files", "ls"}, true},
+		{[]string{"files"}, true},
+		{[]string{"apps", "snapshot", "create"}, true},
+		{[]string{"apps", "add"}, false},
+		{[]string{"filestore", "ls"}, false},
+		{[]string{"config"}, false},
+	} {
+		if Allows(tc.path) != tc.ok {
+			t.Errorf("%v: expected %v", tc.path, tc.ok)
+		}
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	root := newRoot(t)
+	s := NewStore(dssync.MutexWrap(ds.NewMapDatastore()), "QmPeer")
+	a, _, err := s.Add("dapp", 0, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := s.Add("other", 0, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, app := range []*App{a, other} {
+		if err := Mkdir(root, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	putFile(t, root, "/apps/dapp/v1", 10)
+	putFile(t, root, "/apps/other/keep", 10)
+
+	snap, err := s.TakeSnapshot(ctx, root, "dapp", "before", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.TakeSnapshot(ctx, root, "dapp", "before", time.Now()); err == nil {
+		t.Fatal("took a snapshot twice under one name")
+	}
+
+	putFile(t, root, "/apps/dapp/v2", 10)
+	putFile(t, root, "/apps/other/new", 10)
+	got, err := s.Rollback(ctx, root, "dapp", "before")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cid != snap.Cid {
+		t.Fatalf("rolled back to %s, expected %s", got.Cid, snap.Cid)
+	}
+	if _, err := mfs.Lookup(root, "/apps/dapp/v1"); err != nil {
+		t.Fatalf("v1 not restored: %s", err)
+	}
+	if _, err := mfs.Lookup(root, "/apps/dapp/v2"); err == nil {
+		t.Fatal("v2 survived the rollback")
+	}
+	// the other namespaces are untouched
+	if _, err := mfs.Lookup(root, "/apps/other/new"); err != nil {
+		t.Fatalf("other app rolled back: %s", err)
+	}
+
+	if err := s.RemoveSnapshot(ctx, root, "dapp", "before"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rollback(ctx, root, "dapp", "before"); err != ErrNoSnapshot {
+		t.Fatalf("expected ErrNoSnapshot, got %v", err)
+	}
+	if _, err := mfs.Lookup(root, a.SnapshotPath("before")); err == nil {
+		t.Fatal("snapshot left in place")
+	}
+}
+
+func TestQuota(t *testing.T) {
+	root := newRoot(t)
+	a := &App{ID: "dapp", Quota: 100}
+	if err := Mkdir(root, a); err != nil {
+		t.Fatal(err)
+	}
+	before, err := Tree(root, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, err := before.Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	putFile(t, root, "/apps/dapp/big", 200)
+	if err := CheckQuota(root, a, size); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	// over the quota, the namespace may still shrink
+	used, err := Usage(root, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckQuota(root, a, used); err != nil {
+		t.Fatalf("refused a namespace which did not grow: %s", err)
+	}
+
+	if err := Replace(context.Background(), root, a, before); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mfs.Lookup(root, "/apps/dapp/big"); err == nil {
+		t.Fatal("the command was not undone")
+	}
+}
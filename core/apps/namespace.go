@@ -0,0 +1,182 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"os"
+	gopath "path"
+	"time"
+
+	"github.com/TRON-US/go-mfs"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+// Mkdir creates the namespace of a in root unless it exists.
+func Mkdir(root *mfs.Root, a *App) error {
+	err := mfs.Mkdir(root, a.Namespace(), mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil && err != mfs.ErrDirExists {
+		return err
+	}
+	return nil
+}
+
+// Tree returns the node of the namespace of a in root, nil when it does
+// not exist.
+func Tree(root *mfs.Root, a *App) (ipld.Node, error) {
+	fsn, err := mfs.Lookup(root, a.Namespace())
+	if err == os.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fsn.GetNode()
+}
+
+// Usage returns the number of bytes held by the namespace of a in root.
+func Usage(root *mfs.Root, a *App) (uint64, error) {
+	nd, err := Tree(root, a)
+	if nd == nil || err != nil {
+		return 0, err
+	}
+	return nd.Size()
+}
+
+// CheckQuota fails with ErrQuotaExceeded when the namespace of a, which
+// held before bytes, grew over its quota. A namespace already over its
+// quota may still shrink.
+func CheckQuota(root *mfs.Root, a *App, before uint64) error {
+	if a.Quota == 0 {
+		return nil
+	}
+	used, err := Usage(root, a)
+	if err != nil {
+		return err
+	}
+	if used > uint64(a.Quota) && used > before {
+		return fmt.Errorf("%w: app %s would hold %d of %d bytes", ErrQuotaExceeded, a.ID, used, a.Quota)
+	}
+	return nil
+}
+
+// Replace makes nd the namespace of a in root, removing the namespace when
+// nd is nil.
+func Replace(ctx context.Context, root *mfs.Root, a *App, nd ipld.Node) error {
+	if err := unlink(root, NamespaceRoot, a.ID); err != nil {
+		return err
+	}
+	if nd != nil {
+		if err := mfs.Mkdir(root, NamespaceRoot, mfs.MkdirOpts{Mkparents: true}); err != nil && err != mfs.ErrDirExists {
+			return err
+		}
+		if err := mfs.PutNode(root, a.Namespace(), nd); err != nil {
+			return err
+		}
+	}
+	_, err := mfs.FlushPath(ctx, root, NamespaceRoot)
+	return err
+}
+
+// unlink removes the entry name of the MFS directory dir, which may already
+// be gone.
+func unlink(root *mfs.Root, dir, name string) error {
+	fsn, err := mfs.Lookup(root, dir)
+	if err == os.ErrNotExist {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	d, ok := fsn.(*mfs.Directory)
+	if !ok {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if err := d.Unlink(name); err != nil && err != os.ErrNotExist {
+		return err
+	}
+	return nil
+}
+
+// TakeSnapshot copies the namespace of the application id in root to its
+// snapshot name, and records it.
+func (s *Store) TakeSnapshot(ctx context.Context, root *mfs.Root, id, name string, now time.Time) (*Snapshot, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid snapshot name %q: use up to 32 lowercase letters, digits, '.', '_' or '-'", name)
+	}
+	var snap *Snapshot
+	_, err := s.Update(id, func(a *App) error {
+		if _, err := a.Snapshot(name); err == nil {
+			return fmt.Errorf("snapshot %s of app %s already exists", name, id)
+		}
+		if err := Mkdir(root, a); err != nil {
+			return err
+		}
+		nd, err := Tree(root, a)
+		if err != nil {
+			return err
+		}
+		size, err := nd.Size()
+		if err != nil {
+			return err
+		}
+		p := a.SnapshotPath(name)
+		if err := mfs.Mkdir(root, gopath.Dir(p), mfs.MkdirOpts{Mkparents: true}); err != nil && err != mfs.ErrDirExists {
+			return err
+		}
+		if err := mfs.PutNode(root, p, nd); err != nil {
+			return err
+		}
+		if _, err := mfs.FlushPath(ctx, root, gopath.Dir(p)); err != nil {
+			return err
+		}
+		snap = &Snapshot{Name: name, Cid: nd.Cid().String(), Size: size, Created: now}
+		a.Snapshots = append(a.Snapshots, snap)
+		return nil
+	})
+	return snap, err
+}
+
+// Rollback restores the namespace of the application id in root to its
+// snapshot name. The snapshot is kept.
+func (s *Store) Rollback(ctx context.Context, root *mfs.Root, id, name string) (*Snapshot, error) {
+	a, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := a.Snapshot(name)
+	if err != nil {
+		return nil, err
+	}
+	fsn, err := mfs.Lookup(root, a.SnapshotPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s of app %s is gone from %s: %s", name, id, a.SnapshotPath(name), err)
+	}
+	nd, err := fsn.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	return snap, Replace(ctx, root, a, nd)
+}
+
+// RemoveSnapshot removes the snapshot name of the application id in root.
+func (s *Store) RemoveSnapshot(ctx context.Context, root *mfs.Root, id, name string) error {
+	_, err := s.Update(id, func(a *App) error {
+		if _, err := a.Snapshot(name); err != nil {
+			return err
+		}
+		if err := unlink(root, gopath.Dir(a.SnapshotPath(name)), name); err != nil {
+			return err
+		}
+		if _, err := mfs.FlushPath(ctx, root, SnapshotDir); err != nil {
+			return err
+		}
+		for i, snap := range a.Snapshots {
+			if snap.Name == name {
+				a.Snapshots = append(a.Snapshots[:i], a.Snapshots[i+1:]...)
+				break
+			}
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,325 @@
+// Package audit records the commands executed against the daemon API in a
+// local, queryable log.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/e"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("core/audit")
+
+// ConfigKey is the config section of the audit log.
+const ConfigKey = "Audit"
+
+// FileName is the name of the audit log in the repo. The previous log is
+// kept with a ".1" suffix once it reaches its maximum size.
+const FileName = "audit.log"
+
+// DefaultMaxSizeMB is the size at which the log is rotated when nothing is
+// configured.
+const DefaultMaxSizeMB = 16
+
+// Redacted replaces secret arguments and options in the log.
+const Redacted = "<redacted>"
+
+// Config configures the audit log.
+type Config struct {
+	// Disabled turns the audit log off.
+	Disabled bool
+	// MaxSizeMB is the size in MiB at which the log is rotated.
+	MaxSizeMB int `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the audit config of r with the defaults filled in.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if c.MaxSizeMB <= 0 {
+		c.MaxSizeMB = DefaultMaxSizeMB
+	}
+	return c, nil
+}
+
+// Entry is one command executed against the daemon.
+type Entry struct {
+	Time    time.Time
+	Command string
+	Args    []string               `json:",omitempty"`
+	Options map[string]interface{} `json:",omitempty"`
+	// Caller identifies who sent the request, see Caller.
+	Caller string
+	// Code is the exit status of the command, see e.ExitCode.
+	Code     int
+	Error    string `json:",omitempty"`
+	Duration time.Duration
+}
+
+// Log appends entries to the audit log of a repo.
+type Log struct {
+	path    string
+	maxSize int64
+}
+
+// all logs of the process share the lock, a repo has a single log file.
+var appendLk sync.Mutex
+
+// Open returns the audit log of the repo at repoPath.
+func Open(repoPath string, c *Config) *Log {
+	return &Log{
+		path:    filepath.Join(repoPath, FileName),
+		maxSize: int64(c.MaxSizeMB) << 20,
+	}
+}
+
+// Append writes en at the end of the log, rotating it when it is full.
+func (l *Log) Append(en *Entry) error {
+	b, err := json.Marshal(en)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	appendLk.Lock()
+	defer appendLk.Unlock()
+	if fi, err := os.Stat(l.path); err == nil && fi.Size()+int64(len(b)) > l.maxSize {
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Filter selects entries of the log.
+type Filter struct {
+	// Since drops the entries older than it.
+	Since time.Time
+	// Command keeps the entries of the command and its subcommands, e.g.
+	// "wallet" or "wallet withdraw".
+	Command string
+	// Caller keeps the entries of a caller.
+	Caller string
+	// Failed keeps the failed commands only.
+	Failed bool
+	// Limit is the maximum number of entries, 0 for all.
+	Limit int
+}
+
+// Match reports whether en is selected by f.
+func (f *Filter) Match(en *Entry) bool {
+	if en.Time.Before(f.Since) {
+		return false
+	}
+	if f.Command != "" && en.Command != f.Command && !strings.HasPrefix(en.Command, f.Command+" ") {
+		return false
+	}
+	if f.Caller != "" && en.Caller != f.Caller {
+		return false
+	}
+	if f.Failed && en.Code == e.ExitOK {
+		return false
+	}
+	return true
+}
+
+// Read returns the entries of the audit log of the repo at repoPath
+// selected by f, newest first.
+func Read(repoPath string, f *Filter) ([]*Entry, error) {
+	p := filepath.Join(repoPath, FileName)
+	var all []*Entry
+	for _, name := range []string{p + ".1", p} {
+		entries, err := readFile(name, f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+	}
+	for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+		all[i], all[j] = all[j], all[i]
+	}
+	if f.Limit > 0 && len(all) > f.Limit {
+		all = all[:f.Limit]
+	}
+	return all, nil
+}
+
+func readFile(name string, f *Filter) ([]*Entry, error) {
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []*Entry
+	s := bufio.NewScanner(file)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		en := &Entry{}
+		// A line cut short by a crash is skipped.
+		if json.Unmarshal(s.Bytes(), en) != nil {
+			continue
+		}
+		if f.Match(en) {
+			entries = append(entries, en)
+		}
+	}
+	return entries, s.Err()
+}
+
+// Caller identifies the sender of an API request: a fingerprint of its
+// bearer token if it has one, else its remote address.
+func Caller(r *http.Request) string {
+	const prefix = "Bearer "
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, prefix) {
+		sum := sha256.Sum256([]byte(strings.TrimPrefix(auth, prefix)))
+		return "token:" + hex.EncodeToString(sum[:8])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
+
+type ctxKey struct{}
+
+type recorder struct {
+	log    *Log
+	caller string
+	// claimed is set by the first command recording the request, commands
+	// run by that command are not recorded again.
+	claimed int32
+}
+
+// WithCaller returns a context whose commands are recorded in l on behalf
+// of caller.
+func WithCaller(ctx context.Context, l *Log, caller string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &recorder{log: l, caller: caller})
+}
+
+// Start starts recording req. It returns the function to call with the
+// result of the command, or nil when req is not audited.
+func Start(req *cmds.Request) func(error) {
+	rec, ok := req.Context.Value(ctxKey{}).(*recorder)
+	if !ok || !atomic.CompareAndSwapInt32(&rec.claimed, 0, 1) {
+		return nil
+	}
+	en := &Entry{
+		Time:    time.Now(),
+		Command: strings.Join(req.Path, " "),
+		Args:    RedactArgs(req),
+		Options: RedactOptions(req),
+		Caller:  rec.caller,
+	}
+	return func(err error) {
+		en.Duration = time.Since(en.Time)
+		en.Code = e.ExitCode(err)
+		if err != nil {
+			en.Error = err.Error()
+		}
+		if err := rec.log.Append(en); err != nil {
+			log.Errorf("failed to write audit log: %s", err)
+		}
+	}
+}
+
+// Secret reports whether an argument or option called name holds a secret.
+func Secret(name string) bool {
+	name = strings.NewReplacer("-", "", "_", "", ".", "").Replace(strings.ToLower(name))
+	for _, s := range []string{"password", "passwd", "privatekey", "privkey", "mnemonic", "secret", "seed", "authtoken"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactArgs returns the arguments of req with the secret ones redacted.
+func RedactArgs(req *cmds.Request) []string {
+	if len(req.Arguments) == 0 {
+		return nil
+	}
+	args := make([]string, len(req.Arguments))
+	copy(args, req.Arguments)
+	var defs []cmds.Argument
+	if req.Command != nil {
+		defs = req.Command.Arguments
+	}
+	for i := range args {
+		var def cmds.Argument
+		switch {
+		case i < len(defs):
+			def = defs[i]
+		case len(defs) > 0 && defs[len(defs)-1].Variadic:
+			def = defs[len(defs)-1]
+		}
+		if Secret(def.Name) {
+			args[i] = Redacted
+		}
+	}
+	// The value of "config <key> <value>" is as secret as its key.
+	if len(req.Path) == 1 && req.Path[0] == "config" && len(args) > 1 && Secret(args[0]) {
+		args[1] = Redacted
+	}
+	return args
+}
+
+// RedactOptions returns the options of req with the secret ones redacted.
+func RedactOptions(req *cmds.Request) map[string]interface{} {
+	if len(req.Options) == 0 {
+		return nil
+	}
+	// Options may be given by any of their names, e.g. "p" for "password".
+	var defs map[string]cmds.Option
+	if req.Root != nil {
+		defs, _ = req.Root.GetOptions(req.Path)
+	}
+	opts := make(map[string]interface{}, len(req.Options))
+	for k, v := range req.Options {
+		name := k
+		if def, ok := defs[k]; ok {
+			name = def.Name()
+		}
+		if Secret(name) {
+			v = Redacted
+		}
+		opts[k] = v
+	}
+	return opts
+}
@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/e"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+var testRoot = &cmds.Command{
+	Subcommands: map[string]*cmds.Command{
+		"wallet": {
+			Subcommands: map[string]*cmds.Command{
+				"password": {
+					Arguments: []cmds.Argument{cmds.StringArg("password", true, false, "")},
+				},
+				"withdraw": {
+					Arguments: []cmds.Argument{cmds.StringArg("amount", true, false, "")},
+					Options:   []cmds.Option{cmds.StringOption("password", "p", "")},
+				},
+			},
+		},
+		"config": {
+			Arguments: []cmds.Argument{
+				cmds.StringArg("key", true, false, ""),
+				cmds.StringArg("value", false, false, ""),
+			},
+		},
+	},
+}
+
+func testRequest(t *testing.T, ctx context.Context, path []string, args []string, opts cmds.OptMap) *cmds.Request {
+	req, err := cmds.NewRequest(ctx, path, opts, args, nil, testRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestRedact(t *testing.T) {
+	ctx := context.Background()
+	req := testRequest(t, ctx, []string{"wallet", "password"}, []string{"hunter2"}, nil)
+	if args := RedactArgs(req); len(args) != 1 || args[0] != Redacted {
+		t.Errorf("password argument not redacted: %v", args)
+	}
+
+	req = testRequest(t, ctx, []string{"wallet", "withdraw"}, []string{"100"}, cmds.OptMap{"p": "hunter2"})
+	if args := RedactArgs(req); len(args) != 1 || args[0] != "100" {
+		t.Errorf("amount redacted: %v", args)
+	}
+	if opts := RedactOptions(req); opts["p"] != Redacted {
+		t.Errorf("password option not redacted: %v", opts)
+	}
+	if req.Options["p"] != "hunter2" {
+		t.Error("request options changed")
+	}
+
+	req = testRequest(t, ctx, []string{"config"}, []string{"Identity.PrivKey", "CAAS..."}, nil)
+	if args := RedactArgs(req); args[0] != "Identity.PrivKey" || args[1] != Redacted {
+		t.Errorf("config value not redacted: %v", args)
+	}
+	req = testRequest(t, ctx, []string{"config"}, []string{"Addresses.API", "/ip4/127.0.0.1/tcp/5001"}, nil)
+	if args := RedactArgs(req); args[1] != "/ip4/127.0.0.1/tcp/5001" {
+		t.Errorf("config value redacted: %v", args)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/v1/id", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	if c := Caller(r); c != "addr:10.0.0.1" {
+		t.Errorf("got %q", c)
+	}
+	r.Header.Set("Authorization", "Bearer secret")
+	c := Caller(r)
+	if c == "" || c == "addr:10.0.0.1" || c == "token:secret" {
+		t.Errorf("got %q", c)
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := Open(dir, &Config{MaxSizeMB: 1})
+	// Rotate after a few entries.
+	l.maxSize = 600
+
+	for i := 0; i < 5; i++ {
+		// Every API request gets its own caller context.
+		ctx := WithCaller(context.Background(), l, "addr:127.0.0.1")
+		req := testRequest(t, ctx, []string{"wallet", "withdraw"}, []string{"100"}, nil)
+		done := Start(req)
+		if done == nil {
+			t.Fatal("request not audited")
+		}
+		// Commands run by the audited one are not recorded.
+		if Start(req) != nil {
+			t.Fatal("nested command audited")
+		}
+		var err error
+		if i%2 == 1 {
+			err = cmds.Errorf(e.ErrInsufficientFunds, "not enough balance")
+		}
+		done(err)
+	}
+	if Start(testRequest(t, context.Background(), []string{"config"}, []string{"Addresses.API"}, nil)) != nil {
+		t.Error("request without caller audited")
+	}
+	if _, err := os.Stat(l.path + ".1"); err != nil {
+		t.Fatalf("log not rotated: %s", err)
+	}
+
+	all, err := Read(dir, &Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) < 2 {
+		t.Fatalf("got %d entries", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Time.After(all[i-1].Time) {
+			t.Errorf("entries not newest first")
+		}
+	}
+	en := all[0]
+	if en.Command != "wallet withdraw" || en.Caller != "addr:127.0.0.1" || en.Code != e.ExitOK {
+		t.Errorf("unexpected entry %+v", en)
+	}
+
+	failed, err := Read(dir, &Filter{Failed: true, Command: "wallet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, en := range failed {
+		if en.Code != e.ExitInsufficientFunds || en.Error != "not enough balance" {
+			t.Errorf("unexpected entry %+v", en)
+		}
+	}
+	if len(failed) == 0 {
+		t.Error("no failed entries")
+	}
+
+	for _, f := range []*Filter{
+		{Command: "wall"},
+		{Caller: "addr:10.0.0.1"},
+		{Since: time.Now().Add(time.Hour)},
+	} {
+		if got, _ := Read(dir, f); len(got) != 0 {
+			t.Errorf("filter %+v matched %d entries", f, len(got))
+		}
+	}
+	if got, _ := Read(dir, &Filter{Limit: 1}); len(got) != 1 {
+		t.Errorf("limit ignored: %d entries", len(got))
+	}
+}
+
+func TestReadMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if all, err := Read(dir, &Filter{}); err != nil || len(all) != 0 {
+		t.Errorf("got %v, %v", all, err)
+	}
+}
@@ -0,0 +1,646 @@
+// Package blockcache puts a read-through cache on fast storage in front of
+// the blockstore of the node, so that hosts keeping their blocks on
+// spinning disks serve the popular ones from an SSD.
+//
+// The blocks read from the bulk blockstore are copied to the cache, which
+// evicts the least recently read ones above its size. The blocks of hot
+// shards can be pinned in the cache, where they stay until unpinned. Blocks
+// are only ever written to the bulk blockstore, which stays the reference:
+// the cache can be wiped at any time.
+package blockcache
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+	"github.com/TRON-US/go-btfs/repo/repocrypt"
+
+	"github.com/dustin/go-humanize"
+	blocks "github.com/ipfs/go-block-format"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/mount"
+	"github.com/ipfs/go-datastore/query"
+	flatfs "github.com/ipfs/go-ds-flatfs"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	dshelp "github.com/ipfs/go-ipfs-ds-help"
+	logging "github.com/ipfs/go-log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var log = logging.Logger("blockcache")
+
+// ConfigKey is the config section of the block cache.
+const ConfigKey = "BlockCache"
+
+// DefaultMaxSize is the size of the cache when MaxSize is not set.
+const DefaultMaxSize = "10GB"
+
+var (
+	// ErrFull is returned when the pinned blocks would not fit in the cache.
+	ErrFull = errors.New("the pinned blocks would not fit in the block cache")
+	// ErrNotPinned is returned for a root not pinned in the cache.
+	ErrNotPinned = errors.New("not pinned in the block cache")
+)
+
+// Config configures the block cache.
+type Config struct {
+	// Path is the absolute path of the directory of the cache on fast
+	// storage. The cache is disabled when empty.
+	Path string `json:",omitempty"`
+	// MaxSize is the size of the blocks kept in the cache, e.g. "50GB".
+	MaxSize string `json:",omitempty"`
+
+	maxSize int64
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+	prometheus.MustRegister(requests, cacheSize, evictions)
+}
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "blockcache",
+		Name:      "requests_total",
+		Help:      "Block reads through the block cache, by result.",
+	}, []string{"result"})
+	cacheSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "btfs",
+		Subsystem: "blockcache",
+		Name:      "size_bytes",
+		Help:      "Size of the blocks in the block cache.",
+	})
+	evictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "blockcache",
+		Name:      "evictions_total",
+		Help:      "Blocks evicted from the block cache.",
+	})
+)
+
+// Load returns the block cache config of r, nil when the cache is disabled.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if c.Path == "" {
+		return nil, nil
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	if !filepath.IsAbs(c.Path) {
+		return fmt.Errorf("Path %q is not absolute", c.Path)
+	}
+	if c.MaxSize == "" {
+		c.MaxSize = DefaultMaxSize
+	}
+	size, err := humanize.ParseBytes(c.MaxSize)
+	if err != nil || size == 0 {
+		return fmt.Errorf("invalid MaxSize %q", c.MaxSize)
+	}
+	c.maxSize = int64(size)
+	return nil
+}
+
+// Pin is a DAG pinned in the cache.
+type Pin struct {
+	Root    string
+	Blocks  []string
+	Size    int64
+	Created time.Time
+}
+
+// Stats are the counters of the cache since the node started.
+type Stats struct {
+	Path         string
+	MaxSize      int64
+	Size         int64
+	Blocks       int
+	PinnedSize   int64
+	PinnedBlocks int
+	Hits         int64
+	Misses       int64
+	// HitRate is the share of the reads served by the cache.
+	HitRate   float64
+	Evictions int64
+}
+
+type entry struct {
+	c    cid.Cid
+	size int64
+	// pins is the number of pins of the block, which is in the LRU list
+	// when not pinned.
+	pins int
+	elem *list.Element
+}
+
+// Cache is a blockstore reading through a cache on fast storage.
+type Cache struct {
+	// Blockstore is the bulk blockstore, where the blocks are written.
+	blockstore.Blockstore
+	path    string
+	tierDs  *flatfs.Datastore
+	tier    blockstore.Blockstore
+	d       ds.Datastore
+	prefix  string
+	maxSize int64
+
+	mu sync.Mutex
+	// pinMu serializes the pins and unpins.
+	pinMu   sync.Mutex
+	entries map[string]*entry
+	// lru holds the unpinned entries, the most recently read first.
+	lru        *list.List
+	size       int64
+	pinnedSize int64
+	pinned     int
+	hits       int64
+	misses     int64
+	evicted    int64
+}
+
+var _ blockstore.Blockstore = (*Cache)(nil)
+
+// Open returns the cache configured by cfg in front of bulk. The pins are
+// kept in d for the peer peerID.
+func Open(cfg *Config, bulk blockstore.Blockstore, d ds.Datastore, peerID string) (*Cache, error) {
+	if cfg.maxSize == 0 {
+		if err := cfg.compile(); err != nil {
+			return nil, err
+		}
+	}
+	fds, err := flatfs.CreateOrOpen(cfg.Path, flatfs.NextToLast(2), false)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the block cache at %s: %s", cfg.Path, err)
+	}
+	c := &Cache{
+		Blockstore: bulk,
+		path:       cfg.Path,
+		tierDs:     fds,
+		// the blockstore prefixes its keys, the bulk flatfs is mounted
+		// under the prefix the same way. The copies are sealed like the
+		// blocks of the repo when it is encrypted.
+		tier: blockstore.NewBlockstore(repocrypt.NewDatastore(mount.New([]mount.Mount{
+			{Prefix: blockstore.BlockPrefix, Datastore: fds},
+		}), repocrypt.Default)),
+		d:       d,
+		prefix:  fmt.Sprintf("/btfs/%s/blockcache/pins/", peerID),
+		maxSize: cfg.maxSize,
+		entries: map[string]*entry{},
+		lru:     list.New(),
+	}
+	if err := c.load(); err != nil {
+		fds.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// load indexes the blocks in the cache and marks the pinned ones.
+func (c *Cache) load() error {
+	keys, err := c.tierDs.Query(query.Query{KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	defer keys.Close()
+	for r := range keys.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+		k, err := dshelp.DsKeyToCid(ds.RawKey(r.Key))
+		if err != nil {
+			continue
+		}
+		size, err := c.tierDs.GetSize(ds.RawKey(r.Key))
+		if err != nil {
+			continue
+		}
+		e := &entry{c: k, size: int64(size)}
+		e.elem = c.lru.PushBack(e)
+		c.entries[k.KeyString()] = e
+		c.size += e.size
+	}
+	pins, err := c.Pins()
+	if err != nil {
+		return err
+	}
+	for _, p := range pins {
+		for _, s := range p.Blocks {
+			k, err := cid.Decode(s)
+			if err != nil {
+				return err
+			}
+			if _, err := c.fetch(k); err != nil {
+				log.Warnf("cannot cache block %s pinned under %s: %s", s, p.Root, err)
+				continue
+			}
+			c.mu.Lock()
+			c.pinLocked(k)
+			c.mu.Unlock()
+		}
+	}
+	c.mu.Lock()
+	victims := c.evictLocked()
+	c.mu.Unlock()
+	c.remove(victims)
+	return nil
+}
+
+// Close closes the cache, not the bulk blockstore.
+func (c *Cache) Close() error {
+	return c.tierDs.Close()
+}
+
+// lookup returns whether k is in the cache, making it the most recently
+// read block.
+func (c *Cache) lookup(k cid.Cid) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[k.KeyString()]
+	if ok && e.elem != nil {
+		c.lru.MoveToFront(e.elem)
+	}
+	return e, ok
+}
+
+// drop forgets k, not in the cache after all.
+func (c *Cache) drop(k cid.Cid) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forgetLocked(k)
+}
+
+func (c *Cache) forgetLocked(k cid.Cid) {
+	e, ok := c.entries[k.KeyString()]
+	if !ok {
+		return
+	}
+	delete(c.entries, k.KeyString())
+	if e.elem != nil {
+		c.lru.Remove(e.elem)
+	} else {
+		c.pinnedSize -= e.size
+		c.pinned--
+	}
+	c.size -= e.size
+	cacheSize.Set(float64(c.size))
+}
+
+func (c *Cache) Get(k cid.Cid) (blocks.Block, error) {
+	if _, ok := c.lookup(k); ok {
+		b, err := c.tier.Get(k)
+		if err == nil {
+			c.count(true)
+			return b, nil
+		}
+		c.drop(k)
+	}
+	c.count(false)
+	b, err := c.Blockstore.Get(k)
+	if err != nil {
+		return nil, err
+	}
+	c.admit(b)
+	return b, nil
+}
+
+func (c *Cache) count(hit bool) {
+	c.mu.Lock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.mu.Unlock()
+	if hit {
+		requests.WithLabelValues("hit").Inc()
+	} else {
+		requests.WithLabelValues("miss").Inc()
+	}
+}
+
+func (c *Cache) Has(k cid.Cid) (bool, error) {
+	c.mu.Lock()
+	_, ok := c.entries[k.KeyString()]
+	c.mu.Unlock()
+	if ok {
+		return true, nil
+	}
+	return c.Blockstore.Has(k)
+}
+
+func (c *Cache) GetSize(k cid.Cid) (int, error) {
+	c.mu.Lock()
+	e, ok := c.entries[k.KeyString()]
+	c.mu.Unlock()
+	if ok {
+		return int(e.size), nil
+	}
+	return c.Blockstore.GetSize(k)
+}
+
+// DeleteBlock deletes k from the bulk blockstore and the cache, pinned or
+// not.
+func (c *Cache) DeleteBlock(k cid.Cid) error {
+	if err := c.Blockstore.DeleteBlock(k); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	_, ok := c.entries[k.KeyString()]
+	c.forgetLocked(k)
+	c.mu.Unlock()
+	if ok {
+		return c.tier.DeleteBlock(k)
+	}
+	return nil
+}
+
+// admit copies b to the cache, evicting the least recently read blocks
+// above its size.
+func (c *Cache) admit(b blocks.Block) {
+	size := int64(len(b.RawData()))
+	c.mu.Lock()
+	fits := size <= c.maxSize-c.pinnedSize
+	c.mu.Unlock()
+	if !fits {
+		return
+	}
+	if err := c.tier.Put(b); err != nil {
+		log.Warnf("cannot cache block %s: %s", b.Cid(), err)
+		return
+	}
+	c.mu.Lock()
+	c.insertLocked(b.Cid(), size)
+	victims := c.evictLocked()
+	c.mu.Unlock()
+	c.remove(victims)
+}
+
+func (c *Cache) insertLocked(k cid.Cid, size int64) *entry {
+	if e, ok := c.entries[k.KeyString()]; ok {
+		if e.elem != nil {
+			c.lru.MoveToFront(e.elem)
+		}
+		return e
+	}
+	e := &entry{c: k, size: size}
+	e.elem = c.lru.PushFront(e)
+	c.entries[k.KeyString()] = e
+	c.size += size
+	cacheSize.Set(float64(c.size))
+	return e
+}
+
+// evictLocked forgets the least recently read blocks above the size of the
+// cache and returns them.
+func (c *Cache) evictLocked() []cid.Cid {
+	var victims []cid.Cid
+	for c.size > c.maxSize {
+		last := c.lru.Back()
+		if last == nil {
+			break
+		}
+		e := last.Value.(*entry)
+		c.forgetLocked(e.c)
+		victims = append(victims, e.c)
+		c.evicted++
+	}
+	evictions.Add(float64(len(victims)))
+	return victims
+}
+
+// remove deletes the evicted blocks from the cache.
+func (c *Cache) remove(victims []cid.Cid) {
+	for _, k := range victims {
+		if err := c.tier.DeleteBlock(k); err != nil && err != blockstore.ErrNotFound {
+			log.Warnf("cannot evict block %s: %s", k, err)
+		}
+	}
+}
+
+// fetch copies k from the bulk blockstore to the cache unless cached and
+// returns its size.
+func (c *Cache) fetch(k cid.Cid) (int64, error) {
+	c.mu.Lock()
+	e, ok := c.entries[k.KeyString()]
+	c.mu.Unlock()
+	if ok {
+		return e.size, nil
+	}
+	b, err := c.Blockstore.Get(k)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.tier.Put(b); err != nil {
+		return 0, err
+	}
+	size := int64(len(b.RawData()))
+	c.mu.Lock()
+	c.insertLocked(k, size)
+	c.mu.Unlock()
+	return size, nil
+}
+
+// pinLocked pins k, in the cache.
+func (c *Cache) pinLocked(k cid.Cid) {
+	e, ok := c.entries[k.KeyString()]
+	if !ok {
+		return
+	}
+	if e.pins == 0 {
+		c.lru.Remove(e.elem)
+		e.elem = nil
+		c.pinnedSize += e.size
+		c.pinned++
+	}
+	e.pins++
+}
+
+func (c *Cache) pinKey(root cid.Cid) ds.Key {
+	return ds.NewKey(c.prefix + root.String())
+}
+
+// Pin keeps the blocks ks of the DAG root in the cache until unpinned.
+func (c *Cache) Pin(root cid.Cid, ks []cid.Cid) (*Pin, error) {
+	c.pinMu.Lock()
+	defer c.pinMu.Unlock()
+	if b, err := c.d.Get(c.pinKey(root)); err == nil {
+		p := &Pin{}
+		return p, json.Unmarshal(b, p)
+	} else if err != ds.ErrNotFound {
+		return nil, err
+	}
+
+	p := &Pin{Root: root.String(), Created: time.Now().UTC()}
+	var unique []cid.Cid
+	seen := map[string]bool{}
+	for _, k := range ks {
+		if seen[k.KeyString()] {
+			continue
+		}
+		seen[k.KeyString()] = true
+		size, err := c.Blockstore.GetSize(k)
+		if err != nil {
+			return nil, fmt.Errorf("cannot pin block %s: %s", k, err)
+		}
+		unique = append(unique, k)
+		p.Blocks = append(p.Blocks, k.String())
+		p.Size += int64(size)
+	}
+	c.mu.Lock()
+	fits := c.pinnedSize+p.Size <= c.maxSize
+	c.mu.Unlock()
+	if !fits {
+		return nil, ErrFull
+	}
+
+	var pinned []cid.Cid
+	unpin := func() {
+		c.mu.Lock()
+		for _, k := range pinned {
+			c.unpinLocked(k)
+		}
+		c.mu.Unlock()
+	}
+	for _, k := range unique {
+		if _, err := c.fetch(k); err != nil {
+			unpin()
+			return nil, fmt.Errorf("cannot pin block %s: %s", k, err)
+		}
+		c.mu.Lock()
+		c.pinLocked(k)
+		c.mu.Unlock()
+		pinned = append(pinned, k)
+	}
+	b, err := json.Marshal(p)
+	if err == nil {
+		err = c.d.Put(c.pinKey(root), b)
+	}
+	if err != nil {
+		unpin()
+		return nil, err
+	}
+	c.mu.Lock()
+	victims := c.evictLocked()
+	c.mu.Unlock()
+	c.remove(victims)
+	return p, nil
+}
+
+func (c *Cache) unpinLocked(k cid.Cid) {
+	e, ok := c.entries[k.KeyString()]
+	if !ok || e.pins == 0 {
+		return
+	}
+	e.pins--
+	if e.pins == 0 {
+		e.elem = c.lru.PushFront(e)
+		c.pinnedSize -= e.size
+		c.pinned--
+	}
+}
+
+// Unpin lets the blocks of the DAG root be evicted from the cache.
+func (c *Cache) Unpin(root cid.Cid) error {
+	c.pinMu.Lock()
+	defer c.pinMu.Unlock()
+	b, err := c.d.Get(c.pinKey(root))
+	if err == ds.ErrNotFound {
+		return ErrNotPinned
+	}
+	if err != nil {
+		return err
+	}
+	p := &Pin{}
+	if err := json.Unmarshal(b, p); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	for _, s := range p.Blocks {
+		if k, err := cid.Decode(s); err == nil {
+			c.unpinLocked(k)
+		}
+	}
+	c.mu.Unlock()
+	return c.d.Delete(c.pinKey(root))
+}
+
+// Pins lists the DAGs pinned in the cache.
+func (c *Cache) Pins() ([]*Pin, error) {
+	rs, err := c.d.Query(query.Query{Prefix: c.prefix})
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	var pins []*Pin
+	for r := range rs.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		p := &Pin{}
+		if err := json.Unmarshal(r.Value, p); err != nil {
+			return nil, err
+		}
+		pins = append(pins, p)
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		return pins[i].Created.Before(pins[j].Created)
+	})
+	return pins, nil
+}
+
+// Stats returns the counters of the cache.
+func (c *Cache) Stats() *Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &Stats{
+		Path:         c.path,
+		MaxSize:      c.maxSize,
+		Size:         c.size,
+		Blocks:       len(c.entries),
+		PinnedSize:   c.pinnedSize,
+		PinnedBlocks: c.pinned,
+		Hits:         c.hits,
+		Misses:       c.misses,
+		Evictions:    c.evicted,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		s.HitRate = float64(c.hits) / float64(total)
+	}
+	return s
+}
+
+var (
+	activeLk sync.RWMutex
+	active   *Cache
+)
+
+// SetActive makes c the block cache of the node. Nil disables it.
+func SetActive(c *Cache) {
+	activeLk.Lock()
+	defer activeLk.Unlock()
+	active = c
+}
+
+// Active returns the block cache of the node, nil when disabled.
+func Active() *Cache {
+	activeLk.RLock()
+	defer activeLk.RUnlock()
+	return active
+}
@@ -0,0 +1,148 @@
+package blockcache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+)
+
+// newBlocks returns n blocks of 100 bytes in bulk.
+func newBlocks(t *testing.T, bulk blockstore.Blockstore, n int) []blocks.Block {
+	var bs []blocks.Block
+	for i := 0; i < n; i++ {
+		data := []byte(fmt.Sprintf("%0100d", i))
+		b := blocks.NewBlock(data)
+		if err := bulk.Put(b); err != nil {
+			t.Fatal(err)
+		}
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+func openTest(t *testing.T, dir string, maxSize string, bulk blockstore.Blockstore, d ds.Datastore) *Cache {
+	c, err := Open(&Config{Path: dir, MaxSize: maxSize}, bulk, d, "QmPeer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestReadThrough(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blockcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bulk := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	bs := newBlocks(t, bulk, 4)
+
+	c := openTest(t, dir, "300B", bulk, d)
+	for _, b := range bs[:3] {
+		if _, err := c.Get(b.Cid()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// bs[0] is read again, bs[1] is then the least recently read
+	if _, err := c.Get(bs[0].Cid()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(bs[3].Cid()); err != nil {
+		t.Fatal(err)
+	}
+	s := c.Stats()
+	if s.Hits != 1 || s.Misses != 4 || s.Blocks != 3 || s.Size != 300 || s.Evictions != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if ok, _ := c.tier.Has(bs[1].Cid()); ok {
+		t.Fatal("least recently read block not evicted")
+	}
+	// the evicted block is still read from the bulk blockstore
+	if b, err := c.Get(bs[1].Cid()); err != nil || !b.Cid().Equals(bs[1].Cid()) {
+		t.Fatalf("cannot read an evicted block: %v", err)
+	}
+
+	if err := c.DeleteBlock(bs[1].Cid()); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := c.Has(bs[1].Cid()); ok {
+		t.Fatal("deleted block still cached")
+	}
+	c.Close()
+
+	// the cached blocks are found again
+	c = openTest(t, dir, "300B", bulk, d)
+	defer c.Close()
+	if s := c.Stats(); s.Blocks != 2 || s.Size != 200 {
+		t.Fatalf("unexpected stats after reopening %+v", s)
+	}
+}
+
+func TestPin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blockcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bulk := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	bs := newBlocks(t, bulk, 5)
+	ks := []cid.Cid{bs[0].Cid(), bs[1].Cid()}
+
+	c := openTest(t, dir, "300B", bulk, d)
+	if _, err := c.Pin(bs[0].Cid(), ks); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Pin(bs[2].Cid(), []cid.Cid{bs[2].Cid(), bs[3].Cid()}); err != ErrFull {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+	for _, b := range bs[2:] {
+		if _, err := c.Get(b.Cid()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, k := range ks {
+		if ok, _ := c.tier.Has(k); !ok {
+			t.Fatal("pinned block evicted")
+		}
+	}
+	if s := c.Stats(); s.PinnedBlocks != 2 || s.PinnedSize != 200 || s.Size != 300 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	c.Close()
+
+	c = openTest(t, dir, "300B", bulk, d)
+	defer c.Close()
+	pins, err := c.Pins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 1 || len(pins[0].Blocks) != 2 || c.Stats().PinnedBlocks != 2 {
+		t.Fatalf("pins not restored: %+v", pins)
+	}
+	if err := c.Unpin(bs[0].Cid()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unpin(bs[0].Cid()); err != ErrNotPinned {
+		t.Fatalf("expected ErrNotPinned, got %v", err)
+	}
+	if s := c.Stats(); s.PinnedBlocks != 0 || s.PinnedSize != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, c := range []*Config{{Path: "relative"}, {Path: "/abs", MaxSize: "lots"}, {Path: "/abs", MaxSize: "0"}} {
+		if err := c.compile(); err == nil {
+			t.Errorf("accepted %+v", c)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -50,6 +51,11 @@ type BootstrapConfig struct {
 	// for the bootstrap process to use. This makes it possible for clients
 	// to control the peers the process uses at any moment.
 	BootstrapPeers func() []peer.AddrInfo
+
+	// Latencies, if set, returns the measured latency to the bootstrap
+	// peers. The fastest peers are then dialed first, before the ones never
+	// measured.
+	Latencies func() map[peer.ID]time.Duration
 }
 
 // DefaultBootstrapConfig specifies default sane parameters for bootstrapping.
@@ -142,11 +148,16 @@ func bootstrapRound(ctx context.Context, host host.Host, cfg BootstrapConfig) er
 		return ErrNotEnoughBootstrapPeers
 	}
 
-	// connect to a random susbset of bootstrap candidates
-	randSubset := randomSubsetOfPeers(notConnected, numToDial)
+	// connect to the fastest, or a random, susbset of bootstrap candidates
+	var subset []peer.AddrInfo
+	if cfg.Latencies != nil {
+		subset = fastestSubsetOfPeers(notConnected, numToDial, cfg.Latencies())
+	} else {
+		subset = randomSubsetOfPeers(notConnected, numToDial)
+	}
 
-	log.Debugf("%s bootstrapping to %d nodes: %s", id, numToDial, randSubset)
-	return bootstrapConnect(ctx, host, randSubset)
+	log.Debugf("%s bootstrapping to %d nodes: %s", id, numToDial, subset)
+	return bootstrapConnect(ctx, host, subset)
 }
 
 func bootstrapConnect(ctx context.Context, ph host.Host, peers []peer.AddrInfo) error {
@@ -195,6 +206,24 @@ func bootstrapConnect(ctx context.Context, ph host.Host, peers []peer.AddrInfo)
 	return nil
 }
 
+// fastestSubsetOfPeers returns the max peers of in with the lowest latency,
+// the peers of unknown latency coming last in random order.
+func fastestSubsetOfPeers(in []peer.AddrInfo, max int, latencies map[peer.ID]time.Duration) []peer.AddrInfo {
+	out := randomSubsetOfPeers(in, len(in))
+	sort.SliceStable(out, func(i, j int) bool {
+		li, iok := latencies[out[i].ID]
+		lj, jok := latencies[out[j].ID]
+		if iok != jok {
+			return iok
+		}
+		return iok && li < lj
+	})
+	if max < len(out) {
+		out = out[:max]
+	}
+	return out
+}
+
 func randomSubsetOfPeers(in []peer.AddrInfo, max int) []peer.AddrInfo {
 	if max > len(in) {
 		max = len(in)
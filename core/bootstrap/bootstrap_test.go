@@ -2,6 +2,7 @@ package bootstrap
 
 import (
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/test"
@@ -23,3 +24,32 @@ func TestSubsetWhenMaxIsGreaterThanLengthOfSlice(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestFastestSubsetOfPeers(t *testing.T) {
+	var ps []peer.AddrInfo
+	for i := 0; i < 10; i++ {
+		pid, err := test.RandPeerID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ps = append(ps, peer.AddrInfo{ID: pid})
+	}
+	latencies := map[peer.ID]time.Duration{
+		ps[7].ID: 30 * time.Millisecond,
+		ps[2].ID: 10 * time.Millisecond,
+		ps[5].ID: 20 * time.Millisecond,
+	}
+	out := fastestSubsetOfPeers(ps, 2, latencies)
+	if len(out) != 2 || out[0].ID != ps[2].ID || out[1].ID != ps[5].ID {
+		t.Fatalf("expected the 2 fastest peers, got %v", out)
+	}
+	out = fastestSubsetOfPeers(ps, 5, latencies)
+	if len(out) != 5 || out[2].ID != ps[7].ID {
+		t.Fatalf("expected the measured peers first, got %v", out)
+	}
+	for _, p := range out[3:] {
+		if _, ok := latencies[p.ID]; ok {
+			t.Fatalf("measured peer %s after unmeasured ones", p.ID)
+		}
+	}
+}
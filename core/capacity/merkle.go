@@ -0,0 +1,76 @@
+package capacity
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// node hashes two sibling nodes of a Merkle tree.
+func node(l, r []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(l)
+	h.Write(r)
+	return h.Sum(nil)
+}
+
+// parents returns the level above level, the last node of an odd level
+// being paired with itself.
+func parents(level [][]byte) [][]byte {
+	up := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		r := level[i]
+		if i+1 < len(level) {
+			r = level[i+1]
+		}
+		up = append(up, node(level[i], r))
+	}
+	return up
+}
+
+func merkleRoot(leaves [][]byte) []byte {
+	level := leaves
+	for len(level) > 1 {
+		level = parents(level)
+	}
+	return level[0]
+}
+
+// merkleProof returns the siblings of the leaf of index i, from the leaves
+// up.
+func merkleProof(leaves [][]byte, i int64) [][]byte {
+	var proof [][]byte
+	level := leaves
+	for len(level) > 1 {
+		sibling := i ^ 1
+		if sibling >= int64(len(level)) {
+			sibling = i
+		}
+		proof = append(proof, level[sibling])
+		level = parents(level)
+		i /= 2
+	}
+	return proof
+}
+
+// verifyProof checks that proof proves leaf is the leaf of index i of the
+// n leaves of the tree of root.
+func verifyProof(leaf []byte, i, n int64, proof [][]byte, root []byte) bool {
+	h := leaf
+	for _, sibling := range proof {
+		if n <= 1 {
+			return false
+		}
+		if i%2 == 0 {
+			if i+1 == n && !bytes.Equal(sibling, h) {
+				return false
+			}
+			h = node(h, sibling)
+		} else {
+			h = node(sibling, h)
+		}
+		i /= 2
+		n = (n + 1) / 2
+	}
+	return n == 1 && bytes.Equal(h, root)
+}
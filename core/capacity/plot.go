@@ -0,0 +1,190 @@
+// Package capacity lets a host prove the free capacity it advertises. The
+// host plots a temporary file of the size to prove from a seed chosen by
+// the verifier, then answers for random blocks of the plot.
+//
+// Each block of the plot is the previous block XORed with a stream derived
+// from the seed and the index of the block, so that a host which did not
+// keep the plot has to regenerate the chain of blocks up to the one asked,
+// which it cannot do within the deadline of the proof. The blocks are
+// committed to by the Merkle root of their hashes, returned with the plot:
+// an opening of a block holds the previous block with the Merkle proofs of
+// both, which the verifier checks knowing only the seed and the root.
+package capacity
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// BlockSize is the size of the blocks of a plot.
+	BlockSize = 256 * 1024
+
+	dataFile   = "data"
+	leavesFile = "leaves"
+)
+
+// Blocks returns the number of blocks of a plot of size bytes.
+func Blocks(size int64) int64 {
+	return size / BlockSize
+}
+
+// nextBlock sets block to the block of index i of the plot of seed, block
+// being the previous block, zeros for the first.
+func nextBlock(seed []byte, i int64, block []byte) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(i))
+	key := sha256.Sum256(append(append([]byte{}, seed...), b[:]...))
+	var in [sha256.Size + 8]byte
+	copy(in[:], key[:])
+	for j := 0; j < BlockSize/sha256.Size; j++ {
+		binary.BigEndian.PutUint64(in[sha256.Size:], uint64(j))
+		s := sha256.Sum256(in[:])
+		chunk := block[j*sha256.Size : (j+1)*sha256.Size]
+		for k := range chunk {
+			chunk[k] ^= s[k]
+		}
+	}
+}
+
+func leaf(block []byte) []byte {
+	h := sha256.Sum256(block)
+	return h[:]
+}
+
+// Plot writes the plot of size bytes of seed in dir and returns its Merkle
+// root. progress is called with the number of blocks written.
+func Plot(ctx context.Context, dir string, seed []byte, size int64, progress func(int64)) ([]byte, error) {
+	n := Blocks(size)
+	if n == 0 {
+		return nil, fmt.Errorf("plot size must be at least %d bytes", BlockSize)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filepath.Join(dir, dataFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	w := bufio.NewWriterSize(f, BlockSize)
+	leaves := make([][]byte, n)
+	block := make([]byte, BlockSize)
+	for i := int64(0); i < n; i++ {
+		if i%64 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if progress != nil {
+				progress(i)
+			}
+		}
+		nextBlock(seed, i, block)
+		if _, err := w.Write(block); err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf(block)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		progress(n)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, leavesFile), bytes.Join(leaves, nil), 0600); err != nil {
+		return nil, err
+	}
+	return merkleRoot(leaves), nil
+}
+
+// Opening proves the block of index Index of a plot.
+type Opening struct {
+	Index int64
+	// Prev is the previous block, nil for the first.
+	Prev []byte `json:",omitempty"`
+	// PrevProof is the Merkle proof of Prev.
+	PrevProof [][]byte `json:",omitempty"`
+	// Leaf is the hash of the block, LeafProof its Merkle proof.
+	Leaf      []byte
+	LeafProof [][]byte
+}
+
+// Prove opens the blocks of index indices of the plot in dir.
+func Prove(dir string, indices []int64) ([]*Opening, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, leavesFile))
+	if err != nil {
+		return nil, err
+	}
+	if len(b)%sha256.Size != 0 {
+		return nil, errors.New("corrupted plot")
+	}
+	leaves := make([][]byte, len(b)/sha256.Size)
+	for i := range leaves {
+		leaves[i] = b[i*sha256.Size : (i+1)*sha256.Size]
+	}
+	f, err := os.Open(filepath.Join(dir, dataFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	n := int64(len(leaves))
+	var opens []*Opening
+	for _, i := range indices {
+		if i < 0 || i >= n {
+			return nil, fmt.Errorf("block %d out of the %d blocks of the plot", i, n)
+		}
+		o := &Opening{Index: i, Leaf: leaves[i], LeafProof: merkleProof(leaves, i)}
+		if i > 0 {
+			o.Prev = make([]byte, BlockSize)
+			if _, err := f.ReadAt(o.Prev, (i-1)*BlockSize); err != nil && err != io.EOF {
+				return nil, err
+			}
+			o.PrevProof = merkleProof(leaves, i-1)
+		}
+		opens = append(opens, o)
+	}
+	return opens, nil
+}
+
+// Verify checks that o opens a block of the plot of seed in blocks blocks
+// with the Merkle root root.
+func Verify(seed []byte, blocks int64, root []byte, o *Opening) error {
+	if o.Index < 0 || o.Index >= blocks {
+		return fmt.Errorf("block %d out of the %d blocks of the plot", o.Index, blocks)
+	}
+	block := make([]byte, BlockSize)
+	if o.Index > 0 {
+		if len(o.Prev) != BlockSize {
+			return fmt.Errorf("block %d: invalid previous block", o.Index)
+		}
+		if !verifyProof(leaf(o.Prev), o.Index-1, blocks, o.PrevProof, root) {
+			return fmt.Errorf("block %d: invalid proof of the previous block", o.Index)
+		}
+		copy(block, o.Prev)
+	}
+	nextBlock(seed, o.Index, block)
+	if !bytes.Equal(leaf(block), o.Leaf) {
+		return fmt.Errorf("block %d does not match the seed", o.Index)
+	}
+	if !verifyProof(o.Leaf, o.Index, blocks, o.LeafProof, root) {
+		return fmt.Errorf("block %d: invalid proof", o.Index)
+	}
+	return nil
+}
+
+// Remove deletes the plot in dir.
+func Remove(dir string) error {
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,68 @@
+package capacity
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestPlotProveVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capacity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	seed := []byte("seed")
+	const blocks = 11
+	root, err := Plot(context.Background(), dir, seed, blocks*BlockSize+1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indices := []int64{0, 1, 5, 9, 10}
+	openings, err := Prove(dir, indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range openings {
+		if err := Verify(seed, blocks, root, o); err != nil {
+			t.Fatalf("block %d: %s", o.Index, err)
+		}
+	}
+	if err := Verify([]byte("other seed"), blocks, root, openings[2]); err == nil {
+		t.Fatal("opening verified with another seed")
+	}
+	tampered := *openings[2]
+	tampered.Prev = append([]byte{}, tampered.Prev...)
+	tampered.Prev[0] ^= 1
+	if err := Verify(seed, blocks, root, &tampered); err == nil {
+		t.Fatal("tampered opening verified")
+	}
+	moved := *openings[2]
+	moved.Index = 6
+	if err := Verify(seed, blocks, root, &moved); err == nil {
+		t.Fatal("opening verified for another block")
+	}
+	if _, err := Prove(dir, []int64{blocks}); err == nil {
+		t.Fatal("proved a block out of the plot")
+	}
+}
+
+func TestMerkle(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		leaves := make([][]byte, n)
+		for i := range leaves {
+			leaves[i] = leaf([]byte{byte(i)})
+		}
+		root := merkleRoot(leaves)
+		for i := range leaves {
+			proof := merkleProof(leaves, int64(i))
+			if !verifyProof(leaves[i], int64(i), int64(n), proof, root) {
+				t.Fatalf("proof of leaf %d of %d not verified", i, n)
+			}
+			if n > 1 && verifyProof(leaves[(i+1)%n], int64(i), int64(n), proof, root) {
+				t.Fatalf("proof of leaf %d of %d verified for another leaf", i, n)
+			}
+		}
+	}
+}
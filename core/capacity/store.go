@@ -0,0 +1,193 @@
+package capacity
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/dustin/go-humanize"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// ConfigKey is the config section of the capacity proofs.
+const ConfigKey = "CapacityProof"
+
+// Defaults of the capacity proofs.
+const (
+	DefaultMaxPlot  = 16 << 30
+	DefaultSize     = 1 << 30
+	DefaultSamples  = 8
+	DefaultDeadline = 10 * time.Second
+	// FailureTTL is how long the hosts failing a proof are skipped by the
+	// uploads.
+	FailureTTL = 7 * 24 * time.Hour
+
+	resultKeyPrefix = "/btfs/%s/capacity/hosts/"
+)
+
+// Config configures the capacity proofs, of a host and of a renter.
+type Config struct {
+	// MaxPlot is the largest plot this host writes to prove its capacity,
+	// e.g. "16GB".
+	MaxPlot string `json:",omitempty"`
+	// Size is the capacity a renter has the hosts prove, e.g. "1GB".
+	Size string `json:",omitempty"`
+	// Samples is the number of blocks a renter checks of a plot.
+	Samples int `json:",omitempty"`
+	// Deadline is the time a host has to answer for the blocks, e.g. "10s".
+	Deadline string `json:",omitempty"`
+
+	maxPlot  int64
+	size     int64
+	deadline time.Duration
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the capacity proofs config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func parseSize(s string, def int64) (int64, error) {
+	if s == "" {
+		return def, nil
+	}
+	size, err := humanize.ParseBytes(s)
+	if err != nil || size < BlockSize {
+		return 0, fmt.Errorf("invalid size %q, must be at least %s", s, humanize.IBytes(BlockSize))
+	}
+	return int64(size), nil
+}
+
+func (c *Config) compile() error {
+	var err error
+	if c.maxPlot, err = parseSize(c.MaxPlot, DefaultMaxPlot); err != nil {
+		return err
+	}
+	if c.size, err = parseSize(c.Size, DefaultSize); err != nil {
+		return err
+	}
+	if c.Samples < 0 {
+		return fmt.Errorf("negative Samples")
+	}
+	if c.Samples == 0 {
+		c.Samples = DefaultSamples
+	}
+	c.deadline = DefaultDeadline
+	if c.Deadline != "" {
+		if c.deadline, err = time.ParseDuration(c.Deadline); err != nil || c.deadline <= 0 {
+			return fmt.Errorf("invalid deadline %q", c.Deadline)
+		}
+	}
+	return nil
+}
+
+// MaxPlotSize returns the largest plot this host writes.
+func (c *Config) MaxPlotSize() int64 {
+	return c.maxPlot
+}
+
+// ProofSize returns the capacity a renter has the hosts prove.
+func (c *Config) ProofSize() int64 {
+	return c.size
+}
+
+// ProofDeadline returns the time a host has to answer for the blocks.
+func (c *Config) ProofDeadline() time.Duration {
+	return c.deadline
+}
+
+// Result is the outcome of the last capacity proof of a host.
+type Result struct {
+	Host   string
+	Time   time.Time
+	Size   int64
+	Passed bool
+	Error  string `json:",omitempty"`
+	// PlotTime is the time the host took to plot, ProveTime the time it took
+	// to answer for the blocks.
+	PlotTime  time.Duration `json:",omitempty"`
+	ProveTime time.Duration `json:",omitempty"`
+}
+
+func resultKey(peerID, hostID string) ds.Key {
+	return ds.NewKey(fmt.Sprintf(resultKeyPrefix, peerID) + hostID)
+}
+
+// PutResult records r as the last capacity proof of its host verified by
+// the node peerID.
+func PutResult(d ds.Datastore, peerID string, r *Result) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return d.Put(resultKey(peerID, r.Host), b)
+}
+
+// GetResult returns the last capacity proof of the host hostID verified by
+// the node peerID, nil when there is none.
+func GetResult(d ds.Datastore, peerID, hostID string) (*Result, error) {
+	b, err := d.Get(resultKey(peerID, hostID))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &Result{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("invalid capacity proof of %s: %s", hostID, err)
+	}
+	return r, nil
+}
+
+// Results returns the last capacity proofs verified by the node peerID,
+// sorted by host.
+func Results(d ds.Datastore, peerID string) ([]*Result, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(resultKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var rs []*Result
+	for e := range results.Next() {
+		if e.Error != nil {
+			return nil, e.Error
+		}
+		r := &Result{}
+		if err := json.Unmarshal(e.Value, r); err != nil {
+			return nil, fmt.Errorf("invalid capacity proof %s: %s", e.Key, err)
+		}
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Host < rs[j].Host
+	})
+	return rs, nil
+}
+
+// Failed reports whether the host hostID failed its last capacity proof
+// verified by the node peerID less than FailureTTL before now.
+func Failed(d ds.Datastore, peerID, hostID string, now time.Time) bool {
+	r, err := GetResult(d, peerID, hostID)
+	if err != nil || r == nil {
+		return false
+	}
+	return !r.Passed && now.Sub(r.Time) < FailureTTL
+}
@@ -0,0 +1,35 @@
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+)
+
+func TestFailed(t *testing.T) {
+	d := ds.NewMapDatastore()
+	now := time.Now()
+	if Failed(d, "self", "host", now) {
+		t.Fatal("host never verified failed")
+	}
+	if err := PutResult(d, "self", &Result{Host: "host", Time: now, Error: "too slow"}); err != nil {
+		t.Fatal(err)
+	}
+	if !Failed(d, "self", "host", now.Add(time.Hour)) {
+		t.Fatal("host failing its proof not failed")
+	}
+	if Failed(d, "self", "host", now.Add(FailureTTL)) {
+		t.Fatal("host failed past the failure TTL")
+	}
+	if err := PutResult(d, "self", &Result{Host: "host", Time: now, Passed: true}); err != nil {
+		t.Fatal(err)
+	}
+	if Failed(d, "self", "host", now) {
+		t.Fatal("host passing its proof failed")
+	}
+	rs, err := Results(d, "self")
+	if err != nil || len(rs) != 1 {
+		t.Fatalf("got results %v, %v", rs, err)
+	}
+}
@@ -0,0 +1,149 @@
+// Package escrow is the client of the escrow service, which holds the
+// payments of renters to hosts and the ledger channels of the wallets.
+//
+// The node only depends on the Client interface, programs embedding renter
+// or host flows can pass a Mock instead of a connection to the service.
+package escrow
+
+import (
+	"context"
+	"time"
+
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+	cgrpc "github.com/tron-us/go-btfs-common/utils/grpc"
+)
+
+// Client is the part of the escrow service used by renters, hosts and the
+// wallet.
+//
+// Errors returned by the service are returned as is, so that their gRPC
+// status can be inspected.
+type Client interface {
+	// SubmitContracts submits the contracts of an upload, once agreed with the hosts.
+	SubmitContracts(ctx context.Context, in *escrowpb.EscrowContractRequest) (*escrowpb.SignedSubmitContractResult, error)
+	// PayIn pays the amount of the submitted contracts to the escrow wallet.
+	PayIn(ctx context.Context, in *escrowpb.SignedPayinRequest) (*escrowpb.SignedPayinResult, error)
+	// IsPaid reports whether a contract is paid in.
+	IsPaid(ctx context.Context, in *escrowpb.SignedContractID) (*escrowpb.SignedPayinStatus, error)
+	// GetPayOutStatusBatch returns the payout status of contracts.
+	GetPayOutStatusBatch(ctx context.Context, in *escrowpb.SignedContractIDBatch) (*escrowpb.SignedPayoutStatusBatch, error)
+	// GetModifyPayOutStatusBatch returns the payout status of the contracts modified since a time.
+	GetModifyPayOutStatusBatch(ctx context.Context, in *escrowpb.SignedModifyContractIDBatch) (*escrowpb.SignedPayoutStatusBatch, error)
+	// CancelContracts cancels the payments left of a contract, returning
+	// them to the buyer.
+	CancelContracts(ctx context.Context, in *escrowpb.SignedCancelRequest) (*escrowpb.SignedCancelContractResult, error)
+	// BalanceOf returns the balance of an account, creating it if it does not exist.
+	BalanceOf(ctx context.Context, in *ledgerpb.SignedCreateAccountRequest) (*escrowpb.SignedBalanceResult, error)
+	// CreateChannel creates a channel on the ledger.
+	CreateChannel(ctx context.Context, in *ledgerpb.SignedChannelCommit) (*ledgerpb.ChannelID, error)
+	// CloseChannel closes a channel on the ledger.
+	CloseChannel(ctx context.Context, in *ledgerpb.SignedChannelState) (*ledgerpb.ChannelClosed, error)
+}
+
+type client struct {
+	addr    string
+	timeout time.Duration
+}
+
+// New returns a Client of the escrow service at addr. Every call opens a
+// (short) connection, sends its request and closes it.
+func New(addr string) Client {
+	return &client{addr: addr}
+}
+
+// NewWithTimeout is like New, with timeout bounding the time to connect to
+// the service.
+func NewWithTimeout(addr string, timeout time.Duration) Client {
+	return &client{addr: addr, timeout: timeout}
+}
+
+// call runs f on a connection to the service. The error of f is returned
+// as is, other errors are those of the connection.
+func (c *client) call(ctx context.Context, f func(context.Context, escrowpb.EscrowServiceClient) error) error {
+	cb := cgrpc.EscrowClient(c.addr)
+	if c.timeout > 0 {
+		cb.Timeout(c.timeout)
+	}
+	var callErr error
+	err := cb.WithContext(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		callErr = f(ctx, client)
+		return callErr
+	})
+	if callErr != nil {
+		return callErr
+	}
+	return err
+}
+
+func (c *client) SubmitContracts(ctx context.Context, in *escrowpb.EscrowContractRequest) (out *escrowpb.SignedSubmitContractResult, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.SubmitContracts(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) PayIn(ctx context.Context, in *escrowpb.SignedPayinRequest) (out *escrowpb.SignedPayinResult, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.PayIn(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) IsPaid(ctx context.Context, in *escrowpb.SignedContractID) (out *escrowpb.SignedPayinStatus, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.IsPaid(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) GetPayOutStatusBatch(ctx context.Context, in *escrowpb.SignedContractIDBatch) (out *escrowpb.SignedPayoutStatusBatch, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.GetPayOutStatusBatch(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) GetModifyPayOutStatusBatch(ctx context.Context, in *escrowpb.SignedModifyContractIDBatch) (out *escrowpb.SignedPayoutStatusBatch, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.GetModifyPayOutStatusBatch(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) CancelContracts(ctx context.Context, in *escrowpb.SignedCancelRequest) (out *escrowpb.SignedCancelContractResult, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.CancelContracts(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) BalanceOf(ctx context.Context, in *ledgerpb.SignedCreateAccountRequest) (out *escrowpb.SignedBalanceResult, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.BalanceOf(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) CreateChannel(ctx context.Context, in *ledgerpb.SignedChannelCommit) (out *ledgerpb.ChannelID, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.CreateChannel(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) CloseChannel(ctx context.Context, in *ledgerpb.SignedChannelState) (out *ledgerpb.ChannelClosed, err error) {
+	err = c.call(ctx, func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
+		out, err = client.CloseChannel(ctx, in)
+		return err
+	})
+	return out, err
+}
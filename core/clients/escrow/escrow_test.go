@@ -0,0 +1,84 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testServer struct {
+	escrowpb.UnimplementedEscrowServiceServer
+}
+
+func (s *testServer) BalanceOf(ctx context.Context, in *ledgerpb.SignedCreateAccountRequest) (*escrowpb.SignedBalanceResult, error) {
+	return &escrowpb.SignedBalanceResult{Result: &escrowpb.BalanceResult{Balance: 42}}, nil
+}
+
+func TestClient(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	escrowpb.RegisterEscrowServiceServer(s, &testServer{})
+	go s.Serve(l)
+	defer s.Stop()
+
+	ctx := context.Background()
+	c := New("http://" + l.Addr().String())
+	res, err := c.BalanceOf(ctx, &ledgerpb.SignedCreateAccountRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Result.Balance != 42 {
+		t.Errorf("got balance %d", res.Result.Balance)
+	}
+	if _, err := c.PayIn(ctx, &escrowpb.SignedPayinRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestClientUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := New("http://"+addr).IsPaid(ctx, &escrowpb.SignedContractID{}); err == nil {
+		t.Error("no error from an unreachable service")
+	}
+}
+
+func TestMock(t *testing.T) {
+	insufficient := errors.New("insufficient balance")
+	var c Client = &Mock{
+		CreateChannelFunc: func(ctx context.Context, in *ledgerpb.SignedChannelCommit) (*ledgerpb.ChannelID, error) {
+			if in.Channel.Amount > 10 {
+				return nil, insufficient
+			}
+			return &ledgerpb.ChannelID{Id: 1}, nil
+		},
+	}
+	ctx := context.Background()
+	id, err := c.CreateChannel(ctx, &ledgerpb.SignedChannelCommit{Channel: &ledgerpb.ChannelCommit{Amount: 5}})
+	if err != nil || id.Id != 1 {
+		t.Errorf("got %v, %v", id, err)
+	}
+	if _, err := c.CreateChannel(ctx, &ledgerpb.SignedChannelCommit{Channel: &ledgerpb.ChannelCommit{Amount: 50}}); err != insufficient {
+		t.Errorf("got %v", err)
+	}
+	if _, err := c.CloseChannel(ctx, &ledgerpb.SignedChannelState{}); err != ErrNotMocked {
+		t.Errorf("got %v", err)
+	}
+}
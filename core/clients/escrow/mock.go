@@ -0,0 +1,91 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+)
+
+// ErrNotMocked is returned by the methods of a Mock without a function.
+var ErrNotMocked = errors.New("escrow: method not mocked")
+
+// Mock is a Client calling its functions, a method without a function
+// fails with ErrNotMocked.
+type Mock struct {
+	SubmitContractsFunc            func(ctx context.Context, in *escrowpb.EscrowContractRequest) (*escrowpb.SignedSubmitContractResult, error)
+	PayInFunc                      func(ctx context.Context, in *escrowpb.SignedPayinRequest) (*escrowpb.SignedPayinResult, error)
+	IsPaidFunc                     func(ctx context.Context, in *escrowpb.SignedContractID) (*escrowpb.SignedPayinStatus, error)
+	GetPayOutStatusBatchFunc       func(ctx context.Context, in *escrowpb.SignedContractIDBatch) (*escrowpb.SignedPayoutStatusBatch, error)
+	GetModifyPayOutStatusBatchFunc func(ctx context.Context, in *escrowpb.SignedModifyContractIDBatch) (*escrowpb.SignedPayoutStatusBatch, error)
+	CancelContractsFunc            func(ctx context.Context, in *escrowpb.SignedCancelRequest) (*escrowpb.SignedCancelContractResult, error)
+	BalanceOfFunc                  func(ctx context.Context, in *ledgerpb.SignedCreateAccountRequest) (*escrowpb.SignedBalanceResult, error)
+	CreateChannelFunc              func(ctx context.Context, in *ledgerpb.SignedChannelCommit) (*ledgerpb.ChannelID, error)
+	CloseChannelFunc               func(ctx context.Context, in *ledgerpb.SignedChannelState) (*ledgerpb.ChannelClosed, error)
+}
+
+var _ Client = (*Mock)(nil)
+
+func (m *Mock) SubmitContracts(ctx context.Context, in *escrowpb.EscrowContractRequest) (*escrowpb.SignedSubmitContractResult, error) {
+	if m.SubmitContractsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.SubmitContractsFunc(ctx, in)
+}
+
+func (m *Mock) PayIn(ctx context.Context, in *escrowpb.SignedPayinRequest) (*escrowpb.SignedPayinResult, error) {
+	if m.PayInFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.PayInFunc(ctx, in)
+}
+
+func (m *Mock) IsPaid(ctx context.Context, in *escrowpb.SignedContractID) (*escrowpb.SignedPayinStatus, error) {
+	if m.IsPaidFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.IsPaidFunc(ctx, in)
+}
+
+func (m *Mock) GetPayOutStatusBatch(ctx context.Context, in *escrowpb.SignedContractIDBatch) (*escrowpb.SignedPayoutStatusBatch, error) {
+	if m.GetPayOutStatusBatchFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetPayOutStatusBatchFunc(ctx, in)
+}
+
+func (m *Mock) GetModifyPayOutStatusBatch(ctx context.Context, in *escrowpb.SignedModifyContractIDBatch) (*escrowpb.SignedPayoutStatusBatch, error) {
+	if m.GetModifyPayOutStatusBatchFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetModifyPayOutStatusBatchFunc(ctx, in)
+}
+
+func (m *Mock) CancelContracts(ctx context.Context, in *escrowpb.SignedCancelRequest) (*escrowpb.SignedCancelContractResult, error) {
+	if m.CancelContractsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.CancelContractsFunc(ctx, in)
+}
+
+func (m *Mock) BalanceOf(ctx context.Context, in *ledgerpb.SignedCreateAccountRequest) (*escrowpb.SignedBalanceResult, error) {
+	if m.BalanceOfFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.BalanceOfFunc(ctx, in)
+}
+
+func (m *Mock) CreateChannel(ctx context.Context, in *ledgerpb.SignedChannelCommit) (*ledgerpb.ChannelID, error) {
+	if m.CreateChannelFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.CreateChannelFunc(ctx, in)
+}
+
+func (m *Mock) CloseChannel(ctx context.Context, in *ledgerpb.SignedChannelState) (*ledgerpb.ChannelClosed, error) {
+	if m.CloseChannelFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.CloseChannelFunc(ctx, in)
+}
@@ -0,0 +1,127 @@
+// Package guard is the client of the guard service, which keeps the file
+// store metas and contracts of renters and hosts and challenges the hosts.
+//
+// The node only depends on the Client interface, programs embedding renter
+// or host flows can pass a Mock instead of a connection to the service.
+package guard
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/protover"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	cgrpc "github.com/tron-us/go-btfs-common/utils/grpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client is the part of the guard service used by renters and hosts.
+//
+// Errors returned by the service are returned as is, so that their gRPC
+// status can be inspected.
+type Client interface {
+	// SubmitFileStoreMeta submits the contracts of an uploaded file.
+	SubmitFileStoreMeta(ctx context.Context, in *guardpb.FileStoreStatus) (*guardpb.Result, error)
+	// SendQuestions sends the challenge questions of the shards of a file.
+	SendQuestions(ctx context.Context, in *guardpb.FileChallengeQuestions) (*guardpb.Result, error)
+	// CheckFileStoreMeta returns the file store meta of a file.
+	CheckFileStoreMeta(ctx context.Context, in *guardpb.CheckFileStoreMetaRequest) (*guardpb.FileStoreStatus, error)
+	// ListHostContracts returns a page of the contracts of a host.
+	ListHostContracts(ctx context.Context, in *guardpb.ListHostContractsRequest) (*guardpb.ContractsList, error)
+	// RequestChallenge asks for the challenge of a shard a host is ready to
+	// store.
+	RequestChallenge(ctx context.Context, in *guardpb.ReadyForChallengeRequest) (*guardpb.RequestChallengeQuestion, error)
+	// ResponseChallenge answers a challenge.
+	ResponseChallenge(ctx context.Context, in *guardpb.ResponseChallengeQuestion) (*guardpb.Result, error)
+}
+
+type client struct {
+	addr    string
+	timeout time.Duration
+}
+
+// New returns a Client of the guard service at addr. Every call opens a
+// (short) connection, sends its request and closes it.
+func New(addr string) Client {
+	return &client{addr: addr}
+}
+
+// NewWithTimeout is like New, with timeout bounding the time to connect to
+// the service.
+func NewWithTimeout(addr string, timeout time.Duration) Client {
+	return &client{addr: addr, timeout: timeout}
+}
+
+// call runs f on a connection to the service, announcing the protocol
+// versions of the node. The error of f is returned as is, unless the service
+// supports none of the versions, other errors are those of the connection.
+func (c *client) call(ctx context.Context, f func(context.Context, guardpb.GuardServiceClient, ...grpc.CallOption) error) error {
+	cb := cgrpc.GuardClient(c.addr)
+	if c.timeout > 0 {
+		cb.Timeout(c.timeout)
+	}
+	var header metadata.MD
+	var callErr error
+	err := cb.WithContext(protover.OutgoingContext(ctx, protover.HostGuard), func(ctx context.Context, client guardpb.GuardServiceClient) error {
+		callErr = f(ctx, client, grpc.Header(&header))
+		return callErr
+	})
+	if err := protover.CheckHeader(protover.HostGuard, header); err != nil {
+		return err
+	}
+	if callErr != nil {
+		return callErr
+	}
+	return err
+}
+
+func (c *client) SubmitFileStoreMeta(ctx context.Context, in *guardpb.FileStoreStatus) (out *guardpb.Result, err error) {
+	err = c.call(ctx, func(ctx context.Context, client guardpb.GuardServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.SubmitFileStoreMeta(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) SendQuestions(ctx context.Context, in *guardpb.FileChallengeQuestions) (out *guardpb.Result, err error) {
+	err = c.call(ctx, func(ctx context.Context, client guardpb.GuardServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.SendQuestions(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) CheckFileStoreMeta(ctx context.Context, in *guardpb.CheckFileStoreMetaRequest) (out *guardpb.FileStoreStatus, err error) {
+	err = c.call(ctx, func(ctx context.Context, client guardpb.GuardServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.CheckFileStoreMeta(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) ListHostContracts(ctx context.Context, in *guardpb.ListHostContractsRequest) (out *guardpb.ContractsList, err error) {
+	err = c.call(ctx, func(ctx context.Context, client guardpb.GuardServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.ListHostContracts(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) RequestChallenge(ctx context.Context, in *guardpb.ReadyForChallengeRequest) (out *guardpb.RequestChallengeQuestion, err error) {
+	err = c.call(ctx, func(ctx context.Context, client guardpb.GuardServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.RequestChallenge(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) ResponseChallenge(ctx context.Context, in *guardpb.ResponseChallengeQuestion) (out *guardpb.Result, err error) {
+	err = c.call(ctx, func(ctx context.Context, client guardpb.GuardServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.ResponseChallenge(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
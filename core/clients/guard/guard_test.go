@@ -0,0 +1,109 @@
+package guard
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/TRON-US/go-btfs/core/protover"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testServer struct {
+	guardpb.UnimplementedGuardServiceServer
+	// announce are the protocol versions announced by the server.
+	announce string
+	// announced are the protocol versions announced by the client.
+	announced string
+}
+
+func (s *testServer) CheckFileStoreMeta(ctx context.Context, in *guardpb.CheckFileStoreMetaRequest) (*guardpb.FileStoreStatus, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(protover.MetadataKey)) > 0 {
+		s.announced = md.Get(protover.MetadataKey)[0]
+	}
+	if s.announce != "" {
+		grpc.SetHeader(ctx, metadata.Pairs(protover.MetadataKey, s.announce))
+	}
+	if in.FileHash == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing file hash")
+	}
+	return &guardpb.FileStoreStatus{FileStoreMeta: guardpb.FileStoreMeta{FileHash: in.FileHash}}, nil
+}
+
+func serve(t *testing.T, ts *testServer) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	guardpb.RegisterGuardServiceServer(s, ts)
+	go s.Serve(l)
+	return "http://" + l.Addr().String(), s.Stop
+}
+
+func TestClient(t *testing.T) {
+	addr, stop := serve(t, &testServer{})
+	defer stop()
+
+	ctx := context.Background()
+	c := New(addr)
+	meta, err := c.CheckFileStoreMeta(ctx, &guardpb.CheckFileStoreMetaRequest{FileHash: "Qm1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.FileHash != "Qm1" {
+		t.Errorf("got file hash %q", meta.FileHash)
+	}
+	// Errors of the service keep their status.
+	_, err = c.CheckFileStoreMeta(ctx, &guardpb.CheckFileStoreMetaRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v", err)
+	}
+	_, err = c.SendQuestions(ctx, &guardpb.FileChallengeQuestions{})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestClientProtocolVersion(t *testing.T) {
+	ts := &testServer{announce: "1-2"}
+	addr, stop := serve(t, ts)
+	defer stop()
+
+	ctx := context.Background()
+	c := New(addr)
+	if _, err := c.CheckFileStoreMeta(ctx, &guardpb.CheckFileStoreMetaRequest{FileHash: "Qm1"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := protover.Supported[protover.HostGuard].String(); ts.announced != want {
+		t.Errorf("announced %q, want %q", ts.announced, want)
+	}
+
+	// A guard supporting none of the versions fails the calls with an
+	// ErrIncompatible, whatever its answer.
+	ts.announce = "9-10"
+	_, err := c.CheckFileStoreMeta(ctx, &guardpb.CheckFileStoreMetaRequest{})
+	if _, ok := err.(*protover.ErrIncompatible); !ok {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestMock(t *testing.T) {
+	var c Client = &Mock{
+		ListHostContractsFunc: func(ctx context.Context, in *guardpb.ListHostContractsRequest) (*guardpb.ContractsList, error) {
+			return &guardpb.ContractsList{Count: in.RequestPageSize}, nil
+		},
+	}
+	res, err := c.ListHostContracts(context.Background(), &guardpb.ListHostContractsRequest{RequestPageSize: 10})
+	if err != nil || res.Count != 10 {
+		t.Errorf("got %v, %v", res, err)
+	}
+	if _, err := c.SendQuestions(context.Background(), nil); err != ErrNotMocked {
+		t.Errorf("got %v", err)
+	}
+}
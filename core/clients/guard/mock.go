@@ -0,0 +1,66 @@
+package guard
+
+import (
+	"context"
+	"errors"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+)
+
+// ErrNotMocked is returned by the methods of a Mock without a function.
+var ErrNotMocked = errors.New("guard: method not mocked")
+
+// Mock is a Client calling its functions, a method without a function
+// fails with ErrNotMocked.
+type Mock struct {
+	SubmitFileStoreMetaFunc func(ctx context.Context, in *guardpb.FileStoreStatus) (*guardpb.Result, error)
+	SendQuestionsFunc       func(ctx context.Context, in *guardpb.FileChallengeQuestions) (*guardpb.Result, error)
+	CheckFileStoreMetaFunc  func(ctx context.Context, in *guardpb.CheckFileStoreMetaRequest) (*guardpb.FileStoreStatus, error)
+	ListHostContractsFunc   func(ctx context.Context, in *guardpb.ListHostContractsRequest) (*guardpb.ContractsList, error)
+	RequestChallengeFunc    func(ctx context.Context, in *guardpb.ReadyForChallengeRequest) (*guardpb.RequestChallengeQuestion, error)
+	ResponseChallengeFunc   func(ctx context.Context, in *guardpb.ResponseChallengeQuestion) (*guardpb.Result, error)
+}
+
+var _ Client = (*Mock)(nil)
+
+func (m *Mock) SubmitFileStoreMeta(ctx context.Context, in *guardpb.FileStoreStatus) (*guardpb.Result, error) {
+	if m.SubmitFileStoreMetaFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.SubmitFileStoreMetaFunc(ctx, in)
+}
+
+func (m *Mock) SendQuestions(ctx context.Context, in *guardpb.FileChallengeQuestions) (*guardpb.Result, error) {
+	if m.SendQuestionsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.SendQuestionsFunc(ctx, in)
+}
+
+func (m *Mock) CheckFileStoreMeta(ctx context.Context, in *guardpb.CheckFileStoreMetaRequest) (*guardpb.FileStoreStatus, error) {
+	if m.CheckFileStoreMetaFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.CheckFileStoreMetaFunc(ctx, in)
+}
+
+func (m *Mock) ListHostContracts(ctx context.Context, in *guardpb.ListHostContractsRequest) (*guardpb.ContractsList, error) {
+	if m.ListHostContractsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ListHostContractsFunc(ctx, in)
+}
+
+func (m *Mock) RequestChallenge(ctx context.Context, in *guardpb.ReadyForChallengeRequest) (*guardpb.RequestChallengeQuestion, error) {
+	if m.RequestChallengeFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.RequestChallengeFunc(ctx, in)
+}
+
+func (m *Mock) ResponseChallenge(ctx context.Context, in *guardpb.ResponseChallengeQuestion) (*guardpb.Result, error) {
+	if m.ResponseChallengeFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ResponseChallengeFunc(ctx, in)
+}
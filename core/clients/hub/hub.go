@@ -0,0 +1,119 @@
+// Package hub is the client of the hub service, which ranks the hosts of
+// the network and keeps their settings and stats.
+//
+// The node only depends on the Client interface, programs embedding renter
+// or host flows can pass a Mock instead of a connection to the service.
+package hub
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/protover"
+
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+	cgrpc "github.com/tron-us/go-btfs-common/utils/grpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client is the part of the hub query service used by renters and hosts.
+//
+// Errors returned by the service are returned as is, so that their gRPC
+// status can be inspected.
+type Client interface {
+	// GetSettings returns the default settings of a host.
+	GetSettings(ctx context.Context, in *hubpb.SettingsReq) (*hubpb.SettingsResp, error)
+	// GetHosts returns the hosts of a mode.
+	GetHosts(ctx context.Context, in *hubpb.HostsReq) (*hubpb.HostsResp, error)
+	// GetStats returns the storage stats of a host.
+	GetStats(ctx context.Context, in *hubpb.StatsReq) (*hubpb.StatsResp, error)
+}
+
+type client struct {
+	addr    string
+	timeout time.Duration
+}
+
+// New returns a Client of the hub service at addr. Every call opens a
+// (short) connection, sends its request and closes it.
+func New(addr string) Client {
+	return &client{addr: addr}
+}
+
+// NewWithTimeout is like New, with timeout bounding the time to connect to
+// the service.
+func NewWithTimeout(addr string, timeout time.Duration) Client {
+	return &client{addr: addr, timeout: timeout}
+}
+
+// call runs f on a connection to the service, announcing the protocol
+// versions of the node. The error of f is returned as is, unless the service
+// supports none of the versions, other errors are those of the connection.
+func (c *client) call(ctx context.Context, f func(context.Context, hubpb.HubQueryServiceClient, ...grpc.CallOption) error) error {
+	cb := cgrpc.HubQueryClient(c.addr)
+	if c.timeout > 0 {
+		cb.Timeout(c.timeout)
+	}
+	var header metadata.MD
+	var callErr error
+	err := cb.WithContext(protover.OutgoingContext(ctx, protover.NodeHub), func(ctx context.Context, client hubpb.HubQueryServiceClient) error {
+		callErr = f(ctx, client, grpc.Header(&header))
+		return callErr
+	})
+	if err := protover.CheckHeader(protover.NodeHub, header); err != nil {
+		return err
+	}
+	if callErr != nil {
+		return callErr
+	}
+	return err
+}
+
+func (c *client) GetSettings(ctx context.Context, in *hubpb.SettingsReq) (out *hubpb.SettingsResp, err error) {
+	err = c.call(ctx, func(ctx context.Context, client hubpb.HubQueryServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.GetSettings(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) GetHosts(ctx context.Context, in *hubpb.HostsReq) (out *hubpb.HostsResp, err error) {
+	err = c.call(ctx, func(ctx context.Context, client hubpb.HubQueryServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.GetHosts(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (c *client) GetStats(ctx context.Context, in *hubpb.StatsReq) (out *hubpb.StatsResp, err error) {
+	err = c.call(ctx, func(ctx context.Context, client hubpb.HubQueryServiceClient, opts ...grpc.CallOption) error {
+		out, err = client.GetStats(ctx, in, opts...)
+		return err
+	})
+	return out, err
+}
+
+// HostSettings returns the default settings of the host peerID.
+func HostSettings(ctx context.Context, c Client, peerID string) (*nodepb.Node_Settings, error) {
+	resp, err := c.GetSettings(ctx, &hubpb.SettingsReq{Id: peerID})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != hubpb.ResponseCode_SUCCESS {
+		return nil, errors.New(resp.Message)
+	}
+	ns := new(nodepb.Node_Settings)
+	ns.StoragePriceAsk = uint64(resp.SettingsData.StoragePriceAsk)
+	ns.StoragePriceDefault = ns.StoragePriceAsk
+	ns.CustomizedPricing = false
+	// XXX: These configs need to be controlled by a customized flag as well
+	ns.StorageTimeMin = uint64(resp.SettingsData.StorageTimeMin)
+	ns.BandwidthLimit = resp.SettingsData.BandwidthLimit
+	ns.BandwidthPriceAsk = uint64(resp.SettingsData.BandwidthPriceAsk)
+	ns.CollateralStake = uint64(resp.SettingsData.CollateralStake)
+	return ns, nil
+}
@@ -0,0 +1,65 @@
+package hub
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testServer struct {
+	hubpb.UnimplementedHubQueryServiceServer
+}
+
+func (s *testServer) GetSettings(ctx context.Context, in *hubpb.SettingsReq) (*hubpb.SettingsResp, error) {
+	return &hubpb.SettingsResp{
+		Code:         hubpb.ResponseCode_SUCCESS,
+		SettingsData: &hubpb.SettingsData{StoragePriceAsk: 250000, StorageTimeMin: 30},
+	}, nil
+}
+
+func TestClient(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	hubpb.RegisterHubQueryServiceServer(s, &testServer{})
+	go s.Serve(l)
+	defer s.Stop()
+
+	c := New("http://" + l.Addr().String())
+	ns, err := HostSettings(context.Background(), c, "QmHost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.StoragePriceAsk != 250000 || ns.StoragePriceDefault != 250000 || ns.StorageTimeMin != 30 {
+		t.Errorf("got %+v", ns)
+	}
+	if _, err := c.GetStats(context.Background(), &hubpb.StatsReq{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestHostSettings(t *testing.T) {
+	var got string
+	c := &Mock{
+		GetSettingsFunc: func(ctx context.Context, in *hubpb.SettingsReq) (*hubpb.SettingsResp, error) {
+			got = in.Id
+			return &hubpb.SettingsResp{Code: hubpb.ResponseCode_TIMEOUT_ERROR, Message: "unknown host"}, nil
+		},
+	}
+	if _, err := HostSettings(context.Background(), c, "QmHost"); err == nil || err.Error() != "unknown host" {
+		t.Errorf("got %v", err)
+	}
+	if got != "QmHost" {
+		t.Errorf("requested settings of %q", got)
+	}
+	if _, err := c.GetHosts(context.Background(), &hubpb.HostsReq{}); err != ErrNotMocked {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package hub
+
+import (
+	"context"
+	"errors"
+
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+)
+
+// ErrNotMocked is returned by the methods of a Mock without a function.
+var ErrNotMocked = errors.New("hub: method not mocked")
+
+// Mock is a Client calling its functions, a method without a function
+// fails with ErrNotMocked.
+type Mock struct {
+	GetSettingsFunc func(ctx context.Context, in *hubpb.SettingsReq) (*hubpb.SettingsResp, error)
+	GetHostsFunc    func(ctx context.Context, in *hubpb.HostsReq) (*hubpb.HostsResp, error)
+	GetStatsFunc    func(ctx context.Context, in *hubpb.StatsReq) (*hubpb.StatsResp, error)
+}
+
+var _ Client = (*Mock)(nil)
+
+func (m *Mock) GetSettings(ctx context.Context, in *hubpb.SettingsReq) (*hubpb.SettingsResp, error) {
+	if m.GetSettingsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetSettingsFunc(ctx, in)
+}
+
+func (m *Mock) GetHosts(ctx context.Context, in *hubpb.HostsReq) (*hubpb.HostsResp, error) {
+	if m.GetHostsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetHostsFunc(ctx, in)
+}
+
+func (m *Mock) GetStats(ctx context.Context, in *hubpb.StatsReq) (*hubpb.StatsResp, error) {
+	if m.GetStatsFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetStatsFunc(ctx, in)
+}
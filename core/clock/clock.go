@@ -0,0 +1,226 @@
+// Package clock detects the skew of the system clock against NTP servers.
+//
+// Guard and escrow reject the contracts whose timestamps are off their own
+// clocks, and hosts reject the renters whose timestamps are off theirs,
+// which shows as signature or window errors far from their cause. The
+// daemon checks the clock on start and periodically, and refuses to sign
+// contracts while it is off by more than the tolerance, pointing to
+// 'btfs diag clock'.
+package clock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+)
+
+// ConfigKey is the config section of the clock checks.
+const ConfigKey = "Clock"
+
+// Defaults of the clock checks.
+const (
+	// DefaultTolerance is the skew guard and escrow accept on the
+	// timestamps of the contracts.
+	DefaultTolerance = 30 * time.Second
+	// MaxAge is the age after which a check is renewed before signing.
+	MaxAge = 10 * time.Minute
+	// QueryTimeout bounds the query of a server.
+	QueryTimeout = 5 * time.Second
+)
+
+// DefaultServers are the NTP servers queried when none is configured.
+var DefaultServers = []string{"pool.ntp.org", "time.google.com", "time.cloudflare.com"}
+
+// ErrSkew is returned when the system clock is off by more than the
+// tolerance.
+var ErrSkew = errors.New("system clock skew")
+
+// Config configures the clock checks.
+type Config struct {
+	// Disabled turns the checks off, e.g. for nodes without NTP access.
+	Disabled bool `json:",omitempty"`
+	// Servers are the NTP servers queried, host or host:port.
+	Servers []string `json:",omitempty"`
+	// Tolerance is the skew accepted on the timestamps of the contracts
+	// and of the peers, e.g. "30s".
+	Tolerance string `json:",omitempty"`
+
+	tolerance time.Duration
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the clock config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	if len(c.Servers) == 0 {
+		c.Servers = DefaultServers
+	}
+	c.tolerance = DefaultTolerance
+	if c.Tolerance != "" {
+		t, err := time.ParseDuration(c.Tolerance)
+		if err != nil || t <= 0 {
+			return fmt.Errorf("invalid tolerance %q", c.Tolerance)
+		}
+		c.tolerance = t
+	}
+	return nil
+}
+
+// ToleranceDuration returns the skew accepted on the timestamps.
+func (c *Config) ToleranceDuration() time.Duration {
+	if c == nil {
+		return DefaultTolerance
+	}
+	return c.tolerance
+}
+
+// Report is the outcome of a clock check.
+type Report struct {
+	Time time.Time
+	// Offset is the median offset of the servers which answered, positive
+	// when the system clock is ahead.
+	Offset    time.Duration
+	Tolerance time.Duration
+	// Synced reports whether any server answered.
+	Synced  bool
+	Samples []*Sample
+}
+
+// Skewed reports whether the system clock is off by more than the
+// tolerance.
+func (r *Report) Skewed() bool {
+	return r.Synced && abs(r.Offset) > r.Tolerance
+}
+
+// Err returns the error of a skewed clock, nil otherwise.
+func (r *Report) Err() error {
+	if !r.Skewed() {
+		return nil
+	}
+	dir := "ahead"
+	if r.Offset < 0 {
+		dir = "behind"
+	}
+	return fmt.Errorf("%w: the system clock is %s %s, beyond the %s tolerance of the contract timestamps; "+
+		"enable time synchronization (NTP), see 'btfs diag clock'", ErrSkew, abs(r.Offset).Round(time.Millisecond),
+		dir, r.Tolerance)
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// Check queries the servers of c and returns the report of the clock at
+// now.
+func Check(ctx context.Context, c *Config, now time.Time) *Report {
+	r := &Report{Time: now, Tolerance: c.ToleranceDuration()}
+	var offsets []time.Duration
+	for _, s := range c.Servers {
+		qctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+		sample, err := query(qctx, s)
+		cancel()
+		if err != nil {
+			sample = &Sample{Server: s, Error: err.Error()}
+		} else {
+			offsets = append(offsets, sample.Offset)
+		}
+		r.Samples = append(r.Samples, sample)
+	}
+	if len(offsets) > 0 {
+		r.Synced = true
+		r.Offset = median(offsets)
+	}
+	last.Lock()
+	last.report = r
+	last.Unlock()
+	return r
+}
+
+// query is Query, replaced by tests.
+var query = Query
+
+func median(ds []time.Duration) time.Duration {
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	m := len(ds) / 2
+	if len(ds)%2 == 0 {
+		return (ds[m-1] + ds[m]) / 2
+	}
+	return ds[m]
+}
+
+var last struct {
+	sync.Mutex
+	report *Report
+}
+
+// Last returns the report of the last check, nil when none was made.
+func Last() *Report {
+	last.Lock()
+	defer last.Unlock()
+	return last.report
+}
+
+// BeforeSigning returns ErrSkew when the system clock is off by more than
+// the tolerance of r, checking it again when the last check is older than
+// MaxAge. No error is returned when the servers cannot be reached.
+func BeforeSigning(ctx context.Context, r repo.Repo) error {
+	c, err := Load(r)
+	if err != nil {
+		return err
+	}
+	if c.Disabled {
+		return nil
+	}
+	now := time.Now()
+	rep := Last()
+	if rep == nil || now.Sub(rep.Time) > MaxAge || rep.Tolerance != c.ToleranceDuration() {
+		rep = Check(ctx, c, now)
+	}
+	return rep.Err()
+}
+
+// CheckTimestamp returns an error when the timestamp t of what, set by a
+// peer, is ahead of now by more than tolerance. Timestamps behind now are
+// accepted, they may have waited for an offline signature.
+func CheckTimestamp(what string, t, now time.Time, tolerance time.Duration) error {
+	if skew := t.Sub(now); skew > tolerance {
+		return fmt.Errorf("%w: %s is %s ahead of this clock, beyond the %s tolerance; "+
+			"one of the clocks is off, see 'btfs diag clock'", ErrSkew, what, skew.Round(time.Second), tolerance)
+	}
+	return nil
+}
+
+// CheckPeer is CheckTimestamp with the tolerance of r, nil when the checks
+// are disabled.
+func CheckPeer(r repo.Repo, what string, t time.Time) error {
+	c, err := Load(r)
+	if err != nil {
+		return err
+	}
+	if c.Disabled {
+		return nil
+	}
+	return CheckTimestamp(what, t, time.Now(), c.ToleranceDuration())
+}
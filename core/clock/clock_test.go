@@ -0,0 +1,129 @@
+package clock
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve answers the SNTP requests on conn with a clock off by offset.
+func serve(conn net.PacketConn, offset time.Duration) {
+	buf := make([]byte, ntpPacketSize)
+	for {
+		_, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		resp := make([]byte, ntpPacketSize)
+		resp[0] = 0x24 // version 4, server
+		resp[1] = 2
+		copy(resp[24:32], buf[40:48])
+		now := time.Now().Add(offset)
+		binary.BigEndian.PutUint64(resp[32:], toNTP(now))
+		binary.BigEndian.PutUint64(resp[40:], toNTP(now))
+		conn.WriteTo(resp, addr)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go serve(conn, -time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := Query(ctx, conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the local clock is a minute ahead of the server
+	if d := s.Offset - time.Minute; d < -time.Second || d > time.Second {
+		t.Fatalf("expected an offset of 1m, got %s", s.Offset)
+	}
+}
+
+func TestNTPTime(t *testing.T) {
+	now := time.Unix(1600000000, 123456789)
+	if d := fromNTP(toNTP(now)).Sub(now); d < -time.Microsecond || d > time.Microsecond {
+		t.Fatalf("round trip off by %s", d)
+	}
+}
+
+func TestParseAnswer(t *testing.T) {
+	now := time.Now()
+	resp := make([]byte, ntpPacketSize)
+	resp[0] = 0x24
+	resp[1] = 2
+	binary.BigEndian.PutUint64(resp[24:], 42)
+	binary.BigEndian.PutUint64(resp[32:], toNTP(now))
+	binary.BigEndian.PutUint64(resp[40:], toNTP(now))
+	if _, err := parseAnswer("s", resp, 43, now, now); err == nil {
+		t.Fatal("expected an answer to another request to fail")
+	}
+	resp[1] = 0
+	if _, err := parseAnswer("s", resp, 42, now, now); err == nil {
+		t.Fatal("expected a kiss-o'-death to fail")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	defer func(q func(context.Context, string) (*Sample, error)) { query = q }(query)
+	offsets := map[string]time.Duration{"a": 40 * time.Second, "b": 50 * time.Second, "c": -time.Hour}
+	query = func(ctx context.Context, server string) (*Sample, error) {
+		if server == "down" {
+			return nil, errors.New("timeout")
+		}
+		return &Sample{Server: server, Offset: offsets[server]}, nil
+	}
+
+	c := &Config{Servers: []string{"a", "b", "c", "down"}}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	r := Check(context.Background(), c, time.Now())
+	if !r.Synced || r.Offset != 40*time.Second || len(r.Samples) != 4 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if !errors.Is(r.Err(), ErrSkew) || Last() != r {
+		t.Fatal("expected a skewed clock")
+	}
+
+	c = &Config{Servers: []string{"a", "down"}, Tolerance: "1m"}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	if r := Check(context.Background(), c, time.Now()); r.Err() != nil {
+		t.Fatalf("expected a skew within tolerance, got %s", r.Err())
+	}
+	c = &Config{Servers: []string{"down"}}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	if r := Check(context.Background(), c, time.Now()); r.Synced || r.Err() != nil {
+		t.Fatal("expected an unchecked clock to pass")
+	}
+}
+
+func TestCheckTimestamp(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		t  time.Time
+		ok bool
+	}{
+		{now, true},
+		{now.Add(20 * time.Second), true},
+		{now.Add(-time.Hour), true},
+		{now.Add(time.Minute), false},
+	} {
+		err := CheckTimestamp("contract", tc.t, now, DefaultTolerance)
+		if (err == nil) != tc.ok {
+			t.Fatalf("timestamp %s: unexpected error %v", tc.t.Sub(now), err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package clock
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	ntpPort       = "123"
+	ntpPacketSize = 48
+	// ntpEpochOffset is the seconds from the NTP epoch, 1900, to the Unix
+	// epoch.
+	ntpEpochOffset = 2208988800
+	// ntpClientHeader is leap indicator 0, version 4, mode 3 (client).
+	ntpClientHeader = 0x23
+	ntpModeServer   = 4
+)
+
+// Sample is the offset of the local clock to an NTP server, positive when
+// the local clock is ahead.
+type Sample struct {
+	Server string
+	Offset time.Duration
+	RTT    time.Duration
+	Error  string `json:",omitempty"`
+}
+
+func toNTP(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+func fromNTP(ts uint64) time.Time {
+	secs := int64(ts>>32) - ntpEpochOffset
+	nanos := int64((ts & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(secs, nanos)
+}
+
+// Query measures the offset of the local clock to the SNTP server, a host
+// or host:port.
+func Query(ctx context.Context, server string) (*Sample, error) {
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, ntpPort)
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if d, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(d)
+	}
+
+	req := make([]byte, ntpPacketSize)
+	req[0] = ntpClientHeader
+	sent := time.Now()
+	// the transmit time of the request comes back as the originate time
+	// of the answer
+	origin := toNTP(sent)
+	binary.BigEndian.PutUint64(req[40:], origin)
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, ntpPacketSize)
+	k, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	received := time.Now()
+	if k < ntpPacketSize {
+		return nil, errors.New("short answer")
+	}
+	return parseAnswer(server, resp, origin, sent, received)
+}
+
+// parseAnswer returns the sample of the answer resp to the request sent at
+// sent with the transmit timestamp origin, received at received.
+func parseAnswer(server string, resp []byte, origin uint64, sent, received time.Time) (*Sample, error) {
+	if mode := resp[0] & 0x7; mode != ntpModeServer {
+		return nil, fmt.Errorf("answer of mode %d", mode)
+	}
+	if stratum := resp[1]; stratum == 0 {
+		return nil, fmt.Errorf("server refused: %q", resp[12:16])
+	}
+	if binary.BigEndian.Uint64(resp[24:]) != origin {
+		return nil, errors.New("answer to another request")
+	}
+	rx := binary.BigEndian.Uint64(resp[32:])
+	tx := binary.BigEndian.Uint64(resp[40:])
+	if tx == 0 {
+		return nil, errors.New("answer without a transmit time")
+	}
+	t2, t3 := fromNTP(rx), fromNTP(tx)
+	// the server time is taken at the middle of the round trip, less the
+	// time the server held the request
+	return &Sample{
+		Server: server,
+		Offset: (sent.Sub(t2) + received.Sub(t3)) / 2,
+		RTT:    received.Sub(sent) - t3.Sub(t2),
+	}, nil
+}
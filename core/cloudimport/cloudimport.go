@@ -0,0 +1,466 @@
+// Package cloudimport migrates the objects of a cloud storage, S3, GCS or a
+// plain http server, to decentralized storage. Each object is streamed into
+// the node, reed-solomon encoded, then uploaded to hosts like
+// 'btfs storage upload' does.
+//
+// An import is a job whose ledger is kept in the datastore: the objects
+// listed from the source, and for each of them how far it went. A job
+// interrupted, by a failure or a restart of the daemon, is resumed where
+// it stopped: the objects stored are skipped, the ones added to the node
+// are uploaded without being fetched again.
+package cloudimport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+
+	"github.com/google/uuid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("cloudimport")
+
+const (
+	jobPrefix    = "/btfs/%s/cloudimport/jobs/"
+	objectPrefix = "/btfs/%s/cloudimport/objects/%s/"
+
+	DefaultCopies   = 3
+	MaxCopies       = 10
+	DefaultParallel = 4
+	MaxParallel     = 32
+)
+
+// States of a job.
+const (
+	JobListing     = "listing"
+	JobRunning     = "running"
+	JobDone        = "done"
+	JobFailed      = "failed"
+	JobCanceled    = "canceled"
+	JobInterrupted = "interrupted"
+)
+
+// States of an object.
+const (
+	ObjectPending   = "pending"
+	ObjectAdded     = "added"
+	ObjectUploading = "uploading"
+	ObjectStored    = "stored"
+	ObjectFailed    = "failed"
+)
+
+var (
+	// ErrNotFound is returned for a job that does not exist.
+	ErrNotFound = errors.New("no such import job")
+	// ErrRunning is returned when running a job already running.
+	ErrRunning = errors.New("the import job is running")
+	// ErrSessionLost is returned by Uploader.Wait for an upload session
+	// the daemon no longer runs, e.g. after a restart.
+	ErrSessionLost = errors.New("upload session lost")
+)
+
+// Job is an import of the objects of a source.
+type Job struct {
+	ID      string
+	Source  string
+	Options SourceOptions
+	// Copies is the redundancy of the encoding of the objects: the hosts
+	// of the shards store Copies times the size of an object.
+	Copies   int
+	Parallel int
+	// StorageLength is the storage period in days, Price the price per GiB
+	// per day in µBTT, 0 for the price the hosts ask.
+	StorageLength int
+	Price         int64 `json:",omitempty"`
+	Priority      int   `json:",omitempty"`
+	// Listed is set once the objects of the source are recorded.
+	Listed  bool
+	Status  string
+	Error   string `json:",omitempty"`
+	Created time.Time
+	Updated time.Time
+}
+
+// Entry is an object of a job and how far it went.
+type Entry struct {
+	Object
+	Index     int
+	State     string
+	FileHash  string `json:",omitempty"`
+	SessionID string `json:",omitempty"`
+	Error     string `json:",omitempty"`
+	Attempts  int    `json:",omitempty"`
+}
+
+// Summary counts the objects of a job by state.
+type Summary struct {
+	Objects int
+	Bytes   int64
+	// StoredBytes is the size of the objects stored.
+	StoredBytes int64
+	States      map[string]int
+}
+
+// Summarize counts entries by state.
+func Summarize(entries []*Entry) *Summary {
+	s := &Summary{States: map[string]int{}}
+	for _, e := range entries {
+		s.Objects++
+		s.States[e.State]++
+		if e.Size > 0 {
+			s.Bytes += e.Size
+			if e.State == ObjectStored {
+				s.StoredBytes += e.Size
+			}
+		}
+	}
+	return s
+}
+
+// Uploader stores objects on the network.
+type Uploader interface {
+	// Add streams r into the node, encoded for copies, and returns the hash
+	// of the file.
+	Add(ctx context.Context, r io.Reader, copies int) (string, error)
+	// Upload starts the upload session of the file fileHash and returns its
+	// ID.
+	Upload(ctx context.Context, fileHash string) (string, error)
+	// Wait returns once the upload session ssId ends, nil when the file is
+	// stored.
+	Wait(ctx context.Context, ssId string) error
+}
+
+// running are the cancel functions of the jobs run by the daemon.
+var running = struct {
+	sync.Mutex
+	jobs map[string]context.CancelFunc
+}{jobs: map[string]context.CancelFunc{}}
+
+// Running reports whether the job id is run by the daemon.
+func Running(id string) bool {
+	running.Lock()
+	defer running.Unlock()
+	_, ok := running.jobs[id]
+	return ok
+}
+
+// Cancel stops the job id, false when the daemon does not run it.
+func Cancel(id string) bool {
+	running.Lock()
+	defer running.Unlock()
+	cancel, ok := running.jobs[id]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// Store keeps the ledgers of the import jobs of a node in its datastore.
+type Store struct {
+	d      ds.Datastore
+	peerID string
+
+	// serializes the read-modify-write of jobs and entries
+	mu sync.Mutex
+}
+
+// NewStore returns the import jobs of the node peerID kept in d.
+func NewStore(d ds.Datastore, peerID string) *Store {
+	return &Store{d: d, peerID: peerID}
+}
+
+// ForNode returns the import jobs of n.
+func ForNode(n *core.IpfsNode) *Store {
+	return NewStore(n.Repo.Datastore(), n.Identity.Pretty())
+}
+
+func (s *Store) jobKey(id string) ds.Key {
+	return ds.NewKey(fmt.Sprintf(jobPrefix, s.peerID) + id)
+}
+
+func (s *Store) entryKey(id string, index int) ds.Key {
+	return ds.NewKey(fmt.Sprintf(objectPrefix, s.peerID, id) + fmt.Sprintf("%08d", index))
+}
+
+func (s *Store) put(k ds.Key, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.d.Put(k, b)
+}
+
+// NewJob records a new job importing the objects of source.
+func (s *Store) NewJob(j *Job, now time.Time) (*Job, error) {
+	if _, err := ParseSource(j.Source, j.Options); err != nil {
+		return nil, err
+	}
+	if j.Copies < 2 || j.Copies > MaxCopies {
+		return nil, fmt.Errorf("copies must be within [2, %d]", MaxCopies)
+	}
+	if j.Parallel < 1 || j.Parallel > MaxParallel {
+		return nil, fmt.Errorf("parallel must be within [1, %d]", MaxParallel)
+	}
+	j.ID = uuid.New().String()
+	j.Status = JobListing
+	j.Created, j.Updated = now, now
+	return j, s.put(s.jobKey(j.ID), j)
+}
+
+// Get returns the job id. A job left running by a previous daemon is
+// interrupted.
+func (s *Store) Get(id string) (*Job, error) {
+	b, err := s.d.Get(s.jobKey(id))
+	if err == ds.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJob(b)
+}
+
+func unmarshalJob(b []byte) (*Job, error) {
+	j := &Job{}
+	if err := json.Unmarshal(b, j); err != nil {
+		return nil, err
+	}
+	if (j.Status == JobRunning || j.Status == JobListing) && !Running(j.ID) {
+		j.Status = JobInterrupted
+	}
+	return j, nil
+}
+
+// List returns the jobs, the latest first.
+func (s *Store) List() ([]*Job, error) {
+	res, err := s.d.Query(query.Query{Prefix: fmt.Sprintf(jobPrefix, s.peerID)})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(entries))
+	for _, e := range entries {
+		j, err := unmarshalJob(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import job %s: %s", e.Key, err)
+		}
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].Created.After(jobs[k].Created) })
+	return jobs, nil
+}
+
+// Entries returns the objects of the job id in the order listed.
+func (s *Store) Entries(id string) ([]*Entry, error) {
+	res, err := s.d.Query(query.Query{Prefix: fmt.Sprintf(objectPrefix, s.peerID, id)})
+	if err != nil {
+		return nil, err
+	}
+	results, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(results))
+	for _, r := range results {
+		e := &Entry{}
+		if err := json.Unmarshal(r.Value, e); err != nil {
+			return nil, fmt.Errorf("invalid import object %s: %s", r.Key, err)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, k int) bool { return entries[i].Index < entries[k].Index })
+	return entries, nil
+}
+
+func (s *Store) setStatus(j *Job, status string, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j.Status, j.Error, j.Updated = status, "", time.Now()
+	if err != nil {
+		j.Error = err.Error()
+	}
+	return s.put(s.jobKey(j.ID), j)
+}
+
+func (s *Store) putEntry(id string, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(s.entryKey(id, e.Index), e)
+}
+
+// Run runs the job id from where it stopped until ctx is done: it lists
+// the source the first time, then imports the objects not stored yet,
+// Parallel at once, with up.
+func (s *Store) Run(ctx context.Context, id string, up Uploader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	running.Lock()
+	if _, ok := running.jobs[id]; ok {
+		running.Unlock()
+		return ErrRunning
+	}
+	running.jobs[id] = cancel
+	running.Unlock()
+	defer func() {
+		running.Lock()
+		delete(running.jobs, id)
+		running.Unlock()
+	}()
+
+	j, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	src, err := ParseSource(j.Source, j.Options)
+	if err != nil {
+		return err
+	}
+	err = s.run(ctx, j, src, up)
+	switch {
+	case ctx.Err() != nil:
+		_ = s.setStatus(j, JobCanceled, nil)
+	case err != nil:
+		_ = s.setStatus(j, JobFailed, err)
+	default:
+		err = s.setStatus(j, JobDone, nil)
+	}
+	return err
+}
+
+func (s *Store) run(ctx context.Context, j *Job, src Source, up Uploader) error {
+	entries, err := s.Entries(j.ID)
+	if err != nil {
+		return err
+	}
+	// a job stopped while listing lists again, the entries recorded are
+	// overwritten
+	if !j.Listed {
+		if err := s.setStatus(j, JobListing, nil); err != nil {
+			return err
+		}
+		objects, err := src.List(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot list %s: %s", j.Source, err)
+		}
+		entries = make([]*Entry, len(objects))
+		for i, o := range objects {
+			entries[i] = &Entry{Object: o, Index: i, State: ObjectPending}
+			if err := s.putEntry(j.ID, entries[i]); err != nil {
+				return err
+			}
+		}
+		j.Listed = true
+	}
+	if err := s.setStatus(j, JobRunning, nil); err != nil {
+		return err
+	}
+
+	todo := make(chan *Entry)
+	var wg sync.WaitGroup
+	var failed int
+	var mu sync.Mutex
+	for i := 0; i < j.Parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range todo {
+				if err := s.importObject(ctx, j, src, up, e); err != nil && ctx.Err() == nil {
+					log.Errorf("import job %s: object %s: %s", j.ID, e.Key, err)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, e := range entries {
+		if e.State == ObjectStored {
+			continue
+		}
+		select {
+		case todo <- e:
+		case <-ctx.Done():
+		}
+	}
+	close(todo)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d objects failed, resume the job to retry them", failed, len(entries))
+	}
+	return nil
+}
+
+// importObject takes e from its state to stored, recording each step.
+func (s *Store) importObject(ctx context.Context, j *Job, src Source, up Uploader, e *Entry) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	e.Attempts++
+	fail := func(err error) error {
+		if ctx.Err() == nil {
+			e.State, e.Error = ObjectFailed, err.Error()
+			if perr := s.putEntry(j.ID, e); perr != nil {
+				return perr
+			}
+		}
+		return err
+	}
+
+	if e.FileHash == "" {
+		r, err := src.Open(ctx, e.Key)
+		if err != nil {
+			return fail(err)
+		}
+		h, err := up.Add(ctx, r, j.Copies)
+		r.Close()
+		if err != nil {
+			return fail(err)
+		}
+		e.FileHash, e.State, e.Error = h, ObjectAdded, ""
+		if err := s.putEntry(j.ID, e); err != nil {
+			return err
+		}
+	}
+
+	if e.SessionID != "" {
+		err := up.Wait(ctx, e.SessionID)
+		if err == nil {
+			e.State, e.Error = ObjectStored, ""
+			return s.putEntry(j.ID, e)
+		}
+		if err != ErrSessionLost {
+			e.SessionID = ""
+			return fail(err)
+		}
+	}
+	ssId, err := up.Upload(ctx, e.FileHash)
+	if err != nil {
+		return fail(err)
+	}
+	e.SessionID, e.State, e.Error = ssId, ObjectUploading, ""
+	if err := s.putEntry(j.ID, e); err != nil {
+		return err
+	}
+	if err := up.Wait(ctx, ssId); err != nil {
+		e.SessionID = ""
+		return fail(err)
+	}
+	e.State = ObjectStored
+	return s.putEntry(j.ID, e)
+}
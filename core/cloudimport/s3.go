@@ -0,0 +1,190 @@
+package cloudimport
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	// emptyPayloadHash is the sha256 of the empty body of the requests.
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	amzDateFormat    = "20060102T150405Z"
+)
+
+// s3Source lists and reads the objects of an S3 bucket, or of an S3
+// compatible storage, signing the requests with AWS signature version 4.
+type s3Source struct {
+	bucket, prefix string
+	region         string
+	// endpoint is set for a path style access, e.g. to MinIO
+	endpoint string
+
+	accessKey, secretKey, sessionToken string
+}
+
+func newS3(bucket, prefix string, opts SourceOptions) *s3Source {
+	s := &s3Source{
+		bucket:       bucket,
+		prefix:       prefix,
+		region:       opts.Region,
+		endpoint:     strings.TrimSuffix(opts.Endpoint, "/"),
+		accessKey:    getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.region == "" {
+		s.region = getenv("AWS_REGION", "AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		s.region = defaultS3Region
+	}
+	return s
+}
+
+// objectURL returns the URL of the object key, the bucket for "".
+func (s *s3Source) objectURL(key string) string {
+	if s.endpoint != "" {
+		return s.endpoint + "/" + s.bucket + "/" + escapePath(key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, escapePath(key))
+}
+
+// escapePath escapes the segments of the object key p as S3 expects them.
+func escapePath(p string) string {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		segs[i] = strings.Replace(url.QueryEscape(seg), "+", "%20", -1)
+	}
+	return strings.Join(segs, "/")
+}
+
+func (s *s3Source) request(ctx context.Context, rawurl string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.accessKey != "" {
+		signV4(req, s.accessKey, s.secretKey, s.sessionToken, s.region, "s3", time.Now())
+	}
+	return get(req)
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key  string
+		Size int64
+		ETag string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (s *s3Source) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}}
+		if s.prefix != "" {
+			q.Set("prefix", s.prefix)
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		resp, err := s.request(ctx, s.objectURL("")+"?"+q.Encode())
+		if err != nil {
+			return nil, err
+		}
+		res := &listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid listing of bucket %s: %s", s.bucket, err)
+		}
+		for _, c := range res.Contents {
+			// the folders of the consoles are empty objects
+			if strings.HasSuffix(c.Key, "/") {
+				continue
+			}
+			objects = append(objects, Object{Key: c.Key, Size: c.Size, ETag: strings.Trim(c.ETag, `"`)})
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+func (s *s3Source) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.request(ctx, s.objectURL(key))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// signV4 signs req, which has no body, with the AWS signature version 4
+// of service in region. The headers already set on req are signed too.
+func signV4(req *http.Request, accessKey, secretKey, sessionToken, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// url.Values.Encode sorts by key and escapes as AWS expects, but for
+	// the spaces
+	query := strings.Replace(req.URL.Query().Encode(), "+", "%20", -1)
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, query, canonicalHeaders.String(), signedHeaders, emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
@@ -0,0 +1,122 @@
+package cloudimport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	gopath "path"
+	"strings"
+)
+
+// Object is an object of a source.
+type Object struct {
+	Key string
+	// Size is the size of the object in bytes, -1 when unknown.
+	Size int64
+	ETag string `json:",omitempty"`
+}
+
+// Source is a cloud storage the objects are imported from.
+type Source interface {
+	// List returns the objects to import.
+	List(ctx context.Context) ([]Object, error)
+	// Open streams the object key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// SourceOptions configures the access to a source. The credentials are
+// read from the environment: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN for S3, GOOGLE_OAUTH_ACCESS_TOKEN for GCS. Public
+// buckets are read without them.
+type SourceOptions struct {
+	// Endpoint replaces the endpoint of the service, e.g. an S3 compatible
+	// storage such as MinIO.
+	Endpoint string `json:",omitempty"`
+	// Region is the S3 region, AWS_REGION or us-east-1 by default.
+	Region string `json:",omitempty"`
+}
+
+// ParseSource returns the source of uri: s3://bucket/prefix,
+// gs://bucket/prefix, or the http(s) URL of a single object.
+func ParseSource(uri string, opts SourceOptions) (Source, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source %q: %s", uri, err)
+	}
+	switch u.Scheme {
+	case "s3", "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid source %q: no bucket", uri)
+		}
+		prefix := strings.TrimPrefix(u.Path, "/")
+		if u.Scheme == "gs" {
+			return newGCS(u.Host, prefix, opts), nil
+		}
+		return newS3(u.Host, prefix, opts), nil
+	case "http", "https":
+		return &httpSource{url: uri}, nil
+	}
+	return nil, fmt.Errorf("unsupported source %q, expected s3://, gs://, http:// or https://", uri)
+}
+
+func getenv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// get runs req and fails unless the response is a success.
+func get(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s %s", req.Method, req.URL, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return resp, nil
+}
+
+// httpSource is a single object served over http.
+type httpSource struct {
+	url string
+}
+
+func (s *httpSource) List(ctx context.Context) ([]Object, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := get(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	u, _ := url.Parse(s.url)
+	key := gopath.Base(u.Path)
+	if key == "/" || key == "." {
+		key = u.Host
+	}
+	return []Object{{Key: key, Size: resp.ContentLength, ETag: resp.Header.Get("ETag")}}, nil
+}
+
+func (s *httpSource) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := get(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -7,15 +7,17 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/coreunix"
+	"github.com/TRON-US/go-btfs/core/dek"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	files "github.com/TRON-US/go-btfs-files"
 	coreiface "github.com/TRON-US/interface-go-btfs-core"
 	"github.com/TRON-US/interface-go-btfs-core/options"
 	mh "github.com/multiformats/go-multihash"
-	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
 // ErrDepthLimitExceeded indicates that the max depth has been exceeded.
@@ -26,6 +28,10 @@ type AddEvent struct {
 	Hash  string `json:",omitempty"`
 	Bytes int64  `json:",omitempty"`
 	Size  string `json:",omitempty"`
+
+	// Checksum is the hex checksum of the plain file content, set when a
+	// manifest is requested.
+	Checksum string `json:",omitempty"`
 }
 
 const (
@@ -49,6 +55,7 @@ const (
 	pubkeyName                 = "public-key"
 	peerIdName                 = "peer-id"
 	pinDurationCountOptionName = "pin-duration-count"
+	addSessionOptionName       = "session"
 )
 
 const adderOutChanSize = 8
@@ -150,7 +157,11 @@ only-hash, and progress/status related flags) will change the final hash.
 		cmds.BoolOption(encryptName, "Encrypt the file."),
 		cmds.StringOption(pubkeyName, "The public key to encrypt the file."),
 		cmds.StringOption(peerIdName, "The peer id to encrypt the file."),
+		cmds.StringOption(dekOptionName, "Encrypt the file with the data encryption key of this name, created on first use. See 'btfs keys'."),
 		cmds.IntOption(pinDurationCountOptionName, "d", "Duration for which the object is pinned in days.").WithDefault(0),
+		cmds.StringOption(addSessionOptionName, "Stage the added blocks into the given add session. See 'btfs repo add-session'."),
+		cmds.StringOption(manifestOptionName, "Write a checksum manifest of the plain file contents using the given algorithm (sha256, sha512). See 'btfs verify'."),
+		cmds.StringOption(manifestFileOptionName, "Path of the manifest file. Defaults to <root cid>.manifest.json."),
 	},
 	PreRun: func(req *cmds.Request, env cmds.Environment) error {
 		quiet, _ := req.Options[quietOptionName].(bool)
@@ -163,10 +174,11 @@ only-hash, and progress/status related flags) will change the final hash.
 			return nil
 		}
 
-		// btfs cli progress bar defaults to true unless quiet or silent is used
+		// btfs cli progress bar defaults to on for terminals unless quiet or
+		// silent is used
 		_, found := req.Options[progressOptionName].(bool)
 		if !found {
-			req.Options[progressOptionName] = true
+			req.Options[progressOptionName] = cmdenv.ProgressDefault()
 		}
 
 		return nil
@@ -196,6 +208,9 @@ only-hash, and progress/status related flags) will change the final hash.
 		pubkey, _ := req.Options[pubkeyName].(string)
 		peerId, _ := req.Options[peerIdName].(string)
 		pinDuration, _ := req.Options[pinDurationCountOptionName].(int)
+		sessionID, _ := req.Options[addSessionOptionName].(string)
+		manifestAlg, _ := req.Options[manifestOptionName].(string)
+		dekName, _ := req.Options[dekOptionName].(string)
 
 		hashFunCode, ok := mh.Names[strings.ToLower(hashFunStr)]
 		if !ok {
@@ -207,6 +222,50 @@ only-hash, and progress/status related flags) will change the final hash.
 			return err
 		}
 
+		ctx := req.Context
+		if sessionID != "" {
+			n, err := cmdenv.GetNode(env)
+			if err != nil {
+				return err
+			}
+			session, err := coreunix.GetAddSession(n.Repo.Datastore(), sessionID)
+			if err != nil {
+				return err
+			}
+			ctx = coreunix.ContextWithAddSession(ctx, session)
+		}
+
+		var checksums *checksumTree
+		if manifestAlg != "" {
+			if nocopy {
+				return fmt.Errorf("--%s can't be used with --%s", manifestOptionName, noCopyOptionName)
+			}
+			checksums, err = newChecksumTree(manifestAlg)
+			if err != nil {
+				return err
+			}
+		}
+
+		var dekKey []byte
+		if dekName != "" {
+			if encrypt {
+				return fmt.Errorf("--%s can't be used with --%s", dekOptionName, encryptName)
+			}
+			m, err := dekManager(env)
+			if err != nil {
+				return err
+			}
+			key, version, err := m.CurrentKey(ctx, dekName)
+			if err != nil {
+				return err
+			}
+			meta := &dek.Meta{Dek: dekName, DekVersion: version}
+			if tokenMetadata, err = meta.AppendTo(tokenMetadata); err != nil {
+				return err
+			}
+			dekKey = key
+		}
+
 		toadd := req.Files
 		if wrap {
 			toadd = files.NewSliceDirectory([]files.DirEntry{
@@ -257,7 +316,16 @@ only-hash, and progress/status related flags) will change the final hash.
 		var added int
 		addit := toadd.Entries()
 		for addit.Next() {
-			_, dir := addit.Node().(files.Directory)
+			node := addit.Node()
+			_, dir := node.(files.Directory)
+			if checksums != nil {
+				node = checksums.wrap(addit.Name(), node)
+			}
+			if dekKey != nil {
+				if node, err = sealFile(node, dekKey); err != nil {
+					return err
+				}
+			}
 			errCh := make(chan error, 1)
 			events := make(chan interface{}, adderOutChanSize)
 			opts[len(opts)-1] = options.Unixfs.Events(events)
@@ -265,7 +333,7 @@ only-hash, and progress/status related flags) will change the final hash.
 			go func() {
 				var err error
 				defer close(events)
-				_, err = api.Unixfs().Add(req.Context, addit.Node(), opts...)
+				_, err = api.Unixfs().Add(ctx, node, opts...)
 				errCh <- err
 			}()
 
@@ -286,12 +354,19 @@ only-hash, and progress/status related flags) will change the final hash.
 					output.Name = path.Join(addit.Name(), output.Name)
 				}
 
-				if err := res.Emit(&AddEvent{
+				ev := &AddEvent{
 					Name:  output.Name,
 					Hash:  h,
 					Bytes: output.Bytes,
 					Size:  output.Size,
-				}); err != nil {
+				}
+				if checksums != nil && h != "" {
+					if sum, n, ok := checksums.checksum(output.Name); ok {
+						ev.Checksum = sum
+						ev.Bytes = n
+					}
+				}
+				if err := res.Emit(ev); err != nil {
 					return err
 				}
 			}
@@ -356,19 +431,14 @@ only-hash, and progress/status related flags) will change the final hash.
 
 				progress, _ := req.Options[progressOptionName].(bool)
 
-				var bar *pb.ProgressBar
+				var bar *cmdenv.ProgressBar
 				if progress {
-					bar = pb.New64(0).SetUnits(pb.U_BYTES)
-					bar.ManualUpdate = true
-					bar.ShowTimeLeft = false
-					bar.ShowPercent = false
-					bar.Output = os.Stderr
-					bar.Start()
+					bar = cmdenv.NewProgressBar(os.Stderr)
 				}
 
 				lastFile := ""
 				lastHash := ""
-				var totalProgress, prevFiles, lastBytes int64
+				var prevFiles, lastBytes int64
 
 			LOOP:
 				for {
@@ -390,7 +460,7 @@ only-hash, and progress/status related flags) will change the final hash.
 
 							if progress {
 								// clear progress bar line before we print "added x" output
-								fmt.Fprintf(os.Stderr, "\033[2K\r")
+								bar.Clear()
 							}
 							if quiet {
 								fmt.Fprintf(os.Stdout, "%s\n", output.Hash)
@@ -411,19 +481,11 @@ only-hash, and progress/status related flags) will change the final hash.
 								lastFile = output.Name
 							}
 							lastBytes = output.Bytes
-							delta := prevFiles + lastBytes - totalProgress
-							totalProgress = bar.Add64(delta)
-						}
-
-						if progress {
-							bar.Update()
+							bar.Add(prevFiles + lastBytes - bar.Bytes())
 						}
 					case size := <-sizeChan:
 						if progress {
-							bar.Total = size
-							bar.ShowPercent = true
-							bar.ShowBar = true
-							bar.ShowTimeLeft = true
+							bar.SetTotal(size)
 						}
 					case <-req.Context.Done():
 						// don't set or print error here, that happens in the goroutine below
@@ -431,12 +493,8 @@ only-hash, and progress/status related flags) will change the final hash.
 					}
 				}
 
-				if progress && bar.Total == 0 && bar.Get() != 0 {
-					bar.Total = bar.Get()
-					bar.ShowPercent = true
-					bar.ShowBar = true
-					bar.ShowTimeLeft = true
-					bar.Update()
+				if progress && bar.Bytes() != 0 {
+					bar.Finish()
 				}
 			}
 
@@ -448,18 +506,34 @@ only-hash, and progress/status related flags) will change the final hash.
 			wait := make(chan struct{})
 			go progressBar(wait)
 
-			defer func() { <-wait }()
-			defer close(outChan)
+			var finish sync.Once
+			done := func() {
+				finish.Do(func() {
+					close(outChan)
+					<-wait
+				})
+			}
+			defer done()
 
+			manifestAlg, _ := req.Options[manifestOptionName].(string)
+			var added []*AddEvent
 			for {
 				v, err := res.Next()
 				if err != nil {
 					if err == io.EOF {
+						if manifestAlg != "" {
+							// Let the added lines print first
+							done()
+							return writeManifest(req, manifestAlg, added)
+						}
 						return nil
 					}
 
 					return err
 				}
+				if e, ok := v.(*AddEvent); ok && e.Hash != "" {
+					added = append(added, e)
+				}
 
 				select {
 				case outChan <- v:
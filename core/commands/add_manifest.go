@@ -0,0 +1,304 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+)
+
+const (
+	manifestOptionName     = "manifest"
+	manifestFileOptionName = "manifest-file"
+
+	// ManifestVersion is the version of the checksum manifest format.
+	ManifestVersion = 1
+)
+
+var manifestHashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Manifest maps the paths of an added tree to checksums of the plain file
+// contents, so the content can be checked without understanding CIDs.
+type Manifest struct {
+	Version   int
+	Algorithm string
+	Root      string
+	Entries   []ManifestEntry
+}
+
+// ManifestEntry is one file of a Manifest. Path is relative to the root.
+type ManifestEntry struct {
+	Path     string
+	Cid      string
+	Size     int64
+	Checksum string
+}
+
+func newManifestHash(algorithm string) (hash.Hash, error) {
+	newHash, ok := manifestHashes[strings.ToLower(algorithm)]
+	if !ok {
+		names := make([]string, 0, len(manifestHashes))
+		for name := range manifestHashes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unsupported manifest algorithm %q, expected one of %s",
+			algorithm, strings.Join(names, ", "))
+	}
+	return newHash(), nil
+}
+
+// readManifest loads and checks a manifest file.
+func readManifest(r io.Reader, file string) (*Manifest, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Manifest)
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %s", file, err)
+	}
+	if m.Version != ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	if _, err := newManifestHash(m.Algorithm); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// buildManifest creates the manifest of an add from its events. The last
+// event is the root; every file has to be below it.
+func buildManifest(algorithm string, events []*AddEvent) (*Manifest, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("nothing added")
+	}
+	root := events[len(events)-1]
+	m := &Manifest{
+		Version:   ManifestVersion,
+		Algorithm: strings.ToLower(algorithm),
+		Root:      root.Hash,
+		Entries:   []ManifestEntry{},
+	}
+	for _, e := range events {
+		if e.Checksum == "" {
+			continue
+		}
+		p := e.Name
+		switch {
+		case p == root.Name:
+			p = ""
+		case root.Name == "":
+		case strings.HasPrefix(p, root.Name+"/"):
+			p = strings.TrimPrefix(p, root.Name+"/")
+		default:
+			return nil, fmt.Errorf("%s is not below the root %s, use -w to add several paths with a manifest", e.Name, root.Name)
+		}
+		m.Entries = append(m.Entries, ManifestEntry{
+			Path:     p,
+			Cid:      e.Hash,
+			Size:     e.Bytes,
+			Checksum: e.Checksum,
+		})
+	}
+	return m, nil
+}
+
+// checksumTree records checksums of the plain contents of the files read
+// from a files.Node tree, keyed by the path the adder reports.
+type checksumTree struct {
+	algorithm string
+
+	mu   sync.Mutex
+	sums map[string]*checksumFile
+}
+
+func newChecksumTree(algorithm string) (*checksumTree, error) {
+	if _, err := newManifestHash(algorithm); err != nil {
+		return nil, err
+	}
+	return &checksumTree{algorithm: algorithm, sums: map[string]*checksumFile{}}, nil
+}
+
+// wrap returns nd with every regular file hashing the data read from it.
+func (t *checksumTree) wrap(p string, nd files.Node) files.Node {
+	switch nd := nd.(type) {
+	case *files.Symlink:
+		return nd
+	case files.File:
+		h, _ := newManifestHash(t.algorithm)
+		f := &checksumFile{File: nd, h: h}
+		t.mu.Lock()
+		t.sums[p] = f
+		t.mu.Unlock()
+		return f
+	case files.Directory:
+		return &checksumDir{Directory: nd, t: t, path: p}
+	}
+	return nd
+}
+
+// checksum returns the hex checksum and size of the file at p, once it has
+// been read completely.
+func (t *checksumTree) checksum(p string) (string, int64, bool) {
+	t.mu.Lock()
+	f, ok := t.sums[p]
+	t.mu.Unlock()
+	if !ok {
+		return "", 0, false
+	}
+	return f.sum()
+}
+
+type checksumDir struct {
+	files.Directory
+	t    *checksumTree
+	path string
+}
+
+func (d *checksumDir) Entries() files.DirIterator {
+	return &checksumIterator{DirIterator: d.Directory.Entries(), d: d}
+}
+
+type checksumIterator struct {
+	files.DirIterator
+	d *checksumDir
+}
+
+func (it *checksumIterator) Node() files.Node {
+	return it.d.t.wrap(path.Join(it.d.path, it.Name()), it.DirIterator.Node())
+}
+
+type checksumFile struct {
+	files.File
+
+	mu      sync.Mutex
+	h       hash.Hash
+	n       int64
+	invalid bool
+}
+
+func (f *checksumFile) Read(b []byte) (int, error) {
+	n, err := f.File.Read(b)
+	f.mu.Lock()
+	f.h.Write(b[:n])
+	f.n += int64(n)
+	f.mu.Unlock()
+	return n, err
+}
+
+func (f *checksumFile) Seek(offset int64, whence int) (int64, error) {
+	pos, err := f.File.Seek(offset, whence)
+	f.mu.Lock()
+	if err == nil && pos == 0 {
+		f.h.Reset()
+		f.n = 0
+	} else {
+		// Partial reads can't be hashed in order anymore
+		f.invalid = true
+	}
+	f.mu.Unlock()
+	return pos, err
+}
+
+func (f *checksumFile) sum() (string, int64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.invalid {
+		return "", 0, false
+	}
+	return hex.EncodeToString(f.h.Sum(nil)), f.n, true
+}
+
+// verifyChecksums hashes every file of nd and compares them with the manifest
+// entries, calling report for every entry and for files missing from it.
+func verifyChecksums(m *Manifest, nd files.Node, report func(*VerifyManifestOutput) error) (int, error) {
+	expected := make(map[string]ManifestEntry, len(m.Entries))
+	for _, e := range m.Entries {
+		expected[e.Path] = e
+	}
+
+	var fails int
+	err := files.Walk(nd, func(p string, n files.Node) error {
+		f, ok := n.(files.File)
+		if !ok {
+			return nil
+		}
+		if _, link := n.(*files.Symlink); link {
+			return nil
+		}
+		h, _ := newManifestHash(m.Algorithm)
+		size, err := io.Copy(h, f)
+		if err != nil {
+			return err
+		}
+		p = filepath.ToSlash(p)
+		out := &VerifyManifestOutput{
+			Path:   p,
+			Actual: hex.EncodeToString(h.Sum(nil)),
+		}
+		if e, ok := expected[p]; ok {
+			delete(expected, p)
+			out.Expected = e.Checksum
+			out.Ok = e.Checksum == out.Actual && e.Size == size
+		}
+		if !out.Ok {
+			fails++
+		}
+		return report(out)
+	})
+	if err != nil {
+		return fails, err
+	}
+
+	missing := make([]string, 0, len(expected))
+	for p := range expected {
+		missing = append(missing, p)
+	}
+	sort.Strings(missing)
+	for _, p := range missing {
+		fails++
+		if err := report(&VerifyManifestOutput{Path: p, Expected: expected[p].Checksum}); err != nil {
+			return fails, err
+		}
+	}
+	return fails, nil
+}
+
+// writeManifest writes the manifest of the added events to the file given by
+// --manifest-file, or <root cid>.manifest.json.
+func writeManifest(req *cmds.Request, algorithm string, events []*AddEvent) error {
+	m, err := buildManifest(algorithm, events)
+	if err != nil {
+		return err
+	}
+	file, _ := req.Options[manifestFileOptionName].(string)
+	if file == "" {
+		file = m.Root + ".manifest.json"
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "manifest written to %s\n", file)
+	return nil
+}
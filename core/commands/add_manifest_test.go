@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"io/ioutil"
+	"testing"
+
+	files "github.com/TRON-US/go-btfs-files"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	tree, err := newChecksumTree("sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := tree.wrap("docs", files.NewMapDirectory(map[string]files.Node{
+		"a": files.NewBytesFile([]byte("testfileA")),
+		"sub": files.NewMapDirectory(map[string]files.Node{
+			"b": files.NewBytesFile([]byte("testfileB")),
+		}),
+	}))
+
+	// Read the tree like the adder does
+	err = files.Walk(dir, func(_ string, n files.Node) error {
+		if f, ok := n.(files.File); ok {
+			_, err := ioutil.ReadAll(f)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []*AddEvent
+	for _, name := range []string{"docs/a", "docs/sub/b"} {
+		sum, n, ok := tree.checksum(name)
+		if !ok {
+			t.Fatalf("no checksum for %s", name)
+		}
+		events = append(events, &AddEvent{Name: name, Hash: "Qm" + name, Bytes: n, Checksum: sum})
+	}
+	events = append(events, &AddEvent{Name: "docs", Hash: "QmRoot"})
+
+	m, err := buildManifest("sha256", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Root != "QmRoot" || len(m.Entries) != 2 || m.Entries[1].Path != "sub/b" || m.Entries[1].Size != 9 {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	// sha256("testfileA")
+	if m.Entries[0].Checksum != "a1925b9230d3e8b4477d57e11dfcad43c88b670c0c931f854643b070f3580410" {
+		t.Fatalf("unexpected checksum %s", m.Entries[0].Checksum)
+	}
+
+	verify := func(nd files.Node) int {
+		fails, err := verifyChecksums(m, nd, func(*VerifyManifestOutput) error { return nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fails
+	}
+	if fails := verify(files.NewMapDirectory(map[string]files.Node{
+		"a": files.NewBytesFile([]byte("testfileA")),
+		"sub": files.NewMapDirectory(map[string]files.Node{
+			"b": files.NewBytesFile([]byte("testfileB")),
+		}),
+	})); fails != 0 {
+		t.Fatalf("expected identical content to verify, got %d failures", fails)
+	}
+	if fails := verify(files.NewMapDirectory(map[string]files.Node{
+		"a": files.NewBytesFile([]byte("changed")),
+		"c": files.NewBytesFile([]byte("extra")),
+	})); fails != 3 {
+		t.Fatalf("expected a mismatch, a missing and an unlisted file, got %d failures", fails)
+	}
+
+	if _, err := buildManifest("sha256", []*AddEvent{
+		{Name: "x", Hash: "QmX", Checksum: "00"},
+		{Name: "y", Hash: "QmY", Checksum: "01"},
+	}); err == nil {
+		t.Fatal("expected several roots to be rejected")
+	}
+}
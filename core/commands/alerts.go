@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/alerts"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+var AlertsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Notify the alerts of the node by email, Telegram or Slack.",
+		ShortDescription: `
+The node raises alerts when the disk storing the shards is likely to fail,
+or when a file would likely miss its retrieval SLA. The Alerts config
+section sends them to channels:
+
+  > btfs config --json Alerts.Channels.ops '{"Type": "email", "Options":
+      {"addr": "smtp.example.com:587", "from": "btfs@example.com",
+       "to": "ops@example.com", "username": "btfs", "password": "..."}}'
+  > btfs config --json Alerts.Channels.chat '{"Type": "telegram", "Options":
+      {"token": "<bot token>", "chat": "<chat id>"}}'
+  > btfs config --json Alerts.Channels.team '{"Type": "slack", "Options":
+      {"url": "https://hooks.slack.com/services/..."}}'
+
+Alerts.Rules route the alerts by source (diskhealth, probe) and severity
+(info, warning, critical). Every alert goes to every channel without rules:
+
+  > btfs config --json Alerts.Rules '[{"MinSeverity": "critical",
+      "Channels": ["chat"]}, {"Sources": ["probe"], "Channels": ["ops"]}]'
+
+'btfs alerts test' sends a test alert to check the channels.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":   alertsLsCmd,
+		"test": alertsTestCmd,
+	},
+}
+
+// AlertChannelOutput is an alert channel, without its options which hold
+// its credentials.
+type AlertChannelOutput struct {
+	Name string
+	Type string
+}
+
+var alertsLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the alert channels.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		c, err := alerts.LoadConfig(n.Repo)
+		if err != nil {
+			return err
+		}
+		out := make([]*AlertChannelOutput, 0, len(c.Channels))
+		for name, ch := range c.Channels {
+			out = append(out, &AlertChannelOutput{Name: name, Type: ch.Type})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+		return cmds.EmitOnce(res, out)
+	},
+	Type: []*AlertChannelOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out []*AlertChannelOutput) error {
+			tw := tabwriter.NewWriter(w, 1, 2, 1, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tTYPE")
+			for _, c := range out {
+				fmt.Fprintf(tw, "%s\t%s\n", c.Name, c.Type)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var alertsTestCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Send a test alert.",
+		ShortDescription: `
+'btfs alerts test' sends a test alert to all the channels, or to the channels
+given.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("channel", false, true, "Channels to send the test alert to, all by default."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		d, err := alerts.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		names := req.Arguments
+		if len(names) == 0 {
+			names = d.Channels()
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no alert channels, see 'btfs alerts --help'")
+		}
+		a := &alerts.Alert{
+			Time:     time.Now(),
+			Peer:     n.Identity.Pretty(),
+			Source:   "test",
+			Severity: alerts.Info,
+			Title:    "test alert",
+			Text:     "The alerts of this node reach this channel.",
+		}
+		if err := d.Send(req.Context, a, names...); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &MessageOutput{fmt.Sprintf("sent a test alert to %s", strings.Join(names, ", "))})
+	},
+	Type: MessageOutput{},
+}
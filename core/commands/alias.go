@@ -0,0 +1,404 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// AliasesConfigKey is the config section holding the command aliases, e.g.
+//
+//  "Aliases": {"up": "storage upload --copies 3"}
+const AliasesConfigKey = "Aliases"
+
+// maxAliasDepth bounds aliases expanding to other aliases.
+const maxAliasDepth = 10
+
+// localOnlyCommands are CLI commands missing from the daemon command tree;
+// aliases cannot shadow them either.
+var localOnlyCommands = []string{"daemon", "init"}
+
+func init() {
+	configschema.RegisterSection(AliasesConfigKey, map[string]string{})
+}
+
+// AliasOutput is one alias and its expansion.
+type AliasOutput struct {
+	Name    string
+	Command string
+}
+
+var AliasCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage command aliases.",
+		ShortDescription: `
+Aliases are shortcuts for commands with a fixed set of arguments and flags,
+stored in the Aliases section of the config and expanded by the btfs CLI:
+
+  > btfs alias add up "storage upload --copies 3 --encrypt"
+  > btfs up <file-hash>
+
+The remaining arguments are appended to the expansion, unless it refers to
+them as $1 to $9 or $@ (all of them), which turns the alias into a macro:
+
+  > btfs alias add pinls 'pin ls --type=recursive $1'
+
+Use single quotes so that the shell does not expand the placeholders.
+Aliases cannot shadow built-in commands, and may expand to other aliases.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"add":  aliasAddCmd,
+		"list": aliasListCmd,
+		"rm":   aliasRmCmd,
+	},
+}
+
+var aliasAddCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Add or replace a command alias.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the alias."),
+		cmds.StringArg("command", true, true, "Command the alias expands to, without the leading 'btfs'."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		name := req.Arguments[0]
+		command := strings.Join(req.Arguments[1:], " ")
+		if err := validateAliasName(req.Root, name); err != nil {
+			return err
+		}
+		words, err := splitAliasWords(command)
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			return errors.New("alias command is empty")
+		}
+
+		err = updateAliases(env, func(aliases map[string]string) error {
+			_, isCommand := req.Root.Subcommands[words[0]]
+			_, isAlias := aliases[words[0]]
+			if !isCommand && !isAlias && !isLocalOnlyCommand(words[0]) {
+				return fmt.Errorf("%q is neither a command nor an alias", words[0])
+			}
+			aliases[name] = command
+			// Reject aliases that end up expanding to themselves.
+			_, err := ExpandAlias(req.Root, []string{name}, aliases)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &AliasOutput{Name: name, Command: command})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *AliasOutput) error {
+			_, err := fmt.Fprintf(w, "added alias %s = %s\n", out.Name, out.Command)
+			return err
+		}),
+	},
+	Type: AliasOutput{},
+}
+
+var aliasListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the command aliases.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfgRoot, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		r, err := fsrepo.Open(cfgRoot)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		aliases := map[string]string{}
+		if _, err := repo.GetConfigSection(r, AliasesConfigKey, &aliases); err != nil {
+			return err
+		}
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		out := make([]AliasOutput, 0, len(names))
+		for _, name := range names {
+			out = append(out, AliasOutput{Name: name, Command: aliases[name]})
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out []AliasOutput) error {
+			for _, a := range out {
+				fmt.Fprintf(w, "%s = %s\n", a.Name, a.Command)
+			}
+			return nil
+		}),
+	},
+	Type: []AliasOutput{},
+}
+
+var aliasRmCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove a command alias.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the alias."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		name := req.Arguments[0]
+		return updateAliases(env, func(aliases map[string]string) error {
+			if _, ok := aliases[name]; !ok {
+				return fmt.Errorf("no alias named %q", name)
+			}
+			delete(aliases, name)
+			return nil
+		})
+	},
+}
+
+// updateAliases loads the aliases, lets update change them and stores them
+// back unless update fails.
+func updateAliases(env cmds.Environment, update func(map[string]string) error) error {
+	cfgRoot, err := cmdenv.GetConfigRoot(env)
+	if err != nil {
+		return err
+	}
+	r, err := fsrepo.Open(cfgRoot)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	aliases := map[string]string{}
+	if _, err := repo.GetConfigSection(r, AliasesConfigKey, &aliases); err != nil {
+		return err
+	}
+	if err := update(aliases); err != nil {
+		return err
+	}
+	return repo.SetConfigSection(r, AliasesConfigKey, aliases)
+}
+
+func validateAliasName(root *cmds.Command, name string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t\n'\"$") {
+		return fmt.Errorf("invalid alias name %q", name)
+	}
+	if _, ok := root.Subcommands[name]; ok || isLocalOnlyCommand(name) {
+		return fmt.Errorf("%q is a built-in command", name)
+	}
+	return nil
+}
+
+func isLocalOnlyCommand(name string) bool {
+	for _, c := range localOnlyCommands {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAliases reads the aliases from the config of the repo at cfgRoot
+// without opening the repo, so that the CLI can expand them while a daemon
+// holds the repo lock. A missing repo has no aliases.
+func LoadAliases(cfgRoot string) (map[string]string, error) {
+	if !fsrepo.IsInitialized(cfgRoot) {
+		return nil, nil
+	}
+	raw, err := configschema.ReadConfig(cfgRoot)
+	if err != nil {
+		return nil, err
+	}
+	section, ok := raw[AliasesConfigKey].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	aliases := make(map[string]string, len(section))
+	for k, v := range section {
+		if s, ok := v.(string); ok {
+			aliases[k] = s
+		}
+	}
+	return aliases, nil
+}
+
+// ConfigRootFromArgs returns the value of the global --config option in
+// the command line args (without the program name), or "".
+func ConfigRootFromArgs(root *cmds.Command, args []string) string {
+	cfg := ""
+	commandIndex(root, args, func(name, value string) {
+		if name == ConfigOption || name == "c" {
+			cfg = value
+		}
+	})
+	return cfg
+}
+
+// ExpandAlias replaces an alias used as the command in args (the command
+// line without the program name) by its expansion. Global options before
+// the command are kept. Built-in commands always take precedence.
+func ExpandAlias(root *cmds.Command, args []string, aliases map[string]string) ([]string, error) {
+	seen := map[string]bool{}
+	for depth := 0; ; depth++ {
+		i := commandIndex(root, args, nil)
+		if i < 0 {
+			return args, nil
+		}
+		name := args[i]
+		if _, ok := root.Subcommands[name]; ok || isLocalOnlyCommand(name) {
+			return args, nil
+		}
+		body, ok := aliases[name]
+		if !ok {
+			return args, nil
+		}
+		if seen[name] || depth >= maxAliasDepth {
+			return nil, fmt.Errorf("alias %q expands to itself", name)
+		}
+		seen[name] = true
+
+		words, err := splitAliasWords(body)
+		if err != nil {
+			return nil, fmt.Errorf("alias %q: %s", name, err)
+		}
+		expanded, err := substituteAliasArgs(name, words, args[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		args = append(append([]string{}, args[:i]...), expanded...)
+	}
+}
+
+// commandIndex returns the index of the first positional argument in args,
+// skipping global options and their values, or -1. Each option seen is
+// passed to visit when it is not nil.
+func commandIndex(root *cmds.Command, args []string, visit func(name, value string)) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				return i + 1
+			}
+			return -1
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return i
+		}
+		name := strings.TrimLeft(arg, "-")
+		value := ""
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			name, value = name[:eq], name[eq+1:]
+		} else if takesValue(root, name) && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if visit != nil {
+			visit(name, value)
+		}
+	}
+	return -1
+}
+
+func takesValue(root *cmds.Command, name string) bool {
+	for _, opt := range root.Options {
+		for _, n := range opt.Names() {
+			if n == name {
+				return opt.Type() != reflect.Bool
+			}
+		}
+	}
+	return false
+}
+
+// substituteAliasArgs fills the $1-$9 and $@ placeholders of words with
+// args, or appends args when no placeholder is used.
+func substituteAliasArgs(name string, words, args []string) ([]string, error) {
+	var out []string
+	used := false
+	for _, w := range words {
+		switch {
+		case w == "$@":
+			used = true
+			out = append(out, args...)
+		case len(w) == 2 && w[0] == '$' && w[1] >= '1' && w[1] <= '9':
+			used = true
+			n, _ := strconv.Atoi(w[1:])
+			if n > len(args) {
+				return nil, fmt.Errorf("alias %q needs at least %d arguments", name, n)
+			}
+			out = append(out, args[n-1])
+		default:
+			out = append(out, w)
+		}
+	}
+	if !used {
+		out = append(out, args...)
+	}
+	return out, nil
+}
+
+// splitAliasWords splits s into words like a POSIX shell would, honoring
+// single quotes, double quotes and backslash escapes.
+func splitAliasWords(s string) ([]string, error) {
+	var (
+		words   []string
+		cur     strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+func TestSplitAliasWords(t *testing.T) {
+	words, err := splitAliasWords(`storage upload --copies 3 "a b" 'c $1' d\ e`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"storage", "upload", "--copies", "3", "a b", "c $1", "d e"}
+	if !reflect.DeepEqual(words, want) {
+		t.Errorf("got %q, want %q", words, want)
+	}
+	if _, err := splitAliasWords(`pin "ls`); err == nil {
+		t.Error("expected an unterminated quote error")
+	}
+}
+
+func TestExpandAlias(t *testing.T) {
+	root := &cmds.Command{
+		Options: []cmds.Option{
+			cmds.StringOption(ConfigOption, "c", ""),
+			cmds.BoolOption(DebugOption, "D", ""),
+		},
+		Subcommands: map[string]*cmds.Command{
+			"pin":     {},
+			"storage": {},
+		},
+	}
+	aliases := map[string]string{
+		"up":    "storage upload --copies 3",
+		"pinls": "pin ls --type=recursive $1",
+		"up2":   "up --encrypt",
+		"loop":  "loop",
+		"pin":   "storage",
+	}
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"up", "Qm1"}, []string{"storage", "upload", "--copies", "3", "Qm1"}},
+		{[]string{"-c", "/repo", "-D", "up"}, []string{"-c", "/repo", "-D", "storage", "upload", "--copies", "3"}},
+		{[]string{"pinls", "Qm1"}, []string{"pin", "ls", "--type=recursive", "Qm1"}},
+		{[]string{"up2", "Qm1"}, []string{"storage", "upload", "--copies", "3", "--encrypt", "Qm1"}},
+		{[]string{"pin", "ls"}, []string{"pin", "ls"}},
+		{[]string{"unknown"}, []string{"unknown"}},
+	}
+	for _, c := range cases {
+		got, err := ExpandAlias(root, c.args, aliases)
+		if err != nil {
+			t.Errorf("%q: %s", c.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %q, want %q", c.args, got, c.want)
+		}
+	}
+	if _, err := ExpandAlias(root, []string{"loop"}, aliases); err == nil {
+		t.Error("expected a recursive alias error")
+	}
+	if _, err := ExpandAlias(root, []string{"pinls"}, aliases); err == nil {
+		t.Error("expected a missing argument error")
+	}
+	if got := ConfigRootFromArgs(root, []string{"--config=/repo", "up"}); got != "/repo" {
+		t.Errorf("got config root %q", got)
+	}
+}
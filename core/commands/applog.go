@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/applog"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	options "github.com/TRON-US/interface-go-btfs-core/options"
+	path "github.com/TRON-US/interface-go-btfs-core/path"
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	logBtnsOptionName    = "btns"
+	logHistoryOptionName = "history"
+)
+
+// LogOutput is a log written by this node.
+type LogOutput struct {
+	Name string
+	ID   string
+}
+
+// LogEntryOutput is an entry of a log.
+type LogEntryOutput struct {
+	Log  string
+	Seq  uint64
+	Cid  string
+	Prev string `json:",omitempty"`
+	Time time.Time
+	Data []byte
+}
+
+func newLogEntryOutput(c cid.Cid, e *applog.Entry) *LogEntryOutput {
+	out := &LogEntryOutput{
+		Log:  e.Log,
+		Seq:  e.Seq,
+		Cid:  c.String(),
+		Time: time.Unix(e.Time, 0),
+		Data: e.Data,
+	}
+	if e.Prev != nil {
+		out.Prev = e.Prev.String()
+	}
+	return out
+}
+
+var logEntryEncoder = cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *LogEntryOutput) error {
+	_, err := fmt.Fprintf(w, "%d %s %s\n", out.Seq, out.Cid, out.Data)
+	return err
+})
+
+var logCreateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Create a signed append-only log.",
+		ShortDescription: `
+Creates the empty log <name> and the ed25519 key <name> signing its entries
+in the keystore. The log is named by the ID of its key, which readers pass
+to 'btfs log follow'.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the log."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		name := req.Arguments[0]
+		s := applog.ForNode(n)
+		if _, err := s.Get(name); err == nil {
+			return applog.ErrExists
+		}
+		key, err := api.Key().Generate(req.Context, name, options.Key.Type(options.Ed25519Key))
+		if err != nil {
+			return err
+		}
+		l, err := s.Create(name, key.ID())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &LogOutput{Name: l.Name, ID: l.ID})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *LogOutput) error {
+			_, err := fmt.Fprintf(w, "created log %s: %s\n", out.Name, out.ID)
+			return err
+		}),
+	},
+	Type: LogOutput{},
+}
+
+var logAppendCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Append an entry to a log.",
+		ShortDescription: `
+Signs and stores an entry with the data, pins the new head of the log and
+announces it to the followers on pubsub when the daemon runs with
+--enable-pubsub-experiment. The head is also published under the BTNS name
+of the log, for followers joining later.
+
+  > echo "deployed v1.2.0" | btfs log append releases
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the log."),
+		cmds.FileArg("data", true, false, "Data of the entry.").EnableStdin(),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(logBtnsOptionName, "Publish the new head under the BTNS name of the log.").WithDefault(true),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		file, err := cmdenv.GetFileArg(req.Files.Entries())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		data, err := ioutil.ReadAll(io.LimitReader(file, applog.MaxDataSize+1))
+		if err != nil {
+			return err
+		}
+
+		name := req.Arguments[0]
+		s := applog.ForNode(n)
+		l, err := s.Get(name)
+		if err != nil {
+			return err
+		}
+		priv, err := n.Repo.Keystore().Get(name)
+		if err != nil {
+			return fmt.Errorf("cannot get the key of log %s: %s", name, err)
+		}
+		c, e, err := s.Append(req.Context, api.Dag(), name, priv, data)
+		if err != nil {
+			return err
+		}
+		head := path.IpfsPath(c)
+		if l.Head == "" {
+			err = api.Pin().Add(req.Context, head)
+		} else {
+			err = api.Pin().Update(req.Context, path.New(l.Head), head)
+		}
+		if err != nil {
+			return err
+		}
+
+		if n.PubSub != nil {
+			id, err := peer.IDB58Decode(l.ID)
+			if err != nil {
+				return err
+			}
+			if err := api.PubSub().Publish(req.Context, applog.Topic(id), []byte(c.String())); err != nil {
+				return err
+			}
+		}
+		if btns, _ := req.Options[logBtnsOptionName].(bool); btns {
+			_, err := api.Name().Publish(req.Context, head, options.Name.Key(name), options.Name.AllowOffline(true))
+			if err != nil {
+				return err
+			}
+		}
+		return cmds.EmitOnce(res, newLogEntryOutput(c, e))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: logEntryEncoder,
+	},
+	Type: LogEntryOutput{},
+}
+
+var logFollowCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Print the entries of a log as they are appended.",
+		ShortDescription: `
+Prints the entries appended to the log <log-id> as their heads are
+announced on pubsub, after checking they are signed by the key of the log
+and follow each other. Needs a daemon run with --enable-pubsub-experiment.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("log-id", true, false, "ID of the log."),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(logHistoryOptionName, "Print the entries already in the log first."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		id, err := peer.IDB58Decode(req.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("invalid log id: %s", err)
+		}
+		sub, err := api.PubSub().Subscribe(req.Context, applog.Topic(id), options.PubSub.Discover(true))
+		if err != nil {
+			return err
+		}
+		defer sub.Close()
+
+		if f, ok := res.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		// seq is the last entry printed, unknown until a head is read
+		var (
+			seq   uint64
+			known bool
+		)
+		emit := func(head cid.Cid, history bool) error {
+			if !known && !history {
+				e, err := applog.Get(req.Context, api.Dag(), id, head)
+				if err != nil {
+					log.Warnf("ignoring head %s of log %s: %s", head, id.Pretty(), err)
+					return nil
+				}
+				seq = e.Seq - 1
+			}
+			cids, entries, err := applog.Walk(req.Context, api.Dag(), id, head, seq)
+			if err != nil {
+				log.Warnf("ignoring head %s of log %s: %s", head, id.Pretty(), err)
+				return nil
+			}
+			known = true
+			for i, e := range entries {
+				if err := res.Emit(newLogEntryOutput(cids[i], e)); err != nil {
+					return err
+				}
+				seq = e.Seq
+			}
+			return nil
+		}
+
+		// the head published under the BTNS name of the log
+		history, _ := req.Options[logHistoryOptionName].(bool)
+		if p, err := api.Name().Resolve(req.Context, id.Pretty()); err != nil {
+			log.Warnf("cannot resolve the head of log %s: %s", id.Pretty(), err)
+		} else if rp, err := api.ResolvePath(req.Context, p); err != nil {
+			log.Warnf("cannot resolve the head of log %s: %s", id.Pretty(), err)
+		} else if history {
+			if err := emit(rp.Cid(), true); err != nil {
+				return err
+			}
+		} else if e, err := applog.Get(req.Context, api.Dag(), id, rp.Cid()); err != nil {
+			log.Warnf("cannot read the head of log %s: %s", id.Pretty(), err)
+		} else {
+			seq, known = e.Seq, true
+		}
+
+		for {
+			msg, err := sub.Next(req.Context)
+			if err == io.EOF || err == context.Canceled {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			head, err := cid.Decode(string(msg.Data()))
+			if err != nil {
+				log.Warnf("invalid head of log %s from %s", id.Pretty(), msg.From().Pretty())
+				continue
+			}
+			if err := emit(head, history); err != nil {
+				return err
+			}
+		}
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: logEntryEncoder,
+	},
+	Type: LogEntryOutput{},
+}
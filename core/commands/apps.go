@@ -0,0 +1,411 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/apps"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+)
+
+const appQuotaOptionName = "quota"
+
+// AppOutput is an application registered on the node.
+type AppOutput struct {
+	ID        string
+	Namespace string
+	Quota     int64
+	Used      uint64
+	Snapshots int
+	Created   time.Time
+	// Token is the API token of the application, only set when it is
+	// registered or reset.
+	Token string `json:",omitempty"`
+}
+
+func newAppOutput(n *core.IpfsNode, a *apps.App, token string) (*AppOutput, error) {
+	used, err := apps.Usage(n.FilesRoot, a)
+	if err != nil {
+		return nil, err
+	}
+	return &AppOutput{
+		ID:        a.ID,
+		Namespace: a.Namespace(),
+		Quota:     a.Quota,
+		Used:      used,
+		Snapshots: len(a.Snapshots),
+		Created:   a.Created,
+		Token:     token,
+	}, nil
+}
+
+var AppsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the applications sharing the files API of the node.",
+		ShortDescription: `
+Registering an application gives it its own MFS namespace, /apps/<id>, and
+an API token. Callers with the token, 'Authorization: Bearer <token>', may
+only run 'btfs files' in the namespace, which they see as the root, and
+'btfs apps snapshot' on their own namespace, so that dApps sharing a node
+cannot trample each other's directory trees:
+
+    $ btfs apps add mydapp --quota 1GB
+    $ btfs apps snapshot create mydapp before-migration
+    $ btfs apps snapshot rollback mydapp before-migration
+
+A files command leaving the namespace over its quota is undone and fails.
+The snapshots are kept in /.snapshots/apps/<id>, out of reach of the
+application.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"add":      appsAddCmd,
+		"list":     appsListCmd,
+		"rm":       appsRmCmd,
+		"set":      appsSetCmd,
+		"token":    appsTokenCmd,
+		"snapshot": appsSnapshotCmd,
+	},
+}
+
+func parseQuota(s string) (int64, error) {
+	q, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s: %s", appQuotaOptionName, err)
+	}
+	return int64(q), nil
+}
+
+func formatQuota(a *AppOutput) string {
+	if a.Quota == 0 {
+		return humanize.Bytes(a.Used) + " / unlimited"
+	}
+	return humanize.Bytes(a.Used) + " / " + humanize.Bytes(uint64(a.Quota))
+}
+
+var appEncoder = cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *AppOutput) error {
+	fmt.Fprintf(w, "%s in %s, %s used, %d snapshots\n", out.ID, out.Namespace, formatQuota(out), out.Snapshots)
+	if out.Token != "" {
+		fmt.Fprintf(w, "token: %s\n", out.Token)
+		fmt.Fprintln(w, "The token is not shown again, keep it safe.")
+	}
+	return nil
+})
+
+// appsAccess returns the node and its applications, for the application
+// id. An application may only reach itself.
+func appsAccess(req *cmds.Request, env cmds.Environment, id string) (*core.IpfsNode, *apps.Store, error) {
+	if a := apps.FromContext(req.Context); a != nil && a.ID != id {
+		return nil, nil, cmds.Errorf(cmds.ErrForbidden, "app %s may not reach app %s", a.ID, id)
+	}
+	n, err := cmdenv.GetNode(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n, apps.ForNode(n), nil
+}
+
+var appsAddCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Register an application and print its API token.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("id", true, false, "ID of the application."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(appQuotaOptionName, "Bytes the namespace may hold, e.g. 1GB. 0 for no limit.").WithDefault("0"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		quota, err := parseQuota(req.Options[appQuotaOptionName].(string))
+		if err != nil {
+			return err
+		}
+		n, s, err := appsAccess(req, env, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		a, token, err := s.Add(req.Arguments[0], quota, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := apps.Mkdir(n.FilesRoot, a); err != nil {
+			return err
+		}
+		out, err := newAppOutput(n, a, token)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: appEncoder,
+	},
+	Type: AppOutput{},
+}
+
+var appsListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the applications.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		as, err := apps.ForNode(n).List()
+		if err != nil {
+			return err
+		}
+		out := make([]*AppOutput, 0, len(as))
+		for _, a := range as {
+			o, err := newAppOutput(n, a, "")
+			if err != nil {
+				return err
+			}
+			out = append(out, o)
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out []*AppOutput) error {
+			tw := tabwriter.NewWriter(w, 1, 2, 1, ' ', 0)
+			fmt.Fprintln(tw, "ID\tNAMESPACE\tUSED\tSNAPSHOTS\tCREATED")
+			for _, a := range out {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", a.ID, a.Namespace, formatQuota(a), a.Snapshots,
+					a.Created.Format(time.RFC3339))
+			}
+			return tw.Flush()
+		}),
+	},
+	Type: []*AppOutput{},
+}
+
+var appsRmCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Unregister an application.",
+		ShortDescription: `
+The token of the application stops working. Its namespace and snapshots are
+kept, and can be removed with 'btfs files rm -r /apps/<id>' and
+'btfs files rm -r /.snapshots/apps/<id>'.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("id", true, false, "ID of the application."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		return apps.ForNode(n).Remove(req.Arguments[0])
+	},
+}
+
+var appsSetCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Change the quota of an application.",
+		ShortDescription: `
+The quota applies to the next files commands, a namespace already over it
+only accepts the commands which do not grow it.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("id", true, false, "ID of the application."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(appQuotaOptionName, "Bytes the namespace may hold, e.g. 1GB. 0 for no limit."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		quotaOpt, ok := req.Options[appQuotaOptionName].(string)
+		if !ok {
+			return fmt.Errorf("nothing to change, use --%s", appQuotaOptionName)
+		}
+		quota, err := parseQuota(quotaOpt)
+		if err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		a, err := apps.ForNode(n).Update(req.Arguments[0], func(a *apps.App) error {
+			a.Quota = quota
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		out, err := newAppOutput(n, a, "")
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: appEncoder,
+	},
+	Type: AppOutput{},
+}
+
+var appsTokenCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Replace the API token of an application.",
+		ShortDescription: `
+Prints a new token for the application, its previous token stops working.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("id", true, false, "ID of the application."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		s := apps.ForNode(n)
+		token, err := s.ResetToken(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		a, err := s.Get(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		out, err := newAppOutput(n, a, token)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: appEncoder,
+	},
+	Type: AppOutput{},
+}
+
+var appsSnapshotCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Take snapshots of the namespace of an application and roll it back.",
+		ShortDescription: `
+A snapshot copies the namespace of an application as it is, without
+duplicating its blocks. Rolling back replaces the namespace with the
+snapshot, which is kept, and leaves the other namespaces untouched. An
+application may manage the snapshots of its own namespace with its token.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"create":   appsSnapshotCreateCmd,
+		"ls":       appsSnapshotLsCmd,
+		"rollback": appsSnapshotRollbackCmd,
+		"rm":       appsSnapshotRmCmd,
+	},
+}
+
+var snapshotArgs = []cmds.Argument{
+	cmds.StringArg("id", true, false, "ID of the application."),
+	cmds.StringArg("name", true, false, "Name of the snapshot."),
+}
+
+func writeSnapshot(w io.Writer, snap *apps.Snapshot) {
+	fmt.Fprintf(w, "%s: %s (%s), %s\n", snap.Name, snap.Cid, humanize.Bytes(snap.Size), snap.Created.Format(time.RFC3339))
+}
+
+var snapshotEncoder = cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *apps.Snapshot) error {
+	writeSnapshot(w, out)
+	return nil
+})
+
+var appsSnapshotCreateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Take a snapshot of the namespace of an application.",
+	},
+	Arguments: snapshotArgs,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, s, err := appsAccess(req, env, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		snap, err := s.TakeSnapshot(req.Context, n.FilesRoot, req.Arguments[0], req.Arguments[1], time.Now())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, snap)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: snapshotEncoder,
+	},
+	Type: apps.Snapshot{},
+}
+
+var appsSnapshotLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the snapshots of the namespace of an application.",
+	},
+	Arguments: snapshotArgs[:1],
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		_, s, err := appsAccess(req, env, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		a, err := s.Get(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		out := a.Snapshots
+		if out == nil {
+			out = []*apps.Snapshot{}
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out []*apps.Snapshot) error {
+			for _, snap := range out {
+				writeSnapshot(w, snap)
+			}
+			return nil
+		}),
+	},
+	Type: []*apps.Snapshot{},
+}
+
+var appsSnapshotRollbackCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Roll the namespace of an application back to a snapshot.",
+	},
+	Arguments: snapshotArgs,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, s, err := appsAccess(req, env, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		snap, err := s.Rollback(req.Context, n.FilesRoot, req.Arguments[0], req.Arguments[1])
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, snap)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: snapshotEncoder,
+	},
+	Type: apps.Snapshot{},
+}
+
+var appsSnapshotRmCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove a snapshot of the namespace of an application.",
+	},
+	Arguments: snapshotArgs,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, s, err := appsAccess(req, env, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		return s.RemoveSnapshot(req.Context, n.FilesRoot, req.Arguments[0], req.Arguments[1])
+	},
+}
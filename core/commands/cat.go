@@ -37,6 +37,7 @@ var CatCmd = &cmds.Command{
 		cmds.BoolOption(catMetaDisplayOptionName, "m", "Display token metadata"),
 		cmds.BoolOption(decryptName, "d", "Decrypt the file."),
 		cmds.StringOption(privateKeyName, "pk", "The private key to decrypt file."),
+		cmds.BoolOption(cmdenv.ProgressOptionName, "Show progress on stderr for large outputs. Defaults to true when stderr is a terminal."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		api, err := cmdenv.GetApi(env, req)
@@ -65,7 +66,7 @@ var CatCmd = &cmds.Command{
 			return err
 		}
 
-		readers, length, err := cat(req.Context, api, req.Arguments, int64(offset), int64(max), meta, req.Options)
+		readers, length, err := cat(req.Context, env, api, req.Arguments, int64(offset), int64(max), meta, req.Options)
 		if err != nil {
 			return err
 		}
@@ -88,7 +89,8 @@ var CatCmd = &cmds.Command{
 	},
 	PostRun: cmds.PostRunMap{
 		cmds.CLI: func(res cmds.Response, re cmds.ResponseEmitter) error {
-			if res.Length() > 0 && res.Length() < progressBarMinSize {
+			if !cmdenv.ProgressEnabled(res.Request()) ||
+				res.Length() > 0 && res.Length() < progressBarMinSize {
 				return cmds.Copy(re, res)
 			}
 
@@ -103,8 +105,7 @@ var CatCmd = &cmds.Command{
 
 				switch val := v.(type) {
 				case io.Reader:
-					bar, reader := progressBarForReader(os.Stderr, val, int64(res.Length()))
-					bar.Start()
+					_, reader := progressBarForReader(os.Stderr, val, int64(res.Length()))
 
 					err = re.Emit(reader)
 					if err != nil {
@@ -118,7 +119,7 @@ var CatCmd = &cmds.Command{
 	},
 }
 
-func cat(ctx context.Context, api iface.CoreAPI, paths []string, offset int64, max int64, meta bool, opts cmds.OptMap) ([]io.Reader, uint64, error) {
+func cat(ctx context.Context, env cmds.Environment, api iface.CoreAPI, paths []string, offset int64, max int64, meta bool, opts cmds.OptMap) ([]io.Reader, uint64, error) {
 	readers := make([]io.Reader, 0, len(paths))
 	length := uint64(0)
 	if max == 0 {
@@ -138,9 +139,22 @@ func cat(ctx context.Context, api iface.CoreAPI, paths []string, offset int64, m
 	}
 
 	for _, p := range paths {
-		f, err := api.Unixfs().Get(ctx, path.New(p), getOptions...)
-		if err != nil {
-			return nil, 0, err
+		var f files.Node
+		if opts[decryptName].(bool) && !meta {
+			df, err := openDekFile(ctx, env, api, path.New(p))
+			if err != nil {
+				return nil, 0, err
+			}
+			if df != nil {
+				f = df
+			}
+		}
+		if f == nil {
+			var err error
+			f, err = api.Unixfs().Get(ctx, path.New(p), getOptions...)
+			if err != nil {
+				return nil, 0, err
+			}
 		}
 
 		var file files.File
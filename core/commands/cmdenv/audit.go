@@ -0,0 +1,44 @@
+package cmdenv
+
+import (
+	"sync"
+
+	"github.com/TRON-US/go-btfs/core/audit"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+var (
+	withAuditLk sync.Mutex
+	withAudit   = map[*cmds.Command]bool{}
+)
+
+// WithAudit makes cmd and all its subcommands record their execution in
+// the audit log when they are run through the daemon API (see audit.Start).
+// Commands shared by several trees are only wrapped once.
+func WithAudit(cmd *cmds.Command) *cmds.Command {
+	withAuditLk.Lock()
+	defer withAuditLk.Unlock()
+	addAudit(cmd)
+	return cmd
+}
+
+func addAudit(cmd *cmds.Command) {
+	if withAudit[cmd] {
+		return
+	}
+	withAudit[cmd] = true
+	if run := cmd.Run; run != nil {
+		cmd.Run = func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+			done := audit.Start(req)
+			err := run(req, res, env)
+			if done != nil {
+				done(err)
+			}
+			return err
+		}
+	}
+	for _, sub := range cmd.Subcommands {
+		addAudit(sub)
+	}
+}
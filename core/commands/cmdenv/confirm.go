@@ -0,0 +1,79 @@
+package cmdenv
+
+import (
+	"fmt"
+	"strings"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// YesOptionName is the option confirming a destructive operation up front.
+const YesOptionName = "yes"
+
+// OptionYes is added to commands asking for a confirmation. The btfs CLI
+// asks on the terminal when it is not given; API requests without it are
+// not asked anything.
+var OptionYes = cmds.BoolOption(YesOptionName, "y", "Do not ask for confirmation.")
+
+// maxAffected limits the affected items listed by a summary.
+const maxAffected = 20
+
+// confirmationPrefix marks the errors asking the CLI for a confirmation, so
+// that they can be recognized after going through the HTTP API.
+const confirmationPrefix = "confirmation required: "
+
+// ConfirmationError is returned by commands that need a confirmation before
+// going on. It describes what is about to happen.
+type ConfirmationError struct {
+	Action   string
+	Affected []string
+}
+
+func (e *ConfirmationError) Error() string {
+	return confirmationPrefix + e.Summary()
+}
+
+// Summary is the action and what it affects, one item per line and at
+// most maxAffected items.
+func (e *ConfirmationError) Summary() string {
+	var b strings.Builder
+	b.WriteString(e.Action)
+	for i, a := range e.Affected {
+		if i == maxAffected {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(e.Affected)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n  - %s", a)
+	}
+	return b.String()
+}
+
+// RequireConfirmation returns a ConfirmationError for action unless req
+// confirms it with --yes or comes from an API client that does not know
+// about the option.
+func RequireConfirmation(req *cmds.Request, action string, affected []string) error {
+	yes, asked := req.Options[YesOptionName].(bool)
+	if yes || !asked {
+		return nil
+	}
+	return &ConfirmationError{Action: action, Affected: affected}
+}
+
+// ConfirmationSummary returns the summary of a ConfirmationError from its
+// message, and whether msg is one.
+func ConfirmationSummary(msg string) (string, bool) {
+	if !strings.HasPrefix(msg, confirmationPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(msg, confirmationPrefix), true
+}
+
+// HasYesOption reports whether cmd asks for confirmations.
+func HasYesOption(cmd *cmds.Command) bool {
+	for _, opt := range cmd.Options {
+		if opt.Name() == YesOptionName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package cmdenv
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+func TestRequireConfirmation(t *testing.T) {
+	affected := []string{"/a", "/b"}
+	cases := []struct {
+		options cmds.OptMap
+		ask     bool
+	}{
+		{cmds.OptMap{}, false},
+		{cmds.OptMap{YesOptionName: true}, false},
+		{cmds.OptMap{YesOptionName: false}, true},
+	}
+	for _, c := range cases {
+		err := RequireConfirmation(&cmds.Request{Options: c.options}, "remove:", affected)
+		if (err != nil) != c.ask {
+			t.Errorf("options %v: got %v, want confirmation %v", c.options, err, c.ask)
+			continue
+		}
+		if err == nil {
+			continue
+		}
+		summary, ok := ConfirmationSummary(err.Error())
+		if !ok || summary != "remove:\n  - /a\n  - /b" {
+			t.Errorf("summary %q %v", summary, ok)
+		}
+	}
+	if _, ok := ConfirmationSummary("some other error"); ok {
+		t.Error("plain error taken for a confirmation")
+	}
+}
+
+func TestConfirmationSummaryLimit(t *testing.T) {
+	var affected []string
+	for i := 0; i < maxAffected+5; i++ {
+		affected = append(affected, fmt.Sprint(i))
+	}
+	summary := (&ConfirmationError{Action: "gc:", Affected: affected}).Summary()
+	lines := strings.Split(summary, "\n")
+	if len(lines) != maxAffected+2 || lines[len(lines)-1] != "  ... and 5 more" {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
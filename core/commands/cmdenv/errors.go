@@ -0,0 +1,40 @@
+package cmdenv
+
+import (
+	"sync"
+
+	"github.com/TRON-US/go-btfs/core/commands/e"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+var (
+	withCodesLk sync.Mutex
+	withCodes   = map[*cmds.Command]bool{}
+)
+
+// WithErrorCodes makes cmd and all its subcommands return their errors
+// with an error code (see e.Code), which the CLI turns into its exit
+// status. Errors due to the timeout of a command say so (see
+// DeadlineError). Commands shared by several trees are only wrapped once.
+func WithErrorCodes(cmd *cmds.Command) *cmds.Command {
+	withCodesLk.Lock()
+	defer withCodesLk.Unlock()
+	addErrorCodes(cmd)
+	return cmd
+}
+
+func addErrorCodes(cmd *cmds.Command) {
+	if withCodes[cmd] {
+		return
+	}
+	withCodes[cmd] = true
+	if run := cmd.Run; run != nil {
+		cmd.Run = func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+			return e.WithCode(DeadlineError(req, run(req, res, env)))
+		}
+	}
+	for _, sub := range cmd.Subcommands {
+		addErrorCodes(sub)
+	}
+}
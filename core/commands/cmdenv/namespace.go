@@ -0,0 +1,113 @@
+package cmdenv
+
+import (
+	"fmt"
+	gopath "path"
+	"strings"
+	"sync"
+
+	"github.com/TRON-US/go-btfs/core/apps"
+	"github.com/TRON-US/go-btfs/core/users"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"github.com/TRON-US/go-mfs"
+)
+
+var (
+	withNamespaceLk sync.Mutex
+	withNamespace   = map[*cmds.Command]bool{}
+)
+
+// Namespace returns the MFS directory the caller of req works in: the
+// namespace of the application or of the account running it, "" for the
+// whole MFS.
+func Namespace(req *cmds.Request) string {
+	if a := apps.FromContext(req.Context); a != nil {
+		return a.Namespace()
+	}
+	if u := users.FromContext(req.Context); u != nil {
+		return u.Namespace()
+	}
+	return ""
+}
+
+// WithNamespace makes the MFS commands cmd and its subcommands work in the
+// namespace of the application or account running them (see Namespace):
+// MFS paths of the arguments are resolved in the namespace, which is
+// created when first used. Paths of content (/btfs/..., /btns/...) are left
+// as is. A command run by an application leaving its namespace over its
+// quota is undone.
+func WithNamespace(cmd *cmds.Command) *cmds.Command {
+	withNamespaceLk.Lock()
+	defer withNamespaceLk.Unlock()
+	addNamespace(cmd)
+	return cmd
+}
+
+func addNamespace(cmd *cmds.Command) {
+	if withNamespace[cmd] {
+		return
+	}
+	withNamespace[cmd] = true
+	if run := cmd.Run; run != nil {
+		cmd.Run = func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+			ns := Namespace(req)
+			if ns == "" {
+				return run(req, res, env)
+			}
+			nd, err := GetNode(env)
+			if err != nil {
+				return err
+			}
+			err = mfs.Mkdir(nd.FilesRoot, ns, mfs.MkdirOpts{Mkparents: true, Flush: true})
+			if err != nil && err != mfs.ErrDirExists {
+				return err
+			}
+			// optional paths default to the root
+			if len(req.Arguments) < len(cmd.Arguments) && !cmd.Arguments[len(req.Arguments)].Required {
+				req.Arguments = append(req.Arguments, "/")
+			}
+			for i, arg := range req.Arguments {
+				if !strings.HasPrefix(arg, "/") || isContentPath(arg) {
+					continue
+				}
+				if p := gopath.Clean(arg); p != "/" {
+					req.Arguments[i] = ns + p
+				} else {
+					req.Arguments[i] = ns
+				}
+			}
+			a := apps.FromContext(req.Context)
+			if a == nil || a.Quota == 0 {
+				return run(req, res, env)
+			}
+			before, err := apps.Tree(nd.FilesRoot, a)
+			if err != nil {
+				return err
+			}
+			var size uint64
+			if before != nil {
+				if size, err = before.Size(); err != nil {
+					return err
+				}
+			}
+			if err := run(req, res, env); err != nil {
+				return err
+			}
+			if qerr := apps.CheckQuota(nd.FilesRoot, a, size); qerr != nil {
+				if err := apps.Replace(req.Context, nd.FilesRoot, a, before); err != nil {
+					return fmt.Errorf("%s, and undoing the command failed: %s", qerr, err)
+				}
+				return qerr
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Subcommands {
+		addNamespace(sub)
+	}
+}
+
+func isContentPath(p string) bool {
+	return strings.HasPrefix(p, "/btfs/") || strings.HasPrefix(p, "/btns/")
+}
@@ -0,0 +1,251 @@
+package cmdenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	OutputOptionName = "output"
+	QuietOptionName  = "quiet"
+
+	// YAML is the encoding type selected by --output yaml.
+	YAML cmds.EncodingType = "yaml"
+	// Quiet is the encoding type selected by --quiet, it writes nothing.
+	Quiet cmds.EncodingType = "quiet"
+
+	// typeText is the generated text encoding used by --output text on
+	// commands without a text encoder of their own. Without --output such
+	// commands keep defaulting to JSON.
+	typeText cmds.EncodingType = "typetext"
+)
+
+var OptionOutput = cmds.StringOption(OutputOptionName, "Output format: text, json or yaml. Overrides --encoding.")
+var OptionQuiet = cmds.BoolOption(QuietOptionName, "q", "Write no output, only report errors and the exit status.")
+
+func init() {
+	cmds.Encoders[YAML] = func(req *cmds.Request) func(io.Writer) cmds.Encoder {
+		return func(w io.Writer) cmds.Encoder { return &yamlEncoder{w: w} }
+	}
+	cmds.Encoders[Quiet] = func(req *cmds.Request) func(io.Writer) cmds.Encoder {
+		return func(w io.Writer) cmds.Encoder { return quietEncoder{} }
+	}
+}
+
+// WithOutputOptions adds --output and --quiet to cmd and all its
+// subcommands, and gives every runnable subcommand without a text encoder
+// one generated from its response for --output text. It panics if a
+// subcommand already defines one of the option names.
+func WithOutputOptions(cmd *cmds.Command) *cmds.Command {
+	checkOutputOptions(cmd)
+	cmd.Options = append(cmd.Options, OptionOutput, OptionQuiet)
+	addTypeEncoders(cmd)
+	return cmd
+}
+
+func checkOutputOptions(cmd *cmds.Command) {
+	for _, opt := range cmd.Options {
+		for _, name := range opt.Names() {
+			for _, reserved := range append(OptionOutput.Names(), OptionQuiet.Names()...) {
+				if name == reserved {
+					panic(fmt.Sprintf("option %q is reserved for the output options", name))
+				}
+			}
+		}
+	}
+	for _, sub := range cmd.Subcommands {
+		checkOutputOptions(sub)
+	}
+}
+
+func addTypeEncoders(cmd *cmds.Command) {
+	if cmd.Run != nil {
+		if _, ok := cmd.Encoders[cmds.Text]; !ok {
+			if cmd.Encoders == nil {
+				cmd.Encoders = cmds.EncoderMap{}
+			}
+			cmd.Encoders[typeText] = cmds.MakeEncoder(func(req *cmds.Request, w io.Writer, v interface{}) error {
+				return writeText(w, "", reflect.ValueOf(v))
+			})
+		}
+	}
+	for _, sub := range cmd.Subcommands {
+		addTypeEncoders(sub)
+	}
+}
+
+// ApplyOutputOptions maps --output and --quiet onto the encoding of req.
+func ApplyOutputOptions(req *cmds.Request) error {
+	output, _ := req.Options[OutputOptionName].(string)
+	quiet, _ := req.Options[QuietOptionName].(bool)
+	switch {
+	case quiet && output != "":
+		return fmt.Errorf("--%s can't be combined with --%s", QuietOptionName, OutputOptionName)
+	case quiet:
+		req.SetOption(cmds.EncLong, string(Quiet))
+	case output != "":
+		switch enc := cmds.EncodingType(strings.ToLower(output)); enc {
+		case cmds.Text:
+			if _, ok := req.Command.Encoders[typeText]; ok {
+				enc = typeText
+			}
+			req.SetOption(cmds.EncLong, string(enc))
+		case cmds.JSON, YAML:
+			req.SetOption(cmds.EncLong, string(enc))
+		default:
+			return fmt.Errorf("unsupported output format %q, expected text, json or yaml", output)
+		}
+	}
+	return nil
+}
+
+type quietEncoder struct{}
+
+func (quietEncoder) Encode(interface{}) error { return nil }
+
+// yamlEncoder writes every value as a YAML document. Values go through JSON
+// first so the field names match the JSON output.
+type yamlEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *yamlEncoder) Encode(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic yaml.MapSlice
+	var out interface{} = &generic
+	if len(b) == 0 || b[0] != '{' {
+		out = new(interface{})
+	}
+	if err := yaml.Unmarshal(b, out); err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, "---\n"); err != nil {
+			return err
+		}
+	}
+	e.count++
+	b, err = yaml.Marshal(out)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+// writeText writes v as indented "Name: value" lines.
+func writeText(w io.Writer, indent string, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if f.Anonymous {
+				if err := writeText(w, indent, v.Field(i)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := writeTextField(w, indent, f.Name, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			if err := writeTextField(w, indent, fmt.Sprint(k.Interface()), v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if isScalar(v.Index(i)) {
+				if _, err := fmt.Fprintf(w, "%s%s\n", indent, scalarText(v.Index(i))); err != nil {
+					return err
+				}
+				continue
+			}
+			if i > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			if err := writeText(w, indent, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		_, err := fmt.Fprintf(w, "%s%s\n", indent, scalarText(v))
+		return err
+	}
+	return nil
+}
+
+func writeTextField(w io.Writer, indent, name string, v reflect.Value) error {
+	if isScalar(v) {
+		_, err := fmt.Fprintf(w, "%s%s: %s\n", indent, name, scalarText(v))
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s%s:\n", indent, name); err != nil {
+		return err
+	}
+	return writeText(w, indent+"  ", v)
+}
+
+// scalarText formats a value, printing numbers decoded from JSON without
+// an exponent.
+func scalarText(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+	if f, ok := v.Interface().(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func isScalar(v reflect.Value) bool {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		// Byte slices and types with their own formatting print as values
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return true
+		}
+		_, ok := v.Interface().(fmt.Stringer)
+		return ok
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package cmdenv
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+type outputTestResult struct {
+	Name  string
+	Size  float64
+	Peers []string
+	Info  map[string]interface{}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	res := &outputTestResult{
+		Name:  "host",
+		Size:  255592763392,
+		Peers: []string{"a", "b"},
+		Info:  map[string]interface{}{"z": true, "a": 1},
+	}
+	if err := writeText(&buf, "", reflect.ValueOf(res)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Name: host\nSize: 255592763392\nPeers:\n  a\n  b\nInfo:\n  a: 1\n  z: true\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected text output:\n%s", buf.String())
+	}
+}
+
+func TestYAMLEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := &yamlEncoder{w: &buf}
+	if err := enc.Encode(&outputTestResult{Name: "a", Peers: []string{"p"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode("second"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Name: a\nSize: 0\nPeers:\n- p\nInfo: null\n---\nsecond\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected yaml output:\n%s", buf.String())
+	}
+}
+
+func TestApplyOutputOptions(t *testing.T) {
+	cmd := WithOutputOptions(&cmds.Command{
+		Run: func(*cmds.Request, cmds.ResponseEmitter, cmds.Environment) error { return nil },
+	})
+	for _, tc := range []struct {
+		opts map[string]interface{}
+		enc  string
+		ok   bool
+	}{
+		{map[string]interface{}{OutputOptionName: "YAML"}, string(YAML), true},
+		{map[string]interface{}{OutputOptionName: "text"}, string(typeText), true},
+		{map[string]interface{}{QuietOptionName: true}, string(Quiet), true},
+		{map[string]interface{}{QuietOptionName: true, OutputOptionName: "json"}, "", false},
+		{map[string]interface{}{OutputOptionName: "xml"}, "", false},
+	} {
+		req := &cmds.Request{Root: cmd, Command: cmd, Options: tc.opts}
+		err := ApplyOutputOptions(req)
+		if (err == nil) != tc.ok {
+			t.Fatalf("%v: unexpected error %v", tc.opts, err)
+		}
+		if enc, _ := req.Options[cmds.EncLong].(string); tc.ok && enc != tc.enc {
+			t.Fatalf("%v: expected encoding %s, got %s", tc.opts, tc.enc, enc)
+		}
+	}
+}
+
+func TestWithOutputOptionsConflict(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a conflicting quiet option to panic")
+		}
+	}()
+	WithOutputOptions(&cmds.Command{Subcommands: map[string]*cmds.Command{
+		"ls": {Options: []cmds.Option{cmds.BoolOption("quiet", "Quiet.")}},
+	}})
+}
@@ -0,0 +1,216 @@
+package cmdenv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// ProgressOptionName is the option turning progress rendering on and off
+// on commands that support it.
+const ProgressOptionName = "progress"
+
+// progressInterval limits how often the progress line is redrawn.
+const progressInterval = 200 * time.Millisecond
+
+// Progress is a structured progress event of a long running operation.
+// Zero totals are unknown.
+type Progress struct {
+	Stage       string   `json:",omitempty"`
+	Bytes       int64    `json:",omitempty"`
+	TotalBytes  int64    `json:",omitempty"`
+	Shards      int      `json:",omitempty"`
+	TotalShards int      `json:",omitempty"`
+	Hosts       []string `json:",omitempty"`
+}
+
+// ProgressDefault reports whether progress should be rendered when the
+// user did not ask either way: only when stderr is a terminal, so logs
+// and pipes stay clean.
+func ProgressDefault() bool {
+	return terminal.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// ProgressEnabled returns the --progress option of req, defaulting to
+// ProgressDefault.
+func ProgressEnabled(req *cmds.Request) bool {
+	if p, ok := req.Options[ProgressOptionName].(bool); ok {
+		return p
+	}
+	return ProgressDefault()
+}
+
+// ProgressBar renders Progress events as a single self-updating line:
+// stage, bytes, shards, rate based ETA and the hosts currently involved.
+type ProgressBar struct {
+	out   io.Writer
+	start time.Time
+	now   func() time.Time
+
+	mu    sync.Mutex
+	cur   Progress
+	last  time.Time
+	drawn bool
+}
+
+// NewProgressBar returns a bar writing to out, usually os.Stderr.
+func NewProgressBar(out io.Writer) *ProgressBar {
+	return &ProgressBar{out: out, start: time.Now(), now: time.Now}
+}
+
+// Update replaces the current progress and redraws the line, at most every
+// progressInterval unless the operation completed.
+func (b *ProgressBar) Update(p Progress) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cur = p
+	now := b.now()
+	if now.Sub(b.last) < progressInterval && !b.complete() {
+		return
+	}
+	b.last = now
+	b.draw()
+}
+
+// Add advances the byte count by n, for readers and extractors reporting
+// deltas.
+func (b *ProgressBar) Add(n int64) {
+	b.mu.Lock()
+	p := b.cur
+	b.mu.Unlock()
+	p.Bytes += n
+	b.Update(p)
+}
+
+// Bytes returns the current byte count.
+func (b *ProgressBar) Bytes() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cur.Bytes
+}
+
+// SetTotal sets the total byte count once it is known.
+func (b *ProgressBar) SetTotal(total int64) {
+	b.mu.Lock()
+	p := b.cur
+	b.mu.Unlock()
+	p.TotalBytes = total
+	b.Update(p)
+}
+
+// Clear erases the progress line, so that regular output can be printed.
+// The next update draws it again.
+func (b *ProgressBar) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.drawn {
+		fmt.Fprint(b.out, "\033[2K\r")
+		b.drawn = false
+	}
+}
+
+// Finish draws the final state and ends the line.
+func (b *ProgressBar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.draw()
+	fmt.Fprintln(b.out)
+	b.drawn = false
+}
+
+// NewProxyReader returns a reader advancing the bar by the bytes read from
+// r, which clears the line at EOF.
+func (b *ProgressBar) NewProxyReader(r io.Reader) io.Reader {
+	return &progressReader{r: r, bar: b}
+}
+
+func (b *ProgressBar) complete() bool {
+	p := b.cur
+	return p.TotalBytes > 0 && p.Bytes >= p.TotalBytes ||
+		p.TotalShards > 0 && p.Shards >= p.TotalShards
+}
+
+func (b *ProgressBar) draw() {
+	fmt.Fprintf(b.out, "\033[2K\r%s", formatProgress(b.cur, b.now().Sub(b.start)))
+	b.drawn = true
+}
+
+// formatProgress renders p after elapsed time.
+func formatProgress(p Progress, elapsed time.Duration) string {
+	var parts []string
+	if p.Stage != "" {
+		parts = append(parts, p.Stage)
+	}
+	if p.Bytes > 0 || p.TotalBytes > 0 {
+		s := humanize.IBytes(uint64(p.Bytes))
+		if p.TotalBytes > 0 {
+			s += fmt.Sprintf(" / %s (%d%%)", humanize.IBytes(uint64(p.TotalBytes)), percent(p.Bytes, p.TotalBytes))
+		}
+		parts = append(parts, s)
+	}
+	if p.TotalShards > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d shards", p.Shards, p.TotalShards))
+	}
+	if eta, ok := estimate(p, elapsed); ok {
+		parts = append(parts, "ETA "+eta.String())
+	}
+	if len(p.Hosts) > 0 {
+		hosts := p.Hosts
+		more := ""
+		if len(hosts) > 3 {
+			more = fmt.Sprintf(" +%d", len(hosts)-3)
+			hosts = hosts[:3]
+		}
+		short := make([]string, len(hosts))
+		for i, h := range hosts {
+			if len(h) > 10 {
+				h = "…" + h[len(h)-8:]
+			}
+			short[i] = h
+		}
+		parts = append(parts, "hosts "+strings.Join(short, ",")+more)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// estimate extrapolates the remaining time from the average rate so far,
+// on bytes when their total is known, else on shards.
+func estimate(p Progress, elapsed time.Duration) (time.Duration, bool) {
+	done, total := p.Bytes, p.TotalBytes
+	if total <= 0 {
+		done, total = int64(p.Shards), int64(p.TotalShards)
+	}
+	if total <= 0 || done <= 0 || done >= total || elapsed <= 0 {
+		return 0, false
+	}
+	remaining := time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+	return remaining.Round(time.Second), true
+}
+
+func percent(n, total int64) int64 {
+	if n >= total {
+		return 100
+	}
+	return n * 100 / total
+}
+
+type progressReader struct {
+	r   io.Reader
+	bar *ProgressBar
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.bar.Add(int64(n))
+	if err == io.EOF {
+		pr.bar.Clear()
+	}
+	return n, err
+}
@@ -0,0 +1,67 @@
+package cmdenv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatProgress(t *testing.T) {
+	cases := []struct {
+		p       Progress
+		elapsed time.Duration
+		want    string
+	}{
+		{Progress{Bytes: 512}, time.Second, "512 B"},
+		{Progress{Bytes: 1 << 20, TotalBytes: 4 << 20}, 10 * time.Second, "1.0 MiB / 4.0 MiB (25%)  ETA 30s"},
+		{Progress{Stage: "guard", Shards: 1, TotalShards: 3}, time.Minute, "guard  1/3 shards  ETA 2m0s"},
+		{Progress{Shards: 3, TotalShards: 3, Hosts: []string{"a", "b", "c", "16Uiu2HAmPeerId"}}, time.Minute,
+			"3/3 shards  hosts a,b,c +1"},
+		{Progress{Hosts: []string{"16Uiu2HAmPeerId"}}, 0, "hosts …AmPeerId"},
+	}
+	for _, c := range cases {
+		if got := formatProgress(c.p, c.elapsed); got != c.want {
+			t.Errorf("formatProgress(%+v) = %q, want %q", c.p, got, c.want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	if _, ok := estimate(Progress{Bytes: 10}, time.Second); ok {
+		t.Error("estimated without a total")
+	}
+	if _, ok := estimate(Progress{TotalBytes: 10}, time.Second); ok {
+		t.Error("estimated without progress")
+	}
+	if _, ok := estimate(Progress{Bytes: 10, TotalBytes: 10}, time.Second); ok {
+		t.Error("estimated a completed operation")
+	}
+	eta, ok := estimate(Progress{Bytes: 1, TotalBytes: 4, Shards: 3, TotalShards: 4}, 3*time.Second)
+	if !ok || eta != 9*time.Second {
+		t.Errorf("got %v %v, want 9s from bytes", eta, ok)
+	}
+}
+
+func TestProgressBarThrottle(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Unix(0, 0)
+	bar := NewProgressBar(&buf)
+	bar.now = func() time.Time { return now }
+	bar.start = now
+
+	bar.SetTotal(100)
+	bar.Add(10)
+	if n := strings.Count(buf.String(), "\r"); n != 1 {
+		t.Fatalf("drew %d times within the interval, want 1", n)
+	}
+	now = now.Add(progressInterval)
+	bar.Add(10)
+	bar.Add(80)
+	if n := strings.Count(buf.String(), "\r"); n != 3 {
+		t.Fatalf("drew %d times, want 3 (interval elapsed, then completion)", n)
+	}
+	if bar.Bytes() != 100 {
+		t.Errorf("bytes = %d, want 100", bar.Bytes())
+	}
+}
@@ -0,0 +1,48 @@
+package cmdenv
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// Timeout returns the time req may run: its --timeout, else the default
+// timeout of its command, 0 for none.
+func Timeout(req *cmds.Request) time.Duration {
+	if s, ok := req.Options[cmds.TimeoutOpt].(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d
+		}
+	}
+	if req.Command != nil {
+		return req.Command.RunTimeout
+	}
+	return 0
+}
+
+// DeadlineError returns err as a timed out cmds.Error naming the command
+// and its timeout when err is due to the deadline of req, err otherwise.
+func DeadlineError(req *cmds.Request, err error) error {
+	if err == nil {
+		return nil
+	}
+	// Keep the errors that already have a code, timed out or not.
+	var code cmds.ErrorType
+	if errors.As(err, &code) && code != cmds.ErrNormal {
+		return err
+	}
+	if !errors.Is(err, context.DeadlineExceeded) &&
+		(req.Context == nil || req.Context.Err() != context.DeadlineExceeded) {
+		return err
+	}
+	cmd := strings.Join(append([]string{"btfs"}, req.Path...), " ")
+	d := Timeout(req)
+	if d == 0 {
+		return cmds.Errorf(cmds.ErrTimedOut, "%s: timed out", cmd)
+	}
+	return cmds.Errorf(cmds.ErrTimedOut, "%s: timed out after %s, use --%s to allow more time",
+		cmd, d, cmds.TimeoutOpt)
+}
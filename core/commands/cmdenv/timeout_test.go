@@ -0,0 +1,57 @@
+package cmdenv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+func TestDeadlineError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+
+	req := &cmds.Request{
+		Context: ctx,
+		Path:    []string{"wallet", "balance"},
+		Command: &cmds.Command{RunTimeout: time.Minute},
+		Options: cmds.OptMap{},
+	}
+	for _, tc := range []struct {
+		err     error
+		timeout string
+		want    string
+	}{
+		{errors.New("rpc error: code = DeadlineExceeded"), "", "btfs wallet balance: timed out after 1m0s, use --timeout to allow more time"},
+		{context.DeadlineExceeded, "5s", "btfs wallet balance: timed out after 5s, use --timeout to allow more time"},
+		{cmds.Errorf(cmds.ErrClient, "bad argument"), "", "bad argument"},
+	} {
+		delete(req.Options, cmds.TimeoutOpt)
+		if tc.timeout != "" {
+			req.Options[cmds.TimeoutOpt] = tc.timeout
+		}
+		err := DeadlineError(req, tc.err)
+		if err.Error() != tc.want {
+			t.Errorf("got %q, want %q", err, tc.want)
+		}
+		var code cmds.ErrorType
+		if tc.err != context.DeadlineExceeded && errors.As(tc.err, &code) {
+			continue
+		}
+		if !errors.As(err, &code) || code != cmds.ErrTimedOut {
+			t.Errorf("%q is not timed out", err)
+		}
+	}
+
+	req.Context = context.Background()
+	plain := errors.New("plain")
+	if err := DeadlineError(req, plain); err != plain {
+		t.Errorf("got %v", err)
+	}
+	if DeadlineError(req, nil) != nil {
+		t.Error("nil error changed")
+	}
+}
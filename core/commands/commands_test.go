@@ -69,6 +69,9 @@ func TestROCommands(t *testing.T) {
 func TestCommands(t *testing.T) {
 	list := []string{
 		"/add",
+		"/alerts",
+		"/alerts/ls",
+		"/alerts/test",
 		"/bitswap",
 		"/bitswap/ledger",
 		"/bitswap/reprovide",
@@ -95,6 +98,12 @@ func TestCommands(t *testing.T) {
 		"/config/profile/apply",
 		"/config/optin",
 		"/config/optout",
+		"/config/cors",
+		"/config/cors/show",
+		"/config/cors/set",
+		"/config/cors/reset",
+		"/config/validate",
+		"/config/migrate",
 		"/dag",
 		"/dag/get",
 		"/dag/export",
@@ -106,17 +115,28 @@ func TestCommands(t *testing.T) {
 		"/dht/findprovs",
 		"/dht/get",
 		"/dht/provide",
+		"/dht/priority",
+		"/dht/priority/ls",
+		"/dht/priority/add",
+		"/dht/priority/rm",
 		"/dht/put",
 		"/dht/query",
 		"/diag",
+		"/diag/audit",
 		"/diag/cmds",
 		"/diag/cmds/clear",
 		"/diag/cmds/set-time",
+		"/diag/latency",
+		"/diag/clock",
+		"/diag/speedtest",
 		"/diag/sys",
 		"/dns",
+		"/events",
 		"/file",
 		"/file/ls",
 		"/files",
+		"/fleet",
+		"/fleet/upgrade",
 		"/files/chcid",
 		"/files/cp",
 		"/files/flush",
@@ -125,6 +145,9 @@ func TestCommands(t *testing.T) {
 		"/files/mv",
 		"/files/read",
 		"/files/rm",
+		"/files/restore",
+		"/files/trash",
+		"/files/trash/empty",
 		"/files/stat",
 		"/filestore",
 		"/filestore/dups",
@@ -134,11 +157,21 @@ func TestCommands(t *testing.T) {
 		"/get",
 		"/id",
 		"/key",
+		"/keys",
+		"/keys/dek",
+		"/keys/dek/grant",
+		"/keys/dek/import",
+		"/keys/dek/list",
+		"/keys/dek/rotate",
+		"/keys/dek/share",
 		"/key/gen",
 		"/key/list",
 		"/key/rename",
 		"/key/rm",
 		"/log",
+		"/log/append",
+		"/log/create",
+		"/log/follow",
 		"/log/level",
 		"/log/ls",
 		"/log/tail",
@@ -172,13 +205,18 @@ func TestCommands(t *testing.T) {
 		"/p2p/stream",
 		"/p2p/stream/close",
 		"/p2p/stream/ls",
+		"/paywall",
+		"/paywall/ls",
+		"/paywall/pay",
 		"/pin",
 		"/pin/add",
 		"/ping",
+		"/power-mode",
 		"/pin/ls",
 		"/pin/rm",
 		"/pin/update",
 		"/pin/verify",
+		"/publish-site",
 		"/pubsub",
 		"/pubsub/ls",
 		"/pubsub/peers",
@@ -186,9 +224,30 @@ func TestCommands(t *testing.T) {
 		"/pubsub/sub",
 		"/refs",
 		"/refs/local",
+		"/remote",
+		"/remote/add",
+		"/remote/ls",
+		"/remote/rm",
 		"/repo",
+		"/repo/add-session",
+		"/repo/add-session/abort",
+		"/repo/add-session/commit",
+		"/repo/add-session/ls",
+		"/repo/add-session/new",
+		"/repo/bench",
+		"/repo/cache",
+		"/repo/cache/ls",
+		"/repo/cache/pin",
+		"/repo/cache/stat",
+		"/repo/cache/unpin",
+		"/repo/encrypt",
+		"/repo/encrypt/status",
 		"/repo/fsck",
 		"/repo/gc",
+		"/repo/import-ipfs",
+		"/repo/maintenance",
+		"/repo/maintenance/run",
+		"/repo/maintenance/schedule",
 		"/repo/stat",
 		"/repo/verify",
 		"/repo/version",
@@ -200,9 +259,11 @@ func TestCommands(t *testing.T) {
 		"/stats/bitswap",
 		"/stats/bw",
 		"/stats/dht",
+		"/stats/history",
 		"/stats/repo",
 		"/swarm",
 		"/swarm/addrs",
+		"/swarm/addrs/announced",
 		"/swarm/addrs/listen",
 		"/swarm/addrs/local",
 		"/swarm/connect",
@@ -210,14 +271,55 @@ func TestCommands(t *testing.T) {
 		"/swarm/filters",
 		"/swarm/filters/add",
 		"/swarm/filters/rm",
+		"/swarm/peer-stats",
+		"/swarm/peer-stats/block",
+		"/swarm/peer-stats/blocked",
+		"/swarm/peer-stats/report",
+		"/swarm/peer-stats/unblock",
 		"/swarm/peers",
 		"/tar",
 		"/tar/add",
 		"/tar/cat",
+		"/top",
+		"/update",
+		"/update/channel",
+		"/update/rollback",
+		"/update/now",
 		"/urlstore",
 		"/urlstore/add",
+		"/users",
+		"/users/add",
+		"/users/list",
+		"/users/rm",
+		"/users/set",
+		"/users/token",
 		"/version",
 		"/version/deps",
+		"/verify",
+		"/openapi",
+		"/completion",
+		"/completion/bash",
+		"/completion/zsh",
+		"/completion/fish",
+		"/completion/powershell",
+		"/completion/peers",
+		"/completion/cids",
+		"/doctor",
+		"/alias",
+		"/alias/add",
+		"/alias/list",
+		"/alias/rm",
+		"/apps",
+		"/apps/add",
+		"/apps/list",
+		"/apps/rm",
+		"/apps/set",
+		"/apps/snapshot",
+		"/apps/snapshot/create",
+		"/apps/snapshot/ls",
+		"/apps/snapshot/rm",
+		"/apps/snapshot/rollback",
+		"/apps/token",
 		"/cid",
 		"/cid/format",
 		"/cid/base32",
@@ -229,11 +331,26 @@ func TestCommands(t *testing.T) {
 		"/storage/path/capacity",
 		"/storage/path/status",
 		"/storage/path/migrate",
+		"/storage/update",
+		"/storage/health",
+		"/storage/import",
+		"/storage/import/status",
+		"/storage/import/ls",
+		"/storage/import/resume",
+		"/storage/import/cancel",
+		"/storage/market",
+		"/storage/market/ls",
 		"/storage/upload",
 		"/storage/upload/init",
 		"/storage/upload/recvcontract",
+		"/storage/upload/limits",
+		"/storage/upload/queue",
 		"/storage/upload/status",
 		"/storage/upload/repair",
+		"/storage/upload/expiry",
+		"/storage/upload/expiry/ls",
+		"/storage/upload/expiry/cancel",
+		"/storage/upload/policies",
 		"/storage/upload/getcontractbatch",
 		"/storage/upload/signcontractbatch",
 		"/storage/upload/getunsigned",
@@ -243,16 +360,82 @@ func TestCommands(t *testing.T) {
 		"/storage/hosts",
 		"/storage/hosts/sync",
 		"/storage/hosts/info",
+		"/storage/hosts/score",
+		"/storage/hosts/explain",
+		"/storage/hosts/availability",
 		"/storage/challenge",
 		"/storage/challenge/request",
 		"/storage/challenge/response",
+		"/storage/challenge/cache",
+		"/storage/capacity",
+		"/storage/capacity/verify",
+		"/storage/capacity/ls",
+		"/storage/capacity/plot",
+		"/storage/capacity/status",
+		"/storage/capacity/prove",
+		"/storage/digest",
+		"/storage/payer",
+		"/storage/payer/delegate",
+		"/storage/payer/revoke",
+		"/storage/payer/ls",
+		"/storage/payer/use",
+		"/storage/payer/clear",
+		"/storage/payer/status",
+		"/storage/payer/sign",
+		"/storage/probe",
+		"/storage/probe/run",
+		"/storage/probe/status",
+		"/storage/probe/read",
+		"/storage/collateral",
+		"/storage/collateral/ls",
+		"/storage/collateral/query",
+		"/storage/collateral/terms",
+		"/storage/collateral/post",
+		"/storage/bandwidth",
+		"/storage/bandwidth/open",
+		"/storage/bandwidth/close",
+		"/storage/bandwidth/status",
+		"/storage/bandwidth/terms",
+		"/storage/bandwidth/accept",
+		"/storage/bandwidth/pay",
+		"/storage/host",
+		"/storage/host/drain",
+		"/storage/host/tenants",
+		"/storage/host/tenants/ls",
+		"/storage/host/tenants/add",
+		"/storage/host/tenants/rm",
+		"/storage/host/tenants/report",
+		"/storage/host/tenants/payout",
+		"/storage/settlement",
+		"/storage/settlement/status",
+		"/storage/settlement/settle",
+		"/storage/settlement/receipts",
+		"/storage/export",
+		"/storage/files",
+		"/storage/files/attest",
+		"/storage/files/verify",
+		"/storage/files/prove",
+		"/storage/files/stats",
+		"/storage/files/plan-restore",
+		"/storage/sessions",
+		"/storage/sessions/inspect",
+		"/storage/guard",
+		"/storage/guard/queue",
 		"/storage/stats",
 		"/storage/stats/info",
+		"/storage/stats/ingest",
 		"/storage/stats/sync",
 		"/storage/contracts",
+		"/storage/contracts/archive",
 		"/storage/contracts/list",
+		"/storage/contracts/propose",
+		"/storage/contracts/quote",
+		"/storage/contracts/history",
+		"/storage/contracts/renegotiate",
 		"/storage/contracts/stat",
 		"/storage/contracts/sync",
+		"/storage/escrow",
+		"/storage/escrow/reconcile",
 		"/metadata",
 		"/metadata/add",
 		"/metadata/rm",
@@ -263,14 +446,34 @@ func TestCommands(t *testing.T) {
 		"/wallet/init",
 		"/wallet/balance",
 		"/wallet/withdraw",
+		"/wallet/withdrawals",
+		"/wallet/connector",
 		"/wallet/deposit",
 		"/wallet/keys",
 		"/wallet/password",
+		"/wallet/password/change",
 		"/wallet/transactions",
 		"/wallet/transfer",
+		"/wallet/schedules",
+		"/wallet/schedules/ls",
+		"/wallet/schedules/cancel",
+		"/wallet/signer",
+		"/wallet/relay",
+		"/wallet/relay/send",
+		"/wallet/relay/terms",
+		"/wallet/relay/accept",
+		"/wallet/devices",
+		"/wallet/devices/ls",
+		"/wallet/devices/link",
+		"/wallet/devices/revoke",
+		"/wallet/devices/send",
+		"/wallet/devices/transfers",
 		"/wallet/import",
+		"/wallet/export",
 		"/wallet/discovery",
 		"/wallet/validate_password",
+		"/wallet/unlock",
+		"/wallet/lock",
 		"/tron",
 		"/tron/prepare",
 		"/tron/send",
@@ -0,0 +1,415 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// maxCompletionCids caps the pinned roots offered for completion.
+const maxCompletionCids = 100
+
+// Argument kinds completed by the generated scripts.
+const (
+	completeNone  = "none"
+	completeFile  = "file"
+	completePeers = "peers"
+	completeCids  = "cids"
+)
+
+// completionEntry describes one command of the tree for the completion
+// scripts.
+type completionEntry struct {
+	Path     string   // e.g. "btfs swarm connect"
+	Subs     []string // subcommand names
+	Opts     []string // --long and -s option names
+	Args     []string // kind of each positional argument
+	Variadic bool     // whether the last argument repeats
+}
+
+var CompletionCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Generate shell completion scripts.",
+		ShortDescription: `
+'btfs completion <shell>' prints a completion script for bash, zsh, fish or
+powershell, generated from the btfs command tree. Peer IDs are completed from
+the peerstore and CIDs from the pinned roots.
+
+  bash:       source <(btfs completion bash)
+  zsh:        btfs completion zsh > "${fpath[1]}/_btfs"
+  fish:       btfs completion fish > ~/.config/fish/completions/btfs.fish
+  powershell: btfs completion powershell | Out-String | Invoke-Expression
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"bash":       completionScriptCmd("bash", writeBashCompletion),
+		"zsh":        completionScriptCmd("zsh", writeZshCompletion),
+		"fish":       completionScriptCmd("fish", writeFishCompletion),
+		"powershell": completionScriptCmd("powershell", writePowershellCompletion),
+		"peers":      completionPeersCmd,
+		"cids":       completionCidsCmd,
+	},
+}
+
+func completionScriptCmd(shell string, write func(io.Writer, []completionEntry) error) *cmds.Command {
+	return &cmds.Command{
+		Helptext: cmds.HelpText{
+			Tagline: fmt.Sprintf("Print the %s completion script.", shell),
+		},
+		Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+			var b strings.Builder
+			if err := write(&b, completionEntries(req.Root)); err != nil {
+				return err
+			}
+			return cmds.EmitOnce(res, b.String())
+		},
+		Encoders: cmds.EncoderMap{
+			cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, script string) error {
+				_, err := io.WriteString(w, script)
+				return err
+			}),
+		},
+		Type: "",
+	}
+}
+
+var completionPeersCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List peer IDs from the peerstore, used by the completion scripts.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		var out []string
+		for _, p := range n.Peerstore.Peers() {
+			if p != n.Identity {
+				out = append(out, p.Pretty())
+			}
+		}
+		sort.Strings(out)
+		return cmds.EmitOnce(res, &stringList{out})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(stringListEncoder),
+	},
+	Type: stringList{},
+}
+
+var completionCidsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List pinned roots, used by the completion scripts.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		keys, err := n.Pinning.RecursiveKeys(req.Context)
+		if err != nil {
+			return err
+		}
+		enc, err := cmdenv.GetCidEncoder(req)
+		if err != nil {
+			return err
+		}
+		out := make([]string, 0, len(keys))
+		// The pinner keeps no times, so take the last pinned entries
+		for i := len(keys) - 1; i >= 0 && len(out) < maxCompletionCids; i-- {
+			out = append(out, enc.Encode(keys[i]))
+		}
+		return cmds.EmitOnce(res, &stringList{out})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(stringListEncoder),
+	},
+	Type: stringList{},
+}
+
+// completionEntries walks the command tree below root.
+func completionEntries(root *cmds.Command) []completionEntry {
+	var entries []completionEntry
+	var walk func(path string, c *cmds.Command)
+	walk = func(path string, c *cmds.Command) {
+		e := completionEntry{Path: path}
+		for name := range c.Subcommands {
+			e.Subs = append(e.Subs, name)
+		}
+		sort.Strings(e.Subs)
+		for _, opt := range c.Options {
+			for _, name := range opt.Names() {
+				if len(name) == 1 {
+					e.Opts = append(e.Opts, "-"+name)
+				} else {
+					e.Opts = append(e.Opts, "--"+name)
+				}
+			}
+		}
+		sort.Strings(e.Opts)
+		for _, arg := range c.Arguments {
+			e.Args = append(e.Args, completionArgKind(arg))
+			e.Variadic = arg.Variadic
+		}
+		entries = append(entries, e)
+		for _, name := range e.Subs {
+			walk(path+" "+name, c.Subcommands[name])
+		}
+	}
+	walk("btfs", root)
+
+	// Options of parent commands apply to their subcommands too
+	opts := map[string][]string{}
+	for i := range entries {
+		e := &entries[i]
+		if idx := strings.LastIndex(e.Path, " "); idx > 0 {
+			e.Opts = append(append([]string{}, opts[e.Path[:idx]]...), e.Opts...)
+			sort.Strings(e.Opts)
+		}
+		opts[e.Path] = e.Opts
+	}
+	return entries
+}
+
+func completionArgKind(arg cmds.Argument) string {
+	if arg.Type == cmds.ArgFile {
+		return completeFile
+	}
+	name := strings.ToLower(arg.Name)
+	switch {
+	case strings.Contains(name, "peer"):
+		return completePeers
+	case strings.Contains(name, "path"), strings.Contains(name, "hash"),
+		strings.Contains(name, "cid"), name == "root", name == "ref", name == "obj":
+		return completeCids
+	}
+	return completeNone
+}
+
+func (e completionEntry) argKinds() string {
+	kinds := strings.Join(e.Args, " ")
+	if e.Variadic {
+		kinds += "+"
+	}
+	return kinds
+}
+
+const bashCompletionFunc = `
+_btfs_nth() {
+	local n=$1
+	shift
+	if [[ $n -lt $# ]]; then
+		shift $n
+		printf '%s' "$1"
+	elif [[ $# -gt 0 ]]; then
+		shift $(($# - 1))
+		[[ "$1" == *+ ]] && printf '%s' "$1"
+	fi
+}
+
+_btfs() {
+	if [[ -n "${ZSH_VERSION-}" ]]; then
+		setopt localoptions ksharrays shwordsplit
+	fi
+	local cur="${COMP_WORDS[COMP_CWORD]}" path="btfs" nargs=0 i w kind
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		w="${COMP_WORDS[i]}"
+		[[ "$w" == -* ]] && continue
+		if [[ $nargs -eq 0 && " ${_btfs_subs[$path]} " == *" $w "* ]]; then
+			path="$path $w"
+		else
+			nargs=$((nargs + 1))
+		fi
+	done
+	COMPREPLY=()
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "${_btfs_opts[$path]}" -- "$cur"))
+		return
+	fi
+	if [[ $nargs -eq 0 && -n "${_btfs_subs[$path]}" ]]; then
+		COMPREPLY=($(compgen -W "${_btfs_subs[$path]}" -- "$cur"))
+		return
+	fi
+	kind=$(_btfs_nth $nargs ${_btfs_args[$path]})
+	case "${kind%+}" in
+	file) COMPREPLY=($(compgen -f -- "$cur")) ;;
+	peers | cids) COMPREPLY=($(compgen -W "$(btfs completion "${kind%+}" 2>/dev/null)" -- "$cur")) ;;
+	esac
+}
+`
+
+func writeBashCompletionBody(w io.Writer, entries []completionEntry) error {
+	fmt.Fprintln(w, "declare -A _btfs_subs _btfs_opts _btfs_args")
+	for _, e := range entries {
+		if len(e.Subs) > 0 {
+			fmt.Fprintf(w, "_btfs_subs['%s']='%s'\n", e.Path, strings.Join(e.Subs, " "))
+		}
+		fmt.Fprintf(w, "_btfs_opts['%s']='%s'\n", e.Path, strings.Join(e.Opts, " "))
+		if len(e.Args) > 0 {
+			fmt.Fprintf(w, "_btfs_args['%s']='%s'\n", e.Path, e.argKinds())
+		}
+	}
+	_, err := io.WriteString(w, bashCompletionFunc)
+	return err
+}
+
+func writeBashCompletion(w io.Writer, entries []completionEntry) error {
+	fmt.Fprintln(w, "# bash completion for btfs, generated by 'btfs completion bash'")
+	if err := writeBashCompletionBody(w, entries); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "complete -o default -F _btfs btfs")
+	return err
+}
+
+func writeZshCompletion(w io.Writer, entries []completionEntry) error {
+	fmt.Fprintln(w, "#compdef btfs")
+	fmt.Fprintln(w, "# zsh completion for btfs, generated by 'btfs completion zsh'")
+	fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	if err := writeBashCompletionBody(w, entries); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "complete -o default -F _btfs btfs")
+	return err
+}
+
+const fishCompletionFunc = `
+function __btfs_path
+	set -l words (commandline -opc)
+	set -l path btfs
+	set -l nargs 0
+	for w in $words[2..-1]
+		if string match -q -- '-*' $w
+			continue
+		end
+		if test $nargs -eq 0; and contains -- "$path $w" $__btfs_paths
+			set path "$path $w"
+		else
+			set nargs (math $nargs + 1)
+		end
+	end
+	echo $path
+	echo $nargs
+end
+
+function __btfs_at
+	set -l state (__btfs_path)
+	test "$state[1]" = "$argv[1]"; or return 1
+	if test (count $argv) -eq 1
+		return 0
+	end
+	if test "$argv[2]" = "+"
+		test "$state[2]" -ge "$argv[3]"
+		return
+	end
+	test "$state[2]" = "$argv[2]"
+end
+`
+
+func writeFishCompletion(w io.Writer, entries []completionEntry) error {
+	fmt.Fprintln(w, "# fish completion for btfs, generated by 'btfs completion fish'")
+	fmt.Fprint(w, "set -g __btfs_paths")
+	for _, e := range entries {
+		fmt.Fprintf(w, " '%s'", e.Path)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, fishCompletionFunc)
+	fmt.Fprintln(w, "complete -c btfs -f")
+	for _, e := range entries {
+		if len(e.Subs) > 0 {
+			fmt.Fprintf(w, "complete -c btfs -n \"__btfs_at '%s' 0\" -a '%s'\n", e.Path, strings.Join(e.Subs, " "))
+		}
+		for _, opt := range e.Opts {
+			if strings.HasPrefix(opt, "--") {
+				fmt.Fprintf(w, "complete -c btfs -n \"__btfs_at '%s'\" -l %s\n", e.Path, opt[2:])
+			} else {
+				fmt.Fprintf(w, "complete -c btfs -n \"__btfs_at '%s'\" -s %s\n", e.Path, opt[1:])
+			}
+		}
+		for i, kind := range e.Args {
+			cond := fmt.Sprintf("__btfs_at '%s' %d", e.Path, i)
+			if e.Variadic && i == len(e.Args)-1 {
+				cond = fmt.Sprintf("__btfs_at '%s' + %d", e.Path, i)
+			}
+			switch kind {
+			case completeFile:
+				fmt.Fprintf(w, "complete -c btfs -n \"%s\" -F\n", cond)
+			case completePeers, completeCids:
+				fmt.Fprintf(w, "complete -c btfs -n \"%s\" -a '(btfs completion %s 2>/dev/null)'\n", cond, kind)
+			}
+		}
+	}
+	return nil
+}
+
+const powershellCompletionFunc = `
+Register-ArgumentCompleter -Native -CommandName btfs -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	$path = 'btfs'
+	$nargs = 0
+	foreach ($element in $commandAst.CommandElements | Select-Object -Skip 1) {
+		$w = $element.ToString()
+		if ($element.Extent.StartOffset -ge $cursorPosition -or $w -eq $wordToComplete) { break }
+		if ($w.StartsWith('-')) { continue }
+		if ($nargs -eq 0 -and $btfsSubs.ContainsKey($path) -and ($btfsSubs[$path] -contains $w)) {
+			$path = "$path $w"
+		} else {
+			$nargs++
+		}
+	}
+	$candidates = @()
+	if ($wordToComplete.StartsWith('-')) {
+		$candidates = $btfsOpts[$path]
+	} elseif ($nargs -eq 0 -and $btfsSubs.ContainsKey($path)) {
+		$candidates = $btfsSubs[$path]
+	} elseif ($btfsArgs.ContainsKey($path)) {
+		$kinds = $btfsArgs[$path]
+		$kind = $null
+		if ($nargs -lt $kinds.Count) { $kind = $kinds[$nargs] }
+		elseif ($kinds[-1].EndsWith('+')) { $kind = $kinds[-1] }
+		if ($kind) { $kind = $kind.TrimEnd('+') }
+		if ($kind -eq 'peers' -or $kind -eq 'cids') {
+			$candidates = & btfs completion $kind 2>$null
+		} elseif ($kind -eq 'file') {
+			return
+		}
+	}
+	$candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+		[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+	}
+}
+`
+
+func writePowershellCompletion(w io.Writer, entries []completionEntry) error {
+	fmt.Fprintln(w, "# powershell completion for btfs, generated by 'btfs completion powershell'")
+	list := func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, s := range items {
+			quoted[i] = "'" + s + "'"
+		}
+		return "@(" + strings.Join(quoted, ", ") + ")"
+	}
+	fmt.Fprintln(w, "$btfsSubs = @{}")
+	fmt.Fprintln(w, "$btfsOpts = @{}")
+	fmt.Fprintln(w, "$btfsArgs = @{}")
+	for _, e := range entries {
+		if len(e.Subs) > 0 {
+			fmt.Fprintf(w, "$btfsSubs['%s'] = %s\n", e.Path, list(e.Subs))
+		}
+		fmt.Fprintf(w, "$btfsOpts['%s'] = %s\n", e.Path, list(e.Opts))
+		if len(e.Args) > 0 {
+			kinds := append([]string{}, e.Args...)
+			if e.Variadic {
+				kinds[len(kinds)-1] += "+"
+			}
+			fmt.Fprintf(w, "$btfsArgs['%s'] = %s\n", e.Path, list(kinds))
+		}
+	}
+	_, err := io.WriteString(w, powershellCompletionFunc)
+	return err
+}
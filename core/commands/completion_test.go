@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+func TestCompletionEntries(t *testing.T) {
+	root := &cmds.Command{
+		Options: []cmds.Option{cmds.BoolOption("debug", "D", "")},
+		Subcommands: map[string]*cmds.Command{
+			"swarm": {
+				Subcommands: map[string]*cmds.Command{
+					"connect": {
+						Options:   []cmds.Option{cmds.BoolOption("force", "")},
+						Arguments: []cmds.Argument{cmds.StringArg("peer-id", true, true, "")},
+					},
+				},
+			},
+			"add": {Arguments: []cmds.Argument{cmds.FileArg("path", true, true, "")}},
+			"cat": {Arguments: []cmds.Argument{cmds.StringArg("btfs-path", true, false, ""), cmds.StringArg("offset", false, false, "")}},
+		},
+	}
+
+	entries := completionEntries(root)
+	byPath := map[string]completionEntry{}
+	for _, e := range entries {
+		byPath[e.Path] = e
+	}
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(entries))
+	}
+	if subs := byPath["btfs"].Subs; !reflect.DeepEqual(subs, []string{"add", "cat", "swarm"}) {
+		t.Fatalf("unexpected root subcommands %v", subs)
+	}
+	connect := byPath["btfs swarm connect"]
+	if !reflect.DeepEqual(connect.Opts, []string{"--debug", "--force", "-D"}) {
+		t.Fatalf("expected inherited options, got %v", connect.Opts)
+	}
+	if connect.argKinds() != "peers+" {
+		t.Fatalf("unexpected connect arguments %q", connect.argKinds())
+	}
+	if kinds := byPath["btfs add"].argKinds(); kinds != "file+" {
+		t.Fatalf("unexpected add arguments %q", kinds)
+	}
+	if kinds := byPath["btfs cat"].argKinds(); kinds != "cids none" {
+		t.Fatalf("unexpected cat arguments %q", kinds)
+	}
+}
@@ -60,12 +60,15 @@ Set the value of the 'Datastore.Path' key:
 `,
 	},
 	Subcommands: map[string]*cmds.Command{
-		"show":    configShowCmd,
-		"edit":    configEditCmd,
-		"replace": configReplaceCmd,
-		"profile": configProfileCmd,
-		"optin":   optInCmd,
-		"optout":  optOutCmd,
+		"show":     configShowCmd,
+		"edit":     configEditCmd,
+		"replace":  configReplaceCmd,
+		"profile":  configProfileCmd,
+		"optin":    optInCmd,
+		"optout":   optOutCmd,
+		"cors":     configCORSCmd,
+		"validate": configValidateCmd,
+		"migrate":  configMigrateCmd,
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg("key", true, false, "The key of the config entry (e.g. \"Addresses.API\")."),
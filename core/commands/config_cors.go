@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/corehttp/cors"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	corsOriginOptionName      = "origin"
+	corsMethodOptionName      = "method"
+	corsCredentialsOptionName = "credentials"
+)
+
+// CORSGroupOutput is the CORS setting of one route group.
+type CORSGroupOutput struct {
+	Group   string
+	Default bool
+	cors.Config
+}
+
+// CORSUpdateOutput is the output of the CORS update commands.
+type CORSUpdateOutput struct {
+	CORSGroupOutput
+	Applied bool
+}
+
+var configCORSCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage CORS settings of the API, gateway and wallet routes.",
+		ShortDescription: `
+'btfs config cors' manages structured CORS settings per route group:
+
+  API      the daemon API (defaults to API.HTTPHeaders, else localhost)
+  Gateway  the gateway and its read-only API (defaults to Gateway.HTTPHeaders, else '*')
+  Wallet   the wallet API routes (defaults to localhost only, POST)
+
+Preflight (OPTIONS) responses are derived from these settings. Changes are
+applied to the routes of a running daemon immediately.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"show":  configCORSShowCmd,
+		"set":   configCORSSetCmd,
+		"reset": configCORSResetCmd,
+	},
+}
+
+var configCORSShowCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the CORS settings of every route group.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfgRoot, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		r, err := fsrepo.Open(cfgRoot)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		groups, err := cors.Load(r)
+		if err != nil {
+			return err
+		}
+		out := make([]CORSGroupOutput, 0, len(cors.GroupNames))
+		for _, name := range cors.GroupNames {
+			c, err := groups.Get(name)
+			if err != nil {
+				return err
+			}
+			out = append(out, corsGroupOutput(name, c))
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out []CORSGroupOutput) error {
+			for _, g := range out {
+				writeCORSGroup(w, &g)
+			}
+			return nil
+		}),
+	},
+	Type: []CORSGroupOutput{},
+}
+
+var configCORSSetCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Set the CORS settings of a route group.",
+		ShortDescription: `
+Replaces the allowed origins, methods and credentials of a route group.
+Origins are '*' or scheme://host[:port]; '<port>' is substituted with the
+port the route group listens on.
+
+  $ btfs config cors set API --origin=https://app.example.com --method=POST
+  $ btfs config cors set Wallet --origin=http://localhost:<port> --method=POST --credentials
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("group", true, false, "Route group: "+strings.Join(cors.GroupNames, ", ")+"."),
+	},
+	Options: []cmds.Option{
+		cmds.StringsOption(corsOriginOptionName, "Allowed origin, may be given multiple times."),
+		cmds.StringsOption(corsMethodOptionName, "Allowed HTTP method, may be given multiple times."),
+		cmds.BoolOption(corsCredentialsOptionName, "Allow credentials.").WithDefault(false),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		origins, _ := req.Options[corsOriginOptionName].([]string)
+		methods, _ := req.Options[corsMethodOptionName].([]string)
+		if len(origins) == 0 || len(methods) == 0 {
+			return fmt.Errorf("at least one --%s and one --%s are required",
+				corsOriginOptionName, corsMethodOptionName)
+		}
+		for i, m := range methods {
+			methods[i] = strings.ToUpper(m)
+		}
+		c := &cors.Config{
+			AllowedOrigins:   origins,
+			AllowedMethods:   methods,
+			AllowCredentials: req.Options[corsCredentialsOptionName].(bool),
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		return updateCORSGroup(req, res, env, c)
+	},
+	Encoders: corsUpdateEncoders,
+	Type:     CORSUpdateOutput{},
+}
+
+var configCORSResetCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Restore the default CORS settings of a route group.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("group", true, false, "Route group: "+strings.Join(cors.GroupNames, ", ")+"."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		return updateCORSGroup(req, res, env, nil)
+	},
+	Encoders: corsUpdateEncoders,
+	Type:     CORSUpdateOutput{},
+}
+
+var corsUpdateEncoders = cmds.EncoderMap{
+	cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *CORSUpdateOutput) error {
+		writeCORSGroup(w, &out.CORSGroupOutput)
+		if out.Applied {
+			fmt.Fprintln(w, "Applied to the running daemon.")
+		} else {
+			fmt.Fprintln(w, "Takes effect when the daemon (re)starts.")
+		}
+		return nil
+	}),
+}
+
+// updateCORSGroup stores c as the settings of the requested group, nil
+// restores its defaults, and pushes the change to a running daemon.
+func updateCORSGroup(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment, c *cors.Config) error {
+	group := req.Arguments[0]
+
+	cfgRoot, err := cmdenv.GetConfigRoot(env)
+	if err != nil {
+		return err
+	}
+	r, err := fsrepo.Open(cfgRoot)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	groups, err := cors.Load(r)
+	if err != nil {
+		return err
+	}
+	if err := groups.Set(group, c); err != nil {
+		return err
+	}
+	if err := cors.Save(r, groups); err != nil {
+		return err
+	}
+
+	return cmds.EmitOnce(res, &CORSUpdateOutput{
+		CORSGroupOutput: corsGroupOutput(group, c),
+		Applied:         cors.Update(group, c),
+	})
+}
+
+func corsGroupOutput(group string, c *cors.Config) CORSGroupOutput {
+	if c == nil {
+		return CORSGroupOutput{Group: group, Default: true, Config: *cors.Defaults(group)}
+	}
+	return CORSGroupOutput{Group: group, Config: *c}
+}
+
+func writeCORSGroup(w io.Writer, g *CORSGroupOutput) {
+	suffix := ""
+	if g.Default {
+		suffix = " (default)"
+	}
+	fmt.Fprintf(w, "%s%s:\n", g.Group, suffix)
+	fmt.Fprintf(w, "  Origins:     %s\n", strings.Join(g.AllowedOrigins, ", "))
+	fmt.Fprintf(w, "  Methods:     %s\n", strings.Join(g.AllowedMethods, ", "))
+	fmt.Fprintf(w, "  Credentials: %t\n", g.AllowCredentials)
+}
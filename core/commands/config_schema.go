@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	config "github.com/TRON-US/go-btfs-config"
+	serialize "github.com/TRON-US/go-btfs-config/serialize"
+)
+
+// ConfigValidateOutput lists the problems found in the config.
+type ConfigValidateOutput struct {
+	Version  int
+	Problems []configschema.Problem
+}
+
+// ConfigMigrateOutput lists the changes made by a config migration.
+type ConfigMigrateOutput struct {
+	From    int
+	To      int
+	Applied []string
+	Backup  string `json:",omitempty"`
+}
+
+var configValidateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Check the config file against the config schema.",
+		ShortDescription: `
+'btfs config validate' checks the config file for values of the wrong type,
+unknown or misspelled keys, options that conflict with each other and an
+outdated layout. The command fails if any error is found; warnings are only
+reported. The daemon runs the same checks on startup and refuses to start
+on errors.
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfgRoot, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		raw, err := configschema.ReadConfig(cfgRoot)
+		if err != nil {
+			return err
+		}
+		version, _ := configschema.LayoutVersion(raw)
+		problems := configschema.Validate(raw)
+		if err := res.Emit(&ConfigValidateOutput{Version: version, Problems: problems}); err != nil {
+			return err
+		}
+		if configschema.HasErrors(problems) {
+			return errors.New("config is invalid")
+		}
+		return nil
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ConfigValidateOutput) error {
+			if len(out.Problems) == 0 {
+				fmt.Fprintf(w, "config is valid (layout version %d)\n", out.Version)
+				return nil
+			}
+			for _, p := range out.Problems {
+				fmt.Fprintln(w, p)
+			}
+			return nil
+		}),
+	},
+	Type: ConfigValidateOutput{},
+}
+
+var configMigrateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Upgrade the config file to the current layout.",
+		ShortDescription: `
+'btfs config migrate' upgrades a config written by an older btfs to the
+layout of this version, and applies the setting upgrades the daemon would
+otherwise apply on startup. The previous config is kept next to it as
+config-pre-migrate-*. The daemon must not be running.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(configDryRunOptionName, "List the changes without writing them."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfgRoot, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		locked, err := fsrepo.LockedByOtherProcess(cfgRoot)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return errors.New("the repo is in use, stop the daemon before migrating the config")
+		}
+		raw, err := configschema.ReadConfig(cfgRoot)
+		if err != nil {
+			return err
+		}
+		from, _ := configschema.LayoutVersion(raw)
+		applied, err := configschema.Migrate(raw)
+		if err != nil {
+			return err
+		}
+		out := &ConfigMigrateOutput{From: from, To: configschema.Version, Applied: applied}
+
+		dryRun, _ := req.Options[configDryRunOptionName].(bool)
+		if dryRun || (len(applied) == 0 && from == configschema.Version) {
+			return cmds.EmitOnce(res, out)
+		}
+		filename, err := config.Filename(cfgRoot)
+		if err != nil {
+			return err
+		}
+		out.Backup, err = backupConfigFile(cfgRoot, filename, "pre-migrate-")
+		if err != nil {
+			return err
+		}
+		if err := serialize.WriteConfigFile(filename, raw); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ConfigMigrateOutput) error {
+			if len(out.Applied) == 0 && out.From == out.To {
+				fmt.Fprintf(w, "config is already at layout version %d\n", out.To)
+				return nil
+			}
+			fmt.Fprintf(w, "layout version %d -> %d\n", out.From, out.To)
+			for _, a := range out.Applied {
+				fmt.Fprintf(w, "  %s\n", a)
+			}
+			if out.Backup != "" {
+				fmt.Fprintf(w, "previous config saved to %s\n", out.Backup)
+			}
+			return nil
+		}),
+	},
+	Type: ConfigMigrateOutput{},
+}
+
+// backupConfigFile copies the config file into a new file in cfgRoot, the
+// same way FSRepo.BackupConfig does for an open repo.
+func backupConfigFile(cfgRoot, filename, prefix string) (string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	temp, err := ioutil.TempFile(cfgRoot, "config-"+prefix)
+	if err != nil {
+		return "", err
+	}
+	defer temp.Close()
+	if _, err := temp.Write(b); err != nil {
+		os.Remove(temp.Name())
+		return "", err
+	}
+	return temp.Name(), nil
+}
@@ -39,6 +39,7 @@ var DhtCmd = &cmds.Command{
 		"get":       getValueDhtCmd,
 		"put":       putValueDhtCmd,
 		"provide":   provideRefDhtCmd,
+		"priority":  dhtPriorityCmd,
 	},
 }
 
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/priorityprov"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	cid "github.com/ipfs/go-cid"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+const (
+	priorityIntervalOptionName    = "interval"
+	priorityReplicationOptionName = "replication"
+)
+
+var dhtPriorityCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the CIDs provided ahead of the reprovide queue.",
+		ShortDescription: `
+With PriorityProviding.Enabled set, the provider records of the priority CIDs
+are provided outside of the reprovide queue, each every
+PriorityProviding.Interval (1h by default) and to PriorityProviding.Replication
+peers (40 by default), so that they are found even when the reprovider is
+backed up:
+
+    $ btfs config --json PriorityProviding.Enabled true
+
+The shard CIDs of the active host contracts are priority CIDs, unless
+PriorityProviding.SkipContracts is set. 'btfs dht priority add' adds a pinned
+CID, or sets the interval and replication of a CID or contract. Removing the
+CID of a contract resets it to the defaults.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":  dhtPriorityLsCmd,
+		"add": dhtPriorityAddCmd,
+		"rm":  dhtPriorityRmCmd,
+	},
+}
+
+var dhtPriorityLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the priority CIDs.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		rs, err := priorityprov.List(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, rs)
+	},
+	Type: []*priorityprov.Record{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, rs []*priorityprov.Record) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "CID\tSOURCE\tINTERVAL\tREPLICATION\tPROVIDED")
+			for _, r := range rs {
+				source := r.Source
+				if r.ContractID != "" {
+					source += " " + r.ContractID
+				}
+				interval, replication := "default", "default"
+				if r.Interval > 0 {
+					interval = r.Interval.String()
+				}
+				if r.Replication > 0 {
+					replication = fmt.Sprint(r.Replication)
+				}
+				provided := "-"
+				if !r.Provided.IsZero() {
+					provided = r.Provided.Format(time.RFC3339)
+				}
+				if r.Error != "" {
+					provided += " (failed: " + r.Error + ")"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Cid, source, interval, replication, provided)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var dhtPriorityAddCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Add a priority CID, or set how it is provided.",
+		ShortDescription: `
+Adds the CID as a priority CID, or sets the interval and replication of a
+priority CID. The key is a CID, or the ID of a host contract for its shard:
+
+    $ btfs dht priority add <cid> --interval 30m --replication 60`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("key", true, false, "CID, or ID of a host contract."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(priorityIntervalOptionName, "Time between two provides, at least 5m. Default: PriorityProviding.Interval."),
+		cmds.IntOption(priorityReplicationOptionName, "Number of peers provided to. Default: PriorityProviding.Replication."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		r := &priorityprov.Record{Cid: req.Arguments[0], Source: priorityprov.SourcePin, Added: time.Now()}
+		if _, err := cid.Decode(r.Cid); err != nil {
+			c, err := contracts.ShardContract(d, self, nodepb.ContractStat_HOST.String(), req.Arguments[0])
+			if err != nil {
+				return fmt.Errorf("%s is neither a cid nor a host contract", req.Arguments[0])
+			}
+			r.Cid, r.Source, r.ContractID = c.ShardHash, priorityprov.SourceContract, c.ContractId
+		}
+		rs, err := priorityprov.List(d, self)
+		if err != nil {
+			return err
+		}
+		for _, known := range rs {
+			if known.Cid == r.Cid {
+				r = known
+			}
+		}
+		if s, ok := req.Options[priorityIntervalOptionName].(string); ok {
+			if r.Interval, err = priorityprov.ParseInterval(s); err != nil {
+				return err
+			}
+		}
+		if i, ok := req.Options[priorityReplicationOptionName].(int); ok {
+			if i <= 0 {
+				return fmt.Errorf("replication must be positive")
+			}
+			r.Replication = i
+		}
+		if err := priorityprov.Put(d, self, r); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, r)
+	},
+	Type: priorityprov.Record{},
+}
+
+var dhtPriorityRmCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove a priority CID.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", true, false, "Priority CID."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		return priorityprov.Remove(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0])
+	},
+}
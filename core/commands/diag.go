@@ -1,6 +1,17 @@
 package commands
 
 import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/audit"
+	"github.com/TRON-US/go-btfs/core/clock"
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/latency"
+
 	cmds "github.com/TRON-US/go-btfs-cmds"
 )
 
@@ -10,7 +21,291 @@ var DiagCmd = &cmds.Command{
 	},
 
 	Subcommands: map[string]*cmds.Command{
-		"sys":  sysDiagCmd,
-		"cmds": ActiveReqsCmd,
+		"sys":       sysDiagCmd,
+		"cmds":      ActiveReqsCmd,
+		"audit":     diagAuditCmd,
+		"latency":   diagLatencyCmd,
+		"clock":     diagClockCmd,
+		"speedtest": diagSpeedtestCmd,
+	},
+}
+
+const (
+	auditSinceOptionName   = "since"
+	auditCommandOptionName = "command"
+	auditCallerOptionName  = "caller"
+	auditFailedOptionName  = "failed"
+	auditLimitOptionName   = "limit"
+)
+
+// AuditOutput lists audit log entries, newest first.
+type AuditOutput struct {
+	Entries []*audit.Entry
+}
+
+var diagAuditCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the commands executed against the daemon.",
+		ShortDescription: `
+Shows the audit log of the commands run through the daemon API, newest first:
+when and by whom a command was run, its arguments, exit status and duration.
+Secret arguments and options such as passwords, private keys and mnemonics
+are redacted before they are written.
+`,
+		LongDescription: `
+Shows the audit log of the commands run through the daemon API, newest first:
+when and by whom a command was run, its arguments, exit status and duration.
+Secret arguments and options such as passwords, private keys and mnemonics
+are redacted before they are written.
+
+The caller is a fingerprint of the bearer token of the request when it has
+one, else its remote address. The exit status is the one the CLI exits with
+(see 'btfs --help').
+
+The log is kept in audit.log in the repo and rotated once it reaches
+Audit.MaxSizeMB (16 MiB by default), keeping the previous log. It can be
+turned off with:
+
+    btfs config --json Audit.Disabled true
+
+Examples:
+
+    btfs diag audit --since 24h --failed
+    btfs diag audit --command "wallet withdraw" --caller addr:127.0.0.1
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(auditSinceOptionName, "s", "Only show entries newer than a duration (e.g. \"24h\") or an RFC 3339 time."),
+		cmds.StringOption(auditCommandOptionName, "Only show a command and its subcommands, e.g. \"wallet\"."),
+		cmds.StringOption(auditCallerOptionName, "Only show the commands of a caller."),
+		cmds.BoolOption(auditFailedOptionName, "f", "Only show failed commands."),
+		cmds.IntOption(auditLimitOptionName, "n", "Maximum number of entries to show, 0 for all.").WithDefault(100),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfgRoot, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		f := &audit.Filter{}
+		if s, ok := req.Options[auditSinceOptionName].(string); ok {
+			if f.Since, err = parseAuditSince(s, time.Now()); err != nil {
+				return cmds.Errorf(cmds.ErrClient, "%s", err)
+			}
+		}
+		f.Command, _ = req.Options[auditCommandOptionName].(string)
+		f.Caller, _ = req.Options[auditCallerOptionName].(string)
+		f.Failed, _ = req.Options[auditFailedOptionName].(bool)
+		f.Limit, _ = req.Options[auditLimitOptionName].(int)
+
+		entries, err := audit.Read(cfgRoot, f)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &AuditOutput{Entries: entries})
+	},
+	Type: AuditOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *AuditOutput) error {
+			renderAudit(w, out)
+			return nil
+		}),
+	},
+}
+
+// parseAuditSince parses a duration before now or an RFC 3339 time.
+func parseAuditSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q: not a duration nor an RFC 3339 time", auditSinceOptionName, s)
+	}
+	return t, nil
+}
+
+func renderAudit(w io.Writer, out *AuditOutput) {
+	tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tCALLER\tCODE\tDURATION\tCOMMAND")
+	for _, en := range out.Entries {
+		cmd := en.Command
+		if len(en.Args) > 0 {
+			cmd += " " + strings.Join(en.Args, " ")
+		}
+		if en.Error != "" {
+			cmd += "  (" + en.Error + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", en.Time.Format(time.RFC3339), en.Caller, en.Code,
+			en.Duration.Round(time.Millisecond), cmd)
+	}
+	tw.Flush()
+}
+
+const latencyClassOptionName = "class"
+
+// LatencyClass is the latency of the peers of a class.
+type LatencyClass struct {
+	Class string
+	// Stats are over the RTTs of all the peers of the class.
+	Stats   latency.Stats
+	Targets []*LatencyTarget
+}
+
+// LatencyTarget is the latency of a peer.
+type LatencyTarget struct {
+	ID       string
+	Stats    latency.Stats
+	Failures int
+	Updated  time.Time
+}
+
+// LatencyOutput lists the latency of the peer classes.
+type LatencyOutput struct {
+	Classes []*LatencyClass
+}
+
+var diagLatencyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the latency to the bootstrap peers and the hub.",
+		ShortDescription: `
+The daemon measures the round-trip time to the bootstrap peers and the hub
+every ten minutes, keeping the last 64 measures of each. This shows their
+percentiles by peer class, then by peer. On start, the node dials the
+bootstrap peers with the lowest median first.
+
+A peer failing since its last successful measure is not preferred, its
+failures are counted in the FAIL column.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(latencyClassOptionName, "Only show a peer class: bootstrap or hub."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		classes := latency.Classes
+		if c, ok := req.Options[latencyClassOptionName].(string); ok {
+			if !latency.IsClass(c) {
+				return cmds.Errorf(cmds.ErrClient, "unknown peer class %q, expected one of %s", c,
+					strings.Join(latency.Classes, ", "))
+			}
+			classes = []string{c}
+		}
+		out := &LatencyOutput{}
+		for _, c := range classes {
+			targets, err := latency.List(n.Repo.Datastore(), n.Identity.Pretty(), c)
+			if err != nil {
+				return err
+			}
+			lc := &LatencyClass{Class: c}
+			var all []time.Duration
+			for _, t := range targets {
+				all = append(all, t.RTTs...)
+				lc.Targets = append(lc.Targets, &LatencyTarget{
+					ID:       t.ID,
+					Stats:    latency.Percentiles(t.RTTs),
+					Failures: t.Failures,
+					Updated:  t.Updated,
+				})
+			}
+			lc.Stats = latency.Percentiles(all)
+			out.Classes = append(out.Classes, lc)
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: LatencyOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *LatencyOutput) error {
+			renderLatency(w, out)
+			return nil
+		}),
+	},
+}
+
+func renderLatency(w io.Writer, out *LatencyOutput) {
+	ms := func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	}
+	tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PEER\tSAMPLES\tP50\tP90\tP99\tFAIL")
+	for _, c := range out.Classes {
+		s := c.Stats
+		if s.Count == 0 {
+			fmt.Fprintf(tw, "%s\t0\t-\t-\t-\t\n", c.Class)
+		} else {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t\n", c.Class, s.Count, ms(s.P50), ms(s.P90), ms(s.P99))
+		}
+		for _, t := range c.Targets {
+			s := t.Stats
+			if s.Count == 0 {
+				fmt.Fprintf(tw, "  %s\t0\t-\t-\t-\t%d\n", t.ID, t.Failures)
+				continue
+			}
+			fmt.Fprintf(tw, "  %s\t%d\t%s\t%s\t%s\t%d\n", t.ID, s.Count, ms(s.P50), ms(s.P90), ms(s.P99), t.Failures)
+		}
+	}
+	tw.Flush()
+}
+
+var diagClockCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Check the system clock against NTP servers.",
+		ShortDescription: `
+Guard and escrow reject the contracts whose timestamps are off their own
+clocks, and hosts reject the contracts of renters whose clocks are ahead of
+theirs. This queries the NTP servers and shows the offset of the system
+clock to each, the median of which must be within the tolerance for the
+node to sign contracts. The daemon checks it every five minutes.
+
+The servers and the tolerance are set with:
+
+    $ btfs config --json Clock.Servers '["pool.ntp.org"]'
+    $ btfs config Clock.Tolerance 30s
+
+and the checks turned off, for nodes without NTP access, with:
+
+    $ btfs config --json Clock.Disabled true
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		c, err := clock.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, clock.Check(req.Context, c, time.Now()))
 	},
+	Type: clock.Report{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, r *clock.Report) error {
+			renderClock(w, r)
+			return nil
+		}),
+	},
+}
+
+func renderClock(w io.Writer, r *clock.Report) {
+	tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVER\tOFFSET\tRTT")
+	for _, s := range r.Samples {
+		if s.Error != "" {
+			fmt.Fprintf(tw, "%s\t-\t-\t(%s)\n", s.Server, s.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Server, s.Offset.Round(time.Millisecond), s.RTT.Round(time.Millisecond))
+	}
+	tw.Flush()
+	switch {
+	case !r.Synced:
+		fmt.Fprintln(w, "no server answered, the clock is not checked")
+	case r.Skewed():
+		fmt.Fprintf(w, "%s\n", r.Err())
+	default:
+		fmt.Fprintf(w, "system clock off by %s, within the %s tolerance\n", r.Offset.Round(time.Millisecond), r.Tolerance)
+	}
 }
@@ -0,0 +1,349 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/core/hub"
+	"github.com/TRON-US/go-btfs/core/latency"
+	"github.com/TRON-US/go-btfs/core/speedtest"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	humanize "github.com/dustin/go-humanize"
+	cid "github.com/ipfs/go-cid"
+	"github.com/ipfs/go-merkledag"
+	peer "github.com/libp2p/go-libp2p-core/peer"
+	ping "github.com/libp2p/go-libp2p/p2p/protocol/ping"
+)
+
+const (
+	speedtestSizeOptionName    = "size"
+	speedtestHostsOptionName   = "hosts"
+	speedtestPeerOptionName    = "peer"
+	speedtestGatewayOptionName = "gateway"
+	speedtestSamplesOptionName = "samples"
+
+	defaultSpeedtestGateway = "https://gateway.btfs.io"
+	// speedtestCandidates bounds the hosts pinged to find the nearest.
+	speedtestCandidates = 20
+	speedtestTimeout    = 2 * time.Minute
+	// speedtestKeep is how long a host keeps the test object it added for
+	// the download.
+	speedtestKeep = 5 * time.Minute
+)
+
+// SpeedtestOutput is the result of a speed test.
+type SpeedtestOutput struct {
+	Size    int64
+	Cid     string
+	Results []*speedtest.Result
+}
+
+var diagSpeedtestCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Test the connectivity of the node to the BTFS network.",
+		ShortDescription: `
+Adds a throwaway test object of random bytes, then measures the latency to
+a few nearby hosts and public gateways, and the throughput of the object
+uploaded to and downloaded from them. Run it on a new host to validate its
+connectivity before it accepts contracts:
+
+    $ btfs diag speedtest
+    $ btfs diag speedtest --size 16MB --hosts 5 --gateway https://gateway.btfs.io
+
+The nearest hosts are picked among the known hosts by their ping, unless
+given with --peer. A host fetches the test object of the node, the upload,
+and serves one of the same size, the download. A gateway fetches the test
+object from the network on the first request, the upload, and serves it from
+its cache on the second, the download. The test objects are removed once
+measured.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(speedtestSizeOptionName, "Size of the test object, e.g. 4MB, at most 32MiB.").WithDefault("4MiB"),
+		cmds.IntOption(speedtestHostsOptionName, "Number of nearby hosts tested.").WithDefault(3),
+		cmds.StringsOption(speedtestPeerOptionName, "Peer ID of a host to test instead of the nearby hosts, may be given multiple times."),
+		cmds.StringsOption(speedtestGatewayOptionName, "Public gateway to test, may be given multiple times. Default: "+defaultSpeedtestGateway+"."),
+		cmds.IntOption(speedtestSamplesOptionName, "Number of latency samples per target.").WithDefault(5),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if !n.IsOnline {
+			return ErrNotOnline
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		size, err := humanize.ParseBytes(req.Options[speedtestSizeOptionName].(string))
+		if err != nil {
+			return cmds.Errorf(cmds.ErrClient, "invalid size: %s", err)
+		}
+		samples := req.Options[speedtestSamplesOptionName].(int)
+		if samples <= 0 {
+			return cmds.Errorf(cmds.ErrClient, "samples must be positive")
+		}
+		var pids []peer.ID
+		peers, _ := req.Options[speedtestPeerOptionName].([]string)
+		for _, s := range peers {
+			pid, err := peer.IDB58Decode(s)
+			if err != nil {
+				return cmds.Errorf(cmds.ErrClient, "invalid peer id %s: %s", s, err)
+			}
+			pids = append(pids, pid)
+		}
+		gateways, _ := req.Options[speedtestGatewayOptionName].([]string)
+		if len(gateways) == 0 {
+			gateways = []string{defaultSpeedtestGateway}
+		}
+
+		data, err := speedtest.Object(int64(size))
+		if err != nil {
+			return cmds.Errorf(cmds.ErrClient, err.Error())
+		}
+		added, err := api.Unixfs().Add(req.Context, files.NewBytesFile(data), options.Unixfs.Pin(false))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := speedtest.Remove(context.Background(), n.DAG, added.Cid()); err != nil {
+				log.Debugf("remove the test object %s: %s", added.Cid(), err)
+			}
+		}()
+		out := &SpeedtestOutput{Size: int64(size), Cid: added.Cid().String()}
+
+		if len(pids) == 0 {
+			if pids, err = nearestHosts(req.Context, n, req.Options[speedtestHostsOptionName].(int)); err != nil {
+				return err
+			}
+		}
+		// one target at a time, so that they do not share the bandwidth
+		for _, pid := range pids {
+			out.Results = append(out.Results, hostSpeedtest(req.Context, n, api, pid, added.Cid(), int64(size), samples))
+		}
+
+		pctx, cancel := context.WithTimeout(req.Context, speedtestTimeout)
+		err = n.Routing.Provide(pctx, added.Cid(), true)
+		cancel()
+		client := &http.Client{Timeout: speedtestTimeout}
+		for _, g := range gateways {
+			if err != nil {
+				out.Results = append(out.Results, &speedtest.Result{Kind: speedtest.KindGateway, ID: g,
+					Error: fmt.Sprintf("provide the test object: %s", err)})
+				continue
+			}
+			out.Results = append(out.Results, speedtest.Gateway(req.Context, client, g, out.Cid, out.Size, samples))
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: SpeedtestOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *SpeedtestOutput) error {
+			ms := func(d time.Duration) string {
+				return d.Round(time.Millisecond).String()
+			}
+			rate := func(r int64) string {
+				if r <= 0 {
+					return "-"
+				}
+				return humanize.Bytes(uint64(r)) + "/s"
+			}
+			fmt.Fprintf(w, "Test object %s (%s)\n\n", out.Cid, humanize.Bytes(uint64(out.Size)))
+			tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "TARGET\tKIND\tP50\tP90\tP99\tUPLOAD\tDOWNLOAD\tERROR")
+			for _, r := range out.Results {
+				p50, p90, p99 := "-", "-", "-"
+				if r.Latency.Count > 0 {
+					p50, p90, p99 = ms(r.Latency.P50), ms(r.Latency.P90), ms(r.Latency.P99)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Kind, p50, p90, p99,
+					rate(r.Upload), rate(r.Download), r.Error)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// nearestHosts returns the num known hosts with the lowest ping.
+func nearestHosts(ctx context.Context, n *core.IpfsNode, num int) ([]peer.ID, error) {
+	if num <= 0 {
+		return nil, cmds.Errorf(cmds.ErrClient, "hosts must be positive")
+	}
+	hosts, err := helper.GetHostsFromDatastore(ctx, n, hub.HubModeAll, speedtestCandidates)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	rtts := map[string]time.Duration{}
+	for _, h := range hosts {
+		pid, err := peer.IDB58Decode(h.NodeId)
+		if err != nil || pid == n.Identity {
+			continue
+		}
+		wg.Add(1)
+		go func(pid peer.ID) {
+			defer wg.Done()
+			if rs := pingRTTs(ctx, n, pid, 1); len(rs) > 0 {
+				mu.Lock()
+				rtts[pid.Pretty()] = rs[0]
+				mu.Unlock()
+			}
+		}(pid)
+	}
+	wg.Wait()
+	var pids []peer.ID
+	for _, id := range speedtest.Nearest(rtts, num) {
+		pid, _ := peer.IDB58Decode(id)
+		pids = append(pids, pid)
+	}
+	if len(pids) == 0 {
+		return nil, fmt.Errorf("no reachable host, sync them with 'btfs storage announce' or give them with --%s",
+			speedtestPeerOptionName)
+	}
+	return pids, nil
+}
+
+// pingRTTs returns the RTTs of count pings to pid, the successful ones.
+func pingRTTs(ctx context.Context, n *core.IpfsNode, pid peer.ID, count int) []time.Duration {
+	ctx, cancel := context.WithTimeout(ctx, kPingTimeout*time.Duration(count))
+	defer cancel()
+	var rtts []time.Duration
+	pings := ping.Ping(ctx, n.PeerHost, pid)
+	for i := 0; i < count; i++ {
+		r, ok := <-pings
+		if !ok {
+			break
+		}
+		if r.Error == nil {
+			rtts = append(rtts, r.RTT)
+		}
+	}
+	return rtts
+}
+
+func hostSpeedtest(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, pid peer.ID, c cid.Cid,
+	size int64, samples int) *speedtest.Result {
+	r := &speedtest.Result{Kind: speedtest.KindHost, ID: pid.Pretty()}
+	rtts := pingRTTs(ctx, n, pid, samples)
+	if len(rtts) == 0 {
+		r.Error = "unreachable"
+		return r
+	}
+	r.Latency = latency.Percentiles(rtts)
+
+	ctx, cancel := context.WithTimeout(ctx, speedtestTimeout)
+	defer cancel()
+	b, err := remote.P2PCallStrings(ctx, n, api, pid, "/diag/speedtest", c.String(), strconv.FormatInt(size, 10))
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	reply := &speedtest.HostReply{}
+	if err := json.Unmarshal(b, reply); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Upload = speedtest.Throughput(size, reply.Fetch)
+
+	hc, err := cid.Decode(reply.Cid)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	start := time.Now()
+	if err := merkledag.FetchGraph(ctx, hc, n.DAG); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Download = speedtest.Throughput(size, time.Since(start))
+	if err := speedtest.Remove(ctx, n.DAG, hc); err != nil {
+		log.Debugf("remove the test object %s of %s: %s", hc, pid, err)
+	}
+	return r
+}
+
+// speedtestServing bounds the speed tests served at once.
+var speedtestServing = make(chan struct{}, 1)
+
+// DiagSpeedtestCmd is called by a node testing its speed: it fetches the
+// test object of the node, then adds one of the same size for the node to
+// download.
+var DiagSpeedtestCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Serve the speed test of a node.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", true, false, "Test object of the node."),
+		cmds.StringArg("size", true, false, "Size of the test object in bytes."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		c, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		size, err := strconv.ParseInt(req.Arguments[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		data, err := speedtest.Object(size)
+		if err != nil {
+			return err
+		}
+		select {
+		case speedtestServing <- struct{}{}:
+			defer func() { <-speedtestServing }()
+		default:
+			return fmt.Errorf("busy with another speed test, retry later")
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context, speedtestTimeout)
+		defer cancel()
+		start := time.Now()
+		if err := merkledag.FetchGraph(ctx, c, n.DAG); err != nil {
+			return err
+		}
+		reply := &speedtest.HostReply{Fetch: time.Since(start)}
+		if err := speedtest.Remove(ctx, n.DAG, c); err != nil {
+			log.Debugf("remove the test object %s: %s", c, err)
+		}
+
+		added, err := api.Unixfs().Add(ctx, files.NewBytesFile(data), options.Unixfs.Pin(false))
+		if err != nil {
+			return err
+		}
+		reply.Cid = added.Cid().String()
+		time.AfterFunc(speedtestKeep, func() {
+			if err := speedtest.Remove(context.Background(), n.DAG, added.Cid()); err != nil {
+				log.Debugf("remove the test object %s: %s", added.Cid(), err)
+			}
+		})
+		return cmds.EmitOnce(res, reply)
+	},
+	Type: speedtest.HostReply{},
+}
@@ -0,0 +1,419 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/clock"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/repo"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	config "github.com/TRON-US/go-btfs-config"
+	cgrpc "github.com/tron-us/go-btfs-common/utils/grpc"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr-net"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	doctorTimeoutOptionName = "probe-timeout"
+
+	lowDiskRatio = 0.05
+	lowDiskBytes = 5 << 30
+)
+
+// Doctor finding severities, most urgent first.
+const (
+	doctorCritical = "critical"
+	doctorWarning  = "warning"
+	doctorOk       = "ok"
+)
+
+var doctorSeverityRank = map[string]int{
+	doctorCritical: 0,
+	doctorWarning:  1,
+	doctorOk:       2,
+}
+
+// DoctorFinding is the result of a single doctor check.
+type DoctorFinding struct {
+	Check    string
+	Severity string
+	Message  string
+	Fix      string `json:",omitempty"`
+}
+
+// DoctorOutput is the list of findings, ordered by priority.
+type DoctorOutput struct {
+	Findings []DoctorFinding
+}
+
+var DoctorCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Diagnose common node misconfigurations.",
+		ShortDescription: `
+'btfs doctor' checks the node for common problems and prints suggested fixes,
+most urgent first:
+
+  - swarm, API and gateway ports that are unusable or unreachable
+  - clock skew, which makes guard and escrow reject contract signatures
+  - low disk space in the repo
+  - repo and datastore permissions
+  - announced addresses the node no longer listens on
+  - unreachable guard, escrow, hub, status and chain endpoints
+
+Network probes use --probe-timeout each.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(doctorTimeoutOptionName, "Timeout of each network probe.").WithDefault("10s"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		timeout, err := time.ParseDuration(req.Options[doctorTimeoutOptionName].(string))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", doctorTimeoutOptionName, err)
+		}
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		cfgRoot, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+
+		var findings []DoctorFinding
+		findings = append(findings, doctorPorts(nd, cfg)...)
+		findings = append(findings, doctorClock(req.Context, nd.Repo, timeout)...)
+		findings = append(findings, doctorDisk(cfgRoot)...)
+		findings = append(findings, doctorPermissions(cfgRoot)...)
+		findings = append(findings, doctorAnnounce(nd, cfg)...)
+		findings = append(findings, doctorEndpoints(req.Context, cfg, timeout)...)
+		sortDoctorFindings(findings)
+
+		return cmds.EmitOnce(res, &DoctorOutput{Findings: findings})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *DoctorOutput) error {
+			for _, f := range out.Findings {
+				fmt.Fprintf(w, "[%s] %s: %s\n", f.Severity, f.Check, f.Message)
+				if f.Fix != "" {
+					fmt.Fprintf(w, "    fix: %s\n", f.Fix)
+				}
+			}
+			return nil
+		}),
+	},
+	Type: DoctorOutput{},
+}
+
+// sortDoctorFindings orders findings by severity, keeping the check order
+// within the same severity.
+func sortDoctorFindings(findings []DoctorFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return doctorSeverityRank[findings[i].Severity] < doctorSeverityRank[findings[j].Severity]
+	})
+}
+
+func doctorPorts(nd *core.IpfsNode, cfg *config.Config) []DoctorFinding {
+	const check = "ports"
+	if !nd.IsOnline {
+		// Without a daemon the configured ports must be free to bind.
+		var findings []DoctorFinding
+		addrs := append([]string{}, cfg.Addresses.Swarm...)
+		addrs = append(addrs, cfg.Addresses.API...)
+		addrs = append(addrs, cfg.Addresses.Gateway...)
+		for _, s := range addrs {
+			if err := tryListen(s); err != nil {
+				findings = append(findings, DoctorFinding{
+					Check:    check,
+					Severity: doctorCritical,
+					Message:  fmt.Sprintf("cannot listen on %s: %v", s, err),
+					Fix:      "stop the process using the port or change it with 'btfs config Addresses'",
+				})
+			}
+		}
+		if len(findings) == 0 {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorOk,
+				Message: "configured ports are free"})
+		}
+		return findings
+	}
+
+	if len(nd.PeerHost.Network().Peers()) == 0 {
+		return []DoctorFinding{{
+			Check:    check,
+			Severity: doctorCritical,
+			Message:  "the node has no swarm peers",
+			Fix:      "allow inbound TCP on the swarm port in the firewall and check 'btfs bootstrap list'",
+		}}
+	}
+	for _, a := range nd.PeerHost.Addrs() {
+		if manet.IsPublicAddr(a) {
+			return []DoctorFinding{{Check: check, Severity: doctorOk,
+				Message: fmt.Sprintf("swarm is reachable at %s", a)}}
+		}
+	}
+	return []DoctorFinding{{
+		Check:    check,
+		Severity: doctorWarning,
+		Message:  "the node has no public swarm address and is probably behind NAT",
+		Fix:      "forward the swarm port on the router or set Addresses.Announce to the public address",
+	}}
+}
+
+// tryListen reports whether the TCP multiaddr s can be bound.
+func tryListen(s string) error {
+	maddr, err := ma.NewMultiaddr(s)
+	if err != nil {
+		return err
+	}
+	network, host, err := manet.DialArgs(maddr)
+	if err != nil {
+		return err
+	}
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil
+	}
+	l, err := net.Listen(network, host)
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
+
+func doctorClock(ctx context.Context, r repo.Repo, timeout time.Duration) []DoctorFinding {
+	const check = "clock"
+	c, err := clock.Load(r)
+	if err != nil {
+		return []DoctorFinding{{Check: check, Severity: doctorWarning,
+			Message: fmt.Sprintf("cannot check clock: %v", err)}}
+	}
+	if c.Disabled {
+		return []DoctorFinding{{Check: check, Severity: doctorWarning,
+			Message: "clock checks are disabled",
+			Fix:     "make sure time synchronization (NTP) is enabled on this machine"}}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	rep := clock.Check(ctx, c, time.Now())
+	if !rep.Synced {
+		return []DoctorFinding{{Check: check, Severity: doctorWarning,
+			Message: fmt.Sprintf("cannot check clock: no answer from %s", strings.Join(c.Servers, ", ")),
+			Fix:     fmt.Sprintf("allow NTP (UDP port 123) or set %s.Servers", clock.ConfigKey)}}
+	}
+	return []DoctorFinding{clockFinding(rep.Offset, rep.Tolerance)}
+}
+
+// clockFinding rates the skew against the tolerance of the contract
+// timestamps, warning from half of it.
+func clockFinding(skew, tolerance time.Duration) DoctorFinding {
+	const check = "clock"
+	if skew < 0 {
+		skew = -skew
+	}
+	switch {
+	case skew > tolerance:
+		return DoctorFinding{Check: check, Severity: doctorCritical,
+			Message: fmt.Sprintf("system clock is off by %s; contract signatures will be rejected",
+				skew.Round(time.Millisecond)),
+			Fix: "enable time synchronization (NTP) on this machine"}
+	case skew > tolerance/2:
+		return DoctorFinding{Check: check, Severity: doctorWarning,
+			Message: fmt.Sprintf("system clock is off by %s, close to the %s tolerance",
+				skew.Round(time.Millisecond), tolerance),
+			Fix: "enable time synchronization (NTP) on this machine"}
+	default:
+		return DoctorFinding{Check: check, Severity: doctorOk,
+			Message: fmt.Sprintf("system clock is within %s", tolerance/2)}
+	}
+}
+
+func doctorDisk(cfgRoot string) []DoctorFinding {
+	free, total, err := diskUsage(cfgRoot)
+	if err != nil {
+		return []DoctorFinding{{Check: "disk", Severity: doctorWarning,
+			Message: fmt.Sprintf("cannot read disk usage: %v", err)}}
+	}
+	return []DoctorFinding{diskFinding(free, total)}
+}
+
+func diskFinding(free, total uint64) DoctorFinding {
+	const check = "disk"
+	msg := fmt.Sprintf("%d of %d bytes free", free, total)
+	if total > 0 && float64(free)/float64(total) < lowDiskRatio || free < lowDiskBytes {
+		return DoctorFinding{Check: check, Severity: doctorCritical, Message: msg,
+			Fix: "free up space, run 'btfs repo gc' or move the repo to a larger disk"}
+	}
+	return DoctorFinding{Check: check, Severity: doctorOk, Message: msg}
+}
+
+func doctorPermissions(cfgRoot string) []DoctorFinding {
+	const check = "permissions"
+	var findings []DoctorFinding
+	for _, p := range []string{cfgRoot, filepath.Join(cfgRoot, "datastore"), filepath.Join(cfgRoot, "blocks")} {
+		fi, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorCritical,
+				Message: fmt.Sprintf("cannot access %s: %v", p, err),
+				Fix:     fmt.Sprintf("make %s owned by the user running btfs", p)})
+			continue
+		}
+		if fi.Mode().Perm()&0200 == 0 {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorCritical,
+				Message: fmt.Sprintf("%s is not writable by its owner", p),
+				Fix:     fmt.Sprintf("chmod u+rwx %s", p)})
+		}
+		if fi.Mode().Perm()&0002 != 0 {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorWarning,
+				Message: fmt.Sprintf("%s is world-writable", p),
+				Fix:     fmt.Sprintf("chmod o-w %s", p)})
+		}
+	}
+	cfgFile := filepath.Join(cfgRoot, "config")
+	if fi, err := os.Stat(cfgFile); err == nil && fi.Mode().Perm()&0077 != 0 {
+		findings = append(findings, DoctorFinding{Check: check, Severity: doctorWarning,
+			Message: fmt.Sprintf("%s holds the private key and is readable by others", cfgFile),
+			Fix:     fmt.Sprintf("chmod 600 %s", cfgFile)})
+	}
+	if len(findings) == 0 {
+		findings = append(findings, DoctorFinding{Check: check, Severity: doctorOk,
+			Message: "repo permissions look fine"})
+	}
+	return findings
+}
+
+func doctorAnnounce(nd *core.IpfsNode, cfg *config.Config) []DoctorFinding {
+	const check = "announce"
+	if len(cfg.Addresses.Announce) == 0 {
+		return []DoctorFinding{{Check: check, Severity: doctorOk, Message: "no addresses are announced explicitly"}}
+	}
+	var local []ma.Multiaddr
+	if ifaces, err := manet.InterfaceMultiaddrs(); err == nil {
+		local = append(local, ifaces...)
+	}
+	if nd.IsOnline {
+		local = append(local, nd.PeerHost.Addrs()...)
+	}
+	var findings []DoctorFinding
+	for _, s := range cfg.Addresses.Announce {
+		a, err := ma.NewMultiaddr(s)
+		if err != nil {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorCritical,
+				Message: fmt.Sprintf("invalid announce address %q: %v", s, err),
+				Fix:     "fix or remove the entry in Addresses.Announce"})
+			continue
+		}
+		if manet.IsPrivateAddr(a) || manet.IsIPLoopback(a) {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorWarning,
+				Message: fmt.Sprintf("announced address %s is not publicly routable", s),
+				Fix:     "announce the public address of this node instead"})
+			continue
+		}
+		if nd.IsOnline && !announcedIPKnown(a, local) {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorWarning,
+				Message: fmt.Sprintf("announced address %s is not observed on this node and may be stale", s),
+				Fix:     "update Addresses.Announce to the current public address"})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, DoctorFinding{Check: check, Severity: doctorOk,
+			Message: "announced addresses look current"})
+	}
+	return findings
+}
+
+// announcedIPKnown reports whether the IP of a matches one of local.
+func announcedIPKnown(a ma.Multiaddr, local []ma.Multiaddr) bool {
+	ip, err := manet.ToIP(a)
+	if err != nil {
+		// DNS announce addresses cannot be checked here.
+		return true
+	}
+	for _, l := range local {
+		if lip, err := manet.ToIP(l); err == nil && lip.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func doctorEndpoints(ctx context.Context, cfg *config.Config, timeout time.Duration) []DoctorFinding {
+	grpcServices := []struct {
+		name, addr, service string
+	}{
+		{"guard", cfg.Services.GuardDomain, "guard-interceptor"},
+		{"escrow", cfg.Services.EscrowDomain, "escrow"},
+		{"hub", cfg.Services.HubDomain, "hub"},
+		{"status", cfg.Services.StatusServerDomain, "status-server"},
+	}
+	var findings []DoctorFinding
+	for _, s := range grpcServices {
+		check := "endpoint/" + s.name
+		if s.addr == "" {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorCritical,
+				Message: "no endpoint configured",
+				Fix:     fmt.Sprintf("set the %s domain under Services in the config", s.name)})
+			continue
+		}
+		client := cgrpc.HealthCheckClient(s.addr)
+		client.Timeout(timeout)
+		err := client.WithContext(ctx, func(ctx context.Context, client grpc_health_v1.HealthClient) error {
+			resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: s.service})
+			if err != nil {
+				return err
+			}
+			if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+				return fmt.Errorf("service status is %s", resp.Status)
+			}
+			return nil
+		})
+		if err != nil {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorCritical,
+				Message: fmt.Sprintf("%s is unreachable: %v", s.addr, err),
+				Fix:     "check the network, proxy and firewall settings or the Services config"})
+			continue
+		}
+		findings = append(findings, DoctorFinding{Check: check, Severity: doctorOk,
+			Message: fmt.Sprintf("%s is serving", s.addr)})
+	}
+
+	// Chain nodes are plain host:port gRPC endpoints.
+	for _, s := range []struct{ name, addr string }{
+		{"solidity", cfg.Services.SolidityDomain},
+		{"fullnode", cfg.Services.FullnodeDomain},
+	} {
+		check := "endpoint/" + s.name
+		if s.addr == "" {
+			continue
+		}
+		conn, err := net.DialTimeout("tcp", s.addr, timeout)
+		if err != nil {
+			findings = append(findings, DoctorFinding{Check: check, Severity: doctorCritical,
+				Message: fmt.Sprintf("%s is unreachable: %v", s.addr, err),
+				Fix:     "check the network and firewall settings or the Services config"})
+			continue
+		}
+		conn.Close()
+		findings = append(findings, DoctorFinding{Check: check, Severity: doctorOk,
+			Message: fmt.Sprintf("%s accepts connections", s.addr)})
+	}
+	return findings
+}
@@ -0,0 +1,13 @@
+package commands
+
+import "syscall"
+
+// diskUsage returns the bytes available to the user running btfs on the
+// disk of path, and its capacity.
+func diskUsage(path string) (free uint64, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return uint64(st.F_bavail) * uint64(st.F_bsize), st.F_blocks * uint64(st.F_bsize), nil
+}
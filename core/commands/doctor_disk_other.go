@@ -0,0 +1,14 @@
+// +build !linux,!darwin,!freebsd,!dragonfly,!openbsd,!netbsd,!solaris
+
+package commands
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// diskUsage returns the bytes available to the user running btfs on the
+// disk of path, and its capacity.
+func diskUsage(path string) (free uint64, total uint64, err error) {
+	return 0, 0, fmt.Errorf("disk usage is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,16 @@
+// +build netbsd solaris
+
+package commands
+
+import "golang.org/x/sys/unix"
+
+// diskUsage returns the bytes available to the user running btfs on the
+// disk of path, and its capacity.
+func diskUsage(path string) (free uint64, total uint64, err error) {
+	var st unix.Statvfs_t
+	if err := unix.Statvfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	// Frsize is 32 bits wide on some systems
+	return st.Bavail * uint64(st.Frsize), st.Blocks * uint64(st.Frsize), nil
+}
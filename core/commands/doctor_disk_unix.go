@@ -0,0 +1,16 @@
+// +build linux darwin freebsd dragonfly
+
+package commands
+
+import "syscall"
+
+// diskUsage returns the bytes available to the user running btfs on the
+// disk of path, and its capacity.
+func diskUsage(path string) (free uint64, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	// the field types differ between the systems
+	return uint64(st.Bavail) * uint64(st.Bsize), uint64(st.Blocks) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/clock"
+)
+
+func TestClockFinding(t *testing.T) {
+	cases := []struct {
+		skew time.Duration
+		want string
+	}{
+		{time.Second, doctorOk},
+		{-15 * time.Second, doctorOk},
+		{-16 * time.Second, doctorWarning},
+		{30 * time.Second, doctorWarning},
+		{-31 * time.Second, doctorCritical},
+		{2 * time.Minute, doctorCritical},
+	}
+	for _, c := range cases {
+		if got := clockFinding(c.skew, clock.DefaultTolerance).Severity; got != c.want {
+			t.Errorf("skew %s: got %s, want %s", c.skew, got, c.want)
+		}
+	}
+}
+
+func TestDiskFinding(t *testing.T) {
+	if got := diskFinding(100<<30, 200<<30).Severity; got != doctorOk {
+		t.Errorf("half free disk: got %s", got)
+	}
+	if got := diskFinding(1<<30, 200<<30).Severity; got != doctorCritical {
+		t.Errorf("nearly full disk: got %s", got)
+	}
+	// more bytes free than lowDiskBytes, but under lowDiskRatio
+	if got := diskFinding(20<<30, 1<<40).Severity; got != doctorCritical {
+		t.Errorf("disk 2%% free: got %s", got)
+	}
+	if got := diskFinding(60<<30, 1<<40).Severity; got != doctorOk {
+		t.Errorf("disk 6%% free: got %s", got)
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	switch runtime.GOOS {
+	case "windows", "plan9", "js", "aix":
+		t.Skip("disk usage not supported")
+	}
+	free, total, err := diskUsage(os.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total == 0 || free > total {
+		t.Fatalf("expected at most %d bytes free, got %d", total, free)
+	}
+}
+
+func TestSortDoctorFindings(t *testing.T) {
+	findings := []DoctorFinding{
+		{Check: "a", Severity: doctorOk},
+		{Check: "b", Severity: doctorWarning},
+		{Check: "c", Severity: doctorCritical},
+		{Check: "d", Severity: doctorWarning},
+	}
+	sortDoctorFindings(findings)
+	var got string
+	for _, f := range findings {
+		got += f.Check
+	}
+	if got != "cbda" {
+		t.Errorf("got order %s, want cbda", got)
+	}
+}
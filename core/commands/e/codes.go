@@ -0,0 +1,104 @@
+package e
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	ds "github.com/ipfs/go-datastore"
+	lock "github.com/ipfs/go-fs-lock"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+// Error codes of btfs failures. They extend the error types of go-btfs-cmds
+// and are carried by cmds.Error, so they go through the HTTP API.
+const (
+	// ErrAuth is a failed authentication, e.g. a wrong wallet password.
+	ErrAuth cmds.ErrorType = 100 + iota
+	// ErrNotFound is a missing object, file, key or peer.
+	ErrNotFound
+	// ErrInsufficientFunds is a balance too low for the operation.
+	ErrInsufficientFunds
+	// ErrNetwork is a failure to reach the daemon, a peer or a service.
+	ErrNetwork
+	// ErrConflict is a resource held or changed by someone else, e.g. the
+	// repo lock.
+	ErrConflict
+)
+
+// Exit statuses of the btfs CLI. They are stable, scripts may rely on them.
+const (
+	ExitOK                = 0
+	ExitFailure           = 1
+	ExitAuth              = 2
+	ExitNotFound          = 3
+	ExitInsufficientFunds = 4
+	ExitNetwork           = 5
+	ExitConflict          = 6
+	ExitTimedOut          = 7
+)
+
+// Code returns the error code of err: the code of a cmds.Error in its
+// chain, else one guessed from well known errors, else cmds.ErrNormal.
+func Code(err error) cmds.ErrorType {
+	var code cmds.ErrorType
+	// cmds.Error unwraps to its code.
+	if errors.As(err, &code) && code != cmds.ErrNormal {
+		return code
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return cmds.ErrTimedOut
+	case errors.Is(err, ds.ErrNotFound), errors.Is(err, ipld.ErrNotFound), errors.Is(err, os.ErrNotExist):
+		return ErrNotFound
+	case errors.As(err, new(lock.LockedError)):
+		return ErrConflict
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return cmds.ErrTimedOut
+		}
+		return ErrNetwork
+	}
+	return cmds.ErrNormal
+}
+
+// WithCode returns err as a cmds.Error carrying its Code, so that the code
+// survives the HTTP API. Errors without a code are returned unchanged.
+func WithCode(err error) error {
+	if err == nil {
+		return nil
+	}
+	// Every cmds.Error unwraps to its code.
+	if errors.As(err, new(cmds.ErrorType)) {
+		return err
+	}
+	if code := Code(err); code != cmds.ErrNormal {
+		return cmds.Error{Message: err.Error(), Code: code}
+	}
+	return err
+}
+
+// ExitCode returns the exit status of the CLI for err.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	switch Code(err) {
+	case ErrAuth, cmds.ErrForbidden:
+		return ExitAuth
+	case ErrNotFound:
+		return ExitNotFound
+	case ErrInsufficientFunds:
+		return ExitInsufficientFunds
+	case ErrNetwork:
+		return ExitNetwork
+	case ErrConflict:
+		return ExitConflict
+	case cmds.ErrTimedOut:
+		return ExitTimedOut
+	}
+	return ExitFailure
+}
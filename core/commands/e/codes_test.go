@@ -0,0 +1,56 @@
+package e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	ds "github.com/ipfs/go-datastore"
+	lock "github.com/ipfs/go-fs-lock"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("boom"), ExitFailure},
+		{cmds.Errorf(cmds.ErrClient, "bad argument"), ExitFailure},
+		{cmds.Errorf(ErrAuth, "incorrect password"), ExitAuth},
+		{&cmds.Error{Message: "forbidden", Code: cmds.ErrForbidden}, ExitAuth},
+		{cmds.Errorf(ErrInsufficientFunds, "not enough balance"), ExitInsufficientFunds},
+		{fmt.Errorf("get: %w", ds.ErrNotFound), ExitNotFound},
+		{&os.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}, ExitNotFound},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ExitNetwork},
+		{fmt.Errorf("repo: %w", lock.LockedError("someone else has the lock")), ExitConflict},
+		{context.DeadlineExceeded, ExitTimedOut},
+		{cmds.Errorf(cmds.ErrTimedOut, "timed out"), ExitTimedOut},
+	} {
+		if got := ExitCode(tc.err); got != tc.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	if WithCode(nil) != nil {
+		t.Error("nil error got a code")
+	}
+	plain := errors.New("boom")
+	if WithCode(plain) != plain {
+		t.Error("error without code changed")
+	}
+	coded := cmds.Errorf(cmds.ErrClient, "bad argument")
+	if WithCode(coded) != coded {
+		t.Error("cmds.Error changed")
+	}
+	err := WithCode(fmt.Errorf("get: %w", ds.ErrNotFound))
+	if ce, ok := err.(cmds.Error); !ok || ce.Code != ErrNotFound || ce.Message != "get: datastore: key not found" {
+		t.Errorf("got %#v", err)
+	}
+}
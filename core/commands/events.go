@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/events"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	eventsTopicOptionName  = "topic"
+	eventsLastIDOptionName = "last-event-id"
+)
+
+var EventsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Print the events of the daemon as they happen.",
+		ShortDescription: `
+Prints the events of the running daemon: the states of the upload sessions
+and the shards contracted (topic upload), and the wallet transactions
+recorded and confirmed (topic wallet). --last-event-id resumes after an
+event, from the recent events the daemon keeps:
+
+    $ btfs events --topic upload
+
+Browsers subscribe to the same events as Server-Sent Events with an
+EventSource on the API, which resumes after a reconnection on its own. The
+topic query parameters select the topics, and the access_token parameter
+carries the API token of a shared node:
+
+    new EventSource('http://127.0.0.1:5001/api/v1/events?topic=upload&topic=wallet')
+
+The event 'missed' is sent first when the events after the last one
+received are no longer kept, e.g. after the daemon restarted.`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(eventsTopicOptionName, "Only print the events of this topic: upload or wallet."),
+		cmds.StringOption(eventsLastIDOptionName, "Print the recent events after this event first."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if !n.IsDaemon {
+			return cmds.Errorf(cmds.ErrClient, "daemon not running")
+		}
+		var topics []string
+		if t, ok := req.Options[eventsTopicOptionName].(string); ok {
+			if t != events.TopicUpload && t != events.TopicWallet {
+				return fmt.Errorf("unknown topic %q, must be %s or %s", t, events.TopicUpload, events.TopicWallet)
+			}
+			topics = append(topics, t)
+		}
+		lastID, _ := req.Options[eventsLastIDOptionName].(string)
+		s, missed, complete := events.Default.Subscribe(lastID, topics...)
+		defer s.Close()
+
+		if !complete {
+			log.Warnf("events after %s are no longer kept", lastID)
+		}
+		for _, e := range missed {
+			if err := res.Emit(e); err != nil {
+				return err
+			}
+		}
+		if f, ok := res.(http.Flusher); ok {
+			f.Flush()
+		}
+		for {
+			select {
+			case e, ok := <-s.Events():
+				if !ok {
+					return fmt.Errorf("events dropped, resume with --%s", eventsLastIDOptionName)
+				}
+				if err := res.Emit(e); err != nil {
+					return err
+				}
+			case <-req.Context.Done():
+				return nil
+			}
+		}
+	},
+	Type: events.Event{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, e *events.Event) error {
+			_, err := fmt.Fprintf(w, "%s %s %s %s\n", e.Time.Format("15:04:05"), e.ID, e.Topic, e.Data)
+			return err
+		}),
+	},
+}
@@ -9,9 +9,11 @@ import (
 	gopath "path"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/TRON-US/go-btfs/core"
 	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/trash"
 
 	"github.com/TRON-US/go-btfs-cmds"
 	"github.com/TRON-US/go-mfs"
@@ -69,16 +71,18 @@ operations.
 		cmds.BoolOption(filesFlushOptionName, "f", "Flush target and ancestors after write.").WithDefault(true),
 	},
 	Subcommands: map[string]*cmds.Command{
-		"read":  filesReadCmd,
-		"write": filesWriteCmd,
-		"mv":    filesMvCmd,
-		"cp":    filesCpCmd,
-		"ls":    filesLsCmd,
-		"mkdir": filesMkdirCmd,
-		"stat":  filesStatCmd,
-		"rm":    filesRmCmd,
-		"flush": filesFlushCmd,
-		"chcid": filesChcidCmd,
+		"read":    filesReadCmd,
+		"write":   filesWriteCmd,
+		"mv":      filesMvCmd,
+		"cp":      filesCpCmd,
+		"ls":      filesLsCmd,
+		"mkdir":   filesMkdirCmd,
+		"stat":    filesStatCmd,
+		"rm":      filesRmCmd,
+		"flush":   filesFlushCmd,
+		"chcid":   filesChcidCmd,
+		"restore": filesRestoreCmd,
+		"trash":   filesTrashCmd,
 	},
 }
 
@@ -1005,6 +1009,19 @@ Remove files or directories.
     dog
     fish
     $ btfs files rm -r /bar
+
+'btfs files rm -r /' empties the whole files tree after asking for a
+confirmation, which --yes skips.
+
+When the trash is enabled, removed files are moved to /.trash instead, and
+purged once Trash.TTL (a week by default) elapsed. 'btfs files restore'
+brings them back:
+
+    $ btfs config --json Trash.Enabled true
+    $ btfs files rm /foo
+    $ btfs files restore /foo
+
+--force and --no-trash, and removals in /.trash, are permanent.
 `,
 	},
 
@@ -1014,6 +1031,8 @@ Remove files or directories.
 	Options: []cmds.Option{
 		cmds.BoolOption(recursiveOptionName, "r", "Recursively remove directories."),
 		cmds.BoolOption(forceOptionName, "Forcibly remove target at path; implies -r for directories"),
+		cmds.BoolOption(noTrashOptionName, "Remove permanently, even when the trash is enabled."),
+		cmdenv.OptionYes,
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		nd, err := cmdenv.GetNode(env)
@@ -1026,8 +1045,17 @@ Remove files or directories.
 			return err
 		}
 
+		bin, err := rmTrash(req, nd)
+		if err != nil {
+			return err
+		}
+
 		if path == "/" {
-			return fmt.Errorf("cannot delete root")
+			dashr, _ := req.Options[recursiveOptionName].(bool)
+			if !dashr {
+				return fmt.Errorf("cannot delete root, use -r to remove everything in it")
+			}
+			return removeRootEntries(req, nd.FilesRoot, bin)
 		}
 
 		// 'rm a/b/c/' will fail unless we trim the slash at the end
@@ -1076,6 +1104,11 @@ Remove files or directories.
 			}
 		}
 
+		if bin != nil && !trash.InTrash(path) {
+			_, err := bin.Move(req.Context, path, time.Now())
+			return err
+		}
+
 		err = pdir.Unlink(name)
 		if err != nil {
 			return err
@@ -1085,6 +1118,53 @@ Remove files or directories.
 	},
 }
 
+// removeRootEntries empties the files root once the user confirmed it,
+// moving the entries to bin unless it is nil.
+func removeRootEntries(req *cmds.Request, root *mfs.Root, bin *trash.Trash) error {
+	if _, ok := req.Options[cmdenv.YesOptionName].(bool); !ok {
+		return fmt.Errorf("cannot delete root without --%s", cmdenv.YesOptionName)
+	}
+	dir := root.GetDirectory()
+	names, err := dir.ListNames(req.Context)
+	if err != nil {
+		return err
+	}
+	if bin != nil {
+		// the trash is emptied with 'btfs files trash empty'
+		kept := names[:0]
+		for _, name := range names {
+			if !trash.InTrash("/" + name) {
+				kept = append(kept, name)
+			}
+		}
+		names = kept
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	affected := make([]string, len(names))
+	for i, name := range names {
+		affected[i] = "/" + name
+	}
+	err = cmdenv.RequireConfirmation(req,
+		fmt.Sprintf("This removes all %d entries of the files root:", len(names)), affected)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if bin != nil {
+			if _, err := bin.Move(req.Context, "/"+name, time.Now()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := dir.Unlink(name); err != nil {
+			return err
+		}
+	}
+	return dir.Flush()
+}
+
 func getPrefixNew(req *cmds.Request) (cid.Builder, error) {
 	cidVer, cidVerSet := req.Options[filesCidVersionOptionName].(int)
 	hashFunStr, hashFunSet := req.Options[filesHashOptionName].(string)
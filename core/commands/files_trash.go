@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	gopath "path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/trash"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	noTrashOptionName   = "no-trash"
+	restoreToOptionName = "to"
+)
+
+// TrashOutput lists the entries of the trash, latest removed first.
+type TrashOutput struct {
+	Entries []*TrashEntry
+}
+
+// TrashEntry is a removed entry and when it is purged.
+type TrashEntry struct {
+	trash.Entry
+	Expires time.Time
+}
+
+// rmTrash returns the trash 'files rm' moves entries to, nil when they are
+// removed permanently.
+func rmTrash(req *cmds.Request, nd *core.IpfsNode) (*trash.Trash, error) {
+	force, _ := req.Options[forceOptionName].(bool)
+	noTrash, _ := req.Options[noTrashOptionName].(bool)
+	if force || noTrash {
+		return nil, nil
+	}
+	cfg, err := trash.Load(nd.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	return trash.New(nd.FilesRoot, nd.Repo.Datastore(), nd.Identity.Pretty()), nil
+}
+
+// trashNamespace matches the entries of the trash removed from the
+// namespace of the application or account running req.
+func trashNamespace(req *cmds.Request) (string, func(*trash.Entry) bool) {
+	ns := cmdenv.Namespace(req)
+	if ns == "" {
+		return "", func(*trash.Entry) bool { return true }
+	}
+	return ns, func(e *trash.Entry) bool {
+		return strings.HasPrefix(e.Path, ns+"/")
+	}
+}
+
+var filesRestoreCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Restore files from the trash.",
+		ShortDescription: `
+Moves files removed with 'btfs files rm' back from the trash, see 'btfs
+files trash'. A file is given by its trash ID, or by its original path to
+restore the latest removed from there:
+
+    $ btfs files rm -r /photos
+    $ btfs files restore /photos
+
+The file is restored to its original path, or to --to, which must not
+exist.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("path-or-id", true, true, "Original path or trash ID of the file to restore."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(restoreToOptionName, "Path to restore the file to."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		to, _ := req.Options[restoreToOptionName].(string)
+		if to != "" && len(req.Arguments) > 1 {
+			return fmt.Errorf("cannot restore several files to --%s", restoreToOptionName)
+		}
+		ns, match := trashNamespace(req)
+		if to != "" {
+			if to, err = checkPath(to); err != nil {
+				return err
+			}
+			if ns != "" {
+				to = gopath.Join(ns, to)
+			}
+		}
+
+		bin := trash.New(nd.FilesRoot, nd.Repo.Datastore(), nd.Identity.Pretty())
+		for _, arg := range req.Arguments {
+			e, err := bin.Find(arg)
+			if err == nil && !match(e) {
+				err = trash.ErrNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %s", arg, err)
+			}
+			if err := bin.Restore(req.Context, e, to); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var filesTrashCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the files in the trash.",
+		ShortDescription: `
+Lists the files removed with 'btfs files rm' while the trash is enabled,
+latest first. They are kept in /.trash until they expire, after Trash.TTL
+(a week by default):
+
+    $ btfs config --json Trash.Enabled true
+    $ btfs config Trash.TTL 72h
+
+'btfs files restore' brings them back, 'btfs files trash empty' purges
+them now.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"empty": filesTrashEmptyCmd,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := trash.Load(nd.Repo)
+		if err != nil {
+			return err
+		}
+		ttl, _ := cfg.Retention()
+		_, match := trashNamespace(req)
+		entries, err := trash.New(nd.FilesRoot, nd.Repo.Datastore(), nd.Identity.Pretty()).List()
+		if err != nil {
+			return err
+		}
+		out := &TrashOutput{}
+		for _, e := range entries {
+			if match(e) {
+				out.Entries = append(out.Entries, &TrashEntry{Entry: *e, Expires: e.Removed.Add(ttl)})
+			}
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: TrashOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *TrashOutput) error {
+			tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tREMOVED\tEXPIRES\tPATH")
+			for _, e := range out.Entries {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.ID, e.Removed.Format(time.RFC3339),
+					e.Expires.Format(time.RFC3339), e.Path)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var filesTrashEmptyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Purge the files in the trash.",
+		ShortDescription: `
+Removes permanently the files in the trash, after asking for a confirmation
+which --yes skips.
+`,
+	},
+	Options: []cmds.Option{
+		cmdenv.OptionYes,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		_, match := trashNamespace(req)
+		bin := trash.New(nd.FilesRoot, nd.Repo.Datastore(), nd.Identity.Pretty())
+		entries, err := bin.List()
+		if err != nil {
+			return err
+		}
+		var affected []string
+		for _, e := range entries {
+			if match(e) {
+				affected = append(affected, e.Path)
+			}
+		}
+		if len(affected) == 0 {
+			return nil
+		}
+		err = cmdenv.RequireConfirmation(req,
+			fmt.Sprintf("This permanently removes the %d files of the trash:", len(affected)), affected)
+		if err != nil {
+			return err
+		}
+		_, err = bin.Purge(req.Context, match)
+		return err
+	},
+}
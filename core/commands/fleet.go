@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	version "github.com/TRON-US/go-btfs"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/fleet"
+	"github.com/TRON-US/go-btfs/core/remotes"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	fleetPeersOptionName          = "peers"
+	fleetStrategyOptionName       = "strategy"
+	fleetVersionOptionName        = "version"
+	fleetMaxFailuresOptionName    = "max-failures"
+	fleetDrainTimeoutOptionName   = "drain-timeout"
+	fleetRestartTimeoutOptionName = "restart-timeout"
+)
+
+var FleetCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage a fleet of nodes from the CLI.",
+		ShortDescription: `
+A fleet is the nodes an operator runs, each reached over its API as a remote
+of the CLI, see 'btfs remote'.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"upgrade": fleetUpgradeCmd,
+	},
+}
+
+var fleetUpgradeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Upgrade a fleet of nodes in waves.",
+		ShortDescription: `
+Upgrades the nodes listed in the --peers file to the version of this CLI, or
+--version, a wave of nodes at a time:
+
+  > btfs fleet upgrade --peers hosts.txt --strategy 10%
+
+The file lists a node per line: the name of a remote added with 'btfs remote
+add', or the API address of the node followed by its token if any. Lines
+starting with # are comments. --strategy is the size of a wave, a share of
+the nodes such as 10% or a number of nodes.
+
+Each node of a wave is drained: it refuses new shards while the shards it
+is downloading complete, for at most --drain-timeout. It is then updated
+from its release channel with 'btfs update now', which restarts it, and
+must come back at the version within --restart-timeout, with no critical
+finding of 'btfs doctor'. A node failing before its restart accepts new
+shards again. Nodes already at the version are skipped.
+
+Once more than --max-failures nodes failed, the upgrade stops after the
+wave, leaving the nodes of the next waves as they were.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(fleetPeersOptionName, "File listing the nodes, one per line."),
+		cmds.StringOption(fleetStrategyOptionName, "Size of a wave, a share of the nodes or a number of nodes.").WithDefault("10%"),
+		cmds.StringOption(fleetVersionOptionName, "Version to upgrade to. Default: the version of this CLI."),
+		cmds.IntOption(fleetMaxFailuresOptionName, "Nodes allowed to fail before the upgrade stops.").WithDefault(0),
+		cmds.StringOption(fleetDrainTimeoutOptionName, "Wait for a node to complete its pending shards.").WithDefault(fleet.DefaultDrainTimeout.String()),
+		cmds.StringOption(fleetRestartTimeoutOptionName, "Wait for a node to come back at the version.").WithDefault(fleet.DefaultTimeout.String()),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		path, _ := req.Options[fleetPeersOptionName].(string)
+		if path == "" {
+			return errors.New("the file listing the nodes is required, use --peers")
+		}
+		strategy, err := fleet.ParseStrategy(req.Options[fleetStrategyOptionName].(string))
+		if err != nil {
+			return err
+		}
+		o := fleet.Options{
+			Version:     version.CurrentVersionNumber,
+			Strategy:    strategy,
+			MaxFailures: req.Options[fleetMaxFailuresOptionName].(int),
+		}
+		if v, ok := req.Options[fleetVersionOptionName].(string); ok && v != "" {
+			o.Version = v
+		}
+		if o.MaxFailures < 0 {
+			return fmt.Errorf("invalid %s: %d", fleetMaxFailuresOptionName, o.MaxFailures)
+		}
+		if o.DrainTimeout, err = time.ParseDuration(req.Options[fleetDrainTimeoutOptionName].(string)); err != nil {
+			return fmt.Errorf("invalid %s: %v", fleetDrainTimeoutOptionName, err)
+		}
+		if o.Timeout, err = time.ParseDuration(req.Options[fleetRestartTimeoutOptionName].(string)); err != nil {
+			return fmt.Errorf("invalid %s: %v", fleetRestartTimeoutOptionName, err)
+		}
+
+		root, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		ps, err := fleet.ParsePeers(f, remotes.Open(root))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", path, err)
+		}
+		nodes := make([]fleet.Node, 0, len(ps))
+		for _, p := range ps {
+			n, err := fleet.NewNode(p)
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, n)
+		}
+		return fleet.Upgrade(req.Context, nodes, o, func(r *fleet.Result) error {
+			return res.Emit(r)
+		})
+	},
+	Type: fleet.Result{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, r *fleet.Result) error {
+			switch r.Status {
+			case fleet.StatusSkipped:
+				_, err := fmt.Fprintf(w, "wave %d: %s skipped, at %s\n", r.Wave, r.Node, r.From)
+				return err
+			case fleet.StatusFailed:
+				_, err := fmt.Fprintf(w, "wave %d: %s failed: %s\n", r.Wave, r.Node, r.Error)
+				return err
+			}
+			_, err := fmt.Fprintf(w, "wave %d: %s upgraded from %s to %s in %s\n", r.Wave, r.Node, r.From, r.To,
+				r.Elapsed.Round(time.Second))
+			return err
+		}),
+	},
+}
@@ -21,7 +21,6 @@ import (
 	"github.com/TRON-US/interface-go-btfs-core/path"
 	"github.com/ipfs/go-cid"
 	"github.com/whyrusleeping/tar-utils"
-	"gopkg.in/cheggaaa/pb.v1"
 )
 
 var ErrInvalidCompressionLevel = errors.New("compression level must be between 1 and 9")
@@ -71,6 +70,7 @@ If '--meta' or '-m' is enabled, this option is ignored.
 		cmds.StringOption(privateKeyName, "pk", "The private key to decrypt file."),
 		cmds.StringOption(repairShardsName, "rs", "Repair the list of shards. Multihashes separated by ','."),
 		cmds.BoolOption(quietOptionName, "q", "Quiet mode: perform get operation without writing to anywhere. Same as using -o /dev/null."),
+		cmds.BoolOption(cmdenv.ProgressOptionName, "Show download progress. Defaults to true when stderr is a terminal."),
 	},
 	PreRun: func(req *cmds.Request, env cmds.Environment) error {
 		_, err := getCompressOptions(req)
@@ -112,9 +112,21 @@ If '--meta' or '-m' is enabled, this option is ignored.
 			options.Unixfs.Repairs(repairs),
 		}
 
-		file, err := api.Unixfs().Get(req.Context, p, opts...)
-		if err != nil {
-			return err
+		var file files.Node
+		if decrypt && !meta {
+			f, err := openDekFile(req.Context, env, api, p)
+			if err != nil {
+				return err
+			}
+			if f != nil {
+				file = f
+			}
+		}
+		if file == nil {
+			file, err = api.Unixfs().Get(req.Context, p, opts...)
+			if err != nil {
+				return err
+			}
 		}
 
 		quiet, _ := req.Options[quietOptionName].(bool)
@@ -170,6 +182,7 @@ If '--meta' or '-m' is enabled, this option is ignored.
 				Archive:     archive,
 				Compression: cmplvl,
 				Size:        int64(res.Length()),
+				Progress:    cmdenv.ProgressEnabled(req),
 			}
 
 			return gw.Write(outReader, outPath)
@@ -177,39 +190,14 @@ If '--meta' or '-m' is enabled, this option is ignored.
 	},
 }
 
-type clearlineReader struct {
-	io.Reader
-	out io.Writer
-}
-
-func (r *clearlineReader) Read(p []byte) (n int, err error) {
-	n, err = r.Reader.Read(p)
-	if err == io.EOF {
-		// callback
-		fmt.Fprintf(r.out, "\033[2K\r") // clear progress bar line on EOF
-	}
-	return
-}
-
-func progressBarForReader(out io.Writer, r io.Reader, l int64) (*pb.ProgressBar, io.Reader) {
+func progressBarForReader(out io.Writer, r io.Reader, l int64) (*cmdenv.ProgressBar, io.Reader) {
 	bar := makeProgressBar(out, l)
-	barR := bar.NewProxyReader(r)
-	return bar, &clearlineReader{barR, out}
+	return bar, bar.NewProxyReader(r)
 }
 
-func makeProgressBar(out io.Writer, l int64) *pb.ProgressBar {
-	// setup bar reader
-	// TODO: get total length of files
-	bar := pb.New64(l).SetUnits(pb.U_BYTES)
-	bar.Output = out
-
-	// the progress bar lib doesn't give us a way to get the width of the output,
-	// so as a hack we just use a callback to measure the output, then get rid of it
-	bar.Callback = func(line string) {
-		terminalWidth := len(line)
-		bar.Callback = nil
-		log.Infof("terminal width: %v\n", terminalWidth)
-	}
+func makeProgressBar(out io.Writer, l int64) *cmdenv.ProgressBar {
+	bar := cmdenv.NewProgressBar(out)
+	bar.SetTotal(l)
 	return bar
 }
 
@@ -230,6 +218,7 @@ type getWriter struct {
 	Archive     bool
 	Compression int
 	Size        int64
+	Progress    bool
 }
 
 func (gw *getWriter) Write(r io.Reader, fpath string) error {
@@ -262,22 +251,27 @@ func (gw *getWriter) writeArchive(r io.Reader, fpath string) error {
 	defer file.Close()
 
 	fmt.Fprintf(gw.Out, "Saving archive to %s\n", fpath)
-	bar, barR := progressBarForReader(gw.Err, r, gw.Size)
-	bar.Start()
-	defer bar.Finish()
+	if gw.Progress {
+		bar, barR := progressBarForReader(gw.Err, r, gw.Size)
+		defer bar.Finish()
+		r = barR
+	}
 
-	_, err = io.Copy(file, barR)
+	_, err = io.Copy(file, r)
 	return err
 }
 
 func (gw *getWriter) writeExtracted(r io.Reader, fpath string) error {
 	fmt.Fprintf(gw.Out, "Saving file(s) to %s\n", fpath)
-	bar := makeProgressBar(gw.Err, gw.Size)
-	bar.Start()
-	defer bar.Finish()
-	defer bar.Set64(gw.Size)
-
-	extractor := &tar.Extractor{Path: fpath, Progress: bar.Add64}
+	extractor := &tar.Extractor{Path: fpath}
+	if gw.Progress {
+		bar := makeProgressBar(gw.Err, gw.Size)
+		defer bar.Finish()
+		extractor.Progress = func(n int64) int64 {
+			bar.Add(n)
+			return bar.Bytes()
+		}
+	}
 	return extractor.Extract(r)
 }
 
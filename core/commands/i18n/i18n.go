@@ -0,0 +1,146 @@
+// Package i18n translates the help text of the btfs commands.
+//
+// Translations are catalogs of help texts keyed by command path, registered
+// per locale. Anything a catalog does not translate keeps its English text,
+// so catalogs can cover the most used commands first.
+package i18n
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// Text is the translation of the help text of one command. Empty fields
+// are not translated.
+type Text struct {
+	Tagline          string
+	ShortDescription string
+	LongDescription  string
+	Subcommands      string
+}
+
+// Catalog maps command paths, joined with spaces ("" is the root command),
+// to their translation.
+type Catalog map[string]Text
+
+var (
+	catalogsLk sync.Mutex
+	catalogs   = map[string]Catalog{}
+)
+
+// Register adds the translations of catalog to locale, e.g. "zh-CN".
+func Register(locale string, catalog Catalog) {
+	catalogsLk.Lock()
+	defer catalogsLk.Unlock()
+	locale = Normalize(locale)
+	c, ok := catalogs[locale]
+	if !ok {
+		c = Catalog{}
+		catalogs[locale] = c
+	}
+	for path, t := range catalog {
+		c[path] = t
+	}
+}
+
+// Locales returns the locales with translations, sorted.
+func Locales() []string {
+	catalogsLk.Lock()
+	defer catalogsLk.Unlock()
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Normalize turns a POSIX locale name such as "zh_CN.UTF-8" into its
+// language tag, "zh-CN". The "C" and "POSIX" locales normalize to "".
+func Normalize(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.Replace(strings.TrimSpace(locale), "_", "-", -1)
+	if locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	parts := strings.SplitN(locale, "-", 2)
+	parts[0] = strings.ToLower(parts[0])
+	if len(parts) == 2 {
+		parts[1] = strings.ToUpper(parts[1])
+	}
+	return strings.Join(parts, "-")
+}
+
+// FromEnv returns the locale of the environment, following the usual
+// LC_ALL, LC_MESSAGES, LANG precedence.
+func FromEnv() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// lookup returns the catalog of locale, falling back to a catalog of the
+// same language, e.g. "zh-CN" for "zh".
+func lookup(locale string) Catalog {
+	locale = Normalize(locale)
+	if locale == "" {
+		return nil
+	}
+	catalogsLk.Lock()
+	defer catalogsLk.Unlock()
+	if c, ok := catalogs[locale]; ok {
+		return c
+	}
+	lang := strings.SplitN(locale, "-", 2)[0]
+	if c, ok := catalogs[lang]; ok {
+		return c
+	}
+	var fallback string
+	for l := range catalogs {
+		if strings.HasPrefix(l, lang+"-") && (fallback == "" || l < fallback) {
+			fallback = l
+		}
+	}
+	return catalogs[fallback]
+}
+
+// Apply translates the help text of root and its subcommands to locale.
+// It reports whether a translation was found for the locale.
+func Apply(root *cmds.Command, locale string) bool {
+	catalog := lookup(locale)
+	if catalog == nil {
+		return false
+	}
+	apply(root, nil, catalog)
+	return true
+}
+
+func apply(cmd *cmds.Command, path []string, catalog Catalog) {
+	if t, ok := catalog[strings.Join(path, " ")]; ok {
+		h := &cmd.Helptext
+		if t.Tagline != "" {
+			h.Tagline = t.Tagline
+		}
+		if t.ShortDescription != "" {
+			h.ShortDescription = t.ShortDescription
+		}
+		if t.LongDescription != "" {
+			h.LongDescription = t.LongDescription
+		}
+		if t.Subcommands != "" {
+			h.Subcommands = t.Subcommands
+		}
+	}
+	for name, sub := range cmd.Subcommands {
+		apply(sub, append(path[:len(path):len(path)], name), catalog)
+	}
+}
@@ -0,0 +1,93 @@
+package i18n
+
+import (
+	"testing"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"zh_CN.UTF-8":   "zh-CN",
+		"zh_cn":         "zh-CN",
+		"ZH-CN":         "zh-CN",
+		"de_DE@euro":    "de-DE",
+		"en":            "en",
+		"C":             "",
+		"POSIX":         "",
+		"C.UTF-8":       "",
+		"":              "",
+		" fr_FR.UTF-8 ": "fr-FR",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func testRoot() *cmds.Command {
+	return &cmds.Command{
+		Helptext: cmds.HelpText{Tagline: "root", Subcommands: "SUBCOMMANDS"},
+		Subcommands: map[string]*cmds.Command{
+			"add": {Helptext: cmds.HelpText{Tagline: "add", ShortDescription: "adds"}},
+			"pin": {
+				Helptext: cmds.HelpText{Tagline: "pin"},
+				Subcommands: map[string]*cmds.Command{
+					"ls": {Helptext: cmds.HelpText{Tagline: "pin ls"}},
+				},
+			},
+		},
+	}
+}
+
+func TestApply(t *testing.T) {
+	Register("xx-YY", Catalog{
+		"":       {Subcommands: "SOUS-COMMANDES"},
+		"add":    {Tagline: "ajouter"},
+		"pin ls": {Tagline: "lister"},
+	})
+
+	root := testRoot()
+	if !Apply(root, "xx_YY.UTF-8") {
+		t.Fatal("no translation applied")
+	}
+	if root.Helptext.Tagline != "root" || root.Helptext.Subcommands != "SOUS-COMMANDES" {
+		t.Errorf("root help text is %+v", root.Helptext)
+	}
+	add := root.Subcommands["add"].Helptext
+	if add.Tagline != "ajouter" || add.ShortDescription != "adds" {
+		t.Errorf("add help text is %+v", add)
+	}
+	if got := root.Subcommands["pin"].Helptext.Tagline; got != "pin" {
+		t.Errorf("pin tagline is %q", got)
+	}
+	if got := root.Subcommands["pin"].Subcommands["ls"].Helptext.Tagline; got != "lister" {
+		t.Errorf("pin ls tagline is %q", got)
+	}
+
+	// The language alone falls back to a catalog of that language.
+	root = testRoot()
+	if !Apply(root, "xx") || root.Subcommands["add"].Helptext.Tagline != "ajouter" {
+		t.Error("no fallback to the xx-YY catalog")
+	}
+
+	root = testRoot()
+	for _, locale := range []string{"", "C", "yy_XX.UTF-8"} {
+		if Apply(root, locale) {
+			t.Errorf("translation applied for %q", locale)
+		}
+	}
+	if root.Subcommands["add"].Helptext.Tagline != "add" {
+		t.Error("help text changed without translation")
+	}
+}
+
+func TestZhCN(t *testing.T) {
+	root := testRoot()
+	if !Apply(root, "zh_CN.UTF-8") {
+		t.Fatal("zh-CN is not registered")
+	}
+	if root.Helptext.Tagline == "root" || root.Subcommands["add"].Helptext.Tagline == "add" {
+		t.Error("zh-CN did not translate the help text")
+	}
+}
@@ -0,0 +1,387 @@
+package i18n
+
+func init() {
+	Register("zh-CN", zhCN)
+}
+
+// zhCN is the Simplified Chinese help text.
+var zhCN = Catalog{
+	"": {
+		Tagline: "全球点对点 merkle-dag 文件系统。",
+		Subcommands: `
+基本命令
+  init          初始化 btfs 本地配置
+  add <path>    添加文件到 BTFS
+  cat <ref>     显示 BTFS 对象数据
+  get <ref>     下载 BTFS 对象
+  ls <ref>      列出对象的链接
+  refs <ref>    列出对象链接的哈希
+
+BTFS 命令
+  storage       管理客户端和主机的存储功能
+  publish-site  部署静态网站
+
+数据结构命令
+  block         操作数据存储中的原始块
+  object        操作原始 dag 节点
+  files         像 unix 文件系统一样操作对象
+  dag           操作 IPLD 文档（实验性）
+  metadata      操作 BTFS 文件的元数据
+
+高级命令
+  daemon        启动长期运行的守护进程
+  mount         挂载只读的 BTFS 挂载点
+  resolve       解析任意类型的名称
+  name          发布和解析 BTNS 名称
+  key           创建和列出 BTNS 名称密钥对
+  keys          管理数据加密密钥
+  dns           解析 DNS 链接
+  pin           将对象固定到本地存储
+  repo          管理 BTFS 仓库
+  stats         各类运行统计
+  p2p           Libp2p 流挂载
+  filestore     管理 filestore（实验性）
+  top           显示节点的实时监控面板
+  users         管理共享节点的账户
+  apps          管理应用的命名空间
+  alerts        通过邮件、Telegram 或 Slack 发送节点的告警
+  paywall       对网关提供的内容收取 BTT
+  events        实时打印守护进程的事件
+
+网络命令
+  id            显示 BTFS 节点信息
+  bootstrap     添加或删除引导节点
+  swarm         管理与 p2p 网络的连接
+  dht           在 DHT 中查询值或节点
+  ping          测量连接的延迟
+  diag          打印诊断信息
+  doctor        诊断常见的配置错误
+  power-mode    减少节点的后台活动
+
+工具命令
+  config        管理配置
+  version       显示 btfs 版本信息
+  commands      列出所有可用命令
+  cid           转换并查看 CID 的属性
+  log           管理和显示运行中守护进程的日志
+  alias         管理命令别名
+  update        管理自动更新渠道并回滚更新
+  remote        管理 CLI 运行命令的远程守护进程
+  fleet         分批升级节点集群
+
+使用 'btfs <command> --help' 了解每个命令的详细信息。
+
+btfs 使用本地文件系统中的仓库，默认位于 ~/.btfs。要更改仓库位置，
+请设置 $BTFS_PATH 环境变量：
+
+  export BTFS_PATH=/path/to/btfsrepo
+
+退出状态
+
+命令行程序以下列值之一退出：
+
+0     执行成功。
+1     执行失败。
+2     认证失败。
+3     未找到。
+4     余额不足。
+5     网络错误。
+6     冲突，例如仓库被其他进程锁定。
+7     超时。
+
+超时
+
+--timeout=<duration>（例如 30s、5m）限制命令的运行时间。调用远程服务的
+命令默认会超时：wallet 为 2m，tron 和 guard 为 1m，storage 为 5m，
+除非命令自身另有设置。
+`,
+	},
+
+	"add":                           {Tagline: "添加文件或目录到 btfs。"},
+	"alias":                         {Tagline: "管理命令别名。"},
+	"alias add":                     {Tagline: "添加或替换命令别名。"},
+	"alias list":                    {Tagline: "列出命令别名。"},
+	"alias rm":                      {Tagline: "删除命令别名。"},
+	"apps":                          {Tagline: "管理共享节点文件 API 的应用。"},
+	"apps add":                      {Tagline: "注册应用并打印其 API 令牌。"},
+	"apps list":                     {Tagline: "列出应用。"},
+	"apps rm":                       {Tagline: "注销应用。"},
+	"apps set":                      {Tagline: "修改应用的配额。"},
+	"apps snapshot":                 {Tagline: "为应用的命名空间创建快照并回滚。"},
+	"apps snapshot create":          {Tagline: "为应用的命名空间创建快照。"},
+	"apps snapshot ls":              {Tagline: "列出应用命名空间的快照。"},
+	"apps snapshot rm":              {Tagline: "删除应用命名空间的快照。"},
+	"apps snapshot rollback":        {Tagline: "将应用的命名空间回滚到快照。"},
+	"apps token":                    {Tagline: "替换应用的 API 令牌。"},
+	"bitswap":                       {Tagline: "与 bitswap 代理交互。"},
+	"block":                         {Tagline: "操作原始 BTFS 块。"},
+	"block get":                     {Tagline: "获取原始 BTFS 块。"},
+	"block put":                     {Tagline: "将输入存储为 BTFS 块。"},
+	"block rm":                      {Tagline: "删除 BTFS 块。"},
+	"block stat":                    {Tagline: "打印原始 BTFS 块的信息。"},
+	"bootstrap":                     {Tagline: "显示或编辑引导节点列表。"},
+	"cat":                           {Tagline: "显示 BTFS 对象数据。"},
+	"cid":                           {Tagline: "转换并查看 CID 的属性。"},
+	"commands":                      {Tagline: "列出所有可用命令。"},
+	"completion":                    {Tagline: "生成 shell 补全脚本。"},
+	"config":                        {Tagline: "获取和设置 btfs 配置值。"},
+	"config edit":                   {Tagline: "在 $EDITOR 中打开配置文件进行编辑。"},
+	"config profile":                {Tagline: "将配置方案应用到配置。"},
+	"config replace":                {Tagline: "用 <file> 替换配置。"},
+	"config show":                   {Tagline: "输出配置文件内容。"},
+	"daemon":                        {Tagline: "运行联网的 BTFS 节点。"},
+	"dag":                           {Tagline: "操作 ipld dag 对象。"},
+	"dht":                           {Tagline: "直接通过 DHT 发出命令。"},
+	"dht priority":                  {Tagline: "管理在重新提供队列之前提供的 CID。"},
+	"dht priority ls":               {Tagline: "列出优先 CID。"},
+	"dht priority add":              {Tagline: "添加优先 CID，或设置其提供方式。"},
+	"dht priority rm":               {Tagline: "移除优先 CID。"},
+	"diag":                          {Tagline: "生成诊断报告。"},
+	"diag audit":                    {Tagline: "显示在守护进程上执行过的命令。"},
+	"diag clock":                    {Tagline: "对照 NTP 服务器检查系统时钟。"},
+	"diag latency":                  {Tagline: "显示到引导节点和 hub 的延迟。"},
+	"diag speedtest":                {Tagline: "测试节点到 BTFS 网络的连通性。"},
+	"dns":                           {Tagline: "解析 DNS 链接。"},
+	"events":                        {Tagline: "实时打印守护进程的事件。"},
+	"doctor":                        {Tagline: "诊断常见的节点配置错误。"},
+	"alerts":                        {Tagline: "通过邮件、Telegram 或 Slack 发送节点的告警。"},
+	"alerts ls":                     {Tagline: "列出告警渠道。"},
+	"alerts test":                   {Tagline: "发送测试告警。"},
+	"file":                          {Tagline: "操作表示 Unix 文件系统的 BTFS 对象。"},
+	"files":                         {Tagline: "操作 unixfs 文件。"},
+	"files cp":                      {Tagline: "将 BTFS 文件和目录复制到 MFS（或在 MFS 内复制）。"},
+	"files ls":                      {Tagline: "列出本地可变命名空间中的目录。"},
+	"files mkdir":                   {Tagline: "创建目录。"},
+	"files mv":                      {Tagline: "移动文件。"},
+	"files read":                    {Tagline: "读取 MFS 中的文件。"},
+	"files rm":                      {Tagline: "删除文件。"},
+	"files restore":                 {Tagline: "从回收站恢复文件。"},
+	"files trash":                   {Tagline: "列出回收站中的文件。"},
+	"files trash empty":             {Tagline: "清空回收站中的文件。"},
+	"files stat":                    {Tagline: "显示文件状态。"},
+	"files write":                   {Tagline: "写入可变文件。"},
+	"filestore":                     {Tagline: "操作 filestore 对象。"},
+	"get":                           {Tagline: "下载 BTFS 对象。"},
+	"guard":                         {Tagline: "从 BTFS 客户端与 guard 服务交互。"},
+	"id":                            {Tagline: "显示 btfs 节点 id 信息。"},
+	"init":                          {Tagline: "初始化 btfs 配置文件。"},
+	"key":                           {Tagline: "创建和列出 BTNS 名称密钥对"},
+	"keys":                          {Tagline: "管理客户端加密文件的数据加密密钥。"},
+	"keys dek":                      {Tagline: "列出、轮换和共享数据加密密钥。"},
+	"keys dek grant":                {Tagline: "通过网关授予对使用数据加密密钥加密的文件的访问权限。"},
+	"keys dek import":               {Tagline: "导入其他节点共享的数据加密密钥。"},
+	"keys dek list":                 {Tagline: "列出数据加密密钥。"},
+	"keys dek rotate":               {Tagline: "轮换数据加密密钥。"},
+	"keys dek share":                {Tagline: "与其他节点共享数据加密密钥。"},
+	"key gen":                       {Tagline: "创建新的密钥对"},
+	"key list":                      {Tagline: "列出所有本地密钥对"},
+	"key rename":                    {Tagline: "重命名密钥对"},
+	"key rm":                        {Tagline: "删除密钥对"},
+	"log":                           {Tagline: "操作守护进程的日志输出。"},
+	"log append":                    {Tagline: "向日志追加条目。"},
+	"log create":                    {Tagline: "创建签名的仅追加日志。"},
+	"log follow":                    {Tagline: "在条目追加时打印日志的条目。"},
+	"log level":                     {Tagline: "更改日志级别。"},
+	"log ls":                        {Tagline: "列出日志子系统。"},
+	"log tail":                      {Tagline: "读取事件日志。"},
+	"ls":                            {Tagline: "列出 Unix 文件系统对象的目录内容。"},
+	"metadata":                      {Tagline: "操作 BTFS 文件的元数据。"},
+	"mount":                         {Tagline: "将 BTFS 挂载到文件系统（只读）。"},
+	"name":                          {Tagline: "发布和解析 BTNS 名称。"},
+	"name publish":                  {Tagline: "发布 BTNS 名称。"},
+	"name resolve":                  {Tagline: "解析 BTNS 名称。"},
+	"object":                        {Tagline: "操作 BTFS 对象。"},
+	"openapi":                       {Tagline: "输出存储和钱包 HTTP API 的 OpenAPI 规范。"},
+	"p2p":                           {Tagline: "Libp2p 流挂载。"},
+	"paywall":                       {Tagline: "对网关提供的内容收取 BTT。"},
+	"paywall ls":                    {Tagline: "列出网关的收费路径。"},
+	"paywall pay":                   {Tagline: "为网关 URL 上的内容付费。"},
+	"pin":                           {Tagline: "将对象固定到本地存储（或取消固定）。"},
+	"pin add":                       {Tagline: "将对象固定到本地存储。"},
+	"pin ls":                        {Tagline: "列出固定到本地存储的对象。"},
+	"pin rm":                        {Tagline: "从本地存储删除固定的对象。"},
+	"pin update":                    {Tagline: "更新递归固定"},
+	"pin verify":                    {Tagline: "验证递归固定是否完整。"},
+	"ping":                          {Tagline: "向 BTFS 主机发送回显请求包。"},
+	"power-mode":                    {Tagline: "显示或设置节点的电源模式。"},
+	"publish-site":                  {Tagline: "部署静态网站。"},
+	"pubsub":                        {Tagline: "btfs 上的实验性发布订阅系统。"},
+	"refs":                          {Tagline: "列出对象的链接（引用）。"},
+	"remote":                        {Tagline: "管理 CLI 运行命令的远程守护进程。"},
+	"remote add":                    {Tagline: "添加远程守护进程。"},
+	"remote ls":                     {Tagline: "列出远程守护进程。"},
+	"remote rm":                     {Tagline: "删除远程守护进程。"},
+	"fleet":                         {Tagline: "从 CLI 管理节点集群。"},
+	"fleet upgrade":                 {Tagline: "分批升级节点集群。"},
+	"repo":                          {Tagline: "管理 BTFS 仓库。"},
+	"repo bench":                    {Tagline: "测试数据存储设置的写入吞吐量。"},
+	"repo cache":                    {Tagline: "管理快速存储上的块缓存。"},
+	"repo cache stat":               {Tagline: "显示块缓存的大小和命中率。"},
+	"repo cache pin":                {Tagline: "将 DAG 的块固定在块缓存中。"},
+	"repo cache unpin":              {Tagline: "允许从块缓存中淘汰 DAG 的块。"},
+	"repo cache ls":                 {Tagline: "列出固定在块缓存中的 DAG。"},
+	"repo encrypt":                  {Tagline: "静态加密仓库中的块和密钥。"},
+	"repo encrypt status":           {Tagline: "显示仓库的加密进度。"},
+	"repo gc":                       {Tagline: "对仓库执行垃圾回收。"},
+	"repo maintenance":              {Tagline: "压缩数据存储并校验仓库中的块。"},
+	"repo maintenance run":          {Tagline: "立即执行仓库维护。"},
+	"repo maintenance schedule":     {Tagline: "显示或设置每日维护时间窗口。"},
+	"repo import-ipfs":              {Tagline: "导入 go-ipfs 仓库中的块、固定、文件和密钥。"},
+	"repo stat":                     {Tagline: "获取当前仓库的统计信息。"},
+	"repo verify":                   {Tagline: "验证仓库中的所有块都未损坏。"},
+	"repo version":                  {Tagline: "显示仓库版本。"},
+	"resolve":                       {Tagline: "将名称的值解析为 BTFS 路径。"},
+	"restart":                       {Tagline: "重启守护进程。"},
+	"rm":                            {Tagline: "从本地 btfs 节点删除文件或目录。"},
+	"shutdown":                      {Tagline: "关闭 btfs 守护进程"},
+	"stats":                         {Tagline: "查询 BTFS 统计信息。"},
+	"stats bw":                      {Tagline: "打印 btfs 带宽信息。"},
+	"stats history":                 {Tagline: "打印节点统计信息的历史记录。"},
+	"storage":                       {Tagline: "与 BTFS 上的存储服务交互。"},
+	"storage announce":              {Tagline: "更新并公布存储主机信息。"},
+	"storage challenge":             {Tagline: "处理存储挑战的请求和响应。"},
+	"storage challenge cache":       {Tagline: "显示主机挑战缓存的命中率。"},
+	"storage contracts":             {Tagline: "获取节点的存储合约信息。"},
+	"storage contracts archive":     {Tagline: "归档处于终态的存储合约。"},
+	"storage contracts propose":     {Tagline: "提议合约的续约价格（主机）。"},
+	"storage contracts quote":       {Tagline: "报出合约的续约价格（主机）。"},
+	"storage contracts history":     {Tagline: "显示合约的价格协商记录。"},
+	"storage contracts renegotiate": {Tagline: "重新协商合约的续约价格（租用者）。"},
+	"storage capacity":              {Tagline: "验证存储主机公布的可用容量。"},
+	"storage capacity verify":       {Tagline: "让主机证明其可用容量。"},
+	"storage capacity ls":           {Tagline: "列出主机最近的容量证明。"},
+	"storage capacity plot":         {Tagline: "写入待证明容量的绘图文件（主机）。"},
+	"storage capacity status":       {Tagline: "显示绘图进度（主机）。"},
+	"storage capacity prove":        {Tagline: "回应绘图文件的数据块（主机）。"},
+	"storage digest":                {Tagline: "汇总需要关注的租用者合约。"},
+	"storage escrow":                {Tagline: "检查租用者合约在托管服务的付款。"},
+	"storage escrow reconcile":      {Tagline: "核对托管账本与本地合约。"},
+	"storage hosts":                 {Tagline: "查看主机信息。"},
+	"storage hosts score":           {Tagline: "分解主机的评分。"},
+	"storage hosts explain":         {Tagline: "解释为何为上传选择了这些主机。"},
+	"storage hosts availability":    {Tagline: "显示对等节点传播的主机可用性。"},
+	"storage info":                  {Tagline: "显示存储主机信息。"},
+	"storage market":                {Tagline: "浏览并比较存储主机。"},
+	"storage market ls":             {Tagline: "以可比较的表格列出存储主机。"},
+	"storage path":                  {Tagline: "修改 BTFS 客户端的主机存储目录。"},
+	"storage payer":                 {Tagline: "使用另一节点的钱包支付存储合约。"},
+	"storage payer delegate":        {Tagline: "将存储合约的支付委托给本节点（付款方）。"},
+	"storage payer revoke":          {Tagline: "撤销本节点的委托（付款方）。"},
+	"storage payer ls":              {Tagline: "列出本节点的委托（付款方）。"},
+	"storage payer use":             {Tagline: "使用委托支付本节点的存储合约。"},
+	"storage payer clear":           {Tagline: "重新使用本节点自己的钱包支付存储合约。"},
+	"storage payer status":          {Tagline: "显示本节点存储合约的付款方。"},
+	"storage payer sign":            {Tagline: "作为被委托节点的付款方签名消息（付款方）。"},
+	"storage probe":                 {Tagline: "通过合成探测测试已存储文件的检索。"},
+	"storage probe run":             {Tagline: "立即探测已存储文件的主机。"},
+	"storage probe status":          {Tagline: "显示主机的探测结果和有风险的文件。"},
+	"storage probe read":            {Tagline: "为检索探测读取分片的一段（主机）。"},
+	"storage collateral":            {Tagline: "为主机合约锁定抵押金，并查看主机的抵押金。"},
+	"storage collateral ls":         {Tagline: "列出本节点合约的抵押金锁定。"},
+	"storage collateral query":      {Tagline: "获取主机的抵押金条款。"},
+	"storage collateral terms":      {Tagline: "显示本主机每个合约锁定的抵押金。"},
+	"storage collateral post":       {Tagline: "接受主机为合约提交的抵押金。"},
+	"storage bandwidth":             {Tagline: "通过账本通道支付主机的检索带宽。"},
+	"storage bandwidth open":        {Tagline: "向主机开启带宽通道。"},
+	"storage bandwidth close":       {Tagline: "关闭带宽通道。"},
+	"storage bandwidth status":      {Tagline: "显示本节点的带宽通道。"},
+	"storage bandwidth terms":       {Tagline: "显示本主机的带宽价格。"},
+	"storage bandwidth accept":      {Tagline: "接受租用方开启的带宽通道。"},
+	"storage bandwidth pay":         {Tagline: "接收租用方为本主机带宽的付款。"},
+	"storage host":                  {Tagline: "管理本节点的托管。"},
+	"storage host drain":            {Tagline: "停止接受新的分片，例如在升级之前。"},
+	"storage host tenants":          {Tagline: "在本节点上运行多个逻辑主机。"},
+	"storage host tenants ls":       {Tagline: "列出本主机的租户。"},
+	"storage host tenants add":      {Tagline: "添加或更新本主机的租户。"},
+	"storage host tenants rm":       {Tagline: "移除本主机的租户。"},
+	"storage host tenants report":   {Tagline: "报告本主机各租户的收益。"},
+	"storage host tenants payout":   {Tagline: "将租户的收益支付到其钱包。"},
+	"storage settlement":            {Tagline: "批量提取主机合约的付款。"},
+	"storage settlement status":     {Tagline: "显示待结算的付款。"},
+	"storage settlement settle":     {Tagline: "立即结算待处理的付款。"},
+	"storage settlement receipts":   {Tagline: "列出结算收据。"},
+	"storage export":                {Tagline: "将文件导出为可验证的包。"},
+	"storage files":                 {Tagline: "收集已存储文件的保留证据。"},
+	"storage files attest":          {Tagline: "收集文件各主机签名的证明。"},
+	"storage files verify":          {Tagline: "验证证明包。"},
+	"storage files prove":           {Tagline: "证明合约的分片（主机）。"},
+	"storage files stats":           {Tagline: "显示已存储和拥有文件的检索统计。"},
+	"storage files plan-restore":    {Tagline: "规划文件的恢复而不下载。"},
+	"storage sessions":              {Tagline: "检查租用者的上传会话。"},
+	"storage sessions inspect":      {Tagline: "显示上传会话的状态机。"},
+	"storage guard":                 {Tagline: "检查租用者向 guard 的提交。"},
+	"storage guard queue":           {Tagline: "列出等待提交给 guard 的文件存储状态。"},
+	"storage stats":                 {Tagline: "获取节点存储统计。"},
+	"storage stats ingest":          {Tagline: "显示主机的接收队列。"},
+	"storage update":                {Tagline: "存储文件的新版本，只上传改变的分片。"},
+	"storage health":                {Tagline: "检查存储主机分片的磁盘的健康状况。"},
+	"storage import":                {Tagline: "将云存储中的对象导入去中心化存储。"},
+	"storage import status":         {Tagline: "显示导入任务的记录。"},
+	"storage import ls":             {Tagline: "列出导入任务，最新的在前。"},
+	"storage import resume":         {Tagline: "从中断处继续导入任务。"},
+	"storage import cancel":         {Tagline: "取消正在运行的导入任务。"},
+	"storage upload":                {Tagline: "通过 BTT 支付将文件存储到 BTFS 网络节点。"},
+	"storage upload limits":         {Tagline: "获取本主机存储的分片大小。"},
+	"storage upload queue":          {Tagline: "列出正在运行和排队的上传会话。"},
+	"storage upload expiry":         {Tagline: "管理存储到期的文件。"},
+	"storage upload expiry ls":      {Tagline: "列出存储到期的文件。"},
+	"storage upload expiry cancel":  {Tagline: "取消文件的存储到期。"},
+	"storage upload policies":       {Tagline: "列出本节点的上传策略。"},
+	"storage upload status":         {Tagline: "查看存储上传和支付状态（客户端视角）。"},
+	"swarm":                         {Tagline: "与节点群交互。"},
+	"swarm addrs":                   {Tagline: "列出已知地址，便于调试。"},
+	"swarm addrs announced":         {Tagline: "列出当前向网络通告的地址。"},
+	"swarm connect":                 {Tagline: "打开到指定地址的连接。"},
+	"swarm disconnect":              {Tagline: "关闭到指定地址的连接。"},
+	"swarm peers":                   {Tagline: "列出已打开连接的节点。"},
+	"swarm peer-stats":              {Tagline: "显示节点对本节点的使用情况。"},
+	"swarm peer-stats report":       {Tagline: "导出由本节点签名的节点滥用报告。"},
+	"swarm peer-stats blocked":      {Tagline: "列出被本节点拒绝的节点。"},
+	"swarm peer-stats block":        {Tagline: "拒绝节点的连接。"},
+	"swarm peer-stats unblock":      {Tagline: "重新接受被阻止节点的连接。"},
+	"tar":                           {Tagline: "btfs 中 tar 文件的工具函数。"},
+	"top":                           {Tagline: "显示节点的实时监控面板。"},
+	"update":                        {Tagline: "管理 BTFS 自动更新。"},
+	"update channel":                {Tagline: "显示或设置更新渠道。"},
+	"update rollback":               {Tagline: "切换回上次更新替换掉的程序。"},
+	"update now":                    {Tagline: "立即检查更新渠道。"},
+	"urlstore":                      {Tagline: "操作 urlstore。"},
+	"users":                         {Tagline: "管理团队共享节点的账户。"},
+	"users add":                     {Tagline: "添加账户并打印其 API 令牌。"},
+	"users list":                    {Tagline: "列出账户。"},
+	"users rm":                      {Tagline: "删除账户。"},
+	"users set":                     {Tagline: "修改账户的角色或存储预算。"},
+	"users token":                   {Tagline: "替换账户的 API 令牌。"},
+	"verify":                        {Tagline: "根据校验清单验证 btfs 内容。"},
+	"version":                       {Tagline: "显示 btfs 版本信息。"},
+	"wallet":                        {Tagline: "BTFS 钱包"},
+	"wallet balance":                {Tagline: "BTFS 钱包余额"},
+	"wallet connector":              {Tagline: "显示钱包的交易所连接器"},
+	"wallet deposit":                {Tagline: "BTFS 钱包充值"},
+	"wallet devices":                {Tagline: "管理关联到钱包的 BitTorrent Speed 设备"},
+	"wallet devices ls":             {Tagline: "列出关联到钱包的设备"},
+	"wallet devices link":           {Tagline: "关联设备到钱包"},
+	"wallet devices revoke":         {Tagline: "撤销关联到钱包的设备"},
+	"wallet devices send":           {Tagline: "从关联设备转账到另一个 BTT 钱包"},
+	"wallet devices transfers":      {Tagline: "列出钱包的转账及其来源设备"},
+	"wallet import":                 {Tagline: "导入 BTFS 钱包"},
+	"wallet export":                 {Tagline: "导出 BTFS 钱包密钥到 keystore 文件"},
+	"wallet init":                   {Tagline: "初始化 BTFS 钱包"},
+	"wallet keys":                   {Tagline: "BTFS 钱包密钥"},
+	"wallet password":               {Tagline: "BTFS 钱包密码"},
+	"wallet password change":        {Tagline: "修改 BTFS 钱包的密码。"},
+	"wallet relay":                  {Tagline: "通过代付链上手续费的中继节点转账 BTT"},
+	"wallet relay send":             {Tagline: "通过中继节点转账到另一个 BTT 钱包"},
+	"wallet relay terms":            {Tagline: "显示本节点中继转账的条款"},
+	"wallet relay accept":           {Tagline: "中继另一个节点的转账（中继节点）。"},
+	"wallet transactions":           {Tagline: "BTFS 钱包交易记录"},
+	"wallet transfer":               {Tagline: "转账到另一个 BTT 钱包"},
+	"wallet unlock":                 {Tagline: "暂时解锁 BTFS 钱包"},
+	"wallet lock":                   {Tagline: "锁定 BTFS 钱包"},
+	"wallet schedules":              {Tagline: "管理定时转账"},
+	"wallet schedules ls":           {Tagline: "列出定时转账"},
+	"wallet schedules cancel":       {Tagline: "取消定时转账"},
+	"wallet signer":                 {Tagline: "显示钱包交易的签名方式"},
+	"wallet withdraw":               {Tagline: "BTFS 钱包提现"},
+	"wallet withdrawals":            {Tagline: "列出提现到外部地址的记录"},
+}
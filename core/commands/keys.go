@@ -0,0 +1,345 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/dek"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+	path "github.com/TRON-US/interface-go-btfs-core/path"
+)
+
+const (
+	dekOptionName      = "dek"
+	grantTTLOptionName = "ttl"
+)
+
+// DekOutput is a data encryption key, without its key material.
+type DekOutput struct {
+	Name     string
+	Wrapper  string
+	Version  int
+	Versions int
+	Created  time.Time
+	Rotated  time.Time
+}
+
+func newDekOutput(r *dek.Record) *DekOutput {
+	return &DekOutput{
+		Name:     r.Name,
+		Wrapper:  r.Wrapper,
+		Version:  r.Current().Version,
+		Versions: len(r.Versions),
+		Created:  r.Versions[0].Created,
+		Rotated:  r.Current().Created,
+	}
+}
+
+// GrantOutput is an access grant for the gateway.
+type GrantOutput struct {
+	Grant string
+	// Path is the gateway path of the file with the grant.
+	Path    string
+	Expires time.Time
+}
+
+// SharedDekOutput is a data encryption key encrypted for another node.
+type SharedDekOutput struct {
+	Shared string
+}
+
+var KeysCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the data encryption keys of client-side encrypted files.",
+		ShortDescription: `
+Files added with 'btfs add --dek <name>' are encrypted with the data
+encryption key (DEK) <name> before they leave the node. The DEK is created
+on first use and kept in the datastore wrapped by the wallet key, or by an
+external KMS when the Keys.KMS config section is set:
+
+  > btfs config --json Keys.KMS '{"URL": "https://kms.example", "KeyID": "btfs"}'
+
+They are decrypted with 'btfs cat --decrypt' and 'btfs get --decrypt', or
+through the gateway with an access grant from 'btfs keys dek grant'.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"dek": keysDekCmd,
+	},
+}
+
+var keysDekCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List, rotate and share data encryption keys.",
+		ShortDescription: `
+Rotating a DEK adds a version which encrypts the files added from then on,
+the previous versions still decrypt the files added with them.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"list":   keysDekListCmd,
+		"rotate": keysDekRotateCmd,
+		"grant":  keysDekGrantCmd,
+		"share":  keysDekShareCmd,
+		"import": keysDekImportCmd,
+	},
+}
+
+func dekManager(env cmds.Environment) (*dek.Manager, error) {
+	n, err := cmdenv.GetNode(env)
+	if err != nil {
+		return nil, err
+	}
+	return dek.ForNode(n)
+}
+
+var keysDekListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the data encryption keys.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		m, err := dekManager(env)
+		if err != nil {
+			return err
+		}
+		rs, err := m.List()
+		if err != nil {
+			return err
+		}
+		out := make([]*DekOutput, 0, len(rs))
+		for _, r := range rs {
+			out = append(out, newDekOutput(r))
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out []*DekOutput) error {
+			tw := tabwriter.NewWriter(w, 1, 2, 1, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tVERSION\tWRAPPER\tROTATED")
+			for _, d := range out {
+				fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", d.Name, d.Version, d.Wrapper, d.Rotated.Format(time.RFC3339))
+			}
+			return tw.Flush()
+		}),
+	},
+	Type: []*DekOutput{},
+}
+
+var keysDekRotateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Rotate a data encryption key.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the data encryption key."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		m, err := dekManager(env)
+		if err != nil {
+			return err
+		}
+		r, err := m.Rotate(req.Context, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, newDekOutput(r))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *DekOutput) error {
+			_, err := fmt.Fprintf(w, "rotated %s to version %d\n", out.Name, out.Version)
+			return err
+		}),
+	},
+	Type: DekOutput{},
+}
+
+var keysDekGrantCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Grant access to a file encrypted with a data encryption key through the gateway.",
+		ShortDescription: `
+Returns the gateway path of a file with a grant letting anyone holding it
+read the decrypted file from the gateway of this node until it expires:
+
+  > btfs keys dek grant --ttl 24h QmSomeHash
+  /btfs/QmSomeHash?grant=eyJEZWsiOi...
+  > curl "http://127.0.0.1:8080/btfs/QmSomeHash?grant=eyJEZWsiOi..."
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("btfs-path", true, false, "The path of the encrypted file."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(grantTTLOptionName, "How long the grant is valid.").WithDefault("24h"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		ttl, err := time.ParseDuration(req.Options[grantTTLOptionName].(string))
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %s", grantTTLOptionName, err)
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		p, err := api.ResolvePath(req.Context, path.New(req.Arguments[0]))
+		if err != nil {
+			return err
+		}
+		b, err := api.Unixfs().GetMetadata(req.Context, p)
+		if err != nil {
+			return err
+		}
+		meta, ok := dek.ParseMeta(b)
+		if !ok {
+			return fmt.Errorf("%s is not encrypted with a data encryption key", req.Arguments[0])
+		}
+		m, err := dekManager(env)
+		if err != nil {
+			return err
+		}
+		g := &dek.Grant{
+			Dek:     meta.Dek,
+			Version: meta.DekVersion,
+			Cid:     p.Cid().String(),
+			Expires: time.Now().Add(ttl).UTC().Truncate(time.Second),
+		}
+		token, err := m.IssueGrant(req.Context, g)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &GrantOutput{
+			Grant:   token,
+			Path:    fmt.Sprintf("/btfs/%s?grant=%s", g.Cid, token),
+			Expires: g.Expires,
+		})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *GrantOutput) error {
+			_, err := fmt.Fprintln(w, out.Path)
+			return err
+		}),
+	},
+	Type: GrantOutput{},
+}
+
+var keysDekShareCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Share a data encryption key with another node.",
+		ShortDescription: `
+Encrypts the latest version of a DEK for the node <peer-id>, which imports
+it with 'btfs keys dek import' to decrypt the files encrypted with it.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the data encryption key."),
+		cmds.StringArg("peer-id", true, false, "The node to share the key with."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		m, err := dekManager(env)
+		if err != nil {
+			return err
+		}
+		r, err := m.Get(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		s, err := m.Share(req.Context, r.Name, r.Current().Version, req.Arguments[1])
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &SharedDekOutput{Shared: s})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *SharedDekOutput) error {
+			_, err := fmt.Fprintln(w, out.Shared)
+			return err
+		}),
+	},
+	Type: SharedDekOutput{},
+}
+
+var keysDekImportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Import a data encryption key shared by another node.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("shared", true, false, "The output of 'btfs keys dek share'."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		m, err := dek.ForNode(n)
+		if err != nil {
+			return err
+		}
+		r, err := m.Import(req.Context, req.Arguments[0], n.PrivateKey)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, newDekOutput(r))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *DekOutput) error {
+			_, err := fmt.Fprintf(w, "imported %s, %d versions\n", out.Name, out.Versions)
+			return err
+		}),
+	},
+	Type: DekOutput{},
+}
+
+// sealFile returns the file nd encrypted with the DEK key.
+func sealFile(nd files.Node, key []byte) (files.Node, error) {
+	f, ok := nd.(files.File)
+	if !ok {
+		return nil, fmt.Errorf("--%s can only encrypt files", dekOptionName)
+	}
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := dek.Seal(key, b)
+	if err != nil {
+		return nil, err
+	}
+	return files.NewBytesFile(sealed), nil
+}
+
+// openDekFile returns the file at p decrypted, or nil if it is not encrypted
+// with a DEK.
+func openDekFile(ctx context.Context, env cmds.Environment, api coreiface.CoreAPI, p path.Path) (files.File, error) {
+	b, err := api.Unixfs().GetMetadata(ctx, p)
+	if err != nil {
+		// not a file with metadata, left to the caller
+		return nil, nil
+	}
+	meta, ok := dek.ParseMeta(b)
+	if !ok {
+		return nil, nil
+	}
+	m, err := dekManager(env)
+	if err != nil {
+		return nil, err
+	}
+	key, err := m.Key(ctx, meta.Dek, meta.DekVersion)
+	if err != nil {
+		return nil, fmt.Errorf("data encryption key %s version %d: %s", meta.Dek, meta.DekVersion, err)
+	}
+	nd, err := api.Unixfs().Get(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := nd.(files.File)
+	if !ok {
+		return nil, coreiface.ErrNotSupported
+	}
+	defer f.Close()
+	return dek.OpenFile(key, f)
+}
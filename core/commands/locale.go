@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"github.com/TRON-US/go-btfs/core/commands/i18n"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// LocaleConfigKey is the config key of the language of the help text, e.g.
+//
+//  "Locale": "zh-CN"
+//
+// Without it the locale is taken from the environment (LC_ALL, LC_MESSAGES
+// and LANG).
+const LocaleConfigKey = "Locale"
+
+func init() {
+	configschema.RegisterSection(LocaleConfigKey, "")
+}
+
+// LoadLocale reads the locale from the config of the repo at cfgRoot
+// without opening the repo, like LoadAliases. A missing repo or key is "".
+func LoadLocale(cfgRoot string) (string, error) {
+	if !fsrepo.IsInitialized(cfgRoot) {
+		return "", nil
+	}
+	raw, err := configschema.ReadConfig(cfgRoot)
+	if err != nil {
+		return "", err
+	}
+	locale, _ := raw[LocaleConfigKey].(string)
+	return locale, nil
+}
+
+// Localize translates the help text of root to the configured locale,
+// falling back to the locale of the environment.
+func Localize(root *cmds.Command, cfgRoot string) {
+	locale, err := LoadLocale(cfgRoot)
+	if err != nil {
+		log.Debugf("cannot load locale: %s", err)
+	}
+	if locale == "" {
+		locale = i18n.FromEnv()
+	}
+	i18n.Apply(root, locale)
+}
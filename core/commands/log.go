@@ -28,13 +28,19 @@ system (not just for the daemon logs, but all commands):
         One of: debug, info, warn, error, dpanic, panic, fatal
     IPFS_LOGGING_FMT - sets formatting of the log output.
         One of: color, nocolor
+
+'btfs log create', 'append' and 'follow' manage signed append-only logs
+stored on BTFS, for feeds and audit trails.
 `,
 	},
 
 	Subcommands: map[string]*cmds.Command{
-		"level": logLevelCmd,
-		"ls":    logLsCmd,
-		"tail":  logTailCmd,
+		"level":  logLevelCmd,
+		"ls":     logLsCmd,
+		"tail":   logTailCmd,
+		"create": logCreateCmd,
+		"append": logAppendCmd,
+		"follow": logFollowCmd,
 	},
 }
 
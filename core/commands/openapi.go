@@ -0,0 +1,389 @@
+package commands
+
+import (
+	"encoding"
+	"encoding/json"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	version "github.com/TRON-US/go-btfs"
+
+	shell "github.com/TRON-US/go-btfs-api"
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// openAPIPrefixes are the command trees described by `btfs openapi`.
+var openAPIPrefixes = []string{"storage", "wallet"}
+
+// openAPIPath is the path the HTTP API is mounted at, see corehttp.APIPath.
+const openAPIPath = "/api/" + shell.API_VERSION
+
+const openAPIErrorSchema = "Error"
+
+var OpenAPICmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Print the OpenAPI specification of the storage and wallet HTTP API.",
+		ShortDescription: `
+'btfs openapi' prints an OpenAPI 3 specification of the storage and wallet
+endpoints of the HTTP API, generated from the btfs command tree and the Go
+types of the responses, to generate clients in other languages:
+
+  $ btfs openapi > btfs.json
+  $ openapi-generator generate -i btfs.json -g python -o btfs-client
+
+Every command is a POST to /api/v1/<command path>, its positional arguments
+are repeated 'arg' query parameters in order and its options are query
+parameters. Errors are answered with a non 200 status and an Error object.
+Commands which stream emit one JSON value per line.
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		return cmds.EmitOnce(res, openAPISpec(req.Root, openAPIPrefixes, version.CurrentVersionNumber))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, spec *openAPIDocument) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(spec)
+		}),
+	},
+	Type: openAPIDocument{},
+}
+
+// openAPIDocument is the subset of an OpenAPI 3 document the generator
+// fills.
+type openAPIDocument struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       openAPIInfo                     `json:"info"`
+	Servers    []openAPIServer                 `json:"servers"`
+	Paths      map[string]map[string]openAPIOp `json:"paths"`
+	Components openAPIComponents               `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]openAPISchema `json:"schemas"`
+}
+
+type openAPIOp struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags"`
+	Parameters  []openAPIParam             `json:"parameters,omitempty"`
+	RequestBody *openAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParam struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+	Explode     *bool         `json:"explode,omitempty"`
+	Schema      openAPISchema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema openAPISchema `json:"schema"`
+}
+
+// openAPISchema is a JSON schema object.
+type openAPISchema map[string]interface{}
+
+func schemaRef(name string) openAPISchema {
+	return openAPISchema{"$ref": "#/components/schemas/" + name}
+}
+
+// openAPISpec describes the commands below the prefixes of root.
+func openAPISpec(root *cmds.Command, prefixes []string, ver string) *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "BTFS storage and wallet API", Version: ver},
+		Servers: []openAPIServer{{URL: "http://127.0.0.1:5001"}},
+		Paths:   map[string]map[string]openAPIOp{},
+		Components: openAPIComponents{Schemas: map[string]openAPISchema{
+			openAPIErrorSchema: {
+				"type": "object",
+				"properties": map[string]openAPISchema{
+					"Message": {"type": "string"},
+					"Code":    {"type": "integer"},
+					"Type":    {"type": "string"},
+				},
+			},
+		}},
+	}
+	schemas := &schemaBuilder{defs: doc.Components.Schemas}
+
+	var walk func(p []string, c *cmds.Command, inherited []cmds.Option)
+	walk = func(p []string, c *cmds.Command, inherited []cmds.Option) {
+		opts := append(append([]cmds.Option{}, inherited...), c.Options...)
+		if c.Run != nil {
+			doc.Paths[openAPIPath+"/"+strings.Join(p, "/")] = map[string]openAPIOp{
+				"post": openAPIOperation(p, c, opts, schemas),
+			}
+		}
+		names := make([]string, 0, len(c.Subcommands))
+		for name := range c.Subcommands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			walk(append(append([]string{}, p...), name), c.Subcommands[name], opts)
+		}
+	}
+	for _, prefix := range prefixes {
+		if c, ok := root.Subcommands[prefix]; ok {
+			// the global options of root are left out
+			walk([]string{prefix}, c, nil)
+		}
+	}
+	return doc
+}
+
+func openAPIOperation(p []string, c *cmds.Command, opts []cmds.Option, schemas *schemaBuilder) openAPIOp {
+	tag := p[0]
+	if len(p) > 2 {
+		tag = strings.Join(p[:2], "/")
+	}
+	op := openAPIOp{
+		OperationID: strings.Replace(strings.Join(p, "_"), "-", "_", -1),
+		Summary:     c.Helptext.Tagline,
+		Description: strings.TrimSpace(c.Helptext.ShortDescription),
+		Tags:        []string{tag},
+		Responses: map[string]openAPIResponse{
+			"default": {
+				Description: "Error.",
+				Content: map[string]openAPIMediaType{
+					"application/json": {Schema: schemaRef(openAPIErrorSchema)},
+				},
+			},
+		},
+	}
+
+	var argDocs []string
+	var required, variadic bool
+	var strArgs int
+	for _, arg := range c.Arguments {
+		if arg.Type == cmds.ArgFile {
+			file := openAPISchema{"type": "string", "format": "binary"}
+			if arg.Variadic {
+				file = openAPISchema{"type": "array", "items": file}
+			}
+			op.RequestBody = &openAPIBody{
+				Required: arg.Required,
+				Content: map[string]openAPIMediaType{
+					"multipart/form-data": {Schema: openAPISchema{
+						"type":       "object",
+						"properties": map[string]openAPISchema{"file": file},
+					}},
+				},
+			}
+			continue
+		}
+		strArgs++
+		required = required || arg.Required
+		variadic = variadic || arg.Variadic
+		argDocs = append(argDocs, arg.Name+": "+arg.Description)
+	}
+	if strArgs > 0 {
+		param := openAPIParam{
+			Name:        "arg",
+			In:          "query",
+			Description: strings.Join(argDocs, "\n"),
+			Required:    required,
+			Schema:      openAPISchema{"type": "string"},
+		}
+		if strArgs > 1 || variadic {
+			explode := true
+			param.Explode = &explode
+			param.Schema = openAPISchema{"type": "array", "items": openAPISchema{"type": "string"}}
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+
+	for _, opt := range opts {
+		s := openAPISchema{"type": optionSchemaType(opt.Type())}
+		if def := opt.Default(); def != nil {
+			s["default"] = def
+		}
+		param := openAPIParam{
+			Name:        opt.Name(),
+			In:          "query",
+			Description: opt.Description(),
+			Schema:      s,
+		}
+		if opt.Type() == reflect.Slice {
+			// repeated like the arguments
+			explode := true
+			param.Explode = &explode
+			s["items"] = openAPISchema{"type": "string"}
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+
+	ok := openAPIResponse{Description: "Success."}
+	if c.Type != nil {
+		ok.Content = map[string]openAPIMediaType{
+			"application/json": {Schema: schemas.schema(reflect.TypeOf(c.Type))},
+		}
+	}
+	op.Responses["200"] = ok
+	return op
+}
+
+func optionSchemaType(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return "array"
+	}
+	return "string"
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaBuilder reflects the JSON schemas of Go types the way encoding/json
+// marshals them, the named structs being defined once in defs.
+type schemaBuilder struct {
+	defs map[string]openAPISchema
+}
+
+// schemaName returns the name of the named type t, e.g. "capacity.Result".
+func schemaName(t reflect.Type) string {
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return openAPISchema{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return openAPISchema{"type": "integer", "format": "int64", "description": "Nanoseconds."}
+	case reflect.PtrTo(t).Implements(textMarshalerType):
+		return openAPISchema{"type": "string"}
+	case reflect.PtrTo(t).Implements(jsonMarshalerType):
+		// the JSON is the type's own
+		return openAPISchema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return openAPISchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32:
+		return openAPISchema{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return openAPISchema{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return openAPISchema{"type": "number"}
+	case reflect.String:
+		return openAPISchema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return openAPISchema{"type": "string", "format": "byte"}
+		}
+		return openAPISchema{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return openAPISchema{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		name := schemaName(t)
+		if _, ok := b.defs[name]; !ok {
+			// defined before its fields for the recursive types
+			b.defs[name] = openAPISchema{}
+			b.defs[name] = b.object(t)
+		}
+		return schemaRef(name)
+	}
+	// interfaces, channels and funcs can be anything
+	return openAPISchema{}
+}
+
+func (b *schemaBuilder) object(t reflect.Type) openAPISchema {
+	props := map[string]openAPISchema{}
+	b.fields(t, props)
+	return openAPISchema{"type": "object", "properties": props}
+}
+
+// fields adds the JSON fields of the struct t to props, those of the
+// embedded structs included unless shadowed.
+func (b *schemaBuilder) fields(t reflect.Type, props map[string]openAPISchema) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded = append(embedded, ft)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := b.schema(f.Type)
+		if strings.Contains(opts, "string") {
+			s = openAPISchema{"type": "string"}
+		}
+		props[name] = s
+	}
+	for _, et := range embedded {
+		sub := map[string]openAPISchema{}
+		b.fields(et, sub)
+		for name, s := range sub {
+			if _, ok := props[name]; !ok {
+				props[name] = s
+			}
+		}
+	}
+}
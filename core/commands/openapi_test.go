@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+type openAPITestBase struct {
+	ID   string
+	Size int64
+}
+
+type openAPITestOutput struct {
+	openAPITestBase
+	Size    string `json:"size,omitempty"`
+	Time    time.Time
+	Took    time.Duration
+	Data    []byte
+	Tags    map[string]int
+	Next    *openAPITestOutput `json:",omitempty"`
+	Hidden  string             `json:"-"`
+	private int
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	root := &cmds.Command{
+		Options: []cmds.Option{cmds.BoolOption("debug", "D", "")},
+		Subcommands: map[string]*cmds.Command{
+			"storage": {
+				Options: []cmds.Option{cmds.StringOption("output", "")},
+				Subcommands: map[string]*cmds.Command{
+					"upload": {
+						Arguments: []cmds.Argument{cmds.StringArg("file-hash", true, false, "Hash of the file.")},
+						Options:   []cmds.Option{cmds.IntOption("copy", "Copies.").WithDefault(3)},
+						Run:       func(*cmds.Request, cmds.ResponseEmitter, cmds.Environment) error { return nil },
+						Type:      openAPITestOutput{},
+						Subcommands: map[string]*cmds.Command{
+							"add-shards": {
+								Arguments: []cmds.Argument{cmds.StringArg("shard", true, true, "")},
+								Run:       func(*cmds.Request, cmds.ResponseEmitter, cmds.Environment) error { return nil },
+							},
+						},
+					},
+				},
+			},
+			"add": {Run: func(*cmds.Request, cmds.ResponseEmitter, cmds.Environment) error { return nil }},
+		},
+	}
+
+	doc := openAPISpec(root, []string{"storage", "wallet"}, "1.0.0")
+	if len(doc.Paths) != 2 {
+		t.Fatalf("expected 2 paths, got %v", doc.Paths)
+	}
+	upload, ok := doc.Paths["/api/v1/storage/upload"]["post"]
+	if !ok {
+		t.Fatal("missing storage upload")
+	}
+	var names []string
+	for _, p := range upload.Parameters {
+		names = append(names, p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"arg", "output", "copy"}) {
+		t.Fatalf("unexpected parameters %v", names)
+	}
+	if upload.Parameters[0].Schema["type"] != "string" || !upload.Parameters[0].Required {
+		t.Fatalf("unexpected argument %v", upload.Parameters[0])
+	}
+	if upload.Parameters[2].Schema["type"] != "integer" || upload.Parameters[2].Schema["default"] != 3 {
+		t.Fatalf("unexpected option %v", upload.Parameters[2].Schema)
+	}
+	shards := doc.Paths["/api/v1/storage/upload/add-shards"]["post"]
+	if shards.OperationID != "storage_upload_add_shards" || shards.Parameters[0].Schema["type"] != "array" {
+		t.Fatalf("unexpected operation %+v", shards)
+	}
+	if _, ok := shards.Responses["200"].Content["application/json"]; ok {
+		t.Fatal("unexpected response schema of an untyped command")
+	}
+
+	if ref := upload.Responses["200"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/commands.openAPITestOutput" {
+		t.Fatalf("unexpected response schema %v", ref)
+	}
+	props := doc.Components.Schemas["commands.openAPITestOutput"]["properties"].(map[string]openAPISchema)
+	var fields []string
+	for name := range props {
+		fields = append(fields, name)
+	}
+	if len(fields) != 8 {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	for name, typ := range map[string]string{
+		"ID":   "string",
+		"Size": "integer",
+		"size": "string",
+		"Time": "string",
+		"Took": "integer",
+		"Data": "string",
+		"Tags": "object",
+	} {
+		if props[name]["type"] != typ {
+			t.Fatalf("field %s: expected %s, got %v", name, typ, props[name])
+		}
+	}
+	if props["Next"]["$ref"] != "#/components/schemas/commands.openAPITestOutput" {
+		t.Fatalf("unexpected recursive field %v", props["Next"])
+	}
+}
@@ -0,0 +1,122 @@
+// Package paging implements the cursor based pagination of the commands
+// listing many entries.
+//
+// A paginated command takes a --limit on the number of entries it returns
+// and a --cursor to resume from. Its output carries a NextCursor, empty on
+// the last page, to pass as --cursor to get the next page:
+//
+//	btfs pin ls --limit 1000
+//	btfs pin ls --limit 1000 --cursor <NextCursor>
+//
+// Cursors are opaque. They hold the key of the last entry returned and its
+// position, so that a page starts right after that entry even when entries
+// were added or removed in between, or at the same position if it is gone.
+package paging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	LimitOptionName  = "limit"
+	CursorOptionName = "cursor"
+)
+
+// Options returns the pagination options. defLimit is the default limit,
+// 0 returns every entry.
+func Options(defLimit int) []cmds.Option {
+	return []cmds.Option{
+		cmds.IntOption(LimitOptionName, "Maximum number of entries to return, 0 for all.").WithDefault(defLimit),
+		CursorOption(),
+	}
+}
+
+// CursorOption returns the --cursor option, for commands that already have
+// their own limit option.
+func CursorOption() cmds.Option {
+	return cmds.StringOption(CursorOptionName, "Resume the listing from the NextCursor of a previous page.")
+}
+
+type cursor struct {
+	Offset int    `json:"o"`
+	Key    string `json:"k"`
+}
+
+// Page is a page of entries requested by a command.
+type Page struct {
+	// Limit is the maximum number of entries of the page, 0 for all.
+	Limit  int
+	cursor cursor
+}
+
+// New returns the page of at most limit entries starting at the given
+// cursor, an empty cursor being the first page.
+func New(limit int, c string) (*Page, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("invalid --%s %d: must not be negative", LimitOptionName, limit)
+	}
+	p := &Page{Limit: limit}
+	if c == "" {
+		return p, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(c)
+	if err == nil {
+		err = json.Unmarshal(b, &p.cursor)
+	}
+	if err != nil || p.cursor.Offset < 0 {
+		return nil, fmt.Errorf("invalid --%s %q", CursorOptionName, c)
+	}
+	return p, nil
+}
+
+// FromRequest returns the page requested with the options of Options.
+func FromRequest(req *cmds.Request) (*Page, error) {
+	limit, _ := req.Options[LimitOptionName].(int)
+	c, _ := req.Options[CursorOptionName].(string)
+	return New(limit, c)
+}
+
+// Requested reports whether the request asks for a page rather than every
+// entry.
+func (p *Page) Requested() bool {
+	return p.Limit > 0 || p.cursor != cursor{}
+}
+
+// Bounds returns the range [start, end) of the page among n entries, key
+// giving the stable key of the i-th entry, and the cursor of the next page,
+// empty when it is the last one.
+func (p *Page) Bounds(n int, key func(i int) string) (start, end int, next string) {
+	start = p.cursor.Offset
+	if p.cursor.Key != "" {
+		for i := 0; i < n; i++ {
+			if key(i) == p.cursor.Key {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if start > n {
+		start = n
+	}
+	end = n
+	if p.Limit > 0 && start+p.Limit < n {
+		end = start + p.Limit
+	}
+	if end < n {
+		b, _ := json.Marshal(&cursor{Offset: end, Key: key(end - 1)})
+		next = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return start, end, next
+}
+
+// WriteNext writes the cursor of the next page at the end of a text output.
+func WriteNext(w io.Writer, next string) {
+	if next != "" {
+		fmt.Fprintf(w, "next page: --%s=%s\n", CursorOptionName, next)
+	}
+}
@@ -0,0 +1,89 @@
+package paging
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func keys(n int) []string {
+	var ks []string
+	for i := 0; i < n; i++ {
+		ks = append(ks, fmt.Sprintf("k%02d", i))
+	}
+	return ks
+}
+
+// list pages through ks with the given limit.
+func list(t *testing.T, ks []string, limit int) [][]string {
+	var pages [][]string
+	cursor := ""
+	for {
+		p, err := New(limit, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start, end, next := p.Bounds(len(ks), func(i int) string { return ks[i] })
+		pages = append(pages, ks[start:end])
+		if next == "" {
+			return pages
+		}
+		if len(pages) > len(ks) {
+			t.Fatal("pagination does not end")
+		}
+		cursor = next
+	}
+}
+
+func TestBounds(t *testing.T) {
+	ks := keys(10)
+	if got := list(t, ks, 0); !reflect.DeepEqual(got, [][]string{ks}) {
+		t.Errorf("no limit: got %v", got)
+	}
+	if got := list(t, ks, 4); !reflect.DeepEqual(got, [][]string{ks[:4], ks[4:8], ks[8:]}) {
+		t.Errorf("limit 4: got %v", got)
+	}
+	if got := list(t, ks, 5); !reflect.DeepEqual(got, [][]string{ks[:5], ks[5:]}) {
+		t.Errorf("limit 5: got %v", got)
+	}
+	if got := list(t, nil, 5); len(got) != 1 || len(got[0]) != 0 {
+		t.Errorf("empty: got %v", got)
+	}
+}
+
+func TestBoundsChanged(t *testing.T) {
+	ks := keys(10)
+	p, _ := New(4, "")
+	_, _, next := p.Bounds(len(ks), func(i int) string { return ks[i] })
+
+	// An entry before the cursor is removed, the next page still starts
+	// right after the last entry returned.
+	removed := append(append([]string{}, ks[:1]...), ks[2:]...)
+	p, _ = New(4, next)
+	start, end, _ := p.Bounds(len(removed), func(i int) string { return removed[i] })
+	if got := removed[start:end]; !reflect.DeepEqual(got, ks[4:8]) {
+		t.Errorf("got %v, want %v", got, ks[4:8])
+	}
+
+	// The last entry returned is gone, the page starts at the same position.
+	gone := append(append([]string{}, ks[:3]...), ks[4:]...)
+	start, end, _ = p.Bounds(len(gone), func(i int) string { return gone[i] })
+	if got := gone[start:end]; !reflect.DeepEqual(got, ks[5:9]) {
+		t.Errorf("got %v, want %v", got, ks[5:9])
+	}
+}
+
+func TestNew(t *testing.T) {
+	// The second cursor is {"o":-1}.
+	for _, c := range []string{"garbage", "eyJvIjotMX0"} {
+		if _, err := New(10, c); err == nil {
+			t.Errorf("cursor %q accepted", c)
+		}
+	}
+	if _, err := New(-1, ""); err == nil {
+		t.Error("negative limit accepted")
+	}
+	if p, _ := New(0, ""); p.Requested() {
+		t.Error("no limit nor cursor is a page")
+	}
+}
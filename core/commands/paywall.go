@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/paywall"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"github.com/tron-us/go-btfs-common/crypto"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	paywallMaxPriceOptionName = "max-price"
+
+	// paywallTimeout bounds each request of a payment to a gateway.
+	paywallTimeout = time.Minute
+)
+
+var PaywallCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Charge BTT for the content served by the gateway.",
+		ShortDescription: `
+The gateway answers the requests of the content under a priced path with
+402 Payment Required and the terms of the access. Once the price is paid
+from the ledger of the client to the ledger of the node, the gateway issues
+an access token, passed in the X-Paywall-Token header or the paywall-token
+query parameter of the following requests:
+
+  > btfs config --json Paywall '{"Enabled": true, "Rules": [{"Path":
+      "/btfs/<cid>", "Price": 1000000, "TTL": "72h"}]}'
+
+The gateway applies the Paywall config section when the daemon starts. The
+paths are matched with their root CID as a CIDv1 in base32, whatever its
+encoding in the config or in the requests.
+
+'btfs paywall pay' pays for the content at a gateway URL.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":  paywallLsCmd,
+		"pay": paywallPayCmd,
+	},
+}
+
+var paywallLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the priced paths of the gateway.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		c, err := paywall.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		if c.Rules == nil {
+			c.Rules = []*paywall.Rule{}
+		}
+		return cmds.EmitOnce(res, c)
+	},
+	Type: paywall.Config{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *paywall.Config) error {
+			if !out.Enabled {
+				fmt.Fprintln(w, "the paywall is disabled")
+			}
+			tw := tabwriter.NewWriter(w, 1, 2, 1, ' ', 0)
+			fmt.Fprintln(tw, "PATH\tPRICE\tTTL")
+			for _, r := range out.Rules {
+				fmt.Fprintf(tw, "%s\t%d µBTT\t%s\n", r.Path, r.Price, r.Duration())
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// PaywallPayOutput is the access to paywalled content.
+type PaywallPayOutput struct {
+	*paywall.Access
+	Price int64
+	// URL is the URL paid for, with the access token.
+	URL string
+}
+
+var paywallPayCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Pay for the content at a gateway URL.",
+		ShortDescription: `
+Requests the URL, and pays the price of the terms the gateway answers from
+the ledger of the node to the ledger of the publisher. Prints the access
+token issued, and the URL with the token. Use '-p=<password>' to specific
+password.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("url", true, false, "URL of the content on the gateway."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(passwordOptionName, "p", "password"),
+		cmds.Int64Option(paywallMaxPriceOptionName, "Highest price paid in µBTT, no bound when not set."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		u, err := url.Parse(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		client := &http.Client{Timeout: paywallTimeout}
+
+		hreq, err := http.NewRequestWithContext(req.Context, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(hreq)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusPaymentRequired {
+			return fmt.Errorf("%s is not paywalled, the gateway answered %s", u, resp.Status)
+		}
+		terms := &paywall.Terms{}
+		if err := json.NewDecoder(resp.Body).Decode(terms); err != nil {
+			return fmt.Errorf("invalid paywall terms: %s", err)
+		}
+		if max, ok := req.Options[paywallMaxPriceOptionName].(int64); ok && terms.Price > max {
+			return fmt.Errorf("the price of %s is %d µBTT, over --%s", terms.Path, terms.Price, paywallMaxPriceOptionName)
+		}
+		publisher, err := peer.Decode(terms.Publisher)
+		if err != nil {
+			return fmt.Errorf("invalid publisher: %s", err)
+		}
+
+		privKey, err := crypto.ToPrivKey(cfg.Identity.PrivKey)
+		if err != nil {
+			return err
+		}
+		p, err := wallet.NewPaywallPayment(req.Context, privKey, publisher, terms.Path, terms.Price, time.Now())
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		pay := *u
+		pay.Path, pay.RawQuery = terms.PayPath, ""
+		preq, err := http.NewRequestWithContext(req.Context, http.MethodPost, pay.String(), bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		preq.Header.Set("Content-Type", "application/json")
+		presp, err := client.Do(preq)
+		if err != nil {
+			return err
+		}
+		defer presp.Body.Close()
+		if presp.StatusCode != http.StatusOK {
+			msg, _ := ioutil.ReadAll(io.LimitReader(presp.Body, 1024))
+			return fmt.Errorf("the gateway rejected the payment: %s", bytes.TrimSpace(msg))
+		}
+		out := &PaywallPayOutput{Access: &paywall.Access{}, Price: terms.Price}
+		if err := json.NewDecoder(presp.Body).Decode(out.Access); err != nil {
+			return fmt.Errorf("invalid paywall access: %s", err)
+		}
+		q := u.Query()
+		q.Set(paywall.TokenParam, out.Token)
+		u.RawQuery = q.Encode()
+		out.URL = u.String()
+		return cmds.EmitOnce(res, out)
+	},
+	Type: PaywallPayOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *PaywallPayOutput) error {
+			fmt.Fprintf(w, "paid %d µBTT for %s until %s\n", out.Price, out.Path, out.Expiry.Format(time.RFC3339))
+			fmt.Fprintf(w, "token: %s\nurl: %s\n", out.Token, out.URL)
+			return nil
+		}),
+	},
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"time"
 
 	core "github.com/TRON-US/go-btfs/core"
 	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
 	e "github.com/TRON-US/go-btfs/core/commands/e"
+	paging "github.com/TRON-US/go-btfs/core/commands/paging"
 	coreapi "github.com/TRON-US/go-btfs/core/coreapi"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
@@ -319,6 +321,10 @@ With arguments, the command fails if any of the arguments is not a pinned
 object. And if --type=<type> is additionally used, the command will also fail
 if any of the arguments is not of the specified type.
 
+Use --limit to list many pins a page at a time, in the order of their hash.
+Each page ends with the cursor of the next one, to pass as --cursor. Pages
+can't be streamed.
+
 Example:
 	$ echo "hello" | btfs add -q
 	QmZULkCELmmk5XNfCgTnCyFgAVxBRBXyDHGGMVoLFLiXEN
@@ -339,11 +345,11 @@ Example:
 	Arguments: []cmds.Argument{
 		cmds.StringArg("btfs-path", false, true, "Path to object(s) to be listed."),
 	},
-	Options: []cmds.Option{
+	Options: append([]cmds.Option{
 		cmds.StringOption(pinTypeOptionName, "t", "The type of pinned keys to list. Can be \"direct\", \"indirect\", \"recursive\", or \"all\".").WithDefault("all"),
 		cmds.BoolOption(pinQuietOptionName, "q", "Write just hashes of objects."),
 		cmds.BoolOption(pinStreamOptionName, "s", "Enable streaming of pins as they are discovered."),
-	},
+	}, paging.Options(0)...),
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		n, err := cmdenv.GetNode(env)
 		if err != nil {
@@ -357,6 +363,13 @@ Example:
 
 		typeStr, _ := req.Options[pinTypeOptionName].(string)
 		stream, _ := req.Options[pinStreamOptionName].(bool)
+		page, err := paging.FromRequest(req)
+		if err != nil {
+			return err
+		}
+		if stream && page.Requested() {
+			return fmt.Errorf("--%s cannot be paginated, pins are streamed as they are discovered", pinStreamOptionName)
+		}
 
 		switch typeStr {
 		case "all", "direct", "indirect", "recursive":
@@ -386,8 +399,12 @@ Example:
 		}
 
 		if !stream {
+			var next string
+			if page.Requested() {
+				lgcList, next = pinLsPage(page, lgcList)
+			}
 			return cmds.EmitOnce(res, &PinLsOutputWrapper{
-				PinLsList: PinLsList{Keys: lgcList},
+				PinLsList: PinLsList{Keys: lgcList, NextCursor: next},
 			})
 		}
 
@@ -425,6 +442,10 @@ Example:
 					fmt.Fprintf(w, "%s %s\n", k, v.Type)
 				}
 			}
+			// Keep the quiet output a plain list of hashes.
+			if !quiet {
+				paging.WriteNext(w, out.PinLsList.NextCursor)
+			}
 
 			return nil
 		}),
@@ -441,7 +462,26 @@ type PinLsOutputWrapper struct {
 
 // PinLsList is a set of pins with their type
 type PinLsList struct {
-	Keys map[string]PinLsType
+	Keys       map[string]PinLsType
+	NextCursor string `json:",omitempty"`
+}
+
+// pinLsPage returns the pins of page, in the order of their cid, and the
+// cursor of the next page.
+func pinLsPage(page *paging.Page, keys map[string]PinLsType) (map[string]PinLsType, string) {
+	cids := make([]string, 0, len(keys))
+	for c := range keys {
+		cids = append(cids, c)
+	}
+	sort.Strings(cids)
+	start, end, next := page.Bounds(len(cids), func(i int) string {
+		return cids[i]
+	})
+	out := make(map[string]PinLsType, end-start)
+	for _, c := range cids[start:end] {
+		out[c] = keys[c]
+	}
+	return out, next
 }
 
 // PinLsType contains the type of a pin
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/power"
+	"github.com/TRON-US/go-btfs/repo"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const powerFactorOptionName = "factor"
+
+// PowerModeOutput is the power mode of the node.
+type PowerModeOutput struct {
+	Mode   string
+	Factor int
+}
+
+var PowerModeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show or set the power mode of the node.",
+		ShortDescription: `
+Without argument, shows the power mode. The low-power mode reduces the
+background activity of nodes on laptops and ARM boards:
+
+    $ btfs power-mode low
+    $ btfs power-mode low --factor 8
+    $ btfs power-mode normal
+
+In low-power mode the node:
+
+  - runs its DHT as a client only and reprovides its blocks --factor times
+    less often, from the next start of the daemon,
+  - syncs the hosts, the host stats and the latencies, provides the priority
+    records and polls the pending wallet transactions --factor times less
+    often,
+  - answers the storage challenges as a host in maintenance, asking the
+    challengers to retry later, without reading its shards.
+
+The daemon picks up the mode within a period of each task, no restart is
+needed but for the DHT and the reprovider.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("mode", false, false, "Power mode: normal or low."),
+	},
+	Options: []cmds.Option{
+		cmds.IntOption(powerFactorOptionName, "How many times less often the background tasks run in low-power mode."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := power.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		changed := false
+		if len(req.Arguments) > 0 {
+			cfg.Mode, changed = req.Arguments[0], true
+		}
+		if f, ok := req.Options[powerFactorOptionName].(int); ok {
+			cfg.Factor, changed = f, true
+		}
+		if changed {
+			if err := cfg.Validate(); err != nil {
+				return cmds.Errorf(cmds.ErrClient, err.Error())
+			}
+			if cfg.Factor == 0 {
+				cfg.Factor = power.DefaultFactor
+			}
+			if err := repo.SetConfigSection(n.Repo, power.ConfigKey, cfg); err != nil {
+				return err
+			}
+		}
+		return cmds.EmitOnce(res, &PowerModeOutput{Mode: cfg.Mode, Factor: cfg.Factor})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *PowerModeOutput) error {
+			if out.Mode == power.Low {
+				fmt.Fprintf(w, "mode: %s, background tasks %d times less often\n", out.Mode, out.Factor)
+			} else {
+				fmt.Fprintf(w, "mode: %s\n", out.Mode)
+			}
+			return nil
+		}),
+	},
+	Type: PowerModeOutput{},
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/upload"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	options "github.com/TRON-US/interface-go-btfs-core/options"
+	path "github.com/TRON-US/interface-go-btfs-core/path"
+)
+
+const (
+	siteDomainOptionName = "domain"
+	siteKeyOptionName    = "key"
+	siteUploadOptionName = "upload"
+)
+
+// PublishSiteOutput is a deployed static site.
+type PublishSiteOutput struct {
+	Cid string
+	// Name is the BTNS name the site is published under.
+	Name string
+	// Session is the storage session keeping the site on hosts, when it is
+	// uploaded.
+	Session string `json:",omitempty"`
+	// DNSLink is the TXT record serving the site at the domain, when one is
+	// given.
+	DNSLink string `json:",omitempty"`
+}
+
+var PublishSiteCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Deploy a static website.",
+		ShortDescription: `
+Adds and pins the directory of a static website, publishes it under a BTNS
+name and prints the DNSLink record serving it at a domain:
+
+  > btfs publish-site ./public --domain example.com
+  added site QmSomeHash
+  published /btns/QmSomeName
+  set this DNS record to serve the site at example.com:
+    _dnslink.example.com TXT "dnslink=/btns/QmSomeName"
+
+The record points to the BTNS name, so it only needs to be set once, the
+following deploys update the name. With --upload the site is also stored on
+hosts, so that it stays available when this node is offline.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.FileArg("dir", true, false, "The directory of the site.").EnableRecursive(),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(cmds.RecLong, "Add the directory recursively.").WithDefault(true),
+		cmds.OptionHidden,
+		cmds.StringOption(siteDomainOptionName, "The domain serving the site."),
+		cmds.StringOption(siteKeyOptionName, "k", "Name of the key to publish the site with, see 'btfs key list'.").WithDefault("self"),
+		cmds.BoolOption(siteUploadOptionName, "Also store the site on hosts."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		it := req.Files.Entries()
+		if !it.Next() {
+			if err := it.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("expected a directory argument")
+		}
+		dir, ok := it.Node().(files.Directory)
+		if !ok {
+			return fmt.Errorf("%s is not a directory", it.Name())
+		}
+		added, err := api.Unixfs().Add(req.Context, dir, options.Unixfs.Pin(true))
+		if err != nil {
+			return err
+		}
+		out := &PublishSiteOutput{Cid: added.Cid().String()}
+
+		if up, _ := req.Options[siteUploadOptionName].(bool); up {
+			if out.Session, err = uploadSite(req.Context, env, out.Cid); err != nil {
+				return fmt.Errorf("cannot store the site on hosts: %s", err)
+			}
+		}
+
+		key, _ := req.Options[siteKeyOptionName].(string)
+		entry, err := api.Name().Publish(req.Context, path.IpfsPath(added.Cid()), options.Name.Key(key))
+		if err != nil {
+			return err
+		}
+		out.Name = entry.Name()
+
+		if domain, _ := req.Options[siteDomainOptionName].(string); domain != "" {
+			domain = strings.TrimSuffix(domain, ".")
+			out.DNSLink = fmt.Sprintf(`_dnslink.%s TXT "dnslink=/btns/%s"`, domain, out.Name)
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *PublishSiteOutput) error {
+			fmt.Fprintf(w, "added site %s\n", out.Cid)
+			if out.Session != "" {
+				fmt.Fprintf(w, "storing on hosts, session %s\n", out.Session)
+			}
+			fmt.Fprintf(w, "published /btns/%s\n", out.Name)
+			if out.DNSLink != "" {
+				domain, _ := req.Options[siteDomainOptionName].(string)
+				fmt.Fprintf(w, "set this DNS record to serve the site at %s:\n", strings.TrimSuffix(domain, "."))
+				fmt.Fprintf(w, "  %s\n", out.DNSLink)
+			}
+			return nil
+		}),
+	},
+	Type: PublishSiteOutput{},
+}
+
+// uploadSite starts storing the site root on hosts with 'btfs storage
+// upload' and returns the storage session.
+func uploadSite(ctx context.Context, env cmds.Environment, root string) (string, error) {
+	req, err := cmds.NewRequest(ctx, []string{}, cmds.OptMap{}, []string{root}, nil, upload.StorageUploadCmd)
+	if err != nil {
+		return "", err
+	}
+	if err := req.FillDefaults(); err != nil {
+		return "", err
+	}
+	re, res := cmds.NewChanResponsePair(req)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cmds.NewExecutor(upload.StorageUploadCmd).Execute(req, re, env)
+	}()
+	var session string
+	for {
+		v, err := res.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if r, ok := v.(*upload.Res); ok {
+			session = r.ID
+		}
+	}
+	if err := <-errCh; err != nil {
+		return "", err
+	}
+	return session, nil
+}
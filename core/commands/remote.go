@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/remotes"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	remoteTokenOptionName = "token"
+	remoteForceOptionName = "force"
+)
+
+var RemoteCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the remote daemons the CLI runs commands on.",
+		ShortDescription: `
+A remote is a daemon the CLI runs commands on with 'btfs --remote <name>',
+with the API address and the token stored once, instead of exporting them in
+each shell:
+
+  > btfs remote add prod --api https://host:5001 --token <token>
+  > btfs --remote prod storage stats info
+
+The API address is a http or https URL, or a multiaddr such as
+/dns4/host/tcp/5001. The token is sent as a bearer token: the token of a user
+of a shared node, or of an application. The remotes are stored in the
+remotes.json file of the btfs directory, only readable by its owner.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"add": remoteAddCmd,
+		"rm":  remoteRmCmd,
+		"ls":  remoteLsCmd,
+	},
+}
+
+// RemoteOutput is a remote, without its token.
+type RemoteOutput struct {
+	Name     string
+	API      string
+	HasToken bool
+}
+
+func remoteOutput(p *remotes.Profile) *RemoteOutput {
+	return &RemoteOutput{Name: p.Name, API: p.API, HasToken: p.Token != ""}
+}
+
+var remoteAddCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Add a remote daemon.",
+		ShortDescription: `
+'btfs remote add <name> --api <address>' stores the API address of a daemon,
+and the token given with --token, under name. --force replaces a remote of
+the same name.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the remote."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(remoteTokenOptionName, "Token sent to the remote daemon."),
+		cmds.BoolOption(remoteForceOptionName, "f", "Replace the remote of the same name."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		root, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		api, _ := req.Options[ApiOption].(string)
+		if api == "" {
+			return errors.New("the api address of the remote is required, use --api")
+		}
+		token, _ := req.Options[remoteTokenOptionName].(string)
+		force, _ := req.Options[remoteForceOptionName].(bool)
+		p := &remotes.Profile{Name: req.Arguments[0], API: api, Token: token}
+		if err := remotes.Open(root).Add(p, force); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, remoteOutput(p))
+	},
+	Type: RemoteOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *RemoteOutput) error {
+			_, err := fmt.Fprintf(w, "added remote %s at %s\n", out.Name, out.API)
+			return err
+		}),
+	},
+}
+
+var remoteRmCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove a remote daemon.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the remote."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		root, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		if err := remotes.Open(root).Remove(req.Arguments[0]); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &MessageOutput{fmt.Sprintf("removed remote %s", req.Arguments[0])})
+	},
+	Type: MessageOutput{},
+}
+
+var remoteLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the remote daemons.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		root, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		ps, err := remotes.Open(root).List()
+		if err != nil {
+			return err
+		}
+		out := make([]*RemoteOutput, 0, len(ps))
+		for _, p := range ps {
+			out = append(out, remoteOutput(p))
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: []*RemoteOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out []*RemoteOutput) error {
+			tw := tabwriter.NewWriter(w, 1, 2, 1, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tAPI\tTOKEN")
+			for _, r := range out {
+				fmt.Fprintf(tw, "%s\t%s\t%t\n", r.Name, r.API, r.HasToken)
+			}
+			return tw.Flush()
+		}),
+	},
+}
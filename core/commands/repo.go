@@ -1,24 +1,23 @@
 package commands
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"io"
-	"os"
-	"runtime"
-	"strings"
-	"sync"
 	"text/tabwriter"
 
+	"github.com/TRON-US/go-btfs/core"
 	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	corerepo "github.com/TRON-US/go-btfs/core/corerepo"
+	"github.com/TRON-US/go-btfs/gc"
 	fsrepo "github.com/TRON-US/go-btfs/repo/fsrepo"
 	humanize "github.com/dustin/go-humanize"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	cid "github.com/ipfs/go-cid"
-	bstore "github.com/ipfs/go-ipfs-blockstore"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
 )
 
 type RepoVersion struct {
@@ -34,11 +33,17 @@ var RepoCmd = &cmds.Command{
 	},
 
 	Subcommands: map[string]*cmds.Command{
-		"stat":    repoStatCmd,
-		"gc":      repoGcCmd,
-		"fsck":    repoFsckCmd,
-		"version": repoVersionCmd,
-		"verify":  repoVerifyCmd,
+		"stat":        repoStatCmd,
+		"gc":          repoGcCmd,
+		"fsck":        repoFsckCmd,
+		"version":     repoVersionCmd,
+		"verify":      repoVerifyCmd,
+		"add-session": repoAddSessionCmd,
+		"maintenance": repoMaintenanceCmd,
+		"import-ipfs": repoImportIpfsCmd,
+		"cache":       repoCacheCmd,
+		"bench":       repoBenchCmd,
+		"encrypt":     repoEncryptCmd,
 	},
 }
 
@@ -46,11 +51,15 @@ var RepoCmd = &cmds.Command{
 type GcResult struct {
 	Key   cid.Cid
 	Error string `json:",omitempty"`
+	// Report is what a dry run would remove, with --dry-run --report.
+	Report *gc.Report `json:",omitempty"`
 }
 
 const (
 	repoStreamErrorsOptionName = "stream-errors"
 	repoQuietOptionName        = "quiet"
+	repoDryRunOptionName       = "dry-run"
+	repoReportOptionName       = "report"
 )
 
 var repoGcCmd = &cmds.Command{
@@ -60,17 +69,39 @@ var repoGcCmd = &cmds.Command{
 'btfs repo gc' is a plumbing command that will sweep the local
 set of stored objects and remove ones that are not pinned in
 order to reclaim hard disk space.
+
+On a storage host with active contracts, the command lists them and asks
+for a confirmation first, which --yes skips.
+
+With --dry-run nothing is removed, the command lists the blocks a sweep
+would remove. --report prints a summary instead: the blocks and bytes
+reclaimed by age and by subsystem (upload sessions, shards of active and of
+ended host contracts), and the unpinned blocks kept by MFS and staged adds.
 `,
 	},
 	Options: []cmds.Option{
 		cmds.BoolOption(repoStreamErrorsOptionName, "Stream errors."),
 		cmds.BoolOption(repoQuietOptionName, "q", "Write minimal output."),
+		cmdenv.OptionYes,
+		cmds.BoolOption(repoDryRunOptionName, "List the blocks to remove without removing them."),
+		cmds.BoolOption(repoReportOptionName, "Print a summary of the space a dry run would reclaim."),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		n, err := cmdenv.GetNode(env)
 		if err != nil {
 			return err
 		}
+		dryRun, _ := req.Options[repoDryRunOptionName].(bool)
+		report, _ := req.Options[repoReportOptionName].(bool)
+		if report && !dryRun {
+			return fmt.Errorf("--%s needs --%s", repoReportOptionName, repoDryRunOptionName)
+		}
+		if dryRun {
+			return gcDryRun(req, re, env, n, report)
+		}
+		if err := confirmHostGc(req, n); err != nil {
+			return err
+		}
 
 		streamErrors, _ := req.Options[repoStreamErrorsOptionName].(bool)
 
@@ -116,8 +147,14 @@ order to reclaim hard disk space.
 				_, err := fmt.Fprintf(w, "Error: %s\n", gcr.Error)
 				return err
 			}
+			if gcr.Report != nil {
+				return writeGcReport(w, gcr.Report)
+			}
 
 			prefix := "removed "
+			if dryRun, _ := req.Options[repoDryRunOptionName].(bool); dryRun {
+				prefix = "would remove "
+			}
 			if quiet {
 				prefix = ""
 			}
@@ -133,6 +170,109 @@ const (
 	repoHumanOptionName    = "human"
 )
 
+// gcDryRun emits the blocks a garbage collection of n would remove, or a
+// report of them.
+func gcDryRun(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment, n *core.IpfsNode, report bool) error {
+	cfgRoot, err := cmdenv.GetConfigRoot(env)
+	if err != nil {
+		return err
+	}
+	subsystems, err := gcSubsystems(n)
+	if err != nil {
+		return err
+	}
+	var onRemove func(cid.Cid)
+	if !report {
+		onRemove = func(k cid.Cid) {
+			// as for a sweep, the client may be gone
+			_ = re.Emit(&GcResult{Key: k})
+		}
+	}
+	r, err := corerepo.GarbageCollectDryRun(req.Context, n, cfgRoot, subsystems, onRemove)
+	if err != nil {
+		return err
+	}
+	if report {
+		return re.Emit(&GcResult{Report: r})
+	}
+	return nil
+}
+
+// gcSubsystems returns the roots of the storage subsystems GC may reclaim
+// blocks of: shards uploaded by this node, and shards it hosts.
+func gcSubsystems(n *core.IpfsNode) (gc.Groups, error) {
+	d, peerID := n.Repo.Datastore(), n.Identity.Pretty()
+	groups := gc.Groups{}
+	add := func(group, hash string) {
+		if c, err := cid.Decode(hash); err == nil {
+			groups[group] = append(groups[group], c)
+		}
+	}
+	rcs, err := contracts.ListContracts(d, peerID, nodepb.ContractStat_RENTER.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range rcs {
+		add("upload sessions", c.ShardHash)
+	}
+	hcs, err := contracts.ListContracts(d, peerID, nodepb.ContractStat_HOST.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range hcs {
+		if helper.ContractFilterMap["active"][c.Status] {
+			add("contract shards", c.ShardHash)
+		} else {
+			add("orphaned shards", c.ShardHash)
+		}
+	}
+	return groups, nil
+}
+
+func writeGcReport(w io.Writer, r *gc.Report) error {
+	tw := tabwriter.NewWriter(w, 1, 2, 2, ' ', 0)
+	section := func(title string, bs []gc.Bucket) {
+		fmt.Fprintf(tw, "\n%s:\n", title)
+		for _, b := range bs {
+			fmt.Fprintf(tw, "  %s\t%d blocks\t%s\n", b.Name, b.Blocks, humanize.Bytes(b.Bytes))
+		}
+	}
+	fmt.Fprintf(tw, "would remove %d blocks, %s\n", r.Removed.Blocks, humanize.Bytes(r.Removed.Bytes))
+	section("by age", r.ByAge)
+	section("by subsystem", r.BySubsystem)
+	section("kept though not pinned", r.Kept)
+	return tw.Flush()
+}
+
+// confirmHostGc asks for a confirmation before collecting the garbage of a
+// storage host with active contracts.
+func confirmHostGc(req *cmds.Request, n *core.IpfsNode) error {
+	cfg, err := n.Repo.Config()
+	if err != nil {
+		return err
+	}
+	if !cfg.Experimental.StorageHostEnabled {
+		return nil
+	}
+	cs, err := contracts.ListContracts(n.Repo.Datastore(), n.Identity.Pretty(), nodepb.ContractStat_HOST.String())
+	if err != nil {
+		return err
+	}
+	var affected []string
+	for _, c := range cs {
+		if helper.ContractFilterMap["active"][c.Status] {
+			affected = append(affected, fmt.Sprintf("contract %s: shard %s (%s) until %s",
+				c.ContractId, c.ShardHash, humanize.IBytes(uint64(c.ShardSize)), c.EndTime.Format("2006-01-02")))
+		}
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+	return cmdenv.RequireConfirmation(req, fmt.Sprintf(
+		"This node hosts %d active storage contracts. Garbage collection deletes every unpinned block, "+
+			"and contract shards that are not pinned anymore will fail their challenges:", len(affected)), affected)
+}
+
 var repoStatCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Get stats for the currently used repo.",
@@ -229,117 +369,6 @@ var repoFsckCmd = &cmds.Command{
 	},
 }
 
-type VerifyProgress struct {
-	Msg      string
-	Progress int
-}
-
-func verifyWorkerRun(ctx context.Context, wg *sync.WaitGroup, keys <-chan cid.Cid, results chan<- string, bs bstore.Blockstore) {
-	defer wg.Done()
-
-	for k := range keys {
-		_, err := bs.Get(k)
-		if err != nil {
-			select {
-			case results <- fmt.Sprintf("block %s was corrupt (%s)", k, err):
-			case <-ctx.Done():
-				return
-			}
-
-			continue
-		}
-
-		select {
-		case results <- "":
-		case <-ctx.Done():
-			return
-		}
-	}
-}
-
-func verifyResultChan(ctx context.Context, keys <-chan cid.Cid, bs bstore.Blockstore) <-chan string {
-	results := make(chan string)
-
-	go func() {
-		defer close(results)
-
-		var wg sync.WaitGroup
-
-		for i := 0; i < runtime.NumCPU()*2; i++ {
-			wg.Add(1)
-			go verifyWorkerRun(ctx, &wg, keys, results, bs)
-		}
-
-		wg.Wait()
-	}()
-
-	return results
-}
-
-var repoVerifyCmd = &cmds.Command{
-	Helptext: cmds.HelpText{
-		Tagline: "Verify all blocks in repo are not corrupted.",
-	},
-	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
-		nd, err := cmdenv.GetNode(env)
-		if err != nil {
-			return err
-		}
-
-		bs := bstore.NewBlockstore(nd.Repo.Datastore())
-		bs.HashOnRead(true)
-
-		keys, err := bs.AllKeysChan(req.Context)
-		if err != nil {
-			log.Error(err)
-			return err
-		}
-
-		results := verifyResultChan(req.Context, keys, bs)
-
-		var fails int
-		var i int
-		for msg := range results {
-			if msg != "" {
-				if err := res.Emit(&VerifyProgress{Msg: msg}); err != nil {
-					return err
-				}
-				fails++
-			}
-			i++
-			if err := res.Emit(&VerifyProgress{Progress: i}); err != nil {
-				return err
-			}
-		}
-
-		if fails != 0 {
-			return errors.New("verify complete, some blocks were corrupt")
-		}
-
-		return res.Emit(&VerifyProgress{Msg: "verify complete, all blocks validated."})
-	},
-	Type: &VerifyProgress{},
-	Encoders: cmds.EncoderMap{
-		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, obj *VerifyProgress) error {
-			if strings.Contains(obj.Msg, "was corrupt") {
-				fmt.Fprintln(os.Stdout, obj.Msg)
-				return nil
-			}
-
-			if obj.Msg != "" {
-				if len(obj.Msg) < 20 {
-					obj.Msg += "             "
-				}
-				fmt.Fprintln(w, obj.Msg)
-				return nil
-			}
-
-			fmt.Fprintf(w, "%d blocks processed.\r", obj.Progress)
-			return nil
-		}),
-	},
-}
-
 var repoVersionCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Show the repo version.",
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/coreunix"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+)
+
+type AddSessionOutput struct {
+	ID      string
+	Created time.Time
+	Roots   []string
+}
+
+type AddSessionListOutput struct {
+	Sessions []AddSessionOutput
+}
+
+const addSessionPinOptionName = "pin"
+
+var repoAddSessionCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage GC-safe add sessions.",
+		ShortDescription: `
+An add session stages every block written by 'btfs add --session=<id>'
+in a GC-exempt area of the repo, so a garbage collection running during
+a long add cannot remove half-written data. The staged data is released
+once the session is committed (its roots get pinned) or aborted.
+
+  > btfs repo add-session new
+  > btfs add -r --pin=false --session=<id> <dir>
+  > btfs repo add-session commit <id>
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"new":    repoAddSessionNewCmd,
+		"ls":     repoAddSessionLsCmd,
+		"commit": repoAddSessionCommitCmd,
+		"abort":  repoAddSessionAbortCmd,
+	},
+}
+
+var repoAddSessionNewCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Open a new add session.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		s, err := coreunix.NewAddSession(n.Repo.Datastore())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, addSessionOutput(s))
+	},
+	Type: AddSessionOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *AddSessionOutput) error {
+			_, err := fmt.Fprintln(w, out.ID)
+			return err
+		}),
+	},
+}
+
+var repoAddSessionLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List open add sessions.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		sessions, err := coreunix.ListAddSessions(n.Repo.Datastore())
+		if err != nil {
+			return err
+		}
+		out := &AddSessionListOutput{Sessions: []AddSessionOutput{}}
+		for _, s := range sessions {
+			out.Sessions = append(out.Sessions, *addSessionOutput(s))
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: AddSessionListOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *AddSessionListOutput) error {
+			tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+			defer tw.Flush()
+			for _, s := range out.Sessions {
+				fmt.Fprintf(tw, "%s\t%s\t%d roots\n", s.ID, s.Created.Format(time.RFC3339), len(s.Roots))
+			}
+			return nil
+		}),
+	},
+}
+
+var repoAddSessionCommitCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Commit an add session.",
+		ShortDescription: `
+Pins the roots of every add completed in the session and closes it.
+Use --pin=false when the data is handed off by other means, e.g. right
+after a 'btfs storage upload' of the roots has finished.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("session-id", true, false, "ID of the add session."),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(addSessionPinOptionName, "Pin the session roots recursively.").WithDefault(true),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		s, err := coreunix.GetAddSession(n.Repo.Datastore(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		dopin, _ := req.Options[addSessionPinOptionName].(bool)
+		if dopin {
+			roots, err := s.RootCids()
+			if err != nil {
+				return err
+			}
+			for _, c := range roots {
+				if err := api.Pin().Add(req.Context, path.IpfsPath(c), options.Pin.Recursive(true)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := s.Close(); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, addSessionOutput(s))
+	},
+	Type: AddSessionOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *AddSessionOutput) error {
+			for _, r := range out.Roots {
+				fmt.Fprintf(w, "committed %s\n", r)
+			}
+			return nil
+		}),
+	},
+}
+
+var repoAddSessionAbortCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Abort an add session.",
+		ShortDescription: `
+Closes the session without pinning anything. Staged blocks that are not
+referenced elsewhere are removed by the next garbage collection.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("session-id", true, false, "ID of the add session."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		s, err := coreunix.GetAddSession(n.Repo.Datastore(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		if err := s.Close(); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &MessageOutput{Message: fmt.Sprintf("aborted %s\n", s.ID)})
+	},
+	Type: MessageOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *MessageOutput) error {
+			_, err := fmt.Fprint(w, out.Message)
+			return err
+		}),
+	},
+}
+
+func addSessionOutput(s *coreunix.AddSession) *AddSessionOutput {
+	roots := s.Roots
+	if roots == nil {
+		roots = []string{}
+	}
+	return &AddSessionOutput{ID: s.ID, Created: s.Created, Roots: roots}
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/commands"
+	"github.com/TRON-US/go-btfs/repo/durability"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	repoBenchDirOptionName       = "dir"
+	repoBenchBackendOptionName   = "backend"
+	repoBenchBlocksOptionName    = "blocks"
+	repoBenchBlockSizeOptionName = "block-size"
+	repoBenchBatchOptionName     = "batch"
+)
+
+var repoBenchCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Benchmark the write throughput of the datastore settings.",
+		ShortDescription: `
+'btfs repo bench' writes blocks to temporary flatfs and badger datastores in
+the repo directory, or --dir, with the fsync and batching settings worth
+comparing, and reports the throughput of each with when its writes are synced.
+
+The fsync policy of a backend is set in Datastore.Spec: "sync" for flatfs and
+"syncWrites" for badgerds sync every write, the safe default of flatfs. With
+them off, "syncInterval" syncs the writes periodically instead, so that at
+most its duration of writes is lost on a crash:
+
+    "sync": false, "syncInterval": "1s"
+
+The number and size of the blocks 'btfs add' writes at once are set by
+DatastoreWrites.BatchNodes (128 by default) and DatastoreWrites.BatchSize
+(8MiB by default). The datastores are reopened when the daemon restarts.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(repoBenchDirOptionName, "Directory to benchmark, the repo directory by default."),
+		cmds.StringOption(repoBenchBackendOptionName, "Backend to benchmark: flatfs or badgerds, both by default."),
+		cmds.IntOption(repoBenchBlocksOptionName, "Number of blocks written.").WithDefault(256),
+		cmds.StringOption(repoBenchBlockSizeOptionName, "Size of the blocks written.").WithDefault("256KiB"),
+		cmds.IntOption(repoBenchBatchOptionName, "Number of blocks written at once when batched.").WithDefault(durability.DefaultBatchNodes),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		dir, _ := req.Options[repoBenchDirOptionName].(string)
+		if dir == "" {
+			dir = env.(*commands.Context).ConfigRoot
+		}
+		size, err := humanize.ParseBytes(req.Options[repoBenchBlockSizeOptionName].(string))
+		if err != nil {
+			return fmt.Errorf("invalid block size: %s", err)
+		}
+		opts := durability.BenchOptions{
+			Blocks:     req.Options[repoBenchBlocksOptionName].(int),
+			BlockSize:  int(size),
+			BatchNodes: req.Options[repoBenchBatchOptionName].(int),
+		}
+		if b, _ := req.Options[repoBenchBackendOptionName].(string); b != "" {
+			opts.Backends = []string{b}
+		}
+		return durability.Bench(req.Context, dir, opts, func(r *durability.BenchResult) error {
+			return res.Emit(r)
+		})
+	},
+	Type: durability.BenchResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, r *durability.BenchResult) error {
+			settings := r.Backend + " " + r.Settings
+			if r.Error != "" {
+				_, err := fmt.Fprintf(w, "%-48s error: %s\n", settings, r.Error)
+				return err
+			}
+			_, err := fmt.Fprintf(w, "%-48s %10s/s  synced %s\n", settings,
+				strings.TrimSpace(humanize.Bytes(uint64(r.Throughput))), r.Durability)
+			return err
+		}),
+	},
+}
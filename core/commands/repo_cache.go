@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/TRON-US/go-btfs/core/blockcache"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	"github.com/ipfs/go-merkledag"
+)
+
+var errNoBlockCache = errors.New("the block cache is disabled, set BlockCache.Path")
+
+var repoCacheCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the block cache on fast storage.",
+		ShortDescription: `
+With BlockCache.Path set to a directory on fast storage, the blocks read from
+the repo are copied to a cache in the directory, of BlockCache.MaxSize (10GB by
+default), which evicts the least recently read blocks. The blocks of a hot
+shard or file can be pinned in the cache with 'btfs repo cache pin':
+
+    $ btfs config --json BlockCache '{"Path": "/mnt/ssd/btfs-cache", "MaxSize": "200GB"}'
+
+The cache only holds copies: its directory can be wiped while the daemon is
+stopped.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"stat":  repoCacheStatCmd,
+		"pin":   repoCachePinCmd,
+		"unpin": repoCacheUnpinCmd,
+		"ls":    repoCacheLsCmd,
+	},
+}
+
+var repoCacheStatCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the size and hit rate of the block cache.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		c := blockcache.Active()
+		if c == nil {
+			return errNoBlockCache
+		}
+		return cmds.EmitOnce(res, c.Stats())
+	},
+	Type: blockcache.Stats{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, s *blockcache.Stats) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintf(tw, "Path:\t%s\n", s.Path)
+			fmt.Fprintf(tw, "Size:\t%s of %s, %d blocks\n", humanize.Bytes(uint64(s.Size)),
+				humanize.Bytes(uint64(s.MaxSize)), s.Blocks)
+			fmt.Fprintf(tw, "Pinned:\t%s, %d blocks\n", humanize.Bytes(uint64(s.PinnedSize)), s.PinnedBlocks)
+			fmt.Fprintf(tw, "Hits:\t%d\n", s.Hits)
+			fmt.Fprintf(tw, "Misses:\t%d\n", s.Misses)
+			fmt.Fprintf(tw, "Hit rate:\t%.1f%%\n", s.HitRate*100)
+			fmt.Fprintf(tw, "Evictions:\t%d\n", s.Evictions)
+			return tw.Flush()
+		}),
+	},
+}
+
+var repoCachePinCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Keep the blocks of a DAG in the block cache.",
+		ShortDescription: `
+'btfs repo cache pin' copies the blocks of a DAG in the repo, such as a hot
+shard, to the block cache where they are never evicted until unpinned.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", true, false, "Root of the DAG to pin."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		c := blockcache.Active()
+		if c == nil {
+			return errNoBlockCache
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		root, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		// the blocks must be in the repo already
+		dag := merkledag.NewDAGService(blockservice.New(n.Blockstore, offline.Exchange(n.Blockstore)))
+		set := cid.NewSet()
+		if err := merkledag.Walk(req.Context, merkledag.GetLinksDirect(dag), root, set.Visit); err != nil {
+			return fmt.Errorf("cannot walk %s: %s", root, err)
+		}
+		p, err := c.Pin(root, set.Keys())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, p)
+	},
+	Type: blockcache.Pin{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, p *blockcache.Pin) error {
+			_, err := fmt.Fprintf(w, "pinned %s: %d blocks, %s\n", p.Root, len(p.Blocks), humanize.Bytes(uint64(p.Size)))
+			return err
+		}),
+	},
+}
+
+var repoCacheUnpinCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Let the blocks of a DAG be evicted from the block cache.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", true, false, "Root of the pinned DAG."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		c := blockcache.Active()
+		if c == nil {
+			return errNoBlockCache
+		}
+		root, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		if err := c.Unpin(root); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &stringList{[]string{root.String()}})
+	},
+	Type: stringList{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(stringListEncoder),
+	},
+}
+
+var repoCacheLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the DAGs pinned in the block cache.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		c := blockcache.Active()
+		if c == nil {
+			return errNoBlockCache
+		}
+		pins, err := c.Pins()
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, pins)
+	},
+	Type: []*blockcache.Pin{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, pins []*blockcache.Pin) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ROOT\tBLOCKS\tSIZE")
+			for _, p := range pins {
+				fmt.Fprintf(tw, "%s\t%d\t%s\n", p.Root, len(p.Blocks), humanize.Bytes(uint64(p.Size)))
+			}
+			return tw.Flush()
+		}),
+	},
+}
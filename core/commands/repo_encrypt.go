@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/dek"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/repocrypt"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+)
+
+const repoEncryptKMSOptionName = "kms"
+
+var repoEncryptCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Encrypt the blocks and the keys of the repo at rest.",
+		ShortDescription: `
+'btfs repo encrypt' turns the encryption of the repo on and seals the blocks
+and the keys already in the repo, in place while the daemon runs. The blocks
+written from then on are sealed as they are written.
+
+The contents are sealed with a random repo key, itself wrapped by a key
+derived from the wallet password given with -p, or by the KMS of the Keys
+config section with --kms:
+
+    $ btfs repo encrypt -p <wallet password>
+
+The repo key is unlocked when the daemon starts, with the wallet password
+read from the BTFS_WALLET_PASSWORD environment variable, or with the KMS.
+The daemon does not start without it. Running the command again seals the
+contents left in the clear, such as blocks written by an older version.
+
+Sealing adds 38 bytes to each block. The btfs_repocrypt_* metrics count the
+blocks sealed and opened, and the time spent doing so.`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(passwordOptionName, "p", "Wallet password wrapping the repo key."),
+		cmds.BoolOption(repoEncryptKMSOptionName, "Wrap the repo key with the KMS of the Keys config section."),
+	},
+	Subcommands: map[string]*cmds.Command{
+		"status": repoEncryptStatusCmd,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if !repocrypt.Default.Unlocked() {
+			source := repocrypt.PasswordSource
+			if kms, _ := req.Options[repoEncryptKMSOptionName].(bool); kms {
+				source = repocrypt.KMSSource
+			} else {
+				// the repo key is derived from the password, unlocking
+				// the wallet does not do
+				if password, _ := req.Options[passwordOptionName].(string); password == "" {
+					return errPasswordRequired
+				}
+				cfg, err := n.Repo.Config()
+				if err != nil {
+					return err
+				}
+				if err := validatePassword(n.Repo, cfg, req); err != nil {
+					return err
+				}
+			}
+			password, _ := req.Options[passwordOptionName].(string)
+			w, err := repoCryptWrapper(n.Repo, source, password)
+			if err != nil {
+				return err
+			}
+			if err := repocrypt.Default.Enable(req.Context, n.Repo, w, source); err != nil {
+				return err
+			}
+		}
+		return repocrypt.Migrate(req.Context, n.Repo, repocrypt.Default, func(p repocrypt.Progress) error {
+			return res.Emit(&p)
+		})
+	},
+	Type: repocrypt.Progress{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, p *repocrypt.Progress) error {
+			if p.Done {
+				_, err := fmt.Fprintf(w, "Sealed %d of %d blocks (%s) and %d keys, the repo is encrypted.\n",
+					p.Sealed, p.Blocks, humanize.Bytes(uint64(p.Bytes)), p.Keys)
+				return err
+			}
+			_, err := fmt.Fprintf(w, "Sealed %d of %d blocks visited (%s)\n",
+				p.Sealed, p.Blocks, humanize.Bytes(uint64(p.Bytes)))
+			return err
+		}),
+	},
+}
+
+var repoEncryptStatusCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show how much of the repo is encrypted.",
+		ShortDescription: `
+'btfs repo encrypt status' counts the blocks and the keys of the repo sealed
+with the repo key, reading every block.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		s, err := repocrypt.GetStatus(req.Context, n.Repo, repocrypt.Default)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, s)
+	},
+	Type: repocrypt.Status{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, s *repocrypt.Status) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintf(tw, "Enabled:\t%t\n", s.Enabled)
+			if s.Enabled {
+				fmt.Fprintf(tw, "Key source:\t%s\n", s.KeySource)
+				fmt.Fprintf(tw, "Unlocked:\t%t\n", s.Unlocked)
+			}
+			fmt.Fprintf(tw, "Blocks:\t%d of %d sealed\n", s.SealedBlocks, s.Blocks)
+			fmt.Fprintf(tw, "Keys:\t%d of %d sealed\n", s.SealedKeys, s.Keys)
+			return tw.Flush()
+		}),
+	},
+}
+
+// repoCryptWrapper returns the wrapper of the repo key of r from source.
+func repoCryptWrapper(r repo.Repo, source, password string) (repocrypt.Wrapper, error) {
+	if source == repocrypt.KMSSource {
+		c, err := dek.Load(r)
+		if err != nil {
+			return nil, err
+		}
+		if c.KMS == nil {
+			return nil, fmt.Errorf("no KMS configured in %s.KMS", dek.ConfigKey)
+		}
+		return dek.NewKMSWrapper(c.KMS)
+	}
+	return repocrypt.NewPasswordWrapper(password)
+}
+
+// UnlockRepo unlocks the repo key of r when the repo is encrypted, so that
+// the node built on r reads and seals its contents. The wallet password is
+// read from the BTFS_WALLET_PASSWORD environment variable.
+func UnlockRepo(ctx context.Context, r repo.Repo) error {
+	c, err := repocrypt.Load(r)
+	if err != nil || !c.Enabled || repocrypt.Default.Unlocked() {
+		return err
+	}
+	password := os.Getenv(repocrypt.PasswordEnv)
+	if c.KeySource == repocrypt.PasswordSource && password == "" {
+		return errors.New("the repo is encrypted, set " + repocrypt.PasswordEnv + " to the wallet password")
+	}
+	w, err := repoCryptWrapper(r, c.KeySource, password)
+	if err != nil {
+		return err
+	}
+	if err := repocrypt.Default.Unlock(ctx, r, w); err != nil {
+		return fmt.Errorf("cannot unlock the encrypted repo: %s", err)
+	}
+	return nil
+}
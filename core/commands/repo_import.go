@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/ipfsimport"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	importIpfsPathOptionName    = "path"
+	importIpfsMfsPathOptionName = "mfs-path"
+	importIpfsPinsOptionName    = "pins"
+	importIpfsKeysOptionName    = "keys"
+)
+
+// ImportIpfsOutput is the progress, then the result, of an import.
+type ImportIpfsOutput struct {
+	Progress *ipfsimport.Progress `json:",omitempty"`
+	Result   *ipfsimport.Result   `json:",omitempty"`
+}
+
+var repoImportIpfsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Import the blocks, pins, files and keys of a go-ipfs repo.",
+		ShortDescription: `
+'btfs repo import-ipfs' migrates the content of a go-ipfs repo into the BTFS
+repo, ~/.ipfs or $IPFS_PATH by default:
+
+  blocks  every block, the ones already in the BTFS repo are skipped
+  pins    the recursive and direct pins, without expiration
+  files   the MFS root, placed at --mfs-path instead of the BTFS root
+  keys    the keys of the keystore, and the identity as 'ipfs-self'
+
+Repos of versions 7 to 11 are read, from go-ipfs 0.4.17 to 0.11, whatever
+their datastore (flatfs, leveldb or badger). The go-ipfs daemon must be
+stopped. Keys whose name is taken are skipped and listed, an import can be
+run again after a failure.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(importIpfsPathOptionName, "Path of the go-ipfs repo, ~/.ipfs or $IPFS_PATH by default."),
+		cmds.StringOption(importIpfsMfsPathOptionName, "Where to place the go-ipfs files, empty to skip them.").WithDefault("/ipfs"),
+		cmds.BoolOption(importIpfsPinsOptionName, "Import the pins.").WithDefault(true),
+		cmds.BoolOption(importIpfsKeysOptionName, "Import the keys.").WithDefault(true),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		p, _ := req.Options[importIpfsPathOptionName].(string)
+		if p == "" {
+			if p, err = ipfsimport.DefaultPath(); err != nil {
+				return err
+			}
+		}
+		src, err := ipfsimport.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		mfsPath, _ := req.Options[importIpfsMfsPathOptionName].(string)
+		pins, _ := req.Options[importIpfsPinsOptionName].(bool)
+		keys, _ := req.Options[importIpfsKeysOptionName].(bool)
+		opts := ipfsimport.Options{MfsPath: mfsPath, Pins: pins, Keys: keys}
+		dst := &ipfsimport.Target{
+			Blockstore: n.Blockstore,
+			Pinner:     n.Pinning,
+			Keystore:   n.Repo.Keystore(),
+			DAG:        n.DAG,
+			FilesRoot:  n.FilesRoot,
+		}
+
+		// keep the garbage collection away from the blocks until pinned
+		defer n.Blockstore.PinLock().Unlock()
+		result, err := ipfsimport.Import(req.Context, src, dst, opts, func(p ipfsimport.Progress) {
+			_ = res.Emit(&ImportIpfsOutput{Progress: &p})
+		})
+		if err != nil {
+			return err
+		}
+		return res.Emit(&ImportIpfsOutput{Result: result})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ImportIpfsOutput) error {
+			if p := out.Progress; p != nil {
+				fmt.Fprintf(w, "%d blocks copied\n", p.Blocks)
+				return nil
+			}
+			r := out.Result
+			fmt.Fprintf(w, "blocks: %d copied, %d already present\n", r.Blocks, r.BlocksSkipped)
+			fmt.Fprintf(w, "pins: %d\n", r.Pins)
+			if len(r.PinsSkipped) > 0 {
+				fmt.Fprintf(w, "pins skipped, root block missing: %s\n", strings.Join(r.PinsSkipped, ", "))
+			}
+			if r.FilesRoot != "" {
+				fmt.Fprintf(w, "files: %s\n", r.FilesRoot)
+			}
+			if len(r.Keys) > 0 {
+				fmt.Fprintf(w, "keys: %s\n", strings.Join(r.Keys, ", "))
+			}
+			if len(r.KeysSkipped) > 0 {
+				fmt.Fprintf(w, "keys skipped, name taken: %s\n", strings.Join(r.KeysSkipped, ", "))
+			}
+			return nil
+		}),
+	},
+	Type: ImportIpfsOutput{},
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/maintenance"
+	"github.com/TRON-US/go-btfs/repo"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	maintenanceTasksOptionName   = "tasks"
+	maintenanceWindowOptionName  = "window"
+	maintenanceLatencyOptionName = "max-read-latency"
+)
+
+// MaintenanceOutput is the progress, then the result, of a maintenance
+// run.
+type MaintenanceOutput struct {
+	Progress *maintenance.Progress `json:",omitempty"`
+	Result   *maintenance.Result   `json:",omitempty"`
+}
+
+// MaintenanceSchedule is the scheduled maintenance and the last run.
+type MaintenanceSchedule struct {
+	Window         string
+	Tasks          []string
+	MaxReadLatency string
+	LastRun        *maintenance.LastRun `json:",omitempty"`
+}
+
+var repoMaintenanceCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Compact the datastore and scrub the blocks of the repo.",
+		ShortDescription: `
+The maintenance runs these tasks, in this order:
+
+  archive  archives the storage contracts final for ContractArchive.After (720h)
+  compact  compacts the datastore backends supporting it (badger)
+  scrub    checks every block against its hash and lists the corrupt ones
+  reindex  drops the filestore entries whose file changed or is gone
+
+On a storage host, the maintenance stops when reading the shards challenged
+by guards gets slower than Maintenance.MaxReadLatency (500ms by default).
+'btfs repo maintenance schedule' sets a daily window the daemon runs it in.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"run":      repoMaintenanceRunCmd,
+		"schedule": repoMaintenanceScheduleCmd,
+	},
+}
+
+func splitTasks(s string) []string {
+	var tasks []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+var repoMaintenanceRunCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Run the repo maintenance now.",
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(maintenanceTasksOptionName, "Comma separated tasks to run, all by default."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := maintenance.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		names := cfg.Tasks
+		if s, ok := req.Options[maintenanceTasksOptionName].(string); ok {
+			names = splitTasks(s)
+		}
+		tasks, err := maintenance.ParseTasks(names)
+		if err != nil {
+			return err
+		}
+		latency, err := cfg.ReadLatency()
+		if err != nil {
+			return err
+		}
+
+		last := &maintenance.LastRun{Start: time.Now(), Tasks: tasks}
+		result, err := maintenance.ForNode(n, latency).Run(req.Context, tasks, func(p maintenance.Progress) {
+			// the run goes on if the client is gone
+			_ = res.Emit(&MaintenanceOutput{Progress: &p})
+		})
+		last.End = time.Now()
+		if err != nil {
+			last.Error = err.Error()
+		}
+		if perr := maintenance.PutLastRun(n.Repo.Datastore(), n.Identity.Pretty(), last); perr != nil {
+			log.Errorf("cannot record the maintenance run: %s", perr)
+		}
+		if err != nil {
+			return err
+		}
+		return res.Emit(&MaintenanceOutput{Result: result})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *MaintenanceOutput) error {
+			if p := out.Progress; p != nil {
+				switch {
+				case p.Msg != "":
+					fmt.Fprintf(w, "%s: %s\n", p.Task, p.Msg)
+				case p.Total > 0 && p.Done == p.Total:
+					fmt.Fprintf(w, "%s: done, %d processed\n", p.Task, p.Done)
+				default:
+					fmt.Fprintf(w, "%s: %d/%d\n", p.Task, p.Done, p.Total)
+				}
+				return nil
+			}
+			r := out.Result
+			if r.Archived > 0 {
+				fmt.Fprintf(w, "%d storage contracts archived\n", r.Archived)
+			}
+			if r.Reclaimed > 0 {
+				fmt.Fprintf(w, "compaction reclaimed %s\n", humanize.Bytes(uint64(r.Reclaimed)))
+			}
+			fmt.Fprintf(w, "%d corrupt blocks, %d filestore entries dropped\n", len(r.Corrupt), r.Dropped)
+			return nil
+		}),
+	},
+	Type: MaintenanceOutput{},
+}
+
+var repoMaintenanceScheduleCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show or set the daily maintenance window.",
+		ShortDescription: `
+Without options, shows the maintenance schedule and the last run. The
+options change it, the daemon picks it up within ten minutes:
+
+  > btfs repo maintenance schedule --window 02:00-05:00 --tasks compact,scrub
+
+The window is in local time. The daemon runs the maintenance once a day in
+it, and stops it at the end of the window. An empty --window unschedules
+it.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(maintenanceWindowOptionName, "Daily time range to run in, HH:MM-HH:MM."),
+		cmds.StringOption(maintenanceTasksOptionName, "Comma separated tasks to run, all by default."),
+		cmds.StringOption(maintenanceLatencyOptionName, "Stop when reading challenged shards gets slower, e.g. 500ms."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := maintenance.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		changed := false
+		if s, ok := req.Options[maintenanceWindowOptionName].(string); ok {
+			cfg.Window, changed = s, true
+		}
+		if s, ok := req.Options[maintenanceTasksOptionName].(string); ok {
+			cfg.Tasks, changed = splitTasks(s), true
+		}
+		if s, ok := req.Options[maintenanceLatencyOptionName].(string); ok {
+			cfg.MaxReadLatency, changed = s, true
+		}
+		if changed {
+			if err := cfg.Validate(); err != nil {
+				return err
+			}
+			if err := repo.SetConfigSection(n.Repo, maintenance.ConfigKey, cfg); err != nil {
+				return err
+			}
+		}
+
+		tasks, _ := maintenance.ParseTasks(cfg.Tasks)
+		latency, _ := cfg.ReadLatency()
+		last, err := maintenance.GetLastRun(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &MaintenanceSchedule{
+			Window:         cfg.Window,
+			Tasks:          tasks,
+			MaxReadLatency: latency.String(),
+			LastRun:        last,
+		})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *MaintenanceSchedule) error {
+			if out.Window == "" {
+				fmt.Fprintln(w, "window: none, the maintenance is not scheduled")
+			} else {
+				fmt.Fprintf(w, "window: %s\n", out.Window)
+			}
+			fmt.Fprintf(w, "tasks: %s\n", strings.Join(out.Tasks, ", "))
+			fmt.Fprintf(w, "max read latency: %s\n", out.MaxReadLatency)
+			if l := out.LastRun; l != nil {
+				status := "ok"
+				if l.Error != "" {
+					status = l.Error
+				}
+				fmt.Fprintf(w, "last run: %s, %s (%s): %s\n", l.Start.Format(time.RFC3339),
+					l.End.Sub(l.Start).Round(time.Second), strings.Join(l.Tasks, ", "), status)
+			}
+			return nil
+		}),
+	},
+	Type: MaintenanceSchedule{},
+}
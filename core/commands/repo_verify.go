@@ -0,0 +1,318 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/repoverify"
+	"github.com/TRON-US/go-btfs/repo/repocrypt"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	path "github.com/TRON-US/interface-go-btfs-core/path"
+	humanize "github.com/dustin/go-humanize"
+	cid "github.com/ipfs/go-cid"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+const (
+	verifyPinnedOnlyOptionName    = "pinned-only"
+	verifyContractsOnlyOptionName = "contracts-only"
+	verifyRateOptionName          = "rate"
+	verifyRepairOptionName        = "repair"
+
+	// verifyFetchTimeout bounds fetching a block to repair from the network.
+	verifyFetchTimeout = time.Minute
+)
+
+type VerifyProgress struct {
+	Msg      string
+	Progress int
+	// Problem is the block failing verification Msg reports.
+	Problem *repoverify.Problem `json:",omitempty"`
+}
+
+var repoVerifyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Verify all blocks in repo are not corrupted.",
+		ShortDescription: `
+'btfs repo verify' reads every block of the repo and checks it against its
+hash. --pinned-only checks the blocks of the pinned objects instead, and
+--contracts-only the shards of the active host contracts, both reporting
+the blocks missing from them too.
+
+--rate bounds the bytes read per second, e.g. 20MB, to verify the repo of a
+node while it serves.
+
+--repair drops the corrupt blocks and fetches them again from the network,
+with the missing ones. With --contracts-only, the blocks of a contract shard
+no peer has are rebuilt from the other shards of its file.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(verifyPinnedOnlyOptionName, "Verify only the blocks of the pinned objects."),
+		cmds.BoolOption(verifyContractsOnlyOptionName, "Verify only the shards of the active host contracts."),
+		cmds.StringOption(verifyRateOptionName, "Bytes read per second at most, e.g. 20MB, unbounded by default."),
+		cmds.BoolOption(verifyRepairOptionName, "Fetch again or rebuild the corrupt and missing blocks."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		pinned, _ := req.Options[verifyPinnedOnlyOptionName].(bool)
+		contractsOnly, _ := req.Options[verifyContractsOnlyOptionName].(bool)
+		if pinned && contractsOnly {
+			return fmt.Errorf("--%s and --%s are exclusive", verifyPinnedOnlyOptionName, verifyContractsOnlyOptionName)
+		}
+		var rate uint64
+		if s, ok := req.Options[verifyRateOptionName].(string); ok {
+			if rate, err = humanize.ParseBytes(s); err != nil {
+				return fmt.Errorf("invalid --%s %q: %s", verifyRateOptionName, s, err)
+			}
+		}
+		repair, _ := req.Options[verifyRepairOptionName].(bool)
+
+		raw := bstore.NewBlockstore(repocrypt.NewDatastore(nd.Repo.Datastore(), repocrypt.Default))
+		bs := bstore.NewBlockstore(repocrypt.NewDatastore(nd.Repo.Datastore(), repocrypt.Default))
+		bs.HashOnRead(true)
+
+		var (
+			blocks   <-chan repoverify.Block
+			problems []*repoverify.Problem
+			shards   map[string]*nodepb.Contracts_Contract
+		)
+		switch {
+		case pinned:
+			rkeys, err := nd.Pinning.RecursiveKeys(req.Context)
+			if err != nil {
+				return err
+			}
+			dkeys, err := nd.Pinning.DirectKeys(req.Context)
+			if err != nil {
+				return err
+			}
+			blocks, problems, err = reachableBlocks(req.Context, raw, rkeys, dkeys)
+			if err != nil {
+				return err
+			}
+		case contractsOnly:
+			shards, err = activeShards(nd)
+			if err != nil {
+				return err
+			}
+			roots := make([]cid.Cid, 0, len(shards))
+			for h := range shards {
+				if c, err := cid.Decode(h); err == nil {
+					roots = append(roots, c)
+				}
+			}
+			blocks, problems, err = reachableBlocks(req.Context, raw, roots, nil)
+			if err != nil {
+				return err
+			}
+		default:
+			blocks, err = repoverify.All(req.Context, raw)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+		}
+		for _, p := range problems {
+			if err := res.Emit(&VerifyProgress{Msg: problemMsg(p), Problem: p}); err != nil {
+				return err
+			}
+		}
+
+		ctx, cancel := context.WithCancel(req.Context)
+		defer cancel()
+		var i int
+		var emitErr error
+		err = repoverify.Verify(ctx, bs, blocks, repoverify.Options{Rate: int64(rate)}, func(b repoverify.Block, p *repoverify.Problem) {
+			i++
+			if p != nil {
+				problems = append(problems, p)
+				emitErr = res.Emit(&VerifyProgress{Msg: problemMsg(p), Problem: p})
+			}
+			if emitErr == nil {
+				emitErr = res.Emit(&VerifyProgress{Progress: i})
+			}
+			if emitErr != nil {
+				cancel()
+			}
+		})
+		if emitErr != nil {
+			return emitErr
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(problems) != 0 && repair {
+			if shards == nil {
+				if shards, err = activeShards(nd); err != nil {
+					return err
+				}
+			}
+			api, err := cmdenv.GetApi(env, req)
+			if err != nil {
+				return err
+			}
+			left := repairBlocks(req.Context, nd, api, bs, problems, shards, func(msg string) {
+				_ = res.Emit(&VerifyProgress{Msg: msg})
+			})
+			if left != 0 {
+				return fmt.Errorf("verify complete, %d of %d bad blocks could not be repaired", left, len(problems))
+			}
+			return res.Emit(&VerifyProgress{Msg: fmt.Sprintf("verify complete, %d bad blocks repaired.", len(problems))})
+		}
+		if len(problems) != 0 {
+			return errors.New("verify complete, some blocks were corrupt")
+		}
+
+		return res.Emit(&VerifyProgress{Msg: "verify complete, all blocks validated."})
+	},
+	Type: &VerifyProgress{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, obj *VerifyProgress) error {
+			if obj.Problem != nil {
+				fmt.Fprintln(os.Stdout, obj.Msg)
+				return nil
+			}
+
+			if obj.Msg != "" {
+				if len(obj.Msg) < 20 {
+					obj.Msg += "             "
+				}
+				fmt.Fprintln(w, obj.Msg)
+				return nil
+			}
+
+			fmt.Fprintf(w, "%d blocks processed.\r", obj.Progress)
+			return nil
+		}),
+	},
+}
+
+func problemMsg(p *repoverify.Problem) string {
+	if p.Missing {
+		return fmt.Sprintf("block %s is missing", p.Cid)
+	}
+	return fmt.Sprintf("block %s was corrupt (%s)", p.Cid, p.Err)
+}
+
+// reachableBlocks returns the blocks reachable from the recursive and the
+// direct roots, and the problems of the missing ones.
+func reachableBlocks(ctx context.Context, bs bstore.Blockstore,
+	recursive, direct []cid.Cid) (<-chan repoverify.Block, []*repoverify.Problem, error) {
+	bl, problems, err := repoverify.Reachable(ctx, bs, recursive, direct)
+	if err != nil {
+		return nil, nil, err
+	}
+	blocks := make(chan repoverify.Block, len(bl))
+	for _, b := range bl {
+		blocks <- b
+	}
+	close(blocks)
+	return blocks, problems, nil
+}
+
+// activeShards returns the active host contracts of n by shard hash.
+func activeShards(n *core.IpfsNode) (map[string]*nodepb.Contracts_Contract, error) {
+	cs, err := contracts.ListContracts(n.Repo.Datastore(), n.Identity.Pretty(), nodepb.ContractStat_HOST.String())
+	if err != nil {
+		return nil, err
+	}
+	shards := make(map[string]*nodepb.Contracts_Contract)
+	for _, c := range cs {
+		if helper.ContractFilterMap["active"][c.Status] {
+			shards[c.ShardHash] = c
+		}
+	}
+	return shards, nil
+}
+
+// repairBlocks drops the corrupt blocks of problems and fetches them again
+// with the missing ones, rebuilding the contract shards no peer has. It
+// returns the number of blocks left unrepaired.
+func repairBlocks(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, bs bstore.Blockstore,
+	problems []*repoverify.Problem, shards map[string]*nodepb.Contracts_Contract, report func(string)) int {
+	verified := func(c cid.Cid) bool {
+		_, err := bs.Get(c)
+		return err == nil
+	}
+	rebuilt := make(map[string]error)
+	var left int
+	for _, p := range problems {
+		if !p.Missing {
+			if err := n.Blockstore.DeleteBlock(p.Cid); err != nil {
+				report(fmt.Sprintf("cannot drop corrupt block %s: %s", p.Cid, err))
+				left++
+				continue
+			}
+		}
+		var errs []string
+		if n.IsOnline {
+			fctx, cancel := context.WithTimeout(ctx, verifyFetchTimeout)
+			_, err := n.Blocks.GetBlock(fctx, p.Cid)
+			cancel()
+			if err == nil && verified(p.Cid) {
+				report(fmt.Sprintf("block %s repaired from the network", p.Cid))
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+		} else {
+			errs = append(errs, "the node is offline")
+		}
+		if c, ok := shards[rootKey(p)]; ok {
+			err, done := rebuilt[c.ShardHash]
+			if !done {
+				err = rebuildShard(ctx, api, p.Root, c)
+				rebuilt[c.ShardHash] = err
+				if err == nil {
+					report(fmt.Sprintf("shard %s of contract %s rebuilt", c.ShardHash, c.ContractId))
+				}
+			}
+			if err == nil && verified(p.Cid) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, "rebuilding the shard: "+err.Error())
+			}
+		}
+		report(fmt.Sprintf("cannot repair block %s: %s", p.Cid, strings.Join(errs, "; ")))
+		left++
+	}
+	return left
+}
+
+// rootKey is the root p was reached from, empty when unknown.
+func rootKey(p *repoverify.Problem) string {
+	if !p.Root.Defined() {
+		return ""
+	}
+	return p.Root.String()
+}
+
+// rebuildShard rebuilds the shard of the host contract c from the other
+// shards of its file.
+func rebuildShard(ctx context.Context, api coreiface.CoreAPI, shard cid.Cid, c *nodepb.Contracts_Contract) error {
+	f, err := api.Unixfs().Get(ctx, path.New(c.FileHash), options.Unixfs.Repairs([]cid.Cid{shard}))
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -8,7 +8,15 @@ import (
 	name "github.com/TRON-US/go-btfs/core/commands/name"
 	ocmd "github.com/TRON-US/go-btfs/core/commands/object"
 	"github.com/TRON-US/go-btfs/core/commands/storage"
+	"github.com/TRON-US/go-btfs/core/commands/storage/bandwidth"
+	"github.com/TRON-US/go-btfs/core/commands/storage/capacity"
 	"github.com/TRON-US/go-btfs/core/commands/storage/challenge"
+	"github.com/TRON-US/go-btfs/core/commands/storage/collateral"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/files"
+	"github.com/TRON-US/go-btfs/core/commands/storage/payer"
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+	"github.com/TRON-US/go-btfs/core/commands/storage/tenants"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/upload"
 	unixfs "github.com/TRON-US/go-btfs/core/commands/unixfs"
 
@@ -26,12 +34,13 @@ const (
 	LocalOption   = "local" // DEPRECATED: use OfflineOption
 	OfflineOption = "offline"
 	ApiOption     = "api"
+	RemoteOption  = "remote"
 )
 
 var Root = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline:  "Global p2p merkle-dag filesystem.",
-		Synopsis: "btfs [--config=<config> | -c] [--debug | -D] [--help] [-h] [--api=<api>] [--offline] [--cid-base=<base>] [--upgrade-cidv0-in-output] [--encoding=<encoding> | --enc] [--timeout=<timeout>] <command> ...",
+		Synopsis: "btfs [--config=<config> | -c] [--debug | -D] [--help] [-h] [--api=<api>] [--remote=<remote>] [--offline] [--cid-base=<base>] [--upgrade-cidv0-in-output] [--encoding=<encoding> | --enc] [--timeout=<timeout>] <command> ...",
 		Subcommands: `
 BASIC COMMANDS
   init          Initialize btfs local configuration
@@ -43,6 +52,7 @@ BASIC COMMANDS
 
 BTFS COMMANDS
   storage       Manage client and host storage features
+  publish-site  Deploy a static website
 
 DATA STRUCTURE COMMANDS
   block         Interact with raw blocks in the datastore
@@ -57,12 +67,19 @@ ADVANCED COMMANDS
   resolve       Resolve any type of name
   name          Publish and resolve BTNS names
   key           Create and list BTNS name keypairs
+  keys          Manage data encryption keys
   dns           Resolve DNS links
   pin           Pin objects to local storage
   repo          Manipulate the BTFS repository
   stats         Various operational stats
   p2p           Libp2p stream mounting
   filestore     Manage the filestore (experimental)
+  top           Show a live dashboard of the node
+  users         Manage the accounts of a shared node
+  apps          Manage the namespaces of applications
+  alerts        Notify the alerts of the node by email, Telegram or Slack
+  paywall       Charge BTT for the content served by the gateway
+  events        Print the events of the daemon as they happen
 
 NETWORK COMMANDS
   id            Show info about BTFS peers
@@ -71,6 +88,8 @@ NETWORK COMMANDS
   dht           Query the DHT for values or peers
   ping          Measure the latency of a connection
   diag          Print diagnostics
+  doctor        Diagnose common misconfigurations
+  power-mode    Reduce the background activity of the node
 
 TOOL COMMANDS
   config        Manage configuration
@@ -78,6 +97,10 @@ TOOL COMMANDS
   commands      List all available commands
   cid           Convert and discover properties of CIDs
   log           Manage and show logs of running daemon
+  alias         Manage command aliases
+  update        Manage the auto-update channel and roll back updates
+  remote        Manage the remote daemons the CLI runs commands on
+  fleet         Upgrade the nodes of a fleet in waves
 
 Use 'btfs <command> --help' to learn more about each command.
 
@@ -93,6 +116,18 @@ The CLI will exit with one of the following values:
 
 0     Successful execution.
 1     Failed executions.
+2     Authentication failure.
+3     Not found.
+4     Insufficient funds.
+5     Network error.
+6     Conflict, e.g. the repo is locked by another process.
+7     Timed out.
+
+TIMEOUTS
+
+--timeout=<duration> (e.g. 30s, 5m) limits the time a command may run. The
+commands calling remote services time out by default: wallet after 2m,
+tron and guard after 1m, storage after 5m unless they set their own.
 `,
 	},
 	Options: []cmds.Option{
@@ -103,6 +138,7 @@ The CLI will exit with one of the following values:
 		cmds.BoolOption(LocalOption, "L", "Run the command locally, instead of using the daemon. DEPRECATED: use --offline."),
 		cmds.BoolOption(OfflineOption, "Run the command offline."),
 		cmds.StringOption(ApiOption, "Use a specific API instance (defaults to /ip4/127.0.0.1/tcp/5001)"),
+		cmds.StringOption(RemoteOption, "Use the API instance of a remote added with 'btfs remote add'."),
 
 		// global options, added to every command
 		cmdenv.OptionCidBase,
@@ -118,50 +154,66 @@ The CLI will exit with one of the following values:
 var CommandsDaemonCmd = CommandsCmd(Root)
 
 var rootSubcommands = map[string]*cmds.Command{
-	"add":       AddCmd,
-	"bitswap":   BitswapCmd,
-	"block":     BlockCmd,
-	"cat":       CatCmd,
-	"commands":  CommandsDaemonCmd,
-	"files":     FilesCmd,
-	"filestore": FileStoreCmd,
-	"get":       GetCmd,
-	"pubsub":    PubsubCmd,
-	"repo":      RepoCmd,
-	"stats":     StatsCmd,
-	"bootstrap": BootstrapCmd,
-	"config":    ConfigCmd,
-	"dag":       dag.DagCmd,
-	"dht":       DhtCmd,
-	"diag":      DiagCmd,
-	"dns":       DNSCmd,
-	"id":        IDCmd,
-	"key":       KeyCmd,
-	"log":       LogCmd,
-	"ls":        LsCmd,
-	"mount":     MountCmd,
-	"name":      name.NameCmd,
-	"object":    ocmd.ObjectCmd,
-	"pin":       PinCmd,
-	"ping":      PingCmd,
-	"p2p":       P2PCmd,
-	"refs":      RefsCmd,
-	"resolve":   ResolveCmd,
-	"swarm":     SwarmCmd,
-	"tar":       TarCmd,
-	"file":      unixfs.UnixFSCmd,
-	"urlstore":  urlStoreCmd,
-	"version":   VersionCmd,
-	"shutdown":  daemonShutdownCmd,
-	"restart":   restartCmd,
-	"cid":       CidCmd,
-	"rm":        RmCmd,
-	"storage":   storage.StorageCmd,
-	"metadata":  MetadataCmd,
-	"guard":     GuardCmd,
-	"wallet":    WalletCmd,
-	"tron":      TronCmd,
-	//"update":    ExternalBinary(),
+	"add":          AddCmd,
+	"bitswap":      BitswapCmd,
+	"block":        BlockCmd,
+	"cat":          CatCmd,
+	"commands":     CommandsDaemonCmd,
+	"files":        FilesCmd,
+	"filestore":    FileStoreCmd,
+	"get":          GetCmd,
+	"pubsub":       PubsubCmd,
+	"repo":         RepoCmd,
+	"stats":        StatsCmd,
+	"bootstrap":    BootstrapCmd,
+	"config":       ConfigCmd,
+	"dag":          dag.DagCmd,
+	"dht":          DhtCmd,
+	"diag":         DiagCmd,
+	"dns":          DNSCmd,
+	"events":       EventsCmd,
+	"id":           IDCmd,
+	"key":          KeyCmd,
+	"keys":         KeysCmd,
+	"log":          LogCmd,
+	"ls":           LsCmd,
+	"mount":        MountCmd,
+	"name":         name.NameCmd,
+	"object":       ocmd.ObjectCmd,
+	"pin":          PinCmd,
+	"ping":         PingCmd,
+	"p2p":          P2PCmd,
+	"refs":         RefsCmd,
+	"resolve":      ResolveCmd,
+	"swarm":        SwarmCmd,
+	"tar":          TarCmd,
+	"users":        UsersCmd,
+	"apps":         AppsCmd,
+	"file":         unixfs.UnixFSCmd,
+	"urlstore":     urlStoreCmd,
+	"version":      VersionCmd,
+	"shutdown":     daemonShutdownCmd,
+	"restart":      restartCmd,
+	"remote":       RemoteCmd,
+	"fleet":        FleetCmd,
+	"alerts":       AlertsCmd,
+	"paywall":      PaywallCmd,
+	"cid":          CidCmd,
+	"rm":           RmCmd,
+	"storage":      storage.StorageCmd,
+	"publish-site": PublishSiteCmd,
+	"metadata":     MetadataCmd,
+	"guard":        GuardCmd,
+	"wallet":       WalletCmd,
+	"tron":         TronCmd,
+	"verify":       VerifyCmd,
+	"completion":   CompletionCmd,
+	"openapi":      OpenAPICmd,
+	"doctor":       DoctorCmd,
+	"alias":        AliasCmd,
+	"update":       UpdateCmd,
+	"top":          TopCmd,
+	"power-mode":   PowerModeCmd,
 }
 
 // RootRO is the readonly version of Root
@@ -213,6 +265,21 @@ var rootROSubcommands = map[string]*cmds.Command{
 var RootRemote = &cmds.Command{}
 
 var rootRemoteSubcommands = map[string]*cmds.Command{
+	"diag": &cmds.Command{
+		Subcommands: map[string]*cmds.Command{
+			"speedtest": DiagSpeedtestCmd,
+		},
+	},
+	"wallet": &cmds.Command{
+		Subcommands: map[string]*cmds.Command{
+			"relay": &cmds.Command{
+				Subcommands: map[string]*cmds.Command{
+					"terms":  WalletRelayTermsCmd,
+					"accept": WalletRelayAcceptCmd,
+				},
+			},
+		},
+	},
 	"storage": &cmds.Command{
 		Subcommands: map[string]*cmds.Command{
 			"challenge": &cmds.Command{
@@ -220,10 +287,51 @@ var rootRemoteSubcommands = map[string]*cmds.Command{
 					"response": challenge.StorageChallengeResponseCmd,
 				},
 			},
+			"contracts": &cmds.Command{
+				Subcommands: map[string]*cmds.Command{
+					"quote": contracts.StorageContractsQuoteCmd,
+				},
+			},
+			"probe": &cmds.Command{
+				Subcommands: map[string]*cmds.Command{
+					"read": probe.StorageProbeReadCmd,
+				},
+			},
+			"collateral": &cmds.Command{
+				Subcommands: map[string]*cmds.Command{
+					"terms": collateral.StorageCollateralTermsCmd,
+					"post":  collateral.StorageCollateralPostCmd,
+				},
+			},
+			"bandwidth": &cmds.Command{
+				Subcommands: map[string]*cmds.Command{
+					"terms":  bandwidth.StorageBandwidthTermsCmd,
+					"accept": bandwidth.StorageBandwidthAcceptCmd,
+					"pay":    bandwidth.StorageBandwidthPayCmd,
+				},
+			},
+			"capacity": &cmds.Command{
+				Subcommands: map[string]*cmds.Command{
+					"plot":   capacity.StorageCapacityPlotCmd,
+					"status": capacity.StorageCapacityStatusCmd,
+					"prove":  capacity.StorageCapacityProveCmd,
+				},
+			},
+			"payer": &cmds.Command{
+				Subcommands: map[string]*cmds.Command{
+					"sign": payer.StoragePayerSignCmd,
+				},
+			},
+			"files": &cmds.Command{
+				Subcommands: map[string]*cmds.Command{
+					"prove": files.StorageFilesProveCmd,
+				},
+			},
 			"upload": &cmds.Command{
 				Subcommands: map[string]*cmds.Command{
 					"init":         upload.StorageUploadInitCmd,
 					"recvcontract": upload.StorageUploadRecvContractCmd,
+					"limits":       upload.StorageUploadLimitsCmd,
 				},
 			},
 		},
@@ -231,6 +339,13 @@ var rootRemoteSubcommands = map[string]*cmds.Command{
 }
 
 func init() {
+	// exporting decrypts with the data encryption keys of this package,
+	// which the storage package cannot import
+	storage.StorageCmd.Subcommands["export"] = StorageExportCmd
+	// paying out a tenant transfers from the wallet with the checks of
+	// this package
+	tenants.StorageHostTenantsCmd.Subcommands["payout"] = storageHostTenantsPayoutCmd
+
 	Root.ProcessHelp()
 	*RootRO = *Root
 	*RootRemote = *Root
@@ -251,9 +366,31 @@ func init() {
 	// also sanitize remote version command
 	rootRemoteSubcommands["version"] = VersionROCmd
 
+	// Machine-readable output for the commands scripts use the most
+	cmdenv.WithOutputOptions(WalletCmd)
+	cmdenv.WithOutputOptions(storage.StorageCmd)
+	cmdenv.WithOutputOptions(DiagCmd)
+
+	// Accounts of a shared node work in their own MFS namespace
+	cmdenv.WithNamespace(FilesCmd)
+
 	Root.Subcommands = rootSubcommands
 	RootRO.Subcommands = rootROSubcommands
 	RootRemote.Subcommands = rootRemoteSubcommands
+
+	for name, d := range defaultTimeouts {
+		setDefaultTimeouts(name, rootSubcommands[name], d)
+	}
+
+	// Error codes for the exit status of the CLI
+	cmdenv.WithErrorCodes(Root)
+	cmdenv.WithErrorCodes(RootRO)
+	cmdenv.WithErrorCodes(RootRemote)
+
+	// Audit log of the commands run through the API
+	cmdenv.WithAudit(Root)
+	cmdenv.WithAudit(RootRO)
+	cmdenv.WithAudit(RootRemote)
 }
 
 type MessageOutput struct {
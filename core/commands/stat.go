@@ -30,6 +30,7 @@ for your BTFS node.`,
 		"repo":    repoStatCmd,
 		"bitswap": bitswapStatCmd,
 		"dht":     statDhtCmd,
+		"history": statHistoryCmd,
 	},
 }
 
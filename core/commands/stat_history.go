@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/history"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	statHistoryMetricOptionName = "metric"
+	statHistoryRangeOptionName  = "range"
+)
+
+// StatHistoryOutput is the history of a metric, oldest sample first.
+type StatHistoryOutput struct {
+	Metric   string
+	Interval time.Duration
+	Samples  []*history.Sample
+}
+
+var statHistoryCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Print the history of node statistics.",
+		ShortDescription: `
+'btfs stats history' prints the samples of a node statistic taken by the
+daemon every 10 minutes and kept for 30 days.
+`,
+		LongDescription: `
+'btfs stats history' prints the samples of a node statistic taken by the
+daemon every 10 minutes and kept for 30 days, oldest first. Each metric has
+one or more series:
+
+  bw          total_in, total_out (bytes), rate_in, rate_out (bytes/s)
+  repo        size (bytes), objects
+  peers       count
+  contracts   active host contracts
+  earnings    paid, outstanding host compensation (µBTT)
+
+contracts and earnings are only sampled on storage hosts.
+
+Example:
+
+    > btfs stats history --metric peers --range 7d
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(statHistoryMetricOptionName, "m", "Metric to print: "+strings.Join(history.Metrics, ", ")+".").WithDefault(history.Bandwidth),
+		cmds.StringOption(statHistoryRangeOptionName, "r", "Time range to print, e.g. \"6h\" or \"7d\".").WithDefault("24h"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		metric, _ := req.Options[statHistoryMetricOptionName].(string)
+		if !history.IsMetric(metric) {
+			return cmds.Errorf(cmds.ErrClient, "unknown metric %q, must be one of %s",
+				metric, strings.Join(history.Metrics, ", "))
+		}
+		rangeStr, _ := req.Options[statHistoryRangeOptionName].(string)
+		d, err := parseHistoryRange(rangeStr)
+		if err != nil {
+			return cmds.Errorf(cmds.ErrClient, "%s", err)
+		}
+
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		samples, err := history.Query(n.Repo.Datastore(), n.Identity.Pretty(), metric, time.Now().Add(-d))
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &StatHistoryOutput{
+			Metric:   metric,
+			Interval: history.Interval,
+			Samples:  samples,
+		})
+	},
+	Type: StatHistoryOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *StatHistoryOutput) error {
+			renderStatHistory(w, out)
+			return nil
+		}),
+	},
+}
+
+// parseHistoryRange parses a duration, also accepting days, e.g. "7d".
+func parseHistoryRange(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --%s %q, expected a positive duration like \"6h\" or \"7d\"",
+			statHistoryRangeOptionName, s)
+	}
+	return d, nil
+}
+
+func renderStatHistory(w io.Writer, out *StatHistoryOutput) {
+	// Series may appear over time, e.g. once the node becomes a host.
+	seen := map[string]bool{}
+	var series []string
+	for _, s := range out.Samples {
+		for name := range s.Values {
+			if !seen[name] {
+				seen[name] = true
+				series = append(series, name)
+			}
+		}
+	}
+	sort.Strings(series)
+
+	tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "TIME")
+	for _, name := range series {
+		fmt.Fprintf(tw, "\t%s", strings.ToUpper(name))
+	}
+	fmt.Fprintln(tw)
+	for _, s := range out.Samples {
+		fmt.Fprint(tw, s.Time.Format("2006-01-02 15:04"))
+		for _, name := range series {
+			if v, ok := s.Values[name]; ok {
+				fmt.Fprintf(tw, "\t%s", strconv.FormatFloat(v, 'f', -1, 64))
+			} else {
+				fmt.Fprint(tw, "\t-")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/history"
+)
+
+func TestParseHistoryRange(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"6h":  6 * time.Hour,
+		"90m": 90 * time.Minute,
+	} {
+		if d, err := parseHistoryRange(s); err != nil || d != want {
+			t.Errorf("parseHistoryRange(%q) = %s, %v", s, d, err)
+		}
+	}
+	for _, s := range []string{"", "d", "-1d", "0h", "week"} {
+		if _, err := parseHistoryRange(s); err == nil {
+			t.Errorf("parseHistoryRange(%q) succeeded", s)
+		}
+	}
+}
+
+func TestRenderStatHistory(t *testing.T) {
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	renderStatHistory(&b, &StatHistoryOutput{
+		Metric: history.Earnings,
+		Samples: []*history.Sample{
+			{Time: start, Values: map[string]float64{"paid": 10}},
+			{Time: start.Add(history.Interval), Values: map[string]float64{"paid": 12, "outstanding": 2.5}},
+		},
+	})
+	want := `TIME              OUTSTANDING  PAID
+2020-06-01 12:00  -            10
+2020-06-01 12:10  2.5          12
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -2,6 +2,8 @@ package announce
 
 import (
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
@@ -9,6 +11,7 @@ import (
 	cmds "github.com/TRON-US/go-btfs-cmds"
 
 	"github.com/alecthomas/units"
+	humanize "github.com/dustin/go-humanize"
 )
 
 const (
@@ -31,6 +34,8 @@ const (
 	challengePriceCustomizedOptionName   = "challenge-price-customized"
 	challengeCustomizedPricingOptionName = "challenge-customized-pricing"
 
+	nowOptionName = "now"
+
 	bttTotalSupply uint64 = 990_000_000_000
 )
 
@@ -43,7 +48,12 @@ This command updates host information and broadcasts to the BTFS network.
 Examples
 
 To set the min price per GiB to 1000000 µBTT (1 BTT):
-$ btfs storage announce --host-storage-price=1000000`,
+$ btfs storage announce --host-storage-price=1000000
+
+The host settings are announced periodically, and right after the daemon
+starts when they changed since the last announcement. Use --now to announce
+them immediately, e.g. after changing the prices:
+$ btfs storage announce --host-storage-price=1000000 --now`,
 	},
 	Options: []cmds.Option{
 		cmds.Uint64Option(hostStoragePriceOptionName, "s", "Min price per GiB of storage per day in µBTT."),
@@ -62,6 +72,7 @@ $ btfs storage announce --host-storage-price=1000000`,
 		cmds.Uint64Option(repairPriceCustomizedOptionName, "rpc", "Customized repair price provides by enabled Host."),
 		cmds.Uint64Option(challengePriceDefaultOptionName, "cpd", "Host challenge default price refer to market."),
 		cmds.Uint64Option(challengePriceCustomizedOptionName, "cpc", "Customized challenge price provides by enabled Host."),
+		cmds.BoolOption(nowOptionName, "Announce the host settings now instead of at the next periodic announcement.").WithDefault(false),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		cfg, err := cmdenv.GetConfig(env)
@@ -189,6 +200,21 @@ $ btfs storage announce --host-storage-price=1000000`,
 			return err
 		}
 
+		if now, _ := req.Options[nowOptionName].(bool); now {
+			a, err := Now(req.Context)
+			if err != nil {
+				return err
+			}
+			return cmds.EmitOnce(res, a)
+		}
 		return nil
 	},
+	Type: Announcement{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a *Announcement) error {
+			_, err := fmt.Fprintf(w, "Announced at %s: storage price %d µBTT, capacity %s.\n",
+				a.Time.Format(time.RFC3339), a.Settings.StoragePriceAsk, humanize.Bytes(a.StorageVolumeCap))
+			return err
+		}),
+	},
 }
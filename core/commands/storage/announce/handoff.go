@@ -0,0 +1,92 @@
+package announce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+const lastAnnouncementKey = "/btfs/%s/announce/last"
+
+// Announcement is what a host announced to the network: its prices,
+// capacity and roles.
+type Announcement struct {
+	Time             time.Time
+	StorageVolumeCap uint64
+	Settings         *nodepb.Node_Settings
+}
+
+// Stale reports whether the prices of the settings ns differ from what a
+// announced, true when a is nil as nothing was announced.
+func (a *Announcement) Stale(ns *nodepb.Node_Settings) bool {
+	if a == nil || a.Settings == nil {
+		return true
+	}
+	s := a.Settings
+	return s.StoragePriceAsk != ns.StoragePriceAsk ||
+		s.BandwidthPriceAsk != ns.BandwidthPriceAsk ||
+		s.CollateralStake != ns.CollateralStake ||
+		s.BandwidthLimit != ns.BandwidthLimit ||
+		s.StorageTimeMin != ns.StorageTimeMin ||
+		s.CustomizedPricing != ns.CustomizedPricing ||
+		s.RepairPriceCustomized != ns.RepairPriceCustomized ||
+		s.RepairCustomizedPricing != ns.RepairCustomizedPricing ||
+		s.ChallengePriceCustomized != ns.ChallengePriceCustomized ||
+		s.ChallengeCustomizedPricing != ns.ChallengeCustomizedPricing
+}
+
+// LastAnnouncement returns the last announcement of the host peerID, nil
+// when it never announced.
+func LastAnnouncement(d ds.Datastore, peerID string) (*Announcement, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(lastAnnouncementKey, peerID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a := &Announcement{}
+	if err := json.Unmarshal(b, a); err != nil {
+		return nil, fmt.Errorf("invalid last announcement: %s", err)
+	}
+	return a, nil
+}
+
+// SaveAnnouncement records a as the last announcement of the host peerID.
+func SaveAnnouncement(d ds.Datastore, peerID string, a *Announcement) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(lastAnnouncementKey, peerID)), b)
+}
+
+var (
+	announcerMu sync.Mutex
+	announcer   func(context.Context) (*Announcement, error)
+)
+
+// RegisterAnnouncer sets f as the function announcing the host settings
+// to the network, run by Now.
+func RegisterAnnouncer(f func(context.Context) (*Announcement, error)) {
+	announcerMu.Lock()
+	defer announcerMu.Unlock()
+	announcer = f
+}
+
+// Now announces the host settings to the network without waiting for the
+// next periodic announcement.
+func Now(ctx context.Context) (*Announcement, error) {
+	announcerMu.Lock()
+	f := announcer
+	announcerMu.Unlock()
+	if f == nil {
+		return nil, fmt.Errorf("announcements are disabled, enable the storage host or analytics")
+	}
+	return f(ctx)
+}
@@ -0,0 +1,45 @@
+package announce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+func TestAnnouncement(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	last, err := LastAnnouncement(d, "self")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns := &nodepb.Node_Settings{StoragePriceAsk: 125000, StorageTimeMin: 30}
+	if !last.Stale(ns) {
+		t.Fatal("expected settings never announced to be stale")
+	}
+	a := &Announcement{Time: time.Now(), StorageVolumeCap: 1 << 30, Settings: ns}
+	if err := SaveAnnouncement(d, "self", a); err != nil {
+		t.Fatal(err)
+	}
+	if last, err = LastAnnouncement(d, "self"); err != nil {
+		t.Fatal(err)
+	}
+	if last.Stale(ns) || last.StorageVolumeCap != a.StorageVolumeCap {
+		t.Fatalf("unexpected last announcement %+v", last)
+	}
+	if !last.Stale(&nodepb.Node_Settings{StoragePriceAsk: 250000, StorageTimeMin: 30}) {
+		t.Fatal("expected a new price to be stale")
+	}
+
+	if _, err := Now(context.Background()); err == nil {
+		t.Fatal("expected no announcement without an announcer")
+	}
+	RegisterAnnouncer(func(ctx context.Context) (*Announcement, error) { return a, nil })
+	defer RegisterAnnouncer(nil)
+	if got, err := Now(context.Background()); err != nil || got != a {
+		t.Fatalf("unexpected announcement %+v, %v", got, err)
+	}
+}
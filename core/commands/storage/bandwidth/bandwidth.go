@@ -0,0 +1,579 @@
+// Package bandwidth has renters pay their hosts for the bytes they retrieve
+// as they retrieve them, instead of in the price of the contracts: a renter
+// opens a ledger channel to a host with a deposit, and signs states paying
+// the host more and more as bitswap meters the bytes the host sends it. The
+// host closes the channel with the latest state periodically, or at once
+// when the renter stops paying.
+package bandwidth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	config "github.com/TRON-US/go-btfs-config"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+
+	bitswap "github.com/ipfs/go-bitswap"
+	logging "github.com/ipfs/go-log"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var log = logging.Logger("storage/bandwidth")
+
+// callTimeout bounds a call to the peer of a channel.
+const callTimeout = 30 * time.Second
+
+var StorageBandwidthCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Pay the retrieval bandwidth of hosts through ledger channels.",
+		ShortDescription: `
+A host with Bandwidth.Enabled set charges Bandwidth.Price µBTT per GiB it
+sends to its renters:
+
+    $ btfs config --json Bandwidth '{"Enabled": true, "Price": 100000,
+        "Credit": 10000, "SettlePeriod": "24h"}'
+
+A renter opens a ledger channel to the host with a deposit, and the renter
+daemon pays the host every minute for the bytes received from it since the
+channel opened, as metered by bitswap, until the deposit is spent:
+
+    $ btfs storage bandwidth open <host-id> 1000000
+
+The host closes the channel with the latest payment after SettlePeriod, or
+once the deposit is paid; the renter then opens another channel. 'btfs
+storage bandwidth close' closes a channel at once.
+
+Disputes: the host closes the channel as disputed when the renter owes more
+than the Credit µBTT of its terms unpaid, and the renter marks a channel
+disputed when the host meters more than the credit past what the renter
+metered. Either side only ever closes the channel with the latest state
+signed by the renter.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"open":   storageBandwidthOpenCmd,
+		"close":  storageBandwidthCloseCmd,
+		"status": storageBandwidthStatusCmd,
+		"terms":  StorageBandwidthTermsCmd,
+		"accept": StorageBandwidthAcceptCmd,
+		"pay":    StorageBandwidthPayCmd,
+	},
+}
+
+var storageBandwidthOpenCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Open a bandwidth channel to a host.",
+		ShortDescription: `
+Commits deposit µBTT of the ledger of this node to a channel to the host,
+paying the bytes retrieved from the host at the price of its terms. The
+rest of the deposit is refunded when the channel closes.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("host-id", true, false, "Peer ID of the host."),
+		cmds.StringArg("deposit", true, false, "Deposit of the channel in µBTT."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		host, err := peer.Decode(req.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("invalid host ID %s: %s", req.Arguments[0], err)
+		}
+		deposit, err := strconv.ParseInt(req.Arguments[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid deposit: %s", err)
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		if c, err := OpenChannel(d, self, RoleRenter, host.Pretty()); err != nil {
+			return err
+		} else if c != nil {
+			return fmt.Errorf("channel %d to %s is open already", c.ChannelID, c.Host)
+		}
+		c, err := open(req.Context, n, api, cfg, host, deposit)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, c)
+	},
+	Type: Channel{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c *Channel) error {
+			fmt.Fprintf(w, "channel %d to %s opened with %d µBTT at %d µBTT per GiB\n", c.ChannelID, c.Host,
+				c.Deposit, c.Price)
+			return nil
+		}),
+	},
+}
+
+func open(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, cfg *config.Config, host peer.ID,
+	deposit int64) (*Channel, error) {
+	b, err := call(ctx, n, api, host, "/storage/bandwidth/terms")
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the bandwidth terms of %s: %s", host, err)
+	}
+	terms := &Terms{}
+	if err := json.Unmarshal(b, terms); err != nil {
+		return nil, err
+	}
+	if terms.Host != host.Pretty() || terms.Price <= 0 {
+		return nil, errors.New("invalid bandwidth terms")
+	}
+	privKey, err := cfg.Identity.DecodePrivateKey("")
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := host.ExtractPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	_, recv, err := counters(n, host)
+	if err != nil {
+		return nil, err
+	}
+	client := escrowclient.New(cfg.Services.EscrowDomain)
+	c, o, err := Open(ctx, client, privKey, hostKey, terms, n.Identity.Pretty(), deposit, recv, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	d, self := n.Repo.Datastore(), n.Identity.Pretty()
+	if err := PutChannel(d, self, c); err != nil {
+		return nil, err
+	}
+	if b, err = json.Marshal(o); err != nil {
+		return nil, err
+	}
+	if _, err := call(ctx, n, api, host, "/storage/bandwidth/accept", string(b)); err != nil {
+		if cerr := Close(ctx, client, c, nil, StateSettled, time.Now()); cerr != nil {
+			log.Errorf("refund bandwidth channel %d: %s", c.ChannelID, cerr)
+		}
+		if perr := PutChannel(d, self, c); perr != nil {
+			return nil, perr
+		}
+		return nil, fmt.Errorf("host %s did not accept the channel: %s", host, err)
+	}
+	return c, nil
+}
+
+var storageBandwidthCloseCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Close a bandwidth channel.",
+		ShortDescription: `
+Closes the channel with its latest payment. A renter first pays the host
+for the bytes metered since the last payment, a host collects the payments
+and refunds the rest of the deposit.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("channel-id", true, false, "ID of the channel."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseInt(req.Arguments[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid channel ID: %s", err)
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		c, err := GetChannel(d, self, id)
+		if err != nil {
+			return err
+		}
+		if c.State != StateOpen {
+			return fmt.Errorf("channel %d is %s", c.ChannelID, c.State)
+		}
+		privKey, err := cfg.Identity.DecodePrivateKey("")
+		if err != nil {
+			return err
+		}
+		if c.Role == RoleRenter {
+			if err := pay(req.Context, n, api, privKey, c); err != nil {
+				log.Warnf("pay bandwidth channel %d before closing: %s", c.ChannelID, err)
+			}
+			// the renter closes with its own signature only
+			privKey = nil
+		}
+		client := escrowclient.New(cfg.Services.EscrowDomain)
+		cerr := Close(req.Context, client, c, privKey, StateSettled, time.Now())
+		if err := PutChannel(d, self, c); err != nil {
+			return err
+		}
+		if cerr != nil {
+			return fmt.Errorf("cannot close channel %d: %s", c.ChannelID, cerr)
+		}
+		return cmds.EmitOnce(res, c)
+	},
+	Type: Channel{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c *Channel) error {
+			fmt.Fprintf(w, "channel %d closed, %d µBTT paid of %d\n", c.ChannelID, c.Paid, c.Deposit)
+			return nil
+		}),
+	},
+}
+
+var storageBandwidthStatusCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the bandwidth channels of this node.",
+		ShortDescription: `
+Shows the bandwidth channels of this node as a renter and as a host, the
+latest first, or the channel given: the deposit, the total paid, what the
+bytes metered cost, and the disputes.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("channel-id", false, false, "ID of the channel, all channels when not set."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		if len(req.Arguments) == 0 {
+			cs, err := ListChannels(d, self)
+			if err != nil {
+				return err
+			}
+			return cmds.EmitOnce(res, cs)
+		}
+		id, err := strconv.ParseInt(req.Arguments[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid channel ID: %s", err)
+		}
+		c, err := GetChannel(d, self, id)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, []*Channel{c})
+	},
+	Type: []*Channel{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, cs []*Channel) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "CHANNEL\tROLE\tPEER\tDEPOSIT\tPAID\tOWED\tBYTES\tSTATE")
+			for _, c := range cs {
+				p := c.Host
+				if c.Role == RoleHost {
+					p = c.Renter
+				}
+				state := c.State
+				if c.Dispute != "" {
+					state += ": " + c.Dispute
+				} else if c.Error != "" {
+					state += ": " + c.Error
+				}
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", c.ChannelID, c.Role, p, c.Deposit, c.Paid,
+					c.Owed(), c.Metered, state)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// StorageBandwidthTermsCmd is called by renters for the bandwidth terms of
+// this host.
+var StorageBandwidthTermsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the price of the bandwidth of this host.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageHostEnabled {
+			return fmt.Errorf("storage host api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		c, err := Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		t, err := c.Terms(n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, t)
+	},
+	Type: Terms{},
+}
+
+// StorageBandwidthAcceptCmd is called by renters to open a bandwidth
+// channel to this host.
+var StorageBandwidthAcceptCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Accept the bandwidth channel a renter opens.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("opening", true, false, "Channel opening of the renter, in JSON."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageHostEnabled {
+			return fmt.Errorf("storage host api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		renter, ok := remote.GetStreamRequestRemotePeerID(req, n)
+		if !ok {
+			return fmt.Errorf("fail to get peer ID from request")
+		}
+		o := &Opening{}
+		if err := json.Unmarshal([]byte(req.Arguments[0]), o); err != nil {
+			return fmt.Errorf("invalid channel opening: %s", err)
+		}
+		if o.Renter != renter.Pretty() {
+			return errors.New("channel opening of another renter")
+		}
+		bc, err := Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		terms, err := bc.Terms(n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		if c, err := OpenChannel(d, self, RoleHost, o.Renter); err != nil {
+			return err
+		} else if c != nil {
+			return fmt.Errorf("channel %d of %s is open already", c.ChannelID, o.Renter)
+		}
+		renterKey, err := renter.ExtractPublicKey()
+		if err != nil {
+			return err
+		}
+		sent, _, err := counters(n, renter)
+		if err != nil {
+			return err
+		}
+		c, err := Accept(o, renterKey, n.PrivateKey.GetPublic(), terms, sent, time.Now())
+		if err != nil {
+			return err
+		}
+		return PutChannel(d, self, c)
+	},
+}
+
+// StorageBandwidthPayCmd is called by renters to pay the bandwidth of this
+// host.
+var StorageBandwidthPayCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Receive the payment of a renter for the bandwidth of this host.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("payment", true, false, "Payment of the renter, in JSON."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		renter, ok := remote.GetStreamRequestRemotePeerID(req, n)
+		if !ok {
+			return fmt.Errorf("fail to get peer ID from request")
+		}
+		p := &Payment{}
+		if err := json.Unmarshal([]byte(req.Arguments[0]), p); err != nil {
+			return fmt.Errorf("invalid payment: %s", err)
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		c, err := GetChannel(d, self, p.ChannelID)
+		if err != nil {
+			return err
+		}
+		if c.Role != RoleHost || c.Renter != renter.Pretty() {
+			return fmt.Errorf("channel %d of another renter", p.ChannelID)
+		}
+		if c.State == StateOpen {
+			renterKey, err := renter.ExtractPublicKey()
+			if err != nil {
+				return err
+			}
+			sent, _, err := counters(n, renter)
+			if err != nil {
+				return err
+			}
+			c.Meter(sent)
+			if err := c.Receive(p, renterKey, n.PrivateKey.GetPublic()); err != nil {
+				return err
+			}
+			if err := PutChannel(d, self, c); err != nil {
+				return err
+			}
+		}
+		return cmds.EmitOnce(res, &Receipt{ChannelID: c.ChannelID, State: c.State, Paid: c.Paid,
+			Metered: c.Metered})
+	},
+	Type: Receipt{},
+}
+
+// counters returns the bytes bitswap sent to and received from p.
+func counters(n *core.IpfsNode, p peer.ID) (sent uint64, recv uint64, err error) {
+	bs, ok := n.Exchange.(*bitswap.Bitswap)
+	if !ok {
+		return 0, 0, errors.New("bitswap is not running")
+	}
+	r := bs.LedgerForPeer(p)
+	if r == nil {
+		return 0, 0, nil
+	}
+	return r.Sent, r.Recv, nil
+}
+
+func call(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, p peer.ID, path string,
+	args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+	return remote.P2PCallStrings(ctx, n, api, p, path, args...)
+}
+
+// pay meters the bytes received from the host of the renter channel c, and
+// pays the host what the renter owes. A payment the host did not receive is
+// sent again.
+func pay(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, privKey ic.PrivKey, c *Channel) error {
+	host, err := peer.Decode(c.Host)
+	if err != nil {
+		return err
+	}
+	hostKey, err := host.ExtractPublicKey()
+	if err != nil {
+		return err
+	}
+	_, recv, err := counters(n, host)
+	if err != nil {
+		return err
+	}
+	c.Meter(recv)
+	p, err := c.Pay(privKey, hostKey)
+	if err != nil {
+		return err
+	}
+	if p == nil && c.Error == "" {
+		return nil
+	}
+	if p == nil {
+		p = &Payment{ChannelID: c.ChannelID, Sequence: c.Sequence, Paid: c.Paid, Metered: c.Metered,
+			State: c.Latest}
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	b, err = call(ctx, n, api, host, "/storage/bandwidth/pay", string(b))
+	if err != nil {
+		c.Error = err.Error()
+		return err
+	}
+	r := &Receipt{}
+	if err := json.Unmarshal(b, r); err != nil {
+		c.Error = err.Error()
+		return err
+	}
+	c.Error = ""
+	c.Apply(r, time.Now())
+	return nil
+}
+
+// Sync pays the hosts of the open renter channels of n for the bytes
+// retrieved, and closes the open host channels due at now. It returns the
+// number of payments made and of channels closed.
+func Sync(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, cfg *config.Config,
+	now time.Time) (paid int, closed int, err error) {
+	d, self := n.Repo.Datastore(), n.Identity.Pretty()
+	cs, err := ListChannels(d, self)
+	if err != nil {
+		return 0, 0, err
+	}
+	privKey, err := cfg.Identity.DecodePrivateKey("")
+	if err != nil {
+		return 0, 0, err
+	}
+	bc, err := Load(n.Repo)
+	if err != nil {
+		return 0, 0, err
+	}
+	client := escrowclient.New(cfg.Services.EscrowDomain)
+	for _, c := range cs {
+		if c.State != StateOpen {
+			continue
+		}
+		switch c.Role {
+		case RoleRenter:
+			if !cfg.Experimental.StorageClientEnabled {
+				continue
+			}
+			seq := c.Sequence
+			if err := pay(ctx, n, api, privKey, c); err != nil {
+				log.Warnf("pay bandwidth channel %d: %s", c.ChannelID, err)
+			} else if c.Sequence > seq {
+				paid++
+			}
+		case RoleHost:
+			if !cfg.Experimental.StorageHostEnabled {
+				continue
+			}
+			renter, err := peer.Decode(c.Renter)
+			if err != nil {
+				return paid, closed, err
+			}
+			sent, _, err := counters(n, renter)
+			if err != nil {
+				return paid, closed, err
+			}
+			c.Meter(sent)
+			if due, state := c.Due(bc.SettlePeriodDuration(), now); due {
+				if err := Close(ctx, client, c, privKey, state, now); err != nil {
+					log.Warnf("close bandwidth channel %d: %s", c.ChannelID, err)
+				} else {
+					closed++
+				}
+			}
+		}
+		if err := PutChannel(d, self, c); err != nil {
+			return paid, closed, err
+		}
+	}
+	return paid, closed, nil
+}
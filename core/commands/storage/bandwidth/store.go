@@ -0,0 +1,481 @@
+package bandwidth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/tron-us/go-btfs-common/crypto"
+	"github.com/tron-us/go-btfs-common/ledger"
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+	"github.com/tron-us/protobuf/proto"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// ConfigKey is the config section of the bandwidth channels.
+const ConfigKey = "Bandwidth"
+
+// DefaultSettlePeriod is how long a host keeps a channel open when
+// SettlePeriod is not set.
+const DefaultSettlePeriod = 24 * time.Hour
+
+// gib is the unit of the price of the bandwidth.
+const gib = 1 << 30
+
+// Roles of the node in a bandwidth channel.
+const (
+	RoleHost   = "host"
+	RoleRenter = "renter"
+)
+
+// States of a bandwidth channel.
+const (
+	StateOpen     = "open"
+	StateSettled  = "settled"
+	StateDisputed = "disputed"
+)
+
+const channelKeyPrefix = "/btfs/%s/bandwidth/channels/"
+
+// Config configures the bandwidth a host charges for the retrievals of its
+// renters.
+type Config struct {
+	// Enabled makes the host accept bandwidth channels.
+	Enabled bool
+	// Price is the price of the bandwidth, in µBTT per GiB sent.
+	Price int64
+	// Credit is the µBTT a renter may owe unpaid: past it the host closes
+	// the channel as disputed, and a renter disputes the channel of a host
+	// claiming it past what the renter metered.
+	Credit int64
+	// SettlePeriod is how long the host keeps a channel open before
+	// closing it with the latest payment, e.g. "24h", DefaultSettlePeriod
+	// when empty.
+	SettlePeriod string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the bandwidth config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if c.Price < 0 || (c.Enabled && c.Price == 0) {
+		return errors.New("Price must be positive")
+	}
+	if c.Credit < 0 {
+		return errors.New("Credit must not be negative")
+	}
+	if c.SettlePeriod != "" {
+		if d, err := time.ParseDuration(c.SettlePeriod); err != nil || d <= 0 {
+			return fmt.Errorf("invalid SettlePeriod %q", c.SettlePeriod)
+		}
+	}
+	return nil
+}
+
+// SettlePeriodDuration returns how long the host keeps a channel open.
+func (c *Config) SettlePeriodDuration() time.Duration {
+	d, err := time.ParseDuration(c.SettlePeriod)
+	if err != nil {
+		return DefaultSettlePeriod
+	}
+	return d
+}
+
+// Terms are the price of the bandwidth of a host.
+type Terms struct {
+	Host         string
+	Price        int64
+	Credit       int64
+	SettlePeriod time.Duration
+}
+
+// Terms returns the bandwidth terms of the host.
+func (c *Config) Terms(host string) (*Terms, error) {
+	if !c.Enabled {
+		return nil, errors.New("this host does not accept bandwidth channels")
+	}
+	return &Terms{Host: host, Price: c.Price, Credit: c.Credit, SettlePeriod: c.SettlePeriodDuration()}, nil
+}
+
+// Cost returns the price of n bytes at price µBTT per GiB, rounded down.
+func Cost(n uint64, price int64) int64 {
+	return int64(n/gib)*price + int64(n%gib)*price/gib
+}
+
+// Channel is a ledger channel from a renter to a host, paying the bytes the
+// host sends the renter as they are retrieved. Each payment is a channel
+// state signed by the renter with the total paid so far; the channel is
+// closed with the latest one.
+type Channel struct {
+	ChannelID int64
+	Role      string
+	Host      string
+	Renter    string
+	Deposit   int64
+	Price     int64
+	Credit    int64
+	// Paid is the total paid in the latest state, at Sequence.
+	Paid     int64
+	Sequence int64
+	// Metered is the number of bytes the host sent the renter since the
+	// channel opened, as metered by this node, and Counter the last value
+	// of the counter of the bytes exchanged metered from.
+	Metered uint64
+	Counter uint64
+	// Claimed is the number of bytes metered by the host, as last told to
+	// the renter.
+	Claimed uint64 `json:",omitempty"`
+	State   string
+	Opened  time.Time
+	Closed  time.Time `json:",omitempty"`
+	// Latest is the latest channel state signed by the renter.
+	Latest  []byte
+	Dispute string `json:",omitempty"`
+	Error   string `json:",omitempty"`
+}
+
+// Owed returns what the renter owes for the bytes metered, bounded by the
+// deposit.
+func (c *Channel) Owed() int64 {
+	owed := Cost(c.Metered, c.Price)
+	if owed > c.Deposit {
+		return c.Deposit
+	}
+	return owed
+}
+
+// Meter adds the bytes counted since the last call to the bytes metered,
+// from the counter of the bytes exchanged with the peer of the channel. A
+// counter lower than the last one was reset, with the node restarting.
+func (c *Channel) Meter(counter uint64) {
+	if counter < c.Counter {
+		c.Metered += counter
+	} else {
+		c.Metered += counter - c.Counter
+	}
+	c.Counter = counter
+}
+
+func (c *Channel) close(state string, now time.Time) {
+	c.State, c.Closed, c.Error = state, now, ""
+}
+
+// Opening is a bandwidth channel a renter opens to a host, with the state
+// refunding the renter.
+type Opening struct {
+	ChannelID int64
+	Renter    string
+	Deposit   int64
+	Price     int64
+	Refund    []byte
+}
+
+// Payment is a state of a bandwidth channel paying Paid in total to the
+// host, with the bytes the renter metered.
+type Payment struct {
+	ChannelID int64
+	Sequence  int64
+	Paid      int64
+	Metered   uint64
+	State     []byte
+}
+
+// Receipt is the answer of the host to a payment, with the bytes the host
+// metered.
+type Receipt struct {
+	ChannelID int64
+	State     string
+	Paid      int64
+	Metered   uint64
+}
+
+// Open commits deposit from the ledger of the renter privKey to the host
+// hostKey at the price of terms, and returns the channel of the renter with
+// the opening for the host. counter is the count of the bytes received
+// from the host the renter meters from.
+func Open(ctx context.Context, client escrowclient.Client, privKey ic.PrivKey, hostKey ic.PubKey,
+	terms *Terms, renter string, deposit int64, counter uint64, now time.Time) (*Channel, *Opening, error) {
+	if deposit <= 0 {
+		return nil, nil, errors.New("deposit must be positive")
+	}
+	commit, err := ledger.NewChannelCommit(privKey.GetPublic(), hostKey, deposit)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := crypto.Sign(privKey, commit)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := client.CreateChannel(ctx, ledger.NewSignedChannelCommit(commit, sig))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open the channel: %s", err)
+	}
+	refund, err := signedState(privKey, hostKey, id.GetId(), 1, deposit, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := &Channel{ChannelID: id.GetId(), Role: RoleRenter, Host: terms.Host, Renter: renter, Deposit: deposit,
+		Price: terms.Price, Credit: terms.Credit, Sequence: 1, Counter: counter, State: StateOpen, Opened: now,
+		Latest: refund}
+	o := &Opening{ChannelID: c.ChannelID, Renter: renter, Deposit: deposit, Price: terms.Price, Refund: refund}
+	return c, o, nil
+}
+
+// Accept checks the opening o of the renter renterKey against the terms of
+// the host hostKey, and returns the channel of the host.
+func Accept(o *Opening, renterKey, hostKey ic.PubKey, terms *Terms, counter uint64,
+	now time.Time) (*Channel, error) {
+	if o.Deposit <= 0 {
+		return nil, errors.New("invalid deposit")
+	}
+	if o.Price != terms.Price {
+		return nil, fmt.Errorf("price of %d µBTT per GiB, not %d", o.Price, terms.Price)
+	}
+	c := &Channel{ChannelID: o.ChannelID, Role: RoleHost, Host: terms.Host, Renter: o.Renter,
+		Deposit: o.Deposit, Price: terms.Price, Credit: terms.Credit, Counter: counter, State: StateOpen,
+		Opened: now}
+	s, err := c.check(o.Refund, renterKey, hostKey, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid refund state: %s", err)
+	}
+	c.Sequence, c.Latest = s.GetChannel().GetSequence(), o.Refund
+	return c, nil
+}
+
+// Pay signs the state of the channel c of the renter privKey to the host
+// hostKey paying what the renter owes, and returns the payment of the
+// increase, nil when the renter owes nothing more.
+func (c *Channel) Pay(privKey ic.PrivKey, hostKey ic.PubKey) (*Payment, error) {
+	owed := c.Owed()
+	if owed <= c.Paid {
+		return nil, nil
+	}
+	state, err := signedState(privKey, hostKey, c.ChannelID, c.Sequence+1, c.Deposit-owed, owed)
+	if err != nil {
+		return nil, err
+	}
+	c.Sequence, c.Paid, c.Latest = c.Sequence+1, owed, state
+	return &Payment{ChannelID: c.ChannelID, Sequence: c.Sequence, Paid: owed, Metered: c.Metered,
+		State: state}, nil
+}
+
+// Receive checks the payment p of the renter renterKey for the channel c of
+// the host hostKey, and makes its state the latest. The payments of a
+// channel pay more and more, the latest one may be received again.
+func (c *Channel) Receive(p *Payment, renterKey, hostKey ic.PubKey) error {
+	if c.State != StateOpen {
+		return fmt.Errorf("channel %d is %s", c.ChannelID, c.State)
+	}
+	s, err := c.check(p.State, renterKey, hostKey, c.Sequence, c.Paid)
+	if err != nil {
+		return fmt.Errorf("invalid payment: %s", err)
+	}
+	c.Sequence, c.Paid, c.Latest = s.GetChannel().GetSequence(), s.GetChannel().GetTo().GetBalance(), p.State
+	return nil
+}
+
+// check returns the signed state b of c if it is signed by the renter, from
+// sequence minSequence, paying the host paid at least and refunding the
+// renter the rest of the deposit.
+func (c *Channel) check(b []byte, renterKey, hostKey ic.PubKey, minSequence, paid int64) (
+	*ledgerpb.SignedChannelState, error) {
+	s := &ledgerpb.SignedChannelState{}
+	if err := proto.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	renterAddr, err := ic.RawFull(renterKey)
+	if err != nil {
+		return nil, err
+	}
+	hostAddr, err := ic.RawFull(hostKey)
+	if err != nil {
+		return nil, err
+	}
+	st := s.GetChannel()
+	switch {
+	case st.GetId().GetId() != c.ChannelID:
+		return nil, errors.New("state of another channel")
+	case string(st.GetFrom().GetAddress().GetKey()) != string(renterAddr) ||
+		string(st.GetTo().GetAddress().GetKey()) != string(hostAddr):
+		return nil, errors.New("state of a channel not from the renter to the host")
+	case st.GetSequence() < minSequence:
+		return nil, errors.New("stale state")
+	case st.GetTo().GetBalance() < paid:
+		return nil, errors.New("state paying less than the previous one")
+	case st.GetFrom().GetBalance() < 0 || st.GetFrom().GetBalance()+st.GetTo().GetBalance() != c.Deposit:
+		return nil, errors.New("state not splitting the deposit")
+	}
+	if ok, err := crypto.Verify(renterKey, st, s.GetFromSignature()); err != nil || !ok {
+		return nil, errors.New("state not signed by the renter")
+	}
+	return s, nil
+}
+
+func signedState(privKey ic.PrivKey, hostKey ic.PubKey, id, sequence, renterBalance,
+	hostBalance int64) ([]byte, error) {
+	from, err := ledger.NewAccount(privKey.GetPublic(), renterBalance)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ledger.NewAccount(hostKey, hostBalance)
+	if err != nil {
+		return nil, err
+	}
+	s := ledger.NewChannelState(&ledgerpb.ChannelID{Id: id}, sequence, from, to)
+	sig, err := crypto.Sign(privKey, s)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(ledger.NewSignedChannelState(s, sig, nil))
+}
+
+// Close closes the channel c with its latest state, countersigned with
+// hostKey by the host, and marks it state at now.
+func Close(ctx context.Context, client escrowclient.Client, c *Channel, hostKey ic.PrivKey, state string,
+	now time.Time) error {
+	s := &ledgerpb.SignedChannelState{}
+	if err := proto.Unmarshal(c.Latest, s); err != nil {
+		return err
+	}
+	if hostKey != nil {
+		sig, err := crypto.Sign(hostKey, s.GetChannel())
+		if err != nil {
+			return err
+		}
+		s.ToSignature = sig
+	}
+	if _, err := client.CloseChannel(ctx, s); err != nil {
+		c.Error = err.Error()
+		return err
+	}
+	c.close(state, now)
+	return nil
+}
+
+// Apply updates the channel c of the renter with the receipt r of the host.
+// The renter disputes the channel of a host claiming more than its credit
+// past what the renter metered; a channel the host closed is closed.
+func (c *Channel) Apply(r *Receipt, now time.Time) {
+	c.Claimed = r.Metered
+	if r.State != StateOpen {
+		c.close(r.State, now)
+		return
+	}
+	if r.Metered > c.Metered && Cost(r.Metered-c.Metered, c.Price) > c.Credit {
+		c.Dispute = fmt.Sprintf("the host claims %d bytes, %d metered", r.Metered, c.Metered)
+	}
+}
+
+// Due reports whether the host is to close the channel c at now: when the
+// renter owes more than its credit, which disputes the channel, when the
+// renter paid the deposit, or when the channel is open for period.
+func (c *Channel) Due(period time.Duration, now time.Time) (bool, string) {
+	switch {
+	case c.State != StateOpen:
+		return false, ""
+	case c.Owed()-c.Paid > c.Credit:
+		c.Dispute = fmt.Sprintf("the renter owes %d µBTT unpaid, over the %d µBTT credit", c.Owed()-c.Paid,
+			c.Credit)
+		return true, StateDisputed
+	case c.Paid >= c.Deposit, !now.Before(c.Opened.Add(period)):
+		return true, StateSettled
+	}
+	return false, ""
+}
+
+func channelKey(peerID string, id int64) ds.Key {
+	return ds.NewKey(fmt.Sprintf(channelKeyPrefix, peerID) + strconv.FormatInt(id, 10))
+}
+
+// PutChannel saves the bandwidth channel c of the node peerID.
+func PutChannel(d ds.Datastore, peerID string, c *Channel) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return d.Put(channelKey(peerID, c.ChannelID), b)
+}
+
+// GetChannel returns the bandwidth channel id of the node peerID.
+func GetChannel(d ds.Datastore, peerID string, id int64) (*Channel, error) {
+	b, err := d.Get(channelKey(peerID, id))
+	if err == ds.ErrNotFound {
+		return nil, fmt.Errorf("no bandwidth channel %d", id)
+	} else if err != nil {
+		return nil, err
+	}
+	c := &Channel{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid bandwidth channel %d: %s", id, err)
+	}
+	return c, nil
+}
+
+// ListChannels returns the bandwidth channels of the node peerID, the
+// latest first.
+func ListChannels(d ds.Datastore, peerID string) ([]*Channel, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(channelKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	cs := make([]*Channel, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		c := &Channel{}
+		if err := json.Unmarshal(r.Value, c); err != nil {
+			return nil, fmt.Errorf("invalid bandwidth channel %s: %s", r.Key, err)
+		}
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].Opened.After(cs[j].Opened)
+	})
+	return cs, nil
+}
+
+// OpenChannel returns the open channel of the node peerID in role with the
+// peer other, nil when none.
+func OpenChannel(d ds.Datastore, peerID, role, other string) (*Channel, error) {
+	cs, err := ListChannels(d, peerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cs {
+		p := c.Host
+		if role == RoleHost {
+			p = c.Renter
+		}
+		if c.Role == role && p == other && c.State == StateOpen {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,254 @@
+package bandwidth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+func newKey(t *testing.T) ic.PrivKey {
+	k, _, err := ic.GenerateKeyPair(ic.Secp256k1, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// mockEscrow returns an escrow creating channels and recording the signed
+// states of the channels closed.
+func mockEscrow(closed map[int64]*ledgerpb.SignedChannelState) *escrowclient.Mock {
+	var next int64
+	return &escrowclient.Mock{
+		CreateChannelFunc: func(ctx context.Context, in *ledgerpb.SignedChannelCommit) (*ledgerpb.ChannelID, error) {
+			next++
+			return &ledgerpb.ChannelID{Id: next}, nil
+		},
+		CloseChannelFunc: func(ctx context.Context, in *ledgerpb.SignedChannelState) (*ledgerpb.ChannelClosed, error) {
+			closed[in.Channel.Id.Id] = in
+			return &ledgerpb.ChannelClosed{}, nil
+		},
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, c := range []Config{{}, {Enabled: true, Price: 10}, {Enabled: true, Price: 10, SettlePeriod: "1h"}} {
+		if err := c.validate(); err != nil {
+			t.Fatalf("%+v: %s", c, err)
+		}
+	}
+	for _, c := range []Config{{Enabled: true}, {Price: -1}, {Credit: -1}, {SettlePeriod: "daily"}} {
+		if err := c.validate(); err == nil {
+			t.Fatalf("%+v: expected an error", c)
+		}
+	}
+	if _, err := (&Config{Price: 10}).Terms("host"); err == nil {
+		t.Fatal("expected no terms when disabled")
+	}
+	terms, err := (&Config{Enabled: true, Price: 10}).Terms("host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if terms.SettlePeriod != DefaultSettlePeriod {
+		t.Fatalf("expected the default settle period, got %s", terms.SettlePeriod)
+	}
+}
+
+func TestCost(t *testing.T) {
+	for _, tc := range []struct {
+		n    uint64
+		want int64
+	}{
+		{0, 0},
+		{gib, 1000},
+		{gib / 2, 500},
+		{3*gib + gib/4, 3250},
+		{1 << 50, 1 << 20 * 1000},
+	} {
+		if got := Cost(tc.n, 1000); got != tc.want {
+			t.Errorf("%d bytes: expected %d, got %d", tc.n, tc.want, got)
+		}
+	}
+}
+
+func TestMeter(t *testing.T) {
+	c := &Channel{Counter: 100}
+	c.Meter(150)
+	c.Meter(170)
+	// the node restarted
+	c.Meter(30)
+	if c.Metered != 100 || c.Counter != 30 {
+		t.Fatalf("expected 100 bytes metered, got %d", c.Metered)
+	}
+}
+
+// openChannel opens a channel of 1000 µBTT from renter to host at 1000 µBTT
+// per GiB with a credit of 100 µBTT, accepted by the host.
+func openChannel(t *testing.T, client escrowclient.Client, renter, host ic.PrivKey,
+	now time.Time) (*Channel, *Channel) {
+	terms := &Terms{Host: "host", Price: 1000, Credit: 100}
+	rc, o, err := Open(context.Background(), client, renter, host.GetPublic(), terms, "renter", 1000, 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc, err := Accept(o, renter.GetPublic(), host.GetPublic(), terms, 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rc, hc
+}
+
+func TestPayments(t *testing.T) {
+	renter, host, other := newKey(t), newKey(t), newKey(t)
+	closed := make(map[int64]*ledgerpb.SignedChannelState)
+	client := mockEscrow(closed)
+	now := time.Now()
+	rc, hc := openChannel(t, client, renter, host, now)
+	if hc.Role != RoleHost || hc.State != StateOpen || hc.Deposit != 1000 || hc.Sequence != 1 {
+		t.Fatalf("unexpected channel %+v", hc)
+	}
+
+	// nothing retrieved, nothing owed
+	if p, err := rc.Pay(renter, host.GetPublic()); err != nil || p != nil {
+		t.Fatalf("expected no payment, got %+v, %v", p, err)
+	}
+	rc.Meter(gib / 4)
+	p, err := rc.Pay(renter, host.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Paid != 250 || p.Sequence != 2 {
+		t.Fatalf("unexpected payment %+v", p)
+	}
+	if err := hc.Receive(p, renter.GetPublic(), host.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+	// a payment received again is accepted
+	if err := hc.Receive(p, renter.GetPublic(), host.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+	if hc.Paid != 250 || hc.Sequence != 2 {
+		t.Fatalf("unexpected channel %+v", hc)
+	}
+
+	rc.Meter(gib / 2)
+	p2, err := rc.Pay(renter, host.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stale payments, and payments of another renter, are rejected
+	if err := hc.Receive(p2, other.GetPublic(), host.GetPublic()); err == nil {
+		t.Fatal("accepted the payment of another renter")
+	}
+	if err := hc.Receive(p2, renter.GetPublic(), host.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+	if err := hc.Receive(p, renter.GetPublic(), host.GetPublic()); err == nil {
+		t.Fatal("accepted a stale payment")
+	}
+
+	// the payments are bounded by the deposit
+	rc.Meter(10 * gib)
+	p3, err := rc.Pay(renter, host.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p3.Paid != 1000 {
+		t.Fatalf("expected the deposit paid, got %d", p3.Paid)
+	}
+	if err := hc.Receive(p3, renter.GetPublic(), host.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+
+	hc.Meter(gib / 5)
+	if due, state := hc.Due(time.Hour, now); !due || state != StateSettled {
+		t.Fatalf("expected the paid channel settled, got %v %s", due, state)
+	}
+	if err := Close(context.Background(), client, hc, host, StateSettled, now); err != nil {
+		t.Fatal(err)
+	}
+	s := closed[hc.ChannelID]
+	if s.Channel.To.Balance != 1000 || len(s.ToSignature) == 0 {
+		t.Fatalf("unexpected closing state %+v", s)
+	}
+	if err := hc.Receive(p3, renter.GetPublic(), host.GetPublic()); err == nil {
+		t.Fatal("accepted a payment of a closed channel")
+	}
+}
+
+func TestDisputes(t *testing.T) {
+	renter, host := newKey(t), newKey(t)
+	client := mockEscrow(make(map[int64]*ledgerpb.SignedChannelState))
+	now := time.Now()
+	rc, hc := openChannel(t, client, renter, host, now)
+
+	// a host within its credit, then past it
+	hc.Meter(gib / 20)
+	if due, _ := hc.Due(time.Hour, now); due {
+		t.Fatal("expected the channel open within the credit")
+	}
+	hc.Meter(gib / 5)
+	due, state := hc.Due(time.Hour, now)
+	if !due || state != StateDisputed || hc.Dispute == "" {
+		t.Fatalf("expected the channel disputed, got %v %s", due, state)
+	}
+	if due, state := (&Channel{State: StateOpen, Deposit: 10, Opened: now}).Due(time.Hour,
+		now.Add(2*time.Hour)); !due || state != StateSettled {
+		t.Fatalf("expected the channel settled after the period, got %v %s", due, state)
+	}
+
+	// a host claiming past what the renter metered
+	rc.Meter(gib / 10)
+	rc.Apply(&Receipt{ChannelID: rc.ChannelID, State: StateOpen, Metered: gib / 10}, now)
+	if rc.Dispute != "" {
+		t.Fatalf("unexpected dispute %s", rc.Dispute)
+	}
+	rc.Apply(&Receipt{ChannelID: rc.ChannelID, State: StateOpen, Metered: gib}, now)
+	if rc.Dispute == "" || rc.State != StateOpen {
+		t.Fatal("expected the channel disputed")
+	}
+	rc.Apply(&Receipt{ChannelID: rc.ChannelID, State: StateDisputed, Metered: gib}, now)
+	if rc.State != StateDisputed || rc.Closed.IsZero() {
+		t.Fatal("expected the channel closed by the host")
+	}
+}
+
+func TestChannels(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	for i, c := range []*Channel{
+		{ChannelID: 1, Role: RoleRenter, Host: "h1", State: StateSettled, Opened: now.Add(-time.Hour)},
+		{ChannelID: 2, Role: RoleRenter, Host: "h1", State: StateOpen, Opened: now},
+		{ChannelID: 3, Role: RoleHost, Renter: "r1", State: StateOpen, Opened: now.Add(-time.Minute)},
+	} {
+		if err := PutChannel(d, "self", c); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+	cs, err := ListChannels(d, "self")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 3 || cs[0].ChannelID != 2 || cs[2].ChannelID != 1 {
+		t.Fatalf("unexpected channels %+v", cs)
+	}
+	if c, err := OpenChannel(d, "self", RoleRenter, "h1"); err != nil || c.ChannelID != 2 {
+		t.Fatalf("expected channel 2, got %+v, %v", c, err)
+	}
+	if c, err := OpenChannel(d, "self", RoleHost, "r1"); err != nil || c.ChannelID != 3 {
+		t.Fatalf("expected channel 3, got %+v, %v", c, err)
+	}
+	if c, err := OpenChannel(d, "self", RoleRenter, "h2"); err != nil || c != nil {
+		t.Fatalf("expected no channel, got %+v, %v", c, err)
+	}
+	if _, err := GetChannel(d, "self", 4); err == nil {
+		t.Fatal("expected no channel 4")
+	}
+}
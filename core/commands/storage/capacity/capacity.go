@@ -0,0 +1,254 @@
+package capacity
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/capacity"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	sizeOptionName = "size"
+
+	// minPlotRate is the slowest a host may plot, in bytes per second.
+	minPlotRate  = 10 << 20
+	plotOverhead = time.Minute
+	pollPeriod   = 2 * time.Second
+)
+
+var StorageCapacityCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Verify the free capacity the storage hosts advertise.",
+		ShortDescription: `
+Has a host prove it has the free capacity it advertises: the host writes a
+temporary plot of the size to prove, generated from a random seed, then
+answers within a deadline for random blocks of it, which it cannot do
+without keeping the whole plot. The plot is deleted once proven.
+
+    $ btfs storage capacity verify <host-id> --size 4GB
+
+The uploads skip the hosts which failed a proof for a week. The sizes,
+deadline and number of blocks checked are set with:
+
+    $ btfs config CapacityProof.Size 4GB
+    $ btfs config CapacityProof.Deadline 10s
+    $ btfs config --json CapacityProof.Samples 8
+
+A host bounds the plots it writes with:
+
+    $ btfs config CapacityProof.MaxPlot 16GB`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"verify": storageCapacityVerifyCmd,
+		"ls":     storageCapacityLsCmd,
+		"plot":   StorageCapacityPlotCmd,
+		"status": StorageCapacityStatusCmd,
+		"prove":  StorageCapacityProveCmd,
+	},
+}
+
+var storageCapacityVerifyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Have a host prove its free capacity.",
+		ShortDescription: `
+Asks the host to plot the size to prove, waits for it to be done, allowing
+10MB/s, and checks random blocks of the plot.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("host-id", true, false, "Peer ID of the host."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(sizeOptionName, "Capacity to prove, e.g. 4GB. Default: CapacityProof.Size."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		conf, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !conf.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		cfg, err := capacity.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		host, err := peer.IDB58Decode(req.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("invalid host ID: %s", err)
+		}
+		size := cfg.ProofSize()
+		if s, ok := req.Options[sizeOptionName].(string); ok {
+			v, err := humanize.ParseBytes(s)
+			if err != nil || v < capacity.BlockSize {
+				return fmt.Errorf("invalid size %q", s)
+			}
+			size = int64(v)
+		}
+		seed := make([]byte, 32)
+		if _, err := rand.Read(seed); err != nil {
+			return err
+		}
+		r := &capacity.Result{Host: host.Pretty(), Time: time.Now(), Size: size}
+		if err := verify(req, n, api, cfg, host, seed, r); err != nil {
+			// the proofs the host could not make are failed, not the ones
+			// it did not take
+			if errors.Is(err, errNotTaken) {
+				return err
+			}
+			r.Error = err.Error()
+		} else {
+			r.Passed = true
+		}
+		if err := capacity.PutResult(n.Repo.Datastore(), n.Identity.Pretty(), r); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, r)
+	},
+	Type: capacity.Result{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, r *capacity.Result) error {
+			if !r.Passed {
+				_, err := fmt.Fprintf(w, "host %s failed to prove %s: %s\n", r.Host, humanize.IBytes(uint64(r.Size)), r.Error)
+				return err
+			}
+			_, err := fmt.Fprintf(w, "host %s proved %s, plotted in %s, answered in %s\n", r.Host,
+				humanize.IBytes(uint64(r.Size)), r.PlotTime.Round(time.Second), r.ProveTime.Round(time.Millisecond))
+			return err
+		}),
+	},
+}
+
+var errNotTaken = errors.New("proof not taken")
+
+func verify(req *cmds.Request, n *core.IpfsNode, api coreiface.CoreAPI, cfg *capacity.Config,
+	host peer.ID, seed []byte, r *capacity.Result) error {
+	ctx := req.Context
+	seedHex := hex.EncodeToString(seed)
+	if _, err := remote.P2PCallStrings(ctx, n, api, host, "/storage/capacity/plot", seedHex,
+		strconv.FormatInt(r.Size, 10)); err != nil {
+		return fmt.Errorf("%w: %s", errNotTaken, err)
+	}
+	deadline := time.Now().Add(time.Duration(r.Size/minPlotRate)*time.Second + plotOverhead)
+	st := &PlotStatus{}
+	for !st.Done {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("plotting took over %s", time.Since(r.Time).Round(time.Second))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollPeriod):
+		}
+		b, err := remote.P2PCallStrings(ctx, n, api, host, "/storage/capacity/status", seedHex)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errNotTaken, err)
+		}
+		st = &PlotStatus{}
+		if err := json.Unmarshal(b, st); err != nil {
+			return err
+		}
+		if st.Error != "" {
+			return fmt.Errorf("plotting failed: %s", st.Error)
+		}
+	}
+	r.PlotTime = time.Since(r.Time)
+	if st.Blocks != capacity.Blocks(r.Size) {
+		return fmt.Errorf("plotted %d blocks instead of %d", st.Blocks, capacity.Blocks(r.Size))
+	}
+
+	indices := make([]string, cfg.Samples)
+	for i := range indices {
+		indices[i] = strconv.FormatInt(mrand.Int63n(st.Blocks), 10)
+	}
+	start := time.Now()
+	b, err := remote.P2PCallStrings(ctx, n, api, host, "/storage/capacity/prove", seedHex,
+		strings.Join(indices, ","))
+	r.ProveTime = time.Since(start)
+	if err != nil {
+		return fmt.Errorf("failed to prove: %s", err)
+	}
+	if r.ProveTime > cfg.ProofDeadline() {
+		return fmt.Errorf("answered in %s, over the %s deadline", r.ProveTime.Round(time.Millisecond),
+			cfg.ProofDeadline())
+	}
+	out := &ProveOutput{}
+	if err := json.Unmarshal(b, out); err != nil {
+		return err
+	}
+	if len(out.Openings) != len(indices) {
+		return fmt.Errorf("answered for %d blocks instead of %d", len(out.Openings), len(indices))
+	}
+	for i, o := range out.Openings {
+		if strconv.FormatInt(o.Index, 10) != indices[i] {
+			return fmt.Errorf("answered for block %d instead of %s", o.Index, indices[i])
+		}
+		if err := capacity.Verify(seed, st.Blocks, st.Root, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LsOutput are the last capacity proofs of the hosts.
+type LsOutput struct {
+	Results []*capacity.Result
+}
+
+var storageCapacityLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the last capacity proofs of the hosts.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		rs, err := capacity.Results(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &LsOutput{Results: rs})
+	},
+	Type: LsOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *LsOutput) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "HOST\tTIME\tSIZE\tRESULT")
+			for _, r := range out.Results {
+				result := "passed"
+				if !r.Passed {
+					result = "failed: " + r.Error
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Host, r.Time.Format(time.RFC3339),
+					humanize.IBytes(uint64(r.Size)), result)
+			}
+			return tw.Flush()
+		}),
+	},
+}
@@ -0,0 +1,249 @@
+package capacity
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/capacity"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+
+	humanize "github.com/dustin/go-humanize"
+	logging "github.com/ipfs/go-log"
+	"github.com/shirou/gopsutil/disk"
+)
+
+var log = logging.Logger("storage/capacity")
+
+const (
+	// plotTTL is how long a plot is kept waiting for its proof.
+	plotTTL  = time.Hour
+	plotsDir = "capacity"
+	// maxSamples bounds the blocks a host answers for at once.
+	maxSamples = 64
+)
+
+// plot is the plot this host is writing or keeps for its proof, there is
+// one at a time.
+type plot struct {
+	seed    string
+	dir     string
+	blocks  int64
+	written int64
+	cancel  context.CancelFunc
+
+	// set once done
+	done bool
+	root []byte
+	err  error
+}
+
+var (
+	plotMu  sync.Mutex
+	current *plot
+)
+
+// dropPlot removes the plot p unless another replaced it. plotMu is held.
+func dropPlot(p *plot) {
+	if current != p {
+		return
+	}
+	p.cancel()
+	current = nil
+	go func() {
+		if err := capacity.Remove(p.dir); err != nil {
+			log.Errorf("failed to remove the capacity plot %s: %s", p.dir, err)
+		}
+	}()
+}
+
+func parseSeed(s string) error {
+	if b, err := hex.DecodeString(s); err != nil || len(b) != 32 {
+		return fmt.Errorf("invalid seed %q", s)
+	}
+	return nil
+}
+
+var StorageCapacityPlotCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Plot the capacity to prove (host).",
+		ShortDescription: `
+Called by a renter or the hub, starts writing the plot of size bytes of the
+seed, dropping the plot this host kept for a previous proof.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("seed", true, false, "Hex encoded 32 bytes seed of the plot."),
+		cmds.StringArg("size", true, false, "Size of the plot in bytes."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if err := helper.HostEnabled(env); err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		root, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := capacity.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		seedHex := req.Arguments[0]
+		if err := parseSeed(seedHex); err != nil {
+			return err
+		}
+		size, err := strconv.ParseInt(req.Arguments[1], 10, 64)
+		if err != nil || size < capacity.BlockSize {
+			return fmt.Errorf("invalid size %q", req.Arguments[1])
+		}
+		if size > cfg.MaxPlotSize() {
+			return fmt.Errorf("size over the %s plots of this host", humanize.IBytes(uint64(cfg.MaxPlotSize())))
+		}
+
+		plotMu.Lock()
+		defer plotMu.Unlock()
+		if current != nil {
+			if !current.done {
+				return errors.New("already plotting")
+			}
+			dropPlot(current)
+		}
+		du, err := disk.UsageWithContext(req.Context, root)
+		if err != nil {
+			return err
+		}
+		if uint64(size) > du.Free {
+			return fmt.Errorf("only %s free", humanize.IBytes(du.Free))
+		}
+		seed, _ := hex.DecodeString(seedHex)
+		ctx, cancel := context.WithTimeout(context.Background(), plotTTL)
+		p := &plot{
+			seed:   seedHex,
+			dir:    filepath.Join(root, plotsDir, seedHex),
+			blocks: capacity.Blocks(size),
+			cancel: cancel,
+		}
+		current = p
+		go func() {
+			r, err := capacity.Plot(ctx, p.dir, seed, size, func(written int64) {
+				atomic.StoreInt64(&p.written, written)
+			})
+			plotMu.Lock()
+			p.done, p.root, p.err = true, r, err
+			plotMu.Unlock()
+			// the plot is temporary
+			<-ctx.Done()
+			plotMu.Lock()
+			dropPlot(p)
+			plotMu.Unlock()
+		}()
+		return nil
+	},
+}
+
+// PlotStatus is the progress of a plot.
+type PlotStatus struct {
+	Blocks  int64
+	Written int64
+	Done    bool
+	// Root is the Merkle root of the plot once done.
+	Root  []byte `json:",omitempty"`
+	Error string `json:",omitempty"`
+}
+
+// plotOf returns the plot of seed. plotMu is held.
+func plotOf(seed string) (*plot, error) {
+	if current == nil || current.seed != seed {
+		return nil, fmt.Errorf("no plot of seed %s", seed)
+	}
+	return current, nil
+}
+
+var StorageCapacityStatusCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the progress of a plot (host).",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("seed", true, false, "Hex encoded seed of the plot."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if err := helper.HostEnabled(env); err != nil {
+			return err
+		}
+		plotMu.Lock()
+		defer plotMu.Unlock()
+		p, err := plotOf(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		out := &PlotStatus{Blocks: p.blocks, Written: atomic.LoadInt64(&p.written), Done: p.done, Root: p.root}
+		if p.err != nil {
+			out.Error = p.err.Error()
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: PlotStatus{},
+}
+
+// ProveOutput opens the blocks of a plot asked for.
+type ProveOutput struct {
+	Openings []*capacity.Opening
+}
+
+var StorageCapacityProveCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Answer for blocks of a plot (host).",
+		ShortDescription: `
+Opens the blocks of the plot of the seed at the comma separated indices,
+then deletes the plot.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("seed", true, false, "Hex encoded seed of the plot."),
+		cmds.StringArg("indices", true, false, "Comma separated indices of the blocks."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if err := helper.HostEnabled(env); err != nil {
+			return err
+		}
+		var indices []int64
+		for _, s := range strings.Split(req.Arguments[1], ",") {
+			i, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid index %q", s)
+			}
+			indices = append(indices, i)
+		}
+		if len(indices) > maxSamples {
+			return fmt.Errorf("at most %d blocks can be proven at once", maxSamples)
+		}
+		plotMu.Lock()
+		defer plotMu.Unlock()
+		p, err := plotOf(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		if !p.done || p.err != nil {
+			return errors.New("plot not done")
+		}
+		openings, err := capacity.Prove(p.dir, indices)
+		if err != nil {
+			return err
+		}
+		dropPlot(p)
+		return cmds.EmitOnce(res, &ProveOutput{Openings: openings})
+	},
+	Type: ProveOutput{},
+}
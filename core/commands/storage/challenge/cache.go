@@ -0,0 +1,281 @@
+package challenge
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	lru "github.com/hashicorp/golang-lru"
+	cid "github.com/ipfs/go-cid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CacheConfigKey is the config section of the challenge cache of hosts.
+const CacheConfigKey = "ChallengeCache"
+
+// Defaults of the challenge cache.
+const (
+	DefaultMaxShards = 256
+	DefaultMaxProofs = 4096
+	DefaultWindow    = 10 * time.Minute
+)
+
+// CacheConfig configures the cache of the challenge responses of a host.
+//
+// The chunk lists of the shards challenged are cached, sparing the
+// traversal of their DAG on each challenge, and so are the proofs answered,
+// for a repeated challenge of the same chunk and nonce within the window to
+// be answered without reading the chunk again. A challenge with a new nonce
+// always reads its chunk, which keeps the challenges sound.
+type CacheConfig struct {
+	Disabled bool
+	// MaxShards bounds the shards whose chunk lists are cached,
+	// DefaultMaxShards when zero.
+	MaxShards int `json:",omitempty"`
+	// MaxProofs bounds the proofs cached, DefaultMaxProofs when zero.
+	MaxProofs int `json:",omitempty"`
+	// Window is how long a proof is reused for its nonce, e.g. "10m".
+	// DefaultWindow when empty.
+	Window string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(CacheConfigKey, CacheConfig{})
+	prometheus.MustRegister(cacheRequests, cacheInvalidations)
+}
+
+var (
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "challenge_cache",
+		Name:      "requests_total",
+		Help:      "Lookups of the challenge cache of the host, by cache and result.",
+	}, []string{"cache", "result"})
+	cacheInvalidations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "challenge_cache",
+		Name:      "invalidations_total",
+		Help:      "Entries of the challenge cache dropped on shard rewrites.",
+	})
+)
+
+// LoadCacheConfig returns the challenge cache config of r.
+func LoadCacheConfig(r repo.Repo) (*CacheConfig, error) {
+	c := &CacheConfig{}
+	if _, err := repo.GetConfigSection(r, CacheConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", CacheConfigKey, err)
+	}
+	if c.MaxShards < 0 || c.MaxProofs < 0 {
+		return nil, fmt.Errorf("invalid %s config: negative bound", CacheConfigKey)
+	}
+	if c.MaxShards == 0 {
+		c.MaxShards = DefaultMaxShards
+	}
+	if c.MaxProofs == 0 {
+		c.MaxProofs = DefaultMaxProofs
+	}
+	if c.Window == "" {
+		c.Window = DefaultWindow.String()
+	}
+	if d, err := time.ParseDuration(c.Window); err != nil || d <= 0 {
+		return nil, fmt.Errorf("invalid %s config: invalid window %q", CacheConfigKey, c.Window)
+	}
+	return c, nil
+}
+
+type chunksKey struct {
+	root, shard cid.Cid
+}
+
+type proofKey struct {
+	root  cid.Cid
+	shard cid.Cid
+	index int
+	nonce string
+}
+
+type proof struct {
+	cid  cid.Cid
+	hash string
+	at   time.Time
+}
+
+// counter counts the lookups of a cache.
+type counter struct {
+	hits, misses uint64
+	name         string
+}
+
+func (c *counter) count(hit bool) {
+	if hit {
+		atomic.AddUint64(&c.hits, 1)
+		cacheRequests.WithLabelValues(c.name, "hit").Inc()
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+		cacheRequests.WithLabelValues(c.name, "miss").Inc()
+	}
+}
+
+func (c *counter) stats(entries int) CacheStats {
+	s := CacheStats{Entries: entries, Hits: atomic.LoadUint64(&c.hits), Misses: atomic.LoadUint64(&c.misses)}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRate = float64(s.Hits) / float64(total)
+	}
+	return s
+}
+
+// Cache is the challenge cache of a host. A nil Cache caches nothing.
+type Cache struct {
+	invalidated uint64
+	window      time.Duration
+	chunks      *lru.Cache
+	proofs      *lru.Cache
+	chunksCount *counter
+	proofsCount *counter
+}
+
+// NewCache returns the cache configured by c, nil when disabled.
+func NewCache(c *CacheConfig) (*Cache, error) {
+	if c.Disabled {
+		return nil, nil
+	}
+	window, err := time.ParseDuration(c.Window)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := lru.New(c.MaxShards)
+	if err != nil {
+		return nil, err
+	}
+	proofs, err := lru.New(c.MaxProofs)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{
+		window:      window,
+		chunks:      chunks,
+		proofs:      proofs,
+		chunksCount: &counter{name: "chunks"},
+		proofsCount: &counter{name: "proofs"},
+	}, nil
+}
+
+func (c *Cache) getChunks(root, shard cid.Cid) ([]cid.Cid, bool) {
+	if c == nil {
+		return nil, false
+	}
+	v, ok := c.chunks.Get(chunksKey{root, shard})
+	c.chunksCount.count(ok)
+	if !ok {
+		return nil, false
+	}
+	return v.([]cid.Cid), true
+}
+
+func (c *Cache) putChunks(root, shard cid.Cid, cids []cid.Cid) {
+	if c == nil {
+		return
+	}
+	c.chunks.Add(chunksKey{root, shard}, cids)
+}
+
+func (c *Cache) getProof(root, shard cid.Cid, index int, nonce string, now time.Time) (*proof, bool) {
+	if c == nil {
+		return nil, false
+	}
+	k := proofKey{root, shard, index, nonce}
+	v, ok := c.proofs.Get(k)
+	if ok && now.Sub(v.(*proof).at) > c.window {
+		c.proofs.Remove(k)
+		ok = false
+	}
+	c.proofsCount.count(ok)
+	if !ok {
+		return nil, false
+	}
+	return v.(*proof), true
+}
+
+func (c *Cache) putProof(root, shard cid.Cid, index int, nonce string, p *proof) {
+	if c == nil {
+		return
+	}
+	c.proofs.Add(proofKey{root, shard, index, nonce}, p)
+}
+
+// Invalidate drops the entries of the shard or file hash h, to be called
+// when it is rewritten or removed.
+func (c *Cache) Invalidate(h cid.Cid) {
+	if c == nil {
+		return
+	}
+	var n uint64
+	for _, k := range c.chunks.Keys() {
+		if ck := k.(chunksKey); ck.root.Equals(h) || ck.shard.Equals(h) {
+			c.chunks.Remove(k)
+			n++
+		}
+	}
+	for _, k := range c.proofs.Keys() {
+		if pk := k.(proofKey); pk.root.Equals(h) || pk.shard.Equals(h) {
+			c.proofs.Remove(k)
+			n++
+		}
+	}
+	atomic.AddUint64(&c.invalidated, n)
+	cacheInvalidations.Add(float64(n))
+}
+
+// CacheStats are the lookups of a cache since the daemon started.
+type CacheStats struct {
+	Entries int
+	Hits    uint64
+	Misses  uint64
+	HitRate float64
+}
+
+// CacheOutput are the stats of the challenge cache.
+type CacheOutput struct {
+	Enabled     bool
+	Chunks      CacheStats
+	Proofs      CacheStats
+	Invalidated uint64
+}
+
+// Stats returns the stats of the cache.
+func (c *Cache) Stats() *CacheOutput {
+	if c == nil {
+		return &CacheOutput{}
+	}
+	return &CacheOutput{
+		Enabled:     true,
+		Chunks:      c.chunksCount.stats(c.chunks.Len()),
+		Proofs:      c.proofsCount.stats(c.proofs.Len()),
+		Invalidated: atomic.LoadUint64(&c.invalidated),
+	}
+}
+
+var (
+	hostCacheOnce sync.Once
+	hostCache     *Cache
+)
+
+// HostCache returns the challenge cache of the host, configured from r
+// the first time it is called. The cache is disabled when its config is
+// invalid.
+func HostCache(r repo.Repo) *Cache {
+	hostCacheOnce.Do(func() {
+		cfg, err := LoadCacheConfig(r)
+		if err == nil {
+			hostCache, err = NewCache(cfg)
+		}
+		if err != nil {
+			log.Errorf("challenge cache disabled: %s", err)
+		}
+	})
+	return hostCache
+}
@@ -0,0 +1,75 @@
+package challenge
+
+import (
+	"testing"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func testCid(t *testing.T, s string) cid.Cid {
+	h, err := mh.Sum([]byte(s), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cid.NewCidV0(h)
+}
+
+func TestCache(t *testing.T) {
+	c, err := NewCache(&CacheConfig{MaxShards: 2, MaxProofs: 2, Window: "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, shard, other := testCid(t, "root"), testCid(t, "shard"), testCid(t, "other")
+	if _, ok := c.getChunks(root, shard); ok {
+		t.Fatal("expected a miss")
+	}
+	c.putChunks(root, shard, []cid.Cid{root, shard})
+	if cids, ok := c.getChunks(root, shard); !ok || len(cids) != 2 {
+		t.Fatalf("expected a hit, got %v", cids)
+	}
+
+	now := time.Now()
+	c.putProof(root, shard, 1, "nonce", &proof{cid: shard, hash: "answer", at: now})
+	if p, ok := c.getProof(root, shard, 1, "nonce", now.Add(time.Second)); !ok || p.hash != "answer" {
+		t.Fatal("expected the proof of the repeated nonce")
+	}
+	if _, ok := c.getProof(root, shard, 1, "other nonce", now); ok {
+		t.Fatal("expected a new nonce to miss")
+	}
+	if _, ok := c.getProof(root, shard, 1, "nonce", now.Add(2*time.Minute)); ok {
+		t.Fatal("expected the proof to expire after the window")
+	}
+
+	c.putChunks(root, other, []cid.Cid{root, other})
+	c.putProof(root, shard, 1, "nonce", &proof{cid: shard, hash: "answer", at: now})
+	c.Invalidate(shard)
+	if _, ok := c.getChunks(root, shard); ok {
+		t.Fatal("expected the rewritten shard to be dropped")
+	}
+	if _, ok := c.getProof(root, shard, 1, "nonce", now); ok {
+		t.Fatal("expected the proofs of the rewritten shard to be dropped")
+	}
+	if _, ok := c.getChunks(root, other); !ok {
+		t.Fatal("expected the other shard to stay cached")
+	}
+
+	s := c.Stats()
+	if !s.Enabled || s.Invalidated != 2 || s.Chunks.Hits != 2 || s.Chunks.Misses != 2 ||
+		s.Proofs.Hits != 1 || s.Proofs.Misses != 3 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if s.Chunks.HitRate != 0.5 {
+		t.Fatalf("expected a 50%% hit rate, got %v", s.Chunks.HitRate)
+	}
+
+	var disabled *Cache
+	disabled.putChunks(root, shard, nil)
+	if _, ok := disabled.getChunks(root, shard); ok {
+		t.Fatal("expected a disabled cache to miss")
+	}
+	if disabled.Stats().Enabled {
+		t.Fatal("expected a disabled cache")
+	}
+}
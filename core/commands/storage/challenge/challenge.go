@@ -2,18 +2,25 @@ package challenge
 
 import (
 	"fmt"
+	"io"
 	"strconv"
 	"time"
 
 	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
 	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/core/diskhealth"
+	"github.com/TRON-US/go-btfs/core/ingest"
+	"github.com/TRON-US/go-btfs/core/power"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	"github.com/tron-us/go-common/v2/json"
 
 	cidlib "github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log"
 )
 
+var log = logging.Logger("core/commands/storage/challenge")
+
 var StorageChallengeCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Interact with storage challenge requests and responses.",
@@ -21,11 +28,13 @@ var StorageChallengeCmd = &cmds.Command{
 These commands contain both client-side and host-side challenge functions.
 
 btfs storage challenge request <peer-id> <contract-id> <file-hash> <shard-hash> <chunk-index> <nonce>
-btfs storage challenge response <contract-id> <file-hash> <shard-hash> <chunk-index> <nonce>`,
+btfs storage challenge response <contract-id> <file-hash> <shard-hash> <chunk-index> <nonce>
+btfs storage challenge cache`,
 	},
 	Subcommands: map[string]*cmds.Command{
 		"request":  storageChallengeRequestCmd,
 		"response": StorageChallengeResponseCmd,
+		"cache":    storageChallengeCacheCmd,
 	},
 }
 
@@ -34,7 +43,10 @@ var storageChallengeRequestCmd = &cmds.Command{
 		Tagline: "Challenge storage hosts with Proof-of-Storage requests.",
 		ShortDescription: `
 This command challenges storage hosts on behalf of a client to see if hosts
-still store a piece of file (usually a shard) as agreed in storage contract.`,
+still store a piece of file (usually a shard) as agreed in storage contract.
+A host failing to read the chunk challenged reports the chunk, the offset the
+read failed at and the kind of the failure: transient for an IO hiccup worth
+a retry, or disk, missing or corrupt for data the host may have lost.`,
 	},
 	Arguments: append([]cmds.Argument{
 		cmds.StringArg("peer-id", true, false, "Host Peer ID to send challenge requests."),
@@ -71,6 +83,9 @@ still store a piece of file (usually a shard) as agreed in storage contract.`,
 		// Pass arguments through to host response endpoint
 		resp, err := remote.P2PCallStrings(req.Context, n, api, pi.ID, "/storage/challenge/response",
 			req.Arguments[1:]...)
+		if ce, ok := ParseChunkError(err); ok && ce.Transient() {
+			return fmt.Errorf("%s, retry the challenge later", err)
+		}
 		if err != nil {
 			return err
 		}
@@ -98,7 +113,10 @@ var StorageChallengeResponseCmd = &cmds.Command{
 		Tagline: "Storage host responds to Proof-of-Storage requests.",
 		ShortDescription: `
 This command (on host) reads the challenge question and returns the answer to
-the challenge request back to the caller.`,
+the challenge request back to the caller. When the chunk cannot be read, the
+error tells the chunk, the offset and the error code of the failure, and its
+kind, parsed by the guard and the renter with ParseChunkError. The failures
+are exported as the btfs_challenge_chunk_failures_total metric.`,
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg("contract-id", true, false, "Contract ID associated with the challenge requests."),
@@ -108,7 +126,7 @@ the challenge request back to the caller.`,
 		cmds.StringArg("nonce", true, false, "Nonce for this challenge. A random UUIDv4 string."),
 	},
 	RunTimeout: 1 * time.Minute,
-	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) (err error) {
 		cfg, err := cmdenv.GetConfig(env)
 		if err != nil {
 			return err
@@ -117,12 +135,25 @@ the challenge request back to the caller.`,
 			return fmt.Errorf("storage host api not enabled")
 		}
 		res.RecordEvent("HGetConfig")
+		defer func() {
+			recordResult(req.Arguments[0], req.Arguments[2], err)
+		}()
+		// the challenges answered hold off the shards uploaded
+		defer ingest.Default.Challenge()()
 
 		n, err := cmdenv.GetNode(env)
 		if err != nil {
 			return err
 		}
 		res.RecordEvent("HGetNode")
+		// a host in low-power mode is in maintenance, it reads no shard
+		pcfg, err := power.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		if err := pcfg.ChallengeError(); err != nil {
+			return err
+		}
 		api, err := cmdenv.GetApi(env, req)
 		if err != nil {
 			return err
@@ -147,12 +178,18 @@ the challenge request back to the caller.`,
 		nonce := req.Arguments[4]
 		// Get (cached) challenge response object and solve challenge
 		sc, err := NewStorageChallengeResponse(req.Context, n, api, fileHash, shardHash, "", false, 0)
-		if err != nil {
-			return err
+		if err == nil {
+			res.RecordEvent("HNewResponse")
+			err = sc.SolveChallenge(chunkIndex, nonce)
+		}
+		// the shard reads feed the disk failure prediction, unless cut short
+		// or answered from the cache
+		if req.Context.Err() == nil && (err != nil || !sc.Cached) {
+			rerr := diskhealth.RecordRead(n.Repo.Datastore(), n.Identity.Pretty(), time.Now(), err)
+			if rerr != nil {
+				log.Errorf("failed to record shard read: %s", rerr)
+			}
 		}
-		res.RecordEvent("HNewResponse")
-
-		err = sc.SolveChallenge(chunkIndex, nonce)
 		if err != nil {
 			return err
 		}
@@ -164,3 +201,47 @@ the challenge request back to the caller.`,
 	},
 	Type: StorageChallengeRes{},
 }
+
+var storageChallengeCacheCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the hit rates of the challenge cache of the host.",
+		ShortDescription: `
+A host caches the chunk lists of the shards challenged, and the proofs it
+answered so that a challenge repeated with the same nonce is answered
+without reading the chunk again. A challenge with a new nonce always reads
+its chunk. The cache is configured when the daemon starts, e.g.:
+
+    $ btfs config --json ChallengeCache.MaxShards 1024
+    $ btfs config --json ChallengeCache.MaxProofs 16384
+    $ btfs config ChallengeCache.Window 30m
+    $ btfs config --json ChallengeCache.Disabled true
+
+The hits and misses are also exported as the prometheus metric
+btfs_challenge_cache_requests_total.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, HostCache(n.Repo).Stats())
+	},
+	Type: CacheOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *CacheOutput) error {
+			if !out.Enabled {
+				fmt.Fprintln(w, "challenge cache disabled")
+				return nil
+			}
+			for _, c := range []struct {
+				name string
+				s    CacheStats
+			}{{"chunks", out.Chunks}, {"proofs", out.Proofs}} {
+				fmt.Fprintf(w, "%s: %d entries, %d hits, %d misses, %.1f%% hit rate\n",
+					c.name, c.s.Entries, c.s.Hits, c.s.Misses, c.s.HitRate*100)
+			}
+			fmt.Fprintf(w, "invalidated: %d entries\n", out.Invalidated)
+			return nil
+		}),
+	},
+}
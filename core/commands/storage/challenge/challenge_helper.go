@@ -8,6 +8,7 @@ import (
 	"io"
 	"math/big"
 	"sync"
+	"time"
 
 	core "github.com/TRON-US/go-btfs/core"
 	coreiface "github.com/TRON-US/interface-go-btfs-core"
@@ -37,6 +38,9 @@ type StorageChallenge struct {
 	Nonce      string  // Random nonce for each challenge request (uuidv4)
 	Hash       string  // Generated SHA-256 hash (chunk bytes + nonce bytes) for proof-of-file-existence
 	Expiration uint64  // End date of the pinned chunks
+	Cached     bool    // Whether Hash was answered from the challenge cache
+
+	cache *Cache // Challenge cache of the host, nil when not caching
 }
 
 // newStorageChallengeHelper creates a challenge object with new ID, resolves the cid path
@@ -74,9 +78,33 @@ func NewStorageChallenge(ctx context.Context, node *core.IpfsNode, api coreiface
 	return newStorageChallengeHelper(ctx, node, api, rootHash, shardHash, "", false, 0)
 }
 
+// NewStorageChallengeResponse creates the challenge object of a host. The
+// chunks of the shard are listed from the challenge cache of the host when
+// cached, init (re)storing the shard drops its cached entries.
 func NewStorageChallengeResponse(ctx context.Context, node *core.IpfsNode, api coreiface.CoreAPI,
 	rootHash, shardHash cid.Cid, challengeID string, init bool, expir uint64) (*StorageChallenge, error) {
-	return newStorageChallengeHelper(ctx, node, api, rootHash, shardHash, challengeID, init, expir)
+	cache := HostCache(node.Repo)
+	if init {
+		cache.Invalidate(shardHash)
+	} else if cids, ok := cache.getChunks(rootHash, shardHash); ok {
+		return &StorageChallenge{
+			Ctx:     ctx,
+			Node:    node,
+			API:     api,
+			allCIDs: cids,
+			ID:      challengeID,
+			RID:     rootHash,
+			SID:     shardHash,
+			cache:   cache,
+		}, nil
+	}
+	sc, err := newStorageChallengeHelper(ctx, node, api, rootHash, shardHash, challengeID, init, expir)
+	if err != nil {
+		return nil, err
+	}
+	cache.putChunks(rootHash, shardHash, sc.allCIDs)
+	sc.cache = cache
+	return sc, nil
 }
 
 // getAllCIDsRecursive traverses the full DAG to find all cids and
@@ -94,7 +122,7 @@ func (sc *StorageChallenge) getAllCIDsRecursive(blockHash cid.Cid, init bool) er
 	// Check if can be resolved
 	rp, err := sc.API.ResolvePath(sc.Ctx, path.IpfsPath(blockHash))
 	if err != nil {
-		return err
+		return sc.chunkError(len(sc.allCIDs), blockHash, 0, err)
 	}
 	// Mark as seen
 	sc.seenCIDs[ncs] = true
@@ -102,7 +130,7 @@ func (sc *StorageChallenge) getAllCIDsRecursive(blockHash cid.Cid, init bool) er
 	// Recurse
 	links, err := sc.API.Object().Links(sc.Ctx, rp)
 	if err != nil {
-		return err
+		return sc.chunkError(len(sc.allCIDs)-1, blockHash, 0, err)
 	}
 	// Check if we are at the shard hash level - only check selected shard
 	for _, l := range links {
@@ -180,30 +208,55 @@ func (sc *StorageChallenge) SolveChallenge(chIndex int, chNonce string) error {
 		return fmt.Errorf("chunk index is out of range")
 	}
 
+	// Decode nonce
+	nonce, err := uuid.Parse(chNonce)
+	if err != nil {
+		return err
+	}
+
+	// Answer a repeated challenge from the cache
+	now := time.Now()
+	if p, ok := sc.cache.getProof(sc.RID, sc.SID, chIndex, chNonce, now); ok {
+		sc.CID = p.cid
+		sc.CIndex = chIndex
+		sc.Nonce = chNonce
+		sc.Hash = p.hash
+		sc.Cached = true
+		return nil
+	}
+
 	// Fetch the raw data
 	chHash := sc.allCIDs[chIndex]
 	r, _, err := sc.API.Object().Data(sc.Ctx, path.IpfsPath(chHash), true, false)
 	if err != nil {
-		return err
+		return sc.chunkError(chIndex, chHash, 0, err)
 	}
 	sc.CID = chHash
 	sc.CIndex = chIndex
-
-	// Decode nonce
-	nonce, err := uuid.Parse(chNonce)
-	if err != nil {
-		return err
-	}
 	sc.Nonce = chNonce
+	sc.Cached = false
 
 	// Re-hash to solve challenge
 	h := sha256.New()
-	if _, err := io.Copy(h, r); err != nil {
-		return err
+	if n, err := io.Copy(h, r); err != nil {
+		return sc.chunkError(chIndex, chHash, n, err)
 	}
 	nb := [16]byte(nonce)
 	h.Write(nb[:])
 	sc.Hash = fmt.Sprintf("%x", h.Sum(nil))
+	sc.cache.putProof(sc.RID, sc.SID, chIndex, chNonce, &proof{cid: chHash, hash: sc.Hash, at: now})
 
 	return nil
 }
+
+// chunkError returns the failure to read the chunk index of hash c after
+// offset bytes, telling a chunk lost from a read failing for a while.
+func (sc *StorageChallenge) chunkError(index int, c cid.Cid, offset int64, err error) error {
+	stored := true
+	if sc.Node != nil && sc.Node.Blockstore != nil {
+		if has, herr := sc.Node.Blockstore.Has(c); herr == nil {
+			stored = has
+		}
+	}
+	return newChunkError(index, c.String(), offset, stored, err)
+}
@@ -0,0 +1,166 @@
+package challenge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"syscall"
+
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Kinds of the chunk failures, telling the IO hiccups a host recovers from
+// from the data it lost.
+const (
+	// KindTransient is a read cut short or refused for a while: a timeout,
+	// an interrupted call, a busy device or no memory left.
+	KindTransient = "transient"
+	// KindDisk is an error of the storage device, e.g. a bad sector.
+	KindDisk = "disk"
+	// KindMissing is a chunk the host no longer stores.
+	KindMissing = "missing"
+	// KindCorrupt is a chunk read whose hash is not its CID.
+	KindCorrupt = "corrupt"
+	// KindUnknown is any other failure.
+	KindUnknown = "unknown"
+)
+
+var chunkFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "btfs",
+	Subsystem: "challenge",
+	Name:      "chunk_failures_total",
+	Help:      "Chunks the host failed to read to answer a challenge, by kind and error code.",
+}, []string{"kind", "code"})
+
+func init() {
+	prometheus.MustRegister(chunkFailures)
+}
+
+// ChunkError is the failure of a host to read the chunk of a challenge,
+// reported to the challenger in its message: the guard and the renter
+// parse it with ParseChunkError.
+type ChunkError struct {
+	// Index is the index of the chunk challenged, CID its hash.
+	Index int
+	CID   string
+	// Offset is the number of bytes of the chunk read before the failure.
+	Offset int64
+	// Code is the name of the error, the errno of a system call such as
+	// EIO, or notfound, hashmismatch, timeout or canceled.
+	Code    string
+	Kind    string
+	Message string
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk-failure %d %s at offset %d: %s %s: %s",
+		e.Index, e.CID, e.Offset, e.Kind, e.Code, e.Message)
+}
+
+// Transient reports whether the host may answer the chunk once retried.
+func (e *ChunkError) Transient() bool {
+	return e.Kind == KindTransient
+}
+
+// newChunkError returns the failure to read the chunk index of hash c after
+// offset bytes. stored reports whether the chunk is still in the
+// blockstore of the host, since a chunk missing there is looked up in the
+// network until the challenge times out.
+func newChunkError(index int, c string, offset int64, stored bool, err error) *ChunkError {
+	var ce *ChunkError
+	if errors.As(err, &ce) {
+		return ce
+	}
+	code, kind := classify(err)
+	if !stored {
+		kind = KindMissing
+	}
+	return &ChunkError{Index: index, CID: c, Offset: offset, Code: code, Kind: kind, Message: err.Error()}
+}
+
+// classify returns the code and the kind of the read failure err.
+func classify(err error) (string, string) {
+	var errno syscall.Errno
+	switch {
+	case errors.Is(err, ipld.ErrNotFound), errors.Is(err, blockstore.ErrNotFound), os.IsNotExist(err):
+		return "notfound", KindMissing
+	case errors.Is(err, blockstore.ErrHashMismatch):
+		return "hashmismatch", KindCorrupt
+	case errors.Is(err, context.DeadlineExceeded), os.IsTimeout(err):
+		return "timeout", KindTransient
+	case errors.Is(err, context.Canceled):
+		return "canceled", KindTransient
+	case errors.As(err, &errno):
+		return errnoName(errno), errnoKind(errno)
+	}
+	return "unknown", KindUnknown
+}
+
+// errnoKind returns the kind of the failure of a system call.
+func errnoKind(errno syscall.Errno) string {
+	switch errno {
+	case syscall.EIO, syscall.ENXIO, syscall.ENODEV, syscall.EROFS:
+		return KindDisk
+	case syscall.ENOENT:
+		return KindMissing
+	case syscall.EINTR, syscall.EAGAIN, syscall.EBUSY, syscall.ENOMEM, syscall.EMFILE, syscall.ENFILE:
+		return KindTransient
+	}
+	if errno.Timeout() || errno.Temporary() {
+		return KindTransient
+	}
+	return KindUnknown
+}
+
+var errnoNames = map[syscall.Errno]string{
+	syscall.EIO:    "EIO",
+	syscall.ENXIO:  "ENXIO",
+	syscall.ENODEV: "ENODEV",
+	syscall.EROFS:  "EROFS",
+	syscall.ENOENT: "ENOENT",
+	syscall.EINTR:  "EINTR",
+	syscall.EAGAIN: "EAGAIN",
+	syscall.EBUSY:  "EBUSY",
+	syscall.ENOMEM: "ENOMEM",
+	syscall.EMFILE: "EMFILE",
+	syscall.ENFILE: "ENFILE",
+	syscall.EACCES: "EACCES",
+	syscall.ENOSPC: "ENOSPC",
+}
+
+// errnoName returns the name of errno, its number when not known.
+func errnoName(errno syscall.Errno) string {
+	if name, ok := errnoNames[errno]; ok {
+		return name
+	}
+	return "errno" + strconv.Itoa(int(errno))
+}
+
+var chunkErrorRe = regexp.MustCompile(`chunk-failure (-?\d+) (\S+) at offset (\d+): (\S+) (\S+): (.*)`)
+
+// ParseChunkError returns the chunk failure in err, the message of a
+// ChunkError, possibly wrapped by the remote call.
+func ParseChunkError(err error) (*ChunkError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var ce *ChunkError
+	if errors.As(err, &ce) {
+		return ce, true
+	}
+	m := chunkErrorRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return nil, false
+	}
+	index, ierr := strconv.Atoi(m[1])
+	offset, oerr := strconv.ParseInt(m[3], 10, 64)
+	if ierr != nil || oerr != nil {
+		return nil, false
+	}
+	return &ChunkError{Index: index, CID: m[2], Offset: offset, Kind: m[4], Code: m[5], Message: m[6]}, true
+}
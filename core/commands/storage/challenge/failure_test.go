@@ -0,0 +1,56 @@
+package challenge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+func TestClassify(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		code string
+		kind string
+	}{
+		{fmt.Errorf("get block: %w", ipld.ErrNotFound), "notfound", KindMissing},
+		{blockstore.ErrHashMismatch, "hashmismatch", KindCorrupt},
+		{context.DeadlineExceeded, "timeout", KindTransient},
+		{&os.PathError{Op: "read", Path: "/blocks/CIQ", Err: syscall.EIO}, "EIO", KindDisk},
+		{&os.PathError{Op: "open", Path: "/blocks/CIQ", Err: syscall.EMFILE}, "EMFILE", KindTransient},
+		{&os.PathError{Op: "open", Path: "/blocks/CIQ", Err: syscall.ENOENT}, "notfound", KindMissing},
+		{fmt.Errorf("reed solomon mismatch"), "unknown", KindUnknown},
+	} {
+		if code, kind := classify(c.err); code != c.code || kind != c.kind {
+			t.Errorf("%v: expected %s %s, got %s %s", c.err, c.code, c.kind, code, kind)
+		}
+	}
+	if ce := newChunkError(3, "QmChunk", 0, false, context.DeadlineExceeded); ce.Kind != KindMissing {
+		t.Fatalf("expected a chunk not stored missing, got %+v", ce)
+	}
+}
+
+func TestParseChunkError(t *testing.T) {
+	ce := newChunkError(12, "QmChunk", 4096, true, &os.PathError{Op: "read", Path: "/blocks/CIQ", Err: syscall.EIO})
+	err := fmt.Errorf("remote call failed: %s", ce)
+	got, ok := ParseChunkError(err)
+	if !ok {
+		t.Fatalf("expected a chunk failure in %q", err)
+	}
+	if *got != *ce {
+		t.Fatalf("expected %+v, got %+v", ce, got)
+	}
+	if got.Transient() {
+		t.Fatal("expected a disk error not transient")
+	}
+	if _, ok := ParseChunkError(fmt.Errorf("chunk index is out of range")); ok {
+		t.Fatal("parsed a chunk failure from another error")
+	}
+	if _, ok := ParseChunkError(nil); ok {
+		t.Fatal("parsed a chunk failure from no error")
+	}
+}
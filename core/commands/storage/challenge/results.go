@@ -0,0 +1,55 @@
+package challenge
+
+import (
+	"sync"
+	"time"
+)
+
+// maxResults is the number of challenge results kept in memory.
+const maxResults = 50
+
+// Result is the outcome of a challenge this host answered.
+type Result struct {
+	Time       time.Time
+	ContractID string
+	ShardHash  string
+	Error      string `json:",omitempty"`
+	// Chunk is the failure to read the chunk challenged, if so.
+	Chunk *ChunkError `json:",omitempty"`
+}
+
+var (
+	resultsLk sync.Mutex
+	results   []Result
+)
+
+// recordResult keeps the outcome of a challenge, dropping the oldest once
+// maxResults are kept.
+func recordResult(contractID, shardHash string, err error) {
+	r := Result{Time: time.Now(), ContractID: contractID, ShardHash: shardHash}
+	if err != nil {
+		r.Error = err.Error()
+		if ce, ok := ParseChunkError(err); ok {
+			r.Chunk = ce
+			chunkFailures.WithLabelValues(ce.Kind, ce.Code).Inc()
+		}
+	}
+	resultsLk.Lock()
+	defer resultsLk.Unlock()
+	if len(results) == maxResults {
+		results = results[1:]
+	}
+	results = append(results, r)
+}
+
+// RecentResults returns the results of the latest challenges answered
+// since the daemon started, newest first.
+func RecentResults() []Result {
+	resultsLk.Lock()
+	defer resultsLk.Unlock()
+	out := make([]Result, len(results))
+	for i, r := range results {
+		out[len(results)-1-i] = r
+	}
+	return out
+}
@@ -0,0 +1,315 @@
+// Package collateral has hosts lock BTT for each of their contracts as a
+// guarantee: the collateral stays in a ledger channel to the escrow until
+// the contract ends, and is slashed to the escrow when the guard proves the
+// host lost the data of the contract.
+package collateral
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	config "github.com/TRON-US/go-btfs-config"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+
+	logging "github.com/ipfs/go-log"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var log = logging.Logger("storage/collateral")
+
+// queryTimeout bounds getting the terms of a host.
+const queryTimeout = 30 * time.Second
+
+var StorageCollateralCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Lock collateral for the host contracts, and check the collateral of hosts.",
+		ShortDescription: `
+With Collateral.Enabled set, a host locks Collateral.Amount µBTT of its ledger
+for each contract it accepts, in a channel to the escrow, and posts the
+channel state slashing it to the renter:
+
+    $ btfs config --json Collateral.Enabled true
+    $ btfs config --json Collateral.Amount 1000000
+
+The host releases the collateral once the contract ended. When the guard
+proves the host lost the data of the contract, the renter slashes the
+collateral to the escrow instead.
+
+Renters get the collateral terms of hosts with 'btfs storage collateral
+query', and see them in 'btfs storage market ls', whose --collateralized
+lists only the hosts posting collateral.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":    storageCollateralLsCmd,
+		"query": storageCollateralQueryCmd,
+		"terms": StorageCollateralTermsCmd,
+		"post":  StorageCollateralPostCmd,
+	},
+}
+
+var storageCollateralLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the collateral locks of the contracts of this node.",
+		ShortDescription: `
+Lists the collateral this node locked as a host, and the collateral posted to
+it as a renter, the latest first.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		ls, err := ListLocks(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, ls)
+	},
+	Type: []*Lock{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ls []*Lock) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "CONTRACT\tROLE\tPEER\tAMOUNT\tUNTIL\tSTATE")
+			for _, l := range ls {
+				p := l.Host
+				if l.Role == RoleHost {
+					p = l.Renter
+				}
+				state := l.State
+				if l.Error != "" {
+					state += ": " + l.Error
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", l.ContractID, l.Role, p, l.Amount,
+					l.Until.Format(time.RFC3339), state)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var storageCollateralQueryCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Get the collateral terms of hosts.",
+		ShortDescription: `
+Gets the collateral the hosts lock per contract, and saves it for
+'btfs storage market ls'.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("host-id", true, true, "Peer ID of the host."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		ts := make([]*Terms, 0, len(req.Arguments))
+		for _, id := range req.Arguments {
+			pid, err := peer.Decode(id)
+			if err != nil {
+				return fmt.Errorf("invalid host ID %s: %s", id, err)
+			}
+			t, err := queryTerms(req.Context, n, api, pid)
+			if err != nil {
+				return fmt.Errorf("cannot get the collateral terms of %s: %s", id, err)
+			}
+			if err := PutTerms(n.Repo.Datastore(), n.Identity.Pretty(), t); err != nil {
+				return err
+			}
+			ts = append(ts, t)
+		}
+		return cmds.EmitOnce(res, ts)
+	},
+	Type: []*Terms{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ts []*Terms) error {
+			for _, t := range ts {
+				if t.Amount == 0 {
+					fmt.Fprintf(w, "%s: no collateral\n", t.Host)
+					continue
+				}
+				fmt.Fprintf(w, "%s: %d µBTT per contract\n", t.Host, t.Amount)
+			}
+			return nil
+		}),
+	},
+}
+
+func queryTerms(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, host peer.ID) (*Terms, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	b, err := remote.P2PCallStrings(ctx, n, api, host, "/storage/collateral/terms")
+	if err != nil {
+		return nil, err
+	}
+	t := &Terms{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, err
+	}
+	if t.Host != host.Pretty() || t.Amount < 0 {
+		return nil, errors.New("invalid terms")
+	}
+	t.Checked = time.Now()
+	return t, nil
+}
+
+// StorageCollateralTermsCmd is called by renters for the collateral terms
+// of this host.
+var StorageCollateralTermsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the collateral this host locks per contract.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageHostEnabled {
+			return fmt.Errorf("storage host api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		c, err := Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, c.Terms(n.Identity.Pretty()))
+	},
+	Type: Terms{},
+}
+
+// StorageCollateralPostCmd is called by hosts to post the collateral of a
+// contract of this renter.
+var StorageCollateralPostCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Accept the collateral a host posts for a contract.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("posting", true, false, "Collateral posting of the host, in JSON."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		host, ok := remote.GetStreamRequestRemotePeerID(req, n)
+		if !ok {
+			return fmt.Errorf("fail to get peer ID from request")
+		}
+		p := &Posting{}
+		if err := json.Unmarshal([]byte(req.Arguments[0]), p); err != nil {
+			return fmt.Errorf("invalid collateral posting: %s", err)
+		}
+		if p.Host != host.Pretty() {
+			return errors.New("collateral posting of another host")
+		}
+		hostKey, err := host.ExtractPublicKey()
+		if err != nil {
+			return err
+		}
+		escrowKey, err := escrowPubKey(cfg)
+		if err != nil {
+			return err
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		if ok, err := HasLock(d, self, p.ContractID); err != nil {
+			return err
+		} else if ok {
+			return fmt.Errorf("collateral of contract %s posted already", p.ContractID)
+		}
+		l, err := Accept(p, hostKey, escrowKey, self, time.Now())
+		if err != nil {
+			return err
+		}
+		return PutLock(d, self, l)
+	},
+}
+
+// Post locks the collateral of the contract contractID of the host n for
+// renter until the contract ends, when Collateral.Enabled is set, and
+// posts it to the renter. The collateral is released at once when the
+// renter does not take it.
+func Post(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, cfg *config.Config, contractID string,
+	renter peer.ID, until time.Time) error {
+	c, err := Load(n.Repo)
+	if err != nil {
+		return err
+	}
+	if !c.Enabled {
+		return nil
+	}
+	escrowKey, err := escrowPubKey(cfg)
+	if err != nil {
+		return err
+	}
+	privKey, err := cfg.Identity.DecodePrivateKey("")
+	if err != nil {
+		return err
+	}
+	client := escrowclient.New(cfg.Services.EscrowDomain)
+	l, p, err := LockFor(ctx, client, privKey, escrowKey, contractID, n.Identity.Pretty(), renter.Pretty(),
+		c.Amount, until, time.Now())
+	if err != nil {
+		return err
+	}
+	d, self := n.Repo.Datastore(), n.Identity.Pretty()
+	if err := PutLock(d, self, l); err != nil {
+		return err
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if _, err := remote.P2PCallStrings(ctx, n, api, renter, "/storage/collateral/post", string(b)); err != nil {
+		if cerr := closeChannel(ctx, client, l); cerr != nil {
+			log.Errorf("release collateral of contract %s: %s", contractID, cerr)
+			l.Error = cerr.Error()
+		} else {
+			l.State, l.Closed = StateReleased, time.Now()
+		}
+		if perr := PutLock(d, self, l); perr != nil {
+			return perr
+		}
+		return fmt.Errorf("cannot post the collateral of contract %s: %s", contractID, err)
+	}
+	return nil
+}
+
+func escrowPubKey(cfg *config.Config) (ic.PubKey, error) {
+	if len(cfg.Services.EscrowPubKeys) == 0 {
+		return nil, fmt.Errorf("No Services.EscrowPubKeys are set in config")
+	}
+	return helper.ConvertToPubKey(cfg.Services.EscrowPubKeys[0])
+}
@@ -0,0 +1,384 @@
+package collateral
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/tron-us/go-btfs-common/crypto"
+	"github.com/tron-us/go-btfs-common/ledger"
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+	"github.com/tron-us/protobuf/proto"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// ConfigKey is the config section of the host collateral.
+const ConfigKey = "Collateral"
+
+// Roles of the node in a collateral lock.
+const (
+	RoleHost   = "host"
+	RoleRenter = "renter"
+)
+
+// States of a collateral lock.
+const (
+	StateLocked   = "locked"
+	StateReleased = "released"
+	StateSlashed  = "slashed"
+)
+
+const (
+	lockKeyPrefix  = "/btfs/%s/collateral/locks/"
+	termsKeyPrefix = "/btfs/%s/collateral/terms/"
+)
+
+// Config configures the collateral a host locks for its contracts.
+type Config struct {
+	// Enabled makes the host lock Amount for each contract it accepts.
+	Enabled bool
+	// Amount is the collateral locked per contract, in µBTT.
+	Amount int64
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the collateral config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if c.Amount < 0 || (c.Enabled && c.Amount == 0) {
+		return errors.New("Amount must be positive")
+	}
+	return nil
+}
+
+// Terms are the collateral a host locks per contract, none when Amount is
+// 0.
+type Terms struct {
+	Host   string
+	Amount int64
+	// Checked is when a renter got the terms from the host.
+	Checked time.Time `json:",omitempty"`
+}
+
+// Terms returns the collateral terms of the host.
+func (c *Config) Terms(host string) *Terms {
+	t := &Terms{Host: host}
+	if c.Enabled {
+		t.Amount = c.Amount
+	}
+	return t
+}
+
+// Lock is the collateral of a contract, locked by its host in a ledger
+// channel to the escrow until the contract ends.
+type Lock struct {
+	ContractID string
+	Role       string
+	Host       string
+	Renter     string
+	Amount     int64
+	ChannelID  int64
+	Until      time.Time
+	State      string
+	Locked     time.Time
+	Closed     time.Time `json:",omitempty"`
+	// Settle is the channel state signed by the host this node closes the
+	// channel with: the release refunding the host for the host, the slash
+	// paying the escrow for the renter.
+	Settle []byte `json:",omitempty"`
+	Error  string `json:",omitempty"`
+}
+
+// Posting is the collateral of a contract a host posts to its renter, with
+// the channel state slashing it.
+type Posting struct {
+	ContractID string
+	Host       string
+	Amount     int64
+	ChannelID  int64
+	Until      time.Time
+	Slash      []byte
+}
+
+// LockFor locks amount of the ledger of the host privKey in a channel to
+// the escrow escrowKey for the contract of renter until the time until,
+// and returns the lock of the host with the posting for the renter.
+func LockFor(ctx context.Context, client escrowclient.Client, privKey ic.PrivKey, escrowKey ic.PubKey,
+	contractID, host, renter string, amount int64, until, now time.Time) (*Lock, *Posting, error) {
+	if amount <= 0 {
+		return nil, nil, errors.New("collateral must be positive")
+	}
+	commit, err := ledger.NewChannelCommit(privKey.GetPublic(), escrowKey, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := crypto.Sign(privKey, commit)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := client.CreateChannel(ctx, ledger.NewSignedChannelCommit(commit, sig))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot lock the collateral: %s", err)
+	}
+	release, err := signedState(privKey, escrowKey, id, amount, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	slash, err := signedState(privKey, escrowKey, id, 0, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	l := &Lock{ContractID: contractID, Role: RoleHost, Host: host, Renter: renter, Amount: amount,
+		ChannelID: id.GetId(), Until: until, State: StateLocked, Locked: now, Settle: release}
+	p := &Posting{ContractID: contractID, Host: host, Amount: amount, ChannelID: id.GetId(), Until: until,
+		Slash: slash}
+	return l, p, nil
+}
+
+func signedState(privKey ic.PrivKey, escrowKey ic.PubKey, id *ledgerpb.ChannelID, hostBalance,
+	escrowBalance int64) ([]byte, error) {
+	from, err := ledger.NewAccount(privKey.GetPublic(), hostBalance)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ledger.NewAccount(escrowKey, escrowBalance)
+	if err != nil {
+		return nil, err
+	}
+	s := ledger.NewChannelState(id, 1, from, to)
+	sig, err := crypto.Sign(privKey, s)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(ledger.NewSignedChannelState(s, sig, nil))
+}
+
+// Accept checks the posting p of the host hostKey for a renter contract,
+// and returns the lock of the renter slashing it with the escrow
+// escrowKey.
+func Accept(p *Posting, hostKey, escrowKey ic.PubKey, renter string, now time.Time) (*Lock, error) {
+	if p.ContractID == "" || p.Amount <= 0 {
+		return nil, errors.New("invalid collateral posting")
+	}
+	s := &ledgerpb.SignedChannelState{}
+	if err := proto.Unmarshal(p.Slash, s); err != nil {
+		return nil, fmt.Errorf("invalid slash state: %s", err)
+	}
+	hostAddr, err := ic.RawFull(hostKey)
+	if err != nil {
+		return nil, err
+	}
+	escrowAddr, err := ic.RawFull(escrowKey)
+	if err != nil {
+		return nil, err
+	}
+	c := s.GetChannel()
+	switch {
+	case c.GetId().GetId() != p.ChannelID:
+		return nil, errors.New("slash state of another channel")
+	case string(c.GetFrom().GetAddress().GetKey()) != string(hostAddr) ||
+		string(c.GetTo().GetAddress().GetKey()) != string(escrowAddr):
+		return nil, errors.New("slash state of a channel not from the host to the escrow")
+	case c.GetFrom().GetBalance() != 0 || c.GetTo().GetBalance() != p.Amount:
+		return nil, errors.New("slash state not paying the collateral to the escrow")
+	}
+	if ok, err := crypto.Verify(hostKey, c, s.GetFromSignature()); err != nil || !ok {
+		return nil, errors.New("slash state not signed by the host")
+	}
+	return &Lock{ContractID: p.ContractID, Role: RoleRenter, Host: p.Host, Renter: renter, Amount: p.Amount,
+		ChannelID: p.ChannelID, Until: p.Until, State: StateLocked, Locked: now, Settle: p.Slash}, nil
+}
+
+// PutLock saves the collateral lock l of the node peerID.
+func PutLock(d ds.Datastore, peerID string, l *Lock) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(lockKeyPrefix, peerID)+l.ContractID), b)
+}
+
+// HasLock reports whether the node peerID has a collateral lock for the
+// contract contractID.
+func HasLock(d ds.Datastore, peerID, contractID string) (bool, error) {
+	return d.Has(ds.NewKey(fmt.Sprintf(lockKeyPrefix, peerID) + contractID))
+}
+
+// ListLocks returns the collateral locks of the node peerID, the latest
+// first.
+func ListLocks(d ds.Datastore, peerID string) ([]*Lock, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(lockKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	ls := make([]*Lock, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		l := &Lock{}
+		if err := json.Unmarshal(r.Value, l); err != nil {
+			return nil, fmt.Errorf("invalid collateral lock %s: %s", r.Key, err)
+		}
+		ls = append(ls, l)
+	}
+	sort.Slice(ls, func(i, j int) bool {
+		return ls[i].Locked.After(ls[j].Locked)
+	})
+	return ls, nil
+}
+
+// Run settles the collateral locks of the node peerID at now: the renter
+// slashes the collateral of the contracts whose data loss the guard proved,
+// lost by contract ID, and the host releases its collateral once the
+// contract ended without loss. It returns the number of locks released and
+// slashed.
+func Run(ctx context.Context, d ds.Datastore, peerID string, client escrowclient.Client, lost map[string]bool,
+	now time.Time) (released int, slashed int, err error) {
+	ls, err := ListLocks(d, peerID)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, l := range ls {
+		if l.State != StateLocked {
+			continue
+		}
+		switch {
+		case lost[l.ContractID] && l.Role == RoleRenter:
+			if err := closeChannel(ctx, client, l); err != nil {
+				l.Error = err.Error()
+				break
+			}
+			l.State, l.Closed, l.Error = StateSlashed, now, ""
+			slashed++
+		case lost[l.ContractID]:
+			// the renter closes the channel to the escrow
+			l.State, l.Closed = StateSlashed, now
+			slashed++
+		case now.Before(l.Until):
+			continue
+		case l.Role == RoleHost:
+			if err := closeChannel(ctx, client, l); err != nil {
+				l.Error = err.Error()
+				break
+			}
+			l.State, l.Closed, l.Error = StateReleased, now, ""
+			released++
+		default:
+			// the host closes the channel refunding itself
+			l.State, l.Closed = StateReleased, now
+			released++
+		}
+		if err := PutLock(d, peerID, l); err != nil {
+			return released, slashed, err
+		}
+	}
+	return released, slashed, nil
+}
+
+func closeChannel(ctx context.Context, client escrowclient.Client, l *Lock) error {
+	s := &ledgerpb.SignedChannelState{}
+	if err := proto.Unmarshal(l.Settle, s); err != nil {
+		return err
+	}
+	_, err := client.CloseChannel(ctx, s)
+	return err
+}
+
+// PutTerms saves the collateral terms of a host got by the node peerID.
+func PutTerms(d ds.Datastore, peerID string, t *Terms) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(termsKeyPrefix, peerID)+t.Host), b)
+}
+
+// HostCollateral is the collateral of a host as known by a renter.
+type HostCollateral struct {
+	// Amount is the collateral the host locks per contract, 0 when it
+	// posts none or its terms were never checked.
+	Amount int64
+	// Locked is the collateral the host locks for the contracts of the
+	// renter, in Contracts locks.
+	Locked    int64
+	Contracts int
+	// Slashed counts the locks of the host slashed by the renter.
+	Slashed int `json:",omitempty"`
+}
+
+// Collateralized reports whether the host posts collateral.
+func (h *HostCollateral) Collateralized() bool {
+	return h != nil && (h.Amount > 0 || h.Contracts > 0)
+}
+
+// Hosts returns the collateral of the hosts known by the renter peerID,
+// from their terms and the locks of its contracts, by host ID.
+func Hosts(d ds.Datastore, peerID string) (map[string]*HostCollateral, error) {
+	hosts := make(map[string]*HostCollateral)
+	get := func(id string) *HostCollateral {
+		h, ok := hosts[id]
+		if !ok {
+			h = &HostCollateral{}
+			hosts[id] = h
+		}
+		return h
+	}
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(termsKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		t := &Terms{}
+		if err := json.Unmarshal(r.Value, t); err != nil {
+			return nil, fmt.Errorf("invalid collateral terms %s: %s", r.Key, err)
+		}
+		get(t.Host).Amount = t.Amount
+	}
+	ls, err := ListLocks(d, peerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range ls {
+		if l.Role != RoleRenter {
+			continue
+		}
+		switch h := get(l.Host); l.State {
+		case StateLocked:
+			h.Locked += l.Amount
+			h.Contracts++
+		case StateSlashed:
+			h.Slashed++
+		}
+	}
+	return hosts, nil
+}
@@ -0,0 +1,176 @@
+package collateral
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+	"github.com/tron-us/protobuf/proto"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+func newKey(t *testing.T) ic.PrivKey {
+	k, _, err := ic.GenerateKeyPair(ic.Secp256k1, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// mockEscrow returns an escrow creating channels and recording the states
+// of the channels closed.
+func mockEscrow(closed map[int64]*ledgerpb.ChannelState) *escrowclient.Mock {
+	var next int64
+	return &escrowclient.Mock{
+		CreateChannelFunc: func(ctx context.Context, in *ledgerpb.SignedChannelCommit) (*ledgerpb.ChannelID, error) {
+			next++
+			return &ledgerpb.ChannelID{Id: next}, nil
+		},
+		CloseChannelFunc: func(ctx context.Context, in *ledgerpb.SignedChannelState) (*ledgerpb.ChannelClosed, error) {
+			closed[in.Channel.Id.Id] = in.Channel
+			return &ledgerpb.ChannelClosed{}, nil
+		},
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, c := range []Config{{}, {Enabled: true, Amount: 10}, {Amount: 10}} {
+		if err := c.validate(); err != nil {
+			t.Fatalf("%+v: %s", c, err)
+		}
+	}
+	for _, c := range []Config{{Enabled: true}, {Amount: -1}} {
+		if err := c.validate(); err == nil {
+			t.Fatalf("%+v: expected an error", c)
+		}
+	}
+	if a := (&Config{Amount: 10}).Terms("host").Amount; a != 0 {
+		t.Fatalf("disabled collateral of %d", a)
+	}
+}
+
+func TestAccept(t *testing.T) {
+	host, escrow, other := newKey(t), newKey(t), newKey(t)
+	client := mockEscrow(map[int64]*ledgerpb.ChannelState{})
+	now := time.Now()
+	_, p, err := LockFor(context.Background(), client, host, escrow.GetPublic(), "c1", "host", "renter", 100,
+		now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := Accept(p, host.GetPublic(), escrow.GetPublic(), "renter", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Role != RoleRenter || l.State != StateLocked || l.Amount != 100 {
+		t.Fatalf("unexpected lock %+v", l)
+	}
+
+	// a posting of another host, to another escrow or for another amount
+	if _, err := Accept(p, other.GetPublic(), escrow.GetPublic(), "renter", now); err == nil {
+		t.Fatal("accepted the posting of another host")
+	}
+	if _, err := Accept(p, host.GetPublic(), other.GetPublic(), "renter", now); err == nil {
+		t.Fatal("accepted a posting to another escrow")
+	}
+	p.Amount = 1000
+	if _, err := Accept(p, host.GetPublic(), escrow.GetPublic(), "renter", now); err == nil {
+		t.Fatal("accepted a posting of another amount")
+	}
+}
+
+func TestRun(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	host, escrow := newKey(t), newKey(t)
+	closed := map[int64]*ledgerpb.ChannelState{}
+	client := mockEscrow(closed)
+	now := time.Now()
+	end := now.Add(24 * time.Hour)
+
+	// the host locks the collateral of two contracts, the renter the
+	// collateral posted for a third
+	for _, id := range []string{"kept", "lost"} {
+		l, _, err := LockFor(context.Background(), client, host, escrow.GetPublic(), id, "host", "renter", 100,
+			end, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := PutLock(d, "node", l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, p, err := LockFor(context.Background(), client, host, escrow.GetPublic(), "slashed", "host", "node", 50,
+		end, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := Accept(p, host.GetPublic(), escrow.GetPublic(), "node", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := PutLock(d, "node", l); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := HasLock(d, "node", "slashed"); err != nil || !ok {
+		t.Fatalf("lock not found: %v", err)
+	}
+
+	lost := map[string]bool{"lost": true, "slashed": true}
+	released, slashed, err := Run(context.Background(), d, "node", client, lost, now)
+	if err != nil || released != 0 || slashed != 2 {
+		t.Fatalf("%d released, %d slashed (%v)", released, slashed, err)
+	}
+	// only the renter closes the channel of a lost contract, to the escrow
+	if len(closed) != 1 || closed[p.ChannelID].To.Balance != 50 {
+		t.Fatalf("unexpected channels closed %v", closed)
+	}
+
+	released, slashed, err = Run(context.Background(), d, "node", client, lost, end)
+	if err != nil || released != 1 || slashed != 0 {
+		t.Fatalf("%d released, %d slashed (%v)", released, slashed, err)
+	}
+	if len(closed) != 2 || closed[1].From.Balance != 100 || closed[1].To.Balance != 0 {
+		t.Fatalf("collateral not refunded to the host: %v", closed)
+	}
+
+	hosts, err := Hosts(d, "node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := hosts["host"]; h == nil || h.Slashed != 1 || h.Contracts != 0 || h.Collateralized() {
+		t.Fatalf("unexpected host collateral %+v", h)
+	}
+	if err := PutTerms(d, "node", &Terms{Host: "host", Amount: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if hosts, err = Hosts(d, "node"); err != nil || !hosts["host"].Collateralized() {
+		t.Fatalf("host not collateralized: %+v (%v)", hosts["host"], err)
+	}
+}
+
+func TestSlashState(t *testing.T) {
+	host, escrow := newKey(t), newKey(t)
+	_, p, err := LockFor(context.Background(), mockEscrow(nil), host, escrow.GetPublic(), "c", "host", "renter",
+		100, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &ledgerpb.SignedChannelState{}
+	if err := proto.Unmarshal(p.Slash, s); err != nil {
+		t.Fatal(err)
+	}
+	// a slash state altered after signing is refused
+	s.Channel.Sequence = 2
+	if p.Slash, err = proto.Marshal(s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Accept(p, host.GetPublic(), escrow.GetPublic(), "renter", time.Now()); err == nil {
+		t.Fatal("accepted an altered slash state")
+	}
+}
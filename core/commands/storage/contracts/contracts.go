@@ -3,15 +3,21 @@ package contracts
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/TRON-US/go-btfs/core"
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	guardclient "github.com/TRON-US/go-btfs/core/clients/guard"
 	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/paging"
 	"github.com/TRON-US/go-btfs/core/commands/rm"
+	"github.com/TRON-US/go-btfs/core/commands/storage/challenge"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/contractarchive"
 	contractspb "github.com/TRON-US/go-btfs/protos/contracts"
 	shardpb "github.com/TRON-US/go-btfs/protos/shard"
 
@@ -22,8 +28,8 @@ import (
 	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
 	nodepb "github.com/tron-us/go-btfs-common/protos/node"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 
+	cidlib "github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log"
 	ic "github.com/libp2p/go-libp2p-core/crypto"
@@ -34,9 +40,12 @@ var contractsLog = logging.Logger("storage/contracts")
 const (
 	contractsSyncPurgeOptionName = "purge"
 
-	contractsListOrderOptionName  = "order"
-	contractsListStatusOptionName = "status"
-	contractsListSizeOptionName   = "size"
+	contractsListOrderOptionName    = "order"
+	contractsListStatusOptionName   = "status"
+	contractsListSizeOptionName     = "size"
+	contractsListArchivedOptionName = "include-archived"
+
+	contractsArchiveOlderOptionName = "older-than"
 
 	contractsKeyPrefix = "/btfs/%s/contracts/"
 	hostContractsKey   = contractsKeyPrefix + "host"
@@ -50,7 +59,8 @@ const (
 
 // Storage Contracts
 //
-// Includes sub-commands: sync, stat, list
+// Includes sub-commands: sync, stat, list, archive, propose, quote, history,
+// and renegotiate, registered by the storage command.
 var StorageContractsCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Get node storage contracts info.",
@@ -58,9 +68,13 @@ var StorageContractsCmd = &cmds.Command{
 This command get node storage contracts info respect to different roles.`,
 	},
 	Subcommands: map[string]*cmds.Command{
-		"sync": storageContractsSyncCmd,
-		"stat": storageContractsStatCmd,
-		"list": storageContractsListCmd,
+		"sync":    storageContractsSyncCmd,
+		"stat":    storageContractsStatCmd,
+		"list":    storageContractsListCmd,
+		"archive": storageContractsArchiveCmd,
+		"propose": storageContractsProposeCmd,
+		"quote":   StorageContractsQuoteCmd,
+		"history": storageContractsHistoryCmd,
 	},
 }
 
@@ -109,6 +123,10 @@ This command contracts stats based on role from network(hub) to local node data
 			if err != nil {
 				return err
 			}
+			err = contractarchive.ResetWatermark(n.Repo.Datastore(), n.Identity.Pretty(), role.String())
+			if err != nil {
+				return err
+			}
 		}
 		return SyncContracts(req.Context, n, req, env, role.String())
 	},
@@ -190,7 +208,11 @@ var storageContractsListCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Get contracts list based on role.",
 		ShortDescription: `
-This command get contracts list based on role from the local node data store.`,
+This command get contracts list based on role from the local node data store.
+Pass the returned NextCursor as --cursor to get the next page.
+
+Contracts in a final state are archived some time after their last change,
+see 'btfs storage contracts archive'. --include-archived lists them too.`,
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg("role", true, false, "Role in BTFS storage network [host|renter|reserved]."),
@@ -198,7 +220,9 @@ This command get contracts list based on role from the local node data store.`,
 	Options: []cmds.Option{
 		cmds.StringOption(contractsListOrderOptionName, "o", "Order to return the list of contracts.").WithDefault("escrow_time,asc"),
 		cmds.StringOption(contractsListStatusOptionName, "st", "Filter the returned list by contract status [active|finished|invalid|all].").WithDefault("active"),
-		cmds.IntOption(contractsListSizeOptionName, "s", "Number of contracts to return.").WithDefault(20),
+		cmds.IntOption(contractsListSizeOptionName, "s", "Number of contracts to return, 0 for all.").WithDefault(20),
+		paging.CursorOption(),
+		cmds.BoolOption(contractsListArchivedOptionName, "a", "Include the archived contracts.").WithDefault(false),
 	},
 	RunTimeout: 3 * time.Second,
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
@@ -234,10 +258,21 @@ This command get contracts list based on role from the local node data store.`,
 			return fmt.Errorf("invalid filter option: %s", filterOpt)
 		}
 		size, _ := req.Options[contractsListSizeOptionName].(int)
+		cursor, _ := req.Options[paging.CursorOptionName].(string)
+		page, err := paging.New(size, cursor)
+		if err != nil {
+			return err
+		}
 		contracts, err := ListContracts(n.Repo.Datastore(), n.Identity.Pretty(), cr.String())
 		if err != nil {
 			return err
 		}
+		if archived, _ := req.Options[contractsListArchivedOptionName].(bool); archived {
+			contracts, err = withArchived(n.Repo.Datastore(), n.Identity.Pretty(), cr.String(), contracts)
+			if err != nil {
+				return err
+			}
+		}
 		sort.Sort(ByTime(contracts))
 		if parts[1] == "" || parts[1] == "desc" {
 			// reverse
@@ -247,17 +282,127 @@ This command get contracts list based on role from the local node data store.`,
 		}
 		result := make([]*nodepb.Contracts_Contract, 0)
 		for _, c := range contracts {
-			if _, ok := states[c.Status]; !ok {
-				continue
+			if _, ok := states[c.Status]; ok {
+				result = append(result, c)
 			}
-			result = append(result, c)
-			if len(result) == size {
-				break
+		}
+		start, end, next := page.Bounds(len(result), func(i int) string {
+			return result[i].ContractId
+		})
+		return cmds.EmitOnce(res, &ContractsListOutput{Contracts: result[start:end], NextCursor: next})
+	},
+	Type: ContractsListOutput{},
+}
+
+// ContractsListOutput is a page of contracts. It encodes like
+// nodepb.Contracts, plus the cursor of the next page.
+type ContractsListOutput struct {
+	Contracts  []*nodepb.Contracts_Contract `json:"contracts"`
+	NextCursor string                       `json:",omitempty"`
+}
+
+// withArchived appends to cs the archived contracts of role not in cs.
+func withArchived(d datastore.Datastore, peerId, role string,
+	cs []*nodepb.Contracts_Contract) ([]*nodepb.Contracts_Contract, error) {
+	archived, err := contractarchive.List(d, peerId, role)
+	if err != nil {
+		return nil, err
+	}
+	listed := map[string]bool{}
+	for _, c := range cs {
+		listed[c.ContractId] = true
+	}
+	for _, c := range archived {
+		if !listed[c.ContractId] {
+			cs = append(cs, c)
+		}
+	}
+	return cs, nil
+}
+
+// sub-commands: btfs storage contracts archive
+var storageContractsArchiveCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Archive the contracts in a final state.",
+		ShortDescription: `
+This command moves the contracts closed, canceled, lost or obsolete for longer
+than --older-than out of the contracts list, into a compressed archive of the
+local node data store. The archived contracts are no longer synced, and are
+only listed with 'btfs storage contracts list --include-archived'.
+
+The repo maintenance archives the contracts older than ContractArchive.After
+(720h by default), unless ContractArchive.Disabled is set.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("role", true, false, "Role in BTFS storage network [host|renter|reserved]."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(contractsArchiveOlderOptionName, "Archive the contracts final for longer, ContractArchive.After by default."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		role, err := checkContractStatRole(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		cfg, err := contractarchive.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		age, err := cfg.Age()
+		if err != nil {
+			return err
+		}
+		if s, ok := req.Options[contractsArchiveOlderOptionName].(string); ok {
+			if age, err = time.ParseDuration(s); err != nil || age < 0 {
+				return fmt.Errorf("invalid %s %q", contractsArchiveOlderOptionName, s)
 			}
 		}
-		return cmds.EmitOnce(res, &nodepb.Contracts{Contracts: result})
+		result, err := Archive(n.Repo.Datastore(), n.Identity.Pretty(), role.String(), time.Now().Add(-age))
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, result)
+	},
+	Type: contractarchive.Result{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *contractarchive.Result) error {
+			_, err := fmt.Fprintf(w, "archived %d contracts, %d signed contracts, in %d segments\n",
+				out.Contracts, out.Signed, out.Segments)
+			return err
+		}),
 	},
-	Type: nodepb.Contracts{},
+}
+
+// Archive moves the contracts of role in a final state since before out of
+// the contracts list and the signed contracts into the contract archive.
+func Archive(d datastore.Datastore, peerId, role string, before time.Time) (*contractarchive.Result, error) {
+	cs, err := ListContracts(d, peerId, role)
+	if err != nil {
+		return nil, err
+	}
+	scs, err := sessions.ListShardsContracts(d, peerId, role)
+	if err != nil {
+		return nil, err
+	}
+	keep, ids, result, err := contractarchive.Archive(d, peerId, role, cs, scs, before)
+	if err != nil {
+		return nil, err
+	}
+	if result.Contracts > 0 {
+		if err := Save(d, keep, role); err != nil {
+			return nil, err
+		}
+	}
+	if len(ids) > 0 {
+		if err := sessions.RemoveShardsContracts(d, peerId, role, ids); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
 func getKey(role string) string {
@@ -325,6 +470,14 @@ func SyncContracts(ctx context.Context, n *core.IpfsNode, req *cmds.Request, env
 			latest = &c.SignedGuardContract.LastModifyTime
 		}
 	}
+	// the archived contracts are gone from cs, do not fetch them again
+	mark, err := contractarchive.Watermark(n.Repo.Datastore(), n.Identity.Pretty(), role)
+	if err != nil {
+		return err
+	}
+	if !mark.IsZero() && (latest == nil || mark.After(*latest)) {
+		latest = &mark
+	}
 	updated, err := GetUpdatedGuardContracts(ctx, n, latest)
 	if err != nil {
 		return err
@@ -336,6 +489,12 @@ func SyncContracts(ctx context.Context, n *core.IpfsNode, req *cmds.Request, env
 		if err != nil {
 			return err
 		}
+		cache := challenge.HostCache(n.Repo)
+		for _, h := range stale {
+			if c, err := cidlib.Parse(h); err == nil {
+				cache.Invalidate(c)
+			}
+		}
 		go func() {
 			// Use a new context that can clean up in the background
 			_, err := rm.RmDag(context.Background(), stale, n, req, env, true)
@@ -403,23 +562,11 @@ func GetUpdatedGuardContracts(ctx context.Context, n *core.IpfsNode,
 // closes (short) connection
 func ListHostContracts(ctx context.Context, cfg *config.Config,
 	listReq *guardpb.ListHostContractsRequest) ([]*guardpb.Contract, bool, error) {
-	cb := grpc.GuardClient(cfg.Services.GuardDomain)
-	cb.Timeout(guardTimeout)
-	var contracts []*guardpb.Contract
-	var lastPage bool
-	err := cb.WithContext(ctx, func(ctx context.Context, client guardpb.GuardServiceClient) error {
-		res, err := client.ListHostContracts(ctx, listReq)
-		if err != nil {
-			return err
-		}
-		contracts = res.Contracts
-		lastPage = res.Count < listReq.RequestPageSize
-		return nil
-	})
+	res, err := guardclient.NewWithTimeout(cfg.Services.GuardDomain, guardTimeout).ListHostContracts(ctx, listReq)
 	if err != nil {
 		return nil, false, err
 	}
-	return contracts, lastPage, nil
+	return res.Contracts, res.Count < listReq.RequestPageSize, nil
 }
 
 // SyncContractPayoutStatus looks at local contracts, refreshes from Guard, and then
@@ -443,93 +590,93 @@ func syncContractPayoutStatus(ctx context.Context, n *core.IpfsNode,
 	for i, ct := range cts {
 		ctsIndexMap[ct.ContractId] = i
 	}
-	err = grpc.EscrowClient(cfg.Services.EscrowDomain).WithContext(ctx,
-		func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
-			// Loop only max page size each time
-			for ci := 0; ci < len(cs); ci += cconfig.ConstRequestPayoutBatchPageSize {
-				in := &escrowpb.SignedModifyContractIDBatch{
-					Data: &escrowpb.ContractIDBatch{
-						Address: pkBytes,
-					},
-				}
-				minLatestTime := time.Unix(1<<63-62135596801, 999999999)
-				csIndexMap := map[string]int{}
-				for i := ci; i < ci+cconfig.ConstRequestPayoutBatchPageSize && i < len(cs); i++ {
-					c := cs[i]
-					csIndexMap[c.SignedGuardContract.ContractId] = i
-					// Get the minimum latest timestamp, if does not exist, then just pull everything
-					if cti, ok := ctsIndexMap[c.SignedGuardContract.ContractId]; ok {
-						if cts[cti].LastModifyTime.Before(minLatestTime) {
-							minLatestTime = cts[cti].LastModifyTime
-						}
-					} else {
-						minLatestTime = time.Time{}
+	client := escrowclient.New(cfg.Services.EscrowDomain)
+	err = func() error {
+		// Loop only max page size each time
+		for ci := 0; ci < len(cs); ci += cconfig.ConstRequestPayoutBatchPageSize {
+			in := &escrowpb.SignedModifyContractIDBatch{
+				Data: &escrowpb.ContractIDBatch{
+					Address: pkBytes,
+				},
+			}
+			minLatestTime := time.Unix(1<<63-62135596801, 999999999)
+			csIndexMap := map[string]int{}
+			for i := ci; i < ci+cconfig.ConstRequestPayoutBatchPageSize && i < len(cs); i++ {
+				c := cs[i]
+				csIndexMap[c.SignedGuardContract.ContractId] = i
+				// Get the minimum latest timestamp, if does not exist, then just pull everything
+				if cti, ok := ctsIndexMap[c.SignedGuardContract.ContractId]; ok {
+					if cts[cti].LastModifyTime.Before(minLatestTime) {
+						minLatestTime = cts[cti].LastModifyTime
 					}
-					in.Data.ContractId = append(in.Data.ContractId, c.SignedGuardContract.ContractId)
+				} else {
+					minLatestTime = time.Time{}
 				}
-				in.LastModifyTime = minLatestTime
-				sign, err := crypto.Sign(n.PrivateKey, in.Data)
-				if err != nil {
-					contractsLog.Error("sign contractID error:", err)
-					return err
+				in.Data.ContractId = append(in.Data.ContractId, c.SignedGuardContract.ContractId)
+			}
+			in.LastModifyTime = minLatestTime
+			sign, err := crypto.Sign(n.PrivateKey, in.Data)
+			if err != nil {
+				contractsLog.Error("sign contractID error:", err)
+				return err
+			}
+			in.Signature = sign
+			sb, err := client.GetModifyPayOutStatusBatch(ctx, in)
+			if err != nil {
+				contractsLog.Error("get payout status batch error:", err)
+				return err
+			}
+			var lastUpdated time.Time
+			for _, s := range sb.Status {
+				if _, ok := csIndexMap[s.ContractId]; !ok {
+					continue // Ignore bad contracts
 				}
-				in.Signature = sign
-				sb, err := client.GetModifyPayOutStatusBatch(ctx, in)
-				if err != nil {
-					contractsLog.Error("get payout status batch error:", err)
-					return err
+				// Find the latest valid timestamp for all updates
+				if s.ErrorMsg == "" && s.LastModifyTime.After(lastUpdated) {
+					lastUpdated = s.LastModifyTime
 				}
-				var lastUpdated time.Time
-				for _, s := range sb.Status {
-					if _, ok := csIndexMap[s.ContractId]; !ok {
-						continue // Ignore bad contracts
-					}
-					// Find the latest valid timestamp for all updates
-					if s.ErrorMsg == "" && s.LastModifyTime.After(lastUpdated) {
-						lastUpdated = s.LastModifyTime
-					}
+			}
+			for _, s := range sb.Status {
+				csi, ok := csIndexMap[s.ContractId]
+				if !ok {
+					continue // Ignore bad contracts
 				}
-				for _, s := range sb.Status {
-					csi, ok := csIndexMap[s.ContractId]
-					if !ok {
-						continue // Ignore bad contracts
-					}
-					c := cs[csi]
-
-					resCt := &nodepb.Contracts_Contract{
-						ContractId:              c.SignedGuardContract.ContractId,
-						HostId:                  c.SignedGuardContract.HostPid,
-						RenterId:                c.SignedGuardContract.RenterPid,
-						Status:                  c.SignedGuardContract.State,
-						StartTime:               c.SignedGuardContract.RentStart,
-						EndTime:                 c.SignedGuardContract.RentEnd,
-						LastModifyTime:          lastUpdated, // sync time with this batch
-						NextEscrowTime:          s.NextPayoutTime,
-						CompensationPaid:        s.PaidAmount,
-						CompensationOutstanding: s.Amount - s.PaidAmount,
-						UnitPrice:               c.SignedGuardContract.Price,
-						ShardSize:               c.SignedGuardContract.ShardFileSize,
-						ShardHash:               c.SignedGuardContract.ShardHash,
-						FileHash:                c.SignedGuardContract.FileHash,
-					}
+				c := cs[csi]
 
-					if s.ErrorMsg != "" {
-						resCt.LastModifyTime = time.Time{} // reset to beginning
-						resCt.NextEscrowTime = time.Time{} // reset to unknown
-						contractsLog.Debug("got payout status error message:", s.ErrorMsg)
-					}
+				resCt := &nodepb.Contracts_Contract{
+					ContractId:              c.SignedGuardContract.ContractId,
+					HostId:                  c.SignedGuardContract.HostPid,
+					RenterId:                c.SignedGuardContract.RenterPid,
+					Status:                  c.SignedGuardContract.State,
+					StartTime:               c.SignedGuardContract.RentStart,
+					EndTime:                 c.SignedGuardContract.RentEnd,
+					LastModifyTime:          lastUpdated, // sync time with this batch
+					NextEscrowTime:          s.NextPayoutTime,
+					CompensationPaid:        s.PaidAmount,
+					CompensationOutstanding: s.Amount - s.PaidAmount,
+					UnitPrice:               c.SignedGuardContract.Price,
+					ShardSize:               c.SignedGuardContract.ShardFileSize,
+					ShardHash:               c.SignedGuardContract.ShardHash,
+					FileHash:                c.SignedGuardContract.FileHash,
+				}
 
-					// If already exists, update existing
-					// Otherwise append/add to list
-					if cti, ok := ctsIndexMap[resCt.ContractId]; ok {
-						cts[cti] = resCt
-					} else {
-						cts = append(cts, resCt)
-					}
+				if s.ErrorMsg != "" {
+					resCt.LastModifyTime = time.Time{} // reset to beginning
+					resCt.NextEscrowTime = time.Time{} // reset to unknown
+					contractsLog.Debug("got payout status error message:", s.ErrorMsg)
+				}
+
+				// If already exists, update existing
+				// Otherwise append/add to list
+				if cti, ok := ctsIndexMap[resCt.ContractId]; ok {
+					cts[cti] = resCt
+				} else {
+					cts = append(cts, resCt)
 				}
 			}
-			return nil
-		})
+		}
+		return nil
+	}()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,418 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+const (
+	negotiationKey = contractsKeyPrefix + "negotiations/%s"
+	proposalPrefix = contractsKeyPrefix + "proposals/"
+	proposalKey    = proposalPrefix + "%s"
+
+	// RenegotiationConfigKey is the config section of the renter side of
+	// the price renegotiations.
+	RenegotiationConfigKey = "Renegotiation"
+	// DefaultRenegotiationWindow is how long before their end contracts
+	// are renegotiated when nothing is configured.
+	DefaultRenegotiationWindow = 72 * time.Hour
+)
+
+// Actions of the rounds of a negotiation.
+const (
+	ActionProposed = "proposed"
+	ActionQuoted   = "quoted"
+	ActionAccepted = "accepted"
+	ActionRejected = "rejected"
+	ActionMigrated = "migrated"
+)
+
+// RenegotiationConfig bounds the renewal prices a renter accepts.
+type RenegotiationConfig struct {
+	// Auto renegotiates the contracts ending within Window.
+	Auto bool
+	// Window is how long before their end contracts are renegotiated,
+	// e.g. "72h".
+	Window string `json:",omitempty"`
+	// MaxIncrease is the price increase accepted, in percent of the price
+	// of the contract.
+	MaxIncrease float64 `json:",omitempty"`
+	// MaxPrice is the highest price accepted in µBTT per GiB per day, no
+	// limit when zero.
+	MaxPrice int64 `json:",omitempty"`
+	// Migrate renews the contract with a cheaper host when the price
+	// proposed is out of bounds, instead of letting it end.
+	Migrate bool
+}
+
+func init() {
+	configschema.RegisterSection(RenegotiationConfigKey, RenegotiationConfig{})
+}
+
+// LoadRenegotiationConfig returns the renegotiation config of r.
+func LoadRenegotiationConfig(r repo.Repo) (*RenegotiationConfig, error) {
+	c := &RenegotiationConfig{}
+	if _, err := repo.GetConfigSection(r, RenegotiationConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", RenegotiationConfigKey, err)
+	}
+	if _, err := c.RenewalWindow(); err != nil {
+		return nil, err
+	}
+	if c.MaxIncrease < 0 || c.MaxPrice < 0 {
+		return nil, fmt.Errorf("invalid %s config: negative bound", RenegotiationConfigKey)
+	}
+	return c, nil
+}
+
+// RenewalWindow returns how long before their end contracts are
+// renegotiated.
+func (c *RenegotiationConfig) RenewalWindow() (time.Duration, error) {
+	if c.Window == "" {
+		return DefaultRenegotiationWindow, nil
+	}
+	d, err := time.ParseDuration(c.Window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s config: invalid Window %q", RenegotiationConfigKey, c.Window)
+	}
+	return d, nil
+}
+
+// Bound returns the highest renewal price accepted for a contract of the
+// given price.
+func (c *RenegotiationConfig) Bound(price int64) int64 {
+	bound := int64(math.Floor(float64(price) * (1 + c.MaxIncrease/100)))
+	if c.MaxPrice > 0 && bound > c.MaxPrice {
+		bound = c.MaxPrice
+	}
+	return bound
+}
+
+// Decide returns the action of the renter on a renewal price proposed for
+// a contract of the given price, and why.
+func (c *RenegotiationConfig) Decide(price, proposed int64) (string, string) {
+	bound := c.Bound(price)
+	if proposed <= bound {
+		return ActionAccepted, fmt.Sprintf("%d is within the bound %d", proposed, bound)
+	}
+	reason := fmt.Sprintf("%d is above the bound %d", proposed, bound)
+	if c.Migrate {
+		return ActionMigrated, reason
+	}
+	return ActionRejected, reason
+}
+
+// Round is a step of the price negotiation of a contract.
+type Round struct {
+	Time time.Time
+	// Role is the role of the node in the contract.
+	Role   string
+	Action string
+	// Price is the price of the contract, Proposed the price proposed for
+	// its renewal, in µBTT per GiB per day.
+	Price    int64
+	Proposed int64
+	// Peer is the host or renter the round was with.
+	Peer string `json:",omitempty"`
+	// Session is the upload session of the renewed contract.
+	Session string `json:",omitempty"`
+	Reason  string `json:",omitempty"`
+}
+
+var negotiationLk sync.Mutex
+
+// Negotiation returns the rounds of the price negotiation of the contract
+// contractID recorded by the node peerID, oldest first.
+func Negotiation(d ds.Datastore, peerID, contractID string) ([]*Round, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(negotiationKey, peerID, contractID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rounds []*Round
+	if err := json.Unmarshal(b, &rounds); err != nil {
+		return nil, fmt.Errorf("invalid negotiation of %s: %s", contractID, err)
+	}
+	return rounds, nil
+}
+
+// RecordRound adds r to the negotiation of the contract contractID.
+func RecordRound(d ds.Datastore, peerID, contractID string, r *Round) error {
+	negotiationLk.Lock()
+	defer negotiationLk.Unlock()
+	rounds, err := Negotiation(d, peerID, contractID)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(append(rounds, r))
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(negotiationKey, peerID, contractID)), b)
+}
+
+// Decided reports whether the renter of a contract decided on its renewal
+// in rounds.
+func Decided(rounds []*Round) bool {
+	for _, r := range rounds {
+		if r.Role != nodepb.ContractStat_RENTER.String() {
+			continue
+		}
+		switch r.Action {
+		case ActionAccepted, ActionRejected, ActionMigrated:
+			return true
+		}
+	}
+	return false
+}
+
+// Proposal is the renewal price a host proposes for a contract.
+type Proposal struct {
+	ContractID string
+	RenterPid  string
+	ShardHash  string
+	Price      int64
+	Time       time.Time
+}
+
+// GetProposal returns the proposal of the host peerID for the contract
+// contractID, nil if there is none.
+func GetProposal(d ds.Datastore, peerID, contractID string) (*Proposal, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(proposalKey, peerID, contractID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &Proposal{}
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("invalid proposal for %s: %s", contractID, err)
+	}
+	return p, nil
+}
+
+// PutProposal records the proposal p of the host peerID.
+func PutProposal(d ds.Datastore, peerID string, p *Proposal) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(proposalKey, peerID, p.ContractID)), b)
+}
+
+// ProposedPrice reports whether the host peerID proposed price, or less,
+// to the renter renterPid for renewing a contract storing shardHash.
+func ProposedPrice(d ds.Datastore, peerID, renterPid, shardHash string, price int64) (bool, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(proposalPrefix, peerID)})
+	if err != nil {
+		return false, err
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		p := &Proposal{}
+		if err := json.Unmarshal(e.Value, p); err != nil {
+			return false, fmt.Errorf("invalid proposal %s: %s", e.Key, err)
+		}
+		if p.RenterPid == renterPid && p.ShardHash == shardHash && p.Price <= price {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ShardContract returns the guard contract contractID of the node peerID
+// in the given role.
+func ShardContract(d ds.Datastore, peerID, role, contractID string) (*guardpb.Contract, error) {
+	cs, err := sessions.ListShardsContracts(d, peerID, role)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cs {
+		if c.SignedGuardContract != nil && c.SignedGuardContract.ContractId == contractID {
+			return c.SignedGuardContract, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s contract %s", role, contractID)
+}
+
+// Quote is the renewal price of a contract, answered by its host.
+type Quote struct {
+	ContractID string
+	// Price is the price of the contract, Proposed the renewal price.
+	Price    int64
+	Proposed int64
+}
+
+// NegotiationOutput lists the rounds of the negotiation of a contract.
+type NegotiationOutput struct {
+	Rounds []*Round
+}
+
+var storageContractsProposeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Propose the renewal price of a contract (host).",
+		ShortDescription: `
+Records the price per GiB per day in µBTT (=0.000001BTT) the host asks for
+renewing a contract, answered to its renter when it renegotiates. Without a
+proposal, the renter is quoted the current storage price ask of the host.
+A proposal below the price ask lets the renter renew at that price.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("contract-id", true, false, "ID of the contract stored by this host."),
+		cmds.StringArg("price", true, false, "Renewal price per GiB per day in µBTT."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		price, err := strconv.ParseInt(req.Arguments[1], 10, 64)
+		if err != nil || price <= 0 {
+			return fmt.Errorf("invalid price %q", req.Arguments[1])
+		}
+		d := n.Repo.Datastore()
+		self := n.Identity.Pretty()
+		c, err := ShardContract(d, self, nodepb.ContractStat_HOST.String(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		err = PutProposal(d, self, &Proposal{
+			ContractID: c.ContractId,
+			RenterPid:  c.RenterPid,
+			ShardHash:  c.ShardHash,
+			Price:      price,
+			Time:       now,
+		})
+		if err != nil {
+			return err
+		}
+		return RecordRound(d, self, c.ContractId, &Round{
+			Time:     now,
+			Role:     nodepb.ContractStat_HOST.String(),
+			Action:   ActionProposed,
+			Price:    c.Price,
+			Proposed: price,
+			Peer:     c.RenterPid,
+		})
+	},
+}
+
+// StorageContractsQuoteCmd answers the renewal price of a contract to its
+// renter, through the remote API.
+var StorageContractsQuoteCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Quote the renewal price of a contract (host).",
+		ShortDescription: `
+Called by the renter of a contract stored by this host when renegotiating
+it, answers the price proposed by 'btfs storage contracts propose' or the
+current storage price ask.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("contract-id", true, false, "ID of the contract stored by this host."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		d := n.Repo.Datastore()
+		self := n.Identity.Pretty()
+		c, err := ShardContract(d, self, nodepb.ContractStat_HOST.String(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		renter, ok := remote.GetStreamRequestRemotePeerID(req, n)
+		if !ok || renter.Pretty() != c.RenterPid {
+			return errors.New("only the renter of the contract can renegotiate it")
+		}
+		q := &Quote{ContractID: c.ContractId, Price: c.Price}
+		p, err := GetProposal(d, self, c.ContractId)
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			q.Proposed = p.Price
+		} else {
+			settings, err := helper.GetHostStorageConfig(req.Context, n)
+			if err != nil {
+				return err
+			}
+			q.Proposed = int64(settings.StoragePriceAsk)
+		}
+		err = RecordRound(d, self, c.ContractId, &Round{
+			Time:     time.Now(),
+			Role:     nodepb.ContractStat_HOST.String(),
+			Action:   ActionQuoted,
+			Price:    q.Price,
+			Proposed: q.Proposed,
+			Peer:     c.RenterPid,
+		})
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, q)
+	},
+	Type: Quote{},
+}
+
+var storageContractsHistoryCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the price negotiation of a contract.",
+		ShortDescription: `
+Lists the proposals, quotes and decisions recorded by this node on the
+renewal price of a contract, as its host or as its renter.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("contract-id", true, false, "ID of the contract."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		rounds, err := Negotiation(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &NegotiationOutput{Rounds: rounds})
+	},
+	Type: NegotiationOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *NegotiationOutput) error {
+			tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "TIME\tROLE\tACTION\tPRICE\tPROPOSED\tSESSION\tREASON")
+			for _, r := range out.Rounds {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n", r.Time.Format(time.RFC3339), r.Role,
+					r.Action, r.Price, r.Proposed, r.Session, r.Reason)
+			}
+			return tw.Flush()
+		}),
+	},
+}
@@ -0,0 +1,91 @@
+package contracts
+
+import (
+	"testing"
+	"time"
+
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestDecide(t *testing.T) {
+	cfg := &RenegotiationConfig{MaxIncrease: 10, MaxPrice: 1050}
+	for _, c := range []struct {
+		price, proposed int64
+		migrate         bool
+		action          string
+	}{
+		{1000, 900, false, ActionAccepted},
+		{1000, 1050, false, ActionAccepted},
+		{1000, 1051, false, ActionRejected},
+		{1000, 1051, true, ActionMigrated},
+		{500, 550, false, ActionAccepted},
+		{500, 551, false, ActionRejected},
+	} {
+		cfg.Migrate = c.migrate
+		if action, reason := cfg.Decide(c.price, c.proposed); action != c.action {
+			t.Errorf("%d proposed for %d: expected %s, got %s (%s)", c.proposed, c.price, c.action, action, reason)
+		}
+	}
+	if _, err := (&RenegotiationConfig{Window: "soon"}).RenewalWindow(); err == nil {
+		t.Fatal("expected an invalid window")
+	}
+}
+
+func TestNegotiation(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	renter := nodepb.ContractStat_RENTER.String()
+	if err := RecordRound(d, "QmSelf", "c1", &Round{Role: renter, Action: ActionQuoted, Proposed: 1200}); err != nil {
+		t.Fatal(err)
+	}
+	rounds, err := Negotiation(d, "QmSelf", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Decided(rounds) {
+		t.Fatal("expected a quote not to be a decision")
+	}
+	if err := RecordRound(d, "QmSelf", "c1", &Round{Role: renter, Action: ActionRejected}); err != nil {
+		t.Fatal(err)
+	}
+	if rounds, err = Negotiation(d, "QmSelf", "c1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rounds) != 2 || !Decided(rounds) {
+		t.Fatalf("expected 2 rounds ending with a decision, got %+v", rounds)
+	}
+	if rounds, err = Negotiation(d, "QmSelf", "c2"); err != nil || rounds != nil {
+		t.Fatalf("expected no rounds, got %v %v", rounds, err)
+	}
+}
+
+func TestProposedPrice(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	err := PutProposal(d, "QmHost", &Proposal{
+		ContractID: "c1", RenterPid: "QmRenter", ShardHash: "QmShard", Price: 800, Time: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		renter, shard string
+		price         int64
+		ok            bool
+	}{
+		{"QmRenter", "QmShard", 800, true},
+		{"QmRenter", "QmShard", 900, true},
+		{"QmRenter", "QmShard", 700, false},
+		{"QmOther", "QmShard", 800, false},
+		{"QmRenter", "QmOther", 800, false},
+	} {
+		ok, err := ProposedPrice(d, "QmHost", c.renter, c.shard, c.price)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != c.ok {
+			t.Errorf("%s %s at %d: expected %t", c.renter, c.shard, c.price, c.ok)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("storage/digest")
+
+const (
+	daysOptionName = "days"
+	sendOptionName = "send"
+)
+
+// DigestOutput is the digest of the renter contracts and when the last
+// one was sent.
+type DigestOutput struct {
+	*Digest
+	LastSent time.Time `json:",omitempty"`
+}
+
+var StorageDigestCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Summarize the renter contracts needing attention.",
+		ShortDescription: `
+Lists the renter contracts expiring in the next days with the cost of
+renewing them, and the files with shards lost or on hosts missing their
+retrieval SLA, see 'btfs storage probe'.
+
+A renter daemon sends the digest daily, when there is something to report,
+once enabled:
+
+    $ btfs config --json ContractDigest.Enabled true
+    $ btfs config --json ContractDigest.Days 14
+    $ btfs config ContractDigest.WebhookURL https://hooks.example.com/btfs
+
+The digest is logged, and posted as JSON to the webhook when set. --send
+sends it right away.`,
+	},
+	Options: []cmds.Option{
+		cmds.IntOption(daysOptionName, "Days ahead to list the contracts expiring. Default: ContractDigest.Days."),
+		cmds.BoolOption(sendOptionName, "Send the digest now."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		conf, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !conf.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		days := cfg.Days
+		if v, ok := req.Options[daysOptionName].(int); ok {
+			if v <= 0 {
+				return fmt.Errorf("--%s must be positive", daysOptionName)
+			}
+			days = v
+		}
+		now := time.Now()
+		dg, err := Compute(n, days, now)
+		if err != nil {
+			return err
+		}
+		if send, _ := req.Options[sendOptionName].(bool); send {
+			if err := Deliver(req.Context, n, cfg, dg); err != nil {
+				return err
+			}
+		}
+		out := &DigestOutput{Digest: dg}
+		last, err := Last(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		if last != nil {
+			out.LastSent = last.Time
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: DigestOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *DigestOutput) error {
+			fmt.Fprintf(w, "%d contracts expiring in %d days, %d µBTT to renew them\n",
+				len(out.Expiring), out.Days, out.RenewalCost)
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			if len(out.Expiring) > 0 {
+				fmt.Fprintln(tw, "CONTRACT\tFILE\tSHARD\tHOST\tEND\tRENEWAL")
+				for _, e := range out.Expiring {
+					fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%d\n", e.ContractID, e.FileHash, e.ShardIndex,
+						e.HostID, e.RentEnd.Format(time.RFC3339), e.RenewalCost)
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+			}
+			for _, f := range out.Degraded {
+				fmt.Fprintf(w, "file %s degraded: %d of %d shards lost, %d at risk\n",
+					f.FileHash, f.Lost, f.Shards, f.AtRisk)
+			}
+			if !out.LastSent.IsZero() {
+				fmt.Fprintf(w, "last sent %s\n", out.LastSent.Format(time.RFC3339))
+			}
+			return nil
+		}),
+	},
+}
+
+// Compute returns the digest at now of the renter contracts of n expiring
+// in the next days.
+func Compute(n *core.IpfsNode, days int, now time.Time) (*Digest, error) {
+	d := n.Repo.Datastore()
+	self := n.Identity.Pretty()
+	scs, err := sessions.ListShardsContracts(d, self, nodepb.ContractStat_RENTER.String())
+	if err != nil {
+		return nil, err
+	}
+	var cs []*guardpb.Contract
+	for _, sc := range scs {
+		if sc.SignedGuardContract != nil {
+			cs = append(cs, sc.SignedGuardContract)
+		}
+	}
+	risks, err := probe.Risks(d, self)
+	if err != nil {
+		return nil, err
+	}
+	return Build(cs, risks, days, now), nil
+}
+
+// Deliver logs dg, posts it to the webhook of cfg when set and records it
+// as the last digest of n.
+func Deliver(ctx context.Context, n *core.IpfsNode, cfg *Config, dg *Digest) error {
+	log.Warnf("contract digest: %d contracts expiring in %d days (%d µBTT to renew), %d files degraded",
+		len(dg.Expiring), dg.Days, dg.RenewalCost, len(dg.Degraded))
+	if cfg.WebhookURL != "" {
+		if err := Send(ctx, cfg.WebhookURL, dg); err != nil {
+			return fmt.Errorf("failed to send the contract digest: %s", err)
+		}
+	}
+	return SaveLast(n.Repo.Datastore(), n.Identity.Pretty(), dg)
+}
@@ -0,0 +1,225 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	ds "github.com/ipfs/go-datastore"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+)
+
+// ConfigKey is the config section of the contract digests.
+const ConfigKey = "ContractDigest"
+
+const (
+	// Period is the time between two digests.
+	Period = 24 * time.Hour
+	// DefaultDays is the number of days ahead the contracts expiring are
+	// listed for.
+	DefaultDays = 7
+
+	webhookTimeout = 30 * time.Second
+	lastKey        = "/btfs/%s/digest/last"
+)
+
+// Config configures the daily contract digest of a renter.
+type Config struct {
+	Enabled bool
+	// Days is the number of days ahead the contracts expiring are listed
+	// for.
+	Days int `json:",omitempty"`
+	// WebhookURL is posted the digest as JSON when set, the digest is logged
+	// either way.
+	WebhookURL string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the contract digest config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if c.Days < 0 {
+		return nil, fmt.Errorf("invalid %s config: negative Days", ConfigKey)
+	}
+	if c.Days == 0 {
+		c.Days = DefaultDays
+	}
+	return c, nil
+}
+
+// Expiring is a renter contract ending within the days of a digest.
+type Expiring struct {
+	ContractID string
+	FileHash   string
+	ShardIndex int32
+	HostID     string
+	RentEnd    time.Time
+	// RenewalCost is the cost in µBTT of renewing the contract for as long,
+	// at the same price.
+	RenewalCost int64
+}
+
+// Degraded is a file some shards of which are lost or at risk.
+type Degraded struct {
+	FileHash string
+	Shards   int
+	// Lost are the shards no host stores anymore.
+	Lost int
+	// AtRisk are the shards on hosts missing the retrieval SLA, see
+	// 'btfs storage probe'.
+	AtRisk int `json:",omitempty"`
+}
+
+// Digest summarizes the renter contracts needing attention.
+type Digest struct {
+	Time     time.Time
+	Days     int
+	Expiring []*Expiring
+	Degraded []*Degraded
+	// RenewalCost is the cost in µBTT of renewing all the contracts
+	// expiring.
+	RenewalCost int64
+}
+
+// Empty reports whether no contract of d needs attention.
+func (d *Digest) Empty() bool {
+	return len(d.Expiring) == 0 && len(d.Degraded) == 0
+}
+
+// lostStates are the states of the contracts a host failed.
+var lostStates = map[guardpb.Contract_ContractState]bool{
+	guardpb.Contract_LOST:     true,
+	guardpb.Contract_CANCELED: true,
+}
+
+// Build returns the digest at now of the renter contracts cs expiring in
+// the next days, and of their files lost or at risk in risks.
+func Build(cs []*guardpb.Contract, risks []*probe.FileRisk, days int, now time.Time) *Digest {
+	dg := &Digest{Time: now, Days: days}
+	end := now.AddDate(0, 0, days)
+	// whether each shard of each file has an active contract
+	active := make(map[string]map[int32]bool)
+	for _, c := range cs {
+		shards, ok := active[c.FileHash]
+		if !ok {
+			shards = make(map[int32]bool)
+			active[c.FileHash] = shards
+		}
+		isActive := helper.ContractFilterMap["active"][c.State]
+		if isActive {
+			shards[c.ShardIndex] = true
+		} else if lostStates[c.State] && !shards[c.ShardIndex] {
+			shards[c.ShardIndex] = false
+		}
+		if !isActive || c.RentEnd.Before(now) || c.RentEnd.After(end) {
+			continue
+		}
+		length := int(c.RentEnd.Sub(c.RentStart).Hours() / 24)
+		e := &Expiring{
+			ContractID:  c.ContractId,
+			FileHash:    c.FileHash,
+			ShardIndex:  c.ShardIndex,
+			HostID:      c.HostPid,
+			RentEnd:     c.RentEnd,
+			RenewalCost: uh.TotalPay(c.ShardFileSize, c.Price, length),
+		}
+		dg.Expiring = append(dg.Expiring, e)
+		dg.RenewalCost += e.RenewalCost
+	}
+	degraded := make(map[string]*Degraded)
+	for fileHash, shards := range active {
+		f := &Degraded{FileHash: fileHash, Shards: len(shards)}
+		for _, ok := range shards {
+			if !ok {
+				f.Lost++
+			}
+		}
+		// files no shard of which is active anymore have expired
+		if f.Lost > 0 && f.Lost < f.Shards {
+			degraded[fileHash] = f
+		}
+	}
+	for _, r := range risks {
+		f, ok := degraded[r.FileHash]
+		if !ok {
+			f = &Degraded{FileHash: r.FileHash, Shards: r.Shards}
+			degraded[r.FileHash] = f
+		}
+		f.AtRisk = r.AtRisk
+	}
+	for _, f := range degraded {
+		dg.Degraded = append(dg.Degraded, f)
+	}
+	sort.Slice(dg.Expiring, func(i, j int) bool {
+		return dg.Expiring[i].RentEnd.Before(dg.Expiring[j].RentEnd)
+	})
+	sort.Slice(dg.Degraded, func(i, j int) bool {
+		return dg.Degraded[i].FileHash < dg.Degraded[j].FileHash
+	})
+	return dg
+}
+
+// Send posts dg as JSON to url.
+func Send(ctx context.Context, url string, dg *Digest) error {
+	b, err := json.Marshal(dg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s answered %s", url, resp.Status)
+	}
+	return nil
+}
+
+// Last returns the last digest sent by the node peerID, nil when none was.
+func Last(d ds.Datastore, peerID string) (*Digest, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(lastKey, peerID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dg := &Digest{}
+	if err := json.Unmarshal(b, dg); err != nil {
+		return nil, fmt.Errorf("invalid digest: %s", err)
+	}
+	return dg, nil
+}
+
+// SaveLast records dg as the last digest sent by the node peerID.
+func SaveLast(d ds.Datastore, peerID string, dg *Digest) error {
+	b, err := json.Marshal(dg)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(lastKey, peerID)), b)
+}
@@ -0,0 +1,86 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+)
+
+func contract(id, fileHash string, index int, state guardpb.Contract_ContractState, end time.Time) *guardpb.Contract {
+	c := &guardpb.Contract{}
+	c.ContractId = id
+	c.FileHash = fileHash
+	c.ShardIndex = int32(index)
+	c.HostPid = "host"
+	c.ShardFileSize = 1 << 30
+	c.Price = 10
+	c.RentStart = end.AddDate(0, 0, -30)
+	c.RentEnd = end
+	c.State = state
+	return c
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Now()
+	cs := []*guardpb.Contract{
+		contract("soon", "a", 0, guardpb.Contract_UPLOADED, now.AddDate(0, 0, 3)),
+		contract("later", "a", 1, guardpb.Contract_UPLOADED, now.AddDate(0, 0, 30)),
+		contract("lost", "a", 2, guardpb.Contract_LOST, now.AddDate(0, 0, 3)),
+		// a shard repaired is not lost
+		contract("lost-repaired", "b", 0, guardpb.Contract_LOST, now.AddDate(0, 0, 30)),
+		contract("repaired", "b", 0, guardpb.Contract_UPLOADED, now.AddDate(0, 0, 30)),
+		// a file no shard of which is active has expired
+		contract("expired", "c", 0, guardpb.Contract_CLOSED, now.AddDate(0, 0, -1)),
+		contract("expired-lost", "c", 1, guardpb.Contract_LOST, now.AddDate(0, 0, -1)),
+	}
+	risks := []*probe.FileRisk{{FileHash: "d", Shards: 4, AtRisk: 3}}
+	dg := Build(cs, risks, 7, now)
+	if len(dg.Expiring) != 1 || dg.Expiring[0].ContractID != "soon" {
+		t.Fatalf("got expiring %+v", dg.Expiring)
+	}
+	if dg.RenewalCost != 300 || dg.Expiring[0].RenewalCost != 300 {
+		t.Fatalf("got renewal cost %d, want 300", dg.RenewalCost)
+	}
+	if len(dg.Degraded) != 2 {
+		t.Fatalf("got degraded %+v", dg.Degraded)
+	}
+	if a := dg.Degraded[0]; a.FileHash != "a" || a.Shards != 3 || a.Lost != 1 {
+		t.Fatalf("got degraded %+v", a)
+	}
+	if d := dg.Degraded[1]; d.FileHash != "d" || d.AtRisk != 3 {
+		t.Fatalf("got degraded %+v", d)
+	}
+	if !Build(nil, nil, 7, now).Empty() {
+		t.Fatal("digest of no contracts not empty")
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Digest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	if err := Send(context.Background(), srv.URL, &Digest{Days: 7, RenewalCost: 42}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Days != 7 || got.RenewalCost != 42 {
+		t.Fatalf("webhook got %+v", got)
+	}
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := Send(context.Background(), failing.URL, &Digest{}); err == nil {
+		t.Fatal("failed webhook not reported")
+	}
+}
@@ -0,0 +1,121 @@
+package escrow
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const recoverOptionName = "recover"
+
+var StorageEscrowCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Check the payments of the renter contracts at the escrow.",
+		ShortDescription: `
+The escrow service holds the payments of the renter contracts until they are
+paid out to the hosts.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"reconcile": storageEscrowReconcileCmd,
+	},
+}
+
+var storageEscrowReconcileCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Reconcile the escrow ledger with the local contracts.",
+		ShortDescription: `
+Pulls the payout status of the renter contracts of this node from the escrow
+and compares it with the local contracts and upload sessions. Reports:
+
+    missing-payin     a contract paid in locally the escrow has no payin of
+    unreturned-funds  funds the escrow still holds for a failed upload
+    amount-mismatch   a contract paid in for another amount than agreed
+
+With --recover, a cancellation is requested at the escrow for each contract
+of unreturned funds, returning the funds left to this node. The requests are
+recorded and shown on later runs instead of being filed again.`,
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(recoverOptionName, "r", "File recovery requests for the unreturned funds.").WithDefault(false),
+	},
+	RunTimeout: 5 * time.Minute,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		d := n.Repo.Datastore()
+		self := n.Identity.Pretty()
+		records, err := Records(d, self)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(records))
+		for _, r := range records {
+			ids = append(ids, r.ContractID)
+		}
+		client := escrowclient.New(cfg.Services.EscrowDomain)
+		ledger, err := Ledger(req.Context, n, client, ids)
+		if err != nil {
+			return fmt.Errorf("cannot pull the escrow ledger: %s", err)
+		}
+		out := &ReconcileOutput{Contracts: len(records), Discrepancies: Reconcile(records, ledger)}
+		fileRecovery, _ := req.Options[recoverOptionName].(bool)
+		for _, dc := range out.Discrepancies {
+			if dc.Recovery, err = GetRecovery(d, self, dc.ContractID); err != nil {
+				return err
+			}
+			if !fileRecovery || dc.Kind != KindUnreturned || (dc.Recovery != nil && dc.Recovery.Error == "") {
+				continue
+			}
+			if dc.Recovery, err = FileRecovery(req.Context, n, client, dc.ContractID); err != nil {
+				return err
+			}
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: ReconcileOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ReconcileOutput) error {
+			fmt.Fprintf(w, "%d contracts checked, %d discrepancies\n", out.Contracts, len(out.Discrepancies))
+			if len(out.Discrepancies) == 0 {
+				return nil
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "CONTRACT\tKIND\tAMOUNT\tDETAIL\tRECOVERY")
+			for _, dc := range out.Discrepancies {
+				recovery := "-"
+				switch r := dc.Recovery; {
+				case r == nil:
+				case r.Error != "":
+					recovery = "failed: " + r.Error
+				case r.Canceled:
+					recovery = fmt.Sprintf("canceled, %d returned", r.Amount)
+				default:
+					recovery = "filed " + r.Time.Format(time.RFC3339)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", dc.ContractID, dc.Kind, dc.Amount, dc.Detail, recovery)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// ReconcileOutput is the outcome of the reconciliation of the escrow ledger.
+type ReconcileOutput struct {
+	Contracts     int
+	Discrepancies []*Discrepancy
+}
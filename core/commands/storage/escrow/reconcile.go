@@ -0,0 +1,267 @@
+package escrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	renterpb "github.com/TRON-US/go-btfs/protos/renter"
+
+	cconfig "github.com/tron-us/go-btfs-common/config"
+	"github.com/tron-us/go-btfs-common/crypto"
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+	"github.com/tron-us/protobuf/proto"
+
+	ds "github.com/ipfs/go-datastore"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+const recoveryKey = "/btfs/%s/escrow/recoveries/%s"
+
+// Kinds of the discrepancies between the escrow ledger and the local
+// records.
+const (
+	// KindMissingPayin is a contract paid locally the escrow has no payin of.
+	KindMissingPayin = "missing-payin"
+	// KindUnreturned is a contract of a failed upload the escrow still holds
+	// the funds of.
+	KindUnreturned = "unreturned-funds"
+	// KindAmountMismatch is a contract paid in for another amount than
+	// agreed.
+	KindAmountMismatch = "amount-mismatch"
+)
+
+// Record is what this node knows of one of its renter contracts.
+type Record struct {
+	ContractID string
+	Session    string
+	// SessionStatus is the status of the upload session, empty when the
+	// session is gone.
+	SessionStatus string
+	// GuardState is the state of the guard contract, nil before the upload
+	// reached the guard.
+	GuardState *guardpb.Contract_ContractState
+	Amount     int64
+}
+
+// paid reports whether the upload of the contract got past its payin.
+func (r *Record) paid() bool {
+	if r.GuardState != nil && (helper.ContractFilterMap["active"][*r.GuardState] ||
+		helper.ContractFilterMap["finished"][*r.GuardState]) {
+		return true
+	}
+	switch strings.Split(r.SessionStatus, ":")[0] {
+	case sessions.RssGuardStatus, sessions.RssWaitUploadStatus, sessions.RssCompleteStatus:
+		return true
+	}
+	return false
+}
+
+// Discrepancy is a contract the escrow ledger and the local records
+// disagree on.
+type Discrepancy struct {
+	ContractID string
+	Session    string
+	Kind       string
+	// Amount is the amount at stake.
+	Amount int64
+	Detail string
+	// Recovery is the recovery request filed for the funds, if any.
+	Recovery *Recovery `json:",omitempty"`
+}
+
+// Recovery is a request to the escrow to return the funds of a contract.
+type Recovery struct {
+	Time     time.Time
+	Canceled bool
+	Amount   int64
+	// Reference is the reference of the cancellation at the escrow.
+	Reference string `json:",omitempty"`
+	Error     string `json:",omitempty"`
+}
+
+// Records returns the renter contracts of the node peerID agreed with the
+// hosts, with the status of their upload.
+func Records(d ds.Datastore, peerID string) ([]*Record, error) {
+	cs, err := sessions.ListShardsContracts(d, peerID, nodepb.ContractStat_RENTER.String())
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[string]string)
+	var rs []*Record
+	for _, c := range cs {
+		if len(c.SignedEscrowContract) == 0 {
+			// synced from the guard, never agreed by this node
+			continue
+		}
+		ec := &escrowpb.SignedEscrowContract{}
+		if err := proto.Unmarshal(c.SignedEscrowContract, ec); err != nil || ec.Contract == nil {
+			continue
+		}
+		r := &Record{ContractID: ec.Contract.ContractId, Amount: ec.Contract.Amount}
+		if c.SignedGuardContract != nil {
+			r.GuardState = &c.SignedGuardContract.State
+		}
+		r.Session = strings.Split(r.ContractID, ":")[0]
+		status, ok := statuses[r.Session]
+		if !ok {
+			s := &renterpb.RenterSessionStatus{}
+			err := sessions.Get(d, fmt.Sprintf(sessions.RenterSessionStatusKey, peerID, r.Session), s)
+			if err != nil && err != ds.ErrNotFound {
+				return nil, err
+			}
+			status = s.Status
+			statuses[r.Session] = status
+		}
+		r.SessionStatus = status
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// Reconcile compares the records with the ledger, the payout status of
+// the contracts at the escrow by contract ID.
+func Reconcile(records []*Record, ledger map[string]*escrowpb.PayoutStatus) []*Discrepancy {
+	var out []*Discrepancy
+	for _, r := range records {
+		s, ok := ledger[r.ContractID]
+		if ok && s.ErrorMsg != "" {
+			ok = false
+		}
+		d := &Discrepancy{ContractID: r.ContractID, Session: r.Session}
+		switch {
+		case r.paid() && !ok:
+			d.Kind, d.Amount = KindMissingPayin, r.Amount
+			d.Detail = "no escrow for a contract paid in"
+		case r.paid() && (s.Status == escrowpb.EscrowStatus_INITIATED || s.Status == escrowpb.EscrowStatus_NOTSTART):
+			d.Kind, d.Amount = KindMissingPayin, r.Amount
+			d.Detail = fmt.Sprintf("escrow %s for a contract paid in", s.Status)
+		case !r.paid() && ok && s.LeftAmount > 0 &&
+			(s.Status == escrowpb.EscrowStatus_ACTIVE || s.Status == escrowpb.EscrowStatus_NOTSTART_PAID):
+			d.Kind, d.Amount = KindUnreturned, s.LeftAmount
+			d.Detail = fmt.Sprintf("escrow %s for an upload %s", s.Status, sessionState(r.SessionStatus))
+		case ok && s.Status != escrowpb.EscrowStatus_CANCELED && s.Amount != r.Amount && r.paid():
+			d.Kind, d.Amount = KindAmountMismatch, s.Amount-r.Amount
+			d.Detail = fmt.Sprintf("escrow holds %d for a contract of %d", s.Amount, r.Amount)
+		default:
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func sessionState(status string) string {
+	if status == "" {
+		return "gone"
+	}
+	return status
+}
+
+// Ledger returns the payout status of the contracts at the escrow, by
+// contract ID. The contracts unknown to the escrow are missing.
+func Ledger(ctx context.Context, n *core.IpfsNode, c escrowclient.Client, contractIDs []string) (map[string]*escrowpb.PayoutStatus, error) {
+	address, err := address(n)
+	if err != nil {
+		return nil, err
+	}
+	ledger := make(map[string]*escrowpb.PayoutStatus)
+	for i := 0; i < len(contractIDs); i += cconfig.ConstRequestPayoutBatchPageSize {
+		end := i + cconfig.ConstRequestPayoutBatchPageSize
+		if end > len(contractIDs) {
+			end = len(contractIDs)
+		}
+		in := &escrowpb.SignedContractIDBatch{
+			Data: &escrowpb.ContractIDBatch{
+				ContractId: contractIDs[i:end],
+				Address:    address,
+			},
+		}
+		if in.Signature, err = crypto.Sign(n.PrivateKey, in.Data); err != nil {
+			return nil, err
+		}
+		sb, err := c.GetPayOutStatusBatch(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sb.Status {
+			ledger[s.ContractId] = s
+		}
+	}
+	return ledger, nil
+}
+
+// FileRecovery asks the escrow to cancel the contract contractID, which
+// returns the funds left to this node, and records the request.
+func FileRecovery(ctx context.Context, n *core.IpfsNode, c escrowclient.Client, contractID string) (*Recovery, error) {
+	address, err := address(n)
+	if err != nil {
+		return nil, err
+	}
+	in := &escrowpb.SignedCancelRequest{
+		Request: &escrowpb.CancelContractRequest{
+			ContractId:     contractID,
+			AuthAddress:    address,
+			AuthSignedTime: time.Now(),
+		},
+	}
+	if in.AuthSignature, err = crypto.Sign(n.PrivateKey, in.Request); err != nil {
+		return nil, err
+	}
+	r := &Recovery{Time: time.Now()}
+	res, err := c.CancelContracts(ctx, in)
+	if err != nil {
+		r.Error = err.Error()
+	} else if res.Result != nil {
+		r.Canceled = res.Result.Canceled
+		r.Amount = res.Result.Amount
+		r.Reference = res.Result.Reference
+	}
+	if err := PutRecovery(n.Repo.Datastore(), n.Identity.Pretty(), contractID, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetRecovery returns the recovery request filed for the contract
+// contractID, nil when none was.
+func GetRecovery(d ds.Datastore, peerID, contractID string) (*Recovery, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(recoveryKey, peerID, contractID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &Recovery{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// PutRecovery records the recovery request r filed for the contract
+// contractID.
+func PutRecovery(d ds.Datastore, peerID, contractID string, r *Recovery) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(recoveryKey, peerID, contractID)), b)
+}
+
+func address(n *core.IpfsNode) ([]byte, error) {
+	pk, err := n.Identity.ExtractPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return ic.RawFull(pk)
+}
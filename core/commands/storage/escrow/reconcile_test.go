@@ -0,0 +1,74 @@
+package escrow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestReconcile(t *testing.T) {
+	uploaded := guardpb.Contract_UPLOADED
+	records := []*Record{
+		// paid, escrow agrees
+		{ContractID: "ok", SessionStatus: sessions.RssCompleteStatus, GuardState: &uploaded, Amount: 100},
+		// paid, unknown to the escrow
+		{ContractID: "lost", SessionStatus: sessions.RssWaitUploadStatus, Amount: 100},
+		// paid, never paid in
+		{ContractID: "initiated", SessionStatus: sessions.RssGuardFileMetaSignedStatus, Amount: 100},
+		// failed, funds still held
+		{ContractID: "held", SessionStatus: sessions.RssErrorStatus, Amount: 100},
+		// failed, funds returned
+		{ContractID: "returned", SessionStatus: sessions.RssErrorStatus, Amount: 100},
+		// failed before the payin
+		{ContractID: "unpaid", SessionStatus: sessions.RssErrorStatus, Amount: 100},
+		// paid in for another amount
+		{ContractID: "short", SessionStatus: sessions.RssCompleteStatus, Amount: 100},
+	}
+	ledger := map[string]*escrowpb.PayoutStatus{
+		"ok":        {ContractId: "ok", Status: escrowpb.EscrowStatus_ACTIVE, Amount: 100, LeftAmount: 50},
+		"lost":      {ContractId: "lost", ErrorMsg: "not found"},
+		"initiated": {ContractId: "initiated", Status: escrowpb.EscrowStatus_INITIATED, Amount: 100},
+		"held":      {ContractId: "held", Status: escrowpb.EscrowStatus_NOTSTART_PAID, Amount: 100, LeftAmount: 100},
+		"returned":  {ContractId: "returned", Status: escrowpb.EscrowStatus_CANCELED, Amount: 100},
+		"short":     {ContractId: "short", Status: escrowpb.EscrowStatus_ACTIVE, Amount: 80, LeftAmount: 80},
+	}
+	want := map[string]string{
+		"lost":      KindMissingPayin,
+		"initiated": KindMissingPayin,
+		"held":      KindUnreturned,
+		"short":     KindAmountMismatch,
+	}
+	got := Reconcile(records, ledger)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d discrepancies, got %d", len(want), len(got))
+	}
+	for _, d := range got {
+		if want[d.ContractID] != d.Kind {
+			t.Fatalf("contract %s: expected %q, got %q", d.ContractID, want[d.ContractID], d.Kind)
+		}
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	if r, err := GetRecovery(d, "peer", "held"); err != nil || r != nil {
+		t.Fatalf("expected no recovery, got %v %v", r, err)
+	}
+	if err := PutRecovery(d, "peer", "held", &Recovery{Time: time.Now(), Canceled: true, Amount: 100}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := GetRecovery(d, "peer", "held")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Canceled || r.Amount != 100 {
+		t.Fatalf("unexpected recovery %+v", r)
+	}
+}
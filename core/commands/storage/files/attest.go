@@ -0,0 +1,312 @@
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	blocks "github.com/ipfs/go-block-format"
+	cidlib "github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+)
+
+const (
+	// maxShardDepth is the deepest a shard is under the root of its file.
+	maxShardDepth = 4
+	// maxPathLength bounds the blocks of the path of an attestation.
+	maxPathLength = 64
+)
+
+// ErrInvalidAttestation is returned for an attestation which does not
+// prove what it claims.
+var ErrInvalidAttestation = errors.New("invalid attestation")
+
+// Block is a block of the path of an attestation, its raw data.
+type Block struct {
+	Cid  string
+	Data []byte
+}
+
+// Attestation is the signed statement of a host that it holds the shard of
+// a contract at a time. Its path is the blocks from the root of the file to
+// the shard, then down the shard to a leaf picked from the nonce of the
+// renter, so that the host needs the blocks of the shard to answer.
+type Attestation struct {
+	ContractID string
+	FileHash   string
+	ShardHash  string
+	Host       string
+	Nonce      string
+	Time       time.Time
+	Path       []*Block
+	Signature  []byte `json:",omitempty"`
+}
+
+func (a *Attestation) signedBytes() ([]byte, error) {
+	u := *a
+	u.Signature = nil
+	return json.Marshal(&u)
+}
+
+// Sign signs a with key, the key of its host.
+func (a *Attestation) Sign(key ic.PrivKey) error {
+	b, err := a.signedBytes()
+	if err != nil {
+		return err
+	}
+	a.Signature, err = key.Sign(b)
+	return err
+}
+
+// Verify checks that a was signed by its host for the shard shardHash of
+// the file fileHash and the nonce, and that its path proves the shard from
+// the root of the file down to a leaf.
+func (a *Attestation) Verify(fileHash, shardHash, nonce string) error {
+	if a.FileHash != fileHash || a.ShardHash != shardHash || a.Nonce != nonce {
+		return fmt.Errorf("%w: attests shard %s of file %s for nonce %s", ErrInvalidAttestation,
+			a.ShardHash, a.FileHash, a.Nonce)
+	}
+	if err := verifySignature(a.Host, a.signedBytes, a.Signature); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAttestation, err)
+	}
+	if err := verifyPath(a.Path, fileHash, shardHash, nonce); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAttestation, err)
+	}
+	return nil
+}
+
+func verifySignature(signer string, signedBytes func() ([]byte, error), sig []byte) error {
+	id, err := peer.IDB58Decode(signer)
+	if err != nil {
+		return fmt.Errorf("invalid signer: %s", err)
+	}
+	pub, err := id.ExtractPublicKey()
+	if err != nil {
+		return fmt.Errorf("cannot get the key of %s: %s", signer, err)
+	}
+	b, err := signedBytes()
+	if err != nil {
+		return err
+	}
+	if ok, err := pub.Verify(b, sig); err != nil || !ok {
+		return fmt.Errorf("not signed by %s", signer)
+	}
+	return nil
+}
+
+// pick returns the index of the link followed at depth under a shard for
+// nonce, out of n.
+func pick(nonce string, depth, n int) int {
+	h := sha256.New()
+	h.Write([]byte(nonce))
+	var d [8]byte
+	binary.BigEndian.PutUint64(d[:], uint64(depth))
+	h.Write(d[:])
+	return int(binary.BigEndian.Uint64(h.Sum(nil)[:8]) % uint64(n))
+}
+
+// decode checks that data is the block c and returns its node.
+func decode(c cidlib.Cid, data []byte) (ipld.Node, error) {
+	sum, err := c.Prefix().Sum(data)
+	if err != nil {
+		return nil, err
+	}
+	if !sum.Equals(c) {
+		return nil, fmt.Errorf("data of block %s does not match its hash", c)
+	}
+	blk, err := blocks.NewBlockWithCid(data, c)
+	if err != nil {
+		return nil, err
+	}
+	switch c.Type() {
+	case cidlib.DagProtobuf:
+		return merkledag.DecodeProtobufBlock(blk)
+	case cidlib.Raw:
+		return merkledag.DecodeRawBlock(blk)
+	default:
+		return nil, fmt.Errorf("unsupported codec of block %s", c)
+	}
+}
+
+func verifyPath(path []*Block, fileHash, shardHash, nonce string) error {
+	if len(path) == 0 || len(path) > maxPathLength {
+		return fmt.Errorf("path of %d blocks", len(path))
+	}
+	if path[0].Cid != fileHash {
+		return fmt.Errorf("path starts at %s instead of the file root", path[0].Cid)
+	}
+	shard := -1
+	for i, b := range path {
+		c, err := cidlib.Parse(b.Cid)
+		if err != nil {
+			return err
+		}
+		nd, err := decode(c, b.Data)
+		if err != nil {
+			return err
+		}
+		if b.Cid == shardHash && shard < 0 {
+			shard = i
+		}
+		links := nd.Links()
+		if i == len(path)-1 {
+			if len(links) > 0 {
+				return fmt.Errorf("path ends at %s, not a leaf", b.Cid)
+			}
+			break
+		}
+		next := path[i+1].Cid
+		if shard >= 0 {
+			// under the shard, the links picked by the nonce
+			if len(links) == 0 {
+				return fmt.Errorf("path goes on under leaf %s", b.Cid)
+			}
+			if l := links[pick(nonce, i-shard, len(links))]; l.Cid.String() != next {
+				return fmt.Errorf("path follows %s instead of %s under %s", next, l.Cid, b.Cid)
+			}
+			continue
+		}
+		linked := false
+		for _, l := range links {
+			if l.Cid.String() == next {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			return fmt.Errorf("block %s does not link to %s", b.Cid, next)
+		}
+	}
+	if shard < 0 || shard > maxShardDepth {
+		return fmt.Errorf("path does not reach shard %s", shardHash)
+	}
+	return nil
+}
+
+// Prove returns the path of the attestation of the shard shardHash of the
+// file fileHash for nonce, from the blocks dag holds.
+func Prove(ctx context.Context, dag ipld.NodeGetter, fileHash, shardHash cidlib.Cid, nonce string) ([]*Block, error) {
+	nodes, err := shardPath(ctx, dag, fileHash, shardHash, 0)
+	if err != nil {
+		return nil, err
+	}
+	if nodes == nil {
+		return nil, fmt.Errorf("shard %s not found under %s", shardHash, fileHash)
+	}
+	for depth := 0; ; depth++ {
+		links := nodes[len(nodes)-1].Links()
+		if len(links) == 0 {
+			break
+		}
+		if len(nodes) == maxPathLength {
+			return nil, fmt.Errorf("shard %s too deep", shardHash)
+		}
+		nd, err := links[pick(nonce, depth, len(links))].GetNode(ctx, dag)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, nd)
+	}
+	path := make([]*Block, len(nodes))
+	for i, nd := range nodes {
+		path[i] = &Block{Cid: nd.Cid().String(), Data: nd.RawData()}
+	}
+	return path, nil
+}
+
+// shardPath returns the nodes from c down to the shard shardHash, nil when
+// the shard is not under c. The shards of the other hosts are skipped.
+func shardPath(ctx context.Context, dag ipld.NodeGetter, c, shardHash cidlib.Cid, depth int) ([]ipld.Node, error) {
+	nd, err := dag.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if c.Equals(shardHash) {
+		return []ipld.Node{nd}, nil
+	}
+	if depth == maxShardDepth {
+		return nil, nil
+	}
+	links := nd.Links()
+	for _, l := range links {
+		if l.Cid.Equals(shardHash) {
+			links = []*ipld.Link{l}
+			break
+		}
+	}
+	for _, l := range links {
+		nodes, err := shardPath(ctx, dag, l.Cid, shardHash, depth+1)
+		if err != nil && ctx.Err() != nil {
+			return nil, err
+		}
+		if nodes != nil {
+			return append([]ipld.Node{nd}, nodes...), nil
+		}
+	}
+	return nil, nil
+}
+
+// Failure is a host which did not attest its shard.
+type Failure struct {
+	ContractID string
+	Host       string
+	ShardHash  string
+	Error      string
+}
+
+// Bundle is the evidence of the retention of a file by its hosts at a
+// time, collected and signed by its renter.
+type Bundle struct {
+	FileHash     string
+	Renter       string
+	Time         time.Time
+	Attestations []*Attestation
+	Failures     []*Failure `json:",omitempty"`
+	Signature    []byte     `json:",omitempty"`
+}
+
+func (b *Bundle) signedBytes() ([]byte, error) {
+	u := *b
+	u.Signature = nil
+	return json.Marshal(&u)
+}
+
+// Sign signs b with key, the key of its renter.
+func (b *Bundle) Sign(key ic.PrivKey) error {
+	raw, err := b.signedBytes()
+	if err != nil {
+		return err
+	}
+	b.Signature, err = key.Sign(raw)
+	return err
+}
+
+// Verify checks that b was signed by its renter and returns the error of
+// each of its attestations, nil for the valid ones.
+func (b *Bundle) Verify() ([]error, error) {
+	if err := verifySignature(b.Renter, b.signedBytes, b.Signature); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %s", err)
+	}
+	errs := make([]error, len(b.Attestations))
+	for i, a := range b.Attestations {
+		errs[i] = a.Verify(b.FileHash, a.ShardHash, a.Nonce)
+	}
+	return errs, nil
+}
+
+// ParseBundle decodes the bundle in data.
+func ParseBundle(data []byte) (*Bundle, error) {
+	b := &Bundle{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %s", err)
+	}
+	return b, nil
+}
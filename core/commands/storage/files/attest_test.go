@@ -0,0 +1,176 @@
+package files
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	cidlib "github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+)
+
+const nonce = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+func identity(t *testing.T) (ic.PrivKey, string) {
+	priv, _, err := ic.GenerateKeyPair(ic.Secp256k1, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, id.Pretty()
+}
+
+// file adds a file of two shards of leaves to dag and returns its root and
+// shards.
+func file(t *testing.T, dag ipld.DAGService) (cidlib.Cid, []cidlib.Cid) {
+	ctx := context.Background()
+	root := &merkledag.ProtoNode{}
+	var shards []cidlib.Cid
+	for i := 0; i < 2; i++ {
+		shard := &merkledag.ProtoNode{}
+		for j := 0; j < 5; j++ {
+			data := make([]byte, 64)
+			rand.Read(data)
+			leaf := merkledag.NewRawNode(data)
+			if err := dag.Add(ctx, leaf); err != nil {
+				t.Fatal(err)
+			}
+			if err := shard.AddNodeLink("", leaf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := dag.Add(ctx, shard); err != nil {
+			t.Fatal(err)
+		}
+		if err := root.AddNodeLink("", shard); err != nil {
+			t.Fatal(err)
+		}
+		shards = append(shards, shard.Cid())
+	}
+	if err := dag.Add(ctx, root); err != nil {
+		t.Fatal(err)
+	}
+	return root.Cid(), shards
+}
+
+func TestAttestation(t *testing.T) {
+	dag := mdtest.Mock()
+	root, shards := file(t, dag)
+	key, host := identity(t)
+
+	path, err := Prove(context.Background(), dag, root, shards[1], nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 3 || path[1].Cid != shards[1].String() {
+		t.Fatalf("unexpected path %v", path)
+	}
+	a := &Attestation{
+		ContractID: "c",
+		FileHash:   root.String(),
+		ShardHash:  shards[1].String(),
+		Host:       host,
+		Nonce:      nonce,
+		Time:       time.Now(),
+		Path:       path,
+	}
+	if err := a.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Verify(root.String(), shards[1].String(), nonce); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tamper := range map[string]func(a *Attestation){
+		"other shard": func(a *Attestation) { a.ShardHash = shards[0].String() },
+		"other time":  func(a *Attestation) { a.Time = a.Time.Add(-time.Hour) },
+		"bad data":    func(a *Attestation) { a.Path[2].Data = append([]byte{}, a.Path[1].Data...) },
+		"short path":  func(a *Attestation) { a.Path = a.Path[:2] },
+	} {
+		b := *a
+		b.Path = append([]*Block{}, a.Path...)
+		b.Path[2] = &Block{Cid: a.Path[2].Cid, Data: a.Path[2].Data}
+		tamper(&b)
+		if err := b.Verify(root.String(), b.ShardHash, nonce); !errors.Is(err, ErrInvalidAttestation) {
+			t.Fatalf("%s: expected an invalid attestation, got %v", name, err)
+		}
+	}
+}
+
+func TestVerifyPath(t *testing.T) {
+	dag := mdtest.Mock()
+	root, shards := file(t, dag)
+	ctx := context.Background()
+	path, err := Prove(ctx, dag, root, shards[0], nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a leaf of the shard the nonce did not pick
+	shard, err := dag.Get(ctx, shards[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range shard.Links() {
+		if l.Cid.String() == path[2].Cid {
+			continue
+		}
+		leaf, err := dag.Get(ctx, l.Cid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other := []*Block{path[0], path[1], {Cid: leaf.Cid().String(), Data: leaf.RawData()}}
+		if err := verifyPath(other, root.String(), shards[0].String(), nonce); err == nil {
+			t.Fatal("expected the leaf not picked by the nonce to fail")
+		}
+		break
+	}
+}
+
+func TestBundle(t *testing.T) {
+	dag := mdtest.Mock()
+	root, shards := file(t, dag)
+	hostKey, host := identity(t)
+	renterKey, renter := identity(t)
+
+	b := &Bundle{FileHash: root.String(), Renter: renter, Time: time.Now()}
+	for i, s := range shards {
+		path, err := Prove(context.Background(), dag, root, s, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := &Attestation{FileHash: root.String(), ShardHash: s.String(), Host: host, Nonce: nonce, Path: path}
+		if err := a.Sign(hostKey); err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			// signed by another key
+			a.Host = renter
+		}
+		b.Attestations = append(b.Attestations, a)
+	}
+	if err := b.Sign(renterKey); err != nil {
+		t.Fatal(err)
+	}
+	errs, err := b.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs[0] != nil || errs[1] == nil {
+		t.Fatalf("unexpected results %v", errs)
+	}
+
+	b.Time = b.Time.Add(time.Hour)
+	if _, err := b.Verify(); err == nil {
+		t.Fatal("expected a bundle changed after signing to fail")
+	}
+}
@@ -0,0 +1,601 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/bandwidth"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/core/ingest"
+
+	chunker "github.com/TRON-US/go-btfs-chunker"
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	humanize "github.com/dustin/go-humanize"
+	uuid "github.com/google/uuid"
+	cidlib "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ProveTimeout bounds the attestation of a host.
+const ProveTimeout = time.Minute
+
+var StorageFilesCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Collect evidence of the retention of the stored files.",
+		ShortDescription: `
+Collects a signed attestation from each host of a file that it holds its
+shard now, into a bundle an auditor can check without trusting the renter
+or the hosts:
+
+    $ btfs storage files attest <file-hash> > bundle.json
+    $ btfs storage files verify bundle.json
+
+Each attestation has the blocks from the root of the file down to the shard
+of the host, then down to a leaf of the shard picked from a random nonce,
+so that the host had to hold the shard when it answered. The attestations
+are signed by the hosts with their peer keys, the bundle by the renter.
+
+'btfs storage files plan-restore' checks the file can be restored from the
+hosts up now, and estimates the download.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"attest":       storageFilesAttestCmd,
+		"verify":       storageFilesVerifyCmd,
+		"prove":        StorageFilesProveCmd,
+		"stats":        storageFilesStatsCmd,
+		"plan-restore": storageFilesPlanRestoreCmd,
+	},
+}
+
+var storageFilesAttestCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Collect the signed attestations of the hosts of a file.",
+		ShortDescription: `
+Asks the host of each active contract of the file to attest its shard and
+prints the bundle of the attestations, signed by this renter. The hosts
+which did not attest, or whose attestation does not verify, are listed as
+failures.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("file-hash", true, false, "Hash of the file uploaded."),
+	},
+	RunTimeout: 10 * time.Minute,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		fileHash, err := cidlib.Parse(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		b, err := Attest(req.Context, n, api, fileHash.String(), time.Now())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, b)
+	},
+	Type: Bundle{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, b *Bundle) error {
+			e := json.NewEncoder(w)
+			e.SetIndent("", "  ")
+			return e.Encode(b)
+		}),
+	},
+}
+
+// Attest collects the attestations of the hosts of the active renter
+// contracts of the file fileHash into a bundle signed by n.
+func Attest(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, fileHash string, now time.Time) (*Bundle, error) {
+	self := n.Identity.Pretty()
+	scs, err := sessions.ListShardsContracts(n.Repo.Datastore(), self, nodepb.ContractStat_RENTER.String())
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{FileHash: fileHash, Renter: self, Time: now}
+	for _, sc := range scs {
+		c := sc.SignedGuardContract
+		if c == nil || c.FileHash != fileHash || !helper.ContractFilterMap["active"][c.State] {
+			continue
+		}
+		a, err := attest(ctx, n, api, c)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			b.Failures = append(b.Failures, &Failure{
+				ContractID: c.ContractId,
+				Host:       c.HostPid,
+				ShardHash:  c.ShardHash,
+				Error:      err.Error(),
+			})
+			continue
+		}
+		b.Attestations = append(b.Attestations, a)
+	}
+	if len(b.Attestations) == 0 && len(b.Failures) == 0 {
+		return nil, fmt.Errorf("no active contracts for file %s", fileHash)
+	}
+	if err := b.Sign(n.PrivateKey); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// attest has the host of c attest its shard, verified.
+func attest(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, c *guardpb.Contract) (*Attestation, error) {
+	hostPid, err := peer.IDB58Decode(c.HostPid)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ProveTimeout)
+	defer cancel()
+	raw, err := remote.P2PCallStrings(ctx, n, api, hostPid, "/storage/files/prove", c.ContractId,
+		nonce.String())
+	if err != nil {
+		return nil, err
+	}
+	a := &Attestation{}
+	if err := json.Unmarshal(raw, a); err != nil {
+		return nil, err
+	}
+	if a.ContractID != c.ContractId || a.Host != c.HostPid {
+		return nil, fmt.Errorf("%w: attests contract %s of host %s", ErrInvalidAttestation,
+			a.ContractID, a.Host)
+	}
+	if err := a.Verify(c.FileHash, c.ShardHash, nonce.String()); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+var StorageFilesProveCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Attest the shard of a contract (host).",
+		ShortDescription: `
+Called by the renter of a contract stored by this host, returns the
+attestation of its shard for the nonce, signed by this host.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("contract-id", true, false, "ID of the contract stored by this host."),
+		cmds.StringArg("nonce", true, false, "Nonce of the attestation. A random UUIDv4 string."),
+	},
+	RunTimeout: ProveTimeout,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageHostEnabled {
+			return fmt.Errorf("storage host api not enabled")
+		}
+		// the attestations hold off the shards uploaded, as the challenges
+		defer ingest.Default.Challenge()()
+
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		// only the blocks held by this host
+		api, err := cmdenv.GetApi(env, req, options.Api.Offline(true))
+		if err != nil {
+			return err
+		}
+		if _, err := uuid.Parse(req.Arguments[1]); err != nil {
+			return fmt.Errorf("invalid nonce: %s", err)
+		}
+		c, err := contracts.ShardContract(n.Repo.Datastore(), n.Identity.Pretty(),
+			nodepb.ContractStat_HOST.String(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		renter, ok := remote.GetStreamRequestRemotePeerID(req, n)
+		if !ok || renter.Pretty() != c.RenterPid {
+			return errors.New("only the renter of the contract can have it attested")
+		}
+		fileHash, err := cidlib.Parse(c.FileHash)
+		if err != nil {
+			return err
+		}
+		shardHash, err := cidlib.Parse(c.ShardHash)
+		if err != nil {
+			return err
+		}
+		path, err := Prove(req.Context, api.Dag(), fileHash, shardHash, req.Arguments[1])
+		if err != nil {
+			return err
+		}
+		a := &Attestation{
+			ContractID: c.ContractId,
+			FileHash:   c.FileHash,
+			ShardHash:  c.ShardHash,
+			Host:       n.Identity.Pretty(),
+			Nonce:      req.Arguments[1],
+			Time:       time.Now(),
+			Path:       path,
+		}
+		if err := a.Sign(n.PrivateKey); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, a)
+	},
+	Type: Attestation{},
+}
+
+// VerifyResult is the outcome of the check of an attestation.
+type VerifyResult struct {
+	ContractID string
+	Host       string
+	ShardHash  string
+	Time       time.Time
+	Valid      bool
+	Error      string `json:",omitempty"`
+}
+
+// VerifyOutput is the outcome of the check of a bundle.
+type VerifyOutput struct {
+	FileHash string
+	Renter   string
+	Time     time.Time
+	Results  []*VerifyResult
+	Failures []*Failure `json:",omitempty"`
+}
+
+var storageFilesVerifyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Verify a bundle of attestations.",
+		ShortDescription: `
+Checks the signature of the renter of the bundle and the attestations of
+the hosts in it: their signatures, and that the blocks of each hash to
+their IDs and link the root of the file to a leaf of the shard. Fails when
+the bundle is not signed by its renter.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.FileArg("bundle", true, false, "Bundle printed by 'btfs storage files attest'.").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		file, err := cmdenv.GetFileArg(req.Files.Entries())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		b, err := ParseBundle(data)
+		if err != nil {
+			return err
+		}
+		errs, err := b.Verify()
+		if err != nil {
+			return err
+		}
+		out := &VerifyOutput{FileHash: b.FileHash, Renter: b.Renter, Time: b.Time, Failures: b.Failures}
+		for i, a := range b.Attestations {
+			r := &VerifyResult{
+				ContractID: a.ContractID,
+				Host:       a.Host,
+				ShardHash:  a.ShardHash,
+				Time:       a.Time,
+				Valid:      errs[i] == nil,
+			}
+			if errs[i] != nil {
+				r.Error = errs[i].Error()
+			}
+			out.Results = append(out.Results, r)
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: VerifyOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *VerifyOutput) error {
+			fmt.Fprintf(w, "file %s, collected by %s on %s\n", out.FileHash, out.Renter,
+				out.Time.Format(time.RFC3339))
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "HOST\tSHARD\tTIME\tRESULT")
+			for _, r := range out.Results {
+				result := "valid"
+				if !r.Valid {
+					result = "invalid: " + r.Error
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Host, r.ShardHash, r.Time.Format(time.RFC3339), result)
+			}
+			for _, f := range out.Failures {
+				fmt.Fprintf(tw, "%s\t%s\t\tnot attested: %s\n", f.Host, f.ShardHash, f.Error)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+const (
+	planOutOptionName      = "out"
+	planRateOptionName     = "rate"
+	planDownlinkOptionName = "downlink"
+
+	// planCallTimeout bounds the call resolving a host of a restore plan.
+	planCallTimeout = 15 * time.Second
+)
+
+var storageFilesPlanRestoreCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Plan the restore of a stored file without downloading it.",
+		ShortDescription: `
+Resolves the hosts of the active contracts of each shard of the file, and
+plans the download of the shards needed to restore it: from which hosts,
+the bytes, the cost of their bandwidth and the expected duration. Nothing is
+downloaded, so that disaster recovery runbooks can check periodically that
+the file can still be restored, and how fast:
+
+    $ btfs storage files plan-restore <file-hash> --out plan.json
+    file <file-hash> restorable from 10 of 20 shards available, 10 needed
+    1.0 GB in about 2m30s for 0 µBTT
+
+A host is up when it answers the call for its bandwidth terms, or is
+connected to this node. The rate of a host is estimated from its retrieval
+probes, see 'btfs storage probe', or is --rate when it was not probed. The
+command exits with an error when the file cannot be restored, after writing
+the plan.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("file-hash", true, false, "Hash of the file uploaded."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(planOutOptionName, "o", "File to write the plan to, printed when not given."),
+		cmds.StringOption(planRateOptionName, "Download rate per second assumed of the hosts not probed.").WithDefault("1MB"),
+		cmds.StringOption(planDownlinkOptionName, "Download rate per second of this node, unbounded when not given."),
+	},
+	RunTimeout: 5 * time.Minute,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		rate, err := parseRate(req, planRateOptionName)
+		if err != nil {
+			return err
+		}
+		if rate == 0 {
+			return fmt.Errorf("--%s must be positive", planRateOptionName)
+		}
+		downlink, err := parseRate(req, planDownlinkOptionName)
+		if err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		fileHash, err := cidlib.Parse(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		p, err := PlanRestore(req.Context, n, api, fileHash, rate, downlink, time.Now())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, p)
+	},
+	Type: RestorePlan{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, p *RestorePlan) error {
+			e := json.NewEncoder(w)
+			e.SetIndent("", "  ")
+			return e.Encode(p)
+		}),
+	},
+	PostRun: cmds.PostRunMap{
+		cmds.CLI: func(res cmds.Response, re cmds.ResponseEmitter) error {
+			v, err := res.Next()
+			if err != nil {
+				return err
+			}
+			p, ok := v.(*RestorePlan)
+			if !ok {
+				return fmt.Errorf("unexpected output type %T", v)
+			}
+			out, _ := res.Request().Options[planOutOptionName].(string)
+			if out == "" {
+				if err := re.Emit(p); err != nil {
+					return err
+				}
+			} else {
+				b, err := json.MarshalIndent(p, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := ioutil.WriteFile(filepath.Clean(out), append(b, '\n'), 0644); err != nil {
+					return err
+				}
+				writePlanSummary(os.Stdout, p)
+			}
+			if !p.Restorable {
+				return fmt.Errorf("file %s cannot be restored: only %d shards available, %d needed",
+					p.FileHash, p.Available, p.DataShards)
+			}
+			return nil
+		},
+	},
+}
+
+func parseRate(req *cmds.Request, name string) (uint64, error) {
+	s, _ := req.Options[name].(string)
+	if s == "" {
+		return 0, nil
+	}
+	rate, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s: %s", name, err)
+	}
+	return rate, nil
+}
+
+func writePlanSummary(w io.Writer, p *RestorePlan) {
+	state := "restorable"
+	if !p.Restorable {
+		state = "not restorable"
+	}
+	fmt.Fprintf(w, "file %s %s from %d of %d shards available, %d needed\n", p.FileHash, state,
+		p.Available, len(p.Shards), p.DataShards)
+	if p.Restorable {
+		fmt.Fprintf(w, "%s in about %s for %d µBTT\n", humanize.Bytes(p.Bytes), p.Duration, p.Cost)
+	}
+	for _, warning := range p.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
+
+// PlanRestore resolves the hosts of the active renter contracts of the
+// shards of the reed-solomon file fileHash, and plans its download. The
+// hosts not probed are assumed to send rate bytes per second.
+func PlanRestore(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, fileHash cidlib.Cid,
+	rate, downlink uint64, now time.Time) (*RestorePlan, error) {
+	mbytes, err := api.Unixfs().GetMetadata(ctx, path.IpfsPath(fileHash))
+	if err != nil {
+		return nil, fmt.Errorf("file must be reed-solomon encoded: %s", err)
+	}
+	var rsMeta chunker.RsMetaMap
+	if err := json.Unmarshal(mbytes, &rsMeta); err != nil || rsMeta.NumData == 0 {
+		return nil, fmt.Errorf("file must be reed-solomon encoded")
+	}
+	hashes, _, err := helper.CheckAndGetReedSolomonShardHashes(ctx, n, api, fileHash)
+	if err != nil {
+		return nil, err
+	}
+	d := n.Repo.Datastore()
+	self := n.Identity.Pretty()
+	scs, err := sessions.ListShardsContracts(d, self, nodepb.ContractStat_RENTER.String())
+	if err != nil {
+		return nil, err
+	}
+	byShard := make(map[string][]*guardpb.Contract)
+	hosts := make(map[string]*RestoreHost)
+	for _, sc := range scs {
+		c := sc.SignedGuardContract
+		if c == nil || c.FileHash != fileHash.String() || !helper.ContractFilterMap["active"][c.State] {
+			continue
+		}
+		byShard[c.ShardHash] = append(byShard[c.ShardHash], c)
+		hosts[c.HostPid] = &RestoreHost{ID: c.HostPid, Rate: rate}
+	}
+	pcfg, err := probe.Load(n.Repo)
+	if err != nil {
+		return nil, err
+	}
+	probes, err := probe.Hosts(d, self)
+	if err != nil {
+		return nil, err
+	}
+	var wg sync.WaitGroup
+	for _, h := range hosts {
+		if ph, ok := probes[h.ID]; ok {
+			if s := ph.Stats(); s.P90 > 0 {
+				h.Rate = uint64(float64(pcfg.RangeBytes()) / s.P90.Seconds())
+				h.Probed = true
+			}
+		}
+		wg.Add(1)
+		go func(h *RestoreHost) {
+			defer wg.Done()
+			resolveRestoreHost(ctx, n, api, h)
+		}(h)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	p := &RestorePlan{
+		Version:      RestorePlanVersion,
+		FileHash:     fileHash.String(),
+		Renter:       self,
+		Time:         now,
+		FileSize:     rsMeta.FileSize,
+		DataShards:   int(rsMeta.NumData),
+		ParityShards: int(rsMeta.NumParity),
+	}
+	for i, hash := range hashes {
+		s := &RestoreShard{Index: i, Hash: hash.String()}
+		for _, c := range byShard[s.Hash] {
+			s.Size = uint64(c.ShardFileSize)
+			h := *hosts[c.HostPid]
+			h.ContractID = c.ContractId
+			s.Hosts = append(s.Hosts, &h)
+		}
+		p.Shards = append(p.Shards, s)
+	}
+	p.Schedule(downlink)
+	return p, nil
+}
+
+// resolveRestoreHost has the host h send its bandwidth terms, up when it
+// answers or is connected to the node.
+func resolveRestoreHost(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, h *RestoreHost) {
+	pid, err := peer.IDB58Decode(h.ID)
+	if err != nil {
+		h.Status, h.Error = HostDown, err.Error()
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, planCallTimeout)
+	defer cancel()
+	b, err := remote.P2PCallStrings(ctx, n, api, pid, "/storage/bandwidth/terms")
+	if err == nil {
+		t := &bandwidth.Terms{}
+		if err = json.Unmarshal(b, t); err == nil {
+			h.Status, h.Price = HostUp, t.Price
+			return
+		}
+	}
+	// a host not charging its bandwidth refuses the call
+	if n.PeerHost.Network().Connectedness(pid) == network.Connected {
+		h.Status = HostUp
+		return
+	}
+	h.Status, h.Error = HostDown, err.Error()
+}
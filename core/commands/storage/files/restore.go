@@ -0,0 +1,151 @@
+package files
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/bandwidth"
+)
+
+// RestorePlanVersion is the version of the format of the restore plans.
+const RestorePlanVersion = 1
+
+// Statuses of the hosts of a restore plan.
+const (
+	HostUp   = "up"
+	HostDown = "down"
+)
+
+// RestoreHost is a host of a shard, as resolved when planning.
+type RestoreHost struct {
+	ID         string
+	ContractID string
+	// Status is up when the host answered, or is connected to the node, and
+	// down when it could not be reached.
+	Status string
+	// Rate is the estimated download rate from the host, in bytes per
+	// second: the range of its retrieval probes over their 90th percentile
+	// latency, or the rate assumed when it was not probed.
+	Rate   uint64
+	Probed bool
+	// Price is the price of the bandwidth of the host in µBTT per GiB, 0
+	// when the retrievals are paid by the contracts.
+	Price int64  `json:",omitempty"`
+	Error string `json:",omitempty"`
+}
+
+// RestoreShard is a shard of the file and its hosts.
+type RestoreShard struct {
+	Index int
+	Hash  string
+	Size  uint64
+	Hosts []*RestoreHost `json:",omitempty"`
+	// Source is the host the shard is downloaded from, empty when the shard
+	// is not needed or has no host up.
+	Source string `json:",omitempty"`
+}
+
+// RestorePlan is how the file would be downloaded from its hosts now, for
+// the runbooks to check the file can be restored in time and budget.
+type RestorePlan struct {
+	Version  int
+	FileHash string
+	Renter   string
+	Time     time.Time
+	FileSize uint64
+	// The file is restored from any DataShards of its shards.
+	DataShards   int
+	ParityShards int
+	// Available is the number of shards with a host up.
+	Available  int
+	Restorable bool
+	// Bytes, Cost in µBTT and Duration are the estimates of the download
+	// of the shards planned.
+	Bytes    uint64
+	Cost     int64
+	Duration time.Duration
+	Shards   []*RestoreShard
+	Warnings []string `json:",omitempty"`
+}
+
+// source returns the host to download the shard from, the fastest up,
+// then the cheapest, nil when none is up.
+func (s *RestoreShard) source() *RestoreHost {
+	var best *RestoreHost
+	for _, h := range s.Hosts {
+		if h.Status != HostUp {
+			continue
+		}
+		if best == nil || h.Rate > best.Rate || h.Rate == best.Rate && h.Price < best.Price {
+			best = h
+		}
+	}
+	return best
+}
+
+// Schedule picks the shards to download and their hosts, and estimates
+// the download with the hosts sending in parallel, bounded by the downlink
+// of the node in bytes per second, unbounded when 0.
+func (p *RestorePlan) Schedule(downlink uint64) {
+	type candidate struct {
+		s *RestoreShard
+		h *RestoreHost
+	}
+	var cs []candidate
+	p.Warnings = nil
+	for _, s := range p.Shards {
+		s.Source = ""
+		h := s.source()
+		if h == nil {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("shard %d %s has no host up", s.Index, s.Hash))
+			continue
+		}
+		cs = append(cs, candidate{s, h})
+	}
+	p.Available = len(cs)
+	p.Restorable = p.Available >= p.DataShards
+	p.Bytes, p.Cost, p.Duration = 0, 0, 0
+	if !p.Restorable {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("only %d shards available, %d needed",
+			p.Available, p.DataShards))
+		return
+	}
+	if p.Available == p.DataShards && p.ParityShards > 0 {
+		p.Warnings = append(p.Warnings, "no shard to spare, the file is lost with one more host")
+	}
+	// the data shards first, sparing the decoding, then the fastest
+	sort.SliceStable(cs, func(i, j int) bool {
+		di, dj := cs[i].s.Index < p.DataShards, cs[j].s.Index < p.DataShards
+		if di != dj {
+			return di
+		}
+		return cs[i].h.Rate > cs[j].h.Rate
+	})
+	// a host sends its shards one after the other
+	sent := make(map[string]uint64)
+	rates := make(map[string]uint64)
+	for _, c := range cs[:p.DataShards] {
+		c.s.Source = c.h.ID
+		p.Bytes += c.s.Size
+		p.Cost += bandwidth.Cost(c.s.Size, c.h.Price)
+		sent[c.h.ID] += c.s.Size
+		rates[c.h.ID] = c.h.Rate
+	}
+	for id, n := range sent {
+		if d := transferTime(n, rates[id]); d > p.Duration {
+			p.Duration = d
+		}
+	}
+	if d := transferTime(p.Bytes, downlink); downlink > 0 && d > p.Duration {
+		p.Duration = d
+	}
+}
+
+// transferTime is the time to transfer n bytes at rate bytes per second.
+func transferTime(n, rate uint64) time.Duration {
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / float64(rate) * float64(time.Second)).Round(time.Second)
+}
@@ -0,0 +1,54 @@
+package files
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleRestore(t *testing.T) {
+	const mb = 1000 * 1000
+	host := func(id, status string, rate uint64, price int64) *RestoreHost {
+		return &RestoreHost{ID: id, Status: status, Rate: rate, Price: price}
+	}
+	p := &RestorePlan{
+		DataShards:   2,
+		ParityShards: 2,
+		Shards: []*RestoreShard{
+			{Index: 0, Hash: "Qm0", Size: 100 * mb, Hosts: []*RestoreHost{host("h0", HostUp, 10*mb, 0)}},
+			{Index: 1, Hash: "Qm1", Size: 100 * mb, Hosts: []*RestoreHost{host("h1", HostDown, 50*mb, 0)}},
+			{Index: 2, Hash: "Qm2", Size: 100 * mb, Hosts: []*RestoreHost{
+				host("h2", HostUp, 5*mb, 0),
+				host("h3", HostUp, 20*mb, 1<<30),
+			}},
+			{Index: 3, Hash: "Qm3", Size: 100 * mb, Hosts: []*RestoreHost{host("h0", HostUp, 10*mb, 0)}},
+		},
+	}
+	p.Schedule(0)
+	if !p.Restorable || p.Available != 3 {
+		t.Fatalf("expected restorable from 3 shards, got %v %d", p.Restorable, p.Available)
+	}
+	// the data shard 0, then the fastest of the parity shards
+	if p.Shards[0].Source != "h0" || p.Shards[2].Source != "h3" || p.Shards[1].Source != "" || p.Shards[3].Source != "" {
+		t.Fatalf("unexpected sources %s %s %s %s", p.Shards[0].Source, p.Shards[1].Source,
+			p.Shards[2].Source, p.Shards[3].Source)
+	}
+	if p.Bytes != 200*mb || p.Cost != 100*mb || p.Duration != 10*time.Second {
+		t.Fatalf("unexpected estimates %d bytes %d µBTT %s", p.Bytes, p.Cost, p.Duration)
+	}
+	if len(p.Warnings) != 1 {
+		t.Fatalf("expected the shard without host up warned, got %v", p.Warnings)
+	}
+
+	p.Schedule(mb)
+	if p.Duration != 200*time.Second {
+		t.Fatalf("expected the duration bounded by the downlink, got %s", p.Duration)
+	}
+
+	p.Shards[2].Hosts[1].Status = HostDown
+	p.Shards[2].Hosts[0].Status = HostDown
+	p.Shards[0].Hosts[0].Status = HostDown
+	p.Schedule(0)
+	if p.Restorable || p.Bytes != 0 {
+		t.Fatalf("expected not restorable from 1 shard, got %+v", p)
+	}
+}
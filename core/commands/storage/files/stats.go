@@ -0,0 +1,74 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/access"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+
+	humanize "github.com/dustin/go-humanize"
+	cidlib "github.com/ipfs/go-cid"
+)
+
+var storageFilesStatsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the retrievals of the stored and owned files.",
+		ShortDescription: `
+Shows how often the files stored by this host, or owned by this renter, were
+retrieved from this node, with the bytes served and the number of distinct
+requesters, the most bytes served first. Only the files with a contract and
+whose blocks are in the local blockstore are counted, a host counts the
+retrievals of its shards of the file.
+
+A retrieval is a send of the root block of the file, or of the shard, over
+bitswap. The counts are recorded once a minute and go back to the first
+daemon run tracking them.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", false, false, "Hash of the file. All the files served when not given."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		file := ""
+		if len(req.Arguments) > 0 {
+			c, err := cidlib.Parse(req.Arguments[0])
+			if err != nil {
+				return err
+			}
+			file = c.String()
+		}
+		if err := access.Default.Load(n.Repo.Datastore(), n.Identity.Pretty()); err != nil {
+			return err
+		}
+		ss := access.Default.List(file)
+		if file != "" && len(ss) == 0 {
+			return fmt.Errorf("no retrievals of file %s", file)
+		}
+		return cmds.EmitOnce(res, &StatsRes{Files: ss})
+	},
+	Type: StatsRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *StatsRes) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "FILE\tROLE\tRETRIEVALS\tSERVED\tREQUESTERS\tLAST SERVED")
+			for _, s := range out.Files {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\t%s\n", s.File, s.Role, s.Retrievals,
+					humanize.Bytes(s.Bytes), s.Requesters, s.LastServed.Format(time.RFC3339))
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// StatsRes are the retrievals of the files served.
+type StatsRes struct {
+	Files []*access.Stats
+}
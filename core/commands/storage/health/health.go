@@ -0,0 +1,120 @@
+package health
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/diskhealth"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// HealthOutput is the health of the disk storing the shards of the host,
+// and the alerts raised so far.
+type HealthOutput struct {
+	Report *diskhealth.Report
+	Alerts []*diskhealth.Alert
+}
+
+var StorageHealthCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Check the health of the disk storing the host shards.",
+		ShortDescription: `
+Predicts the failure of the disk holding the repo from its SMART data, read
+with smartctl where it is installed, and from the trend of the shard read
+errors met answering challenges. The level is ok, warning or failing:
+
+    $ btfs storage health
+    failing
+      /dev/sda Current_Pending_Sector grew from 8 to 24
+      shard read errors are rising: 9.1% of 44 reads in the last 3 days, 0.0% before
+
+A host daemon checks the disk hourly, logs an alert each time the level
+gets worse and lists them here. The disk polled is set with:
+
+    $ btfs config DiskHealth.Device /dev/nvme0n1
+
+The shards are moved before the disk dies, along with the whole repo, when
+a path on another disk is set for the daemon to migrate to once the disk is
+failing, as 'btfs storage path' does:
+
+    $ btfs config DiskHealth.MigrateTo /mnt/spare/.btfs
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := diskhealth.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		root, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		d := n.Repo.Datastore()
+		self := n.Identity.Pretty()
+		report, err := diskhealth.Check(req.Context, d, self, cfg, root, time.Now())
+		if err != nil {
+			return err
+		}
+		alerts, err := diskhealth.Alerts(d, self)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &HealthOutput{Report: report, Alerts: alerts})
+	},
+	Type: HealthOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *HealthOutput) error {
+			r := out.Report
+			fmt.Fprintln(w, r.Level)
+			for _, reason := range r.Reasons {
+				fmt.Fprintf(w, "  %s\n", reason)
+			}
+			if r.Smart != nil {
+				names := make([]string, 0, len(r.Smart.Attributes))
+				for name := range r.Smart.Attributes {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				attrs := make([]string, len(names))
+				for i, name := range names {
+					attrs[i] = fmt.Sprintf("%s=%d", name, r.Smart.Attributes[name])
+				}
+				fmt.Fprintf(w, "SMART %s: passed=%t %s\n", r.Smart.Device, r.Smart.Passed,
+					strings.Join(attrs, " "))
+			} else {
+				fmt.Fprintf(w, "SMART unavailable: %s\n", r.SmartError)
+			}
+			if len(r.Reads) > 0 {
+				tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+				fmt.Fprintln(tw, "DAY\tREADS\tERRORS")
+				for _, d := range r.Reads {
+					fmt.Fprintf(tw, "%s\t%d\t%d\n", d.Day, d.Reads, d.Errors)
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+			}
+			if len(out.Alerts) > 0 {
+				fmt.Fprintln(w, "alerts:")
+			}
+			for _, a := range out.Alerts {
+				fmt.Fprintf(w, "  %s %s: %s\n", a.Time.Format(time.RFC3339), a.Level,
+					strings.Join(a.Reasons, "; "))
+				if a.MigrateTo != "" {
+					fmt.Fprintf(w, "    repo moving to %s\n", a.MigrateTo)
+				}
+			}
+			return nil
+		}),
+	},
+}
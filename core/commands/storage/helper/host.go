@@ -0,0 +1,21 @@
+package helper
+
+import (
+	"fmt"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// HostEnabled returns an error unless the node runs the storage host api.
+func HostEnabled(env cmds.Environment) error {
+	cfg, err := cmdenv.GetConfig(env)
+	if err != nil {
+		return err
+	}
+	if !cfg.Experimental.StorageHostEnabled {
+		return fmt.Errorf("storage host api not enabled")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package hosts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/hostgossip"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const availabilityReportersOptionName = "reporters"
+
+var storageHostsAvailabilityCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the availability of the hosts gossiped by the peers.",
+		ShortDescription: `
+Lists the hosts reached or not by the node and its peers lately, when the
+host gossip is enabled, with their status: up, down or unknown. Uploads skip
+the hosts down until the hub syncs again. Pass peer IDs to only list these
+hosts, and --reporters to list the peers heard from and the trust in them.
+
+The node publishes the hosts it connected to, or failed to, signed with its
+key. The reports of a peer weigh by the trust in it, which grows when they
+agree with what the node observed itself and shrinks when they do not. Enable
+the gossip, which enables pubsub, and trust or ignore peers with e.g.
+
+    $ btfs config --json HostGossip.Enabled true
+    $ btfs config --json HostGossip.Trusted '["<peer-id>"]'
+
+then restart the daemon.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("peer-id", false, true, "Peer ID of the host to list."),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(availabilityReportersOptionName, "r", "List the peers heard from instead of the hosts.").WithDefault(false),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		t := hostgossip.Default()
+		if t == nil {
+			return errors.New("host gossip not running, enable it with HostGossip.Enabled")
+		}
+		out := &AvailabilityRes{}
+		if reporters, _ := req.Options[availabilityReportersOptionName].(bool); reporters {
+			out.Reporters = t.Reporters()
+			return cmds.EmitOnce(res, out)
+		}
+		now := time.Now()
+		if len(req.Arguments) == 0 {
+			out.Hosts = t.Hosts(now)
+		}
+		for _, host := range req.Arguments {
+			out.Hosts = append(out.Hosts, t.Availability(host, now))
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: AvailabilityRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *AvailabilityRes) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			if out.Reporters != nil {
+				fmt.Fprintln(tw, "REPORTER\tTRUST\tAGREED\tDISAGREED\tLAST REPORT")
+				for _, r := range out.Reporters {
+					trust := fmt.Sprintf("%.2f", r.Trust)
+					if r.Fixed {
+						trust = "trusted"
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", r.ID, trust, r.Agreed, r.Disagreed,
+						r.LastReport.Format(time.RFC3339))
+				}
+				return tw.Flush()
+			}
+			fmt.Fprintln(tw, "HOST\tSTATUS\tSCORE\tWEIGHT\tREPORTERS\tOWN\tLAST UP")
+			for _, a := range out.Hosts {
+				own, lastUp := "-", "-"
+				if a.Own != nil {
+					own = hostgossip.StatusDown
+					if a.Own.Up {
+						own = hostgossip.StatusUp
+					}
+				}
+				if !a.LastUp.IsZero() {
+					lastUp = a.LastUp.Format(time.RFC3339)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%.2f\t%.2f\t%d\t%s\t%s\n", a.Host, a.Status, a.Score, a.Weight,
+					a.Reporters, own, lastUp)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// AvailabilityRes lists the availability of the hosts, or the reporters.
+type AvailabilityRes struct {
+	Hosts     []*hostgossip.Availability `json:",omitempty"`
+	Reporters []*hostgossip.Reporter     `json:",omitempty"`
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/TRON-US/go-btfs/core"
 	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/paging"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	"github.com/TRON-US/go-btfs/core/hub"
 
@@ -28,8 +29,11 @@ var StorageHostsCmd = &cmds.Command{
 		ShortDescription: `Allows interaction with information on hosts. Host information is synchronized from btfs-hub and saved in local datastore.`,
 	},
 	Subcommands: map[string]*cmds.Command{
-		"info": storageHostsInfoCmd,
-		"sync": storageHostsSyncCmd,
+		"info":         storageHostsInfoCmd,
+		"sync":         storageHostsSyncCmd,
+		"score":        storageHostsScoreCmd,
+		"explain":      storageHostsExplainCmd,
+		"availability": storageHostsAvailabilityCmd,
 	},
 }
 
@@ -40,11 +44,14 @@ var storageHostsInfoCmd = &cmds.Command{
 This command displays saved information from btfs-hub under multiple modes.
 Each mode ranks hosts based on its criteria and is randomized based on current node location.
 
-Mode options include:` + hub.AllModeHelpText,
+Mode options include:` + hub.AllModeHelpText + `
+
+Use --limit to page through many hosts, passing the returned NextCursor as
+--cursor to get the next page.`,
 	},
-	Options: []cmds.Option{
+	Options: append([]cmds.Option{
 		cmds.StringOption(hostInfoModeOptionName, "m", "Hosts info showing mode. Default: mode set in config option Experimental.HostsSyncMode."),
-	},
+	}, paging.Options(0)...),
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		cfg, err := cmdenv.GetConfig(env)
 		if err != nil {
@@ -64,18 +71,27 @@ Mode options include:` + hub.AllModeHelpText,
 			return err
 		}
 
+		page, err := paging.FromRequest(req)
+		if err != nil {
+			return err
+		}
+
 		nodes, err := helper.GetHostsFromDatastore(req.Context, n, mode, 0)
 		if err != nil {
 			return err
 		}
+		start, end, next := page.Bounds(len(nodes), func(i int) string {
+			return nodes[i].NodeId
+		})
 
-		return cmds.EmitOnce(res, &HostInfoRes{nodes})
+		return cmds.EmitOnce(res, &HostInfoRes{Nodes: nodes[start:end], NextCursor: next})
 	},
 	Type: HostInfoRes{},
 }
 
 type HostInfoRes struct {
-	Nodes []*hubpb.Host
+	Nodes      []*hubpb.Host
+	NextCursor string `json:",omitempty"`
 }
 
 var storageHostsSyncCmd = &cmds.Command{
@@ -0,0 +1,181 @@
+package hosts
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/paging"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/hub"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+)
+
+var storageHostsScoreCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Break down the scores of the hosts.",
+		ShortDescription: `
+Lists the hosts synced from btfs-hub in the order uploads try them, with
+their hub score broken down into its factors: uptime, age, version, upload,
+download and reputation. Each factor contributes its value times its weight
+over the sum of the weights.
+
+The weights are 1 unless overridden in the HostScore config section, e.g.
+
+    $ btfs config --json HostScore.Weights '{"upload": 3, "age": 0}'
+
+With weights set, the hosts are ranked by the weighted mean of their
+factors instead of their hub score. Pass peer IDs to only list these hosts,
+and see 'btfs storage hosts explain' for the factors which drove the
+selection of the hosts of an upload.
+
+Mode options include:` + hub.AllModeHelpText,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("peer-id", false, true, "Peer ID of the host to list."),
+	},
+	Options: append([]cmds.Option{
+		cmds.StringOption(hostInfoModeOptionName, "m", "Hosts mode. Default: mode set in config option Experimental.HostsSyncMode."),
+	}, paging.Options(0)...),
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		mode, ok := req.Options[hostInfoModeOptionName].(string)
+		if !ok {
+			mode = cfg.Experimental.HostsSyncMode
+		}
+		page, err := paging.FromRequest(req)
+		if err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		sc, err := hub.LoadScoreConfig(n.Repo)
+		if err != nil {
+			return err
+		}
+		nodes, err := helper.GetHostsFromDatastore(req.Context, n, mode, 0)
+		if err != nil {
+			return err
+		}
+		hosts := Scores(nodes, sc, req.Arguments)
+		start, end, next := page.Bounds(len(hosts), func(i int) string {
+			return hosts[i].Host
+		})
+		return cmds.EmitOnce(res, &ScoreRes{Overridden: sc.Overridden(), Hosts: hosts[start:end], NextCursor: next})
+	},
+	Type: ScoreRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ScoreRes) error {
+			if out.Overridden {
+				fmt.Fprintln(w, "ranked by the weights of the HostScore config")
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprint(tw, "ID\tHUB\tSCORE")
+			for _, name := range hub.FactorNames() {
+				fmt.Fprintf(tw, "\t%s", strings.ToUpper(name))
+			}
+			fmt.Fprintln(tw)
+			for _, e := range out.Hosts {
+				fmt.Fprintf(tw, "%s\t%.2f\t%.2f", e.Host, e.HubScore, e.Score)
+				for _, f := range e.Factors {
+					fmt.Fprintf(tw, "\t%.2fx%g", f.Value, f.Weight)
+				}
+				fmt.Fprintln(tw)
+			}
+			if out.NextCursor != "" {
+				fmt.Fprintf(tw, "next cursor: %s\n", out.NextCursor)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// ScoreRes is a page of the scores of the hosts.
+type ScoreRes struct {
+	// Overridden is set when the hosts are ranked by the weights of the
+	// config rather than their hub score.
+	Overridden bool
+	Hosts      []*hub.Explanation
+	NextCursor string `json:",omitempty"`
+}
+
+// Scores returns the scores of the hosts of nodes under sc in the order
+// uploads try them, only of the hosts ids when any.
+func Scores(nodes []*hubpb.Host, sc *hub.ScoreConfig, ids []string) []*hub.Explanation {
+	hub.Rank(nodes, sc)
+	only := map[string]bool{}
+	for _, id := range ids {
+		only[id] = true
+	}
+	es := make([]*hub.Explanation, 0, len(nodes))
+	for _, h := range nodes {
+		if len(only) > 0 && !only[h.NodeId] {
+			continue
+		}
+		es = append(es, hub.Explain(h, sc))
+	}
+	return es
+}
+
+var storageHostsExplainCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Explain why the hosts of an upload were chosen.",
+		ShortDescription: `
+Lists the hosts chosen for the shards of the upload session <session-id>,
+including the ones which then failed to sign a contract, and why: ranked by
+score, with their rank and the factors of the score which contributed the
+most, tried as a connected peer once the ranked hosts ran out, or listed by
+the renter with --host-selection. See 'btfs storage hosts score' to tune the
+weights of the factors.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("session-id", true, false, "ID of the upload session."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		ss, err := hub.ListSelections(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &ExplainRes{Selections: ss})
+	},
+	Type: ExplainRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ExplainRes) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "SHARD\tHOST\tREASON\tRANK\tSCORE\tDRIVERS")
+			for _, s := range out.Selections {
+				rank, score := "-", "-"
+				if s.Rank > 0 {
+					rank = fmt.Sprintf("%d/%d", s.Rank, s.Of)
+				}
+				if s.Explanation != nil {
+					score = fmt.Sprintf("%.2f", s.Explanation.Score)
+				}
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", s.ShardIndex, s.Host, s.Reason, rank, score,
+					strings.Join(s.Drivers, ", "))
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// ExplainRes lists the host selections of an upload session.
+type ExplainRes struct {
+	Selections []*hub.Selection
+}
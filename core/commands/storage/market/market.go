@@ -0,0 +1,342 @@
+package market
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/paging"
+	"github.com/TRON-US/go-btfs/core/commands/storage/collateral"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+	"github.com/TRON-US/go-btfs/core/hub"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	sortOptionName     = "sort"
+	minScoreOptionName = "min-score"
+	regionOptionName   = "region"
+	modeOptionName     = "mode"
+
+	collateralizedOptionName = "collateralized"
+)
+
+// Sort orders of the market listing.
+const (
+	SortPrice      = "price"
+	SortScore      = "score"
+	SortLatency    = "latency"
+	SortReputation = "reputation"
+	SortPersonal   = "personal"
+)
+
+// lostStates are the states of the contracts a host failed.
+var lostStates = map[guardpb.Contract_ContractState]bool{
+	guardpb.Contract_LOST:     true,
+	guardpb.Contract_CANCELED: true,
+}
+
+var StorageMarketCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Browse and compare the storage hosts.",
+		ShortDescription: `
+Compares the hosts synced from btfs-hub, see 'btfs storage hosts sync', with
+what this node knows of them.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls": storageMarketLsCmd,
+	},
+}
+
+var storageMarketLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the storage hosts in a comparable table.",
+		ShortDescription: `
+Lists the hosts synced from btfs-hub with their price, score and reputation,
+merged with the latency measured by this node and its personal reputation of
+the host: the share of its renter contracts the host kept, out of those
+synced with 'btfs storage contracts sync', times the share of the retrieval
+probes of its shards that succeeded, see 'btfs storage probe'. The
+collateral of the hosts is the collateral they lock per contract and for the
+contracts of this node, see 'btfs storage collateral'.
+
+    $ btfs storage market ls --sort price --min-score 8 --region eu
+    $ btfs storage market ls --collateralized
+
+Sort by price (ascending), score, reputation or personal (descending), or
+latency (ascending, unmeasured hosts last). --region matches the region or
+the country code of the hosts. Use --output json for external tooling, and
+--limit to page through many hosts, passing the returned NextCursor as
+--cursor to get the next page.
+
+Mode options include:` + hub.AllModeHelpText,
+	},
+	Options: append([]cmds.Option{
+		cmds.StringOption(sortOptionName, "Sort by price, score, latency, reputation or personal.").WithDefault(SortPrice),
+		cmds.FloatOption(minScoreOptionName, "Minimum hub score of the hosts listed."),
+		cmds.StringOption(regionOptionName, "Only list the hosts of this region or country code."),
+		cmds.StringOption(modeOptionName, "m", "Hosts mode. Default: mode set in config option Experimental.HostsSyncMode."),
+		cmds.BoolOption(collateralizedOptionName, "Only list the hosts posting collateral."),
+	}, paging.Options(0)...),
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		mode, ok := req.Options[modeOptionName].(string)
+		if !ok {
+			mode = cfg.Experimental.HostsSyncMode
+		}
+		sortBy, _ := req.Options[sortOptionName].(string)
+		minScore, _ := req.Options[minScoreOptionName].(float64)
+		region, _ := req.Options[regionOptionName].(string)
+		collateralized, _ := req.Options[collateralizedOptionName].(bool)
+		page, err := paging.FromRequest(req)
+		if err != nil {
+			return err
+		}
+
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		nodes, err := helper.GetHostsFromDatastore(req.Context, n, mode, 0)
+		if err != nil {
+			return err
+		}
+		cs, err := contracts.ListContracts(n.Repo.Datastore(), n.Identity.Pretty(), nodepb.ContractStat_RENTER.String())
+		if err != nil {
+			return err
+		}
+		probes, err := probe.Hosts(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		hosts := Merge(nodes, Personals(cs, probes), func(id string) time.Duration {
+			pid, err := peer.IDB58Decode(id)
+			if err != nil {
+				return 0
+			}
+			return n.Peerstore.LatencyEWMA(pid)
+		})
+		hosts, err = Compare(hosts, sortBy, minScore, region)
+		if err != nil {
+			return err
+		}
+		collaterals, err := collateral.Hosts(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		hosts = WithCollateral(hosts, collaterals, collateralized)
+		start, end, next := page.Bounds(len(hosts), func(i int) string {
+			return hosts[i].ID
+		})
+		return cmds.EmitOnce(res, &MarketOutput{Hosts: hosts[start:end], NextCursor: next})
+	},
+	Type: MarketOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *MarketOutput) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tREGION\tPRICE\tSCORE\tREPUTATION\tLATENCY\tPERSONAL\tCOLLATERAL\tLEFT")
+			for _, h := range out.Hosts {
+				latency := "-"
+				if h.Latency > 0 {
+					latency = h.Latency.Round(time.Millisecond).String()
+				}
+				personal := "-"
+				if h.Personal != nil {
+					personal = fmt.Sprintf("%.0f%% of %d", h.Personal.Reputation*100, h.Personal.Contracts)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%.2f\t%s\t%s\t%s\t%s\n", h.ID, h.location(), h.Price,
+					h.Score, h.Reputation, latency, personal, h.collateral(), humanize.Bytes(uint64(h.StorageLeft)))
+			}
+			if out.NextCursor != "" {
+				fmt.Fprintf(tw, "next cursor: %s\n", out.NextCursor)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// MarketOutput is a page of the hosts of the market.
+type MarketOutput struct {
+	Hosts      []*MarketHost
+	NextCursor string `json:",omitempty"`
+}
+
+// MarketHost is a host as listed by the hub, merged with what this node
+// knows of it.
+type MarketHost struct {
+	ID          string
+	Region      string
+	Country     string
+	Price       uint64
+	Score       float32
+	Reputation  float32
+	StorageLeft float32
+	// Latency is the latency measured by this node, zero when unknown.
+	Latency time.Duration
+	// Personal is the reputation of the host with this node, nil when no
+	// renter contract of this node was hosted by it.
+	Personal *Personal `json:",omitempty"`
+	// Collateral is the collateral of the host, nil when it posts none
+	// known to this node.
+	Collateral *collateral.HostCollateral `json:",omitempty"`
+}
+
+func (h *MarketHost) location() string {
+	if h.Country == "" {
+		return h.Region
+	}
+	if h.Region == "" {
+		return h.Country
+	}
+	return h.Region + "/" + h.Country
+}
+
+func (h *MarketHost) collateral() string {
+	c := h.Collateral
+	switch {
+	case c == nil:
+		return "-"
+	case c.Contracts == 0:
+		return fmt.Sprintf("%d/contract", c.Amount)
+	default:
+		return fmt.Sprintf("%d/contract, %d locked", c.Amount, c.Locked)
+	}
+}
+
+// Personal is the reputation of a host from the renter contracts of this
+// node it hosted and their retrieval probes.
+type Personal struct {
+	Contracts int
+	Lost      int
+	// Probes counts the retrieval probes of the host, ProbeSuccessRate is
+	// the share that succeeded.
+	Probes           int     `json:",omitempty"`
+	ProbeSuccessRate float64 `json:",omitempty"`
+	// Reputation is the share of the contracts the host did not lose, times
+	// the probe success rate when probed.
+	Reputation float64
+}
+
+// Personals returns the personal reputation of the hosts of the renter
+// contracts cs, probed as in probes, by host ID.
+func Personals(cs []*nodepb.Contracts_Contract, probes map[string]*probe.Host) map[string]*Personal {
+	ps := make(map[string]*Personal)
+	for _, c := range cs {
+		p, ok := ps[c.HostId]
+		if !ok {
+			p = &Personal{}
+			ps[c.HostId] = p
+		}
+		p.Contracts++
+		if lostStates[c.Status] {
+			p.Lost++
+		}
+	}
+	for id, p := range ps {
+		p.Reputation = float64(p.Contracts-p.Lost) / float64(p.Contracts)
+		if h, ok := probes[id]; ok && len(h.Samples) > 0 {
+			s := h.Stats()
+			p.Probes, p.ProbeSuccessRate = s.Probes, s.SuccessRate
+			p.Reputation *= s.SuccessRate
+		}
+	}
+	return ps
+}
+
+// Merge returns the hosts of the hub nodes with their personal reputation
+// and the latency measured by latency.
+func Merge(nodes []*hubpb.Host, personals map[string]*Personal, latency func(id string) time.Duration) []*MarketHost {
+	hosts := make([]*MarketHost, 0, len(nodes))
+	for _, h := range nodes {
+		hosts = append(hosts, &MarketHost{
+			ID:          h.NodeId,
+			Region:      h.Region,
+			Country:     h.CountryShort,
+			Price:       h.StoragePriceAsk,
+			Score:       h.Score,
+			Reputation:  h.Reputation,
+			StorageLeft: h.StorageVolumeLeft,
+			Latency:     latency(h.NodeId),
+			Personal:    personals[h.NodeId],
+		})
+	}
+	return hosts
+}
+
+// Compare filters the hosts with a score of at least minScore in region,
+// any region when empty, and sorts them by sortBy.
+func Compare(hosts []*MarketHost, sortBy string, minScore float64, region string) ([]*MarketHost, error) {
+	var less func(a, b *MarketHost) bool
+	switch sortBy {
+	case SortPrice:
+		less = func(a, b *MarketHost) bool { return a.Price < b.Price }
+	case SortScore:
+		less = func(a, b *MarketHost) bool { return a.Score > b.Score }
+	case SortReputation:
+		less = func(a, b *MarketHost) bool { return a.Reputation > b.Reputation }
+	case SortLatency:
+		less = func(a, b *MarketHost) bool {
+			if a.Latency == 0 || b.Latency == 0 {
+				return b.Latency == 0 && a.Latency != 0
+			}
+			return a.Latency < b.Latency
+		}
+	case SortPersonal:
+		less = func(a, b *MarketHost) bool {
+			if a.Personal == nil || b.Personal == nil {
+				return b.Personal == nil && a.Personal != nil
+			}
+			return a.Personal.Reputation > b.Personal.Reputation
+		}
+	default:
+		return nil, fmt.Errorf("invalid sort %q, must be price, score, latency, reputation or personal", sortBy)
+	}
+	out := make([]*MarketHost, 0, len(hosts))
+	for _, h := range hosts {
+		if float64(h.Score) < minScore {
+			continue
+		}
+		if region != "" && !strings.EqualFold(h.Region, region) && !strings.EqualFold(h.Country, region) {
+			continue
+		}
+		out = append(out, h)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return less(out[i], out[j]) })
+	return out, nil
+}
+
+// WithCollateral sets the collateral of the hosts from collaterals, by
+// host ID, and filters out the hosts posting none when collateralized.
+func WithCollateral(hosts []*MarketHost, collaterals map[string]*collateral.HostCollateral,
+	collateralized bool) []*MarketHost {
+	out := make([]*MarketHost, 0, len(hosts))
+	for _, h := range hosts {
+		if c := collaterals[h.ID]; c.Collateralized() {
+			h.Collateral = c
+		}
+		if collateralized && h.Collateral == nil {
+			continue
+		}
+		out = append(out, h)
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package market
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/collateral"
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+func ids(hosts []*MarketHost) []string {
+	var out []string
+	for _, h := range hosts {
+		out = append(out, h.ID)
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	nodes := []*hubpb.Host{
+		{NodeId: "a", Region: "EU", CountryShort: "DE", Score: 9, Reputation: 0.5, StoragePriceAsk: 300},
+		{NodeId: "b", Region: "NA", CountryShort: "US", Score: 8.5, Reputation: 0.9, StoragePriceAsk: 100},
+		{NodeId: "c", Region: "EU", CountryShort: "FR", Score: 7, Reputation: 0.7, StoragePriceAsk: 50},
+		{NodeId: "d", Region: "EU", CountryShort: "NL", Score: 8, Reputation: 0.8, StoragePriceAsk: 200},
+	}
+	personals := Personals([]*nodepb.Contracts_Contract{
+		{HostId: "a", Status: guardpb.Contract_UPLOADED},
+		{HostId: "a", Status: guardpb.Contract_LOST},
+		{HostId: "d", Status: guardpb.Contract_CLOSED},
+		{HostId: "b", Status: guardpb.Contract_CLOSED},
+	}, map[string]*probe.Host{
+		"b": {ID: "b", Samples: []probe.Sample{{Latency: time.Second}, {Error: "timeout"}}},
+	})
+	if p := personals["a"]; p.Contracts != 2 || p.Lost != 1 || p.Reputation != 0.5 {
+		t.Fatalf("unexpected personal reputation %+v", p)
+	}
+	if p := personals["b"]; p.Probes != 2 || p.Reputation != 0.5 {
+		t.Fatalf("expected the probes to weigh in, got %+v", p)
+	}
+	latencies := map[string]time.Duration{"a": 80 * time.Millisecond, "b": 20 * time.Millisecond}
+	hosts := Merge(nodes, personals, func(id string) time.Duration { return latencies[id] })
+
+	for _, tc := range []struct {
+		sort     string
+		minScore float64
+		region   string
+		want     []string
+	}{
+		{SortPrice, 0, "", []string{"c", "b", "d", "a"}},
+		{SortPrice, 8, "eu", []string{"d", "a"}},
+		{SortScore, 0, "", []string{"a", "b", "d", "c"}},
+		{SortReputation, 0, "", []string{"b", "d", "c", "a"}},
+		{SortLatency, 0, "", []string{"b", "a", "c", "d"}},
+		{SortPersonal, 0, "", []string{"d", "a", "b", "c"}},
+		{SortPrice, 0, "fr", []string{"c"}},
+	} {
+		out, err := Compare(hosts, tc.sort, tc.minScore, tc.region)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(out); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("sort %s min score %v region %q: got %v, want %v", tc.sort, tc.minScore, tc.region, got, tc.want)
+		}
+	}
+	if _, err := Compare(hosts, "age", 0, ""); err == nil {
+		t.Fatal("expected an invalid sort to fail")
+	}
+}
+
+func TestWithCollateral(t *testing.T) {
+	hosts := []*MarketHost{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	collaterals := map[string]*collateral.HostCollateral{
+		"a": {Amount: 100},
+		"b": {},
+		"c": {Locked: 100, Contracts: 1},
+	}
+	out := WithCollateral(hosts, collaterals, true)
+	if got := ids(out); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Fatalf("collateralized hosts %v", got)
+	}
+	if got := WithCollateral(hosts, collaterals, false); len(got) != 3 || got[1].Collateral != nil {
+		t.Fatalf("unexpected hosts %v", ids(got))
+	}
+	if got := out[1].collateral(); got != "0/contract, 100 locked" {
+		t.Fatalf("unexpected collateral %q", got)
+	}
+}
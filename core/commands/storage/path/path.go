@@ -1,6 +1,7 @@
 package path
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -108,64 +109,70 @@ storage location, a specified path as a parameter need to be passed.
 		cmds.StringArg("storage-size", true, false, "Storage Commitment Size"),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
-		locked := lock.TryLock()
-		if locked {
-			defer lock.Unlock()
-		} else {
-			return errors.New("Cannot set path concurrently.")
-		}
-		StorePath = strings.Trim(req.Arguments[0], " ")
-
-		if StorePath == "" {
-			return fmt.Errorf("path is not defined")
-		}
-		var err error
-		if StorePath, err = homedir.Expand(StorePath); err != nil {
+		promisedStorageSize, err := humanize.ParseBytes(req.Arguments[1])
+		if err != nil {
 			return err
 		}
-		if !filepath.IsAbs(StorePath) {
-			StorePath, err = filepath.Abs(StorePath)
-			if err != nil {
-				return err
-			}
-		}
-		if btfsPath != "" {
-			if btfsPath != StorePath {
-				OriginPath = btfsPath
-			} else {
-				return fmt.Errorf("specifed path is same with current path")
-			}
-		} else if envBtfsPath := os.Getenv(key); envBtfsPath != "" {
-			OriginPath = envBtfsPath
-		} else if home, err := homedir.Expand(defaultPath); err == nil && home != "" {
-			OriginPath = home
-		} else {
-			return fmt.Errorf("can not find the original stored path")
-		}
+		return Relocate(req.Context, req.Arguments[0], promisedStorageSize)
+	},
+}
 
-		if err := validatePath(OriginPath, StorePath); err != nil {
-			return err
-		}
+// Relocate moves the BTFS path to storePath, whose disk must have required
+// bytes free, and restarts the daemon on it.
+func Relocate(ctx context.Context, storePath string, required uint64) error {
+	locked := lock.TryLock()
+	if locked {
+		defer lock.Unlock()
+	} else {
+		return errors.New("Cannot set path concurrently.")
+	}
+	StorePath = strings.Trim(storePath, " ")
 
-		usage, err := disk.UsageWithContext(req.Context, filepath.Dir(StorePath))
-		if err != nil {
-			return err
-		}
-		promisedStorageSize, err := humanize.ParseBytes(req.Arguments[1])
+	if StorePath == "" {
+		return fmt.Errorf("path is not defined")
+	}
+	var err error
+	if StorePath, err = homedir.Expand(StorePath); err != nil {
+		return err
+	}
+	if !filepath.IsAbs(StorePath) {
+		StorePath, err = filepath.Abs(StorePath)
 		if err != nil {
 			return err
 		}
-		if usage.Free < promisedStorageSize {
-			return fmt.Errorf("Not enough disk space, expect: ge %v bytes, actual: %v bytes",
-				promisedStorageSize, usage.Free)
+	}
+	if btfsPath != "" {
+		if btfsPath != StorePath {
+			OriginPath = btfsPath
+		} else {
+			return fmt.Errorf("specifed path is same with current path")
 		}
+	} else if envBtfsPath := os.Getenv(key); envBtfsPath != "" {
+		OriginPath = envBtfsPath
+	} else if home, err := homedir.Expand(defaultPath); err == nil && home != "" {
+		OriginPath = home
+	} else {
+		return fmt.Errorf("can not find the original stored path")
+	}
 
-		restartCmd := exec.Command(Excutable, "restart", "-p")
-		if err := restartCmd.Run(); err != nil {
-			return fmt.Errorf("restart command: %s", err)
-		}
-		return nil
-	},
+	if err := validatePath(OriginPath, StorePath); err != nil {
+		return err
+	}
+
+	usage, err := disk.UsageWithContext(ctx, filepath.Dir(StorePath))
+	if err != nil {
+		return err
+	}
+	if usage.Free < required {
+		return fmt.Errorf("Not enough disk space, expect: ge %v bytes, actual: %v bytes",
+			required, usage.Free)
+	}
+
+	restartCmd := exec.Command(Excutable, "restart", "-p")
+	if err := restartCmd.Run(); err != nil {
+		return fmt.Errorf("restart command: %s", err)
+	}
+	return nil
 }
 
 var PathStatusCmd = &cmds.Command{
@@ -0,0 +1,186 @@
+package payer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+	"github.com/tron-us/protobuf/proto"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+const grantKeyPrefix = "/btfs/%s/payer/grants/"
+
+// Grant is a delegation this payer node gave, with what its delegate spent.
+type Grant struct {
+	*uh.Delegation
+	Spent   int64
+	Revoked bool `json:",omitempty"`
+}
+
+// Check returns why g does not allow its delegate to have contracts signed
+// at now, nil when it does.
+func (g *Grant) Check(now time.Time) error {
+	if g.Revoked {
+		return errors.New("delegation revoked")
+	}
+	if now.After(g.Expires) {
+		return fmt.Errorf("delegation expired on %s", g.Expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Charge adds amount to the spending of g, within its MaxSpend.
+func (g *Grant) Charge(amount int64) error {
+	if amount < 0 {
+		return fmt.Errorf("invalid amount %d", amount)
+	}
+	if g.Spent+amount > g.MaxSpend {
+		return fmt.Errorf("amount %d exceeds the %d µBTT left to spend", amount, g.MaxSpend-g.Spent)
+	}
+	g.Spent += amount
+	return nil
+}
+
+func grantKey(peerID, delegate string) ds.Key {
+	return ds.NewKey(fmt.Sprintf(grantKeyPrefix, peerID) + delegate)
+}
+
+// grantsMu serializes the updates of the grants, for charges not to race.
+var grantsMu sync.Mutex
+
+// GetGrant returns the grant of the payer peerID to delegate, nil when
+// there is none.
+func GetGrant(d ds.Datastore, peerID, delegate string) (*Grant, error) {
+	b, err := d.Get(grantKey(peerID, delegate))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	g := &Grant{}
+	if err := json.Unmarshal(b, g); err != nil {
+		return nil, fmt.Errorf("invalid grant to %s: %s", delegate, err)
+	}
+	return g, nil
+}
+
+// PutGrant records the grant g of the payer peerID.
+func PutGrant(d ds.Datastore, peerID string, g *Grant) error {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	return d.Put(grantKey(peerID, g.Delegate), b)
+}
+
+// UpdateGrant applies f to the grant of the payer peerID to delegate and
+// records it, unless f fails.
+func UpdateGrant(d ds.Datastore, peerID, delegate string, f func(g *Grant) error) error {
+	grantsMu.Lock()
+	defer grantsMu.Unlock()
+	g, err := GetGrant(d, peerID, delegate)
+	if err != nil {
+		return err
+	}
+	if g == nil {
+		return fmt.Errorf("no delegation to %s", delegate)
+	}
+	if err := f(g); err != nil {
+		return err
+	}
+	return PutGrant(d, peerID, g)
+}
+
+// ListGrants returns the grants of the payer peerID, sorted by delegate.
+func ListGrants(d ds.Datastore, peerID string) ([]*Grant, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(grantKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var gs []*Grant
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		g := &Grant{}
+		if err := json.Unmarshal(r.Value, g); err != nil {
+			return nil, fmt.Errorf("invalid grant %s: %s", r.Key, err)
+		}
+		gs = append(gs, g)
+	}
+	sort.Slice(gs, func(i, j int) bool {
+		return gs[i].Delegate < gs[j].Delegate
+	})
+	return gs, nil
+}
+
+// Authorize checks that raw, a message of the given kind a delegate asks
+// the payer to sign, has the payer as buyer or renter: the payer of peer ID
+// self and public key pubKey. It returns the amount the payer commits to
+// pay by signing it.
+func Authorize(kind string, raw []byte, self string, pubKey []byte) (int64, error) {
+	var (
+		msg   proto.Message
+		check func() bool
+		spend func() int64
+	)
+	switch kind {
+	case uh.SignKindBalance:
+		m := &ledgerpb.PublicKey{}
+		msg, check = m, func() bool { return bytes.Equal(m.Key, pubKey) }
+	case uh.SignKindChannelCommit:
+		m := &ledgerpb.ChannelCommit{}
+		msg, check = m, func() bool { return m.Payer != nil && bytes.Equal(m.Payer.Key, pubKey) }
+		spend = func() int64 { return m.Amount }
+	case uh.SignKindEscrowContract:
+		m := &escrowpb.EscrowContract{}
+		msg, check = m, func() bool { return bytes.Equal(m.BuyerAddress, pubKey) }
+	case uh.SignKindChannelState:
+		m := &ledgerpb.ChannelState{}
+		msg, check = m, func() bool {
+			return m.From != nil && m.From.Address != nil && bytes.Equal(m.From.Address.Key, pubKey)
+		}
+	case uh.SignKindPayin:
+		m := &escrowpb.PayinRequest{}
+		msg, check = m, func() bool { return bytes.Equal(m.BuyerAddress, pubKey) }
+	case uh.SignKindGuardContract:
+		m := &guardpb.ContractMeta{}
+		msg, check = m, func() bool { return m.RenterPid == self }
+	case uh.SignKindFileMeta:
+		m := &guardpb.FileStoreMeta{}
+		msg, check = m, func() bool { return m.RenterPid == self }
+	case uh.SignKindQuestions:
+		m := &guardpb.ShardChallengeQuestions{}
+		msg, check = m, func() bool { return m.PreparerPid == self }
+	case uh.SignKindWaitUpload:
+		m := &guardpb.CheckFileStoreMetaRequest{}
+		msg, check = m, func() bool { return m.RenterPid == self }
+	default:
+		return 0, fmt.Errorf("invalid kind %q", kind)
+	}
+	if err := proto.Unmarshal(raw, msg); err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", kind, err)
+	}
+	if !check() {
+		return 0, fmt.Errorf("%s is not on behalf of this payer", kind)
+	}
+	if spend == nil {
+		return 0, nil
+	}
+	return spend(), nil
+}
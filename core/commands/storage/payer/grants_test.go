@@ -0,0 +1,138 @@
+package payer
+
+import (
+	"testing"
+	"time"
+
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+	"github.com/tron-us/protobuf/proto"
+
+	ds "github.com/ipfs/go-datastore"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func newKey(t *testing.T) (ic.PrivKey, peer.ID) {
+	priv, _, err := ic.GenerateKeyPair(ic.Secp256k1, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, id
+}
+
+func TestDelegation(t *testing.T) {
+	payerKey, payerID := newKey(t)
+	_, delegateID := newKey(t)
+	now := time.Now()
+	dl := &uh.Delegation{
+		Payer:    payerID.Pretty(),
+		Delegate: delegateID.Pretty(),
+		MaxSpend: 100,
+		Expires:  now.Add(time.Hour).UTC(),
+	}
+	if err := dl.Sign(payerKey); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Encode(dl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Verify(now); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Verify(now.Add(2 * time.Hour)); err == nil {
+		t.Fatal("expired delegation verified")
+	}
+	decoded.MaxSpend = 1000
+	if err := decoded.Verify(now); err == nil {
+		t.Fatal("tampered delegation verified")
+	}
+}
+
+func TestCharge(t *testing.T) {
+	d := ds.NewMapDatastore()
+	g := &Grant{Delegation: &uh.Delegation{Payer: "payer", Delegate: "delegate", MaxSpend: 100,
+		Expires: time.Now().Add(time.Hour)}}
+	if err := PutGrant(d, "payer", g); err != nil {
+		t.Fatal(err)
+	}
+	charge := func(amount int64) error {
+		return UpdateGrant(d, "payer", "delegate", func(g *Grant) error {
+			if err := g.Check(time.Now()); err != nil {
+				return err
+			}
+			return g.Charge(amount)
+		})
+	}
+	if err := charge(60); err != nil {
+		t.Fatal(err)
+	}
+	if err := charge(60); err == nil {
+		t.Fatal("charged over the max spend")
+	}
+	if err := charge(40); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateGrant(d, "payer", "delegate", func(g *Grant) error {
+		g.Revoked = true
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := charge(0); err == nil {
+		t.Fatal("charged a revoked grant")
+	}
+	if err := UpdateGrant(d, "payer", "other", func(g *Grant) error { return nil }); err == nil {
+		t.Fatal("updated a missing grant")
+	}
+	gs, err := ListGrants(d, "payer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gs) != 1 || gs[0].Spent != 100 || !gs[0].Revoked {
+		t.Fatalf("got grants %+v", gs)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	self := "payer"
+	pubKey := []byte("payer key")
+	marshal := func(m proto.Message) []byte {
+		b, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := []struct {
+		kind   string
+		msg    proto.Message
+		amount int64
+		ok     bool
+	}{
+		{uh.SignKindBalance, &ledgerpb.PublicKey{Key: pubKey}, 0, true},
+		{uh.SignKindBalance, &ledgerpb.PublicKey{Key: []byte("other key")}, 0, false},
+		{uh.SignKindChannelCommit, &ledgerpb.ChannelCommit{Payer: &ledgerpb.PublicKey{Key: pubKey}, Amount: 42}, 42, true},
+		{uh.SignKindChannelCommit, &ledgerpb.ChannelCommit{Amount: 42}, 0, false},
+		{uh.SignKindFileMeta, &guardpb.FileStoreMeta{RenterPid: self}, 0, true},
+		{uh.SignKindFileMeta, &guardpb.FileStoreMeta{RenterPid: "delegate"}, 0, false},
+		{"other", &guardpb.FileStoreMeta{RenterPid: self}, 0, false},
+	}
+	for _, tt := range tests {
+		amount, err := Authorize(tt.kind, marshal(tt.msg), self, pubKey)
+		if (err == nil) != tt.ok || amount != tt.amount {
+			t.Errorf("Authorize(%s, %v) = %d, %v", tt.kind, tt.msg, amount, err)
+		}
+	}
+}
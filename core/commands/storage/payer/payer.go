@@ -0,0 +1,362 @@
+package payer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	maxSpendOptionName = "max-spend"
+	expiresOptionName  = "expires"
+)
+
+var StoragePayerCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Pay storage contracts from the wallet of another node.",
+		ShortDescription: `
+Lets uploader nodes have their storage contracts paid by the wallet of a payer
+node, e.g. a company treasury, instead of their own. The payer delegates to an
+uploader node:
+
+    (payer)    $ btfs storage payer delegate <uploader-peer-id> --max-spend 100000000
+    (uploader) $ btfs storage payer use <delegation>
+
+The uploader then has the payer sign the balance checks, payments and
+contracts of its uploads, which escrow and guard see as made by the payer:
+the payer is the renter of the contracts and its wallet pays for them. The
+payer only signs for the nodes it delegated to, within the amount delegated.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"delegate": storagePayerDelegateCmd,
+		"revoke":   storagePayerRevokeCmd,
+		"ls":       storagePayerLsCmd,
+		"use":      storagePayerUseCmd,
+		"clear":    storagePayerClearCmd,
+		"status":   storagePayerStatusCmd,
+		"sign":     StoragePayerSignCmd,
+	},
+}
+
+// DelegateOutput is a delegation encoded to be passed to its delegate.
+type DelegateOutput struct {
+	Delegation string
+}
+
+// Encode encodes d for 'btfs storage payer use'.
+func Encode(d *uh.Delegation) (string, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Decode decodes a delegation encoded by Encode.
+func Decode(s string) (*uh.Delegation, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delegation: %s", err)
+	}
+	d := &uh.Delegation{}
+	if err := json.Unmarshal(b, d); err != nil {
+		return nil, fmt.Errorf("invalid delegation: %s", err)
+	}
+	return d, nil
+}
+
+var storagePayerDelegateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Delegate the payment of storage contracts to this node (payer).",
+		ShortDescription: `
+Lets the node delegate-peer-id have its storage contracts paid by the wallet
+of this node, up to --max-spend µBTT until --expires. Outputs the delegation
+to pass to 'btfs storage payer use' on the delegate node. Delegating again to
+the same node replaces the delegation, keeping what it spent.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("delegate-peer-id", true, false, "Peer ID of the uploader node."),
+	},
+	Options: []cmds.Option{
+		cmds.Int64Option(maxSpendOptionName, "Most µBTT the delegate can spend."),
+		cmds.StringOption(expiresOptionName, "Validity of the delegation, e.g. 720h.").WithDefault("720h"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		delegate, err := peer.IDB58Decode(req.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("invalid delegate peer ID: %s", err)
+		}
+		if delegate == n.Identity {
+			return errors.New("cannot delegate to this node")
+		}
+		maxSpend, ok := req.Options[maxSpendOptionName].(int64)
+		if !ok || maxSpend <= 0 {
+			return fmt.Errorf("--%s must be positive", maxSpendOptionName)
+		}
+		expires, _ := req.Options[expiresOptionName].(string)
+		validity, err := time.ParseDuration(expires)
+		if err != nil || validity <= 0 {
+			return fmt.Errorf("invalid --%s %q", expiresOptionName, expires)
+		}
+		dl := &uh.Delegation{
+			Payer:    n.Identity.Pretty(),
+			Delegate: delegate.Pretty(),
+			MaxSpend: maxSpend,
+			Expires:  time.Now().Add(validity).UTC(),
+		}
+		if err := dl.Sign(n.PrivateKey); err != nil {
+			return err
+		}
+		d := n.Repo.Datastore()
+		g := &Grant{Delegation: dl}
+		grantsMu.Lock()
+		defer grantsMu.Unlock()
+		prev, err := GetGrant(d, n.Identity.Pretty(), dl.Delegate)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			g.Spent = prev.Spent
+		}
+		if err := PutGrant(d, n.Identity.Pretty(), g); err != nil {
+			return err
+		}
+		s, err := Encode(dl)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &DelegateOutput{Delegation: s})
+	},
+	Type: DelegateOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *DelegateOutput) error {
+			_, err := fmt.Fprintln(w, out.Delegation)
+			return err
+		}),
+	},
+}
+
+var storagePayerRevokeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Revoke a delegation of this node (payer).",
+		ShortDescription: `
+Stops signing for the node delegate-peer-id. The contracts already signed
+stay paid by this node.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("delegate-peer-id", true, false, "Peer ID of the uploader node."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		return UpdateGrant(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0], func(g *Grant) error {
+			g.Revoked = true
+			return nil
+		})
+	},
+}
+
+// LsOutput are the delegations of this node.
+type LsOutput struct {
+	Grants []*Grant
+}
+
+var storagePayerLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the delegations of this node (payer).",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		gs, err := ListGrants(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &LsOutput{Grants: gs})
+	},
+	Type: LsOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *LsOutput) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "DELEGATE\tSPENT\tMAX SPEND\tEXPIRES\tSTATUS")
+			now := time.Now()
+			for _, g := range out.Grants {
+				status := "active"
+				if err := g.Check(now); err != nil {
+					status = err.Error()
+				}
+				fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", g.Delegate, g.Spent, g.MaxSpend,
+					g.Expires.Format(time.RFC3339), status)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var storagePayerUseCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Pay the storage contracts of this node with a delegation.",
+		ShortDescription: `
+Has the payer of the delegation, output by 'btfs storage payer delegate' on
+the payer node, sign and pay the storage contracts of the next uploads of this
+node. The payer must be online during the uploads. Stop with
+'btfs storage payer clear'.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("delegation", true, false, "Delegation of the payer node."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageClientEnabled {
+			return fmt.Errorf("storage client api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		dl, err := Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		if dl.Delegate != n.Identity.Pretty() {
+			return fmt.Errorf("delegation is for node %s", dl.Delegate)
+		}
+		if err := dl.Verify(time.Now()); err != nil {
+			return err
+		}
+		return uh.PutDelegation(n.Repo.Datastore(), n.Identity.Pretty(), dl)
+	},
+}
+
+var storagePayerClearCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Pay the storage contracts of this node with its own wallet again.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		return uh.PutDelegation(n.Repo.Datastore(), n.Identity.Pretty(), nil)
+	},
+}
+
+// StatusOutput is who pays the storage contracts of this node.
+type StatusOutput struct {
+	Payer      string
+	Delegation *uh.Delegation `json:",omitempty"`
+	// Error is why the delegation is no longer valid.
+	Error string `json:",omitempty"`
+}
+
+var storagePayerStatusCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the payer of the storage contracts of this node.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		dl, err := uh.GetDelegation(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		out := &StatusOutput{Payer: n.Identity.Pretty(), Delegation: dl}
+		if dl != nil {
+			out.Payer = dl.Payer
+			if err := dl.Verify(time.Now()); err != nil {
+				out.Error = err.Error()
+			}
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: StatusOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *StatusOutput) error {
+			if out.Delegation == nil {
+				_, err := fmt.Fprintf(w, "payer: %s (this node)\n", out.Payer)
+				return err
+			}
+			fmt.Fprintf(w, "payer: %s\nmax spend: %d µBTT\nexpires: %s\n", out.Payer,
+				out.Delegation.MaxSpend, out.Delegation.Expires.Format(time.RFC3339))
+			if out.Error != "" {
+				fmt.Fprintf(w, "invalid: %s\n", out.Error)
+			}
+			return nil
+		}),
+	},
+}
+
+var StoragePayerSignCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Sign a message as the payer of a delegate (payer).",
+		ShortDescription: `
+Called by a node this node delegated to, signs a message of its uploads on
+behalf of this node. Only signs the messages with this node as buyer or
+renter, and the channel commits within the amount left to the delegate.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("kind", true, false, "Kind of the message."),
+		cmds.StringArg("message", true, false, "Message to sign."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		delegate, ok := remote.GetStreamRequestRemotePeerID(req, n)
+		if !ok {
+			return fmt.Errorf("fail to get peer ID from request")
+		}
+		pubKey, err := ic.RawFull(n.PrivateKey.GetPublic())
+		if err != nil {
+			return err
+		}
+		self := n.Identity.Pretty()
+		raw := []byte(req.Arguments[1])
+		amount, err := Authorize(req.Arguments[0], raw, self, pubKey)
+		if err != nil {
+			return err
+		}
+		err = UpdateGrant(n.Repo.Datastore(), self, delegate.Pretty(), func(g *Grant) error {
+			if err := g.Check(time.Now()); err != nil {
+				return err
+			}
+			return g.Charge(amount)
+		})
+		if err != nil {
+			return err
+		}
+		sig, err := n.PrivateKey.Sign(raw)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &uh.SignOutput{Signature: sig})
+	},
+	Type: uh.SignOutput{},
+}
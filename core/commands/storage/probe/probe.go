@@ -0,0 +1,401 @@
+// Package probe tests the retrieval of the files of a renter before they
+// are needed: it periodically reads a random small range of a shard from
+// each host, records the latency and outcome, and alerts when a file would
+// likely miss its retrieval SLA.
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/alerts"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+	path "github.com/TRON-US/interface-go-btfs-core/path"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	cidlib "github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var log = logging.Logger("storage/probe")
+
+// ProbeTimeout bounds a single probe.
+const ProbeTimeout = time.Minute
+
+var StorageProbeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Test the retrieval of the stored files with synthetic probes.",
+		ShortDescription: `
+With RetrievalProbes.Enabled set, a renter daemon reads a random small range
+of a shard from each of its hosts every RetrievalProbes.Period (1h by
+default), records the latency and outcome, and alerts when a file would
+likely miss its retrieval SLA, before the data is needed:
+
+    $ btfs config --json RetrievalProbes.Enabled true
+    $ btfs config RetrievalProbes.SLA 5s
+    $ btfs config --json RetrievalProbes.MinSuccessRate 0.9
+    $ btfs config --json RetrievalProbes.MaxRiskShare 0.5
+
+A host misses the SLA when less than MinSuccessRate of its probes succeed
+or their 90th percentile latency is above SLA. A file is at risk when more
+than MaxRiskShare of its shards are on such hosts. The probes also weigh in
+the personal reputation of the hosts, see 'btfs storage market ls'.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"run":    storageProbeRunCmd,
+		"status": storageProbeStatusCmd,
+		"read":   StorageProbeReadCmd,
+	},
+}
+
+// RunOutput is the outcome of a probe round.
+type RunOutput struct {
+	Probed int
+	Failed int
+	Risks  []*FileRisk
+}
+
+var storageProbeRunCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Probe the hosts of the stored files now.",
+	},
+	RunTimeout: 30 * time.Minute,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		cfg, err := Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		out, err := Run(req.Context, n, api, cfg, time.Now())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: RunOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *RunOutput) error {
+			fmt.Fprintf(w, "%d hosts probed, %d failed\n", out.Probed, out.Failed)
+			return writeRisks(w, out.Risks)
+		}),
+	},
+}
+
+// StatusOutput are the probes of the hosts, the files at risk and the
+// alerts raised so far.
+type StatusOutput struct {
+	Hosts  []*HostStatus
+	Risks  []*FileRisk
+	Alerts []*Alert
+}
+
+// HostStatus are the probe stats of a host.
+type HostStatus struct {
+	ID string
+	HostStats
+	Last time.Time
+	// Risk is why the host misses the SLA, if it does.
+	Risk string `json:",omitempty"`
+}
+
+var storageProbeStatusCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the probes of the hosts and the files at risk.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		d := n.Repo.Datastore()
+		self := n.Identity.Pretty()
+		hosts, err := Hosts(d, self)
+		if err != nil {
+			return err
+		}
+		out := &StatusOutput{}
+		for _, h := range hosts {
+			hs := &HostStatus{ID: h.ID, HostStats: h.Stats(), Risk: h.Risk(cfg)}
+			if len(h.Samples) > 0 {
+				hs.Last = h.Samples[len(h.Samples)-1].Time
+			}
+			out.Hosts = append(out.Hosts, hs)
+		}
+		sort.Slice(out.Hosts, func(i, j int) bool {
+			return out.Hosts[i].ID < out.Hosts[j].ID
+		})
+		if out.Risks, err = Risks(d, self); err != nil {
+			return err
+		}
+		if out.Alerts, err = Alerts(d, self); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: StatusOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *StatusOutput) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "HOST\tPROBES\tSUCCESS\tP90\tLAST\tRISK")
+			for _, h := range out.Hosts {
+				risk := h.Risk
+				if risk == "" {
+					risk = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%d\t%.0f%%\t%s\t%s\t%s\n", h.ID, h.Probes, h.SuccessRate*100,
+					h.P90.Round(time.Millisecond), h.Last.Format(time.RFC3339), risk)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			if err := writeRisks(w, out.Risks); err != nil {
+				return err
+			}
+			for _, a := range out.Alerts {
+				fmt.Fprintf(w, "%s alert: file %s at risk\n", a.Time.Format(time.RFC3339), a.FileHash)
+			}
+			return nil
+		}),
+	},
+}
+
+func writeRisks(w io.Writer, risks []*FileRisk) error {
+	for _, r := range risks {
+		fmt.Fprintf(w, "file %s at risk, %d of %d shards on hosts missing the SLA\n", r.FileHash, r.AtRisk, r.Shards)
+		for h, reason := range r.Hosts {
+			fmt.Fprintf(w, "  %s: %s\n", h, reason)
+		}
+	}
+	return nil
+}
+
+// ReadOutput is the range of a shard read for a probe.
+type ReadOutput struct {
+	Data []byte
+}
+
+var StorageProbeReadCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Read a range of a shard for a retrieval probe (host).",
+		ShortDescription: `
+Called by the renter of a contract stored by this host to probe the
+retrieval of its shard, reads length bytes of the shard from offset.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("contract-id", true, false, "ID of the contract stored by this host."),
+		cmds.StringArg("offset", true, false, "Offset of the range in the shard."),
+		cmds.StringArg("length", true, false, "Length of the range, up to 1MiB."),
+	},
+	RunTimeout: ProbeTimeout,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		if !cfg.Experimental.StorageHostEnabled {
+			return fmt.Errorf("storage host api not enabled")
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		offset, err := strconv.ParseInt(req.Arguments[1], 10, 64)
+		if err != nil || offset < 0 {
+			return fmt.Errorf("invalid offset %q", req.Arguments[1])
+		}
+		length, err := strconv.ParseInt(req.Arguments[2], 10, 64)
+		if err != nil || length <= 0 || length > MaxRangeSize {
+			return fmt.Errorf("invalid length %q", req.Arguments[2])
+		}
+		c, err := contracts.ShardContract(n.Repo.Datastore(), n.Identity.Pretty(),
+			nodepb.ContractStat_HOST.String(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		renter, ok := remote.GetStreamRequestRemotePeerID(req, n)
+		if !ok || renter.Pretty() != c.RenterPid {
+			return errors.New("only the renter of the contract can probe it")
+		}
+		data, err := readRange(req.Context, api, c.ShardHash, offset, length)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &ReadOutput{Data: data})
+	},
+	Type: ReadOutput{},
+}
+
+// readRange reads length bytes from offset of the shard shardHash.
+func readRange(ctx context.Context, api coreiface.CoreAPI, shardHash string, offset, length int64) ([]byte, error) {
+	sh, err := cidlib.Parse(shardHash)
+	if err != nil {
+		return nil, err
+	}
+	nd, err := api.Unixfs().Get(ctx, path.IpfsPath(sh))
+	if err != nil {
+		return nil, err
+	}
+	defer nd.Close()
+	f, ok := nd.(files.File)
+	if !ok {
+		return nil, fmt.Errorf("shard %s is not a file", shardHash)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	k, err := io.ReadFull(f, data)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return data[:k], err
+}
+
+// Run probes each host of the active renter contracts of n once, records
+// the probes and alerts on the files getting at risk.
+func Run(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, cfg *Config, now time.Time) (*RunOutput, error) {
+	d := n.Repo.Datastore()
+	self := n.Identity.Pretty()
+	scs, err := sessions.ListShardsContracts(d, self, nodepb.ContractStat_RENTER.String())
+	if err != nil {
+		return nil, err
+	}
+	var cs []*guardpb.Contract
+	byHost := make(map[string][]*guardpb.Contract)
+	for _, sc := range scs {
+		c := sc.SignedGuardContract
+		if c == nil || !helper.ContractFilterMap["active"][c.State] {
+			continue
+		}
+		cs = append(cs, c)
+		byHost[c.HostPid] = append(byHost[c.HostPid], c)
+	}
+
+	out := &RunOutput{}
+	for host, hcs := range byHost {
+		// a random range of a random shard of the host
+		c := hcs[rand.Intn(len(hcs))]
+		length := cfg.rangeSize
+		if c.ShardFileSize < length {
+			length = c.ShardFileSize
+		}
+		var offset int64
+		if c.ShardFileSize > length {
+			offset = rand.Int63n(c.ShardFileSize - length + 1)
+		}
+		rtt, perr := probe(ctx, n, api, c, offset, length)
+		if ctx.Err() != nil {
+			// cut short, not the host's fault
+			return nil, ctx.Err()
+		}
+		out.Probed++
+		if perr != nil {
+			out.Failed++
+			log.Debugf("retrieval probe of host %s failed: %s", host, perr)
+		}
+		if err := RecordProbe(d, self, host, rtt, perr, now); err != nil {
+			return nil, err
+		}
+	}
+
+	hosts, err := Hosts(d, self)
+	if err != nil {
+		return nil, err
+	}
+	out.Risks = Evaluate(cs, hosts, cfg)
+	prev, err := Risks(d, self)
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveRisks(d, self, out.Risks); err != nil {
+		return nil, err
+	}
+	wasAtRisk := make(map[string]bool)
+	for _, r := range prev {
+		wasAtRisk[r.FileHash] = true
+	}
+	for _, r := range out.Risks {
+		if wasAtRisk[r.FileHash] {
+			continue
+		}
+		var reasons []string
+		for h, reason := range r.Hosts {
+			reasons = append(reasons, h+" "+reason)
+		}
+		log.Warnf("file %s would likely miss its retrieval SLA, %d of %d shards on hosts missing it: %s",
+			r.FileHash, r.AtRisk, r.Shards, strings.Join(reasons, "; "))
+		if err := PutAlert(d, self, &Alert{Time: now, FileRisk: r}); err != nil {
+			return nil, err
+		}
+		alerts.Raise(n.Repo, &alerts.Alert{
+			Time:     now,
+			Peer:     self,
+			Source:   "probe",
+			Severity: alerts.Warning,
+			Title:    fmt.Sprintf("file %s would likely miss its retrieval SLA", r.FileHash),
+			Text:     fmt.Sprintf("%d of %d shards on hosts missing it:\n%s", r.AtRisk, r.Shards, strings.Join(reasons, "\n")),
+		})
+	}
+	return out, nil
+}
+
+// probe reads the range of the shard of c from its host, returning the
+// time it took.
+func probe(ctx context.Context, n *core.IpfsNode, api coreiface.CoreAPI, c *guardpb.Contract, offset, length int64) (time.Duration, error) {
+	hostPid, err := peer.IDB58Decode(c.HostPid)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+	start := time.Now()
+	b, err := remote.P2PCallStrings(ctx, n, api, hostPid, "/storage/probe/read", c.ContractId,
+		strconv.FormatInt(offset, 10), strconv.FormatInt(length, 10))
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	out := &ReadOutput{}
+	if err := json.Unmarshal(b, out); err != nil {
+		return 0, err
+	}
+	if int64(len(out.Data)) != length {
+		return 0, fmt.Errorf("read %d bytes of %d", len(out.Data), length)
+	}
+	return rtt, nil
+}
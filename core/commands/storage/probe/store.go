@@ -0,0 +1,372 @@
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/latency"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/dustin/go-humanize"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+)
+
+// ConfigKey is the config section of the retrieval probes.
+const ConfigKey = "RetrievalProbes"
+
+// Defaults of the retrieval probes.
+const (
+	DefaultPeriod         = time.Hour
+	DefaultRangeSize      = 64 * 1024
+	DefaultSLA            = 5 * time.Second
+	DefaultMinSuccessRate = 0.9
+	DefaultMaxRiskShare   = 0.5
+)
+
+const (
+	// MaxSamples is the number of probes kept per host.
+	MaxSamples = 32
+	// MaxAlerts is the number of alerts kept.
+	MaxAlerts = 50
+	// MaxRangeSize bounds the range a host reads for a probe.
+	MaxRangeSize = 1024 * 1024
+
+	hostKeyPrefix  = "/btfs/%s/probes/hosts/"
+	risksKey       = "/btfs/%s/probes/risks"
+	alertKeyPrefix = "/btfs/%s/probes/alerts/"
+)
+
+// Config configures the retrieval probes of a renter.
+type Config struct {
+	Enabled bool
+	// Period is the time between two probe rounds, e.g. "1h".
+	Period string `json:",omitempty"`
+	// RangeSize is the size of the range of a shard read by a probe, e.g.
+	// "64KB".
+	RangeSize string `json:",omitempty"`
+	// SLA is the retrieval latency a host must meet at the 90th percentile,
+	// e.g. "5s".
+	SLA string `json:",omitempty"`
+	// MinSuccessRate is the share of the probes of a host that must succeed.
+	MinSuccessRate float64 `json:",omitempty"`
+	// MaxRiskShare is the share of the shards of a file on hosts missing the
+	// SLA above which the file is at risk.
+	MaxRiskShare float64 `json:",omitempty"`
+
+	period    time.Duration
+	rangeSize int64
+	sla       time.Duration
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the retrieval probes config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	var err error
+	c.period = DefaultPeriod
+	if c.Period != "" {
+		if c.period, err = time.ParseDuration(c.Period); err != nil || c.period <= 0 {
+			return fmt.Errorf("invalid period %q", c.Period)
+		}
+	}
+	c.rangeSize = DefaultRangeSize
+	if c.RangeSize != "" {
+		size, err := humanize.ParseBytes(c.RangeSize)
+		if err != nil || size == 0 || size > MaxRangeSize {
+			return fmt.Errorf("range size %q is not within (0, %s]", c.RangeSize,
+				humanize.IBytes(MaxRangeSize))
+		}
+		c.rangeSize = int64(size)
+	}
+	c.sla = DefaultSLA
+	if c.SLA != "" {
+		if c.sla, err = time.ParseDuration(c.SLA); err != nil || c.sla <= 0 {
+			return fmt.Errorf("invalid SLA %q", c.SLA)
+		}
+	}
+	if c.MinSuccessRate < 0 || c.MinSuccessRate > 1 || c.MaxRiskShare < 0 || c.MaxRiskShare > 1 {
+		return fmt.Errorf("MinSuccessRate and MaxRiskShare must be within [0, 1]")
+	}
+	if c.MinSuccessRate == 0 {
+		c.MinSuccessRate = DefaultMinSuccessRate
+	}
+	if c.MaxRiskShare == 0 {
+		c.MaxRiskShare = DefaultMaxRiskShare
+	}
+	return nil
+}
+
+// PeriodDuration returns the time between two probe rounds.
+func (c *Config) PeriodDuration() time.Duration {
+	return c.period
+}
+
+// RangeBytes returns the size of the range of a shard read by a probe.
+func (c *Config) RangeBytes() int64 {
+	return c.rangeSize
+}
+
+// Sample is the outcome of a probe.
+type Sample struct {
+	Time    time.Time
+	Latency time.Duration `json:",omitempty"`
+	Error   string        `json:",omitempty"`
+}
+
+// Host are the last probes of a host.
+type Host struct {
+	ID string
+	// Samples are the last MaxSamples probes, oldest first.
+	Samples []Sample
+}
+
+// HostStats summarize the probes of a host.
+type HostStats struct {
+	Probes      int
+	SuccessRate float64
+	// P90 is the 90th percentile of the latency of the successful probes.
+	P90 time.Duration
+}
+
+// Stats returns the stats of the probes of h.
+func (h *Host) Stats() HostStats {
+	s := HostStats{Probes: len(h.Samples)}
+	var rtts []time.Duration
+	for _, p := range h.Samples {
+		if p.Error == "" {
+			rtts = append(rtts, p.Latency)
+		}
+	}
+	if s.Probes > 0 {
+		s.SuccessRate = float64(len(rtts)) / float64(s.Probes)
+	}
+	s.P90 = latency.Percentiles(rtts).P90
+	return s
+}
+
+// Risk returns why the host h misses the SLA of c, empty when it meets it
+// or was never probed.
+func (h *Host) Risk(c *Config) string {
+	if h == nil || len(h.Samples) == 0 {
+		return ""
+	}
+	s := h.Stats()
+	if s.SuccessRate < c.MinSuccessRate {
+		return fmt.Sprintf("%.0f%% of %d probes succeeded", s.SuccessRate*100, s.Probes)
+	}
+	if s.P90 > c.sla {
+		return fmt.Sprintf("p90 latency %s over %s", s.P90.Round(time.Millisecond), c.sla)
+	}
+	return ""
+}
+
+func hostKey(peerID, hostID string) ds.Key {
+	return ds.NewKey(fmt.Sprintf(hostKeyPrefix, peerID) + hostID)
+}
+
+// RecordProbe adds a probe of the host hostID by the node peerID, failed
+// when err is not nil.
+func RecordProbe(d ds.Datastore, peerID, hostID string, rtt time.Duration, err error, at time.Time) error {
+	h := &Host{ID: hostID}
+	b, gerr := d.Get(hostKey(peerID, hostID))
+	if gerr == nil {
+		if uerr := json.Unmarshal(b, h); uerr != nil {
+			return fmt.Errorf("invalid probes of host %s: %s", hostID, uerr)
+		}
+	} else if gerr != ds.ErrNotFound {
+		return gerr
+	}
+	s := Sample{Time: at, Latency: rtt}
+	if err != nil {
+		s = Sample{Time: at, Error: err.Error()}
+	}
+	h.Samples = append(h.Samples, s)
+	if len(h.Samples) > MaxSamples {
+		h.Samples = h.Samples[len(h.Samples)-MaxSamples:]
+	}
+	b, err = json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return d.Put(hostKey(peerID, hostID), b)
+}
+
+// Hosts returns the hosts probed by the node peerID, by host ID.
+func Hosts(d ds.Datastore, peerID string) (map[string]*Host, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(hostKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	hosts := make(map[string]*Host)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		h := &Host{}
+		if err := json.Unmarshal(r.Value, h); err != nil {
+			return nil, fmt.Errorf("invalid probes %s: %s", r.Key, err)
+		}
+		hosts[h.ID] = h
+	}
+	return hosts, nil
+}
+
+// FileRisk is a file likely to miss its retrieval SLA.
+type FileRisk struct {
+	FileHash string
+	Shards   int
+	// AtRisk are the shards on hosts missing the SLA.
+	AtRisk int
+	// Hosts are the hosts missing the SLA, with the reason.
+	Hosts map[string]string
+}
+
+// Evaluate returns the files of the renter contracts cs at risk of
+// missing their retrieval SLA from the probes of their hosts, sorted by
+// file hash.
+func Evaluate(cs []*guardpb.Contract, hosts map[string]*Host, c *Config) []*FileRisk {
+	files := make(map[string]*FileRisk)
+	shards := make(map[string]bool)
+	for _, gc := range cs {
+		if !helper.ContractFilterMap["active"][gc.State] {
+			continue
+		}
+		// a shard renewed or repaired has several contracts
+		k := fmt.Sprintf("%s/%d", gc.FileHash, gc.ShardIndex)
+		if shards[k] {
+			continue
+		}
+		shards[k] = true
+		f, ok := files[gc.FileHash]
+		if !ok {
+			f = &FileRisk{FileHash: gc.FileHash, Hosts: map[string]string{}}
+			files[gc.FileHash] = f
+		}
+		f.Shards++
+		if reason := hosts[gc.HostPid].Risk(c); reason != "" {
+			f.AtRisk++
+			f.Hosts[gc.HostPid] = reason
+		}
+	}
+	var risks []*FileRisk
+	for _, f := range files {
+		if float64(f.AtRisk) > c.MaxRiskShare*float64(f.Shards) {
+			risks = append(risks, f)
+		}
+	}
+	sort.Slice(risks, func(i, j int) bool {
+		return risks[i].FileHash < risks[j].FileHash
+	})
+	return risks
+}
+
+// Risks returns the files at risk after the last probe round of the node
+// peerID.
+func Risks(d ds.Datastore, peerID string) ([]*FileRisk, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(risksKey, peerID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var risks []*FileRisk
+	if err := json.Unmarshal(b, &risks); err != nil {
+		return nil, fmt.Errorf("invalid file risks: %s", err)
+	}
+	return risks, nil
+}
+
+// SaveRisks records the files at risk after a probe round of the node
+// peerID.
+func SaveRisks(d ds.Datastore, peerID string, risks []*FileRisk) error {
+	b, err := json.Marshal(risks)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(risksKey, peerID)), b)
+}
+
+// Alert is raised when a file gets at risk of missing its retrieval SLA.
+type Alert struct {
+	Time time.Time
+	*FileRisk
+}
+
+// PutAlert records a, dropping the oldest alerts once MaxAlerts are kept.
+func PutAlert(d ds.Datastore, peerID string, a *Alert) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	// zero padded for the keys to sort by time
+	k := ds.NewKey(fmt.Sprintf(alertKeyPrefix+"%020d", peerID, a.Time.UnixNano()))
+	if err := d.Put(k, b); err != nil {
+		return err
+	}
+	results, err := d.Query(query.Query{
+		Prefix:   fmt.Sprintf(alertKeyPrefix, peerID),
+		KeysOnly: true,
+		Orders:   []query.Order{query.OrderByKeyDescending{}},
+		Offset:   MaxAlerts,
+	})
+	if err != nil {
+		return err
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := d.Delete(ds.NewKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Alerts returns the alerts raised for the node peerID, latest first.
+func Alerts(d ds.Datastore, peerID string) ([]*Alert, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(alertKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var alerts []*Alert
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		a := &Alert{}
+		if err := json.Unmarshal(r.Value, a); err != nil {
+			return nil, fmt.Errorf("invalid probe alert %s: %s", r.Key, err)
+		}
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Time.After(alerts[j].Time)
+	})
+	return alerts, nil
+}
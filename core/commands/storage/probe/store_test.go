@@ -0,0 +1,102 @@
+package probe
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+)
+
+func TestConfig(t *testing.T) {
+	c := &Config{}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	if c.period != DefaultPeriod || c.rangeSize != DefaultRangeSize || c.sla != DefaultSLA ||
+		c.MinSuccessRate != DefaultMinSuccessRate || c.MaxRiskShare != DefaultMaxRiskShare {
+		t.Fatalf("unexpected defaults %+v", c)
+	}
+	for _, bad := range []*Config{
+		{Period: "soon"},
+		{RangeSize: "2MB"},
+		{SLA: "-1s"},
+		{MinSuccessRate: 1.5},
+	} {
+		if err := bad.compile(); err == nil {
+			t.Fatalf("expected config %+v to be invalid", bad)
+		}
+	}
+}
+
+func TestProbes(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	c := &Config{SLA: "1s"}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < MaxSamples+2; i++ {
+		if err := RecordProbe(d, "self", "fast", 100*time.Millisecond, nil, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RecordProbe(d, "self", "slow", 3*time.Second, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordProbe(d, "self", "down", 0, errors.New("timeout"), now); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := Hosts(d, "self")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 3 || len(hosts["fast"].Samples) != MaxSamples {
+		t.Fatalf("unexpected hosts %+v", hosts)
+	}
+	if s := hosts["fast"].Stats(); s.SuccessRate != 1 || s.P90 != 100*time.Millisecond {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if hosts["fast"].Risk(c) != "" || hosts["slow"].Risk(c) == "" || hosts["down"].Risk(c) == "" {
+		t.Fatal("expected the slow and down hosts to miss the SLA")
+	}
+	if hosts["unprobed"].Risk(c) != "" {
+		t.Fatal("expected an unprobed host to meet the SLA")
+	}
+
+	active := guardpb.Contract_UPLOADED
+	cs := []*guardpb.Contract{
+		contract("ok", 0, "fast", active),
+		contract("ok", 1, "slow", active),
+		contract("bad", 0, "slow", active),
+		contract("bad", 1, "down", active),
+		// renewed on the fast host, counted once
+		contract("bad", 1, "fast", active),
+		contract("gone", 0, "down", guardpb.Contract_CLOSED),
+	}
+	risks := Evaluate(cs, hosts, c)
+	if len(risks) != 1 || risks[0].FileHash != "bad" || risks[0].Shards != 2 || risks[0].AtRisk != 2 {
+		t.Fatalf("unexpected risks %+v", risks)
+	}
+
+	for i := 0; i < MaxAlerts+1; i++ {
+		if err := PutAlert(d, "self", &Alert{Time: now.Add(time.Duration(i) * time.Second), FileRisk: risks[0]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alerts, err := Alerts(d, "self")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != MaxAlerts || !alerts[0].Time.Equal(now.Add(MaxAlerts*time.Second)) {
+		t.Fatalf("expected the last %d alerts, latest first", MaxAlerts)
+	}
+}
+
+func contract(fileHash string, index int, hostPid string, state guardpb.Contract_ContractState) *guardpb.Contract {
+	c := &guardpb.Contract{State: state}
+	c.FileHash, c.ShardIndex, c.HostPid = fileHash, int32(index), hostPid
+	return c
+}
@@ -0,0 +1,280 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("storage/settlement")
+
+const contractOptionName = "contract"
+
+// settleMu serializes the settlements of the daemon and of the command.
+var settleMu sync.Mutex
+
+// withdraw withdraws amount from the ledger of n, replaced by tests.
+var withdraw = func(ctx context.Context, n *core.IpfsNode, amount int64) (int64, int64, error) {
+	cfg, err := n.Repo.Config()
+	if err != nil {
+		return 0, 0, err
+	}
+	return wallet.WithdrawLedger(ctx, cfg, n, amount)
+}
+
+// Run accrues the payouts of the host contracts of n and settles them when
+// due under c, or right away when force is set. It returns the receipt of
+// the settlement, nil when there was none.
+func Run(ctx context.Context, n *core.IpfsNode, c *Config, force bool, now time.Time) (*Receipt, error) {
+	settleMu.Lock()
+	defer settleMu.Unlock()
+
+	d, peerID := n.Repo.Datastore(), n.Identity.Pretty()
+	st, err := GetState(d, peerID)
+	if err != nil {
+		return nil, err
+	}
+	cts, err := contracts.ListContracts(d, peerID, nodepb.ContractStat_HOST.String())
+	if err != nil {
+		return nil, err
+	}
+	Accrue(st, cts, now)
+	trigger, payouts := Due(c, st, now)
+	if force && len(st.Pending) > 0 {
+		trigger, payouts = TriggerManual, Batch(st)
+	}
+	if len(payouts) == 0 {
+		return nil, PutState(d, peerID, st)
+	}
+
+	r := NewReceipt(trigger, payouts, now)
+	r.ChannelID, r.TxID, err = withdraw(ctx, n, r.Amount)
+	if err != nil {
+		r.Error = err.Error()
+		log.Warnf("failed to settle %d payouts of %d µBTT: %s", len(payouts), r.Amount, err)
+	} else {
+		st.Settled(r)
+		log.Infof("settled %d payouts of %d µBTT, transaction %d", len(payouts), r.Amount, r.TxID)
+	}
+	if err := PutReceipt(d, peerID, r); err != nil {
+		return nil, err
+	}
+	return r, PutState(d, peerID, st)
+}
+
+var StorageSettlementCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Batch the withdrawals of the payouts of the host contracts.",
+		ShortDescription: `
+Each payout of a host contract lands in the ledger account of the host.
+Withdrawing them one by one costs a transaction each, so a host daemon
+withdraws its payouts in batches once enabled:
+
+    $ btfs config --json Settlement.Enabled true
+
+A batch is withdrawn when any bound is reached: the number of payouts
+pending, their value in µBTT, or the age of the oldest one:
+
+    $ btfs config --json Settlement.MaxCount 500
+    $ btfs config --json Settlement.MinValue 100000000
+    $ btfs config Settlement.Window 168h
+
+Every batch gets a receipt listing the contracts it pays, see
+'btfs storage settlement receipts'.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"status":   storageSettlementStatusCmd,
+		"settle":   storageSettlementSettleCmd,
+		"receipts": storageSettlementReceiptsCmd,
+	},
+}
+
+// StatusOutput is what the settlements of a host are at.
+type StatusOutput struct {
+	Enabled      bool
+	Pending      int
+	PendingValue int64
+	Oldest       time.Time `json:",omitempty"`
+	MaxCount     int
+	MinValue     int64 `json:",omitempty"`
+	Window       time.Duration
+	// LastReceipt is the receipt of the last settlement.
+	LastReceipt *Receipt `json:",omitempty"`
+}
+
+var storageSettlementStatusCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the payouts pending settlement.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if err := helper.HostEnabled(env); err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		c, err := Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		d, peerID := n.Repo.Datastore(), n.Identity.Pretty()
+		st, err := GetState(d, peerID)
+		if err != nil {
+			return err
+		}
+		rs, err := Receipts(d, peerID)
+		if err != nil {
+			return err
+		}
+		out := &StatusOutput{
+			Enabled:      c.Enabled,
+			Pending:      len(st.Pending),
+			PendingValue: st.PendingValue(),
+			Oldest:       st.Oldest(),
+			MaxCount:     c.MaxCount,
+			MinValue:     c.MinValue,
+			Window:       c.WindowDuration(),
+		}
+		if len(rs) > 0 {
+			out.LastReceipt = rs[0]
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: StatusOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *StatusOutput) error {
+			fmt.Fprintf(w, "enabled: %t\n", out.Enabled)
+			fmt.Fprintf(w, "pending: %d payouts, %d µBTT\n", out.Pending, out.PendingValue)
+			if out.Pending > 0 {
+				fmt.Fprintf(w, "oldest: %s\n", out.Oldest.Format(time.RFC3339))
+			}
+			fmt.Fprintf(w, "settled at: %d payouts", out.MaxCount)
+			if out.MinValue > 0 {
+				fmt.Fprintf(w, ", %d µBTT", out.MinValue)
+			}
+			fmt.Fprintf(w, " or after %s\n", out.Window)
+			if r := out.LastReceipt; r != nil {
+				fmt.Fprintf(w, "last settlement: %s, %s\n", r.ID, receiptResult(r))
+			}
+			return nil
+		}),
+	},
+}
+
+func receiptResult(r *Receipt) string {
+	if r.Error != "" {
+		return "failed: " + r.Error
+	}
+	return fmt.Sprintf("%d µBTT in transaction %d", r.Amount, r.TxID)
+}
+
+var storageSettlementSettleCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Settle the payouts pending now.",
+		ShortDescription: `
+Withdraws the payouts pending in one transaction, whatever the bounds of the
+batches, and prints the receipt.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if err := helper.HostEnabled(env); err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		c, err := Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		r, err := Run(req.Context, n, c, true, time.Now())
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			return fmt.Errorf("no payouts pending")
+		}
+		return cmds.EmitOnce(res, r)
+	},
+	Type: Receipt{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, r *Receipt) error {
+			_, err := fmt.Fprintf(w, "settlement %s of %d payouts: %s\n", r.ID, len(r.Payouts), receiptResult(r))
+			return err
+		}),
+	},
+}
+
+// ReceiptsOutput are the receipts of the settlements of a host.
+type ReceiptsOutput struct {
+	Receipts []*Receipt
+}
+
+var storageSettlementReceiptsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the receipts of the settlements.",
+		ShortDescription: `
+Lists the settlements, latest first. --contract lists the ones which paid a
+contract, with its payouts; the JSON output has the payouts of every
+contract of each settlement.`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(contractOptionName, "List the settlements paying this contract."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if err := helper.HostEnabled(env); err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		rs, err := Receipts(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		if id, ok := req.Options[contractOptionName].(string); ok {
+			var filtered []*Receipt
+			for _, r := range rs {
+				if r.Includes(id) {
+					filtered = append(filtered, r)
+				}
+			}
+			rs = filtered
+		}
+		return cmds.EmitOnce(res, &ReceiptsOutput{Receipts: rs})
+	},
+	Type: ReceiptsOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ReceiptsOutput) error {
+			contract, _ := req.Options[contractOptionName].(string)
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tTIME\tTRIGGER\tPAYOUTS\tRESULT")
+			for _, r := range out.Receipts {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", r.ID, r.Time.Format(time.RFC3339), r.Trigger,
+					len(r.Payouts), receiptResult(r))
+				for _, p := range r.Payouts {
+					if p.ContractID == contract {
+						fmt.Fprintf(tw, "  %s\t%s\t\t%d µBTT\t\n", p.ContractID, p.Time.Format(time.RFC3339), p.Amount)
+					}
+				}
+			}
+			return tw.Flush()
+		}),
+	},
+}
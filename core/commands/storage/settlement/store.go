@@ -0,0 +1,305 @@
+package settlement
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/wallet"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+// ConfigKey is the config section of the settlement batching of a host.
+const ConfigKey = "Settlement"
+
+// Defaults of the settlement batching.
+const (
+	DefaultMaxCount = 500
+	DefaultWindow   = 7 * 24 * time.Hour
+
+	stateKey         = "/btfs/%s/settlement/state"
+	receiptKeyPrefix = "/btfs/%s/settlement/receipts/"
+)
+
+// Triggers of a settlement.
+const (
+	TriggerCount  = "count"
+	TriggerValue  = "value"
+	TriggerWindow = "window"
+	TriggerManual = "manual"
+)
+
+// Config configures the settlement batching of a host. The payouts of the
+// contracts are withdrawn from the ledger in one transaction once any of
+// the bounds is reached.
+type Config struct {
+	Enabled bool
+	// MaxCount is the number of payouts pending at which they are settled.
+	MaxCount int `json:",omitempty"`
+	// MinValue is the value in µBTT pending at which the payouts are
+	// settled, none when 0.
+	MinValue int64 `json:",omitempty"`
+	// Window is the longest a payout waits to be settled, e.g. "24h".
+	Window string `json:",omitempty"`
+
+	window time.Duration
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the settlement batching config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	if c.MaxCount < 0 || c.MinValue < 0 {
+		return fmt.Errorf("negative bound")
+	}
+	if c.MaxCount == 0 {
+		c.MaxCount = DefaultMaxCount
+	}
+	c.window = DefaultWindow
+	if c.Window != "" {
+		w, err := time.ParseDuration(c.Window)
+		if err != nil || w <= 0 {
+			return fmt.Errorf("invalid window %q", c.Window)
+		}
+		c.window = w
+	}
+	return nil
+}
+
+// WindowDuration returns the longest a payout waits to be settled.
+func (c *Config) WindowDuration() time.Duration {
+	return c.window
+}
+
+// Payout is a payment of a contract to this host.
+type Payout struct {
+	ContractID string
+	Amount     int64
+	// Time is when the payment was seen.
+	Time time.Time
+}
+
+// State is what the settlements of a host are at.
+type State struct {
+	// Paid is the compensation paid of each contract seen, by contract ID,
+	// nil before the first accrual.
+	Paid map[string]int64
+	// Pending are the payouts not settled yet, oldest first.
+	Pending []*Payout
+}
+
+// PendingValue returns the value of the payouts pending, in µBTT.
+func (st *State) PendingValue() int64 {
+	var v int64
+	for _, p := range st.Pending {
+		v += p.Amount
+	}
+	return v
+}
+
+// Oldest returns the time of the oldest payout pending.
+func (st *State) Oldest() time.Time {
+	if len(st.Pending) == 0 {
+		return time.Time{}
+	}
+	return st.Pending[0].Time
+}
+
+// GetState returns the settlement state of the host peerID.
+func GetState(d ds.Datastore, peerID string) (*State, error) {
+	st := &State{}
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(stateKey, peerID)))
+	if err == ds.ErrNotFound {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, st); err != nil {
+		return nil, fmt.Errorf("invalid settlement state: %s", err)
+	}
+	return st, nil
+}
+
+// PutState saves the settlement state of the host peerID.
+func PutState(d ds.Datastore, peerID string, st *State) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(stateKey, peerID)), b)
+}
+
+// Accrue adds the compensation paid for the contracts cts since the last
+// accrual to the payouts pending of st and returns the number added. The
+// compensation paid before the first accrual is taken as settled.
+func Accrue(st *State, cts []*nodepb.Contracts_Contract, now time.Time) int {
+	first := st.Paid == nil
+	if first {
+		st.Paid = map[string]int64{}
+	}
+	added := 0
+	for _, c := range cts {
+		paid := st.Paid[c.ContractId]
+		if c.CompensationPaid <= paid {
+			continue
+		}
+		st.Paid[c.ContractId] = c.CompensationPaid
+		if first {
+			continue
+		}
+		st.Pending = append(st.Pending, &Payout{
+			ContractID: c.ContractId,
+			Amount:     c.CompensationPaid - paid,
+			Time:       now,
+		})
+		added++
+	}
+	return added
+}
+
+// Due returns the trigger and the payouts of st to settle at now under c,
+// no payouts when none is due. The payouts are the oldest ones, as many as
+// one withdrawal takes.
+func Due(c *Config, st *State, now time.Time) (string, []*Payout) {
+	var trigger string
+	switch {
+	case len(st.Pending) == 0:
+		return "", nil
+	case len(st.Pending) >= c.MaxCount:
+		trigger = TriggerCount
+	case c.MinValue > 0 && st.PendingValue() >= c.MinValue:
+		trigger = TriggerValue
+	case now.Sub(st.Oldest()) >= c.window:
+		trigger = TriggerWindow
+	default:
+		return "", nil
+	}
+	return trigger, Batch(st)
+}
+
+// Batch returns the oldest payouts of st one withdrawal takes.
+func Batch(st *State) []*Payout {
+	var (
+		batch []*Payout
+		value int64
+	)
+	for _, p := range st.Pending {
+		if value+p.Amount > wallet.WithdrawMaxAmount {
+			break
+		}
+		value += p.Amount
+		batch = append(batch, p)
+	}
+	return batch
+}
+
+// Receipt is the record of a settlement, a withdrawal of a batch of
+// payouts.
+type Receipt struct {
+	ID      string
+	Time    time.Time
+	Trigger string
+	Amount  int64
+	Payouts []*Payout
+	// ChannelID and TxID are the ledger channel and the exchange
+	// transaction of the withdrawal, see 'btfs wallet transactions'.
+	ChannelID int64 `json:",omitempty"`
+	TxID      int64 `json:",omitempty"`
+	// Error is why the withdrawal failed, the payouts staying pending.
+	Error string `json:",omitempty"`
+}
+
+// NewReceipt returns the receipt of the settlement of payouts at now.
+func NewReceipt(trigger string, payouts []*Payout, now time.Time) *Receipt {
+	r := &Receipt{
+		ID:      fmt.Sprintf("%019d", now.UnixNano()),
+		Time:    now,
+		Trigger: trigger,
+		Payouts: payouts,
+	}
+	for _, p := range payouts {
+		r.Amount += p.Amount
+	}
+	return r
+}
+
+// Includes reports whether the receipt settled a payout of the contract
+// contractID.
+func (r *Receipt) Includes(contractID string) bool {
+	for _, p := range r.Payouts {
+		if p.ContractID == contractID {
+			return true
+		}
+	}
+	return false
+}
+
+// Settled removes the payouts of the receipt r from the payouts pending of
+// st.
+func (st *State) Settled(r *Receipt) {
+	settled := map[*Payout]bool{}
+	for _, p := range r.Payouts {
+		settled[p] = true
+	}
+	pending := st.Pending[:0]
+	for _, p := range st.Pending {
+		if !settled[p] {
+			pending = append(pending, p)
+		}
+	}
+	st.Pending = pending
+}
+
+// PutReceipt records the receipt r of the host peerID.
+func PutReceipt(d ds.Datastore, peerID string, r *Receipt) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(receiptKeyPrefix, peerID)+r.ID), b)
+}
+
+// Receipts returns the receipts of the host peerID, latest first.
+func Receipts(d ds.Datastore, peerID string) ([]*Receipt, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(receiptKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var rs []*Receipt
+	for e := range results.Next() {
+		if e.Error != nil {
+			return nil, e.Error
+		}
+		r := &Receipt{}
+		if err := json.Unmarshal(e.Value, r); err != nil {
+			return nil, fmt.Errorf("invalid settlement receipt %s: %s", e.Key, err)
+		}
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].ID > rs[j].ID
+	})
+	return rs, nil
+}
@@ -0,0 +1,104 @@
+package settlement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+func contract(id string, paid int64) *nodepb.Contracts_Contract {
+	return &nodepb.Contracts_Contract{ContractId: id, CompensationPaid: paid}
+}
+
+func TestAccrue(t *testing.T) {
+	now := time.Now()
+	st := &State{}
+	// paid before the first accrual is taken as settled
+	if n := Accrue(st, []*nodepb.Contracts_Contract{contract("a", 10), contract("b", 0)}, now); n != 0 {
+		t.Fatalf("expected no payouts on the first accrual, got %d", n)
+	}
+	n := Accrue(st, []*nodepb.Contracts_Contract{contract("a", 25), contract("b", 0), contract("c", 5)}, now)
+	if n != 2 || st.PendingValue() != 20 {
+		t.Fatalf("expected 2 payouts of 20 µBTT, got %d of %d", n, st.PendingValue())
+	}
+	if n := Accrue(st, []*nodepb.Contracts_Contract{contract("a", 25), contract("c", 5)}, now); n != 0 {
+		t.Fatalf("expected no new payouts, got %d", n)
+	}
+}
+
+func TestDue(t *testing.T) {
+	now := time.Now()
+	c := &Config{MaxCount: 3, MinValue: 100, Window: "24h"}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	pending := func(amounts ...int64) *State {
+		st := &State{}
+		for i, a := range amounts {
+			st.Pending = append(st.Pending, &Payout{ContractID: string(rune('a' + i)), Amount: a, Time: now})
+		}
+		return st
+	}
+
+	for _, tc := range []struct {
+		st      *State
+		at      time.Time
+		trigger string
+	}{
+		{pending(), now, ""},
+		{pending(10, 10), now, ""},
+		{pending(10, 10, 10), now, TriggerCount},
+		{pending(60, 60), now, TriggerValue},
+		{pending(10), now.Add(24 * time.Hour), TriggerWindow},
+	} {
+		trigger, payouts := Due(c, tc.st, tc.at)
+		if trigger != tc.trigger {
+			t.Fatalf("expected trigger %q, got %q", tc.trigger, trigger)
+		}
+		if trigger != "" && len(payouts) != len(tc.st.Pending) {
+			t.Fatalf("expected %d payouts, got %d", len(tc.st.Pending), len(payouts))
+		}
+	}
+
+	// one withdrawal takes up to its maximum
+	st := pending(wallet.WithdrawMaxAmount-1, 1, 1)
+	if _, payouts := Due(c, st, now); len(payouts) != 2 {
+		t.Fatalf("expected 2 payouts in the batch, got %d", len(payouts))
+	}
+}
+
+func TestReceipts(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	st := &State{Pending: []*Payout{
+		{ContractID: "a", Amount: 5, Time: now},
+		{ContractID: "b", Amount: 7, Time: now},
+	}}
+	first := NewReceipt(TriggerManual, st.Pending[:1], now)
+	first.Error = "insufficient funds"
+	second := NewReceipt(TriggerCount, append([]*Payout{}, st.Pending...), now.Add(time.Minute))
+	st.Settled(second)
+	if len(st.Pending) != 0 || second.Amount != 12 {
+		t.Fatalf("expected all payouts settled for 12 µBTT, got %d pending of %d", len(st.Pending), second.Amount)
+	}
+	for _, r := range []*Receipt{first, second} {
+		if err := PutReceipt(d, "peer", r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rs, err := Receipts(d, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 || rs[0].ID != second.ID || rs[1].Error == "" {
+		t.Fatalf("unexpected receipts %+v", rs)
+	}
+	if !rs[0].Includes("b") || rs[1].Includes("b") {
+		t.Fatal("receipts not traced to their contracts")
+	}
+}
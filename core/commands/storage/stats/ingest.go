@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/ingest"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+)
+
+// IngestOutput is the ingest queue of the host with its bounds.
+type IngestOutput struct {
+	*ingest.Status
+	MaxPending    int
+	MaxChallenges int
+}
+
+// sub-commands: btfs storage stats ingest
+var storageStatsIngestCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the ingest queue of the host.",
+		ShortDescription: `
+Shows the shards the host is downloading and the challenges it is answering.
+Past IngestQueue.MaxPending shards, or IngestQueue.MaxChallenges challenges,
+the host tells the renters to retry after the time it needs to drain its
+queue at the write throughput it measured. The queue is exported as the
+btfs_ingest_* metrics as well.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		return emitIngest(res, env)
+	},
+	Type:     IngestOutput{},
+	Encoders: ingestEncoders,
+}
+
+// StorageHostDrainCmd is 'btfs storage host drain'.
+var StorageHostDrainCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Stop accepting new shards, e.g. before an upgrade.",
+		ShortDescription: `
+Has the host tell the renters to retry their new shards later, while the
+shards it is downloading complete. The host is drained once no shard is
+pending, see 'btfs storage stats ingest'. The host accepts shards again with
+--off, or once restarted.`,
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(offOptionName, "Accept new shards again."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		off, _ := req.Options[offOptionName].(bool)
+		ingest.Default.Drain(!off)
+		return emitIngest(res, env)
+	},
+	Type:     IngestOutput{},
+	Encoders: ingestEncoders,
+}
+
+const offOptionName = "off"
+
+func emitIngest(res cmds.ResponseEmitter, env cmds.Environment) error {
+	n, err := cmdenv.GetNode(env)
+	if err != nil {
+		return err
+	}
+	cfg, err := ingest.Load(n.Repo)
+	if err != nil {
+		return err
+	}
+	return cmds.EmitOnce(res, &IngestOutput{
+		Status:        ingest.Default.Status(),
+		MaxPending:    cfg.MaxPending,
+		MaxChallenges: cfg.MaxChallenges,
+	})
+}
+
+var ingestEncoders = cmds.EncoderMap{
+	cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *IngestOutput) error {
+		if out.Draining {
+			fmt.Fprintln(w, "draining: new shards refused")
+		}
+		_, err := fmt.Fprintf(w, "shards: %d/%d (%s)\nchallenges: %d/%d\nwrite rate: %s/s\nretry after: %s\n",
+			out.Pending, out.MaxPending, humanize.IBytes(uint64(out.PendingBytes)),
+			out.Challenges, out.MaxChallenges, humanize.IBytes(uint64(out.WriteRate)),
+			out.RetryAfter.Round(time.Second))
+		return err
+	}),
+}
@@ -25,7 +25,7 @@ const (
 
 // Storage Stats
 //
-// Includes sub-commands: info, sync
+// Includes sub-commands: info, sync, ingest
 var StorageStatsCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Get node storage stats.",
@@ -33,8 +33,9 @@ var StorageStatsCmd = &cmds.Command{
 This command get node storage stats in the network.`,
 	},
 	Subcommands: map[string]*cmds.Command{
-		"sync": storageStatsSyncCmd,
-		"info": storageStatsInfoCmd,
+		"sync":   storageStatsSyncCmd,
+		"info":   storageStatsInfoCmd,
+		"ingest": storageStatsIngestCmd,
 	},
 }
 
@@ -2,12 +2,24 @@ package storage
 
 import (
 	"github.com/TRON-US/go-btfs/core/commands/storage/announce"
+	"github.com/TRON-US/go-btfs/core/commands/storage/bandwidth"
+	"github.com/TRON-US/go-btfs/core/commands/storage/capacity"
 	"github.com/TRON-US/go-btfs/core/commands/storage/challenge"
+	"github.com/TRON-US/go-btfs/core/commands/storage/collateral"
 	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/digest"
+	"github.com/TRON-US/go-btfs/core/commands/storage/escrow"
+	"github.com/TRON-US/go-btfs/core/commands/storage/files"
+	"github.com/TRON-US/go-btfs/core/commands/storage/health"
 	"github.com/TRON-US/go-btfs/core/commands/storage/hosts"
 	"github.com/TRON-US/go-btfs/core/commands/storage/info"
+	"github.com/TRON-US/go-btfs/core/commands/storage/market"
 	"github.com/TRON-US/go-btfs/core/commands/storage/path"
+	"github.com/TRON-US/go-btfs/core/commands/storage/payer"
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+	"github.com/TRON-US/go-btfs/core/commands/storage/settlement"
 	"github.com/TRON-US/go-btfs/core/commands/storage/stats"
+	"github.com/TRON-US/go-btfs/core/commands/storage/tenants"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/upload"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
@@ -21,13 +33,45 @@ Storage services include client upload operations, host storage operations,
 host information sync/display operations, and BTT payment-related routines.`,
 	},
 	Subcommands: map[string]*cmds.Command{
-		"upload":    upload.StorageUploadCmd,
-		"hosts":     hosts.StorageHostsCmd,
-		"info":      info.StorageInfoCmd,
-		"announce":  announce.StorageAnnounceCmd,
-		"challenge": challenge.StorageChallengeCmd,
-		"stats":     stats.StorageStatsCmd,
-		"contracts": contracts.StorageContractsCmd,
-		"path":      path.PathCmd,
+		"upload":     upload.StorageUploadCmd,
+		"update":     upload.StorageUpdateCmd,
+		"hosts":      hosts.StorageHostsCmd,
+		"info":       info.StorageInfoCmd,
+		"announce":   announce.StorageAnnounceCmd,
+		"challenge":  challenge.StorageChallengeCmd,
+		"stats":      stats.StorageStatsCmd,
+		"contracts":  contracts.StorageContractsCmd,
+		"path":       path.PathCmd,
+		"health":     health.StorageHealthCmd,
+		"market":     market.StorageMarketCmd,
+		"escrow":     escrow.StorageEscrowCmd,
+		"probe":      probe.StorageProbeCmd,
+		"payer":      payer.StoragePayerCmd,
+		"digest":     digest.StorageDigestCmd,
+		"capacity":   capacity.StorageCapacityCmd,
+		"settlement": settlement.StorageSettlementCmd,
+		"files":      files.StorageFilesCmd,
+		"sessions":   upload.StorageSessionsCmd,
+		"collateral": collateral.StorageCollateralCmd,
+		"import":     upload.StorageImportCmd,
+		"guard":      upload.StorageGuardCmd,
+		"bandwidth":  bandwidth.StorageBandwidthCmd,
+		"host":       storageHostCmd,
+	},
+}
+
+func init() {
+	// renegotiating uploads the renewed contracts, which the contracts
+	// package cannot import
+	contracts.StorageContractsCmd.Subcommands["renegotiate"] = upload.StorageContractsRenegotiateCmd
+}
+
+var storageHostCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the hosting of this node.",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"tenants": tenants.StorageHostTenantsCmd,
+		"drain":   stats.StorageHostDrainCmd,
 	},
 }
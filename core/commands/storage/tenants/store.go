@@ -0,0 +1,413 @@
+package tenants
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/tron-us/go-btfs-common/crypto"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	tenantKeyPrefix   = "/btfs/%s/tenants/list/"
+	contractKeyPrefix = "/btfs/%s/tenants/contracts/"
+	payoutKeyPrefix   = "/btfs/%s/tenants/payouts/"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Tenant is a logical host run by the node: the contracts assigned to it
+// fill its capacity pool and earn for its wallet.
+type Tenant struct {
+	Name string
+	// Wallet is the TRON address the earnings of the tenant are paid out
+	// to.
+	Wallet string
+	// Price is the lowest price of the tenant in µBTT per GiB per day, the
+	// price ask of the node when 0.
+	Price int64 `json:",omitempty"`
+	// StorageMax is the capacity pool of the tenant in bytes, unbounded
+	// when 0.
+	StorageMax int64 `json:",omitempty"`
+	// Renters are the renters served by the tenant only. A tenant without
+	// renters serves those no other tenant serves.
+	Renters []string `json:",omitempty"`
+	Created time.Time
+}
+
+// Validate checks the settings of t.
+func (t *Tenant) Validate() error {
+	if !namePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid tenant name %q: lowercase letters, digits, - and _ only", t.Name)
+	}
+	if b, err := crypto.Decode58Check(t.Wallet); err != nil || len(b) != 21 {
+		return fmt.Errorf("invalid wallet address %q", t.Wallet)
+	}
+	if t.Price < 0 || t.StorageMax < 0 {
+		return errors.New("price and storage max cannot be negative")
+	}
+	for _, r := range t.Renters {
+		if _, err := peer.IDB58Decode(r); err != nil {
+			return fmt.Errorf("invalid renter %q", r)
+		}
+	}
+	return nil
+}
+
+func (t *Tenant) serves(renter string) bool {
+	for _, r := range t.Renters {
+		if r == renter {
+			return true
+		}
+	}
+	return false
+}
+
+// PutTenant saves the tenant t of the node peerID. The renters of t cannot
+// be served by another tenant.
+func PutTenant(d ds.Datastore, peerID string, t *Tenant) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	ts, err := ListTenants(d, peerID)
+	if err != nil {
+		return err
+	}
+	for _, other := range ts {
+		if other.Name == t.Name {
+			continue
+		}
+		for _, r := range t.Renters {
+			if other.serves(r) {
+				return fmt.Errorf("renter %s already served by tenant %s", r, other.Name)
+			}
+		}
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(tenantKeyPrefix, peerID)+t.Name), b)
+}
+
+// GetTenant returns the tenant name of the node peerID.
+func GetTenant(d ds.Datastore, peerID, name string) (*Tenant, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(tenantKeyPrefix, peerID) + name))
+	if err == ds.ErrNotFound {
+		return nil, fmt.Errorf("no tenant %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := &Tenant{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, fmt.Errorf("invalid tenant %s: %s", name, err)
+	}
+	return t, nil
+}
+
+// ListTenants returns the tenants of the node peerID by name.
+func ListTenants(d ds.Datastore, peerID string) ([]*Tenant, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(tenantKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	ts := make([]*Tenant, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		t := &Tenant{}
+		if err := json.Unmarshal(r.Value, t); err != nil {
+			return nil, fmt.Errorf("invalid tenant %s: %s", r.Key, err)
+		}
+		ts = append(ts, t)
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Name < ts[j].Name })
+	return ts, nil
+}
+
+// RemoveTenant removes the tenant name of the node peerID, which must have
+// no contract running at now.
+func RemoveTenant(d ds.Datastore, peerID, name string, now time.Time) error {
+	if _, err := GetTenant(d, peerID, name); err != nil {
+		return err
+	}
+	cs, err := ListContracts(d, peerID)
+	if err != nil {
+		return err
+	}
+	for _, c := range cs {
+		if c.Tenant == name && c.Active(now) {
+			return fmt.Errorf("tenant %s has contracts running until %s", name, c.End.Format(time.RFC3339))
+		}
+	}
+	return d.Delete(ds.NewKey(fmt.Sprintf(tenantKeyPrefix, peerID) + name))
+}
+
+// Contract is a contract of the node assigned to a tenant.
+type Contract struct {
+	ContractID string
+	Tenant     string
+	Renter     string
+	// Size is the size of the shard in bytes.
+	Size int64
+	// Amount is the pay of the contract in µBTT.
+	Amount int64
+	Start  time.Time
+	End    time.Time
+}
+
+// Active reports whether c runs at now.
+func (c *Contract) Active(now time.Time) bool {
+	return now.Before(c.End)
+}
+
+// Earned returns the part of the pay of c earned at now, in proportion to
+// the time stored.
+func (c *Contract) Earned(now time.Time) int64 {
+	switch {
+	case !now.After(c.Start):
+		return 0
+	case !now.Before(c.End):
+		return c.Amount
+	}
+	return int64(float64(c.Amount) * float64(now.Sub(c.Start)) / float64(c.End.Sub(c.Start)))
+}
+
+// PutContract saves the contract c of the node peerID.
+func PutContract(d ds.Datastore, peerID string, c *Contract) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(contractKeyPrefix, peerID)+c.ContractID), b)
+}
+
+// DeleteContract drops the contract contractID of the node peerID, one
+// which never started.
+func DeleteContract(d ds.Datastore, peerID, contractID string) error {
+	return d.Delete(ds.NewKey(fmt.Sprintf(contractKeyPrefix, peerID) + contractID))
+}
+
+// ListContracts returns the contracts of the tenants of the node peerID,
+// the latest first.
+func ListContracts(d ds.Datastore, peerID string) ([]*Contract, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(contractKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	cs := make([]*Contract, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		c := &Contract{}
+		if err := json.Unmarshal(r.Value, c); err != nil {
+			return nil, fmt.Errorf("invalid tenant contract %s: %s", r.Key, err)
+		}
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Start.After(cs[j].Start) })
+	return cs, nil
+}
+
+// Payout is a transfer of the earnings of a tenant to its wallet.
+type Payout struct {
+	Tenant string
+	TxID   string
+	Wallet string
+	Amount int64
+	Time   time.Time
+}
+
+// PutPayout saves the payout p of the node peerID.
+func PutPayout(d ds.Datastore, peerID string, p *Payout) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(payoutKeyPrefix, peerID)+p.Tenant+"/"+p.TxID), b)
+}
+
+// ListPayouts returns the payouts of the tenant name of the node peerID,
+// of all tenants when empty, the latest first.
+func ListPayouts(d ds.Datastore, peerID, name string) ([]*Payout, error) {
+	prefix := fmt.Sprintf(payoutKeyPrefix, peerID)
+	if name != "" {
+		prefix += name + "/"
+	}
+	results, err := d.Query(query.Query{Prefix: prefix})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	ps := make([]*Payout, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		p := &Payout{}
+		if err := json.Unmarshal(r.Value, p); err != nil {
+			return nil, fmt.Errorf("invalid tenant payout %s: %s", r.Key, err)
+		}
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Time.After(ps[j].Time) })
+	return ps, nil
+}
+
+// Assign returns the tenant of ts to assign a contract of size bytes at
+// price from renter to, given the bytes used by the contracts running by
+// tenant: the tenant serving the renter if any, otherwise the tenant of
+// the highest price tier the price meets among those serving no renter in
+// particular, the cheapest one when it meets none. The tenant must have
+// room for size bytes in its pool.
+func Assign(ts []*Tenant, used map[string]int64, renter string, price, size int64) (*Tenant, error) {
+	var candidates []*Tenant
+	for _, t := range ts {
+		if t.serves(renter) {
+			candidates = []*Tenant{t}
+			break
+		}
+		if len(t.Renters) == 0 {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no tenant serves this renter")
+	}
+	var best *Tenant
+	full := true
+	for _, t := range candidates {
+		if t.StorageMax > 0 && used[t.Name]+size > t.StorageMax {
+			continue
+		}
+		full = false
+		switch {
+		case best == nil:
+			best = t
+		case t.Price <= price && (best.Price > price || t.Price > best.Price):
+			best = t
+		case best.Price > price && t.Price < best.Price:
+			best = t
+		}
+	}
+	if full {
+		return nil, errors.New("the capacity pools of the tenants are full")
+	}
+	return best, nil
+}
+
+// Used returns the bytes used by the contracts of cs running at now, by
+// tenant.
+func Used(cs []*Contract, now time.Time) map[string]int64 {
+	used := map[string]int64{}
+	for _, c := range cs {
+		if c.Active(now) {
+			used[c.Tenant] += c.Size
+		}
+	}
+	return used
+}
+
+// AssignContract returns the tenant of the node peerID to assign a contract
+// of size bytes at price from renter to at now, nil when the node has no
+// tenants.
+func AssignContract(d ds.Datastore, peerID, renter string, price, size int64, now time.Time) (*Tenant, error) {
+	ts, err := ListTenants(d, peerID)
+	if err != nil || len(ts) == 0 {
+		return nil, err
+	}
+	cs, err := ListContracts(d, peerID)
+	if err != nil {
+		return nil, err
+	}
+	return Assign(ts, Used(cs, now), renter, price, size)
+}
+
+// Earnings are the earnings of a tenant.
+type Earnings struct {
+	Tenant string
+	Wallet string
+	// Contracts counts the contracts of the tenant, Active those running.
+	Contracts int
+	Active    int
+	// Used is the size of the shards of the contracts running, out of the
+	// StorageMax of the tenant.
+	Used       int64
+	StorageMax int64 `json:",omitempty"`
+	// Contracted is the pay of the contracts, Earned the part earned so
+	// far, PaidOut the part transferred to the wallet and Due the part
+	// earned left to pay out, in µBTT.
+	Contracted int64
+	Earned     int64
+	PaidOut    int64
+	Due        int64
+}
+
+// Report returns the earnings at now of the tenants ts, given their
+// contracts cs and their payouts ps, by tenant.
+func Report(ts []*Tenant, cs []*Contract, ps []*Payout, now time.Time) []*Earnings {
+	byName := map[string]*Earnings{}
+	es := make([]*Earnings, 0, len(ts))
+	for _, t := range ts {
+		e := &Earnings{Tenant: t.Name, Wallet: t.Wallet, StorageMax: t.StorageMax}
+		byName[t.Name] = e
+		es = append(es, e)
+	}
+	for _, c := range cs {
+		e, ok := byName[c.Tenant]
+		if !ok {
+			continue
+		}
+		e.Contracts++
+		if c.Active(now) {
+			e.Active++
+			e.Used += c.Size
+		}
+		e.Contracted += c.Amount
+		e.Earned += c.Earned(now)
+	}
+	for _, p := range ps {
+		if e, ok := byName[p.Tenant]; ok {
+			e.PaidOut += p.Amount
+		}
+	}
+	for _, e := range es {
+		if e.Due = e.Earned - e.PaidOut; e.Due < 0 {
+			e.Due = 0
+		}
+	}
+	return es
+}
+
+// TenantEarnings returns the earnings at now of the tenant name of the
+// node peerID.
+func TenantEarnings(d ds.Datastore, peerID, name string, now time.Time) (*Earnings, error) {
+	t, err := GetTenant(d, peerID, name)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := ListContracts(d, peerID)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := ListPayouts(d, peerID, name)
+	if err != nil {
+		return nil, err
+	}
+	return Report([]*Tenant{t}, cs, ps, now)[0], nil
+}
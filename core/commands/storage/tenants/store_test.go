@@ -0,0 +1,134 @@
+package tenants
+
+import (
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	self   = "16Uiu2HAmHostSelf"
+	wallet = "TTACjzSeJ9jDHaxRxnho1n3mVK9JASNyr9"
+)
+
+func newPeer(t *testing.T) string {
+	k, _, err := ic.GenerateKeyPair(ic.Secp256k1, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id.Pretty()
+}
+
+func TestValidate(t *testing.T) {
+	for _, tn := range []*Tenant{
+		{Name: "Premium", Wallet: wallet},
+		{Name: "premium", Wallet: "TTACjzSeJ9jDHaxRxnho1n3mVK9JASNyr8"},
+		{Name: "premium", Wallet: wallet, Price: -1},
+		{Name: "premium", Wallet: wallet, Renters: []string{"renter"}},
+	} {
+		if err := tn.Validate(); err == nil {
+			t.Errorf("expected %+v refused", tn)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	renter, partnerRenter := newPeer(t), newPeer(t)
+	ts := []*Tenant{
+		{Name: "basic", Price: 100},
+		{Name: "partner", Price: 10, Renters: []string{partnerRenter}},
+		{Name: "premium", Price: 500, StorageMax: 1000},
+	}
+	for _, c := range []struct {
+		renter string
+		price  int64
+		size   int64
+		used   map[string]int64
+		want   string
+	}{
+		{renter, 600, 100, nil, "premium"},
+		{renter, 300, 100, nil, "basic"},
+		// the cheapest tier when none is met, for the price check
+		{renter, 50, 100, nil, "basic"},
+		{renter, 600, 100, map[string]int64{"premium": 950}, "basic"},
+		{partnerRenter, 600, 100, nil, "partner"},
+	} {
+		tn, err := Assign(ts, c.used, c.renter, c.price, c.size)
+		if err != nil || tn.Name != c.want {
+			t.Errorf("price %d used %v: expected %s, got %+v %v", c.price, c.used, c.want, tn, err)
+		}
+	}
+	if _, err := Assign(ts, map[string]int64{"partner": 1}, partnerRenter, 600, 100); err != nil {
+		t.Fatal(err)
+	}
+	full := []*Tenant{{Name: "partner", StorageMax: 100, Renters: []string{partnerRenter}}}
+	if _, err := Assign(full, map[string]int64{"partner": 50}, partnerRenter, 600, 100); err == nil {
+		t.Fatal("expected a full pool refused")
+	}
+	if _, err := Assign(full, nil, renter, 600, 100); err == nil {
+		t.Fatal("expected a renter no tenant serves refused")
+	}
+}
+
+func TestReport(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	renter := newPeer(t)
+	if err := PutTenant(d, self, &Tenant{Name: "basic", Wallet: wallet, StorageMax: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutTenant(d, self, &Tenant{Name: "partner", Wallet: wallet, Renters: []string{renter}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutTenant(d, self, &Tenant{Name: "other", Wallet: wallet, Renters: []string{renter}}); err == nil {
+		t.Fatal("expected a renter served by two tenants refused")
+	}
+
+	tn, err := AssignContract(d, self, newPeer(t), 100, 600, now)
+	if err != nil || tn.Name != "basic" {
+		t.Fatalf("expected the basic tenant, got %+v %v", tn, err)
+	}
+	for _, c := range []*Contract{
+		{ContractID: "c1", Tenant: "basic", Size: 600, Amount: 1000, Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+		{ContractID: "c2", Tenant: "basic", Size: 600, Amount: 300, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+	} {
+		if err := PutContract(d, self, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// c1 fills the pool
+	if _, err := AssignContract(d, self, newPeer(t), 100, 600, now); err == nil {
+		t.Fatal("expected the full pool refused")
+	}
+	if err := RemoveTenant(d, self, "basic", now); err == nil {
+		t.Fatal("expected a tenant with contracts running kept")
+	}
+	if err := PutPayout(d, self, &Payout{Tenant: "basic", TxID: "tx", Amount: 200, Time: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := TenantEarnings(d, self, "basic", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Earnings{Tenant: "basic", Wallet: wallet, Contracts: 2, Active: 1, Used: 600, StorageMax: 1000,
+		Contracted: 1300, Earned: 800, PaidOut: 200, Due: 600}
+	if *e != want {
+		t.Fatalf("expected %+v, got %+v", want, *e)
+	}
+
+	if err := RemoveTenant(d, self, "basic", now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if ts, err := ListTenants(d, self); err != nil || len(ts) != 1 {
+		t.Fatalf("expected one tenant left, got %v %v", ts, err)
+	}
+}
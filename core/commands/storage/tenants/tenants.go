@@ -0,0 +1,233 @@
+// Package tenants has a host run several logical hosts, its tenants, on one
+// node: each with its wallet the earnings of its contracts are paid out to,
+// its price tier and its capacity pool. The host assigns each contract it
+// accepts to a tenant, and reports the earnings of each.
+package tenants
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	walletOptionName     = "wallet"
+	priceOptionName      = "price"
+	storageMaxOptionName = "storage-max"
+	rentersOptionName    = "renters"
+)
+
+var StorageHostTenantsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Run several logical hosts on this node.",
+		ShortDescription: `
+Tenants split this host into logical hosts, e.g. price tiers or business
+entities, each with its wallet, price and capacity pool:
+
+    $ btfs storage host tenants add premium --wallet <address> --price 500 --storage-max 2TB
+    $ btfs storage host tenants add partner --wallet <address> --price 100 --renters <peer-id>
+
+Once a tenant is added, the host assigns each contract it accepts to one: the
+tenant serving the renter if any, otherwise the tenant of the highest price
+the renter offers among those serving no renter in particular. A contract is
+refused when the price of its tenant is not met, or its pool has no room
+left for the shard. Without tenants, the host accepts contracts as usual.
+
+The earnings of the contracts of a tenant accrue over their term, see
+'btfs storage host tenants report', and are paid out to its wallet from the
+BTT wallet of the node with 'btfs storage host tenants payout'.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":     storageHostTenantsLsCmd,
+		"add":    storageHostTenantsAddCmd,
+		"rm":     storageHostTenantsRmCmd,
+		"report": storageHostTenantsReportCmd,
+	},
+}
+
+var storageHostTenantsLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the tenants of this host.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		ts, err := ListTenants(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, ts)
+	},
+	Type: []*Tenant{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ts []*Tenant) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tWALLET\tPRICE\tSTORAGE MAX\tRENTERS")
+			for _, t := range ts {
+				price, max, renters := "ask", "-", "any"
+				if t.Price > 0 {
+					price = fmt.Sprint(t.Price)
+				}
+				if t.StorageMax > 0 {
+					max = humanize.Bytes(uint64(t.StorageMax))
+				}
+				if len(t.Renters) > 0 {
+					renters = strings.Join(t.Renters, ",")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", t.Name, t.Wallet, price, max, renters)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var storageHostTenantsAddCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Add or update a tenant of this host.",
+		ShortDescription: `
+Adds the tenant <name>, or updates it with the options given. --price is the
+lowest price of the tenant in µBTT per GiB per day, the price ask of the host
+when 0. --storage-max bounds the size of the shards of its contracts running,
+e.g. 500GB. --renters lists the renters only this tenant serves.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the tenant."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(walletOptionName, "w", "TRON address the earnings of the tenant are paid out to."),
+		cmds.Int64Option(priceOptionName, "Lowest price of the tenant in µBTT per GiB per day."),
+		cmds.StringOption(storageMaxOptionName, "Capacity pool of the tenant, e.g. 500GB. 0 for unbounded."),
+		cmds.StringOption(rentersOptionName, "Comma separated peer IDs of the renters only this tenant serves. Empty for any."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if err := helper.HostEnabled(env); err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		name := req.Arguments[0]
+		t, err := GetTenant(d, self, name)
+		if err != nil {
+			t = &Tenant{Name: name, Created: time.Now()}
+		}
+		if wallet, ok := req.Options[walletOptionName].(string); ok {
+			t.Wallet = wallet
+		}
+		if price, ok := req.Options[priceOptionName].(int64); ok {
+			t.Price = price
+		}
+		if s, ok := req.Options[storageMaxOptionName].(string); ok {
+			max, err := humanize.ParseBytes(s)
+			if err != nil {
+				return fmt.Errorf("invalid storage max %q: %s", s, err)
+			}
+			t.StorageMax = int64(max)
+		}
+		if s, ok := req.Options[rentersOptionName].(string); ok {
+			t.Renters = nil
+			for _, r := range strings.Split(s, ",") {
+				if r = strings.TrimSpace(r); r != "" {
+					t.Renters = append(t.Renters, r)
+				}
+			}
+		}
+		if err := PutTenant(d, self, t); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, t)
+	},
+	Type: Tenant{},
+}
+
+var storageHostTenantsRmCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove a tenant of this host.",
+		ShortDescription: `
+Removes the tenant <name>, which must have no contract running. Its earnings
+not paid out yet are still reported.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the tenant."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		return RemoveTenant(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0], time.Now())
+	},
+}
+
+var storageHostTenantsReportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Report the earnings of the tenants of this host.",
+		ShortDescription: `
+Reports, for each tenant or the tenants given, its contracts, the capacity of
+its pool used, and its earnings in µBTT: the pay of its contracts, the part
+earned so far over their term, the part paid out to its wallet and the part
+due.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", false, true, "Name of the tenant."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		ts, err := ListTenants(d, self)
+		if err != nil {
+			return err
+		}
+		if len(req.Arguments) > 0 {
+			ts = ts[:0]
+			for _, name := range req.Arguments {
+				t, err := GetTenant(d, self, name)
+				if err != nil {
+					return err
+				}
+				ts = append(ts, t)
+			}
+		}
+		cs, err := ListContracts(d, self)
+		if err != nil {
+			return err
+		}
+		ps, err := ListPayouts(d, self, "")
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, Report(ts, cs, ps, time.Now()))
+	},
+	Type: []*Earnings{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, es []*Earnings) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "TENANT\tCONTRACTS\tUSED\tCONTRACTED\tEARNED\tPAID OUT\tDUE")
+			for _, e := range es {
+				used := humanize.Bytes(uint64(e.Used))
+				if e.StorageMax > 0 {
+					used += "/" + humanize.Bytes(uint64(e.StorageMax))
+				}
+				fmt.Fprintf(tw, "%s\t%d/%d\t%s\t%d\t%d\t%d\t%d\n", e.Tenant, e.Active, e.Contracts, used,
+					e.Contracted, e.Earned, e.PaidOut, e.Due)
+			}
+			return tw.Flush()
+		}),
+	},
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	guardclient "github.com/TRON-US/go-btfs/core/clients/guard"
 	"github.com/TRON-US/go-btfs/core/commands/storage/challenge"
 	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
@@ -12,9 +13,7 @@ import (
 
 	config "github.com/TRON-US/go-btfs-config"
 	cc "github.com/tron-us/go-btfs-common/config"
-	"github.com/tron-us/go-btfs-common/crypto"
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
-	cgrpc "github.com/tron-us/go-btfs-common/utils/grpc"
 	"github.com/tron-us/protobuf/proto"
 
 	cidlib "github.com/ipfs/go-cid"
@@ -93,8 +92,12 @@ func PrepCustomFileChallengeQuestions(rss *sessions.RenterSession, fileHash cidl
 	} else {
 		go func() {
 			if bytes, err := func() ([]byte, error) {
+				signer, err := uh.GetSigner(rss.CtxParams)
+				if err != nil {
+					return nil, err
+				}
 				for _, sq := range fileQuestions.ShardQuestions {
-					sig, err := crypto.Sign(rss.CtxParams.N.PrivateKey, sq)
+					sig, err := signer.Sign(uh.SignKindQuestions, sq)
 					if err != nil {
 						return nil, err
 					}
@@ -193,16 +196,12 @@ func SendChallengeQuestions(ctx context.Context, cfg *config.Config, fileHash ci
 
 // sendChallengeQuestions opens a grpc connection, sends questions, and closes (short) connection
 func sendChallengeQuestions(ctx context.Context, cfg *config.Config, req *guardpb.FileChallengeQuestions) error {
-	cb := cgrpc.GuardClient(cfg.Services.GuardDomain)
-	cb.Timeout(GuardTimeout)
-	return cb.WithContext(ctx, func(ctx context.Context, client guardpb.GuardServiceClient) error {
-		res, err := client.SendQuestions(ctx, req)
-		if err != nil {
-			return err
-		}
-		if res.Code != guardpb.ResponseCode_SUCCESS {
-			return fmt.Errorf("failed to send questions: %v", res.Message)
-		}
-		return nil
-	})
+	res, err := guardclient.NewWithTimeout(cfg.Services.GuardDomain, GuardTimeout).SendQuestions(ctx, req)
+	if err != nil {
+		return err
+	}
+	if res.Code != guardpb.ResponseCode_SUCCESS {
+		return fmt.Errorf("failed to send questions: %v", res.Message)
+	}
+	return nil
 }
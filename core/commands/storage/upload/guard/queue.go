@@ -0,0 +1,61 @@
+package guard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	guardclient "github.com/TRON-US/go-btfs/core/clients/guard"
+	"github.com/TRON-US/go-btfs/core/guardqueue"
+
+	config "github.com/TRON-US/go-btfs-config"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+)
+
+// SubmitFileStatus opens a grpc connection, submits the file store status
+// of an upload, and closes (short) connection
+func SubmitFileStatus(ctx context.Context, cfg *config.Config, fileStatus *guardpb.FileStoreStatus) error {
+	res, err := guardclient.NewWithTimeout(cfg.Services.GuardDomain, GuardTimeout).
+		SubmitFileStoreMeta(ctx, fileStatus)
+	if err != nil {
+		return err
+	}
+	if res.Code != guardpb.ResponseCode_SUCCESS {
+		return fmt.Errorf("failed to execute submit file status to gurad: %v", res.Message)
+	}
+	return nil
+}
+
+// SubmitQueued persists fileStatus in the guard queue of the node before
+// submitting it, so that a failed or interrupted submission is retried, see
+// RetryQueued.
+func SubmitQueued(ctx context.Context, n *core.IpfsNode, cfg *config.Config, fileStatus *guardpb.FileStoreStatus) error {
+	d := n.Repo.Datastore()
+	self := n.Identity.Pretty()
+	e, err := guardqueue.Enqueue(d, self, fileStatus, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := SubmitFileStatus(ctx, cfg, fileStatus); err != nil {
+		if qerr := guardqueue.Failed(d, self, e, err, time.Now()); qerr != nil {
+			return fmt.Errorf("%s, and cannot queue it for retry: %s", err, qerr)
+		}
+		return fmt.Errorf("%s, queued for retry", err)
+	}
+	return guardqueue.Done(d, self, fileStatus.FileHash, time.Now())
+}
+
+// RetryQueued submits the file store statuses of the guard queue of the node
+// whose retry is due, or all of them when force is set. When fileHashes are
+// given, only their statuses are submitted.
+func RetryQueued(ctx context.Context, n *core.IpfsNode, force bool, fileHashes ...string) (*guardqueue.Result, error) {
+	cfg, err := n.Repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	return guardqueue.Retry(ctx, n.Repo.Datastore(), n.Identity.Pretty(),
+		func(ctx context.Context, s *guardpb.FileStoreStatus) error {
+			return SubmitFileStatus(ctx, cfg, s)
+		}, time.Now(), force, fileHashes...)
+}
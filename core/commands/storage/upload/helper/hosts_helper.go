@@ -8,8 +8,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/TRON-US/go-btfs/core/capacity"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/core/hostgossip"
+	"github.com/TRON-US/go-btfs/core/hub"
+	"github.com/TRON-US/go-btfs/core/ingest"
 
 	iface "github.com/TRON-US/interface-go-btfs-core"
 	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
@@ -24,17 +28,50 @@ const (
 )
 
 type IHostsProvider interface {
-	NextValidHost(price int64) (string, error)
+	// NextValidHost returns a host storing shards of shardSize bytes at
+	// price.
+	NextValidHost(price int64, shardSize int64) (string, error)
+}
+
+// Selection returns why hp chose host, nil when unknown.
+func Selection(hp IHostsProvider, host string) *hub.Selection {
+	var s *hub.Selection
+	switch p := hp.(type) {
+	case *HostsProvider:
+		p.Lock()
+		s = p.selections[host]
+		p.Unlock()
+	case *CustomizedHostsProvider:
+		p.Lock()
+		s = p.selections[host]
+		p.Unlock()
+	}
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+// FilterHosts only lets hp choose the hosts f accepts, skipping the backup
+// hosts, whose region and score are unknown.
+func FilterHosts(hp IHostsProvider, f func(*hubpb.Host) bool) {
+	if p, ok := hp.(*HostsProvider); ok {
+		p.Lock()
+		p.filter = f
+		p.Unlock()
+	}
 }
 
 type CustomizedHostsProvider struct {
-	cp      *ContextParams
-	current int
-	hosts   []string
+	cp         *ContextParams
+	current    int
+	hosts      []string
+	selections map[string]*hub.Selection
 	sync.Mutex
 }
 
-func (p *CustomizedHostsProvider) NextValidHost(price int64) (string, error) {
+func (p *CustomizedHostsProvider) NextValidHost(price int64, shardSize int64) (string, error) {
 	for true {
 		if index, err := p.AddIndex(); err == nil {
 			id, err := peer.IDB58Decode(p.hosts[index])
@@ -42,9 +79,24 @@ func (p *CustomizedHostsProvider) NextValidHost(price int64) (string, error) {
 				continue
 			}
 			if err := p.cp.Api.Swarm().Connect(p.cp.Ctx, peer.AddrInfo{ID: id}); err != nil {
+				hostgossip.Observe(p.hosts[index], false)
 				p.hosts = append(p.hosts, p.hosts[index])
 				continue
 			}
+			hostgossip.Observe(p.hosts[index], true)
+			if !ShardSizeCompatible(p.cp.Ctx, p.cp.N, p.cp.Api, id, shardSize) {
+				log.Errorf("host %s does not store shards of %d bytes", p.hosts[index], shardSize)
+				continue
+			}
+			p.Lock()
+			p.selections[p.hosts[index]] = &hub.Selection{
+				Host:   p.hosts[index],
+				Time:   time.Now(),
+				Reason: hub.ReasonRenter,
+				Rank:   index + 1,
+				Of:     len(p.hosts),
+			}
+			p.Unlock()
 			return p.hosts[index], nil
 		} else {
 			break
@@ -55,9 +107,10 @@ func (p *CustomizedHostsProvider) NextValidHost(price int64) (string, error) {
 
 func GetCustomizedHostsProvider(cp *ContextParams, hosts []string) IHostsProvider {
 	return &CustomizedHostsProvider{
-		cp:      cp,
-		current: -1,
-		hosts:   hosts,
+		cp:         cp,
+		current:    -1,
+		hosts:      hosts,
+		selections: map[string]*hub.Selection{},
 	}
 }
 
@@ -74,16 +127,23 @@ func (p *CustomizedHostsProvider) AddIndex() (int, error) {
 type HostsProvider struct {
 	cp *ContextParams
 	sync.Mutex
-	mode            string
-	current         int
-	hosts           []*hubpb.Host
-	blacklist       []string
-	backupList      []string
-	backupListLock  sync.Mutex
-	ctx             context.Context
-	cancel          context.CancelFunc
-	times           int
-	needHigherPrice bool
+	mode              string
+	current           int
+	hosts             []*hubpb.Host
+	blacklist         []string
+	backupList        []string
+	backupListLock    sync.Mutex
+	ctx               context.Context
+	cancel            context.CancelFunc
+	times             int
+	needHigherPrice   bool
+	needSmallerShards bool
+	score             *hub.ScoreConfig
+	// ranked is the number of hosts ranked, the ones after are retried
+	ranked     int
+	selections map[string]*hub.Selection
+	// filter, when set, accepts the hosts chosen
+	filter func(*hubpb.Host) bool
 }
 
 func GetHostsProvider(cp *ContextParams, blacklist []string) IHostsProvider {
@@ -96,6 +156,7 @@ func GetHostsProvider(cp *ContextParams, blacklist []string) IHostsProvider {
 		ctx:             ctx,
 		cancel:          cancel,
 		needHigherPrice: false,
+		selections:      map[string]*hub.Selection{},
 	}
 	p.init()
 	return p
@@ -106,6 +167,11 @@ func (p *HostsProvider) init() (err error) {
 	if err != nil {
 		return err
 	}
+	if p.score, err = hub.LoadScoreConfig(p.cp.N.Repo); err != nil {
+		log.Error(err)
+	}
+	hub.Rank(p.hosts, p.score)
+	p.ranked = len(p.hosts)
 	peers, err := p.cp.Api.Swarm().Peers(p.cp.Ctx)
 	if err != nil {
 		log.Debug(err)
@@ -157,7 +223,7 @@ func (p *HostsProvider) AddIndex() (int, error) {
 	return p.current, nil
 }
 
-func (p *HostsProvider) PickFromBackupHosts() (string, error) {
+func (p *HostsProvider) PickFromBackupHosts(shardSize int64) (string, error) {
 	for true {
 		host, err := func() (string, error) {
 			p.backupListLock.Lock()
@@ -176,12 +242,16 @@ func (p *HostsProvider) PickFromBackupHosts() (string, error) {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
 		id, err := peer.IDB58Decode(host)
-		if err != nil {
+		if err != nil || remote.Incompatible(id) != nil ||
+			capacity.Failed(p.cp.N.Repo.Datastore(), p.cp.N.Identity.Pretty(), host, time.Now()) ||
+			ingest.Busy(host, time.Now()) || hostgossip.Down(host) {
 			continue
 		}
 		if err := p.cp.Api.Swarm().Connect(ctx, peer.AddrInfo{ID: id}); err != nil {
+			hostgossip.Observe(host, false)
 			continue
 		}
+		hostgossip.Observe(host, true)
 		nsBytes, err := remote.P2PCall(ctx, p.cp.N, p.cp.Api, id, "/storage/info")
 		if err != nil {
 			continue
@@ -197,15 +267,18 @@ func (p *HostsProvider) PickFromBackupHosts() (string, error) {
 				b = true
 			}
 		}
-		if !b {
+		if !b || !ShardSizeCompatible(ctx, p.cp.N, p.cp.Api, id, shardSize) {
 			continue
 		}
+		p.Lock()
+		p.selections[host] = &hub.Selection{Host: host, Time: time.Now(), Reason: hub.ReasonBackup}
+		p.Unlock()
 		return host, nil
 	}
 	return "", errors.New("shouldn't reach here")
 }
 
-func (p *HostsProvider) NextValidHost(price int64) (string, error) {
+func (p *HostsProvider) NextValidHost(price int64, shardSize int64) (string, error) {
 	endOfBackup := false
 LOOP:
 	for true {
@@ -217,6 +290,7 @@ LOOP:
 		}
 		p.Lock()
 		times := p.times
+		filter := p.filter
 		p.Unlock()
 		if index, err := p.AddIndex(); times < 2000 && err == nil {
 			host := p.hosts[index]
@@ -225,22 +299,45 @@ LOOP:
 					continue LOOP
 				}
 			}
+			if filter != nil && !filter(host) {
+				continue
+			}
+			if capacity.Failed(p.cp.N.Repo.Datastore(), p.cp.N.Identity.Pretty(), host.NodeId, time.Now()) ||
+				ingest.Busy(host.NodeId, time.Now()) {
+				continue
+			}
+			// Skip the hosts found down since the hub synced, by the node or
+			// its peers.
+			if hostgossip.Down(host.NodeId) {
+				continue
+			}
 			id, err := peer.IDB58Decode(host.NodeId)
 			if err != nil || int64(host.StoragePriceAsk) > price {
 				p.needHigherPrice = true
 				continue
 			}
+			// Skip the hosts known to speak none of our protocol versions.
+			if remote.Incompatible(id) != nil {
+				continue
+			}
 			ctx, _ := context.WithTimeout(p.ctx, 3*time.Second)
 			if err := p.cp.Api.Swarm().Connect(ctx, peer.AddrInfo{ID: id}); err != nil {
+				hostgossip.Observe(host.NodeId, false)
 				p.Lock()
 				p.hosts = append(p.hosts, host)
 				p.times++
 				p.Unlock()
 				continue
 			}
+			hostgossip.Observe(host.NodeId, true)
+			if !ShardSizeCompatible(p.ctx, p.cp.N, p.cp.Api, id, shardSize) {
+				p.needSmallerShards = true
+				continue
+			}
+			p.selected(host, index)
 			return host.NodeId, nil
-		} else if !endOfBackup {
-			if h, err := p.PickFromBackupHosts(); err == nil {
+		} else if !endOfBackup && filter == nil {
+			if h, err := p.PickFromBackupHosts(shardSize); err == nil {
 				return h, nil
 			} else {
 				endOfBackup = true
@@ -254,10 +351,36 @@ LOOP:
 	return "", errors.New(p.getMsg())
 }
 
+// selected records the selection of host, at index of the hosts.
+func (p *HostsProvider) selected(host *hubpb.Host, index int) {
+	e := hub.Explain(host, p.score)
+	s := &hub.Selection{
+		Host:        host.NodeId,
+		Time:        time.Now(),
+		Reason:      hub.ReasonScore,
+		Rank:        index + 1,
+		Of:          p.ranked,
+		Explanation: e,
+	}
+	// the hosts failing to connect are retried after the others
+	if s.Rank > p.ranked {
+		s.Rank = 0
+	}
+	for _, f := range e.Drivers(hub.NumDrivers) {
+		s.Drivers = append(s.Drivers, f.Name)
+	}
+	p.Lock()
+	p.selections[host.NodeId] = s
+	p.Unlock()
+}
+
 func (p *HostsProvider) getMsg() string {
 	msg := failMsg
 	if p.needHigherPrice {
 		msg += " or raise price"
 	}
+	if p.needSmallerShards {
+		msg += " or lower the shard size"
+	}
 	return msg
 }
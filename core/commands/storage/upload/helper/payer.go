@@ -0,0 +1,185 @@
+package helper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+
+	"github.com/tron-us/go-btfs-common/crypto"
+	"github.com/tron-us/protobuf/proto"
+
+	ds "github.com/ipfs/go-datastore"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Kinds of the messages the payer of the storage contracts signs.
+const (
+	SignKindBalance        = "balance"
+	SignKindChannelCommit  = "channel-commit"
+	SignKindEscrowContract = "escrow-contract"
+	SignKindGuardContract  = "guard-contract"
+	SignKindChannelState   = "channel-state"
+	SignKindPayin          = "payin"
+	SignKindFileMeta       = "file-meta"
+	SignKindQuestions      = "questions"
+	SignKindWaitUpload     = "wait-upload"
+)
+
+const delegationKey = "/btfs/%s/payer/delegation"
+
+// Signer signs as the payer of the storage contracts of a renter.
+type Signer interface {
+	// ID is the peer ID of the payer, the renter of the contracts.
+	ID() peer.ID
+	PubKey() ic.PubKey
+	// Sign signs msg, a message of the given kind.
+	Sign(kind string, msg proto.Message) ([]byte, error)
+}
+
+type localSigner struct {
+	id  peer.ID
+	key ic.PrivKey
+}
+
+func (s *localSigner) ID() peer.ID       { return s.id }
+func (s *localSigner) PubKey() ic.PubKey { return s.key.GetPublic() }
+
+func (s *localSigner) Sign(kind string, msg proto.Message) ([]byte, error) {
+	return crypto.Sign(s.key, msg)
+}
+
+// remoteSigner has the payer node sign, under the delegation it gave.
+type remoteSigner struct {
+	cp  *ContextParams
+	id  peer.ID
+	pub ic.PubKey
+}
+
+func (s *remoteSigner) ID() peer.ID       { return s.id }
+func (s *remoteSigner) PubKey() ic.PubKey { return s.pub }
+
+func (s *remoteSigner) Sign(kind string, msg proto.Message) ([]byte, error) {
+	raw, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	b, err := remote.P2PCall(s.cp.Ctx, s.cp.N, s.cp.Api, s.id, "/storage/payer/sign", kind, raw)
+	if err != nil {
+		return nil, fmt.Errorf("payer %s failed to sign the %s: %s", s.id.Pretty(), kind, err)
+	}
+	out := &SignOutput{}
+	if err := json.Unmarshal(b, out); err != nil {
+		return nil, err
+	}
+	return out.Signature, nil
+}
+
+// SignOutput is the signature of a message by the payer.
+type SignOutput struct {
+	Signature []byte
+}
+
+// GetSigner returns the signer of the storage contracts of the node of cp:
+// the payer of its delegation, the node itself without.
+func GetSigner(cp *ContextParams) (Signer, error) {
+	d, err := GetDelegation(cp.N.Repo.Datastore(), cp.N.Identity.Pretty())
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return &localSigner{id: cp.N.Identity, key: cp.N.PrivateKey}, nil
+	}
+	id, err := peer.IDB58Decode(d.Payer)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := id.ExtractPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &remoteSigner{cp: cp, id: id, pub: pub}, nil
+}
+
+// Delegation lets the node Delegate have its storage contracts paid by the
+// wallet of the node Payer, up to MaxSpend µBTT until Expires.
+type Delegation struct {
+	Payer     string
+	Delegate  string
+	MaxSpend  int64
+	Expires   time.Time
+	Signature []byte `json:",omitempty"`
+}
+
+func (d *Delegation) signedBytes() ([]byte, error) {
+	u := *d
+	u.Signature = nil
+	return json.Marshal(&u)
+}
+
+// Sign signs d with key, the key of its payer.
+func (d *Delegation) Sign(key ic.PrivKey) error {
+	b, err := d.signedBytes()
+	if err != nil {
+		return err
+	}
+	d.Signature, err = key.Sign(b)
+	return err
+}
+
+// Verify checks that d was signed by its payer and is not expired at now.
+func (d *Delegation) Verify(now time.Time) error {
+	id, err := peer.IDB58Decode(d.Payer)
+	if err != nil {
+		return fmt.Errorf("invalid payer: %s", err)
+	}
+	pub, err := id.ExtractPublicKey()
+	if err != nil {
+		return err
+	}
+	b, err := d.signedBytes()
+	if err != nil {
+		return err
+	}
+	if ok, err := pub.Verify(b, d.Signature); err != nil || !ok {
+		return errors.New("delegation not signed by its payer")
+	}
+	if now.After(d.Expires) {
+		return fmt.Errorf("delegation expired on %s", d.Expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// GetDelegation returns the delegation the node peerID pays its contracts
+// with, nil when it pays them itself.
+func GetDelegation(d ds.Datastore, peerID string) (*Delegation, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(delegationKey, peerID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dl := &Delegation{}
+	if err := json.Unmarshal(b, dl); err != nil {
+		return nil, fmt.Errorf("invalid delegation: %s", err)
+	}
+	return dl, nil
+}
+
+// PutDelegation has the node peerID pay its contracts with dl, or itself
+// when nil.
+func PutDelegation(d ds.Datastore, peerID string, dl *Delegation) error {
+	k := ds.NewKey(fmt.Sprintf(delegationKey, peerID))
+	if dl == nil {
+		return d.Delete(k)
+	}
+	b, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	return d.Put(k, b)
+}
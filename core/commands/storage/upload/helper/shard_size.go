@@ -0,0 +1,178 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	chunker "github.com/TRON-US/go-btfs-chunker"
+	files "github.com/TRON-US/go-btfs-files"
+	iface "github.com/TRON-US/interface-go-btfs-core"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+
+	humanize "github.com/dustin/go-humanize"
+	cidlib "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ShardSizeConfigKey is the config section of the shard sizes a host
+// accepts.
+const ShardSizeConfigKey = "ShardSize"
+
+// Bounds of the shard size a renter can choose.
+const (
+	MinShardSize = 1 << 20
+	MaxShardSize = 4 << 30
+	// maxShards is the most shards a reed-solomon encoding has.
+	maxShards = 256
+)
+
+// ShardSizeConfig bounds the shards a host stores.
+type ShardSizeConfig struct {
+	// HostMax is the largest shard this host stores, e.g. "256MB",
+	// advertised to renters. Any size when empty.
+	HostMax string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ShardSizeConfigKey, ShardSizeConfig{})
+}
+
+// HostMaxShardSize returns the largest shard the host of r stores, zero
+// for any size.
+func HostMaxShardSize(r repo.Repo) (int64, error) {
+	c := &ShardSizeConfig{}
+	if _, err := repo.GetConfigSection(r, ShardSizeConfigKey, c); err != nil {
+		return 0, fmt.Errorf("invalid %s config: %s", ShardSizeConfigKey, err)
+	}
+	if c.HostMax == "" {
+		return 0, nil
+	}
+	max, err := humanize.ParseBytes(c.HostMax)
+	if err != nil || max == 0 {
+		return 0, fmt.Errorf("invalid %s config: invalid HostMax %q", ShardSizeConfigKey, c.HostMax)
+	}
+	return int64(max), nil
+}
+
+// ShardLimits are the shard sizes a host advertises to renters.
+type ShardLimits struct {
+	// MaxShardSize is the largest shard the host stores in bytes, zero for
+	// any size.
+	MaxShardSize int64
+}
+
+const shardLimitsTimeout = 5 * time.Second
+
+// hostLimits caches the ShardLimits of the hosts by peer ID.
+var hostLimits sync.Map
+
+// ShardSizeCompatible reports whether the host pid stores shards of
+// shardSize bytes, asking it for its limits the first time. Hosts which do
+// not advertise limits are taken to store any size, their init rejecting
+// the shards they do not.
+func ShardSizeCompatible(ctx context.Context, n *core.IpfsNode, api iface.CoreAPI, pid peer.ID, shardSize int64) bool {
+	if l, ok := hostLimits.Load(pid); ok {
+		return l.(*ShardLimits).Accepts(shardSize)
+	}
+	ctx, cancel := context.WithTimeout(ctx, shardLimitsTimeout)
+	defer cancel()
+	b, err := remote.P2PCall(ctx, n, api, pid, "/storage/upload/limits")
+	if err != nil {
+		log.Debugf("no shard limits from host %s: %s", pid.Pretty(), err)
+		return true
+	}
+	l := &ShardLimits{}
+	if err := json.Unmarshal(b, l); err != nil {
+		log.Debugf("invalid shard limits from host %s: %s", pid.Pretty(), err)
+		return true
+	}
+	hostLimits.Store(pid, l)
+	return l.Accepts(shardSize)
+}
+
+// Accepts reports whether shards of shardSize bytes are within l.
+func (l *ShardLimits) Accepts(shardSize int64) bool {
+	return l.MaxShardSize == 0 || shardSize <= l.MaxShardSize
+}
+
+// ShardScheme returns the data and parity shards encoding a file of
+// fileSize bytes in shards of about shardSize bytes, keeping the share of
+// parity shards of an encoding in numData and numParity shards.
+func ShardScheme(fileSize, shardSize int64, numData, numParity uint64) (uint64, uint64, error) {
+	if shardSize < MinShardSize || shardSize > MaxShardSize {
+		return 0, 0, fmt.Errorf("shard size must be within [%s, %s]",
+			humanize.IBytes(MinShardSize), humanize.IBytes(MaxShardSize))
+	}
+	data := uint64(math.Ceil(float64(fileSize) / float64(shardSize)))
+	if data == 0 {
+		data = 1
+	}
+	parity := uint64(math.Ceil(float64(data) * float64(numParity) / float64(numData)))
+	if parity == 0 {
+		parity = 1
+	}
+	if data+parity > maxShards {
+		return 0, 0, fmt.Errorf("shard size %s is too small for a %s file, the encoding exceeds %d shards",
+			humanize.IBytes(uint64(shardSize)), humanize.IBytes(uint64(fileSize)), maxShards)
+	}
+	return data, parity, nil
+}
+
+// Reshard re-encodes the reed-solomon file fileHash in shards of about
+// shardSize bytes and returns the hash of the new encoding, fileHash when
+// the shards already have that size, and the throughput of the encoding in
+// bytes per second.
+func Reshard(params *ContextParams, fileHash string, shardSize int64) (string, float64, error) {
+	fileCid, err := cidlib.Parse(fileHash)
+	if err != nil {
+		return "", 0, err
+	}
+	rootPath := path.IpfsPath(fileCid)
+	mbytes, err := params.Api.Unixfs().GetMetadata(params.Ctx, rootPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("file must be reed-solomon encoded: %s", err)
+	}
+	var rsMeta chunker.RsMetaMap
+	if err := json.Unmarshal(mbytes, &rsMeta); err != nil || rsMeta.NumData == 0 {
+		return "", 0, fmt.Errorf("file must be reed-solomon encoded")
+	}
+	if rsMeta.IsDir {
+		return "", 0, fmt.Errorf("shard size can only be chosen for files, not directories")
+	}
+	data, parity, err := ShardScheme(int64(rsMeta.FileSize), shardSize, rsMeta.NumData, rsMeta.NumParity)
+	if err != nil {
+		return "", 0, err
+	}
+	if data == rsMeta.NumData && parity == rsMeta.NumParity {
+		return fileHash, 0, nil
+	}
+	nd, err := params.Api.Unixfs().Get(params.Ctx, rootPath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer nd.Close()
+	f, ok := nd.(files.File)
+	if !ok {
+		return "", 0, fmt.Errorf("%s is not a file", fileHash)
+	}
+	start := time.Now()
+	added, err := params.Api.Unixfs().Add(params.Ctx, f,
+		options.Unixfs.Chunker(fmt.Sprintf("%s-%d-%d-%d", chunker.PrefixForReedSolomon,
+			data, parity, chunker.DefaultReedSolomonShardSize)),
+		options.Unixfs.Pin(true))
+	if err != nil {
+		return "", 0, err
+	}
+	rate := float64(rsMeta.FileSize) / time.Since(start).Seconds()
+	return added.Cid().String(), rate, nil
+}
@@ -0,0 +1,42 @@
+package helper
+
+import (
+	"testing"
+)
+
+func TestShardScheme(t *testing.T) {
+	const gib = 1 << 30
+	for _, c := range []struct {
+		fileSize, shardSize int64
+		data, parity        uint64
+	}{
+		{10 * gib, 256 << 20, 40, 80},
+		{100 << 10, MinShardSize, 1, 2},
+		{gib + 1, gib, 2, 4},
+	} {
+		data, parity, err := ShardScheme(c.fileSize, c.shardSize, 10, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data != c.data || parity != c.parity {
+			t.Fatalf("expected %d+%d shards for %d bytes in %d byte shards, got %d+%d",
+				c.data, c.parity, c.fileSize, c.shardSize, data, parity)
+		}
+	}
+	if _, _, err := ShardScheme(10*gib, 64<<20, 10, 20); err == nil {
+		t.Fatal("expected an encoding of more than 256 shards to be rejected")
+	}
+	if _, _, err := ShardScheme(gib, MinShardSize-1, 10, 20); err == nil {
+		t.Fatal("expected a shard size below the minimum to be rejected")
+	}
+}
+
+func TestShardLimits(t *testing.T) {
+	if !(&ShardLimits{}).Accepts(MaxShardSize) {
+		t.Fatal("expected a host without limit to store any shard")
+	}
+	l := &ShardLimits{MaxShardSize: 64 << 20}
+	if !l.Accepts(64<<20) || l.Accepts(64<<20+1) {
+		t.Fatal("expected the shards up to the host maximum only")
+	}
+}
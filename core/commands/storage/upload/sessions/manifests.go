@@ -0,0 +1,112 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	renterpb "github.com/TRON-US/go-btfs/protos/renter"
+
+	ds "github.com/ipfs/go-datastore"
+)
+
+const RenterManifestKey = "/btfs/%s/renter/manifests/%s"
+
+// Manifest is a version of a file stored on hosts. An updated version only
+// uploads the shards which changed, the others stay with the hosts storing
+// them for the previous version.
+type Manifest struct {
+	Version  int
+	FileHash string
+	// Prev is the file hash of the previous version, empty for the first.
+	Prev     string `json:",omitempty"`
+	FileSize int64
+	Shards   []*ManifestShard
+}
+
+// ManifestShard is a shard of a file version and the renter session whose
+// contracts store it.
+type ManifestShard struct {
+	Index   int
+	Hash    string
+	Session string
+	// Changed is set when the shard was uploaded for this version.
+	Changed bool `json:",omitempty"`
+}
+
+// GetManifest returns the manifest of the file version fileHash uploaded by
+// the renter peerID, nil if there is none.
+func GetManifest(d ds.Datastore, peerID, fileHash string) (*Manifest, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(RenterManifestKey, peerID, fileHash)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest of %s: %s", fileHash, err)
+	}
+	return m, nil
+}
+
+// SaveManifest records the manifest m of the renter peerID.
+func SaveManifest(d ds.Datastore, peerID string, m *Manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(RenterManifestKey, peerID, m.FileHash)), b)
+}
+
+// FirstManifest returns the manifest of a file uploaded in one session,
+// with all its shards in it.
+func FirstManifest(fileHash string, fileSize int64, shardHashes []string, ssId string) *Manifest {
+	m := &Manifest{Version: 1, FileHash: fileHash, FileSize: fileSize}
+	for i, h := range shardHashes {
+		m.Shards = append(m.Shards, &ManifestShard{Index: i, Hash: h, Session: ssId, Changed: true})
+	}
+	return m
+}
+
+// NextManifest returns the manifest of the version fileHash of the file of
+// prev, with the shards changed from prev uploaded in the session ssId, and
+// the indexes of these shards.
+func NextManifest(prev *Manifest, fileHash string, fileSize int64, shardHashes []string,
+	ssId string) (*Manifest, []int, error) {
+	if len(shardHashes) != len(prev.Shards) {
+		return nil, nil, fmt.Errorf("%s has %d shards, %s has %d: the encoding scheme differs",
+			fileHash, len(shardHashes), prev.FileHash, len(prev.Shards))
+	}
+	m := &Manifest{Version: prev.Version + 1, FileHash: fileHash, Prev: prev.FileHash, FileSize: fileSize}
+	var changed []int
+	for i, h := range shardHashes {
+		s := &ManifestShard{Index: i, Hash: h, Session: prev.Shards[i].Session}
+		if h != prev.Shards[i].Hash {
+			s.Session = ssId
+			s.Changed = true
+			changed = append(changed, i)
+		}
+		m.Shards = append(m.Shards, s)
+	}
+	return m, changed, nil
+}
+
+// CompleteSession returns the ID of a complete session of the renter
+// peerID uploading fileHash, empty if there is none.
+func CompleteSession(d ds.Datastore, peerID, fileHash string) (string, error) {
+	keys, err := ListKeys(d, fmt.Sprintf(RenterSessionPrefix, peerID), "/status")
+	if err != nil {
+		return "", err
+	}
+	for _, k := range keys {
+		s := &renterpb.RenterSessionStatus{}
+		if err := Get(d, k, s); err != nil {
+			continue
+		}
+		if s.Status == RssCompleteStatus && s.Hash == fileHash {
+			return getSessionId(k), nil
+		}
+	}
+	return "", nil
+}
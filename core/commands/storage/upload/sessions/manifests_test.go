@@ -0,0 +1,57 @@
+package sessions
+
+import (
+	"reflect"
+	"testing"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestNextManifest(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	v1 := FirstManifest("QmV1", 100, []string{"Qm1", "Qm2", "Qm3", "Qm4"}, "ss1")
+	if err := SaveManifest(d, "QmRenter", v1); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetManifest(d, "QmRenter", "QmV1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v1) {
+		t.Fatalf("expected %+v, got %+v", v1, got)
+	}
+
+	v2, changed, err := NextManifest(v1, "QmV2", 100, []string{"Qm1", "Qm2b", "Qm3", "Qm4b"}, "ss2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changed, []int{1, 3}) {
+		t.Fatalf("expected shards 1 and 3 changed, got %v", changed)
+	}
+	if v2.Version != 2 || v2.Prev != "QmV1" {
+		t.Fatalf("not linked to the previous version: %+v", v2)
+	}
+	sessions := []string{"ss1", "ss2", "ss1", "ss2"}
+	for i, s := range v2.Shards {
+		if s.Session != sessions[i] || s.Changed != (sessions[i] == "ss2") {
+			t.Errorf("shard %d: %+v", i, s)
+		}
+	}
+
+	// the unchanged shards keep the session storing them
+	v3, changed, err := NextManifest(v2, "QmV3", 100, []string{"Qm1c", "Qm2b", "Qm3", "Qm4b"}, "ss3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changed, []int{0}) || v3.Shards[1].Session != "ss2" || v3.Shards[2].Session != "ss1" {
+		t.Fatalf("unexpected version 3 %+v", v3)
+	}
+
+	if _, _, err := NextManifest(v3, "QmV4", 100, []string{"Qm1"}, "ss4"); err == nil {
+		t.Fatal("accepted a different encoding scheme")
+	}
+	if m, err := GetManifest(d, "QmRenter", "QmNone"); m != nil || err != nil {
+		t.Fatalf("expected no manifest, got %v %v", m, err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/events"
 	renterpb "github.com/TRON-US/go-btfs/protos/renter"
 	sessionpb "github.com/TRON-US/go-btfs/protos/session"
 
@@ -134,6 +135,16 @@ func GetRenterSession(ctxParams *uh.ContextParams, ssId string, hash string, sha
 	return rs, nil
 }
 
+// CachedRenterSessions returns the renter sessions loaded in memory since
+// the daemon started, which includes every session it is running.
+func CachedRenterSessions() []*RenterSession {
+	sessions := make([]*RenterSession, 0, renterSessionsInMem.Count())
+	for item := range renterSessionsInMem.IterBuffered() {
+		sessions = append(sessions, item.Val.(*RenterSession))
+	}
+	return sessions
+}
+
 var helperText = map[string]string{
 	RssInitStatus:       "Searching for recommended hosts…",
 	RssSubmitStatus:     "Hosts found! Checking wallet balance and submitting contracts to escrow.",
@@ -172,12 +183,29 @@ func (rs *RenterSession) enterState(e *fsm.Event) {
 				Info:        "",
 				LastUpdated: time.Now(),
 			}})
+	if terr := addTransition(rs.CtxParams.N.Repo.Datastore(), rs.PeerId, rs.SsId, &Transition{
+		Event:   e.Event,
+		From:    e.Src,
+		To:      e.Dst,
+		Time:    time.Now(),
+		Message: msg,
+	}); terr != nil {
+		log.Debugf("record the transition of session %s: %s", rs.SsId, terr)
+	}
+	notifyChange(rs.SsId)
+	events.Publish(events.TopicUpload, &UploadEvent{
+		SessionID: rs.SsId,
+		FileHash:  rs.Hash,
+		Status:    e.Dst,
+		Message:   msg,
+	})
 	go func() {
 		_ = rs.To(RssErrorStatus, err)
 	}()
 }
 
 func (rs *RenterSession) UpdateAdditionalInfo(info string) error {
+	defer notifyChange(rs.SsId)
 	return Save(rs.CtxParams.N.Repo.Datastore(), fmt.Sprintf(RenterSessionAdditionalInfoKey, rs.PeerId, rs.SsId),
 		&renterpb.RenterSessionAdditionalInfo{
 			Info:        info,
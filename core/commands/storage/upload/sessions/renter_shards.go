@@ -9,6 +9,7 @@ import (
 
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/events"
 	shardpb "github.com/TRON-US/go-btfs/protos/shard"
 
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
@@ -136,12 +137,23 @@ func (rs *RenterShard) doContract(signedEscrowContract []byte, signedGuardContra
 		SignedGuardContract:  signedGuardContract,
 	}
 	shardId := GetShardId(rs.ssId, rs.hash, rs.index)
-	return Batch(rs.ds, []string{
+	defer notifyChange(rs.ssId)
+	err := Batch(rs.ds, []string{
 		fmt.Sprintf(renterShardStatusKey, rs.peerId, shardId),
 		fmt.Sprintf(renterShardContractsKey, rs.peerId, shardId),
 	}, []proto.Message{
 		status, signedContracts,
 	})
+	if err == nil {
+		index := rs.index
+		events.Publish(events.TopicUpload, &UploadEvent{
+			SessionID:  rs.ssId,
+			Status:     rshContractStatus,
+			ShardHash:  rs.hash,
+			ShardIndex: &index,
+		})
+	}
+	return err
 }
 
 func (rs *RenterShard) Contract(signedEscrowContract []byte, signedGuardContract *guardpb.Contract) error {
@@ -195,6 +207,21 @@ func DeleteShardsContracts(d datastore.Datastore, peerId string, role string) er
 	return Batch(d, ks, vs)
 }
 
+// RemoveShardsContracts removes the signed contracts of contractIds.
+func RemoveShardsContracts(d datastore.Datastore, peerId string, role string, contractIds []string) error {
+	key := renterShardContractsKey
+	if role == nodepb.ContractStat_HOST.String() {
+		key = hostShardContractsKey
+	}
+	ks := make([]string, 0, len(contractIds))
+	vs := make([]proto.Message, 0, len(contractIds))
+	for _, id := range contractIds {
+		ks = append(ks, fmt.Sprintf(key, peerId, id))
+		vs = append(vs, nil)
+	}
+	return Batch(d, ks, vs)
+}
+
 // SaveShardsContracts persists updated guard contracts from upstream, if an existing entry
 // is not available, then an empty signed escrow contract is inserted along with the
 // new guard contract.
@@ -278,6 +305,7 @@ func SaveShardsContracts(ds datastore.Datastore, scs []*shardpb.SignedContracts,
 
 func (rs *RenterShard) UpdateAdditionalInfo(info string) error {
 	shardId := GetShardId(rs.ssId, rs.hash, rs.index)
+	defer notifyChange(rs.ssId)
 	return Save(rs.ds, fmt.Sprintf(renterShardAdditionalInfoKey, rs.peerId, shardId),
 		&renterpb.RenterSessionAdditionalInfo{
 			Info:        info,
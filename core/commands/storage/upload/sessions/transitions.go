@@ -0,0 +1,66 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+)
+
+// RenterSessionTransitionsKey is the datastore key of the transitions of a
+// renter session.
+const RenterSessionTransitionsKey = RenterSessionKey + "transitions"
+
+// Transition is a change of state of a renter session.
+type Transition struct {
+	Event   string
+	From    string
+	To      string
+	Time    time.Time
+	Message string `json:",omitempty"`
+}
+
+// RssPath returns the states a renter session goes through when it
+// completes, in order.
+func RssPath() []string {
+	path := []string{RssInitStatus}
+	for _, e := range rssFsmEvents {
+		if e.Dst != RssErrorStatus {
+			path = append(path, e.Dst)
+		}
+	}
+	return path
+}
+
+// Transitions returns the transitions of the renter session ssId of
+// peerId, oldest first. The sessions started before transitions were
+// recorded have none.
+func Transitions(d ds.Datastore, peerId, ssId string) ([]*Transition, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(RenterSessionTransitionsKey, peerId, ssId)))
+	if err == ds.ErrNotFound {
+		return []*Transition{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ts []*Transition
+	if err := json.Unmarshal(b, &ts); err != nil {
+		return nil, fmt.Errorf("invalid transitions of session %s: %s", ssId, err)
+	}
+	return ts, nil
+}
+
+// addTransition appends t to the transitions of the renter session ssId of
+// peerId.
+func addTransition(d ds.Datastore, peerId, ssId string, t *Transition) error {
+	ts, err := Transitions(d, peerId, ssId)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(append(ts, t))
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(RenterSessionTransitionsKey, peerId, ssId)), b)
+}
@@ -0,0 +1,98 @@
+package sessions
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	renterpb "github.com/TRON-US/go-btfs/protos/renter"
+)
+
+// MaxWaitTimeout bounds how long a status request waits for a renter
+// session to change.
+const MaxWaitTimeout = 5 * time.Minute
+
+var (
+	watchLk sync.Mutex
+	// watchers are closed when their renter session changes
+	watchers = map[string]chan struct{}{}
+)
+
+// changed returns a channel closed at the next change of the renter
+// session ssId, of its state or of its shards.
+func changed(ssId string) <-chan struct{} {
+	watchLk.Lock()
+	defer watchLk.Unlock()
+	ch, ok := watchers[ssId]
+	if !ok {
+		ch = make(chan struct{})
+		watchers[ssId] = ch
+	}
+	return ch
+}
+
+// UploadEvent is published on events.TopicUpload when a renter session
+// enters a state, or one of its shards is contracted.
+type UploadEvent struct {
+	SessionID string
+	FileHash  string `json:",omitempty"`
+	Status    string
+	Message   string `json:",omitempty"`
+	// ShardHash and ShardIndex are set on the events of a shard.
+	ShardHash  string `json:",omitempty"`
+	ShardIndex *int   `json:",omitempty"`
+}
+
+// notifyChange wakes the requests waiting for the renter session ssId.
+func notifyChange(ssId string) {
+	watchLk.Lock()
+	defer watchLk.Unlock()
+	if ch, ok := watchers[ssId]; ok {
+		close(ch)
+		delete(watchers, ssId)
+	}
+}
+
+// WaitStatus waits until done returns true for the state of rs, or the
+// session reaches a final state, ctx is done or timeout elapses, and returns
+// the status of rs then.
+func (rs *RenterSession) WaitStatus(ctx context.Context, timeout time.Duration,
+	done func(status string) bool) (*renterpb.RenterSessionStatus, error) {
+	var status *renterpb.RenterSessionStatus
+	err := waitStatus(ctx, rs.SsId, timeout, func() (string, error) {
+		var err error
+		status, err = rs.Status()
+		if err != nil {
+			return "", err
+		}
+		return status.Status, nil
+	}, done)
+	return status, err
+}
+
+func waitStatus(ctx context.Context, ssId string, timeout time.Duration,
+	status func() (string, error), done func(string) bool) error {
+	if timeout > MaxWaitTimeout {
+		timeout = MaxWaitTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		// watch before reading, not to miss a change in between
+		ch := changed(ssId)
+		s, err := status()
+		if err != nil {
+			return err
+		}
+		if s == RssCompleteStatus || s == RssErrorStatus || done(s) {
+			return nil
+		}
+		select {
+		case <-ch:
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package sessions
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitStatus(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		state = RssInitStatus
+	)
+	set := func(s string) {
+		mu.Lock()
+		state = s
+		mu.Unlock()
+		notifyChange("ss")
+	}
+	status := func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return state, nil
+	}
+	ctx := context.Background()
+
+	// waits for the change
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		set(RssSubmitStatus)
+		time.Sleep(20 * time.Millisecond)
+		set(RssPayStatus)
+	}()
+	start := time.Now()
+	err := waitStatus(ctx, "ss", time.Minute, status, func(s string) bool { return s == RssPayStatus })
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+	s, _ := status()
+	assert.Equal(t, RssPayStatus, s)
+
+	// returns the status after the timeout
+	start = time.Now()
+	err = waitStatus(ctx, "ss", 30*time.Millisecond, status, func(s string) bool { return false })
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 30*time.Millisecond)
+
+	// returns at once in a final state
+	set(RssErrorStatus)
+	start = time.Now()
+	err = waitStatus(ctx, "ss", time.Minute, status, func(s string) bool { return false })
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+
+	// stops with the request
+	set(RssGuardStatus)
+	cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err = waitStatus(cctx, "ss", time.Minute, status, func(s string) bool { return false })
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
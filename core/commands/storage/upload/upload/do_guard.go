@@ -11,10 +11,8 @@ import (
 	renterpb "github.com/TRON-US/go-btfs/protos/renter"
 
 	config "github.com/TRON-US/go-btfs-config"
-	"github.com/tron-us/go-btfs-common/crypto"
 	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
-	cgrpc "github.com/tron-us/go-btfs-common/utils/grpc"
 
 	"github.com/gogo/protobuf/proto"
 	cidlib "github.com/ipfs/go-cid"
@@ -61,11 +59,11 @@ func doGuard(rss *sessions.RenterSession, res *escrowpb.SignedPayinResult, fileS
 	} else {
 		go func() {
 			if sig, err := func() ([]byte, error) {
-				payerPrivKey, err := rss.CtxParams.Cfg.Identity.DecodePrivateKey("")
+				signer, err := uh.GetSigner(rss.CtxParams)
 				if err != nil {
 					return nil, err
 				}
-				sig, err := crypto.Sign(payerPrivKey, &fsStatus.FileStoreMeta)
+				sig, err := signer.Sign(uh.SignKindFileMeta, &fsStatus.FileStoreMeta)
 				if err != nil {
 					return nil, err
 				}
@@ -83,7 +81,7 @@ func doGuard(rss *sessions.RenterSession, res *escrowpb.SignedPayinResult, fileS
 	if err := rss.To(sessions.RssToGuardFileMetaSignedEvent); err != nil {
 		return err
 	}
-	fsStatus, err = submitFileMetaHelper(rss.Ctx, rss.CtxParams.Cfg, fsStatus, signBytes)
+	fsStatus, err = submitFileMetaHelper(rss.Ctx, rss.CtxParams, fsStatus, signBytes)
 	if err != nil {
 		return err
 	}
@@ -157,7 +155,7 @@ func newFileStatus(contracts []*guardpb.Contract, configuration *config.Config,
 	}, nil
 }
 
-func submitFileMetaHelper(ctx context.Context, configuration *config.Config,
+func submitFileMetaHelper(ctx context.Context, ctxParams *uh.ContextParams,
 	fileStatus *guardpb.FileStoreStatus, sign []byte) (*guardpb.FileStoreStatus, error) {
 	if fileStatus.PreparerPid == fileStatus.RenterPid {
 		fileStatus.RenterSignature = sign
@@ -166,26 +164,10 @@ func submitFileMetaHelper(ctx context.Context, configuration *config.Config,
 		fileStatus.PreparerSignature = sign
 	}
 
-	err := submitFileStatus(ctx, configuration, fileStatus)
+	err := guard.SubmitQueued(ctx, ctxParams.N, ctxParams.Cfg, fileStatus)
 	if err != nil {
 		return nil, err
 	}
 
 	return fileStatus, nil
 }
-
-func submitFileStatus(ctx context.Context, cfg *config.Config,
-	fileStatus *guardpb.FileStoreStatus) error {
-	cb := cgrpc.GuardClient(cfg.Services.GuardDomain)
-	cb.Timeout(guard.GuardTimeout)
-	return cb.WithContext(ctx, func(ctx context.Context, client guardpb.GuardServiceClient) error {
-		res, err := client.SubmitFileStoreMeta(ctx, fileStatus)
-		if err != nil {
-			return err
-		}
-		if res.Code != guardpb.ResponseCode_SUCCESS {
-			return fmt.Errorf("failed to execute submit file status to gurad: %v", res.Message)
-		}
-		return nil
-	})
-}
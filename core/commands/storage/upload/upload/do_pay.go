@@ -3,16 +3,15 @@ package upload
 import (
 	"context"
 
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/escrow"
 	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 	renterpb "github.com/TRON-US/go-btfs/protos/renter"
 
 	config "github.com/TRON-US/go-btfs-config"
-	"github.com/tron-us/go-btfs-common/crypto"
 	"github.com/tron-us/go-btfs-common/ledger"
 	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 	"github.com/tron-us/protobuf/proto"
 )
 
@@ -37,20 +36,20 @@ func pay(rss *sessions.RenterSession, result *escrowpb.SignedSubmitContractResul
 		go func() {
 			if err := func() error {
 				chanState := result.Result.BuyerChannelState
-				payerPrivKey, err := rss.CtxParams.Cfg.Identity.DecodePrivateKey("")
+				signer, err := uh.GetSigner(rss.CtxParams)
 				if err != nil {
 					return err
 				}
-				sig, err := crypto.Sign(payerPrivKey, chanState.Channel)
+				sig, err := signer.Sign(uh.SignKindChannelState, chanState.Channel)
 				if err != nil {
 					return err
 				}
 				chanState.FromSignature = sig
-				payinReq, err := ledger.NewPayinRequest(result.Result.PayinId, payerPrivKey.GetPublic(), chanState)
+				payinReq, err := ledger.NewPayinRequest(result.Result.PayinId, signer.PubKey(), chanState)
 				if err != nil {
 					return err
 				}
-				payinSig, err := crypto.Sign(payerPrivKey, payinReq)
+				payinSig, err := signer.Sign(uh.SignKindPayin, payinReq)
 				if err != nil {
 					return err
 				}
@@ -85,24 +84,15 @@ func pay(rss *sessions.RenterSession, result *escrowpb.SignedSubmitContractResul
 }
 
 func payInToEscrow(ctx context.Context, configuration *config.Config, signedPayinReq *escrowpb.SignedPayinRequest) (*escrowpb.SignedPayinResult, error) {
-	var signedPayinRes *escrowpb.SignedPayinResult
-	err := grpc.EscrowClient(configuration.Services.EscrowDomain).WithContext(ctx,
-		func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
-			res, err := client.PayIn(ctx, signedPayinReq)
-			if err != nil {
-				log.Error(err)
-				return err
-			}
-			err = escrow.VerifyEscrowRes(configuration, res.Result, res.EscrowSignature)
-			if err != nil {
-				log.Error(err)
-				return err
-			}
-			signedPayinRes = res
-			return nil
-		})
+	res, err := escrowclient.New(configuration.Services.EscrowDomain).PayIn(ctx, signedPayinReq)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	err = escrow.VerifyEscrowRes(configuration, res.Result, res.EscrowSignature)
 	if err != nil {
+		log.Error(err)
 		return nil, err
 	}
-	return signedPayinRes, nil
+	return res, nil
 }
@@ -2,20 +2,22 @@ package upload
 
 import (
 	"context"
-	"fmt"
 
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/core/commands/e"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/escrow"
 	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 	renterpb "github.com/TRON-US/go-btfs/protos/renter"
 
+	cmds "github.com/TRON-US/go-btfs-cmds"
 	config "github.com/TRON-US/go-btfs-config"
 	"github.com/tron-us/go-btfs-common/ledger"
-	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
 	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 	"github.com/tron-us/protobuf/proto"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
 )
 
 func checkBalance(rss *sessions.RenterSession, offlineSigning bool, totalPay int64) error {
@@ -31,14 +33,20 @@ func checkBalance(rss *sessions.RenterSession, offlineSigning bool, totalPay int
 	} else {
 		go func() {
 			if err := func() error {
-				privKey, err := rss.CtxParams.Cfg.Identity.DecodePrivateKey("")
+				signer, err := uh.GetSigner(rss.CtxParams)
 				if err != nil {
 					return err
 				}
-				lgSignedPubKey, err := ledger.NewSignedPublicKey(privKey, privKey.GetPublic())
+				raw, err := ic.RawFull(signer.PubKey())
 				if err != nil {
 					return err
 				}
+				lgPubKey := &ledgerpb.PublicKey{Key: raw}
+				sig, err := signer.Sign(uh.SignKindBalance, lgPubKey)
+				if err != nil {
+					return err
+				}
+				lgSignedPubKey := &ledgerpb.SignedPublicKey{Key: lgPubKey, Signature: sig}
 				signedBytes, err := proto.Marshal(lgSignedPubKey)
 				if err != nil {
 					return err
@@ -60,7 +68,7 @@ func checkBalance(rss *sessions.RenterSession, offlineSigning bool, totalPay int
 		return err
 	}
 	if balance < totalPay {
-		return fmt.Errorf("not enough balance to submit contract, current balance is [%v]", balance)
+		return cmds.Errorf(e.ErrInsufficientFunds, "not enough balance to submit contract, current balance is [%v]", balance)
 	}
 	return nil
 }
@@ -71,23 +79,15 @@ func balanceHelper(ctx context.Context, configuration *config.Config, signedByte
 	if err != nil {
 		return 0, err
 	}
-	var balance int64 = 0
 	ctx, _ = helper.NewGoContext(ctx)
-	err = grpc.EscrowClient(configuration.Services.EscrowDomain).WithContext(ctx,
-		func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
-			res, err := client.BalanceOf(ctx, ledger.NewSignedCreateAccountRequest(ledgerSignedPubKey.Key, ledgerSignedPubKey.Signature))
-			if err != nil {
-				return err
-			}
-			err = escrow.VerifyEscrowRes(configuration, res.Result, res.EscrowSignature)
-			if err != nil {
-				return err
-			}
-			balance = res.Result.Balance
-			return nil
-		})
+	res, err := escrowclient.New(configuration.Services.EscrowDomain).BalanceOf(ctx,
+		ledger.NewSignedCreateAccountRequest(ledgerSignedPubKey.Key, ledgerSignedPubKey.Signature))
+	if err != nil {
+		return 0, err
+	}
+	err = escrow.VerifyEscrowRes(configuration, res.Result, res.EscrowSignature)
 	if err != nil {
 		return 0, err
 	}
-	return balance, nil
+	return res.Result.Balance, nil
 }
@@ -9,7 +9,6 @@ import (
 	renterpb "github.com/TRON-US/go-btfs/protos/renter"
 
 	config "github.com/TRON-US/go-btfs-config"
-	"github.com/tron-us/go-btfs-common/crypto"
 	"github.com/tron-us/go-btfs-common/ledger"
 	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
 	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
@@ -70,15 +69,15 @@ func NewContractRequest(rss *sessions.RenterSession, signedContracts []*escrowpb
 			if err := func() error {
 				var chanCommit *ledgerpb.ChannelCommit
 				var buyerChanSig []byte
-				buyerPrivKey, err := conf.Identity.DecodePrivateKey("")
+				signer, err := uh.GetSigner(rss.CtxParams)
 				if err != nil {
 					return err
 				}
-				chanCommit, err = ledger.NewChannelCommit(buyerPrivKey.GetPublic(), escrowPubKey, totalPrice)
+				chanCommit, err = ledger.NewChannelCommit(signer.PubKey(), escrowPubKey, totalPrice)
 				if err != nil {
 					return err
 				}
-				buyerChanSig, err = crypto.Sign(buyerPrivKey, chanCommit)
+				buyerChanSig, err = signer.Sign(uh.SignKindChannelCommit, chanCommit)
 				if err != nil {
 					return err
 				}
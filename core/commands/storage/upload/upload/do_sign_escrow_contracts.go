@@ -8,7 +8,6 @@ import (
 	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 
-	"github.com/tron-us/go-btfs-common/crypto"
 	"github.com/tron-us/go-btfs-common/ledger"
 	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
 	"github.com/tron-us/protobuf/proto"
@@ -37,7 +36,12 @@ func renterSignEscrowContract(rss *sessions.RenterSession, shardHash string, sha
 	if !offlineSigning {
 		errChan := make(chan error)
 		go func() {
-			sign, err := crypto.Sign(rss.CtxParams.N.PrivateKey, escrowContract)
+			signer, err := uh.GetSigner(rss.CtxParams)
+			if err != nil {
+				errChan <- err
+				return
+			}
+			sign, err := signer.Sign(uh.SignKindEscrowContract, escrowContract)
 			if err != nil {
 				errChan <- err
 				return
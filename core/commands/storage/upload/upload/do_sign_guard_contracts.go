@@ -9,7 +9,6 @@ import (
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 
 	config "github.com/TRON-US/go-btfs-config"
-	"github.com/tron-us/go-btfs-common/crypto"
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
 	"github.com/tron-us/protobuf/proto"
 
@@ -78,7 +77,12 @@ func RenterSignGuardContract(rss *sessions.RenterSession, params *ContractParams
 	uh.GuardContractMaps.Set(shardId, bytes)
 	if !offlineSigning {
 		go func() {
-			sign, err := crypto.Sign(rss.CtxParams.N.PrivateKey, gm)
+			signer, err := uh.GetSigner(rss.CtxParams)
+			if err != nil {
+				_ = rss.To(sessions.RssToErrorEvent, err)
+				return
+			}
+			sign, err := signer.Sign(uh.SignKindGuardContract, gm)
 			if err != nil {
 				_ = rss.To(sessions.RssToErrorEvent, err)
 				return
@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/escrow"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 
 	config "github.com/TRON-US/go-btfs-config"
 	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 	"github.com/tron-us/protobuf/proto"
 )
 
@@ -73,25 +73,17 @@ func prepareContracts(rss *sessions.RenterSession, shardHashes []string) ([]*esc
 
 func submitContractToEscrow(ctx context.Context, configuration *config.Config,
 	request *escrowpb.EscrowContractRequest) (*escrowpb.SignedSubmitContractResult, error) {
-	var (
-		response *escrowpb.SignedSubmitContractResult
-		err      error
-	)
-	err = grpc.EscrowClient(configuration.Services.EscrowDomain).WithContext(ctx,
-		func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
-			response, err = client.SubmitContracts(ctx, request)
-			if err != nil {
-				return err
-			}
-			if response == nil {
-				return fmt.Errorf("escrow reponse is nil")
-			}
-			// verify
-			err = escrow.VerifyEscrowRes(configuration, response.Result, response.EscrowSignature)
-			if err != nil {
-				return fmt.Errorf("verify escrow failed %v", err)
-			}
-			return nil
-		})
-	return response, err
+	response, err := escrowclient.New(configuration.Services.EscrowDomain).SubmitContracts(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	if response == nil {
+		return nil, fmt.Errorf("escrow reponse is nil")
+	}
+	// verify
+	err = escrow.VerifyEscrowRes(configuration, response.Result, response.EscrowSignature)
+	if err != nil {
+		return response, fmt.Errorf("verify escrow failed %v", err)
+	}
+	return response, nil
 }
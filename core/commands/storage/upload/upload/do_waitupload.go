@@ -1,19 +1,18 @@
 package upload
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"math"
+	"sync"
 	"time"
 
+	guardclient "github.com/TRON-US/go-btfs/core/clients/guard"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 	renterpb "github.com/TRON-US/go-btfs/protos/renter"
 
-	"github.com/tron-us/go-btfs-common/crypto"
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 
 	"github.com/alecthomas/units"
 	"github.com/cenkalti/backoff/v4"
@@ -24,20 +23,37 @@ const (
 	thresholdContractsNums = 20
 )
 
+// waiting holds the IDs of the sessions waiting for their upload to be
+// confirmed by the guard.
+var waiting sync.Map
+
+// Waiting reports whether the session ssId waits for the guard to confirm
+// its upload.
+func Waiting(ssId string) bool {
+	_, ok := waiting.Load(ssId)
+	return ok
+}
+
 func getSuccessThreshold(totalShards int) int {
 	return int(math.Min(float64(totalShards), thresholdContractsNums))
 }
 
 func ResumeWaitUploadOnSigning(rss *sessions.RenterSession) error {
+	signer, err := helper.GetSigner(rss.CtxParams)
+	if err != nil {
+		return err
+	}
 	return waitUpload(rss, false, &guardpb.FileStoreStatus{
 		FileStoreMeta: guardpb.FileStoreMeta{
-			RenterPid: rss.CtxParams.N.Identity.String(),
+			RenterPid: signer.ID().String(),
 			FileSize:  math.MaxInt64,
 		},
 	}, true)
 }
 
 func waitUpload(rss *sessions.RenterSession, offlineSigning bool, fsStatus *guardpb.FileStoreStatus, resume bool) error {
+	waiting.Store(rss.SsId, true)
+	defer waiting.Delete(rss.SsId)
 	threshold := getSuccessThreshold(len(rss.ShardHashes))
 	if !resume {
 		if err := rss.To(sessions.RssToWaitUploadEvent); err != nil {
@@ -50,7 +66,7 @@ func waitUpload(rss *sessions.RenterSession, offlineSigning bool, fsStatus *guar
 		RequesterPid: fsStatus.RenterPid,
 		RequestTime:  time.Now().UTC(),
 	}
-	payerPrivKey, err := rss.CtxParams.Cfg.Identity.DecodePrivateKey("")
+	signer, err := helper.GetSigner(rss.CtxParams)
 	if err != nil {
 		return err
 	}
@@ -69,7 +85,7 @@ func waitUpload(rss *sessions.RenterSession, offlineSigning bool, fsStatus *guar
 		}
 	} else {
 		go func() {
-			sign, err := crypto.Sign(payerPrivKey, req)
+			sign, err := signer.Sign(helper.SignKindWaitUpload, req)
 			if err != nil {
 				_ = rss.To(sessions.RssToErrorEvent, err)
 				return
@@ -93,41 +109,38 @@ func waitUpload(rss *sessions.RenterSession, offlineSigning bool, fsStatus *guar
 	} else if scaledRetry > highRetry {
 		scaledRetry = highRetry
 	}
+	gc := guardclient.New(rss.CtxParams.Cfg.Services.GuardDomain)
 	err = backoff.Retry(func() error {
-		err = grpc.GuardClient(rss.CtxParams.Cfg.Services.GuardDomain).WithContext(rss.Ctx,
-			func(ctx context.Context, client guardpb.GuardServiceClient) error {
-				meta, err := client.CheckFileStoreMeta(ctx, req)
-				if err != nil {
-					return err
-				}
-				num := 0
-				m := make(map[string]int)
-				for _, c := range meta.Contracts {
-					m[c.State.String()]++
-					switch c.State {
-					case guardpb.Contract_READY_CHALLENGE, guardpb.Contract_REQUEST_CHALLENGE, guardpb.Contract_UPLOADED:
-						num++
-					}
-					shard, err := sessions.GetRenterShard(rss.CtxParams, rss.SsId, c.ShardHash, int(c.ShardIndex))
-					if err != nil {
-						return err
-					}
-					err = shard.UpdateAdditionalInfo(c.State.String())
-					if err != nil {
-						return err
-					}
-				}
-				bytes, err := json.Marshal(m)
-				if err == nil {
-					rss.UpdateAdditionalInfo(string(bytes))
-				}
-				log.Infof("%d shards uploaded.", num)
-				if num >= threshold {
-					return nil
-				}
-				return errors.New("uploading")
-			})
-		return err
+		meta, err := gc.CheckFileStoreMeta(rss.Ctx, req)
+		if err != nil {
+			return err
+		}
+		num := 0
+		m := make(map[string]int)
+		for _, c := range meta.Contracts {
+			m[c.State.String()]++
+			switch c.State {
+			case guardpb.Contract_READY_CHALLENGE, guardpb.Contract_REQUEST_CHALLENGE, guardpb.Contract_UPLOADED:
+				num++
+			}
+			shard, err := sessions.GetRenterShard(rss.CtxParams, rss.SsId, c.ShardHash, int(c.ShardIndex))
+			if err != nil {
+				return err
+			}
+			err = shard.UpdateAdditionalInfo(c.State.String())
+			if err != nil {
+				return err
+			}
+		}
+		bytes, err := json.Marshal(m)
+		if err == nil {
+			rss.UpdateAdditionalInfo(string(bytes))
+		}
+		log.Infof("%d shards uploaded.", num)
+		if num >= threshold {
+			return nil
+		}
+		return errors.New("uploading")
 	}, helper.WaitUploadBo(highRetry))
 	if err != nil {
 		return err
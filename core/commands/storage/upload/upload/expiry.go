@@ -0,0 +1,373 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	iface "github.com/TRON-US/interface-go-btfs-core"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+const (
+	storeForOptionName   = "store-for"
+	onExpiryOptionName   = "on-expiry"
+	retrieveToOptionName = "retrieve-to"
+)
+
+// Actions of the renter when the storage of a file expires.
+const (
+	ExpiryDelete            = "delete"
+	ExpiryRetrieveAndDelete = "retrieve-and-delete"
+)
+
+// States of an expiry.
+const (
+	ExpiryPending   = "pending"
+	ExpiryDone      = "done"
+	ExpiryFailed    = "failed"
+	ExpiryCancelled = "cancelled"
+)
+
+// retrieveLead is how long before its expiry a file is retrieved, while
+// its hosts still store it.
+var retrieveLead = 48 * time.Hour
+
+var expiryKeyPrefix = "/btfs/%s/renter/expiries/"
+
+// Expiry is the end of the storage of a file set by the renter at upload:
+// its contracts are not renewed, and the file is deleted from the node at
+// Expires, after being retrieved back when OnExpiry is
+// ExpiryRetrieveAndDelete.
+type Expiry struct {
+	FileHash  string
+	SessionID string
+	Expires   time.Time
+	OnExpiry  string
+	// RetrieveTo is the local path the file is retrieved to, the file
+	// stays stored on the node when empty.
+	RetrieveTo string `json:",omitempty"`
+	State      string
+	Created    time.Time
+	Retrieved  time.Time `json:",omitempty"`
+	Error      string    `json:",omitempty"`
+}
+
+// ParseStoreFor parses the storage period of --store-for in days, e.g.
+// 180d or 26w, or a duration rounded up to days.
+func ParseStoreFor(s string) (int, error) {
+	unit := 0
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 1
+	case strings.HasSuffix(s, "w"):
+		unit = 7
+	}
+	if unit > 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid storage period %q", s)
+		}
+		return n * unit, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid storage period %q, expected days such as 180d, weeks or a duration", s)
+	}
+	return int((d + 24*time.Hour - 1) / (24 * time.Hour)), nil
+}
+
+// expiryOptions returns the expiry set by the options of req, and its
+// storage period in days, nil without --store-for.
+func expiryOptions(req *cmds.Request) (*Expiry, int, error) {
+	storeFor, _ := req.Options[storeForOptionName].(string)
+	onExpiry, _ := req.Options[onExpiryOptionName].(string)
+	to, _ := req.Options[retrieveToOptionName].(string)
+	if storeFor == "" {
+		if onExpiry != "" || to != "" {
+			return nil, 0, fmt.Errorf("--%s and --%s require --%s", onExpiryOptionName, retrieveToOptionName,
+				storeForOptionName)
+		}
+		return nil, 0, nil
+	}
+	days, err := ParseStoreFor(storeFor)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch onExpiry {
+	case "":
+		onExpiry = ExpiryDelete
+	case ExpiryDelete, ExpiryRetrieveAndDelete:
+	default:
+		return nil, 0, fmt.Errorf("invalid --%s %q, expected %s or %s", onExpiryOptionName, onExpiry,
+			ExpiryDelete, ExpiryRetrieveAndDelete)
+	}
+	if to != "" {
+		if onExpiry != ExpiryRetrieveAndDelete {
+			return nil, 0, fmt.Errorf("--%s requires --%s %s", retrieveToOptionName, onExpiryOptionName,
+				ExpiryRetrieveAndDelete)
+		}
+		if to, err = filepath.Abs(to); err != nil {
+			return nil, 0, err
+		}
+	}
+	return &Expiry{OnExpiry: onExpiry, RetrieveTo: to}, days, nil
+}
+
+// AddExpiry records the expiry e of the file of peerId, uploaded at now
+// for days.
+func AddExpiry(d ds.Datastore, peerId string, e *Expiry, days int, now time.Time) error {
+	e.Created = now
+	e.Expires = now.Add(time.Duration(days) * 24 * time.Hour)
+	e.State = ExpiryPending
+	return putExpiry(d, peerId, e)
+}
+
+func putExpiry(d ds.Datastore, peerId string, e *Expiry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(expiryKeyPrefix, peerId)+e.FileHash), b)
+}
+
+// GetExpiry returns the expiry of the file of peerId, nil when its storage
+// does not expire.
+func GetExpiry(d ds.Datastore, peerId, fileHash string) (*Expiry, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(expiryKeyPrefix, peerId) + fileHash))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	e := &Expiry{}
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil, fmt.Errorf("invalid expiry of file %s: %s", fileHash, err)
+	}
+	return e, nil
+}
+
+// ListExpiries returns the expiries of the files of peerId, the next
+// first.
+func ListExpiries(d ds.Datastore, peerId string) ([]*Expiry, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(expiryKeyPrefix, peerId)})
+	if err != nil {
+		return nil, err
+	}
+	es := make([]*Expiry, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		e := &Expiry{}
+		if err := json.Unmarshal(r.Value, e); err != nil {
+			return nil, fmt.Errorf("invalid expiry %s: %s", r.Key, err)
+		}
+		es = append(es, e)
+	}
+	sort.Slice(es, func(i, j int) bool {
+		return es[i].Expires.Before(es[j].Expires)
+	})
+	return es, nil
+}
+
+// CancelExpiry cancels the pending expiry of the file of peerId, whose
+// contracts are renewed again.
+func CancelExpiry(d ds.Datastore, peerId, fileHash string) (*Expiry, error) {
+	e, err := GetExpiry(d, peerId, fileHash)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, fmt.Errorf("no expiry for file %s", fileHash)
+	}
+	if e.State != ExpiryPending {
+		return nil, fmt.Errorf("expiry of file %s is %s", fileHash, e.State)
+	}
+	e.State = ExpiryCancelled
+	return e, putExpiry(d, peerId, e)
+}
+
+// Expiring reports whether the storage of the file of peerId expires, so
+// that its contracts are not renewed.
+func Expiring(d ds.Datastore, peerId, fileHash string) (bool, error) {
+	e, err := GetExpiry(d, peerId, fileHash)
+	if err != nil {
+		return false, err
+	}
+	return e != nil && e.State != ExpiryCancelled, nil
+}
+
+// RunExpiries runs the pending expiries of peerId due at now: retrieve
+// retrieves the files to retrieve from retrieveLead before they expire,
+// then remove deletes them from the node once expired. A failed retrieval
+// is retried until the expiry, which then fails with the file kept.
+func RunExpiries(d ds.Datastore, peerId string, now time.Time, retrieve func(*Expiry) error,
+	remove func(*Expiry) error) (int, error) {
+	es, err := ListExpiries(d, peerId)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range es {
+		if e.State != ExpiryPending || now.Before(e.Expires.Add(-retrieveLead)) {
+			continue
+		}
+		if e.OnExpiry == ExpiryRetrieveAndDelete && e.Retrieved.IsZero() {
+			if err := retrieve(e); err != nil {
+				e.Error = fmt.Sprintf("retrieve: %s", err)
+				if !now.Before(e.Expires) {
+					e.State = ExpiryFailed
+				}
+				if err := putExpiry(d, peerId, e); err != nil {
+					return n, err
+				}
+				continue
+			}
+			e.Retrieved = now
+			e.Error = ""
+		}
+		if !now.Before(e.Expires) {
+			// a file retrieved to the node is kept there
+			if e.OnExpiry == ExpiryDelete || e.RetrieveTo != "" {
+				if err := remove(e); err != nil {
+					e.Error = fmt.Sprintf("delete: %s", err)
+					e.State = ExpiryFailed
+				}
+			}
+			if e.State == ExpiryPending {
+				e.State = ExpiryDone
+			}
+			n++
+		}
+		if err := putExpiry(d, peerId, e); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// RetrieveFile retrieves the file fileHash from its hosts and pins it on
+// the node, then writes it to the path to when set, or into it when to is
+// a directory.
+func RetrieveFile(ctx context.Context, api iface.CoreAPI, fileHash, to string) error {
+	p := path.New(fileHash)
+	if err := api.Pin().Add(ctx, p, options.Pin.Recursive(true)); err != nil {
+		return err
+	}
+	if to == "" {
+		return nil
+	}
+	if fi, err := os.Stat(to); err == nil && fi.IsDir() {
+		to = filepath.Join(to, fileHash)
+	}
+	nd, err := api.Unixfs().Get(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer nd.Close()
+	return files.WriteTo(nd, to)
+}
+
+// RemoveFile unpins the file fileHash from the node, which the garbage
+// collection then deletes.
+func RemoveFile(ctx context.Context, api iface.CoreAPI, fileHash string) error {
+	err := api.Pin().Rm(ctx, path.New(fileHash), options.Pin.RmRecursive(true))
+	if err != nil && !strings.Contains(err.Error(), "not pinned") {
+		return err
+	}
+	return nil
+}
+
+var StorageUploadExpiryCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the files whose storage expires.",
+		ShortDescription: `
+Lists and cancels the expiries set by 'btfs storage upload --store-for'. The
+contracts of an expiring file are not renewed. When it expires, the daemon
+deletes the file from the node with --on-expiry delete, the default; with
+--on-expiry retrieve-and-delete it first retrieves the file back from its
+hosts, two days before the expiry, and keeps it on the node, or writes it to
+--retrieve-to then deletes it from the node:
+
+    $ btfs storage upload <file-hash> --store-for 180d \
+        --on-expiry retrieve-and-delete --retrieve-to /archive/`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":     storageUploadExpiryLsCmd,
+		"cancel": storageUploadExpiryCancelCmd,
+	},
+}
+
+var storageUploadExpiryLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the files whose storage expires.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		es, err := ListExpiries(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, es)
+	},
+	Type: []*Expiry{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, es []*Expiry) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "FILE\tEXPIRES\tON EXPIRY\tRETRIEVE TO\tSTATE\tERROR")
+			for _, e := range es {
+				to := e.RetrieveTo
+				if to == "" {
+					to = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.FileHash, e.Expires.Format(time.RFC3339), e.OnExpiry,
+					to, e.State, e.Error)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var storageUploadExpiryCancelCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Cancel the expiry of a file.",
+		ShortDescription: `
+Cancels the expiry of the file, which is kept on the node and whose
+contracts are renewed again.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("file-hash", true, false, "Hash of the file."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		e, err := CancelExpiry(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, e)
+	},
+	Type: Expiry{},
+}
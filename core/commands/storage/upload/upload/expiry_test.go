@@ -0,0 +1,125 @@
+package upload
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestParseStoreFor(t *testing.T) {
+	for s, days := range map[string]int{"180d": 180, "2w": 14, "36h": 2, "24h": 1} {
+		got, err := ParseStoreFor(s)
+		if err != nil || got != days {
+			t.Fatalf("%s: expected %d days, got %d (%v)", s, days, got, err)
+		}
+	}
+	for _, s := range []string{"", "0d", "-3d", "d", "180", "1y"} {
+		if _, err := ParseStoreFor(s); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestExpiryOptions(t *testing.T) {
+	e, days, err := expiryOptions(&cmds.Request{Options: cmds.OptMap{storeForOptionName: "30d"}})
+	if err != nil || days != 30 || e.OnExpiry != ExpiryDelete {
+		t.Fatalf("unexpected expiry %+v for %d days (%v)", e, days, err)
+	}
+	e, _, err = expiryOptions(&cmds.Request{Options: cmds.OptMap{}})
+	if err != nil || e != nil {
+		t.Fatalf("unexpected expiry %+v (%v)", e, err)
+	}
+	for _, opts := range []cmds.OptMap{
+		{onExpiryOptionName: ExpiryDelete},
+		{storeForOptionName: "30d", onExpiryOptionName: "archive"},
+		{storeForOptionName: "30d", retrieveToOptionName: "/archive"},
+	} {
+		if _, _, err := expiryOptions(&cmds.Request{Options: opts}); err == nil {
+			t.Fatalf("%v: expected an error", opts)
+		}
+	}
+}
+
+func TestRunExpiries(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	for _, e := range []*Expiry{
+		{FileHash: "deleted", OnExpiry: ExpiryDelete},
+		{FileHash: "kept", OnExpiry: ExpiryRetrieveAndDelete},
+		{FileHash: "archived", OnExpiry: ExpiryRetrieveAndDelete, RetrieveTo: "/archive"},
+	} {
+		if err := AddExpiry(d, "peer", e, 10, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AddExpiry(d, "peer", &Expiry{FileHash: "cancelled", OnExpiry: ExpiryDelete}, 10, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CancelExpiry(d, "peer", "cancelled"); err != nil {
+		t.Fatal(err)
+	}
+	if expiring, err := Expiring(d, "peer", "cancelled"); err != nil || expiring {
+		t.Fatalf("cancelled expiry expiring: %v (%v)", expiring, err)
+	}
+	if expiring, err := Expiring(d, "peer", "deleted"); err != nil || !expiring {
+		t.Fatalf("expiry not expiring: %v (%v)", expiring, err)
+	}
+
+	retrieved, removed := map[string]int{}, map[string]int{}
+	failRetrieve := true
+	run := func(at time.Time) int {
+		n, err := RunExpiries(d, "peer", at, func(e *Expiry) error {
+			retrieved[e.FileHash]++
+			if failRetrieve {
+				failRetrieve = false
+				return errors.New("no host")
+			}
+			return nil
+		}, func(e *Expiry) error {
+			removed[e.FileHash]++
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// nothing is due before the retrieval lead
+	if n := run(now.Add(24 * time.Hour)); n != 0 || len(retrieved) != 0 {
+		t.Fatalf("expiries run early: %d, %v", n, retrieved)
+	}
+	// the files are retrieved before they expire, a failure is retried
+	end := now.Add(10 * 24 * time.Hour)
+	run(end.Add(-retrieveLead))
+	run(end.Add(-retrieveLead / 2))
+	if retrieved["kept"]+retrieved["archived"] != 3 || len(removed) != 0 {
+		t.Fatalf("unexpected retrievals %v, removals %v", retrieved, removed)
+	}
+	if n := run(end); n != 3 {
+		t.Fatalf("expected 3 expiries, got %d", n)
+	}
+	if removed["deleted"] != 1 || removed["archived"] != 1 || removed["kept"] != 0 || removed["cancelled"] != 0 {
+		t.Fatalf("unexpected removals %v", removed)
+	}
+	es, err := ListExpiries(d, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range es {
+		expected := ExpiryDone
+		if e.FileHash == "cancelled" {
+			expected = ExpiryCancelled
+		}
+		if e.State != expected || e.Error != "" {
+			t.Fatalf("%s: expected %s, got %s (%s)", e.FileHash, expected, e.State, e.Error)
+		}
+	}
+	if n := run(end.Add(time.Hour)); n != 0 {
+		t.Fatalf("expiries run twice: %d", n)
+	}
+}
@@ -0,0 +1,113 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/guard"
+	"github.com/TRON-US/go-btfs/core/guardqueue"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const guardRetryOptionName = "retry"
+
+var StorageGuardCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Inspect the submissions of the renter to the guard.",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"queue": storageGuardQueueCmd,
+	},
+}
+
+// GuardQueueEntry is a file store status waiting to be submitted to the
+// guard.
+type GuardQueueEntry struct {
+	FileHash    string
+	Enqueued    time.Time
+	Attempts    int
+	LastAttempt time.Time
+	NextAttempt time.Time
+	LastError   string `json:",omitempty"`
+}
+
+// GuardQueueRes lists the guard queue, after the retries forced.
+type GuardQueueRes struct {
+	Entries []*GuardQueueEntry
+	// OldestPending is the age of the oldest entry.
+	OldestPending time.Duration
+	Retried       *guardqueue.Result `json:",omitempty"`
+}
+
+var storageGuardQueueCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the file store statuses waiting to be submitted to the guard.",
+		ShortDescription: `
+The file store status of an upload is queued before it is submitted to the
+guard, and stays in the queue until the guard accepts it. The daemon retries
+the failed submissions with a backoff doubling from 1 minute to 1 hour.
+
+--retry submits the statuses of the queue right away, or of the files
+<file-hash> only.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("file-hash", false, true, "Hashes of the files to retry the submission of."),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(guardRetryOptionName, "Retry the submissions now."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		out := &GuardQueueRes{}
+		if retry, _ := req.Options[guardRetryOptionName].(bool); retry {
+			if !n.IsOnline {
+				return fmt.Errorf("retrying the guard submissions requires a running daemon")
+			}
+			if out.Retried, err = guard.RetryQueued(req.Context, n, true, req.Arguments...); err != nil {
+				return err
+			}
+		} else if len(req.Arguments) > 0 {
+			return fmt.Errorf("<file-hash> requires --%s", guardRetryOptionName)
+		}
+		entries, err := guardqueue.List(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		_, out.OldestPending = guardqueue.Stats(entries, time.Now())
+		out.Entries = make([]*GuardQueueEntry, 0, len(entries))
+		for _, e := range entries {
+			out.Entries = append(out.Entries, &GuardQueueEntry{
+				FileHash:    e.FileHash,
+				Enqueued:    e.Enqueued,
+				Attempts:    e.Attempts,
+				LastAttempt: e.LastAttempt,
+				NextAttempt: e.NextAttempt,
+				LastError:   e.LastError,
+			})
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: GuardQueueRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *GuardQueueRes) error {
+			if r := out.Retried; r != nil {
+				fmt.Fprintf(w, "retried: %d submitted, %d failed\n", r.Submitted, r.Failed)
+			}
+			fmt.Fprintf(w, "%d pending, oldest %s\n", len(out.Entries), out.OldestPending.Round(time.Second))
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "FILE\tENQUEUED\tATTEMPTS\tNEXT ATTEMPT\tLAST ERROR")
+			for _, e := range out.Entries {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", e.FileHash, e.Enqueued.Format(time.RFC3339),
+					e.Attempts, e.NextAttempt.Format(time.RFC3339), e.LastError)
+			}
+			return tw.Flush()
+		}),
+	},
+}
@@ -0,0 +1,438 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/clock"
+	"github.com/TRON-US/go-btfs/core/cloudimport"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+
+	chunker "github.com/TRON-US/go-btfs-chunker"
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	config "github.com/TRON-US/go-btfs-config"
+	files "github.com/TRON-US/go-btfs-files"
+	iface "github.com/TRON-US/interface-go-btfs-core"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/google/uuid"
+)
+
+const (
+	importCopiesOptionName   = "copies"
+	importParallelOptionName = "parallel"
+	importEndpointOptionName = "endpoint"
+	importRegionOptionName   = "region"
+	importObjectsOptionName  = "objects"
+
+	// importUploadTimeout bounds an upload session started by an import,
+	// as the RunTimeout of 'btfs storage upload' does.
+	importUploadTimeout = 15 * time.Minute
+)
+
+// importPollInterval is how often the upload session of an object is
+// checked for completion.
+var importPollInterval = 10 * time.Second
+
+// ImportRes is an import job, with its objects counted by state.
+type ImportRes struct {
+	Job     *cloudimport.Job
+	Summary *cloudimport.Summary `json:",omitempty"`
+	Objects []*cloudimport.Entry `json:",omitempty"`
+}
+
+// ImportListRes lists the import jobs.
+type ImportListRes struct {
+	Jobs []*cloudimport.Job
+}
+
+var StorageImportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Import the objects of a cloud storage into decentralized storage.",
+		ShortDescription: `
+Migrates the objects of a cloud storage to BTFS hosts. Each object is
+streamed into the node, reed-solomon encoded, then uploaded:
+
+    $ btfs storage import s3://bucket/prefix --copies 3
+    $ btfs storage import gs://bucket/prefix
+    $ btfs storage import https://example.com/archive.tar
+
+The sources are s3://bucket/prefix, gs://bucket/prefix and the http(s) URL
+of a single object. S3 is accessed with AWS_ACCESS_KEY_ID,
+AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION from the environment
+of the daemon, S3 compatible storages with --endpoint; GCS with
+GOOGLE_OAUTH_ACCESS_TOKEN. Public buckets need no credentials.
+
+--copies is the redundancy of the encoding: the hosts store that many times
+the size of an object, and any share of the shards 1/copies recovers it.
+--parallel objects are imported at once, their upload sessions going
+through the upload queue, see 'btfs storage upload queue'.
+
+The command returns the ID of the job, which runs in the daemon. Its ledger
+records how far each object went: 'btfs storage import status' shows it,
+'btfs storage import resume' restarts a job failed, canceled or interrupted
+by a restart of the daemon where it stopped.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"status": storageImportStatusCmd,
+		"ls":     storageImportLsCmd,
+		"resume": storageImportResumeCmd,
+		"cancel": storageImportCancelCmd,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("source", true, false, "s3://bucket/prefix, gs://bucket/prefix or http(s) URL to import."),
+	},
+	Options: []cmds.Option{
+		cmds.IntOption(importCopiesOptionName, "Redundancy of the encoding of the objects.").WithDefault(cloudimport.DefaultCopies),
+		cmds.IntOption(importParallelOptionName, "Number of objects imported at once.").WithDefault(cloudimport.DefaultParallel),
+		cmds.StringOption(importEndpointOptionName, "Endpoint of an S3 compatible storage, or of GCS."),
+		cmds.StringOption(importRegionOptionName, "S3 region. Default: AWS_REGION, or us-east-1."),
+		cmds.Int64Option(uploadPriceOptionName, "p", "Max price per GiB per day of storage in µBTT (=0.000001BTT)."),
+		cmds.IntOption(storageLengthOptionName, "len", "File storage period on hosts in days.").WithDefault(defaultStorageLength),
+		cmds.IntOption(priorityOptionName, "Priority of the upload sessions in the upload queue, higher first.").WithDefault(0),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if err := clock.BeforeSigning(req.Context, n.Repo); err != nil {
+			return err
+		}
+		j := &cloudimport.Job{Source: req.Arguments[0]}
+		j.Options.Endpoint, _ = req.Options[importEndpointOptionName].(string)
+		j.Options.Region, _ = req.Options[importRegionOptionName].(string)
+		j.Copies, _ = req.Options[importCopiesOptionName].(int)
+		j.Parallel, _ = req.Options[importParallelOptionName].(int)
+		j.StorageLength, _ = req.Options[storageLengthOptionName].(int)
+		j.Price, _ = req.Options[uploadPriceOptionName].(int64)
+		j.Priority, _ = req.Options[priorityOptionName].(int)
+		if j.Price < 0 || j.StorageLength <= 0 {
+			return errors.New("the price and the storage length must be positive")
+		}
+		store := cloudimport.ForNode(n)
+		j, err = store.NewJob(j, time.Now())
+		if err != nil {
+			return err
+		}
+		up, err := newImportUploader(req, env, n, j)
+		if err != nil {
+			return err
+		}
+		go runImport(store, j.ID, up)
+		return cmds.EmitOnce(res, &ImportRes{Job: j})
+	},
+	Type: ImportRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ImportRes) error {
+			return writeImport(w, out)
+		}),
+	},
+}
+
+var storageImportStatusCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the ledger of an import job.",
+		ShortDescription: `
+Shows the state of the job and its objects counted by state: pending,
+added to the node, uploading, stored or failed. --objects lists them with
+their file hash and upload session.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("job-id", true, false, "ID of the import job."),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(importObjectsOptionName, "List the objects of the job."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		store := cloudimport.ForNode(n)
+		j, err := store.Get(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		entries, err := store.Entries(j.ID)
+		if err != nil {
+			return err
+		}
+		out := &ImportRes{Job: j, Summary: cloudimport.Summarize(entries)}
+		if all, _ := req.Options[importObjectsOptionName].(bool); all {
+			out.Objects = entries
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: ImportRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ImportRes) error {
+			return writeImport(w, out)
+		}),
+	},
+}
+
+var storageImportLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the import jobs, the latest first.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		jobs, err := cloudimport.ForNode(n).List()
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &ImportListRes{Jobs: jobs})
+	},
+	Type: ImportListRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ImportListRes) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "JOB\tSTATUS\tSOURCE\tCREATED")
+			for _, j := range out.Jobs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", j.ID, j.Status, j.Source, j.Created.Format(time.RFC3339))
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var storageImportResumeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Resume an import job where it stopped.",
+		ShortDescription: `
+Runs the job again: the objects stored are skipped, the ones added to the
+node are uploaded without being fetched again, and the upload sessions
+still running are waited for.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("job-id", true, false, "ID of the import job."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if err := clock.BeforeSigning(req.Context, n.Repo); err != nil {
+			return err
+		}
+		store := cloudimport.ForNode(n)
+		j, err := store.Get(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		if cloudimport.Running(j.ID) {
+			return cloudimport.ErrRunning
+		}
+		if j.Status == cloudimport.JobDone {
+			return fmt.Errorf("import job %s is done", j.ID)
+		}
+		up, err := newImportUploader(req, env, n, j)
+		if err != nil {
+			return err
+		}
+		go runImport(store, j.ID, up)
+		return cmds.EmitOnce(res, &ImportRes{Job: j})
+	},
+	Type: ImportRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ImportRes) error {
+			_, err := fmt.Fprintf(w, "resumed %s\n", out.Job.ID)
+			return err
+		}),
+	},
+}
+
+var storageImportCancelCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Cancel a running import job.",
+		ShortDescription: `
+Stops the job, which can be resumed later. The upload sessions already
+started go on.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("job-id", true, false, "ID of the import job."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		j, err := cloudimport.ForNode(n).Get(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		if !cloudimport.Cancel(j.ID) {
+			return fmt.Errorf("import job %s is not running", j.ID)
+		}
+		return cmds.EmitOnce(res, &ImportRes{Job: j})
+	},
+	Type: ImportRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ImportRes) error {
+			_, err := fmt.Fprintf(w, "canceled %s\n", out.Job.ID)
+			return err
+		}),
+	},
+}
+
+func writeImport(w io.Writer, out *ImportRes) error {
+	j := out.Job
+	fmt.Fprintf(w, "job %s: %s\n", j.ID, j.Status)
+	fmt.Fprintf(w, "source: %s, %d copies, %d in parallel\n", j.Source, j.Copies, j.Parallel)
+	if j.Error != "" {
+		fmt.Fprintf(w, "error: %s\n", j.Error)
+	}
+	if s := out.Summary; s != nil {
+		fmt.Fprintf(w, "objects: %d, %s stored of %s\n", s.Objects,
+			humanize.Bytes(uint64(s.StoredBytes)), humanize.Bytes(uint64(s.Bytes)))
+		for _, state := range []string{cloudimport.ObjectPending, cloudimport.ObjectAdded, cloudimport.ObjectUploading,
+			cloudimport.ObjectStored, cloudimport.ObjectFailed} {
+			if c := s.States[state]; c > 0 {
+				fmt.Fprintf(w, "  %s: %d\n", state, c)
+			}
+		}
+	}
+	if len(out.Objects) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "OBJECT\tSTATE\tFILE\tSESSION\tERROR")
+	for _, e := range out.Objects {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Key, e.State, e.FileHash, e.SessionID, e.Error)
+	}
+	return tw.Flush()
+}
+
+func runImport(store *cloudimport.Store, id string, up cloudimport.Uploader) {
+	if err := store.Run(context.Background(), id, up); err != nil {
+		log.Errorf("import job %s: %s", id, err)
+	}
+}
+
+// importUploader stores the objects of an import job like 'btfs storage
+// upload' does.
+type importUploader struct {
+	req *cmds.Request
+	n   *core.IpfsNode
+	cfg *config.Config
+	api iface.CoreAPI
+	job *cloudimport.Job
+}
+
+func newImportUploader(req *cmds.Request, env cmds.Environment, n *core.IpfsNode,
+	j *cloudimport.Job) (*importUploader, error) {
+	cfg, err := n.Repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	api, err := cmdenv.GetApi(env, req)
+	if err != nil {
+		return nil, err
+	}
+	// the upload options of the job, read by GetPriceAndMinStorageLength
+	req.Options[storageLengthOptionName] = j.StorageLength
+	if j.Price > 0 {
+		req.Options[uploadPriceOptionName] = j.Price
+	}
+	return &importUploader{req: req, n: n, cfg: cfg, api: api, job: j}, nil
+}
+
+func (u *importUploader) params(ctx context.Context) *helper.ContextParams {
+	return &helper.ContextParams{Req: u.req, Ctx: ctx, N: u.n, Cfg: u.cfg, Api: u.api}
+}
+
+func (u *importUploader) Add(ctx context.Context, r io.Reader, copies int) (string, error) {
+	data := uint64(chunker.DefaultReedSolomonDataShards)
+	p, err := u.api.Unixfs().Add(ctx, files.NewReaderFile(r),
+		options.Unixfs.Chunker(fmt.Sprintf("%s-%d-%d-%d", chunker.PrefixForReedSolomon,
+			data, data*uint64(copies-1), chunker.DefaultReedSolomonShardSize)),
+		options.Unixfs.Pin(true))
+	if err != nil {
+		return "", err
+	}
+	return p.Cid().String(), nil
+}
+
+func (u *importUploader) Upload(ctx context.Context, fileHash string) (string, error) {
+	// the session outlives the import, its context is bounded as the
+	// sessions of 'btfs storage upload' are
+	sctx, cancel := context.WithTimeout(context.Background(), importUploadTimeout)
+	defer cancel()
+	p := u.params(sctx)
+	signer, err := helper.GetSigner(p)
+	if err != nil {
+		return "", err
+	}
+	shardHashes, fileSize, shardSize, err := helper.GetShardHashes(p, fileHash)
+	if err != nil {
+		return "", err
+	}
+	price, storageLength, err := helper.GetPriceAndMinStorageLength(p)
+	if err != nil {
+		return "", err
+	}
+	ssId := uuid.New().String()
+	rss, err := sessions.GetRenterSession(p, ssId, fileHash, shardHashes)
+	if err != nil {
+		return "", err
+	}
+	shardIndexes := make([]int, len(rss.ShardHashes))
+	for i := range shardIndexes {
+		shardIndexes[i] = i
+	}
+	ticket, err := enqueue(u.n.Repo, ssId, u.job.Priority, 0)
+	if err != nil {
+		return "", err
+	}
+	UploadShard(rss, helper.GetHostsProvider(p, make([]string, 0)), price, shardSize, storageLength, false,
+		signer.ID(), fileSize, shardIndexes, nil, ticket)
+	return ssId, nil
+}
+
+func (u *importUploader) Wait(ctx context.Context, ssId string) error {
+	active := false
+	for _, rss := range sessions.CachedRenterSessions() {
+		if rss.SsId == ssId {
+			active = true
+			break
+		}
+	}
+	ticker := time.NewTicker(importPollInterval)
+	defer ticker.Stop()
+	for {
+		rss, err := sessions.GetRenterSession(u.params(ctx), ssId, "", make([]string, 0))
+		if err != nil {
+			return err
+		}
+		st, err := rss.Status()
+		if err != nil {
+			return err
+		}
+		switch st.Status {
+		case sessions.RssCompleteStatus:
+			return nil
+		case sessions.RssErrorStatus:
+			return fmt.Errorf("upload session %s failed: %s", ssId, st.Message)
+		}
+		if !active {
+			return cloudimport.ErrSessionLost
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -7,19 +7,25 @@ import (
 	"strconv"
 	"time"
 
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	guardclient "github.com/TRON-US/go-btfs/core/clients/guard"
+	"github.com/TRON-US/go-btfs/core/clock"
 	"github.com/TRON-US/go-btfs/core/commands/storage/challenge"
+	"github.com/TRON-US/go-btfs/core/commands/storage/collateral"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/tenants"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/escrow"
 	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/core/ingest"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	"github.com/tron-us/go-btfs-common/crypto"
 	"github.com/tron-us/go-btfs-common/ledger"
 	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 	"github.com/tron-us/protobuf/proto"
 
 	"github.com/alecthomas/units"
@@ -64,6 +70,13 @@ the shard and replies back to client for the next challenge step.`,
 		if err != nil {
 			return err
 		}
+		maxShardSize, err := uh.HostMaxShardSize(ctxParams.N.Repo)
+		if err != nil {
+			return err
+		}
+		if !(&uh.ShardLimits{MaxShardSize: maxShardSize}).Accepts(shardSize) {
+			return fmt.Errorf("shard size invalid: want: <=%d, got: %d", maxShardSize, shardSize)
+		}
 		accept, err := hm.AcceptContract(ctxParams.N.Repo.Datastore(), ctxParams.N.Identity.String(), shardSize)
 		if err != nil {
 			return err
@@ -80,13 +93,32 @@ the shard and replies back to client for the next challenge step.`,
 		if err != nil {
 			return err
 		}
-		if uint64(price) < settings.StoragePriceAsk {
-			return fmt.Errorf("price invalid: want: >=%d, got: %d", settings.StoragePriceAsk, price)
-		}
 		requestPid, ok := remote.GetStreamRequestRemotePeerID(req, ctxParams.N)
 		if !ok {
 			return fmt.Errorf("fail to get peer ID from request")
 		}
+		// the contracts of a host with tenants go to one of them, at its
+		// price and within its pool
+		tenant, err := tenants.AssignContract(ctxParams.N.Repo.Datastore(), ctxParams.N.Identity.Pretty(),
+			requestPid.Pretty(), price, shardSize, time.Now())
+		if err != nil {
+			return err
+		}
+		priceAsk := settings.StoragePriceAsk
+		if tenant != nil && tenant.Price > 0 {
+			priceAsk = uint64(tenant.Price)
+		}
+		if uint64(price) < priceAsk {
+			// renewals get the price proposed for them
+			proposed, err := contracts.ProposedPrice(ctxParams.N.Repo.Datastore(), ctxParams.N.Identity.Pretty(),
+				requestPid.Pretty(), req.Arguments[2], price)
+			if err != nil {
+				return err
+			}
+			if !proposed {
+				return fmt.Errorf("price invalid: want: >=%d, got: %d", priceAsk, price)
+			}
+		}
 		storeLen, err := strconv.Atoi(req.Arguments[6])
 		if err != nil {
 			return err
@@ -154,6 +186,17 @@ the shard and replies back to client for the next challenge step.`,
 			return errors.New("invalid contract")
 		}
 
+		// a skewed clock fails the contract at guard and escrow, refused
+		// here with the cause
+		err = clock.CheckPeer(ctxParams.N.Repo, fmt.Sprintf("the contract of renter %s", peerId),
+			halfSignedGuardContract.LastModifyTime)
+		if err != nil {
+			return err
+		}
+		if err := clock.BeforeSigning(ctxParams.Ctx, ctxParams.N.Repo); err != nil {
+			return err
+		}
+
 		// Sign on the contract
 		signedEscrowContractBytes, err := signEscrowContractAndMarshal(escrowContract, halfSignedEscrowContract,
 			ctxParams.N.PrivateKey)
@@ -164,7 +207,32 @@ the shard and replies back to client for the next challenge step.`,
 		if err != nil {
 			return err
 		}
+		ingestCfg, err := ingest.Load(ctxParams.N.Repo)
+		if err != nil {
+			return err
+		}
+		ticket, err := ingest.Default.Admit(ingestCfg, shardSize)
+		if err != nil {
+			return err
+		}
+		if tenant != nil {
+			err = tenants.PutContract(ctxParams.N.Repo.Datastore(), ctxParams.N.Identity.Pretty(), &tenants.Contract{
+				ContractID: escrowContract.ContractId,
+				Tenant:     tenant.Name,
+				Renter:     requestPid.Pretty(),
+				Size:       shardSize,
+				Amount:     guardContractMeta.Amount,
+				Start:      guardContractMeta.RentStart,
+				End:        guardContractMeta.RentEnd,
+			})
+			if err != nil {
+				ticket.Release(0, false)
+				return err
+			}
+		}
 		go func() {
+			defer ticket.Release(0, false)
+			contractPaid := false
 			tmp := func() error {
 				shard, err := sessions.GetHostShard(ctxParams, escrowContract.ContractId)
 				if err != nil {
@@ -194,6 +262,7 @@ the shard and replies back to client for the next challenge step.`,
 				if !paid {
 					return fmt.Errorf("contract is not paid: %s", escrowContract.ContractId)
 				}
+				contractPaid = true
 				tmp := new(guardpb.Contract)
 				err = proto.Unmarshal(signedGuardContractBytes, tmp)
 				if err != nil {
@@ -203,7 +272,9 @@ the shard and replies back to client for the next challenge step.`,
 				if err != nil {
 					return err
 				}
+				start := time.Now()
 				err = downloadShardFromClient(ctxParams, halfSignedGuardContract, req.Arguments[1], shardHash)
+				ticket.Release(time.Since(start), err == nil)
 				if err != nil {
 					return err
 				}
@@ -224,18 +295,15 @@ the shard and replies back to client for the next challenge step.`,
 				// req.Context obsolete
 				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 				defer cancel()
+				gc := guardclient.New(ctxParams.Cfg.Services.GuardDomain)
 				var question *guardpb.RequestChallengeQuestion
-				err = grpc.GuardClient(ctxParams.Cfg.Services.GuardDomain).WithContext(ctx,
-					func(ctx context.Context, client guardpb.GuardServiceClient) error {
-						for i := 0; i < 3; i++ {
-							question, err = client.RequestChallenge(ctx, in)
-							if err == nil {
-								break
-							}
-							time.Sleep(30 * time.Second)
-						}
-						return err
-					})
+				for i := 0; i < 3; i++ {
+					question, err = gc.RequestChallenge(ctx, in)
+					if err == nil {
+						break
+					}
+					time.Sleep(30 * time.Second)
+				}
 				if err != nil {
 					return err
 				}
@@ -282,14 +350,7 @@ the shard and replies back to client for the next challenge step.`,
 					return err
 				}
 				resp.Signature = sig
-				err = grpc.GuardClient(ctxParams.Cfg.Services.GuardDomain).WithContext(ctx,
-					func(ctx context.Context, client guardpb.GuardServiceClient) error {
-						_, err := client.ResponseChallenge(ctx, resp)
-						if err != nil {
-							return err
-						}
-						return nil
-					})
+				_, err = gc.ResponseChallenge(ctx, resp)
 				if err != nil {
 					log.Debug(err)
 					return err
@@ -297,11 +358,21 @@ the shard and replies back to client for the next challenge step.`,
 				if err := shard.Complete(); err != nil {
 					return err
 				}
-				return nil
+				return collateral.Post(ctx, ctxParams.N, ctxParams.Api, ctxParams.Cfg, escrowContract.ContractId,
+					requestPid, guardContractMeta.RentEnd)
 			}()
 			if tmp != nil {
 				log.Debug(tmp)
 			}
+			// the pool of the tenant is freed of the contracts never paid
+			if !contractPaid && tenant != nil {
+				err := tenants.DeleteContract(ctxParams.N.Repo.Datastore(), ctxParams.N.Identity.Pretty(),
+					escrowContract.ContractId)
+				if err != nil {
+					log.Errorf("failed to drop the contract %s of tenant %s: %s", escrowContract.ContractId,
+						tenant.Name, err)
+				}
+			}
 		}()
 		return nil
 	},
@@ -411,25 +482,16 @@ func downloadShardFromClient(ctxParams *uh.ContextParams, guardContract *guardpb
 }
 
 func isPaidin(ctxParams *uh.ContextParams, contractID *escrowpb.SignedContractID) (bool, error) {
-	var signedPayinRes *escrowpb.SignedPayinStatus
 	ctx, _ := helper.NewGoContext(ctxParams.Ctx)
-	err := grpc.EscrowClient(ctxParams.Cfg.Services.EscrowDomain).WithContext(ctx,
-		func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
-			res, err := client.IsPaid(ctx, contractID)
-			if err != nil {
-				return err
-			}
-			err = escrow.VerifyEscrowRes(ctxParams.Cfg, res.Status, res.EscrowSignature)
-			if err != nil {
-				return err
-			}
-			signedPayinRes = res
-			return nil
-		})
+	res, err := escrowclient.New(ctxParams.Cfg.Services.EscrowDomain).IsPaid(ctx, contractID)
+	if err != nil {
+		return false, err
+	}
+	err = escrow.VerifyEscrowRes(ctxParams.Cfg, res.Status, res.EscrowSignature)
 	if err != nil {
 		return false, err
 	}
-	return signedPayinRes.Status.Paid, nil
+	return res.Status.Paid, nil
 }
 
 func signContractID(id string, privKey ic.PrivKey) (*escrowpb.SignedContractID, error) {
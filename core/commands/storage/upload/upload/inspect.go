@@ -0,0 +1,303 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/uploadq"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+
+	"github.com/ipfs/go-datastore"
+)
+
+const (
+	graphOptionName      = "graph"
+	unstickOptionName    = "unstick"
+	staleAfterOptionName = "stale-after"
+
+	// DefaultStaleAfter is the time without progress after which a session
+	// is stuck.
+	DefaultStaleAfter = time.Hour
+)
+
+// Actions unsticking the renter sessions.
+const (
+	// ActionResume restarts the guard check of a session waiting for the
+	// confirmation of its upload.
+	ActionResume = "resume"
+	// ActionFail moves a session to the error state, cancelling what is
+	// left of it.
+	ActionFail = "fail"
+)
+
+var StorageSessionsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Inspect the upload sessions of the renter.",
+		ShortDescription: `
+Inspects the state machines of the upload sessions and of their contracts,
+and recovers the sessions stuck in a state.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"inspect": storageSessionsInspectCmd,
+	},
+}
+
+var storageSessionsInspectCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the state machine of an upload session.",
+		ShortDescription: `
+Shows the state of the upload session <session-id> and of the contracts of
+its shards, and the transitions of the session with their time. --graph
+draws the states of the session up to completion.
+
+A session is stuck when it waits for the guard to confirm its upload but no
+guard check runs, e.g. after its callback was lost, or when it made no
+progress in any other state for --stale-after. --unstick then takes the
+action reported: 'resume' restarts the guard check, 'fail' moves the
+session to the error state so that the file can be uploaded again.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("session-id", true, false, "ID of the upload session."),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption(graphOptionName, "Draw the state machine of the session."),
+		cmds.BoolOption(unstickOptionName, "Take the action unsticking the session, if stuck."),
+		cmds.StringOption(staleAfterOptionName, "Time without progress after which a session is stuck.").WithDefault(DefaultStaleAfter.String()),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		staleAfter, err := time.ParseDuration(req.Options[staleAfterOptionName].(string))
+		if err != nil || staleAfter <= 0 {
+			return fmt.Errorf("invalid --%s %q", staleAfterOptionName, req.Options[staleAfterOptionName])
+		}
+		ctxParams, err := helper.ExtractContextParams(req, env)
+		if err != nil {
+			return err
+		}
+		ssId := req.Arguments[0]
+		d := ctxParams.N.Repo.Datastore()
+		self := ctxParams.N.Identity.Pretty()
+		// loading a session creates it, check it exists first
+		if ok, err := d.Has(datastore.NewKey(fmt.Sprintf(sessions.RenterSessionStatusKey, self, ssId))); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("no upload session %s", ssId)
+		}
+		rss, err := sessions.GetRenterSession(ctxParams, ssId, "", make([]string, 0))
+		if err != nil {
+			return err
+		}
+		out, err := inspect(rss, time.Now(), staleAfter)
+		if err != nil {
+			return err
+		}
+		if unstick, _ := req.Options[unstickOptionName].(bool); unstick && out.Action != "" {
+			if out.Unstuck, err = unstickSession(rss, out); err != nil {
+				return err
+			}
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: InspectRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *InspectRes) error {
+			fmt.Fprintf(w, "session %s of %s: %s since %s\n", out.SessionID, out.FileHash, out.Status,
+				out.LastUpdated.Format(time.RFC3339))
+			if out.Message != "" {
+				fmt.Fprintf(w, "  %s\n", out.Message)
+			}
+			if out.QueuePosition > 0 {
+				fmt.Fprintf(w, "queued #%d\n", out.QueuePosition)
+			}
+			if out.Stuck != "" {
+				fmt.Fprintf(w, "stuck: %s; action: %s\n", out.Stuck, out.Action)
+			}
+			if out.Unstuck != "" {
+				fmt.Fprintf(w, "unstuck: %s\n", out.Unstuck)
+			}
+			if graph, _ := req.Options[graphOptionName].(bool); graph {
+				fmt.Fprintln(w)
+				renderGraph(w, out)
+			}
+			fmt.Fprintln(w)
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "SHARD\tSTATUS\tGUARD\tHOST\tCONTRACT")
+			for _, s := range out.Shards {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", s.Index, s.Status, dash(s.GuardState), dash(s.Host),
+					dash(s.ContractID))
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// InspectRes is the state machine of a renter session and its shards.
+type InspectRes struct {
+	SessionID     string
+	FileHash      string
+	Status        string
+	Message       string
+	LastUpdated   time.Time
+	QueuePosition int `json:",omitempty"`
+	// Path are the states of a session which completes, in order.
+	Path        []string
+	Transitions []*sessions.Transition
+	Shards      []*InspectShard
+	// Stuck is why the session is stuck, Action the action unsticking it.
+	Stuck  string `json:",omitempty"`
+	Action string `json:",omitempty"`
+	// Unstuck is the outcome of the action, when taken.
+	Unstuck string `json:",omitempty"`
+}
+
+// InspectShard is the state of the contract of a shard of a session.
+type InspectShard struct {
+	Index      int
+	Hash       string
+	Status     string
+	ContractID string `json:",omitempty"`
+	Host       string `json:",omitempty"`
+	// GuardState is the last state of the contract reported by the guard.
+	GuardState string `json:",omitempty"`
+}
+
+func inspect(rss *sessions.RenterSession, now time.Time, staleAfter time.Duration) (*InspectRes, error) {
+	status, err := rss.Status()
+	if err != nil {
+		return nil, err
+	}
+	ts, err := sessions.Transitions(rss.CtxParams.N.Repo.Datastore(), rss.PeerId, rss.SsId)
+	if err != nil {
+		return nil, err
+	}
+	out := &InspectRes{
+		SessionID:     rss.SsId,
+		FileHash:      rss.Hash,
+		Status:        status.Status,
+		Message:       status.Message,
+		LastUpdated:   status.LastUpdated,
+		QueuePosition: uploadq.Default.Position(rss.SsId),
+		Path:          sessions.RssPath(),
+		Transitions:   ts,
+	}
+	for i, h := range rss.ShardHashes {
+		shard, err := sessions.GetRenterShard(rss.CtxParams, rss.SsId, h, i)
+		if err != nil {
+			return nil, err
+		}
+		st, err := shard.Status()
+		if err != nil {
+			return nil, err
+		}
+		s := &InspectShard{Index: i, Hash: h, Status: st.Status}
+		if cs, err := shard.Contracts(); err == nil && cs.SignedGuardContract != nil {
+			s.ContractID = cs.SignedGuardContract.ContractId
+			s.Host = cs.SignedGuardContract.HostPid
+		}
+		if info, err := shard.GetAdditionalInfo(); err == nil {
+			s.GuardState = info.Info
+		}
+		out.Shards = append(out.Shards, s)
+	}
+	out.Stuck, out.Action = stuck(out.Status, out.LastUpdated, now, staleAfter, Waiting(rss.SsId))
+	return out, nil
+}
+
+// stuck returns why a session in status since updated is stuck at now, and
+// the action unsticking it, empty when it is not stuck. waiting reports
+// whether the session waits for the guard to confirm its upload.
+func stuck(status string, updated, now time.Time, staleAfter time.Duration, waiting bool) (string, string) {
+	switch {
+	case status == sessions.RssCompleteStatus || status == sessions.RssErrorStatus || waiting:
+		return "", ""
+	case status == sessions.RssWaitUploadReqSignedStatus:
+		return "no guard check confirms the upload, its callback was lost", ActionResume
+	case now.Sub(updated) >= staleAfter:
+		return fmt.Sprintf("no progress for %s", now.Sub(updated).Round(time.Second)), ActionFail
+	}
+	return "", ""
+}
+
+func unstickSession(rss *sessions.RenterSession, out *InspectRes) (string, error) {
+	switch out.Action {
+	case ActionResume:
+		go func() {
+			if err := ResumeWaitUploadOnSigning(rss); err != nil {
+				log.Errorf("resume the guard check of session %s: %s", rss.SsId, err)
+			}
+		}()
+		return "guard check restarted", nil
+	case ActionFail:
+		err := rss.To(sessions.RssToErrorEvent, fmt.Errorf("stuck in %s, failed by 'btfs storage sessions inspect --%s'",
+			out.Status, unstickOptionName))
+		if err != nil {
+			return "", err
+		}
+		return "moved to " + sessions.RssErrorStatus, nil
+	}
+	return "", errors.New("unknown action " + out.Action)
+}
+
+// renderGraph draws the states of the session of out, from init to
+// completion, with the time they were entered.
+func renderGraph(w io.Writer, out *InspectRes) {
+	entered := map[string]*sessions.Transition{}
+	for _, t := range out.Transitions {
+		entered[t.To] = t
+	}
+	// the states up to current were gone through
+	current := out.Status
+	if t, ok := entered[sessions.RssErrorStatus]; ok && current == sessions.RssErrorStatus {
+		current = t.From
+	}
+	reached := -1
+	for i, s := range out.Path {
+		if s == current {
+			reached = i
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, s := range out.Path {
+		if i > 0 {
+			edge := " :"
+			if i <= reached {
+				edge = " |"
+			}
+			if t, ok := entered[s]; ok {
+				fmt.Fprintf(tw, "%s  %s\t%s\n", edge, t.Event, t.Time.Format(time.RFC3339))
+			} else {
+				fmt.Fprintf(tw, "%s\t\n", edge)
+			}
+		}
+		mark := "[ ]"
+		switch {
+		case s == out.Status:
+			mark = "[*]"
+		case i <= reached:
+			mark = "[x]"
+		}
+		fmt.Fprintf(tw, "%s %s\t\n", mark, s)
+		if s == current && out.Status == sessions.RssErrorStatus {
+			t := entered[sessions.RssErrorStatus]
+			fmt.Fprintf(tw, " \\  %s\t%s\n", t.Event, t.Time.Format(time.RFC3339))
+			fmt.Fprintf(tw, "    [*] %s: %s\t\n", sessions.RssErrorStatus, t.Message)
+		}
+	}
+	if out.Status == sessions.RssErrorStatus && reached < 0 {
+		fmt.Fprintf(tw, "[*] %s\t\n", sessions.RssErrorStatus)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,58 @@
+package upload
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+)
+
+func TestStuck(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		status  string
+		age     time.Duration
+		waiting bool
+		action  string
+	}{
+		{sessions.RssCompleteStatus, 48 * time.Hour, false, ""},
+		{sessions.RssErrorStatus, 48 * time.Hour, false, ""},
+		{sessions.RssWaitUploadReqSignedStatus, time.Minute, true, ""},
+		{sessions.RssWaitUploadReqSignedStatus, time.Minute, false, ActionResume},
+		{sessions.RssPayStatus, time.Minute, false, ""},
+		{sessions.RssPayStatus, 2 * time.Hour, false, ActionFail},
+		{sessions.RssWaitUploadStatus, 2 * time.Hour, true, ""},
+	} {
+		reason, action := stuck(tc.status, now.Add(-tc.age), now, DefaultStaleAfter, tc.waiting)
+		if action != tc.action || (action == "") != (reason == "") {
+			t.Fatalf("%s for %s: expected action %q, got %q (%s)", tc.status, tc.age, tc.action, action, reason)
+		}
+	}
+}
+
+func TestRenderGraph(t *testing.T) {
+	now := time.Now()
+	out := &InspectRes{
+		Status: sessions.RssErrorStatus,
+		Path:   sessions.RssPath(),
+		Transitions: []*sessions.Transition{
+			{Event: sessions.RssToSubmitEvent, From: sessions.RssInitStatus, To: sessions.RssSubmitStatus, Time: now},
+			{Event: sessions.RssToErrorEvent, From: sessions.RssSubmitStatus, To: sessions.RssErrorStatus, Time: now,
+				Message: "not enough balance"},
+		},
+	}
+	if out.Path[0] != sessions.RssInitStatus || out.Path[len(out.Path)-1] != sessions.RssCompleteStatus {
+		t.Fatalf("unexpected path %v", out.Path)
+	}
+	var b bytes.Buffer
+	renderGraph(&b, out)
+	g := b.String()
+	for _, want := range []string{"[x] init", "[x] submit", sessions.RssToSubmitEvent, "[*] error: not enough balance",
+		"[ ] complete"} {
+		if !strings.Contains(g, want) {
+			t.Fatalf("expected %q in\n%s", want, g)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package upload
+
+import (
+	"fmt"
+
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// StorageUploadLimitsCmd advertises the shard sizes a host stores to the
+// renters selecting it, through the remote API.
+var StorageUploadLimitsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Get the shard sizes stored by this host.",
+		ShortDescription: `
+Called by renters selecting hosts for their shards, answers the largest
+shard this host stores, set in config option ShardSize.HostMax. Zero means
+any size.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		ctxParams, err := uh.ExtractContextParams(req, env)
+		if err != nil {
+			return err
+		}
+		if !ctxParams.Cfg.Experimental.StorageHostEnabled {
+			return fmt.Errorf("storage host api not enabled")
+		}
+		max, err := uh.HostMaxShardSize(ctxParams.N.Repo)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &uh.ShardLimits{MaxShardSize: max})
+	},
+	Type: uh.ShardLimits{},
+}
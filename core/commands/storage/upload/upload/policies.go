@@ -0,0 +1,161 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/uploadpolicy"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+)
+
+const policyOptionName = "policy"
+
+// applyPolicy applies the upload policy of req, --policy or the default
+// one, to its options, and returns it with its name, nil when the upload
+// follows none. The options left to their defaults take the settings of the
+// policy; when the config locks it, the options set must comply with it.
+func applyPolicy(req *cmds.Request, ctxParams *helper.ContextParams, fileHash string) (string,
+	*uploadpolicy.Policy, error) {
+	cfg, err := uploadpolicy.Load(ctxParams.N.Repo)
+	if err != nil {
+		return "", nil, err
+	}
+	name, _ := req.Options[policyOptionName].(string)
+	name, p, err := cfg.Select(name)
+	if err != nil || p == nil {
+		return "", nil, err
+	}
+
+	// the options equal to their defaults are taken as left to them
+	o := &uploadpolicy.Options{}
+	if days, _ := req.Options[storageLengthOptionName].(int); days != defaultStorageLength {
+		o.StorageLength = days
+	} else if _, ok := req.Options[storeForOptionName].(string); ok {
+		o.StorageLength = days
+	}
+	if copies, _ := req.Options[replicationFactorOptionName].(int); copies != defaultRepFactor {
+		o.Copies = copies
+	}
+	o.Price, _ = req.Options[uploadPriceOptionName].(int64)
+	if mode, _ := req.Options[hostSelectModeOptionName].(string); mode == "custom" {
+		o.CustomHosts = true
+	}
+	if p.Encrypted {
+		meta, err := ctxParams.Api.Unixfs().GetMetadata(ctxParams.Ctx, path.New(fileHash))
+		o.Encrypted = err == nil && uploadpolicy.Encrypted(meta)
+	}
+	if err := p.Apply(name, o, cfg.Locked); err != nil {
+		return "", nil, err
+	}
+
+	if o.StorageLength > 0 {
+		req.Options[storageLengthOptionName] = o.StorageLength
+	}
+	if o.Copies > 0 {
+		req.Options[replicationFactorOptionName] = o.Copies
+	}
+	if o.Price > 0 {
+		req.Options[uploadPriceOptionName] = o.Price
+	}
+	return name, p, nil
+}
+
+// filterHosts only lets hp choose the hosts placed by the policy p.
+func filterHosts(hp helper.IHostsProvider, p *uploadpolicy.Policy) {
+	if !p.Placed() {
+		return
+	}
+	helper.FilterHosts(hp, func(h *hubpb.Host) bool {
+		return p.Allows(h.Region, h.CountryShort, h.Score)
+	})
+}
+
+// PoliciesRes lists the upload policies of the node.
+type PoliciesRes struct {
+	Default  string
+	Locked   bool
+	Policies map[string]*uploadpolicy.Policy
+}
+
+var StorageUploadPoliciesCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the upload policies of this node.",
+		ShortDescription: `
+Lists the named upload policies selected with 'btfs storage upload --policy':
+the presets archive, hot and cheap, and the ones of the config option
+UploadPolicies.Policies, which may redefine them. A policy sets the storage
+length in days, the copies, the max price, whether the file must be
+encrypted, and the regions or country codes and the least score of the hosts.
+
+The uploads without --policy follow UploadPolicies.Default, marked with *.
+When UploadPolicies.Locked is true, the options of every upload must comply
+with its policy: a storage length and copies at least the policy's, a price
+at most its max price, and no custom hosts when the policy places the files:
+
+    $ btfs config --json UploadPolicies '{"Default": "eu", "Locked": true,
+        "Policies": {"eu": {"StorageLength": 90, "Copies": 3, "Encrypted": true,
+        "Regions": ["EU", "CH"]}}}'
+    $ btfs storage upload policies`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := uploadpolicy.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &PoliciesRes{
+			Default:  cfg.Default,
+			Locked:   cfg.Locked,
+			Policies: cfg.All(),
+		})
+	},
+	Type: PoliciesRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *PoliciesRes) error {
+			tw := tabwriter.NewWriter(w, 4, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tDAYS\tCOPIES\tMAX PRICE\tENCRYPTED\tPLACEMENT")
+			c := &uploadpolicy.Config{Policies: out.Policies}
+			for _, name := range c.Names() {
+				p := out.Policies[name]
+				if name == out.Default {
+					name += " *"
+					if out.Locked {
+						name += " (locked)"
+					}
+				}
+				days, copies, price, placement := "default", "default", "host ask", "any"
+				if p.StorageLength > 0 {
+					days = fmt.Sprint(p.StorageLength)
+				}
+				if p.Copies > 0 {
+					copies = fmt.Sprint(p.Copies)
+				}
+				if p.MaxPrice > 0 {
+					price = fmt.Sprint(p.MaxPrice)
+				}
+				if p.Placed() {
+					var rules []string
+					if len(p.Regions) > 0 {
+						rules = append(rules, strings.Join(p.Regions, ","))
+					}
+					if p.MinScore > 0 {
+						rules = append(rules, fmt.Sprintf("score>=%g", p.MinScore))
+					}
+					placement = strings.Join(rules, " ")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%s\n", name, days, copies, price, p.Encrypted, placement)
+			}
+			return tw.Flush()
+		}),
+	},
+}
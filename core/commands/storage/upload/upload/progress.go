@@ -0,0 +1,138 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/uploadq"
+	renterpb "github.com/TRON-US/go-btfs/protos/renter"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// progressPollInterval is how often a session is checked for progress.
+var progressPollInterval = time.Second
+
+// emitUploadProgress emits the progress of the session ssId until it
+// completes, fails or ctx is done.
+func emitUploadProgress(ctx context.Context, res cmds.ResponseEmitter, ctxParams *helper.ContextParams,
+	ssId string, shardSize int64) error {
+	ticker := time.NewTicker(progressPollInterval)
+	defer ticker.Stop()
+	for {
+		p, status, err := uploadProgress(ctxParams, ssId, shardSize)
+		if err != nil {
+			return err
+		}
+		if err := res.Emit(&Res{ID: ssId, Progress: p}); err != nil {
+			return err
+		}
+		switch status.Status {
+		case sessions.RssCompleteStatus:
+			return nil
+		case sessions.RssErrorStatus:
+			return fmt.Errorf("upload session %s failed: %s", ssId, status.Message)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// uploadProgress reads the progress of session ssId from the datastore.
+// A shard counts as done once its host signed the contract, bytes are
+// derived from the done shards.
+func uploadProgress(ctxParams *helper.ContextParams, ssId string, shardSize int64) (
+	*cmdenv.Progress, *renterpb.RenterSessionStatus, error) {
+	rss, err := sessions.GetRenterSession(ctxParams, ssId, "", make([]string, 0))
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := rss.Status()
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &cmdenv.Progress{
+		Stage:       st.Status,
+		TotalBytes:  shardSize * int64(len(rss.ShardHashes)),
+		TotalShards: len(rss.ShardHashes),
+	}
+	if pos := uploadq.Default.Position(ssId); pos > 0 {
+		p.Stage = fmt.Sprintf("queued #%d", pos)
+	}
+	for i, h := range rss.ShardHashes {
+		shard, err := sessions.GetRenterShard(ctxParams, ssId, h, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		shardStatus, err := shard.Status()
+		if err != nil {
+			return nil, nil, err
+		}
+		info, err := shard.GetAdditionalInfo()
+		if err != nil && err != datastore.ErrNotFound {
+			return nil, nil, err
+		}
+		// "contract" is the status of shards whose host signed the contract.
+		if shardStatus.Status != "contract" && info.Info != guardpb.Contract_UPLOADED.String() {
+			continue
+		}
+		p.Shards++
+		contracts, err := shard.Contracts()
+		if err != nil {
+			return nil, nil, err
+		}
+		if contracts.SignedGuardContract != nil {
+			p.Hosts = append(p.Hosts, contracts.SignedGuardContract.HostPid)
+		}
+	}
+	p.Bytes = shardSize * int64(p.Shards)
+	return p, st, nil
+}
+
+// uploadPostRun passes the session id through and renders the progress
+// events that follow it on stderr.
+func uploadPostRun(res cmds.Response, re cmds.ResponseEmitter) error {
+	var bar *cmdenv.ProgressBar
+	for {
+		v, err := res.Next()
+		if err == io.EOF {
+			if bar != nil {
+				bar.Finish()
+			}
+			return nil
+		}
+		if err != nil {
+			if bar != nil {
+				bar.Finish()
+			}
+			return err
+		}
+		out, ok := v.(*Res)
+		if !ok {
+			return errors.New("unexpected upload output type")
+		}
+		if out.Progress == nil {
+			if err := re.Emit(out); err != nil {
+				return err
+			}
+			continue
+		}
+		if bar == nil {
+			bar = cmdenv.NewProgressBar(os.Stderr)
+		}
+		bar.Update(*out.Progress)
+	}
+}
@@ -0,0 +1,56 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/uploadq"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// QueueRes lists the upload sessions of the upload queue.
+type QueueRes struct {
+	MaxSessions int
+	Running     []*uploadq.Entry
+	Queued      []*uploadq.Entry
+}
+
+var StorageUploadQueueCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the upload sessions running and queued.",
+		ShortDescription: `
+Lists the upload sessions running, up to UploadQueue.MaxSessions, then the
+ones waiting for them to end, next first.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := uploadq.Load(n.Repo)
+		if err != nil {
+			return err
+		}
+		running, queued := uploadq.Default.List()
+		return cmds.EmitOnce(res, &QueueRes{MaxSessions: cfg.MaxSessions, Running: running, Queued: queued})
+	},
+	Type: QueueRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *QueueRes) error {
+			fmt.Fprintf(w, "%d of %d sessions running, %d queued\n", len(out.Running), out.MaxSessions, len(out.Queued))
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "SESSION\tSTATE\tPRIORITY\tSHARDS\tSINCE")
+			for _, e := range out.Running {
+				fmt.Fprintf(tw, "%s\trunning\t%d\t%d\t%s\n", e.ID, e.Priority, e.MaxShards, e.Started.Format(time.RFC3339))
+			}
+			for i, e := range out.Queued {
+				fmt.Fprintf(tw, "%s\tqueued #%d\t%d\t%d\t%s\n", e.ID, i+1, e.Priority, e.MaxShards, e.Queued.Format(time.RFC3339))
+			}
+			return tw.Flush()
+		}),
+	},
+}
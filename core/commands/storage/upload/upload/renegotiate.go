@@ -0,0 +1,172 @@
+package upload
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/clock"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	"github.com/google/uuid"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// RenegotiateRes is the outcome of the renegotiation of a contract.
+type RenegotiateRes struct {
+	ContractID string
+	Action     string
+	Price      int64
+	Proposed   int64
+	// ID is the session renewing the contract, with its host or a cheaper
+	// one.
+	ID     string `json:",omitempty"`
+	Reason string
+}
+
+var StorageContractsRenegotiateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Renegotiate the renewal price of a contract (renter).",
+		ShortDescription: `
+Asks the host of a contract for its renewal price and decides from the
+bounds of the Renegotiation config:
+
+    $ btfs config --json Renegotiation.MaxIncrease 10
+    $ btfs config --json Renegotiation.MaxPrice 1000
+    $ btfs config --json Renegotiation.Migrate true
+
+A price within the bounds is accepted and the contract renewed with the
+host, from its end for --storage-length days. Above them, the contract is
+renewed with a cheaper host when Renegotiation.Migrate is set, else it ends.
+With Renegotiation.Auto set, the daemon renegotiates the contracts ending
+within Renegotiation.Window (72h by default).
+
+The rounds are recorded, see 'btfs storage contracts history'.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("contract-id", true, false, "ID of the contract of this renter."),
+	},
+	Options: []cmds.Option{
+		cmds.IntOption(storageLengthOptionName, "len", "Renewal period in days. Default: the period of the contract."),
+	},
+	RunTimeout: 5 * time.Minute,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		ctxParams, err := helper.ExtractContextParams(req, env)
+		if err != nil {
+			return err
+		}
+		storageLength, _ := req.Options[storageLengthOptionName].(int)
+		out, err := Renegotiate(ctxParams, req.Arguments[0], storageLength)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: RenegotiateRes{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *RenegotiateRes) error {
+			fmt.Fprintf(w, "%s %s: %s\n", out.ContractID, out.Action, out.Reason)
+			if out.ID != "" {
+				fmt.Fprintf(w, "renewal session %s\n", out.ID)
+			}
+			return nil
+		}),
+	},
+}
+
+// Renegotiate asks the host of the renter contract contractID for its
+// renewal price, and renews the contract for storageLength days, the period
+// of the contract when zero, with the host or a cheaper one as configured.
+func Renegotiate(ctxParams *helper.ContextParams, contractID string, storageLength int) (*RenegotiateRes, error) {
+	n := ctxParams.N
+	d := n.Repo.Datastore()
+	self := n.Identity.Pretty()
+	role := nodepb.ContractStat_RENTER.String()
+	cfg, err := contracts.LoadRenegotiationConfig(n.Repo)
+	if err != nil {
+		return nil, err
+	}
+	c, err := contracts.ShardContract(d, self, role, contractID)
+	if err != nil {
+		return nil, err
+	}
+	if storageLength <= 0 {
+		storageLength = int(c.RentEnd.Sub(c.RentStart).Hours() / 24)
+	}
+	hostPid, err := peer.IDB58Decode(c.HostPid)
+	if err != nil {
+		return nil, err
+	}
+	b, err := remote.P2PCallStrings(ctxParams.Ctx, n, ctxParams.Api, hostPid, "/storage/contracts/quote", contractID)
+	if err != nil {
+		return nil, fmt.Errorf("host %s did not quote: %s", c.HostPid, err)
+	}
+	q := &contracts.Quote{}
+	if err := json.Unmarshal(b, q); err != nil {
+		return nil, err
+	}
+	err = contracts.RecordRound(d, self, contractID, &contracts.Round{
+		Time:     time.Now(),
+		Role:     role,
+		Action:   contracts.ActionQuoted,
+		Price:    c.Price,
+		Proposed: q.Proposed,
+		Peer:     c.HostPid,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &RenegotiateRes{ContractID: contractID, Price: c.Price, Proposed: q.Proposed}
+	out.Action, out.Reason = cfg.Decide(c.Price, q.Proposed)
+	var hp helper.IHostsProvider
+	price := q.Proposed
+	switch out.Action {
+	case contracts.ActionAccepted:
+		hp = helper.GetCustomizedHostsProvider(ctxParams, []string{c.HostPid})
+	case contracts.ActionMigrated:
+		hp = helper.GetHostsProvider(ctxParams, []string{c.HostPid})
+		price = cfg.Bound(c.Price)
+	}
+	if hp != nil {
+		if err := clock.BeforeSigning(ctxParams.Ctx, n.Repo); err != nil {
+			return nil, err
+		}
+		signer, err := helper.GetSigner(ctxParams)
+		if err != nil {
+			return nil, err
+		}
+		out.ID = uuid.New().String()
+		rss, err := sessions.GetRenterSession(ctxParams, out.ID, c.FileHash, []string{c.ShardHash})
+		if err != nil {
+			return nil, err
+		}
+		// the renewed contract starts when the current one ends
+		UploadShard(rss, hp, price, c.ShardFileSize, storageLength, false, signer.ID(), -1,
+			[]int{int(c.ShardIndex)}, &RepairParams{
+				RenterStart: c.RentEnd,
+				RenterEnd:   c.RentEnd.Add(time.Duration(storageLength) * 24 * time.Hour),
+			}, nil)
+	}
+	err = contracts.RecordRound(d, self, contractID, &contracts.Round{
+		Time:     time.Now(),
+		Role:     role,
+		Action:   out.Action,
+		Price:    c.Price,
+		Proposed: q.Proposed,
+		Peer:     c.HostPid,
+		Session:  out.ID,
+		Reason:   out.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
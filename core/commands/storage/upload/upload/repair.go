@@ -1,11 +1,11 @@
 package upload
 
 import (
-	"context"
 	"errors"
 	"strings"
 	"time"
 
+	guardclient "github.com/TRON-US/go-btfs/core/clients/guard"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
@@ -13,7 +13,6 @@ import (
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	"github.com/tron-us/go-btfs-common/crypto"
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 
 	"github.com/libp2p/go-libp2p-core/peer"
 )
@@ -49,15 +48,7 @@ This command repairs the given shards of a file.`,
 		}
 		metaReq.Signature = sig
 		ctx, _ := helper.NewGoContext(req.Context)
-		var meta *guardpb.FileStoreStatus
-		err = grpc.GuardClient(ctxParams.Cfg.Services.GuardDomain).WithContext(ctx, func(ctx context.Context,
-			client guardpb.GuardServiceClient) error {
-			meta, err = client.CheckFileStoreMeta(ctx, metaReq)
-			if err != nil {
-				return err
-			}
-			return nil
-		})
+		meta, err := guardclient.New(ctxParams.Cfg.Services.GuardDomain).CheckFileStoreMeta(ctx, metaReq)
 		if err != nil {
 			return err
 		}
@@ -89,7 +80,7 @@ This command repairs the given shards of a file.`,
 			shardIndexes, &RepairParams{
 				RenterStart: m.RentStart,
 				RenterEnd:   m.RentEnd,
-			})
+			}, nil)
 		seRes := &Res{
 			ID: ssId,
 		}
@@ -1,30 +1,51 @@
 package upload
 
 import (
-	"context"
 	"fmt"
 	"time"
 
+	guardclient "github.com/TRON-US/go-btfs/core/clients/guard"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/uploadq"
+	renterpb "github.com/TRON-US/go-btfs/protos/renter"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	"github.com/tron-us/go-btfs-common/crypto"
 	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 
 	"github.com/ipfs/go-datastore"
 )
 
+const (
+	waitForStateOptionName = "wait-for-state"
+	sinceOptionName        = "since"
+	waitTimeoutOptionName  = "wait-timeout"
+)
+
 var StorageUploadStatusCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Check storage upload and payment status (From client's perspective).",
 		ShortDescription: `
-This command print upload and payment status by the time queried.`,
+This command print upload and payment status by the time queried.
+
+Instead of polling, a client can wait for the session to change: with
+--wait-for-state the status is returned once the session reaches the state,
+and with --since once it leaves the state, the last one the client saw. The
+status is returned anyway when the session completes or fails, or after
+--wait-timeout (30s by default, at most 5m), so that the request can be
+repeated:
+
+    $ btfs storage upload status <session-id> --since=pay --wait-timeout=1m`,
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg("session-id", true, false, "ID for the entire storage upload session.").EnableStdin(),
 	},
+	Options: []cmds.Option{
+		cmds.StringOption(waitForStateOptionName, "Wait until the session reaches this state."),
+		cmds.StringOption(sinceOptionName, "Wait until the session leaves this state."),
+		cmds.StringOption(waitTimeoutOptionName, "Longest wait for the session to change.").WithDefault("30s"),
+	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		status := &StatusRes{}
 		// check and get session info from sessionMap
@@ -44,11 +65,12 @@ This command print upload and payment status by the time queried.`,
 		if err != nil {
 			return err
 		}
-		sessionStatus, err := session.Status()
+		sessionStatus, err := waitSessionStatus(req, session)
 		if err != nil {
 			return err
 		}
 		status.Status = sessionStatus.Status
+		status.QueuePosition = uploadq.Default.Position(ssId)
 		status.Message = sessionStatus.Message
 		info, err := session.GetAdditionalInfo()
 		if err == nil {
@@ -100,28 +122,27 @@ This command print upload and payment status by the time queried.`,
 			shards[sessions.GetShardId(ssId, h, i)] = c
 		}
 		if (status.Status == sessions.RssWaitUploadReqSignedStatus || status.Status == sessions.RssCompleteStatus) && !fullyCompleted {
-			if err := grpc.GuardClient(ctxParams.Cfg.Services.GuardDomain).
-				WithContext(req.Context, func(ctx context.Context, client guardpb.GuardServiceClient) error {
-					req := &guardpb.CheckFileStoreMetaRequest{
-						FileHash:     session.Hash,
-						RenterPid:    session.PeerId,
-						RequesterPid: session.CtxParams.N.Identity.String(),
-						RequestTime:  time.Now(),
-					}
-					sig, err := crypto.Sign(ctxParams.N.PrivateKey, req)
-					if err != nil {
-						return err
-					}
-					req.Signature = sig
-					meta, err := client.CheckFileStoreMeta(ctx, req)
-					if err != nil {
-						return err
-					}
-					for _, c := range meta.Contracts {
-						shards[sessions.GetShardId(ssId, c.ShardHash, int(c.ShardIndex))].AdditionalInfo = c.State.String()
-					}
-					return nil
-				}); err != nil {
+			if err := func() error {
+				metaReq := &guardpb.CheckFileStoreMetaRequest{
+					FileHash:     session.Hash,
+					RenterPid:    session.PeerId,
+					RequesterPid: session.CtxParams.N.Identity.String(),
+					RequestTime:  time.Now(),
+				}
+				sig, err := crypto.Sign(ctxParams.N.PrivateKey, metaReq)
+				if err != nil {
+					return err
+				}
+				metaReq.Signature = sig
+				meta, err := guardclient.New(ctxParams.Cfg.Services.GuardDomain).CheckFileStoreMeta(req.Context, metaReq)
+				if err != nil {
+					return err
+				}
+				for _, c := range meta.Contracts {
+					shards[sessions.GetShardId(ssId, c.ShardHash, int(c.ShardIndex))].AdditionalInfo = c.State.String()
+				}
+				return nil
+			}(); err != nil {
 				log.Debug(err)
 			}
 		}
@@ -131,12 +152,60 @@ This command print upload and payment status by the time queried.`,
 	Type: StatusRes{},
 }
 
+// waitSessionStatus returns the status of session, once it changed as asked
+// by the wait options of req.
+func waitSessionStatus(req *cmds.Request, session *sessions.RenterSession) (*renterpb.RenterSessionStatus, error) {
+	state, _ := req.Options[waitForStateOptionName].(string)
+	since, _ := req.Options[sinceOptionName].(string)
+	if state == "" && since == "" {
+		return session.Status()
+	}
+	if state != "" && since != "" {
+		return nil, fmt.Errorf("--%s and --%s cannot be used together", waitForStateOptionName, sinceOptionName)
+	}
+	for _, s := range []string{state, since} {
+		if s != "" && s != sessions.RssErrorStatus && renterSessionStep(s) < 0 {
+			return nil, fmt.Errorf("unknown session state %q", s)
+		}
+	}
+	timeout, err := time.ParseDuration(req.Options[waitTimeoutOptionName].(string))
+	if err != nil || timeout < 0 {
+		return nil, fmt.Errorf("invalid --%s", waitTimeoutOptionName)
+	}
+	return session.WaitStatus(req.Context, timeout, func(s string) bool {
+		switch state {
+		case "":
+		case sessions.RssErrorStatus:
+			// returned as a final state
+			return false
+		default:
+			// a state passed already is reached
+			return renterSessionStep(s) >= renterSessionStep(state)
+		}
+		return s != since
+	})
+}
+
+// renterSessionStep returns the position of state on the path of a renter
+// session, -1 for the error state or an unknown one.
+func renterSessionStep(state string) int {
+	for i, s := range sessions.RssPath() {
+		if s == state {
+			return i
+		}
+	}
+	return -1
+}
+
 type StatusRes struct {
 	Status         string
 	Message        string
 	AdditionalInfo string
 	FileHash       string
-	Shards         map[string]*ShardStatus
+	// QueuePosition is the position of the session in the upload queue, 0
+	// once it runs.
+	QueuePosition int `json:",omitempty"`
+	Shards        map[string]*ShardStatus
 }
 
 type ShardStatus struct {
@@ -0,0 +1,197 @@
+package upload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/users"
+
+	chunker "github.com/TRON-US/go-btfs-chunker"
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+	"github.com/google/uuid"
+	cidlib "github.com/ipfs/go-cid"
+)
+
+const chunkSizeOptionName = "chunk-size"
+
+// UpdateRes is the new version of an updated file.
+type UpdateRes struct {
+	// ID is the session uploading the changed shards.
+	ID       string
+	FileHash string
+	Version  int
+	// Changed are the indexes of the shards uploaded, the others are kept
+	// by the hosts storing the previous version.
+	Changed []int
+	Shards  int
+}
+
+var StorageUpdateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Store a new version of a file, uploading only the changed shards.",
+		ShortDescription: `
+Adds the new version of a file stored on hosts with the reed-solomon
+encoding of the stored version, and uploads only the shards which differ.
+The other shards stay with the hosts storing them under the contracts of
+the previous version:
+
+    $ btfs storage update <file-hash> ./big.db
+    version 2 QmNewHash: 11 of 30 shards changed, session <session-id>
+
+The version is recorded in a manifest linked to the previous one. Edits
+keeping the size of the file only change the data shards they touch, and
+the parity shards. Edits changing its size change every shard.
+
+Check the upload of the changed shards with:
+    $ btfs storage upload status <session-id>`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("file-hash", true, false, "Hash of the stored version of the file."),
+		cmds.FileArg("file", true, false, "The new version of the file.").EnableStdin(),
+	},
+	Options: []cmds.Option{
+		cmds.Int64Option(uploadPriceOptionName, "p", "Max price per GiB per day of storage in µBTT (=0.000001BTT)."),
+		cmds.IntOption(storageLengthOptionName, "len", "File storage period on hosts in days.").WithDefault(defaultStorageLength),
+		cmds.Int64Option(chunkSizeOptionName, "Chunk size the stored version was added with.").WithDefault(int64(chunker.DefaultReedSolomonShardSize)),
+	},
+	RunTimeout: 15 * time.Minute,
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		ctxParams, err := helper.ExtractContextParams(req, env)
+		if err != nil {
+			return err
+		}
+		n := ctxParams.N
+		d := n.Repo.Datastore()
+		self := n.Identity.Pretty()
+
+		prevHash := req.Arguments[0]
+		prevCid, err := cidlib.Parse(prevHash)
+		if err != nil {
+			return err
+		}
+		prev, err := sessions.GetManifest(d, self, prevHash)
+		if err != nil {
+			return err
+		}
+		if prev == nil {
+			ssId, err := sessions.CompleteSession(d, self, prevHash)
+			if err != nil {
+				return err
+			}
+			if ssId == "" {
+				return fmt.Errorf("%s was not stored on hosts by this node, upload it first", prevHash)
+			}
+			shardHashes, fileSize, _, err := helper.GetShardHashes(ctxParams, prevHash)
+			if err != nil {
+				return err
+			}
+			prev = sessions.FirstManifest(prevHash, fileSize, shardHashes, ssId)
+			if err := sessions.SaveManifest(d, self, prev); err != nil {
+				return err
+			}
+		}
+
+		// add the new version with the encoding of the stored one
+		mbytes, err := ctxParams.Api.Unixfs().GetMetadata(req.Context, path.IpfsPath(prevCid))
+		if err != nil {
+			return err
+		}
+		var rsMeta chunker.RsMetaMap
+		if err := json.Unmarshal(mbytes, &rsMeta); err != nil {
+			return fmt.Errorf("%s is not reed-solomon encoded: %s", prevHash, err)
+		}
+		chunkSize, _ := req.Options[chunkSizeOptionName].(int64)
+		it := req.Files.Entries()
+		if !it.Next() {
+			if err := it.Err(); err != nil {
+				return err
+			}
+			return errors.New("expected a file argument")
+		}
+		file, ok := it.Node().(files.File)
+		if !ok {
+			return fmt.Errorf("%s is not a file", it.Name())
+		}
+		added, err := ctxParams.Api.Unixfs().Add(req.Context, file,
+			options.Unixfs.Chunker(fmt.Sprintf("%s-%d-%d-%d", chunker.PrefixForReedSolomon,
+				rsMeta.NumData, rsMeta.NumParity, chunkSize)),
+			options.Unixfs.Pin(true))
+		if err != nil {
+			return err
+		}
+		fileHash := added.Cid().String()
+		if fileHash == prevHash {
+			return errors.New("the file did not change")
+		}
+		shardHashes, fileSize, shardSize, err := helper.GetShardHashes(ctxParams, fileHash)
+		if err != nil {
+			return err
+		}
+
+		ssId := uuid.New().String()
+		m, changed, err := sessions.NextManifest(prev, fileHash, fileSize, shardHashes, ssId)
+		if err != nil {
+			return err
+		}
+		if len(changed) == 0 {
+			return fmt.Errorf("no shard of %s changed, check --%s", prevHash, chunkSizeOptionName)
+		}
+		changedHashes := make([]string, len(changed))
+		for i, index := range changed {
+			changedHashes[i] = shardHashes[index]
+		}
+
+		price, storageLength, err := helper.GetPriceAndMinStorageLength(ctxParams)
+		if err != nil {
+			return err
+		}
+		hp := helper.GetHostsProvider(ctxParams, make([]string, 0))
+		if u := users.FromContext(req.Context); u != nil {
+			err := users.ForNode(n).Charge(u.Name, shardSize*int64(len(changed)))
+			if err != nil {
+				return err
+			}
+		}
+		rss, err := sessions.GetRenterSession(ctxParams, ssId, fileHash, changedHashes)
+		if err != nil {
+			return err
+		}
+		if err := sessions.SaveManifest(d, self, m); err != nil {
+			return err
+		}
+		signer, err := helper.GetSigner(ctxParams)
+		if err != nil {
+			return err
+		}
+		UploadShard(rss, hp, price, shardSize, storageLength, false, signer.ID(), fileSize, changed, nil, nil)
+		return res.Emit(&UpdateRes{
+			ID:       ssId,
+			FileHash: fileHash,
+			Version:  m.Version,
+			Changed:  changed,
+			Shards:   len(shardHashes),
+		})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *UpdateRes) error {
+			idx := make([]string, len(out.Changed))
+			for i, c := range out.Changed {
+				idx[i] = fmt.Sprint(c)
+			}
+			fmt.Fprintf(w, "version %d %s: %d of %d shards changed, session %s\n",
+				out.Version, out.FileHash, len(out.Changed), out.Shards, out.ID)
+			fmt.Fprintf(w, "changed shards: %s\n", strings.Join(idx, ","))
+			return nil
+		}),
+	},
+	Type: UpdateRes{},
+}
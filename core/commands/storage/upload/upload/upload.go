@@ -7,14 +7,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/TRON-US/go-btfs/core/clock"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/offline"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/users"
 	renterpb "github.com/TRON-US/go-btfs/protos/renter"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 
 	"github.com/cenkalti/backoff/v4"
+	humanize "github.com/dustin/go-humanize"
 	"github.com/google/uuid"
 	logging "github.com/ipfs/go-log"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -28,6 +32,9 @@ const (
 	testOnlyOptionName               = "host-search-local"
 	customizedPayoutOptionName       = "customize-payout"
 	customizedPayoutPeriodOptionName = "customize-payout-period"
+	shardSizeOptionName              = "shard-size"
+	maxConcurrentOptionName          = "max-concurrent"
+	priorityOptionName               = "priority"
 
 	defaultRepFactor     = 3
 	defaultStorageLength = 30
@@ -68,14 +75,55 @@ To custom upload and storage a file on specific hosts:
     # Total # of hosts (N) must match # of shards given
     $ btfs storage upload <shard-hash1> <shard-hash2> ... <shard-hashN> -l -m=custom -s=<host1-peer-id>,<host2-peer-id>,...,<hostN-peer-id>
 
-Use status command to check for completion:
+Large files are cheaper to store in larger shards. Use --shard-size to
+re-encode the file in shards of about that size before uploading it; the
+file hash of the new encoding is returned with the session, with the
+throughput of the encoding in bytes per second. The encoding streams the
+file in stripes whose memory is bounded by the config option
+ReedSolomon.MaxMemory, e.g. 64MB, its temporary files going to
+ReedSolomon.TempDir. Only the hosts
+storing shards of that size, set in their config option ShardSize.HostMax,
+are selected:
+    $ btfs storage upload <file-hash> --shard-size 64MB
+
+A renter runs UploadQueue.MaxSessions sessions at once, 4 by default, the
+next ones waiting in a queue, those of a higher --priority first. Each
+session uploads UploadQueue.MaxShards shards at once, 10 by default, or
+--max-concurrent. Bulk uploads should use these to spare the node and its
+uplink, see 'btfs storage upload queue':
+    $ btfs config --json UploadQueue.MaxSessions 2
+    $ btfs storage upload <file-hash> --max-concurrent 4 --priority 1
+
+Files kept for a fixed retention, e.g. for compliance, expire with
+--store-for: their contracts last that period and are not renewed, then the
+file is deleted from the node, or with --on-expiry retrieve-and-delete first
+retrieved back from the hosts, optionally to --retrieve-to. See
+'btfs storage upload expiry':
+    $ btfs storage upload <file-hash> --store-for 180d --on-expiry retrieve-and-delete
+
+Organizations set named upload policies in the config option UploadPolicies,
+selected with --policy, e.g. archive, hot or cheap: the storage length,
+copies, max price, encryption and host placement of the upload. The uploads
+without --policy follow UploadPolicies.Default, and when it is locked the
+options of every upload must comply with their policy. See
+'btfs storage upload policies':
+    $ btfs storage upload <file-hash> --policy archive
+
+When stderr is a terminal, the command waits for the upload and shows its
+progress: stage, shards stored, an ETA and the hosts storing them. Use
+--progress=false to return right after the session starts, e.g. in scripts,
+and check for completion with the status command:
     $ btfs storage upload status <session-id> | jq`,
 	},
 	Subcommands: map[string]*cmds.Command{
 		"init":              StorageUploadInitCmd,
 		"recvcontract":      StorageUploadRecvContractCmd,
+		"limits":            StorageUploadLimitsCmd,
+		"queue":             StorageUploadQueueCmd,
 		"status":            StorageUploadStatusCmd,
 		"repair":            StorageUploadRepairCmd,
+		"expiry":            StorageUploadExpiryCmd,
+		"policies":          StorageUploadPoliciesCmd,
 		"getcontractbatch":  offline.StorageUploadGetContractBatchCmd,
 		"signcontractbatch": offline.StorageUploadSignContractBatchCmd,
 		"getunsigned":       offline.StorageUploadGetUnsignedCmd,
@@ -96,6 +144,20 @@ Use status command to check for completion:
 		cmds.IntOption(storageLengthOptionName, "len", "File storage period on hosts in days.").WithDefault(defaultStorageLength),
 		cmds.BoolOption(customizedPayoutOptionName, "Enable file storage customized payout schedule.").WithDefault(false),
 		cmds.IntOption(customizedPayoutPeriodOptionName, "Period of customized payout schedule.").WithDefault(1),
+		cmds.StringOption(shardSizeOptionName, "Re-encode the file in shards of about this size, e.g. 64MB."),
+		cmds.IntOption(maxConcurrentOptionName, "Number of shards uploaded at once. Default: UploadQueue.MaxShards."),
+		cmds.IntOption(priorityOptionName, "Priority of the session in the upload queue, higher first.").WithDefault(0),
+		cmds.StringOption(storeForOptionName, "Store the file for this period then let it expire, e.g. 180d. Overrides --storage-length."),
+		cmds.StringOption(onExpiryOptionName, "Action when the storage expires: delete or retrieve-and-delete. Default: delete."),
+		cmds.StringOption(retrieveToOptionName, "Local path to retrieve the file to with --on-expiry retrieve-and-delete."),
+		cmds.StringOption(policyOptionName, "Upload policy setting the options left to their defaults, e.g. archive, hot or cheap. Default: UploadPolicies.Default."),
+		cmds.BoolOption(cmdenv.ProgressOptionName, "Wait for the upload and show its progress. Defaults to true when stderr is a terminal."),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		if _, found := req.Options[cmdenv.ProgressOptionName].(bool); !found {
+			req.Options[cmdenv.ProgressOptionName] = cmdenv.ProgressDefault()
+		}
+		return nil
 	},
 	RunTimeout: 15 * time.Minute,
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
@@ -104,6 +166,9 @@ Use status command to check for completion:
 		if err != nil {
 			return err
 		}
+		if err := clock.BeforeSigning(req.Context, ctxParams.N.Repo); err != nil {
+			return err
+		}
 		renterId := ctxParams.N.Identity
 		offlineSigning := false
 		if len(req.Arguments) > 1 {
@@ -115,6 +180,13 @@ Use status command to check for completion:
 				return err
 			}
 			offlineSigning = true
+		} else {
+			// the payer of a delegation is the renter of the contracts
+			signer, err := helper.GetSigner(ctxParams)
+			if err != nil {
+				return err
+			}
+			renterId = signer.ID()
 		}
 		err = backoff.Retry(func() error {
 			peersLen := len(ctxParams.N.PeerHost.Network().Peers())
@@ -127,10 +199,36 @@ Use status command to check for completion:
 		}, helper.WaitingForPeersBo)
 
 		fileHash := req.Arguments[0]
+		resharded := false
+		var encodeRate float64
+		if size, ok := req.Options[shardSizeOptionName].(string); ok {
+			shardSize, err := humanize.ParseBytes(size)
+			if err != nil {
+				return fmt.Errorf("invalid shard size %q: %s", size, err)
+			}
+			h, rate, err := helper.Reshard(ctxParams, fileHash, int64(shardSize))
+			if err != nil {
+				return err
+			}
+			encodeRate = rate
+			resharded, fileHash = h != fileHash, h
+		}
 		shardHashes, fileSize, shardSize, err := helper.GetShardHashes(ctxParams, fileHash)
 		if err != nil {
 			return err
 		}
+		expiry, days, err := expiryOptions(req)
+		if err != nil {
+			return err
+		}
+		if expiry != nil {
+			// the contracts last the storage period, then lapse
+			req.Options[storageLengthOptionName] = days
+		}
+		policyName, policy, err := applyPolicy(req, ctxParams, req.Arguments[0])
+		if err != nil {
+			return err
+		}
 		price, storageLength, err := helper.GetPriceAndMinStorageLength(ctxParams)
 		if err != nil {
 			return err
@@ -148,6 +246,15 @@ Use status command to check for completion:
 				hp = helper.GetCustomizedHostsProvider(ctxParams, hostIDs)
 			}
 		}
+		if policy != nil {
+			filterHosts(hp, policy)
+		}
+		if u := users.FromContext(req.Context); u != nil {
+			err := users.ForNode(ctxParams.N).Charge(u.Name, shardSize*int64(len(shardHashes)))
+			if err != nil {
+				return err
+			}
+		}
 		rss, err := sessions.GetRenterSession(ctxParams, ssId, fileHash, shardHashes)
 		if err != nil {
 			return err
@@ -170,15 +277,53 @@ Use status command to check for completion:
 		for i, _ := range rss.ShardHashes {
 			shardIndexes = append(shardIndexes, i)
 		}
-		UploadShard(rss, hp, price, shardSize, storageLength, offlineSigning, renterId, fileSize, shardIndexes, nil)
+		maxShards, _ := req.Options[maxConcurrentOptionName].(int)
+		if maxShards < 0 {
+			return fmt.Errorf("invalid --%s %d", maxConcurrentOptionName, maxShards)
+		}
+		priority, _ := req.Options[priorityOptionName].(int)
+		if expiry != nil {
+			expiry.FileHash, expiry.SessionID = fileHash, ssId
+			err := AddExpiry(ctxParams.N.Repo.Datastore(), ctxParams.N.Identity.Pretty(), expiry, days, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+		ticket, err := enqueue(ctxParams.N.Repo, ssId, priority, maxShards)
+		if err != nil {
+			return err
+		}
+		UploadShard(rss, hp, price, shardSize, storageLength, offlineSigning, renterId, fileSize, shardIndexes, nil,
+			ticket)
 		seRes := &Res{
-			ID: ssId,
+			ID:     ssId,
+			Policy: policyName,
 		}
-		return res.Emit(seRes)
+		if resharded {
+			seRes.FileHash = fileHash
+			seRes.EncodeRate = encodeRate
+		}
+		if err := res.Emit(seRes); err != nil {
+			return err
+		}
+		if progress, _ := req.Options[cmdenv.ProgressOptionName].(bool); progress {
+			return emitUploadProgress(req.Context, res, ctxParams, ssId, shardSize)
+		}
+		return nil
+	},
+	PostRun: cmds.PostRunMap{
+		cmds.CLI: uploadPostRun,
 	},
 	Type: Res{},
 }
 
 type Res struct {
 	ID string
+	// FileHash is the hash of the file re-encoded for --shard-size.
+	FileHash string `json:",omitempty"`
+	// EncodeRate is the throughput of the re-encoding in bytes per second.
+	EncodeRate float64 `json:",omitempty"`
+	// Policy is the upload policy followed.
+	Policy   string           `json:",omitempty"`
+	Progress *cmdenv.Progress `json:",omitempty"`
 }
@@ -8,16 +8,59 @@ import (
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/core/hub"
+	"github.com/TRON-US/go-btfs/core/ingest"
+	"github.com/TRON-US/go-btfs/core/uploadq"
+	"github.com/TRON-US/go-btfs/repo"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/libp2p/go-libp2p-core/peer"
 )
 
+// UploadShard uploads the shards of the session rss once the upload queue
+// runs it, see package uploadq. t is the ticket of the session, one with
+// the defaults of the queue is taken when nil.
 func UploadShard(rss *sessions.RenterSession, hp helper.IHostsProvider, price int64, shardSize int64,
 	storageLength int,
-	offlineSigning bool, renterId peer.ID, fileSize int64, shardIndexes []int, rp *RepairParams) {
+	offlineSigning bool, renterId peer.ID, fileSize int64, shardIndexes []int, rp *RepairParams, t *uploadq.Ticket) {
+	go func() {
+		if t == nil {
+			var err error
+			if t, err = enqueue(rss.CtxParams.N.Repo, rss.SsId, 0, 0); err != nil {
+				_ = rss.To(sessions.RssToErrorEvent, err)
+				return
+			}
+		}
+		if err := t.Wait(rss.Ctx); err != nil {
+			return
+		}
+		go func() {
+			<-rss.Ctx.Done()
+			t.Release()
+		}()
+		uploadShards(rss, hp, price, shardSize, storageLength, offlineSigning, renterId, fileSize, shardIndexes, rp, t)
+	}()
+}
+
+// enqueue queues the session ssId in the upload queue of the node.
+func enqueue(r repo.Repo, ssId string, priority, maxShards int) (*uploadq.Ticket, error) {
+	cfg, err := uploadq.Load(r)
+	if err != nil {
+		return nil, err
+	}
+	return uploadq.Default.Enqueue(cfg, ssId, priority, maxShards, time.Now())
+}
+
+func uploadShards(rss *sessions.RenterSession, hp helper.IHostsProvider, price int64, shardSize int64,
+	storageLength int, offlineSigning bool, renterId peer.ID, fileSize int64, shardIndexes []int, rp *RepairParams,
+	t *uploadq.Ticket) {
 	for index, shardHash := range rss.ShardHashes {
 		go func(i int, h string) {
+			// the shards of a session uploaded at once are bounded
+			if err := t.AcquireShard(rss.Ctx); err != nil {
+				return
+			}
+			defer t.ReleaseShard()
 			err := backoff.Retry(func() error {
 				select {
 				case <-rss.Ctx.Done():
@@ -25,7 +68,7 @@ func UploadShard(rss *sessions.RenterSession, hp helper.IHostsProvider, price in
 				default:
 					break
 				}
-				host, err := hp.NextValidHost(price)
+				host, err := hp.NextValidHost(price, shardSize)
 				if err != nil {
 					terr := rss.To(sessions.RssToErrorEvent, err)
 					if terr != nil {
@@ -35,6 +78,12 @@ func UploadShard(rss *sessions.RenterSession, hp helper.IHostsProvider, price in
 					return nil
 				}
 				contractId := helper.NewContractID(rss.SsId)
+				if s := helper.Selection(hp, host); s != nil {
+					s.ContractID, s.ShardIndex = contractId, i
+					if err := hub.SaveSelection(rss.CtxParams.N.Repo.Datastore(), rss.PeerId, rss.SsId, s); err != nil {
+						log.Debugf("record the selection of %s: %s", host, err)
+					}
+				}
 				cb := make(chan error)
 				ShardErrChanMap.Set(contractId, cb)
 				tp := helper.TotalPay(shardSize, price, storageLength)
@@ -114,6 +163,11 @@ func UploadShard(rss *sessions.RenterSession, hp helper.IHostsProvider, price in
 				select {
 				case err = <-cb:
 					ShardErrChanMap.Remove(contractId)
+					if d, ok := ingest.ParseRetryAfter(err); ok {
+						// skipped by the next hosts picked until then
+						log.Debugf("host %s busy, retry after %s", host, d)
+						ingest.MarkBusy(host, time.Now().Add(d))
+					}
 					return err
 				case <-tick:
 					return errors.New("host timeout")
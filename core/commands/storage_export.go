@@ -0,0 +1,413 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/e"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	ipld "github.com/ipfs/go-ipld-format"
+	mdag "github.com/ipfs/go-merkledag"
+	gocar "github.com/ipld/go-car"
+)
+
+const (
+	exportOutOptionName = "out"
+
+	// ExportVersion is the version of the export manifest format.
+	ExportVersion = 1
+
+	// Names of the entries of an export bundle.
+	exportDataName     = "data"
+	exportCarName      = "dag.car"
+	exportManifestName = "manifest.json"
+)
+
+// ExportManifest describes an export bundle: the file tree written under
+// data, with the checksums of its plain contents, the CAR of the DAG of
+// Root, and the renter contracts which stored it.
+type ExportManifest struct {
+	Version   int
+	Root      string
+	Time      time.Time
+	Decrypted bool
+	Car       string
+	Files     *Manifest
+	Contracts []*ExportContract
+}
+
+// ExportContract is a renter contract of the exported file.
+type ExportContract struct {
+	ContractID string
+	Host       string
+	ShardHash  string
+	ShardIndex int32
+	ShardSize  int64
+	Price      int64
+	RentStart  time.Time
+	RentEnd    time.Time
+	State      string
+}
+
+var StorageExportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Export a file to a verifiable bundle.",
+		ShortDescription: `
+Downloads the file or directory at <btfs-path> and writes a bundle of it to
+the --out directory, './<btfs-path>.export' by default:
+
+  data           the file tree, decrypted with --decrypt
+  dag.car        the CAR of the DAG of the file, readable by any IPFS tool
+  manifest.json  the checksums of the files of data and the renter contracts
+                 which stored the file
+
+Every block is checked against its CID before it is written, so the bundle
+keeps the file readable and verifiable off the network, e.g. with
+'btfs dag import dag.car'.
+
+An export runs until the bundle is written, unless bounded with --timeout.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("btfs-path", true, false, "The path to the BTFS object to export.").EnableStdin(),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(exportOutOptionName, "The directory where the bundle is written."),
+		cmds.BoolOption(decryptName, "d", "Decrypt the file."),
+		cmds.StringOption(privateKeyName, "pk", "The private key to decrypt file."),
+		cmds.BoolOption(cmdenv.ProgressOptionName, "Show export progress. Defaults to true when stderr is a terminal."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		p := path.New(req.Arguments[0])
+		rp, err := api.ResolvePath(req.Context, p)
+		if err != nil {
+			return err
+		}
+		root := rp.Cid()
+
+		car, err := exportCar(req.Context, n.DAG, root)
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-req.Context.Done()
+			car.Close()
+		}()
+
+		decrypt, _ := req.Options[decryptName].(bool)
+		privateKey, _ := req.Options[privateKeyName].(string)
+		var data files.Node
+		if decrypt {
+			f, err := openDekFile(req.Context, env, api, rp)
+			if err != nil {
+				return err
+			}
+			if f != nil {
+				data = f
+			}
+		}
+		if data == nil {
+			data, err = api.Unixfs().Get(req.Context, rp,
+				options.Unixfs.Decrypt(decrypt), options.Unixfs.PrivateKey(privateKey))
+			if err != nil {
+				return err
+			}
+		}
+		dataSize, err := data.Size()
+		if err != nil {
+			return err
+		}
+		carSize, _ := car.Size()
+		res.SetLength(uint64(dataSize + carSize))
+
+		contracts, err := exportContracts(n.Repo.Datastore(), n.Identity.Pretty(), root.String())
+		if err != nil {
+			return err
+		}
+		tree, err := newChecksumTree("sha256")
+		if err != nil {
+			return err
+		}
+		m := &ExportManifest{
+			Version:   ExportVersion,
+			Root:      root.String(),
+			Time:      time.Now(),
+			Decrypted: decrypt,
+			Car:       exportCarName,
+			Contracts: contracts,
+		}
+		// the manifest is written last, once the tree has been read
+		manifest := &lazyFile{build: func() ([]byte, error) {
+			fm, err := exportFilesManifest(tree, func(p string) (string, error) {
+				c, err := api.ResolvePath(req.Context, path.Join(rp, p))
+				if err != nil {
+					return "", err
+				}
+				return c.Cid().String(), nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			fm.Root = m.Root
+			m.Files = fm
+			b, err := json.MarshalIndent(m, "", "  ")
+			return append(b, '\n'), err
+		}}
+		bundle := files.NewSliceDirectory([]files.DirEntry{
+			files.FileEntry(exportDataName, tree.wrap(exportDataName, data)),
+			files.FileEntry(exportCarName, car),
+			files.FileEntry(exportManifestName, manifest),
+		})
+		reader, err := fileArchive(bundle, "bundle", false, 0)
+		if err != nil {
+			return err
+		}
+		return res.Emit(reader)
+	},
+	PostRun: cmds.PostRunMap{
+		cmds.CLI: func(res cmds.Response, re cmds.ResponseEmitter) error {
+			req := res.Request()
+			v, err := res.Next()
+			if err != nil {
+				return err
+			}
+			outReader, ok := v.(io.Reader)
+			if !ok {
+				return e.New(e.TypeErr(outReader, v))
+			}
+			gw := getWriter{
+				Out:      os.Stdout,
+				Err:      os.Stderr,
+				Size:     int64(res.Length()),
+				Progress: cmdenv.ProgressEnabled(req),
+			}
+			return gw.Write(outReader, exportOutPath(req))
+		},
+	},
+}
+
+func exportOutPath(req *cmds.Request) string {
+	if out, _ := req.Options[exportOutOptionName].(string); out != "" {
+		return filepath.Clean(out)
+	}
+	_, name := filepath.Split(strings.TrimRight(req.Arguments[0], "/"))
+	return filepath.Clean(name) + ".export"
+}
+
+// exportCar writes the CAR of the DAG of root, fetching the blocks missing,
+// to a temporary file removed once read or closed, and checks it.
+func exportCar(ctx context.Context, dag ipld.NodeGetter, root cid.Cid) (*tempFile, error) {
+	f, err := ioutil.TempFile("", "btfs-export-*.car")
+	if err != nil {
+		return nil, err
+	}
+	t := &tempFile{File: f}
+	if err := gocar.WriteCar(ctx, mdag.NewSession(ctx, dag), []cid.Cid{root}, f); err != nil {
+		t.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Close()
+		return nil, err
+	}
+	if err := verifyCar(f, root); err != nil {
+		t.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// verifyCar checks that the CAR read from r has the single root, and that
+// every block of it matches its CID.
+func verifyCar(r io.Reader, root cid.Cid) error {
+	cr, err := gocar.NewCarReader(r)
+	if err != nil {
+		return err
+	}
+	if len(cr.Header.Roots) != 1 || !cr.Header.Roots[0].Equals(root) {
+		return fmt.Errorf("car roots %v, expected %s", cr.Header.Roots, root)
+	}
+	for {
+		b, err := cr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c, err := b.Cid().Prefix().Sum(b.RawData())
+		if err != nil {
+			return err
+		}
+		if !c.Equals(b.Cid()) {
+			return fmt.Errorf("block %s does not match its data", b.Cid())
+		}
+	}
+}
+
+// exportContracts returns the renter contracts of peerID for fileHash.
+func exportContracts(d datastore.Datastore, peerID, fileHash string) ([]*ExportContract, error) {
+	scs, err := sessions.ListShardsContracts(d, peerID, nodepb.ContractStat_RENTER.String())
+	if err != nil {
+		return nil, err
+	}
+	cs := []*ExportContract{}
+	for _, sc := range scs {
+		c := sc.SignedGuardContract
+		if c == nil || c.FileHash != fileHash {
+			continue
+		}
+		cs = append(cs, &ExportContract{
+			ContractID: c.ContractId,
+			Host:       c.HostPid,
+			ShardHash:  c.ShardHash,
+			ShardIndex: c.ShardIndex,
+			ShardSize:  c.ShardFileSize,
+			Price:      c.Price,
+			RentStart:  c.RentStart,
+			RentEnd:    c.RentEnd,
+			State:      c.State.String(),
+		})
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].ShardIndex != cs[j].ShardIndex {
+			return cs[i].ShardIndex < cs[j].ShardIndex
+		}
+		return cs[i].ContractID < cs[j].ContractID
+	})
+	return cs, nil
+}
+
+// exportFilesManifest returns the checksum manifest of the files read from
+// the tree wrapped as exportDataName, with the paths relative to it and the
+// CIDs given by resolve.
+func exportFilesManifest(t *checksumTree, resolve func(p string) (string, error)) (*Manifest, error) {
+	t.mu.Lock()
+	names := make([]string, 0, len(t.sums))
+	for name := range t.sums {
+		names = append(names, name)
+	}
+	t.mu.Unlock()
+	sort.Strings(names)
+
+	m := &Manifest{Version: ManifestVersion, Algorithm: t.algorithm, Entries: []ManifestEntry{}}
+	for _, name := range names {
+		sum, size, ok := t.checksum(name)
+		if !ok {
+			return nil, fmt.Errorf("%s was not read in order", name)
+		}
+		p := strings.TrimPrefix(strings.TrimPrefix(name, exportDataName), "/")
+		c, err := resolve(p)
+		if err != nil {
+			return nil, err
+		}
+		m.Entries = append(m.Entries, ManifestEntry{Path: p, Cid: c, Size: size, Checksum: sum})
+	}
+	return m, nil
+}
+
+// tempFile is a temporary file removed once read to the end or closed.
+type tempFile struct {
+	*os.File
+	once sync.Once
+}
+
+func (f *tempFile) Read(b []byte) (int, error) {
+	n, err := f.File.Read(b)
+	if err == io.EOF {
+		f.Close()
+	}
+	return n, err
+}
+
+func (f *tempFile) Size() (int64, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return st.Size(), nil
+}
+
+func (f *tempFile) Close() error {
+	var err error
+	f.once.Do(func() {
+		err = f.File.Close()
+		os.Remove(f.Name())
+	})
+	return err
+}
+
+// lazyFile is a file built when its size is first asked, i.e. when a tar
+// writer reaches it.
+type lazyFile struct {
+	build func() ([]byte, error)
+
+	once sync.Once
+	r    *bytes.Reader
+	err  error
+}
+
+func (f *lazyFile) load() error {
+	f.once.Do(func() {
+		var b []byte
+		b, f.err = f.build()
+		f.r = bytes.NewReader(b)
+	})
+	return f.err
+}
+
+func (f *lazyFile) Size() (int64, error) {
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+	return f.r.Size(), nil
+}
+
+func (f *lazyFile) Read(b []byte) (int, error) {
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+	return f.r.Read(b)
+}
+
+func (f *lazyFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+	return f.r.Seek(offset, whence)
+}
+
+func (f *lazyFile) Close() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	files "github.com/TRON-US/go-btfs-files"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	mdag "github.com/ipfs/go-merkledag"
+	dstest "github.com/ipfs/go-merkledag/test"
+	gocar "github.com/ipld/go-car"
+)
+
+func TestVerifyCar(t *testing.T) {
+	ctx := context.Background()
+	dag := dstest.Mock()
+	leaf := mdag.NodeWithData([]byte("exported leaf"))
+	root := mdag.NodeWithData([]byte("exported root"))
+	if err := root.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatal(err)
+	}
+	if err := dag.AddMany(ctx, []ipld.Node{leaf, root}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := gocar.WriteCar(ctx, dag, []cid.Cid{root.Cid()}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	car := buf.Bytes()
+	if err := verifyCar(bytes.NewReader(car), root.Cid()); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyCar(bytes.NewReader(car), leaf.Cid()); err == nil {
+		t.Fatal("expected another root to fail")
+	}
+
+	i := bytes.Index(car, []byte("exported leaf"))
+	car[i] = 'E'
+	if err := verifyCar(bytes.NewReader(car), root.Cid()); err == nil {
+		t.Fatal("expected a corrupted block to fail")
+	}
+}
+
+func TestExportFilesManifest(t *testing.T) {
+	tree, err := newChecksumTree("sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := tree.wrap(exportDataName, files.NewMapDirectory(map[string]files.Node{
+		"a": files.NewBytesFile([]byte("testfileA")),
+		"sub": files.NewMapDirectory(map[string]files.Node{
+			"b": files.NewBytesFile([]byte("testfileB")),
+		}),
+	}))
+	err = files.Walk(dir, func(_ string, n files.Node) error {
+		if f, ok := n.(files.File); ok {
+			_, err := ioutil.ReadAll(f)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := exportFilesManifest(tree, func(p string) (string, error) { return "Qm" + p, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 2 || m.Entries[0].Path != "a" || m.Entries[1].Path != "sub/b" || m.Entries[1].Cid != "Qmsub/b" {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	// sha256("testfileA")
+	if m.Entries[0].Checksum != "a1925b9230d3e8b4477d57e11dfcad43c88b670c0c931f854643b070f3580410" || m.Entries[0].Size != 9 {
+		t.Fatalf("unexpected entry %+v", m.Entries[0])
+	}
+
+	// a single file is the root itself
+	tree, _ = newChecksumTree("sha256")
+	f := tree.wrap(exportDataName, files.NewBytesFile([]byte("testfileA"))).(files.File)
+	if _, err := ioutil.ReadAll(f); err != nil {
+		t.Fatal(err)
+	}
+	m, err = exportFilesManifest(tree, func(p string) (string, error) { return "Qm" + p, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 1 || m.Entries[0].Path != "" {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/tenants"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+var storageHostTenantsPayoutCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Pay out the earnings of a tenant to its wallet.",
+		ShortDescription: `
+Transfers the earnings due to the tenant <name> from the BTT wallet of the
+node to the wallet of the tenant, or [amount] µBTT of them, signed with the
+wallet signer. See 'btfs storage host tenants report' for the earnings due.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the tenant."),
+		cmds.StringArg("amount", false, false, "Amount to pay out in µBTT. Default: the earnings due."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(passwordOptionName, "p", "password"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		if _, err := useWalletSigner(n); err != nil {
+			return err
+		}
+		d, self := n.Repo.Datastore(), n.Identity.Pretty()
+		e, err := tenants.TenantEarnings(d, self, req.Arguments[0], time.Now())
+		if err != nil {
+			return err
+		}
+		amount := e.Due
+		if len(req.Arguments) > 1 {
+			if amount, err = strconv.ParseInt(req.Arguments[1], 10, 64); err != nil || amount <= 0 {
+				return fmt.Errorf("invalid amount %q", req.Arguments[1])
+			}
+			if amount > e.Due {
+				return fmt.Errorf("only %d µBTT due to tenant %s", e.Due, e.Tenant)
+			}
+		}
+		if amount <= 0 {
+			return fmt.Errorf("no earnings due to tenant %s", e.Tenant)
+		}
+		ret, err := wallet.TransferBTT(req.Context, n, cfg, nil, "", e.Wallet, amount)
+		if err != nil {
+			return err
+		}
+		p := &tenants.Payout{Tenant: e.Tenant, TxID: ret.TxId, Wallet: e.Wallet, Amount: amount, Time: time.Now()}
+		if err := tenants.PutPayout(d, self, p); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, p)
+	},
+	Type: tenants.Payout{},
+}
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	commands "github.com/TRON-US/go-btfs/commands"
+	"github.com/TRON-US/go-btfs/core/addrprofile"
 	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	paging "github.com/TRON-US/go-btfs/core/commands/paging"
 	repo "github.com/TRON-US/go-btfs/repo"
 	fsrepo "github.com/TRON-US/go-btfs/repo/fsrepo"
 
@@ -51,6 +53,7 @@ btfs peers in the internet.
 		"disconnect": swarmDisconnectCmd,
 		"filters":    swarmFiltersCmd,
 		"peers":      swarmPeersCmd,
+		"peer-stats": swarmPeerStatsCmd,
 	},
 }
 
@@ -66,19 +69,25 @@ var swarmPeersCmd = &cmds.Command{
 		Tagline: "List peers with open connections.",
 		ShortDescription: `
 'btfs swarm peers' lists the set of peers this node is connected to.
+Use --limit to list them a page at a time, passing the returned NextCursor
+as --cursor to get the next page.
 `,
 	},
-	Options: []cmds.Option{
+	Options: append([]cmds.Option{
 		cmds.BoolOption(swarmVerboseOptionName, "v", "display all extra information"),
 		cmds.BoolOption(swarmStreamsOptionName, "Also list information about open streams for each peer"),
 		cmds.BoolOption(swarmLatencyOptionName, "Also list information about latency to each peer"),
 		cmds.BoolOption(swarmDirectionOptionName, "Also list information about the direction of connection"),
-	},
+	}, paging.Options(0)...),
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		api, err := cmdenv.GetApi(env, req)
 		if err != nil {
 			return err
 		}
+		page, err := paging.FromRequest(req)
+		if err != nil {
+			return err
+		}
 
 		verbose, _ := req.Options[swarmVerboseOptionName].(bool)
 		latency, _ := req.Options[swarmLatencyOptionName].(bool)
@@ -129,6 +138,10 @@ var swarmPeersCmd = &cmds.Command{
 		}
 
 		sort.Sort(&out)
+		start, end, next := page.Bounds(len(out.Peers), func(i int) string {
+			return out.Peers[i].Addr + "/btfs/" + out.Peers[i].Peer
+		})
+		out.Peers, out.NextCursor = out.Peers[start:end], next
 		return cmds.EmitOnce(res, &out)
 	},
 	Encoders: cmds.EncoderMap{
@@ -152,6 +165,7 @@ var swarmPeersCmd = &cmds.Command{
 					fmt.Fprintf(w, "  %s\n", s.Protocol)
 				}
 			}
+			paging.WriteNext(w, ci.NextCursor)
 
 			return nil
 		}),
@@ -185,7 +199,8 @@ func (ci *connInfo) Swap(i, j int) {
 }
 
 type connInfos struct {
-	Peers []connInfo
+	Peers      []connInfo
+	NextCursor string `json:",omitempty"`
 }
 
 func (ci connInfos) Less(i, j int) bool {
@@ -220,8 +235,9 @@ var swarmAddrsCmd = &cmds.Command{
 `,
 	},
 	Subcommands: map[string]*cmds.Command{
-		"local":  swarmAddrsLocalCmd,
-		"listen": swarmAddrsListenCmd,
+		"local":     swarmAddrsLocalCmd,
+		"listen":    swarmAddrsListenCmd,
+		"announced": swarmAddrsAnnouncedCmd,
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		api, err := cmdenv.GetApi(env, req)
@@ -344,6 +360,84 @@ var swarmAddrsListenCmd = &cmds.Command{
 	},
 }
 
+type announcedAddrs struct {
+	Addrs   []string
+	Profile *addrprofile.Status `json:",omitempty"`
+}
+
+var swarmAddrsAnnouncedCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the addresses announced to the network.",
+		ShortDescription: `
+'btfs swarm addrs announced' lists the addresses currently advertised to the
+network, after Addresses.Announce, the AnnounceProfile and
+Addresses.NoAnnounce, with the AnnounceProfile in effect.
+
+Behind a NAT, the AnnounceProfile replaces the IP of the announced addresses
+with a static public IP, or with the addresses of a DDNS hostname resolved
+every DDNSInterval (5m by default), and leaves out the private ranges:
+
+    $ btfs config AnnounceProfile.PublicIP 203.0.113.7
+    $ btfs config AnnounceProfile.DDNS myhost.example.com
+    $ btfs config --json AnnounceProfile.FilterPrivate true
+
+With AnnounceProfile.AnnounceDNS set, the DDNS hostname itself is announced
+as /dns4 and /dns6 addresses. Changes take effect when the daemon restarts.
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if !n.IsOnline {
+			return ErrNotOnline
+		}
+
+		out := &announcedAddrs{Addrs: []string{}}
+		for _, addr := range n.PeerHost.Addrs() {
+			out.Addrs = append(out.Addrs, addr.String())
+		}
+		sort.Strings(out.Addrs)
+		if p := addrprofile.Active(); p != nil {
+			out.Profile = p.Status()
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: announcedAddrs{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *announcedAddrs) error {
+			for _, addr := range out.Addrs {
+				fmt.Fprintln(w, addr)
+			}
+			p := out.Profile
+			if p == nil {
+				return nil
+			}
+			fmt.Fprintln(w)
+			switch {
+			case p.PublicIP != "":
+				fmt.Fprintf(w, "Public IP: %s\n", p.PublicIP)
+			case p.DDNS != "" && p.AnnounceDNS:
+				fmt.Fprintf(w, "DDNS: %s (announced as is)\n", p.DDNS)
+			case p.DDNS != "":
+				resolved := "not resolved yet"
+				if !p.ResolvedAt.IsZero() {
+					resolved = fmt.Sprintf("%v at %s", p.Resolved, p.ResolvedAt.Format(time.RFC3339))
+				}
+				if p.ResolveError != "" {
+					resolved += " (last resolution failed: " + p.ResolveError + ")"
+				}
+				fmt.Fprintf(w, "DDNS: %s, %s\n", p.DDNS, resolved)
+			}
+			if p.FilterPrivate {
+				fmt.Fprintln(w, "Private addresses filtered")
+			}
+			return nil
+		}),
+	},
+}
+
 var swarmConnectCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Open connection to a given address.",
@@ -0,0 +1,220 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/peerstats"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+	peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	peerStatsTopOptionName      = "top"
+	peerStatsDurationOptionName = "duration"
+)
+
+var swarmPeerStatsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the usage of the node by a peer.",
+		ShortDescription: `
+'btfs swarm peer-stats' shows the streams and bytes of a peer, or of the peers
+which moved the most bytes without a peer ID, for each protocol: bitswap, the
+storage and challenge calls, the p2p tunnels and the others. Inbound streams
+are the streams opened by the peer.
+
+The streams opened by a peer are measured over PeerStats.Window (1m by
+default). A peer opening more than PeerStats.MaxStreams streams or moving more
+than PeerStats.MaxBytes in a window commits an offense, and with
+PeerStats.AutoBlock set is refused for PeerStats.BlockFor (1h by default):
+
+    $ btfs config --json PeerStats '{"MaxStreams": 600, "MaxBytes": "1GB", "AutoBlock": true}'
+
+The statistics start with the daemon.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"report":  swarmPeerStatsReportCmd,
+		"blocked": swarmPeerStatsBlockedCmd,
+		"block":   swarmPeerStatsBlockCmd,
+		"unblock": swarmPeerStatsUnblockCmd,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("peer-id", false, false, "Peer to show."),
+	},
+	Options: []cmds.Option{
+		cmds.IntOption(peerStatsTopOptionName, "Number of peers shown without a peer ID, 0 for all.").WithDefault(20),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if !n.IsOnline {
+			return ErrNotOnline
+		}
+		if len(req.Arguments) == 0 {
+			return cmds.EmitOnce(res, peerstats.Default.Top(req.Options[peerStatsTopOptionName].(int)))
+		}
+		p, err := peer.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		s, ok := peerstats.Default.Peer(p)
+		if !ok {
+			return fmt.Errorf("no usage by peer %s", p.Pretty())
+		}
+		return cmds.EmitOnce(res, []*peerstats.Stats{s})
+	},
+	Type: []*peerstats.Stats{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ss []*peerstats.Stats) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			for i, s := range ss {
+				if i > 0 {
+					fmt.Fprintln(tw)
+				}
+				fmt.Fprintf(tw, "%s\tseen since %s\n", s.Peer, s.FirstSeen.Format(time.RFC3339))
+				if s.BlockedUntil != nil {
+					fmt.Fprintf(tw, "blocked until %s\n", s.BlockedUntil.Format(time.RFC3339))
+				}
+				fmt.Fprintln(tw, "PROTOCOL\tSTREAMS IN\tSTREAMS OUT\tBYTES IN\tBYTES OUT")
+				for _, c := range peerstats.Categories {
+					u, ok := s.Usage[c]
+					if !ok {
+						continue
+					}
+					fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", c, u.StreamsIn, u.StreamsOut,
+						humanize.Bytes(uint64(u.BytesIn)), humanize.Bytes(uint64(u.BytesOut)))
+				}
+				for _, o := range s.Offenses {
+					fmt.Fprintf(tw, "offense %s: %d streams, %s in %s, over %s\n", o.Time.Format(time.RFC3339),
+						o.Streams, humanize.Bytes(uint64(o.Bytes)), o.Window, o.Limit)
+				}
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var swarmPeerStatsReportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Export an abuse report of a peer signed by the node.",
+		ShortDescription: `
+'btfs swarm peer-stats report' outputs the usage of the node by a peer and the
+offenses of the peer as JSON, signed with the key of the node, so that the
+report can be checked by whoever receives it.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("peer-id", true, false, "Peer to report."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if !n.IsOnline {
+			return ErrNotOnline
+		}
+		p, err := peer.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		s, ok := peerstats.Default.Peer(p)
+		if !ok {
+			return fmt.Errorf("no usage by peer %s", p.Pretty())
+		}
+		r := peerstats.NewReport(n.Identity, s, time.Now())
+		if err := r.Sign(n.PrivateKey); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, r)
+	},
+	Type: peerstats.Report{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, r *peerstats.Report) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(r)
+		}),
+	},
+}
+
+var swarmPeerStatsBlockedCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the peers refused by the node.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		return cmds.EmitOnce(res, peerstats.Default.Blocked())
+	},
+	Type: []peerstats.Block{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, bs []peerstats.Block) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "PEER\tUNTIL")
+			for _, b := range bs {
+				fmt.Fprintf(tw, "%s\t%s\n", b.Peer, b.Until.Format(time.RFC3339))
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var swarmPeerStatsBlockCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Refuse the connections of a peer.",
+		ShortDescription: `
+'btfs swarm peer-stats block' closes the connections of a peer and refuses its
+new connections for a duration. Blocks do not persist daemon restarts.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("peer-id", true, false, "Peer to block."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(peerStatsDurationOptionName, "d", "How long to refuse the peer.").WithDefault("1h"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		p, err := peer.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		d, err := time.ParseDuration(req.Options[peerStatsDurationOptionName].(string))
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q", req.Options[peerStatsDurationOptionName])
+		}
+		peerstats.Default.Block(p, d)
+		return cmds.EmitOnce(res, &stringList{[]string{p.Pretty()}})
+	},
+	Type: stringList{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(stringListEncoder),
+	},
+}
+
+var swarmPeerStatsUnblockCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Accept the connections of a blocked peer again.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("peer-id", true, false, "Peer to unblock."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		p, err := peer.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		if !peerstats.Default.Unblock(p) {
+			return fmt.Errorf("peer %s is not blocked", p.Pretty())
+		}
+		return cmds.EmitOnce(res, &stringList{[]string{p.Pretty()}})
+	},
+	Type: stringList{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(stringListEncoder),
+	},
+}
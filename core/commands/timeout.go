@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+// defaultTimeouts are the default timeouts of the command classes calling
+// remote services (exchange, escrow, guard, hub, tron nodes), so that they
+// fail instead of hanging when a service stalls. They apply to the commands
+// without a timeout of their own, --timeout overrides them.
+var defaultTimeouts = map[string]time.Duration{
+	"wallet":  2 * time.Minute,
+	"tron":    time.Minute,
+	"guard":   time.Minute,
+	"storage": helper.DefaultStorageTimeout,
+}
+
+// noDefaultTimeouts are the commands of these classes which stream data for
+// as long as it takes, or wait up to a bound of their own, and run without
+// a default timeout.
+var noDefaultTimeouts = map[string]bool{
+	"storage export": true,
+	// waits up to sessions.MaxWaitTimeout with --wait-timeout
+	"storage upload status": true,
+}
+
+// setDefaultTimeouts sets the timeout d on cmd, named name, and its
+// subcommands that have none, but the noDefaultTimeouts.
+func setDefaultTimeouts(name string, cmd *cmds.Command, d time.Duration) {
+	if noDefaultTimeouts[name] {
+		return
+	}
+	if cmd.Run != nil && cmd.RunTimeout == 0 {
+		cmd.RunTimeout = d
+	}
+	for subName, sub := range cmd.Subcommands {
+		setDefaultTimeouts(name+" "+subName, sub, d)
+	}
+}
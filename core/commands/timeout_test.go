@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+)
+
+func TestDefaultTimeouts(t *testing.T) {
+	if d := StorageExportCmd.RunTimeout; d != 0 {
+		t.Fatalf("expected storage export to stream without a timeout, got %s", d)
+	}
+	if d := storage.StorageCmd.Subcommands["upload"].Subcommands["status"].RunTimeout; d != 0 {
+		t.Fatalf("expected storage upload status to wait without a timeout, got %s", d)
+	}
+	if d := storage.StorageCmd.Subcommands["files"].Subcommands["stats"].RunTimeout; d != helper.DefaultStorageTimeout {
+		t.Fatalf("expected the default storage timeout, got %s", d)
+	}
+}
@@ -0,0 +1,321 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	cmdenv "github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/storage/challenge"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	config "github.com/TRON-US/go-btfs-config"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/libp2p/go-libp2p-core/event"
+	metrics "github.com/libp2p/go-libp2p-core/metrics"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	topIntervalOptionName = "interval"
+
+	// topMinRefresh limits the refreshes triggered by network events.
+	topMinRefresh = 500 * time.Millisecond
+	// topWalletRefresh is how often the wallet balance, which is queried
+	// from the chain, is refreshed.
+	topWalletRefresh = 30 * time.Second
+	// topMaxRows limits the rows of each table of the dashboard.
+	topMaxRows = 10
+)
+
+// TopOutput is one refresh of the dashboard.
+type TopOutput struct {
+	Time       time.Time
+	Peers      int
+	TopPeers   []TopPeer
+	Bandwidth  metrics.Stats
+	Sessions   []TopSession
+	Challenges []challenge.Result
+	Wallet     *TopWallet `json:",omitempty"`
+	Errors     []string
+}
+
+// TopPeer is a connected peer and its average latency.
+type TopPeer struct {
+	ID      string
+	Latency time.Duration
+}
+
+// TopSession is a running upload session of this node as a renter.
+type TopSession struct {
+	ID          string
+	Hash        string
+	Status      string
+	Shards      int
+	TotalShards int
+}
+
+// TopWallet is the wallet balance, in µBTT.
+type TopWallet struct {
+	Tron   int64
+	Ledger int64
+}
+
+var TopCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show a live dashboard of the node.",
+		ShortDescription: `
+'btfs top' shows the connected peers, the bandwidth, the running storage
+upload sessions, the latest storage challenges answered by this host, the
+wallet balance and the recent errors of all of them. It refreshes every
+interval and whenever peers connect or disconnect. Press Ctrl-C to quit.
+
+When the output is not a terminal, every refresh is printed after the
+previous one.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(topIntervalOptionName, "i", "Time between refreshes.").WithDefault("2s"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		if !n.IsOnline {
+			return cmds.Errorf(cmds.ErrClient, ErrNotOnline.Error())
+		}
+		cfg, err := cmdenv.GetConfig(env)
+		if err != nil {
+			return err
+		}
+		s, _ := req.Options[topIntervalOptionName].(string)
+		interval, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		if interval < topMinRefresh {
+			return fmt.Errorf("interval must be at least %s", topMinRefresh)
+		}
+
+		sub, err := n.PeerHost.EventBus().Subscribe([]interface{}{
+			new(event.EvtPeerConnectednessChanged),
+			new(event.EvtPeerIdentificationCompleted),
+		})
+		if err != nil {
+			return err
+		}
+		defer sub.Close()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		w := &topWallet{cfg: cfg}
+		var last time.Time
+		for {
+			out := &TopOutput{Time: time.Now()}
+			var peers []TopPeer
+			for _, p := range n.PeerHost.Network().Peers() {
+				peers = append(peers, TopPeer{ID: p.Pretty(), Latency: n.Peerstore.LatencyEWMA(p)})
+			}
+			out.Peers, out.TopPeers = len(peers), topPeers(peers)
+			if n.Reporter != nil {
+				out.Bandwidth = n.Reporter.GetBandwidthTotals()
+			}
+			out.Sessions, out.Errors = topSessions()
+			out.Challenges = challenge.RecentResults()
+			for _, r := range out.Challenges {
+				if r.Error != "" {
+					out.Errors = append(out.Errors, fmt.Sprintf("challenge %s: %s", r.ContractID, r.Error))
+				}
+			}
+			var werr error
+			out.Wallet, werr = w.balance(req.Context)
+			if werr != nil {
+				out.Errors = append(out.Errors, "wallet: "+werr.Error())
+			}
+			if err := res.Emit(out); err != nil {
+				return err
+			}
+			last = time.Now()
+
+			for refresh := false; !refresh; {
+				select {
+				case <-ticker.C:
+					refresh = true
+				case <-sub.Out():
+					refresh = time.Since(last) >= topMinRefresh
+				case <-req.Context.Done():
+					return nil
+				}
+			}
+		}
+	},
+	Type: TopOutput{},
+	PostRun: cmds.PostRunMap{
+		cmds.CLI: func(res cmds.Response, re cmds.ResponseEmitter) error {
+			live := terminal.IsTerminal(int(os.Stdout.Fd()))
+			for {
+				v, err := res.Next()
+				if err != nil {
+					if err == io.EOF {
+						return nil
+					}
+					return err
+				}
+				out, ok := v.(*TopOutput)
+				if !ok {
+					return fmt.Errorf("unexpected top output type %T", v)
+				}
+				if live {
+					// Move home and clear the screen.
+					fmt.Fprint(os.Stdout, "\033[H\033[2J")
+				}
+				renderTop(os.Stdout, out)
+				if !live {
+					fmt.Fprintln(os.Stdout)
+				}
+			}
+		},
+	},
+}
+
+// topSessions returns the running renter sessions, and the errors of those
+// that failed.
+func topSessions() ([]TopSession, []string) {
+	var out []TopSession
+	var errs []string
+	for _, rs := range sessions.CachedRenterSessions() {
+		st, err := rs.Status()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("session %s: %s", rs.SsId, err))
+			continue
+		}
+		switch st.Status {
+		case sessions.RssCompleteStatus:
+			continue
+		case sessions.RssErrorStatus:
+			errs = append(errs, fmt.Sprintf("session %s: %s", rs.SsId, st.Message))
+			continue
+		}
+		done, _, err := rs.GetCompleteShardsNum()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("session %s: %s", rs.SsId, err))
+		}
+		out = append(out, TopSession{
+			ID:          rs.SsId,
+			Hash:        rs.Hash,
+			Status:      st.Status,
+			Shards:      done,
+			TotalShards: len(st.ShardHashes),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	sort.Strings(errs)
+	return out, errs
+}
+
+// topPeers returns the topMaxRows peers with the lowest latency. Peers of
+// unknown latency come last.
+func topPeers(peers []TopPeer) []TopPeer {
+	sort.SliceStable(peers, func(i, j int) bool {
+		li, lj := peers[i].Latency, peers[j].Latency
+		return li != 0 && (lj == 0 || li < lj)
+	})
+	if len(peers) > topMaxRows {
+		peers = peers[:topMaxRows]
+	}
+	return peers
+}
+
+// topWallet caches the wallet balance between topWalletRefresh.
+type topWallet struct {
+	cfg    *config.Config
+	last   time.Time
+	cached *TopWallet
+	err    error
+}
+
+func (w *topWallet) balance(ctx context.Context) (*TopWallet, error) {
+	if time.Since(w.last) < topWalletRefresh {
+		return w.cached, w.err
+	}
+	w.last = time.Now()
+	tron, ledger, err := wallet.GetBalance(ctx, w.cfg)
+	if err != nil {
+		w.err = err
+		return w.cached, err
+	}
+	w.cached, w.err = &TopWallet{Tron: tron, Ledger: ledger}, nil
+	return w.cached, nil
+}
+
+// renderTop writes one screen of the dashboard.
+func renderTop(w io.Writer, out *TopOutput) {
+	bw := out.Bandwidth
+	fmt.Fprintf(w, "btfs top - %s  peers %d  in %s/s  out %s/s  total in %s  out %s\n",
+		out.Time.Format("15:04:05"), out.Peers,
+		humanize.Bytes(uint64(bw.RateIn)), humanize.Bytes(uint64(bw.RateOut)),
+		humanize.Bytes(uint64(bw.TotalIn)), humanize.Bytes(uint64(bw.TotalOut)))
+	if out.Wallet != nil {
+		fmt.Fprintf(w, "wallet: %d µBTT on chain, %d µBTT in ledger\n", out.Wallet.Tron, out.Wallet.Ledger)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\nPEERS (%d)\n", out.Peers)
+	if out.Peers > 0 {
+		fmt.Fprintln(tw, "  ID\tLATENCY")
+	}
+	for _, p := range out.TopPeers {
+		latency := "-"
+		if p.Latency > 0 {
+			latency = p.Latency.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", p.ID, latency)
+	}
+	if more := out.Peers - len(out.TopPeers); more > 0 {
+		fmt.Fprintf(tw, "  ... and %d more\n", more)
+	}
+
+	fmt.Fprintf(tw, "\nSTORAGE SESSIONS (%d)\n", len(out.Sessions))
+	if len(out.Sessions) > 0 {
+		fmt.Fprintln(tw, "  SESSION\tSTATUS\tSHARDS\tFILE")
+	}
+	for i, s := range out.Sessions {
+		if i == topMaxRows {
+			fmt.Fprintf(tw, "  ... and %d more\n", len(out.Sessions)-i)
+			break
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%d/%d\t%s\n", s.ID, s.Status, s.Shards, s.TotalShards, s.Hash)
+	}
+
+	fmt.Fprintf(tw, "\nCHALLENGES (%d)\n", len(out.Challenges))
+	if len(out.Challenges) > 0 {
+		fmt.Fprintln(tw, "  TIME\tCONTRACT\tSHARD\tRESULT")
+	}
+	for i, c := range out.Challenges {
+		if i == topMaxRows {
+			break
+		}
+		result := "ok"
+		if c.Error != "" {
+			result = "failed"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", c.Time.Format("15:04:05"), c.ContractID, c.ShardHash, result)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nERRORS (%d)\n", len(out.Errors))
+	for i, e := range out.Errors {
+		if i == topMaxRows {
+			fmt.Fprintf(w, "  ... and %d more\n", len(out.Errors)-i)
+			break
+		}
+		fmt.Fprintf(w, "  %s\n", e)
+	}
+}
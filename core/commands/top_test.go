@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/challenge"
+)
+
+func TestTopPeers(t *testing.T) {
+	var peers []TopPeer
+	for i := 0; i < topMaxRows+5; i++ {
+		peers = append(peers, TopPeer{ID: fmt.Sprintf("peer%d", i), Latency: time.Duration(i) * time.Millisecond})
+	}
+	top := topPeers(peers)
+	if len(top) != topMaxRows {
+		t.Fatalf("got %d peers, want %d", len(top), topMaxRows)
+	}
+	// peer0 has an unknown latency and comes last, out of the top.
+	if top[0].ID != "peer1" || top[topMaxRows-1].ID != fmt.Sprintf("peer%d", topMaxRows) {
+		t.Errorf("unexpected order %v", top)
+	}
+}
+
+func TestRenderTop(t *testing.T) {
+	out := &TopOutput{
+		Time:     time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+		Peers:    12,
+		TopPeers: []TopPeer{{ID: "QmPeer", Latency: 12 * time.Millisecond}, {ID: "QmSlow"}},
+		Sessions: []TopSession{{ID: "ss1", Hash: "QmFile", Status: "wait-upload", Shards: 3, TotalShards: 30}},
+		Challenges: []challenge.Result{
+			{Time: time.Date(2020, 1, 2, 15, 4, 0, 0, time.UTC), ContractID: "c1", ShardHash: "QmShard"},
+			{Time: time.Date(2020, 1, 2, 15, 3, 0, 0, time.UTC), ContractID: "c2", ShardHash: "QmShard", Error: "bad chunk"},
+		},
+		Wallet: &TopWallet{Tron: 100, Ledger: 200},
+		Errors: []string{"challenge c2: bad chunk"},
+	}
+	var b bytes.Buffer
+	renderTop(&b, out)
+	s := b.String()
+	for _, want := range []string{
+		"btfs top - 15:04:05  peers 12",
+		"wallet: 100 µBTT on chain, 200 µBTT in ledger",
+		"PEERS (12)",
+		"QmPeer  12ms",
+		"QmSlow  -",
+		"... and 10 more",
+		"ss1      wait-upload  3/30    QmFile",
+		"15:04:00  c1        QmShard  ok",
+		"15:03:00  c2        QmShard  failed",
+		"ERRORS (1)\n  challenge c2: bad chunk\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("missing %q in:\n%s", want, s)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	version "github.com/TRON-US/go-btfs"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"gopkg.in/yaml.v2"
+)
+
+// UpdateConfigKey is the config section of the auto-update, e.g.
+//
+//  "Update": {"Channel": "beta"}
+const UpdateConfigKey = "Update"
+
+// DefaultUpdateChannel is used when no channel is configured.
+const DefaultUpdateChannel = "stable"
+
+// UpdateChannels maps the release channels to the base URL of their builds.
+var UpdateChannels = map[string]string{
+	"stable":  "https://dist.btfs.io/release/",
+	"beta":    "https://dist.btfs.io/beta/",
+	"nightly": "https://dist.btfs.io/nightly/",
+}
+
+// UpdateConfig configures the auto-update.
+type UpdateConfig struct {
+	// Channel is one of UpdateChannels.
+	Channel string
+	// PublicKey is the base64 ed25519 key release binaries are signed
+	// with, overriding the key built into the binary. The daemon does not
+	// update itself without a key.
+	PublicKey string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(UpdateConfigKey, UpdateConfig{})
+}
+
+// LoadUpdateConfig returns the update config of r with the defaults
+// filled in.
+func LoadUpdateConfig(r repo.Repo) (*UpdateConfig, error) {
+	uc := &UpdateConfig{}
+	if _, err := repo.GetConfigSection(r, UpdateConfigKey, uc); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", UpdateConfigKey, err)
+	}
+	if uc.Channel == "" {
+		uc.Channel = DefaultUpdateChannel
+	}
+	if _, ok := UpdateChannels[uc.Channel]; !ok {
+		return nil, fmt.Errorf("unknown update channel %q", uc.Channel)
+	}
+	return uc, nil
+}
+
+// UpdateBackupSuffix is appended by the updater to the binary and release
+// config it replaces.
+const UpdateBackupSuffix = ".bk"
+
+// UpdateChannelOutput is the configured update channel.
+type UpdateChannelOutput struct {
+	Channel  string
+	Channels []string
+}
+
+// UpdateNowOutput is the version a node runs when asked to update.
+type UpdateNowOutput struct {
+	Version string
+	Channel string
+}
+
+// UpdateNowRequests wakes the auto-update of the daemon for a check of its
+// channel outside of its schedule and the rollout share of the release.
+var UpdateNowRequests = make(chan struct{}, 1)
+
+// UpdateRollbackOutput describes a rollback.
+type UpdateRollbackOutput struct {
+	Binary  string
+	Version string `json:",omitempty"`
+}
+
+var UpdateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the BTFS auto-update.",
+		ShortDescription: `
+The daemon updates itself from the release channel set in the Update
+section of the config: stable (the default), beta or nightly. Releases are
+rolled out to a growing share of the nodes, and every node decides on its
+own whether it is part of the current share.
+
+Downloaded binaries are only installed with a valid signature of the
+release signing key, built into the binary or set in Update.PublicKey; the
+daemon does not update itself without one. The updater keeps the replaced binary next to the
+new one, and 'btfs update rollback' switches back to it. 'btfs update now'
+has the daemon check its channel at once, e.g. to upgrade a fleet in waves
+with 'btfs fleet upgrade'.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"channel":  updateChannelCmd,
+		"rollback": updateRollbackCmd,
+		"now":      updateNowCmd,
+	},
+}
+
+var updateChannelCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show or set the update channel.",
+		ShortDescription: `
+Without argument, shows the update channel. With one, switches to it; the
+daemon picks it up on its next update check. Switching to an older channel
+does not downgrade the installed version.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("channel", false, false, "One of stable, beta or nightly."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		cfgRoot, err := cmdenv.GetConfigRoot(env)
+		if err != nil {
+			return err
+		}
+		r, err := fsrepo.Open(cfgRoot)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		uc := &UpdateConfig{}
+		if _, err := repo.GetConfigSection(r, UpdateConfigKey, uc); err != nil {
+			return err
+		}
+		if len(req.Arguments) > 0 {
+			channel := req.Arguments[0]
+			if _, ok := UpdateChannels[channel]; !ok {
+				return fmt.Errorf("unknown update channel %q, expected one of %v", channel, updateChannelNames())
+			}
+			uc.Channel = channel
+			if err := repo.SetConfigSection(r, UpdateConfigKey, uc); err != nil {
+				return err
+			}
+		}
+		if uc.Channel == "" {
+			uc.Channel = DefaultUpdateChannel
+		}
+		return cmds.EmitOnce(res, &UpdateChannelOutput{Channel: uc.Channel, Channels: updateChannelNames()})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *UpdateChannelOutput) error {
+			_, err := fmt.Fprintln(w, out.Channel)
+			return err
+		}),
+	},
+	Type: UpdateChannelOutput{},
+}
+
+var updateNowCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Check the update channel now.",
+		ShortDescription: `
+Has the daemon check its update channel at once, whatever the rollout share
+of the release, and install a newer version if any: the daemon is then
+restarted by the updater. The command returns before the check, with the
+version running; poll 'btfs version' for the new one.
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		if cfg.Experimental.DisableAutoUpdate {
+			return errors.New("auto-update disabled by Experimental.DisableAutoUpdate")
+		}
+		uc, err := LoadUpdateConfig(n.Repo)
+		if err != nil {
+			return err
+		}
+		select {
+		case UpdateNowRequests <- struct{}{}:
+		default:
+			// a check is already requested
+		}
+		return cmds.EmitOnce(res, &UpdateNowOutput{Version: version.CurrentVersionNumber, Channel: uc.Channel})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *UpdateNowOutput) error {
+			_, err := fmt.Fprintf(w, "running %s, checking the %s channel\n", out.Version, out.Channel)
+			return err
+		}),
+	},
+	Type: UpdateNowOutput{},
+}
+
+var updateRollbackCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Switch back to the binary replaced by the last update.",
+		ShortDescription: `
+Swaps the btfs binary with the one the last update replaced, so running the
+command again undoes the rollback. Restart the daemon afterwards, and pin
+the version with 'btfs config --bool Experimental.DisableAutoUpdate true'
+to keep the updater from installing the release again.
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		exe, err = filepath.EvalSymlinks(exe)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(exe + UpdateBackupSuffix); err != nil {
+			if os.IsNotExist(err) {
+				return errors.New("no previous version kept on disk, nothing to roll back to")
+			}
+			return err
+		}
+		if err := swapWithBackup(exe); err != nil {
+			return err
+		}
+		// The release config of the updater records the installed version.
+		release := filepath.Join(filepath.Dir(exe), "config.yaml")
+		if _, err := os.Stat(release + UpdateBackupSuffix); err == nil {
+			if err := swapWithBackup(release); err != nil {
+				return err
+			}
+		}
+		return cmds.EmitOnce(res, &UpdateRollbackOutput{Binary: exe, Version: releaseVersion(release)})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *UpdateRollbackOutput) error {
+			version := out.Version
+			if version == "" {
+				version = "the previous version"
+			}
+			_, err := fmt.Fprintf(w, "rolled %s back to %s, restart the daemon to run it\n", out.Binary, version)
+			return err
+		}),
+	},
+	Type: UpdateRollbackOutput{},
+}
+
+// swapWithBackup exchanges path and its backup. Renaming keeps the file
+// modes, and works on a running binary.
+func swapWithBackup(path string) error {
+	backup := path + UpdateBackupSuffix
+	tmp := path + ".rollback"
+	if err := os.Rename(path, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(backup, path); err != nil {
+		os.Rename(tmp, path)
+		return err
+	}
+	return os.Rename(tmp, backup)
+}
+
+// releaseVersion reads the version from the release config of the updater.
+func releaseVersion(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var release struct {
+		Version string `yaml:"version"`
+	}
+	if yaml.Unmarshal(b, &release) != nil {
+		return ""
+	}
+	return release.Version
+}
+
+func updateChannelNames() []string {
+	names := make([]string, 0, len(UpdateChannels))
+	for name := range UpdateChannels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
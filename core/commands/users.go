@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/users"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	userRoleOptionName   = "role"
+	userBudgetOptionName = "budget"
+)
+
+// UserOutput is an account of the node.
+type UserOutput struct {
+	Name    string
+	Role    users.Role
+	Scopes  []users.Scope
+	Budget  int64
+	Used    int64
+	Created time.Time
+	// Token is the API token of the account, only set when it is created
+	// or reset.
+	Token string `json:",omitempty"`
+}
+
+func newUserOutput(u *users.User, token string) *UserOutput {
+	return &UserOutput{
+		Name:    u.Name,
+		Role:    u.Role,
+		Scopes:  u.Role.Scopes(),
+		Budget:  u.Budget,
+		Used:    u.Used,
+		Created: u.Created,
+		Token:   token,
+	}
+}
+
+var UsersCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the accounts of a node shared by a team.",
+		ShortDescription: `
+Adding an account turns the multi-user mode on: remote API callers must then
+send the token of an account, 'Authorization: Bearer <token>', and may only
+run the commands of its role:
+
+  admin     every command, including the accounts and the wallet
+  uploader  add, pin and upload content, and read everything
+  viewer    read content and the state of the node
+
+Callers from the loopback interface without a token are the operator of the
+node and keep running every command. Uploaders and viewers see their own
+MFS namespace, /users/<name>, as the root of 'btfs files', and uploaders
+may be given a budget of bytes stored on hosts.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"add":   usersAddCmd,
+		"list":  usersListCmd,
+		"rm":    usersRmCmd,
+		"set":   usersSetCmd,
+		"token": usersTokenCmd,
+	},
+}
+
+func usersStore(env cmds.Environment) (*users.Store, error) {
+	n, err := cmdenv.GetNode(env)
+	if err != nil {
+		return nil, err
+	}
+	return users.ForNode(n), nil
+}
+
+func parseBudget(s string) (int64, error) {
+	b, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s: %s", userBudgetOptionName, err)
+	}
+	return int64(b), nil
+}
+
+func formatBudget(u *UserOutput) string {
+	if u.Budget == 0 {
+		return humanize.Bytes(uint64(u.Used)) + " / unlimited"
+	}
+	return humanize.Bytes(uint64(u.Used)) + " / " + humanize.Bytes(uint64(u.Budget))
+}
+
+var userEncoder = cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *UserOutput) error {
+	fmt.Fprintf(w, "%s (%s), storage used %s\n", out.Name, out.Role, formatBudget(out))
+	if out.Token != "" {
+		fmt.Fprintf(w, "token: %s\n", out.Token)
+		fmt.Fprintln(w, "The token is not shown again, keep it safe.")
+	}
+	return nil
+})
+
+var usersAddCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Add an account and print its API token.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the account."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(userRoleOptionName, "Role of the account: admin, uploader or viewer.").WithDefault(string(users.Uploader)),
+		cmds.StringOption(userBudgetOptionName, "Bytes the account may store on hosts, e.g. 10GB. 0 for no limit.").WithDefault("0"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		role, err := users.ParseRole(req.Options[userRoleOptionName].(string))
+		if err != nil {
+			return err
+		}
+		budget, err := parseBudget(req.Options[userBudgetOptionName].(string))
+		if err != nil {
+			return err
+		}
+		s, err := usersStore(env)
+		if err != nil {
+			return err
+		}
+		u, token, err := s.Add(req.Arguments[0], role, budget)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, newUserOutput(u, token))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: userEncoder,
+	},
+	Type: UserOutput{},
+}
+
+var usersListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the accounts.",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		s, err := usersStore(env)
+		if err != nil {
+			return err
+		}
+		us, err := s.List()
+		if err != nil {
+			return err
+		}
+		out := make([]*UserOutput, 0, len(us))
+		for _, u := range us {
+			out = append(out, newUserOutput(u, ""))
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out []*UserOutput) error {
+			tw := tabwriter.NewWriter(w, 1, 2, 1, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tROLE\tSTORAGE\tCREATED")
+			for _, u := range out {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", u.Name, u.Role, formatBudget(u), u.Created.Format(time.RFC3339))
+			}
+			return tw.Flush()
+		}),
+	},
+	Type: []*UserOutput{},
+}
+
+var usersRmCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove an account.",
+		ShortDescription: `
+The token of the account stops working. Its MFS namespace is kept, and can
+be removed with 'btfs files rm -r /users/<name>'.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the account."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		s, err := usersStore(env)
+		if err != nil {
+			return err
+		}
+		return s.Remove(req.Arguments[0])
+	},
+}
+
+var usersSetCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Change the role or the storage budget of an account.",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the account."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(userRoleOptionName, "Role of the account: admin, uploader or viewer."),
+		cmds.StringOption(userBudgetOptionName, "Bytes the account may store on hosts, e.g. 10GB. 0 for no limit."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		roleOpt, setRole := req.Options[userRoleOptionName].(string)
+		budgetOpt, setBudget := req.Options[userBudgetOptionName].(string)
+		if !setRole && !setBudget {
+			return fmt.Errorf("nothing to change, use --%s or --%s", userRoleOptionName, userBudgetOptionName)
+		}
+		var (
+			role   users.Role
+			budget int64
+			err    error
+		)
+		if setRole {
+			if role, err = users.ParseRole(roleOpt); err != nil {
+				return err
+			}
+		}
+		if setBudget {
+			if budget, err = parseBudget(budgetOpt); err != nil {
+				return err
+			}
+		}
+		s, err := usersStore(env)
+		if err != nil {
+			return err
+		}
+		u, err := s.Update(req.Arguments[0], func(u *users.User) error {
+			if setRole {
+				u.Role = role
+			}
+			if setBudget {
+				u.Budget = budget
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, newUserOutput(u, ""))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: userEncoder,
+	},
+	Type: UserOutput{},
+}
+
+var usersTokenCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Replace the API token of an account.",
+		ShortDescription: `
+Prints a new token for the account, its previous token stops working.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the account."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		s, err := usersStore(env)
+		if err != nil {
+			return err
+		}
+		token, err := s.ResetToken(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		u, err := s.Get(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, newUserOutput(u, token))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: userEncoder,
+	},
+	Type: UserOutput{},
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+)
+
+// VerifyManifestOutput is the result of checking one manifest entry.
+type VerifyManifestOutput struct {
+	Path     string
+	Expected string `json:",omitempty"`
+	Actual   string `json:",omitempty"`
+	Ok       bool
+}
+
+// attachManifest opens the manifest file of the option of req, for the
+// client to send it to the daemon along the request.
+func attachManifest(req *cmds.Request) error {
+	p, _ := req.Options[manifestOptionName].(string)
+	if p == "" {
+		return fmt.Errorf("--%s required", manifestOptionName)
+	}
+	p = filepath.Clean(p)
+	st, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	f, err := files.NewSerialFile(p, false, st)
+	if err != nil {
+		return err
+	}
+	req.Files = files.NewSliceDirectory([]files.DirEntry{files.FileEntry(filepath.Base(p), f)})
+	return nil
+}
+
+var VerifyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Verify btfs content against a checksum manifest.",
+		ShortDescription: `
+'btfs verify' checks the plain file contents below <btfs-path> against the
+checksums of a manifest written by 'btfs add --manifest'. Every file is
+reported; the command fails if a checksum or size differs, a file is missing
+or a file is not listed in the manifest.
+
+  > btfs add -r --manifest sha256 docs
+  added QmW2WQi7j6c7UgJTarActp7tDNikE4B2qXtFCfLPdsgaTQ docs
+  manifest written to QmW2WQi7j6c7UgJTarActp7tDNikE4B2qXtFCfLPdsgaTQ.manifest.json
+  > btfs verify QmW2WQi7j6c7UgJTarActp7tDNikE4B2qXtFCfLPdsgaTQ --manifest QmW2WQi7j6c7UgJTarActp7tDNikE4B2qXtFCfLPdsgaTQ.manifest.json
+
+The manifest file is read by the client and sent to the daemon.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("btfs-path", true, false, "The path to the content to verify."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(manifestOptionName, "Manifest file written by 'btfs add --manifest'."),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		return attachManifest(req)
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if req.Files == nil {
+			return fmt.Errorf("--%s required", manifestOptionName)
+		}
+		it := req.Files.Entries()
+		if !it.Next() {
+			if it.Err() != nil {
+				return it.Err()
+			}
+			return fmt.Errorf("--%s required", manifestOptionName)
+		}
+		file, ok := it.Node().(files.File)
+		if !ok {
+			return fmt.Errorf("manifest %s is not a file", it.Name())
+		}
+		defer file.Close()
+		m, err := readManifest(file, it.Name())
+		if err != nil {
+			return err
+		}
+
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		nd, err := api.Unixfs().Get(req.Context, path.New(req.Arguments[0]))
+		if err != nil {
+			return err
+		}
+		defer nd.Close()
+
+		fails, err := verifyChecksums(m, nd, func(out *VerifyManifestOutput) error {
+			return res.Emit(out)
+		})
+		if err != nil {
+			return err
+		}
+		if fails != 0 {
+			return errors.New("verify complete, some files do not match the manifest")
+		}
+		return nil
+	},
+	Type: VerifyManifestOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *VerifyManifestOutput) error {
+			name := out.Path
+			if name == "" {
+				name = "."
+			}
+			switch {
+			case out.Ok:
+				fmt.Fprintf(w, "ok       %s\n", name)
+			case out.Actual == "":
+				fmt.Fprintf(w, "missing  %s\n", name)
+			case out.Expected == "":
+				fmt.Fprintf(w, "unlisted %s\n", name)
+			default:
+				fmt.Fprintf(w, "mismatch %s: expected %s, got %s\n", name, out.Expected, out.Actual)
+			}
+			return nil
+		}),
+	},
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	oldcmds "github.com/TRON-US/go-btfs/commands"
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/coreapi"
+	coremock "github.com/TRON-US/go-btfs/core/mock"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+)
+
+// runVerify runs 'btfs verify <p> --manifest <manifest>' in the process of
+// nd, like the CLI without a daemon.
+func runVerify(nd *core.IpfsNode, p, manifest string) ([]*VerifyManifestOutput, error) {
+	req, err := cmds.NewRequest(context.Background(), []string{"verify"},
+		cmds.OptMap{manifestOptionName: manifest}, []string{p}, nil, Root)
+	if err != nil {
+		return nil, err
+	}
+	env := &oldcmds.Context{ConstructNode: func() (*core.IpfsNode, error) { return nd, nil }}
+	re, res := cmds.NewChanResponsePair(req)
+	errCh := make(chan error, 1)
+	go func() {
+		err := cmds.NewExecutor(Root).Execute(req, re, env)
+		if err != nil {
+			// failed before running, e.g. in PreRun
+			re.CloseWithError(err)
+		}
+		errCh <- err
+	}()
+	var outs []*VerifyManifestOutput
+	for {
+		v, err := res.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return outs, err
+		}
+		outs = append(outs, v.(*VerifyManifestOutput))
+	}
+	return outs, <-errCh
+}
+
+func TestVerifyManifestLocal(t *testing.T) {
+	nd, err := coremock.NewMockNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nd.Close()
+	api, err := coreapi.NewCoreAPI(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := api.Unixfs().Add(context.Background(), files.NewMapDirectory(map[string]files.Node{
+		"a": files.NewBytesFile([]byte("testfileA")),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeManifest := func(name, content string) string {
+		sum := sha256.Sum256([]byte(content))
+		b, err := json.Marshal(&Manifest{
+			Version:   ManifestVersion,
+			Algorithm: "sha256",
+			Root:      p.Cid().String(),
+			Entries:   []ManifestEntry{{Path: "a", Size: int64(len(content)), Checksum: hex.EncodeToString(sum[:])}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(f, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	outs, err := runVerify(nd, p.String(), writeManifest("ok.manifest.json", "testfileA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outs) != 1 || outs[0].Path != "a" || !outs[0].Ok {
+		t.Fatalf("unexpected output %+v", outs)
+	}
+
+	outs, err = runVerify(nd, p.String(), writeManifest("bad.manifest.json", "testfileB"))
+	if err == nil {
+		t.Fatal("expected a mismatch to fail")
+	}
+	if len(outs) != 1 || outs[0].Ok {
+		t.Fatalf("unexpected output %+v", outs)
+	}
+
+	if _, err := runVerify(nd, p.String(), ""); err == nil {
+		t.Fatalf("expected --%s required", manifestOptionName)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"io"
 	"runtime"
 	"runtime/debug"
+	"sort"
 
 	version "github.com/TRON-US/go-btfs"
+	"github.com/TRON-US/go-btfs/core/protover"
 	fsrepo "github.com/TRON-US/go-btfs/repo/fsrepo"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
@@ -19,6 +21,9 @@ type VersionOutput struct {
 	Repo    string
 	System  string
 	Golang  string
+	// Protocols are the versions of the protocols spoken with the other
+	// parties of the network, by protocol.
+	Protocols map[string]string
 }
 
 const (
@@ -44,12 +49,17 @@ var VersionCmd = &cmds.Command{
 		cmds.BoolOption(versionAllOptionName, "Show all version information"),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		protocols := make(map[string]string, len(protover.Supported))
+		for ex, r := range protover.Supported {
+			protocols[string(ex)] = r.String()
+		}
 		return cmds.EmitOnce(res, &VersionOutput{
-			Version: version.CurrentVersionNumber,
-			Commit:  version.CurrentCommit,
-			Repo:    fmt.Sprint(fsrepo.RepoVersion),
-			System:  runtime.GOARCH + "/" + runtime.GOOS, //TODO: Precise version here
-			Golang:  runtime.Version(),
+			Version:   version.CurrentVersionNumber,
+			Commit:    version.CurrentCommit,
+			Repo:      fmt.Sprint(fsrepo.RepoVersion),
+			System:    runtime.GOARCH + "/" + runtime.GOOS, //TODO: Precise version here
+			Golang:    runtime.Version(),
+			Protocols: protocols,
 		})
 	},
 	Encoders: cmds.EncoderMap{
@@ -64,6 +74,14 @@ var VersionCmd = &cmds.Command{
 					"Repo version: %s\nSystem version: %s\nGolang version: %s\n",
 					ver, version.Repo, version.System, version.Golang)
 				fmt.Fprint(w, out)
+				names := make([]string, 0, len(version.Protocols))
+				for name := range version.Protocols {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					fmt.Fprintf(w, "Protocol %s versions: %s\n", name, version.Protocols[name])
+				}
 				return nil
 			}
 
@@ -1,17 +1,23 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os/exec"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/commands/e"
 	"github.com/TRON-US/go-btfs/core/commands/storage/path"
 	"github.com/TRON-US/go-btfs/core/wallet"
 	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/repocrypt"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	"github.com/TRON-US/go-btfs-cmds/http"
@@ -23,12 +29,24 @@ func init() {
 		"/wallet/init",
 		"/wallet/deposit",
 		"/wallet/withdraw",
+		"/wallet/withdrawals",
 		"/wallet/password",
+		"/wallet/password/change",
 		"/wallet/keys",
 		"/wallet/import",
+		"/wallet/export",
 		"/wallet/transfer",
+		"/wallet/schedules",
+		"/wallet/relay/send",
+		"/wallet/devices/ls",
+		"/wallet/devices/link",
+		"/wallet/devices/revoke",
+		"/wallet/devices/transfers",
 		"/wallet/balance",
-		"/wallet/discovery")
+		"/wallet/signer",
+		"/wallet/discovery",
+		"/wallet/unlock",
+		"/wallet/lock")
 }
 
 var WalletCmd = &cmds.Command{
@@ -43,14 +61,23 @@ withdraw and query balance of token used in BTFS.`,
 		"init":              walletInitCmd,
 		"deposit":           walletDepositCmd,
 		"withdraw":          walletWithdrawCmd,
+		"withdrawals":       walletWithdrawalsCmd,
+		"connector":         walletConnectorCmd,
 		"balance":           walletBalanceCmd,
 		"password":          walletPasswordCmd,
 		"keys":              walletKeysCmd,
 		"transactions":      walletTransactionsCmd,
 		"import":            walletImportCmd,
+		"export":            walletExportCmd,
 		"transfer":          walletTransferCmd,
+		"schedules":         walletSchedulesCmd,
+		"signer":            walletSignerCmd,
+		"relay":             walletRelayCmd,
+		"devices":           walletDevicesCmd,
 		"discovery":         walletDiscoveryCmd,
 		"validate_password": walletCheckPasswordCmd,
+		"unlock":            walletUnlockCmd,
+		"lock":              walletLockCmd,
 	},
 }
 
@@ -111,7 +138,10 @@ var walletDepositCmd = &cmds.Command{
 		if err != nil {
 			return err
 		}
-		if err := validatePassword(cfg, req); err != nil {
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		if _, err := useWalletSigner(n); err != nil {
 			return err
 		}
 		async, _ := req.Options[asyncOptionName].(bool)
@@ -152,11 +182,17 @@ var walletDepositCmd = &cmds.Command{
 	Type: MessageOutput{},
 }
 
+const toOptionName = "to"
+
 var walletWithdrawCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
-		Tagline:          "BTFS wallet withdraw",
-		ShortDescription: "BTFS wallet withdraw from ledger to block chain. Use '-p=<password>' to specific password.",
-		Options:          "unit is µBTT (=0.000001BTT)",
+		Tagline: "BTFS wallet withdraw",
+		ShortDescription: `BTFS wallet withdraw from ledger to block chain. Use '-p=<password>' to specific password.
+
+--to then transfers the amount withdrawn from the BTT wallet to the address,
+once the withdrawal is confirmed. The transfer runs in the daemon, 'btfs
+wallet withdrawals' shows its progress and, if it fails, where the amount is.`,
+		Options: "unit is µBTT (=0.000001BTT)",
 	},
 
 	Arguments: []cmds.Argument{
@@ -164,6 +200,7 @@ var walletWithdrawCmd = &cmds.Command{
 	},
 	Options: []cmds.Option{
 		cmds.StringOption(passwordOptionName, "p", "password"),
+		cmds.StringOption(toOptionName, "External address to transfer the amount withdrawn to."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		n, err := cmdenv.GetNode(env)
@@ -174,7 +211,10 @@ var walletWithdrawCmd = &cmds.Command{
 		if err != nil {
 			return err
 		}
-		if err := validatePassword(cfg, req); err != nil {
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		if _, err := useWalletSigner(n); err != nil {
 			return err
 		}
 		amount, err := strconv.ParseInt(req.Arguments[0], 10, 64)
@@ -182,6 +222,18 @@ var walletWithdrawCmd = &cmds.Command{
 			return err
 		}
 
+		if to, _ := req.Options[toOptionName].(string); to != "" {
+			conn, err := wallet.LoadConnector(n.Repo)
+			if err != nil {
+				return err
+			}
+			id, err := conn.Withdraw(req.Context, n, cfg, amount, to)
+			if err != nil {
+				return err
+			}
+			return cmds.EmitOnce(res, &MessageOutput{fmt.Sprintf("BTFS wallet withdraw %s submitted, the amount "+
+				"is transferred to %s once confirmed. See 'btfs wallet withdrawals'.\n", id, to)})
+		}
 		err = wallet.WalletWithdraw(req.Context, cfg, n, amount)
 		if err != nil {
 			if strings.Contains(err.Error(), "Please withdraw at least") {
@@ -202,6 +254,109 @@ var walletWithdrawCmd = &cmds.Command{
 	Type: MessageOutput{},
 }
 
+var walletWithdrawalsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the withdrawals to external addresses",
+		ShortDescription: `
+Lists the withdrawals made by 'btfs wallet withdraw --to', the latest first,
+with their stage: withdrawing, confirming, transferring, then done or
+failed. A failed withdrawal tells where its amount is.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		fs, err := wallet.ListWithdrawFlows(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, fs)
+	},
+	Type: []*wallet.WithdrawFlow{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, fs []*wallet.WithdrawFlow) error {
+			for _, f := range fs {
+				fmt.Fprintf(w, "%s %s: %d µBTT to %s, %s\n", f.Created.Format(time.RFC3339), f.ID, f.Amount,
+					f.To, f.Stage)
+				if f.WithdrawTxId != "" {
+					fmt.Fprintf(w, "  withdrawal %s\n", f.WithdrawTxId)
+				}
+				if f.TransferTxId != "" {
+					fmt.Fprintf(w, "  transfer %s\n", f.TransferTxId)
+				}
+				if f.Error != "" {
+					fmt.Fprintf(w, "  error: %s\n  %s\n", f.Error, f.Rollback)
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+var walletConnectorCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the exchange connector of the wallet",
+		ShortDescription: `
+Shows the exchange connector integrating the wallet with a custodial
+platform, and the address the platform deposits BTT to. The connector is
+set in the ExchangeConnector config section, the built-in ones are:
+
+  btfs     the BTT wallet of the node and the BTFS exchange, the default
+  webhook  the btfs flows, with each transaction of the wallet posted as
+           JSON to the url option, signed in the X-Btfs-Signature header
+           with the HMAC-SHA256 of the secret option when set
+
+    $ btfs config --json ExchangeConnector '{"Name": "webhook",
+        "Options": {"url": "https://custody.example.com/btfs", "secret": "..."}}'
+
+'btfs wallet withdraw --to' withdraws through the connector. The daemon
+loads the connector at startup.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		cc := &wallet.ConnectorConfig{}
+		if _, err := repo.GetConfigSection(n.Repo, wallet.ConnectorConfigKey, cc); err != nil {
+			return err
+		}
+		if cc.Name == "" {
+			cc.Name = wallet.ConnectorBTFS
+		}
+		conn, err := wallet.LoadConnector(n.Repo)
+		if err != nil {
+			return err
+		}
+		addr, err := conn.DepositAddress(req.Context, n, cfg)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &ConnectorOutput{Name: cc.Name, DepositAddress: addr,
+			Available: wallet.Connectors()})
+	},
+	Type: ConnectorOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ConnectorOutput) error {
+			fmt.Fprintf(w, "connector: %s\ndeposit address: %s\navailable: %s\n", out.Name, out.DepositAddress,
+				strings.Join(out.Available, ", "))
+			return nil
+		}),
+	},
+}
+
+// ConnectorOutput is the exchange connector of the wallet.
+type ConnectorOutput struct {
+	Name           string
+	DepositAddress string
+	Available      []string
+}
+
 var walletBalanceCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline:          "BTFS wallet balance",
@@ -221,6 +376,9 @@ var walletBalanceCmd = &cmds.Command{
 			return err
 		}
 
+		if _, err := useWalletSigner(n); err != nil {
+			return err
+		}
 		tronBalance, ledgerBalance, err := wallet.GetBalance(req.Context, cfg)
 		if err != nil {
 			log.Error("wallet get balance failed, ERR: ", err)
@@ -244,13 +402,21 @@ type BalanceResponse struct {
 
 var walletPasswordCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
-		Tagline:          "BTFS wallet password",
-		ShortDescription: "set password for BTFS wallet",
+		Tagline: "BTFS wallet password",
+		ShortDescription: `
+Sets the password the private key and the mnemonic of the BTFS wallet are
+encrypted with, under a key derived from the password with Argon2id, or the
+algorithm of the WalletKDF section of the config: argon2id, scrypt, or
+legacy for wallet UIs decrypting the keys themselves. Keys encrypted with
+another algorithm are migrated the first time the password is given.`,
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg("password", true, false, "password of BTFS wallet."),
 	},
 	Options: []cmds.Option{},
+	Subcommands: map[string]*cmds.Command{
+		"change": walletPasswordChangeCmd,
+	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		n, err := cmdenv.GetNode(env)
 		if err != nil {
@@ -263,6 +429,9 @@ var walletPasswordCmd = &cmds.Command{
 		if cfg.UI.Wallet.Initialized {
 			return errors.New("Already init, cannot set password again.")
 		}
+		if err := wallet.ApplyKDF(n.Repo); err != nil {
+			return err
+		}
 		cipherMnemonic, err := wallet.EncryptWithAES(req.Arguments[0], cfg.Identity.Mnemonic)
 		if err != nil {
 			return err
@@ -282,6 +451,94 @@ var walletPasswordCmd = &cmds.Command{
 	Type: MessageOutput{},
 }
 
+const (
+	oldPasswordOptionName = "old"
+	newPasswordOptionName = "new"
+)
+
+var walletPasswordChangeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Change the password of the BTFS wallet.",
+		ShortDescription: `
+Decrypts the private key and the mnemonic of the wallet with the old password,
+encrypts them with the new one, and saves the config in one write. The key of
+a repo encrypted with the wallet password ('btfs repo encrypt') is wrapped
+with the new password along, set it in BTFS_WALLET_PASSWORD from then on:
+
+    $ btfs wallet password change --old <password> --new <password>`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(oldPasswordOptionName, "Current password of the wallet."),
+		cmds.StringOption(newPasswordOptionName, "New password of the wallet."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		oldPassword, _ := req.Options[oldPasswordOptionName].(string)
+		newPassword, _ := req.Options[newPasswordOptionName].(string)
+		if oldPassword == "" || newPassword == "" {
+			return errors.New("both --old and --new are required")
+		}
+		err = changeWalletPassword(req.Context, n.Repo, cfg, oldPassword, newPassword)
+		if err == wallet.ErrIncorrectPassword {
+			return cmds.Errorf(e.ErrAuth, "incorrect password")
+		}
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &MessageOutput{"Password changed."})
+	},
+	Type: MessageOutput{},
+}
+
+// changeWalletPassword encrypts the wallet of cfg, and the repo key of r
+// when the repo is encrypted with the wallet password, with the new password
+// instead of old. The repo key is wrapped with old again when the config
+// cannot be saved.
+func changeWalletPassword(ctx context.Context, r repo.Repo, cfg *config.Config, old, new string) error {
+	if err := wallet.ApplyKDF(r); err != nil {
+		return err
+	}
+	// the config in use is only changed once saved
+	updated := *cfg
+	if err := wallet.ChangePassword(&updated.Identity, old, new); err != nil {
+		return err
+	}
+	rc, err := repocrypt.Load(r)
+	if err != nil {
+		return err
+	}
+	var rollback func() error
+	if rc.Enabled && rc.KeySource == repocrypt.PasswordSource {
+		oldW, err := repocrypt.NewPasswordWrapper(old)
+		if err != nil {
+			return err
+		}
+		newW, err := repocrypt.NewPasswordWrapper(new)
+		if err != nil {
+			return err
+		}
+		if rollback, err = repocrypt.Rewrap(ctx, r, oldW, newW); err != nil {
+			return fmt.Errorf("cannot wrap the repo key with the new password: %s", err)
+		}
+	}
+	if err := r.SetConfig(&updated); err != nil {
+		if rollback != nil {
+			if rerr := rollback(); rerr != nil {
+				return fmt.Errorf("%s, and restoring the repo key failed: %s", err, rerr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
 var walletCheckPasswordCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline:          "check password",
@@ -292,6 +549,9 @@ var walletCheckPasswordCmd = &cmds.Command{
 		cmds.StringOption(passwordOptionName, "p", "password"),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		if password, _ := req.Options[passwordOptionName].(string); password == "" {
+			return errPasswordRequired
+		}
 		n, err := cmdenv.GetNode(env)
 		if err != nil {
 			return err
@@ -300,7 +560,7 @@ var walletCheckPasswordCmd = &cmds.Command{
 		if err != nil {
 			return err
 		}
-		if err := validatePassword(cfg, req); err != nil {
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
 			return err
 		}
 		return cmds.EmitOnce(res, &MessageOutput{"Password is correct."})
@@ -346,14 +606,44 @@ type Keys struct {
 	Mnemonic   string
 }
 
+const (
+	txOffsetOptionName = "offset"
+	txLimitOptionName  = "limit"
+	txStatusOptionName = "status"
+	txTypeOptionName   = "type"
+	txSinceOptionName  = "since"
+	txUntilOptionName  = "until"
+	txSortOptionName   = "sort"
+)
+
 var walletTransactionsCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
-		Tagline:          "BTFS wallet transactions",
-		ShortDescription: "get transactions of BTFS wallet",
+		Tagline: "BTFS wallet transactions",
+		ShortDescription: `get transactions of BTFS wallet, newest first, with the number of
+transactions selected.
+
+Page through long histories with --offset and --limit, and select the
+transactions with --status, e.g. Pending, --type, deposit, withdraw or
+transfer, and --since and --until, a local time such as 2025-01-01T00:00 or
+an RFC 3339 time. --sort orders them newest, oldest or by amount:
+
+    $ btfs wallet transactions --type deposit --since 2025-01-01T00:00 --offset 20 --limit 20`,
 	},
 	Arguments: []cmds.Argument{},
-	Options:   []cmds.Option{},
+	Options: []cmds.Option{
+		cmds.IntOption(txOffsetOptionName, "Number of transactions skipped.").WithDefault(0),
+		cmds.IntOption(txLimitOptionName, "Most transactions returned. Default: all of them."),
+		cmds.StringOption(txStatusOptionName, "Select the transactions of this status: Pending, Success or Failed."),
+		cmds.StringOption(txTypeOptionName, "Select the transactions of this type: deposit, withdraw or transfer."),
+		cmds.StringOption(txSinceOptionName, "Select the transactions created from this time."),
+		cmds.StringOption(txUntilOptionName, "Select the transactions created before this time."),
+		cmds.StringOption(txSortOptionName, "Order of the transactions: newest, oldest or amount.").WithDefault(wallet.TxSortNewest),
+	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		q, err := txQuery(req)
+		if err != nil {
+			return err
+		}
 		n, err := cmdenv.GetNode(env)
 		if err != nil {
 			return err
@@ -362,15 +652,57 @@ var walletTransactionsCmd = &cmds.Command{
 		if err != nil {
 			return err
 		}
-		return cmds.EmitOnce(res, txs)
+		page, total := wallet.QueryTransactions(txs, q)
+		return cmds.EmitOnce(res, &TransactionsRes{Total: total, Transactions: page})
 	},
-	Type: []*walletpb.TransactionV1{},
+	Type: TransactionsRes{},
+}
+
+// TransactionsRes is a page of the transactions of the wallet.
+type TransactionsRes struct {
+	// Total is the number of transactions selected, of all pages.
+	Total        int
+	Transactions []*walletpb.TransactionV1
 }
 
+// txQuery returns the transactions selected by the options of req.
+func txQuery(req *cmds.Request) (*wallet.TxQuery, error) {
+	q := &wallet.TxQuery{}
+	q.Offset, _ = req.Options[txOffsetOptionName].(int)
+	q.Limit, _ = req.Options[txLimitOptionName].(int)
+	q.Status, _ = req.Options[txStatusOptionName].(string)
+	q.Type, _ = req.Options[txTypeOptionName].(string)
+	q.Sort, _ = req.Options[txSortOptionName].(string)
+	var err error
+	if since, ok := req.Options[txSinceOptionName].(string); ok {
+		if q.Since, err = wallet.ParseExecuteAt(since); err != nil {
+			return nil, err
+		}
+	}
+	if until, ok := req.Options[txUntilOptionName].(string); ok {
+		if q.Until, err = wallet.ParseExecuteAt(until); err != nil {
+			return nil, err
+		}
+	}
+	return q, q.Validate()
+}
+
+const (
+	executeAtOptionName = "execute-at"
+	everyOptionName     = "every"
+	timesOptionName     = "times"
+)
+
 var walletTransferCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
-		Tagline:          "Send to another BTT wallet",
-		ShortDescription: "Send to another BTT wallet from current BTT wallet. Use '-p=<password>' to specific password.",
+		Tagline: "Send to another BTT wallet",
+		ShortDescription: `Send to another BTT wallet from current BTT wallet. Use '-p=<password>' to specific password.
+
+--execute-at schedules the transfer instead, at a local time such as
+2025-01-01T00:00 or an RFC 3339 time; --every makes it recur, 'daily',
+'weekly' or every duration such as 720h, up to --times transfers when set.
+The daemon executes the scheduled transfers with the wallet signer, the
+password is only checked when scheduling. See 'btfs wallet schedules'.`,
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg("to", true, false, "address of another BTFS wallet to transfer to."),
@@ -378,6 +710,9 @@ var walletTransferCmd = &cmds.Command{
 	},
 	Options: []cmds.Option{
 		cmds.StringOption(passwordOptionName, "p", "password"),
+		cmds.StringOption(executeAtOptionName, "Time to execute the transfer at. Now when not set."),
+		cmds.StringOption(everyOptionName, "Period of a recurring transfer: daily, weekly or a duration."),
+		cmds.IntOption(timesOptionName, "Number of transfers of a recurring transfer. Until cancelled when not set."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		n, err := cmdenv.GetNode(env)
@@ -388,13 +723,27 @@ var walletTransferCmd = &cmds.Command{
 		if err != nil {
 			return err
 		}
-		if err := validatePassword(cfg, req); err != nil {
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		if _, err := useWalletSigner(n); err != nil {
 			return err
 		}
 		amount, err := strconv.ParseInt(req.Arguments[1], 10, 64)
 		if err != nil {
 			return err
 		}
+		if s, err := transferSchedule(req, amount, time.Now()); err != nil {
+			return err
+		} else if s != nil {
+			if err := wallet.AddSchedule(n.Repo.Datastore(), n.Identity.Pretty(), s, time.Now()); err != nil {
+				return err
+			}
+			return cmds.EmitOnce(res, &TransferResult{
+				Result:  true,
+				Message: fmt.Sprintf("transfer %s scheduled at %s", s.ID, s.ExecuteAt.Format(time.RFC3339)),
+			})
+		}
 		ret, err := wallet.TransferBTT(req.Context, n, cfg, nil, "", req.Arguments[0], amount)
 		if err != nil {
 			return err
@@ -408,46 +757,209 @@ var walletTransferCmd = &cmds.Command{
 	Type: &TransferResult{},
 }
 
-func validatePassword(cfg *config.Config, req *cmds.Request) error {
+// transferSchedule returns the transfer scheduled by the options of req,
+// nil when the transfer is immediate.
+func transferSchedule(req *cmds.Request, amount int64, now time.Time) (*wallet.Schedule, error) {
+	at, _ := req.Options[executeAtOptionName].(string)
+	every, _ := req.Options[everyOptionName].(string)
+	times, timesSet := req.Options[timesOptionName].(int)
+	if at == "" && every == "" {
+		if timesSet {
+			return nil, fmt.Errorf("--%s requires --%s", timesOptionName, everyOptionName)
+		}
+		return nil, nil
+	}
+	s := &wallet.Schedule{To: req.Arguments[0], Amount: amount, Times: times}
+	var err error
+	if every != "" {
+		if s.Period, err = wallet.ParsePeriod(every); err != nil {
+			return nil, err
+		}
+	} else if timesSet {
+		return nil, fmt.Errorf("--%s requires --%s", timesOptionName, everyOptionName)
+	}
+	if at != "" {
+		if s.ExecuteAt, err = wallet.ParseExecuteAt(at); err != nil {
+			return nil, err
+		}
+	} else {
+		s.ExecuteAt = now.Add(s.Period)
+	}
+	return s, nil
+}
+
+// validatePassword checks the password of the wallet given with -p, unless
+// the wallet is unlocked, and migrates the keys encrypted with another key
+// derivation than configured on their first unlock.
+func validatePassword(r repo.Repo, cfg *config.Config, req *cmds.Request) error {
 	password, _ := req.Options[passwordOptionName].(string)
 	if password == "" {
-		return errors.New(
-			`Password required, please use '-p <password>' to specify the password. 
-Try 'btfs wallet password --help' and assign a password if password is not set.`)
+		if _, ok := wallet.DefaultSession.Unlocked(sessionCaller(req), cfg.Identity.PrivKey, time.Now()); ok {
+			return nil
+		}
+		return errPasswordRequired
 	}
 	privK, err := wallet.DecryptWithAES(password, cfg.Identity.EncryptedPrivKey)
 	if err != nil || cfg.Identity.PrivKey != privK {
-		return errors.New("incorrect password")
+		return cmds.Errorf(e.ErrAuth, "incorrect password")
+	}
+	updated := *cfg
+	if err := wallet.ApplyKDF(r); err != nil {
+		log.Warnf("failed to migrate the wallet encryption: %s", err)
+	} else if ok, err := wallet.Migrate(&updated.Identity, password); err != nil {
+		log.Warnf("failed to migrate the wallet encryption: %s", err)
+	} else if ok {
+		if err := r.SetConfig(&updated); err != nil {
+			log.Warnf("failed to save the wallet migrated: %s", err)
+		}
 	}
 	return nil
 }
 
+var errPasswordRequired = errors.New(
+	`Password required, please use '-p <password>' to specify the password, or unlock the wallet
+with 'btfs wallet unlock'. Try 'btfs wallet password --help' and assign a password if password is not set.`)
+
 type TransferResult struct {
 	Result  bool
 	Message string
 }
 
+var walletSchedulesCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the scheduled transfers",
+		ShortDescription: `
+Lists and cancels the transfers scheduled by 'btfs wallet transfer
+--execute-at' or '--every', which the daemon executes when due. A recurring
+transfer skips the times missed while the daemon was down.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":     walletSchedulesLsCmd,
+		"cancel": walletSchedulesCancelCmd,
+	},
+}
+
+var walletSchedulesLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the scheduled transfers",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		ss, err := wallet.ListSchedules(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, ss)
+	},
+	Type: []*wallet.Schedule{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ss []*wallet.Schedule) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tTO\tAMOUNT\tNEXT\tEVERY\tRUNS\tSTATE\tLAST ERROR")
+			for _, s := range ss {
+				every, next := "-", "-"
+				if s.Period > 0 {
+					every = s.Period.String()
+				}
+				if s.State == wallet.ScheduleActive {
+					next = s.ExecuteAt.Format(time.RFC3339)
+				}
+				runs := strconv.Itoa(s.Runs)
+				if s.Times > 0 {
+					runs += "/" + strconv.Itoa(s.Times)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.To, s.Amount, next, every, runs,
+					s.State, s.LastError)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var walletSchedulesCancelCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Cancel a scheduled transfer",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("id", true, false, "ID of the scheduled transfer."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		s, err := wallet.CancelSchedule(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &MessageOutput{Message: fmt.Sprintf("transfer %s cancelled\n", s.ID)})
+	},
+	Type: MessageOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *MessageOutput) error {
+			fmt.Fprint(w, out.Message)
+			return nil
+		}),
+	},
+}
+
 const privateKeyOptionName = "privateKey"
 const mnemonicOptionName = "mnemonic"
 
 var walletImportCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
-		Tagline:          "BTFS wallet import",
-		ShortDescription: "import BTFS wallet",
+		Tagline: "BTFS wallet import",
+		ShortDescription: `import BTFS wallet
+
+Importing a key replaces the node identity and the wallet key, and restarts
+the daemon. The command asks for a confirmation first, which --yes skips.
+
+The key is the private key given with -p, the mnemonic given with -m, or the
+Web3 keystore file given with --keystore, such as written by 'btfs wallet
+export' or TronLink, decrypted with --keystore-password or the password asked
+for on the terminal. Over the HTTP API, --keystore is the JSON of the
+keystore.`,
 	},
 	Arguments: []cmds.Argument{},
 	Options: []cmds.Option{
 		cmds.StringOption(privateKeyOptionName, "p", "Private Key to import."),
 		cmds.StringOption(mnemonicOptionName, "m", "Mnemonic to import."),
+		cmds.StringOption(keystoreOptionName, "Keystore file to import."),
+		cmds.StringOption(keystorePasswordOptionName, "Password the keystore is encrypted with."),
+		cmdenv.OptionYes,
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		return readKeystore(req)
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		n, err := cmdenv.GetNode(env)
 		if err != nil {
 			return err
 		}
-
 		privKey, _ := req.Options[privateKeyOptionName].(string)
 		mnemonic, _ := req.Options[mnemonicOptionName].(string)
+		if ks, _ := req.Options[keystoreOptionName].(string); ks != "" {
+			password, _ := req.Options[keystorePasswordOptionName].(string)
+			if password == "" {
+				return errKeystorePasswordRequired
+			}
+			// decrypted before the confirmation, to fail early
+			if privKey, err = wallet.DecryptKeystore([]byte(ks), password); err != nil {
+				return err
+			}
+		}
+		err = cmdenv.RequireConfirmation(req, "This overwrites the current keys, which are lost unless backed up "+
+			"with 'btfs wallet keys', and restarts the daemon:", []string{
+			"node identity " + n.Identity.Pretty(),
+			"wallet key and mnemonic",
+		})
+		if err != nil {
+			return err
+		}
+
 		err = wallet.ImportKeys(n, privKey, mnemonic)
 		if err != nil {
 			return err
@@ -466,8 +978,11 @@ var walletImportCmd = &cmds.Command{
 
 var walletDiscoveryCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
-		Tagline:          "Speed wallet discovery",
-		ShortDescription: "Speed wallet discovery",
+		Tagline: "Speed wallet discovery",
+		ShortDescription: `
+Speed wallet discovery. Returns the key of the wallet of the BitTorrent Speed
+of this device, to import with 'btfs wallet import', and links the Speed
+install to the wallet with a device key, see 'btfs wallet devices'.`,
 	},
 	Arguments: []cmds.Argument{},
 	Options:   []cmds.Option{},
@@ -487,10 +1002,18 @@ var walletDiscoveryCmd = &cmds.Command{
 		if err != nil {
 			return err
 		}
-		return cmds.EmitOnce(res, DiscoveryResult{Key: key})
+		dev, err := linkSpeed(n.Repo.Datastore(), key)
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, DiscoveryResult{Key: key, DeviceID: dev.ID, DeviceKey: dev.Key})
 	},
 }
 
+// DiscoveryResult is the key of the Speed wallet, with the device key of
+// the Speed install linked to it.
 type DiscoveryResult struct {
-	Key string
+	Key       string
+	DeviceID  string
+	DeviceKey string
 }
@@ -0,0 +1,279 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"github.com/tron-us/go-btfs-common/crypto"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const deviceKeyOptionName = "device-key"
+
+var walletDevicesCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the BitTorrent Speed devices linked to the wallet",
+		ShortDescription: `
+Links BitTorrent Speed installs to the wallet of the node, each with its own
+device key, so that they make transfers from the wallet without its password:
+
+    $ btfs wallet devices link laptop
+    $ btfs wallet devices send <to> <amount> --device-key <key>
+
+'btfs wallet discovery' links the Speed install of this device. A revoked
+device key is refused, 'btfs wallet devices transfers' shows which device
+made each transfer.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":        walletDevicesLsCmd,
+		"link":      walletDevicesLinkCmd,
+		"revoke":    walletDevicesRevokeCmd,
+		"send":      walletDevicesSendCmd,
+		"transfers": walletDevicesTransfersCmd,
+	},
+}
+
+// LinkedDevice is a device linked with its device key.
+type LinkedDevice struct {
+	*wallet.Device
+	Key string
+}
+
+var walletDevicesLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the devices linked to the wallet",
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		devs, err := wallet.ListDevices(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		for _, dev := range devs {
+			dev.KeyHash = ""
+		}
+		return cmds.EmitOnce(res, devs)
+	},
+	Type: []*wallet.Device{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, devs []*wallet.Device) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tNAME\tSTATE\tLINKED\tLAST USED")
+			for _, dev := range devs {
+				used := "-"
+				if !dev.LastUsed.IsZero() {
+					used = dev.LastUsed.Format(time.RFC3339)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", dev.ID, dev.Name, dev.State,
+					dev.Linked.Format(time.RFC3339), used)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+var walletDevicesLinkCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Link a device to the wallet",
+		ShortDescription: `
+Links the device name and shows its device key, to set in its BitTorrent
+Speed. The key is not shown again, relinking a device revokes its previous
+key. Use '-p=<password>' to specific password.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the device."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(passwordOptionName, "p", "password"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		dev, key, err := wallet.LinkDevice(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0], time.Now())
+		if err != nil {
+			return err
+		}
+		dev.KeyHash = ""
+		return cmds.EmitOnce(res, &LinkedDevice{Device: dev, Key: key})
+	},
+	Type: LinkedDevice{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *LinkedDevice) error {
+			fmt.Fprintf(w, "device %s linked as %s\ndevice key: %s\n", out.Name, out.ID, out.Key)
+			return nil
+		}),
+	},
+}
+
+var walletDevicesRevokeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Revoke a device linked to the wallet",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("id", true, false, "ID of the device."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		dev, err := wallet.RevokeDevice(n.Repo.Datastore(), n.Identity.Pretty(), req.Arguments[0], time.Now())
+		if err != nil {
+			return err
+		}
+		dev.KeyHash = ""
+		return cmds.EmitOnce(res, dev)
+	},
+	Type: wallet.Device{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, dev *wallet.Device) error {
+			fmt.Fprintf(w, "device %s (%s) revoked\n", dev.ID, dev.Name)
+			return nil
+		}),
+	},
+}
+
+var walletDevicesSendCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Send to another BTT wallet from a linked device",
+		ShortDescription: `
+Sends amount from the BTT wallet of the node to the address, for the linked
+device of --device-key. Called by BitTorrent Speed, also from other devices.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("to", true, false, "address of another BTFS wallet to transfer to."),
+		cmds.StringArg("amount", true, false, "amount of µBTT (=0.000001BTT) to transfer."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(deviceKeyOptionName, "Device key of the linked device."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		key, _ := req.Options[deviceKeyOptionName].(string)
+		if key == "" {
+			return fmt.Errorf("--%s required", deviceKeyOptionName)
+		}
+		d, peerId := n.Repo.Datastore(), n.Identity.Pretty()
+		dev, err := wallet.AuthDevice(d, peerId, key, time.Now())
+		if err != nil {
+			return err
+		}
+		amount, err := strconv.ParseInt(req.Arguments[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		ret, err := wallet.TransferBTT(req.Context, n, cfg, nil, "", req.Arguments[0], amount)
+		if err != nil {
+			return err
+		}
+		if err := wallet.SetOrigin(d, peerId, ret.TxId, dev.ID); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &TransferResult{
+			Result:  ret.Result,
+			Message: fmt.Sprintf("transaction %v sent from device %s", ret.TxId, dev.Name),
+		})
+	},
+	Type: &TransferResult{},
+}
+
+var walletDevicesTransfersCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the transfers of the wallet with the device which made them",
+		ShortDescription: `
+Lists the transfers of the BTT wallet, the latest first, with their origin:
+'local' for the commands of the node, or the linked device which made them.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("id", false, false, "ID of a device, or 'local', to only list its transfers."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		ts, err := wallet.ListTransfers(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		if len(req.Arguments) > 0 {
+			filtered := make([]*wallet.Transfer, 0)
+			for _, t := range ts {
+				if t.Origin == req.Arguments[0] {
+					filtered = append(filtered, t)
+				}
+			}
+			ts = filtered
+		}
+		return cmds.EmitOnce(res, ts)
+	},
+	Type: []*wallet.Transfer{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ts []*wallet.Transfer) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "TX\tTIME\tTO\tAMOUNT\tSTATUS\tORIGIN")
+			for _, t := range ts {
+				origin := t.Origin
+				if t.Device != "" {
+					origin = fmt.Sprintf("speed: %s", t.Device)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", t.TxId, t.Time.Format(time.RFC3339), t.To, t.Amount,
+					t.Status, origin)
+			}
+			return tw.Flush()
+		}),
+	},
+}
+
+// linkSpeed links the BitTorrent Speed install of this device to the wallet
+// of its key speedKey, which the node takes once imported.
+func linkSpeed(d ds.Datastore, speedKey string) (*LinkedDevice, error) {
+	privKey, err := crypto.ToPrivKey(speedKey)
+	if err != nil {
+		return nil, err
+	}
+	pid, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return nil, errors.New("cannot name this device")
+	}
+	dev, key, err := wallet.LinkDevice(d, pid.Pretty(), "speed@"+host, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	dev.KeyHash = ""
+	return &LinkedDevice{Device: dev, Key: key}, nil
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	keystoreOptionName         = "keystore"
+	keystorePasswordOptionName = "keystore-password"
+)
+
+var errKeystorePasswordRequired = errors.New(
+	"keystore password required, please use '--keystore-password <password>' to specify it")
+
+// WalletKeystoreOutput is the Web3 keystore of the wallet key.
+type WalletKeystoreOutput struct {
+	Address  string
+	Keystore json.RawMessage
+}
+
+var walletExportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Export the BTFS wallet key to a keystore",
+		ShortDescription: `
+Writes the private key of the wallet, encrypted with a keystore password, to
+the keystore file given with --keystore, in the JSON of the Web3 Secret
+Storage the Ethereum and TRON wallets such as TronLink import:
+
+    $ btfs wallet export --keystore wallet.json
+    Keystore password:
+    Repeat keystore password:
+
+The keystore is imported by another BTFS node with 'btfs wallet import
+--keystore'. The key never leaves the daemon unencrypted. The passwords are
+asked for on the terminal when -p and --keystore-password are not given;
+-p is not needed while the wallet is unlocked. The command does not
+overwrite an existing file.`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(keystoreOptionName, "Keystore file to write."),
+		cmds.StringOption(keystorePasswordOptionName, "Password to encrypt the keystore with."),
+		cmds.StringOption(passwordOptionName, "p", "password"),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		if out, _ := req.Options[keystoreOptionName].(string); out == "" {
+			return fmt.Errorf("--%s required", keystoreOptionName)
+		}
+		if _, err := os.Stat(keystoreOutPath(req)); err == nil {
+			return fmt.Errorf("%s already exists", keystoreOutPath(req))
+		}
+		return promptPassword(req, keystorePasswordOptionName, "Keystore password", true)
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		password, _ := req.Options[keystorePasswordOptionName].(string)
+		if password == "" {
+			return errKeystorePasswordRequired
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		b, err := wallet.ExportKeystore(cfg.Identity.PrivKey, password)
+		if err != nil {
+			return err
+		}
+		ks := &wallet.Keystore{}
+		if err := json.Unmarshal(b, ks); err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &WalletKeystoreOutput{Address: ks.Address, Keystore: b})
+	},
+	PostRun: cmds.PostRunMap{
+		cmds.CLI: func(res cmds.Response, re cmds.ResponseEmitter) error {
+			v, err := res.Next()
+			if err != nil {
+				return err
+			}
+			out, ok := v.(*WalletKeystoreOutput)
+			if !ok {
+				return fmt.Errorf("unexpected output type %T", v)
+			}
+			p := keystoreOutPath(res.Request())
+			f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(append(out.Keystore, '\n')); err != nil {
+				f.Close()
+				os.Remove(p)
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stdout, "Exported the key of address %s to %s.\n", out.Address, p)
+			return nil
+		},
+	},
+	Type: WalletKeystoreOutput{},
+}
+
+func keystoreOutPath(req *cmds.Request) string {
+	out, _ := req.Options[keystoreOptionName].(string)
+	return filepath.Clean(out)
+}
+
+// readKeystore replaces the keystore file of the option of req with its
+// JSON, read by the client for the daemon.
+func readKeystore(req *cmds.Request) error {
+	p, _ := req.Options[keystoreOptionName].(string)
+	if p == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(filepath.Clean(p))
+	if err != nil {
+		return err
+	}
+	req.Options[keystoreOptionName] = string(b)
+	return promptPassword(req, keystorePasswordOptionName, "Keystore password", false)
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TRON-US/go-btfs/core/wallet"
+	"github.com/TRON-US/go-btfs/keystore"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/common"
+	"github.com/TRON-US/go-btfs/repo/repocrypt"
+
+	config "github.com/TRON-US/go-btfs-config"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+// sectionRepo keeps the config sections the mock repo cannot set.
+type sectionRepo struct {
+	repo.Mock
+	sections      map[string]interface{}
+	failSetConfig bool
+}
+
+func (r *sectionRepo) SetConfig(updated *config.Config) error {
+	if r.failSetConfig {
+		return errors.New("disk full")
+	}
+	return r.Mock.SetConfig(updated)
+}
+
+func (r *sectionRepo) SetConfigKey(key string, value interface{}) error {
+	r.sections[key] = value
+	return nil
+}
+
+func (r *sectionRepo) GetConfigKey(key string) (interface{}, error) {
+	if v, ok := r.sections[key]; ok {
+		return v, nil
+	}
+	return nil, &common.KeyNotFoundError{}
+}
+
+// unlocks reports whether the repo key of r unwraps with password.
+func unlocks(r repo.Repo, password string) bool {
+	w, err := repocrypt.NewPasswordWrapper(password)
+	if err != nil {
+		return false
+	}
+	return (&repocrypt.Crypt{}).Unlock(context.Background(), r, w) == nil
+}
+
+func TestChangeWalletPasswordRepoKey(t *testing.T) {
+	ctx := context.Background()
+	r := &sectionRepo{sections: map[string]interface{}{}}
+	r.D = dssync.MutexWrap(ds.NewMapDatastore())
+	r.K = keystore.NewMemKeystore()
+	r.C.Identity.PrivKey = "privkey"
+	encrypted, err := wallet.EncryptWithAES("old", r.C.Identity.PrivKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.C.Identity.EncryptedPrivKey = encrypted
+	w, err := repocrypt.NewPasswordWrapper("old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&repocrypt.Crypt{}).Enable(ctx, r, w, repocrypt.PasswordSource); err != nil {
+		t.Fatal(err)
+	}
+
+	// the repo key is restored when the config cannot be saved
+	r.failSetConfig = true
+	cfg, _ := r.Config()
+	if err := changeWalletPassword(ctx, r, cfg, "old", "new"); err == nil {
+		t.Fatal("expected the config write to fail")
+	}
+	if !unlocks(r, "old") || unlocks(r, "new") {
+		t.Fatal("expected the repo key wrapped with the old password")
+	}
+	if r.C.Identity.EncryptedPrivKey != encrypted {
+		t.Fatal("expected the wallet unchanged")
+	}
+
+	r.failSetConfig = false
+	if err := changeWalletPassword(ctx, r, cfg, "wrong", "new"); err != wallet.ErrIncorrectPassword {
+		t.Fatalf("expected the wrong password refused, got %v", err)
+	}
+	if err := changeWalletPassword(ctx, r, cfg, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if !unlocks(r, "new") || unlocks(r, "old") {
+		t.Fatal("expected the repo key wrapped with the new password")
+	}
+	if privKey, err := wallet.DecryptWithAES("new", r.C.Identity.EncryptedPrivKey); err != nil ||
+		privKey != r.C.Identity.PrivKey {
+		t.Fatal("expected the wallet encrypted with the new password")
+	}
+}
+
+// TestChangeWalletPasswordKDF changes the password without the daemon, with
+// the key derivation of the config.
+func TestChangeWalletPasswordKDF(t *testing.T) {
+	defer wallet.SetKDF(wallet.KDF())
+	r := &sectionRepo{sections: map[string]interface{}{
+		wallet.KDFConfigKey: map[string]interface{}{"Algorithm": wallet.KDFScrypt, "N": 1024, "R": 1, "P": 1},
+	}}
+	r.D = dssync.MutexWrap(ds.NewMapDatastore())
+	r.C.Identity.PrivKey = "privkey"
+	encrypted, err := wallet.EncryptWithAES("old", r.C.Identity.PrivKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.C.Identity.EncryptedPrivKey = encrypted
+
+	cfg, _ := r.Config()
+	if err := changeWalletPassword(context.Background(), r, cfg, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(r.C.Identity.EncryptedPrivKey, "$"+wallet.KDFScrypt+"$") {
+		t.Fatalf("expected the wallet encrypted with scrypt, got %s", r.C.Identity.EncryptedPrivKey)
+	}
+	if privKey, err := wallet.DecryptWithAES("new", r.C.Identity.EncryptedPrivKey); err != nil ||
+		privKey != r.C.Identity.PrivKey {
+		t.Fatal("expected the wallet encrypted with the new password")
+	}
+}
@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/corehttp/remote"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const relayerOptionName = "relayer"
+
+var walletRelayCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Transfer BTT through a relayer paying the chain fees",
+		ShortDescription: `
+Lets nodes holding BTT only on the ledger transfer BTT on the chain: a
+relayer node makes the transfer from its BTT wallet and pays its fees, and is
+paid the amount and its fee from the ledger of the node before it transfers.
+The node signs the transfer and the ledger payment, the relayer refunds the
+ledger when the transfer fails.
+
+A relayer enables the Relay config section, with the fee it charges in µBTT
+and the largest amount it relays:
+
+    (relayer) $ btfs config --json Relay '{"Enabled": true, "Fee": 1000, "MaxAmount": 100000000}'
+    (node)    $ btfs config --json Relay '{"Relayer": "<relayer-peer-id>"}'
+    (node)    $ btfs wallet relay send <to> <amount>`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"send":   walletRelaySendCmd,
+		"terms":  WalletRelayTermsCmd,
+		"accept": WalletRelayAcceptCmd,
+	},
+}
+
+var walletRelaySendCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Send to another BTT wallet through the relayer",
+		ShortDescription: `
+Has the relayer of the Relay config section, or --relayer, send amount to
+the address from its BTT wallet, paid from the ledger of the node with its
+fee. Use '-p=<password>' to specific password.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("to", true, false, "address of another BTFS wallet to transfer to."),
+		cmds.StringArg("amount", true, false, "amount of µBTT (=0.000001BTT) to transfer."),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(passwordOptionName, "p", "password"),
+		cmds.StringOption(relayerOptionName, "Peer ID of the relayer. The relayer of the Relay config when not set."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		amount, err := strconv.ParseInt(req.Arguments[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		rc, err := wallet.LoadRelayConfig(n.Repo)
+		if err != nil {
+			return err
+		}
+		relayerId, ok := req.Options[relayerOptionName].(string)
+		if !ok {
+			relayerId = rc.Relayer
+		}
+		if relayerId == "" {
+			return fmt.Errorf("no relayer, set one with --%s or the Relayer of the %s config",
+				relayerOptionName, wallet.RelayConfigKey)
+		}
+		relayer, err := peer.Decode(relayerId)
+		if err != nil {
+			return err
+		}
+
+		b, err := remote.P2PCallStrings(req.Context, n, api, relayer, "/wallet/relay/terms")
+		if err != nil {
+			return err
+		}
+		terms := &wallet.RelayTerms{}
+		if err := json.Unmarshal(b, terms); err != nil {
+			return err
+		}
+		if terms.MaxAmount > 0 && amount > terms.MaxAmount {
+			return fmt.Errorf("relayer %s relays %d µBTT at most", relayerId, terms.MaxAmount)
+		}
+		ret, err := wallet.RelayTransfer(req.Context, n, cfg, relayer, req.Arguments[0], amount, terms.Fee,
+			func(r *wallet.RelayRequest) (*wallet.RelayResult, error) {
+				rb, err := json.Marshal(r)
+				if err != nil {
+					return nil, err
+				}
+				b, err := remote.P2PCallStrings(req.Context, n, api, relayer, "/wallet/relay/accept", string(rb))
+				if err != nil {
+					return nil, err
+				}
+				ret := &wallet.RelayResult{}
+				if err := json.Unmarshal(b, ret); err != nil {
+					return nil, err
+				}
+				return ret, nil
+			})
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &TransferResult{
+			Result:  true,
+			Message: fmt.Sprintf("transaction %v sent by relayer %s for a fee of %d µBTT", ret.TxId, relayerId, ret.Fee),
+		})
+	},
+	Type: &TransferResult{},
+}
+
+var WalletRelayTermsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the terms of the transfers relayed by this node",
+		ShortDescription: `
+Shows the fee charged and the largest amount of the transfers relayed by
+this node, from its Relay config section. Called by the nodes relaying
+through this node before their transfers.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		rc, err := wallet.LoadRelayConfig(n.Repo)
+		if err != nil {
+			return err
+		}
+		terms, err := rc.Terms(n.Identity.Pretty())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, terms)
+	},
+	Type: wallet.RelayTerms{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *wallet.RelayTerms) error {
+			max := "no limit"
+			if out.MaxAmount > 0 {
+				max = fmt.Sprintf("%d µBTT", out.MaxAmount)
+			}
+			fmt.Fprintf(w, "relayer: %s\nfee: %d µBTT\nmax amount: %s\n", out.Relayer, out.Fee, max)
+			return nil
+		}),
+	},
+}
+
+var WalletRelayAcceptCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Relay the transfer of another node (relayer).",
+		ShortDescription: `
+Called by a node relaying through this node, checks its channel on the
+ledger, collects the amount and the fee from its ledger, then transfers from
+the BTT wallet of this node as signed by the node. Only relays when enabled
+in the Relay config section.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("request", true, false, "Relay request."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		from, ok := remote.GetStreamRequestRemotePeerID(req, n)
+		if !ok {
+			return errors.New("fail to get peer ID from request")
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		rc, err := wallet.LoadRelayConfig(n.Repo)
+		if err != nil {
+			return err
+		}
+		r := &wallet.RelayRequest{}
+		if err := json.Unmarshal([]byte(req.Arguments[0]), r); err != nil {
+			return fmt.Errorf("invalid relay request: %s", err)
+		}
+		ret, err := wallet.AcceptRelay(req.Context, n, cfg, rc, from, r, time.Now())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, ret)
+	},
+	Type: wallet.RelayResult{},
+}
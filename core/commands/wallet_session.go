@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/apps"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/users"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const unlockTTLOptionName = "ttl"
+
+// WalletSessionOutput is the wallet unlocked, or locked.
+type WalletSessionOutput struct {
+	Unlocked bool
+	Expires  time.Time `json:",omitempty"`
+}
+
+var walletUnlockCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Unlock the BTFS wallet for a while",
+		ShortDescription: `
+Keeps the private key of the wallet decrypted in the memory of the daemon,
+so that deposit, withdraw, transfer and the other commands spending from the
+wallet run without -p until the wallet is locked with 'btfs wallet lock', or
+the time given with --ttl elapses:
+
+    $ btfs wallet unlock --ttl 1h
+    Password:
+    $ btfs wallet transfer <address> 1000000
+
+The password is asked for on the terminal when -p is not given, keeping it
+out of the shell history and the process list. The daemon forgets the key
+when it stops. The wallet is unlocked for 24h at most.
+
+The wallet is unlocked for the caller only: with the accounts of 'btfs
+users', the commands of the other accounts and applications still require
+-p. Without accounts, every client of the API of the daemon is the same
+caller, and may spend from the wallet while it is unlocked.`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption(passwordOptionName, "p", "password"),
+		cmds.StringOption(unlockTTLOptionName, "How long the wallet stays unlocked.").
+			WithDefault(wallet.DefaultUnlockTTL.String()),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		return promptPassword(req, passwordOptionName, "Password", false)
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		ttl, err := time.ParseDuration(req.Options[unlockTTLOptionName].(string))
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %s", unlockTTLOptionName, err)
+		}
+		if password, _ := req.Options[passwordOptionName].(string); password == "" {
+			return errPasswordRequired
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return err
+		}
+		if err := validatePassword(n.Repo, cfg, req); err != nil {
+			return err
+		}
+		expires, err := wallet.DefaultSession.Unlock(sessionCaller(req), cfg.Identity.PrivKey, ttl, time.Now())
+		if err != nil {
+			return err
+		}
+		return cmds.EmitOnce(res, &WalletSessionOutput{Unlocked: true, Expires: expires})
+	},
+	Type:     WalletSessionOutput{},
+	Encoders: walletSessionEncoders,
+}
+
+var walletLockCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Lock the BTFS wallet",
+		ShortDescription: `
+Has the daemon forget the private key of the wallet unlocked with 'btfs
+wallet unlock'. The commands spending from the wallet require -p again.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		wallet.DefaultSession.Lock(time.Now())
+		return cmds.EmitOnce(res, &WalletSessionOutput{})
+	},
+	Type:     WalletSessionOutput{},
+	Encoders: walletSessionEncoders,
+}
+
+var walletSessionEncoders = cmds.EncoderMap{
+	cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *WalletSessionOutput) error {
+		if !out.Unlocked {
+			_, err := fmt.Fprintln(w, "Wallet locked.")
+			return err
+		}
+		_, err := fmt.Fprintf(w, "Wallet unlocked until %s.\n", out.Expires.Format(time.RFC3339))
+		return err
+	}),
+}
+
+// sessionCaller identifies the caller the wallet is unlocked for: the
+// account or the application of req, "" for the node itself.
+func sessionCaller(req *cmds.Request) string {
+	if a := apps.FromContext(req.Context); a != nil {
+		return "app:" + a.ID
+	}
+	if u := users.FromContext(req.Context); u != nil {
+		return "user:" + u.Name
+	}
+	return ""
+}
+
+// promptPassword asks for the password of the option name on the terminal,
+// twice when confirm, unless given or stdin is not a terminal.
+func promptPassword(req *cmds.Request, name string, prompt string, confirm bool) error {
+	if password, _ := req.Options[name].(string); password != "" {
+		return nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil
+	}
+	read := func(prompt string) (string, error) {
+		fmt.Fprintf(os.Stderr, "%s: ", prompt)
+		b, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return strings.TrimSpace(string(b)), err
+	}
+	password, err := read(prompt)
+	if err != nil {
+		return err
+	}
+	if confirm {
+		again, err := read("Repeat " + strings.ToLower(prompt))
+		if err != nil {
+			return err
+		}
+		if again != password {
+			return errors.New("the passwords do not match")
+		}
+	}
+	req.Options[name] = password
+	return nil
+}
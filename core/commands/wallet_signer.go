@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/cmdenv"
+	"github.com/TRON-US/go-btfs/core/wallet"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	"github.com/tron-us/go-btfs-common/crypto"
+)
+
+var walletSignerCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the signer of the wallet transactions",
+		ShortDescription: `
+Shows the signer of the deposits, withdrawals and transfers of the wallet,
+and the address of its BTT wallet.
+
+By default the wallet is that of the key of the node, in the config. The
+WalletSigner config section has a Ledger device connected over USB sign
+them instead, with the TRON app opened on it, so the BTT earned can be
+withdrawn to and kept in cold storage:
+
+    $ btfs config --json WalletSigner '{"Backend": "ledger"}'
+    $ btfs wallet signer
+
+Each transaction is then confirmed on the device. The key is the first TRON
+account of the device, that of the Path option such as "44'/195'/0'/0/1"
+when set; a device restored from the seed phrase of the node holds the key
+of the node. The channels of the deposits and withdrawals are signed by
+hash, which requires 'Sign by Hash' to be enabled in the settings of the
+TRON app. The Device option selects the device, e.g. /dev/hidraw1, when
+several are connected.
+
+Ledger devices are supported on Linux, where the user running the node needs
+access to their hidraw devices, see the udev rules of Ledger.`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+		s, err := useWalletSigner(n)
+		if err != nil {
+			return err
+		}
+		out := &WalletSignerOutput{Backend: wallet.SignerKey}
+		if s == nil {
+			cfg, err := n.Repo.Config()
+			if err != nil {
+				return err
+			}
+			keys, err := crypto.FromPrivateKey(cfg.Identity.PrivKey)
+			if err != nil {
+				return err
+			}
+			out.Address = keys.Base58Address
+		} else {
+			out.Backend = wallet.SignerLedger
+			if out.Address, err = wallet.SignerBase58Address(s); err != nil {
+				return err
+			}
+		}
+		return cmds.EmitOnce(res, out)
+	},
+	Type: WalletSignerOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *WalletSignerOutput) error {
+			_, err := fmt.Fprintf(w, "signer: %s\naddress: %s\n", out.Backend, out.Address)
+			return err
+		}),
+	},
+}
+
+type WalletSignerOutput struct {
+	Backend string
+	Address string
+}
+
+// useWalletSigner has the wallet signed by the signer configured in the repo
+// of n, which the daemon loads when it starts, and returns it.
+func useWalletSigner(n *core.IpfsNode) (wallet.Signer, error) {
+	s, err := wallet.LoadSigner(n.Repo)
+	if err != nil {
+		return nil, err
+	}
+	wallet.SetSigner(s)
+	return s, nil
+}
@@ -0,0 +1,336 @@
+// Package contractarchive moves the storage contracts in a final state out
+// of the contract listings of a node, once they stopped changing for a while.
+//
+// Archived contracts are written in gzip compressed segments, one per
+// archival, which are compacted into one when they get too many. They are
+// never read to list the current contracts, only when archived contracts are
+// asked for, so the listings stay fast however long the node has run.
+package contractarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	shardpb "github.com/TRON-US/go-btfs/protos/shard"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// ConfigKey is the config section of the contract archive.
+const ConfigKey = "ContractArchive"
+
+// DefaultAfter is how long contracts stay listed once final when nothing
+// is configured.
+const DefaultAfter = 30 * 24 * time.Hour
+
+// MaxSegments is the number of segments above which they are compacted
+// into one.
+const MaxSegments = 16
+
+const (
+	archiveKey   = "/btfs/%s/contracts/archive/%s/"
+	segmentsKey  = archiveKey + "segments/"
+	watermarkKey = archiveKey + "watermark"
+)
+
+// Config configures the archival of the contracts.
+type Config struct {
+	// After is how long contracts stay listed after their last change once
+	// in a final state, e.g. "720h".
+	After string `json:",omitempty"`
+	// Disabled keeps all contracts listed.
+	Disabled bool `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the contract archive config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if _, err := c.Age(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+// Age returns how long final contracts stay listed.
+func (c *Config) Age() (time.Duration, error) {
+	if c.After == "" {
+		return DefaultAfter, nil
+	}
+	d, err := time.ParseDuration(c.After)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid After %q", c.After)
+	}
+	return d, nil
+}
+
+// Final reports whether a contract in state s never changes again.
+func Final(s guardpb.Contract_ContractState) bool {
+	switch s {
+	case guardpb.Contract_CLOSED, guardpb.Contract_CANCELED, guardpb.Contract_LOST, guardpb.Contract_OBSOLETE:
+		return true
+	}
+	return false
+}
+
+// Segment is a batch of archived contracts: their listing entries and the
+// signed contracts they were built from.
+type Segment struct {
+	Contracts []*nodepb.Contracts_Contract
+	Signed    []*shardpb.SignedContracts `json:",omitempty"`
+}
+
+// Result is the outcome of an archival.
+type Result struct {
+	Role string
+	// Contracts and Signed count the listing entries and the signed
+	// contracts archived.
+	Contracts int
+	Signed    int
+	// Segments is the number of segments of the archive after it.
+	Segments int
+}
+
+// Archive writes the contracts of cs and scs which are final and did not
+// change since before to the archive of the role of peerID. It returns the
+// entries of cs to keep listed and the ids of the signed contracts archived,
+// which the caller removes from the datastore.
+func Archive(d ds.Datastore, peerID, role string, cs []*nodepb.Contracts_Contract,
+	scs []*shardpb.SignedContracts, before time.Time) ([]*nodepb.Contracts_Contract, []string, *Result, error) {
+	signed := map[string]*guardpb.Contract{}
+	for _, sc := range scs {
+		if sc.SignedGuardContract != nil {
+			signed[sc.SignedGuardContract.ContractId] = sc.SignedGuardContract
+		}
+	}
+	seg := &Segment{}
+	var keep []*nodepb.Contracts_Contract
+	kept := map[string]bool{}
+	for _, c := range cs {
+		last := c.EndTime
+		if g, ok := signed[c.ContractId]; ok {
+			if g.State != c.Status || !Final(g.State) {
+				// not synced yet
+				keep = append(keep, c)
+				kept[c.ContractId] = true
+				continue
+			}
+			if g.LastModifyTime.After(last) {
+				last = g.LastModifyTime
+			}
+		}
+		if Final(c.Status) && last.Before(before) {
+			seg.Contracts = append(seg.Contracts, c)
+		} else {
+			keep = append(keep, c)
+			kept[c.ContractId] = true
+		}
+	}
+	var ids []string
+	var mark time.Time
+	for _, sc := range scs {
+		g := sc.SignedGuardContract
+		// the sync updates the listed contracts from their signed contracts
+		if g == nil || !Final(g.State) || kept[g.ContractId] {
+			continue
+		}
+		last := g.RentEnd
+		if g.LastModifyTime.After(last) {
+			last = g.LastModifyTime
+		}
+		if !last.Before(before) {
+			continue
+		}
+		seg.Signed = append(seg.Signed, sc)
+		ids = append(ids, g.ContractId)
+		if g.LastModifyTime.After(mark) {
+			mark = g.LastModifyTime
+		}
+	}
+	res := &Result{Role: role, Contracts: len(seg.Contracts), Signed: len(seg.Signed)}
+	if len(seg.Contracts) == 0 && len(seg.Signed) == 0 {
+		n, err := countSegments(d, peerID, role)
+		res.Segments = n
+		return cs, nil, res, err
+	}
+	// the watermark goes first, the sync must not fetch the archived
+	// contracts again once they are gone
+	if err := advanceWatermark(d, peerID, role, mark); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := putSegment(d, peerID, role, seg); err != nil {
+		return nil, nil, nil, err
+	}
+	n, err := Compact(d, peerID, role)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	res.Segments = n
+	return keep, ids, res, nil
+}
+
+// List returns the archived listing entries of the role of peerID, the
+// latest archived of a contract when archived twice.
+func List(d ds.Datastore, peerID, role string) ([]*nodepb.Contracts_Contract, error) {
+	seg, _, err := merge(d, peerID, role)
+	if err != nil {
+		return nil, err
+	}
+	return seg.Contracts, nil
+}
+
+// Compact merges the segments of the role of peerID into one when there
+// are more than MaxSegments, and returns the number of segments left.
+func Compact(d ds.Datastore, peerID, role string) (int, error) {
+	n, err := countSegments(d, peerID, role)
+	if err != nil || n <= MaxSegments {
+		return n, err
+	}
+	seg, keys, err := merge(d, peerID, role)
+	if err != nil {
+		return 0, err
+	}
+	// a crash in between leaves duplicates, which merge drops
+	merged, err := putSegment(d, peerID, role, seg)
+	if err != nil {
+		return 0, err
+	}
+	for _, k := range keys {
+		if k == merged {
+			continue
+		}
+		if err := d.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return 1, nil
+}
+
+// Watermark returns the latest change of the signed contracts archived
+// for the role of peerID, the zero time when none was.
+func Watermark(d ds.Datastore, peerID, role string) (time.Time, error) {
+	var t time.Time
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(watermarkKey, peerID, role)))
+	if err == ds.ErrNotFound {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+	err = t.UnmarshalText(b)
+	return t, err
+}
+
+// ResetWatermark forgets the watermark of the role of peerID, for the
+// contracts to be synced from the beginning.
+func ResetWatermark(d ds.Datastore, peerID, role string) error {
+	return d.Delete(ds.NewKey(fmt.Sprintf(watermarkKey, peerID, role)))
+}
+
+func advanceWatermark(d ds.Datastore, peerID, role string, t time.Time) error {
+	cur, err := Watermark(d, peerID, role)
+	if err != nil || !t.After(cur) {
+		return err
+	}
+	b, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(watermarkKey, peerID, role)), b)
+}
+
+func putSegment(d ds.Datastore, peerID, role string, seg *Segment) (ds.Key, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(seg); err != nil {
+		return ds.Key{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return ds.Key{}, err
+	}
+	// segment keys sort by time, a later segment has the latest records
+	k := ds.NewKey(fmt.Sprintf(segmentsKey+"%020d", peerID, role, time.Now().UnixNano()))
+	return k, d.Put(k, buf.Bytes())
+}
+
+func querySegments(d ds.Datastore, peerID, role string, keysOnly bool) ([]query.Entry, error) {
+	prefix := fmt.Sprintf(segmentsKey, peerID, role)
+	rs, err := d.Query(query.Query{Prefix: strings.TrimSuffix(prefix, "/"), KeysOnly: keysOnly})
+	if err != nil {
+		return nil, err
+	}
+	es, err := rs.Rest()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].Key < es[j].Key })
+	return es, nil
+}
+
+func countSegments(d ds.Datastore, peerID, role string) (int, error) {
+	es, err := querySegments(d, peerID, role, true)
+	return len(es), err
+}
+
+// merge reads the segments of the role of peerID into one, dropping the
+// records archived again in a later segment, and returns their keys.
+func merge(d ds.Datastore, peerID, role string) (*Segment, []ds.Key, error) {
+	es, err := querySegments(d, peerID, role, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	cs := map[string]*nodepb.Contracts_Contract{}
+	scs := map[string]*shardpb.SignedContracts{}
+	var keys []ds.Key
+	for _, e := range es {
+		zr, err := gzip.NewReader(bytes.NewReader(e.Value))
+		if err != nil {
+			return nil, nil, fmt.Errorf("corrupt contract archive segment %s: %s", e.Key, err)
+		}
+		b, err := ioutil.ReadAll(zr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("corrupt contract archive segment %s: %s", e.Key, err)
+		}
+		seg := &Segment{}
+		if err := json.Unmarshal(b, seg); err != nil {
+			return nil, nil, fmt.Errorf("corrupt contract archive segment %s: %s", e.Key, err)
+		}
+		for _, c := range seg.Contracts {
+			cs[c.ContractId] = c
+		}
+		for _, sc := range seg.Signed {
+			scs[sc.SignedGuardContract.ContractId] = sc
+		}
+		keys = append(keys, ds.NewKey(e.Key))
+	}
+	seg := &Segment{}
+	for _, c := range cs {
+		seg.Contracts = append(seg.Contracts, c)
+	}
+	for _, sc := range scs {
+		seg.Signed = append(seg.Signed, sc)
+	}
+	sort.Slice(seg.Contracts, func(i, j int) bool { return seg.Contracts[i].ContractId < seg.Contracts[j].ContractId })
+	sort.Slice(seg.Signed, func(i, j int) bool {
+		return seg.Signed[i].SignedGuardContract.ContractId < seg.Signed[j].SignedGuardContract.ContractId
+	})
+	return seg, keys, nil
+}
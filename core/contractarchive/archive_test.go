@@ -0,0 +1,118 @@
+package contractarchive
+
+import (
+	"testing"
+	"time"
+
+	shardpb "github.com/TRON-US/go-btfs/protos/shard"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+const (
+	peer = "peer"
+	role = "HOST"
+)
+
+func contract(id string, s guardpb.Contract_ContractState, changed time.Time) (*nodepb.Contracts_Contract, *shardpb.SignedContracts) {
+	return &nodepb.Contracts_Contract{ContractId: id, Status: s, EndTime: changed},
+		&shardpb.SignedContracts{SignedGuardContract: &guardpb.Contract{
+			ContractMeta:   guardpb.ContractMeta{ContractId: id, RentEnd: changed},
+			State:          s,
+			LastModifyTime: changed,
+		}}
+}
+
+func TestArchive(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	old := now.Add(-60 * 24 * time.Hour)
+	var cs []*nodepb.Contracts_Contract
+	var scs []*shardpb.SignedContracts
+	for _, c := range []struct {
+		id      string
+		state   guardpb.Contract_ContractState
+		changed time.Time
+	}{
+		{"closed", guardpb.Contract_CLOSED, old},
+		{"canceled", guardpb.Contract_CANCELED, old.Add(time.Hour)},
+		{"recent", guardpb.Contract_CLOSED, now},
+		{"active", guardpb.Contract_UPLOADED, old},
+	} {
+		l, s := contract(c.id, c.state, c.changed)
+		cs = append(cs, l)
+		scs = append(scs, s)
+	}
+	// the listing of "stale" is not synced with its signed contract yet
+	l, s := contract("stale", guardpb.Contract_CLOSED, old)
+	l.Status = guardpb.Contract_UPLOADED
+	cs = append(cs, l)
+	scs = append(scs, s)
+
+	keep, ids, res, err := Archive(d, peer, role, cs, scs, now.Add(-DefaultAfter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keep) != 3 || keep[0].ContractId != "recent" || keep[1].ContractId != "active" || keep[2].ContractId != "stale" {
+		t.Fatalf("unexpected contracts kept %v", keep)
+	}
+	if len(ids) != 2 || ids[0] != "closed" || ids[1] != "canceled" {
+		t.Fatalf("unexpected signed contracts archived %v", ids)
+	}
+	if res.Contracts != 2 || res.Signed != 2 || res.Segments != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if mark, err := Watermark(d, peer, role); err != nil || !mark.Equal(old.Add(time.Hour)) {
+		t.Fatalf("unexpected watermark %s, %v", mark, err)
+	}
+
+	archived, err := List(d, peer, role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived) != 2 || archived[0].ContractId != "canceled" || archived[1].ContractId != "closed" {
+		t.Fatalf("unexpected archived contracts %v", archived)
+	}
+	if archived, _ := List(d, peer, "RENTER"); len(archived) != 0 {
+		t.Fatalf("archived renter contracts %v", archived)
+	}
+
+	// nothing left to archive
+	if _, ids, res, err := Archive(d, peer, role, keep, nil, now.Add(-DefaultAfter)); err != nil || len(ids) != 0 || res.Segments != 1 {
+		t.Fatalf("unexpected archival %v, %+v, %v", ids, res, err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	for i := 0; i <= MaxSegments; i++ {
+		// the same contract archived again, and a new one each time
+		again, _ := contract("again", guardpb.Contract_CLOSED, old)
+		again.CompensationPaid = int64(i)
+		other, _ := contract(string(rune('a'+i)), guardpb.Contract_LOST, old)
+		_, _, res, err := Archive(d, peer, role, []*nodepb.Contracts_Contract{again, other}, nil, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < MaxSegments && res.Segments != i+1 || i == MaxSegments && res.Segments != 1 {
+			t.Fatalf("%d: unexpected segments %d", i, res.Segments)
+		}
+	}
+	archived, err := List(d, peer, role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived) != MaxSegments+2 {
+		t.Fatalf("expected %d contracts, got %d", MaxSegments+2, len(archived))
+	}
+	for _, c := range archived {
+		if c.ContractId == "again" && c.CompensationPaid != MaxSegments {
+			t.Fatalf("expected the latest archived, got %+v", c)
+		}
+	}
+}
@@ -13,8 +13,10 @@ import (
 	"context"
 	"github.com/TRON-US/go-btfs/peering"
 	"io"
+	"time"
 
 	"github.com/TRON-US/go-btfs/core/bootstrap"
+	"github.com/TRON-US/go-btfs/core/latency"
 	"github.com/TRON-US/go-btfs/core/node"
 	"github.com/TRON-US/go-btfs/core/node/libp2p"
 	"github.com/TRON-US/go-btfs/fuse/mount"
@@ -153,6 +155,24 @@ func (n *IpfsNode) Bootstrap(cfg bootstrap.BootstrapConfig) error {
 		}
 	}
 
+	// prefer the bootstrap peers measured fastest, see spin.Latency.
+	if cfg.Latencies == nil {
+		cfg.Latencies = func() map[peer.ID]time.Duration {
+			medians, err := latency.Medians(n.Repo.Datastore(), n.Identity.Pretty(), latency.Bootstrap)
+			if err != nil {
+				log.Warnf("failed to load bootstrap peers latency: %s", err)
+				return nil
+			}
+			out := make(map[peer.ID]time.Duration, len(medians))
+			for id, m := range medians {
+				if pid, err := peer.Decode(id); err == nil {
+					out[pid] = m
+				}
+			}
+			return out
+		}
+	}
+
 	var err error
 	n.Bootstrapper, err = bootstrap.Bootstrap(n.Identity, n.PeerHost, n.Routing, cfg)
 	return err
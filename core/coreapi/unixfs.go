@@ -13,6 +13,8 @@ import (
 
 	"github.com/TRON-US/go-btfs/core"
 	"github.com/TRON-US/go-btfs/core/coreunix"
+	"github.com/TRON-US/go-btfs/core/erasure"
+	"github.com/TRON-US/go-btfs/repo/durability"
 
 	chunker "github.com/TRON-US/go-btfs-chunker"
 	files "github.com/TRON-US/go-btfs-files"
@@ -93,6 +95,11 @@ func (api *UnixfsAPI) Add(ctx context.Context, filesNode files.Node, opts ...opt
 	exch := api.exchange
 	pinning := api.pinning
 
+	session := coreunix.AddSessionFromContext(ctx)
+	if session != nil && !settings.OnlyHash {
+		addblockstore = session.Blockstore(addblockstore)
+	}
+
 	if settings.OnlyHash {
 		node, err := getOrCreateNilNode()
 		if err != nil {
@@ -133,7 +140,18 @@ func (api *UnixfsAPI) Add(ctx context.Context, filesNode files.Node, opts ...opt
 		return nil, err
 	}
 
+	writes, err := durability.Load(api.repo)
+	if err != nil {
+		return nil, err
+	}
+	fileAdder.SetBatchOptions(writes.BatchOptions()...)
+
 	fileAdder.Chunker = settings.Chunker
+	if chunker.IsReedSolomon(settings.Chunker) {
+		if fileAdder.Erasure, err = erasure.Load(api.repo); err != nil {
+			return nil, err
+		}
+	}
 	if settings.Events != nil {
 		fileAdder.Out = settings.Events
 		fileAdder.Progress = settings.Progress
@@ -143,6 +161,9 @@ func (api *UnixfsAPI) Add(ctx context.Context, filesNode files.Node, opts ...opt
 	fileAdder.RawLeaves = settings.RawLeaves
 	fileAdder.NoCopy = settings.NoCopy
 	fileAdder.CidBuilder = prefix
+	if !settings.OnlyHash {
+		fileAdder.Session = session
+	}
 
 	switch settings.Layout {
 	case options.BalancedLayout:
@@ -0,0 +1,187 @@
+// Package apilimit bounds the number of requests of the expensive commands
+// the HTTP API serves at once, such as uploads or garbage collections. The
+// requests beyond the bound wait in a short queue, and are rejected once it
+// is full or after their wait times out, for a burst of requests to slow
+// the node down instead of exhausting its memory.
+package apilimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ConfigKey is the config section of the API concurrency limits.
+const ConfigKey = "APILimits"
+
+// DefaultQueueTimeout is the wait of a queued request when its limit sets
+// none.
+const DefaultQueueTimeout = 30 * time.Second
+
+// Limit bounds the requests of a command served at once.
+type Limit struct {
+	// MaxConcurrent is the number of requests served at once, unbounded
+	// when 0.
+	MaxConcurrent int
+	// MaxQueued is the number of requests waiting for a served one to end,
+	// the requests beyond it are rejected right away.
+	MaxQueued int `json:",omitempty"`
+	// QueueTimeout bounds the wait of a queued request, e.g. "1m".
+	QueueTimeout string `json:",omitempty"`
+}
+
+// Config holds the limits of the commands by path, e.g. "storage/upload".
+// A limit applies to its command only, not to the subcommands.
+type Config struct {
+	// Commands replaces Defaults when set, an empty map lifts all limits.
+	Commands map[string]*Limit `json:",omitempty"`
+}
+
+// Defaults are the limits of the commands when none are configured.
+var Defaults = map[string]*Limit{
+	"storage/upload": {MaxConcurrent: 4, MaxQueued: 16},
+	"repo/gc":        {MaxConcurrent: 1},
+	"dag/export":     {MaxConcurrent: 2, MaxQueued: 4},
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+	prometheus.MustRegister(queued, rejections)
+}
+
+var (
+	queued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "btfs",
+		Subsystem: "api",
+		Name:      "queued_requests",
+		Help:      "Requests waiting for the concurrency limit of their command.",
+	}, []string{"command"})
+	rejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "api",
+		Name:      "limit_rejections_total",
+		Help:      "Requests rejected by the concurrency limit of their command.",
+	}, []string{"command"})
+)
+
+// Load returns the API limits config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if c.Commands == nil {
+		c.Commands = Defaults
+	}
+	return c, nil
+}
+
+// BusyError rejects a request of a command at its concurrency limit.
+type BusyError struct {
+	Command string
+	// RetryAfter is the wait suggested before retrying.
+	RetryAfter time.Duration
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("too many concurrent 'btfs %s' requests, retry after %s",
+		strings.Replace(e.Command, "/", " ", -1), e.RetryAfter)
+}
+
+// Limiter applies the limits of a config.
+type Limiter struct {
+	limits map[string]*limiter
+}
+
+type limiter struct {
+	cmd     string
+	slots   chan struct{}
+	waiting chan struct{}
+	timeout time.Duration
+}
+
+// New returns the limiter of the limits of c.
+func New(c *Config) (*Limiter, error) {
+	l := &Limiter{limits: map[string]*limiter{}}
+	for cmd, lim := range c.Commands {
+		cmd = strings.Trim(cmd, "/")
+		if lim == nil || lim.MaxConcurrent == 0 {
+			continue
+		}
+		if lim.MaxConcurrent < 0 || lim.MaxQueued < 0 {
+			return nil, fmt.Errorf("invalid %s config: negative bound for %s", ConfigKey, cmd)
+		}
+		timeout := DefaultQueueTimeout
+		if lim.QueueTimeout != "" {
+			d, err := time.ParseDuration(lim.QueueTimeout)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid %s config: invalid queue timeout %q for %s",
+					ConfigKey, lim.QueueTimeout, cmd)
+			}
+			timeout = d
+		}
+		l.limits[cmd] = &limiter{
+			cmd:     cmd,
+			slots:   make(chan struct{}, lim.MaxConcurrent),
+			waiting: make(chan struct{}, lim.MaxQueued),
+			timeout: timeout,
+		}
+	}
+	return l, nil
+}
+
+// Commands returns the paths of the commands limited.
+func (l *Limiter) Commands() []string {
+	cmds := make([]string, 0, len(l.limits))
+	for cmd := range l.limits {
+		cmds = append(cmds, cmd)
+	}
+	sort.Strings(cmds)
+	return cmds
+}
+
+// Acquire admits a request of the command at cmdPath, waiting in the queue
+// of its limit when needed. The request must call release once served. A
+// request rejected gets a *BusyError, or the error of ctx when it is done
+// first.
+func (l *Limiter) Acquire(ctx context.Context, cmdPath []string) (release func(), err error) {
+	lim, ok := l.limits[strings.Join(cmdPath, "/")]
+	if !ok {
+		return func() {}, nil
+	}
+	release = func() { <-lim.slots }
+	select {
+	case lim.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	select {
+	case lim.waiting <- struct{}{}:
+	default:
+		rejections.WithLabelValues(lim.cmd).Inc()
+		return nil, &BusyError{Command: lim.cmd, RetryAfter: lim.timeout}
+	}
+	queued.WithLabelValues(lim.cmd).Inc()
+	defer func() {
+		<-lim.waiting
+		queued.WithLabelValues(lim.cmd).Dec()
+	}()
+	t := time.NewTimer(lim.timeout)
+	defer t.Stop()
+	select {
+	case lim.slots <- struct{}{}:
+		return release, nil
+	case <-t.C:
+		rejections.WithLabelValues(lim.cmd).Inc()
+		return nil, &BusyError{Command: lim.cmd, RetryAfter: lim.timeout}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,77 @@
+package apilimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		c  Config
+		ok bool
+	}{
+		{Config{Commands: Defaults}, true},
+		{Config{Commands: map[string]*Limit{"repo/gc": {MaxConcurrent: 1, QueueTimeout: "1m"}}}, true},
+		{Config{Commands: map[string]*Limit{"repo/gc": {MaxConcurrent: -1}}}, false},
+		{Config{Commands: map[string]*Limit{"repo/gc": {MaxConcurrent: 1, QueueTimeout: "soon"}}}, false},
+	}
+	for i, tc := range cases {
+		if _, err := New(&tc.c); (err == nil) != tc.ok {
+			t.Errorf("case %d: expected ok=%t, got %v", i, tc.ok, err)
+		}
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	l, err := New(&Config{Commands: map[string]*Limit{
+		"/dag/export/": {MaxConcurrent: 1, MaxQueued: 1, QueueTimeout: "50ms"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmds := l.Commands(); len(cmds) != 1 || cmds[0] != "dag/export" {
+		t.Fatalf("unexpected commands %v", cmds)
+	}
+	ctx := context.Background()
+	export := []string{"dag", "export"}
+
+	// other commands are not limited
+	if _, err := l.Acquire(ctx, []string{"dag", "get"}); err != nil {
+		t.Fatal(err)
+	}
+	release, err := l.Acquire(ctx, export)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second request waits for the first, the third is rejected
+	done := make(chan error)
+	go func() {
+		r, err := l.Acquire(ctx, export)
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := l.Acquire(ctx, export); err == nil {
+		t.Fatal("expected a full queue")
+	} else if be, ok := err.(*BusyError); !ok || be.RetryAfter != 50*time.Millisecond {
+		t.Fatalf("expected a busy error, got %v", err)
+	}
+	release()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the queued request served, got %v", err)
+	}
+
+	// a queued request times out
+	release, err = l.Acquire(ctx, export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if _, err := l.Acquire(ctx, export); err == nil {
+		t.Fatal("expected the queued request to time out")
+	}
+}
@@ -1,9 +1,11 @@
 package corehttp
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/TRON-US/go-btfs-api"
+	"math"
 	"net"
 	"net/http"
 	"os"
@@ -13,7 +15,14 @@ import (
 	version "github.com/TRON-US/go-btfs"
 	oldcmds "github.com/TRON-US/go-btfs/commands"
 	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/apps"
+	"github.com/TRON-US/go-btfs/core/audit"
 	corecommands "github.com/TRON-US/go-btfs/core/commands"
+	"github.com/TRON-US/go-btfs/core/corehttp/apilimit"
+	"github.com/TRON-US/go-btfs/core/corehttp/cors"
+	"github.com/TRON-US/go-btfs/core/events"
+	"github.com/TRON-US/go-btfs/core/protover"
+	"github.com/TRON-US/go-btfs/core/users"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	cmdsHttp "github.com/TRON-US/go-btfs-cmds/http"
@@ -44,12 +53,7 @@ var redirectPaths = []string{
 	"/api/latest",
 }
 
-var defaultLocalhostOrigins = []string{
-	"http://127.0.0.1:<port>",
-	"https://127.0.0.1:<port>",
-	"http://localhost:<port>",
-	"https://localhost:<port>",
-}
+var defaultLocalhostOrigins = cors.LocalhostOrigins
 
 func addCORSFromEnv(c *cmdsHttp.ServerConfig) {
 	origin := os.Getenv(originEnvKey)
@@ -59,9 +63,7 @@ func addCORSFromEnv(c *cmdsHttp.ServerConfig) {
 	}
 }
 
-func addHeadersFromConfig(c *cmdsHttp.ServerConfig, nc *config.Config) {
-	log.Info("Using API.HTTPHeaders:", nc.API.HTTPHeaders)
-
+func addCORSFromConfig(c *cmdsHttp.ServerConfig, nc *config.Config) {
 	if acao := nc.API.HTTPHeaders[cmdsHttp.ACAOrigin]; acao != nil {
 		c.SetAllowedOrigins(acao...)
 	}
@@ -71,6 +73,10 @@ func addHeadersFromConfig(c *cmdsHttp.ServerConfig, nc *config.Config) {
 	for _, v := range nc.API.HTTPHeaders[cmdsHttp.ACACredentials] {
 		c.SetAllowCredentials(strings.ToLower(v) == "true")
 	}
+}
+
+func addHeadersFromConfig(c *cmdsHttp.ServerConfig, nc *config.Config) {
+	log.Info("Using API.HTTPHeaders:", nc.API.HTTPHeaders)
 
 	c.Headers = make(map[string][]string, len(nc.API.HTTPHeaders)+1)
 
@@ -124,54 +130,284 @@ func patchCORSVars(c *cmdsHttp.ServerConfig, addr net.Addr) {
 	c.SetAllowedOrigins(newOrigins...)
 }
 
-func commandsOption(cctx oldcmds.Context, command *cmds.Command, allowGet bool) ServeOption {
-	return func(n *core.IpfsNode, l net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
-
-		cfg := cmdsHttp.NewServerConfig()
-		cfg.AllowGet = allowGet
+// setCORS applies the structured CORS settings cc to c. A nil cc restores
+// the settings derived from API.HTTPHeaders, the environment and defaults.
+func setCORS(c *cmdsHttp.ServerConfig, cc *cors.Config, nc *config.Config, allowGet bool, addr net.Addr) {
+	if cc != nil {
+		c.SetAllowedOrigins(cc.AllowedOrigins...)
+		c.SetAllowedMethods(cc.AllowedMethods...)
+		c.SetAllowCredentials(cc.AllowCredentials)
+	} else {
 		corsAllowedMethods := []string{http.MethodPost}
 		if allowGet {
 			corsAllowedMethods = append(corsAllowedMethods, http.MethodGet)
 		}
+		c.SetAllowedOrigins()
+		c.SetAllowedMethods(corsAllowedMethods...)
+		c.SetAllowCredentials(false)
+		addCORSFromConfig(c, nc)
+		addCORSFromEnv(c)
+		addCORSDefaults(c)
+	}
+	patchCORSVars(c, addr)
+}
 
-		cfg.SetAllowedMethods(corsAllowedMethods...)
-		cfg.APIPath = APIPath
-		cfg.RedirectPaths = redirectPaths
+func newCommandsServerConfig(allowGet bool, nc *config.Config) *cmdsHttp.ServerConfig {
+	cfg := cmdsHttp.NewServerConfig()
+	cfg.AllowGet = allowGet
+	cfg.APIPath = APIPath
+	cfg.RedirectPaths = redirectPaths
+	addHeadersFromConfig(cfg, nc)
+	return cfg
+}
+
+// commandsOption serves command on the API path. The CORS settings of group
+// apply to it, an empty group only uses the API.HTTPHeaders based settings.
+func commandsOption(cctx oldcmds.Context, command *cmds.Command, allowGet bool, group string) ServeOption {
+	return func(n *core.IpfsNode, l net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
 		rcfg, err := n.Repo.Config()
 		if err != nil {
 			return nil, err
 		}
+		groups, err := cors.Load(n.Repo)
+		if err != nil {
+			return nil, err
+		}
 
-		addHeadersFromConfig(cfg, rcfg)
-		addCORSFromEnv(cfg)
-		addCORSDefaults(cfg)
-		patchCORSVars(cfg, l.Addr())
+		cfg := newCommandsServerConfig(allowGet, rcfg)
+		if group != "" {
+			gc, err := groups.Get(group)
+			if err != nil {
+				return nil, err
+			}
+			setCORS(cfg, gc, rcfg, allowGet, l.Addr())
+			cors.Register(group, func(c *cors.Config) {
+				setCORS(cfg, c, rcfg, allowGet, l.Addr())
+			})
+		} else {
+			setCORS(cfg, nil, rcfg, allowGet, l.Addr())
+		}
 
-		cmdHandler := cmdsHttp.NewHandler(&cctx, command, cfg)
+		ac, err := audit.Load(n.Repo)
+		if err != nil {
+			return nil, err
+		}
+		withAudit := func(h http.Handler) http.Handler {
+			// Without a repo on disk there is nowhere to keep the log.
+			if ac.Disabled || cctx.ConfigRoot == "" {
+				return h
+			}
+			return auditHandler(audit.Open(cctx.ConfigRoot, ac), h)
+		}
+
+		lc, err := apilimit.Load(n.Repo)
+		if err != nil {
+			return nil, err
+		}
+		limiter, err := apilimit.New(lc)
+		if err != nil {
+			return nil, err
+		}
+		withLimits := func(h http.Handler) http.Handler {
+			return limitHandler(limiter, h)
+		}
+
+		withUsers := func(h http.Handler) http.Handler {
+			// Accounts only guard the API, the gateway and the remote
+			// commands have their own access rules.
+			if group != cors.API {
+				return h
+			}
+			return usersHandler(users.ForNode(n), apps.ForNode(n), h)
+		}
+
+		cmdHandler := withUsers(withLimits(withAudit(cmdsHttp.NewHandler(&cctx, command, cfg))))
 		mux.Handle(APIPath+"/", cmdHandler)
 		for _, rp := range redirectPaths {
 			mux.Handle(rp+"/", cmdHandler)
 		}
+
+		// The event streams last, so they stay out of the concurrency
+		// limits of the commands.
+		if group == cors.API {
+			streams := eventStreams(withUsers(withAudit(eventsHandler(cfg, events.Default))), cmdHandler)
+			mux.Handle(APIPath+"/events", streams)
+			for _, rp := range redirectPaths {
+				mux.Handle(rp+"/events", streams)
+			}
+		}
+
+		// Wallet commands move funds, so they get their own, stricter
+		// CORS settings instead of inheriting the API ones.
+		if _, ok := command.Subcommands["wallet"]; ok && group == cors.API {
+			walletCfg := newCommandsServerConfig(allowGet, rcfg)
+			applyWallet := func(c *cors.Config) {
+				if c == nil {
+					c = cors.Defaults(cors.Wallet)
+				}
+				setCORS(walletCfg, c, rcfg, allowGet, l.Addr())
+			}
+			applyWallet(groups.Wallet)
+			cors.Register(cors.Wallet, applyWallet)
+
+			walletHandler := withUsers(withLimits(withAudit(cmdsHttp.NewHandler(&cctx, command, walletCfg))))
+			mux.Handle(APIPath+"/wallet/", walletHandler)
+			for _, rp := range redirectPaths {
+				mux.Handle(rp+"/wallet/", walletHandler)
+			}
+		}
 		return mux, nil
 	}
 }
 
+// auditHandler records the commands served by h in l, on behalf of the
+// caller of the request.
+func auditHandler(l *audit.Log, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller := audit.Caller(r)
+		if u := users.FromContext(r.Context()); u != nil {
+			caller = "user:" + u.Name
+		}
+		if a := apps.FromContext(r.Context()); a != nil {
+			caller = "app:" + a.ID
+		}
+		ctx := audit.WithCaller(r.Context(), l, caller)
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// limitHandler serves the requests of h within the concurrency limits of
+// their commands, answering 429 to those rejected.
+func limitHandler(l *apilimit.Limiter, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, err := l.Acquire(r.Context(), commandPath(r.URL.Path))
+		if be, ok := err.(*apilimit.BusyError); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(be.RetryAfter.Seconds()))))
+			writeCmdsError(w, http.StatusTooManyRequests, be.Error(), cmds.ErrRateLimited)
+			return
+		}
+		if err != nil {
+			// the caller went away while queued
+			return
+		}
+		defer release()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// usersHandler authenticates the callers of h once the multi-user mode is
+// on, and only lets them run the commands of their role. Callers without a
+// token on the loopback interface are the operator of the node. Callers
+// with the token of an application, in any mode, may only run the commands
+// of applications, in its namespace.
+func usersHandler(s *users.Store, as *apps.Store, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		on, err := s.Enabled()
+		if err != nil {
+			writeCmdsError(w, http.StatusInternalServerError, err.Error(), cmds.ErrNormal)
+			return
+		}
+
+		const prefix = "Bearer "
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, prefix) {
+			a, err := as.Authenticate(strings.TrimPrefix(auth, prefix))
+			switch err {
+			case nil:
+				cmdPath := commandPath(r.URL.Path)
+				if !apps.Allows(cmdPath) {
+					writeCmdsError(w, http.StatusForbidden, fmt.Sprintf("app %s may not run 'btfs %s'",
+						a.ID, strings.Join(cmdPath, " ")), cmds.ErrForbidden)
+					return
+				}
+				h.ServeHTTP(w, r.WithContext(apps.WithApp(r.Context(), a)))
+				return
+			case apps.ErrInvalidToken:
+			default:
+				writeCmdsError(w, http.StatusInternalServerError, err.Error(), cmds.ErrNormal)
+				return
+			}
+		}
+		if !on {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if !strings.HasPrefix(auth, prefix) {
+			if isLoopback(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeCmdsError(w, http.StatusUnauthorized, "this node requires an api token", cmds.ErrForbidden)
+			return
+		}
+		u, err := s.Authenticate(strings.TrimPrefix(auth, prefix))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeCmdsError(w, http.StatusUnauthorized, err.Error(), cmds.ErrForbidden)
+			return
+		}
+		cmdPath := commandPath(r.URL.Path)
+		if !u.Role.Allows(users.CommandScope(cmdPath)) {
+			writeCmdsError(w, http.StatusForbidden, fmt.Sprintf("user %s (%s) may not run 'btfs %s'",
+				u.Name, u.Role, strings.Join(cmdPath, " ")), cmds.ErrForbidden)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(users.WithUser(r.Context(), u)))
+	})
+}
+
+// commandPath returns the path of the command requested at the API path p.
+func commandPath(p string) []string {
+	for _, prefix := range append([]string{APIPath}, redirectPaths...) {
+		if strings.HasPrefix(p, prefix+"/") {
+			p = strings.TrimPrefix(p, prefix)
+			break
+		}
+	}
+	var cmdPath []string
+	for _, s := range strings.Split(p, "/") {
+		if s != "" {
+			cmdPath = append(cmdPath, s)
+		}
+	}
+	return cmdPath
+}
+
+func isLoopback(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// writeCmdsError answers a request with a command error, as the commands
+// handler does.
+func writeCmdsError(w http.ResponseWriter, status int, msg string, code cmds.ErrorType) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(cmds.Error{Message: msg, Code: code})
+}
+
 // CommandsOption constructs a ServerOption for hooking the commands into the
 // HTTP server. It will NOT allow GET requests.
 func CommandsOption(cctx oldcmds.Context) ServeOption {
-	return commandsOption(cctx, corecommands.Root, false)
+	return commandsOption(cctx, corecommands.Root, false, cors.API)
 }
 
 // CommandsROOption constructs a ServerOption for hooking the read-only commands
 // into the HTTP server. It will allow GET requests.
 func CommandsROOption(cctx oldcmds.Context) ServeOption {
-	return commandsOption(cctx, corecommands.RootRO, true)
+	return commandsOption(cctx, corecommands.RootRO, true, cors.Gateway)
 }
 
 // CommandsRemoteOption constructs a ServerOption for hooking the public-facing,
 // remote commands into the HTTP server.
 func CommandsRemoteOption(cctx oldcmds.Context) ServeOption {
-	return commandsOption(cctx, corecommands.RootRemote, false)
+	return commandsOption(cctx, corecommands.RootRemote, false, "")
 }
 
 // CheckVersionOption returns a ServeOption that checks whether the client btfs version matches. Does nothing when the user agent string does not contain `/go-btfs/`
@@ -202,3 +438,25 @@ func CheckVersionOption() ServeOption {
 		return mux, nil
 	})
 }
+
+// ProtocolVersionOption negotiates the version of the renter-host protocol
+// with the peers calling the remote API. Requests of peers supporting none
+// of the versions of the node are rejected before reaching the commands,
+// the others run with the negotiated version in their context, see
+// protover.FromContext.
+func ProtocolVersionOption() ServeOption {
+	return ServeOption(func(n *core.IpfsNode, l net.Listener, parent *http.ServeMux) (*http.ServeMux, error) {
+		mux := http.NewServeMux()
+		parent.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(protover.Header, protover.Supported[protover.RenterHost].String())
+			v, err := protover.NegotiateAnnounced(protover.RenterHost, r.Header.Get(protover.Header))
+			if err != nil {
+				writeCmdsError(w, http.StatusBadRequest, err.Error(), cmds.ErrClient)
+				return
+			}
+			mux.ServeHTTP(w, r.WithContext(protover.NewContext(r.Context(), protover.RenterHost, v)))
+		})
+
+		return mux, nil
+	})
+}
@@ -0,0 +1,24 @@
+package corehttp
+
+import (
+	"net"
+	"net/http"
+
+	core "github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/corehttp/compression"
+)
+
+// CompressionOption compresses the responses of the handlers of the server,
+// API or Gateway, as set in the Compression config section, see the
+// compression package.
+func CompressionOption(server string) ServeOption {
+	return func(n *core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
+		s, err := compression.Load(n.Repo, server)
+		if err != nil {
+			return nil, err
+		}
+		childMux := http.NewServeMux()
+		mux.Handle("/", compression.Handler(s, childMux))
+		return childMux, nil
+	}
+}
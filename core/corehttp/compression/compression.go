@@ -0,0 +1,354 @@
+// Package compression compresses the HTTP responses of the API and the
+// gateway with the encoding the client prefers among gzip and zstd, and
+// coalesces the flushes of the streamed responses into chunks, so that
+// large responses such as 'btfs cat' or 'btfs dag export' neither go out
+// uncompressed nor in thousands of tiny chunks over slow links.
+package compression
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ConfigKey is the config section of the response compression.
+const ConfigKey = "Compression"
+
+// Servers whose responses are compressed.
+const (
+	API     = "API"
+	Gateway = "Gateway"
+)
+
+// Encodings.
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// Defaults of the settings.
+const (
+	DefaultMinSize       = 1024
+	DefaultFlushInterval = 100 * time.Millisecond
+)
+
+// zstdWindow bounds the memory of a zstd encoder.
+const zstdWindow = 1 << 20
+
+// DefaultEncodings are the encodings offered, preferred first.
+var DefaultEncodings = []string{Zstd, Gzip}
+
+// Settings configures the compression of the responses of a server.
+type Settings struct {
+	Enabled bool
+	// Encodings are the encodings offered, preferred first when the client
+	// accepts several equally, DefaultEncodings when empty.
+	Encodings []string `json:",omitempty"`
+	// MinSize is the size of the responses of known length below which they
+	// are sent as is, DefaultMinSize when 0.
+	MinSize int64 `json:",omitempty"`
+	// FlushInterval is the least time between the flushes of a streamed
+	// response, e.g. "100ms", DefaultFlushInterval when empty.
+	FlushInterval string `json:",omitempty"`
+}
+
+// Config holds the compression settings of the servers. A nil server keeps
+// its defaults: compressed for the API, as is for the gateway, whose
+// content is mostly compressed already.
+type Config struct {
+	API     *Settings `json:",omitempty"`
+	Gateway *Settings `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Defaults returns the settings of server when none are configured.
+func Defaults(server string) *Settings {
+	return &Settings{Enabled: server == API}
+}
+
+// Load returns the compression settings of server in r.
+func Load(r repo.Repo, server string) (*Settings, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	s := c.API
+	if server == Gateway {
+		s = c.Gateway
+	}
+	if s == nil {
+		s = Defaults(server)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s: %s", ConfigKey, server, err)
+	}
+	return s, nil
+}
+
+// Validate checks the encodings and the flush interval of s.
+func (s *Settings) Validate() error {
+	for _, e := range s.Encodings {
+		if e != Gzip && e != Zstd {
+			return fmt.Errorf("unknown encoding %q, expected %s or %s", e, Zstd, Gzip)
+		}
+	}
+	if s.MinSize < 0 {
+		return fmt.Errorf("negative min size")
+	}
+	if s.FlushInterval != "" {
+		if d, err := time.ParseDuration(s.FlushInterval); err != nil || d < 0 {
+			return fmt.Errorf("invalid flush interval %q", s.FlushInterval)
+		}
+	}
+	return nil
+}
+
+func (s *Settings) encodings() []string {
+	if len(s.Encodings) == 0 {
+		return DefaultEncodings
+	}
+	return s.Encodings
+}
+
+func (s *Settings) minSize() int64 {
+	if s.MinSize == 0 {
+		return DefaultMinSize
+	}
+	return s.MinSize
+}
+
+func (s *Settings) flushInterval() time.Duration {
+	d, err := time.ParseDuration(s.FlushInterval)
+	if err != nil {
+		return DefaultFlushInterval
+	}
+	return d
+}
+
+// Negotiate returns the encoding of offered the Accept-Encoding header
+// accept prefers, empty when it accepts none.
+func Negotiate(accept string, offered []string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if w, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					weight = w
+				}
+			}
+		}
+		q[name] = weight
+	}
+	best, bestQ := "", 0.0
+	for _, e := range offered {
+		w, ok := q[e]
+		if !ok {
+			w, ok = q["*"]
+		}
+		if ok && w > bestQ {
+			best, bestQ = e, w
+		}
+	}
+	return best
+}
+
+// precompressed are the content types not worth compressing.
+var precompressed = []string{
+	"image/", "video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-7z-compressed", "application/x-xz", "application/x-bzip2",
+}
+
+func compressible(contentType string) bool {
+	for _, p := range precompressed {
+		if strings.HasPrefix(contentType, p) {
+			return strings.HasPrefix(contentType, "image/svg")
+		}
+	}
+	return true
+}
+
+// Handler serves h, compressing its responses and coalescing their flushes
+// as set by s.
+func Handler(s *Settings, h http.Handler) http.Handler {
+	offered := s.encodings()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.Enabled {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		cw := &writer{
+			ResponseWriter: w,
+			interval:       s.flushInterval(),
+			minSize:        s.minSize(),
+		}
+		// ranges are of the content as is
+		if r.Method != http.MethodHead && r.Header.Get("Range") == "" {
+			cw.encoding = Negotiate(r.Header.Get("Accept-Encoding"), offered)
+		}
+		defer cw.close()
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// encoder is a compressing writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+var (
+	gzipPool = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+	zstdPool = sync.Pool{New: func() interface{} {
+		// the only error is of an invalid option
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(zstdWindow))
+		return e
+	}}
+)
+
+// writer compresses a response, and flushes it at most every interval.
+type writer struct {
+	http.ResponseWriter
+	encoding string
+	interval time.Duration
+	minSize  int64
+
+	lk        sync.Mutex
+	wroteHead bool
+	enc       encoder
+	lastFlush time.Time
+	timer     *time.Timer
+	closed    bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	w.writeHeader(code)
+}
+
+// writeHeader starts the encoder when the response is to be compressed.
+// w.lk is held.
+func (w *writer) writeHeader(code int) {
+	if w.wroteHead {
+		return
+	}
+	w.wroteHead = true
+	h := w.Header()
+	if w.encoding != "" && code >= http.StatusOK && code != http.StatusNoContent &&
+		code != http.StatusPartialContent && code != http.StatusNotModified &&
+		h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		n, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64)
+		if err != nil || n >= w.minSize {
+			h.Del("Content-Length")
+			h.Del("Accept-Ranges")
+			h.Set("Content-Encoding", w.encoding)
+			w.enc = newEncoder(w.encoding, w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func newEncoder(encoding string, w io.Writer) encoder {
+	if encoding == Gzip {
+		e := gzipPool.Get().(*gzip.Writer)
+		e.Reset(w)
+		return e
+	}
+	e := zstdPool.Get().(*zstd.Encoder)
+	e.Reset(w)
+	return e
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	if !w.wroteHead {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.writeHeader(http.StatusOK)
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends what was written, unless the last flush is less than the
+// flush interval ago, in which case it is delayed until then.
+func (w *writer) Flush() {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	if w.timer != nil || w.closed {
+		return
+	}
+	if wait := w.interval - time.Since(w.lastFlush); wait > 0 {
+		w.timer = time.AfterFunc(wait, func() {
+			w.lk.Lock()
+			defer w.lk.Unlock()
+			w.timer = nil
+			if !w.closed {
+				w.flush()
+			}
+		})
+		return
+	}
+	w.flush()
+}
+
+// flush sends what was written. w.lk is held.
+func (w *writer) flush() {
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+	w.lastFlush = time.Now()
+}
+
+// close ends the compressed stream once the handler returned.
+func (w *writer) close() {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	switch e := w.enc.(type) {
+	case *gzip.Writer:
+		gzipPool.Put(e)
+	case *zstd.Encoder:
+		zstdPool.Put(e)
+	}
+	w.enc = nil
+}
@@ -0,0 +1,145 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", Gzip},
+		{"gzip, zstd", Zstd},
+		{"gzip;q=1.0, zstd;q=0.5", Gzip},
+		{"zstd;q=0, gzip", Gzip},
+		{"*", Zstd},
+		{"br, identity", ""},
+	}
+	for _, tc := range cases {
+		if got := Negotiate(tc.accept, DefaultEncodings); got != tc.want {
+			t.Errorf("%q: expected %q, got %q", tc.accept, tc.want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (&Settings{Encodings: []string{"br"}}).Validate(); err == nil {
+		t.Fatal("expected an unknown encoding to be rejected")
+	}
+	if err := (&Settings{FlushInterval: "often"}).Validate(); err == nil {
+		t.Fatal("expected an invalid flush interval to be rejected")
+	}
+	if err := (&Settings{Enabled: true, Encodings: []string{Gzip}, FlushInterval: "1s"}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func serve(t *testing.T, s *Settings, req *http.Request, h http.HandlerFunc) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	Handler(s, h).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	body := strings.Repeat("btfs ", 1000)
+	text := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		for i := 0; i < 10; i++ {
+			w.Write([]byte(body[:len(body)/10]))
+			w.(http.Flusher).Flush()
+		}
+	}
+	s := &Settings{Enabled: true}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/cat", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := serve(t, s, req, text)
+	if rec.Header().Get("Content-Encoding") != Gzip || rec.Body.Len() >= len(body) {
+		t.Fatalf("expected a gzip response, got %v of %d bytes", rec.Header(), rec.Body.Len())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadAll(zr); err != nil || string(b) != body {
+		t.Fatalf("unexpected body: %v", err)
+	}
+
+	req.Header.Set("Accept-Encoding", "zstd")
+	rec = serve(t, s, req, text)
+	if rec.Header().Get("Content-Encoding") != Zstd {
+		t.Fatalf("expected a zstd response, got %v", rec.Header())
+	}
+	dec, err := zstd.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+	if b, err := ioutil.ReadAll(dec); err != nil || string(b) != body {
+		t.Fatalf("unexpected body: %v", err)
+	}
+
+	// ranges, small or compressed content, and disabled settings go as is
+	req.Header.Set("Range", "bytes=0-10")
+	if rec := serve(t, s, req, text); rec.Header().Get("Content-Encoding") != "" {
+		t.Fatal("expected a range response as is")
+	}
+	req.Header.Del("Range")
+	small := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "4")
+		w.Write([]byte("btfs"))
+	}
+	if rec := serve(t, s, req, small); rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != "btfs" {
+		t.Fatal("expected a small response as is")
+	}
+	png := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(body))
+	}
+	if rec := serve(t, s, req, png); rec.Header().Get("Content-Encoding") != "" {
+		t.Fatal("expected an image as is")
+	}
+	if rec := serve(t, &Settings{}, req, text); rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != body {
+		t.Fatal("expected no compression when disabled")
+	}
+}
+
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushCounter) Flush() {
+	f.flushes++
+}
+
+func TestFlushInterval(t *testing.T) {
+	s := &Settings{Enabled: true, FlushInterval: "50ms"}
+	w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/dag/export", nil)
+	Handler(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 100; i++ {
+			w.Write([]byte("block"))
+			w.(http.Flusher).Flush()
+		}
+		// the delayed flush sends the last writes
+		time.Sleep(100 * time.Millisecond)
+	})).ServeHTTP(w, req)
+	if w.flushes != 2 {
+		t.Fatalf("expected the flushes coalesced into 2, got %d", w.flushes)
+	}
+	if w.Body.Len() != 500 {
+		t.Fatalf("expected the response as is, got %d bytes", w.Body.Len())
+	}
+}
@@ -0,0 +1,168 @@
+// Package cors holds the structured CORS settings of the HTTP route groups
+// (API, gateway, wallet) and keeps running servers in sync with them.
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+)
+
+// ConfigKey is the config section holding the per group CORS settings.
+const ConfigKey = "CORS"
+
+// Route groups.
+const (
+	API     = "API"
+	Gateway = "Gateway"
+	Wallet  = "Wallet"
+)
+
+// GroupNames lists all route groups that accept CORS settings.
+var GroupNames = []string{API, Gateway, Wallet}
+
+// Config is the CORS setting of one route group.
+type Config struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowCredentials bool
+}
+
+// Groups holds the CORS settings of every route group. A nil group keeps
+// its defaults (API.HTTPHeaders / Gateway.HTTPHeaders based for the API and
+// the gateway, localhost only for the wallet).
+type Groups struct {
+	API     *Config `json:",omitempty"`
+	Gateway *Config `json:",omitempty"`
+	Wallet  *Config `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Groups{})
+}
+
+// LocalhostOrigins are the origins allowed by default on API route groups.
+// <port> is substituted with the port the server listens on.
+var LocalhostOrigins = []string{
+	"http://127.0.0.1:<port>",
+	"https://127.0.0.1:<port>",
+	"http://localhost:<port>",
+	"https://localhost:<port>",
+}
+
+// Defaults returns the defaults of a route group, used when nothing is set.
+func Defaults(group string) *Config {
+	switch group {
+	case Gateway:
+		return &Config{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{http.MethodGet},
+		}
+	default:
+		return &Config{
+			AllowedOrigins: LocalhostOrigins,
+			AllowedMethods: []string{http.MethodPost},
+		}
+	}
+}
+
+// Load reads the CORS section from the repo config.
+func Load(r repo.Repo) (*Groups, error) {
+	g := new(Groups)
+	if _, err := repo.GetConfigSection(r, ConfigKey, g); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return g, nil
+}
+
+// Save writes the CORS section to the repo config.
+func Save(r repo.Repo, g *Groups) error {
+	return repo.SetConfigSection(r, ConfigKey, g)
+}
+
+// Get returns the settings of a group, nil when the group keeps its defaults.
+func (g *Groups) Get(group string) (*Config, error) {
+	switch group {
+	case API:
+		return g.API, nil
+	case Gateway:
+		return g.Gateway, nil
+	case Wallet:
+		return g.Wallet, nil
+	}
+	return nil, unknownGroup(group)
+}
+
+// Set replaces the settings of a group, nil restores its defaults.
+func (g *Groups) Set(group string, c *Config) error {
+	switch group {
+	case API:
+		g.API = c
+	case Gateway:
+		g.Gateway = c
+	case Wallet:
+		g.Wallet = c
+	default:
+		return unknownGroup(group)
+	}
+	return nil
+}
+
+// Validate checks that methods are known HTTP methods and origins are
+// either "*" or scheme://host[:port] values.
+func (c *Config) Validate() error {
+	for _, m := range c.AllowedMethods {
+		switch strings.ToUpper(m) {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("unsupported method %q", m)
+		}
+	}
+	for _, o := range c.AllowedOrigins {
+		if o != "*" && !strings.Contains(o, "://") {
+			return fmt.Errorf("invalid origin %q, expected \"*\" or scheme://host[:port]", o)
+		}
+	}
+	if c.AllowCredentials {
+		for _, o := range c.AllowedOrigins {
+			if o == "*" {
+				return fmt.Errorf("credentials cannot be allowed for origin \"*\"")
+			}
+		}
+	}
+	return nil
+}
+
+func unknownGroup(group string) error {
+	return fmt.Errorf("unknown CORS route group %q, expected one of %s",
+		group, strings.Join(GroupNames, ", "))
+}
+
+var (
+	appliersMu sync.Mutex
+	appliers   = map[string][]func(*Config){}
+)
+
+// Register adds a callback that applies updated settings of group to a
+// running server. A nil Config passed to the callback means defaults.
+func Register(group string, apply func(*Config)) {
+	appliersMu.Lock()
+	defer appliersMu.Unlock()
+	appliers[group] = append(appliers[group], apply)
+}
+
+// Update pushes new settings of group to all registered servers and
+// reports whether any running server picked them up.
+func Update(group string, c *Config) bool {
+	appliersMu.Lock()
+	defer appliersMu.Unlock()
+	for _, apply := range appliers[group] {
+		apply(c)
+	}
+	return len(appliers[group]) > 0
+}
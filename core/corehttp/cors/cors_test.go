@@ -0,0 +1,56 @@
+package cors
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		c  Config
+		ok bool
+	}{
+		{Config{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}, true},
+		{Config{AllowedOrigins: []string{"http://localhost:<port>"}, AllowedMethods: []string{"post"}, AllowCredentials: true}, true},
+		{Config{AllowedOrigins: []string{"example.com"}, AllowedMethods: []string{"GET"}}, false},
+		{Config{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"FETCH"}}, false},
+		{Config{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}, AllowCredentials: true}, false},
+	}
+	for i, tc := range cases {
+		if err := tc.c.Validate(); (err == nil) != tc.ok {
+			t.Errorf("case %d: expected ok=%t, got %v", i, tc.ok, err)
+		}
+	}
+}
+
+func TestGroupsSetGet(t *testing.T) {
+	g := new(Groups)
+	c := &Config{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}
+	if err := g.Set(Wallet, c); err != nil {
+		t.Fatal(err)
+	}
+	got, err := g.Get(Wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != c {
+		t.Fatal("expected the wallet settings to be stored")
+	}
+	if got, _ := g.Get(API); got != nil {
+		t.Fatal("expected the API group to keep its defaults")
+	}
+	if err := g.Set("Storage", c); err == nil {
+		t.Fatal("expected an unknown group to be rejected")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	if Update(Gateway, nil) {
+		t.Fatal("expected no registered gateway server")
+	}
+	var applied *Config
+	Register(Gateway, func(c *Config) { applied = c })
+	c := Defaults(Gateway)
+	if !Update(Gateway, c) || applied != c {
+		t.Fatal("expected the update to reach the registered server")
+	}
+}
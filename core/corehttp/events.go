@@ -0,0 +1,164 @@
+package corehttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/events"
+	"github.com/TRON-US/go-btfs/core/users"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	cmdsHttp "github.com/TRON-US/go-btfs-cmds/http"
+)
+
+// eventsKeepAlive is how often an idle event stream sends a comment, so the
+// proxies do not close it.
+var eventsKeepAlive = 30 * time.Second
+
+// eventsRetry is how long the browsers wait to reconnect a closed event
+// stream, in milliseconds.
+const eventsRetry = 3000
+
+// eventsMissed is the event sent first to a client resuming after events it
+// missed: those no longer kept, or published before the node restarted.
+const eventsMissed = "missed"
+
+// topicCommands are the commands whose scope a user needs to receive the
+// events of a topic.
+var topicCommands = map[string][]string{
+	events.TopicUpload: {"storage", "upload", "status"},
+	events.TopicWallet: {"wallet", "transactions"},
+}
+
+// eventStreams serves the event streams requested at the API events path
+// with sse, and the 'btfs events' command with cmd. The browsers'
+// EventSource cannot set headers, so the API token of sse may be given in
+// the access_token query parameter instead.
+func eventStreams(sse, cmd http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			cmd.ServeHTTP(w, r)
+			return
+		}
+		q := r.URL.Query()
+		if token := q.Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+			q.Del("access_token")
+			r.URL.RawQuery = q.Encode()
+		}
+		sse.ServeHTTP(w, r)
+	})
+}
+
+// eventsHandler streams the events of h as Server-Sent Events, to the
+// origins allowed by cfg. The topic query parameters select the topics, all
+// of those the caller may receive by default. A client reconnecting with
+// the Last-Event-ID header, or the lastEventId query parameter, resumes
+// after that event.
+func eventsHandler(cfg *cmdsHttp.ServerConfig, h *events.Hub) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if !eventsOriginAllowed(cfg, origin) {
+				writeCmdsError(w, http.StatusForbidden, "origin not allowed", cmds.ErrForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
+		f, ok := w.(http.Flusher)
+		if !ok {
+			writeCmdsError(w, http.StatusInternalServerError, "streaming not supported", cmds.ErrNormal)
+			return
+		}
+
+		q := r.URL.Query()
+		var topics []string
+		for _, t := range q["topic"] {
+			for _, t := range strings.Split(t, ",") {
+				if _, ok := topicCommands[t]; !ok {
+					writeCmdsError(w, http.StatusBadRequest, fmt.Sprintf("unknown topic %q", t), cmds.ErrClient)
+					return
+				}
+				topics = append(topics, t)
+			}
+		}
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = q.Get("lastEventId")
+		}
+		u := users.FromContext(r.Context())
+		allowed := func(e *events.Event) bool {
+			return u == nil || u.Role.Allows(users.CommandScope(topicCommands[e.Topic]))
+		}
+
+		s, missed, complete := h.Subscribe(lastID, topics...)
+		defer s.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		// nginx buffers the responses otherwise
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "retry: %d\n\n", eventsRetry)
+		if !complete {
+			fmt.Fprintf(w, "event: %s\ndata: %q\n\n", eventsMissed, lastID)
+		}
+		for _, e := range missed {
+			if allowed(e) {
+				writeEvent(w, e)
+			}
+		}
+		f.Flush()
+
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case e, ok := <-s.Events():
+				if !ok {
+					// dropped for falling behind, the client reconnects
+					// and resumes
+					return
+				}
+				if !allowed(e) {
+					continue
+				}
+				if err := writeEvent(w, e); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+			f.Flush()
+		}
+	})
+}
+
+// writeEvent writes e to w in the event stream format, its data being the
+// whole event encoded in a line of JSON.
+func writeEvent(w http.ResponseWriter, e *events.Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Topic, b)
+	return err
+}
+
+// eventsOriginAllowed reports whether the API allows the requests of origin,
+// as set in API.HTTPHeaders or the CORS config.
+func eventsOriginAllowed(cfg *cmdsHttp.ServerConfig, origin string) bool {
+	for _, o := range cfg.AllowedOrigins() {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,8 @@ import (
 	version "github.com/TRON-US/go-btfs"
 	core "github.com/TRON-US/go-btfs/core"
 	coreapi "github.com/TRON-US/go-btfs/core/coreapi"
+	"github.com/TRON-US/go-btfs/core/corehttp/cors"
+	"github.com/TRON-US/go-btfs/core/dek"
 	"github.com/Workiva/go-datastructures/cache"
 
 	options "github.com/TRON-US/interface-go-btfs-core/options"
@@ -53,46 +55,29 @@ func GatewayOption(writable bool, paths ...string) ServeOption {
 			return nil, err
 		}
 
-		headers := make(map[string][]string, len(cfg.Gateway.HTTPHeaders))
-		for h, v := range cfg.Gateway.HTTPHeaders {
-			headers[http.CanonicalHeaderKey(h)] = v
+		groups, err := cors.Load(n.Repo)
+		if err != nil {
+			return nil, err
 		}
+		headers := gatewayHeaders(cfg.Gateway.HTTPHeaders, groups.Gateway)
 
-		// Hard-coded headers.
-		const ACAHeadersName = "Access-Control-Allow-Headers"
-		const ACEHeadersName = "Access-Control-Expose-Headers"
-		const ACAOriginName = "Access-Control-Allow-Origin"
-		const ACAMethodsName = "Access-Control-Allow-Methods"
-
-		if _, ok := headers[ACAOriginName]; !ok {
-			// Default to *all*
-			headers[ACAOriginName] = []string{"*"}
-		}
-		if _, ok := headers[ACAMethodsName]; !ok {
-			// Default to GET
-			headers[ACAMethodsName] = []string{http.MethodGet}
+		keys, err := dek.ForNode(n)
+		if err != nil {
+			log.Warnf("access grants disabled: %s", err)
+			keys = nil
 		}
 
-		headers[ACAHeadersName] = cleanHeaderSet(
-			append([]string{
-				"Content-Type",
-				"User-Agent",
-				"Range",
-				"X-Requested-With",
-			}, headers[ACAHeadersName]...))
-
-		headers[ACEHeadersName] = cleanHeaderSet(
-			append([]string{
-				"Content-Range",
-				"X-Chunked-Output",
-				"X-Stream-Output",
-			}, headers[ACEHeadersName]...))
-
 		gateway := newGatewayHandler(GatewayConfig{
 			Headers:      headers,
 			Writable:     writable,
 			PathPrefixes: cfg.Gateway.PathPrefixes,
-		}, api, cache.New(GatewayReedSolomonDirectoryCacheCapacity))
+		}, api, cache.New(GatewayReedSolomonDirectoryCacheCapacity), keys)
+
+		// the structured CORS settings are applied live
+		base := cfg.Gateway.HTTPHeaders
+		cors.Register(cors.Gateway, func(c *cors.Config) {
+			gateway.setHeaders(gatewayHeaders(base, c))
+		})
 
 		for _, p := range paths {
 			mux.Handle(p+"/", gateway)
@@ -101,6 +86,58 @@ func GatewayOption(writable bool, paths ...string) ServeOption {
 	}
 }
 
+// gatewayHeaders returns the headers of the gateway responses: the
+// Gateway.HTTPHeaders, overridden by the structured CORS settings c when
+// set, and the CORS defaults of the gateway.
+func gatewayHeaders(httpHeaders map[string][]string, c *cors.Config) map[string][]string {
+	headers := make(map[string][]string, len(httpHeaders))
+	for h, v := range httpHeaders {
+		headers[http.CanonicalHeaderKey(h)] = v
+	}
+
+	// Hard-coded headers.
+	const ACAHeadersName = "Access-Control-Allow-Headers"
+	const ACEHeadersName = "Access-Control-Expose-Headers"
+	const ACAOriginName = "Access-Control-Allow-Origin"
+	const ACAMethodsName = "Access-Control-Allow-Methods"
+	const ACACredentialsName = "Access-Control-Allow-Credentials"
+
+	if c != nil {
+		// Structured CORS settings take precedence over HTTPHeaders
+		headers[ACAOriginName] = c.AllowedOrigins
+		headers[ACAMethodsName] = c.AllowedMethods
+		delete(headers, ACACredentialsName)
+		if c.AllowCredentials {
+			headers[ACACredentialsName] = []string{"true"}
+		}
+	}
+
+	if _, ok := headers[ACAOriginName]; !ok {
+		// Default to *all*
+		headers[ACAOriginName] = []string{"*"}
+	}
+	if _, ok := headers[ACAMethodsName]; !ok {
+		// Default to GET
+		headers[ACAMethodsName] = []string{http.MethodGet}
+	}
+
+	headers[ACAHeadersName] = cleanHeaderSet(
+		append([]string{
+			"Content-Type",
+			"User-Agent",
+			"Range",
+			"X-Requested-With",
+		}, headers[ACAHeadersName]...))
+
+	headers[ACEHeadersName] = cleanHeaderSet(
+		append([]string{
+			"Content-Range",
+			"X-Chunked-Output",
+			"X-Stream-Output",
+		}, headers[ACEHeadersName]...))
+	return headers
+}
+
 func VersionOption() ServeOption {
 	return func(_ *core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
 		mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
@@ -2,6 +2,7 @@ package corehttp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -13,10 +14,12 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	files "github.com/TRON-US/go-btfs-files"
 	"github.com/TRON-US/go-btfs/assets"
+	"github.com/TRON-US/go-btfs/core/dek"
 	mfs "github.com/TRON-US/go-mfs"
 	coreiface "github.com/TRON-US/interface-go-btfs-core"
 	ipath "github.com/TRON-US/interface-go-btfs-core/path"
@@ -41,8 +44,12 @@ const (
 // (it serves requests like GET /btfs/QmVRzPKPzNtSrEzBFm2UZfxmPAgnaLke4DMcerbsGGSaFe/link)
 type gatewayHandler struct {
 	config GatewayConfig
-	api    coreiface.CoreAPI
-	rsDirs cache.Cache
+	// headersMu guards config.Headers, updated with the CORS settings.
+	headersMu sync.RWMutex
+	api       coreiface.CoreAPI
+	rsDirs    cache.Cache
+	// keys verifies access grants, nil when they are disabled.
+	keys *dek.Manager
 }
 
 type ReedSolomonDirectory struct {
@@ -75,11 +82,12 @@ func (sw *statusResponseWriter) WriteHeader(code int) {
 	sw.ResponseWriter.WriteHeader(code)
 }
 
-func newGatewayHandler(c GatewayConfig, api coreiface.CoreAPI, dirs cache.Cache) *gatewayHandler {
+func newGatewayHandler(c GatewayConfig, api coreiface.CoreAPI, dirs cache.Cache, keys *dek.Manager) *gatewayHandler {
 	i := &gatewayHandler{
 		config: c,
 		api:    api,
 		rsDirs: dirs,
+		keys:   keys,
 	}
 	return i
 }
@@ -359,7 +367,20 @@ func (i *gatewayHandler) getOrHeadHandler(w http.ResponseWriter, r *http.Request
 	modtime := time.Now()
 
 	if f, ok := dr.(files.File); ok {
-		if strings.HasPrefix(urlPath, ipfsPathPrefix) {
+		if token := r.URL.Query().Get("grant"); token != "" {
+			f, err = i.openGranted(r.Context(), resolvedPath, f, token)
+			switch err {
+			case nil:
+			case dek.ErrInvalidGrant, dek.ErrGrantExpired:
+				webError(w, "btfs access grant", err, http.StatusForbidden)
+				return
+			default:
+				internalWebError(w, err)
+				return
+			}
+			// the decrypted content must not end up in shared caches
+			w.Header().Set("Cache-Control", "private, no-store")
+		} else if strings.HasPrefix(urlPath, ipfsPathPrefix) {
 			w.Header().Set("Cache-Control", "public, max-age=29030400, immutable")
 
 			// set modtime to a really long time ago, since files are immutable and should stay cached
@@ -555,6 +576,34 @@ func (i *gatewayHandler) serveFile(w http.ResponseWriter, req *http.Request, nam
 	http.ServeContent(w, req, name, modtime, content)
 }
 
+// openGranted returns the file f at p decrypted, if token is an access grant
+// to it issued by this node.
+func (i *gatewayHandler) openGranted(ctx context.Context, p ipath.Resolved, f files.File, token string) (files.File, error) {
+	if i.keys == nil {
+		return nil, errors.New("access grants are disabled on this gateway")
+	}
+	g, err := i.keys.VerifyGrant(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if g.Cid != p.Cid().String() {
+		return nil, dek.ErrInvalidGrant
+	}
+	b, err := i.api.Unixfs().GetMetadata(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	meta, ok := dek.ParseMeta(b)
+	if !ok || meta.Dek != g.Dek || meta.DekVersion != g.Version {
+		return nil, dek.ErrInvalidGrant
+	}
+	key, err := i.keys.Key(ctx, meta.Dek, meta.DekVersion)
+	if err != nil {
+		return nil, err
+	}
+	return dek.OpenFile(key, f)
+}
+
 func (i *gatewayHandler) servePretty404IfPresent(w http.ResponseWriter, r *http.Request, parsedPath ipath.Path) bool {
 	resolved404Path, ctype, err := i.searchUpTreeFor404(r, parsedPath)
 	if err != nil {
@@ -762,11 +811,20 @@ func (i *gatewayHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (i *gatewayHandler) addUserHeaders(w http.ResponseWriter) {
+	i.headersMu.RLock()
+	defer i.headersMu.RUnlock()
 	for k, v := range i.config.Headers {
 		w.Header()[k] = v
 	}
 }
 
+// setHeaders replaces the headers added to the responses.
+func (i *gatewayHandler) setHeaders(headers map[string][]string) {
+	i.headersMu.Lock()
+	defer i.headersMu.Unlock()
+	i.config.Headers = headers
+}
+
 func (i *gatewayHandler) cacheEntryFor(p string) (*ReedSolomonDirectory, bool, error) {
 	v := i.rsDirs.Get(p)
 	if v[0] == nil {
@@ -13,12 +13,15 @@ import (
 	version "github.com/TRON-US/go-btfs"
 	core "github.com/TRON-US/go-btfs/core"
 	"github.com/TRON-US/go-btfs/core/coreapi"
+	"github.com/TRON-US/go-btfs/core/corehttp/cors"
+	"github.com/TRON-US/go-btfs/core/dek"
 	namesys "github.com/TRON-US/go-btfs/namesys"
 	repo "github.com/TRON-US/go-btfs/repo"
 
 	config "github.com/TRON-US/go-btfs-config"
 	files "github.com/TRON-US/go-btfs-files"
 	iface "github.com/TRON-US/interface-go-btfs-core"
+	"github.com/TRON-US/interface-go-btfs-core/options"
 	nsopts "github.com/TRON-US/interface-go-btfs-core/options/namesys"
 	ipath "github.com/TRON-US/interface-go-btfs-core/path"
 	datastore "github.com/ipfs/go-datastore"
@@ -680,3 +683,112 @@ func TestVersion(t *testing.T) {
 		t.Fatalf("response doesn't contain protocol version:\n%s", s)
 	}
 }
+
+func TestGatewayAccessGrant(t *testing.T) {
+	n, err := newNodeWithMockNamesys(mockNamesys{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.PrivateKey, _, err = ci.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dh := &delegatedHandler{}
+	ts := httptest.NewServer(dh)
+	defer ts.Close()
+	dh.Handler, err = makeHandler(n, ts.Listener, GatewayOption(false, "/btfs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := coreapi.NewCoreAPI(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := n.Context()
+	keys, err := dek.ForNode(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, v, err := keys.CurrentKey(ctx, "photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := dek.Seal(key, []byte("fnord"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := (&dek.Meta{Dek: "photos", DekVersion: v}).AppendTo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := api.Unixfs().Add(ctx, files.NewBytesFile(sealed), options.Unixfs.TokenMetadata(meta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	grant := func(c string, expires time.Time) string {
+		token, err := keys.IssueGrant(ctx, &dek.Grant{Dek: "photos", Version: v, Cid: c, Expires: expires})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	for _, test := range []struct {
+		name  string
+		query string
+		code  int
+		body  string
+	}{
+		{"no grant", "", http.StatusOK, string(sealed)},
+		{"grant", "?grant=" + grant(p.Cid().String(), time.Now().Add(time.Hour)), http.StatusOK, "fnord"},
+		{"expired", "?grant=" + grant(p.Cid().String(), time.Now().Add(-time.Hour)), http.StatusForbidden, ""},
+		{"other file", "?grant=" + grant(emptyDir[len("/btfs/"):], time.Now().Add(time.Hour)), http.StatusForbidden, ""},
+		{"garbage", "?grant=nope", http.StatusForbidden, ""},
+	} {
+		res, err := http.Get(ts.URL + p.String() + test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if res.StatusCode != test.code {
+			t.Errorf("%s: got code %d: %s", test.name, res.StatusCode, body)
+			continue
+		}
+		if test.body != "" && string(body) != test.body {
+			t.Errorf("%s: got %q", test.name, body)
+		}
+		if test.query != "" && test.code == http.StatusOK && res.Header.Get("Cache-Control") != "private, no-store" {
+			t.Errorf("%s: decrypted content is cacheable: %s", test.name, res.Header.Get("Cache-Control"))
+		}
+	}
+}
+
+func TestGatewayCORSUpdate(t *testing.T) {
+	base := map[string][]string{"access-control-allow-origin": {"https://a.example"}}
+	i := newGatewayHandler(GatewayConfig{Headers: gatewayHeaders(base, nil)}, nil, nil, nil)
+	origin := func() string {
+		w := httptest.NewRecorder()
+		i.addUserHeaders(w)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+	if got := origin(); got != "https://a.example" {
+		t.Fatalf("expected the origin of Gateway.HTTPHeaders, got %q", got)
+	}
+	i.setHeaders(gatewayHeaders(base, &cors.Config{
+		AllowedOrigins:   []string{"https://b.example"},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowCredentials: true,
+	}))
+	if got := origin(); got != "https://b.example" {
+		t.Fatalf("expected the origin of the CORS settings, got %q", got)
+	}
+	i.setHeaders(gatewayHeaders(nil, nil))
+	if got := origin(); got != "*" {
+		t.Fatalf("expected the default origin, got %q", got)
+	}
+}
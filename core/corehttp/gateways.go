@@ -0,0 +1,121 @@
+package corehttp
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	core "github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// GatewaysConfigKey is the config section holding the named gateways, e.g.
+//
+//  btfs config --json Gateways.public '{"Address": "/ip4/0.0.0.0/tcp/8081"}'
+const GatewaysConfigKey = "Gateways"
+
+// NamedGatewayConfig configures one extra gateway listener. Every named
+// gateway is served on its own address next to Addresses.Gateway.
+type NamedGatewayConfig struct {
+	// Address is the multiaddr to listen on.
+	Address string
+
+	// Writable enables PUT/POST/DELETE on this gateway.
+	Writable bool
+
+	// AllowedCIDs restricts the gateway to content under the listed roots.
+	// An empty list serves everything; BTNS paths are refused otherwise.
+	AllowedCIDs []string
+
+	// AuthTokens, when set, requires an "Authorization: Bearer <token>"
+	// header matching one of the listed tokens.
+	AuthTokens []string
+}
+
+func init() {
+	configschema.RegisterSection(GatewaysConfigKey, map[string]NamedGatewayConfig{})
+}
+
+// NamedGateways returns the named gateways from the repo config.
+func NamedGateways(r repo.Repo) (map[string]NamedGatewayConfig, error) {
+	gws := map[string]NamedGatewayConfig{}
+	if _, err := repo.GetConfigSection(r, GatewaysConfigKey, &gws); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", GatewaysConfigKey, err)
+	}
+	for name, gw := range gws {
+		if gw.Address == "" {
+			return nil, fmt.Errorf("gateway %q: missing Address", name)
+		}
+		for _, c := range gw.AllowedCIDs {
+			if _, err := cid.Decode(c); err != nil {
+				return nil, fmt.Errorf("gateway %q: invalid allowed cid %q: %s", name, c, err)
+			}
+		}
+	}
+	return gws, nil
+}
+
+// GatewayAccessOption enforces the auth tokens and the CID allowlist of a
+// named gateway before handing requests to the following options.
+func GatewayAccessOption(gc NamedGatewayConfig) ServeOption {
+	return func(_ *core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
+		allowed := make(map[string]struct{}, len(gc.AllowedCIDs))
+		for _, s := range gc.AllowedCIDs {
+			c, err := cid.Decode(s)
+			if err != nil {
+				return nil, err
+			}
+			// Key on the multihash so CIDv0 and CIDv1 of a root match
+			allowed[string(c.Hash())] = struct{}{}
+		}
+
+		childMux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if len(gc.AuthTokens) > 0 && !validGatewayToken(r, gc.AuthTokens) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if len(allowed) > 0 && !allowedGatewayPath(r.URL.Path, allowed) {
+				http.Error(w, "content not served by this gateway", http.StatusForbidden)
+				return
+			}
+			childMux.ServeHTTP(w, r)
+		})
+		return childMux, nil
+	}
+}
+
+func validGatewayToken(r *http.Request, tokens []string) bool {
+	auth := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(auth, prefix) {
+		return false
+	}
+	got := []byte(strings.TrimPrefix(auth, prefix))
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare(got, []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func allowedGatewayPath(p string, allowed map[string]struct{}) bool {
+	parts := strings.SplitN(strings.TrimPrefix(p, "/"), "/", 3)
+	if len(parts) < 2 || parts[0] != "btfs" {
+		// Only the version endpoint is reachable outside of /btfs
+		return p == "/version"
+	}
+	c, err := cid.Decode(parts[1])
+	if err != nil {
+		return false
+	}
+	_, ok := allowed[string(c.Hash())]
+	return ok
+}
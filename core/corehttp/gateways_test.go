@@ -0,0 +1,54 @@
+package corehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGatewayAccessOption(t *testing.T) {
+	const (
+		allowedV0 = "QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH"
+		// CIDv1 of the same multihash
+		allowedV1 = "bafybeif7ztnhq65lumvvtr4ekcwd2ifwgm3awq4zfr3srh462rwyinlb4y"
+		other     = "QmaG4FuMqEBnQNn3C8XJ5bpW8kLs7zq2ZXgHptJHbKDDVx"
+	)
+	gc := NamedGatewayConfig{
+		Address:     "/ip4/127.0.0.1/tcp/0",
+		AllowedCIDs: []string{allowedV0},
+		AuthTokens:  []string{"secret"},
+	}
+
+	tcs := []struct {
+		uri   string
+		token string
+		code  int
+	}{
+		{"/btfs/" + allowedV0 + "/a.txt", "secret", http.StatusOK},
+		{"/btfs/" + allowedV1, "secret", http.StatusOK},
+		{"/btfs/" + other, "secret", http.StatusForbidden},
+		{"/btns/example.com", "secret", http.StatusForbidden},
+		{"/version", "secret", http.StatusOK},
+		{"/btfs/" + allowedV0, "", http.StatusUnauthorized},
+		{"/btfs/" + allowedV0, "wrong", http.StatusUnauthorized},
+	}
+
+	for _, tc := range tcs {
+		root := http.NewServeMux()
+		mux, err := GatewayAccessOption(gc)(nil, nil, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+		r := httptest.NewRequest(http.MethodGet, tc.uri, nil)
+		if tc.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		root.ServeHTTP(w, r)
+		if w.Code != tc.code {
+			t.Errorf("%s: expected code %d but got %d", tc.uri, tc.code, w.Code)
+		}
+	}
+}
@@ -6,8 +6,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	version "github.com/TRON-US/go-btfs"
+	"github.com/TRON-US/go-btfs/core/apps"
+	"github.com/TRON-US/go-btfs/core/protover"
+	"github.com/TRON-US/go-btfs/core/users"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 )
 
 type testcasecheckversion struct {
@@ -75,3 +82,117 @@ func TestCheckVersionOption(t *testing.T) {
 		}
 	}
 }
+
+func TestProtocolVersionOption(t *testing.T) {
+	defer func(r protover.Range) { protover.Supported[protover.RenterHost] = r }(protover.Supported[protover.RenterHost])
+	protover.Supported[protover.RenterHost] = protover.Range{Min: 1, Max: 3}
+
+	for _, tc := range []struct {
+		announce string
+		version  int // 0 when rejected
+	}{
+		{"", 1},
+		{"2-5", 3},
+		{"2", 2},
+		{"4-5", 0},
+	} {
+		r := httptest.NewRequest(http.MethodPost, APIPath+"/storage/upload/init", nil)
+		if tc.announce != "" {
+			r.Header.Set(protover.Header, tc.announce)
+		}
+
+		negotiated := 0
+		root := http.NewServeMux()
+		mux, err := ProtocolVersionOption()(nil, nil, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			negotiated = protover.FromContext(r.Context(), protover.RenterHost)
+		})
+
+		w := httptest.NewRecorder()
+		root.ServeHTTP(w, r)
+
+		if negotiated != tc.version {
+			t.Errorf("announced %q: handled with version %d, want %d", tc.announce, negotiated, tc.version)
+		}
+		if got := w.Header().Get(protover.Header); got != "1-3" {
+			t.Errorf("announced %q: got versions %q", tc.announce, got)
+		}
+		if tc.version == 0 && w.Code != http.StatusBadRequest {
+			t.Errorf("announced %q: got code %d", tc.announce, w.Code)
+		}
+	}
+}
+
+func TestUsersHandler(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	s := users.NewStore(d, "QmPeer")
+	as := apps.NewStore(d, "QmPeer")
+
+	var caller *users.User
+	var app *apps.App
+	called := false
+	h := usersHandler(s, as, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		caller = users.FromContext(r.Context())
+		app = apps.FromContext(r.Context())
+	}))
+	serve := func(uri, remote, token string) int {
+		called, caller, app = false, nil, nil
+		r := httptest.NewRequest(http.MethodPost, uri, nil)
+		r.RemoteAddr = remote
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// no accounts, the mode is off
+	if code := serve(APIPath+"/config", "192.0.2.1:1234", ""); code != http.StatusOK || !called {
+		t.Fatalf("mode off: got code %d", code)
+	}
+
+	// the token of an application only runs its commands, in any mode
+	_, appToken, err := as.Add("dapp", 0, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(APIPath+"/files/ls", "192.0.2.1:1234", appToken); code != http.StatusOK || app == nil || app.ID != "dapp" {
+		t.Fatalf("app files ls: got code %d, app %v", code, app)
+	}
+	if code := serve(APIPath+"/config", "127.0.0.1:1234", appToken); code != http.StatusForbidden || called {
+		t.Fatalf("app config: got code %d", code)
+	}
+
+	_, token, err := s.Add("alice", users.Viewer, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		uri, remote, token string
+		code               int
+	}{
+		{APIPath + "/config", "127.0.0.1:1234", "", http.StatusOK},
+		{APIPath + "/config", "192.0.2.1:1234", "", http.StatusUnauthorized},
+		{APIPath + "/cat", "192.0.2.1:1234", "bad", http.StatusUnauthorized},
+		{APIPath + "/cat", "192.0.2.1:1234", token, http.StatusOK},
+		{APIPath + "/files/ls", "192.0.2.1:1234", token, http.StatusOK},
+		{APIPath + "/add", "192.0.2.1:1234", token, http.StatusForbidden},
+		{APIPath + "/config", "127.0.0.1:1234", token, http.StatusForbidden},
+	} {
+		code := serve(tc.uri, tc.remote, tc.token)
+		if code != tc.code {
+			t.Errorf("%s from %s: got code %d, want %d", tc.uri, tc.remote, code, tc.code)
+		}
+		if called != (tc.code == http.StatusOK) {
+			t.Errorf("%s from %s: handler called %v", tc.uri, tc.remote, called)
+		}
+		if tc.token == token && called && (caller == nil || caller.Name != "alice") {
+			t.Errorf("%s: called by %v", tc.uri, caller)
+		}
+	}
+}
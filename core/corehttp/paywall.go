@@ -0,0 +1,107 @@
+package corehttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	core "github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/paywall"
+	"github.com/TRON-US/go-btfs/core/wallet"
+)
+
+// maxPaymentSize bounds the body of a paywall payment.
+const maxPaymentSize = 64 << 10
+
+// PaywallOption answers 402 Payment Required to the requests of the content
+// priced in the Paywall config section, unless they carry a valid access
+// token, and serves the payments issuing them, see the paywall package.
+func PaywallOption() ServeOption {
+	return func(n *core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
+		c, err := paywall.Load(n.Repo)
+		if err != nil {
+			return nil, err
+		}
+		if !c.Enabled {
+			return mux, nil
+		}
+		if n.PrivateKey == nil {
+			return nil, fmt.Errorf("the paywall requires the identity key of the node")
+		}
+		publisher := n.Identity.Pretty()
+
+		mux.HandleFunc(paywall.PayPath, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			p := &wallet.PaywallPayment{}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPaymentSize)).Decode(p); err != nil {
+				http.Error(w, "invalid paywall payment: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			i, err := p.ParseIntent()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			ip, err := paywall.CanonicalPath(i.Path)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			rule := c.Match(ip)
+			if rule == nil || rule.Path != ip {
+				http.Error(w, fmt.Sprintf("no price for %s", i.Path), http.StatusNotFound)
+				return
+			}
+			cfg, err := n.Repo.Config()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			now := time.Now()
+			if _, err := wallet.AcceptPaywallPayment(r.Context(), n, cfg, p, rule.Path, rule.Price, now); err != nil {
+				http.Error(w, "payment rejected: "+err.Error(), http.StatusPaymentRequired)
+				return
+			}
+			g := &paywall.Grant{Path: rule.Path, Payer: i.Payer, Expiry: now.Add(rule.Duration())}
+			token, err := paywall.Issue(n.PrivateKey, g)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			log.Infof("paywall: %s paid %d µBTT for %s", i.Payer, i.Amount, rule.Path)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&paywall.Access{Path: g.Path, Token: token, Expiry: g.Expiry})
+		})
+
+		childMux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			// an invalid root is left to the gateway to refuse
+			p, err := paywall.CanonicalPath(r.URL.Path)
+			if err != nil {
+				childMux.ServeHTTP(w, r)
+				return
+			}
+			rule := c.Match(p)
+			if rule == nil {
+				childMux.ServeHTTP(w, r)
+				return
+			}
+			if token := paywall.Token(r); token != "" {
+				g, err := paywall.Verify(n.PrivateKey.GetPublic(), token, time.Now())
+				if err == nil && g.Path == rule.Path {
+					childMux.ServeHTTP(w, r)
+					return
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusPaymentRequired)
+			json.NewEncoder(w).Encode(paywall.NewTerms(publisher, rule))
+		})
+		return childMux, nil
+	}
+}
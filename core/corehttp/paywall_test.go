@@ -0,0 +1,100 @@
+package corehttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/coreapi"
+	"github.com/TRON-US/go-btfs/core/paywall"
+	repo "github.com/TRON-US/go-btfs/repo"
+
+	files "github.com/TRON-US/go-btfs-files"
+	cid "github.com/ipfs/go-cid"
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// paywallRepo holds the Paywall config section the mock repo cannot.
+type paywallRepo struct {
+	repo.Repo
+	c *paywall.Config
+}
+
+func (r *paywallRepo) GetConfigKey(key string) (interface{}, error) {
+	if key == paywall.ConfigKey {
+		return r.c, nil
+	}
+	return r.Repo.GetConfigKey(key)
+}
+
+func TestPaywallCidV1(t *testing.T) {
+	n, err := newNodeWithMockNamesys(mockNamesys{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.PrivateKey, _, err = ci.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := coreapi.NewCoreAPI(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := api.Unixfs().Add(n.Context(), files.NewBytesFile([]byte("fnord")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v0 := p.Cid().String()
+	v1 := cid.NewCidV1(p.Cid().Type(), p.Cid().Hash()).String()
+
+	// priced with the CIDv0, requested with the CIDv1
+	n.Repo = &paywallRepo{Repo: n.Repo, c: &paywall.Config{
+		Enabled: true,
+		Rules:   []*paywall.Rule{{Path: "/btfs/" + v0, Price: 10}},
+	}}
+	dh := &delegatedHandler{}
+	ts := httptest.NewServer(dh)
+	defer ts.Close()
+	dh.Handler, err = makeHandler(n, ts.Listener, PaywallOption(), GatewayOption(false, "/btfs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := paywall.Issue(n.PrivateKey, &paywall.Grant{
+		Path:   "/btfs/" + v1,
+		Payer:  "payer",
+		Expiry: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		path  string
+		token string
+		code  int
+	}{
+		{"/btfs/" + v1, "", http.StatusPaymentRequired},
+		{"/btfs/" + v0, "", http.StatusPaymentRequired},
+		{"/btfs/" + v1, token, http.StatusOK},
+		{"/btfs/" + v0, token, http.StatusOK},
+	} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.token != "" {
+			req.Header.Set(paywall.TokenHeader, test.token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != test.code {
+			t.Errorf("%s: expected code %d, got %d: %s", test.path, test.code, res.StatusCode, body)
+		}
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/protover"
 
 	iface "github.com/TRON-US/interface-go-btfs-core"
 
@@ -85,6 +87,7 @@ func (r *P2PRemoteCall) CallGet(ctx context.Context, api string, args []interfac
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set(protover.Header, protover.Supported[protover.RenterHost].String())
 	// libp2p protocol register
 	tr := &http.Transport{}
 	tr.RegisterProtocol("libp2p",
@@ -97,6 +100,16 @@ func (r *P2PRemoteCall) CallGet(ctx context.Context, api string, args []interfac
 	}
 
 	defer resp.Body.Close()
+	// Peers from before the negotiation announce nothing, and are compatible
+	// as long as this node supports the legacy version.
+	if _, err := protover.NegotiateAnnounced(protover.RenterHost, resp.Header.Get(protover.Header)); err != nil {
+		if _, ok := err.(*protover.ErrIncompatible); ok {
+			incompatiblePeers.Store(r.ID, err)
+			return nil, err
+		}
+	} else {
+		incompatiblePeers.Delete(r.ID)
+	}
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		var tmp IoError = fmt.Errorf("fail to read response body: %s", err)
@@ -113,6 +126,20 @@ func (r *P2PRemoteCall) CallGet(ctx context.Context, api string, args []interfac
 	return body, nil
 }
 
+// incompatiblePeers are the peers which support none of the versions of the
+// renter-host protocol of the node, until the node restarts.
+var incompatiblePeers sync.Map
+
+// Incompatible returns the ErrIncompatible of the last call to pid, if the
+// peer supports none of the versions of the renter-host protocol of the
+// node, so that it can be skipped.
+func Incompatible(pid peer.ID) error {
+	if err, ok := incompatiblePeers.Load(pid); ok {
+		return err.(error)
+	}
+	return nil
+}
+
 type IoError error
 type BusinessError error
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/coreunix"
 	"github.com/TRON-US/go-btfs/gc"
 	"github.com/TRON-US/go-btfs/repo"
 
@@ -83,12 +84,24 @@ func BestEffortRoots(filesRoot *mfs.Root) ([]cid.Cid, error) {
 	return []cid.Cid{rootDag.Cid()}, nil
 }
 
-func GarbageCollect(n *core.IpfsNode, ctx context.Context) error {
+// gcRoots returns the best-effort roots for a GC run on n: the MFS root
+// plus every block staged by an open add session. It is called by GC once
+// the GC lock is held, when the adds holding the pin lock have recorded
+// their blocks in their sessions.
+func gcRoots(ctx context.Context, n *core.IpfsNode) ([]cid.Cid, error) {
 	roots, err := BestEffortRoots(n.FilesRoot)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	rmed := gc.GC(ctx, n.Blockstore, n.Repo.Datastore(), n.Pinning, roots)
+	staged, err := coreunix.AddSessionCids(ctx, n.Repo.Datastore())
+	if err != nil {
+		return nil, err
+	}
+	return append(roots, staged...), nil
+}
+
+func GarbageCollect(n *core.IpfsNode, ctx context.Context) error {
+	rmed := GarbageCollectAsync(n, ctx)
 
 	return CollectResult(ctx, rmed, nil)
 }
@@ -148,15 +161,10 @@ func (e *MultiError) Error() string {
 }
 
 func GarbageCollectAsync(n *core.IpfsNode, ctx context.Context) <-chan gc.Result {
-	roots, err := BestEffortRoots(n.FilesRoot)
-	if err != nil {
-		out := make(chan gc.Result)
-		out <- gc.Result{Error: err}
-		close(out)
-		return out
-	}
-
-	return gc.GC(ctx, n.Blockstore, n.Repo.Datastore(), n.Pinning, roots)
+	return gc.GCWithRoots(ctx, n.Blockstore, n.Repo.Datastore(), n.Pinning,
+		func(ctx context.Context) ([]cid.Cid, error) {
+			return gcRoots(ctx, n)
+		})
 }
 
 func PeriodicGC(ctx context.Context, node *core.IpfsNode) error {
@@ -0,0 +1,96 @@
+package corerepo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/coreunix"
+	"github.com/TRON-US/go-btfs/gc"
+
+	cid "github.com/ipfs/go-cid"
+	dshelp "github.com/ipfs/go-ipfs-ds-help"
+)
+
+// GarbageCollectDryRun reports what a garbage collection of n would remove,
+// broken down by the subsystems groups. The blocks kept by MFS and by
+// staged add sessions are reported too. Ages are read from the flatfs
+// blockstore of the repo at repoPath, if any. onRemove, if not nil, is
+// called with every block GC would remove.
+func GarbageCollectDryRun(ctx context.Context, n *core.IpfsNode, repoPath string, subsystems gc.Groups,
+	onRemove func(cid.Cid)) (*gc.Report, error) {
+	mfsRoots, err := BestEffortRoots(n.FilesRoot)
+	if err != nil {
+		return nil, err
+	}
+	staged, err := coreunix.AddSessionCids(ctx, n.Repo.Datastore())
+	if err != nil {
+		return nil, err
+	}
+	written := func(cid.Cid) (time.Time, bool) { return time.Time{}, false }
+	cfg, err := n.Repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	if dir := flatfsPath(cfg.Datastore.Spec); dir != "" {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(repoPath, dir)
+		}
+		times, err := blockWriteTimes(dir)
+		if err != nil {
+			log.Warnf("cannot read the age of blocks: %s", err)
+		} else {
+			written = func(c cid.Cid) (time.Time, bool) {
+				t, ok := times[dshelp.CidToDsKey(c).String()[1:]]
+				return t, ok
+			}
+		}
+	}
+	return gc.DryRun(ctx, n.Blockstore, n.Pinning,
+		gc.Groups{"mfs": mfsRoots, "staged adds": staged}, subsystems, written, onRemove)
+}
+
+// flatfsPath returns the path of the flatfs datastore in the datastore
+// spec, "" if there is none.
+func flatfsPath(spec map[string]interface{}) string {
+	if spec["type"] == "flatfs" {
+		p, _ := spec["path"].(string)
+		return p
+	}
+	for _, v := range spec {
+		var children []interface{}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			children = []interface{}{v}
+		case []interface{}:
+			children = v
+		}
+		for _, c := range children {
+			if m, ok := c.(map[string]interface{}); ok {
+				if p := flatfsPath(m); p != "" {
+					return p
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// blockWriteTimes returns the modification times of the blocks of the
+// flatfs datastore at dir, by datastore key.
+func blockWriteTimes(dir string) (map[string]time.Time, error) {
+	times := make(map[string]time.Time)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if name := info.Name(); !info.IsDir() && strings.HasSuffix(name, ".data") {
+			times[strings.TrimSuffix(name, ".data")] = info.ModTime()
+		}
+		return nil
+	})
+	return times, err
+}
@@ -9,6 +9,8 @@ import (
 	gopath "path"
 	"strconv"
 
+	"github.com/TRON-US/go-btfs/core/erasure"
+
 	chunker "github.com/TRON-US/go-btfs-chunker"
 	"github.com/TRON-US/go-btfs-files"
 	"github.com/TRON-US/go-btfs-pinner"
@@ -94,6 +96,9 @@ type Adder struct {
 	liveNodes        uint64
 	TokenMetadata    string
 	PinDuration      int64
+	Session          *AddSession
+	// Erasure bounds the reed-solomon encodings, nil for the defaults.
+	Erasure *erasure.Config
 }
 
 func (adder *Adder) GcLocker() bstore.GCLocker {
@@ -114,6 +119,12 @@ func (adder *Adder) mfsRoot() (*mfs.Root, error) {
 	return adder.mroot, nil
 }
 
+// SetBatchOptions sizes the batches the blocks are written in. It must be
+// called before adding.
+func (adder *Adder) SetBatchOptions(opts ...ipld.BatchOption) {
+	adder.bufferedDS = ipld.NewBufferedDAG(adder.ctx, adder.dagService, opts...)
+}
+
 // SetMfsRoot sets `r` as the root for Adder.
 func (adder *Adder) SetMfsRoot(r *mfs.Root) {
 	adder.mroot = r
@@ -121,10 +132,13 @@ func (adder *Adder) SetMfsRoot(r *mfs.Root) {
 
 // Constructs a node from reader's data, and adds it. Doesn't pin.
 func (adder *Adder) add(reader io.Reader, dirTreeBytes []byte) (ipld.Node, error) {
-	chnk, err := chunker.FromString(reader, adder.Chunker)
+	chnk, err := adder.splitter(reader)
 	if err != nil {
 		return nil, err
 	}
+	if c, ok := chnk.(io.Closer); ok {
+		defer c.Close()
+	}
 	if dirTreeBytes != nil {
 		chnk.SetIsDir(true)
 	}
@@ -214,6 +228,20 @@ func (adder *Adder) add(reader io.Reader, dirTreeBytes []byte) (ipld.Node, error
 	return nd, adder.bufferedDS.Commit()
 }
 
+// splitter returns the splitter of adder.Chunker for reader, the
+// reed-solomon encodings being streamed with bounded memory.
+func (adder *Adder) splitter(reader io.Reader) (chunker.Splitter, error) {
+	if !chunker.IsReedSolomon(adder.Chunker) {
+		return chunker.FromString(reader, adder.Chunker)
+	}
+	m, err := chunker.GetRsMetaMapFromString(adder.Chunker)
+	if err != nil {
+		return nil, err
+	}
+	// FileSize of the scheme parsed is the chunk size
+	return erasure.NewSplitter(reader, m.NumData, m.NumParity, m.FileSize, adder.Erasure)
+}
+
 func (adder *Adder) metaDagToBuild(db *ihelper.DagBuilderHelper) bool {
 	if !adder.MetaForDirectory {
 		return db.IsThereMetaData() && !db.IsMetaDagBuilt()
@@ -369,7 +397,10 @@ func (adder *Adder) addNode(node ipld.Node, path string) error {
 
 // AddAllAndPin adds the given request's files and pin them.
 func (adder *Adder) AddAllAndPin(file files.Node) (ipld.Node, error) {
-	if adder.Pin {
+	// Staged adds hold the pin lock as well, so that GC, which reads the
+	// session blocks once it holds the GC lock, waits for the add and finds
+	// all its blocks recorded.
+	if adder.Pin || adder.Session != nil {
 		adder.unlocker = adder.gcLocker.PinLock()
 	}
 	defer func() {
@@ -387,6 +418,12 @@ func (adder *Adder) AddAllAndPin(file files.Node) (ipld.Node, error) {
 		return nil, err
 	}
 
+	if adder.Session != nil {
+		if err := adder.Session.AddRoot(nd.Cid()); err != nil {
+			return nil, err
+		}
+	}
+
 	if !adder.Pin {
 		return nd, nil
 	}
@@ -0,0 +1,303 @@
+package coreunix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+
+	"github.com/google/uuid"
+)
+
+const (
+	addSessionPrefix      = "/add-sessions/"
+	addSessionBlocksInfix = "/blocks/"
+	addSessionRootsInfix  = "/roots/"
+)
+
+var (
+	ErrAddSessionNotFound = errors.New("add session not found")
+	ErrAddSessionClosed   = errors.New("add session closed")
+)
+
+// addSessionsLk orders the roots added to the sessions with their closing,
+// the sessions being loaded once per add.
+var addSessionsLk sync.Mutex
+
+// AddSession stages the blocks written by one or more adds in a GC-exempt
+// area of the datastore. Blocks stay protected until the session is closed
+// by either committing (pinning the roots) or aborting it.
+type AddSession struct {
+	ID      string
+	Created time.Time
+	// Roots are stored each under its own key, so that concurrent adds
+	// do not overwrite each other's.
+	Roots []string
+
+	mu sync.Mutex
+	d  ds.Datastore
+}
+
+type addSessionCtxKey struct{}
+
+// ContextWithAddSession returns a derived context whose adds are staged
+// into the given session.
+func ContextWithAddSession(ctx context.Context, s *AddSession) context.Context {
+	return context.WithValue(ctx, addSessionCtxKey{}, s)
+}
+
+// AddSessionFromContext returns the session attached to ctx, if any.
+func AddSessionFromContext(ctx context.Context) *AddSession {
+	s, _ := ctx.Value(addSessionCtxKey{}).(*AddSession)
+	return s
+}
+
+// NewAddSession creates and persists a new empty add session.
+func NewAddSession(d ds.Datastore) (*AddSession, error) {
+	s := &AddSession{
+		ID:      uuid.New().String(),
+		Created: time.Now(),
+		d:       d,
+	}
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetAddSession loads an existing add session by id.
+func GetAddSession(d ds.Datastore, id string) (*AddSession, error) {
+	b, err := d.Get(ds.NewKey(addSessionPrefix + id))
+	if err == ds.ErrNotFound {
+		return nil, ErrAddSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	s := &AddSession{d: d}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if err := s.loadRoots(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListAddSessions returns all open add sessions.
+func ListAddSessions(d ds.Datastore) ([]*AddSession, error) {
+	qr, err := d.Query(query.Query{
+		Prefix: strings.TrimSuffix(addSessionPrefix, "/"),
+		Orders: []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer qr.Close()
+	var sessions []*AddSession
+	for r := range qr.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		// Skip the per-block entries, only session records sit right below the prefix
+		if strings.Contains(strings.TrimPrefix(r.Key, addSessionPrefix), "/") {
+			continue
+		}
+		s := &AddSession{d: d}
+		if err := json.Unmarshal(r.Value, s); err != nil {
+			return nil, err
+		}
+		if err := s.loadRoots(); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// AddSessionCids returns the cids of all blocks staged by open add sessions.
+// They are meant to be passed to GC as best-effort roots.
+func AddSessionCids(ctx context.Context, d ds.Datastore) ([]cid.Cid, error) {
+	qr, err := d.Query(query.Query{
+		Prefix:   strings.TrimSuffix(addSessionPrefix, "/"),
+		KeysOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer qr.Close()
+	var cids []cid.Cid
+	for r := range qr.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		i := strings.Index(r.Key, addSessionBlocksInfix)
+		if i < 0 {
+			continue
+		}
+		c, err := cid.Decode(r.Key[i+len(addSessionBlocksInfix):])
+		if err != nil {
+			return nil, err
+		}
+		cids = append(cids, c)
+	}
+	return cids, nil
+}
+
+func (s *AddSession) key() ds.Key {
+	return ds.NewKey(addSessionPrefix + s.ID)
+}
+
+func (s *AddSession) blockKey(c cid.Cid) ds.Key {
+	return ds.NewKey(addSessionPrefix + s.ID + addSessionBlocksInfix + c.String())
+}
+
+func (s *AddSession) rootKey(c cid.Cid) ds.Key {
+	return ds.NewKey(addSessionPrefix + s.ID + addSessionRootsInfix + c.String())
+}
+
+// loadRoots adds the roots stored under their own keys to the roots of the
+// session record.
+func (s *AddSession) loadRoots() error {
+	qr, err := s.d.Query(query.Query{
+		Prefix:   addSessionPrefix + s.ID + addSessionRootsInfix,
+		KeysOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer qr.Close()
+	var roots []string
+	for r := range qr.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+		roots = append(roots, r.Key[strings.LastIndex(r.Key, "/")+1:])
+	}
+	sort.Strings(roots)
+	for _, r := range roots {
+		s.appendRoot(r)
+	}
+	return nil
+}
+
+func (s *AddSession) appendRoot(root string) {
+	for _, r := range s.Roots {
+		if r == root {
+			return
+		}
+	}
+	s.Roots = append(s.Roots, root)
+}
+
+func (s *AddSession) save() error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return s.d.Put(s.key(), b)
+}
+
+// Track records a block as belonging to the session. It must be called
+// before the block itself is written.
+func (s *AddSession) Track(c cid.Cid) error {
+	return s.d.Put(s.blockKey(c), nil)
+}
+
+// AddRoot records the root of a completed add. It returns
+// ErrAddSessionClosed once the session is closed.
+func (s *AddSession) AddRoot(c cid.Cid) error {
+	addSessionsLk.Lock()
+	defer addSessionsLk.Unlock()
+	if ok, err := s.d.Has(s.key()); err != nil {
+		return err
+	} else if !ok {
+		return ErrAddSessionClosed
+	}
+	if err := s.d.Put(s.rootKey(c), nil); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.appendRoot(c.String())
+	s.mu.Unlock()
+	return nil
+}
+
+// RootCids returns the decoded roots of all adds completed in the session.
+func (s *AddSession) RootCids() ([]cid.Cid, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cids := make([]cid.Cid, 0, len(s.Roots))
+	for _, r := range s.Roots {
+		c, err := cid.Decode(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session root %s: %v", r, err)
+		}
+		cids = append(cids, c)
+	}
+	return cids, nil
+}
+
+// Close removes the session, its roots and its staged block records. The
+// blocks themselves are left in place and become eligible for GC unless
+// they have been pinned in the meantime.
+func (s *AddSession) Close() error {
+	addSessionsLk.Lock()
+	defer addSessionsLk.Unlock()
+	qr, err := s.d.Query(query.Query{
+		Prefix:   addSessionPrefix + s.ID + "/",
+		KeysOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer qr.Close()
+	for r := range qr.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+		if err := s.d.Delete(ds.NewKey(r.Key)); err != nil {
+			return err
+		}
+	}
+	return s.d.Delete(s.key())
+}
+
+// Blockstore wraps bs so that every block written through it is staged
+// into the session first.
+func (s *AddSession) Blockstore(bs bstore.GCBlockstore) bstore.GCBlockstore {
+	return &sessionBlockstore{GCBlockstore: bs, session: s}
+}
+
+type sessionBlockstore struct {
+	bstore.GCBlockstore
+	session *AddSession
+}
+
+func (b *sessionBlockstore) Put(blk blocks.Block) error {
+	if err := b.session.Track(blk.Cid()); err != nil {
+		return err
+	}
+	return b.GCBlockstore.Put(blk)
+}
+
+func (b *sessionBlockstore) PutMany(blks []blocks.Block) error {
+	for _, blk := range blks {
+		if err := b.session.Track(blk.Cid()); err != nil {
+			return err
+		}
+	}
+	return b.GCBlockstore.PutMany(blks)
+}
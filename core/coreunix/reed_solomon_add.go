@@ -44,7 +44,7 @@ func NewReedSolomonAdder(adder *Adder) (*ReedSolomonAdder, error) {
 
 // AddAllAndPin adds the given request's files and pin them.
 func (rsadder *ReedSolomonAdder) AddAllAndPin(file files.Node) (ipld.Node, error) {
-	if rsadder.Pin {
+	if rsadder.Pin || rsadder.Session != nil {
 		rsadder.unlocker = rsadder.gcLocker.PinLock()
 	}
 	defer func() {
@@ -109,6 +109,12 @@ func (rsadder *ReedSolomonAdder) AddAllAndPin(file files.Node) (ipld.Node, error
 		}
 	}
 
+	if rsadder.Session != nil {
+		if err := rsadder.Session.AddRoot(nd.Cid()); err != nil {
+			return nil, err
+		}
+	}
+
 	// Pin the newly created DAG.
 	if !rsadder.Pin {
 		return nd, nil
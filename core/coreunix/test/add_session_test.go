@@ -0,0 +1,241 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/corerepo"
+	"github.com/TRON-US/go-btfs/core/coreunix"
+	"github.com/TRON-US/go-btfs/gc"
+	"github.com/TRON-US/go-btfs/repo"
+
+	config "github.com/TRON-US/go-btfs-config"
+	files "github.com/TRON-US/go-btfs-files"
+	"github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+)
+
+func TestAddSessionSurvivesGC(t *testing.T) {
+	r := &repo.Mock{
+		C: config.Config{
+			Identity: config.Identity{
+				PeerID: testPeerID, // required by offline node
+			},
+		},
+		D: syncds.MutexWrap(datastore.NewMapDatastore()),
+	}
+	node, err := core.NewNode(context.Background(), &core.BuildCfg{Repo: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := coreunix.NewAddSession(node.Repo.Datastore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := session.Blockstore(node.Blockstore)
+	dserv := dag.NewDAGService(blockservice.New(bs, node.Exchange))
+	adder, err := coreunix.NewAdder(context.Background(), node.Pinning, bs, dserv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adder.Pin = false
+	adder.Session = session
+
+	root, err := adder.AddAllAndPin(files.NewMapDirectory(map[string]files.Node{
+		"a": files.NewBytesFile([]byte("testfileA")),
+		"b": files.NewBytesFile([]byte("testfileB")),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runGC := func() map[string]struct{} {
+		roots, err := coreunix.AddSessionCids(context.Background(), node.Repo.Datastore())
+		if err != nil {
+			t.Fatal(err)
+		}
+		removed := make(map[string]struct{})
+		for res := range gc.GC(context.Background(), node.Blockstore, node.Repo.Datastore(), node.Pinning, roots) {
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			removed[res.KeyRemoved.String()] = struct{}{}
+		}
+		return removed
+	}
+
+	if _, ok := runGC()[root.Cid().String()]; ok {
+		t.Fatal("gc'ed a block staged in an open add session")
+	}
+
+	loaded, err := coreunix.GetAddSession(node.Repo.Datastore(), session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Roots) != 1 || loaded.Roots[0] != root.Cid().String() {
+		t.Fatalf("unexpected session roots %v", loaded.Roots)
+	}
+
+	if err := loaded.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coreunix.GetAddSession(node.Repo.Datastore(), session.ID); err != coreunix.ErrAddSessionNotFound {
+		t.Fatalf("expected closed session to be gone, got %v", err)
+	}
+	if _, ok := runGC()[root.Cid().String()]; !ok {
+		t.Fatal("expected aborted session blocks to be collected")
+	}
+}
+
+// TestAddSessionConcurrentGC runs a GC while a staged add is writing its
+// blocks: the GC waits for the add, and keeps the blocks staged after it
+// was started.
+func TestAddSessionConcurrentGC(t *testing.T) {
+	r := &repo.Mock{
+		C: config.Config{
+			Identity: config.Identity{
+				PeerID: testPeerID, // required by offline node
+			},
+		},
+		D: syncds.MutexWrap(datastore.NewMapDatastore()),
+	}
+	node, err := core.NewNode(context.Background(), &core.BuildCfg{Repo: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := coreunix.NewAddSession(node.Repo.Datastore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := session.Blockstore(node.Blockstore)
+	dserv := dag.NewDAGService(blockservice.New(bs, node.Exchange))
+	adder, err := coreunix.NewAdder(context.Background(), node.Pinning, bs, dserv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adder.Pin = false
+	adder.Session = session
+
+	data := make([]byte, 4<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	pr, pw := io.Pipe()
+	type added struct {
+		nd  ipld.Node
+		err error
+	}
+	addDone := make(chan added, 1)
+	go func() {
+		nd, err := adder.AddAllAndPin(files.NewReaderFile(pr))
+		addDone <- added{nd, err}
+	}()
+	// the add holds the pin lock once it reads its first chunks
+	if _, err := pw.Write(data[:1<<20]); err != nil {
+		t.Fatal(err)
+	}
+
+	gcDone := make(chan map[string]struct{}, 1)
+	go func() {
+		removed := make(map[string]struct{})
+		for res := range corerepo.GarbageCollectAsync(node, context.Background()) {
+			if res.Error != nil {
+				t.Error(res.Error)
+				continue
+			}
+			removed[res.KeyRemoved.String()] = struct{}{}
+		}
+		gcDone <- removed
+	}()
+	// let the GC wait on the lock before the add stages its other blocks
+	time.Sleep(100 * time.Millisecond)
+	if _, err := io.Copy(pw, bytes.NewReader(data[1<<20:])); err != nil {
+		t.Fatal(err)
+	}
+	pw.Close()
+
+	res := <-addDone
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	removed := <-gcDone
+	if _, ok := removed[res.nd.Cid().String()]; ok {
+		t.Fatal("gc'ed the root of an add running concurrently")
+	}
+	err = dag.Walk(context.Background(), dag.GetLinksWithDAG(node.DAG), res.nd.Cid(), func(c cid.Cid) bool {
+		if _, ok := removed[c.String()]; ok {
+			t.Errorf("gc'ed block %s staged by an add running concurrently", c)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAddSessionConcurrentRoots adds roots concurrently through sessions
+// loaded by each add, then after the session is closed.
+func TestAddSessionConcurrentRoots(t *testing.T) {
+	d := syncds.MutexWrap(datastore.NewMapDatastore())
+	session, err := coreunix.NewAddSession(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := make([]cid.Cid, 20)
+	for i := range roots {
+		roots[i] = dag.NodeWithData([]byte(fmt.Sprintf("root %d", i))).Cid()
+	}
+	var wg sync.WaitGroup
+	for _, c := range roots {
+		wg.Add(1)
+		go func(c cid.Cid) {
+			defer wg.Done()
+			s, err := coreunix.GetAddSession(d, session.ID)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err := s.AddRoot(c); err != nil {
+				t.Error(err)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	loaded, err := coreunix.GetAddSession(d, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Roots) != len(roots) {
+		t.Fatalf("expected %d roots, got %v", len(roots), loaded.Roots)
+	}
+
+	if err := loaded.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.AddRoot(roots[0]); err != coreunix.ErrAddSessionClosed {
+		t.Fatalf("expected the closed session to refuse roots, got %v", err)
+	}
+	if _, err := coreunix.GetAddSession(d, session.ID); err != coreunix.ErrAddSessionNotFound {
+		t.Fatalf("expected the closed session to stay gone, got %v", err)
+	}
+	sessions, err := coreunix.ListAddSessions(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("unexpected sessions %v", sessions)
+	}
+}
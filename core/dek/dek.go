@@ -0,0 +1,293 @@
+// Package dek manages the data encryption keys (DEKs) of the node: random
+// AES keys encrypting file content client side before it is added.
+//
+// DEKs are kept in the datastore wrapped by a key encryption key which never
+// leaves its Wrapper, the wallet key of the node or an external KMS. A DEK is
+// rotated by adding a version: content is encrypted with the latest version,
+// the older ones are kept to decrypt what was added with them.
+package dek
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// ConfigKey is the config section of the key management.
+const ConfigKey = "Keys"
+
+// KeySize is the size of a DEK in bytes, an AES-256 key.
+const KeySize = 32
+
+const keyPrefix = "/btfs/%s/keys/dek/"
+
+var (
+	// ErrNotFound is returned for a DEK or version that does not exist.
+	ErrNotFound = errors.New("data encryption key not found")
+	// ErrExists is returned when creating a DEK whose name is taken.
+	ErrExists = errors.New("data encryption key already exists")
+)
+
+// Config configures the key management.
+type Config struct {
+	// KMS wraps the DEKs with an external key management service instead of
+	// the wallet key.
+	KMS *KMSConfig `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the key management config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+// Version is a version of a DEK.
+type Version struct {
+	Version int
+	Created time.Time
+	// Wrapped is the key encrypted by the wrapper of the DEK.
+	Wrapped []byte
+}
+
+// Record is a DEK as kept in the datastore.
+type Record struct {
+	Name string
+	// Wrapper is the name of the Wrapper of the versions.
+	Wrapper  string
+	Versions []Version
+}
+
+// Current returns the latest version of the DEK.
+func (r *Record) Current() *Version {
+	return &r.Versions[len(r.Versions)-1]
+}
+
+func (r *Record) version(v int) *Version {
+	for i := range r.Versions {
+		if r.Versions[i].Version == v {
+			return &r.Versions[i]
+		}
+	}
+	return nil
+}
+
+// Manager creates, rotates and unwraps the DEKs of a node.
+type Manager struct {
+	d      ds.Datastore
+	prefix string
+	w      Wrapper
+
+	// serializes the read-modify-write of records
+	mu sync.Mutex
+}
+
+// NewManager returns the manager of the DEKs of the node peerID kept in d,
+// wrapping new keys with w.
+func NewManager(d ds.Datastore, peerID string, w Wrapper) *Manager {
+	return &Manager{d: d, prefix: fmt.Sprintf(keyPrefix, peerID), w: w}
+}
+
+// ForNode returns the manager of the DEKs of n, wrapping them as configured
+// in its repo.
+func ForNode(n *core.IpfsNode) (*Manager, error) {
+	c, err := Load(n.Repo)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWrapper(c, n.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewManager(n.Repo.Datastore(), n.Identity.Pretty(), w), nil
+}
+
+func validName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/ ") {
+		return fmt.Errorf("invalid data encryption key name %q", name)
+	}
+	return nil
+}
+
+func (m *Manager) key(name string) ds.Key {
+	return ds.NewKey(m.prefix + name)
+}
+
+// Get returns the DEK called name.
+func (m *Manager) Get(name string) (*Record, error) {
+	b, err := m.d.Get(m.key(name))
+	if err == ds.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &Record{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (m *Manager) put(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return m.d.Put(m.key(r.Name), b)
+}
+
+// List returns the DEKs sorted by name.
+func (m *Manager) List() ([]*Record, error) {
+	res, err := m.d.Query(query.Query{Prefix: m.prefix})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+	rs := make([]*Record, 0, len(entries))
+	for _, en := range entries {
+		r := &Record{}
+		if err := json.Unmarshal(en.Value, r); err != nil {
+			return nil, fmt.Errorf("invalid data encryption key %s: %s", en.Key, err)
+		}
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Name < rs[j].Name })
+	return rs, nil
+}
+
+func (m *Manager) newVersion(ctx context.Context, v int, key []byte) (*Version, error) {
+	wrapped, err := m.w.Wrap(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot wrap data encryption key with %s: %s", m.w.Name(), err)
+	}
+	return &Version{Version: v, Created: time.Now(), Wrapped: wrapped}, nil
+}
+
+func randomKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Create generates a DEK called name, at version 1.
+func (m *Manager) Create(ctx context.Context, name string) (*Record, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.Get(name); err == nil {
+		return nil, ErrExists
+	} else if err != ErrNotFound {
+		return nil, err
+	}
+	key, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+	v, err := m.newVersion(ctx, 1, key)
+	if err != nil {
+		return nil, err
+	}
+	r := &Record{Name: name, Wrapper: m.w.Name(), Versions: []Version{*v}}
+	return r, m.put(r)
+}
+
+// Rotate adds a new version to the DEK called name. Content added after is
+// encrypted with it, the previous versions still decrypt older content.
+func (m *Manager) Rotate(ctx context.Context, name string) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkWrapper(r); err != nil {
+		return nil, err
+	}
+	key, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+	v, err := m.newVersion(ctx, r.Current().Version+1, key)
+	if err != nil {
+		return nil, err
+	}
+	r.Versions = append(r.Versions, *v)
+	return r, m.put(r)
+}
+
+func (m *Manager) checkWrapper(r *Record) error {
+	if r.Wrapper != m.w.Name() {
+		return fmt.Errorf("data encryption key %s is wrapped by %s, not the configured %s",
+			r.Name, r.Wrapper, m.w.Name())
+	}
+	return nil
+}
+
+// Key returns the plain key of a version of the DEK called name.
+func (m *Manager) Key(ctx context.Context, name string, version int) ([]byte, error) {
+	r, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkWrapper(r); err != nil {
+		return nil, err
+	}
+	v := r.version(version)
+	if v == nil {
+		return nil, ErrNotFound
+	}
+	key, err := m.w.Unwrap(ctx, v.Wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unwrap data encryption key %s: %s", name, err)
+	}
+	return key, nil
+}
+
+// CurrentKey returns the plain key of the latest version of the DEK called
+// name and that version, creating the DEK if it does not exist.
+func (m *Manager) CurrentKey(ctx context.Context, name string) ([]byte, int, error) {
+	r, err := m.Get(name)
+	if err == ErrNotFound {
+		r, err = m.Create(ctx, name)
+		if err == ErrExists {
+			// created concurrently
+			r, err = m.Get(name)
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	v := r.Current().Version
+	key, err := m.Key(ctx, name, v)
+	if err != nil {
+		return nil, 0, err
+	}
+	return key, v, nil
+}
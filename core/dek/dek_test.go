@@ -0,0 +1,282 @@
+package dek
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func newKey(t *testing.T) (ic.PrivKey, string) {
+	priv, pub, err := ic.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, id.Pretty()
+}
+
+func newManager(t *testing.T) (*Manager, ic.PrivKey, string) {
+	priv, id := newKey(t)
+	w, err := NewWalletWrapper(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewManager(dssync.MutexWrap(ds.NewMapDatastore()), id, w), priv, id
+}
+
+func TestCreateRotate(t *testing.T) {
+	ctx := context.Background()
+	m, _, _ := newManager(t)
+
+	key1, v, err := m.CurrentKey(ctx, "photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 1 || len(key1) != KeySize {
+		t.Fatalf("got version %d, key of %d bytes", v, len(key1))
+	}
+	if _, err := m.Create(ctx, "photos"); err != ErrExists {
+		t.Fatalf("created twice: %v", err)
+	}
+
+	r, err := m.Rotate(ctx, "photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Current().Version != 2 || len(r.Versions) != 2 {
+		t.Fatalf("rotated to %+v", r)
+	}
+	key2, v, err := m.CurrentKey(ctx, "photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 2 || bytes.Equal(key1, key2) {
+		t.Fatal("rotation did not change the current key")
+	}
+	old, err := m.Key(ctx, "photos", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(old, key1) {
+		t.Fatal("version 1 changed with the rotation")
+	}
+	if _, err := m.Key(ctx, "photos", 3); err != ErrNotFound {
+		t.Fatalf("got version 3: %v", err)
+	}
+
+	if _, err := m.Create(ctx, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 || rs[0].Name != "docs" || rs[1].Name != "photos" {
+		t.Fatalf("listed %+v", rs)
+	}
+	if _, err := m.Create(ctx, "a/b"); err == nil {
+		t.Fatal("created a key with a slash in its name")
+	}
+}
+
+func TestWrapperMismatch(t *testing.T) {
+	ctx := context.Background()
+	m, _, id := newManager(t)
+	if _, err := m.Create(ctx, "photos"); err != nil {
+		t.Fatal(err)
+	}
+	kms, err := NewKMSWrapper(&KMSConfig{URL: "http://127.0.0.1:1", KeyID: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := NewManager(m.d, id, kms)
+	if _, err := other.Key(ctx, "photos", 1); err == nil {
+		t.Fatal("unwrapped a wallet wrapped key with a kms")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	sealed, err := Seal(key, []byte("btt to da moon"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := Open(key, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plain) != "btt to da moon" {
+		t.Fatalf("opened %q", plain)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := Open(key, sealed); err != ErrDecrypt {
+		t.Fatalf("opened altered content: %v", err)
+	}
+}
+
+func TestMeta(t *testing.T) {
+	tm, err := (&Meta{Dek: "photos", DekVersion: 2}).AppendTo(`{"price":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := ParseMeta([]byte(tm))
+	if !ok || m.Dek != "photos" || m.DekVersion != 2 {
+		t.Fatalf("parsed %+v from %s", m, tm)
+	}
+	if _, ok := ParseMeta([]byte(`{"price":1}`)); ok {
+		t.Fatal("parsed a dek from metadata without one")
+	}
+}
+
+func TestGrant(t *testing.T) {
+	ctx := context.Background()
+	m, _, _ := newManager(t)
+	if _, err := m.Create(ctx, "photos"); err != nil {
+		t.Fatal(err)
+	}
+	g := &Grant{Dek: "photos", Version: 1, Cid: "QmSomeHash", Expires: time.Now().Add(time.Hour).UTC()}
+	token, err := m.IssueGrant(ctx, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.VerifyGrant(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cid != g.Cid || got.Dek != g.Dek {
+		t.Fatalf("verified %+v", got)
+	}
+
+	// a grant for another file, signed with the same signature
+	forged := *g
+	forged.Cid = "QmOtherHash"
+	other, err := m.IssueGrant(ctx, &forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.VerifyGrant(ctx, other[:bytes.IndexByte([]byte(other), '.')]+token[bytes.IndexByte([]byte(token), '.'):]); err != ErrInvalidGrant {
+		t.Fatalf("verified a forged grant: %v", err)
+	}
+
+	g.Expires = time.Now().Add(-time.Minute)
+	expired, err := m.IssueGrant(ctx, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.VerifyGrant(ctx, expired); err != ErrGrantExpired {
+		t.Fatalf("verified an expired grant: %v", err)
+	}
+
+	// grants of another node do not verify
+	m2, _, _ := newManager(t)
+	if _, err := m2.Create(ctx, "photos"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m2.VerifyGrant(ctx, token); err != ErrInvalidGrant {
+		t.Fatalf("verified a grant of another node: %v", err)
+	}
+}
+
+func TestShareImport(t *testing.T) {
+	ctx := context.Background()
+	alice, _, _ := newManager(t)
+	bob, bobKey, bobID := newManager(t)
+	key, v, err := alice.CurrentKey(ctx, "photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := alice.Share(ctx, "photos", v, bobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := bob.Import(ctx, s, bobKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "photos" || r.Current().Version != v {
+		t.Fatalf("imported %+v", r)
+	}
+	got, err := bob.Key(ctx, "photos", v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Fatal("imported another key")
+	}
+	if _, err := bob.Import(ctx, s, bobKey); err == nil {
+		t.Fatal("imported the same version twice")
+	}
+
+	carol, carolKey, _ := newManager(t)
+	if _, err := carol.Import(ctx, s, carolKey); err == nil {
+		t.Fatal("imported a key shared with another node")
+	}
+}
+
+func TestKMSWrapper(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(&kmsResponse{Message: "bad token"})
+			return
+		}
+		in := &kmsRequest{}
+		if err := json.NewDecoder(r.Body).Decode(in); err != nil || in.KeyID != "btfs" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// a toy kms reversing the bytes
+		reverse := func(b []byte) []byte {
+			out := make([]byte, len(b))
+			for i := range b {
+				out[len(b)-1-i] = b[i]
+			}
+			return out
+		}
+		switch r.URL.Path {
+		case "/wrap":
+			json.NewEncoder(w).Encode(&kmsResponse{Ciphertext: reverse(in.Plaintext)})
+		case "/unwrap":
+			json.NewEncoder(w).Encode(&kmsResponse{Plaintext: reverse(in.Ciphertext)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	w, err := NewWrapper(&Config{KMS: &KMSConfig{URL: ts.URL, KeyID: "btfs", Token: "secret"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(dssync.MutexWrap(ds.NewMapDatastore()), "peer", w)
+	key, _, err := m.CurrentKey(ctx, "photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := m.Get("photos")
+	if r.Wrapper != "kms:btfs" || bytes.Equal(r.Current().Wrapped, key) {
+		t.Fatalf("stored %+v", r)
+	}
+
+	bad, err := NewKMSWrapper(&KMSConfig{URL: ts.URL, KeyID: "btfs", Token: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bad.Wrap(ctx, key); err == nil || err.Error() != "kms wrap failed: bad token" {
+		t.Fatalf("wrapped with a bad token: %v", err)
+	}
+}
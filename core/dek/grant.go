@@ -0,0 +1,95 @@
+package dek
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidGrant is returned for a grant that was not issued by the
+	// node, or was altered.
+	ErrInvalidGrant = errors.New("invalid access grant")
+	// ErrGrantExpired is returned for a grant past its expiry.
+	ErrGrantExpired = errors.New("access grant expired")
+)
+
+// grantInfo separates the grant signing key from the DEK it is derived from.
+var grantInfo = []byte("btfs gateway access grant")
+
+// Grant lets the gateway of the node decrypt a file encrypted with a DEK for
+// whoever holds it, until it expires. Grants are signed with a key derived
+// from the DEK version they name, rotating the DEK does not revoke them.
+type Grant struct {
+	Dek     string
+	Version int
+	// Cid is the file the grant gives access to.
+	Cid     string
+	Expires time.Time
+}
+
+func (m *Manager) grantMAC(ctx context.Context, g *Grant, payload []byte) ([]byte, error) {
+	key, err := m.Key(ctx, g.Dek, g.Version)
+	if err != nil {
+		return nil, err
+	}
+	kmac := hmac.New(sha256.New, key)
+	kmac.Write(grantInfo)
+	mac := hmac.New(sha256.New, kmac.Sum(nil))
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// IssueGrant returns the token of g, signed with the version of its DEK.
+func (m *Manager) IssueGrant(ctx context.Context, g *Grant) (string, error) {
+	payload, err := json.Marshal(g)
+	if err != nil {
+		return "", err
+	}
+	sig, err := m.grantMAC(ctx, g, payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// VerifyGrant returns the grant of a token issued by IssueGrant, if it has
+// not expired.
+func (m *Manager) VerifyGrant(ctx context.Context, token string) (*Grant, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidGrant
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+	g := &Grant{}
+	if err := json.Unmarshal(payload, g); err != nil {
+		return nil, ErrInvalidGrant
+	}
+	want, err := m.grantMAC(ctx, g, payload)
+	if err == ErrNotFound {
+		return nil, ErrInvalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(sig, want) {
+		return nil, ErrInvalidGrant
+	}
+	if time.Now().After(g.Expires) {
+		return nil, ErrGrantExpired
+	}
+	return g, nil
+}
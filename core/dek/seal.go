@@ -0,0 +1,117 @@
+package dek
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	files "github.com/TRON-US/go-btfs-files"
+)
+
+// ErrDecrypt is returned when content does not decrypt with a key, it was
+// encrypted with another key or altered.
+var ErrDecrypt = errors.New("cannot decrypt content, wrong key or altered content")
+
+// Seal encrypts plaintext with key using AES-GCM. The random nonce is
+// prepended to the ciphertext.
+func Seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts ciphertext sealed with key.
+func Open(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// OpenFile returns the content of f, sealed with key, decrypted. The file
+// is decrypted in memory, and can be seeked.
+func OpenFile(key []byte, f files.File) (files.File, error) {
+	sealed, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := Open(key, sealed)
+	if err != nil {
+		return nil, err
+	}
+	return &plainFile{bytes.NewReader(plain)}, nil
+}
+
+type plainFile struct {
+	*bytes.Reader
+}
+
+func (f *plainFile) Close() error {
+	return nil
+}
+
+func (f *plainFile) Size() (int64, error) {
+	return f.Reader.Size(), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Meta is the token metadata of a file encrypted with a DEK, naming the DEK
+// and version needed to decrypt it.
+type Meta struct {
+	Dek        string
+	DekVersion int
+}
+
+// ParseMeta returns the DEK of a file from its token metadata, false if the
+// file is not encrypted with one.
+func ParseMeta(tokenMeta []byte) (*Meta, bool) {
+	m := &Meta{}
+	if err := json.Unmarshal(tokenMeta, m); err != nil || m.Dek == "" || m.DekVersion < 1 {
+		return nil, false
+	}
+	return m, true
+}
+
+// AppendTo adds m to the token metadata tokenMeta of a file, a JSON object
+// or "".
+func (m *Meta) AppendTo(tokenMeta string) (string, error) {
+	fields := map[string]interface{}{}
+	if tokenMeta != "" {
+		if err := json.Unmarshal([]byte(tokenMeta), &fields); err != nil {
+			return "", err
+		}
+	}
+	fields["Dek"] = m.Dek
+	fields["DekVersion"] = m.DekVersion
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,115 @@
+package dek
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	ecies "github.com/TRON-US/go-eccrypto"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// shared is a DEK version encrypted for another node.
+type shared struct {
+	Name    string
+	Version int
+	Key     string
+	Meta    *ecies.EciesMetadata
+	// Check tells whether the key was decrypted with the right private key,
+	// the ecies decryption does not.
+	Check []byte
+}
+
+var checkInfo = []byte("btfs shared data encryption key")
+
+func keyCheck(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(checkInfo)
+	return mac.Sum(nil)
+}
+
+// Share returns a version of the DEK called name encrypted for the node
+// peerID, to be imported there with Import.
+func (m *Manager) Share(ctx context.Context, name string, version int, peerID string) (string, error) {
+	id, err := peer.IDB58Decode(peerID)
+	if err != nil {
+		return "", err
+	}
+	pub, err := id.ExtractPublicKey()
+	if err != nil {
+		return "", fmt.Errorf("cannot get the public key of %s: %s", peerID, err)
+	}
+	pubBytes, err := pub.Bytes()
+	if err != nil {
+		return "", err
+	}
+	key, err := m.Key(ctx, name, version)
+	if err != nil {
+		return "", err
+	}
+	// the keys are marshalled with a 4 bytes protobuf header
+	ciphertext, meta, err := ecies.Encrypt(hex.EncodeToString(pubBytes[4:]), key)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(&shared{Name: name, Version: version, Key: ciphertext, Meta: meta, Check: keyCheck(key)})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Import adds a DEK version shared with this node, whose private key is
+// priv, and returns the DEK.
+func (m *Manager) Import(ctx context.Context, blob string, priv ic.PrivKey) (*Record, error) {
+	b, err := base64.StdEncoding.DecodeString(blob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shared key: %s", err)
+	}
+	s := &shared{}
+	if err := json.Unmarshal(b, s); err != nil || s.Meta == nil || s.Version < 1 {
+		return nil, fmt.Errorf("invalid shared key")
+	}
+	if err := validName(s.Name); err != nil {
+		return nil, err
+	}
+	privBytes, err := priv.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := ecies.Decrypt(hex.EncodeToString(privBytes[4:]), s.Key, s.Meta)
+	key := []byte(plain)
+	if err != nil || !hmac.Equal(keyCheck(key), s.Check) {
+		return nil, fmt.Errorf("cannot decrypt shared key, it was not shared with this node")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, err := m.Get(s.Name)
+	switch err {
+	case nil:
+		if err := m.checkWrapper(r); err != nil {
+			return nil, err
+		}
+		if r.version(s.Version) != nil {
+			return nil, fmt.Errorf("data encryption key %s already has version %d", s.Name, s.Version)
+		}
+	case ErrNotFound:
+		r = &Record{Name: s.Name, Wrapper: m.w.Name()}
+	default:
+		return nil, err
+	}
+	v, err := m.newVersion(ctx, s.Version, key)
+	if err != nil {
+		return nil, err
+	}
+	r.Versions = append(r.Versions, *v)
+	sort.Slice(r.Versions, func(i, j int) bool { return r.Versions[i].Version < r.Versions[j].Version })
+	return r, m.put(r)
+}
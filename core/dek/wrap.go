@@ -0,0 +1,181 @@
+package dek
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// Wrapper encrypts and decrypts DEKs with a key encryption key it keeps.
+type Wrapper interface {
+	// Name identifies the key encryption key, DEKs can only be unwrapped by
+	// a wrapper of the same name.
+	Name() string
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// NewWrapper returns the wrapper configured by c: the KMS when there is one,
+// the wallet key priv otherwise.
+func NewWrapper(c *Config, priv ic.PrivKey) (Wrapper, error) {
+	if c.KMS != nil {
+		return NewKMSWrapper(c.KMS)
+	}
+	return NewWalletWrapper(priv)
+}
+
+// WalletWrapperName is the name of the wrapper of the wallet key.
+const WalletWrapperName = "wallet"
+
+// kekInfo separates the key encryption key from other keys derived from the
+// wallet key.
+var kekInfo = []byte("btfs data encryption key wrapping")
+
+type walletWrapper struct {
+	kek []byte
+}
+
+// NewWalletWrapper returns a wrapper whose key encryption key is derived
+// from the wallet key priv, so that DEKs are only usable with the wallet.
+func NewWalletWrapper(priv ic.PrivKey) (Wrapper, error) {
+	if priv == nil {
+		return nil, errors.New("the wallet key is not available")
+	}
+	raw, err := priv.Raw()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, raw)
+	mac.Write(kekInfo)
+	return &walletWrapper{kek: mac.Sum(nil)}, nil
+}
+
+func (w *walletWrapper) Name() string {
+	return WalletWrapperName
+}
+
+func (w *walletWrapper) Wrap(_ context.Context, key []byte) ([]byte, error) {
+	return Seal(w.kek, key)
+}
+
+func (w *walletWrapper) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	return Open(w.kek, wrapped)
+}
+
+// KMSConfig configures an external key management service.
+//
+// The service is called over HTTP: DEKs are posted as
+// {"KeyID": ..., "Plaintext": <base64>} to URL/wrap, which answers
+// {"Ciphertext": <base64>}, and the reverse to URL/unwrap.
+type KMSConfig struct {
+	URL string
+	// KeyID is the key encryption key of the service wrapping the DEKs.
+	KeyID string
+	// Token is sent as a bearer token when set.
+	Token string `json:",omitempty"`
+	// Timeout bounds each call, e.g. "10s".
+	Timeout string `json:",omitempty"`
+}
+
+// DefaultKMSTimeout bounds the calls to the KMS when no timeout is
+// configured.
+const DefaultKMSTimeout = 10 * time.Second
+
+type kmsWrapper struct {
+	c      *KMSConfig
+	client *http.Client
+}
+
+// NewKMSWrapper returns a wrapper delegating to the KMS configured by c.
+func NewKMSWrapper(c *KMSConfig) (Wrapper, error) {
+	if c.URL == "" || c.KeyID == "" {
+		return nil, fmt.Errorf("%s.KMS needs a URL and a KeyID", ConfigKey)
+	}
+	timeout := DefaultKMSTimeout
+	if c.Timeout != "" {
+		d, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s.KMS.Timeout: %s", ConfigKey, err)
+		}
+		timeout = d
+	}
+	return &kmsWrapper{c: c, client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (w *kmsWrapper) Name() string {
+	return "kms:" + w.c.KeyID
+}
+
+type kmsRequest struct {
+	KeyID      string
+	Plaintext  []byte `json:",omitempty"`
+	Ciphertext []byte `json:",omitempty"`
+}
+
+type kmsResponse struct {
+	Plaintext  []byte
+	Ciphertext []byte
+	Message    string
+}
+
+func (w *kmsWrapper) call(ctx context.Context, op string, in *kmsRequest) (*kmsResponse, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(w.c.URL, "/")+"/"+op, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if w.c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.c.Token)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	out := &kmsResponse{}
+	if err := json.Unmarshal(b, out); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("invalid kms response: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if out.Message == "" {
+			out.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, fmt.Errorf("kms %s failed: %s", op, out.Message)
+	}
+	return out, nil
+}
+
+func (w *kmsWrapper) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	out, err := w.call(ctx, "wrap", &kmsRequest{KeyID: w.c.KeyID, Plaintext: key})
+	if err != nil {
+		return nil, err
+	}
+	return out.Ciphertext, nil
+}
+
+func (w *kmsWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := w.call(ctx, "unwrap", &kmsRequest{KeyID: w.c.KeyID, Ciphertext: wrapped})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
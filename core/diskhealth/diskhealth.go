@@ -0,0 +1,239 @@
+// Package diskhealth predicts the failure of the disk storing the shards of
+// a host, from its SMART data where smartctl is available and from the
+// trend of the shard read errors met answering challenges, so that the
+// host is warned before challenges start failing.
+package diskhealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// ConfigKey is the config section of the disk health checks.
+const ConfigKey = "DiskHealth"
+
+// DefaultMaxReadErrorRate is the rate of failed shard reads above which the
+// disk is suspect when nothing is configured.
+const DefaultMaxReadErrorRate = 0.05
+
+// MaxAlerts is the number of alerts kept.
+const MaxAlerts = 50
+
+const (
+	reportKey      = "/btfs/%s/diskhealth/report"
+	alertKeyPrefix = "/btfs/%s/diskhealth/alerts/"
+)
+
+// Config configures the disk health checks.
+type Config struct {
+	// Device is the disk polled for SMART data, the one holding the repo
+	// when empty.
+	Device string `json:",omitempty"`
+	// MaxReadErrorRate is the rate of failed shard reads above which the
+	// disk is suspect.
+	MaxReadErrorRate float64 `json:",omitempty"`
+	// MigrateTo is a path on another disk the repo, with the shards it
+	// stores, is moved to when the disk is failing. Nothing is moved when
+	// empty.
+	MigrateTo string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the disk health config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if c.MaxReadErrorRate < 0 || c.MaxReadErrorRate > 1 {
+		return nil, fmt.Errorf("invalid %s config: MaxReadErrorRate %v is not within [0, 1]",
+			ConfigKey, c.MaxReadErrorRate)
+	}
+	if c.MaxReadErrorRate == 0 {
+		c.MaxReadErrorRate = DefaultMaxReadErrorRate
+	}
+	return c, nil
+}
+
+// Level is how likely the disk is to fail.
+type Level string
+
+const (
+	OK      Level = "ok"
+	Warning Level = "warning"
+	Failing Level = "failing"
+)
+
+func (l Level) rank() int {
+	switch l {
+	case Warning:
+		return 1
+	case Failing:
+		return 2
+	}
+	return 0
+}
+
+// Worse reports whether l is worse than o.
+func (l Level) Worse(o Level) bool {
+	return l.rank() > o.rank()
+}
+
+// Report is the outcome of a disk health check.
+type Report struct {
+	Time  time.Time
+	Level Level
+	// Reasons explain the level.
+	Reasons []string `json:",omitempty"`
+	Smart   *Smart   `json:",omitempty"`
+	// SmartError is why no SMART data was polled.
+	SmartError string `json:",omitempty"`
+	Reads      []*Reads
+}
+
+func (r *Report) raise(l Level, format string, a ...interface{}) {
+	if l.Worse(r.Level) {
+		r.Level = l
+	}
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, a...))
+}
+
+// Check polls the SMART data of the disk holding repoPath and analyzes the
+// shard read errors of the node peerID. The SMART attributes are compared
+// with the last saved report.
+func Check(ctx context.Context, d ds.Datastore, peerID string, cfg *Config, repoPath string,
+	now time.Time) (*Report, error) {
+	r := &Report{Time: now, Level: OK}
+	dev := cfg.Device
+	var err error
+	if dev == "" {
+		dev, err = DeviceOf(ctx, repoPath)
+	}
+	if err == nil {
+		r.Smart, err = PollSmart(ctx, dev)
+	}
+	if err != nil {
+		r.SmartError = err.Error()
+	} else {
+		prev, err := LastReport(d, peerID)
+		if err != nil {
+			return nil, err
+		}
+		var prevSmart *Smart
+		if prev != nil && prev.Smart != nil && prev.Smart.Device == r.Smart.Device {
+			prevSmart = prev.Smart
+		}
+		r.Smart.analyze(r, prevSmart)
+	}
+
+	r.Reads, err = ReadTrend(d, peerID, now)
+	if err != nil {
+		return nil, err
+	}
+	analyzeReads(r, r.Reads, cfg.MaxReadErrorRate)
+	return r, nil
+}
+
+// LastReport returns the report last saved for the node peerID, nil if
+// there is none.
+func LastReport(d ds.Datastore, peerID string) (*Report, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(reportKey, peerID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("invalid disk health report: %s", err)
+	}
+	return r, nil
+}
+
+// SaveReport saves r as the last report of the node peerID.
+func SaveReport(d ds.Datastore, peerID string, r *Report) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(reportKey, peerID)), b)
+}
+
+// Alert is raised when the disk health gets worse.
+type Alert struct {
+	Time    time.Time
+	Level   Level
+	Reasons []string
+	// MigrateTo is the path the repo is moved to off the failing disk.
+	MigrateTo string `json:",omitempty"`
+}
+
+// PutAlert records a, dropping the oldest alerts once MaxAlerts are kept.
+func PutAlert(d ds.Datastore, peerID string, a *Alert) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	// zero padded for the keys to sort by time
+	k := ds.NewKey(fmt.Sprintf(alertKeyPrefix+"%020d", peerID, a.Time.UnixNano()))
+	if err := d.Put(k, b); err != nil {
+		return err
+	}
+	results, err := d.Query(query.Query{
+		Prefix:   fmt.Sprintf(alertKeyPrefix, peerID),
+		KeysOnly: true,
+		Orders:   []query.Order{query.OrderByKeyDescending{}},
+		Offset:   MaxAlerts,
+	})
+	if err != nil {
+		return err
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := d.Delete(ds.NewKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Alerts returns the alerts raised for the node peerID, latest first.
+func Alerts(d ds.Datastore, peerID string) ([]*Alert, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(alertKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var alerts []*Alert
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		a := &Alert{}
+		if err := json.Unmarshal(r.Value, a); err != nil {
+			return nil, fmt.Errorf("invalid disk health alert %s: %s", r.Key, err)
+		}
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Time.After(alerts[j].Time)
+	})
+	return alerts, nil
+}
@@ -0,0 +1,123 @@
+package diskhealth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+const smartctlFailing = `{
+  "smartctl": {"exit_status": 8},
+  "smart_status": {"passed": false},
+  "ata_smart_attributes": {"table": [
+    {"id": 5, "name": "Reallocated_Sector_Ct", "raw": {"value": 12}},
+    {"id": 9, "name": "Power_On_Hours", "raw": {"value": 20000}},
+    {"id": 197, "name": "Current_Pending_Sector", "raw": {"value": 0}}
+  ]}
+}`
+
+const smartctlNoDevice = `{
+  "smartctl": {"exit_status": 2, "messages": [{"string": "Smartctl open device: /dev/sdz failed"}]}
+}`
+
+func TestSmart(t *testing.T) {
+	s, err := parseSmart("/dev/sda", []byte(smartctlFailing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Passed || len(s.Attributes) != 2 || s.Attributes["Reallocated_Sector_Ct"] != 12 {
+		t.Fatalf("unexpected SMART data %+v", s)
+	}
+	if _, err := parseSmart("/dev/sdz", []byte(smartctlNoDevice)); err == nil {
+		t.Fatal("expected no SMART data for a device which cannot be opened")
+	}
+
+	healthy := &Smart{Device: "/dev/sda", Passed: true, Attributes: map[string]int64{"Reallocated_Sector_Ct": 12}}
+	r := &Report{Level: OK}
+	healthy.analyze(r, nil)
+	if r.Level != Warning {
+		t.Fatalf("expected a warning for reallocated sectors, got %s", r.Level)
+	}
+	r = &Report{Level: OK}
+	healthy.analyze(r, &Smart{Device: "/dev/sda", Attributes: map[string]int64{"Reallocated_Sector_Ct": 4}})
+	if r.Level != Failing {
+		t.Fatalf("expected failing for growing reallocated sectors, got %s %v", r.Level, r.Reasons)
+	}
+}
+
+func TestReadTrend(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Date(2020, 6, 20, 12, 0, 0, 0, time.UTC)
+	record := func(daysAgo, reads, errs int) {
+		at := now.AddDate(0, 0, -daysAgo)
+		for i := 0; i < reads; i++ {
+			var err error
+			if i < errs {
+				err = errors.New("block not found")
+			}
+			if err := RecordRead(d, "QmSelf", at, err); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	record(30, 10, 10)
+	record(5, 50, 1)
+	record(1, 20, 4)
+
+	trend, err := ReadTrend(d, "QmSelf", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trend) != 2 || trend[0].Day != "2020-06-15" || trend[1].Errors != 4 {
+		t.Fatalf("unexpected trend %+v", trend)
+	}
+	r := &Report{Time: now, Level: OK}
+	analyzeReads(r, trend, DefaultMaxReadErrorRate)
+	if r.Level != Failing {
+		t.Fatalf("expected failing for rising read errors, got %s", r.Level)
+	}
+
+	record(8, 50, 10)
+	trend, err = ReadTrend(d, "QmSelf", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = &Report{Time: now, Level: OK}
+	analyzeReads(r, trend, DefaultMaxReadErrorRate)
+	if r.Level != Warning {
+		t.Fatalf("expected a warning for steady read errors, got %s %v", r.Level, r.Reasons)
+	}
+	r = &Report{Time: now, Level: OK}
+	analyzeReads(r, trend, 0.5)
+	if r.Level != OK {
+		t.Fatalf("expected ok below the max rate, got %s", r.Level)
+	}
+
+	if err := ResetReads(d, "QmSelf"); err != nil {
+		t.Fatal(err)
+	}
+	if trend, err = ReadTrend(d, "QmSelf", now); err != nil || len(trend) != 0 {
+		t.Fatalf("expected no reads after reset, got %v %v", trend, err)
+	}
+}
+
+func TestAlerts(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	for i := 0; i < MaxAlerts+5; i++ {
+		a := &Alert{Time: now.Add(time.Duration(i) * time.Minute), Level: Warning}
+		if err := PutAlert(d, "QmSelf", a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alerts, err := Alerts(d, "QmSelf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != MaxAlerts || !alerts[0].Time.Equal(now.Add((MaxAlerts+4)*time.Minute)) {
+		t.Fatalf("expected the latest %d alerts, got %d", MaxAlerts, len(alerts))
+	}
+}
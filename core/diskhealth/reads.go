@@ -0,0 +1,157 @@
+package diskhealth
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+const (
+	// TrendDays is the number of days of shard reads kept.
+	TrendDays = 14
+	// RecentDays are the latest days of the trend compared with the
+	// earlier ones.
+	RecentDays = 3
+	// MinErrors is the number of recent read errors below which the rate
+	// is not significant.
+	MinErrors = 3
+
+	readsKeyPrefix = "/btfs/%s/diskhealth/reads/"
+	dayLayout      = "2006-01-02"
+)
+
+// Reads counts the shard reads of a day answering challenges.
+type Reads struct {
+	Day    string
+	Reads  int
+	Errors int
+}
+
+var readsLk sync.Mutex
+
+// RecordRead counts a shard read of the node peerID at the given time,
+// failed when err is not nil.
+func RecordRead(d ds.Datastore, peerID string, at time.Time, err error) error {
+	readsLk.Lock()
+	defer readsLk.Unlock()
+
+	day := at.UTC().Format(dayLayout)
+	k := ds.NewKey(fmt.Sprintf(readsKeyPrefix, peerID) + day)
+	r := &Reads{Day: day}
+	b, gerr := d.Get(k)
+	if gerr == nil {
+		if jerr := json.Unmarshal(b, r); jerr != nil {
+			return fmt.Errorf("invalid shard reads of %s: %s", day, jerr)
+		}
+	} else if gerr != ds.ErrNotFound {
+		return gerr
+	}
+	r.Reads++
+	if err != nil {
+		r.Errors++
+	}
+	if b, err = json.Marshal(r); err != nil {
+		return err
+	}
+	return d.Put(k, b)
+}
+
+// ReadTrend returns the shard reads of the node peerID of the TrendDays
+// until now, oldest first, and drops the older ones.
+func ReadTrend(d ds.Datastore, peerID string, now time.Time) ([]*Reads, error) {
+	readsLk.Lock()
+	defer readsLk.Unlock()
+
+	prefix := fmt.Sprintf(readsKeyPrefix, peerID)
+	results, err := d.Query(query.Query{Prefix: prefix})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return nil, err
+	}
+	first := now.UTC().AddDate(0, 0, 1-TrendDays).Format(dayLayout)
+	var trend []*Reads
+	for _, e := range entries {
+		r := &Reads{}
+		if err := json.Unmarshal(e.Value, r); err != nil {
+			return nil, fmt.Errorf("invalid shard reads %s: %s", e.Key, err)
+		}
+		if r.Day < first {
+			if err := d.Delete(ds.NewKey(e.Key)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		trend = append(trend, r)
+	}
+	sort.Slice(trend, func(i, j int) bool {
+		return trend[i].Day < trend[j].Day
+	})
+	return trend, nil
+}
+
+// ResetReads drops the shard reads of the node peerID, once its shards are
+// on another disk.
+func ResetReads(d ds.Datastore, peerID string) error {
+	readsLk.Lock()
+	defer readsLk.Unlock()
+
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(readsKeyPrefix, peerID), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := d.Delete(ds.NewKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// analyzeReads raises the level of r when the read error rate of the
+// RecentDays of trend is above maxRate: failing when it is also rising
+// over the earlier days, a warning when it is steady.
+func analyzeReads(r *Report, trend []*Reads, maxRate float64) {
+	if len(trend) == 0 {
+		return
+	}
+	cut := r.Time.UTC().AddDate(0, 0, 1-RecentDays).Format(dayLayout)
+	var recent, earlier Reads
+	for _, t := range trend {
+		c := &earlier
+		if t.Day >= cut {
+			c = &recent
+		}
+		c.Reads += t.Reads
+		c.Errors += t.Errors
+	}
+	if recent.Errors < MinErrors {
+		return
+	}
+	rate := float64(recent.Errors) / float64(recent.Reads)
+	if rate <= maxRate {
+		return
+	}
+	var earlierRate float64
+	if earlier.Reads > 0 {
+		earlierRate = float64(earlier.Errors) / float64(earlier.Reads)
+	}
+	if rate > 2*earlierRate {
+		r.raise(Failing, "shard read errors are rising: %.1f%% of %d reads in the last %d days, %.1f%% before",
+			100*rate, recent.Reads, RecentDays, 100*earlierRate)
+		return
+	}
+	r.raise(Warning, "%.1f%% of %d shard reads failed in the last %d days",
+		100*rate, recent.Reads, RecentDays)
+}
@@ -0,0 +1,162 @@
+package diskhealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// ErrNoSmartctl is returned when smartctl is not installed.
+var ErrNoSmartctl = errors.New("smartctl is not installed")
+
+// predictive are the ATA attributes whose raw value grows as a disk is
+// about to fail.
+var predictive = map[int]string{
+	5:   "Reallocated_Sector_Ct",
+	187: "Reported_Uncorrect",
+	188: "Command_Timeout",
+	197: "Current_Pending_Sector",
+	198: "Offline_Uncorrectable",
+}
+
+// Smart is the SMART data of a disk.
+type Smart struct {
+	Device string
+	// Passed is the overall health self-assessment of the disk.
+	Passed bool
+	// Attributes are the raw values of the attributes predicting failures.
+	Attributes map[string]int64 `json:",omitempty"`
+	// CriticalWarning is the NVMe critical warning bit field.
+	CriticalWarning int64 `json:",omitempty"`
+}
+
+// smartctlOutput is the part of 'smartctl --json' read.
+type smartctlOutput struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String string `json:"string"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	ATA struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMe *struct {
+		CriticalWarning int64 `json:"critical_warning"`
+		MediaErrors     int64 `json:"media_errors"`
+		PercentageUsed  int64 `json:"percentage_used"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// PollSmart reads the SMART data of the disk dev with smartctl.
+func PollSmart(ctx context.Context, dev string) (*Smart, error) {
+	bin, err := exec.LookPath("smartctl")
+	if err != nil {
+		return nil, ErrNoSmartctl
+	}
+	// the exit status is a bit field also set when the disk is failing, the
+	// output tells whether the data was read
+	b, err := exec.CommandContext(ctx, bin, "--json", "-H", "-A", dev).Output()
+	if len(b) == 0 && err != nil {
+		return nil, fmt.Errorf("smartctl %s: %s", dev, err)
+	}
+	return parseSmart(dev, b)
+}
+
+func parseSmart(dev string, b []byte) (*Smart, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("invalid smartctl output: %s", err)
+	}
+	// bits 0 to 2: bad command line, device not opened, SMART command failed
+	if out.Smartctl.ExitStatus&0x7 != 0 || out.SmartStatus == nil {
+		msgs := make([]string, len(out.Smartctl.Messages))
+		for i, m := range out.Smartctl.Messages {
+			msgs[i] = m.String
+		}
+		return nil, fmt.Errorf("no SMART data for %s: %s", dev, strings.Join(msgs, ", "))
+	}
+	s := &Smart{Device: dev, Passed: out.SmartStatus.Passed, Attributes: map[string]int64{}}
+	for _, a := range out.ATA.Table {
+		if name, ok := predictive[a.ID]; ok {
+			s.Attributes[name] = a.Raw.Value
+		}
+	}
+	if out.NVMe != nil {
+		s.CriticalWarning = out.NVMe.CriticalWarning
+		s.Attributes["Media_Errors"] = out.NVMe.MediaErrors
+		s.Attributes["Percentage_Used"] = out.NVMe.PercentageUsed
+	}
+	return s, nil
+}
+
+// analyze raises the level of r from s, and from the attributes which grew
+// since prev when not nil.
+func (s *Smart) analyze(r *Report, prev *Smart) {
+	if !s.Passed {
+		r.raise(Failing, "%s failed its SMART self-assessment", s.Device)
+	}
+	if s.CriticalWarning != 0 {
+		r.raise(Failing, "%s reports the NVMe critical warning %#x", s.Device, s.CriticalWarning)
+	}
+	names := make([]string, 0, len(s.Attributes))
+	for name := range s.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := s.Attributes[name]
+		switch {
+		case name == "Percentage_Used":
+			if v >= 100 {
+				r.raise(Warning, "%s used %d%% of its rated endurance", s.Device, v)
+			}
+		case prev != nil && v > prev.Attributes[name]:
+			r.raise(Failing, "%s %s grew from %d to %d", s.Device, name, prev.Attributes[name], v)
+		case v > 0:
+			r.raise(Warning, "%s %s is %d", s.Device, name, v)
+		}
+	}
+}
+
+// DeviceOf returns the device of the partition mounted on p.
+func DeviceOf(ctx context.Context, p string) (string, error) {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	if real, err := filepath.EvalSymlinks(p); err == nil {
+		p = real
+	}
+	parts, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	dev, mount := "", ""
+	for _, part := range parts {
+		m := part.Mountpoint
+		if len(m) <= len(mount) || !(p == m || strings.HasPrefix(p, strings.TrimSuffix(m, "/")+"/")) {
+			continue
+		}
+		dev, mount = part.Device, m
+	}
+	if dev == "" {
+		return "", fmt.Errorf("no disk found for %s", p)
+	}
+	return dev, nil
+}
@@ -0,0 +1,355 @@
+// Package erasure encodes files in reed-solomon shards with bounded memory.
+// The parity shards are encoded in stripes of a fixed size across the data
+// shards, with the SIMD code paths of the encoder, and written to a
+// temporary file the shard splitters read from, as are the data shards from
+// the file added, spilled to a temporary file when it can not be read at
+// random. The shards and so the DAG are the same as the ones of the
+// reed-solomon chunker, which encodes the whole file in memory.
+package erasure
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	chunker "github.com/TRON-US/go-btfs-chunker"
+	humanize "github.com/dustin/go-humanize"
+	logging "github.com/ipfs/go-log"
+	rs "github.com/klauspost/reedsolomon"
+)
+
+var log = logging.Logger("erasure")
+
+// ConfigKey is the config section of the reed-solomon encodings.
+const ConfigKey = "ReedSolomon"
+
+const (
+	// DefaultMaxMemory is the memory of the stripes of an encoding by
+	// default.
+	DefaultMaxMemory = 64 << 20
+	// minStripe is the smallest stripe encoded in a shard, whatever the
+	// memory bound.
+	minStripe = 64 << 10
+)
+
+// Config configures the reed-solomon encodings.
+type Config struct {
+	// MaxMemory bounds the memory of the stripes of an encoding, e.g. "64MB".
+	MaxMemory string `json:",omitempty"`
+	// TempDir is the directory of the temporary files of the encodings, the
+	// one of the system when empty.
+	TempDir string `json:",omitempty"`
+
+	maxMemory int64
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the reed-solomon encodings config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	c.maxMemory = DefaultMaxMemory
+	if c.MaxMemory != "" {
+		m, err := humanize.ParseBytes(c.MaxMemory)
+		if err != nil || m == 0 {
+			return fmt.Errorf("invalid MaxMemory %q", c.MaxMemory)
+		}
+		c.maxMemory = int64(m)
+	}
+	return nil
+}
+
+// MaxMemoryBytes returns the memory bound of the stripes of an encoding.
+func (c *Config) MaxMemoryBytes() int64 {
+	if c == nil || c.maxMemory == 0 {
+		return DefaultMaxMemory
+	}
+	return c.maxMemory
+}
+
+func (c *Config) tempDir() string {
+	if c == nil {
+		return ""
+	}
+	return c.TempDir
+}
+
+// Stats are the figures of an encoding.
+type Stats struct {
+	Bytes    int64
+	Duration time.Duration
+}
+
+// Rate returns the encoding throughput in bytes per second.
+func (s Stats) Rate() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Duration.Seconds()
+}
+
+// sizedReaderAt is a file which can be read at random.
+type sizedReaderAt interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// Splitter is the chunker.MultiSplitter of a reed-solomon encoding, each
+// of its splitters reading a shard. It must be closed to remove its
+// temporary files.
+type Splitter struct {
+	src      io.ReaderAt
+	parity   *os.File
+	temps    []*os.File
+	numData  uint64
+	numPar   uint64
+	size     uint64
+	fileSize int64
+	perShard int64
+	isDir    bool
+	stats    Stats
+
+	spls     []chunker.Splitter
+	splIndex int
+}
+
+// NewSplitter encodes r in numData data and numParity parity shards split in
+// chunks of size bytes, under the memory bound of c, nil for the defaults.
+func NewSplitter(r io.Reader, numData, numParity, size uint64, c *Config) (*Splitter, error) {
+	if numData == 0 || numParity == 0 || numData+numParity > 256 {
+		return nil, fmt.Errorf("invalid reed-solomon scheme %d+%d", numData, numParity)
+	}
+	s := &Splitter{numData: numData, numPar: numParity, size: size}
+	ok := false
+	defer func() {
+		if !ok {
+			s.Close()
+		}
+	}()
+
+	if f, isSized := r.(sizedReaderAt); isSized {
+		s.src, s.fileSize = f, f.Size()
+	} else {
+		spill, err := s.tempFile(c, "btfs-rs-data-")
+		if err != nil {
+			return nil, err
+		}
+		if s.fileSize, err = io.Copy(spill, r); err != nil {
+			return nil, err
+		}
+		s.src = spill
+	}
+	if s.fileSize == 0 {
+		return nil, errors.New("given file is empty")
+	}
+	s.perShard = (s.fileSize + int64(numData) - 1) / int64(numData)
+
+	parity, err := s.tempFile(c, "btfs-rs-parity-")
+	if err != nil {
+		return nil, err
+	}
+	s.parity = parity
+	if err := s.encode(stripeSize(c.MaxMemoryBytes(), numData+numParity, s.perShard)); err != nil {
+		return nil, err
+	}
+	log.Debugf("encoded %s in %d+%d shards at %s/s", humanize.IBytes(uint64(s.fileSize)),
+		numData, numParity, humanize.IBytes(uint64(s.stats.Rate())))
+
+	for i := 0; i < int(numData+numParity); i++ {
+		s.spls = append(s.spls, chunker.NewSizeSplitter(s.shardReader(i), int64(size)))
+	}
+	ok = true
+	return s, nil
+}
+
+// stripeSize returns the bytes of a shard encoded at once for shards shards
+// of perShard bytes under the memory bound maxMemory.
+func stripeSize(maxMemory int64, shards uint64, perShard int64) int64 {
+	stripe := maxMemory / int64(shards)
+	if stripe < minStripe {
+		stripe = minStripe
+	}
+	if stripe > perShard {
+		stripe = perShard
+	}
+	return stripe
+}
+
+func (s *Splitter) tempFile(c *Config, prefix string) (*os.File, error) {
+	f, err := ioutil.TempFile(c.tempDir(), prefix)
+	if err != nil {
+		return nil, err
+	}
+	s.temps = append(s.temps, f)
+	return f, nil
+}
+
+// readData reads the bytes at off of data shard i into b, padded with
+// zeros past the end of the file.
+func (s *Splitter) readData(i int, off int64, b []byte) error {
+	start := int64(i)*s.perShard + off
+	n := int64(0)
+	if start < s.fileSize {
+		n = s.fileSize - start
+		if n > int64(len(b)) {
+			n = int64(len(b))
+		}
+		if _, err := s.src.ReadAt(b[:n], start); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	for j := n; j < int64(len(b)); j++ {
+		b[j] = 0
+	}
+	return nil
+}
+
+// encode writes the parity shards, encoding stripe bytes of each shard at
+// once.
+func (s *Splitter) encode(stripe int64) error {
+	shards := int(s.numData + s.numPar)
+	enc, err := rs.New(int(s.numData), int(s.numPar), rs.WithAutoGoroutines(int(stripe)))
+	if err != nil {
+		return err
+	}
+	bufs := make([][]byte, shards)
+	for i := range bufs {
+		bufs[i] = make([]byte, stripe)
+	}
+	views := make([][]byte, shards)
+
+	start := time.Now()
+	for off := int64(0); off < s.perShard; off += stripe {
+		n := stripe
+		if off+n > s.perShard {
+			n = s.perShard - off
+		}
+		for i := range views {
+			views[i] = bufs[i][:n]
+		}
+		for i := 0; i < int(s.numData); i++ {
+			if err := s.readData(i, off, views[i]); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(views); err != nil {
+			return err
+		}
+		for j := 0; j < int(s.numPar); j++ {
+			if _, err := s.parity.WriteAt(views[int(s.numData)+j], int64(j)*s.perShard+off); err != nil {
+				return err
+			}
+		}
+	}
+	s.stats = Stats{Bytes: s.fileSize, Duration: time.Since(start)}
+	return nil
+}
+
+// shardReader returns a reader of shard i.
+func (s *Splitter) shardReader(i int) io.Reader {
+	if i >= int(s.numData) {
+		return io.NewSectionReader(s.parity, int64(i-int(s.numData))*s.perShard, s.perShard)
+	}
+	start := int64(i) * s.perShard
+	n := int64(0)
+	if start < s.fileSize {
+		n = s.fileSize - start
+		if n > s.perShard {
+			n = s.perShard
+		}
+	}
+	return io.MultiReader(io.NewSectionReader(s.src, start, n), io.LimitReader(zeros{}, s.perShard-n))
+}
+
+// zeros reads zeros forever.
+type zeros struct{}
+
+func (zeros) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+// Stats returns the figures of the encoding.
+func (s *Splitter) Stats() Stats {
+	return s.stats
+}
+
+// Reader returns a reader of the shards, one after the other.
+func (s *Splitter) Reader() io.Reader {
+	readers := make([]io.Reader, 0, len(s.spls))
+	for i := range s.spls {
+		readers = append(readers, s.shardReader(i))
+	}
+	return io.MultiReader(readers...)
+}
+
+// NextBytes returns the next chunk of the shards, one after the other.
+func (s *Splitter) NextBytes() ([]byte, error) {
+	for s.splIndex < len(s.spls) {
+		b, err := s.spls[s.splIndex].NextBytes()
+		if err != io.EOF {
+			return b, err
+		}
+		s.splIndex++
+	}
+	return nil, io.EOF
+}
+
+// ChunkSize returns the size of the chunks of the shards.
+func (s *Splitter) ChunkSize() uint64 {
+	return s.size
+}
+
+// Splitters returns the splitters of the shards, which can be read
+// concurrently.
+func (s *Splitter) Splitters() []chunker.Splitter {
+	return s.spls
+}
+
+// MetaData returns the reed-solomon scheme of the encoding.
+func (s *Splitter) MetaData() interface{} {
+	return &chunker.RsMetaMap{
+		NumData:   s.numData,
+		NumParity: s.numPar,
+		FileSize:  uint64(s.fileSize),
+		IsDir:     s.isDir,
+	}
+}
+
+// SetIsDir sets whether the file encoded is a directory tree.
+func (s *Splitter) SetIsDir(v bool) {
+	s.isDir = v
+}
+
+// Close removes the temporary files of the encoding.
+func (s *Splitter) Close() error {
+	var first error
+	for _, f := range s.temps {
+		f.Close()
+		if err := os.Remove(f.Name()); err != nil && first == nil {
+			first = err
+		}
+	}
+	s.temps = nil
+	return first
+}
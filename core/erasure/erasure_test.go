@@ -0,0 +1,101 @@
+package erasure
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+
+	chunker "github.com/TRON-US/go-btfs-chunker"
+)
+
+func chunks(t *testing.T, spl chunker.Splitter) [][]byte {
+	var out [][]byte
+	for {
+		b, err := spl.NextBytes()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, b)
+	}
+}
+
+func TestSplitterMatchesChunker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "erasure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// a small bound for several stripes, the last one partial
+	c := &Config{MaxMemory: "100KB", TempDir: dir}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{1, 1000, 1 << 20, 1<<20 + 7} {
+		data := make([]byte, size)
+		rand.Read(data)
+		want, err := chunker.NewReedSolomonSplitter(bytes.NewReader(data), 3, 2, 100000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantChunks := chunks(t, want)
+
+		// read at random, then spilled
+		for _, r := range []io.Reader{bytes.NewReader(data), io.MultiReader(bytes.NewReader(data))} {
+			s, err := NewSplitter(r, 3, 2, 100000, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chunks(t, s); !reflect.DeepEqual(got, wantChunks) {
+				t.Fatalf("size %d: shards differ from the chunker's", size)
+			}
+			if !reflect.DeepEqual(s.MetaData(), want.MetaData()) {
+				t.Fatalf("size %d: metadata %v, expected %v", size, s.MetaData(), want.MetaData())
+			}
+			if len(s.Splitters()) != 5 {
+				t.Fatalf("expected 5 splitters, got %d", len(s.Splitters()))
+			}
+			if s.Stats().Bytes != int64(size) {
+				t.Fatalf("encoded %d bytes instead of %d", s.Stats().Bytes, size)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	left, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Fatalf("%d temporary files left", len(left))
+	}
+}
+
+func TestSplitterEmpty(t *testing.T) {
+	if _, err := NewSplitter(bytes.NewReader(nil), 3, 2, 100000, nil); err == nil {
+		t.Fatal("expected an error for an empty file")
+	}
+}
+
+func TestStripeSize(t *testing.T) {
+	for _, tc := range []struct {
+		maxMemory, perShard, want int64
+	}{
+		{64 << 20, 1 << 30, 2 << 20},
+		{64 << 20, 1 << 20, 1 << 20},
+		{1 << 10, 1 << 30, minStripe},
+	} {
+		if got := stripeSize(tc.maxMemory, 32, tc.perShard); got != tc.want {
+			t.Fatalf("stripeSize(%d, 32, %d) = %d, expected %d", tc.maxMemory, tc.perShard, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package events publishes the events of the node, such as the progress of
+// uploads and the confirmations of wallet transactions, to the clients
+// subscribed through the API. The recent events are kept in memory, so a
+// client reconnecting with the id of the last event it received resumes
+// from it without missing any.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("events")
+
+// Topics of the events.
+const (
+	// TopicUpload events report the states of the upload sessions and the
+	// shards contracted.
+	TopicUpload = "upload"
+	// TopicWallet events report the wallet transactions recorded and their
+	// confirmations.
+	TopicWallet = "wallet"
+)
+
+// Event is an event of the node.
+type Event struct {
+	// ID is unique to the event, see Hub.Since.
+	ID    string
+	Topic string
+	Time  time.Time
+	Data  json.RawMessage
+}
+
+// Hub keeps the recent events of the node and sends the new ones to its
+// subscribers.
+type Hub struct {
+	// epoch tells the ids of this hub from those of previous runs of the
+	// node, which are not kept.
+	epoch string
+
+	mu     sync.Mutex
+	seq    uint64
+	recent []*Event
+	size   int
+	subs   map[*Subscription]struct{}
+}
+
+// NewHub returns a hub keeping the size recent events.
+func NewHub(size int) *Hub {
+	return &Hub{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:  size,
+		subs:  map[*Subscription]struct{}{},
+	}
+}
+
+// Default is the hub of the node.
+var Default = NewHub(1024)
+
+// Publish publishes an event of topic with data, encoded as JSON, on the
+// Default hub.
+func Publish(topic string, data interface{}) {
+	Default.Publish(topic, data)
+}
+
+// Publish publishes an event of topic with data, encoded as JSON.
+func (h *Hub) Publish(topic string, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Errorf("encode %s event: %s", topic, err)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	e := &Event{
+		ID:    fmt.Sprintf("%s-%d", h.epoch, h.seq),
+		Topic: topic,
+		Time:  time.Now(),
+		Data:  b,
+	}
+	h.recent = append(h.recent, e)
+	if len(h.recent) > h.size {
+		h.recent = h.recent[len(h.recent)-h.size:]
+	}
+	for s := range h.subs {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			// a subscriber too slow is dropped, and resumes from the
+			// recent events when it subscribes again
+			log.Warnf("subscriber dropped after %s, too many events pending", e.ID)
+			delete(h.subs, s)
+			close(s.c)
+		}
+	}
+}
+
+// seqOf returns the sequence number of the event id of h, false when the
+// id is not one of h.
+func (h *Hub) seqOf(id string) (uint64, bool) {
+	i := strings.LastIndex(id, "-")
+	if i < 0 || id[:i] != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	return seq, err == nil
+}
+
+// Subscription receives the events of a hub.
+type Subscription struct {
+	h      *Hub
+	c      chan *Event
+	topics map[string]bool
+}
+
+func (s *Subscription) match(e *Event) bool {
+	return len(s.topics) == 0 || s.topics[e.Topic]
+}
+
+// Events returns the channel of the events, closed when the subscription
+// is, or when the subscriber falls behind and is dropped.
+func (s *Subscription) Events() <-chan *Event {
+	return s.c
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+	if _, ok := s.h.subs[s]; ok {
+		delete(s.h.subs, s)
+		close(s.c)
+	}
+}
+
+// Subscribe subscribes to the events of topics, all of them when none,
+// published after the event lastID. It returns the recent events after
+// lastID, the new ones being sent to the subscription, and whether they
+// are complete: false when lastID is too old to be kept, or from a
+// previous run of the node. An empty lastID subscribes to the new events.
+func (h *Hub) Subscribe(lastID string, topics ...string) (*Subscription, []*Event, bool) {
+	s := &Subscription{h: h, c: make(chan *Event, 64), topics: map[string]bool{}}
+	for _, t := range topics {
+		s.topics[t] = true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+	if lastID == "" {
+		return s, nil, true
+	}
+	seq, ok := h.seqOf(lastID)
+	// the recent events hold seq+1 unless it was dropped
+	complete := ok && (len(h.recent) == 0 || seq+1 >= h.firstSeq())
+	var missed []*Event
+	for _, e := range h.recent {
+		if es, _ := h.seqOf(e.ID); ok && es <= seq || !s.match(e) {
+			continue
+		}
+		missed = append(missed, e)
+	}
+	return s, missed, complete
+}
+
+// firstSeq returns the sequence number of the oldest recent event.
+func (h *Hub) firstSeq() uint64 {
+	return h.seq - uint64(len(h.recent)) + 1
+}
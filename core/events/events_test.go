@@ -0,0 +1,90 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	h := NewHub(3)
+	s, missed, complete := h.Subscribe("")
+	if len(missed) != 0 || !complete {
+		t.Fatalf("unexpected recent events %v %v", missed, complete)
+	}
+	h.Publish(TopicUpload, map[string]int{"n": 1})
+	e := <-s.Events()
+	if e.Topic != TopicUpload || string(e.Data) != `{"n":1}` {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	s.Close()
+	// closing twice is fine
+	s.Close()
+	if _, ok := <-s.Events(); ok {
+		t.Fatal("expected the events closed")
+	}
+
+	for i := 2; i <= 4; i++ {
+		h.Publish(TopicWallet, i)
+	}
+	// resuming after the first event, still kept
+	s, missed, complete = h.Subscribe(e.ID)
+	defer s.Close()
+	if len(missed) != 3 || !complete || string(missed[0].Data) != "2" {
+		t.Fatalf("unexpected recent events %v %v", missed, complete)
+	}
+	// resuming after the first event, no longer kept
+	h.Publish(TopicWallet, 5)
+	_, missed, complete = h.Subscribe(e.ID)
+	if len(missed) != 3 || complete || string(missed[0].Data) != "3" {
+		t.Fatalf("unexpected recent events %v %v", missed, complete)
+	}
+	// resuming from a previous run of the node
+	_, missed, complete = h.Subscribe("0-2", TopicUpload)
+	if len(missed) != 0 || complete {
+		t.Fatalf("unexpected recent events %v %v", missed, complete)
+	}
+	_, missed, complete = h.Subscribe(lastID(t, h))
+	if len(missed) != 0 || !complete {
+		t.Fatalf("unexpected recent events %v %v", missed, complete)
+	}
+}
+
+// lastID returns the id of the last event of h.
+func lastID(t *testing.T, h *Hub) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.recent) == 0 {
+		t.Fatal("no recent events")
+	}
+	return h.recent[len(h.recent)-1].ID
+}
+
+func TestTopics(t *testing.T) {
+	h := NewHub(10)
+	s, _, _ := h.Subscribe("", TopicWallet)
+	defer s.Close()
+	h.Publish(TopicUpload, 1)
+	h.Publish(TopicWallet, 2)
+	if e := <-s.Events(); e.Topic != TopicWallet {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	_, missed, _ := h.Subscribe(h.recent[0].ID, TopicUpload)
+	if len(missed) != 0 {
+		t.Fatalf("unexpected recent events %v", missed)
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	h := NewHub(10)
+	s, _, _ := h.Subscribe("")
+	for i := 0; i < cap(s.c)+1; i++ {
+		h.Publish(TopicUpload, i)
+	}
+	n := 0
+	for range s.Events() {
+		n++
+	}
+	if n != cap(s.c) {
+		t.Fatalf("expected %d events before the subscriber was dropped, got %d", cap(s.c), n)
+	}
+	s.Close()
+}
@@ -0,0 +1,79 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/core/remotes"
+
+	shell "github.com/TRON-US/go-btfs-api"
+)
+
+// apiNode is a node reached over its API, as a remote of the CLI.
+type apiNode struct {
+	name string
+	sh   *shell.Shell
+}
+
+// NewNode returns the node of the remote p.
+func NewNode(p *remotes.Profile) (Node, error) {
+	u, err := p.Endpoint()
+	if err != nil {
+		return nil, err
+	}
+	t, err := remotes.NewTransport(p)
+	if err != nil {
+		return nil, err
+	}
+	return &apiNode{
+		name: p.Name,
+		sh:   shell.NewShellWithClient(u.Host+u.Path, &http.Client{Transport: t}),
+	}, nil
+}
+
+func (n *apiNode) Name() string { return n.name }
+
+func (n *apiNode) Version(ctx context.Context) (string, error) {
+	var out struct{ Version string }
+	if err := n.sh.Request("version").Exec(ctx, &out); err != nil {
+		return "", err
+	}
+	return out.Version, nil
+}
+
+func (n *apiNode) Drain(ctx context.Context, on bool) (int, error) {
+	var out struct{ Pending int }
+	if err := n.sh.Request("storage/host/drain").Option("off", !on).Exec(ctx, &out); err != nil {
+		return 0, err
+	}
+	return out.Pending, nil
+}
+
+func (n *apiNode) Update(ctx context.Context) error {
+	return n.sh.Request("update/now").Exec(ctx, nil)
+}
+
+func (n *apiNode) Health(ctx context.Context) error {
+	var out struct {
+		Findings []struct {
+			Check    string
+			Severity string
+			Message  string
+		}
+	}
+	if err := n.sh.Request("doctor").Exec(ctx, &out); err != nil {
+		return err
+	}
+	var critical []string
+	for _, f := range out.Findings {
+		if f.Severity == "critical" {
+			critical = append(critical, fmt.Sprintf("%s: %s", f.Check, f.Message))
+		}
+	}
+	if len(critical) > 0 {
+		return fmt.Errorf("critical findings: %s", strings.Join(critical, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+// Package fleet upgrades the nodes an operator runs, in waves: each node of
+// a wave is drained of its new shards, updated from its release channel,
+// waited for until it is back at the target version, and health-checked.
+// The upgrade stops before the next wave once more nodes failed than the
+// operator allows.
+package fleet
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/remotes"
+)
+
+// Defaults of an upgrade.
+const (
+	DefaultDrainTimeout = 10 * time.Minute
+	DefaultTimeout      = 15 * time.Minute
+	DefaultPoll         = 10 * time.Second
+)
+
+// Status of a node after an upgrade.
+const (
+	StatusUpgraded = "upgraded"
+	StatusSkipped  = "skipped"
+	StatusFailed   = "failed"
+)
+
+// Node is a node of the fleet.
+type Node interface {
+	// Name identifies the node to the operator.
+	Name() string
+	// Version returns the version the node runs.
+	Version(ctx context.Context) (string, error)
+	// Drain has the node refuse new shards when on, or accept them again,
+	// and returns the shards it is still downloading.
+	Drain(ctx context.Context, on bool) (int, error)
+	// Update has the node install the latest release of its channel, and
+	// restart.
+	Update(ctx context.Context) error
+	// Health returns an error when the node reports a critical problem.
+	Health(ctx context.Context) error
+}
+
+// Strategy sizes the waves of an upgrade: a share of the nodes when Percent
+// is set, otherwise Count nodes.
+type Strategy struct {
+	Percent int
+	Count   int
+}
+
+// ParseStrategy parses a wave size, e.g. 10% or 3.
+func ParseStrategy(s string) (Strategy, error) {
+	if p := strings.TrimSuffix(s, "%"); p != s {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 100 {
+			return Strategy{}, fmt.Errorf("invalid strategy %q, expected a share within 1%% and 100%%", s)
+		}
+		return Strategy{Percent: n}, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return Strategy{}, fmt.Errorf("invalid strategy %q, expected a share such as 10%% or a number of nodes", s)
+	}
+	return Strategy{Count: n}, nil
+}
+
+// WaveSize returns the number of nodes of a wave among n, at least one.
+func (s Strategy) WaveSize(n int) int {
+	size := s.Count
+	if s.Percent > 0 {
+		size = (n*s.Percent + 99) / 100
+	}
+	if size < 1 {
+		size = 1
+	}
+	if size > n {
+		size = n
+	}
+	return size
+}
+
+// ParsePeers reads the nodes of a fleet, one per line: the name of a remote
+// of store, or the API address of a node followed by its token if any.
+// Blank lines and lines starting with # are skipped.
+func ParsePeers(r io.Reader, store *remotes.Store) ([]*remotes.Profile, error) {
+	var ps []*remotes.Profile
+	seen := map[string]bool{}
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var p *remotes.Profile
+		switch {
+		case len(fields) > 2:
+			return nil, fmt.Errorf("line %d: expected a remote, or an api address and a token", line)
+		case strings.Contains(fields[0], "/"):
+			p = &remotes.Profile{Name: fields[0], API: fields[0]}
+			if len(fields) == 2 {
+				p.Token = fields[1]
+			}
+			if _, err := p.Endpoint(); err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+		case len(fields) == 2:
+			return nil, fmt.Errorf("line %d: a token is only given with an api address", line)
+		default:
+			var err error
+			if p, err = store.Get(fields[0]); err != nil {
+				return nil, fmt.Errorf("line %d: remote %q: %s", line, fields[0], err)
+			}
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("line %d: %s listed twice", line, p.Name)
+		}
+		seen[p.Name] = true
+		ps = append(ps, p)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(ps) == 0 {
+		return nil, errors.New("no node listed")
+	}
+	return ps, nil
+}
+
+// Options configures an upgrade.
+type Options struct {
+	// Version is the version the nodes are upgraded to.
+	Version  string
+	Strategy Strategy
+	// MaxFailures is the number of nodes allowed to fail before the
+	// upgrade stops.
+	MaxFailures int
+	// DrainTimeout bounds the wait for a node to download its pending
+	// shards, Timeout the wait for it to come back at Version.
+	DrainTimeout time.Duration
+	Timeout      time.Duration
+	// Poll is the interval the nodes are polled at.
+	Poll time.Duration
+}
+
+// Result is the upgrade of a node.
+type Result struct {
+	Node    string
+	Wave    int
+	From    string `json:",omitempty"`
+	To      string `json:",omitempty"`
+	Status  string
+	Error   string `json:",omitempty"`
+	Elapsed time.Duration
+}
+
+// ErrAborted is returned when more nodes failed than allowed.
+var ErrAborted = errors.New("upgrade aborted")
+
+// Upgrade upgrades nodes in the waves of o, passing the result of each node
+// to emit as its wave completes. The nodes of a wave are upgraded at once.
+func Upgrade(ctx context.Context, nodes []Node, o Options, emit func(*Result) error) error {
+	if o.Version == "" {
+		return errors.New("no version to upgrade to")
+	}
+	if o.DrainTimeout <= 0 {
+		o.DrainTimeout = DefaultDrainTimeout
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.Poll <= 0 {
+		o.Poll = DefaultPoll
+	}
+	size := o.Strategy.WaveSize(len(nodes))
+	failures := 0
+	for wave, start := 1, 0; start < len(nodes); wave, start = wave+1, start+size {
+		end := start + size
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+		results := make([]*Result, end-start)
+		var wg sync.WaitGroup
+		for i, n := range nodes[start:end] {
+			wg.Add(1)
+			go func(i int, n Node) {
+				defer wg.Done()
+				results[i] = upgradeNode(ctx, n, o)
+				results[i].Wave = wave
+			}(i, n)
+		}
+		wg.Wait()
+		for _, r := range results {
+			if r.Status == StatusFailed {
+				failures++
+			}
+			if err := emit(r); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if failures > o.MaxFailures {
+			return fmt.Errorf("%w after wave %d: %d nodes failed, %d allowed, %d nodes left as they were",
+				ErrAborted, wave, failures, o.MaxFailures, len(nodes)-end)
+		}
+	}
+	return nil
+}
+
+// upgradeNode upgrades n to o.Version. A node failing before it restarts
+// accepts new shards again.
+func upgradeNode(ctx context.Context, n Node, o Options) *Result {
+	start := time.Now()
+	r := &Result{Node: n.Name(), To: o.Version}
+	fail := func(step string, err error) *Result {
+		r.Status = StatusFailed
+		r.Error = fmt.Sprintf("%s: %s", step, err)
+		r.Elapsed = time.Since(start)
+		return r
+	}
+	from, err := n.Version(ctx)
+	if err != nil {
+		return fail("version", err)
+	}
+	r.From = from
+	if from == o.Version {
+		r.Status = StatusSkipped
+		r.Elapsed = time.Since(start)
+		return r
+	}
+
+	restarted := false
+	defer func() {
+		if !restarted {
+			n.Drain(ctx, false)
+		}
+	}()
+	if err := drain(ctx, n, o); err != nil {
+		return fail("drain", err)
+	}
+	if err := n.Update(ctx); err != nil {
+		return fail("update", err)
+	}
+	if err := poll(ctx, o.Timeout, o.Poll, func() (bool, error) {
+		v, err := n.Version(ctx)
+		if err != nil {
+			// restarting
+			return false, nil
+		}
+		if v != from {
+			restarted = true
+		}
+		if v != o.Version && v != from {
+			return false, fmt.Errorf("came back at %s", v)
+		}
+		return v == o.Version, nil
+	}); err != nil {
+		return fail("restart", err)
+	}
+	if err := n.Health(ctx); err != nil {
+		return fail("health", err)
+	}
+	r.Status = StatusUpgraded
+	r.Elapsed = time.Since(start)
+	return r
+}
+
+// drain has n refuse new shards and waits for its pending ones.
+func drain(ctx context.Context, n Node, o Options) error {
+	return poll(ctx, o.DrainTimeout, o.Poll, func() (bool, error) {
+		pending, err := n.Drain(ctx, true)
+		if err != nil {
+			return false, err
+		}
+		return pending == 0, nil
+	})
+}
+
+// poll calls done every interval until it is done or fails, for at most
+// timeout.
+func poll(ctx context.Context, timeout, interval time.Duration, done func() (bool, error)) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		ok, err := done()
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("timed out after %s", timeout)
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/remotes"
+)
+
+// fakeNode upgrades to its release when updated, unless broken.
+type fakeNode struct {
+	name    string
+	mu      sync.Mutex
+	version string
+	release string
+	pending int
+	drained bool
+	updated bool
+	broken  bool
+	sick    bool
+}
+
+func (n *fakeNode) Name() string { return n.name }
+
+func (n *fakeNode) Version(ctx context.Context) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.version, nil
+}
+
+func (n *fakeNode) Drain(ctx context.Context, on bool) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.drained = on
+	pending := n.pending
+	if n.pending > 0 {
+		n.pending--
+	}
+	return pending, nil
+}
+
+func (n *fakeNode) Update(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.updated = true
+	if !n.broken {
+		// the restart accepts shards again
+		n.version, n.drained = n.release, false
+	}
+	return nil
+}
+
+func (n *fakeNode) Health(ctx context.Context) error {
+	if n.sick {
+		return errors.New("critical findings")
+	}
+	return nil
+}
+
+func fleet(n int) ([]Node, []*fakeNode) {
+	var nodes []Node
+	var fakes []*fakeNode
+	for i := 0; i < n; i++ {
+		f := &fakeNode{name: string(rune('a' + i)), version: "1.4.0", release: "1.5.0", pending: 1}
+		nodes = append(nodes, f)
+		fakes = append(fakes, f)
+	}
+	return nodes, fakes
+}
+
+func TestWaveSize(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		n    int
+		want int
+	}{
+		{"10%", 30, 3},
+		{"10%", 5, 1},
+		{"34%", 10, 4},
+		{"100%", 7, 7},
+		{"3", 10, 3},
+		{"3", 2, 2},
+	} {
+		s, err := ParseStrategy(c.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := s.WaveSize(c.n); size != c.want {
+			t.Errorf("%s of %d: expected %d, got %d", c.s, c.n, c.want, size)
+		}
+	}
+	for _, s := range []string{"0%", "101%", "0", "-1", "ten"} {
+		if _, err := ParseStrategy(s); err == nil {
+			t.Errorf("expected %q refused", s)
+		}
+	}
+}
+
+func TestParsePeers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fleet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := remotes.Open(dir)
+	if err := store.Add(&remotes.Profile{Name: "host1", API: "https://host1:5001", Token: "secret"}, false); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := ParsePeers(strings.NewReader(`
+# hosts of the eu region
+host1
+/dns4/host2/tcp/5001 token2
+http://host3:5001
+`), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 3 || ps[0].Token != "secret" || ps[1].Token != "token2" || ps[2].API != "http://host3:5001" {
+		t.Fatalf("unexpected peers %+v", ps)
+	}
+	for _, s := range []string{"", "host4", "host1\nhost1", "host1 token", "ftp://host5 token", "/dns4/a/tcp/1 t extra"} {
+		if _, err := ParsePeers(strings.NewReader(s), store); err == nil {
+			t.Errorf("expected %q refused", s)
+		}
+	}
+}
+
+func options() Options {
+	return Options{Version: "1.5.0", Strategy: Strategy{Count: 2}, Poll: time.Millisecond,
+		DrainTimeout: time.Second, Timeout: 50 * time.Millisecond}
+}
+
+func TestUpgrade(t *testing.T) {
+	nodes, fakes := fleet(5)
+	fakes[1].version = "1.5.0"
+	var results []*Result
+	err := Upgrade(context.Background(), nodes, options(), func(r *Result) error {
+		results = append(results, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5 || results[4].Wave != 3 {
+		t.Fatalf("expected 5 nodes in 3 waves, got %+v", results)
+	}
+	for i, r := range results {
+		want := StatusUpgraded
+		if i == 1 {
+			want = StatusSkipped
+		}
+		if r.Status != want || r.Node != fakes[i].name {
+			t.Errorf("expected %s %s, got %+v", fakes[i].name, want, r)
+		}
+		if fakes[i].drained {
+			t.Errorf("%s left drained", fakes[i].name)
+		}
+	}
+	if fakes[1].updated {
+		t.Error("a node at the version updated")
+	}
+}
+
+func TestUpgradeAborts(t *testing.T) {
+	nodes, fakes := fleet(5)
+	fakes[0].broken = true
+	fakes[1].sick = true
+	o := options()
+	o.MaxFailures = 1
+	var results []*Result
+	err := Upgrade(context.Background(), nodes, o, func(r *Result) error {
+		results = append(results, r)
+		return nil
+	})
+	if !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected the upgrade aborted, got %v", err)
+	}
+	if len(results) != 2 || results[0].Status != StatusFailed || results[1].Status != StatusFailed {
+		t.Fatalf("expected the first wave failed, got %+v", results)
+	}
+	if !strings.HasPrefix(results[0].Error, "restart") || !strings.HasPrefix(results[1].Error, "health") {
+		t.Fatalf("unexpected failures %+v %+v", results[0], results[1])
+	}
+	if fakes[0].drained {
+		t.Error("a node failing to restart left drained")
+	}
+	if fakes[2].updated {
+		t.Error("the next wave updated")
+	}
+}
+
+func TestUpgradeDrainTimeout(t *testing.T) {
+	nodes, fakes := fleet(1)
+	fakes[0].pending = 1000
+	o := options()
+	o.DrainTimeout = 20 * time.Millisecond
+	err := Upgrade(context.Background(), nodes, o, func(r *Result) error {
+		if r.Status != StatusFailed || !strings.HasPrefix(r.Error, "drain") {
+			t.Fatalf("expected the drain timed out, got %+v", r)
+		}
+		return nil
+	})
+	if !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected the upgrade aborted, got %v", err)
+	}
+	if fakes[0].updated || fakes[0].drained {
+		t.Fatalf("expected the node left running undrained, got %+v", fakes[0])
+	}
+}
@@ -0,0 +1,243 @@
+// Package guardqueue persists the file store statuses a renter submits to
+// the guard, so that a submission interrupted by a failure or by the daemon
+// being killed is retried instead of dropped.
+//
+// A status is enqueued before it is submitted and removed once the guard
+// accepted it. Failed submissions are retried with an exponential backoff,
+// see Retry.
+package guardqueue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	entryKeyPrefix = "/btfs/%s/guard/queue/"
+
+	// MinBackoff is the wait before retrying a submission failed once,
+	// doubled at each failure up to MaxBackoff.
+	MinBackoff = time.Minute
+	MaxBackoff = time.Hour
+	// InFlight is the wait before retrying a submission enqueued, in case
+	// it was interrupted.
+	InFlight = 10 * time.Minute
+)
+
+func init() {
+	prometheus.MustRegister(queueDepth, oldestPending)
+}
+
+var (
+	queueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "btfs",
+		Subsystem: "guard",
+		Name:      "queue_depth",
+		Help:      "File store statuses waiting to be submitted to the guard.",
+	})
+	oldestPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "btfs",
+		Subsystem: "guard",
+		Name:      "queue_oldest_pending_seconds",
+		Help:      "Age of the oldest file store status waiting to be submitted to the guard.",
+	})
+)
+
+// Entry is a file store status waiting to be submitted to the guard.
+type Entry struct {
+	FileHash string
+	// Status is the protobuf encoded file store status.
+	Status   []byte
+	Enqueued time.Time
+	// Attempts is the number of failed submissions.
+	Attempts    int
+	LastAttempt time.Time
+	// NextAttempt is when the submission is retried.
+	NextAttempt time.Time
+	LastError   string `json:",omitempty"`
+}
+
+// FileStoreStatus decodes the status of e.
+func (e *Entry) FileStoreStatus() (*guardpb.FileStoreStatus, error) {
+	s := &guardpb.FileStoreStatus{}
+	if err := s.Unmarshal(e.Status); err != nil {
+		return nil, fmt.Errorf("invalid file store status of %s: %s", e.FileHash, err)
+	}
+	return s, nil
+}
+
+// Backoff returns the wait before retrying a submission failed attempts
+// times.
+func Backoff(attempts int) time.Duration {
+	d := MinBackoff
+	for i := 1; i < attempts && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > MaxBackoff {
+		d = MaxBackoff
+	}
+	return d
+}
+
+func entryKey(peerID, fileHash string) ds.Key {
+	return ds.NewKey(fmt.Sprintf(entryKeyPrefix, peerID) + fileHash)
+}
+
+func put(d ds.Datastore, peerID string, e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return d.Put(entryKey(peerID, e.FileHash), b)
+}
+
+// Enqueue persists s before its submission, replacing the entry of the same
+// file. The entry is due for retry after InFlight.
+func Enqueue(d ds.Datastore, peerID string, s *guardpb.FileStoreStatus, now time.Time) (*Entry, error) {
+	b, err := s.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{FileHash: s.FileHash, Status: b, Enqueued: now, NextAttempt: now.Add(InFlight)}
+	if err := put(d, peerID, e); err != nil {
+		return nil, err
+	}
+	return e, observe(d, peerID, now)
+}
+
+// Get returns the entry of fileHash, ds.ErrNotFound when none is queued.
+func Get(d ds.Datastore, peerID, fileHash string) (*Entry, error) {
+	b, err := d.Get(entryKey(peerID, fileHash))
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{}
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil, fmt.Errorf("invalid guard queue entry %s: %s", fileHash, err)
+	}
+	return e, nil
+}
+
+// Done removes the entry of fileHash once the guard accepted it.
+func Done(d ds.Datastore, peerID, fileHash string, now time.Time) error {
+	if err := d.Delete(entryKey(peerID, fileHash)); err != nil && err != ds.ErrNotFound {
+		return err
+	}
+	return observe(d, peerID, now)
+}
+
+// Failed records the failed submission of e, which is retried after its
+// backoff.
+func Failed(d ds.Datastore, peerID string, e *Entry, err error, now time.Time) error {
+	e.Attempts++
+	e.LastAttempt = now
+	e.NextAttempt = now.Add(Backoff(e.Attempts))
+	e.LastError = err.Error()
+	return put(d, peerID, e)
+}
+
+// List returns the entries queued, oldest first.
+func List(d ds.Datastore, peerID string) ([]*Entry, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(entryKeyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var entries []*Entry
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		e := &Entry{}
+		if err := json.Unmarshal(r.Value, e); err != nil {
+			return nil, fmt.Errorf("invalid guard queue entry %s: %s", r.Key, err)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Enqueued.Before(entries[j].Enqueued) })
+	return entries, nil
+}
+
+// Stats returns the number of entries and the age of the oldest one.
+func Stats(entries []*Entry, now time.Time) (int, time.Duration) {
+	var oldest time.Duration
+	for _, e := range entries {
+		if age := now.Sub(e.Enqueued); age > oldest {
+			oldest = age
+		}
+	}
+	return len(entries), oldest
+}
+
+// observe updates the metrics of the queue of peerID.
+func observe(d ds.Datastore, peerID string, now time.Time) error {
+	entries, err := List(d, peerID)
+	if err != nil {
+		return err
+	}
+	depth, oldest := Stats(entries, now)
+	queueDepth.Set(float64(depth))
+	oldestPending.Set(oldest.Seconds())
+	return nil
+}
+
+// SubmitFunc submits a file store status to the guard.
+type SubmitFunc func(ctx context.Context, s *guardpb.FileStoreStatus) error
+
+// Result is the outcome of the retries of the queue.
+type Result struct {
+	Submitted int
+	Failed    int
+	// Pending is the number of entries left in the queue.
+	Pending int
+}
+
+// Retry submits the entries due at now, or all the entries when force is
+// set. When fileHashes are given, only their entries are retried.
+func Retry(ctx context.Context, d ds.Datastore, peerID string, submit SubmitFunc, now time.Time,
+	force bool, fileHashes ...string) (*Result, error) {
+	entries, err := List(d, peerID)
+	if err != nil {
+		return nil, err
+	}
+	only := map[string]bool{}
+	for _, h := range fileHashes {
+		only[h] = true
+	}
+	r := &Result{}
+	for _, e := range entries {
+		if len(only) > 0 && !only[e.FileHash] || !force && now.Before(e.NextAttempt) {
+			r.Pending++
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		s, err := e.FileStoreStatus()
+		if err == nil {
+			err = submit(ctx, s)
+		}
+		if err != nil {
+			r.Failed++
+			r.Pending++
+			if err := Failed(d, peerID, e, err, now); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := d.Delete(entryKey(peerID, e.FileHash)); err != nil && err != ds.ErrNotFound {
+			return nil, err
+		}
+		r.Submitted++
+	}
+	return r, observe(d, peerID, now)
+}
@@ -0,0 +1,111 @@
+package guardqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+const peer = "peer"
+
+func status(fileHash string) *guardpb.FileStoreStatus {
+	return &guardpb.FileStoreStatus{
+		FileStoreMeta: guardpb.FileStoreMeta{FileHash: fileHash, ShardCount: 3},
+		RentalState:   guardpb.FileStoreStatus_NEW,
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		0:  time.Minute,
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		4:  8 * time.Minute,
+		7:  time.Hour,
+		30: time.Hour,
+	} {
+		if got := Backoff(attempts); got != want {
+			t.Errorf("backoff after %d attempts: expected %s, got %s", attempts, want, got)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+
+	a, err := Enqueue(d, peer, status("a"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Enqueue(d, peer, status("b"), now.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Failed(d, peer, a, errors.New("unavailable"), now); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := List(d, peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].FileHash != "a" || entries[0].Attempts != 1 ||
+		entries[0].LastError != "unavailable" || !entries[0].NextAttempt.Equal(now.Add(MinBackoff)) {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if n, oldest := Stats(entries, now.Add(time.Minute)); n != 2 || oldest != time.Minute {
+		t.Fatalf("expected 2 entries, the oldest of 1m, got %d, %s", n, oldest)
+	}
+
+	var submitted []string
+	fail := map[string]bool{"b": true}
+	submit := func(ctx context.Context, s *guardpb.FileStoreStatus) error {
+		submitted = append(submitted, s.FileHash)
+		if s.ShardCount != 3 {
+			t.Fatalf("unexpected status %v", s)
+		}
+		if fail[s.FileHash] {
+			return errors.New("rejected")
+		}
+		return nil
+	}
+
+	// a is due, b is in flight
+	r, err := Retry(context.Background(), d, peer, submit, now.Add(MinBackoff), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Submitted != 1 || r.Failed != 0 || r.Pending != 1 || len(submitted) != 1 || submitted[0] != "a" {
+		t.Fatalf("expected a submitted, got %+v, %v", r, submitted)
+	}
+	if _, err := Get(d, peer, "a"); err != ds.ErrNotFound {
+		t.Fatalf("expected a done, got %v", err)
+	}
+
+	r, err = Retry(context.Background(), d, peer, submit, now.Add(MinBackoff), true, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Submitted != 0 || r.Failed != 1 || r.Pending != 1 {
+		t.Fatalf("expected b failed, got %+v", r)
+	}
+	b, err := Get(d, peer, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Attempts != 1 || b.LastError != "rejected" {
+		t.Fatalf("unexpected entry %+v", b)
+	}
+
+	if err := Done(d, peer, "b", now); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := List(d, peer); err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty queue, got %v, %v", entries, err)
+	}
+}
@@ -0,0 +1,110 @@
+// Package history keeps periodic samples of node statistics in the
+// datastore, for lightweight charting without external monitoring.
+//
+// Every metric is a ring buffer of Capacity slots, one per Interval: a new
+// sample overwrites the one taken Retention earlier, so the history never
+// grows past Capacity samples per metric.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// Metrics sampled by the daemon.
+const (
+	// Bandwidth has the "total_in", "total_out" (bytes), "rate_in" and
+	// "rate_out" (bytes/s) series.
+	Bandwidth = "bw"
+	// Repo has the "size" (bytes) and "objects" series.
+	Repo = "repo"
+	// Peers has the "count" series.
+	Peers = "peers"
+	// Contracts has the "active" series, the active host contracts.
+	Contracts = "contracts"
+	// Earnings has the "paid" and "outstanding" series, in µBTT, of the
+	// host contracts.
+	Earnings = "earnings"
+)
+
+// Metrics lists all metrics.
+var Metrics = []string{Bandwidth, Repo, Peers, Contracts, Earnings}
+
+const (
+	// Interval is the time between two samples of a metric.
+	Interval = 10 * time.Minute
+	// Retention is how long samples are kept.
+	Retention = 30 * 24 * time.Hour
+	// Capacity is the number of samples kept per metric.
+	Capacity = int64(Retention / Interval)
+
+	keyPrefix = "/btfs/%s/stats/history/%s/"
+)
+
+// Sample is the value of the series of a metric at some time.
+type Sample struct {
+	Time   time.Time
+	Values map[string]float64
+}
+
+// IsMetric reports whether m is a known metric.
+func IsMetric(m string) bool {
+	for _, known := range Metrics {
+		if m == known {
+			return true
+		}
+	}
+	return false
+}
+
+func prefix(peerID, metric string) string {
+	return fmt.Sprintf(keyPrefix, peerID, metric)
+}
+
+// slot returns the ring buffer slot of the samples taken at t.
+func slot(t time.Time) int64 {
+	return t.Unix() / int64(Interval/time.Second) % Capacity
+}
+
+// Record saves the sample s of metric for the node peerID, replacing the
+// oldest sample once the ring buffer is full.
+func Record(d ds.Datastore, peerID, metric string, s *Sample) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf("%s%d", prefix(peerID, metric), slot(s.Time))), b)
+}
+
+// Query returns the samples of metric for the node peerID taken since the
+// given time, oldest first.
+func Query(d ds.Datastore, peerID, metric string, since time.Time) ([]*Sample, error) {
+	results, err := d.Query(query.Query{Prefix: prefix(peerID, metric)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var samples []*Sample
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		s := &Sample{}
+		if err := json.Unmarshal(r.Value, s); err != nil {
+			return nil, fmt.Errorf("invalid %s sample %s: %s", metric, r.Key, err)
+		}
+		if !s.Time.Before(since) {
+			samples = append(samples, s)
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+	return samples, nil
+}
@@ -0,0 +1,61 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+)
+
+func TestRecordQuery(t *testing.T) {
+	d := syncds.MutexWrap(ds.NewMapDatastore())
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		s := &Sample{Time: start.Add(time.Duration(i) * Interval), Values: map[string]float64{"count": float64(i)}}
+		if err := Record(d, "peer", Peers, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Another node and another metric are kept apart.
+	if err := Record(d, "other", Peers, &Sample{Time: start}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Record(d, "peer", Repo, &Sample{Time: start}); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := Query(d, "peer", Peers, start.Add(2*Interval))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 3 {
+		t.Fatalf("got %d samples, want 3", len(samples))
+	}
+	for i, s := range samples {
+		if s.Values["count"] != float64(i+2) || !s.Time.Equal(start.Add(time.Duration(i+2)*Interval)) {
+			t.Errorf("sample %d: %+v", i, s)
+		}
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	d := syncds.MutexWrap(ds.NewMapDatastore())
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := &Sample{Time: start, Values: map[string]float64{"count": 1}}
+	if err := Record(d, "peer", Peers, old); err != nil {
+		t.Fatal(err)
+	}
+	// The same slot a retention period later replaces the old sample.
+	recent := &Sample{Time: start.Add(Retention), Values: map[string]float64{"count": 2}}
+	if err := Record(d, "peer", Peers, recent); err != nil {
+		t.Fatal(err)
+	}
+	samples, err := Query(d, "peer", Peers, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 || samples[0].Values["count"] != 2 {
+		t.Errorf("got %+v", samples)
+	}
+}
@@ -0,0 +1,563 @@
+// Package hostgossip shares the availability of the hosts observed by the
+// nodes, so renters skip the hosts found down since the hub last synced.
+//
+// Nodes opting in publish the hosts they reached, or failed to reach, on a
+// pubsub topic every Interval, in reports signed with their key. The
+// observations of the other nodes are weighted by the trust in their
+// reporter: a reporter starts with little trust, gains some when it agrees
+// with what the node observed itself and loses more when it does not, and
+// the reporters of the HostGossip config are trusted or ignored. A host is
+// only found down on the observations of the node, of trusted reporters or
+// of several reporters agreeing, so a few peers lying cannot rule it out.
+package hostgossip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	logging "github.com/ipfs/go-log"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var log = logging.Logger("hostgossip")
+
+// ConfigKey is the config section of the host gossip.
+const ConfigKey = "HostGossip"
+
+// Topic is the pubsub topic of the reports.
+const Topic = "/btfs/hosts/availability/1.0.0"
+
+const (
+	defaultInterval = 5 * time.Minute
+	defaultMaxAge   = 30 * time.Minute
+
+	// MaxObservations bounds the observations of a report.
+	MaxObservations = 256
+	// maxSkew is how far in the future a report may be dated.
+	maxSkew = time.Minute
+	// maxReporters bounds the reporters counted for a host, the most
+	// trusted.
+	maxReporters = 8
+	// reporterRetention is how long a reporter silent is remembered.
+	reporterRetention = 24 * time.Hour
+)
+
+// Trust of the reporters.
+const (
+	// InitialTrust is the trust in a reporter not heard of.
+	InitialTrust = 0.2
+	// trustGain and trustLoss move the trust of a reporter agreeing and
+	// disagreeing with the node.
+	trustGain = 0.05
+	trustLoss = 0.1
+	// minWeight is the weight of the observations needed to tell whether
+	// a host is up or down: the node, a trusted reporter or several
+	// reporters.
+	minWeight = 0.5
+)
+
+// Statuses of the hosts.
+const (
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusUnknown = "unknown"
+)
+
+// Config enables the host gossip.
+type Config struct {
+	Enabled bool `json:",omitempty"`
+	// Interval is the period of the reports of the node, 5m by default.
+	Interval string `json:",omitempty"`
+	// MaxAge is the age past which observations are dropped, 30m by
+	// default.
+	MaxAge string `json:",omitempty"`
+	// Trusted are the peer IDs of the reporters trusted as the node.
+	Trusted []string `json:",omitempty"`
+	// Ignored are the peer IDs of the reporters ignored.
+	Ignored []string `json:",omitempty"`
+
+	interval, maxAge time.Duration
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the host gossip config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	c.interval, c.maxAge = defaultInterval, defaultMaxAge
+	var err error
+	if c.Interval != "" {
+		if c.interval, err = time.ParseDuration(c.Interval); err != nil || c.interval <= 0 {
+			return fmt.Errorf("invalid Interval %q", c.Interval)
+		}
+	}
+	if c.MaxAge != "" {
+		if c.maxAge, err = time.ParseDuration(c.MaxAge); err != nil || c.maxAge <= 0 {
+			return fmt.Errorf("invalid MaxAge %q", c.MaxAge)
+		}
+	}
+	if c.maxAge < c.interval {
+		return fmt.Errorf("MaxAge %s shorter than Interval %s", c.maxAge, c.interval)
+	}
+	for _, ids := range [][]string{c.Trusted, c.Ignored} {
+		for _, id := range ids {
+			if _, err := peer.IDB58Decode(id); err != nil {
+				return fmt.Errorf("invalid peer ID %q", id)
+			}
+		}
+	}
+	return nil
+}
+
+// Observation is whether a host answered a node at Time.
+type Observation struct {
+	Host string
+	Up   bool
+	Time time.Time
+}
+
+// Report is the observations of a node, signed with its key.
+type Report struct {
+	Reporter     string
+	Time         time.Time
+	Observations []*Observation
+	Signature    []byte `json:",omitempty"`
+}
+
+func (r *Report) signedBytes() ([]byte, error) {
+	c := *r
+	c.Signature = nil
+	return json.Marshal(&c)
+}
+
+// Sign signs r with key, the key of its reporter.
+func (r *Report) Sign(key ic.PrivKey) error {
+	b, err := r.signedBytes()
+	if err != nil {
+		return err
+	}
+	r.Signature, err = key.Sign(b)
+	return err
+}
+
+// Verify checks r is signed by its reporter.
+func (r *Report) Verify() error {
+	id, err := peer.IDB58Decode(r.Reporter)
+	if err != nil {
+		return fmt.Errorf("invalid reporter %q", r.Reporter)
+	}
+	pk, err := id.ExtractPublicKey()
+	if err != nil {
+		return fmt.Errorf("no public key in reporter %s: %s", r.Reporter, err)
+	}
+	b, err := r.signedBytes()
+	if err != nil {
+		return err
+	}
+	if ok, err := pk.Verify(b, r.Signature); err != nil || !ok {
+		return fmt.Errorf("invalid signature of reporter %s", r.Reporter)
+	}
+	return nil
+}
+
+// Errors of the reports refused.
+var (
+	ErrTooFrequent = errors.New("report too frequent")
+	ErrStale       = errors.New("report out of date")
+	ErrIgnored     = errors.New("reporter ignored")
+)
+
+// Table keeps the observations of the node and those reported by the
+// other nodes, by host.
+type Table struct {
+	self     string
+	interval time.Duration
+	maxAge   time.Duration
+	trusted  map[string]bool
+	ignored  map[string]bool
+
+	mu sync.Mutex
+	// own are the observations of the node by host.
+	own map[string]*Observation
+	// heard are the observations reported by host, then reporter.
+	heard     map[string]map[string]*Observation
+	reporters map[string]*Reporter
+}
+
+// Reporter is a node whose reports were received.
+type Reporter struct {
+	ID string
+	// Trust weighs the observations of the reporter, from 0 to 1.
+	Trust float64
+	// Fixed is set for the reporters of the config.
+	Fixed      bool `json:",omitempty"`
+	LastReport time.Time
+	Agreed     int
+	Disagreed  int
+}
+
+// NewTable returns the table of the node self under c.
+func NewTable(self string, c *Config) *Table {
+	t := &Table{
+		self:      self,
+		interval:  c.interval,
+		maxAge:    c.maxAge,
+		trusted:   map[string]bool{},
+		ignored:   map[string]bool{},
+		own:       map[string]*Observation{},
+		heard:     map[string]map[string]*Observation{},
+		reporters: map[string]*Reporter{},
+	}
+	for _, id := range c.Trusted {
+		t.trusted[id] = true
+	}
+	for _, id := range c.Ignored {
+		t.ignored[id] = true
+	}
+	return t
+}
+
+// Interval returns the period of the reports of the node.
+func (t *Table) Interval() time.Duration {
+	return t.interval
+}
+
+// Observe records that the node reached the host, or failed to, at now.
+func (t *Table) Observe(host string, up bool, now time.Time) {
+	if host == "" || host == t.self {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.own[host] = &Observation{Host: host, Up: up, Time: now}
+}
+
+// Report returns the report of the observations of the node younger than
+// MaxAge, the most recent MaxObservations, unsigned. It drops the
+// observations older.
+func (t *Table) Report(now time.Time) *Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune(now)
+	r := &Report{Reporter: t.self, Time: now}
+	for _, o := range t.own {
+		c := *o
+		r.Observations = append(r.Observations, &c)
+	}
+	sort.Slice(r.Observations, func(i, j int) bool {
+		return r.Observations[i].Time.After(r.Observations[j].Time)
+	})
+	if len(r.Observations) > MaxObservations {
+		r.Observations = r.Observations[:MaxObservations]
+	}
+	return r
+}
+
+// prune drops the observations older than MaxAge and the reporters silent
+// for long. t.mu is held.
+func (t *Table) prune(now time.Time) {
+	for host, o := range t.own {
+		if now.Sub(o.Time) > t.maxAge {
+			delete(t.own, host)
+		}
+	}
+	for host, byReporter := range t.heard {
+		for id, o := range byReporter {
+			if now.Sub(o.Time) > t.maxAge {
+				delete(byReporter, id)
+			}
+		}
+		if len(byReporter) == 0 {
+			delete(t.heard, host)
+		}
+	}
+	for id, rep := range t.reporters {
+		if now.Sub(rep.LastReport) > reporterRetention {
+			delete(t.reporters, id)
+		}
+	}
+}
+
+// Check returns why r would be refused at now, without its signature,
+// nil if none.
+func (t *Table) Check(r *Report, now time.Time) error {
+	if r.Reporter == t.self {
+		return fmt.Errorf("report of the node")
+	}
+	if t.ignored[r.Reporter] {
+		return ErrIgnored
+	}
+	if r.Time.After(now.Add(maxSkew)) || now.Sub(r.Time) > t.maxAge {
+		return ErrStale
+	}
+	if len(r.Observations) > MaxObservations {
+		return fmt.Errorf("more than %d observations", MaxObservations)
+	}
+	return nil
+}
+
+// Receive records the observations of r, a report received at now, and
+// moves the trust of its reporter by how they agree with those of the
+// node. A reporter sends a report per interval at most.
+func (t *Table) Receive(r *Report, now time.Time) error {
+	if err := t.Check(r, now); err != nil {
+		return err
+	}
+	if err := r.Verify(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rep, ok := t.reporters[r.Reporter]
+	if !ok {
+		rep = &Reporter{ID: r.Reporter, Trust: InitialTrust}
+		if t.trusted[r.Reporter] {
+			rep.Trust, rep.Fixed = 1, true
+		}
+		t.reporters[r.Reporter] = rep
+	}
+	// half an interval of leeway for the jitter of the publications
+	if !rep.LastReport.IsZero() && r.Time.Sub(rep.LastReport) < t.interval/2 {
+		return ErrTooFrequent
+	}
+	rep.LastReport = r.Time
+	for _, o := range r.Observations {
+		if o.Host == "" || o.Host == r.Reporter || o.Time.After(r.Time) || r.Time.Sub(o.Time) > t.maxAge {
+			continue
+		}
+		if own, ok := t.own[o.Host]; ok && absDuration(own.Time.Sub(o.Time)) <= t.interval {
+			if own.Up == o.Up {
+				rep.Agreed++
+				if !rep.Fixed {
+					rep.Trust = minFloat(1, rep.Trust+trustGain)
+				}
+			} else {
+				rep.Disagreed++
+				if !rep.Fixed {
+					rep.Trust = maxFloat(0, rep.Trust-trustLoss)
+				}
+			}
+		}
+		byReporter, ok := t.heard[o.Host]
+		if !ok {
+			byReporter = map[string]*Observation{}
+			t.heard[o.Host] = byReporter
+		}
+		c := *o
+		byReporter[r.Reporter] = &c
+	}
+	return nil
+}
+
+// Availability is the availability of a host from the observations of the
+// node and the reports.
+type Availability struct {
+	Host   string
+	Status string
+	// Score is the weighted mean of the observations, from -1 when all
+	// found the host down to 1 when all reached it.
+	Score float64
+	// Weight is the sum of the weights of the observations.
+	Weight    float64
+	Reporters int
+	// Own is the observation of the node, if any.
+	Own *Observation `json:",omitempty"`
+	// LastUp is the time the host was last reached.
+	LastUp time.Time `json:",omitempty"`
+}
+
+// Availability returns the availability of host at now.
+func (t *Table) Availability(host string, now time.Time) *Availability {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.availability(host, now)
+}
+
+// availability returns the availability of host at now. t.mu is held.
+func (t *Table) availability(host string, now time.Time) *Availability {
+	a := &Availability{Host: host, Status: StatusUnknown}
+	sum := 0.0
+	add := func(o *Observation, trust float64) {
+		age := now.Sub(o.Time)
+		if age > t.maxAge || age < -maxSkew {
+			return
+		}
+		// the observations fade as they age
+		w := trust * (1 - float64(age)/float64(t.maxAge))
+		if w <= 0 {
+			return
+		}
+		v := -1.0
+		if o.Up {
+			v = 1
+			if o.Time.After(a.LastUp) {
+				a.LastUp = o.Time
+			}
+		}
+		a.Weight += w
+		sum += w * v
+	}
+	if o, ok := t.own[host]; ok {
+		c := *o
+		a.Own = &c
+		add(o, 1)
+	}
+	var heard []*Reporter
+	for id := range t.heard[host] {
+		if rep, ok := t.reporters[id]; ok && !t.ignored[id] {
+			heard = append(heard, rep)
+		}
+	}
+	// the most trusted reporters only, so many new ones cannot outweigh
+	// them
+	sort.Slice(heard, func(i, j int) bool {
+		if heard[i].Trust != heard[j].Trust {
+			return heard[i].Trust > heard[j].Trust
+		}
+		return heard[i].ID < heard[j].ID
+	})
+	if len(heard) > maxReporters {
+		heard = heard[:maxReporters]
+	}
+	for _, rep := range heard {
+		add(t.heard[host][rep.ID], rep.Trust)
+		a.Reporters++
+	}
+	if a.Weight > 0 {
+		a.Score = sum / a.Weight
+	}
+	if a.Own != nil && now.Sub(a.Own.Time) <= t.interval {
+		// what the node observed lately prevails
+		a.Status = StatusDown
+		if a.Own.Up {
+			a.Status = StatusUp
+		}
+	} else if a.Weight >= minWeight {
+		switch {
+		case a.Score >= 0.5:
+			a.Status = StatusUp
+		case a.Score <= -0.5:
+			a.Status = StatusDown
+		}
+	}
+	return a
+}
+
+// Down reports whether host is found down at now.
+func (t *Table) Down(host string, now time.Time) bool {
+	return t.Availability(host, now).Status == StatusDown
+}
+
+// Hosts returns the availability of the hosts observed at now, by host.
+func (t *Table) Hosts(now time.Time) []*Availability {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hosts := map[string]bool{}
+	for host := range t.own {
+		hosts[host] = true
+	}
+	for host := range t.heard {
+		hosts[host] = true
+	}
+	as := make([]*Availability, 0, len(hosts))
+	for host := range hosts {
+		as = append(as, t.availability(host, now))
+	}
+	sort.Slice(as, func(i, j int) bool { return as[i].Host < as[j].Host })
+	return as
+}
+
+// Reporters returns the reporters heard from, the most trusted first.
+func (t *Table) Reporters() []*Reporter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rs := make([]*Reporter, 0, len(t.reporters))
+	for _, rep := range t.reporters {
+		c := *rep
+		rs = append(rs, &c)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].Trust != rs[j].Trust {
+			return rs[i].Trust > rs[j].Trust
+		}
+		return rs[i].ID < rs[j].ID
+	})
+	return rs
+}
+
+var (
+	defaultLk sync.RWMutex
+	// defaultTable is the table of the node while the gossip runs.
+	defaultTable *Table
+)
+
+// SetDefault makes t the table of the node, nil when the gossip stops.
+func SetDefault(t *Table) {
+	defaultLk.Lock()
+	defer defaultLk.Unlock()
+	defaultTable = t
+}
+
+// Default returns the table of the node, nil when the gossip is not
+// running.
+func Default() *Table {
+	defaultLk.RLock()
+	defer defaultLk.RUnlock()
+	return defaultTable
+}
+
+// Observe records an observation of the node in the table of the node,
+// while the gossip runs.
+func Observe(host string, up bool) {
+	if t := Default(); t != nil {
+		t.Observe(host, up, time.Now())
+	}
+}
+
+// Down reports whether host is found down by the table of the node, false
+// while the gossip is not running.
+func Down(host string) bool {
+	t := Default()
+	return t != nil && t.Down(host, time.Now())
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,173 @@
+package hostgossip
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type reporter struct {
+	id  string
+	key ic.PrivKey
+}
+
+func newReporter(t *testing.T) *reporter {
+	key, _, err := ic.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &reporter{id: id.Pretty(), key: key}
+}
+
+func (r *reporter) report(t *testing.T, now time.Time, os ...*Observation) *Report {
+	rep := &Report{Reporter: r.id, Time: now, Observations: os}
+	if err := rep.Sign(r.key); err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+func newTable(t *testing.T, c *Config) *Table {
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	return NewTable(newReporter(t).id, c)
+}
+
+func TestReportSignature(t *testing.T) {
+	now := time.Now()
+	r := newReporter(t)
+	rep := r.report(t, now, &Observation{Host: "h1", Up: true, Time: now})
+	if err := rep.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	rep.Observations[0].Up = false
+	if err := rep.Verify(); err == nil {
+		t.Fatal("expected a tampered report refused")
+	}
+	rep = r.report(t, now)
+	rep.Reporter = newReporter(t).id
+	if err := rep.Verify(); err == nil {
+		t.Fatal("expected a report signed by another node refused")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, c := range []*Config{
+		{Interval: "soon"},
+		{Interval: "10m", MaxAge: "5m"},
+		{Trusted: []string{"me"}},
+	} {
+		if err := c.compile(); err == nil {
+			t.Errorf("expected %+v refused", c)
+		}
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	now := time.Now()
+	tb := newTable(t, &Config{})
+	if a := tb.Availability("h1", now); a.Status != StatusUnknown {
+		t.Fatalf("expected an unknown host, got %s", a.Status)
+	}
+	tb.Observe("h1", false, now)
+	if !tb.Down("h1", now) {
+		t.Fatal("expected the host down as observed")
+	}
+	// the observations fade
+	if a := tb.Availability("h1", now.Add(20*time.Minute)); a.Status != StatusUnknown {
+		t.Fatalf("expected an old observation ignored, got %s %v", a.Status, a.Weight)
+	}
+	if len(tb.Report(now.Add(time.Hour)).Observations) != 0 {
+		t.Fatal("expected the observations dropped after MaxAge")
+	}
+}
+
+func TestReceive(t *testing.T) {
+	now := time.Now()
+	tb := newTable(t, &Config{})
+	r := newReporter(t)
+
+	// a reporter not heard of cannot rule out a host alone
+	if err := tb.Receive(r.report(t, now, &Observation{Host: "h1", Time: now}), now); err != nil {
+		t.Fatal(err)
+	}
+	if a := tb.Availability("h1", now); a.Status != StatusUnknown || a.Reporters != 1 {
+		t.Fatalf("expected the host unknown, got %+v", a)
+	}
+	if err := tb.Receive(r.report(t, now.Add(time.Second)), now); err != ErrTooFrequent {
+		t.Fatalf("expected the report refused, got %v", err)
+	}
+	if err := tb.Receive(r.report(t, now.Add(time.Hour)), now); err != ErrStale {
+		t.Fatalf("expected the report refused, got %v", err)
+	}
+
+	// several reporters agreeing can
+	for i := 0; i < 2; i++ {
+		other := newReporter(t)
+		if err := tb.Receive(other.report(t, now, &Observation{Host: "h1", Time: now}), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !tb.Down("h1", now) {
+		t.Fatalf("expected the host down, got %+v", tb.Availability("h1", now))
+	}
+	// the node reaching the host prevails
+	tb.Observe("h1", true, now)
+	if a := tb.Availability("h1", now); a.Status != StatusUp {
+		t.Fatalf("expected the host up, got %+v", a)
+	}
+}
+
+func TestTrust(t *testing.T) {
+	now := time.Now()
+	honest, liar, trusted := newReporter(t), newReporter(t), newReporter(t)
+	tb := newTable(t, &Config{Trusted: []string{trusted.id}})
+	tb.Observe("h1", true, now)
+	tb.Observe("h2", false, now)
+
+	at := now
+	for i := 0; i < 4; i++ {
+		for _, r := range []*reporter{honest, liar} {
+			up := r == honest
+			rep := r.report(t, at,
+				&Observation{Host: "h1", Up: up, Time: at},
+				&Observation{Host: "h2", Up: !up, Time: at})
+			if err := tb.Receive(rep, at); err != nil {
+				t.Fatal(err)
+			}
+		}
+		at = at.Add(5 * time.Minute)
+	}
+	trust := map[string]float64{}
+	for _, r := range tb.Reporters() {
+		trust[r.ID] = r.Trust
+	}
+	if trust[honest.id] <= InitialTrust || trust[liar.id] != 0 {
+		t.Fatalf("unexpected trust %v", trust)
+	}
+
+	// a trusted reporter counts as the node
+	if err := tb.Receive(trusted.report(t, now, &Observation{Host: "h3", Time: now}), now); err != nil {
+		t.Fatal(err)
+	}
+	if !tb.Down("h3", now) {
+		t.Fatal("expected the host down as reported by a trusted reporter")
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	now := time.Now()
+	r := newReporter(t)
+	tb := newTable(t, &Config{Ignored: []string{r.id}})
+	if err := tb.Receive(r.report(t, now, &Observation{Host: "h1", Time: now}), now); err != ErrIgnored {
+		t.Fatalf("expected the report refused, got %v", err)
+	}
+}
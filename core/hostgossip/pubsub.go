@@ -0,0 +1,101 @@
+package hostgossip
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// Run publishes the signed reports of t every interval on Topic, and
+// records the reports received in t, until ctx is done.
+func Run(ctx context.Context, ps *pubsub.PubSub, key ic.PrivKey, t *Table) error {
+	// reports refused are not relayed further
+	err := ps.RegisterTopicValidator(Topic, func(ctx context.Context, from peer.ID, msg *pubsub.Message) bool {
+		r, err := decode(msg)
+		if err != nil {
+			return false
+		}
+		if err := t.Check(r, time.Now()); err != nil {
+			return false
+		}
+		return r.Verify() == nil
+	})
+	if err != nil {
+		return err
+	}
+	defer ps.UnregisterTopicValidator(Topic)
+	topic, err := ps.Join(Topic)
+	if err != nil {
+		return err
+	}
+	defer topic.Close()
+	sub, err := topic.Subscribe()
+	if err != nil {
+		return err
+	}
+	defer sub.Cancel()
+
+	go publish(ctx, topic, key, t)
+	for {
+		msg, err := sub.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		r, err := decode(msg)
+		if err != nil {
+			continue
+		}
+		if err := t.Receive(r, time.Now()); err != nil && err != ErrTooFrequent {
+			log.Debugf("report of %s refused: %s", r.Reporter, err)
+		}
+	}
+}
+
+// decode returns the report of msg, published by its reporter.
+func decode(msg *pubsub.Message) (*Report, error) {
+	r := &Report{}
+	if err := json.Unmarshal(msg.GetData(), r); err != nil {
+		return nil, err
+	}
+	if msg.GetFrom().Pretty() != r.Reporter {
+		return nil, ErrIgnored
+	}
+	return r, nil
+}
+
+// publish publishes the reports of t every interval, none when the node
+// observed no host.
+func publish(ctx context.Context, topic *pubsub.Topic, key ic.PrivKey, t *Table) {
+	tick := time.NewTicker(t.Interval())
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		r := t.Report(time.Now())
+		if len(r.Observations) == 0 {
+			continue
+		}
+		if err := r.Sign(key); err != nil {
+			log.Errorf("failed to sign the host availability report: %s", err)
+			continue
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			log.Errorf("failed to encode the host availability report: %s", err)
+			continue
+		}
+		if err := topic.Publish(ctx, b); err != nil && ctx.Err() == nil {
+			log.Errorf("failed to publish the host availability report: %s", err)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// ScoreConfigKey is the config section of the local weighting of the hub
+// scores.
+const ScoreConfigKey = "HostScore"
+
+// Factors of the hub score of a host.
+const (
+	FactorUptime     = "uptime"
+	FactorAge        = "age"
+	FactorVersion    = "version"
+	FactorUpload     = "upload"
+	FactorDownload   = "download"
+	FactorReputation = "reputation"
+)
+
+// factors returns the factors of the hub score of h, in order.
+func factors(h *hubpb.Host) []*Factor {
+	return []*Factor{
+		{Name: FactorUptime, Value: h.UptimeScore},
+		{Name: FactorAge, Value: h.AgeScore},
+		{Name: FactorVersion, Value: h.VersionScore},
+		{Name: FactorUpload, Value: h.UploadSpeedScore},
+		{Name: FactorDownload, Value: h.DownloadSpeedScore},
+		{Name: FactorReputation, Value: h.Reputation},
+	}
+}
+
+// ScoreConfig overrides the weights of the factors of the hub scores, to
+// rank the hosts synced from the hub on what matters to this renter.
+type ScoreConfig struct {
+	// Weights are the weights of the factors by name, 1 when not set. The
+	// hosts are ranked by the hub score when no weight is set.
+	Weights map[string]float64 `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ScoreConfigKey, ScoreConfig{})
+}
+
+// LoadScoreConfig returns the score config of r.
+func LoadScoreConfig(r repo.Repo) (*ScoreConfig, error) {
+	c := &ScoreConfig{}
+	if _, err := repo.GetConfigSection(r, ScoreConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ScoreConfigKey, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ScoreConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *ScoreConfig) validate() error {
+	known := map[string]bool{}
+	for _, f := range factors(&hubpb.Host{}) {
+		known[f.Name] = true
+	}
+	for name, w := range c.Weights {
+		if !known[name] {
+			return fmt.Errorf("unknown factor %q, expected one of %s", name, strings.Join(FactorNames(), ", "))
+		}
+		if w < 0 {
+			return fmt.Errorf("negative weight of %s", name)
+		}
+	}
+	total := 0.0
+	for name := range known {
+		total += c.weight(name)
+	}
+	if total == 0 {
+		return fmt.Errorf("all weights are zero")
+	}
+	return nil
+}
+
+// FactorNames returns the names of the factors of the hub scores.
+func FactorNames() []string {
+	var names []string
+	for _, f := range factors(&hubpb.Host{}) {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// Overridden reports whether c ranks the hosts on its own weights.
+func (c *ScoreConfig) Overridden() bool {
+	return c != nil && len(c.Weights) > 0
+}
+
+func (c *ScoreConfig) weight(name string) float64 {
+	if c != nil {
+		if w, ok := c.Weights[name]; ok {
+			return w
+		}
+	}
+	return 1
+}
+
+// Factor is a factor of the score of a host. Contribution is its share of
+// the weighted score.
+type Factor struct {
+	Name         string
+	Value        float32
+	Weight       float64
+	Contribution float64
+}
+
+// Explanation is the score of a host broken down into its factors.
+type Explanation struct {
+	Host     string
+	HubScore float32
+	// Score is the score the hosts are ranked by: the weighted mean of the
+	// factors when the weights are overridden, the hub score otherwise.
+	Score      float64
+	Overridden bool
+	Factors    []*Factor
+}
+
+// Explain returns the score of h under c broken down into its factors.
+func Explain(h *hubpb.Host, c *ScoreConfig) *Explanation {
+	e := &Explanation{Host: h.NodeId, HubScore: h.Score, Overridden: c.Overridden(), Factors: factors(h)}
+	total := 0.0
+	for _, f := range e.Factors {
+		f.Weight = c.weight(f.Name)
+		total += f.Weight
+	}
+	weighted := 0.0
+	for _, f := range e.Factors {
+		if total > 0 {
+			f.Contribution = f.Weight * float64(f.Value) / total
+		}
+		weighted += f.Contribution
+	}
+	e.Score = float64(h.Score)
+	if e.Overridden {
+		e.Score = weighted
+	}
+	return e
+}
+
+// Drivers returns the n factors of e contributing the most, the most first.
+func (e *Explanation) Drivers(n int) []*Factor {
+	fs := make([]*Factor, 0, len(e.Factors))
+	for _, f := range e.Factors {
+		if f.Contribution > 0 {
+			fs = append(fs, f)
+		}
+	}
+	sort.SliceStable(fs, func(i, j int) bool { return fs[i].Contribution > fs[j].Contribution })
+	if len(fs) > n {
+		fs = fs[:n]
+	}
+	return fs
+}
+
+// Rank sorts hosts by their score under c, the best first, when c
+// overrides the weights, and leaves them in the order of the hub otherwise.
+func Rank(hosts []*hubpb.Host, c *ScoreConfig) {
+	if !c.Overridden() {
+		return
+	}
+	scores := make(map[*hubpb.Host]float64, len(hosts))
+	for _, h := range hosts {
+		scores[h] = Explain(h, c).Score
+	}
+	sort.SliceStable(hosts, func(i, j int) bool { return scores[hosts[i]] > scores[hosts[j]] })
+}
+
+// Reasons of the selection of a host.
+const (
+	ReasonScore  = "score"
+	ReasonBackup = "backup"
+	ReasonRenter = "renter"
+)
+
+// Selection records why a host was chosen to store a shard.
+type Selection struct {
+	Host       string
+	ContractID string `json:",omitempty"`
+	ShardIndex int
+	Time       time.Time
+	// Reason is ReasonScore for the hosts ranked by score, ReasonBackup for
+	// the connected peers tried once they ran out, and ReasonRenter for the
+	// hosts listed by the renter.
+	Reason string
+	// Rank is the 1-based rank of the host among Of hosts.
+	Rank int `json:",omitempty"`
+	Of   int `json:",omitempty"`
+	// Explanation is the score of the hosts ranked by score.
+	Explanation *Explanation `json:",omitempty"`
+	// Drivers are the names of the factors which contributed the most.
+	Drivers []string `json:",omitempty"`
+}
+
+// NumDrivers is the number of factors recorded as drivers of a selection.
+const NumDrivers = 3
+
+// RenterSelectionKey is the datastore key of the selection of the host of
+// a contract of a renter session.
+const RenterSelectionKey = "/btfs/%s/renter/selections/%s/%s"
+
+// SaveSelection records the selection s of the renter session ssID of
+// peerID.
+func SaveSelection(d ds.Datastore, peerID, ssID string, s *Selection) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(RenterSelectionKey, peerID, ssID, s.ContractID)), b)
+}
+
+// ListSelections returns the selections of the renter session ssID of
+// peerID, oldest first.
+func ListSelections(d ds.Datastore, peerID, ssID string) ([]*Selection, error) {
+	results, err := d.Query(query.Query{
+		Prefix: fmt.Sprintf(RenterSelectionKey, peerID, ssID, ""),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ss := []*Selection{}
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		s := &Selection{}
+		if err := json.Unmarshal(r.Value, s); err != nil {
+			return nil, fmt.Errorf("invalid selection %s: %s", r.Key, err)
+		}
+		ss = append(ss, s)
+	}
+	sort.SliceStable(ss, func(i, j int) bool { return ss[i].Time.Before(ss[j].Time) })
+	return ss, nil
+}
@@ -0,0 +1,87 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestExplain(t *testing.T) {
+	h := &hubpb.Host{NodeId: "a", Score: 7, UptimeScore: 6, AgeScore: 0, VersionScore: 6,
+		UploadSpeedScore: 12, DownloadSpeedScore: 6, Reputation: 6}
+
+	e := Explain(h, &ScoreConfig{})
+	if e.Overridden || e.Score != 7 {
+		t.Fatalf("expected the hub score, got %+v", e)
+	}
+	if d := e.Drivers(NumDrivers); len(d) != 3 || d[0].Name != FactorUpload || d[0].Contribution != 2 {
+		t.Fatalf("unexpected drivers %+v", d)
+	}
+
+	c := &ScoreConfig{Weights: map[string]float64{FactorUpload: 0, FactorReputation: 3}}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	e = Explain(h, c)
+	// (6 + 0 + 6 + 0 + 6 + 3*6) / 7
+	if !e.Overridden || e.Score < 5.14 || e.Score > 5.15 {
+		t.Fatalf("unexpected score %+v", e)
+	}
+	if d := e.Drivers(1); d[0].Name != FactorReputation {
+		t.Fatalf("unexpected drivers %+v", d)
+	}
+}
+
+func TestValidateScoreConfig(t *testing.T) {
+	for _, c := range []*ScoreConfig{
+		{Weights: map[string]float64{"latency": 1}},
+		{Weights: map[string]float64{FactorAge: -1}},
+		{Weights: map[string]float64{FactorUptime: 0, FactorAge: 0, FactorVersion: 0, FactorUpload: 0,
+			FactorDownload: 0, FactorReputation: 0}},
+	} {
+		if err := c.validate(); err == nil {
+			t.Fatalf("expected %v to be invalid", c.Weights)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	hosts := []*hubpb.Host{
+		{NodeId: "fast", Score: 9, UploadSpeedScore: 10},
+		{NodeId: "old", Score: 8, AgeScore: 10, UploadSpeedScore: 2},
+	}
+	Rank(hosts, nil)
+	if hosts[0].NodeId != "fast" {
+		t.Fatal("expected the hub order without weights")
+	}
+	Rank(hosts, &ScoreConfig{Weights: map[string]float64{FactorAge: 5}})
+	if hosts[0].NodeId != "old" {
+		t.Fatal("expected the old host first")
+	}
+}
+
+func TestSelections(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	for i, id := range []string{"c2", "c1"} {
+		s := &Selection{Host: "h", ContractID: id, ShardIndex: i, Time: now.Add(time.Duration(i) * time.Second),
+			Reason: ReasonScore, Rank: 1, Of: 2}
+		if err := SaveSelection(d, "renter", "ss", s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SaveSelection(d, "renter", "other", &Selection{ContractID: "c3"}); err != nil {
+		t.Fatal(err)
+	}
+	ss, err := ListSelections(d, "renter", "ss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 2 || ss[0].ContractID != "c2" || ss[1].ShardIndex != 1 {
+		t.Fatalf("unexpected selections %+v", ss)
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"strings"
 
 	"github.com/TRON-US/go-btfs/core"
+	hubclient "github.com/TRON-US/go-btfs/core/clients/hub"
 
 	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 )
 
 const (
@@ -50,21 +50,13 @@ func QueryHosts(ctx context.Context, node *core.IpfsNode, mode string) ([]*hubpb
 	if err != nil {
 		return nil, err
 	}
-	var resp *hubpb.HostsResp
-	err = grpc.HubQueryClient(config.Services.HubDomain).WithContext(ctx, func(ctx context.Context,
-		client hubpb.HubQueryServiceClient) error {
-		resp, err = client.GetHosts(ctx, &hubpb.HostsReq{
-			Id:   node.Identity.Pretty(),
-			Mode: hrm,
-		})
-		if err != nil {
-			return err
-		}
-		if resp.Code != hubpb.ResponseCode_SUCCESS {
-			return fmt.Errorf(resp.Message)
-		}
-		return nil
+	resp, err := hubclient.New(config.Services.HubDomain).GetHosts(ctx, &hubpb.HostsReq{
+		Id:   node.Identity.Pretty(),
+		Mode: hrm,
 	})
+	if err == nil && resp.Code != hubpb.ResponseCode_SUCCESS {
+		err = fmt.Errorf(resp.Message)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("Failed to query hosts from Hub service: %v", err)
 	}
@@ -78,20 +70,12 @@ func QueryStats(ctx context.Context, node *core.IpfsNode) (*hubpb.StatsResp, err
 	if err != nil {
 		return nil, err
 	}
-	var resp *hubpb.StatsResp
-	err = grpc.HubQueryClient(config.Services.HubDomain).WithContext(ctx, func(ctx context.Context,
-		client hubpb.HubQueryServiceClient) error {
-		resp, err = client.GetStats(ctx, &hubpb.StatsReq{
-			Id: node.Identity.Pretty(),
-		})
-		if err != nil {
-			return err
-		}
-		if resp.Code != hubpb.ResponseCode_SUCCESS {
-			return fmt.Errorf(resp.Message)
-		}
-		return nil
+	resp, err := hubclient.New(config.Services.HubDomain).GetStats(ctx, &hubpb.StatsReq{
+		Id: node.Identity.Pretty(),
 	})
+	if err == nil && resp.Code != hubpb.ResponseCode_SUCCESS {
+		err = fmt.Errorf(resp.Message)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("Failed to query stats from Hub service: %v", err)
 	}
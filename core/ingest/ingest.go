@@ -0,0 +1,281 @@
+// Package ingest applies back-pressure to the shards a host accepts. The
+// shards being downloaded form the admission queue of the host: a new
+// shard is admitted while the queue and the challenges the host is
+// answering are within bounds, otherwise the renter is told to retry after
+// the time the host needs to drain its queue at the write throughput it
+// measures.
+//
+// Renters parse the retry-after errors of the hosts with ParseRetryAfter
+// and skip busy hosts until then, see MarkBusy.
+package ingest
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ConfigKey is the config section of the ingest queue of a host.
+const ConfigKey = "IngestQueue"
+
+// Defaults of the ingest queue.
+const (
+	DefaultMaxPending    = 8
+	DefaultMaxChallenges = 32
+	// DefaultWriteRate is the write throughput assumed before one is
+	// measured, in bytes per second.
+	DefaultWriteRate = 5 << 20
+
+	MinRetryAfter = 5 * time.Second
+	MaxRetryAfter = 10 * time.Minute
+	// challengeRetryAfter is the wait for the challenges to drain.
+	challengeRetryAfter = 30 * time.Second
+	// rateWeight is the weight of a new sample of the write throughput.
+	rateWeight = 0.2
+)
+
+// Config configures the ingest queue of a host.
+type Config struct {
+	// MaxPending is the number of shards downloaded at once.
+	MaxPending int `json:",omitempty"`
+	// MaxChallenges is the number of challenges answered at once above which
+	// no shard is admitted.
+	MaxChallenges int `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+	prometheus.MustRegister(queueDepth, pendingChallenges, writeRate, rejections)
+}
+
+var (
+	queueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "btfs",
+		Subsystem: "ingest",
+		Name:      "queue_depth",
+		Help:      "Shards being downloaded by the host.",
+	})
+	pendingChallenges = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "btfs",
+		Subsystem: "ingest",
+		Name:      "pending_challenges",
+		Help:      "Challenges being answered by the host.",
+	})
+	writeRate = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "btfs",
+		Subsystem: "ingest",
+		Name:      "write_bytes_per_second",
+		Help:      "Measured throughput of the shard downloads of the host.",
+	})
+	rejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "ingest",
+		Name:      "rejections_total",
+		Help:      "Shards the host told the renter to retry later, by reason.",
+	}, []string{"reason"})
+)
+
+// Load returns the ingest queue config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if c.MaxPending < 0 || c.MaxChallenges < 0 {
+		return nil, fmt.Errorf("invalid %s config: negative bound", ConfigKey)
+	}
+	if c.MaxPending == 0 {
+		c.MaxPending = DefaultMaxPending
+	}
+	if c.MaxChallenges == 0 {
+		c.MaxChallenges = DefaultMaxChallenges
+	}
+	return c, nil
+}
+
+// RetryAfterError tells a renter to retry a shard after After.
+type RetryAfterError struct {
+	After  time.Duration
+	Reason string
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("retry-after %s: %s", e.After, e.Reason)
+}
+
+var retryAfterRe = regexp.MustCompile(`retry-after (\S+):`)
+
+// ParseRetryAfter returns the wait a host asked for in err, the message of
+// a RetryAfterError, possibly wrapped by the remote call.
+func ParseRetryAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	m := retryAfterRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0, false
+	}
+	d, perr := time.ParseDuration(m[1])
+	if perr != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// Queue is the admission queue of the shards of a host.
+type Queue struct {
+	mu         sync.Mutex
+	pending    int
+	bytes      int64
+	challenges int
+	// rate is the write throughput in bytes per second.
+	rate float64
+	// draining refuses new shards, e.g. before the host is upgraded.
+	draining bool
+}
+
+// Default is the queue of the host.
+var Default = NewQueue()
+
+// NewQueue returns an empty queue.
+func NewQueue() *Queue {
+	return &Queue{rate: DefaultWriteRate}
+}
+
+// Ticket is a shard admitted, to be released once downloaded or failed.
+type Ticket struct {
+	q    *Queue
+	size int64
+	once sync.Once
+}
+
+// retryAfter returns the time to drain bytes at the write throughput.
+// q.mu is held.
+func (q *Queue) retryAfter(bytes int64) time.Duration {
+	d := time.Duration(float64(bytes) / q.rate * float64(time.Second))
+	return time.Duration(math.Max(float64(MinRetryAfter), math.Min(float64(d), float64(MaxRetryAfter))))
+}
+
+// Admit admits a shard of size bytes under the bounds of c, or returns a
+// RetryAfterError.
+func (q *Queue) Admit(c *Config, size int64) (*Ticket, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.draining {
+		rejections.WithLabelValues("drain").Inc()
+		return nil, &RetryAfterError{After: MaxRetryAfter, Reason: "host draining"}
+	}
+	if q.challenges >= c.MaxChallenges {
+		rejections.WithLabelValues("challenges").Inc()
+		return nil, &RetryAfterError{After: challengeRetryAfter,
+			Reason: fmt.Sprintf("answering %d challenges", q.challenges)}
+	}
+	if q.pending >= c.MaxPending {
+		rejections.WithLabelValues("queue").Inc()
+		return nil, &RetryAfterError{After: q.retryAfter(q.bytes + size),
+			Reason: fmt.Sprintf("%d shards queued", q.pending)}
+	}
+	q.pending++
+	q.bytes += size
+	queueDepth.Set(float64(q.pending))
+	return &Ticket{q: q, size: size}, nil
+}
+
+// Release removes the shard of t from the queue. The download of the shard
+// having taken d, it is a sample of the write throughput when ok.
+func (t *Ticket) Release(d time.Duration, ok bool) {
+	t.once.Do(func() {
+		q := t.q
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.pending--
+		q.bytes -= t.size
+		queueDepth.Set(float64(q.pending))
+		if ok && d > 0 {
+			sample := float64(t.size) / d.Seconds()
+			q.rate = (1-rateWeight)*q.rate + rateWeight*sample
+			writeRate.Set(q.rate)
+		}
+	})
+}
+
+// Drain has q refuse new shards when on, while the shards admitted are
+// downloaded, or admit them again when off.
+func (q *Queue) Drain(on bool) {
+	q.mu.Lock()
+	q.draining = on
+	q.mu.Unlock()
+}
+
+// Challenge counts a challenge being answered until the returned func is
+// called.
+func (q *Queue) Challenge() func() {
+	q.mu.Lock()
+	q.challenges++
+	pendingChallenges.Set(float64(q.challenges))
+	q.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			q.challenges--
+			pendingChallenges.Set(float64(q.challenges))
+			q.mu.Unlock()
+		})
+	}
+}
+
+// Status is the state of a queue.
+type Status struct {
+	Pending      int
+	PendingBytes int64
+	Challenges   int
+	// WriteRate is the measured write throughput in bytes per second.
+	WriteRate float64
+	// RetryAfter is the wait a renter is told when the queue is full.
+	RetryAfter time.Duration
+	// Draining is set while no shard is admitted.
+	Draining bool
+}
+
+// Status returns the state of q.
+func (q *Queue) Status() *Status {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return &Status{
+		Pending:      q.pending,
+		PendingBytes: q.bytes,
+		Challenges:   q.challenges,
+		WriteRate:    q.rate,
+		RetryAfter:   q.retryAfter(q.bytes),
+		Draining:     q.draining,
+	}
+}
+
+// busy are the hosts which asked this renter to retry later, by peer ID.
+var busy sync.Map
+
+// MarkBusy has the renter skip the host hostID until until.
+func MarkBusy(hostID string, until time.Time) {
+	busy.Store(hostID, until)
+}
+
+// Busy reports whether the host hostID asked to retry after now.
+func Busy(hostID string, now time.Time) bool {
+	v, ok := busy.Load(hostID)
+	if !ok {
+		return false
+	}
+	if now.After(v.(time.Time)) {
+		busy.Delete(hostID)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAdmit(t *testing.T) {
+	q := NewQueue()
+	c := &Config{MaxPending: 2, MaxChallenges: 1}
+	a, err := q.Admit(c, 10<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Admit(c, 10<<20); err != nil {
+		t.Fatal(err)
+	}
+	_, err = q.Admit(c, 10<<20)
+	var ra *RetryAfterError
+	if !errors.As(err, &ra) {
+		t.Fatalf("expected a retry-after error, got %v", err)
+	}
+	// 30MiB at 5MiB/s
+	if ra.After != 6*time.Second {
+		t.Fatalf("expected to retry after 6s, got %s", ra.After)
+	}
+	// 10MiB in a second
+	a.Release(time.Second, true)
+	a.Release(time.Second, true)
+	s := q.Status()
+	if s.Pending != 1 || s.PendingBytes != 10<<20 {
+		t.Fatalf("released twice: %+v", s)
+	}
+	if want := 0.8*DefaultWriteRate + 0.2*(10<<20); s.WriteRate != want {
+		t.Fatalf("expected a write rate of %f, got %f", want, s.WriteRate)
+	}
+	if _, err := q.Admit(c, 10<<20); err != nil {
+		t.Fatal(err)
+	}
+
+	q = NewQueue()
+	done := q.Challenge()
+	if _, err := q.Admit(c, 1); !errors.As(err, &ra) || ra.After != challengeRetryAfter {
+		t.Fatalf("expected to retry after the challenges, got %v", err)
+	}
+	done()
+	done()
+	if _, err := q.Admit(c, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	q := NewQueue()
+	c := &Config{MaxPending: 2, MaxChallenges: 1}
+	a, err := q.Admit(c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Drain(true)
+	var ra *RetryAfterError
+	if _, err := q.Admit(c, 1); !errors.As(err, &ra) || ra.After != MaxRetryAfter {
+		t.Fatalf("expected a draining host to refuse shards, got %v", err)
+	}
+	a.Release(time.Second, true)
+	if s := q.Status(); !s.Draining || s.Pending != 0 {
+		t.Fatalf("expected the queue drained, got %+v", s)
+	}
+	q.Drain(false)
+	if _, err := q.Admit(c, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	err := fmt.Errorf("remote call failed: %s", &RetryAfterError{After: 90 * time.Second, Reason: "8 shards queued"})
+	if d, ok := ParseRetryAfter(err); !ok || d != 90*time.Second {
+		t.Fatalf("got %s, %v", d, ok)
+	}
+	if _, ok := ParseRetryAfter(errors.New("host timeout")); ok {
+		t.Fatal("parsed a retry-after from another error")
+	}
+	if _, ok := ParseRetryAfter(nil); ok {
+		t.Fatal("parsed a retry-after from no error")
+	}
+}
+
+func TestBusy(t *testing.T) {
+	now := time.Now()
+	MarkBusy("host", now.Add(time.Minute))
+	if !Busy("host", now) {
+		t.Fatal("host not busy")
+	}
+	if Busy("host", now.Add(2*time.Minute)) || Busy("other", now) {
+		t.Fatal("host busy past its retry-after")
+	}
+}
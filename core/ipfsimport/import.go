@@ -0,0 +1,170 @@
+package ipfsimport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	gopath "path"
+
+	"github.com/TRON-US/go-btfs/keystore"
+
+	pin "github.com/TRON-US/go-btfs-pinner"
+	"github.com/TRON-US/go-mfs"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+// progressEvery is the number of blocks copied between two progress
+// reports.
+const progressEvery = 1000
+
+// Target is the BTFS repo the content of a go-ipfs repo is imported into.
+type Target struct {
+	Blockstore bstore.Blockstore
+	Pinner     pin.Pinner
+	Keystore   keystore.Keystore
+	DAG        ipld.DAGService
+	FilesRoot  *mfs.Root
+}
+
+// Options selects what is imported.
+type Options struct {
+	// MfsPath is where the MFS root of the go-ipfs repo is placed in the
+	// MFS of the target, empty to skip it.
+	MfsPath string
+	Pins    bool
+	Keys    bool
+}
+
+// Result sums up an import.
+type Result struct {
+	Blocks        int
+	BlocksSkipped int
+	Pins          int
+	// PinsSkipped are the pins whose root block is not in the repo.
+	PinsSkipped []string
+	FilesRoot   string `json:",omitempty"`
+	Keys        []string
+	// KeysSkipped are the keys whose name is taken in the target.
+	KeysSkipped []string
+}
+
+// Progress reports the blocks copied so far.
+type Progress struct {
+	Blocks int
+}
+
+// Import copies the blocks of src to dst, then its pins, MFS root and keys
+// as selected by opts. Blocks already in dst are skipped, the import may
+// be run again after a failure.
+func Import(ctx context.Context, src *Repo, dst *Target, opts Options, progress func(Progress)) (*Result, error) {
+	r := &Result{Keys: []string{}, KeysSkipped: []string{}, PinsSkipped: []string{}}
+
+	// check the MFS path before the long part
+	if opts.MfsPath != "" {
+		if _, err := mfs.Lookup(dst.FilesRoot, opts.MfsPath); err == nil {
+			return nil, fmt.Errorf("%s already exists in the files of the node", opts.MfsPath)
+		} else if err != os.ErrNotExist {
+			return nil, err
+		}
+	}
+
+	keys, err := src.Blockstore.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for c := range keys {
+		has, err := dst.Blockstore.Has(c)
+		if err != nil {
+			return nil, err
+		}
+		if has {
+			r.BlocksSkipped++
+			continue
+		}
+		b, err := src.Blockstore.Get(c)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read block %s: %s", c, err)
+		}
+		if err := dst.Blockstore.Put(b); err != nil {
+			return nil, err
+		}
+		r.Blocks++
+		if progress != nil && r.Blocks%progressEvery == 0 {
+			progress(Progress{Blocks: r.Blocks})
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if opts.Pins {
+		pins, err := src.Pins(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the ipfs pins: %s", err)
+		}
+		for _, p := range pins {
+			if has, err := dst.Blockstore.Has(p.Cid); err != nil {
+				return nil, err
+			} else if !has {
+				r.PinsSkipped = append(r.PinsSkipped, p.Cid.String())
+				continue
+			}
+			mode := pin.Direct
+			if p.Recursive {
+				mode = pin.Recursive
+			}
+			dst.Pinner.PinWithMode(p.Cid, pin.DefaultDurationCount, mode)
+			r.Pins++
+		}
+		if err := dst.Pinner.Flush(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.MfsPath != "" {
+		c, ok, err := src.FilesRoot()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the ipfs files root: %s", err)
+		}
+		if ok {
+			nd, err := dst.DAG.Get(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			dir := gopath.Dir(opts.MfsPath)
+			if err := mfs.Mkdir(dst.FilesRoot, dir, mfs.MkdirOpts{Mkparents: true}); err != nil && err != mfs.ErrDirExists {
+				return nil, err
+			}
+			if err := mfs.PutNode(dst.FilesRoot, opts.MfsPath, nd); err != nil {
+				return nil, err
+			}
+			if _, err := mfs.FlushPath(ctx, dst.FilesRoot, dir); err != nil {
+				return nil, err
+			}
+			r.FilesRoot = c.String()
+		}
+	}
+
+	if opts.Keys {
+		keys, err := src.Keys()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			has, err := dst.Keystore.Has(k.Name)
+			if err != nil {
+				return nil, err
+			}
+			if has {
+				r.KeysSkipped = append(r.KeysSkipped, k.Name)
+				continue
+			}
+			if err := dst.Keystore.Put(k.Name, k.Key); err != nil {
+				return nil, err
+			}
+			r.Keys = append(r.Keys, k.Name)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,375 @@
+// Package ipfsimport reads the content of a go-ipfs repo so that it can be
+// imported into a BTFS repo: its blocks, pins, MFS root and keys.
+//
+// BTFS forked from go-ipfs, the repos share their layout: the datastore
+// described by the datastore_spec of the repo, the keystore directory and
+// the identity in the config. They differ in the pins. Up to repo version
+// 10 go-ipfs keeps them in a pin set DAG, as BTFS does without the pin
+// expirations; from version 11 it keeps one CBOR record per pin in the
+// datastore.
+package ipfsimport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/keystore"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	pin "github.com/TRON-US/go-btfs-pinner"
+	bserv "github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	lockfile "github.com/ipfs/go-fs-lock"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipfs/go-merkledag"
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+const (
+	// MinVersion and MaxVersion bound the go-ipfs repo versions read. From
+	// version 12 the blocks are keyed by multihash instead of CID.
+	MinVersion = 7
+	MaxVersion = 11
+	// dsPinnerVersion is the first repo version keeping the pins as
+	// datastore records.
+	dsPinnerVersion = 11
+
+	lockFile    = "repo.lock"
+	specFile    = "datastore_spec"
+	versionFile = "version"
+	keystoreDir = "keystore"
+	keyPrefix   = "key_"
+
+	// IdentityKey names the identity of the repo among its keys, self
+	// being the identity of the BTFS node.
+	IdentityKey = "ipfs-self"
+)
+
+var (
+	filesRootKey = ds.NewKey("/local/filesroot")
+	pinRootKey   = ds.NewKey("/local/pins")
+	// the BTFS pinner also keeps the expirations of the pins, go-ipfs does
+	// not have them
+	pinMapKeys = []ds.Key{ds.NewKey("/local/pins/recursive/keys"), ds.NewKey("/local/pins/direct/keys")}
+	// pinRecordPrefix holds the pins of the repos from version 11
+	pinRecordPrefix = "/pins/pin/"
+)
+
+// ErrLocked is returned when a go-ipfs daemon uses the repo.
+var ErrLocked = errors.New("the ipfs repo is locked, stop the ipfs daemon first")
+
+// Pin is a pin of the go-ipfs repo.
+type Pin struct {
+	Cid       cid.Cid
+	Recursive bool
+}
+
+// Key is a key of the go-ipfs keystore.
+type Key struct {
+	Name string
+	Key  ci.PrivKey
+}
+
+// Repo is an open go-ipfs repo.
+type Repo struct {
+	Path    string
+	Version int
+	// Datastore is the datastore of the repo, the blocks under /blocks.
+	Datastore  ds.Batching
+	Blockstore bstore.Blockstore
+
+	identity string
+	lock     io.Closer
+}
+
+// DefaultPath returns the path of the go-ipfs repo of the user, $IPFS_PATH
+// or ~/.ipfs.
+func DefaultPath() (string, error) {
+	if p := os.Getenv("IPFS_PATH"); p != "" {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ipfs"), nil
+}
+
+// config is the part of the go-ipfs config read.
+type config struct {
+	Identity struct {
+		PrivKey string
+	}
+}
+
+// Open locks and opens the go-ipfs repo at path. The datastore plugins it
+// uses must be loaded.
+func Open(path string) (*Repo, error) {
+	b, err := ioutil.ReadFile(filepath.Join(path, versionFile))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not an ipfs repo: %s", path, err)
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ipfs repo version %q", b)
+	}
+	if version < MinVersion || version > MaxVersion {
+		return nil, fmt.Errorf("ipfs repo version %d is not supported, migrate it to a version between %d and %d with ipfs-update",
+			version, MinVersion, MaxVersion)
+	}
+
+	b, err = ioutil.ReadFile(filepath.Join(path, "config"))
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config{}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("invalid ipfs config: %s", err)
+	}
+
+	b, err = ioutil.ReadFile(filepath.Join(path, specFile))
+	if err != nil {
+		return nil, err
+	}
+	spec := map[string]interface{}{}
+	if err := json.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("invalid ipfs datastore spec: %s", err)
+	}
+	dsc, err := fsrepo.AnyDatastoreConfig(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported ipfs datastore: %s", err)
+	}
+
+	lock, err := lockfile.Lock(path, lockFile)
+	if err != nil {
+		return nil, ErrLocked
+	}
+	d, err := dsc.Create(path)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	r := New(d, path, version)
+	r.identity = cfg.Identity.PrivKey
+	r.lock = lock
+	return r, nil
+}
+
+// New returns the go-ipfs repo of version at path whose datastore is d.
+func New(d ds.Batching, path string, version int) *Repo {
+	return &Repo{
+		Path:       path,
+		Version:    version,
+		Datastore:  d,
+		Blockstore: bstore.NewBlockstore(d),
+	}
+}
+
+// Close closes the datastore and unlocks the repo.
+func (r *Repo) Close() error {
+	err := r.Datastore.Close()
+	if r.lock != nil {
+		if lerr := r.lock.Close(); err == nil {
+			err = lerr
+		}
+	}
+	return err
+}
+
+// FilesRoot returns the root of the MFS of the repo, false when it has
+// none.
+func (r *Repo) FilesRoot() (cid.Cid, bool, error) {
+	b, err := r.Datastore.Get(filesRootKey)
+	if err == ds.ErrNotFound {
+		return cid.Undef, false, nil
+	}
+	if err != nil {
+		return cid.Undef, false, err
+	}
+	c, err := cid.Cast(b)
+	if err != nil {
+		return cid.Undef, false, err
+	}
+	return c, true, nil
+}
+
+// Pins returns the recursive and direct pins of the repo.
+func (r *Repo) Pins(ctx context.Context) ([]Pin, error) {
+	if r.Version >= dsPinnerVersion {
+		return r.pinRecords()
+	}
+	if has, err := r.Datastore.Has(pinRootKey); err != nil || !has {
+		return nil, err
+	}
+	dag := merkledag.NewDAGService(bserv.New(r.Blockstore, offline.Exchange(r.Blockstore)))
+	p, err := pin.LoadPinner(pinMapDatastore{r.Datastore}, dag, dag)
+	if err != nil {
+		return nil, err
+	}
+	var pins []Pin
+	recursive, err := p.RecursiveKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range recursive {
+		pins = append(pins, Pin{Cid: c, Recursive: true})
+	}
+	direct, err := p.DirectKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range direct {
+		pins = append(pins, Pin{Cid: c})
+	}
+	return pins, nil
+}
+
+// pinMapDatastore serves empty expiration maps to the BTFS pinner loading
+// the pin set of a go-ipfs repo.
+type pinMapDatastore struct {
+	ds.Datastore
+}
+
+func (d pinMapDatastore) Get(k ds.Key) ([]byte, error) {
+	b, err := d.Datastore.Get(k)
+	if err == ds.ErrNotFound {
+		for _, mk := range pinMapKeys {
+			if k == mk {
+				return []byte("{}"), nil
+			}
+		}
+	}
+	return b, err
+}
+
+func (r *Repo) pinRecords() ([]Pin, error) {
+	res, err := r.Datastore.Query(query.Query{Prefix: pinRecordPrefix})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+	pins := make([]Pin, 0, len(entries))
+	for _, e := range entries {
+		p, err := DecodePinRecord(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pin %s: %s", e.Key, err)
+		}
+		pins = append(pins, p)
+	}
+	return pins, nil
+}
+
+// DecodePinRecord decodes the CBOR record of a pin of a go-ipfs repo from
+// version 11: a map of its "cid" and "mode", 0 for recursive and 1 for
+// direct.
+func DecodePinRecord(b []byte) (Pin, error) {
+	var m map[string]interface{}
+	if err := cbor.DecodeInto(b, &m); err != nil {
+		return Pin{}, err
+	}
+	var p Pin
+	switch v := m["cid"].(type) {
+	case []byte:
+		c, err := cid.Cast(v)
+		if err != nil {
+			return Pin{}, err
+		}
+		p.Cid = c
+	case cid.Cid:
+		p.Cid = v
+	default:
+		return Pin{}, errors.New("no cid")
+	}
+	var mode int64
+	switch v := m["mode"].(type) {
+	case int:
+		mode = int64(v)
+	case int64:
+		mode = v
+	case uint64:
+		mode = int64(v)
+	default:
+		return Pin{}, errors.New("no mode")
+	}
+	switch pin.Mode(mode) {
+	case pin.Recursive:
+		p.Recursive = true
+	case pin.Direct:
+	default:
+		return Pin{}, fmt.Errorf("unexpected pin mode %d", mode)
+	}
+	return p, nil
+}
+
+// Keys returns the keys of the keystore of the repo, and its identity as
+// IdentityKey when known.
+func (r *Repo) Keys() ([]Key, error) {
+	var keys []Key
+	if r.identity != "" {
+		b, err := base64.StdEncoding.DecodeString(r.identity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ipfs identity: %s", err)
+		}
+		sk, err := ci.UnmarshalPrivateKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ipfs identity: %s", err)
+		}
+		keys = append(keys, Key{Name: IdentityKey, Key: sk})
+	}
+
+	dir := filepath.Join(r.Path, keystoreDir)
+	names, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ks, err := keystore.NewFSKeystore(dir)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := ks.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range encoded {
+		sk, err := ks.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ipfs key %s: %s", name, err)
+		}
+		keys = append(keys, Key{Name: name, Key: sk})
+	}
+	// go-ipfs before 0.5 named the key files after the keys
+	for _, fi := range names {
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), keyPrefix) || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sk, err := ci.UnmarshalPrivateKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ipfs key %s: %s", fi.Name(), err)
+		}
+		keys = append(keys, Key{Name: fi.Name(), Key: sk})
+	}
+	return keys, nil
+}
@@ -0,0 +1,232 @@
+package ipfsimport
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TRON-US/go-btfs/keystore"
+
+	pin "github.com/TRON-US/go-btfs-pinner"
+	"github.com/TRON-US/go-mfs"
+	ft "github.com/TRON-US/go-unixfs"
+	bserv "github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	dag "github.com/ipfs/go-merkledag"
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+func newKey(t *testing.T) ci.PrivKey {
+	sk, _, err := ci.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sk
+}
+
+// newRepo returns a go-ipfs repo of version holding two files, the first
+// pinned recursively and the second directly.
+func newRepo(t *testing.T, version int) (*Repo, []cid.Cid) {
+	ctx := context.Background()
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	r := New(d, "", version)
+	dserv := dag.NewDAGService(bserv.New(r.Blockstore, offline.Exchange(r.Blockstore)))
+
+	var cids []cid.Cid
+	for _, data := range []string{"recursive", "direct"} {
+		nd := dag.NodeWithData(ft.FilePBData([]byte(data), uint64(len(data))))
+		if err := dserv.Add(ctx, nd); err != nil {
+			t.Fatal(err)
+		}
+		cids = append(cids, nd.Cid())
+	}
+	root := ft.EmptyDirNode()
+	if err := dserv.Add(ctx, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Put(filesRootKey, root.Cid().Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if version >= dsPinnerVersion {
+		for i, c := range cids {
+			var v interface{} = c.Bytes()
+			if i == 1 {
+				v = c
+			}
+			b, err := cbor.DumpObject(map[string]interface{}{"cid": v, "mode": i})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := d.Put(ds.NewKey(pinRecordPrefix+c.String()), b); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return r, cids
+	}
+	p := pin.NewPinner(d, dserv, dserv)
+	p.PinWithMode(cids[0], pin.DefaultDurationCount, pin.Recursive)
+	p.PinWithMode(cids[1], pin.DefaultDurationCount, pin.Direct)
+	if err := p.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// go-ipfs keeps no pin expirations
+	for _, k := range pinMapKeys {
+		if err := d.Delete(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r, cids
+}
+
+func checkPins(t *testing.T, pins []Pin, cids []cid.Cid) {
+	if len(pins) != 2 {
+		t.Fatalf("expected 2 pins, got %v", pins)
+	}
+	for _, p := range pins {
+		if p.Recursive != p.Cid.Equals(cids[0]) {
+			t.Errorf("unexpected pin %s recursive=%v", p.Cid, p.Recursive)
+		}
+	}
+}
+
+func TestPins(t *testing.T) {
+	for _, version := range []int{10, 11} {
+		r, cids := newRepo(t, version)
+		pins, err := r.Pins(context.Background())
+		if err != nil {
+			t.Fatalf("version %d: %s", version, err)
+		}
+		checkPins(t, pins, cids)
+	}
+}
+
+func TestDecodePinRecord(t *testing.T) {
+	b, err := cbor.DumpObject(map[string]interface{}{"cid": []byte("junk"), "mode": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodePinRecord(b); err == nil {
+		t.Fatal("decoded an invalid cid")
+	}
+	if _, err := DecodePinRecord([]byte{0xff}); err == nil {
+		t.Fatal("decoded invalid cbor")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	path, err := ioutil.TempDir("", "ipfsimport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+	r := New(dssync.MutexWrap(ds.NewMapDatastore()), path, 10)
+	dir := filepath.Join(r.Path, keystoreDir)
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := keystore.NewFSKeystore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Put("Encoded", newKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ci.MarshalPrivateKey(newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "plain"), b, 0400); err != nil {
+		t.Fatal(err)
+	}
+	b, err = ci.MarshalPrivateKey(newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.identity = base64.StdEncoding.EncodeToString(b)
+
+	keys, err := r.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, k := range keys {
+		names[k.Name] = true
+	}
+	for _, name := range []string{IdentityKey, "Encoded", "plain"} {
+		if !names[name] {
+			t.Errorf("key %s not read, got %v", name, names)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	src, cids := newRepo(t, 11)
+
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	bs := bstore.NewBlockstore(d)
+	dserv := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+	pub := func(context.Context, cid.Cid) error { return nil }
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.NewMemKeystore()
+	if err := ks.Put(IdentityKey, newKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	dst := &Target{
+		Blockstore: bs,
+		Pinner:     pin.NewPinner(d, dserv, dserv),
+		Keystore:   ks,
+		DAG:        dserv,
+		FilesRoot:  root,
+	}
+	b, err := ci.MarshalPrivateKey(newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.identity = base64.StdEncoding.EncodeToString(b)
+
+	opts := Options{MfsPath: "/imported/ipfs", Pins: true, Keys: true}
+	res, err := Import(ctx, src, dst, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Blocks != 3 || res.Pins != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if len(res.KeysSkipped) != 1 || res.KeysSkipped[0] != IdentityKey {
+		t.Fatalf("the taken key name was not skipped: %+v", res)
+	}
+	for _, c := range cids {
+		if _, pinned, err := dst.Pinner.IsPinned(ctx, c); err != nil || !pinned {
+			t.Fatalf("%s not pinned: %v", c, err)
+		}
+	}
+	if _, err := mfs.Lookup(root, "/imported/ipfs"); err != nil {
+		t.Fatalf("files root not placed: %s", err)
+	}
+
+	// the MFS path is taken now
+	if _, err := Import(ctx, src, dst, opts, nil); err == nil {
+		t.Fatal("imported over an existing MFS path")
+	}
+	opts.MfsPath = ""
+	res, err = Import(ctx, src, dst, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Blocks != 0 || res.BlocksSkipped != 3 {
+		t.Fatalf("blocks copied twice: %+v", res)
+	}
+}
@@ -0,0 +1,190 @@
+// Package latency keeps the round-trip times the daemon measures to the
+// bootstrap nodes and the services it depends on, so that the fastest are
+// preferred on the next start.
+//
+// Every target keeps its last MaxSamples RTTs, the older ones are dropped.
+package latency
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// Peer classes measured by the daemon.
+const (
+	// Bootstrap targets are bootstrap peers, by peer ID.
+	Bootstrap = "bootstrap"
+	// Hub targets are the hub endpoints, by host:port.
+	Hub = "hub"
+)
+
+// Classes lists all peer classes.
+var Classes = []string{Bootstrap, Hub}
+
+const (
+	// MaxSamples is the number of RTTs kept per target.
+	MaxSamples = 64
+
+	keyPrefix = "/btfs/%s/latency/%s/"
+)
+
+// Target is the measured latency of a peer of a class.
+type Target struct {
+	Class string
+	ID    string
+	// RTTs are the last successful measures, oldest first.
+	RTTs []time.Duration
+	// Failures counts the measures failing since the last success.
+	Failures int
+	Updated  time.Time
+}
+
+// Stats are the percentiles of a set of RTTs.
+type Stats struct {
+	Count         int
+	Min, Max      time.Duration
+	P50, P90, P99 time.Duration
+}
+
+// IsClass reports whether c is a known peer class.
+func IsClass(c string) bool {
+	for _, known := range Classes {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+func prefix(peerID, class string) string {
+	return fmt.Sprintf(keyPrefix, peerID, class)
+}
+
+func key(peerID, class, id string) ds.Key {
+	return ds.NewKey(prefix(peerID, class) + id)
+}
+
+// Get returns the latency of the target id of class measured by the node
+// peerID, nil if it was never measured.
+func Get(d ds.Datastore, peerID, class, id string) (*Target, error) {
+	b, err := d.Get(key(peerID, class, id))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := &Target{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, fmt.Errorf("invalid %s latency of %s: %s", class, id, err)
+	}
+	return t, nil
+}
+
+// Record adds a measure of the target id of class to the node peerID, a
+// failure when err is not nil.
+func Record(d ds.Datastore, peerID, class, id string, rtt time.Duration, err error, at time.Time) error {
+	t, gerr := Get(d, peerID, class, id)
+	if gerr != nil {
+		return gerr
+	}
+	if t == nil {
+		t = &Target{Class: class, ID: id}
+	}
+	if err != nil {
+		t.Failures++
+	} else {
+		t.Failures = 0
+		t.RTTs = append(t.RTTs, rtt)
+		if len(t.RTTs) > MaxSamples {
+			t.RTTs = t.RTTs[len(t.RTTs)-MaxSamples:]
+		}
+	}
+	t.Updated = at
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return d.Put(key(peerID, class, id), b)
+}
+
+// List returns the targets of class measured by the node peerID, by ID.
+func List(d ds.Datastore, peerID, class string) ([]*Target, error) {
+	results, err := d.Query(query.Query{Prefix: prefix(peerID, class)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var targets []*Target
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		t := &Target{}
+		if err := json.Unmarshal(r.Value, t); err != nil {
+			return nil, fmt.Errorf("invalid %s latency %s: %s", class, r.Key, err)
+		}
+		targets = append(targets, t)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].ID < targets[j].ID
+	})
+	return targets, nil
+}
+
+// Median returns the median RTT of t, false when it is unreachable: never
+// measured or failing since its last success.
+func (t *Target) Median() (time.Duration, bool) {
+	if t == nil || len(t.RTTs) == 0 || t.Failures > 0 {
+		return 0, false
+	}
+	return Percentiles(t.RTTs).P50, true
+}
+
+// Percentiles returns the stats of rtts.
+func Percentiles(rtts []time.Duration) Stats {
+	if len(rtts) == 0 {
+		return Stats{}
+	}
+	sorted := make([]time.Duration, len(rtts))
+	copy(sorted, rtts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	// nearest rank
+	at := func(p int) time.Duration {
+		i := (p*len(sorted)+99)/100 - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i]
+	}
+	return Stats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		P50:   at(50),
+		P90:   at(90),
+		P99:   at(99),
+	}
+}
+
+// Medians returns the median RTT of the reachable targets of class
+// measured by the node peerID, by ID.
+func Medians(d ds.Datastore, peerID, class string) (map[string]time.Duration, error) {
+	targets, err := List(d, peerID, class)
+	if err != nil {
+		return nil, err
+	}
+	medians := map[string]time.Duration{}
+	for _, t := range targets {
+		if m, ok := t.Median(); ok {
+			medians[t.ID] = m
+		}
+	}
+	return medians, nil
+}
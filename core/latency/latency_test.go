@@ -0,0 +1,76 @@
+package latency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestRecord(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	for i := 1; i <= MaxSamples+10; i++ {
+		if err := Record(d, "QmSelf", Bootstrap, "QmFast", time.Duration(i)*time.Millisecond, nil, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fast, err := Get(d, "QmSelf", Bootstrap, "QmFast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fast.RTTs) != MaxSamples || fast.RTTs[0] != 11*time.Millisecond {
+		t.Fatalf("expected the last %d samples, got %d from %s", MaxSamples, len(fast.RTTs), fast.RTTs[0])
+	}
+
+	if err := Record(d, "QmSelf", Bootstrap, "QmDown", 0, errors.New("timeout"), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := Record(d, "QmSelf", Hub, "hub:443", 5*time.Millisecond, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := List(d, "QmSelf", Bootstrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].ID != "QmDown" || targets[0].Failures != 1 {
+		t.Fatalf("unexpected bootstrap targets %+v", targets)
+	}
+
+	medians, err := Medians(d, "QmSelf", Bootstrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(medians) != 1 || medians["QmFast"] != 42*time.Millisecond {
+		t.Fatalf("unexpected medians %v", medians)
+	}
+
+	// a success clears the failures
+	if err := Record(d, "QmSelf", Bootstrap, "QmDown", time.Millisecond, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if down, _ := Get(d, "QmSelf", Bootstrap, "QmDown"); down.Failures != 0 {
+		t.Fatalf("failures not cleared: %d", down.Failures)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	var rtts []time.Duration
+	for i := 100; i >= 1; i-- {
+		rtts = append(rtts, time.Duration(i)*time.Millisecond)
+	}
+	s := Percentiles(rtts)
+	want := Stats{Count: 100, Min: time.Millisecond, Max: 100 * time.Millisecond,
+		P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P99: 99 * time.Millisecond}
+	if s != want {
+		t.Fatalf("expected %+v, got %+v", want, s)
+	}
+	if s := Percentiles([]time.Duration{time.Second}); s.P50 != time.Second || s.P99 != time.Second {
+		t.Fatalf("unexpected stats of one sample %+v", s)
+	}
+	if s := Percentiles(nil); s.Count != 0 {
+		t.Fatalf("unexpected stats of no sample %+v", s)
+	}
+}
@@ -0,0 +1,222 @@
+// Package maintenance keeps the repo of a node healthy: it archives the
+// old storage contracts, compacts the datastore, scrubs the blocks against
+// their checksums and rebuilds the filestore index, during a daily window
+// when configured.
+//
+// Maintenance is IO heavy. On a storage host it stops as soon as reading
+// the shards challenged by guards gets slower than Config.MaxReadLatency,
+// so that challenges do not fail because of it.
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	ds "github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("core/maintenance")
+
+// ConfigKey is the config section of the maintenance.
+const ConfigKey = "Maintenance"
+
+// DefaultMaxReadLatency is the read latency of challenged shards aborting
+// the maintenance when nothing is configured.
+const DefaultMaxReadLatency = 500 * time.Millisecond
+
+// The maintenance tasks, in the order they run.
+const (
+	// TaskArchive archives the storage contracts final for long enough.
+	TaskArchive = "archive"
+	// TaskCompact compacts the datastore backends supporting it.
+	TaskCompact = "compact"
+	// TaskScrub checks every block against its hash.
+	TaskScrub = "scrub"
+	// TaskReindex drops the filestore entries whose file changed or is gone.
+	TaskReindex = "reindex"
+)
+
+// Tasks lists the maintenance tasks in the order they run.
+var Tasks = []string{TaskArchive, TaskCompact, TaskScrub, TaskReindex}
+
+// ErrAborted is returned when maintenance stops because it slows down the
+// reads of challenged shards.
+var ErrAborted = errors.New("maintenance aborted, block reads are too slow for storage challenges")
+
+// Config configures the scheduled maintenance.
+type Config struct {
+	// Window is the daily time range, in local time, the daemon runs the
+	// maintenance in, e.g. "02:00-05:00". No maintenance is scheduled when
+	// empty.
+	Window string `json:",omitempty"`
+	// Tasks are the tasks run in the window, all when empty.
+	Tasks []string `json:",omitempty"`
+	// MaxReadLatency aborts the maintenance when reading a challenged shard
+	// takes longer, e.g. "500ms".
+	MaxReadLatency string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the maintenance config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+// Validate checks the window, tasks and latency of c.
+func (c *Config) Validate() error {
+	if c.Window != "" {
+		if _, err := ParseWindow(c.Window); err != nil {
+			return err
+		}
+	}
+	if _, err := ParseTasks(c.Tasks); err != nil {
+		return err
+	}
+	_, err := c.ReadLatency()
+	return err
+}
+
+// ReadLatency returns the maximum read latency of challenged shards.
+func (c *Config) ReadLatency() (time.Duration, error) {
+	if c.MaxReadLatency == "" {
+		return DefaultMaxReadLatency, nil
+	}
+	d, err := time.ParseDuration(c.MaxReadLatency)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid MaxReadLatency %q", c.MaxReadLatency)
+	}
+	return d, nil
+}
+
+// ParseTasks checks the task names and returns them in the order they run,
+// all tasks when names is empty.
+func ParseTasks(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return Tasks, nil
+	}
+	want := map[string]bool{}
+	for _, name := range names {
+		if !isTask(name) {
+			return nil, fmt.Errorf("unknown maintenance task %q, expected one of %s", name, strings.Join(Tasks, ", "))
+		}
+		want[name] = true
+	}
+	var tasks []string
+	for _, t := range Tasks {
+		if want[t] {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func isTask(name string) bool {
+	for _, t := range Tasks {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Window is a daily time range. It ends the next day when End is before
+// Start.
+type Window struct {
+	Start, End time.Duration // since midnight
+}
+
+// ParseWindow parses a window such as "02:00-05:00".
+func ParseWindow(s string) (Window, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return Window{}, fmt.Errorf("invalid window %q, expected HH:MM-HH:MM", s)
+	}
+	var w Window
+	for i, p := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(p))
+		if err != nil {
+			return Window{}, fmt.Errorf("invalid window %q, expected HH:MM-HH:MM", s)
+		}
+		d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		if i == 0 {
+			w.Start = d
+		} else {
+			w.End = d
+		}
+	}
+	if w.Start == w.End {
+		return Window{}, fmt.Errorf("invalid window %q, it is empty", s)
+	}
+	return w, nil
+}
+
+// Open returns the start and end of the window t is in, false if t is out
+// of the window.
+func (w Window) Open(t time.Time) (start, end time.Time, ok bool) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	// the window started today, or yesterday when it ends after midnight
+	for _, day := range []time.Time{midnight, midnight.AddDate(0, 0, -1)} {
+		start = day.Add(w.Start)
+		end = day.Add(w.End)
+		if w.End < w.Start {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// LastRun is the last maintenance run of a node.
+type LastRun struct {
+	Start time.Time
+	End   time.Time
+	Tasks []string
+	Error string `json:",omitempty"`
+}
+
+const lastRunKey = "/btfs/%s/maintenance/last"
+
+// GetLastRun returns the last maintenance run of the node peerID, nil if
+// there is none.
+func GetLastRun(d ds.Datastore, peerID string) (*LastRun, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(lastRunKey, peerID)))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &LastRun{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// PutLastRun records the last maintenance run of the node peerID.
+func PutLastRun(d ds.Datastore, peerID string, r *LastRun) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(lastRunKey, peerID)), b)
+}
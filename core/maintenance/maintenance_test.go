@@ -0,0 +1,140 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/contractarchive"
+
+	blocks "github.com/ipfs/go-block-format"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	dshelp "github.com/ipfs/go-ipfs-ds-help"
+)
+
+func TestWindow(t *testing.T) {
+	for _, s := range []string{"", "02:00", "02:00-02:00", "25:00-03:00", "a-b"} {
+		if _, err := ParseWindow(s); err == nil {
+			t.Errorf("parsed invalid window %q", s)
+		}
+	}
+
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 5, day, hour, min, 0, 0, time.Local)
+	}
+	w, err := ParseWindow("02:00-05:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start, end, ok := w.Open(at(10, 3, 30)); !ok || !start.Equal(at(10, 2, 0)) || !end.Equal(at(10, 5, 0)) {
+		t.Errorf("03:30 in %v: %v %v %v", w, start, end, ok)
+	}
+	if _, _, ok := w.Open(at(10, 5, 0)); ok {
+		t.Error("window open at its end")
+	}
+
+	// wraps past midnight
+	w, err = ParseWindow("23:00-01:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start, end, ok := w.Open(at(10, 0, 30)); !ok || !start.Equal(at(9, 23, 0)) || !end.Equal(at(10, 1, 0)) {
+		t.Errorf("00:30 in %v: %v %v %v", w, start, end, ok)
+	}
+	if start, end, ok := w.Open(at(10, 23, 30)); !ok || !start.Equal(at(10, 23, 0)) || !end.Equal(at(11, 1, 0)) {
+		t.Errorf("23:30 in %v: %v %v %v", w, start, end, ok)
+	}
+	if _, _, ok := w.Open(at(10, 12, 0)); ok {
+		t.Error("window open at noon")
+	}
+}
+
+func TestParseTasks(t *testing.T) {
+	tasks, err := ParseTasks([]string{TaskReindex, TaskCompact})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0] != TaskCompact || tasks[1] != TaskReindex {
+		t.Errorf("tasks not in run order: %v", tasks)
+	}
+	if tasks, _ := ParseTasks(nil); len(tasks) != len(Tasks) {
+		t.Errorf("expected all tasks, got %v", tasks)
+	}
+	if _, err := ParseTasks([]string{"defrag"}); err == nil {
+		t.Error("parsed an unknown task")
+	}
+}
+
+func TestScrub(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	good := blocks.NewBlock([]byte("good"))
+	bad := blocks.NewBlock([]byte("bad"))
+	if err := d.Put(bstore.BlockPrefix.Child(dshelp.CidToDsKey(good.Cid())), good.RawData()); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Put(bstore.BlockPrefix.Child(dshelp.CidToDsKey(bad.Cid())), []byte("tampered")); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Runner{Datastore: d}
+	var reported []string
+	res, err := r.Run(context.Background(), []string{TaskScrub}, func(p Progress) {
+		if p.Msg != "" {
+			reported = append(reported, p.Msg)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Corrupt) != 1 || !res.Corrupt[0].Equals(bad.Cid()) {
+		t.Fatalf("expected %s corrupt, got %v", bad.Cid(), res.Corrupt)
+	}
+	if len(reported) != 1 {
+		t.Errorf("expected the corrupt block reported, got %v", reported)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	var roles []string
+	r := &Runner{
+		Datastore: dssync.MutexWrap(ds.NewMapDatastore()),
+		Archive: func(role string) (*contractarchive.Result, error) {
+			roles = append(roles, role)
+			return &contractarchive.Result{Role: role, Contracts: 2}, nil
+		},
+	}
+	res, err := r.Run(context.Background(), []string{TaskArchive}, func(Progress) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 2 || res.Archived != 4 {
+		t.Fatalf("unexpected archival of %v: %+v", roles, res)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	r := &Runner{
+		Datastore: d,
+		Probe: func(ctx context.Context) (time.Duration, bool) {
+			return time.Second, true
+		},
+		MaxLatency:    100 * time.Millisecond,
+		ProbeInterval: time.Millisecond,
+	}
+	// the probe runs concurrently, wait for it to abort
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for ctx.Err() == nil {
+		_, err := r.Run(ctx, Tasks, func(Progress) {})
+		if err == ErrAborted {
+			return
+		}
+		if err != nil {
+			t.Fatalf("expected ErrAborted, got %v", err)
+		}
+	}
+	t.Fatal("the run was not aborted")
+}
@@ -0,0 +1,277 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/contractarchive"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/repocrypt"
+
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-filestore"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+// probeInterval is how often the read latency of challenged shards is
+// measured during a run.
+const probeInterval = 10 * time.Second
+
+// progressEvery is the number of blocks between two progress reports.
+const progressEvery = 1000
+
+// Progress reports the advance of a task.
+type Progress struct {
+	Task string
+	// Done and Total count the items of the task processed, Total is 0
+	// when unknown.
+	Done  uint64
+	Total uint64
+	// Msg describes a finding or the outcome of the task.
+	Msg string `json:",omitempty"`
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	// Archived counts the storage contracts archived.
+	Archived int
+	// Reclaimed is the disk space freed by the compaction.
+	Reclaimed int64
+	// Corrupt lists the blocks not matching their hash.
+	Corrupt []cid.Cid
+	// Dropped counts the filestore entries removed from the index.
+	Dropped int
+}
+
+// Runner runs the maintenance tasks on a repo.
+type Runner struct {
+	Datastore repo.Datastore
+	// Filestore is nil when the filestore is disabled.
+	Filestore *filestore.Filestore
+	// Probe measures the read latency of the data storage challenges read,
+	// false when nothing is challenged.
+	Probe func(ctx context.Context) (time.Duration, bool)
+	// MaxLatency aborts the run when Probe measures a higher latency.
+	MaxLatency    time.Duration
+	ProbeInterval time.Duration
+	// Archive archives the storage contracts of a role, nil when the
+	// archival is disabled.
+	Archive func(role string) (*contractarchive.Result, error)
+}
+
+// ForNode returns a runner on the repo of n, aborting when reading the
+// shards of its active host contracts takes longer than maxLatency.
+func ForNode(n *core.IpfsNode, maxLatency time.Duration) *Runner {
+	r := &Runner{
+		Datastore: n.Repo.Datastore(),
+		Filestore: n.Filestore,
+		Probe: func(ctx context.Context) (time.Duration, bool) {
+			return probeShards(ctx, n)
+		},
+		MaxLatency:    maxLatency,
+		ProbeInterval: probeInterval,
+	}
+	cfg, err := contractarchive.Load(n.Repo)
+	if err != nil {
+		log.Warnf("not archiving contracts: %s", err)
+		return r
+	}
+	if !cfg.Disabled {
+		age, _ := cfg.Age() // checked by Load
+		r.Archive = func(role string) (*contractarchive.Result, error) {
+			return contracts.Archive(n.Repo.Datastore(), n.Identity.Pretty(), role, time.Now().Add(-age))
+		}
+	}
+	return r
+}
+
+// probeShards times reading the first block of an active host contract
+// shard of n, as a challenge does.
+func probeShards(ctx context.Context, n *core.IpfsNode) (time.Duration, bool) {
+	cs, err := contracts.ListContracts(n.Repo.Datastore(), n.Identity.Pretty(), nodepb.ContractStat_HOST.String())
+	if err != nil {
+		log.Warnf("cannot list host contracts: %s", err)
+		return 0, false
+	}
+	for _, c := range cs {
+		if !helper.ContractFilterMap["active"][c.Status] {
+			continue
+		}
+		shard, err := cid.Decode(c.ShardHash)
+		if err != nil {
+			continue
+		}
+		start := time.Now()
+		if _, err := n.Blockstore.Get(shard); err != nil {
+			continue
+		}
+		return time.Since(start), true
+	}
+	return 0, false
+}
+
+// Run runs tasks, which ParseTasks checked, and reports their progress.
+// It stops with ErrAborted when the probed latency gets too high.
+func (r *Runner) Run(ctx context.Context, tasks []string, progress func(Progress)) (*Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	aborted := make(chan struct{})
+	if r.Probe != nil && r.MaxLatency > 0 {
+		go r.guard(ctx, cancel, aborted)
+	}
+
+	res := &Result{}
+	var err error
+	for _, task := range tasks {
+		switch task {
+		case TaskArchive:
+			err = r.archive(res, progress)
+		case TaskCompact:
+			err = r.compact(res, progress)
+		case TaskScrub:
+			err = r.scrub(ctx, res, progress)
+		case TaskReindex:
+			err = r.reindex(ctx, res, progress)
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			break
+		}
+	}
+	select {
+	case <-aborted:
+		return res, ErrAborted
+	default:
+		return res, err
+	}
+}
+
+// guard cancels the run when the probed latency exceeds the maximum.
+func (r *Runner) guard(ctx context.Context, cancel context.CancelFunc, aborted chan<- struct{}) {
+	interval := r.ProbeInterval
+	if interval <= 0 {
+		interval = probeInterval
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		if d, ok := r.Probe(ctx); ok && d > r.MaxLatency {
+			log.Warnf("aborting maintenance, reading a challenged shard took %s", d)
+			close(aborted)
+			cancel()
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+func (r *Runner) archive(res *Result, progress func(Progress)) error {
+	if r.Archive == nil {
+		progress(Progress{Task: TaskArchive, Msg: "the contract archive is disabled"})
+		return nil
+	}
+	roles := []string{nodepb.ContractStat_HOST.String(), nodepb.ContractStat_RENTER.String()}
+	for i, role := range roles {
+		ar, err := r.Archive(role)
+		if err != nil {
+			return err
+		}
+		res.Archived += ar.Contracts
+		progress(Progress{Task: TaskArchive, Done: uint64(i + 1), Total: uint64(len(roles))})
+	}
+	return nil
+}
+
+func (r *Runner) compact(res *Result, progress func(Progress)) error {
+	gcd, ok := r.Datastore.(ds.GCDatastore)
+	if !ok {
+		progress(Progress{Task: TaskCompact, Msg: "the datastore does not support compaction"})
+		return nil
+	}
+	before, err := ds.DiskUsage(r.Datastore)
+	if err != nil {
+		return err
+	}
+	if err := gcd.CollectGarbage(); err != nil {
+		return err
+	}
+	after, err := ds.DiskUsage(r.Datastore)
+	if err != nil {
+		return err
+	}
+	res.Reclaimed = int64(before) - int64(after)
+	progress(Progress{Task: TaskCompact, Done: 1, Total: 1})
+	return nil
+}
+
+func (r *Runner) scrub(ctx context.Context, res *Result, progress func(Progress)) error {
+	bs := bstore.NewBlockstore(repocrypt.NewDatastore(r.Datastore, repocrypt.Default))
+	keys, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return err
+	}
+	var all []cid.Cid
+	for k := range keys {
+		all = append(all, k)
+	}
+	total := uint64(len(all))
+	bs.HashOnRead(true)
+	for i, k := range all {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := bs.Get(k); err == bstore.ErrHashMismatch {
+			res.Corrupt = append(res.Corrupt, k)
+			progress(Progress{Task: TaskScrub, Done: uint64(i + 1), Total: total, Msg: "corrupt block " + k.String()})
+		} else if err != nil && err != bstore.ErrNotFound {
+			return err
+		}
+		if (i+1)%progressEvery == 0 {
+			progress(Progress{Task: TaskScrub, Done: uint64(i + 1), Total: total})
+		}
+	}
+	progress(Progress{Task: TaskScrub, Done: total, Total: total})
+	return nil
+}
+
+func (r *Runner) reindex(ctx context.Context, res *Result, progress func(Progress)) error {
+	if r.Filestore == nil {
+		progress(Progress{Task: TaskReindex, Msg: "the filestore is disabled"})
+		return nil
+	}
+	next, err := filestore.VerifyAll(r.Filestore, false)
+	if err != nil {
+		return err
+	}
+	var done uint64
+	for e := next(); e != nil; e = next() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		done++
+		switch e.Status {
+		case filestore.StatusFileNotFound, filestore.StatusFileChanged:
+			if err := r.Filestore.FileManager().DeleteBlock(e.Key); err != nil {
+				return err
+			}
+			res.Dropped++
+			progress(Progress{Task: TaskReindex, Done: done, Msg: "dropped " + e.Key.String() + ": " + e.Status.String()})
+		}
+		if done%progressEvery == 0 {
+			progress(Progress{Task: TaskReindex, Done: done})
+		}
+	}
+	progress(Progress{Task: TaskReindex, Done: done, Total: done})
+	return nil
+}
@@ -0,0 +1,132 @@
+package netproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Tunnel makes the bridge route the connections to the hosts of the
+// services, URLs or "host:port" addresses, through the proxy.
+func (p *Proxy) Tunnel(services ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range services {
+		if h := hostname(s); h != "" {
+			p.tunnels[h] = true
+		}
+	}
+}
+
+func (p *Proxy) tunneled(addr string) bool {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		h = addr
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.tunnels[strings.ToLower(h)]
+}
+
+func hostname(s string) string {
+	if !strings.Contains(s, "://") {
+		s = "http://" + strings.TrimPrefix(s, "//")
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// StartBridge routes the gRPC calls to the tunneled hosts through the
+// proxy until ctx is done. The gRPC clients of the services take no dialer
+// but honor HTTPS_PROXY: the bridge is an HTTP CONNECT proxy on the
+// loopback interface, which tunnels the connections to the tunneled hosts
+// through the proxy and dials the others directly, and HTTPS_PROXY is
+// pointed to it. It must be called before the first HTTP or gRPC call of
+// the process, which reads HTTPS_PROXY once.
+func (p *Proxy) StartBridge(ctx context.Context) error {
+	for _, env := range []string{"HTTPS_PROXY", "https_proxy"} {
+		if os.Getenv(env) != "" {
+			return fmt.Errorf("%s is set, unset it to route the %s calls through %s", env, Wallet, ConfigKey)
+		}
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(p.serveConnect)}
+	go func() {
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Errorf("proxy bridge stopped: %s", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	return os.Setenv("HTTPS_PROXY", "http://"+l.Addr().String())
+}
+
+func (p *Proxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), dialTimeout)
+	defer cancel()
+	var (
+		backend net.Conn
+		err     error
+	)
+	if p.tunneled(r.Host) {
+		backend, err = p.DialContext(ctx, "tcp", r.Host)
+	} else {
+		var d net.Dialer
+		backend, err = d.DialContext(ctx, "tcp", r.Host)
+	}
+	if err != nil {
+		log.Debugf("bridge to %s: %s", r.Host, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		backend.Close()
+		http.Error(w, "cannot hijack the connection", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hj.Hijack()
+	if err != nil {
+		backend.Close()
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		client.Close()
+		backend.Close()
+		return
+	}
+	// the client may have written past the CONNECT request
+	if n := buf.Reader.Buffered(); n > 0 {
+		b, _ := buf.Reader.Peek(n)
+		if _, err := backend.Write(b); err != nil {
+			client.Close()
+			backend.Close()
+			return
+		}
+	}
+	go pipe(client, backend)
+	go pipe(backend, client)
+}
+
+func pipe(dst, src net.Conn) {
+	io.Copy(dst, src)
+	dst.Close()
+	src.Close()
+}
@@ -0,0 +1,68 @@
+package netproxy
+
+import (
+	"context"
+	"net"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/transport"
+	tptu "github.com/libp2p/go-libp2p-transport-upgrader"
+	tcp "github.com/libp2p/go-tcp-transport"
+	ma "github.com/multiformats/go-multiaddr"
+	mafmt "github.com/multiformats/go-multiaddr-fmt"
+	manet "github.com/multiformats/go-multiaddr-net"
+)
+
+// TCPTransport is the constructor of the libp2p TCP transport dialing
+// through the active proxy. It listens as the TCP transport does.
+func TCPTransport(upgrader *tptu.Upgrader) transport.Transport {
+	return &tcpTransport{TcpTransport: tcp.NewTCPTransport(upgrader), p: Active()}
+}
+
+type tcpTransport struct {
+	*tcp.TcpTransport
+	p *Proxy
+}
+
+// CanDial also takes the /dns4 and /dns6 addresses, resolved by the proxy.
+func (t *tcpTransport) CanDial(addr ma.Multiaddr) bool {
+	return mafmt.TCP.Matches(addr)
+}
+
+func (t *tcpTransport) Dial(ctx context.Context, raddr ma.Multiaddr, p peer.ID) (transport.CapableConn, error) {
+	network, addr, err := manet.DialArgs(raddr)
+	if err != nil {
+		return nil, err
+	}
+	dctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	c, err := t.p.DialContext(dctx, network, addr)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	laddr, err := manet.FromNetAddr(c.LocalAddr())
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return t.Upgrader.UpgradeOutbound(ctx, t, &proxiedConn{Conn: c, laddr: laddr, raddr: raddr}, p)
+}
+
+func (t *tcpTransport) String() string {
+	return "TCP (proxied)"
+}
+
+// proxiedConn is a connection through the proxy, its remote address the
+// one dialed rather than the proxy.
+type proxiedConn struct {
+	net.Conn
+	laddr, raddr ma.Multiaddr
+}
+
+func (c *proxiedConn) LocalMultiaddr() ma.Multiaddr {
+	return c.laddr
+}
+
+func (c *proxiedConn) RemoteMultiaddr() ma.Multiaddr {
+	return c.raddr
+}
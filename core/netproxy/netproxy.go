@@ -0,0 +1,173 @@
+// Package netproxy routes the outbound connections of the node through a
+// SOCKS5 proxy, such as Tor, for the operators in restrictive networks. It
+// is enabled per subsystem: the wallet, its HTTP calls and its gRPC calls to
+// the chain and exchange services, and the libp2p dials.
+package netproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	logging "github.com/ipfs/go-log"
+	"golang.org/x/net/proxy"
+)
+
+var log = logging.Logger("netproxy")
+
+// ConfigKey is the config section of the proxy.
+const ConfigKey = "Proxy"
+
+// dialTimeout bounds the connections through the proxy, longer than a
+// direct dial as building a Tor circuit takes a while.
+const dialTimeout = 30 * time.Second
+
+// Subsystem is a part of the node whose connections can be proxied.
+type Subsystem string
+
+// Subsystems of the proxy.
+const (
+	Wallet Subsystem = "wallet"
+	Libp2p Subsystem = "libp2p"
+)
+
+// Config configures the proxy.
+type Config struct {
+	// Address is the SOCKS5 proxy, "socks5://[user:password@]host:port" or
+	// "host:port", e.g. "127.0.0.1:9050" for a local Tor. Host names are
+	// resolved by the proxy, so that .onion services can be reached.
+	Address string `json:",omitempty"`
+	// Wallet routes the wallet HTTP and gRPC calls through the proxy.
+	Wallet bool `json:",omitempty"`
+	// Libp2p routes the libp2p TCP dials through the proxy. The QUIC and
+	// websocket transports, which cannot be proxied, are then disabled.
+	Libp2p bool `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the proxy configured in r, nil when no subsystem is proxied.
+func Load(r repo.Repo) (*Proxy, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if !c.Wallet && !c.Libp2p {
+		return nil, nil
+	}
+	p, err := New(c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return p, nil
+}
+
+// Proxy dials through a SOCKS5 proxy.
+type Proxy struct {
+	c      Config
+	dialer proxy.ContextDialer
+
+	mu      sync.RWMutex
+	tunnels map[string]bool
+}
+
+// New returns the proxy of c.
+func New(c *Config) (*Proxy, error) {
+	if c.Address == "" {
+		return nil, errors.New("Address is required")
+	}
+	s := c.Address
+	if !strings.Contains(s, "://") {
+		s = "socks5://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Address %q: %s", c.Address, err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, fmt.Errorf("invalid Address %q: only socks5 proxies are supported", c.Address)
+	}
+	if u.Port() == "" {
+		return nil, fmt.Errorf("invalid Address %q: missing port", c.Address)
+	}
+	var auth *proxy.Auth
+	if u.User != nil {
+		auth = &proxy.Auth{User: u.User.Username()}
+		auth.Password, _ = u.User.Password()
+	}
+	d, err := proxy.SOCKS5("tcp", u.Host, auth, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Address %q: %s", c.Address, err)
+	}
+	return &Proxy{c: *c, dialer: d.(proxy.ContextDialer), tunnels: make(map[string]bool)}, nil
+}
+
+// Enabled reports whether the connections of s are proxied by p, false for
+// a nil p.
+func (p *Proxy) Enabled(s Subsystem) bool {
+	if p == nil {
+		return false
+	}
+	switch s {
+	case Wallet:
+		return p.c.Wallet
+	case Libp2p:
+		return p.c.Libp2p
+	}
+	return false
+}
+
+// DialContext dials addr through the proxy.
+func (p *Proxy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	c, err := p.dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s through the proxy: %s", addr, err)
+	}
+	return c, nil
+}
+
+var (
+	activeLk sync.RWMutex
+	active   *Proxy
+)
+
+// SetActive makes p the proxy of the node. Nil disables it.
+func SetActive(p *Proxy) {
+	activeLk.Lock()
+	defer activeLk.Unlock()
+	active = p
+}
+
+// Active returns the proxy of the node, nil when disabled.
+func Active() *Proxy {
+	activeLk.RLock()
+	defer activeLk.RUnlock()
+	return active
+}
+
+// Client returns the HTTP client of the subsystem s: through the active
+// proxy when it proxies s, http.DefaultClient otherwise.
+func Client(s Subsystem) *http.Client {
+	p := Active()
+	if !p.Enabled(s) {
+		return http.DefaultClient
+	}
+	return &http.Client{Transport: &http.Transport{
+		DialContext:         p.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     http.DefaultTransport.(*http.Transport).IdleConnTimeout,
+		TLSHandshakeTimeout: http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout,
+	}}
+}
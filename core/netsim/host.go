@@ -0,0 +1,83 @@
+package netsim
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// WrapHost returns h with the streams it opens and accepts degraded by s.
+// Only the handlers set through the returned host see degraded streams.
+func WrapHost(h host.Host, s *Simulator) host.Host {
+	return &simHost{Host: h, sim: s}
+}
+
+type simHost struct {
+	host.Host
+	sim *Simulator
+}
+
+func (h *simHost) NewStream(ctx context.Context, p peer.ID, pids ...protocol.ID) (network.Stream, error) {
+	st, err := h.Host.NewStream(ctx, p, pids...)
+	if err != nil {
+		return nil, err
+	}
+	c := h.sim.Match(string(st.Protocol()))
+	if c == nil {
+		return st, nil
+	}
+	// opening a stream takes a round trip
+	if err := c.wait(ctx, c.Delay()); err != nil {
+		st.Reset()
+		return nil, err
+	}
+	if c.Disconnect() {
+		st.Reset()
+		return nil, ErrDisconnected
+	}
+	return &stream{Stream: st, c: c}, nil
+}
+
+func (h *simHost) SetStreamHandler(pid protocol.ID, handler network.StreamHandler) {
+	h.Host.SetStreamHandler(pid, h.wrapHandler(handler))
+}
+
+func (h *simHost) SetStreamHandlerMatch(pid protocol.ID, m func(string) bool, handler network.StreamHandler) {
+	h.Host.SetStreamHandlerMatch(pid, m, h.wrapHandler(handler))
+}
+
+func (h *simHost) wrapHandler(handler network.StreamHandler) network.StreamHandler {
+	return func(st network.Stream) {
+		if c := h.sim.Match(string(st.Protocol())); c != nil {
+			st = &stream{Stream: st, c: c}
+		}
+		handler(st)
+	}
+}
+
+// stream is a stream degraded by its conditions.
+type stream struct {
+	network.Stream
+	c *Conditions
+}
+
+func (s *stream) Read(p []byte) (int, error) {
+	if s.c.Disconnect() {
+		s.Stream.Reset()
+		return 0, ErrDisconnected
+	}
+	return s.Stream.Read(p)
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	if s.c.Disconnect() {
+		s.Stream.Reset()
+		return 0, ErrDisconnected
+	}
+	time.Sleep(s.c.Delay() + s.c.transmit(len(p)))
+	return s.Stream.Write(p)
+}
@@ -0,0 +1,242 @@
+// Package netsim degrades the network of a node for QA: it injects
+// latency, jitter, bandwidth caps and random disconnects on the libp2p
+// streams and service calls of chosen protocols, so that the retry logic
+// of storage and the wallet can be exercised under bad network conditions
+// in integration tests.
+//
+// It is configured in the NetworkSimulation config section and must never
+// be enabled on a production node.
+package netsim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ConfigKey is the config section of the network simulation.
+const ConfigKey = "NetworkSimulation"
+
+// Protocols of the service calls of the wallet, matched by rules as the
+// libp2p protocols are.
+const (
+	ExchangeProtocol = "/grpc/exchange"
+	EscrowProtocol   = "/grpc/escrow"
+	SolidityProtocol = "/grpc/solidity"
+	FullnodeProtocol = "/grpc/fullnode"
+)
+
+// ErrDisconnected is returned by the streams and calls a simulated
+// disconnect cut.
+var ErrDisconnected = errors.New("netsim: simulated disconnect")
+
+// Config configures the network simulation.
+type Config struct {
+	Enabled bool
+	// Seed seeds the random jitter and disconnects, for runs to be
+	// reproducible. A random seed is used when zero.
+	Seed int64 `json:",omitempty"`
+	// Rules are the conditions of the protocols, the first matching a
+	// protocol applies.
+	Rules []Rule `json:",omitempty"`
+}
+
+// Rule degrades the network of some protocols.
+type Rule struct {
+	// Protocols are the prefixes of the protocols degraded, e.g. "/rapi"
+	// for the storage remote calls or "/grpc/" for the wallet calls. All
+	// protocols when empty.
+	Protocols []string `json:",omitempty"`
+	// Latency is added to each write, and to each call, e.g. "200ms".
+	Latency string `json:",omitempty"`
+	// Jitter varies the latency by up to this duration either way.
+	Jitter string `json:",omitempty"`
+	// Bandwidth caps the bytes written per second on a stream, e.g. "256KB".
+	Bandwidth string `json:",omitempty"`
+	// DisconnectRate is the probability a read, a write or a call resets
+	// the stream or fails, in [0, 1].
+	DisconnectRate float64 `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the simulator configured in r, nil when disabled.
+func Load(r repo.Repo) (*Simulator, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if !c.Enabled {
+		return nil, nil
+	}
+	s, err := New(c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return s, nil
+}
+
+// Conditions are the compiled network conditions of a rule.
+type Conditions struct {
+	sim            *Simulator
+	protocols      []string
+	latency        time.Duration
+	jitter         time.Duration
+	bandwidth      int64
+	disconnectRate float64
+}
+
+// Simulator applies the conditions of its rules.
+type Simulator struct {
+	rules []*Conditions
+
+	lk  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New returns the simulator of c.
+func New(c *Config) (*Simulator, error) {
+	seed := c.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s := &Simulator{rnd: rand.New(rand.NewSource(seed))}
+	for i, r := range c.Rules {
+		cd := &Conditions{sim: s, protocols: r.Protocols, disconnectRate: r.DisconnectRate}
+		var err error
+		if r.Latency != "" {
+			if cd.latency, err = time.ParseDuration(r.Latency); err != nil || cd.latency < 0 {
+				return nil, fmt.Errorf("rule %d: invalid latency %q", i, r.Latency)
+			}
+		}
+		if r.Jitter != "" {
+			if cd.jitter, err = time.ParseDuration(r.Jitter); err != nil || cd.jitter < 0 {
+				return nil, fmt.Errorf("rule %d: invalid jitter %q", i, r.Jitter)
+			}
+		}
+		if r.Bandwidth != "" {
+			bw, err := humanize.ParseBytes(r.Bandwidth)
+			if err != nil || bw == 0 {
+				return nil, fmt.Errorf("rule %d: invalid bandwidth %q", i, r.Bandwidth)
+			}
+			cd.bandwidth = int64(bw)
+		}
+		if r.DisconnectRate < 0 || r.DisconnectRate > 1 {
+			return nil, fmt.Errorf("rule %d: disconnect rate %v is not within [0, 1]", i, r.DisconnectRate)
+		}
+		s.rules = append(s.rules, cd)
+	}
+	return s, nil
+}
+
+// Match returns the conditions of the protocol proto, nil when no rule
+// matches.
+func (s *Simulator) Match(proto string) *Conditions {
+	if s == nil {
+		return nil
+	}
+	for _, r := range s.rules {
+		if len(r.protocols) == 0 {
+			return r
+		}
+		for _, p := range r.protocols {
+			if strings.HasPrefix(proto, p) {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// Call applies the conditions of proto to a service call: it waits for the
+// latency and fails with ErrDisconnected at the disconnect rate.
+func (s *Simulator) Call(ctx context.Context, proto string) error {
+	c := s.Match(proto)
+	if c == nil {
+		return nil
+	}
+	if err := c.wait(ctx, c.Delay()); err != nil {
+		return err
+	}
+	if c.Disconnect() {
+		return ErrDisconnected
+	}
+	return nil
+}
+
+// Delay returns the latency of the next write or call, with its jitter.
+func (c *Conditions) Delay() time.Duration {
+	d := c.latency
+	if c.jitter > 0 {
+		c.sim.lk.Lock()
+		d += time.Duration(c.sim.rnd.Int63n(int64(2*c.jitter+1))) - c.jitter
+		c.sim.lk.Unlock()
+	}
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Disconnect reports whether the next read, write or call is cut.
+func (c *Conditions) Disconnect() bool {
+	if c.disconnectRate <= 0 {
+		return false
+	}
+	c.sim.lk.Lock()
+	defer c.sim.lk.Unlock()
+	return c.sim.rnd.Float64() < c.disconnectRate
+}
+
+// transmit returns the time to send n bytes under the bandwidth cap.
+func (c *Conditions) transmit(n int) time.Duration {
+	if c.bandwidth <= 0 {
+		return 0
+	}
+	return time.Duration(int64(n) * int64(time.Second) / c.bandwidth)
+}
+
+func (c *Conditions) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var (
+	activeLk sync.RWMutex
+	active   *Simulator
+)
+
+// SetActive makes s the simulator of the node, applied to the wallet calls.
+// Nil disables it.
+func SetActive(s *Simulator) {
+	activeLk.Lock()
+	defer activeLk.Unlock()
+	active = s
+}
+
+// Active returns the simulator of the node, nil when disabled.
+func Active() *Simulator {
+	activeLk.RLock()
+	defer activeLk.RUnlock()
+	return active
+}
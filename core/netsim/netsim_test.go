@@ -0,0 +1,95 @@
+package netsim
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+func TestNew(t *testing.T) {
+	for _, r := range []Rule{
+		{Latency: "soon"},
+		{Jitter: "-1s"},
+		{Bandwidth: "fast"},
+		{DisconnectRate: 1.5},
+	} {
+		if _, err := New(&Config{Rules: []Rule{r}}); err == nil {
+			t.Fatalf("expected %+v to be invalid", r)
+		}
+	}
+	s, err := New(&Config{Seed: 1, Rules: []Rule{
+		{Protocols: []string{"/rapi"}, Latency: "100ms", Jitter: "20ms"},
+		{Protocols: []string{"/grpc/"}, DisconnectRate: 1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Match("/ipfs/bitswap/1.2.0") != nil {
+		t.Fatal("expected no rule for bitswap")
+	}
+	c := s.Match("/rapi")
+	if c == nil || c.latency != 100*time.Millisecond {
+		t.Fatalf("expected the /rapi rule, got %+v", c)
+	}
+	for i := 0; i < 100; i++ {
+		if d := c.Delay(); d < 80*time.Millisecond || d > 120*time.Millisecond {
+			t.Fatalf("delay %s out of the jitter", d)
+		}
+	}
+	if err := s.Call(context.Background(), ExchangeProtocol); err != ErrDisconnected {
+		t.Fatalf("expected a disconnect, got %v", err)
+	}
+	var disabled *Simulator
+	if err := disabled.Call(context.Background(), ExchangeProtocol); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWrapHost(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mn, err := mocknet.FullMeshConnected(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := mn.Hosts()
+	hosts[1].SetStreamHandler("/rapi", func(s network.Stream) {
+		defer s.Close()
+		ioutil.ReadAll(s)
+	})
+	hosts[1].SetStreamHandler("/cut", func(s network.Stream) {
+		defer s.Close()
+		ioutil.ReadAll(s)
+	})
+
+	s, err := New(&Config{Seed: 1, Rules: []Rule{
+		{Protocols: []string{"/rapi"}, Latency: "50ms", Bandwidth: "10kB"},
+		{Protocols: []string{"/cut"}, DisconnectRate: 1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := WrapHost(hosts[0], s)
+
+	start := time.Now()
+	st, err := h.NewStream(ctx, hosts[1].ID(), "/rapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Write(make([]byte, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+	// a round trip to open, the latency and 100ms to send 1kB at 10kB/s
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Fatalf("expected the stream to be degraded, took %s", d)
+	}
+
+	if _, err := h.NewStream(ctx, hosts[1].ID(), "/cut"); err != ErrDisconnected {
+		t.Fatalf("expected a disconnect, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/TRON-US/go-btfs/core/access"
 	"github.com/TRON-US/go-btfs/core/node/helpers"
 	"github.com/TRON-US/go-btfs/repo"
 
@@ -92,7 +93,7 @@ func Dag(bs blockservice.BlockService) format.DAGService {
 // OnlineExchange creates new LibP2P backed block exchange (BitSwap)
 func OnlineExchange(provide bool) interface{} {
 	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, host host.Host, rt routing.Routing, bs blockstore.GCBlockstore) exchange.Interface {
-		bitswapNetwork := network.NewFromIpfsHost(host, rt)
+		bitswapNetwork := access.WrapNetwork(network.NewFromIpfsHost(host, rt), access.Default)
 		exch := bitswap.New(helpers.LifecycleCtx(mctx, lc), bitswapNetwork, bs, bitswap.ProvideEnabled(provide))
 		lc.Append(fx.Hook{
 			OnStop: func(ctx context.Context) error {
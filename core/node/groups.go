@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/TRON-US/go-btfs/core/node/libp2p"
+	"github.com/TRON-US/go-btfs/core/power"
 	"github.com/TRON-US/go-btfs/p2p"
 
 	"github.com/tron-us/go-btfs-common/crypto"
@@ -279,6 +280,16 @@ func Online(bcfg *BuildCfg, cfg *config.Config) fx.Option {
 	/* don't provide from bitswap when the strategic provider service is active */
 	shouldBitswapProvide := !cfg.Experimental.StrategicProviding
 
+	// a node in low-power mode reprovides less often
+	pcfg, err := power.Load(bcfg.Repo)
+	if err != nil {
+		return fx.Error(err)
+	}
+	reprovideInterval, err := pcfg.ReprovideInterval(cfg.Reprovider.Interval)
+	if err != nil {
+		return fx.Error(err)
+	}
+
 	return fx.Options(
 		fx.Provide(OnlineExchange(shouldBitswapProvide)),
 		maybeProvide(Graphsync, cfg.Experimental.GraphsyncEnabled),
@@ -291,7 +302,7 @@ func Online(bcfg *BuildCfg, cfg *config.Config) fx.Option {
 		fx.Provide(p2p.New),
 
 		LibP2P(bcfg, cfg),
-		OnlineProviders(cfg.Experimental.StrategicProviding, cfg.Reprovider.Strategy, cfg.Reprovider.Interval),
+		OnlineProviders(cfg.Experimental.StrategicProviding, cfg.Reprovider.Strategy, reprovideInterval),
 	)
 }
 
@@ -1,13 +1,18 @@
 package libp2p
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/TRON-US/go-btfs/core/addrprofile"
+	"github.com/TRON-US/go-btfs/repo"
+
 	"github.com/libp2p/go-libp2p"
 	host "github.com/libp2p/go-libp2p-core/host"
 	p2pbhost "github.com/libp2p/go-libp2p/p2p/host/basic"
 	ma "github.com/multiformats/go-multiaddr"
 	mamask "github.com/whyrusleeping/multiaddr-filter"
+	"go.uber.org/fx"
 )
 
 func AddrFilters(filters []string) func() (*ma.Filters, Libp2pOpts, error) {
@@ -25,7 +30,7 @@ func AddrFilters(filters []string) func() (*ma.Filters, Libp2pOpts, error) {
 	}
 }
 
-func makeAddrsFactory(announce []string, noAnnounce []string) (p2pbhost.AddrsFactory, error) {
+func makeAddrsFactory(announce []string, noAnnounce []string, profile *addrprofile.Profile) (p2pbhost.AddrsFactory, error) {
 	var annAddrs []ma.Multiaddr
 	for _, addr := range announce {
 		maddr, err := ma.NewMultiaddr(addr)
@@ -57,6 +62,9 @@ func makeAddrsFactory(announce []string, noAnnounce []string) (p2pbhost.AddrsFac
 		} else {
 			addrs = allAddrs
 		}
+		if profile != nil {
+			addrs = profile.Apply(addrs)
+		}
 
 		var out []ma.Multiaddr
 		for _, maddr := range addrs {
@@ -71,9 +79,27 @@ func makeAddrsFactory(announce []string, noAnnounce []string) (p2pbhost.AddrsFac
 	}, nil
 }
 
-func AddrsFactory(announce []string, noAnnounce []string) func() (opts Libp2pOpts, err error) {
-	return func() (opts Libp2pOpts, err error) {
-		addrsFactory, err := makeAddrsFactory(announce, noAnnounce)
+func AddrsFactory(announce []string, noAnnounce []string) func(repo.Repo, fx.Lifecycle) (opts Libp2pOpts, err error) {
+	return func(r repo.Repo, lc fx.Lifecycle) (opts Libp2pOpts, err error) {
+		profile, err := addrprofile.Load(r)
+		if err != nil {
+			return opts, err
+		}
+		if profile != nil {
+			addrprofile.SetActive(profile)
+			ctx, cancel := context.WithCancel(context.Background())
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					go profile.Run(ctx)
+					return nil
+				},
+				OnStop: func(context.Context) error {
+					cancel()
+					return nil
+				},
+			})
+		}
+		addrsFactory, err := makeAddrsFactory(announce, noAnnounce, profile)
 		if err != nil {
 			return opts, err
 		}
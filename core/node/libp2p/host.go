@@ -3,7 +3,9 @@ package libp2p
 import (
 	"context"
 
+	"github.com/TRON-US/go-btfs/core/netsim"
 	"github.com/TRON-US/go-btfs/core/node/helpers"
+	"github.com/TRON-US/go-btfs/core/peerstats"
 	"github.com/TRON-US/go-btfs/repo"
 
 	"github.com/libp2p/go-libp2p"
@@ -42,6 +44,13 @@ func Host(mctx helpers.MetricsCtx, lc fx.Lifecycle, params P2PHostIn) (out P2PHo
 		opts = append(opts, o...)
 	}
 
+	stats, err := peerstats.Load(params.Repo)
+	if err != nil {
+		return out, err
+	}
+	peerstats.Default.SetConfig(stats)
+	opts = append(opts, libp2p.ConnectionGater(peerstats.Default))
+
 	ctx := helpers.LifecycleCtx(mctx, lc)
 	cfg, err := params.Repo.Config()
 	if err != nil {
@@ -79,6 +88,17 @@ func Host(mctx helpers.MetricsCtx, lc fx.Lifecycle, params P2PHostIn) (out P2PHo
 		out.Host = routedhost.Wrap(out.Host, out.Routing)
 	}
 
+	sim, err := netsim.Load(params.Repo)
+	if err != nil {
+		return P2PHostOut{}, err
+	}
+	if sim != nil {
+		log.Warnf("%s is enabled: the network is degraded for testing", netsim.ConfigKey)
+		netsim.SetActive(sim)
+		out.Host = netsim.WrapHost(out.Host, sim)
+	}
+	out.Host = peerstats.WrapHost(out.Host, peerstats.Default)
+
 	lc.Append(fx.Hook{
 		OnStop: func(ctx context.Context) error {
 			return out.Host.Close()
@@ -3,6 +3,8 @@ package libp2p
 import (
 	"fmt"
 
+	"github.com/TRON-US/go-btfs/core/netproxy"
+
 	config "github.com/TRON-US/go-btfs-config"
 
 	libp2p "github.com/libp2p/go-libp2p"
@@ -21,6 +23,14 @@ func Transports(tptConfig config.Transports) interface{} {
 	}) (opts Libp2pOpts, err error) {
 		privateNetworkEnabled := pnet.Fprint != nil
 
+		// Only the TCP dials can go through the SOCKS5 proxy, the other
+		// transports would bypass it.
+		if netproxy.Active().Enabled(netproxy.Libp2p) {
+			log.Infof("libp2p dials go through the %s, only the TCP transport is enabled", netproxy.ConfigKey)
+			opts.Opts = append(opts.Opts, libp2p.Transport(netproxy.TCPTransport))
+			return opts, nil
+		}
+
 		if tptConfig.Network.TCP.WithDefault(true) {
 			opts.Opts = append(opts.Opts, libp2p.Transport(tcp.NewTCPTransport))
 		}
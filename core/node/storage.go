@@ -1,8 +1,12 @@
 package node
 
 import (
+	"context"
+
+	"github.com/TRON-US/go-btfs/core/blockcache"
 	"github.com/TRON-US/go-btfs/core/node/helpers"
 	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/repocrypt"
 	"github.com/TRON-US/go-btfs/thirdparty/cidv0v1"
 	"github.com/TRON-US/go-btfs/thirdparty/verifbs"
 
@@ -30,10 +34,14 @@ type BaseBlocks blockstore.Blockstore
 func BaseBlockstoreCtor(cacheOpts blockstore.CacheOpts, nilRepo bool, hashOnRead bool) func(mctx helpers.MetricsCtx, repo repo.Repo, lc fx.Lifecycle) (bs BaseBlocks, err error) {
 	return func(mctx helpers.MetricsCtx, repo repo.Repo, lc fx.Lifecycle) (bs BaseBlocks, err error) {
 		// hash security
-		bs = blockstore.NewBlockstore(repo.Datastore())
+		bs = blockstore.NewBlockstore(repocrypt.NewDatastore(repo.Datastore(), repocrypt.Default))
 		bs = &verifbs.VerifBS{Blockstore: bs}
 
 		if !nilRepo {
+			bs, err = blockCache(repo, lc, bs)
+			if err != nil {
+				return nil, err
+			}
 			bs, err = blockstore.CachedBlockstore(helpers.LifecycleCtx(mctx, lc), bs, cacheOpts)
 			if err != nil {
 				return nil, err
@@ -51,6 +59,30 @@ func BaseBlockstoreCtor(cacheOpts blockstore.CacheOpts, nilRepo bool, hashOnRead
 	}
 }
 
+// blockCache puts the block cache in front of bs when configured.
+func blockCache(repo repo.Repo, lc fx.Lifecycle, bs blockstore.Blockstore) (blockstore.Blockstore, error) {
+	ccfg, err := blockcache.Load(repo)
+	if err != nil || ccfg == nil {
+		return bs, err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	c, err := blockcache.Open(ccfg, bs, repo.Datastore(), cfg.Identity.PeerID)
+	if err != nil {
+		return nil, err
+	}
+	blockcache.SetActive(c)
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			blockcache.SetActive(nil)
+			return c.Close()
+		},
+	})
+	return c, nil
+}
+
 // GcBlockstoreCtor wraps the base blockstore with GC and Filestore layers
 func GcBlockstoreCtor(bb BaseBlocks) (gclocker blockstore.GCLocker, gcbs blockstore.GCBlockstore, bs blockstore.Blockstore) {
 	gclocker = blockstore.NewGCLocker()
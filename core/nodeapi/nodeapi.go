@@ -0,0 +1,97 @@
+// Package nodeapi extends the CoreAPI of a node with its storage and wallet
+// operations, so that applications embedding a node can drive storage
+// deals in-process instead of through the commands.
+//
+// See docs/examples/btfs-storage-as-a-library for an example.
+package nodeapi
+
+import (
+	"context"
+	"io"
+
+	oldcmds "github.com/TRON-US/go-btfs/commands"
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands"
+	"github.com/TRON-US/go-btfs/core/coreapi"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	coreiface "github.com/TRON-US/interface-go-btfs-core"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+)
+
+// API is the CoreAPI of a node with its storage and wallet operations.
+type API interface {
+	coreiface.CoreAPI
+
+	// Storage returns an implementation of the storage API.
+	Storage() StorageAPI
+
+	// Wallet returns an implementation of the wallet API.
+	Wallet() WalletAPI
+}
+
+type nodeAPI struct {
+	coreiface.CoreAPI
+	node *core.IpfsNode
+	env  *oldcmds.Context
+}
+
+// New returns the API of n, whose repo is at repoPath. n must be online to
+// store files on hosts.
+func New(n *core.IpfsNode, repoPath string, opts ...options.ApiOption) (API, error) {
+	api, err := coreapi.NewCoreAPI(n, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &nodeAPI{
+		CoreAPI: api,
+		node:    n,
+		env: &oldcmds.Context{
+			ConfigRoot: repoPath,
+			ReqLog:     &oldcmds.ReqLog{},
+			ConstructNode: func() (*core.IpfsNode, error) {
+				return n, nil
+			},
+		},
+	}, nil
+}
+
+func (api *nodeAPI) Storage() StorageAPI {
+	return (*storageAPI)(api)
+}
+
+func (api *nodeAPI) Wallet() WalletAPI {
+	return (*walletAPI)(api)
+}
+
+// run executes the command at path in-process and returns the last value
+// it emitted. Most of the storage logic lives in commands.
+func (api *nodeAPI) run(ctx context.Context, path []string, args []string, opts cmds.OptMap) (interface{}, error) {
+	req, err := cmds.NewRequest(ctx, path, opts, args, nil, commands.Root)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.FillDefaults(); err != nil {
+		return nil, err
+	}
+	re, res := cmds.NewChanResponsePair(req)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cmds.NewExecutor(commands.Root).Execute(req, re, api.env)
+	}()
+	var last interface{}
+	for {
+		v, err := res.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		last = v
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return last, nil
+}
@@ -0,0 +1,134 @@
+package nodeapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/upload"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	files "github.com/TRON-US/go-btfs-files"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+	"github.com/TRON-US/interface-go-btfs-core/path"
+)
+
+// UploadPollInterval is how often WaitUpload checks an upload session.
+var UploadPollInterval = 5 * time.Second
+
+// UploadOptions are the options of an upload, the zero value of a field
+// keeps its default.
+type UploadOptions struct {
+	// Price is the max price per GiB per day of storage in µBTT. Defaults
+	// to the price asked by the hosts.
+	Price int64
+	// ReplicationFactor is the number of copies of each shard.
+	ReplicationFactor int
+	// HostSelectMode selects the hosts, see "btfs storage upload --help".
+	// Defaults to the mode set in Experimental.HostsSyncMode.
+	HostSelectMode string
+	// Hosts are the peer ids of the hosts storing the shards in order, with
+	// the "custom" mode.
+	Hosts []string
+	// StorageLength is the storage period on hosts in days.
+	StorageLength int
+	// Policy is the upload policy setting the options left to their
+	// defaults. Defaults to UploadPolicies.Default.
+	Policy string
+}
+
+// StorageAPI stores files on hosts and fetches them back.
+type StorageAPI interface {
+	// Upload starts storing the reed-solomon encoded file at p on hosts,
+	// and returns the id of its upload session. opts may be nil.
+	Upload(ctx context.Context, p path.Path, opts *UploadOptions) (string, error)
+
+	// UploadStatus returns the status of an upload session.
+	UploadStatus(ctx context.Context, sessionID string) (*upload.StatusRes, error)
+
+	// WaitUpload waits for an upload session to complete, and fails if the
+	// session does.
+	WaitUpload(ctx context.Context, sessionID string) (*upload.StatusRes, error)
+
+	// Download returns the file at p, fetched from the hosts storing it.
+	Download(ctx context.Context, p path.Path, opts ...options.UnixfsGetOption) (files.Node, error)
+}
+
+type storageAPI nodeAPI
+
+func (api *storageAPI) Upload(ctx context.Context, p path.Path, opts *UploadOptions) (string, error) {
+	rp, err := api.CoreAPI.ResolvePath(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	optMap := cmds.OptMap{"progress": false}
+	if opts != nil {
+		if opts.Price > 0 {
+			optMap["price"] = opts.Price
+		}
+		if opts.ReplicationFactor > 0 {
+			optMap["replication-factor"] = opts.ReplicationFactor
+		}
+		if opts.HostSelectMode != "" {
+			optMap["host-select-mode"] = opts.HostSelectMode
+		}
+		if len(opts.Hosts) > 0 {
+			optMap["host-selection"] = strings.Join(opts.Hosts, ",")
+		}
+		if opts.StorageLength > 0 {
+			optMap["storage-length"] = opts.StorageLength
+		}
+		if opts.Policy != "" {
+			optMap["policy"] = opts.Policy
+		}
+	}
+	v, err := (*nodeAPI)(api).run(ctx, []string{"storage", "upload"}, []string{rp.Cid().String()}, optMap)
+	if err != nil {
+		return "", err
+	}
+	res, ok := v.(*upload.Res)
+	if !ok {
+		return "", fmt.Errorf("unexpected upload output %T", v)
+	}
+	return res.ID, nil
+}
+
+func (api *storageAPI) UploadStatus(ctx context.Context, sessionID string) (*upload.StatusRes, error) {
+	v, err := (*nodeAPI)(api).run(ctx, []string{"storage", "upload", "status"}, []string{sessionID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	status, ok := v.(*upload.StatusRes)
+	if !ok {
+		return nil, fmt.Errorf("unexpected upload status output %T", v)
+	}
+	return status, nil
+}
+
+func (api *storageAPI) WaitUpload(ctx context.Context, sessionID string) (*upload.StatusRes, error) {
+	ticker := time.NewTicker(UploadPollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := api.UploadStatus(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		switch status.Status {
+		case sessions.RssCompleteStatus:
+			return status, nil
+		case sessions.RssErrorStatus:
+			return status, fmt.Errorf("upload session %s failed: %s", sessionID, status.Message)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return status, ctx.Err()
+		}
+	}
+}
+
+func (api *storageAPI) Download(ctx context.Context, p path.Path, opts ...options.UnixfsGetOption) (files.Node, error) {
+	return api.CoreAPI.Unixfs().Get(ctx, p, opts...)
+}
@@ -0,0 +1,80 @@
+package nodeapi
+
+import (
+	"context"
+
+	"github.com/TRON-US/go-btfs/core/wallet"
+	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+)
+
+// Balance is the balance of the wallet of a node in µBTT.
+type Balance struct {
+	// Ledger is the balance in the BTFS wallet, spent on storage.
+	Ledger int64
+	// Tron is the balance in the BTT wallet on the TRON blockchain.
+	Tron int64
+}
+
+// WalletAPI moves BTT between the wallets of a node and other accounts.
+// Amounts are in µBTT (=0.000001BTT). Unlike the wallet commands, the
+// operations do not ask for the wallet password.
+type WalletAPI interface {
+	// Balance returns the balance of the wallet.
+	Balance(ctx context.Context) (*Balance, error)
+
+	// Deposit moves amount from the BTT wallet to the BTFS wallet. When
+	// async is set, it returns once the deposit is submitted.
+	Deposit(ctx context.Context, amount int64, async bool) error
+
+	// Withdraw moves amount from the BTFS wallet to the BTT wallet.
+	Withdraw(ctx context.Context, amount int64) error
+
+	// Transfer sends amount from the BTT wallet to the address to.
+	Transfer(ctx context.Context, to string, amount int64) (*wallet.TronRet, error)
+
+	// Transactions returns the deposits, withdrawals and transfers of the
+	// wallet.
+	Transactions(ctx context.Context) ([]*walletpb.TransactionV1, error)
+}
+
+type walletAPI nodeAPI
+
+func (api *walletAPI) Balance(ctx context.Context) (*Balance, error) {
+	cfg, err := api.node.Repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	tron, ledger, err := wallet.GetBalance(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Balance{Ledger: ledger, Tron: tron}, nil
+}
+
+func (api *walletAPI) Deposit(ctx context.Context, amount int64, async bool) error {
+	cfg, err := api.node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	return wallet.WalletDeposit(ctx, cfg, api.node, amount, api.node.IsDaemon, async)
+}
+
+func (api *walletAPI) Withdraw(ctx context.Context, amount int64) error {
+	cfg, err := api.node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	return wallet.WalletWithdraw(ctx, cfg, api.node, amount)
+}
+
+func (api *walletAPI) Transfer(ctx context.Context, to string, amount int64) (*wallet.TronRet, error) {
+	cfg, err := api.node.Repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	return wallet.TransferBTT(ctx, api.node, cfg, nil, "", to, amount)
+}
+
+func (api *walletAPI) Transactions(ctx context.Context) ([]*walletpb.TransactionV1, error) {
+	return wallet.GetTransactions(api.node.Repo.Datastore(), api.node.Identity.Pretty())
+}
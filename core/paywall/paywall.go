@@ -0,0 +1,228 @@
+// Package paywall lets publishers charge for the content their gateway
+// serves. A request for a path under a priced rule is answered 402 Payment
+// Required with the terms of the rule. The client pays the price from its
+// ledger to the node, which then issues an access token it signs with its
+// identity key, valid for the path of the rule until its TTL runs out.
+package paywall
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	cid "github.com/ipfs/go-cid"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ConfigKey is the config section of the paywall.
+const ConfigKey = "Paywall"
+
+// DefaultTTL is how long an access lasts when its rule sets no TTL.
+const DefaultTTL = 24 * time.Hour
+
+// Paths of the paywall on the gateway, and where clients pass their token.
+const (
+	PayPath     = "/paywall/pay"
+	TokenHeader = "X-Paywall-Token"
+	TokenParam  = "paywall-token"
+)
+
+// Config configures the paywall of the gateway.
+type Config struct {
+	Enabled bool
+	Rules   []*Rule `json:",omitempty"`
+}
+
+// Rule prices the access to the content under a path.
+type Rule struct {
+	// Path is the path of the content, e.g. /btfs/<cid>, covering the paths
+	// under it. It is loaded in its canonical form, see CanonicalPath.
+	Path string
+	// Price is the price of the access in µBTT.
+	Price int64
+	// TTL is how long the access lasts, e.g. "72h", DefaultTTL when empty.
+	TTL string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the paywall config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	for i, rule := range c.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s config: rule %d: %s", ConfigKey, i, err)
+		}
+	}
+	return c, nil
+}
+
+func (r *Rule) validate() error {
+	if !strings.HasPrefix(r.Path, "/btfs/") && !strings.HasPrefix(r.Path, "/btns/") {
+		return fmt.Errorf("path %q is not under /btfs or /btns", r.Path)
+	}
+	p, err := CanonicalPath(r.Path)
+	if err != nil {
+		return err
+	}
+	r.Path = p
+	if r.Price <= 0 {
+		return errors.New("price must be positive")
+	}
+	if r.TTL != "" {
+		if d, err := time.ParseDuration(r.TTL); err != nil || d <= 0 {
+			return fmt.Errorf("invalid TTL %q", r.TTL)
+		}
+	}
+	return nil
+}
+
+// Duration returns how long an access granted by r lasts.
+func (r *Rule) Duration() time.Duration {
+	d, err := time.ParseDuration(r.TTL)
+	if err != nil {
+		return DefaultTTL
+	}
+	return d
+}
+
+// CanonicalPath returns p cleaned, with the root CID of a /btfs path as a
+// CIDv1 in base32, and the peer ID of a /btns path as a CIDv1 of the key,
+// so that the rules match the content whatever the encoding of its root in
+// the requests. Other paths are only cleaned.
+func CanonicalPath(p string) (string, error) {
+	p = path.Clean("/" + p)
+	parts := strings.SplitN(strings.TrimPrefix(p, "/"), "/", 3)
+	if len(parts) < 2 {
+		return p, nil
+	}
+	switch parts[0] {
+	case "btfs":
+		c, err := cid.Decode(parts[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid root of %q: %s", p, err)
+		}
+		parts[1] = cid.NewCidV1(c.Type(), c.Hash()).String()
+	case "btns":
+		if id, err := peer.Decode(parts[1]); err == nil {
+			parts[1] = peer.ToCid(id).String()
+		} else {
+			// a domain name
+			parts[1] = strings.ToLower(parts[1])
+		}
+	default:
+		return p, nil
+	}
+	return "/" + strings.Join(parts, "/"), nil
+}
+
+// Match returns the rule of the most specific path covering p, nil when
+// the content at p is free. p is in its canonical form, see CanonicalPath.
+func (c *Config) Match(p string) *Rule {
+	var best *Rule
+	for _, r := range c.Rules {
+		root := strings.TrimSuffix(r.Path, "/")
+		if p != root && !strings.HasPrefix(p, root+"/") {
+			continue
+		}
+		if best == nil || len(root) > len(strings.TrimSuffix(best.Path, "/")) {
+			best = r
+		}
+	}
+	return best
+}
+
+// Terms are the conditions of the access to paywalled content, the body of
+// the 402 responses.
+type Terms struct {
+	// Publisher is the peer ID of the node the price is paid to.
+	Publisher string
+	// Path is the path of the rule, the one to pay for.
+	Path  string
+	Price int64
+	TTL   time.Duration
+	// PayPath is where the payment is posted on the gateway.
+	PayPath string
+}
+
+// NewTerms returns the terms of r on the gateway of publisher.
+func NewTerms(publisher string, r *Rule) *Terms {
+	return &Terms{Publisher: publisher, Path: r.Path, Price: r.Price, TTL: r.Duration(), PayPath: PayPath}
+}
+
+// Grant is the access of a payer to a path.
+type Grant struct {
+	Path   string
+	Payer  string
+	Expiry time.Time
+}
+
+// Access is the answer of the gateway to a payment.
+type Access struct {
+	Path   string
+	Token  string
+	Expiry time.Time
+}
+
+// Issue returns the access token of g, signed with key.
+func Issue(key ic.PrivKey, g *Grant) (string, error) {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return "", err
+	}
+	sig, err := key.Sign(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b) + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Verify returns the grant of token, checking it is signed by pub and not
+// expired at now.
+func Verify(pub ic.PubKey, token string, now time.Time) (*Grant, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, errors.New("malformed access token")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed access token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed access token")
+	}
+	if ok, err := pub.Verify(b, sig); err != nil || !ok {
+		return nil, errors.New("invalid signature of the access token")
+	}
+	g := &Grant{}
+	if err := json.Unmarshal(b, g); err != nil {
+		return nil, fmt.Errorf("invalid access token: %s", err)
+	}
+	if now.After(g.Expiry) {
+		return nil, errors.New("access token expired")
+	}
+	return g, nil
+}
+
+// Token returns the access token of the request r, empty when none.
+func Token(r *http.Request) string {
+	if t := r.Header.Get(TokenHeader); t != "" {
+		return t
+	}
+	return r.URL.Query().Get(TokenParam)
+}
@@ -0,0 +1,163 @@
+package paywall
+
+import (
+	"crypto/rand"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+func TestMatch(t *testing.T) {
+	c := &Config{Rules: []*Rule{
+		{Path: "/btfs/QmRoot", Price: 10},
+		{Path: "/btfs/QmRoot/videos/", Price: 100},
+	}}
+	cases := []struct {
+		path string
+		want int64
+	}{
+		{"/btfs/QmRoot", 10},
+		{"/btfs/QmRoot/index.html", 10},
+		{"/btfs/QmRoot/videos", 100},
+		{"/btfs/QmRoot/videos/a.mp4", 100},
+		{"/btfs/QmRootOther", 0},
+		{"/btfs/QmFree", 0},
+	}
+	for _, tc := range cases {
+		var got int64
+		if r := c.Match(tc.path); r != nil {
+			got = r.Price
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected price %d, got %d", tc.path, tc.want, got)
+		}
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	const (
+		v0 = "QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH"
+		// CIDv1 of the same multihash
+		v1 = "bafybeif7ztnhq65lumvvtr4ekcwd2ifwgm3awq4zfr3srh462rwyinlb4y"
+		// CIDv1 of the key of the peer
+		peerID  = "QmTFauExutTsy4XP6JbMFcw2Wa9645HJt2bTqL6qYDCKfe"
+		peerCid = "bafzbeici7y4y7bwmecey4kjqmfeth4arqartkbkspdur452bv4ucweglue"
+	)
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/btfs/" + v0, "/btfs/" + v1},
+		{"/btfs/" + v1 + "/a.txt", "/btfs/" + v1 + "/a.txt"},
+		{"/btfs/" + v0 + "/videos/../a.txt", "/btfs/" + v1 + "/a.txt"},
+		{"/btfs/" + v0 + "/videos/", "/btfs/" + v1 + "/videos"},
+		{"/btns/" + peerID + "/a.txt", "/btns/" + peerCid + "/a.txt"},
+		{"/btns/" + peerCid, "/btns/" + peerCid},
+		{"/btns/Example.com", "/btns/example.com"},
+		{"/version", "/version"},
+	}
+	for _, tc := range cases {
+		got, err := CanonicalPath(tc.path)
+		if err != nil {
+			t.Errorf("%s: %s", tc.path, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.path, tc.want, got)
+		}
+	}
+	if _, err := CanonicalPath("/btfs/QmRoot"); err == nil {
+		t.Fatal("expected an invalid root to be rejected")
+	}
+
+	// the rules are loaded in the canonical form
+	r := &Rule{Path: "/btfs/" + v0 + "/", Price: 1}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{Rules: []*Rule{r}}
+	p, _ := CanonicalPath("/btfs/" + v1 + "/a.txt")
+	if c.Match(p) != r {
+		t.Fatalf("expected %s to match the rule of %s", p, r.Path)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, r := range []*Rule{
+		{Path: "/index.html", Price: 1},
+		{Path: "/btfs/QmRoot", Price: 0},
+		{Path: "/btfs/QmRoot", Price: 1, TTL: "forever"},
+		{Path: "/btfs/QmRoot", Price: 1, TTL: "-1h"},
+	} {
+		if err := r.validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", r)
+		}
+	}
+	r := &Rule{Path: "/btns/example.com", Price: 1, TTL: "72h"}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Duration() != 72*time.Hour {
+		t.Fatalf("expected a 72h access, got %s", r.Duration())
+	}
+	if d := (&Rule{}).Duration(); d != DefaultTTL {
+		t.Fatalf("expected the default TTL, got %s", d)
+	}
+}
+
+func TestIssueVerify(t *testing.T) {
+	key, pub, err := ic.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	g := &Grant{Path: "/btfs/QmRoot", Payer: "payer", Expiry: now.Add(time.Hour)}
+	token, err := Issue(key, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Verify(pub, token, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != g.Path || got.Payer != g.Payer || !got.Expiry.Equal(g.Expiry) {
+		t.Fatalf("expected %+v, got %+v", g, got)
+	}
+
+	if _, err := Verify(pub, token, now.Add(2*time.Hour)); err == nil {
+		t.Fatal("expected an expired token to be rejected")
+	}
+	other, _, err := ic.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := Issue(other, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(pub, forged, now); err == nil {
+		t.Fatal("expected a token of another key to be rejected")
+	}
+	parts := strings.Split(token, ".")
+	tampered, _ := Issue(key, &Grant{Path: "/btfs/QmOther", Expiry: g.Expiry})
+	if _, err := Verify(pub, strings.Split(tampered, ".")[0]+"."+parts[1], now); err == nil {
+		t.Fatal("expected a tampered token to be rejected")
+	}
+	if _, err := Verify(pub, "garbage", now); err == nil {
+		t.Fatal("expected a malformed token to be rejected")
+	}
+}
+
+func TestToken(t *testing.T) {
+	r := httptest.NewRequest("GET", "/btfs/QmRoot?"+TokenParam+"=query", nil)
+	if got := Token(r); got != "query" {
+		t.Fatalf("expected the token of the query, got %q", got)
+	}
+	r.Header.Set(TokenHeader, "header")
+	if got := Token(r); got != "header" {
+		t.Fatalf("expected the token of the header, got %q", got)
+	}
+}
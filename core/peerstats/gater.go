@@ -0,0 +1,97 @@
+package peerstats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/connmgr"
+	"github.com/libp2p/go-libp2p-core/control"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+var _ connmgr.ConnectionGater = (*Tracker)(nil)
+
+// Block is a peer refused by the tracker.
+type Block struct {
+	Peer  string
+	Until time.Time
+}
+
+// block refuses p until until and closes its connections, t.mu held.
+func (t *Tracker) block(p peer.ID, until time.Time) {
+	t.blocked[p] = until
+	if t.net != nil {
+		go t.net.ClosePeer(p)
+	}
+}
+
+// Block refuses the connections of p for d.
+func (t *Tracker) Block(p peer.ID, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.block(p, t.now().Add(d))
+}
+
+// Unblock accepts the connections of p again, false when p was not
+// blocked.
+func (t *Tracker) Unblock(p peer.ID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.blocked[p]
+	delete(t.blocked, p)
+	return ok && until.After(t.now())
+}
+
+// Blocked lists the peers refused, the first unblocked first.
+func (t *Tracker) Blocked() []Block {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	var blocks []Block
+	for p, until := range t.blocked {
+		if !until.After(now) {
+			delete(t.blocked, p)
+			continue
+		}
+		blocks = append(blocks, Block{Peer: p.Pretty(), Until: until})
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Until.Before(blocks[j].Until)
+	})
+	return blocks
+}
+
+// IsBlocked reports whether the connections of p are refused.
+func (t *Tracker) IsBlocked(p peer.ID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.blocked[p]
+	if ok && !until.After(t.now()) {
+		delete(t.blocked, p)
+		return false
+	}
+	return ok
+}
+
+func (t *Tracker) InterceptPeerDial(p peer.ID) bool {
+	return !t.IsBlocked(p)
+}
+
+func (t *Tracker) InterceptAddrDial(peer.ID, ma.Multiaddr) bool {
+	return true
+}
+
+func (t *Tracker) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+func (t *Tracker) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
+	return !t.IsBlocked(p)
+}
+
+func (t *Tracker) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
@@ -0,0 +1,139 @@
+package peerstats
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// WrapHost returns h with the streams it opens and accepts counted by t,
+// which closes the connections of the peers it blocks through h. Only the
+// handlers set through the returned host see counted streams.
+func WrapHost(h host.Host, t *Tracker) host.Host {
+	t.mu.Lock()
+	t.net = h.Network()
+	t.mu.Unlock()
+	return &statsHost{Host: h, t: t}
+}
+
+type statsHost struct {
+	host.Host
+	t *Tracker
+}
+
+func (h *statsHost) NewStream(ctx context.Context, p peer.ID, pids ...protocol.ID) (network.Stream, error) {
+	st, err := h.Host.NewStream(ctx, p, pids...)
+	if err != nil {
+		return nil, err
+	}
+	return newStream(st, h.t, network.DirOutbound), nil
+}
+
+func (h *statsHost) SetStreamHandler(pid protocol.ID, handler network.StreamHandler) {
+	h.Host.SetStreamHandler(pid, h.wrapHandler(handler))
+}
+
+func (h *statsHost) SetStreamHandlerMatch(pid protocol.ID, m func(string) bool, handler network.StreamHandler) {
+	h.Host.SetStreamHandlerMatch(pid, m, h.wrapHandler(handler))
+}
+
+func (h *statsHost) wrapHandler(handler network.StreamHandler) network.StreamHandler {
+	return func(st network.Stream) {
+		handler(newStream(st, h.t, network.DirInbound))
+	}
+}
+
+// stream counts the bytes it moves for its peer.
+type stream struct {
+	network.Stream
+	t   *Tracker
+	p   peer.ID
+	dir network.Direction
+
+	mu       sync.Mutex
+	category string
+	// counted is set once the stream is counted. The category of a remote
+	// API stream is only known from its first request, the first bytes
+	// read when inbound and written when outbound.
+	counted bool
+}
+
+func newStream(st network.Stream, t *Tracker, dir network.Direction) *stream {
+	s := &stream{
+		Stream:   st,
+		t:        t,
+		p:        st.Conn().RemotePeer(),
+		dir:      dir,
+		category: Category(string(st.Protocol())),
+	}
+	if s.category != Storage {
+		s.count(nil)
+	}
+	return s
+}
+
+// count counts the stream, classifying a remote API stream from its first
+// bytes b, and returns its category.
+func (s *stream) count(b []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counted {
+		return s.category
+	}
+	s.counted = true
+	if s.category == Storage {
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			b = b[:i]
+		}
+		if bytes.Contains(b, []byte(challengePath)) {
+			s.category = Challenge
+		}
+	}
+	s.t.stream(s.p, s.category, s.dir)
+	return s.category
+}
+
+// current returns the category of the stream, Storage for a remote API
+// stream not classified yet.
+func (s *stream) current() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.category
+}
+
+func (s *stream) Read(p []byte) (int, error) {
+	n, err := s.Stream.Read(p)
+	if n > 0 {
+		category := s.current()
+		if s.dir == network.DirInbound {
+			category = s.count(p[:n])
+		}
+		s.t.bytes(s.p, category, s.dir, n, true)
+	}
+	return n, err
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	category := s.current()
+	if s.dir == network.DirOutbound {
+		category = s.count(p)
+	}
+	n, err := s.Stream.Write(p)
+	s.t.bytes(s.p, category, s.dir, n, false)
+	return n, err
+}
+
+func (s *stream) Close() error {
+	s.count(nil)
+	return s.Stream.Close()
+}
+
+func (s *stream) Reset() error {
+	s.count(nil)
+	return s.Stream.Reset()
+}
@@ -0,0 +1,371 @@
+// Package peerstats counts the usage of the node by each peer: the streams
+// and bytes of each protocol, bitswap, the storage and challenge calls of
+// the remote API and the p2p tunnels, in both directions.
+//
+// The streams a peer opens to the node are measured over a window. A peer
+// opening more streams or moving more bytes than the limits of the
+// PeerStats config section in a window commits an offense; with AutoBlock
+// the connection gater of the node then refuses the peer for BlockFor.
+// Offenses can be exported as abuse reports signed by the node.
+package peerstats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/dustin/go-humanize"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var log = logging.Logger("peerstats")
+
+// ConfigKey is the config section of the peer statistics.
+const ConfigKey = "PeerStats"
+
+// Categories of the protocols counted.
+const (
+	Bitswap   = "bitswap"
+	Storage   = "storage"
+	Challenge = "challenge"
+	P2P       = "p2p"
+	Other     = "other"
+)
+
+// Categories lists the categories in display order.
+var Categories = []string{Bitswap, Storage, Challenge, P2P, Other}
+
+const (
+	// remoteAPIProtocol carries the storage and challenge calls, told apart
+	// by the path of their first request.
+	remoteAPIProtocol = "/rapi"
+	challengePath     = "/storage/challenge"
+
+	defaultWindow   = time.Minute
+	defaultBlockFor = time.Hour
+	// maxOffenses is the number of offenses kept per peer.
+	maxOffenses = 20
+	// retention is how long a peer idle is kept.
+	retention = 24 * time.Hour
+)
+
+// Config configures the limits of the usage of the node by a peer.
+type Config struct {
+	// Window is the period the streams opened by a peer are measured over,
+	// 1m by default.
+	Window string `json:",omitempty"`
+	// MaxStreams is the number of streams a peer may open in a window, 0
+	// for no limit.
+	MaxStreams int `json:",omitempty"`
+	// MaxBytes is the number of bytes the streams opened by a peer may move
+	// in a window, e.g. "1GB", no limit when empty.
+	MaxBytes string `json:",omitempty"`
+	// AutoBlock blocks the peers committing an offense for BlockFor, 1h by
+	// default.
+	AutoBlock bool   `json:",omitempty"`
+	BlockFor  string `json:",omitempty"`
+
+	window, blockFor time.Duration
+	maxBytes         int64
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the peer statistics config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	c.window, c.blockFor = defaultWindow, defaultBlockFor
+	var err error
+	if c.Window != "" {
+		if c.window, err = time.ParseDuration(c.Window); err != nil || c.window <= 0 {
+			return fmt.Errorf("invalid Window %q", c.Window)
+		}
+	}
+	if c.BlockFor != "" {
+		if c.blockFor, err = time.ParseDuration(c.BlockFor); err != nil || c.blockFor <= 0 {
+			return fmt.Errorf("invalid BlockFor %q", c.BlockFor)
+		}
+	}
+	if c.MaxBytes != "" {
+		b, err := humanize.ParseBytes(c.MaxBytes)
+		if err != nil {
+			return fmt.Errorf("invalid MaxBytes %q: %s", c.MaxBytes, err)
+		}
+		c.maxBytes = int64(b)
+	}
+	if c.MaxStreams < 0 {
+		return fmt.Errorf("negative MaxStreams")
+	}
+	return nil
+}
+
+// Category returns the category of the protocol proto.
+func Category(proto string) string {
+	switch {
+	case strings.HasPrefix(proto, "/ipfs/bitswap"):
+		return Bitswap
+	case strings.HasPrefix(proto, "/x/"):
+		return P2P
+	case proto == remoteAPIProtocol:
+		return Storage
+	}
+	return Other
+}
+
+// Usage is the usage of a category by a peer.
+type Usage struct {
+	StreamsIn  int64
+	StreamsOut int64
+	BytesIn    int64
+	BytesOut   int64
+}
+
+// Offense is a window in which a peer exceeded a limit.
+type Offense struct {
+	Time    time.Time
+	Window  string
+	Streams int
+	Bytes   int64
+	Limit   string
+}
+
+// Stats is the usage of the node by a peer.
+type Stats struct {
+	Peer      string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Usage     map[string]*Usage
+	Offenses  []*Offense `json:",omitempty"`
+	// BlockedUntil is set while the peer is blocked.
+	BlockedUntil *time.Time `json:",omitempty"`
+}
+
+// Total sums the usage of the categories.
+func (s *Stats) Total() Usage {
+	var t Usage
+	for _, u := range s.Usage {
+		t.StreamsIn += u.StreamsIn
+		t.StreamsOut += u.StreamsOut
+		t.BytesIn += u.BytesIn
+		t.BytesOut += u.BytesOut
+	}
+	return t
+}
+
+type peerState struct {
+	stats Stats
+	// the usage of the current window by the streams the peer opened
+	windowStart   time.Time
+	windowStreams int
+	windowBytes   int64
+	offended      bool
+}
+
+// Tracker counts the usage of the node by the peers and blocks the
+// abusive ones.
+type Tracker struct {
+	mu      sync.Mutex
+	cfg     *Config
+	peers   map[peer.ID]*peerState
+	blocked map[peer.ID]time.Time
+	net     network.Network
+	lastGC  time.Time
+	now     func() time.Time
+}
+
+// NewTracker returns a tracker with no limits.
+func NewTracker() *Tracker {
+	t := &Tracker{
+		peers:   map[peer.ID]*peerState{},
+		blocked: map[peer.ID]time.Time{},
+		now:     time.Now,
+	}
+	t.SetConfig(&Config{})
+	return t
+}
+
+// Default is the tracker of the node.
+var Default = NewTracker()
+
+// SetConfig applies c to the windows starting from now.
+func (t *Tracker) SetConfig(c *Config) {
+	if c.window == 0 {
+		// the zero config is valid
+		_ = c.compile()
+	}
+	t.mu.Lock()
+	t.cfg = c
+	t.mu.Unlock()
+}
+
+func (t *Tracker) state(p peer.ID, now time.Time) *peerState {
+	st, ok := t.peers[p]
+	if !ok {
+		st = &peerState{stats: Stats{Peer: p.Pretty(), FirstSeen: now, Usage: map[string]*Usage{}}}
+		t.peers[p] = st
+	}
+	st.stats.LastSeen = now
+	return st
+}
+
+func (t *Tracker) usage(st *peerState, category string) *Usage {
+	u, ok := st.stats.Usage[category]
+	if !ok {
+		u = &Usage{}
+		st.stats.Usage[category] = u
+	}
+	return u
+}
+
+// stream counts a stream of p in category, opened by p when inbound.
+func (t *Tracker) stream(p peer.ID, category string, dir network.Direction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	st := t.state(p, now)
+	if dir == network.DirInbound {
+		t.usage(st, category).StreamsIn++
+		t.inWindow(p, st, now, 1, 0)
+	} else {
+		t.usage(st, category).StreamsOut++
+	}
+	t.gc(now)
+}
+
+// bytes counts n bytes read (in) or written by a stream of p in category,
+// opened by p when inbound.
+func (t *Tracker) bytes(p peer.ID, category string, dir network.Direction, n int, in bool) {
+	if n <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	st := t.state(p, now)
+	u := t.usage(st, category)
+	if in {
+		u.BytesIn += int64(n)
+	} else {
+		u.BytesOut += int64(n)
+	}
+	if dir == network.DirInbound {
+		t.inWindow(p, st, now, 0, int64(n))
+	}
+}
+
+// inWindow adds the streams and bytes of p to its window and records an
+// offense the first time the window exceeds a limit.
+func (t *Tracker) inWindow(p peer.ID, st *peerState, now time.Time, streams int, bytes int64) {
+	c := t.cfg
+	if now.Sub(st.windowStart) >= c.window {
+		st.windowStart, st.windowStreams, st.windowBytes, st.offended = now, 0, 0, false
+	}
+	st.windowStreams += streams
+	st.windowBytes += bytes
+	if st.offended {
+		return
+	}
+	var limit string
+	switch {
+	case c.MaxStreams > 0 && st.windowStreams > c.MaxStreams:
+		limit = fmt.Sprintf("%d streams per %s", c.MaxStreams, c.window)
+	case c.maxBytes > 0 && st.windowBytes > c.maxBytes:
+		limit = fmt.Sprintf("%s per %s", humanize.Bytes(uint64(c.maxBytes)), c.window)
+	default:
+		return
+	}
+	st.offended = true
+	st.stats.Offenses = append(st.stats.Offenses, &Offense{
+		Time:    now,
+		Window:  c.window.String(),
+		Streams: st.windowStreams,
+		Bytes:   st.windowBytes,
+		Limit:   limit,
+	})
+	if len(st.stats.Offenses) > maxOffenses {
+		st.stats.Offenses = st.stats.Offenses[1:]
+	}
+	log.Warnf("peer %s exceeded %s", p, limit)
+	if c.AutoBlock {
+		t.block(p, now.Add(c.blockFor))
+	}
+}
+
+// gc forgets the peers idle for retention, at most once per retention.
+func (t *Tracker) gc(now time.Time) {
+	if now.Sub(t.lastGC) < retention {
+		return
+	}
+	t.lastGC = now
+	for p, st := range t.peers {
+		if now.Sub(st.stats.LastSeen) > retention {
+			delete(t.peers, p)
+		}
+	}
+}
+
+func (t *Tracker) snapshot(p peer.ID, st *peerState, now time.Time) *Stats {
+	s := st.stats
+	s.Usage = make(map[string]*Usage, len(st.stats.Usage))
+	for k, u := range st.stats.Usage {
+		c := *u
+		s.Usage[k] = &c
+	}
+	s.Offenses = append([]*Offense(nil), st.stats.Offenses...)
+	if until, ok := t.blocked[p]; ok && until.After(now) {
+		s.BlockedUntil = &until
+	}
+	return &s
+}
+
+// Peer returns the usage of the node by p, false when p was not seen.
+func (t *Tracker) Peer(p peer.ID) (*Stats, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st, ok := t.peers[p]
+	if !ok {
+		return nil, false
+	}
+	return t.snapshot(p, st, t.now()), true
+}
+
+// Top returns the n peers which moved the most bytes, all when n is 0.
+func (t *Tracker) Top(n int) []*Stats {
+	t.mu.Lock()
+	now := t.now()
+	all := make([]*Stats, 0, len(t.peers))
+	for p, st := range t.peers {
+		all = append(all, t.snapshot(p, st, now))
+	}
+	t.mu.Unlock()
+	sort.Slice(all, func(i, j int) bool {
+		ti, tj := all[i].Total(), all[j].Total()
+		if bi, bj := ti.BytesIn+ti.BytesOut, tj.BytesIn+tj.BytesOut; bi != bj {
+			return bi > bj
+		}
+		return all[i].Peer < all[j].Peer
+	})
+	if n > 0 && len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
@@ -0,0 +1,167 @@
+package peerstats
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+const testPeer = peer.ID("peer")
+
+func newTestTracker(t *testing.T, c *Config) (*Tracker, *time.Time) {
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := NewTracker()
+	tr.now = func() time.Time { return now }
+	tr.SetConfig(c)
+	return tr, &now
+}
+
+func TestOffenseBlocks(t *testing.T) {
+	tr, now := newTestTracker(t, &Config{MaxStreams: 2, AutoBlock: true, BlockFor: "10m"})
+	for i := 0; i < 2; i++ {
+		tr.stream(testPeer, Bitswap, network.DirInbound)
+	}
+	// the streams the node opens are not limited
+	tr.stream(testPeer, Bitswap, network.DirOutbound)
+	if tr.IsBlocked(testPeer) {
+		t.Fatal("blocked within the limits")
+	}
+
+	// a new window
+	*now = now.Add(2 * time.Minute)
+	for i := 0; i < 3; i++ {
+		tr.stream(testPeer, Storage, network.DirInbound)
+	}
+	if !tr.IsBlocked(testPeer) || tr.InterceptPeerDial(testPeer) || tr.InterceptSecured(network.DirInbound, testPeer, nil) {
+		t.Fatal("not blocked over the limits")
+	}
+	s, ok := tr.Peer(testPeer)
+	if !ok {
+		t.Fatal("peer not seen")
+	}
+	if len(s.Offenses) != 1 || s.Offenses[0].Streams != 3 || s.BlockedUntil == nil {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if u := s.Usage[Bitswap]; u.StreamsIn != 2 || u.StreamsOut != 1 {
+		t.Fatalf("unexpected bitswap usage %+v", u)
+	}
+	if len(tr.Blocked()) != 1 {
+		t.Fatal("peer not listed as blocked")
+	}
+
+	*now = now.Add(11 * time.Minute)
+	if tr.IsBlocked(testPeer) || len(tr.Blocked()) != 0 {
+		t.Fatal("block not expired")
+	}
+	tr.Block(testPeer, time.Minute)
+	if !tr.Unblock(testPeer) || tr.IsBlocked(testPeer) {
+		t.Fatal("peer not unblocked")
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	tr, _ := newTestTracker(t, &Config{MaxBytes: "1kB"})
+	tr.stream(testPeer, P2P, network.DirInbound)
+	tr.bytes(testPeer, P2P, network.DirInbound, 600, true)
+	tr.bytes(testPeer, P2P, network.DirInbound, 600, false)
+	// a second offense in the same window is not recorded
+	tr.bytes(testPeer, P2P, network.DirInbound, 600, false)
+	s, _ := tr.Peer(testPeer)
+	if len(s.Offenses) != 1 || s.Offenses[0].Bytes != 1200 {
+		t.Fatalf("unexpected offenses %+v", s.Offenses)
+	}
+	if s.BlockedUntil != nil {
+		t.Fatal("blocked without AutoBlock")
+	}
+	if u := s.Usage[P2P]; u.BytesIn != 600 || u.BytesOut != 1200 {
+		t.Fatalf("unexpected usage %+v", u)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, c := range []*Config{{Window: "0s"}, {BlockFor: "soon"}, {MaxBytes: "lots"}, {MaxStreams: -1}} {
+		if err := c.compile(); err == nil {
+			t.Errorf("accepted %+v", c)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	priv, _, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, now := newTestTracker(t, &Config{MaxStreams: 1})
+	tr.stream(testPeer, Bitswap, network.DirInbound)
+	tr.stream(testPeer, Bitswap, network.DirInbound)
+	s, _ := tr.Peer(testPeer)
+
+	r := NewReport(id, s, *now)
+	if err := r.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	r.Offenses[0].Streams = 1
+	if err := r.Verify(); !errors.Is(err, ErrInvalidReport) {
+		t.Fatalf("expected ErrInvalidReport, got %v", err)
+	}
+}
+
+func TestWrapHost(t *testing.T) {
+	ctx := context.Background()
+	mn, err := mocknet.FullMeshLinked(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := mn.Hosts()
+	tr, _ := newTestTracker(t, &Config{})
+	server := WrapHost(hosts[0], tr)
+	done := make(chan struct{})
+	server.SetStreamHandler(remoteAPIProtocol, func(st network.Stream) {
+		defer close(done)
+		ioutil.ReadAll(st)
+		st.Write([]byte("ok"))
+		st.Close()
+	})
+
+	st, err := hosts[1].NewStream(ctx, hosts[0].ID(), remoteAPIProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := "POST /api/v1/storage/challenge/response?arg=x HTTP/1.1\r\n\r\n"
+	if _, err := st.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+	ioutil.ReadAll(st)
+	<-done
+
+	s, ok := tr.Peer(hosts[1].ID())
+	if !ok {
+		t.Fatal("peer not seen")
+	}
+	u := s.Usage[Challenge]
+	if u == nil || u.StreamsIn != 1 || u.BytesIn != int64(len(req)) || u.BytesOut != 2 {
+		t.Fatalf("unexpected usage %+v", s.Usage)
+	}
+	if _, ok := s.Usage[Storage]; ok {
+		t.Fatal("challenge counted as storage")
+	}
+}
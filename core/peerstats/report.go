@@ -0,0 +1,87 @@
+package peerstats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ErrInvalidReport is returned for a report not signed by its reporter.
+var ErrInvalidReport = errors.New("invalid abuse report")
+
+// Report is an abuse report: the usage of a node by a peer and the
+// offenses of the peer, signed by the node.
+type Report struct {
+	Reporter string
+	// PublicKey is the marshalled public key of the reporter.
+	PublicKey []byte
+	Peer      string
+	Created   time.Time
+	FirstSeen time.Time
+	Usage     map[string]*Usage
+	Offenses  []*Offense
+	// Signature is the signature of the report without Signature by the
+	// key of the reporter.
+	Signature []byte `json:",omitempty"`
+}
+
+// NewReport returns the unsigned report of s by reporter.
+func NewReport(reporter peer.ID, s *Stats, now time.Time) *Report {
+	return &Report{
+		Reporter:  reporter.Pretty(),
+		Peer:      s.Peer,
+		Created:   now.UTC(),
+		FirstSeen: s.FirstSeen.UTC(),
+		Usage:     s.Usage,
+		Offenses:  s.Offenses,
+	}
+}
+
+func (r *Report) signedBytes() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = nil
+	return json.Marshal(&unsigned)
+}
+
+// Sign sets the public key and the signature of r by priv, the key of the
+// reporter.
+func (r *Report) Sign(priv ic.PrivKey) error {
+	pub, err := ic.MarshalPublicKey(priv.GetPublic())
+	if err != nil {
+		return err
+	}
+	r.PublicKey = pub
+	b, err := r.signedBytes()
+	if err != nil {
+		return err
+	}
+	r.Signature, err = priv.Sign(b)
+	return err
+}
+
+// Verify checks that r is signed by its reporter.
+func (r *Report) Verify() error {
+	id, err := peer.Decode(r.Reporter)
+	if err != nil {
+		return fmt.Errorf("%w: bad reporter %q", ErrInvalidReport, r.Reporter)
+	}
+	pub, err := ic.UnmarshalPublicKey(r.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: bad public key: %s", ErrInvalidReport, err)
+	}
+	if !id.MatchesPublicKey(pub) {
+		return fmt.Errorf("%w: public key not of reporter %s", ErrInvalidReport, r.Reporter)
+	}
+	b, err := r.signedBytes()
+	if err != nil {
+		return err
+	}
+	if ok, err := pub.Verify(b, r.Signature); err != nil || !ok {
+		return fmt.Errorf("%w: bad signature", ErrInvalidReport)
+	}
+	return nil
+}
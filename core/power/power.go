@@ -0,0 +1,148 @@
+// Package power reduces the background activity of a node running on a
+// laptop or an ARM board. In low-power mode the node runs its DHT as a
+// client only and reprovides less often, the periodic tasks of the daemon,
+// the wallet polling among them, run Factor times less often, and a host
+// answers the storage challenges with a maintenance retry-after instead of
+// reading its shards.
+//
+// The mode is a config section, switched at runtime with 'btfs power-mode'.
+// The DHT mode and the reprovide interval are set when the daemon starts,
+// everything else follows the mode within a period of its task.
+package power
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/ingest"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+)
+
+// ConfigKey is the config section of the power mode.
+const ConfigKey = "PowerMode"
+
+// The power modes.
+const (
+	Normal = "normal"
+	Low    = "low"
+)
+
+// Modes lists the power modes.
+var Modes = []string{Normal, Low}
+
+const (
+	// DefaultFactor stretches the intervals of the background tasks in
+	// low-power mode when nothing is configured.
+	DefaultFactor = 4
+	// MaxFactor bounds the stretch of the intervals.
+	MaxFactor = 24
+	// MaintenanceRetryAfter is the wait a host in low-power mode asks of the
+	// challengers.
+	MaintenanceRetryAfter = 30 * time.Minute
+	// kReprovideFrequency is the reprovide interval of the daemon when
+	// Reprovider.Interval is not set.
+	kReprovideFrequency = 12 * time.Hour
+)
+
+// Config configures the power mode.
+type Config struct {
+	// Mode is the power mode, normal when empty.
+	Mode string `json:",omitempty"`
+	// Factor stretches the intervals of the background tasks in low-power
+	// mode.
+	Factor int `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the power mode config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if c.Mode == "" {
+		c.Mode = Normal
+	}
+	if c.Factor == 0 {
+		c.Factor = DefaultFactor
+	}
+	return c, nil
+}
+
+// Validate checks the mode and factor of c.
+func (c *Config) Validate() error {
+	if c.Mode != "" && c.Mode != Normal && c.Mode != Low {
+		return fmt.Errorf("unknown power mode %q, expected %s or %s", c.Mode, Normal, Low)
+	}
+	if c.Factor < 0 || c.Factor > MaxFactor {
+		return fmt.Errorf("factor must be between 1 and %d", MaxFactor)
+	}
+	return nil
+}
+
+// IsLow reports whether c is the low-power mode.
+func (c *Config) IsLow() bool {
+	return c != nil && c.Mode == Low
+}
+
+// Stretch returns the interval of a background task running every d, d
+// times the factor in low-power mode.
+func (c *Config) Stretch(d time.Duration) time.Duration {
+	if !c.IsLow() {
+		return d
+	}
+	return d * time.Duration(c.Factor)
+}
+
+// ReprovideInterval returns the reprovide interval for the configured
+// Reprovider.Interval s, stretched in low-power mode. An interval of 0
+// disables the reprovider and is kept.
+func (c *Config) ReprovideInterval(s string) (string, error) {
+	d := kReprovideFrequency
+	if s != "" {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return "", err
+		}
+	}
+	if d == 0 || !c.IsLow() {
+		return s, nil
+	}
+	return c.Stretch(d).String(), nil
+}
+
+// ChallengeError returns the error a host answers the storage challenges
+// with, nil unless in low-power mode. Renters read it as a busy host, see
+// ingest.ParseRetryAfter.
+func (c *Config) ChallengeError() error {
+	if !c.IsLow() {
+		return nil
+	}
+	return &ingest.RetryAfterError{After: MaintenanceRetryAfter, Reason: "host in maintenance, low-power mode"}
+}
+
+// Throttle runs a task at most once per its stretched interval. A task
+// ticking every period calls Due on each tick and skips the tick when it
+// returns false.
+type Throttle struct {
+	last time.Time
+}
+
+// Due reports whether the task running every period is to run at now under
+// c, and if so records the run.
+func (t *Throttle) Due(c *Config, period time.Duration, now time.Time) bool {
+	// the ticks come a little early or late, half a period of slack keeps
+	// the run on the tick expected
+	if !t.last.IsZero() && now.Before(t.last.Add(c.Stretch(period)-period/2)) {
+		return false
+	}
+	t.last = now
+	return true
+}
@@ -0,0 +1,85 @@
+package power
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/ingest"
+)
+
+func TestValidate(t *testing.T) {
+	for _, c := range []*Config{{Mode: "eco"}, {Factor: -1}, {Factor: MaxFactor + 1}} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("accepted invalid config %+v", c)
+		}
+	}
+	for _, c := range []*Config{{}, {Mode: Low, Factor: 2}, {Mode: Normal}} {
+		if err := c.Validate(); err != nil {
+			t.Errorf("rejected %+v: %s", c, err)
+		}
+	}
+}
+
+func TestStretch(t *testing.T) {
+	normal := &Config{Mode: Normal, Factor: DefaultFactor}
+	low := &Config{Mode: Low, Factor: DefaultFactor}
+	if d := normal.Stretch(time.Minute); d != time.Minute {
+		t.Fatalf("normal mode stretched to %s", d)
+	}
+	if d := low.Stretch(time.Minute); d != 4*time.Minute {
+		t.Fatalf("expected 4m in low-power mode, got %s", d)
+	}
+
+	for _, tc := range []struct {
+		c        *Config
+		in, want string
+	}{
+		{normal, "", ""},
+		{normal, "1h", "1h"},
+		{low, "", "48h0m0s"},
+		{low, "1h", "4h0m0s"},
+		{low, "0", "0"},
+	} {
+		got, err := tc.c.ReprovideInterval(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s reprovide interval %q: expected %q, got %q", tc.c.Mode, tc.in, tc.want, got)
+		}
+	}
+	if _, err := low.ReprovideInterval("often"); err == nil {
+		t.Fatal("accepted an invalid interval")
+	}
+}
+
+func TestChallengeError(t *testing.T) {
+	if err := (&Config{Mode: Normal}).ChallengeError(); err != nil {
+		t.Fatalf("normal mode refused challenges: %s", err)
+	}
+	err := (&Config{Mode: Low}).ChallengeError()
+	if d, ok := ingest.ParseRetryAfter(err); !ok || d != MaintenanceRetryAfter {
+		t.Fatalf("expected a retry-after %s, got %v", MaintenanceRetryAfter, err)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	c := &Config{Mode: Low, Factor: 3}
+	var th Throttle
+	start := time.Now()
+	var runs []int
+	for i := 0; i < 7; i++ {
+		if th.Due(c, time.Minute, start.Add(time.Duration(i)*time.Minute)) {
+			runs = append(runs, i)
+		}
+	}
+	if len(runs) != 3 || runs[0] != 0 || runs[1] != 3 || runs[2] != 6 {
+		t.Fatalf("expected runs on ticks 0, 3 and 6, got %v", runs)
+	}
+
+	// back to normal, every tick runs
+	c.Mode = Normal
+	if !th.Due(c, time.Minute, start.Add(7*time.Minute)) || !th.Due(c, time.Minute, start.Add(8*time.Minute)) {
+		t.Fatal("normal mode skipped a tick")
+	}
+}
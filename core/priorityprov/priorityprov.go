@@ -0,0 +1,274 @@
+// Package priorityprov provides the provider records of priority CIDs, the
+// roots of the contracts of a host and the CIDs added with 'btfs dht
+// priority add', outside of the reprovide queue: each on its own, shorter,
+// interval and to more peers than the ordinary blocks, so that renters find
+// their hosts even when the reprovider is backed up.
+package priorityprov
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("priorityprov")
+
+// ConfigKey is the config section of the priority providing.
+const ConfigKey = "PriorityProviding"
+
+// Defaults of the priority providing.
+const (
+	DefaultInterval    = time.Hour
+	DefaultReplication = 40
+)
+
+// MinInterval bounds the interval of the priority records, they are
+// provided at most once per tick of the daemon.
+const MinInterval = 5 * time.Minute
+
+// Sources of the priority CIDs.
+const (
+	SourcePin      = "pin"
+	SourceContract = "contract"
+)
+
+const keyPrefix = "/btfs/%s/provide/priority/"
+
+// Config configures the priority providing.
+type Config struct {
+	Enabled bool
+	// Interval is the default time between two provides of a record, e.g.
+	// "1h".
+	Interval string `json:",omitempty"`
+	// Replication is the default number of peers a record is provided to.
+	Replication int `json:",omitempty"`
+	// SkipContracts leaves the roots of the host contracts to the
+	// reprovider.
+	SkipContracts bool `json:",omitempty"`
+
+	interval time.Duration
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the priority providing config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	c.interval = DefaultInterval
+	if c.Interval != "" {
+		i, err := ParseInterval(c.Interval)
+		if err != nil {
+			return err
+		}
+		c.interval = i
+	}
+	if c.Replication < 0 {
+		return fmt.Errorf("negative Replication")
+	}
+	if c.Replication == 0 {
+		c.Replication = DefaultReplication
+	}
+	return nil
+}
+
+// ParseInterval parses the interval of a priority record.
+func ParseInterval(s string) (time.Duration, error) {
+	i, err := time.ParseDuration(s)
+	if err != nil || i < MinInterval {
+		return 0, fmt.Errorf("invalid interval %q, must be at least %s", s, MinInterval)
+	}
+	return i, nil
+}
+
+// Record is a priority CID with how it is provided. A zero Interval or
+// Replication takes the one of the config.
+type Record struct {
+	Cid         string
+	Source      string
+	ContractID  string        `json:",omitempty"`
+	Interval    time.Duration `json:",omitempty"`
+	Replication int           `json:",omitempty"`
+	Added       time.Time
+	Provided    time.Time `json:",omitempty"`
+	Error       string    `json:",omitempty"`
+}
+
+func (r *Record) interval(c *Config) time.Duration {
+	if r.Interval > 0 {
+		return r.Interval
+	}
+	return c.interval
+}
+
+func (r *Record) replication(c *Config) int {
+	if r.Replication > 0 {
+		return r.Replication
+	}
+	return c.Replication
+}
+
+// Due reports whether r is to be provided at now.
+func (r *Record) Due(c *Config, now time.Time) bool {
+	return r.Provided.IsZero() || !now.Before(r.Provided.Add(r.interval(c)))
+}
+
+func recordKey(peerID, c string) ds.Key {
+	return ds.NewKey(fmt.Sprintf(keyPrefix, peerID) + c)
+}
+
+// Put saves the priority record r of the node peerID.
+func Put(d ds.Datastore, peerID string, r *Record) error {
+	if _, err := cid.Decode(r.Cid); err != nil {
+		return fmt.Errorf("invalid cid %s: %s", r.Cid, err)
+	}
+	if r.Interval != 0 && r.Interval < MinInterval {
+		return fmt.Errorf("interval must be at least %s", MinInterval)
+	}
+	if r.Replication < 0 {
+		return fmt.Errorf("negative replication")
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return d.Put(recordKey(peerID, r.Cid), b)
+}
+
+// Remove removes the priority record of c of the node peerID.
+func Remove(d ds.Datastore, peerID, c string) error {
+	k := recordKey(peerID, c)
+	if ok, err := d.Has(k); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("%s is not a priority cid", c)
+	}
+	return d.Delete(k)
+}
+
+// List returns the priority records of the node peerID, by CID.
+func List(d ds.Datastore, peerID string) ([]*Record, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(keyPrefix, peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	rs := make([]*Record, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		rec := &Record{}
+		if err := json.Unmarshal(r.Value, rec); err != nil {
+			return nil, fmt.Errorf("invalid priority record %s: %s", r.Key, err)
+		}
+		rs = append(rs, rec)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Cid < rs[j].Cid
+	})
+	return rs, nil
+}
+
+// SyncContracts makes the roots of the active host contracts, the shard
+// CIDs by contract ID, priority records of the node peerID, and removes the
+// records of the contracts no longer active. A CID added by hand is kept
+// as is.
+func SyncContracts(d ds.Datastore, peerID string, roots map[string]string, now time.Time) error {
+	rs, err := List(d, peerID)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, r := range rs {
+		known[r.Cid] = true
+		if r.Source != SourceContract {
+			continue
+		}
+		if _, ok := roots[r.ContractID]; !ok {
+			if err := Remove(d, peerID, r.Cid); err != nil {
+				return err
+			}
+		}
+	}
+	for id, root := range roots {
+		if known[root] {
+			continue
+		}
+		known[root] = true
+		if err := Put(d, peerID, &Record{Cid: root, Source: SourceContract, ContractID: id, Added: now}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Router provides the provider record of a CID to replication peers.
+type Router interface {
+	Provide(ctx context.Context, c cid.Cid, replication int) error
+}
+
+// Run provides the priority records of the node peerID due at now with
+// router, the longest waiting first, each bounded by timeout, and records
+// the outcome. It returns the number of records provided.
+func Run(ctx context.Context, d ds.Datastore, peerID string, c *Config, router Router, timeout time.Duration,
+	now time.Time) (int, error) {
+	rs, err := List(d, peerID)
+	if err != nil {
+		return 0, err
+	}
+	due := make([]*Record, 0)
+	for _, r := range rs {
+		if r.Due(c, now) {
+			due = append(due, r)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].Provided.Before(due[j].Provided)
+	})
+	provided := 0
+	for _, r := range due {
+		if ctx.Err() != nil {
+			return provided, ctx.Err()
+		}
+		key, err := cid.Decode(r.Cid)
+		if err != nil {
+			return provided, err
+		}
+		pctx, cancel := context.WithTimeout(ctx, timeout)
+		err = router.Provide(pctx, key, r.replication(c))
+		cancel()
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Provided, r.Error = now, ""
+			provided++
+		}
+		if err := Put(d, peerID, r); err != nil {
+			return provided, err
+		}
+	}
+	return provided, nil
+}
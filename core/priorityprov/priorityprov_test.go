@@ -0,0 +1,135 @@
+package priorityprov
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	u "github.com/ipfs/go-ipfs-util"
+)
+
+type fakeRouter struct {
+	provided map[string]int
+	fail     map[string]bool
+}
+
+func (r *fakeRouter) Provide(ctx context.Context, c cid.Cid, replication int) error {
+	if r.fail[c.String()] {
+		return errors.New("no peer")
+	}
+	r.provided[c.String()] = replication
+	return nil
+}
+
+func testCid(s string) string {
+	return cid.NewCidV0(u.Hash([]byte(s))).String()
+}
+
+func TestConfig(t *testing.T) {
+	c := &Config{}
+	if err := c.compile(); err != nil || c.interval != DefaultInterval || c.Replication != DefaultReplication {
+		t.Fatalf("unexpected defaults %+v (%v)", c, err)
+	}
+	for _, c := range []*Config{{Interval: "1m"}, {Interval: "soon"}, {Replication: -1}} {
+		if err := c.compile(); err == nil {
+			t.Fatalf("%+v: expected an error", c)
+		}
+	}
+}
+
+func TestSyncContracts(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	pinned := &Record{Cid: testCid("pinned"), Source: SourcePin, Added: now}
+	if err := Put(d, "peer", pinned); err != nil {
+		t.Fatal(err)
+	}
+	roots := map[string]string{"c1": testCid("shard1"), "c2": testCid("shard2"), "c3": pinned.Cid}
+	if err := SyncContracts(d, "peer", roots, now); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := List(d, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(rs))
+	}
+	for _, r := range rs {
+		if r.Cid == pinned.Cid && r.Source != SourcePin {
+			t.Fatalf("pinned cid taken by a contract: %+v", r)
+		}
+	}
+
+	delete(roots, "c1")
+	if err := SyncContracts(d, "peer", roots, now); err != nil {
+		t.Fatal(err)
+	}
+	if rs, err = List(d, "peer"); err != nil || len(rs) != 2 {
+		t.Fatalf("record of the ended contract kept: %d (%v)", len(rs), err)
+	}
+	for _, r := range rs {
+		if r.Cid == testCid("shard1") {
+			t.Fatal("record of the ended contract kept")
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	c := &Config{}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	fast, slow, failing := testCid("fast"), testCid("slow"), testCid("failing")
+	for _, r := range []*Record{
+		{Cid: fast, Interval: 10 * time.Minute, Replication: 60},
+		{Cid: slow},
+		{Cid: failing},
+	} {
+		if err := Put(d, "peer", r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Put(d, "peer", &Record{Cid: testCid("x"), Interval: time.Minute}); err == nil {
+		t.Fatal("expected an error for a short interval")
+	}
+
+	router := &fakeRouter{provided: map[string]int{}, fail: map[string]bool{failing: true}}
+	run := func(at time.Time) int {
+		router.provided = map[string]int{}
+		n, err := Run(context.Background(), d, "peer", c, router, time.Second, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := run(now); n != 2 || router.provided[fast] != 60 || router.provided[slow] != DefaultReplication {
+		t.Fatalf("unexpected provides %d: %v", n, router.provided)
+	}
+	// only the records due are provided again, a failed one is retried
+	if n := run(now.Add(15 * time.Minute)); n != 1 || router.provided[fast] == 0 {
+		t.Fatalf("unexpected provides %d: %v", n, router.provided)
+	}
+	router.fail = nil
+	if n := run(now.Add(20 * time.Minute)); n != 1 || router.provided[failing] == 0 {
+		t.Fatalf("unexpected provides %d: %v", n, router.provided)
+	}
+	if n := run(now.Add(time.Hour)); n != 2 || router.provided[slow] == 0 {
+		t.Fatalf("unexpected provides %d: %v", n, router.provided)
+	}
+	rs, err := List(d, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rs {
+		if r.Error != "" {
+			t.Fatalf("%s: error left %s", r.Cid, r.Error)
+		}
+	}
+}
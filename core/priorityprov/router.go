@@ -0,0 +1,121 @@
+package priorityprov
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sync"
+
+	ggio "github.com/gogo/protobuf/io"
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	ddht "github.com/libp2p/go-libp2p-kad-dht/dual"
+	pb "github.com/libp2p/go-libp2p-kad-dht/pb"
+	kb "github.com/libp2p/go-libp2p-kbucket"
+)
+
+// kadProtocols are the protocols of the DHT after its prefix, the latest
+// first.
+var kadProtocols = []protocol.ID{"/kad/2.0.0", "/kad/1.0.0"}
+
+type dhtRouter struct {
+	dht *ddht.DHT
+}
+
+// DHTRouter returns the router providing the records on the DHT d: to the
+// closest peers found by a lookup, as the reprovider does, then to the
+// peers of the routing table nearest to the CID until replication peers
+// were sent the record.
+func DHTRouter(d *ddht.DHT) Router {
+	return &dhtRouter{dht: d}
+}
+
+func (r *dhtRouter) Provide(ctx context.Context, c cid.Cid, replication int) error {
+	if r.dht.LAN != nil {
+		if err := provide(ctx, r.dht.LAN, dht.DefaultPrefix+ddht.LanExtension, c, replication); err != nil {
+			log.Debugf("provide %s on the LAN DHT: %s", c, err)
+		}
+	}
+	return provide(ctx, r.dht.WAN, dht.DefaultPrefix, c, replication)
+}
+
+func provide(ctx context.Context, d *dht.IpfsDHT, prefix protocol.ID, c cid.Cid, replication int) error {
+	key := c.Hash()
+	d.ProviderManager.AddProvider(ctx, key, d.PeerID())
+	closest, err := d.GetClosestPeers(ctx, string(key))
+	if err != nil {
+		return err
+	}
+	peers := make([]peer.ID, 0, replication)
+	sent := make(map[peer.ID]bool)
+	for p := range closest {
+		if !sent[p] {
+			sent[p] = true
+			peers = append(peers, p)
+		}
+	}
+	for _, p := range d.RoutingTable().NearestPeers(kb.ConvertKey(string(key)), replication) {
+		if len(peers) >= replication {
+			break
+		}
+		if !sent[p] && p != d.PeerID() {
+			sent[p] = true
+			peers = append(peers, p)
+		}
+	}
+	if len(peers) == 0 {
+		return fmt.Errorf("no peer to provide %s to", c)
+	}
+
+	self := peer.AddrInfo{ID: d.PeerID(), Addrs: d.Host().Addrs()}
+	if len(self.Addrs) == 0 {
+		return fmt.Errorf("no known addresses for self, cannot put provider")
+	}
+	mes := pb.NewMessage(pb.Message_ADD_PROVIDER, key, 0)
+	mes.ProviderPeers = pb.RawPeerInfosToPBPeers([]peer.AddrInfo{self})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var ok int
+	for _, p := range peers {
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+			if err := sendMessage(ctx, d, prefix, p, mes); err != nil {
+				log.Debugf("put provider %s to %s: %s", c, p, err)
+				return
+			}
+			mu.Lock()
+			ok++
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+	if ok == 0 {
+		return fmt.Errorf("no peer took the provider record of %s", c)
+	}
+	return nil
+}
+
+func sendMessage(ctx context.Context, d *dht.IpfsDHT, prefix protocol.ID, p peer.ID, mes *pb.Message) error {
+	protos := make([]protocol.ID, 0, len(kadProtocols))
+	for _, kad := range kadProtocols {
+		protos = append(protos, prefix+kad)
+	}
+	s, err := d.Host().NewStream(ctx, p, protos...)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(s)
+	if err := ggio.NewDelimitedWriter(w).WriteMsg(mes); err != nil {
+		s.Reset()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		s.Reset()
+		return err
+	}
+	return s.Close()
+}
@@ -0,0 +1,172 @@
+// Package protover negotiates the versions of the protocols spoken between
+// renters and hosts, hosts and the guard, and nodes and the hub.
+//
+// Each side announces the range of versions it supports, and an exchange
+// uses the highest version of both ranges. When the ranges do not overlap
+// the exchange fails early with an ErrIncompatible, instead of sending
+// messages the peer cannot unmarshal.
+//
+// Peers from before the negotiation announce nothing, they are taken to
+// speak version 1, see Legacy.
+package protover
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Exchange is a protocol spoken between two kinds of parties of the network.
+type Exchange string
+
+const (
+	// RenterHost is spoken over the remote API of renters and hosts, to
+	// negotiate and sign contracts.
+	RenterHost Exchange = "renter-host"
+	// HostGuard is spoken by hosts and renters with the guard service.
+	HostGuard Exchange = "host-guard"
+	// NodeHub is spoken by nodes with the hub service.
+	NodeHub Exchange = "node-hub"
+)
+
+const (
+	// Header is the HTTP header announcing the versions supported by the
+	// sender of a remote API request or response.
+	Header = "Btfs-Protocol-Version"
+	// MetadataKey is the gRPC metadata key announcing the versions supported
+	// by the sender of a call or its response.
+	MetadataKey = "btfs-protocol-version"
+)
+
+// Range is the versions of an Exchange supported by a party, from Min to Max
+// included.
+type Range struct {
+	Min int
+	Max int
+}
+
+// Legacy is the range of the peers which announce no versions.
+var Legacy = Range{Min: 1, Max: 1}
+
+// Supported are the versions supported by this node.
+var Supported = map[Exchange]Range{
+	RenterHost: {Min: 1, Max: 1},
+	HostGuard:  {Min: 1, Max: 1},
+	NodeHub:    {Min: 1, Max: 1},
+}
+
+// String returns the range as announced, "1-2", or "1" for a single version.
+func (r Range) String() string {
+	if r.Min == r.Max {
+		return strconv.Itoa(r.Min)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// ParseRange parses a range announced by a peer.
+func ParseRange(s string) (Range, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "-", 2)
+	min, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid protocol version range %q", s)
+	}
+	max := min
+	if len(parts) == 2 {
+		max, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid protocol version range %q", s)
+		}
+	}
+	if min < 1 || max < min {
+		return Range{}, fmt.Errorf("invalid protocol version range %q", s)
+	}
+	return Range{Min: min, Max: max}, nil
+}
+
+// ErrIncompatible is returned when a peer supports none of the versions of
+// an exchange supported by this node.
+type ErrIncompatible struct {
+	Exchange Exchange
+	Local    Range
+	Remote   Range
+}
+
+func (e *ErrIncompatible) Error() string {
+	return fmt.Sprintf("incompatible %s protocol: this node supports versions %s, the peer %s",
+		e.Exchange, e.Local, e.Remote)
+}
+
+// Negotiate returns the version of ex to speak with a peer supporting the
+// remote range.
+func Negotiate(ex Exchange, remote Range) (int, error) {
+	local, ok := Supported[ex]
+	if !ok {
+		return 0, fmt.Errorf("unknown protocol %q", ex)
+	}
+	min, max := local.Min, local.Max
+	if remote.Min > min {
+		min = remote.Min
+	}
+	if remote.Max < max {
+		max = remote.Max
+	}
+	if min > max {
+		return 0, &ErrIncompatible{Exchange: ex, Local: local, Remote: remote}
+	}
+	return max, nil
+}
+
+// NegotiateAnnounced is like Negotiate, with the range announced by the peer
+// in a header or metadata value, Legacy if it announced none.
+func NegotiateAnnounced(ex Exchange, announced string) (int, error) {
+	if announced == "" {
+		return Negotiate(ex, Legacy)
+	}
+	remote, err := ParseRange(announced)
+	if err != nil {
+		return 0, err
+	}
+	return Negotiate(ex, remote)
+}
+
+type ctxKey Exchange
+
+// NewContext returns a context carrying the version of ex negotiated for a
+// request.
+func NewContext(ctx context.Context, ex Exchange, version int) context.Context {
+	return context.WithValue(ctx, ctxKey(ex), version)
+}
+
+// FromContext returns the version of ex negotiated for a request, the
+// Legacy version if none was.
+func FromContext(ctx context.Context, ex Exchange) int {
+	if v, ok := ctx.Value(ctxKey(ex)).(int); ok {
+		return v
+	}
+	return Legacy.Max
+}
+
+// OutgoingContext returns a context announcing the versions of ex supported
+// by this node to the gRPC service called with it.
+func OutgoingContext(ctx context.Context, ex Exchange) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, MetadataKey, Supported[ex].String())
+}
+
+// CheckHeader checks the versions announced in the header of a gRPC
+// response, and returns an ErrIncompatible if the service supports none of
+// the versions of this node. Services announcing nothing are not checked.
+func CheckHeader(ex Exchange, header metadata.MD) error {
+	vals := header.Get(MetadataKey)
+	if len(vals) == 0 {
+		return nil
+	}
+	_, err := NegotiateAnnounced(ex, vals[0])
+	if _, ok := err.(*ErrIncompatible); ok {
+		return err
+	}
+	// A malformed announce is not worth failing a call which went through.
+	return nil
+}
@@ -0,0 +1,96 @@
+package protover
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseRange(t *testing.T) {
+	for s, want := range map[string]Range{
+		"1":     {1, 1},
+		"2-5":   {2, 5},
+		" 3-3 ": {3, 3},
+	} {
+		r, err := ParseRange(s)
+		if err != nil || r != want {
+			t.Errorf("ParseRange(%q) = %v, %v, want %v", s, r, err, want)
+		}
+		if r, err := ParseRange(want.String()); err != nil || r != want {
+			t.Errorf("ParseRange(%q) = %v, %v", want.String(), r, err)
+		}
+	}
+	for _, s := range []string{"", "a", "0", "3-2", "1-b"} {
+		if _, err := ParseRange(s); err == nil {
+			t.Errorf("ParseRange(%q) succeeded", s)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	defer func(r Range) { Supported[RenterHost] = r }(Supported[RenterHost])
+	Supported[RenterHost] = Range{2, 4}
+
+	for _, tc := range []struct {
+		remote Range
+		want   int
+	}{
+		{Range{1, 2}, 2},
+		{Range{3, 9}, 4},
+		{Range{3, 3}, 3},
+		{Range{1, 1}, 0},
+		{Range{5, 6}, 0},
+	} {
+		v, err := Negotiate(RenterHost, tc.remote)
+		if tc.want == 0 {
+			if _, ok := err.(*ErrIncompatible); !ok {
+				t.Errorf("Negotiate(%v) = %d, %v, want ErrIncompatible", tc.remote, v, err)
+			}
+			continue
+		}
+		if err != nil || v != tc.want {
+			t.Errorf("Negotiate(%v) = %d, %v, want %d", tc.remote, v, err, tc.want)
+		}
+	}
+
+	// Peers announcing nothing speak the legacy version, which this node
+	// no longer supports.
+	if _, err := NegotiateAnnounced(RenterHost, ""); err == nil {
+		t.Error("negotiated with a legacy peer")
+	}
+	if v, err := NegotiateAnnounced(RenterHost, "1-3"); err != nil || v != 3 {
+		t.Errorf("got %d, %v", v, err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if v := FromContext(ctx, RenterHost); v != Legacy.Max {
+		t.Errorf("got %d", v)
+	}
+	ctx = NewContext(ctx, RenterHost, 2)
+	if v := FromContext(ctx, RenterHost); v != 2 {
+		t.Errorf("got %d", v)
+	}
+	if v := FromContext(ctx, NodeHub); v != Legacy.Max {
+		t.Errorf("got %d", v)
+	}
+}
+
+func TestCheckHeader(t *testing.T) {
+	for _, tc := range []struct {
+		header metadata.MD
+		ok     bool
+	}{
+		{nil, true},
+		{metadata.Pairs(MetadataKey, "1-2"), true},
+		{metadata.Pairs(MetadataKey, "garbage"), true},
+		{metadata.Pairs(MetadataKey, "7"), false},
+	} {
+		err := CheckHeader(NodeHub, tc.header)
+		if (err == nil) != tc.ok {
+			t.Errorf("CheckHeader(%v) = %v", tc.header, err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package remotes keeps the profiles of the remote daemons the CLI manages
+// with 'btfs --remote <name>': the API address of each daemon and the token
+// sent to it. The profiles are stored in the remotes.json file of the btfs
+// directory, readable by its owner only.
+package remotes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr-net"
+)
+
+// FileName is the file of the profiles in the btfs directory.
+const FileName = "remotes.json"
+
+var (
+	// ErrNotFound is returned for a profile never added.
+	ErrNotFound = errors.New("no remote by that name")
+	// ErrExists is returned when adding a profile under a name taken.
+	ErrExists = errors.New("a remote by that name already exists")
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$`)
+
+// Profile is a remote daemon.
+type Profile struct {
+	Name string
+	// API is the API address of the daemon: a URL such as
+	// https://host:5001, or a multiaddr such as /dns4/host/tcp/5001.
+	API string
+	// Token is sent as a bearer token when set.
+	Token string `json:",omitempty"`
+}
+
+// Endpoint returns the URL of the API of p.
+func (p *Profile) Endpoint() (*url.URL, error) {
+	return ParseAPI(p.API)
+}
+
+// ParseAPI parses the API address of a daemon, a http or https URL or a tcp
+// multiaddr.
+func ParseAPI(api string) (*url.URL, error) {
+	if strings.HasPrefix(api, "/") {
+		m, err := ma.NewMultiaddr(api)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api multiaddr %q: %s", api, err)
+		}
+		network, host, err := manet.DialArgs(m)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(network, "tcp") {
+			return nil, fmt.Errorf("unsupported api address %q, remotes are reached over tcp", api)
+		}
+		return &url.URL{Scheme: "http", Host: host}, nil
+	}
+	u, err := url.Parse(api)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %q: %s", api, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid api url %q, expected http(s)://host:port", api)
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return nil, fmt.Errorf("invalid api url %q, only a scheme, a host and a path are allowed", api)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u, nil
+}
+
+// Store is the file of the profiles.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Open returns the store of the profiles of the btfs directory dir.
+func Open(dir string) *Store {
+	return &Store{path: filepath.Join(dir, FileName)}
+}
+
+func (s *Store) load() (map[string]*Profile, error) {
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return map[string]*Profile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ps := map[string]*Profile{}
+	if err := json.Unmarshal(b, &ps); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", s.path, err)
+	}
+	for name, p := range ps {
+		p.Name = name
+	}
+	return ps, nil
+}
+
+func (s *Store) save(ps map[string]*Profile) error {
+	b, err := json.MarshalIndent(ps, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	// the tokens are credentials, the file is replaced at once and only
+	// readable by its owner
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Add stores p, replacing the profile of the same name when replace is set.
+func (s *Store) Add(p *Profile, replace bool) error {
+	if !validName.MatchString(p.Name) {
+		return fmt.Errorf("invalid remote name %q", p.Name)
+	}
+	if _, err := p.Endpoint(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := ps[p.Name]; ok && !replace {
+		return ErrExists
+	}
+	ps[p.Name] = p
+	return s.save(ps)
+}
+
+// Remove deletes the profile name.
+func (s *Store) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := ps[name]; !ok {
+		return ErrNotFound
+	}
+	delete(ps, name)
+	return s.save(ps)
+}
+
+// Get returns the profile name.
+func (s *Store) Get(name string) (*Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	p, ok := ps[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+// List returns the profiles sorted by name.
+func (s *Store) List() ([]*Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Profile, 0, len(ps))
+	for _, p := range ps {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Transport sends the requests of the CLI to a remote daemon: with the
+// scheme of its API, which the commands client always sets to http, and
+// its token.
+type Transport struct {
+	Scheme string
+	Token  string
+	Base   http.RoundTripper
+}
+
+// NewTransport returns the transport of the requests to p.
+func NewTransport(p *Profile) (*Transport, error) {
+	u, err := p.Endpoint()
+	if err != nil {
+		return nil, err
+	}
+	return &Transport{Scheme: u.Scheme, Token: p.Token, Base: http.DefaultTransport}, nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = t.Scheme
+	if t.Token != "" {
+		r.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+	return t.Base.RoundTrip(r)
+}
@@ -0,0 +1,122 @@
+package remotes
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAPI(t *testing.T) {
+	for api, want := range map[string]string{
+		"https://host:5001":      "https://host:5001",
+		"http://host:5001/btfs/": "http://host:5001/btfs",
+		"/ip4/10.0.0.1/tcp/5001": "http://10.0.0.1:5001",
+		"/dns4/host/tcp/5001":    "http://host:5001",
+	} {
+		u, err := ParseAPI(api)
+		if err != nil {
+			t.Errorf("%s: %s", api, err)
+			continue
+		}
+		if u.String() != want {
+			t.Errorf("%s: got %s, expected %s", api, u, want)
+		}
+	}
+	for _, api := range []string{"host:5001", "ftp://host", "https://", "https://u:p@host", "/ip4/10.0.0.1/udp/5001", "/unix/tmp/api"} {
+		if _, err := ParseAPI(api); err == nil {
+			t.Errorf("accepted %s", api)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remotes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := Open(dir)
+	if ps, err := s.List(); err != nil || len(ps) != 0 {
+		t.Fatalf("unexpected remotes %v, %v", ps, err)
+	}
+	prod := &Profile{Name: "prod", API: "https://host:5001", Token: "secret"}
+	if err := s.Add(prod, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(&Profile{Name: "dev", API: "/ip4/127.0.0.1/tcp/5001"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(&Profile{Name: "prod", API: "https://other:5001"}, false); err != ErrExists {
+		t.Fatalf("replaced a remote: %v", err)
+	}
+	for _, p := range []*Profile{{Name: "../x", API: "https://host"}, {Name: "x", API: "host"}} {
+		if err := s.Add(p, false); err == nil {
+			t.Errorf("added %+v", p)
+		}
+	}
+	fi, err := os.Stat(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("remotes readable by others: %s", fi.Mode())
+	}
+
+	got, err := Open(dir).Get("prod")
+	if err != nil || *got != *prod {
+		t.Fatalf("unexpected remote %+v, %v", got, err)
+	}
+	if err := s.Add(&Profile{Name: "prod", API: "https://other:5001"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = s.Get("prod"); got.API != "https://other:5001" || got.Token != "" {
+		t.Fatalf("remote not replaced %+v", got)
+	}
+	ps, err := s.List()
+	if err != nil || len(ps) != 2 || ps[0].Name != "dev" || ps[1].Name != "prod" {
+		t.Fatalf("unexpected remotes %v, %v", ps, err)
+	}
+	if err := s.Remove("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove("dev"); err != ErrNotFound {
+		t.Fatalf("removed a remote twice: %v", err)
+	}
+	if _, err := s.Get("dev"); err != ErrNotFound {
+		t.Fatalf("removed remote still there: %v", err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var auth string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	tr, err := NewTransport(&Profile{Name: "prod", API: srv.URL, Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Base = srv.Client().Transport
+	// the commands client always asks for http
+	req, err := http.NewRequest(http.MethodPost, "http://"+srv.Listener.Addr().String()+"/api/v1/id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if auth != "Bearer secret" {
+		t.Fatalf("unexpected authorization %q", auth)
+	}
+	if req.Header.Get("Authorization") != "" || req.URL.Scheme != "http" {
+		t.Fatal("the request of the client was altered")
+	}
+}
@@ -0,0 +1,183 @@
+// Package repoverify checks the blocks of a repo against their hashes, all
+// of them or those reachable from some roots, at a bounded read rate so
+// that a node can verify its repo while serving.
+package repoverify
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	blockservice "github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+)
+
+// Problem is a block failing verification.
+type Problem struct {
+	Cid cid.Cid
+	// Root is the root the block was reached from, undefined when all the
+	// blocks are verified.
+	Root cid.Cid
+	// Missing is set when the block is not in the repo, it is corrupt
+	// otherwise.
+	Missing bool
+	Err     string `json:",omitempty"`
+}
+
+// Block is a block to verify, and the root it was reached from.
+type Block struct {
+	Cid  cid.Cid
+	Root cid.Cid
+}
+
+// Options tunes a verification.
+type Options struct {
+	// Rate bounds the bytes read per second, unbounded when 0.
+	Rate int64
+	// Workers is the number of blocks verified concurrently, twice the
+	// number of CPUs when 0.
+	Workers int
+}
+
+// All returns the blocks of bs.
+func All(ctx context.Context, bs bstore.Blockstore) (<-chan Block, error) {
+	keys, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make(chan Block)
+	go func() {
+		defer close(blocks)
+		for k := range keys {
+			select {
+			case blocks <- Block{Cid: k}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return blocks, nil
+}
+
+// Reachable returns the blocks of bs reachable from the recursive roots,
+// and the direct roots, with the blocks missing to walk them.
+func Reachable(ctx context.Context, bs bstore.Blockstore, recursive, direct []cid.Cid) ([]Block, []*Problem, error) {
+	ng := dag.NewDAGService(blockservice.New(bs, offline.Exchange(bs)))
+	seen := cid.NewSet()
+	var blocks []Block
+	var problems []*Problem
+	for _, root := range recursive {
+		root := root
+		getLinks := func(ctx context.Context, c cid.Cid) ([]*ipld.Link, error) {
+			links, err := ipld.GetLinks(ctx, ng, c)
+			if err == ipld.ErrNotFound {
+				problems = append(problems, &Problem{Cid: c, Root: root, Missing: true})
+				return nil, nil
+			}
+			if err != nil {
+				// corrupt, the verification reports it
+				blocks = append(blocks, Block{Cid: c, Root: root})
+				return nil, nil
+			}
+			blocks = append(blocks, Block{Cid: c, Root: root})
+			return links, nil
+		}
+		if err := dag.Walk(ctx, getLinks, root, seen.Visit); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, root := range direct {
+		if !seen.Visit(root) {
+			continue
+		}
+		has, err := bs.Has(root)
+		if err != nil {
+			return nil, nil, err
+		}
+		if has {
+			blocks = append(blocks, Block{Cid: root, Root: root})
+		} else {
+			problems = append(problems, &Problem{Cid: root, Root: root, Missing: true})
+		}
+	}
+	return blocks, problems, nil
+}
+
+// Verify reads the blocks from bs, which must hash on read, and reports
+// each block verified to progress, with its problem when it fails.
+func Verify(ctx context.Context, bs bstore.Blockstore, blocks <-chan Block, opts Options,
+	progress func(b Block, p *Problem)) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU() * 2
+	}
+	lim := newLimiter(opts.Rate)
+	var (
+		wg sync.WaitGroup
+		lk sync.Mutex
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range blocks {
+				if ctx.Err() != nil {
+					return
+				}
+				blk, err := bs.Get(b.Cid)
+				var p *Problem
+				switch {
+				case err == bstore.ErrNotFound:
+					p = &Problem{Cid: b.Cid, Root: b.Root, Missing: true}
+				case err != nil:
+					p = &Problem{Cid: b.Cid, Root: b.Root, Err: err.Error()}
+				default:
+					lim.wait(ctx, len(blk.RawData()))
+				}
+				lk.Lock()
+				progress(b, p)
+				lk.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// limiter paces reads to a rate in bytes per second.
+type limiter struct {
+	rate  int64
+	lk    sync.Mutex
+	start time.Time
+	read  int64
+}
+
+func newLimiter(rate int64) *limiter {
+	return &limiter{rate: rate, start: time.Now()}
+}
+
+// wait accounts n bytes read, and sleeps until reading them fits the rate.
+func (l *limiter) wait(ctx context.Context, n int) {
+	if l.rate <= 0 {
+		return
+	}
+	l.lk.Lock()
+	l.read += int64(n)
+	due := l.start.Add(time.Duration(float64(l.read) / float64(l.rate) * float64(time.Second)))
+	l.lk.Unlock()
+	d := time.Until(due)
+	if d <= 0 {
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
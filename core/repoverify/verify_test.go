@@ -0,0 +1,119 @@
+package repoverify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	dshelp "github.com/ipfs/go-ipfs-ds-help"
+	dag "github.com/ipfs/go-merkledag"
+)
+
+func put(t *testing.T, bs bstore.Blockstore, n *dag.ProtoNode) cid.Cid {
+	if err := bs.Put(n); err != nil {
+		t.Fatal(err)
+	}
+	return n.Cid()
+}
+
+func verify(t *testing.T, bs bstore.Blockstore, bl []Block, rate int64) (int, []*Problem) {
+	ch := make(chan Block, len(bl))
+	for _, b := range bl {
+		ch <- b
+	}
+	close(ch)
+	var done int
+	var problems []*Problem
+	err := Verify(context.Background(), bs, ch, Options{Rate: rate}, func(b Block, p *Problem) {
+		done++
+		if p != nil {
+			problems = append(problems, p)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return done, problems
+}
+
+func TestVerify(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	bs := bstore.NewBlockstore(d)
+	bs.HashOnRead(true)
+
+	good := dag.NodeWithData([]byte("good"))
+	bad := dag.NodeWithData([]byte("bad"))
+	gone := dag.NodeWithData([]byte("gone"))
+	root := dag.NodeWithData([]byte("root"))
+	for _, c := range []*dag.ProtoNode{good, bad, gone} {
+		if err := root.AddNodeLink(string(c.Data()), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put(t, bs, good)
+	put(t, bs, root)
+	put(t, bs, bad)
+	// the block of bad does not match its hash anymore, gone is not stored
+	tampered := blocks.NewBlock([]byte("tampered"))
+	if err := d.Put(bstore.BlockPrefix.Child(dshelp.CidToDsKey(bad.Cid())), tampered.RawData()); err != nil {
+		t.Fatal(err)
+	}
+	direct := dag.NodeWithData([]byte("direct"))
+	orphan := put(t, bs, dag.NodeWithData([]byte("orphan")))
+
+	bl, problems, err := Reachable(context.Background(), bs, []cid.Cid{root.Cid()}, []cid.Cid{direct.Cid()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bl) != 3 {
+		t.Fatalf("expected root, good and bad reached, got %v", bl)
+	}
+	for _, b := range bl {
+		if b.Cid.Equals(orphan) || !b.Root.Equals(root.Cid()) {
+			t.Fatalf("unexpected block %v", b)
+		}
+	}
+	if len(problems) != 2 || !problems[0].Missing || !problems[0].Cid.Equals(gone.Cid()) ||
+		!problems[1].Missing || !problems[1].Cid.Equals(direct.Cid()) {
+		t.Fatalf("expected gone and direct missing, got %v", problems)
+	}
+
+	done, problems := verify(t, bs, bl, 0)
+	if done != 3 || len(problems) != 1 || !problems[0].Cid.Equals(bad.Cid()) || problems[0].Missing {
+		t.Fatalf("expected bad corrupt, got %d verified, %v", done, problems)
+	}
+
+	all, err := All(context.Background(), bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for range all {
+		n++
+	}
+	if n != 4 {
+		t.Fatalf("expected 4 blocks, got %d", n)
+	}
+}
+
+func TestRate(t *testing.T) {
+	bs := bstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	var bl []Block
+	var size int
+	for i := 0; i < 10; i++ {
+		n := dag.NodeWithData(make([]byte, 1000+i))
+		size += len(n.RawData())
+		bl = append(bl, Block{Cid: put(t, bs, n)})
+	}
+	start := time.Now()
+	// a tenth of the blocks per 10ms
+	verify(t, bs, bl, int64(size)*10)
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Fatalf("verified in %s, faster than the rate", d)
+	}
+}
@@ -0,0 +1,166 @@
+// Package speedtest measures the connectivity of a node to the BTFS network:
+// the latency to a few nearby hosts and public gateways, and the throughput
+// of a throwaway test object uploaded to and downloaded from them, so that a
+// new host can validate its setup before it accepts contracts.
+package speedtest
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/latency"
+
+	cid "github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+)
+
+// Sizes of the test object.
+const (
+	DefaultSize = 4 << 20
+	MaxSize     = 32 << 20
+)
+
+// Kinds of the targets of a speed test.
+const (
+	KindHost    = "host"
+	KindGateway = "gateway"
+)
+
+// Result is the outcome of the speed test of a target. Upload is the
+// throughput from the node to the target, Download from the target to the
+// node, in bytes per second, 0 when not measured.
+type Result struct {
+	Kind     string
+	ID       string
+	Latency  latency.Stats
+	Upload   int64  `json:",omitempty"`
+	Download int64  `json:",omitempty"`
+	Error    string `json:",omitempty"`
+}
+
+// HostReply is the answer of a host to a speed test: the time it took to
+// fetch the test object of the node, and the CID of the test object it
+// added for the node to download.
+type HostReply struct {
+	Fetch time.Duration
+	Cid   string
+}
+
+// Object returns a test object of size random bytes.
+func Object(size int64) ([]byte, error) {
+	if size <= 0 || size > MaxSize {
+		return nil, fmt.Errorf("test object size must be between 1 and %d bytes", MaxSize)
+	}
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Remove removes the blocks of the test object c from dag, so that it does
+// not wait for the garbage collection.
+func Remove(ctx context.Context, dag ipld.DAGService, c cid.Cid) error {
+	var cids []cid.Cid
+	err := merkledag.Walk(ctx, merkledag.GetLinksDirect(dag), c, func(k cid.Cid) bool {
+		cids = append(cids, k)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return dag.RemoveMany(ctx, cids)
+}
+
+// Throughput returns the throughput of size bytes transferred in d, in
+// bytes per second.
+func Throughput(size int64, d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(float64(size) / d.Seconds())
+}
+
+// Nearest returns the IDs of the n targets with the lowest RTTs, the
+// fastest first.
+func Nearest(rtts map[string]time.Duration, n int) []string {
+	ids := make([]string, 0, len(rtts))
+	for id := range rtts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if rtts[ids[i]] != rtts[ids[j]] {
+			return rtts[ids[i]] < rtts[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
+
+// Gateway runs the speed test of the public gateway at base on the test
+// object c of size bytes, which the node provides: the latency of samples
+// requests to the gateway, then the first fetch of c through it, the
+// gateway retrieving c from the network, for the upload and the second
+// fetch, served from its cache, for the download.
+func Gateway(ctx context.Context, client *http.Client, base, c string, size int64, samples int) *Result {
+	base = strings.TrimSuffix(base, "/")
+	r := &Result{Kind: KindGateway, ID: base}
+	var rtts []time.Duration
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		if err := get(ctx, client, http.MethodHead, base+"/", nil); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		rtts = append(rtts, time.Since(start))
+	}
+	r.Latency = latency.Percentiles(rtts)
+
+	for _, throughput := range []*int64{&r.Upload, &r.Download} {
+		var n int64
+		start := time.Now()
+		if err := get(ctx, client, http.MethodGet, base+"/btfs/"+c, &n); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		if n != size {
+			r.Error = fmt.Sprintf("gateway returned %d bytes, expected %d", n, size)
+			return r
+		}
+		*throughput = Throughput(size, time.Since(start))
+	}
+	return r
+}
+
+func get(ctx context.Context, client *http.Client, method, url string, n *int64) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// any answer of the gateway itself tells its latency
+	if method == http.MethodHead {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	read, err := io.Copy(ioutil.Discard, resp.Body)
+	*n = read
+	return err
+}
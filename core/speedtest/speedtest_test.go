@@ -0,0 +1,84 @@
+package speedtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestObject(t *testing.T) {
+	b, err := Object(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 1024 {
+		t.Fatalf("expected 1024 bytes, got %d", len(b))
+	}
+	for _, size := range []int64{0, -1, MaxSize + 1} {
+		if _, err := Object(size); err == nil {
+			t.Fatalf("size %d accepted", size)
+		}
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	if r := Throughput(4<<20, 2*time.Second); r != 2<<20 {
+		t.Fatalf("expected %d B/s, got %d", 2<<20, r)
+	}
+	if r := Throughput(4<<20, 0); r != 0 {
+		t.Fatalf("expected 0 for no duration, got %d", r)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	rtts := map[string]time.Duration{
+		"QmSlow": 90 * time.Millisecond,
+		"QmB":    10 * time.Millisecond,
+		"QmA":    10 * time.Millisecond,
+		"QmMid":  40 * time.Millisecond,
+	}
+	ids := Nearest(rtts, 3)
+	if len(ids) != 3 || ids[0] != "QmA" || ids[1] != "QmB" || ids[2] != "QmMid" {
+		t.Fatalf("unexpected nearest %v", ids)
+	}
+	if ids := Nearest(rtts, 10); len(ids) != 4 {
+		t.Fatalf("expected all 4 targets, got %v", ids)
+	}
+}
+
+func TestGateway(t *testing.T) {
+	const size = 2048
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/btfs/QmTest":
+			gets++
+			w.Write(make([]byte, size))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	r := Gateway(context.Background(), srv.Client(), srv.URL+"/", "QmTest", size, 3)
+	if r.Error != "" {
+		t.Fatal(r.Error)
+	}
+	if r.Kind != KindGateway || r.ID != srv.URL || r.Latency.Count != 3 {
+		t.Fatalf("unexpected result %+v", r)
+	}
+	if gets != 2 || r.Upload <= 0 || r.Download <= 0 {
+		t.Fatalf("expected an upload and a download, got %d fetches, %+v", gets, r)
+	}
+
+	if r := Gateway(context.Background(), srv.Client(), srv.URL, "QmTest", size+1, 1); r.Error == "" {
+		t.Fatal("short content accepted")
+	}
+	if r := Gateway(context.Background(), srv.Client(), srv.URL, "QmMissing", size, 1); r.Error == "" {
+		t.Fatal("missing content accepted")
+	}
+}
@@ -0,0 +1,284 @@
+// Package trash keeps the MFS entries removed by 'btfs files rm' in the
+// Dir directory for TTL before purging them, so that they can be restored.
+//
+// A removed entry is moved to Dir/<id>, its original path and removal time
+// are recorded in the datastore.
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	gopath "path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/TRON-US/go-mfs"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// ConfigKey is the config section of the trash.
+const ConfigKey = "Trash"
+
+// Dir is the MFS directory of the removed entries.
+const Dir = "/.trash"
+
+// DefaultTTL is how long removed entries are kept when nothing is
+// configured.
+const DefaultTTL = 7 * 24 * time.Hour
+
+const keyPrefix = "/btfs/%s/trash/"
+
+// ErrNotFound is returned when no removed entry matches.
+var ErrNotFound = errors.New("no such entry in the trash")
+
+// Config configures the trash.
+type Config struct {
+	// Enabled moves the entries removed by 'btfs files rm' to the trash
+	// instead of unlinking them.
+	Enabled bool
+	// TTL is how long removed entries are kept, e.g. "72h".
+	TTL string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the trash config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if _, err := c.Retention(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+// Retention returns how long removed entries are kept.
+func (c *Config) Retention() (time.Duration, error) {
+	if c.TTL == "" {
+		return DefaultTTL, nil
+	}
+	d, err := time.ParseDuration(c.TTL)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid TTL %q", c.TTL)
+	}
+	return d, nil
+}
+
+// Entry is a removed MFS entry.
+type Entry struct {
+	ID      string
+	Path    string
+	Removed time.Time
+}
+
+// Trash is the trash of an MFS root.
+type Trash struct {
+	root   *mfs.Root
+	d      ds.Datastore
+	peerID string
+}
+
+// New returns the trash of the MFS root of the node peerID, recording the
+// entries in d.
+func New(root *mfs.Root, d ds.Datastore, peerID string) *Trash {
+	return &Trash{root: root, d: d, peerID: peerID}
+}
+
+// InTrash reports whether the MFS path p is in the trash.
+func InTrash(p string) bool {
+	p = gopath.Clean(p)
+	return p == Dir || strings.HasPrefix(p, Dir+"/")
+}
+
+func (t *Trash) key(id string) ds.Key {
+	return ds.NewKey(fmt.Sprintf(keyPrefix, t.peerID) + id)
+}
+
+// Move moves the entry at the MFS path p to the trash.
+func (t *Trash) Move(ctx context.Context, p string, now time.Time) (*Entry, error) {
+	p = gopath.Clean(p)
+	if p == "/" || InTrash(p) {
+		return nil, fmt.Errorf("cannot move %s to the trash", p)
+	}
+	err := mfs.Mkdir(t.root, Dir, mfs.MkdirOpts{Mkparents: true})
+	if err != nil && err != mfs.ErrDirExists {
+		return nil, err
+	}
+	// entries removed at once get distinct IDs
+	n := now.UnixNano()
+	for {
+		has, err := t.d.Has(t.key(strconv.FormatInt(n, 36)))
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			break
+		}
+		n++
+	}
+	e := &Entry{ID: strconv.FormatInt(n, 36), Path: p, Removed: now}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := mfs.Mv(t.root, p, gopath.Join(Dir, e.ID)); err != nil {
+		return nil, err
+	}
+	if err := t.d.Put(t.key(e.ID), b); err != nil {
+		return nil, err
+	}
+	return e, t.flush(ctx, gopath.Dir(p))
+}
+
+// List returns the removed entries, latest first.
+func (t *Trash) List() ([]*Entry, error) {
+	results, err := t.d.Query(query.Query{Prefix: fmt.Sprintf(keyPrefix, t.peerID)})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var entries []*Entry
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		e := &Entry{}
+		if err := json.Unmarshal(r.Value, e); err != nil {
+			return nil, fmt.Errorf("invalid trash entry %s: %s", r.Key, err)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Removed.After(entries[j].Removed)
+	})
+	return entries, nil
+}
+
+// Find returns the removed entry of ID idOrPath, else the latest removed
+// from the path idOrPath.
+func (t *Trash) Find(idOrPath string) (*Entry, error) {
+	entries, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.ID == idOrPath {
+			return e, nil
+		}
+	}
+	if strings.HasPrefix(idOrPath, "/") {
+		p := gopath.Clean(idOrPath)
+		for _, e := range entries {
+			if e.Path == p {
+				return e, nil
+			}
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Restore moves the removed entry e to the MFS path dst, its original path
+// when empty. The parent directories of dst are created as needed.
+func (t *Trash) Restore(ctx context.Context, e *Entry, dst string) error {
+	if dst == "" {
+		dst = e.Path
+	}
+	dst = gopath.Clean(dst)
+	if InTrash(dst) {
+		return fmt.Errorf("cannot restore to %s, it is in the trash", dst)
+	}
+	if _, err := mfs.Lookup(t.root, dst); err == nil {
+		return fmt.Errorf("cannot restore to %s, it already exists", dst)
+	} else if err != os.ErrNotExist {
+		return err
+	}
+	if dir := gopath.Dir(dst); dir != "/" {
+		err := mfs.Mkdir(t.root, dir, mfs.MkdirOpts{Mkparents: true})
+		if err != nil && err != mfs.ErrDirExists {
+			return err
+		}
+	}
+	if err := mfs.Mv(t.root, gopath.Join(Dir, e.ID), dst); err != nil {
+		return err
+	}
+	if err := t.d.Delete(t.key(e.ID)); err != nil {
+		return err
+	}
+	return t.flush(ctx, gopath.Dir(dst))
+}
+
+// Expired matches the entries removed before the given time.
+func Expired(before time.Time) func(*Entry) bool {
+	return func(e *Entry) bool {
+		return e.Removed.Before(before)
+	}
+}
+
+// Purge removes for good the entries matching and returns them.
+func (t *Trash) Purge(ctx context.Context, match func(*Entry) bool) ([]*Entry, error) {
+	entries, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+	var purged []*Entry
+	for _, e := range entries {
+		if !match(e) {
+			continue
+		}
+		if err := t.unlink(e.ID); err != nil {
+			return purged, err
+		}
+		if err := t.d.Delete(t.key(e.ID)); err != nil {
+			return purged, err
+		}
+		purged = append(purged, e)
+	}
+	if len(purged) == 0 {
+		return nil, nil
+	}
+	return purged, t.flush(ctx, "/")
+}
+
+// unlink removes Dir/id, which may already be gone.
+func (t *Trash) unlink(id string) error {
+	n, err := mfs.Lookup(t.root, Dir)
+	if err == os.ErrNotExist {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dir, ok := n.(*mfs.Directory)
+	if !ok {
+		return fmt.Errorf("%s is not a directory", Dir)
+	}
+	if err := dir.Unlink(id); err != nil && err != os.ErrNotExist {
+		return err
+	}
+	return nil
+}
+
+// flush flushes the trash and the directory p.
+func (t *Trash) flush(ctx context.Context, p string) error {
+	if _, err := mfs.FlushPath(ctx, t.root, p); err != nil {
+		return err
+	}
+	if _, err := mfs.FlushPath(ctx, t.root, Dir); err != nil && err != os.ErrNotExist {
+		return err
+	}
+	return nil
+}
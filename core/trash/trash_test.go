@@ -0,0 +1,134 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-mfs"
+	ft "github.com/TRON-US/go-unixfs"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	dag "github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+)
+
+func newTrash(t *testing.T) (*Trash, *mfs.Root) {
+	ctx := context.Background()
+	pub := func(context.Context, cid.Cid) error { return nil }
+	root, err := mfs.NewRoot(ctx, mdtest.Mock(), ft.EmptyDirNode(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mfs.Mkdir(root, "/docs", mfs.MkdirOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := mfs.Lookup(root, "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := dag.NodeWithData(ft.FilePBData([]byte("hello"), 5))
+	if err := docs.(*mfs.Directory).AddChild("a.txt", file); err != nil {
+		t.Fatal(err)
+	}
+	return New(root, dssync.MutexWrap(ds.NewMapDatastore()), "QmSelf"), root
+}
+
+func exists(root *mfs.Root, p string) bool {
+	_, err := mfs.Lookup(root, p)
+	return err == nil
+}
+
+func TestMoveRestore(t *testing.T) {
+	ctx := context.Background()
+	bin, root := newTrash(t)
+	now := time.Now()
+
+	e, err := bin.Move(ctx, "/docs/a.txt", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists(root, "/docs/a.txt") || !exists(root, Dir+"/"+e.ID) {
+		t.Fatal("not moved to the trash")
+	}
+	if _, err := bin.Move(ctx, Dir+"/"+e.ID, now); err == nil {
+		t.Fatal("moved the trash to the trash")
+	}
+
+	// restore by path
+	found, err := bin.Find("/docs/a.txt")
+	if err != nil || found.ID != e.ID {
+		t.Fatalf("cannot find by path: %v %v", found, err)
+	}
+	if err := bin.Restore(ctx, found, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(root, "/docs/a.txt") || exists(root, Dir+"/"+e.ID) {
+		t.Fatal("not restored")
+	}
+	if _, err := bin.Find(e.ID); err != ErrNotFound {
+		t.Fatalf("restored entry still in the trash: %v", err)
+	}
+
+	// restore by ID elsewhere, not over an existing file
+	if e, err = bin.Move(ctx, "/docs", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := mfs.Mkdir(root, "/docs", mfs.MkdirOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bin.Restore(ctx, e, ""); err == nil {
+		t.Fatal("restored over an existing directory")
+	}
+	if err := bin.Restore(ctx, e, "/old/docs"); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(root, "/old/docs/a.txt") {
+		t.Fatal("not restored with its parents")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	bin, root := newTrash(t)
+	now := time.Now()
+
+	old, err := bin.Move(ctx, "/docs/a.txt", now.Add(-48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := bin.Move(ctx, "/docs", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purged, err := bin.Purge(ctx, Expired(now.Add(-24*time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purged) != 1 || purged[0].ID != old.ID || exists(root, Dir+"/"+old.ID) {
+		t.Fatalf("expected %s purged, got %v", old.ID, purged)
+	}
+	entries, err := bin.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != recent.ID || !exists(root, Dir+"/"+recent.ID) {
+		t.Fatalf("expected %s kept, got %v", recent.ID, entries)
+	}
+}
+
+func TestInTrash(t *testing.T) {
+	for p, want := range map[string]bool{
+		"/.trash":      true,
+		"/.trash/abc":  true,
+		"/.trash/a/b/": true,
+		"/.trashed":    false,
+		"/docs/.trash": false,
+		"/":            false,
+	} {
+		if InTrash(p) != want {
+			t.Errorf("InTrash(%q) != %v", p, want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Package uploadpolicy names sets of upload settings, such as how long and
+// where files are stored, for 'btfs storage upload --policy'. A node sets a
+// default policy for the uploads without --policy, and may lock it so that
+// every upload follows a policy, which keeps the users of a shared node from
+// uploading with settings their organization does not allow.
+package uploadpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+)
+
+// ConfigKey is the config section of the upload policies.
+const ConfigKey = "UploadPolicies"
+
+// Policy is a named set of upload settings, the zero value of a field
+// leaving the setting to the uploader.
+type Policy struct {
+	// StorageLength is the number of days the files are stored, the least
+	// when the policy is locked.
+	StorageLength int `json:",omitempty"`
+	// Copies is the replication factor of the files, the least when the
+	// policy is locked.
+	Copies int `json:",omitempty"`
+	// MaxPrice is the highest price paid, in µBTT per GiB per day.
+	MaxPrice int64 `json:",omitempty"`
+	// Encrypted only lets the files encrypted with 'btfs add --encrypt' or
+	// --dek be uploaded.
+	Encrypted bool `json:",omitempty"`
+	// Regions only lets the hosts of these regions or country codes store
+	// the files.
+	Regions []string `json:",omitempty"`
+	// MinScore only lets the hosts with at least this score store the
+	// files.
+	MinScore float32 `json:",omitempty"`
+}
+
+// Presets are the policies every node has, which the config can redefine.
+var Presets = map[string]*Policy{
+	"archive": {StorageLength: 365, Copies: 5, Encrypted: true, MinScore: 7},
+	"hot":     {StorageLength: 30, Copies: 3, MinScore: 9},
+	"cheap":   {StorageLength: 30, Copies: 3, MaxPrice: 125000},
+}
+
+// Config configures the upload policies of the node.
+type Config struct {
+	// Default is the policy of the uploads without --policy, none when
+	// empty.
+	Default string `json:",omitempty"`
+	// Locked makes every upload follow a policy, Default unless --policy
+	// names another: the options of the upload must comply with it.
+	Locked bool
+	// Policies are the policies of the node by name, added to the Presets
+	// or redefining them.
+	Policies map[string]*Policy `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the upload policies config of r.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	for name, p := range c.Policies {
+		if name == "" || p == nil {
+			return errors.New("policies must be named and not null")
+		}
+		if p.StorageLength < 0 || p.Copies < 0 || p.MaxPrice < 0 || p.MinScore < 0 {
+			return fmt.Errorf("policy %s: settings must not be negative", name)
+		}
+	}
+	if c.Locked && c.Default == "" {
+		return errors.New("a locked config needs a Default policy")
+	}
+	if c.Default != "" && c.All()[c.Default] == nil {
+		return fmt.Errorf("unknown Default policy %q", c.Default)
+	}
+	return nil
+}
+
+// All returns the policies of the node by name, the Presets and the ones
+// of the config.
+func (c *Config) All() map[string]*Policy {
+	all := make(map[string]*Policy, len(Presets)+len(c.Policies))
+	for name, p := range Presets {
+		all[name] = p
+	}
+	for name, p := range c.Policies {
+		all[name] = p
+	}
+	return all
+}
+
+// Names returns the names of the policies of the node, sorted.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(Presets)+len(c.Policies))
+	for name := range c.All() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select returns the policy name of an upload, the Default one when empty,
+// with its name. It returns a nil policy when the upload follows none.
+func (c *Config) Select(name string) (string, *Policy, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+	p, ok := c.All()[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown upload policy %q, must be one of %s", name,
+			strings.Join(c.Names(), ", "))
+	}
+	return name, p, nil
+}
+
+// Options are the options of an upload a policy sets, zero when left to
+// their defaults.
+type Options struct {
+	StorageLength int
+	Copies        int
+	Price         int64
+	// CustomHosts is set when the uploader chose the hosts.
+	CustomHosts bool
+	// Encrypted is set when the file is encrypted.
+	Encrypted bool
+}
+
+// Apply sets the options o left to their defaults from the policy p named
+// name. When locked, the options set must comply with p: a storage length
+// and copies at least those of p, a price at most its MaxPrice, and no
+// hosts chosen against its placement. An encrypted policy always needs the
+// file encrypted.
+func (p *Policy) Apply(name string, o *Options, locked bool) error {
+	if p.Encrypted && !o.Encrypted {
+		return fmt.Errorf("upload policy %s needs the file encrypted, add it with --encrypt or --dek", name)
+	}
+	if locked {
+		switch {
+		case o.StorageLength > 0 && o.StorageLength < p.StorageLength:
+			return fmt.Errorf("upload policy %s stores the files %d days at least", name, p.StorageLength)
+		case o.Copies > 0 && o.Copies < p.Copies:
+			return fmt.Errorf("upload policy %s stores %d copies at least", name, p.Copies)
+		case o.Price > 0 && p.MaxPrice > 0 && o.Price > p.MaxPrice:
+			return fmt.Errorf("upload policy %s pays %d µBTT per GiB per day at most", name, p.MaxPrice)
+		case o.CustomHosts && p.Placed():
+			return fmt.Errorf("upload policy %s chooses the hosts", name)
+		}
+	}
+	if o.StorageLength == 0 {
+		o.StorageLength = p.StorageLength
+	}
+	if o.Copies == 0 {
+		o.Copies = p.Copies
+	}
+	if o.Price == 0 {
+		o.Price = p.MaxPrice
+	}
+	return nil
+}
+
+// Placed reports whether p restricts the hosts storing the files.
+func (p *Policy) Placed() bool {
+	return len(p.Regions) > 0 || p.MinScore > 0
+}
+
+// Allows reports whether p lets a host of region and country, with score,
+// store the files.
+func (p *Policy) Allows(region, country string, score float32) bool {
+	if score < p.MinScore {
+		return false
+	}
+	if len(p.Regions) == 0 {
+		return true
+	}
+	for _, r := range p.Regions {
+		if strings.EqualFold(r, region) || strings.EqualFold(r, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// Encrypted reports whether the token metadata of a file tells it is
+// encrypted, with a data encryption key or the key of a peer.
+func Encrypted(tokenMeta []byte) bool {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(tokenMeta, &fields); err != nil {
+		return false
+	}
+	if dek, _ := fields["Dek"].(string); dek != "" {
+		return true
+	}
+	_, iv := fields["Iv"]
+	_, mac := fields["Mac"]
+	return iv && mac
+}
@@ -0,0 +1,128 @@
+package uploadpolicy
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, c := range []*Config{
+		{},
+		{Default: "archive", Locked: true},
+		{Default: "eu", Policies: map[string]*Policy{"eu": {Regions: []string{"EU"}}}},
+	} {
+		if err := c.validate(); err != nil {
+			t.Errorf("%+v: %s", c, err)
+		}
+	}
+	for _, c := range []*Config{
+		{Locked: true},
+		{Default: "unknown"},
+		{Policies: map[string]*Policy{"bad": {Copies: -1}}},
+		{Policies: map[string]*Policy{"null": nil}},
+	} {
+		if err := c.validate(); err == nil {
+			t.Errorf("%+v: expected an error", c)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	c := &Config{Default: "eu", Policies: map[string]*Policy{
+		"eu":    {Regions: []string{"EU"}},
+		"cheap": {MaxPrice: 1},
+	}}
+	if name, p, err := c.Select(""); err != nil || name != "eu" || p.Regions[0] != "EU" {
+		t.Fatalf("expected the default policy, got %s %+v %v", name, p, err)
+	}
+	// the config redefines the presets
+	if _, p, err := c.Select("cheap"); err != nil || p.MaxPrice != 1 {
+		t.Fatalf("expected the cheap policy of the config, got %+v %v", p, err)
+	}
+	if _, p, err := c.Select("archive"); err != nil || !p.Encrypted {
+		t.Fatalf("expected the archive preset, got %+v %v", p, err)
+	}
+	if _, _, err := c.Select("fast"); err == nil {
+		t.Fatal("expected an unknown policy to be rejected")
+	}
+	if name, p, err := (&Config{}).Select(""); err != nil || name != "" || p != nil {
+		t.Fatalf("expected no policy, got %s %+v %v", name, p, err)
+	}
+	if names := c.Names(); len(names) != 4 || names[0] != "archive" || names[3] != "hot" {
+		t.Fatalf("unexpected names %v", names)
+	}
+}
+
+func TestApply(t *testing.T) {
+	p := &Policy{StorageLength: 365, Copies: 5, MaxPrice: 100, Regions: []string{"EU"}}
+
+	o := &Options{Price: 50}
+	if err := p.Apply("archive", o, false); err != nil {
+		t.Fatal(err)
+	}
+	if o.StorageLength != 365 || o.Copies != 5 || o.Price != 50 {
+		t.Fatalf("unexpected options %+v", o)
+	}
+	// unlocked, the options set win
+	o = &Options{StorageLength: 30, Price: 500, CustomHosts: true}
+	if err := p.Apply("archive", o, false); err != nil || o.StorageLength != 30 || o.Price != 500 {
+		t.Fatalf("expected the options kept, got %+v %v", o, err)
+	}
+
+	for _, o := range []*Options{
+		{StorageLength: 30},
+		{Copies: 3},
+		{Price: 500},
+		{CustomHosts: true},
+	} {
+		if err := p.Apply("archive", o, true); err == nil {
+			t.Errorf("%+v: expected the locked policy to reject it", o)
+		}
+	}
+	o = &Options{StorageLength: 400, Copies: 6, Price: 80}
+	if err := p.Apply("archive", o, true); err != nil {
+		t.Fatal(err)
+	}
+
+	enc := &Policy{Encrypted: true}
+	if err := enc.Apply("secret", &Options{}, false); err == nil {
+		t.Fatal("expected a file not encrypted to be rejected")
+	}
+	if err := enc.Apply("secret", &Options{Encrypted: true}, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllows(t *testing.T) {
+	p := &Policy{Regions: []string{"eu", "CH"}, MinScore: 8}
+	for _, tc := range []struct {
+		region, country string
+		score           float32
+		want            bool
+	}{
+		{"EU", "DE", 9, true},
+		{"Europe", "CH", 8, true},
+		{"US", "US", 9, false},
+		{"EU", "FR", 7, false},
+	} {
+		if got := p.Allows(tc.region, tc.country, tc.score); got != tc.want {
+			t.Errorf("%+v: expected %v", tc, tc.want)
+		}
+	}
+	if !(&Policy{}).Allows("", "", 0) {
+		t.Fatal("expected any host allowed")
+	}
+}
+
+func TestEncrypted(t *testing.T) {
+	for meta, want := range map[string]bool{
+		``:                                 false,
+		`{"Owner": "me"}`:                  false,
+		`{"Dek": "docs", "DekVersion": 1}`: true,
+		`{"Iv": "aa", "Mac": "bb", "Mode": "cbc"}`: true,
+		`{"Iv": "aa"}`: false,
+	} {
+		if got := Encrypted([]byte(meta)); got != want {
+			t.Errorf("%s: expected %v", meta, want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Package uploadq bounds the upload sessions a renter runs at once, and the
+// shards each of them uploads in parallel, so that bulk uploads do not
+// overwhelm the node or its uplink. The sessions over the bound wait in a
+// queue, the ones of higher priority first, then in the order they came.
+package uploadq
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+)
+
+// ConfigKey is the config section of the upload queue of a renter.
+const ConfigKey = "UploadQueue"
+
+// Defaults of the upload queue.
+const (
+	DefaultMaxSessions = 4
+	DefaultMaxShards   = 10
+	DefaultMaxQueued   = 1000
+)
+
+// Config configures the upload queue of a renter.
+type Config struct {
+	// MaxSessions is the number of upload sessions run at once.
+	MaxSessions int `json:",omitempty"`
+	// MaxShards is the number of shards a session uploads at once, unless
+	// set for the session.
+	MaxShards int `json:",omitempty"`
+	// MaxQueued is the number of sessions waiting over which new ones are
+	// refused.
+	MaxQueued int `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the upload queue config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	if c.MaxSessions < 0 || c.MaxShards < 0 || c.MaxQueued < 0 {
+		return fmt.Errorf("negative bound")
+	}
+	if c.MaxSessions == 0 {
+		c.MaxSessions = DefaultMaxSessions
+	}
+	if c.MaxShards == 0 {
+		c.MaxShards = DefaultMaxShards
+	}
+	if c.MaxQueued == 0 {
+		c.MaxQueued = DefaultMaxQueued
+	}
+	return nil
+}
+
+// Ticket is the place of a session in the queue.
+type Ticket struct {
+	ID       string
+	Priority int
+	// MaxShards is the number of shards the session uploads at once.
+	MaxShards int
+	Queued    time.Time
+	// Started is when the session left the queue, zero while queued.
+	Started time.Time
+
+	q      *Queue
+	seq    uint64
+	ready  chan struct{}
+	shards chan struct{}
+}
+
+// Queue is the upload queue of a renter.
+type Queue struct {
+	mu          sync.Mutex
+	maxSessions int
+	seq         uint64
+	running     map[string]*Ticket
+	// queued are ordered by priority, then arrival.
+	queued []*Ticket
+}
+
+// NewQueue returns an empty queue.
+func NewQueue() *Queue {
+	return &Queue{maxSessions: DefaultMaxSessions, running: map[string]*Ticket{}}
+}
+
+// Default is the upload queue of the node.
+var Default = NewQueue()
+
+// Enqueue queues the session id with priority under c, uploading maxShards
+// shards at once, c.MaxShards when 0. It fails when c.MaxQueued sessions
+// are waiting already. The bound of the sessions run is the one of the
+// last c enqueued with.
+func (q *Queue) Enqueue(c *Config, id string, priority, maxShards int, now time.Time) (*Ticket, error) {
+	if maxShards <= 0 {
+		maxShards = c.MaxShards
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.maxSessions = c.MaxSessions
+	if len(q.queued) >= c.MaxQueued {
+		return nil, fmt.Errorf("upload queue full: %d sessions waiting", len(q.queued))
+	}
+	q.seq++
+	t := &Ticket{
+		ID:        id,
+		Priority:  priority,
+		MaxShards: maxShards,
+		Queued:    now,
+		q:         q,
+		seq:       q.seq,
+		ready:     make(chan struct{}),
+		shards:    make(chan struct{}, maxShards),
+	}
+	i := sort.Search(len(q.queued), func(i int) bool {
+		return q.queued[i].Priority < priority
+	})
+	q.queued = append(q.queued, nil)
+	copy(q.queued[i+1:], q.queued[i:])
+	q.queued[i] = t
+	q.dispatch(now)
+	return t, nil
+}
+
+// dispatch starts the sessions queued first while under the bound.
+func (q *Queue) dispatch(now time.Time) {
+	for len(q.running) < q.maxSessions && len(q.queued) > 0 {
+		t := q.queued[0]
+		q.queued = q.queued[1:]
+		t.Started = now
+		q.running[t.ID] = t
+		close(t.ready)
+	}
+}
+
+// Wait blocks until the session of t may run. The session leaves the queue
+// when ctx is done first.
+func (t *Ticket) Wait(ctx context.Context) error {
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+		t.Release()
+		return ctx.Err()
+	}
+}
+
+// Release removes the session of t from the queue, starting the next
+// sessions queued.
+func (t *Ticket) Release() {
+	q := t.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running[t.ID] == t {
+		delete(q.running, t.ID)
+	}
+	for i, o := range q.queued {
+		if o == t {
+			q.queued = append(q.queued[:i], q.queued[i+1:]...)
+			break
+		}
+	}
+	q.dispatch(time.Now())
+}
+
+// AcquireShard blocks until the session of t may upload one more shard.
+func (t *Ticket) AcquireShard(ctx context.Context) error {
+	select {
+	case t.shards <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReleaseShard ends the upload of a shard acquired.
+func (t *Ticket) ReleaseShard() {
+	<-t.shards
+}
+
+// Position returns the 1-based position of the session id in the queue, 0
+// when it is not waiting.
+func (q *Queue) Position(id string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, t := range q.queued {
+		if t.ID == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Entry describes a session of the queue.
+type Entry struct {
+	ID        string
+	Priority  int
+	MaxShards int
+	Queued    time.Time
+	Started   time.Time `json:",omitempty"`
+}
+
+// List returns the sessions running, oldest first, and the ones waiting,
+// next first.
+func (q *Queue) List() (running, queued []*Entry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry := func(t *Ticket) *Entry {
+		return &Entry{ID: t.ID, Priority: t.Priority, MaxShards: t.MaxShards, Queued: t.Queued, Started: t.Started}
+	}
+	var rs []*Ticket
+	for _, t := range q.running {
+		rs = append(rs, t)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].seq < rs[j].seq })
+	for _, t := range rs {
+		running = append(running, entry(t))
+	}
+	for _, t := range q.queued {
+		queued = append(queued, entry(t))
+	}
+	return running, queued
+}
@@ -0,0 +1,117 @@
+package uploadq
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func config(t *testing.T, maxSessions, maxQueued int) *Config {
+	c := &Config{MaxSessions: maxSessions, MaxQueued: maxQueued}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func started(t *Ticket) bool {
+	select {
+	case <-t.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	q := NewQueue()
+	c := config(t, 1, 10)
+	now := time.Now()
+	enqueue := func(id string, priority int) *Ticket {
+		tk, err := q.Enqueue(c, id, priority, 0, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tk
+	}
+	a := enqueue("a", 0)
+	b := enqueue("b", 0)
+	hi := enqueue("hi", 1)
+	d := enqueue("d", 0)
+	if !started(a) || started(b) || started(hi) {
+		t.Fatal("expected only the first session running")
+	}
+	if q.Position("hi") != 1 || q.Position("b") != 2 || q.Position("d") != 3 || q.Position("a") != 0 {
+		t.Fatalf("unexpected positions %d %d %d", q.Position("hi"), q.Position("b"), q.Position("d"))
+	}
+
+	// higher priority first, then in order
+	for _, next := range []*Ticket{hi, b, d} {
+		for _, tk := range []*Ticket{a, hi, b, d} {
+			if started(tk) && q.running[tk.ID] == tk {
+				tk.Release()
+				break
+			}
+		}
+		if !started(next) {
+			t.Fatalf("expected %s to run next", next.ID)
+		}
+	}
+	if a.MaxShards != DefaultMaxShards {
+		t.Fatalf("expected %d shards at once, got %d", DefaultMaxShards, a.MaxShards)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	q := NewQueue()
+	c := config(t, 1, 1)
+	if _, err := q.Enqueue(c, "a", 0, 0, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(c, "b", 0, 0, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(c, "c", 0, 0, time.Now()); err == nil {
+		t.Fatal("expected a full queue")
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	q := NewQueue()
+	c := config(t, 1, 10)
+	a, _ := q.Enqueue(c, "a", 0, 0, time.Now())
+	b, _ := q.Enqueue(c, "b", 0, 0, time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); err == nil {
+		t.Fatal("expected the wait to be canceled")
+	}
+	if q.Position("b") != 0 {
+		t.Fatal("expected the canceled session out of the queue")
+	}
+	if err := a.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShards(t *testing.T) {
+	q := NewQueue()
+	tk, err := q.Enqueue(config(t, 1, 10), "a", 0, 2, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if err := tk.AcquireShard(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tk.AcquireShard(ctx); err == nil {
+		t.Fatal("expected a third shard to wait")
+	}
+	tk.ReleaseShard()
+	if err := tk.AcquireShard(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,86 @@
+package users
+
+import "strings"
+
+// Scope is a group of commands an API token may run.
+type Scope string
+
+const (
+	// ScopeRead reads content and the state of the node.
+	ScopeRead Scope = "read"
+	// ScopeWrite adds, pins and changes content.
+	ScopeWrite Scope = "write"
+	// ScopeStorage uploads content to hosts, paid by the node.
+	ScopeStorage Scope = "storage"
+	// ScopeAdmin configures the node, moves funds and manages accounts.
+	ScopeAdmin Scope = "admin"
+)
+
+// commandScopes maps commands to their scope, a command inherits the scope
+// of its closest listed parent. Commands not listed need ScopeAdmin.
+var commandScopes = map[string]Scope{
+	"block/get":             ScopeRead,
+	"block/stat":            ScopeRead,
+	"cat":                   ScopeRead,
+	"cid":                   ScopeRead,
+	"commands":              ScopeRead,
+	"dag/export":            ScopeRead,
+	"dag/get":               ScopeRead,
+	"dag/resolve":           ScopeRead,
+	"dns":                   ScopeRead,
+	"events":                ScopeRead,
+	"file/ls":               ScopeRead,
+	"files/ls":              ScopeRead,
+	"files/read":            ScopeRead,
+	"files/stat":            ScopeRead,
+	"files/trash":           ScopeRead,
+	"get":                   ScopeRead,
+	"id":                    ScopeRead,
+	"log/follow":            ScopeRead,
+	"ls":                    ScopeRead,
+	"name/resolve":          ScopeRead,
+	"object/data":           ScopeRead,
+	"object/diff":           ScopeRead,
+	"object/get":            ScopeRead,
+	"object/links":          ScopeRead,
+	"object/stat":           ScopeRead,
+	"pin/ls":                ScopeRead,
+	"pin/verify":            ScopeRead,
+	"refs":                  ScopeRead,
+	"resolve":               ScopeRead,
+	"stats":                 ScopeRead,
+	"storage/upload/status": ScopeRead,
+	"tar/cat":               ScopeRead,
+	"verify":                ScopeRead,
+	"version":               ScopeRead,
+
+	"add":               ScopeWrite,
+	"block/put":         ScopeWrite,
+	"dag/import":        ScopeWrite,
+	"dag/put":           ScopeWrite,
+	"files":             ScopeWrite,
+	"files/trash/empty": ScopeWrite,
+	"keys/dek/grant":    ScopeWrite,
+	"keys/dek/list":     ScopeWrite,
+	"metadata":          ScopeWrite,
+	"object/new":        ScopeWrite,
+	"object/patch":      ScopeWrite,
+	"object/put":        ScopeWrite,
+	"pin":               ScopeWrite,
+	"tar/add":           ScopeWrite,
+	"urlstore/add":      ScopeWrite,
+
+	"storage/update": ScopeStorage,
+	"storage/upload": ScopeStorage,
+}
+
+// CommandScope returns the scope needed to run the command at path, e.g.
+// []string{"files", "ls"}.
+func CommandScope(path []string) Scope {
+	for i := len(path); i > 0; i-- {
+		if s, ok := commandScopes[strings.Join(path[:i], "/")]; ok {
+			return s
+		}
+	}
+	return ScopeAdmin
+}
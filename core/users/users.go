@@ -0,0 +1,332 @@
+// Package users implements the multi-user mode of a node shared by a team:
+// local accounts with a role, authenticating to the API with a bearer
+// token.
+//
+// The mode is on as soon as an account exists. The API then requires the
+// token of an account from every remote caller, and limits each account to
+// the commands of its role. Requests without a token from the loopback
+// interface are the operator of the node, and may run any command.
+//
+// Accounts other than admins work in their own MFS namespace (see
+// Namespace) and may be given a budget of bytes stored on hosts.
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// Role is the set of commands an account may run.
+type Role string
+
+const (
+	// Admin may run every command, and manages the accounts.
+	Admin Role = "admin"
+	// Uploader may add, pin and upload content, and read everything.
+	Uploader Role = "uploader"
+	// Viewer may only read content and the state of the node.
+	Viewer Role = "viewer"
+)
+
+// Roles lists the roles from the most to the least privileged.
+var Roles = []Role{Admin, Uploader, Viewer}
+
+var roleScopes = map[Role][]Scope{
+	Admin:    {ScopeRead, ScopeWrite, ScopeStorage, ScopeAdmin},
+	Uploader: {ScopeRead, ScopeWrite, ScopeStorage},
+	Viewer:   {ScopeRead},
+}
+
+// ParseRole returns the role called s.
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if _, ok := roleScopes[r]; !ok {
+		return "", fmt.Errorf("unknown role %q, expected one of admin, uploader, viewer", s)
+	}
+	return r, nil
+}
+
+// Scopes returns the API token scopes of the role.
+func (r Role) Scopes() []Scope {
+	return roleScopes[r]
+}
+
+// Allows reports whether the role grants the scope s.
+func (r Role) Allows(s Scope) bool {
+	for _, granted := range roleScopes[r] {
+		if granted == s {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	keyPrefix = "/btfs/%s/users/"
+	// namespaceRoot is the MFS directory holding the namespaces.
+	namespaceRoot = "/users"
+	tokenSize     = 24
+)
+
+var (
+	// ErrNotFound is returned for an account that does not exist.
+	ErrNotFound = errors.New("no such user")
+	// ErrExists is returned when adding an account whose name is taken.
+	ErrExists = errors.New("user already exists")
+	// ErrInvalidToken is returned for a token of no account.
+	ErrInvalidToken = errors.New("invalid api token")
+	// ErrBudgetExceeded is returned when an account has not enough budget
+	// left for an upload.
+	ErrBudgetExceeded = errors.New("storage budget exceeded")
+)
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+// User is a local account.
+type User struct {
+	Name string
+	Role Role
+	// TokenHash is the hex sha256 of the API token of the account, the
+	// token itself is only shown when it is created.
+	TokenHash string
+	// Budget is the number of bytes the account may store on hosts, 0 for
+	// no limit.
+	Budget int64
+	// Used is the number of bytes the account uploaded to hosts.
+	Used    int64
+	Created time.Time
+}
+
+// Namespace returns the MFS directory the account works in, "" for admins
+// which see the whole MFS.
+func (u *User) Namespace() string {
+	if u.Role == Admin {
+		return ""
+	}
+	return namespaceRoot + "/" + u.Name
+}
+
+// Store keeps the accounts of a node in its datastore.
+type Store struct {
+	d      ds.Datastore
+	prefix string
+
+	// serializes the read-modify-write of accounts
+	mu sync.Mutex
+}
+
+// NewStore returns the accounts of the node peerID kept in d.
+func NewStore(d ds.Datastore, peerID string) *Store {
+	return &Store{d: d, prefix: fmt.Sprintf(keyPrefix, peerID)}
+}
+
+// ForNode returns the accounts of n.
+func ForNode(n *core.IpfsNode) *Store {
+	return NewStore(n.Repo.Datastore(), n.Identity.Pretty())
+}
+
+func (s *Store) key(name string) ds.Key {
+	return ds.NewKey(s.prefix + name)
+}
+
+// HashToken returns the hash of an API token kept in place of the token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewToken returns a random API token.
+func NewToken() (string, error) {
+	b := make([]byte, tokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Get returns the account called name.
+func (s *Store) Get(name string) (*User, error) {
+	b, err := s.d.Get(s.key(name))
+	if err == ds.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	u := &User{}
+	if err := json.Unmarshal(b, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *Store) put(u *User) error {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return s.d.Put(s.key(u.Name), b)
+}
+
+// List returns the accounts sorted by name.
+func (s *Store) List() ([]*User, error) {
+	res, err := s.d.Query(query.Query{Prefix: s.prefix})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+	us := make([]*User, 0, len(entries))
+	for _, en := range entries {
+		u := &User{}
+		if err := json.Unmarshal(en.Value, u); err != nil {
+			return nil, fmt.Errorf("invalid user %s: %s", en.Key, err)
+		}
+		us = append(us, u)
+	}
+	sort.Slice(us, func(i, j int) bool { return us[i].Name < us[j].Name })
+	return us, nil
+}
+
+// Enabled reports whether the multi-user mode is on, that is whether an
+// account exists.
+func (s *Store) Enabled() (bool, error) {
+	res, err := s.d.Query(query.Query{Prefix: s.prefix, KeysOnly: true, Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return false, err
+	}
+	return len(entries) > 0, nil
+}
+
+// Add creates the account name and returns it with its API token.
+func (s *Store) Add(name string, role Role, budget int64) (*User, string, error) {
+	if !validName.MatchString(name) {
+		return nil, "", fmt.Errorf("invalid user name %q: use up to 32 lowercase letters, digits, '.', '_' or '-'", name)
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return nil, "", err
+	}
+	if budget < 0 {
+		return nil, "", errors.New("the budget cannot be negative")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Get(name); err == nil {
+		return nil, "", ErrExists
+	} else if err != ErrNotFound {
+		return nil, "", err
+	}
+	token, err := NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+	u := &User{
+		Name:      name,
+		Role:      role,
+		TokenHash: HashToken(token),
+		Budget:    budget,
+		Created:   time.Now(),
+	}
+	return u, token, s.put(u)
+}
+
+// Update lets f change the account name and saves it unless f fails.
+func (s *Store) Update(name string, f func(u *User) error) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := f(u); err != nil {
+		return nil, err
+	}
+	return u, s.put(u)
+}
+
+// ResetToken replaces the API token of the account name, the previous one
+// stops working.
+func (s *Store) ResetToken(name string) (string, error) {
+	token, err := NewToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = s.Update(name, func(u *User) error {
+		u.TokenHash = HashToken(token)
+		return nil
+	})
+	return token, err
+}
+
+// Remove deletes the account name. Its MFS namespace is left in place.
+func (s *Store) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Get(name); err != nil {
+		return err
+	}
+	return s.d.Delete(s.key(name))
+}
+
+// Authenticate returns the account whose API token is token.
+func (s *Store) Authenticate(token string) (*User, error) {
+	us, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	h := []byte(HashToken(token))
+	for _, u := range us {
+		if subtle.ConstantTimeCompare(h, []byte(u.TokenHash)) == 1 {
+			return u, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+// Charge counts n bytes uploaded by the account name against its budget,
+// and fails with ErrBudgetExceeded when they do not fit.
+func (s *Store) Charge(name string, n int64) error {
+	_, err := s.Update(name, func(u *User) error {
+		if u.Budget > 0 && u.Used+n > u.Budget {
+			return fmt.Errorf("%w: %d of %d bytes used, %d more needed", ErrBudgetExceeded, u.Used, u.Budget, n)
+		}
+		u.Used += n
+		return nil
+	})
+	return err
+}
+
+type ctxKey struct{}
+
+// WithUser returns a context of a request made by u.
+func WithUser(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, ctxKey{}, u)
+}
+
+// FromContext returns the account which made a request, nil for the
+// operator of the node or when the multi-user mode is off.
+func FromContext(ctx context.Context) *User {
+	u, _ := ctx.Value(ctxKey{}).(*User)
+	return u
+}
@@ -0,0 +1,141 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func newStore() *Store {
+	return NewStore(dssync.MutexWrap(ds.NewMapDatastore()), "QmPeer")
+}
+
+func TestAddAuthenticate(t *testing.T) {
+	s := newStore()
+	if on, err := s.Enabled(); err != nil || on {
+		t.Fatalf("enabled without accounts: %v %v", on, err)
+	}
+
+	u, token, err := s.Add("alice", Uploader, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.TokenHash == token || token == "" {
+		t.Fatal("the token is stored in clear")
+	}
+	if _, _, err := s.Add("alice", Viewer, 0); err != ErrExists {
+		t.Fatalf("added twice: %v", err)
+	}
+	if _, _, err := s.Add("../bob", Viewer, 0); err == nil {
+		t.Fatal("added an invalid name")
+	}
+	if on, err := s.Enabled(); err != nil || !on {
+		t.Fatalf("not enabled with an account: %v %v", on, err)
+	}
+
+	got, err := s.Authenticate(token)
+	if err != nil || got.Name != "alice" {
+		t.Fatalf("authenticated %v: %v", got, err)
+	}
+	if _, err := s.Authenticate("nope"); err != ErrInvalidToken {
+		t.Fatalf("authenticated a bad token: %v", err)
+	}
+
+	newToken, err := s.ResetToken("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(token); err != ErrInvalidToken {
+		t.Fatalf("the previous token still works: %v", err)
+	}
+	if _, err := s.Authenticate(newToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Remove("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(newToken); err != ErrInvalidToken {
+		t.Fatalf("the token of a removed account works: %v", err)
+	}
+	if err := s.Remove("alice"); err != ErrNotFound {
+		t.Fatalf("removed twice: %v", err)
+	}
+}
+
+func TestCharge(t *testing.T) {
+	s := newStore()
+	if _, _, err := s.Add("alice", Uploader, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Charge("alice", 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Charge("alice", 60); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("charged over budget: %v", err)
+	}
+	u, err := s.Get("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Used != 60 {
+		t.Fatalf("used %d, expected 60", u.Used)
+	}
+
+	if _, _, err := s.Add("bob", Uploader, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Charge("bob", 1<<40); err != nil {
+		t.Fatalf("charged an unlimited account: %v", err)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	tcs := []struct {
+		role Role
+		path []string
+		ok   bool
+	}{
+		{Viewer, []string{"cat"}, true},
+		{Viewer, []string{"files", "ls"}, true},
+		{Viewer, []string{"files", "write"}, false},
+		{Viewer, []string{"add"}, false},
+		{Uploader, []string{"add"}, true},
+		{Uploader, []string{"files", "write"}, true},
+		{Uploader, []string{"storage", "upload"}, true},
+		{Uploader, []string{"storage", "upload", "status"}, true},
+		{Uploader, []string{"storage", "hosts"}, false},
+		{Uploader, []string{"wallet", "transfer"}, false},
+		{Uploader, []string{"users", "add"}, false},
+		{Admin, []string{"users", "add"}, true},
+		{Admin, []string{"config"}, true},
+	}
+	for _, tc := range tcs {
+		if ok := tc.role.Allows(CommandScope(tc.path)); ok != tc.ok {
+			t.Errorf("%s allowed to run %v: %v, expected %v", tc.role, tc.path, ok, tc.ok)
+		}
+	}
+	if _, err := ParseRole("root"); err == nil {
+		t.Error("parsed an unknown role")
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != nil {
+		t.Fatal("user in an empty context")
+	}
+	u := &User{Name: "alice", Role: Viewer}
+	if got := FromContext(WithUser(ctx, u)); got != u {
+		t.Fatalf("got %v", got)
+	}
+	if u.Namespace() != "/users/alice" {
+		t.Fatalf("namespace %q", u.Namespace())
+	}
+	if (&User{Name: "root", Role: Admin}).Namespace() != "" {
+		t.Fatal("admins have a namespace")
+	}
+}
@@ -7,11 +7,29 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"errors"
+	"strings"
 )
 
 var iv = []byte{0x02, 0x00, 0x01, 0x06, 0x00, 0x08, 0x01, 0x04, 0x02, 0x00, 0x01, 0x06, 0x00, 0x08, 0x01, 0x04}
 
+// EncryptWithAES encrypts message with AES under the key derived from the
+// password key by the key derivation set with SetKDF.
 func EncryptWithAES(key, message string) (string, error) {
+	return encryptWithKDF(KDF(), key, message)
+}
+
+// DecryptWithAES decrypts a message encrypted by EncryptWithAES, with the
+// key derivation of its header, or the MD5 of key for a message encrypted
+// before the key derivations.
+func DecryptWithAES(key, message string) (string, error) {
+	if strings.HasPrefix(message, "$") {
+		return decryptWithKDF(key, message)
+	}
+	return decryptLegacy(key, message)
+}
+
+// encryptLegacy encrypts message with AES-CBC under the MD5 of key.
+func encryptLegacy(key, message string) (string, error) {
 	hash := md5.New()
 	hash.Write([]byte(key))
 	keyData := hash.Sum(nil)
@@ -31,7 +49,7 @@ func EncryptWithAES(key, message string) (string, error) {
 	return base64.StdEncoding.EncodeToString(crypted), nil
 }
 
-func DecryptWithAES(key, message string) (string, error) {
+func decryptLegacy(key, message string) (string, error) {
 	hash := md5.New()
 	hash.Write([]byte(key))
 	keyData := hash.Sum(nil)
@@ -45,6 +63,9 @@ func DecryptWithAES(key, message string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if len(messageData) == 0 || len(messageData)%block.BlockSize() != 0 {
+		return "", errInvalidCiphertext
+	}
 	dec := cipher.NewCBCDecrypter(block, iv)
 	decrypted := make([]byte, len(messageData))
 	dec.CryptBlocks(decrypted, messageData)
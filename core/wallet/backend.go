@@ -0,0 +1,255 @@
+package wallet
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/core/netsim"
+
+	config "github.com/TRON-US/go-btfs-config"
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	exPb "github.com/tron-us/go-btfs-common/protos/exchange"
+	ledgerPb "github.com/tron-us/go-btfs-common/protos/ledger"
+	tronPb "github.com/tron-us/go-btfs-common/protos/protocol/api"
+	corePb "github.com/tron-us/go-btfs-common/protos/protocol/core"
+	"github.com/tron-us/go-btfs-common/utils/grpc"
+)
+
+// ChainBackend is the part of the exchange, the ledger (through the escrow
+// service) and the TRON network used by the wallet to deposit, withdraw and
+// transfer BTT.
+//
+// The wallet talks to the services configured in Services by default, tests
+// can replace them with an in-memory backend, see SetChainBackend.
+type ChainBackend interface {
+	// PrepareDeposit returns the TRON transaction moving an amount from the
+	// BTT wallet to the exchange, to be signed and submitted with Deposit.
+	PrepareDeposit(ctx context.Context, in *exPb.PrepareDepositRequest) (*exPb.PrepareDepositResponse, error)
+	// Deposit submits a signed deposit transaction.
+	Deposit(ctx context.Context, in *exPb.DepositRequest) (*exPb.DepositResponse, error)
+	// ConfirmDeposit returns the status of a deposit, and once the TRON
+	// transaction is confirmed the channel state crediting the BTFS wallet.
+	ConfirmDeposit(ctx context.Context, in *exPb.ConfirmDepositRequest) (*exPb.ConfirmDepositResponse, error)
+	// PrepareWithdraw starts a withdrawal and returns the ledger address of
+	// the exchange to open a channel to.
+	PrepareWithdraw(ctx context.Context, in *exPb.PrepareWithdrawRequest) (*exPb.PrepareWithdrawResponse, error)
+	// Withdraw submits the channel states of a withdrawal.
+	Withdraw(ctx context.Context, in *exPb.WithdrawRequest) (*exPb.WithdrawResponse, error)
+	// QueryTransaction returns the status of a deposit or a withdrawal.
+	QueryTransaction(ctx context.Context, in *exPb.QueryTransactionRequest) (*exPb.QueryTransactionResponse, error)
+
+	// BalanceOf returns the ledger balance of an account, signed by the
+	// escrow service.
+	BalanceOf(ctx context.Context, in *ledgerPb.SignedCreateAccountRequest) (*escrowpb.SignedBalanceResult, error)
+	// CreateChannel creates a channel on the ledger.
+	CreateChannel(ctx context.Context, in *ledgerPb.SignedChannelCommit) (*ledgerPb.ChannelID, error)
+	// CloseChannel closes a channel on the ledger.
+	CloseChannel(ctx context.Context, in *ledgerPb.SignedChannelState) (*ledgerPb.ChannelClosed, error)
+	// ChannelInfo returns the accounts and the amount committed of a channel
+	// on the ledger.
+	ChannelInfo(ctx context.Context, in *ledgerPb.ChannelID) (*ledgerPb.ChannelInfo, error)
+
+	// GetAccount returns a confirmed TRON account.
+	GetAccount(ctx context.Context, in *corePb.Account) (*corePb.Account, error)
+	// TransferAsset2 returns the TRON transaction transferring a token, to
+	// be signed and broadcast.
+	TransferAsset2(ctx context.Context, in *corePb.TransferAssetContract) (*tronPb.TransactionExtention, error)
+	// BroadcastTransaction broadcasts a signed TRON transaction.
+	BroadcastTransaction(ctx context.Context, in *corePb.Transaction) (*tronPb.Return, error)
+	// GetTransactionById returns a confirmed TRON transaction.
+	GetTransactionById(ctx context.Context, in *tronPb.BytesMessage) (*corePb.Transaction, error)
+	// GetTransactionInfoById returns the execution result of a TRON
+	// transaction.
+	GetTransactionInfoById(ctx context.Context, in *tronPb.BytesMessage) (*corePb.TransactionInfo, error)
+}
+
+// Intervals of the deposit and transfer state machines, shortened by tests.
+var (
+	// confirmDepositInterval is how often a deposit is confirmed.
+	confirmDepositInterval = 5 * time.Second
+	// confirmDepositDelay is the time the exchange waits for a deposit
+	// transaction to be confirmed on TRON before crediting the ledger.
+	confirmDepositDelay = 1 * time.Minute
+	// closeChannelRetryInterval is the wait between attempts to close the
+	// channel of a confirmed deposit.
+	closeChannelRetryInterval = 5 * time.Second
+	// transferConfirmDelay is the time for a transfer to be confirmed, 19
+	// blocks of 3 seconds.
+	transferConfirmDelay = 1 * time.Minute
+)
+
+var (
+	chainBackendLk sync.RWMutex
+	chainBackendOv ChainBackend
+)
+
+// SetChainBackend makes the wallet use b instead of the configured services,
+// until the returned function is called.
+func SetChainBackend(b ChainBackend) (restore func()) {
+	chainBackendLk.Lock()
+	prev := chainBackendOv
+	chainBackendOv = b
+	chainBackendLk.Unlock()
+	return func() {
+		chainBackendLk.Lock()
+		chainBackendOv = prev
+		chainBackendLk.Unlock()
+	}
+}
+
+// NewChainBackend returns the ChainBackend of the services, opening a
+// connection for every call.
+func NewChainBackend(services config.Services) ChainBackend {
+	return &grpcBackend{services: services, escrow: escrowclient.New(services.EscrowDomain)}
+}
+
+// chainBackend returns the backend set by SetChainBackend, or the one of the
+// services, degraded by the network simulation when enabled.
+func chainBackend(services config.Services) ChainBackend {
+	chainBackendLk.RLock()
+	b := chainBackendOv
+	chainBackendLk.RUnlock()
+	if b == nil {
+		b = NewChainBackend(services)
+	}
+	if sim := netsim.Active(); sim != nil {
+		return &simBackend{b: b, sim: sim}
+	}
+	return b
+}
+
+// initServices returns the services set by Init.
+func initServices() config.Services {
+	return config.Services{
+		EscrowDomain:   escrowService,
+		ExchangeDomain: exchangeService,
+		SolidityDomain: solidityService,
+	}
+}
+
+type grpcBackend struct {
+	services config.Services
+	escrow   escrowclient.Client
+}
+
+func (b *grpcBackend) exchange(ctx context.Context, f func(context.Context, exPb.ExchangeClient) error) error {
+	return grpc.ExchangeClient(b.services.ExchangeDomain).WithContext(ctx, f)
+}
+
+func (b *grpcBackend) PrepareDeposit(ctx context.Context, in *exPb.PrepareDepositRequest) (out *exPb.PrepareDepositResponse, err error) {
+	err = b.exchange(ctx, func(ctx context.Context, client exPb.ExchangeClient) error {
+		out, err = client.PrepareDeposit(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (b *grpcBackend) Deposit(ctx context.Context, in *exPb.DepositRequest) (out *exPb.DepositResponse, err error) {
+	err = b.exchange(ctx, func(ctx context.Context, client exPb.ExchangeClient) error {
+		out, err = client.Deposit(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (b *grpcBackend) ConfirmDeposit(ctx context.Context, in *exPb.ConfirmDepositRequest) (out *exPb.ConfirmDepositResponse, err error) {
+	err = b.exchange(ctx, func(ctx context.Context, client exPb.ExchangeClient) error {
+		out, err = client.ConfirmDeposit(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (b *grpcBackend) PrepareWithdraw(ctx context.Context, in *exPb.PrepareWithdrawRequest) (out *exPb.PrepareWithdrawResponse, err error) {
+	err = b.exchange(ctx, func(ctx context.Context, client exPb.ExchangeClient) error {
+		out, err = client.PrepareWithdraw(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (b *grpcBackend) Withdraw(ctx context.Context, in *exPb.WithdrawRequest) (out *exPb.WithdrawResponse, err error) {
+	err = b.exchange(ctx, func(ctx context.Context, client exPb.ExchangeClient) error {
+		out, err = client.Withdraw(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (b *grpcBackend) QueryTransaction(ctx context.Context, in *exPb.QueryTransactionRequest) (out *exPb.QueryTransactionResponse, err error) {
+	err = b.exchange(ctx, func(ctx context.Context, client exPb.ExchangeClient) error {
+		out, err = client.QueryTransaction(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+func (b *grpcBackend) BalanceOf(ctx context.Context, in *ledgerPb.SignedCreateAccountRequest) (*escrowpb.SignedBalanceResult, error) {
+	return b.escrow.BalanceOf(ctx, in)
+}
+
+func (b *grpcBackend) CreateChannel(ctx context.Context, in *ledgerPb.SignedChannelCommit) (*ledgerPb.ChannelID, error) {
+	return b.escrow.CreateChannel(ctx, in)
+}
+
+func (b *grpcBackend) CloseChannel(ctx context.Context, in *ledgerPb.SignedChannelState) (*ledgerPb.ChannelClosed, error) {
+	return b.escrow.CloseChannel(ctx, in)
+}
+
+// ChannelInfo queries the channels API of the ledger, served along the
+// escrow service.
+func (b *grpcBackend) ChannelInfo(ctx context.Context, in *ledgerPb.ChannelID) (out *ledgerPb.ChannelInfo, err error) {
+	err = grpc.LedgerClient(b.services.EscrowDomain).WithContext(ctx,
+		func(ctx context.Context, client ledgerPb.ChannelsClient) error {
+			out, err = client.GetChannelInfo(ctx, in)
+			return err
+		})
+	return out, err
+}
+
+func (b *grpcBackend) GetAccount(ctx context.Context, in *corePb.Account) (out *corePb.Account, err error) {
+	err = grpc.SolidityClient(b.services.SolidityDomain).WithContext(ctx,
+		func(ctx context.Context, client tronPb.WalletSolidityClient) error {
+			out, err = client.GetAccount(ctx, in)
+			return err
+		})
+	return out, err
+}
+
+func (b *grpcBackend) TransferAsset2(ctx context.Context, in *corePb.TransferAssetContract) (out *tronPb.TransactionExtention, err error) {
+	err = grpc.WalletClient(b.services.FullnodeDomain).WithContext(ctx,
+		func(ctx context.Context, client tronPb.WalletClient) error {
+			out, err = client.TransferAsset2(ctx, in)
+			return err
+		})
+	return out, err
+}
+
+func (b *grpcBackend) BroadcastTransaction(ctx context.Context, in *corePb.Transaction) (out *tronPb.Return, err error) {
+	err = grpc.WalletClient(b.services.FullnodeDomain).WithContext(ctx,
+		func(ctx context.Context, client tronPb.WalletClient) error {
+			out, err = client.BroadcastTransaction(ctx, in)
+			return err
+		})
+	return out, err
+}
+
+func (b *grpcBackend) GetTransactionById(ctx context.Context, in *tronPb.BytesMessage) (out *corePb.Transaction, err error) {
+	err = grpc.SolidityClient(b.services.SolidityDomain).WithContext(ctx,
+		func(ctx context.Context, client tronPb.WalletSolidityClient) error {
+			out, err = client.GetTransactionById(ctx, in)
+			return err
+		})
+	return out, err
+}
+
+func (b *grpcBackend) GetTransactionInfoById(ctx context.Context, in *tronPb.BytesMessage) (out *corePb.TransactionInfo, err error) {
+	err = grpc.WalletClient(b.services.FullnodeDomain).WithContext(ctx,
+		func(ctx context.Context, client tronPb.WalletClient) error {
+			out, err = client.GetTransactionInfoById(ctx, in)
+			return err
+		})
+	return out, err
+}
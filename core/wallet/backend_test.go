@@ -0,0 +1,365 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	coremock "github.com/TRON-US/go-btfs/core/mock"
+	"github.com/TRON-US/go-btfs/core/wallet/wallettest"
+	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+
+	config "github.com/TRON-US/go-btfs-config"
+	tronPb "github.com/tron-us/go-btfs-common/protos/protocol/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ ChainBackend = (*wallettest.Fake)(nil)
+
+// newFakeChain returns a mock node whose wallet runs on a Fake, with the
+// waits of the state machines shortened.
+func newFakeChain(t *testing.T) (*core.IpfsNode, *config.Config, *wallettest.Fake) {
+	node, err := coremock.NewMockNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := node.Repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := wallettest.New()
+	cfg.Identity.PrivKey = "CAISILOZbORDZlczUlp5jdonb5y5SMZgaZy6OWp58SkS8jS8"
+	cfg.Services.EscrowDomain = "https://escrow.test"
+	cfg.Services.EscrowPubKeys = []string{f.EscrowPubKey()}
+	t.Cleanup(SetChainBackend(f))
+
+	intervals := []*time.Duration{&confirmDepositInterval, &confirmDepositDelay,
+		&closeChannelRetryInterval, &transferConfirmDelay, &txRetryInterval,
+		&withdrawConfirmInterval}
+	for _, d := range intervals {
+		prev := *d
+		*d = time.Millisecond
+		d := d
+		t.Cleanup(func() { *d = prev })
+	}
+
+	if err := Init(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	return node, cfg, f
+}
+
+func txStatus(t *testing.T, n *core.IpfsNode, id string) string {
+	txs, err := GetTransactions(n.Repo.Datastore(), n.Identity.Pretty())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range txs {
+		if tx.Id == id {
+			return tx.Status
+		}
+	}
+	t.Fatalf("transaction %s not found", id)
+	return ""
+}
+
+func TestDepositConfirmed(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.Confirmations = 3
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	// The first attempt to close the channel fails, and is retried.
+	f.FailNext("CloseChannel", wallettest.ErrUnavailable)
+
+	err := WalletDeposit(context.Background(), cfg, node, 60, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(40), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, int64(60), f.LedgerBalance(hostWallet.ledgerAddress))
+
+	tron, ledger, err := GetBalance(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(40), tron)
+	assert.Equal(t, int64(60), ledger)
+}
+
+func TestDepositRejected(t *testing.T) {
+	node, _, f := newFakeChain(t)
+	f.Confirmations = 1
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	f.RejectNext()
+
+	prepareResponse, err := Deposit(context.Background(), node, hostWallet.ledgerAddress, 60,
+		hostWallet.signer, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, StatusFailed, txStatus(t, node, "1"))
+	assert.Equal(t, int64(1), prepareResponse.GetId())
+	assert.Equal(t, int64(100), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, int64(0), f.LedgerBalance(hostWallet.ledgerAddress))
+}
+
+func TestDepositInsufficientTronBalance(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.SetTronBalance(hostWallet.tronAddress, 10)
+
+	err := WalletDeposit(context.Background(), cfg, node, 60, false, false)
+	assert.EqualError(t, err, ErrInsufficientUserBalanceOnTron.Error())
+}
+
+func TestWithdraw(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.SetLedgerBalance(hostWallet.ledgerAddress, 100)
+
+	err := WalletWithdraw(context.Background(), cfg, node, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(70), f.LedgerBalance(hostWallet.ledgerAddress))
+	assert.Equal(t, int64(30), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, int64(30), f.LedgerBalance(wallettest.ExchangeAddress))
+	assert.Equal(t, StatusSuccess, txStatus(t, node, "1"))
+}
+
+func TestWithdrawRejected(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.SetLedgerBalance(hostWallet.ledgerAddress, 100)
+	f.RejectNext()
+
+	err := WalletWithdraw(context.Background(), cfg, node, 30)
+	assert.Error(t, err)
+	assert.Equal(t, int64(100), f.LedgerBalance(hostWallet.ledgerAddress))
+	assert.Equal(t, int64(0), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, StatusFailed, txStatus(t, node, "1"))
+}
+
+// waitWithdrawFlow returns the withdrawal to an external address once over.
+func waitWithdrawFlow(t *testing.T, n *core.IpfsNode, id string) *WithdrawFlow {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		fs, err := ListWithdrawFlows(n.Repo.Datastore(), n.Identity.Pretty())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range fs {
+			if f.ID == id && f.Final() {
+				return f
+			}
+		}
+	}
+	t.Fatalf("withdrawal %s not over", id)
+	return nil
+}
+
+func TestWithdrawTo(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.Confirmations = 2
+	f.SetLedgerBalance(hostWallet.ledgerAddress, 100)
+	to := "416E2FFC26BDF48B1983CCC9EC2521867F98667760"
+	toAddr, err := hex.DecodeString(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flow, err := WithdrawTo(context.Background(), cfg, node, 30, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flow = waitWithdrawFlow(t, node, flow.ID)
+	assert.Equal(t, FlowDone, flow.Stage)
+	assert.NotEmpty(t, flow.TransferTxId)
+	assert.Equal(t, int64(70), f.LedgerBalance(hostWallet.ledgerAddress))
+	assert.Equal(t, int64(0), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, int64(30), f.TronBalance(toAddr))
+	// the transfer is checked once after transferConfirmDelay, while pending
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, StatusPending, txStatus(t, node, flow.TransferTxId))
+}
+
+func TestWithdrawToTransferFailed(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.SetLedgerBalance(hostWallet.ledgerAddress, 100)
+	f.FailNext("TransferAsset2", wallettest.ErrUnavailable)
+
+	flow, err := WithdrawTo(context.Background(), cfg, node, 30, "416E2FFC26BDF48B1983CCC9EC2521867F98667760")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flow = waitWithdrawFlow(t, node, flow.ID)
+	assert.Equal(t, FlowFailed, flow.Stage)
+	assert.Contains(t, flow.Error, FlowTransferring)
+	assert.Contains(t, flow.Rollback, "btfs wallet transfer")
+	// the amount withdrawn stays in the BTT wallet
+	assert.Equal(t, int64(30), f.TronBalance(hostWallet.tronAddress))
+
+	// nothing is withdrawn without balance
+	_, err = WithdrawTo(context.Background(), cfg, node, 300, "416E2FFC26BDF48B1983CCC9EC2521867F98667760")
+	assert.Error(t, err)
+	fs, err := ListWithdrawFlows(node.Repo.Datastore(), node.Identity.Pretty())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, fs, 2)
+	assert.Equal(t, FlowFailed, fs[0].Stage)
+	assert.Contains(t, fs[0].Rollback, "stays in the ledger")
+}
+
+func TestWithdrawInsufficientLedgerBalance(t *testing.T) {
+	node, _, f := newFakeChain(t)
+	f.SetLedgerBalance(hostWallet.ledgerAddress, 10)
+
+	_, _, err := Withdraw(context.Background(), node, hostWallet.ledgerAddress, hostWallet.tronAddress, 30,
+		hostWallet.signer)
+	assert.Equal(t, ErrInsufficientUserBalanceOnLedger, err)
+	assert.Equal(t, int64(10), f.LedgerBalance(hostWallet.ledgerAddress))
+}
+
+func TestTransferConfirmed(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.Confirmations = 1
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	to := "416E2FFC26BDF48B1983CCC9EC2521867F98667760"
+	toAddr, err := hex.DecodeString(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := TransferBTT(context.Background(), node, cfg, nil, "", to, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, ret.Result)
+	assert.Equal(t, int64(75), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, int64(25), f.TronBalance(toAddr))
+
+	// The transfer is checked once after transferConfirmDelay, while pending.
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, StatusPending, txStatus(t, node, ret.TxId))
+	_, _, err = UpdatePendingTransactions(context.Background(), node.Repo.Datastore(), cfg,
+		node.Identity.Pretty())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, StatusSuccess, txStatus(t, node, ret.TxId))
+}
+
+func TestTransferRejected(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	f.RejectNext()
+
+	ret, err := TransferBTT(context.Background(), node, cfg, nil, "",
+		"416E2FFC26BDF48B1983CCC9EC2521867F98667760", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(100), f.TronBalance(hostWallet.tronAddress))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, StatusFailed, txStatus(t, node, ret.TxId))
+}
+
+func TestTransferInsufficientBalance(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.SetTronBalance(hostWallet.tronAddress, 10)
+
+	_, err := TransferBTT(context.Background(), node, cfg, nil, "",
+		"416E2FFC26BDF48B1983CCC9EC2521867F98667760", 25)
+	assert.EqualError(t, err, "assetBalance is not sufficient.")
+	assert.Equal(t, int64(10), f.TronBalance(hostWallet.tronAddress))
+}
+
+func TestTransferRetried(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	to := "416E2FFC26BDF48B1983CCC9EC2521867F98667760"
+	f.RefuseNextBroadcast(tronPb.Return_TAPOS_ERROR)
+	f.RefuseNextBroadcast(tronPb.Return_TRANSACTION_EXPIRATION_ERROR)
+
+	ret, err := TransferBTT(context.Background(), node, cfg, nil, "", to, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, ret.Result)
+	assert.Equal(t, int64(75), f.TronBalance(hostWallet.tronAddress))
+
+	for i := 0; i <= txRetries; i++ {
+		f.RefuseNextBroadcast(tronPb.Return_TAPOS_ERROR)
+	}
+	_, err = TransferBTT(context.Background(), node, cfg, nil, "", to, 25)
+	if be, ok := err.(*BroadcastError); !ok || be.Code != tronPb.Return_TAPOS_ERROR {
+		t.Fatalf("expected a TAPOS error, got %v", err)
+	}
+	assert.Equal(t, int64(75), f.TronBalance(hostWallet.tronAddress))
+
+	// other refusals are not retried
+	f.RefuseNextBroadcast(tronPb.Return_SIGERROR)
+	f.RefuseNextBroadcast(tronPb.Return_TAPOS_ERROR)
+	_, err = TransferBTT(context.Background(), node, cfg, nil, "", to, 25)
+	if be, ok := err.(*BroadcastError); !ok || be.Code != tronPb.Return_SIGERROR {
+		t.Fatalf("expected a signature error, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, StatusSuccess, txStatus(t, node, ret.TxId))
+}
+
+func TestTransferConcurrent(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	f.RefuseNextBroadcast(tronPb.Return_TAPOS_ERROR)
+
+	var wg sync.WaitGroup
+	rets := make([]*TronRet, 4)
+	for i := range rets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ret, err := TransferBTT(context.Background(), node, cfg, nil, "",
+				"416E2FFC26BDF48B1983CCC9EC2521867F98667760", 10)
+			assert.NoError(t, err)
+			rets[i] = ret
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int64(60), f.TronBalance(hostWallet.tronAddress))
+	time.Sleep(100 * time.Millisecond)
+	for _, ret := range rets {
+		assert.Equal(t, StatusSuccess, txStatus(t, node, ret.TxId))
+	}
+}
+
+func TestUpdatePendingDeposit(t *testing.T) {
+	node, cfg, f := newFakeChain(t)
+	f.Confirmations = 1
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	f.RejectNext()
+
+	// Submit the deposit without confirming it.
+	prepareResponse, err := PrepareDeposit(context.Background(), hostWallet.ledgerAddress, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = DepositRequest(context.Background(), prepareResponse, hostWallet.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = PersistTx(node.Repo.Datastore(), node.Identity.Pretty(), "1", 60, BttWallet, InAppWallet,
+		StatusPending, walletpb.TransactionV1_EXCHANGE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{StatusPending, StatusFailed} {
+		_, _, err := UpdatePendingTransactions(context.Background(), node.Repo.Datastore(), cfg,
+			node.Identity.Pretty())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, want, txStatus(t, node, "1"))
+	}
+}
@@ -0,0 +1,279 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/events"
+	"github.com/TRON-US/go-btfs/core/netproxy"
+	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	config "github.com/TRON-US/go-btfs-config"
+	"github.com/tron-us/go-btfs-common/crypto"
+)
+
+// ConnectorConfigKey is the config section of the exchange connector.
+const ConnectorConfigKey = "ExchangeConnector"
+
+// Names of the connectors shipped with the node.
+const (
+	ConnectorBTFS    = "btfs"
+	ConnectorWebhook = "webhook"
+)
+
+// notifyTimeout bounds a notification of a connector.
+const notifyTimeout = 30 * time.Second
+
+// notifyQueueSize bounds the notifications waiting for a slow connector.
+const notifyQueueSize = 1024
+
+// ConnectorConfig selects the exchange connector of the node.
+type ConnectorConfig struct {
+	// Name of the connector, ConnectorBTFS when empty.
+	Name string `json:",omitempty"`
+	// Options of the connector, see its documentation.
+	Options map[string]string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConnectorConfigKey, ConnectorConfig{})
+	RegisterConnector(ConnectorBTFS, func(map[string]string) (Connector, error) {
+		return btfsConnector{}, nil
+	})
+	RegisterConnector(ConnectorWebhook, newWebhookConnector)
+}
+
+// Connector integrates the wallet flows of the node with a custodial
+// platform holding the BTT of its users.
+type Connector interface {
+	// DepositAddress returns the address the platform sends BTT to, to
+	// fund the node.
+	DepositAddress(ctx context.Context, n *core.IpfsNode, cfg *config.Config) (string, error)
+	// Withdraw submits the withdrawal of amount µBTT from the ledger of the
+	// node to the address to of the platform, and returns its ID.
+	Withdraw(ctx context.Context, n *core.IpfsNode, cfg *config.Config, amount int64, to string) (string, error)
+	// Notify is called with each transaction of the wallet of the node
+	// peerId recorded, and with each change of its status.
+	Notify(ctx context.Context, peerId string, tx *walletpb.TransactionV1) error
+}
+
+// ConnectorFactory returns the connector configured with options.
+type ConnectorFactory func(options map[string]string) (Connector, error)
+
+var (
+	connectorsLk sync.RWMutex
+	connectors   = map[string]ConnectorFactory{}
+	// connector is notified of the transactions of the wallet.
+	connector Connector = btfsConnector{}
+)
+
+// RegisterConnector makes the connector name available to the config, it
+// panics when name is registered already.
+func RegisterConnector(name string, f ConnectorFactory) {
+	connectorsLk.Lock()
+	defer connectorsLk.Unlock()
+	if _, ok := connectors[name]; ok {
+		panic("exchange connector registered twice: " + name)
+	}
+	connectors[name] = f
+}
+
+// Connectors returns the names of the connectors registered.
+func Connectors() []string {
+	connectorsLk.RLock()
+	defer connectorsLk.RUnlock()
+	names := make([]string, 0, len(connectors))
+	for name := range connectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadConnector returns the connector configured in r.
+func LoadConnector(r repo.Repo) (Connector, error) {
+	c := &ConnectorConfig{}
+	if _, err := repo.GetConfigSection(r, ConnectorConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConnectorConfigKey, err)
+	}
+	return NewConnector(c)
+}
+
+// NewConnector returns the connector configured by c.
+func NewConnector(c *ConnectorConfig) (Connector, error) {
+	if c.Name == "" {
+		c.Name = ConnectorBTFS
+	}
+	connectorsLk.RLock()
+	f, ok := connectors[c.Name]
+	connectorsLk.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid %s config: unknown connector %q, expected one of %v",
+			ConnectorConfigKey, c.Name, Connectors())
+	}
+	conn, err := f(c.Options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConnectorConfigKey, err)
+	}
+	return conn, nil
+}
+
+// SetConnector makes c notified of the transactions of the wallet, until
+// the returned function is called.
+func SetConnector(c Connector) (restore func()) {
+	connectorsLk.Lock()
+	prev := connector
+	connector = c
+	connectorsLk.Unlock()
+	return func() {
+		connectorsLk.Lock()
+		connector = prev
+		connectorsLk.Unlock()
+	}
+}
+
+type notification struct {
+	c      Connector
+	peerId string
+	tx     *walletpb.TransactionV1
+}
+
+var (
+	notifications     = make(chan notification, notifyQueueSize)
+	startNotifierOnce sync.Once
+)
+
+// notify notifies the connector of tx in the background, in the order of
+// the updates so that a status is never followed by an earlier one, and
+// publishes it to the subscribers of the wallet events.
+func notify(peerId string, tx *walletpb.TransactionV1) {
+	events.Publish(events.TopicWallet, tx)
+	connectorsLk.RLock()
+	c := connector
+	connectorsLk.RUnlock()
+	startNotifierOnce.Do(func() {
+		go func() {
+			for n := range notifications {
+				ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+				if err := n.c.Notify(ctx, n.peerId, n.tx); err != nil {
+					log.Warnf("notify transaction %s: %s", n.tx.Id, err)
+				}
+				cancel()
+			}
+		}()
+	})
+	select {
+	case notifications <- notification{c: c, peerId: peerId, tx: tx}:
+	default:
+		log.Warnf("notify transaction %s: too many pending notifications, dropped", tx.Id)
+	}
+}
+
+// btfsConnector runs the flows on the BTT wallet of the node and the BTFS
+// exchange.
+type btfsConnector struct{}
+
+// DepositAddress returns the address of the BTT wallet of the node, from
+// which 'btfs wallet deposit' moves the BTT to the ledger.
+func (btfsConnector) DepositAddress(ctx context.Context, n *core.IpfsNode, cfg *config.Config) (string, error) {
+	if s := configuredSigner(); s != nil {
+		return SignerBase58Address(s)
+	}
+	privKey, err := crypto.ToPrivKey(cfg.Identity.PrivKey)
+	if err != nil {
+		return "", err
+	}
+	keys, err := crypto.FromIcPrivateKey(privKey)
+	if err != nil {
+		return "", err
+	}
+	return keys.Base58Address, nil
+}
+
+// Withdraw withdraws to the BTT wallet of the node then transfers to to,
+// see WithdrawTo.
+func (btfsConnector) Withdraw(ctx context.Context, n *core.IpfsNode, cfg *config.Config, amount int64,
+	to string) (string, error) {
+	f, err := WithdrawTo(ctx, cfg, n, amount, to)
+	if err != nil {
+		return "", err
+	}
+	return f.ID, nil
+}
+
+func (btfsConnector) Notify(ctx context.Context, peerId string, tx *walletpb.TransactionV1) error {
+	return nil
+}
+
+// webhookConnector is the reference connector of the platforms: the flows
+// of btfsConnector, with the transactions posted to a webhook.
+type webhookConnector struct {
+	btfsConnector
+	url    string
+	secret []byte
+}
+
+// WebhookSignatureHeader holds the hex HMAC-SHA256 of the body of a
+// notification of the webhook connector, keyed with its secret.
+const WebhookSignatureHeader = "X-Btfs-Signature"
+
+// TransactionEvent is the body of a notification of the webhook connector.
+type TransactionEvent struct {
+	Peer        string
+	Time        time.Time
+	Transaction *walletpb.TransactionV1
+}
+
+// newWebhookConnector returns the webhook connector posting to the option
+// url, signed with the option secret when set.
+func newWebhookConnector(options map[string]string) (Connector, error) {
+	c := &webhookConnector{url: options["url"], secret: []byte(options["secret"])}
+	if c.url == "" {
+		return nil, errors.New("the webhook connector requires the url option")
+	}
+	for k := range options {
+		if k != "url" && k != "secret" {
+			return nil, fmt.Errorf("unknown webhook connector option %q", k)
+		}
+	}
+	return c, nil
+}
+
+func (c *webhookConnector) Notify(ctx context.Context, peerId string, tx *walletpb.TransactionV1) error {
+	b, err := json.Marshal(&TransactionEvent{Peer: peerId, Time: time.Now(), Transaction: tx})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.secret) > 0 {
+		mac := hmac.New(sha256.New, c.secret)
+		mac.Write(b)
+		req.Header.Set(WebhookSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := netproxy.Client(netproxy.Wallet).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s answered %s", c.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package wallet
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	coremock "github.com/TRON-US/go-btfs/core/mock"
+	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+
+	config "github.com/TRON-US/go-btfs-config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingConnector struct {
+	btfsConnector
+	txs chan *walletpb.TransactionV1
+}
+
+func (c *recordingConnector) Notify(ctx context.Context, peerId string, tx *walletpb.TransactionV1) error {
+	c.txs <- tx
+	return nil
+}
+
+func TestConnectorNotified(t *testing.T) {
+	node, err := coremock.NewMockNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &recordingConnector{txs: make(chan *walletpb.TransactionV1, 2)}
+	t.Cleanup(SetConnector(c))
+
+	d, peerId := node.Repo.Datastore(), node.Identity.Pretty()
+	if err := PersistTx(d, peerId, "tx", 10, BttWallet, "to", StatusPending, walletpb.TransactionV1_ON_CHAIN); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateStatus(d, peerId, "tx", StatusSuccess); err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []string{StatusPending, StatusSuccess} {
+		select {
+		case tx := <-c.txs:
+			assert.Equal(t, "tx", tx.Id)
+			assert.Equal(t, status, tx.Status)
+		case <-time.After(5 * time.Second):
+			t.Fatal("connector not notified")
+		}
+	}
+}
+
+func TestNewConnector(t *testing.T) {
+	conn, err := NewConnector(&ConnectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, btfsConnector{}, conn)
+
+	for _, cc := range []*ConnectorConfig{
+		{Name: "custody"},
+		{Name: ConnectorWebhook},
+		{Name: ConnectorWebhook, Options: map[string]string{"url": "https://example.com", "retries": "3"}},
+	} {
+		_, err := NewConnector(cc)
+		assert.Error(t, err, "%+v", cc)
+	}
+	conn, err = NewConnector(&ConnectorConfig{Name: ConnectorWebhook,
+		Options: map[string]string{"url": "https://example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.IsType(t, &webhookConnector{}, conn)
+}
+
+func TestWebhookConnector(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		received <- r
+	}))
+	defer srv.Close()
+
+	conn, err := newWebhookConnector(map[string]string{"url": srv.URL, "secret": "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &walletpb.TransactionV1{Id: "tx", Amount: 10, Status: StatusSuccess}
+	if err := conn.Notify(context.Background(), "peer", tx); err != nil {
+		t.Fatal(err)
+	}
+	r := <-received
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get(WebhookSignatureHeader))
+	e := &TransactionEvent{}
+	if err := json.Unmarshal(body, e); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "peer", e.Peer)
+	assert.Equal(t, "tx", e.Transaction.Id)
+
+	addr, err := conn.DepositAddress(context.Background(), nil, &config.Config{
+		Identity: config.Identity{PrivKey: "CAISILOZbORDZlczUlp5jdonb5y5SMZgaZy6OWp58SkS8jS8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "TTACjzSeJ9jDHaxRxnho1n3mVK9JASNyr9", addr)
+}
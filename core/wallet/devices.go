@@ -0,0 +1,218 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+
+	"github.com/google/uuid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// States of a linked device.
+const (
+	DeviceLinked  = "linked"
+	DeviceRevoked = "revoked"
+)
+
+// OriginLocal is the origin of the transfers made by the commands of the
+// node, rather than by a linked device.
+const OriginLocal = "local"
+
+var (
+	walletDeviceKeyPrefix = "/btfs/%v/wallet/devices/"
+	walletOriginKeyPrefix = "/btfs/%v/wallet/origins/"
+)
+
+// ErrUnknownDevice is returned for a device key of no linked device.
+var ErrUnknownDevice = errors.New("unknown or revoked device key")
+
+// Device is a BitTorrent Speed install linked to the wallet of the node,
+// which makes transfers with its own device key.
+type Device struct {
+	ID       string
+	Name     string
+	State    string
+	Linked   time.Time
+	LastUsed time.Time `json:",omitempty"`
+	Revoked  time.Time `json:",omitempty"`
+	// KeyHash is the hex SHA-256 of the device key, the key itself is only
+	// shown when linking.
+	KeyHash string `json:",omitempty"`
+}
+
+// LinkDevice links the device name to the wallet of peerId, and returns it
+// with its new device key. A device linked with the same name is revoked,
+// relinking a device replaces its key.
+func LinkDevice(d ds.Datastore, peerId, name string, now time.Time) (*Device, string, error) {
+	if name == "" {
+		return nil, "", errors.New("device name required")
+	}
+	devs, err := ListDevices(d, peerId)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, dev := range devs {
+		if dev.Name == name && dev.State == DeviceLinked {
+			if _, err := RevokeDevice(d, peerId, dev.ID, now); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, "", err
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	key := hex.EncodeToString(b)
+	dev := &Device{ID: id.String(), Name: name, State: DeviceLinked, Linked: now, KeyHash: hashDeviceKey(key)}
+	if err := putDevice(d, peerId, dev); err != nil {
+		return nil, "", err
+	}
+	return dev, key, nil
+}
+
+func hashDeviceKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+func putDevice(d ds.Datastore, peerId string, dev *Device) error {
+	b, err := json.Marshal(dev)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(walletDeviceKeyPrefix, peerId)+dev.ID), b)
+}
+
+// ListDevices returns the devices linked to the wallet of peerId, revoked
+// included, the first linked first.
+func ListDevices(d ds.Datastore, peerId string) ([]*Device, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(walletDeviceKeyPrefix, peerId)})
+	if err != nil {
+		return nil, err
+	}
+	devs := make([]*Device, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		dev := &Device{}
+		if err := json.Unmarshal(r.Value, dev); err != nil {
+			return nil, fmt.Errorf("invalid device %s: %s", r.Key, err)
+		}
+		devs = append(devs, dev)
+	}
+	sort.Slice(devs, func(i, j int) bool {
+		return devs[i].Linked.Before(devs[j].Linked)
+	})
+	return devs, nil
+}
+
+// RevokeDevice revokes the device id of the wallet of peerId, whose key is
+// refused from then on.
+func RevokeDevice(d ds.Datastore, peerId, id string, now time.Time) (*Device, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(walletDeviceKeyPrefix, peerId) + id))
+	if err == ds.ErrNotFound {
+		return nil, fmt.Errorf("no linked device %s", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dev := &Device{}
+	if err := json.Unmarshal(b, dev); err != nil {
+		return nil, err
+	}
+	if dev.State == DeviceRevoked {
+		return nil, fmt.Errorf("device %s is revoked already", id)
+	}
+	dev.State = DeviceRevoked
+	dev.Revoked = now
+	return dev, putDevice(d, peerId, dev)
+}
+
+// AuthDevice returns the linked device of the wallet of peerId with the
+// device key, and records its use.
+func AuthDevice(d ds.Datastore, peerId, key string, now time.Time) (*Device, error) {
+	devs, err := ListDevices(d, peerId)
+	if err != nil {
+		return nil, err
+	}
+	hash := []byte(hashDeviceKey(key))
+	for _, dev := range devs {
+		if subtle.ConstantTimeCompare(hash, []byte(dev.KeyHash)) != 1 {
+			continue
+		}
+		if dev.State != DeviceLinked {
+			return nil, ErrUnknownDevice
+		}
+		dev.LastUsed = now
+		return dev, putDevice(d, peerId, dev)
+	}
+	return nil, ErrUnknownDevice
+}
+
+// SetOrigin records the device which made the transaction txId of the
+// wallet of peerId.
+func SetOrigin(d ds.Datastore, peerId, txId, deviceId string) error {
+	return d.Put(ds.NewKey(fmt.Sprintf(walletOriginKeyPrefix, peerId)+txId), []byte(deviceId))
+}
+
+// Transfer is a transfer of the wallet with its origin, OriginLocal or the
+// ID of the linked device which made it.
+type Transfer struct {
+	TxId   string
+	Time   time.Time
+	To     string
+	Amount int64
+	Status string
+	Origin string
+	Device string `json:",omitempty"`
+}
+
+// ListTransfers returns the transfers of the wallet of peerId on the
+// chain, the latest first, with the device which made them.
+func ListTransfers(d ds.Datastore, peerId string) ([]*Transfer, error) {
+	txs, err := GetTransactions(d, peerId)
+	if err != nil {
+		return nil, err
+	}
+	devs, err := ListDevices(d, peerId)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]string{}
+	for _, dev := range devs {
+		names[dev.ID] = dev.Name
+	}
+	ts := make([]*Transfer, 0)
+	for _, tx := range txs {
+		if tx.Type != walletpb.TransactionV1_ON_CHAIN {
+			continue
+		}
+		t := &Transfer{TxId: tx.Id, Time: tx.TimeCreate, To: tx.To, Amount: tx.Amount, Status: tx.Status,
+			Origin: OriginLocal}
+		b, err := d.Get(ds.NewKey(fmt.Sprintf(walletOriginKeyPrefix, peerId) + tx.Id))
+		if err != nil && err != ds.ErrNotFound {
+			return nil, err
+		}
+		if err == nil {
+			t.Origin = string(b)
+			t.Device = names[t.Origin]
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
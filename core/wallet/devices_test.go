@@ -0,0 +1,102 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevices(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+
+	laptop, key, err := LinkDevice(d, "peer", "laptop", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, laptop.KeyHash, key)
+	phone, phoneKey, err := LinkDevice(d, "peer", "phone", now.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, key, phoneKey)
+
+	dev, err := AuthDevice(d, "peer", key, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, laptop.ID, dev.ID)
+	_, err = AuthDevice(d, "peer", "key", now)
+	assert.Equal(t, ErrUnknownDevice, err)
+	_, err = AuthDevice(d, "other", key, now)
+	assert.Equal(t, ErrUnknownDevice, err)
+
+	if _, err := RevokeDevice(d, "peer", phone.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	_, err = AuthDevice(d, "peer", phoneKey, now)
+	assert.Equal(t, ErrUnknownDevice, err)
+	_, err = RevokeDevice(d, "peer", phone.ID, now)
+	assert.Error(t, err)
+
+	// relinking replaces the key of the device
+	_, newKey, err := LinkDevice(d, "peer", "laptop", now.Add(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = AuthDevice(d, "peer", key, now)
+	assert.Equal(t, ErrUnknownDevice, err)
+	if _, err := AuthDevice(d, "peer", newKey, now); err != nil {
+		t.Fatal(err)
+	}
+
+	devs, err := ListDevices(d, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, devs, 3)
+	assert.Equal(t, laptop.ID, devs[0].ID)
+	assert.Equal(t, DeviceRevoked, devs[0].State)
+	assert.Equal(t, now.Add(time.Minute).Unix(), devs[0].LastUsed.Unix())
+	assert.Equal(t, DeviceRevoked, devs[1].State)
+	assert.Equal(t, DeviceLinked, devs[2].State)
+}
+
+func TestListTransfers(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	dev, _, err := LinkDevice(d, "peer", "laptop", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"local", "speed"} {
+		err := PersistTx(d, "peer", id, 10, BttWallet, "to", StatusPending, walletpb.TransactionV1_ON_CHAIN)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = PersistTx(d, "peer", "deposit", 10, BttWallet, InAppWallet, StatusPending, walletpb.TransactionV1_EXCHANGE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetOrigin(d, "peer", "speed", dev.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := ListTransfers(d, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, ts, 2)
+	origins := map[string]*Transfer{}
+	for _, tr := range ts {
+		origins[tr.TxId] = tr
+	}
+	assert.Equal(t, OriginLocal, origins["local"].Origin)
+	assert.Equal(t, dev.ID, origins["speed"].Origin)
+	assert.Equal(t, "laptop", origins["speed"].Device)
+}
@@ -51,6 +51,16 @@ func ImportKeys(n *core.IpfsNode, privKey string, mnemonic string) (err error) {
 	return nil
 }
 
+// ExportKeystore returns the Web3 keystore of the private key privKey of
+// the node identity, encrypted with password.
+func ExportKeystore(privKey string, password string) ([]byte, error) {
+	hexKey, err := privKeyToHex(privKey)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptKeystore(hexKey, password)
+}
+
 func privKeyToHex(input string) (string, error) {
 	isHex := true
 	for _, v := range input {
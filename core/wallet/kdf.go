@@ -0,0 +1,300 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDFConfigKey is the config section of the key derivation of the wallet
+// encryption, e.g.
+//
+//  "WalletKDF": {"Algorithm": "scrypt", "N": 65536}
+const KDFConfigKey = "WalletKDF"
+
+// Key derivation functions of the wallet encryption.
+const (
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+	// KDFLegacy is the MD5 of the password the keys were encrypted with
+	// before, for wallet UIs decrypting the keys themselves.
+	KDFLegacy = "legacy"
+)
+
+// Defaults of the key derivation, the recommendations of RFC 9106 and of
+// scrypt for interactive logins.
+const (
+	DefaultArgon2Time      = 1
+	DefaultArgon2MemoryKiB = 64 << 10
+	DefaultArgon2Threads   = 4
+	DefaultScryptN         = 1 << 15
+	DefaultScryptR         = 8
+	DefaultScryptP         = 1
+)
+
+// Bounds of the parameters read from a ciphertext, which cannot have the
+// wallet spend more to decrypt it.
+const (
+	maxArgon2Time      = 64
+	maxArgon2MemoryKiB = 4 << 20
+	maxScryptN         = 1 << 22
+	maxScryptRP        = 1 << 10
+)
+
+const (
+	kdfVersion = "v=1"
+	saltSize   = 16
+	keySize    = 32
+)
+
+// KDFConfig configures the key derivation of the wallet encryption. The
+// parameters of Algorithm are taken, the defaults when 0.
+type KDFConfig struct {
+	// Algorithm is KDFArgon2id when empty.
+	Algorithm string `json:",omitempty"`
+	// Time, MemoryKiB and Threads are the Argon2id parameters.
+	Time      uint32 `json:",omitempty"`
+	MemoryKiB uint32 `json:",omitempty"`
+	Threads   uint8  `json:",omitempty"`
+	// N, R and P are the scrypt parameters.
+	N int `json:",omitempty"`
+	R int `json:",omitempty"`
+	P int `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(KDFConfigKey, KDFConfig{})
+}
+
+// LoadKDF returns the key derivation configured in r, with its defaults.
+func LoadKDF(r repo.Repo) (*KDFConfig, error) {
+	c := &KDFConfig{}
+	if _, err := repo.GetConfigSection(r, KDFConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", KDFConfigKey, err)
+	}
+	c.withDefaults()
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", KDFConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *KDFConfig) withDefaults() {
+	if c.Algorithm == "" {
+		c.Algorithm = KDFArgon2id
+	}
+	switch c.Algorithm {
+	case KDFArgon2id:
+		if c.Time == 0 {
+			c.Time = DefaultArgon2Time
+		}
+		if c.MemoryKiB == 0 {
+			c.MemoryKiB = DefaultArgon2MemoryKiB
+		}
+		if c.Threads == 0 {
+			c.Threads = DefaultArgon2Threads
+		}
+	case KDFScrypt:
+		if c.N == 0 {
+			c.N = DefaultScryptN
+		}
+		if c.R == 0 {
+			c.R = DefaultScryptR
+		}
+		if c.P == 0 {
+			c.P = DefaultScryptP
+		}
+	}
+}
+
+func (c *KDFConfig) validate() error {
+	switch c.Algorithm {
+	case KDFArgon2id:
+		// argon2.IDKey panics with no time or thread
+		if c.Time < 1 || c.Time > maxArgon2Time || c.Threads < 1 ||
+			c.MemoryKiB < 8*uint32(c.Threads) || c.MemoryKiB > maxArgon2MemoryKiB {
+			return fmt.Errorf("argon2id parameters out of bounds")
+		}
+	case KDFScrypt:
+		if c.N < 2 || c.N&(c.N-1) != 0 || c.N > maxScryptN {
+			return fmt.Errorf("scrypt N must be a power of 2 up to %d", maxScryptN)
+		}
+		if c.R <= 0 || c.P <= 0 || c.R*c.P > maxScryptRP {
+			return fmt.Errorf("scrypt parameters out of bounds")
+		}
+	case KDFLegacy:
+	default:
+		return fmt.Errorf("unknown algorithm %q, must be %s, %s or %s", c.Algorithm, KDFArgon2id, KDFScrypt, KDFLegacy)
+	}
+	return nil
+}
+
+// header returns the header of the ciphertexts encrypted with c.
+func (c *KDFConfig) header() string {
+	switch c.Algorithm {
+	case KDFArgon2id:
+		return fmt.Sprintf("$%s$%s$t=%d,m=%d,p=%d", c.Algorithm, kdfVersion, c.Time, c.MemoryKiB, c.Threads)
+	case KDFScrypt:
+		return fmt.Sprintf("$%s$%s$n=%d,r=%d,p=%d", c.Algorithm, kdfVersion, c.N, c.R, c.P)
+	}
+	return ""
+}
+
+// derive returns the AES-256 key of password and salt.
+func (c *KDFConfig) derive(password string, salt []byte) ([]byte, error) {
+	switch c.Algorithm {
+	case KDFArgon2id:
+		return argon2.IDKey([]byte(password), salt, c.Time, c.MemoryKiB, c.Threads, keySize), nil
+	case KDFScrypt:
+		return scrypt.Key([]byte(password), salt, c.N, c.R, c.P, keySize)
+	}
+	return nil, fmt.Errorf("unknown algorithm %q", c.Algorithm)
+}
+
+// Outdated reports whether message was not encrypted with the key
+// derivation of c.
+func (c *KDFConfig) Outdated(message string) bool {
+	if c.Algorithm == KDFLegacy {
+		return strings.HasPrefix(message, "$")
+	}
+	return !strings.HasPrefix(message, c.header()+"$")
+}
+
+var (
+	kdfLk  sync.RWMutex
+	kdfCur = defaultKDF()
+)
+
+func defaultKDF() *KDFConfig {
+	c := &KDFConfig{}
+	c.withDefaults()
+	return c
+}
+
+// SetKDF makes EncryptWithAES derive its keys with c.
+func SetKDF(c *KDFConfig) {
+	kdfLk.Lock()
+	defer kdfLk.Unlock()
+	kdfCur = c
+}
+
+// ApplyKDF sets the key derivation configured in r, read again by the
+// commands encrypting the wallet, which may run without the daemon.
+func ApplyKDF(r repo.Repo) error {
+	c, err := LoadKDF(r)
+	if err != nil {
+		return err
+	}
+	SetKDF(c)
+	return nil
+}
+
+// KDF returns the key derivation of EncryptWithAES.
+func KDF() *KDFConfig {
+	kdfLk.RLock()
+	defer kdfLk.RUnlock()
+	return kdfCur
+}
+
+// encryptWithKDF encrypts message with AES-256-GCM under the key c derives
+// from password and a random salt. The ciphertext is prefixed with the
+// header of c, so that it is decrypted whatever the key derivation
+// configured then:
+//
+//  $argon2id$v=1$t=1,m=65536,p=4$<salt>$<nonce and sealed message>
+func encryptWithKDF(c *KDFConfig, password, message string) (string, error) {
+	if c.Algorithm == KDFLegacy {
+		return encryptLegacy(password, message)
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := c.derive(password, salt)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(message), nil)
+	return strings.Join([]string{c.header(),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(sealed)}, "$"), nil
+}
+
+var errInvalidCiphertext = errors.New("invalid ciphertext")
+
+// decryptWithKDF decrypts a message encrypted by encryptWithKDF.
+func decryptWithKDF(password, message string) (string, error) {
+	// "", algorithm, version, parameters, salt, sealed
+	parts := strings.Split(message, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return "", errInvalidCiphertext
+	}
+	if parts[2] != kdfVersion {
+		return "", fmt.Errorf("unsupported ciphertext version %q", parts[2])
+	}
+	c := &KDFConfig{Algorithm: parts[1]}
+	var n int
+	var err error
+	switch c.Algorithm {
+	case KDFArgon2id:
+		n, err = fmt.Sscanf(parts[3], "t=%d,m=%d,p=%d", &c.Time, &c.MemoryKiB, &c.Threads)
+	case KDFScrypt:
+		n, err = fmt.Sscanf(parts[3], "n=%d,r=%d,p=%d", &c.N, &c.R, &c.P)
+	default:
+		return "", fmt.Errorf("unknown key derivation %q", c.Algorithm)
+	}
+	if err != nil || n != 3 || c.validate() != nil || c.header() != strings.Join(parts[:4], "$") {
+		return "", errInvalidCiphertext
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return "", errInvalidCiphertext
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return "", errInvalidCiphertext
+	}
+	key, err := c.derive(password, salt)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errInvalidCiphertext
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrIncorrectPassword
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+)
+
+// cheap keeps the key derivations of the tests fast.
+var cheap = []*KDFConfig{
+	{Algorithm: KDFArgon2id, Time: 1, MemoryKiB: 64, Threads: 1},
+	{Algorithm: KDFScrypt, N: 16, R: 8, P: 1},
+	{Algorithm: KDFLegacy},
+}
+
+func TestEncryptWithKDF(t *testing.T) {
+	for _, c := range cheap {
+		cipherText, err := encryptWithKDF(c, "password", "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Outdated(cipherText) {
+			t.Errorf("%s: expected %q up to date", c.Algorithm, cipherText)
+		}
+		if plain, err := DecryptWithAES("password", cipherText); err != nil || plain != "secret" {
+			t.Errorf("%s: expected the message decrypted, got %q %v", c.Algorithm, plain, err)
+		}
+		if c.Algorithm == KDFLegacy {
+			continue
+		}
+		if !strings.HasPrefix(cipherText, "$"+c.Algorithm+"$v=1$") {
+			t.Errorf("%s: expected a versioned header, got %q", c.Algorithm, cipherText)
+		}
+		if _, err := DecryptWithAES("wrong", cipherText); err != ErrIncorrectPassword {
+			t.Errorf("%s: expected the wrong password refused, got %v", c.Algorithm, err)
+		}
+		if again, _ := encryptWithKDF(c, "password", "secret"); again == cipherText {
+			t.Errorf("%s: expected a random salt", c.Algorithm)
+		}
+	}
+	legacy, err := encryptLegacy("password", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cheap[0].Outdated(legacy) || cheap[0].Outdated("$argon2id$v=1$t=1,m=64,p=1$salt$sealed") {
+		t.Fatal("expected only the other key derivations outdated")
+	}
+	if !cheap[2].Outdated("$argon2id$v=1$t=1,m=64,p=1$salt$sealed") {
+		t.Fatal("expected a key derivation outdated when configuring the legacy one")
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	for _, c := range []struct {
+		name, cipherText string
+	}{
+		{"empty", ""},
+		{"unknown version", "$argon2id$v=2$t=1,m=64,p=1$c2FsdA$c2VhbGVk"},
+		{"argon2id no time", "$argon2id$v=1$t=0,m=64,p=1$c2FsdA$c2VhbGVk"},
+		{"argon2id no thread", "$argon2id$v=1$t=1,m=64,p=0$c2FsdA$c2VhbGVk"},
+		{"argon2id no memory", "$argon2id$v=1$t=1,m=0,p=1$c2FsdA$c2VhbGVk"},
+		{"argon2id time out of range", "$argon2id$v=1$t=65,m=64,p=1$c2FsdA$c2VhbGVk"},
+		{"argon2id memory out of range", "$argon2id$v=1$t=1,m=1048576000,p=1$c2FsdA$c2VhbGVk"},
+		{"argon2id threads overflow", "$argon2id$v=1$t=1,m=64,p=256$c2FsdA$c2VhbGVk"},
+		{"argon2id negative time", "$argon2id$v=1$t=-1,m=64,p=1$c2FsdA$c2VhbGVk"},
+		{"argon2id missing parameter", "$argon2id$v=1$t=1,m=64$c2FsdA$c2VhbGVk"},
+		{"argon2id trailing parameter", "$argon2id$v=1$t=1,m=64,p=1,x=1$c2FsdA$c2VhbGVk"},
+		{"scrypt n not a power of 2", "$scrypt$v=1$n=15,r=8,p=1$c2FsdA$c2VhbGVk"},
+		{"scrypt n out of range", "$scrypt$v=1$n=8388608,r=8,p=1$c2FsdA$c2VhbGVk"},
+		{"scrypt no r", "$scrypt$v=1$n=16,r=0,p=1$c2FsdA$c2VhbGVk"},
+		{"scrypt no p", "$scrypt$v=1$n=16,r=8,p=0$c2FsdA$c2VhbGVk"},
+		{"scrypt r p out of range", "$scrypt$v=1$n=16,r=1024,p=2$c2FsdA$c2VhbGVk"},
+		{"unknown algorithm", "$md5$v=1$$c2FsdA$c2VhbGVk"},
+		{"invalid salt", "$argon2id$v=1$t=1,m=64,p=1$c2Fsd!$c2VhbGVk"},
+		{"sealed shorter than the nonce", "$argon2id$v=1$t=1,m=64,p=1$c2FsdA$c2Vh"},
+		{"missing part", "$argon2id$v=1$t=1,m=64,p=1$c2FsdA"},
+		{"legacy too short", "c2VjcmV0"},
+	} {
+		if _, err := DecryptWithAES("password", c.cipherText); err == nil {
+			t.Errorf("%s: expected %q refused", c.name, c.cipherText)
+		}
+	}
+}
+
+func TestLoadKDFDefaults(t *testing.T) {
+	c := &KDFConfig{}
+	c.withDefaults()
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if c.header() != "$argon2id$v=1$t=1,m=65536,p=4" {
+		t.Fatalf("unexpected default %q", c.header())
+	}
+	for _, c := range []*KDFConfig{
+		{Algorithm: "pbkdf2"},
+		{Algorithm: KDFScrypt, N: 1000, R: 8, P: 1},
+		{Algorithm: KDFArgon2id, Time: 1, MemoryKiB: 8 << 20, Threads: 1},
+		{Algorithm: KDFArgon2id, Time: 0, MemoryKiB: 64, Threads: 1},
+		{Algorithm: KDFArgon2id, Time: 1, MemoryKiB: 64, Threads: 0},
+	} {
+		if err := c.validate(); err == nil {
+			t.Errorf("expected %+v refused", c)
+		}
+	}
+}
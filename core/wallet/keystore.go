@@ -0,0 +1,250 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tron-us/go-btfs-common/crypto"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// Parameters of the scrypt of the keystores exported, those of the
+// keystores of geth and TronLink.
+const (
+	KeystoreScryptN = 1 << 18
+	KeystoreScryptR = 8
+	KeystoreScryptP = 1
+
+	keystoreVersion = 3
+	keystoreCipher  = "aes-128-ctr"
+	keystoreDKLen   = 32
+)
+
+// ErrKeystorePassword is the password of a keystore not the one it was
+// encrypted with.
+var ErrKeystorePassword = errors.New("could not decrypt the keystore with the password given")
+
+// Keystore is an encrypted keystore of the Web3 Secret Storage version 3,
+// the JSON the wallets of Ethereum and TRON such as TronLink import and
+// export a private key in.
+type Keystore struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+	// Address is the hex of the 20 bytes of the address of the key, without
+	// the 41 prefix of the TRON addresses.
+	Address string         `json:"address"`
+	Crypto  keystoreCrypto `json:"crypto"`
+}
+
+type keystoreCrypto struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	KDF       string                 `json:"kdf"`
+	KDFParams map[string]interface{} `json:"kdfparams"`
+	MAC       string                 `json:"mac"`
+}
+
+// EncryptKeystore returns the keystore of the hex secp256k1 private key
+// hexKey encrypted with password.
+func EncryptKeystore(hexKey, password string) ([]byte, error) {
+	return encryptKeystore(hexKey, password, KeystoreScryptN, KeystoreScryptR, KeystoreScryptP)
+}
+
+func encryptKeystore(hexKey, password string, n, r, p int) ([]byte, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := keystoreAddress(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	dk, err := scrypt.Key([]byte(password), salt, n, r, p, keystoreDKLen)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := aesCTR(dk[:16], iv, key)
+	if err != nil {
+		return nil, err
+	}
+	ks := &Keystore{
+		Version: keystoreVersion,
+		ID:      uuid.New().String(),
+		Address: addr,
+	}
+	ks.Crypto.Cipher = keystoreCipher
+	ks.Crypto.CipherText = hex.EncodeToString(ciphertext)
+	ks.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	ks.Crypto.KDF = "scrypt"
+	ks.Crypto.KDFParams = map[string]interface{}{
+		"n":     n,
+		"r":     r,
+		"p":     p,
+		"dklen": keystoreDKLen,
+		"salt":  hex.EncodeToString(salt),
+	}
+	ks.Crypto.MAC = hex.EncodeToString(keystoreMAC(dk, ciphertext))
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// DecryptKeystore returns the hex private key of the keystore b, encrypted
+// with scrypt or pbkdf2, checking it is the key of the keystore address.
+func DecryptKeystore(b []byte, password string) (string, error) {
+	ks := &Keystore{}
+	if err := json.Unmarshal(b, ks); err != nil {
+		return "", fmt.Errorf("invalid keystore: %v", err)
+	}
+	if ks.Version != keystoreVersion {
+		return "", fmt.Errorf("unsupported keystore version %d", ks.Version)
+	}
+	if ks.Crypto.Cipher != keystoreCipher {
+		return "", fmt.Errorf("unsupported keystore cipher %q", ks.Crypto.Cipher)
+	}
+	ciphertext, err := hex.DecodeString(ks.Crypto.CipherText)
+	if err != nil {
+		return "", fmt.Errorf("invalid keystore ciphertext: %v", err)
+	}
+	iv, err := hex.DecodeString(ks.Crypto.CipherParams.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return "", errors.New("invalid keystore iv")
+	}
+	mac, err := hex.DecodeString(ks.Crypto.MAC)
+	if err != nil {
+		return "", fmt.Errorf("invalid keystore mac: %v", err)
+	}
+	dk, err := keystoreKey(ks.Crypto.KDF, ks.Crypto.KDFParams, password)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(keystoreMAC(dk, ciphertext), mac) {
+		return "", ErrKeystorePassword
+	}
+	key, err := aesCTR(dk[:16], iv, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	hexKey := hex.EncodeToString(key)
+	if want := normalizeKeystoreAddress(ks.Address); want != "" {
+		addr, err := keystoreAddress(hexKey)
+		if err != nil {
+			return "", err
+		}
+		if addr != want {
+			return "", fmt.Errorf("keystore key of address %s, not %s", addr, ks.Address)
+		}
+	}
+	return hexKey, nil
+}
+
+// keystoreKey derives the key of password with the kdf and the params of a
+// keystore, refusing the params having the wallet spend more than geth.
+func keystoreKey(kdf string, params map[string]interface{}, password string) ([]byte, error) {
+	salt, err := hex.DecodeString(kdfString(params, "salt"))
+	if err != nil || len(salt) == 0 {
+		return nil, errors.New("invalid keystore salt")
+	}
+	if dklen := kdfInt(params, "dklen"); dklen != keystoreDKLen {
+		return nil, fmt.Errorf("unsupported keystore dklen %d", dklen)
+	}
+	switch kdf {
+	case "scrypt":
+		n, r, p := kdfInt(params, "n"), kdfInt(params, "r"), kdfInt(params, "p")
+		if n <= 1 || n > maxScryptN || r <= 0 || r > maxScryptRP || p <= 0 || p > maxScryptRP {
+			return nil, fmt.Errorf("keystore scrypt n=%d r=%d p=%d out of bounds", n, r, p)
+		}
+		return scrypt.Key([]byte(password), salt, n, r, p, keystoreDKLen)
+	case "pbkdf2":
+		if prf := kdfString(params, "prf"); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported keystore pbkdf2 prf %q", prf)
+		}
+		c := kdfInt(params, "c")
+		if c <= 0 || c > 1<<24 {
+			return nil, fmt.Errorf("keystore pbkdf2 c=%d out of bounds", c)
+		}
+		return pbkdf2.Key([]byte(password), salt, c, keystoreDKLen, sha256.New), nil
+	}
+	return nil, fmt.Errorf("unsupported keystore kdf %q", kdf)
+}
+
+func kdfString(params map[string]interface{}, name string) string {
+	s, _ := params[name].(string)
+	return s
+}
+
+func kdfInt(params map[string]interface{}, name string) int {
+	f, _ := params[name].(float64)
+	if f != float64(int(f)) {
+		return 0
+	}
+	return int(f)
+}
+
+// keystoreMAC is the Keccak-256 of the second half of the derived key and
+// of the ciphertext.
+func keystoreMAC(dk, ciphertext []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(dk[16:32])
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+func aesCTR(key, iv, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// keystoreAddress returns the keystore address of the hex private key.
+func keystoreAddress(hexKey string) (string, error) {
+	privateKey, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		return "", err
+	}
+	addr, err := crypto.PublicKeyToAddress(privateKey.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	b := addr.Bytes()
+	return hex.EncodeToString(b[len(b)-20:]), nil
+}
+
+// normalizeKeystoreAddress returns the 20 bytes hex of the address of a
+// keystore, with or without the 0x or 41 prefix, and "" when it is not
+// hex, such as the base58 addresses some wallets write.
+func normalizeKeystoreAddress(addr string) string {
+	addr = strings.ToLower(strings.TrimPrefix(addr, "0x"))
+	if len(addr) == 42 && strings.HasPrefix(addr, "41") {
+		addr = addr[2:]
+	}
+	if b, err := hex.DecodeString(addr); err != nil || len(b) != 20 || bytes.Equal(b, make([]byte, 20)) {
+		return ""
+	}
+	return addr
+}
@@ -0,0 +1,79 @@
+package wallet
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const keystoreTestKey = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+
+func TestKeystore(t *testing.T) {
+	b, err := encryptKeystore(keystoreTestKey, "testpassword", 1<<10, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecryptKeystore(b, "testpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != keystoreTestKey {
+		t.Fatalf("expected the key %s, got %s", keystoreTestKey, key)
+	}
+	if _, err := DecryptKeystore(b, "wrong"); err != ErrKeystorePassword {
+		t.Fatalf("expected a wrong password refused, got %v", err)
+	}
+
+	ks := &Keystore{}
+	if err := json.Unmarshal(b, ks); err != nil {
+		t.Fatal(err)
+	}
+	ks.Address = "41" + "0000000000000000000000000000000000000001"
+	other, _ := json.Marshal(ks)
+	if _, err := DecryptKeystore(other, "testpassword"); err == nil {
+		t.Fatal("expected a key not of the keystore address refused")
+	}
+	ks.Crypto.KDFParams["n"] = 1 << 30
+	costly, _ := json.Marshal(ks)
+	if _, err := DecryptKeystore(costly, "testpassword"); err == nil {
+		t.Fatal("expected a scrypt past the bounds refused")
+	}
+}
+
+// TestKeystoreVectors decrypts the test vectors of the Web3 Secret Storage
+// definition.
+func TestKeystoreVectors(t *testing.T) {
+	for kdf, v := range map[string]string{
+		"pbkdf2": `{
+  "crypto": {
+    "cipher": "aes-128-ctr",
+    "cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+    "ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+    "kdf": "pbkdf2",
+    "kdfparams": {"c": 262144, "dklen": 32, "prf": "hmac-sha256", "salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},
+    "mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+  },
+  "id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+  "version": 3
+}`,
+		"scrypt": `{
+  "crypto": {
+    "cipher": "aes-128-ctr",
+    "cipherparams": {"iv": "83dbcc02d8ccb40e466191a123791e0e"},
+    "ciphertext": "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c",
+    "kdf": "scrypt",
+    "kdfparams": {"dklen": 32, "n": 262144, "p": 8, "r": 1, "salt": "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},
+    "mac": "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"
+  },
+  "id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+  "version": 3
+}`,
+	} {
+		key, err := DecryptKeystore([]byte(v), "testpassword")
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if key != keystoreTestKey {
+			t.Fatalf("%s: expected the key %s, got %s", kdf, keystoreTestKey, key)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// DefaultLedgerPath is the derivation path of the first TRON account, the
+// one the nodes derive from their seed phrase too: a device restored from
+// the seed phrase of a node holds the key of the node.
+const DefaultLedgerPath = "44'/195'/0'/0/0"
+
+// Instructions of the TRON app of the Ledger devices.
+const (
+	ledgerCLA         = 0xe0
+	ledgerInsAddress  = 0x02
+	ledgerInsSign     = 0x04
+	ledgerInsSignHash = 0x05
+	// ledgerChunkSize bounds the data of an instruction.
+	ledgerChunkSize = 250
+)
+
+// Status words of the TRON app.
+const (
+	ledgerOK            = 0x9000
+	ledgerRejected      = 0x6985
+	ledgerLocked        = 0x5515
+	ledgerSecurity      = 0x6982
+	ledgerUnknownIns    = 0x6d00
+	ledgerUnknownCLA    = 0x6e00
+	ledgerInvalidData   = 0x6a80
+	ledgerIncorrectData = 0x6b00
+)
+
+// HID transport of the Ledger devices.
+const (
+	hidPacketSize = 64
+	hidChannel    = 0x0101
+	hidTag        = 0x05
+)
+
+// openLedger opens the Ledger device at path, the first one found when
+// empty.
+var openLedger = openHIDLedger
+
+// ErrLedgerRejected is returned when a transaction is rejected on the
+// device.
+var ErrLedgerRejected = errors.New("transaction rejected on the Ledger device")
+
+// LedgerError is an error status of the TRON app of a Ledger device.
+type LedgerError struct {
+	Status uint16
+}
+
+func (e *LedgerError) Error() string {
+	switch e.Status {
+	case ledgerLocked, ledgerSecurity:
+		return "the Ledger device is locked, unlock it"
+	case ledgerUnknownIns, ledgerUnknownCLA:
+		return "open the TRON app on the Ledger device"
+	case ledgerInvalidData, ledgerIncorrectData:
+		return "the TRON app refused the transaction, enable 'Sign by Hash' in its settings to sign channels"
+	}
+	return fmt.Sprintf("Ledger device error 0x%04x", e.Status)
+}
+
+// ledgerSigner signs with the TRON app of a Ledger device, which asks the
+// user to confirm each transaction. The device is opened for each
+// signature, so it can be disconnected between them.
+type ledgerSigner struct {
+	device string
+	path   []uint32
+
+	mu  sync.Mutex
+	pub *ecdsa.PublicKey
+}
+
+// NewLedgerSigner returns the signer of the key at the BIP44 path of the
+// Ledger device at device, the first one found when empty. The device is
+// only opened on first use.
+func NewLedgerSigner(device string, path string) (Signer, error) {
+	p, err := parseBIP44Path(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ledgerSigner{device: device, path: p}, nil
+}
+
+// parseBIP44Path parses a derivation path such as 44'/195'/0'/0/0.
+func parseBIP44Path(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimPrefix(path, "m/"), "/")
+	if len(parts) == 0 || len(parts) > 10 {
+		return nil, fmt.Errorf("invalid derivation path %q", path)
+	}
+	p := make([]uint32, len(parts))
+	for i, s := range parts {
+		hardened := strings.HasSuffix(s, "'")
+		n, err := strconv.ParseUint(strings.TrimSuffix(s, "'"), 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q", path)
+		}
+		p[i] = uint32(n)
+		if hardened {
+			p[i] |= 0x80000000
+		}
+	}
+	return p, nil
+}
+
+// encodePath returns the derivation path as sent to the device.
+func (s *ledgerSigner) encodePath() []byte {
+	b := make([]byte, 1+4*len(s.path))
+	b[0] = byte(len(s.path))
+	for i, n := range s.path {
+		binary.BigEndian.PutUint32(b[1+4*i:], n)
+	}
+	return b
+}
+
+// PublicKey returns the key of the device, asked once.
+func (s *ledgerSigner) PublicKey() (*ecdsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pub != nil {
+		return s.pub, nil
+	}
+	resp, err := s.exchange(apdu(ledgerInsAddress, 0, 0, s.encodePath()))
+	if err != nil {
+		return nil, err
+	}
+	// the key length, the key, the address length and the address
+	if len(resp) < 1 || len(resp) < 1+int(resp[0]) {
+		return nil, errors.New("invalid public key from the Ledger device")
+	}
+	pk, err := ic.UnmarshalSecp256k1PublicKey(resp[1 : 1+resp[0]])
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key from the Ledger device: %s", err)
+	}
+	s.pub = (*ecdsa.PublicKey)(pk.(*ic.Secp256k1PublicKey))
+	return s.pub, nil
+}
+
+// SignTron has the transaction displayed on the device to be confirmed.
+func (s *ledgerSigner) SignTron(raw []byte) ([]byte, error) {
+	data := append(s.encodePath(), raw...)
+	var apdus [][]byte
+	for i := 0; i < len(data); i += ledgerChunkSize {
+		end := i + ledgerChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		var p1 byte
+		switch {
+		case i == 0 && end == len(data):
+			p1 = 0x10
+		case i == 0:
+			p1 = 0x00
+		case end == len(data):
+			p1 = 0x90
+		default:
+			p1 = 0x80
+		}
+		apdus = append(apdus, apdu(ledgerInsSign, p1, 0, data[i:end]))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sig, err := s.exchange(apdus...)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != 65 {
+		return nil, errors.New("invalid signature from the Ledger device")
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	return sig, nil
+}
+
+// SignChannel has the hash of the message signed by the device, which
+// requires 'Sign by Hash' to be enabled in the settings of the TRON app.
+func (s *ledgerSigner) SignChannel(raw []byte) ([]byte, error) {
+	hash := sha256.Sum256(raw)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sig, err := s.exchange(apdu(ledgerInsSignHash, 0, 0, append(s.encodePath(), hash[:]...)))
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != 65 {
+		return nil, errors.New("invalid signature from the Ledger device")
+	}
+	return asn1.Marshal(EcdsaSignature{
+		R: new(big.Int).SetBytes(sig[:32]),
+		S: new(big.Int).SetBytes(sig[32:64]),
+	})
+}
+
+// exchange sends the instructions to the device in turn and returns the
+// response to the last one.
+func (s *ledgerSigner) exchange(apdus ...[]byte) ([]byte, error) {
+	dev, err := openLedger(s.device)
+	if err != nil {
+		return nil, err
+	}
+	defer dev.Close()
+	var resp []byte
+	for _, a := range apdus {
+		if resp, err = ledgerExchange(dev, a); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// apdu returns the instruction ins of the TRON app.
+func apdu(ins, p1, p2 byte, data []byte) []byte {
+	return append([]byte{ledgerCLA, ins, p1, p2, byte(len(data))}, data...)
+}
+
+// ledgerExchange sends the instruction a to dev and returns its response,
+// without its status.
+func ledgerExchange(dev io.ReadWriter, a []byte) ([]byte, error) {
+	for _, p := range hidWrap(a) {
+		if _, err := dev.Write(p); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := hidUnwrap(dev)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 {
+		return nil, errors.New("invalid response from the Ledger device")
+	}
+	switch status := binary.BigEndian.Uint16(resp[len(resp)-2:]); status {
+	case ledgerOK:
+		return resp[:len(resp)-2], nil
+	case ledgerRejected:
+		return nil, ErrLedgerRejected
+	default:
+		return nil, &LedgerError{Status: status}
+	}
+}
+
+// hidWrap splits the instruction a into the HID packets of the device: the
+// channel, the tag and the sequence number of the packet, followed by the
+// length of a in the first one.
+func hidWrap(a []byte) [][]byte {
+	data := make([]byte, 2+len(a))
+	binary.BigEndian.PutUint16(data, uint16(len(a)))
+	copy(data[2:], a)
+	var packets [][]byte
+	for seq := 0; len(data) > 0; seq++ {
+		p := make([]byte, hidPacketSize)
+		binary.BigEndian.PutUint16(p, hidChannel)
+		p[2] = hidTag
+		binary.BigEndian.PutUint16(p[3:], uint16(seq))
+		n := copy(p[5:], data)
+		data = data[n:]
+		packets = append(packets, p)
+	}
+	return packets
+}
+
+// hidUnwrap reads the HID packets of a response from r.
+func hidUnwrap(r io.Reader) ([]byte, error) {
+	var resp []byte
+	size := -1
+	for seq := 0; size < 0 || len(resp) < size; seq++ {
+		p := make([]byte, hidPacketSize)
+		if _, err := io.ReadFull(r, p); err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint16(p) != hidChannel || p[2] != hidTag ||
+			binary.BigEndian.Uint16(p[3:]) != uint16(seq) {
+			return nil, errors.New("invalid packet from the Ledger device")
+		}
+		data := p[5:]
+		if seq == 0 {
+			size = int(binary.BigEndian.Uint16(data))
+			data = data[2:]
+		}
+		resp = append(resp, data...)
+	}
+	return resp[:size], nil
+}
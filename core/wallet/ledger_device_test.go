@@ -0,0 +1,237 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/tron-us/go-btfs-common/crypto"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// fakeLedger is a Ledger device running the TRON app with key.
+type fakeLedger struct {
+	key    *ecdsa.PrivateKey
+	reject bool
+	// flags are the P1 of the sign instructions received.
+	flags []byte
+
+	in, out bytes.Buffer
+	tx      []byte
+}
+
+func (d *fakeLedger) Write(p []byte) (int, error) {
+	d.in.Write(p)
+	a, err := hidUnwrap(bytes.NewReader(d.in.Bytes()))
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return len(p), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	d.in.Reset()
+	resp := d.handle(a)
+	for _, p := range hidWrap(resp) {
+		d.out.Write(p)
+	}
+	return len(p), nil
+}
+
+func (d *fakeLedger) Read(p []byte) (int, error) {
+	return d.out.Read(p)
+}
+
+func (d *fakeLedger) Close() error {
+	return nil
+}
+
+func (d *fakeLedger) handle(a []byte) []byte {
+	ins, p1, data := a[1], a[2], a[5:]
+	status := func(sw uint16, b ...byte) []byte {
+		return append(b, byte(sw>>8), byte(sw))
+	}
+	if d.reject {
+		return status(ledgerRejected)
+	}
+	switch ins {
+	case ledgerInsAddress:
+		pub, _ := signerLedgerAddress(KeySigner(d.key))
+		return status(ledgerOK, append(append([]byte{65}, pub...), 34)...)
+	case ledgerInsSign:
+		d.flags = append(d.flags, p1)
+		if p1 == 0x00 || p1 == 0x10 {
+			data = data[1+4*int(data[0]):]
+			d.tx = nil
+		}
+		d.tx = append(d.tx, data...)
+		if p1 == 0x00 || p1 == 0x80 {
+			return status(ledgerOK)
+		}
+		sig, err := crypto.EcdsaSign(d.key, d.tx)
+		if err != nil {
+			return status(0x6f00)
+		}
+		sig[64] += 27
+		return status(ledgerOK, sig...)
+	case ledgerInsSignHash:
+		r, s, err := ecdsa.Sign(rand.Reader, d.key, data[1+4*int(data[0]):])
+		if err != nil {
+			return status(0x6f00)
+		}
+		sig := make([]byte, 65)
+		copy(sig[32-len(r.Bytes()):32], r.Bytes())
+		copy(sig[64-len(s.Bytes()):64], s.Bytes())
+		return status(ledgerOK, sig...)
+	}
+	return status(ledgerUnknownIns)
+}
+
+func testKey(t *testing.T) (ic.PrivKey, *ecdsa.PrivateKey) {
+	privKey, err := crypto.ToPrivKey("CAISILOZbORDZlczUlp5jdonb5y5SMZgaZy6OWp58SkS8jS8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := privKey.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.HexToECDSA(hex.EncodeToString(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privKey, key
+}
+
+func withFakeLedger(t *testing.T, d *fakeLedger) {
+	prev := openLedger
+	openLedger = func(string) (io.ReadWriteCloser, error) {
+		return d, nil
+	}
+	t.Cleanup(func() { openLedger = prev })
+}
+
+func TestParseBIP44Path(t *testing.T) {
+	p, err := parseBIP44Path("m/44'/195'/0'/0/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint32{0x8000002c, 0x800000c3, 0x80000000, 0, 1}
+	for i := range want {
+		if len(p) != len(want) || p[i] != want[i] {
+			t.Fatalf("expected %x, got %x", want, p)
+		}
+	}
+	for _, path := range []string{"", "44'/x", "44'//0", "4294967295"} {
+		if _, err := parseBIP44Path(path); err == nil {
+			t.Errorf("expected %q rejected", path)
+		}
+	}
+}
+
+func TestHIDFraming(t *testing.T) {
+	a := make([]byte, 300)
+	rand.Read(a)
+	packets := hidWrap(a)
+	// 57 bytes in the first packet, 59 in the others
+	if len(packets) != 6 {
+		t.Fatalf("expected 6 packets, got %d", len(packets))
+	}
+	if binary.BigEndian.Uint16(packets[5][3:]) != 5 {
+		t.Fatal("unexpected sequence number")
+	}
+	got, err := hidUnwrap(bytes.NewReader(bytes.Join(packets, nil)))
+	if err != nil || !bytes.Equal(got, a) {
+		t.Fatalf("unexpected message %x %v", got, err)
+	}
+	packets[1][4] = 3
+	if _, err := hidUnwrap(bytes.NewReader(bytes.Join(packets, nil))); err == nil {
+		t.Fatal("expected a packet out of sequence rejected")
+	}
+}
+
+func TestLedgerSigner(t *testing.T) {
+	privKey, key := testKey(t)
+	d := &fakeLedger{key: key}
+	withFakeLedger(t, d)
+	s, err := NewLedgerSigner("", DefaultLedgerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := signerAddress(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := crypto.FromIcPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(addr) != keys.HexAddress {
+		t.Fatalf("expected the address %s, got %x", keys.HexAddress, addr)
+	}
+	ledgerAddr, err := signerLedgerAddress(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := ic.RawFull(privKey.GetPublic()); !bytes.Equal(ledgerAddr, raw) {
+		t.Fatalf("expected the ledger address %x, got %x", raw, ledgerAddr)
+	}
+
+	// a transaction is sent in chunks, and signed as with the key
+	tx := make([]byte, 600)
+	rand.Read(tx)
+	sig, err := s.SignTron(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := KeySigner(key).SignTron(tx); !bytes.Equal(sig, want) {
+		t.Fatalf("expected the signature %x, got %x", want, sig)
+	}
+	if !bytes.Equal(d.flags, []byte{0x00, 0x80, 0x90}) {
+		t.Fatalf("unexpected chunks %x", d.flags)
+	}
+
+	msg := []byte("channel state")
+	sig, err = s.SignChannel(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := privKey.GetPublic().Verify(msg, sig); !ok || err != nil {
+		t.Fatalf("expected the channel signature valid, got %v", err)
+	}
+
+	d.reject = true
+	if _, err := s.SignChannel(msg); err != ErrLedgerRejected {
+		t.Fatalf("expected the signature rejected, got %v", err)
+	}
+}
+
+func TestSignedPublicKey(t *testing.T) {
+	privKey, key := testKey(t)
+	d := &fakeLedger{key: key}
+	withFakeLedger(t, d)
+	s, err := NewLedgerSigner("", DefaultLedgerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetSigner(s)
+	defer SetSigner(nil)
+
+	signed, err := signedPublicKey(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := crypto.Verify(privKey.GetPublic(), signed.Key, signed.Signature); !ok || err != nil {
+		t.Fatalf("expected the public key signed, got %v", err)
+	}
+	// cached, the device is not asked again
+	d.reject = true
+	if again, err := signedPublicKey(s); err != nil || again != signed {
+		t.Fatalf("expected the signed public key cached, got %v", err)
+	}
+}
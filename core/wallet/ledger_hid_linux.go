@@ -0,0 +1,80 @@
+// +build linux
+
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ledgerHIDID is the bus and vendor of the Ledger devices in the HID_ID of
+// their hidraw devices.
+const ledgerHIDID = "HID_ID=0003:00002C97:"
+
+// ledgerUsagePage starts the report descriptor of the generic interface of
+// the Ledger devices, their other interfaces being FIDO U2F.
+var ledgerUsagePage = []byte{0x06, 0xa0, 0xff}
+
+// openHIDLedger opens the hidraw device of a Ledger, at device or the
+// first one found.
+func openHIDLedger(device string) (io.ReadWriteCloser, error) {
+	if device == "" {
+		devices, err := ledgerHIDDevices()
+		if err != nil {
+			return nil, err
+		}
+		if len(devices) == 0 {
+			return nil, errors.New("no Ledger device found, connect and unlock it")
+		}
+		device = devices[0]
+	}
+	f, err := os.OpenFile(device, os.O_RDWR, 0)
+	if os.IsPermission(err) {
+		return nil, fmt.Errorf("open %s: permission denied, see the udev rules of the Ledger devices", device)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hidraw{f}, nil
+}
+
+// ledgerHIDDevices returns the hidraw devices of the Ledgers connected.
+func ledgerHIDDevices() ([]string, error) {
+	dirs, err := filepath.Glob("/sys/class/hidraw/hidraw*")
+	if err != nil {
+		return nil, err
+	}
+	var devices []string
+	for _, dir := range dirs {
+		uevent, err := ioutil.ReadFile(filepath.Join(dir, "device", "uevent"))
+		if err != nil || !strings.Contains(string(uevent), ledgerHIDID) {
+			continue
+		}
+		desc, err := ioutil.ReadFile(filepath.Join(dir, "device", "report_descriptor"))
+		if err != nil || !bytes.HasPrefix(desc, ledgerUsagePage) {
+			continue
+		}
+		devices = append(devices, filepath.Join("/dev", filepath.Base(dir)))
+	}
+	return devices, nil
+}
+
+// hidraw is a hidraw device, whose output reports are prefixed with their
+// report number.
+type hidraw struct {
+	*os.File
+}
+
+func (h hidraw) Write(p []byte) (int, error) {
+	n, err := h.File.Write(append([]byte{0}, p...))
+	if n > 0 {
+		n--
+	}
+	return n, err
+}
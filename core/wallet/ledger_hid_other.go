@@ -0,0 +1,12 @@
+// +build !linux
+
+package wallet
+
+import (
+	"errors"
+	"io"
+)
+
+func openHIDLedger(device string) (io.ReadWriteCloser, error) {
+	return nil, errors.New("the Ledger devices are only supported on Linux")
+}
@@ -0,0 +1,125 @@
+package wallet
+
+import (
+	"context"
+
+	"github.com/TRON-US/go-btfs/core/netsim"
+
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	exPb "github.com/tron-us/go-btfs-common/protos/exchange"
+	ledgerPb "github.com/tron-us/go-btfs-common/protos/ledger"
+	tronPb "github.com/tron-us/go-btfs-common/protos/protocol/api"
+	corePb "github.com/tron-us/go-btfs-common/protos/protocol/core"
+)
+
+// simBackend degrades the calls of a backend as the network simulation
+// rules of the protocols of their services say, see package netsim.
+type simBackend struct {
+	b   ChainBackend
+	sim *netsim.Simulator
+}
+
+func (s *simBackend) PrepareDeposit(ctx context.Context, in *exPb.PrepareDepositRequest) (*exPb.PrepareDepositResponse, error) {
+	if err := s.sim.Call(ctx, netsim.ExchangeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.PrepareDeposit(ctx, in)
+}
+
+func (s *simBackend) Deposit(ctx context.Context, in *exPb.DepositRequest) (*exPb.DepositResponse, error) {
+	if err := s.sim.Call(ctx, netsim.ExchangeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.Deposit(ctx, in)
+}
+
+func (s *simBackend) ConfirmDeposit(ctx context.Context, in *exPb.ConfirmDepositRequest) (*exPb.ConfirmDepositResponse, error) {
+	if err := s.sim.Call(ctx, netsim.ExchangeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.ConfirmDeposit(ctx, in)
+}
+
+func (s *simBackend) PrepareWithdraw(ctx context.Context, in *exPb.PrepareWithdrawRequest) (*exPb.PrepareWithdrawResponse, error) {
+	if err := s.sim.Call(ctx, netsim.ExchangeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.PrepareWithdraw(ctx, in)
+}
+
+func (s *simBackend) Withdraw(ctx context.Context, in *exPb.WithdrawRequest) (*exPb.WithdrawResponse, error) {
+	if err := s.sim.Call(ctx, netsim.ExchangeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.Withdraw(ctx, in)
+}
+
+func (s *simBackend) QueryTransaction(ctx context.Context, in *exPb.QueryTransactionRequest) (*exPb.QueryTransactionResponse, error) {
+	if err := s.sim.Call(ctx, netsim.ExchangeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.QueryTransaction(ctx, in)
+}
+
+func (s *simBackend) BalanceOf(ctx context.Context, in *ledgerPb.SignedCreateAccountRequest) (*escrowpb.SignedBalanceResult, error) {
+	if err := s.sim.Call(ctx, netsim.EscrowProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.BalanceOf(ctx, in)
+}
+
+func (s *simBackend) CreateChannel(ctx context.Context, in *ledgerPb.SignedChannelCommit) (*ledgerPb.ChannelID, error) {
+	if err := s.sim.Call(ctx, netsim.EscrowProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.CreateChannel(ctx, in)
+}
+
+func (s *simBackend) CloseChannel(ctx context.Context, in *ledgerPb.SignedChannelState) (*ledgerPb.ChannelClosed, error) {
+	if err := s.sim.Call(ctx, netsim.EscrowProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.CloseChannel(ctx, in)
+}
+
+func (s *simBackend) ChannelInfo(ctx context.Context, in *ledgerPb.ChannelID) (*ledgerPb.ChannelInfo, error) {
+	if err := s.sim.Call(ctx, netsim.EscrowProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.ChannelInfo(ctx, in)
+}
+
+func (s *simBackend) GetAccount(ctx context.Context, in *corePb.Account) (*corePb.Account, error) {
+	if err := s.sim.Call(ctx, netsim.SolidityProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.GetAccount(ctx, in)
+}
+
+func (s *simBackend) TransferAsset2(ctx context.Context, in *corePb.TransferAssetContract) (*tronPb.TransactionExtention, error) {
+	if err := s.sim.Call(ctx, netsim.FullnodeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.TransferAsset2(ctx, in)
+}
+
+func (s *simBackend) BroadcastTransaction(ctx context.Context, in *corePb.Transaction) (*tronPb.Return, error) {
+	if err := s.sim.Call(ctx, netsim.FullnodeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.BroadcastTransaction(ctx, in)
+}
+
+func (s *simBackend) GetTransactionById(ctx context.Context, in *tronPb.BytesMessage) (*corePb.Transaction, error) {
+	if err := s.sim.Call(ctx, netsim.SolidityProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.GetTransactionById(ctx, in)
+}
+
+func (s *simBackend) GetTransactionInfoById(ctx context.Context, in *tronPb.BytesMessage) (*corePb.TransactionInfo, error) {
+	if err := s.sim.Call(ctx, netsim.FullnodeProtocol); err != nil {
+		return nil, err
+	}
+	return s.b.GetTransactionInfoById(ctx, in)
+}
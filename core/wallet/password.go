@@ -0,0 +1,67 @@
+package wallet
+
+import (
+	"errors"
+
+	config "github.com/TRON-US/go-btfs-config"
+)
+
+var (
+	// ErrIncorrectPassword is returned when the password given does not
+	// decrypt the keys of the wallet.
+	ErrIncorrectPassword = errors.New("incorrect password")
+	// ErrNoPassword is returned when the keys of the wallet are not
+	// encrypted yet.
+	ErrNoPassword = errors.New("no password set, set one with 'btfs wallet password'")
+)
+
+// ChangePassword re-encrypts the private key and the mnemonic of id,
+// encrypted with the password old, with the password new. id is only
+// updated when both are.
+func ChangePassword(id *config.Identity, old, new string) error {
+	if id.EncryptedPrivKey == "" {
+		return ErrNoPassword
+	}
+	if new == "" {
+		return errors.New("the new password cannot be empty")
+	}
+	privKey, err := DecryptWithAES(old, id.EncryptedPrivKey)
+	if err != nil || privKey != id.PrivKey {
+		return ErrIncorrectPassword
+	}
+	mnemonic := ""
+	if id.EncryptedMnemonic != "" {
+		// the mnemonic of a key imported is empty, and so encrypted
+		mnemonic, err = DecryptWithAES(old, id.EncryptedMnemonic)
+		if err != nil || mnemonic != id.Mnemonic {
+			return ErrIncorrectPassword
+		}
+	}
+	cipherPrivKey, err := EncryptWithAES(new, privKey)
+	if err != nil {
+		return err
+	}
+	cipherMnemonic, err := EncryptWithAES(new, mnemonic)
+	if err != nil {
+		return err
+	}
+	id.EncryptedPrivKey = cipherPrivKey
+	id.EncryptedMnemonic = cipherMnemonic
+	return nil
+}
+
+// Migrate re-encrypts the private key and the mnemonic of id, encrypted
+// with password, with the key derivation set with SetKDF when they were
+// encrypted with another, e.g. before the key derivations. It reports
+// whether id was updated.
+func Migrate(id *config.Identity, password string) (bool, error) {
+	c := KDF()
+	if id.EncryptedPrivKey == "" ||
+		(!c.Outdated(id.EncryptedPrivKey) && (id.EncryptedMnemonic == "" || !c.Outdated(id.EncryptedMnemonic))) {
+		return false, nil
+	}
+	if err := ChangePassword(id, password, password); err != nil {
+		return false, err
+	}
+	return true, nil
+}
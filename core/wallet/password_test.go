@@ -0,0 +1,89 @@
+package wallet
+
+import (
+	"testing"
+
+	config "github.com/TRON-US/go-btfs-config"
+)
+
+func encryptedIdentity(t *testing.T, password string) *config.Identity {
+	id := &config.Identity{
+		PrivKey:  "CAISILOZbORDZlczUlp5jdonb5y5SMZgaZy6OWp58SkS8jS8",
+		Mnemonic: "refuse ability giant glance coral nose fatigue draw wise grow apart pioneer",
+	}
+	var err error
+	if id.EncryptedPrivKey, err = EncryptWithAES(password, id.PrivKey); err != nil {
+		t.Fatal(err)
+	}
+	if id.EncryptedMnemonic, err = EncryptWithAES(password, id.Mnemonic); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestChangePassword(t *testing.T) {
+	id := encryptedIdentity(t, "old")
+	if err := ChangePassword(id, "wrong", "new"); err != ErrIncorrectPassword {
+		t.Fatalf("expected the password refused, got %v", err)
+	}
+	if err := ChangePassword(id, "old", ""); err == nil {
+		t.Fatal("expected an empty password refused")
+	}
+	if err := ChangePassword(id, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if privKey, err := DecryptWithAES("new", id.EncryptedPrivKey); err != nil || privKey != id.PrivKey {
+		t.Fatalf("expected the private key encrypted with the new password, got %v", err)
+	}
+	if mnemonic, err := DecryptWithAES("new", id.EncryptedMnemonic); err != nil || mnemonic != id.Mnemonic {
+		t.Fatalf("expected the mnemonic encrypted with the new password, got %v", err)
+	}
+	if err := ChangePassword(id, "old", "newer"); err != ErrIncorrectPassword {
+		t.Fatalf("expected the old password refused, got %v", err)
+	}
+
+	// a key imported has no mnemonic
+	id = encryptedIdentity(t, "old")
+	id.Mnemonic, id.EncryptedMnemonic = "", ""
+	if err := ChangePassword(id, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ChangePassword(&config.Identity{PrivKey: id.PrivKey}, "old", "new"); err != ErrNoPassword {
+		t.Fatalf("expected no password set, got %v", err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	defer SetKDF(KDF())
+	SetKDF(cheap[0])
+	id := &config.Identity{
+		PrivKey:  "CAISILOZbORDZlczUlp5jdonb5y5SMZgaZy6OWp58SkS8jS8",
+		Mnemonic: "refuse ability giant glance coral nose fatigue draw wise grow apart pioneer",
+	}
+	var err error
+	if id.EncryptedPrivKey, err = encryptLegacy("password", id.PrivKey); err != nil {
+		t.Fatal(err)
+	}
+	if id.EncryptedMnemonic, err = encryptLegacy("password", id.Mnemonic); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Migrate(id, "wrong"); err != ErrIncorrectPassword {
+		t.Fatalf("expected the wrong password refused, got %v", err)
+	}
+	if ok, err := Migrate(id, "password"); err != nil || !ok {
+		t.Fatalf("expected the keys migrated, got %v %v", ok, err)
+	}
+	if KDF().Outdated(id.EncryptedPrivKey) || KDF().Outdated(id.EncryptedMnemonic) {
+		t.Fatalf("expected the keys encrypted with argon2id, got %+v", id)
+	}
+	if ok, err := Migrate(id, "password"); err != nil || ok {
+		t.Fatalf("expected the keys migrated once, got %v %v", ok, err)
+	}
+	SetKDF(cheap[1])
+	if ok, err := Migrate(id, "password"); err != nil || !ok {
+		t.Fatalf("expected the keys migrated to scrypt, got %v %v", ok, err)
+	}
+	if mnemonic, err := DecryptWithAES("password", id.EncryptedMnemonic); err != nil || mnemonic != id.Mnemonic {
+		t.Fatalf("expected the mnemonic migrated, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+
+	config "github.com/TRON-US/go-btfs-config"
+	ledgerPb "github.com/tron-us/go-btfs-common/protos/ledger"
+
+	"github.com/google/uuid"
+	ds "github.com/ipfs/go-datastore"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// paywallTTL is how long the publisher accepts a paywall payment after it
+// was signed.
+var paywallTTL = 10 * time.Minute
+
+var walletPaywallNonceKey = "/btfs/%v/wallet/paywall/%v/%v"
+
+// PaywallIntent is the price of the access to the content under Path paid
+// by the payer to the publisher, through the ledger channel ChannelId.
+type PaywallIntent struct {
+	Payer     string
+	Publisher string
+	Path      string
+	Amount    int64
+	ChannelId int64
+	Nonce     string
+	Expiry    time.Time
+}
+
+// PaywallPayment is a paywall intent signed with the identity key of its
+// payer, with the channel state paying the publisher.
+type PaywallPayment struct {
+	Intent    []byte
+	Signature []byte
+	State     *ledgerPb.SignedChannelState
+}
+
+// ParseIntent returns the intent of p, unverified.
+func (p *PaywallPayment) ParseIntent() (*PaywallIntent, error) {
+	i := &PaywallIntent{}
+	if err := json.Unmarshal(p.Intent, i); err != nil {
+		return nil, fmt.Errorf("invalid paywall intent: %s", err)
+	}
+	return i, nil
+}
+
+// NewPaywallPayment commits amount from the ledger of the payer privKey to
+// publisher, and returns the payment of the access to the content under
+// path.
+func NewPaywallPayment(ctx context.Context, privKey ic.PrivKey, publisher peer.ID, path string,
+	amount int64, now time.Time) (*PaywallPayment, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	payer, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	channelId, payerAddr, publisherAddr, key, err := openChannel(ctx, privKey, publisher, amount, now)
+	if err != nil {
+		return nil, err
+	}
+	state, err := signedChannelState(channelId, payerAddr, publisherAddr, 0, amount, key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	intent, err := json.Marshal(&PaywallIntent{
+		Payer:     payer.Pretty(),
+		Publisher: publisher.Pretty(),
+		Path:      path,
+		Amount:    amount,
+		ChannelId: channelId.GetId(),
+		Nonce:     nonce.String(),
+		Expiry:    now.Add(paywallTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := privKey.Sign(intent)
+	if err != nil {
+		return nil, err
+	}
+	return &PaywallPayment{Intent: intent, Signature: sig, State: state}, nil
+}
+
+// AcceptPaywallPayment closes the channel of the payment p of the access to
+// the content under path, collecting price from the ledger of the payer to
+// the ledger of n. It returns the intent paid. Each intent is accepted once.
+func AcceptPaywallPayment(ctx context.Context, n *core.IpfsNode, cfg *config.Config, p *PaywallPayment,
+	path string, price int64, now time.Time) (*PaywallIntent, error) {
+	i, err := p.ParseIntent()
+	if err != nil {
+		return nil, err
+	}
+	payer, err := peer.Decode(i.Payer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payer: %s", err)
+	}
+	pubKey, err := payer.ExtractPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := pubKey.Verify(p.Intent, p.Signature); err != nil || !ok {
+		return nil, errors.New("invalid signature of the paywall intent")
+	}
+	switch {
+	case i.Publisher != n.Identity.Pretty():
+		return nil, errors.New("paywall intent of another publisher")
+	case i.Path != path:
+		return nil, fmt.Errorf("paywall intent of %s, not %s", i.Path, path)
+	case now.After(i.Expiry):
+		return nil, errors.New("paywall intent expired")
+	case i.Amount < price:
+		return nil, fmt.Errorf("amount under the %d µBTT price", price)
+	}
+
+	if err := Init(ctx, cfg); err != nil {
+		return nil, err
+	}
+	err = checkChannelState(p.State, i.ChannelId, pubKey, hostWallet.ledgerAddress, 0, i.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid channel state: %s", err)
+	}
+
+	d := n.Repo.Datastore()
+	key := ds.NewKey(fmt.Sprintf(walletPaywallNonceKey, n.Identity.Pretty(), i.Payer, i.Nonce))
+	if ok, err := d.Has(key); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, errors.New("paywall intent paid already")
+	}
+	if err := closeChannel(ctx, p.State); err != nil {
+		return nil, err
+	}
+	if err := d.Put(key, []byte(now.UTC().Format(time.RFC3339))); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
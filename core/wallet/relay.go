@@ -0,0 +1,434 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	config "github.com/TRON-US/go-btfs-config"
+	"github.com/tron-us/go-btfs-common/crypto"
+	ledgerPb "github.com/tron-us/go-btfs-common/protos/ledger"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	ds "github.com/ipfs/go-datastore"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// RelayConfigKey is the config section of the relayed transfers.
+const RelayConfigKey = "Relay"
+
+// relayTTL is how long the relayer accepts a relayed transfer after it
+// was signed.
+var relayTTL = 10 * time.Minute
+
+var walletRelayNonceKey = "/btfs/%v/wallet/relay/%v/%v"
+
+// RelayConfig configures the transfers relayed by a relayer, which pays the
+// fees of the transfers of nodes without BTT on the chain, for BTT of their
+// ledger.
+type RelayConfig struct {
+	// Enabled makes the node relay the transfers of the other nodes.
+	Enabled bool
+	// Fee is charged in µBTT for each transfer relayed by the node.
+	Fee int64
+	// MaxAmount bounds the amount of the transfers relayed by the node, in
+	// µBTT, no bound when 0.
+	MaxAmount int64 `json:",omitempty"`
+	// Relayer is the peer ID of the node relaying the transfers of this
+	// node.
+	Relayer string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(RelayConfigKey, RelayConfig{})
+}
+
+// LoadRelayConfig returns the relay config of r.
+func LoadRelayConfig(r repo.Repo) (*RelayConfig, error) {
+	c := &RelayConfig{}
+	if _, err := repo.GetConfigSection(r, RelayConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", RelayConfigKey, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", RelayConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *RelayConfig) validate() error {
+	if c.Fee < 0 || c.MaxAmount < 0 {
+		return errors.New("Fee and MaxAmount must not be negative")
+	}
+	if c.Relayer != "" {
+		if _, err := peer.Decode(c.Relayer); err != nil {
+			return fmt.Errorf("invalid Relayer: %s", err)
+		}
+	}
+	return nil
+}
+
+// RelayTerms are the conditions of the transfers relayed by a relayer.
+type RelayTerms struct {
+	Relayer   string
+	Fee       int64
+	MaxAmount int64
+}
+
+// Terms returns the terms of the transfers relayed by the node relayer.
+func (c *RelayConfig) Terms(relayer string) (*RelayTerms, error) {
+	if !c.Enabled {
+		return nil, errors.New("this node does not relay transfers")
+	}
+	return &RelayTerms{Relayer: relayer, Fee: c.Fee, MaxAmount: c.MaxAmount}, nil
+}
+
+// RelayIntent is a transfer the payer asks the relayer to make in its
+// place, paid through the ledger channel ChannelId.
+type RelayIntent struct {
+	Payer     string
+	Relayer   string
+	To        string
+	Amount    int64
+	Fee       int64
+	ChannelId int64
+	Nonce     string
+	Expiry    time.Time
+}
+
+// RelayRequest is a relay intent signed with the identity key of its payer,
+// with the channel states paying the relayer the amount and the fee, and
+// releasing the commit of the payer when they cannot be collected.
+type RelayRequest struct {
+	Intent    []byte
+	Signature []byte
+	Success   *ledgerPb.SignedChannelState
+	Fail      *ledgerPb.SignedChannelState
+}
+
+// RelayResult is a transfer made by a relayer.
+type RelayResult struct {
+	TxId   string
+	Amount int64
+	Fee    int64
+}
+
+// NewRelayRequest commits amount and fee from the ledger of the payer privKey
+// to relayer, and returns the request for relayer to transfer amount to the
+// address to.
+func NewRelayRequest(ctx context.Context, privKey ic.PrivKey, relayer peer.ID, to string, amount,
+	fee int64, now time.Time) (*RelayRequest, error) {
+	if _, err := toHex(to); err != nil {
+		return nil, fmt.Errorf("invalid address %s: %s", to, err)
+	}
+	if amount <= 0 || fee < 0 {
+		return nil, errors.New("amount must be positive and fee not negative")
+	}
+	payer, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	channelId, payerAddr, relayerAddr, key, err := openChannel(ctx, privKey, relayer, amount+fee, now)
+	if err != nil {
+		return nil, err
+	}
+	success, err := signedChannelState(channelId, payerAddr, relayerAddr, 0, amount+fee, key)
+	if err != nil {
+		return nil, err
+	}
+	fail, err := signedChannelState(channelId, payerAddr, relayerAddr, amount+fee, 0, key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	intent, err := json.Marshal(&RelayIntent{
+		Payer:     payer.Pretty(),
+		Relayer:   relayer.Pretty(),
+		To:        to,
+		Amount:    amount,
+		Fee:       fee,
+		ChannelId: channelId.GetId(),
+		Nonce:     nonce.String(),
+		Expiry:    now.Add(relayTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := privKey.Sign(intent)
+	if err != nil {
+		return nil, err
+	}
+	return &RelayRequest{Intent: intent, Signature: sig, Success: success, Fail: fail}, nil
+}
+
+// RelayTransfer has relayer transfer amount to the address to for the
+// ledger of n, for fee, with relay sending the request to relayer. The
+// relayer refunds the ledger when its transfer fails.
+func RelayTransfer(ctx context.Context, n *core.IpfsNode, cfg *config.Config, relayer peer.ID, to string,
+	amount, fee int64, relay func(*RelayRequest) (*RelayResult, error)) (*RelayResult, error) {
+	privKey, err := crypto.ToPrivKey(cfg.Identity.PrivKey)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewRelayRequest(ctx, privKey, relayer, to, amount, fee, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	res, err := relay(r)
+	if err != nil {
+		return nil, err
+	}
+	err = PersistTx(n.Repo.Datastore(), n.Identity.Pretty(), res.TxId, amount,
+		InAppWallet, to, StatusPending, walletpb.TransactionV1_ON_CHAIN)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// AcceptRelay checks the channel of the request r of the payer from on the
+// ledger, closes it to collect the amount and the fee, then makes the
+// transfer on the chain with the wallet of n, refunding the payer when the
+// transfer fails. Each intent is relayed once.
+func AcceptRelay(ctx context.Context, n *core.IpfsNode, cfg *config.Config, c *RelayConfig, from peer.ID,
+	r *RelayRequest, now time.Time) (*RelayResult, error) {
+	if !c.Enabled {
+		return nil, errors.New("this node does not relay transfers")
+	}
+	pubKey, err := from.ExtractPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := pubKey.Verify(r.Intent, r.Signature); err != nil || !ok {
+		return nil, errors.New("invalid signature of the relay intent")
+	}
+	i := &RelayIntent{}
+	if err := json.Unmarshal(r.Intent, i); err != nil {
+		return nil, fmt.Errorf("invalid relay intent: %s", err)
+	}
+	switch {
+	case i.Payer != from.Pretty() || i.Relayer != n.Identity.Pretty():
+		return nil, errors.New("relay intent of another node")
+	case now.After(i.Expiry):
+		return nil, errors.New("relay intent expired")
+	case i.Amount <= 0:
+		return nil, errors.New("invalid amount of the relay intent")
+	case c.MaxAmount > 0 && i.Amount > c.MaxAmount:
+		return nil, fmt.Errorf("amount over the %d µBTT relayed at most", c.MaxAmount)
+	case i.Fee < c.Fee:
+		return nil, fmt.Errorf("fee under the %d µBTT charged", c.Fee)
+	}
+
+	if err := Init(ctx, cfg); err != nil {
+		return nil, err
+	}
+	payerAddr, err := ic.RawFull(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	total := i.Amount + i.Fee
+	err = checkChannelState(r.Success, i.ChannelId, pubKey, hostWallet.ledgerAddress, 0, total)
+	if err != nil {
+		return nil, fmt.Errorf("invalid success state: %s", err)
+	}
+	err = checkChannelState(r.Fail, i.ChannelId, pubKey, hostWallet.ledgerAddress, total, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fail state: %s", err)
+	}
+	if err := checkChannel(ctx, i.ChannelId, payerAddr, hostWallet.ledgerAddress, total); err != nil {
+		return nil, err
+	}
+
+	d := n.Repo.Datastore()
+	key := ds.NewKey(fmt.Sprintf(walletRelayNonceKey, n.Identity.Pretty(), i.Payer, i.Nonce))
+	if ok, err := d.Has(key); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, errors.New("relay intent relayed already")
+	}
+	if err := d.Put(key, []byte(now.UTC().Format(time.RFC3339))); err != nil {
+		return nil, err
+	}
+
+	// the amount and the fee are collected before anything is sent
+	if err := closeChannel(ctx, r.Success); err != nil {
+		if cerr := closeChannel(ctx, r.Fail); cerr != nil {
+			log.Errorf("release relay channel %d: %s", i.ChannelId, cerr)
+		}
+		return nil, fmt.Errorf("collect relay channel %d: %s", i.ChannelId, err)
+	}
+	ret, err := TransferBTT(ctx, n, cfg, nil, "", i.To, i.Amount)
+	if err != nil {
+		if rerr := refundRelay(ctx, cfg, from, total, now); rerr != nil {
+			log.Errorf("refund %d µBTT of relay channel %d to %s: %s", total, i.ChannelId, from, rerr)
+			return nil, fmt.Errorf("%s, and refunding the %d µBTT collected failed: %s", err, total, rerr)
+		}
+		return nil, err
+	}
+	return &RelayResult{TxId: ret.TxId, Amount: i.Amount, Fee: i.Fee}, nil
+}
+
+// refundRelay pays amount back from the ledger of the relayer to the ledger
+// of payer, through a channel closed at once.
+func refundRelay(ctx context.Context, cfg *config.Config, payer peer.ID, amount int64, now time.Time) error {
+	privKey, err := crypto.ToPrivKey(cfg.Identity.PrivKey)
+	if err != nil {
+		return err
+	}
+	channelId, relayerAddr, payerAddr, key, err := openChannel(ctx, privKey, payer, amount, now)
+	if err != nil {
+		return err
+	}
+	s, err := signedChannelState(channelId, relayerAddr, payerAddr, 0, amount, key)
+	if err != nil {
+		return err
+	}
+	_, err = chainBackend(initServices()).CloseChannel(ctx, s)
+	return err
+}
+
+// openChannel commits amount from the ledger of the payer privKey to the
+// node recipient. It returns the channel, the ledger addresses of the payer
+// and of the recipient, and the key signing the states of the channel.
+func openChannel(ctx context.Context, privKey ic.PrivKey, recipient peer.ID, amount int64,
+	now time.Time) (*ledgerPb.ChannelID, []byte, []byte, *ecdsa.PrivateKey, error) {
+	payerAddr, err := ic.RawFull(privKey.GetPublic())
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	recipientAddr, err := ledgerAddress(recipient)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	key, err := toECDSA(privKey)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	commit := &ledgerPb.ChannelCommit{
+		Payer:     &ledgerPb.PublicKey{Key: payerAddr},
+		Recipient: &ledgerPb.PublicKey{Key: recipientAddr},
+		Amount:    amount,
+		PayerId:   now.UnixNano(),
+	}
+	signature, err := Sign(commit, key)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	channelId, err := chainBackend(initServices()).CreateChannel(ctx,
+		&ledgerPb.SignedChannelCommit{Channel: commit, Signature: signature})
+	if err != nil {
+		if err.Error() == ErrInsufficientUserBalanceOnLedger.Error() {
+			return nil, nil, nil, nil, ErrInsufficientUserBalanceOnLedger
+		}
+		return nil, nil, nil, nil, err
+	}
+	return channelId, payerAddr, recipientAddr, key, nil
+}
+
+// closeChannel countersigns s with the wallet of the node and closes
+// its channel.
+func closeChannel(ctx context.Context, s *ledgerPb.SignedChannelState) error {
+	sig, err := Sign(s.Channel, hostWallet.privateKey)
+	if err != nil {
+		return err
+	}
+	s.ToSignature = sig
+	_, err = chainBackend(initServices()).CloseChannel(ctx, s)
+	return err
+}
+
+func signedChannelState(id *ledgerPb.ChannelID, from, to []byte, fromBalance, toBalance int64,
+	key *ecdsa.PrivateKey) (*ledgerPb.SignedChannelState, error) {
+	s := &ledgerPb.ChannelState{
+		Id:       id,
+		Sequence: 1,
+		From:     &ledgerPb.Account{Address: &ledgerPb.PublicKey{Key: from}, Balance: fromBalance},
+		To:       &ledgerPb.Account{Address: &ledgerPb.PublicKey{Key: to}, Balance: toBalance},
+	}
+	sig, err := Sign(s, key)
+	if err != nil {
+		return nil, err
+	}
+	return &ledgerPb.SignedChannelState{Channel: s, FromSignature: sig}, nil
+}
+
+// checkChannelState checks s is the state of the channel id signed by the
+// payer, paying fromBalance to the payer and toBalance to the account to.
+func checkChannelState(s *ledgerPb.SignedChannelState, id int64, payer ic.PubKey, to []byte, fromBalance,
+	toBalance int64) error {
+	from, err := ic.RawFull(payer)
+	if err != nil {
+		return err
+	}
+	c := s.GetChannel()
+	switch {
+	case c.GetId().GetId() != id:
+		return errors.New("channel of another intent")
+	case string(c.GetFrom().GetAddress().GetKey()) != string(from) ||
+		string(c.GetTo().GetAddress().GetKey()) != string(to):
+		return errors.New("channel between other accounts")
+	case c.GetFrom().GetBalance() != fromBalance || c.GetTo().GetBalance() != toBalance:
+		return errors.New("balances do not match the intent")
+	}
+	raw, err := proto.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if ok, err := payer.Verify(raw, s.GetFromSignature()); err != nil || !ok {
+		return errors.New("not signed by the payer")
+	}
+	return nil
+}
+
+// checkChannel checks the channel id is open on the ledger, from the account
+// from to the account to, and holds amount at least.
+func checkChannel(ctx context.Context, id int64, from, to []byte, amount int64) error {
+	info, err := chainBackend(initServices()).ChannelInfo(ctx, &ledgerPb.ChannelID{Id: id})
+	if err != nil {
+		return fmt.Errorf("channel %d not found on the ledger: %s", id, err)
+	}
+	switch {
+	case !bytes.Equal(info.GetFromAccount().GetAddress().GetKey(), from) ||
+		!bytes.Equal(info.GetToAccount().GetAddress().GetKey(), to):
+		return fmt.Errorf("channel %d between other accounts on the ledger", id)
+	case info.GetCloseSequence() != 0:
+		return fmt.Errorf("channel %d closed already", id)
+	case info.GetFromAccount().GetBalance() < amount:
+		return fmt.Errorf("channel %d holds %d µBTT, not the %d µBTT of the intent", id,
+			info.GetFromAccount().GetBalance(), amount)
+	}
+	return nil
+}
+
+// ledgerAddress returns the ledger address of the node p.
+func ledgerAddress(p peer.ID) ([]byte, error) {
+	pubKey, err := p.ExtractPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return ic.RawFull(pubKey)
+}
+
+func toECDSA(privKey ic.PrivKey) (*ecdsa.PrivateKey, error) {
+	raw, err := privKey.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.HexToECDSA(hex.EncodeToString(raw))
+}
@@ -0,0 +1,300 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	coremock "github.com/TRON-US/go-btfs/core/mock"
+	"github.com/TRON-US/go-btfs/core/wallet/wallettest"
+
+	config "github.com/TRON-US/go-btfs-config"
+	ledgerPb "github.com/tron-us/go-btfs-common/protos/ledger"
+
+	"github.com/google/uuid"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRelayer returns a relayer on a Fake, the mock node taking the identity
+// of the wallet.
+func newRelayer(t *testing.T) (*core.IpfsNode, *config.Config, *wallettest.Fake) {
+	node, cfg, f := newFakeChain(t)
+	privKey, err := cfg.Identity.DecodePrivateKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Identity = id
+	return node, cfg, f
+}
+
+// newPayer returns a node with a new key and ledgerBalance on f.
+func newPayer(t *testing.T, f *wallettest.Fake, ledgerBalance int64) (*core.IpfsNode, *config.Config, ic.PrivKey) {
+	node, err := coremock.NewMockNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := node.Repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey, _, err := ic.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ic.MarshalPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Identity.PrivKey = base64.StdEncoding.EncodeToString(b)
+	addr, err := ic.RawFull(privKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetLedgerBalance(addr, ledgerBalance)
+	return node, cfg, privKey
+}
+
+func TestRelayTransfer(t *testing.T) {
+	relayer, cfg, f := newRelayer(t)
+	f.Confirmations = 1
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	rc := &RelayConfig{Enabled: true, Fee: 2}
+	payer, payerCfg, privKey := newPayer(t, f, 50)
+	payerId, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payerAddr, err := ic.RawFull(privKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := "416E2FFC26BDF48B1983CCC9EC2521867F98667760"
+	toAddr, err := hex.DecodeString(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []byte
+	res, err := RelayTransfer(context.Background(), payer, payerCfg, relayer.Identity, to, 20, 2,
+		func(r *RelayRequest) (*RelayResult, error) {
+			// the request crosses the network as JSON
+			if sent, err = json.Marshal(r); err != nil {
+				return nil, err
+			}
+			received := &RelayRequest{}
+			if err := json.Unmarshal(sent, received); err != nil {
+				return nil, err
+			}
+			return AcceptRelay(context.Background(), relayer, cfg, rc, payerId, received, time.Now())
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(20), f.TronBalance(toAddr))
+	assert.Equal(t, int64(80), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, int64(28), f.LedgerBalance(payerAddr))
+	assert.Equal(t, int64(22), f.LedgerBalance(hostWallet.ledgerAddress))
+	assert.Equal(t, StatusPending, txStatus(t, payer, res.TxId))
+
+	// an intent is relayed once
+	r := &RelayRequest{}
+	if err := json.Unmarshal(sent, r); err != nil {
+		t.Fatal(err)
+	}
+	_, err = AcceptRelay(context.Background(), relayer, cfg, rc, payerId, r, time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, int64(20), f.TronBalance(toAddr))
+
+	// the transfer is checked once after transferConfirmDelay
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, StatusPending, txStatus(t, relayer, res.TxId))
+}
+
+func TestRelayTransferFailed(t *testing.T) {
+	relayer, cfg, f := newRelayer(t)
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	f.FailNext("TransferAsset2", wallettest.ErrUnavailable)
+	_, _, privKey := newPayer(t, f, 50)
+	payerId, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payerAddr, err := ic.RawFull(privKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRelayRequest(context.Background(), privKey, relayer.Identity,
+		"416E2FFC26BDF48B1983CCC9EC2521867F98667760", 20, 2, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(28), f.LedgerBalance(payerAddr))
+	_, err = AcceptRelay(context.Background(), relayer, cfg, &RelayConfig{Enabled: true}, payerId, r, time.Now())
+	assert.Error(t, err)
+	// the payer is refunded
+	assert.Equal(t, int64(50), f.LedgerBalance(payerAddr))
+	assert.Equal(t, int64(0), f.LedgerBalance(hostWallet.ledgerAddress))
+	assert.Equal(t, int64(100), f.TronBalance(hostWallet.tronAddress))
+}
+
+func TestAcceptRelayRejected(t *testing.T) {
+	relayer, cfg, f := newRelayer(t)
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	_, _, privKey := newPayer(t, f, 50)
+	payerId, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRelayRequest(context.Background(), privKey, relayer.Identity,
+		"416E2FFC26BDF48B1983CCC9EC2521867F98667760", 20, 2, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		rc   *RelayConfig
+		from peer.ID
+		now  time.Time
+	}{
+		{"disabled", &RelayConfig{}, payerId, time.Now()},
+		{"fee", &RelayConfig{Enabled: true, Fee: 3}, payerId, time.Now()},
+		{"max amount", &RelayConfig{Enabled: true, MaxAmount: 10}, payerId, time.Now()},
+		{"expired", &RelayConfig{Enabled: true}, payerId, time.Now().Add(relayTTL + time.Minute)},
+		{"signer", &RelayConfig{Enabled: true}, relayer.Identity, time.Now()},
+	} {
+		_, err := AcceptRelay(context.Background(), relayer, cfg, tc.rc, tc.from, r, tc.now)
+		assert.Error(t, err, tc.name)
+	}
+	assert.Equal(t, int64(100), f.TronBalance(hostWallet.tronAddress))
+
+	rc := &RelayConfig{Fee: -1}
+	assert.Error(t, rc.validate())
+	rc = &RelayConfig{Relayer: "relayer"}
+	assert.Error(t, rc.validate())
+}
+
+// forgedRelayRequest returns the request of the intent i signed by privKey,
+// paid by the states of the channel i.ChannelId signed by key.
+func forgedRelayRequest(t *testing.T, privKey ic.PrivKey, key *ecdsa.PrivateKey, i *RelayIntent) *RelayRequest {
+	payerAddr, err := ic.RawFull(privKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := &ledgerPb.ChannelID{Id: i.ChannelId}
+	total := i.Amount + i.Fee
+	success, err := signedChannelState(id, payerAddr, hostWallet.ledgerAddress, 0, total, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fail, err := signedChannelState(id, payerAddr, hostWallet.ledgerAddress, total, 0, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intent, err := json.Marshal(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := privKey.Sign(intent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &RelayRequest{Intent: intent, Signature: sig, Success: success, Fail: fail}
+}
+
+func TestAcceptRelayForged(t *testing.T) {
+	relayer, cfg, f := newRelayer(t)
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	rc := &RelayConfig{Enabled: true}
+	_, _, privKey := newPayer(t, f, 50)
+	payerId, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := toECDSA(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a channel of 22 µBTT to the relayer
+	r, err := NewRelayRequest(context.Background(), privKey, relayer.Identity,
+		"416E2FFC26BDF48B1983CCC9EC2521867F98667760", 20, 2, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	channelId := r.Success.GetChannel().GetId().GetId()
+	intent := func(channelId, amount int64) *RelayIntent {
+		nonce, err := uuid.NewRandom()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &RelayIntent{
+			Payer:     payerId.Pretty(),
+			Relayer:   relayer.Identity.Pretty(),
+			To:        "416E2FFC26BDF48B1983CCC9EC2521867F98667760",
+			Amount:    amount,
+			ChannelId: channelId,
+			Nonce:     nonce.String(),
+			Expiry:    time.Now().Add(relayTTL),
+		}
+	}
+
+	junk := forgedRelayRequest(t, privKey, key, intent(channelId, 20))
+	junk.Success.FromSignature = []byte("junk")
+	for _, tc := range []struct {
+		name string
+		r    *RelayRequest
+	}{
+		{"no channel", forgedRelayRequest(t, privKey, key, intent(channelId+100, 20))},
+		{"over the channel", forgedRelayRequest(t, privKey, key, intent(channelId, 40))},
+		{"signer of the states", forgedRelayRequest(t, privKey, otherKey, intent(channelId, 20))},
+		{"junk signature", junk},
+	} {
+		_, err := AcceptRelay(context.Background(), relayer, cfg, rc, payerId, tc.r, time.Now())
+		assert.Error(t, err, tc.name)
+	}
+	assert.Equal(t, int64(100), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, int64(0), f.LedgerBalance(hostWallet.ledgerAddress))
+}
+
+func TestAcceptRelayCollectFailed(t *testing.T) {
+	relayer, cfg, f := newRelayer(t)
+	f.SetTronBalance(hostWallet.tronAddress, 100)
+	_, _, privKey := newPayer(t, f, 50)
+	payerId, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payerAddr, err := ic.RawFull(privKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRelayRequest(context.Background(), privKey, relayer.Identity,
+		"416E2FFC26BDF48B1983CCC9EC2521867F98667760", 20, 2, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.FailNext("CloseChannel", wallettest.ErrUnavailable)
+	_, err = AcceptRelay(context.Background(), relayer, cfg, &RelayConfig{Enabled: true}, payerId, r, time.Now())
+	assert.Error(t, err)
+	// nothing is sent, and the commit of the payer is released
+	assert.Equal(t, int64(100), f.TronBalance(hostWallet.tronAddress))
+	assert.Equal(t, int64(50), f.LedgerBalance(payerAddr))
+}
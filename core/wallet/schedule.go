@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// States of a scheduled transfer.
+const (
+	ScheduleActive    = "active"
+	ScheduleDone      = "done"
+	ScheduleFailed    = "failed"
+	ScheduleCancelled = "cancelled"
+)
+
+// Periods of the recurring transfers, besides the durations.
+const (
+	Daily  = 24 * time.Hour
+	Weekly = 7 * Daily
+)
+
+// MinSchedulePeriod bounds how often a transfer recurs.
+const MinSchedulePeriod = time.Hour
+
+var walletScheduleKeyPrefix = "/btfs/%v/wallet/schedules/"
+
+// Schedule is a transfer to execute at a time, then every Period when
+// recurring.
+type Schedule struct {
+	ID        string
+	To        string
+	Amount    int64
+	ExecuteAt time.Time
+	// Period is zero for a one-time transfer.
+	Period time.Duration `json:",omitempty"`
+	// Times bounds the transfers of a recurring transfer, 0 when it
+	// recurs until cancelled.
+	Times     int `json:",omitempty"`
+	State     string
+	Created   time.Time
+	Runs      int
+	LastRun   time.Time `json:",omitempty"`
+	LastTxId  string    `json:",omitempty"`
+	LastError string    `json:",omitempty"`
+}
+
+// ParsePeriod parses the period of a recurring transfer, 'daily',
+// 'weekly' or a duration.
+func ParsePeriod(s string) (time.Duration, error) {
+	switch strings.ToLower(s) {
+	case "daily":
+		return Daily, nil
+	case "weekly":
+		return Weekly, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid period %q, expected daily, weekly or a duration", s)
+	}
+	if d < MinSchedulePeriod {
+		return 0, fmt.Errorf("period %s below %s", d, MinSchedulePeriod)
+	}
+	return d, nil
+}
+
+// ParseExecuteAt parses the time of a scheduled transfer, in RFC 3339 or
+// as '2006-01-02T15:04' in the local time zone.
+func ParseExecuteAt(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02T15:04", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected 2006-01-02T15:04 or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// AddSchedule records the scheduled transfer s of peerId, created at now.
+func AddSchedule(d ds.Datastore, peerId string, s *Schedule, now time.Time) error {
+	if s.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if !s.ExecuteAt.After(now) {
+		return fmt.Errorf("execution time %s is not in the future", s.ExecuteAt.Format(time.RFC3339))
+	}
+	if s.Times < 0 || s.Period == 0 && s.Times > 1 {
+		return errors.New("invalid number of transfers")
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return err
+	}
+	s.ID = id.String()
+	s.State = ScheduleActive
+	s.Created = now
+	return putSchedule(d, peerId, s)
+}
+
+func putSchedule(d ds.Datastore, peerId string, s *Schedule) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(walletScheduleKeyPrefix, peerId)+s.ID), b)
+}
+
+// ListSchedules returns the scheduled transfers of peerId, the next to
+// execute first.
+func ListSchedules(d ds.Datastore, peerId string) ([]*Schedule, error) {
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(walletScheduleKeyPrefix, peerId)})
+	if err != nil {
+		return nil, err
+	}
+	ss := make([]*Schedule, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		s := &Schedule{}
+		if err := json.Unmarshal(r.Value, s); err != nil {
+			return nil, fmt.Errorf("invalid scheduled transfer %s: %s", r.Key, err)
+		}
+		ss = append(ss, s)
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].ExecuteAt.Before(ss[j].ExecuteAt)
+	})
+	return ss, nil
+}
+
+// CancelSchedule cancels the active scheduled transfer id of peerId.
+func CancelSchedule(d ds.Datastore, peerId, id string) (*Schedule, error) {
+	b, err := d.Get(ds.NewKey(fmt.Sprintf(walletScheduleKeyPrefix, peerId) + id))
+	if err == ds.ErrNotFound {
+		return nil, fmt.Errorf("no scheduled transfer %s", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &Schedule{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if s.State != ScheduleActive {
+		return nil, fmt.Errorf("scheduled transfer %s is %s", id, s.State)
+	}
+	s.State = ScheduleCancelled
+	return s, putSchedule(d, peerId, s)
+}
+
+// RunSchedules executes with transfer the active scheduled transfers of
+// peerId due at now. A recurring transfer then moves to its next time
+// after now, the times missed while the node was down are skipped, and
+// goes on after a failure; a one-time transfer is not retried.
+func RunSchedules(d ds.Datastore, peerId string, now time.Time,
+	transfer func(to string, amount int64) (string, error)) (int, error) {
+	ss, err := ListSchedules(d, peerId)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, s := range ss {
+		if s.State != ScheduleActive || s.ExecuteAt.After(now) {
+			continue
+		}
+		txId, err := transfer(s.To, s.Amount)
+		s.Runs++
+		s.LastRun = now
+		s.LastTxId = txId
+		s.LastError = ""
+		if err != nil {
+			s.LastError = err.Error()
+		} else {
+			n++
+		}
+		switch {
+		case s.Period > 0 && (s.Times == 0 || s.Runs < s.Times):
+			for !s.ExecuteAt.After(now) {
+				s.ExecuteAt = s.ExecuteAt.Add(s.Period)
+			}
+		case err != nil:
+			s.State = ScheduleFailed
+		default:
+			s.State = ScheduleDone
+		}
+		if err := putSchedule(d, peerId, s); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
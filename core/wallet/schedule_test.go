@@ -0,0 +1,75 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSchedules(t *testing.T) {
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	now := time.Now()
+	once := &Schedule{To: "once", Amount: 1, ExecuteAt: now.Add(time.Hour)}
+	weekly := &Schedule{To: "weekly", Amount: 2, ExecuteAt: now.Add(time.Hour), Period: Weekly, Times: 2}
+	for _, s := range []*Schedule{once, weekly} {
+		assert.NoError(t, AddSchedule(d, "peer", s, now))
+	}
+	assert.Error(t, AddSchedule(d, "peer", &Schedule{To: "past", Amount: 1, ExecuteAt: now}, now))
+
+	var sent []string
+	transfer := func(to string, amount int64) (string, error) {
+		sent = append(sent, to)
+		return "tx-" + to, nil
+	}
+	n, err := RunSchedules(d, "peer", now, transfer)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// the weekly transfer was missed for two weeks, it runs once
+	n, err = RunSchedules(d, "peer", now.Add(15*Daily), transfer)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.ElementsMatch(t, []string{"once", "weekly"}, sent)
+	ss, err := ListSchedules(d, "peer")
+	assert.NoError(t, err)
+	byTo := map[string]*Schedule{}
+	for _, s := range ss {
+		byTo[s.To] = s
+	}
+	assert.Equal(t, ScheduleDone, byTo["once"].State)
+	assert.Equal(t, ScheduleActive, byTo["weekly"].State)
+	assert.True(t, now.Add(time.Hour+3*Weekly).Equal(byTo["weekly"].ExecuteAt))
+
+	// the last transfer fails, the schedule ends as failed
+	_, err = RunSchedules(d, "peer", now.Add(22*Daily), func(to string, amount int64) (string, error) {
+		return "", errors.New("no balance")
+	})
+	assert.NoError(t, err)
+	ss, err = ListSchedules(d, "peer")
+	assert.NoError(t, err)
+	for _, s := range ss {
+		if s.To == "weekly" {
+			assert.Equal(t, ScheduleFailed, s.State)
+			assert.Equal(t, 2, s.Runs)
+			assert.Equal(t, "no balance", s.LastError)
+		}
+	}
+
+	_, err = CancelSchedule(d, "peer", weekly.ID)
+	assert.Error(t, err)
+}
+
+func TestParsePeriod(t *testing.T) {
+	p, err := ParsePeriod("weekly")
+	assert.NoError(t, err)
+	assert.Equal(t, Weekly, p)
+	p, err = ParsePeriod("720h")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*Daily, p)
+	_, err = ParsePeriod("1m")
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package wallet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Bounds of the time the wallet stays unlocked.
+const (
+	DefaultUnlockTTL = 15 * time.Minute
+	MaxUnlockTTL     = 24 * time.Hour
+)
+
+// Session keeps the private key of the wallet decrypted in the memory of
+// the daemon for a while, so that the commands spending from the wallet run
+// without its password. The session is bound to the caller which unlocked
+// it: the commands of the other callers still require the password.
+type Session struct {
+	mu      sync.Mutex
+	caller  string
+	privKey string
+	expires time.Time
+}
+
+// DefaultSession is the session of the wallet of the daemon.
+var DefaultSession = &Session{}
+
+// Unlock keeps privKey, decrypted with the password of the wallet, for ttl
+// from now on behalf of caller, and returns the time the session expires.
+// It replaces the session of any other caller.
+func (s *Session) Unlock(caller, privKey string, ttl time.Duration, now time.Time) (time.Time, error) {
+	if ttl <= 0 || ttl > MaxUnlockTTL {
+		return time.Time{}, fmt.Errorf("the wallet is unlocked for at most %s", MaxUnlockTTL)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.caller, s.privKey, s.expires = caller, privKey, now.Add(ttl)
+	return s.expires, nil
+}
+
+// Lock forgets the private key, and reports whether the wallet was
+// unlocked.
+func (s *Session) Lock(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlocked := s.unlocked(now)
+	s.caller, s.privKey, s.expires = "", "", time.Time{}
+	return unlocked
+}
+
+// Unlocked reports whether the wallet of privKey is unlocked for caller at
+// now, and until when.
+func (s *Session) Unlocked(caller, privKey string, now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.unlocked(now) || s.caller != caller || s.privKey != privKey {
+		return time.Time{}, false
+	}
+	return s.expires, true
+}
+
+// unlocked forgets the private key once expired. s.mu is held.
+func (s *Session) unlocked(now time.Time) bool {
+	if s.privKey == "" {
+		return false
+	}
+	if !now.Before(s.expires) {
+		s.caller, s.privKey, s.expires = "", "", time.Time{}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	s := &Session{}
+	now := time.Now()
+	if _, ok := s.Unlocked("alice", "key", now); ok {
+		t.Fatal("expected a new session locked")
+	}
+	if _, err := s.Unlock("alice", "key", 25*time.Hour, now); err == nil {
+		t.Fatal("expected a ttl past the bound refused")
+	}
+	expires, err := s.Unlock("alice", "key", DefaultUnlockTTL, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until, ok := s.Unlocked("alice", "key", now.Add(time.Minute)); !ok || !until.Equal(expires) {
+		t.Fatalf("expected the wallet unlocked until %s, got %s %v", expires, until, ok)
+	}
+	// another caller of the API
+	if _, ok := s.Unlocked("bob", "key", now); ok {
+		t.Fatal("expected the session locked for another caller")
+	}
+	if _, ok := s.Unlocked("", "key", now); ok {
+		t.Fatal("expected the session locked for the node")
+	}
+	// another key imported since
+	if _, ok := s.Unlocked("alice", "other", now); ok {
+		t.Fatal("expected the session of another key locked")
+	}
+	if _, ok := s.Unlocked("alice", "key", expires); ok {
+		t.Fatal("expected the session expired")
+	}
+	if s.Lock(now) {
+		t.Fatal("expected the session forgotten once expired")
+	}
+
+	s.Unlock("alice", "key", time.Hour, now)
+	if !s.Lock(now) {
+		t.Fatal("expected the session locked")
+	}
+	if _, ok := s.Unlocked("alice", "key", now); ok {
+		t.Fatal("expected the wallet locked")
+	}
+}
@@ -31,6 +31,11 @@ var (
 //Sign a Transaction, ChannelState, ChannelCommit in exchange proto or tron proto or ledger proto.
 //parameter 'in' can be Transaction, ChannelState, ChannelCommit, return signature.
 func Sign(in interface{}, key *ecdsa.PrivateKey) ([]byte, error) {
+	return SignWith(in, KeySigner(key))
+}
+
+// SignWith signs in like Sign, with signer.
+func SignWith(in interface{}, signer Signer) ([]byte, error) {
 	switch in.(type) {
 	case *exPb.TronTransaction:
 		transaction := in.(*exPb.TronTransaction)
@@ -46,7 +51,7 @@ func Sign(in interface{}, key *ecdsa.PrivateKey) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		return SignTron(rawData, key)
+		return signer.SignTron(rawData)
 
 	case *corePb.Transaction:
 		transaction := in.(*corePb.Transaction)
@@ -62,7 +67,7 @@ func Sign(in interface{}, key *ecdsa.PrivateKey) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		return SignTron(rawData, key)
+		return signer.SignTron(rawData)
 
 	case *ledgerPb.ChannelState:
 		channelState := in.(*ledgerPb.ChannelState)
@@ -74,7 +79,7 @@ func Sign(in interface{}, key *ecdsa.PrivateKey) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		return SignChannel(raw, key)
+		return signer.SignChannel(raw)
 
 	case *ledgerPb.ChannelCommit:
 		channelCommit := in.(*ledgerPb.ChannelCommit)
@@ -86,7 +91,7 @@ func Sign(in interface{}, key *ecdsa.PrivateKey) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		return SignChannel(raw, key)
+		return signer.SignChannel(raw)
 
 	default:
 		return nil, ErrTypeParam
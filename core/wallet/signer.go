@@ -0,0 +1,108 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tronPb "github.com/tron-us/go-btfs-common/protos/protocol/api"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Retries of the transactions rejected for their reference block.
+var (
+	// txRetries bounds the attempts to broadcast a transaction after the
+	// first.
+	txRetries = 3
+	// txRetryInterval is the wait before an attempt, doubled after each.
+	txRetryInterval = time.Second
+)
+
+func init() {
+	prometheus.MustRegister(txRetried, txRetryFailures)
+}
+
+var (
+	txRetried = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "wallet",
+		Name:      "tx_retries_total",
+		Help:      "TRON transactions prepared again on a new reference block, by broadcast error.",
+	}, []string{"code"})
+	txRetryFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "wallet",
+		Name:      "tx_retry_failures_total",
+		Help:      "TRON transactions still rejected after all their retries.",
+	})
+)
+
+// BroadcastError is a transaction rejected by the TRON node.
+type BroadcastError struct {
+	Code    tronPb.ReturnResponseCode
+	Message string
+}
+
+func (e *BroadcastError) Error() string {
+	return fmt.Sprintf("broadcast transaction: %s: %s", e.Code, e.Message)
+}
+
+// Retryable reports whether the transaction was rejected for its reference
+// block or expiration, or while the node was busy, and can be prepared and
+// signed again.
+func (e *BroadcastError) Retryable() bool {
+	switch e.Code {
+	case tronPb.Return_TAPOS_ERROR, tronPb.Return_TRANSACTION_EXPIRATION_ERROR, tronPb.Return_SERVER_BUSY:
+		return true
+	}
+	return false
+}
+
+var (
+	signersLk sync.Mutex
+	signers   = map[string]*sync.Mutex{}
+)
+
+// signer returns the lock serializing the transactions of the address.
+func signer(address string) *sync.Mutex {
+	signersLk.Lock()
+	defer signersLk.Unlock()
+	s, ok := signers[address]
+	if !ok {
+		s = &sync.Mutex{}
+		signers[address] = s
+	}
+	return s
+}
+
+// withRetries runs send, which prepares, signs and broadcasts a transaction
+// from address, after the transactions of address running, and again on a
+// new reference block while it is rejected for a retryable reason.
+func withRetries(ctx context.Context, address string, send func() error) error {
+	s := signer(address)
+	s.Lock()
+	defer s.Unlock()
+
+	wait := txRetryInterval
+	for attempt := 0; ; attempt++ {
+		err := send()
+		be, ok := err.(*BroadcastError)
+		if !ok || !be.Retryable() {
+			return err
+		}
+		if attempt == txRetries {
+			txRetryFailures.Inc()
+			return err
+		}
+		txRetried.WithLabelValues(be.Code.String()).Inc()
+		log.Debugf("transaction from %s rejected with %s, retrying in %s", address, be.Code, wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wait *= 2
+	}
+}
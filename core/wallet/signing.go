@@ -0,0 +1,223 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/tron-us/go-btfs-common/crypto"
+	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
+	"github.com/tron-us/protobuf/proto"
+)
+
+// SignerConfigKey is the config section of the wallet signer.
+const SignerConfigKey = "WalletSigner"
+
+// Backends of the wallet signer.
+const (
+	// SignerKey signs with the private key of the config.
+	SignerKey = "key"
+	// SignerLedger signs with the TRON app of a Ledger device connected
+	// over USB.
+	SignerLedger = "ledger"
+)
+
+// SignerConfig selects the signer of the wallet transactions.
+type SignerConfig struct {
+	// Backend of the signer, SignerKey when empty.
+	Backend string `json:",omitempty"`
+	// Path is the BIP44 derivation path of the key on the device,
+	// DefaultLedgerPath when empty.
+	Path string `json:",omitempty"`
+	// Device is the path of the device, e.g. /dev/hidraw0, the first Ledger
+	// found when empty.
+	Device string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(SignerConfigKey, SignerConfig{})
+}
+
+// Signer signs the transactions of the wallet: the TRON transactions of the
+// deposits and transfers, and the channels of the deposits and withdrawals
+// on the ledger.
+type Signer interface {
+	// PublicKey returns the secp256k1 key of the BTT and BTFS wallets.
+	PublicKey() (*ecdsa.PublicKey, error)
+	// SignTron returns the signature of the raw data of a TRON
+	// transaction, R || S || V.
+	SignTron(raw []byte) ([]byte, error)
+	// SignChannel returns the signature of a message of the ledger, the
+	// ASN.1 ECDSA signature of its SHA-256.
+	SignChannel(raw []byte) ([]byte, error)
+}
+
+// KeySigner returns the signer holding key.
+func KeySigner(key *ecdsa.PrivateKey) Signer {
+	return keySigner{key}
+}
+
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s keySigner) PublicKey() (*ecdsa.PublicKey, error) {
+	return &s.key.PublicKey, nil
+}
+
+func (s keySigner) SignTron(raw []byte) ([]byte, error) {
+	return SignTron(raw, s.key)
+}
+
+func (s keySigner) SignChannel(raw []byte) ([]byte, error) {
+	return SignChannel(raw, s.key)
+}
+
+var (
+	signerLk sync.RWMutex
+	// deviceSigner signs the wallet transactions instead of the key of the
+	// config when set.
+	deviceSigner Signer
+	// signedKey caches the signed public key of deviceSigner, which the
+	// balances of the ledger require.
+	signedKey *ledgerpb.SignedPublicKey
+	// loaded is the last signer loaded, kept while its config is unchanged
+	// so the device is not asked for its key again.
+	loaded       Signer
+	loadedConfig SignerConfig
+)
+
+// LoadSigner returns the signer configured in r, nil for the key of the
+// config.
+func LoadSigner(r repo.Repo) (Signer, error) {
+	c := &SignerConfig{}
+	if _, err := repo.GetConfigSection(r, SignerConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", SignerConfigKey, err)
+	}
+	signerLk.Lock()
+	defer signerLk.Unlock()
+	if loaded != nil && *c == loadedConfig {
+		return loaded, nil
+	}
+	s, err := NewSigner(c)
+	if err != nil {
+		return nil, err
+	}
+	loaded, loadedConfig = s, *c
+	return s, nil
+}
+
+// NewSigner returns the signer configured by c, nil for the key of the
+// config.
+func NewSigner(c *SignerConfig) (Signer, error) {
+	switch c.Backend {
+	case "", SignerKey:
+		return nil, nil
+	case SignerLedger:
+		path := c.Path
+		if path == "" {
+			path = DefaultLedgerPath
+		}
+		return NewLedgerSigner(c.Device, path)
+	}
+	return nil, fmt.Errorf("unknown wallet signer %q, must be %s or %s", c.Backend, SignerKey, SignerLedger)
+}
+
+// SetSigner makes s sign the wallet transactions instead of the key of the
+// config, until it is set to nil.
+func SetSigner(s Signer) {
+	signerLk.Lock()
+	defer signerLk.Unlock()
+	if s != deviceSigner {
+		deviceSigner = s
+		signedKey = nil
+	}
+}
+
+// configuredSigner returns the signer set with SetSigner, nil if none.
+func configuredSigner() Signer {
+	signerLk.RLock()
+	defer signerLk.RUnlock()
+	return deviceSigner
+}
+
+// signerAddress returns the TRON address of s, 41 followed by 20 bytes.
+func signerAddress(s Signer) ([]byte, error) {
+	pub, err := s.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	addr, err := crypto.PublicKeyToAddress(*pub)
+	if err != nil {
+		return nil, err
+	}
+	return addr.Bytes(), nil
+}
+
+// SignerBase58Address returns the base58 TRON address of s.
+func SignerBase58Address(s Signer) (string, error) {
+	addr, err := signerAddress(s)
+	if err != nil {
+		return "", err
+	}
+	return crypto.Encode58Check(addr)
+}
+
+// signerHexAddress returns the hex TRON address of s.
+func signerHexAddress(s Signer) (string, error) {
+	addr, err := signerAddress(s)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(addr), nil
+}
+
+// signerLedgerAddress returns the account of s on the ledger, its
+// uncompressed public key.
+func signerLedgerAddress(s Signer) ([]byte, error) {
+	pub, err := s.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 65)
+	b[0] = 4
+	x, y := pub.X.Bytes(), pub.Y.Bytes()
+	copy(b[33-len(x):33], x)
+	copy(b[65-len(y):], y)
+	return b, nil
+}
+
+// signedPublicKey returns the public key of s signed by s, cached for
+// deviceSigner.
+func signedPublicKey(s Signer) (*ledgerpb.SignedPublicKey, error) {
+	signerLk.RLock()
+	cached := signedKey
+	signerLk.RUnlock()
+	if cached != nil && s == configuredSigner() {
+		return cached, nil
+	}
+	addr, err := signerLedgerAddress(s)
+	if err != nil {
+		return nil, err
+	}
+	key := &ledgerpb.PublicKey{Key: addr}
+	raw, err := proto.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.SignChannel(raw)
+	if err != nil {
+		return nil, err
+	}
+	signed := &ledgerpb.SignedPublicKey{Key: key, Signature: sig}
+	signerLk.Lock()
+	if s == deviceSigner {
+		signedKey = signed
+	}
+	signerLk.Unlock()
+	return signed, nil
+}
@@ -2,40 +2,42 @@ package wallet
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/e"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
 	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
 
+	cmds "github.com/TRON-US/go-btfs-cmds"
 	config "github.com/TRON-US/go-btfs-config"
-	escrowPb "github.com/tron-us/go-btfs-common/protos/escrow"
 	exPb "github.com/tron-us/go-btfs-common/protos/exchange"
 	ledgerPb "github.com/tron-us/go-btfs-common/protos/ledger"
 	tronPb "github.com/tron-us/go-btfs-common/protos/protocol/api"
 	corePb "github.com/tron-us/go-btfs-common/protos/protocol/core"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 
 	"github.com/gogo/protobuf/proto"
 	ds "github.com/ipfs/go-datastore"
 )
 
+// The balance errors have the insufficient funds code for the exit status
+// of the CLI.
 var (
-	ErrInsufficientExchangeBalanceOnTron   = errors.New("exchange balance on Tron network is not sufficient")
-	ErrInsufficientUserBalanceOnTron       = errors.New(fmt.Sprint("User balance on tron network is not sufficient."))
-	ErrInsufficientUserBalanceOnLedger     = errors.New("rpc error: code = ResourceExhausted desc = NSF")
-	ErrInsufficientExchangeBalanceOnLedger = errors.New("exchange balance on Private Ledger is not sufficient")
+	ErrInsufficientExchangeBalanceOnTron   = cmds.Errorf(e.ErrInsufficientFunds, "exchange balance on Tron network is not sufficient")
+	ErrInsufficientUserBalanceOnTron       = cmds.Errorf(e.ErrInsufficientFunds, "User balance on tron network is not sufficient.")
+	ErrInsufficientUserBalanceOnLedger     = cmds.Errorf(e.ErrInsufficientFunds, "rpc error: code = ResourceExhausted desc = NSF")
+	ErrInsufficientExchangeBalanceOnLedger = cmds.Errorf(e.ErrInsufficientFunds, "exchange balance on Private Ledger is not sufficient")
 )
 
 // Do the deposit action, integrate exchange's PrepareDeposit and Deposit API.
 func Deposit(ctx context.Context, n *core.IpfsNode, ledgerAddr []byte, amount int64,
-	privateKey *ecdsa.PrivateKey, runDaemon bool, async bool) (*exPb.PrepareDepositResponse, error) {
+	signer Signer, runDaemon bool, async bool) (*exPb.PrepareDepositResponse, error) {
 	log.Debug("Deposit begin!")
 	//PrepareDeposit
 	prepareResponse, err := PrepareDeposit(ctx, ledgerAddr, amount)
@@ -48,7 +50,7 @@ func Deposit(ctx context.Context, n *core.IpfsNode, ledgerAddr []byte, amount in
 	log.Debug(fmt.Sprintf("PrepareDeposit success, id: [%d]", prepareResponse.GetId()))
 
 	//Do the DepositRequest.
-	depositResponse, err := DepositRequest(ctx, prepareResponse, privateKey)
+	depositResponse, err := DepositRequest(ctx, prepareResponse, signer)
 	if err != nil {
 		return prepareResponse, err
 	}
@@ -64,9 +66,9 @@ func Deposit(ctx context.Context, n *core.IpfsNode, ledgerAddr []byte, amount in
 	}
 
 	if runDaemon && async {
-		go ConfirmDepositProcess(context.Background(), n, prepareResponse, privateKey)
+		go ConfirmDepositProcess(context.Background(), n, prepareResponse, signer)
 	} else {
-		err := ConfirmDepositProcess(ctx, n, prepareResponse, privateKey)
+		err := ConfirmDepositProcess(ctx, n, prepareResponse, signer)
 		if err != nil {
 			return nil, err
 		}
@@ -80,65 +82,38 @@ func Deposit(ctx context.Context, n *core.IpfsNode, ledgerAddr []byte, amount in
 // Call exchange's PrepareDeposit API.
 func PrepareDeposit(ctx context.Context, ledgerAddr []byte, amount int64) (*exPb.PrepareDepositResponse, error) {
 	// Prepare to Deposit.
-	var err error
-	var prepareResponse *exPb.PrepareDepositResponse
-	err = grpc.ExchangeClient(exchangeService).WithContext(ctx,
-		func(ctx context.Context, client exPb.ExchangeClient) error {
-			prepareDepositRequest := &exPb.PrepareDepositRequest{Amount: amount, OutTxId: time.Now().UnixNano(),
-				UserAddress: ledgerAddr}
-			prepareResponse, err = client.PrepareDeposit(ctx, prepareDepositRequest)
-			if err != nil {
-				return err
-			}
-			return nil
-		})
-	if err != nil {
-		return nil, err
-	}
-	return prepareResponse, nil
+	prepareDepositRequest := &exPb.PrepareDepositRequest{Amount: amount, OutTxId: time.Now().UnixNano(),
+		UserAddress: ledgerAddr}
+	return chainBackend(initServices()).PrepareDeposit(ctx, prepareDepositRequest)
 }
 
 // Call exchange's Deposit API
-func DepositRequest(ctx context.Context, prepareResponse *exPb.PrepareDepositResponse, privateKey *ecdsa.PrivateKey) (*exPb.DepositResponse, error) {
+func DepositRequest(ctx context.Context, prepareResponse *exPb.PrepareDepositResponse, signer Signer) (*exPb.DepositResponse, error) {
 	// Sign Tron Transaction.
 	tronTransaction := prepareResponse.GetTronTransaction()
 	for range tronTransaction.GetRawData().GetContract() {
-		signature, err := Sign(tronTransaction, privateKey)
+		signature, err := SignWith(tronTransaction, signer)
 		if err != nil {
 			return nil, err
 		}
 		tronTransaction.Signature = append(tronTransaction.GetSignature(), signature)
 	}
 
-	var err error
-	var depositResponse *exPb.DepositResponse
-	err = grpc.ExchangeClient(exchangeService).WithContext(ctx,
-		func(ctx context.Context, client exPb.ExchangeClient) error {
-			depositRequest := &exPb.DepositRequest{Id: prepareResponse.GetId(), SignedTronTransaction: tronTransaction}
-
-			depositResponse, err = client.Deposit(ctx, depositRequest)
-			if err != nil {
-				return err
-			}
-			return nil
-		})
-	if err != nil {
-		return nil, err
-	}
-	return depositResponse, nil
+	depositRequest := &exPb.DepositRequest{Id: prepareResponse.GetId(), SignedTronTransaction: tronTransaction}
+	return chainBackend(initServices()).Deposit(ctx, depositRequest)
 }
 
 // Continuous call ConfirmDeposit until it responses a FAILED or SUCCESS.
 func ConfirmDepositProcess(ctx context.Context, n *core.IpfsNode, prepareResponse *exPb.PrepareDepositResponse,
-	privateKey *ecdsa.PrivateKey) error {
+	signer Signer) error {
 	log.Debug(fmt.Sprintf("[Id:%d] ConfirmDepositProcess begin.", prepareResponse.GetId()))
 
 	// Continuous call ConfirmDeposit until it responses a FAILED or SUCCESS.
 	for time.Now().UnixNano()/1e6 < (prepareResponse.GetTronTransaction().GetRawData().GetExpiration() + 480*1000) {
-		time.Sleep(5 * time.Second)
+		time.Sleep(confirmDepositInterval)
 
 		// ConfirmDeposit after 1min, because watcher need wait for 1min to confirm tron transaction.
-		if time.Now().UnixNano()/1e6 < (prepareResponse.GetTronTransaction().GetRawData().GetTimestamp() + 60*1000) {
+		if time.Now().UnixNano()/1e6 < (prepareResponse.GetTronTransaction().GetRawData().GetTimestamp() + confirmDepositDelay.Milliseconds()) {
 			continue
 		}
 		log.Debug(fmt.Sprintf("[Id:%d] ConfirmDeposit begin.", prepareResponse.GetId()))
@@ -163,7 +138,7 @@ func ConfirmDepositProcess(ctx context.Context, n *core.IpfsNode, prepareRespons
 		if confirmDepositResponse.GetResponse().GetCode() == exPb.Response_SUCCESS {
 			signSuccessChannelState := confirmDepositResponse.GetSuccessChannelState()
 			if signSuccessChannelState != nil {
-				toSignature, err := Sign(signSuccessChannelState.GetChannel(), privateKey)
+				toSignature, err := SignWith(signSuccessChannelState.GetChannel(), signer)
 				if err != nil {
 					return err
 				}
@@ -173,18 +148,11 @@ func ConfirmDepositProcess(ctx context.Context, n *core.IpfsNode, prepareRespons
 			}
 
 			for i := 0; i < 10; i++ {
-				err = grpc.EscrowClient(escrowService).WithContext(ctx,
-					func(ctx context.Context, client escrowPb.EscrowServiceClient) error {
-						_, err = client.CloseChannel(ctx, signSuccessChannelState)
-						if err != nil {
-							return err
-						}
-						return nil
-					})
+				_, err = chainBackend(initServices()).CloseChannel(ctx, signSuccessChannelState)
 				if err == nil {
 					break
 				}
-				time.Sleep(5 * time.Second)
+				time.Sleep(closeChannelRetryInterval)
 			}
 			if err != nil {
 				return err
@@ -207,28 +175,14 @@ func ConfirmDepositProcess(ctx context.Context, n *core.IpfsNode, prepareRespons
 
 // Call exchange's ConfirmDeposit API.
 func ConfirmDeposit(ctx context.Context, logId int64) (*exPb.ConfirmDepositResponse, error) {
-	var err error
-	var confirmDepositResponse *exPb.ConfirmDepositResponse
-	err = grpc.ExchangeClient(exchangeService).WithContext(ctx,
-		func(ctx context.Context, client exPb.ExchangeClient) error {
-			confirmDepositRequest := &exPb.ConfirmDepositRequest{Id: logId}
-			confirmDepositResponse, err = client.ConfirmDeposit(ctx, confirmDepositRequest)
-			if err != nil {
-				return err
-			}
-			return nil
-		})
-	if err != nil {
-		return nil, err
-	}
-	return confirmDepositResponse, nil
+	return chainBackend(initServices()).ConfirmDeposit(ctx, &exPb.ConfirmDepositRequest{Id: logId})
 }
 
 // Do the withdraw action, integrate exchange's PrepareWithdraw and Withdraw API, return channel id and error.
 // If Withdraw succeed, with return channel id and logInfo id, error is nil; otherwise will return error, channel id
 // and logInfo id is 0.
 func Withdraw(ctx context.Context, n *core.IpfsNode, ledgerAddr, externalAddr []byte, amount int64,
-	privateKey *ecdsa.PrivateKey) (int64, int64, error) {
+	signer Signer) (int64, int64, error) {
 	log.Debug("Withdraw begin!")
 	outTxId := time.Now().UnixNano()
 	//PrepareWithdraw
@@ -248,31 +202,23 @@ func Withdraw(ctx context.Context, n *core.IpfsNode, ledgerAddr, externalAddr []
 		PayerId:   time.Now().UnixNano() + prepareResponse.GetId(),
 	}
 	//Sign channel commit.
-	signature, err := Sign(channelCommit, privateKey)
+	signature, err := SignWith(channelCommit, signer)
 	if err != nil {
 		return 0, 0, err
 	}
 
-	var channelId *ledgerPb.ChannelID
-	err = grpc.EscrowClient(escrowService).WithContext(ctx,
-		func(ctx context.Context, client escrowPb.EscrowServiceClient) error {
-			channelId, err = client.CreateChannel(ctx,
-				&ledgerPb.SignedChannelCommit{Channel: channelCommit, Signature: signature})
-			if err != nil {
-				if err.Error() == ErrInsufficientUserBalanceOnLedger.Error() {
-					return ErrInsufficientUserBalanceOnLedger
-				}
-				return err
-			}
-			return nil
-		})
+	channelId, err := chainBackend(initServices()).CreateChannel(ctx,
+		&ledgerPb.SignedChannelCommit{Channel: channelCommit, Signature: signature})
 	if err != nil {
+		if err.Error() == ErrInsufficientUserBalanceOnLedger.Error() {
+			return 0, 0, ErrInsufficientUserBalanceOnLedger
+		}
 		return 0, 0, err
 	}
 	log.Debug(fmt.Sprintf("CreateChannel success, channelId: [%d]", channelId.GetId()))
 
 	//Do the WithdrawRequest.
-	withdrawResponse, err := WithdrawRequest(ctx, channelId, ledgerAddr, amount, prepareResponse, privateKey)
+	withdrawResponse, err := WithdrawRequest(ctx, channelId, ledgerAddr, amount, prepareResponse, signer)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -302,29 +248,19 @@ func Withdraw(ctx context.Context, n *core.IpfsNode, ledgerAddr, externalAddr []
 // Call exchange's Withdraw API
 func PrepareWithdraw(ctx context.Context, ledgerAddr, externalAddr []byte, amount, outTxId int64) (
 	*exPb.PrepareWithdrawResponse, error) {
-	var err error
-	var prepareResponse *exPb.PrepareWithdrawResponse
-	err = grpc.ExchangeClient(exchangeService).WithContext(ctx,
-		func(ctx context.Context, client exPb.ExchangeClient) error {
-			prepareWithdrawRequest := &exPb.PrepareWithdrawRequest{
-				Amount: amount, OutTxId: outTxId, UserAddress: ledgerAddr, UserExternalAddress: externalAddr}
-			prepareResponse, err = client.PrepareWithdraw(ctx, prepareWithdrawRequest)
-			if err != nil {
-				return err
-			}
-			log.Debug(prepareResponse)
-			return nil
-		})
+	prepareWithdrawRequest := &exPb.PrepareWithdrawRequest{
+		Amount: amount, OutTxId: outTxId, UserAddress: ledgerAddr, UserExternalAddress: externalAddr}
+	prepareResponse, err := chainBackend(initServices()).PrepareWithdraw(ctx, prepareWithdrawRequest)
 	if err != nil {
 		return nil, err
 	}
-
+	log.Debug(prepareResponse)
 	return prepareResponse, nil
 }
 
 // Call exchange's PrepareWithdraw API
 func WithdrawRequest(ctx context.Context, channelId *ledgerPb.ChannelID, ledgerAddr []byte, amount int64,
-	prepareResponse *exPb.PrepareWithdrawResponse, privateKey *ecdsa.PrivateKey) (*exPb.WithdrawResponse, error) {
+	prepareResponse *exPb.PrepareWithdrawResponse, signer Signer) (*exPb.WithdrawResponse, error) {
 	//make signed success channel state.
 	successChannelState := &ledgerPb.ChannelState{
 		Id:       channelId,
@@ -342,7 +278,7 @@ func WithdrawRequest(ctx context.Context, channelId *ledgerPb.ChannelID, ledgerA
 			Balance: amount,
 		},
 	}
-	successSignature, err := Sign(successChannelState, privateKey)
+	successSignature, err := SignWith(successChannelState, signer)
 	if err != nil {
 		return nil, err
 	}
@@ -365,54 +301,32 @@ func WithdrawRequest(ctx context.Context, channelId *ledgerPb.ChannelID, ledgerA
 			Balance: 0,
 		},
 	}
-	failSignature, err := Sign(failChannelState, privateKey)
+	failSignature, err := SignWith(failChannelState, signer)
 	if err != nil {
 		return nil, err
 	}
 
-	var withdrawResponse *exPb.WithdrawResponse
-	err = grpc.ExchangeClient(exchangeService).WithContext(ctx,
-		func(ctx context.Context, client exPb.ExchangeClient) error {
-			failChannelStateSigned := &ledgerPb.SignedChannelState{Channel: failChannelState, FromSignature: failSignature}
-			//Post the withdraw request.
-			withdrawRequest := &exPb.WithdrawRequest{
-				Id:                  prepareResponse.GetId(),
-				SuccessChannelState: successChannelStateSigned,
-				FailureChannelState: failChannelStateSigned,
-			}
-			withdrawResponse, err = client.Withdraw(ctx, withdrawRequest)
-			if err != nil {
-				return err
-			}
-			return nil
-		})
-	if err != nil {
-		return nil, err
+	failChannelStateSigned := &ledgerPb.SignedChannelState{Channel: failChannelState, FromSignature: failSignature}
+	//Post the withdraw request.
+	withdrawRequest := &exPb.WithdrawRequest{
+		Id:                  prepareResponse.GetId(),
+		SuccessChannelState: successChannelStateSigned,
+		FailureChannelState: failChannelStateSigned,
 	}
-	return withdrawResponse, nil
+	return chainBackend(initServices()).Withdraw(ctx, withdrawRequest)
 }
 
 //Get the token balance on tron blockchain
 func GetTokenBalance(ctx context.Context, addr []byte, tokenId string) (int64, error) {
-	var tokenBalance int64 = 0
-	err := grpc.SolidityClient(solidityService).WithContext(ctx,
-		func(ctx context.Context, client tronPb.WalletSolidityClient) error {
-			account := &corePb.Account{Address: addr}
-			myAccount, err := client.GetAccount(ctx, account)
-			if err != nil {
-				return err
-			}
-			tokenMap := myAccount.GetAssetV2()
-			if tokenMap == nil || len(tokenMap) == 0 {
-				return nil
-			}
-			tokenBalance = tokenMap[tokenId]
-			return nil
-		})
+	myAccount, err := chainBackend(initServices()).GetAccount(ctx, &corePb.Account{Address: addr})
 	if err != nil {
 		return 0, err
 	}
-	return tokenBalance, nil
+	tokenMap := myAccount.GetAssetV2()
+	if tokenMap == nil || len(tokenMap) == 0 {
+		return 0, nil
+	}
+	return tokenMap[tokenId], nil
 }
 
 var (
@@ -425,16 +339,20 @@ var (
 
 func PersistTx(d ds.Datastore, peerId string, txId string, amount int64,
 	from string, to string, status string, txType walletpb.TransactionV1_Type) error {
-	return sessions.Save(d, fmt.Sprintf(walletTransactionV1Key, peerId, txId),
-		&walletpb.TransactionV1{
-			Id:         txId,
-			TimeCreate: time.Now(),
-			Amount:     amount,
-			From:       from,
-			To:         to,
-			Status:     status,
-			Type:       txType,
-		})
+	tx := &walletpb.TransactionV1{
+		Id:         txId,
+		TimeCreate: time.Now(),
+		Amount:     amount,
+		From:       from,
+		To:         to,
+		Status:     status,
+		Type:       txType,
+	}
+	if err := sessions.Save(d, fmt.Sprintf(walletTransactionV1Key, peerId, txId), tx); err != nil {
+		return err
+	}
+	notify(peerId, tx)
+	return nil
 }
 
 func UpdateStatus(d ds.Datastore, peerId string, txId string, status string) error {
@@ -446,7 +364,10 @@ func UpdateStatus(d ds.Datastore, peerId string, txId string, status string) err
 	}
 	if s.Status != status {
 		s.Status = status
-		return sessions.Save(d, key, s)
+		if err := sessions.Save(d, key, s); err != nil {
+			return err
+		}
+		notify(peerId, s)
 	}
 	return nil
 }
@@ -489,6 +410,115 @@ func (p TxSlice) Swap(i, j int) {
 	p[i], p[j] = p[j], p[i]
 }
 
+// Types of the transactions, by the wallets they move BTT between.
+const (
+	TxDeposit  = "deposit"
+	TxWithdraw = "withdraw"
+	TxTransfer = "transfer"
+)
+
+// TxTypeOf returns the type of tx: a deposit from the BTT wallet to the
+// BTFS wallet, a withdrawal back, or a transfer to another address.
+func TxTypeOf(tx *walletpb.TransactionV1) string {
+	switch {
+	case tx.From == BttWallet && tx.To == InAppWallet:
+		return TxDeposit
+	case tx.From == InAppWallet && tx.To == BttWallet:
+		return TxWithdraw
+	default:
+		return TxTransfer
+	}
+}
+
+// Orders of the transactions.
+const (
+	TxSortNewest = "newest"
+	TxSortOldest = "oldest"
+	TxSortAmount = "amount"
+)
+
+// TxQuery selects a page of the transactions, the zero value selecting all
+// of them, newest first.
+type TxQuery struct {
+	// Offset is the number of transactions skipped.
+	Offset int
+	// Limit is the most transactions returned, all of them when zero.
+	Limit int
+	// Status selects the transactions of a status, e.g. Pending.
+	Status string
+	// Type selects the transactions of a type, e.g. TxDeposit.
+	Type string
+	// Since and Until select the transactions created from Since and
+	// before Until, when set.
+	Since time.Time
+	Until time.Time
+	// Sort is the order of the transactions, TxSortNewest when empty.
+	Sort string
+}
+
+// Validate checks the fields of q.
+func (q *TxQuery) Validate() error {
+	if q.Offset < 0 || q.Limit < 0 {
+		return errors.New("offset and limit must not be negative")
+	}
+	switch q.Type {
+	case "", TxDeposit, TxWithdraw, TxTransfer:
+	default:
+		return fmt.Errorf("invalid transaction type %q, must be %s, %s or %s", q.Type,
+			TxDeposit, TxWithdraw, TxTransfer)
+	}
+	switch q.Sort {
+	case "", TxSortNewest, TxSortOldest, TxSortAmount:
+	default:
+		return fmt.Errorf("invalid order %q, must be %s, %s or %s", q.Sort,
+			TxSortNewest, TxSortOldest, TxSortAmount)
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return errors.New("since must be before until")
+	}
+	return nil
+}
+
+// QueryTransactions returns the page of txs selected by q, and the number
+// of transactions selected by q before paging.
+func QueryTransactions(txs []*walletpb.TransactionV1, q *TxQuery) ([]*walletpb.TransactionV1, int) {
+	selected := make([]*walletpb.TransactionV1, 0, len(txs))
+	for _, tx := range txs {
+		if q.Status != "" && !strings.EqualFold(tx.Status, q.Status) {
+			continue
+		}
+		if q.Type != "" && TxTypeOf(tx) != q.Type {
+			continue
+		}
+		if !q.Since.IsZero() && tx.TimeCreate.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && !tx.TimeCreate.Before(q.Until) {
+			continue
+		}
+		selected = append(selected, tx)
+	}
+	sort.Sort(TxSlice(selected))
+	switch q.Sort {
+	case TxSortOldest:
+		sort.Sort(sort.Reverse(TxSlice(selected)))
+	case TxSortAmount:
+		// the largest first, the newest of the same amount first
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].Amount > selected[j].Amount
+		})
+	}
+	total := len(selected)
+	if q.Offset >= total {
+		return []*walletpb.TransactionV1{}, total
+	}
+	selected = selected[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(selected) {
+		selected = selected[:q.Limit]
+	}
+	return selected, total
+}
+
 func loadV0Txs(d ds.Datastore, peerId string) ([]*walletpb.TransactionV1, error) {
 	list, err := sessions.List(d, fmt.Sprintf(walletTransactionKeyPrefix, peerId))
 	if err != nil {
@@ -622,14 +652,7 @@ func getExchangeTxStatus(ctx context.Context, cfg *config.Config, txIdStr string
 	in := &exPb.QueryTransactionRequest{
 		Id: txId,
 	}
-	var resp *exPb.QueryTransactionResponse
-	err = grpc.ExchangeClient(cfg.Services.ExchangeDomain).WithContext(ctx, func(ctx context.Context, client exPb.ExchangeClient) error {
-		resp, err = client.QueryTransaction(ctx, in)
-		if err != nil {
-			return err
-		}
-		return nil
-	})
+	resp, err := chainBackend(cfg.Services).QueryTransaction(ctx, in)
 	if err != nil {
 		return "", err
 	}
@@ -646,25 +669,18 @@ func getExchangeTxStatus(ctx context.Context, cfg *config.Config, txIdStr string
 }
 
 func getOnChainTxStatus(ctx context.Context, d ds.Datastore, cfg *config.Config, peerId string, txId string) (string, error) {
-	status := StatusPending
-	err := grpc.WalletClient(cfg.Services.FullnodeDomain).WithContext(ctx, func(ctx context.Context, client tronPb.WalletClient) error {
-		bytes, err := hex.DecodeString(txId)
-		if err != nil {
-			return err
-		}
-		in := &tronPb.BytesMessage{
-			Value: bytes,
-		}
-		resp, err := client.GetTransactionInfoById(ctx, in)
-		if err != nil {
-			return err
-		}
-		status = resp.Result.String()
-		return nil
-	})
+	bytes, err := hex.DecodeString(txId)
+	if err != nil {
+		return "", err
+	}
+	in := &tronPb.BytesMessage{
+		Value: bytes,
+	}
+	resp, err := chainBackend(cfg.Services).GetTransactionInfoById(ctx, in)
 	if err != nil {
 		return "", err
 	}
+	status := resp.Result.String()
 	if status == corePb.TransactionInfo_SUCESS.String() {
 		status = StatusSuccess
 	} else if status == corePb.TransactionInfo_FAILED.String() {
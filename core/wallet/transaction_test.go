@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	walletpb "github.com/TRON-US/go-btfs/protos/wallet"
+)
+
+func TestQueryTransactions(t *testing.T) {
+	now := time.Now()
+	var txs []*walletpb.TransactionV1
+	for i := 0; i < 10; i++ {
+		tx := &walletpb.TransactionV1{
+			Id:         fmt.Sprint(i),
+			TimeCreate: now.Add(time.Duration(i) * time.Hour),
+			Amount:     int64(i % 4),
+			Status:     StatusSuccess,
+			From:       InAppWallet,
+			To:         "address",
+		}
+		switch i % 3 {
+		case 0:
+			tx.From, tx.To = BttWallet, InAppWallet
+		case 1:
+			tx.From, tx.To = InAppWallet, BttWallet
+		}
+		if i >= 8 {
+			tx.Status = StatusPending
+		}
+		txs = append(txs, tx)
+	}
+
+	ids := func(txs []*walletpb.TransactionV1) string {
+		s := ""
+		for _, tx := range txs {
+			s += tx.Id
+		}
+		return s
+	}
+	for _, tc := range []struct {
+		q     TxQuery
+		ids   string
+		total int
+	}{
+		{TxQuery{}, "9876543210", 10},
+		{TxQuery{Offset: 2, Limit: 3}, "765", 10},
+		{TxQuery{Offset: 10}, "", 10},
+		{TxQuery{Sort: TxSortOldest, Limit: 2}, "01", 10},
+		{TxQuery{Sort: TxSortAmount, Limit: 4}, "7362", 10},
+		{TxQuery{Status: "pending"}, "98", 2},
+		{TxQuery{Type: TxDeposit}, "9630", 4},
+		{TxQuery{Type: TxWithdraw, Limit: 1}, "7", 3},
+		{TxQuery{Type: TxTransfer, Status: StatusSuccess}, "52", 2},
+		{TxQuery{Since: now.Add(3 * time.Hour), Until: now.Add(6 * time.Hour)}, "543", 3},
+	} {
+		page, total := QueryTransactions(txs, &tc.q)
+		if got := ids(page); got != tc.ids || total != tc.total {
+			t.Errorf("%+v: expected %q of %d, got %q of %d", tc.q, tc.ids, tc.total, got, total)
+		}
+	}
+}
+
+func TestTxQueryValidate(t *testing.T) {
+	now := time.Now()
+	for _, q := range []TxQuery{
+		{Offset: -1},
+		{Limit: -1},
+		{Type: "exchange"},
+		{Sort: "size"},
+		{Since: now, Until: now},
+	} {
+		if err := q.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", q)
+		}
+	}
+	q := TxQuery{Offset: 10, Limit: 10, Type: TxTransfer, Sort: TxSortAmount, Since: now}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -14,7 +14,6 @@ import (
 	"github.com/tron-us/go-btfs-common/crypto"
 	tronPb "github.com/tron-us/go-btfs-common/protos/protocol/api"
 	protocol_core "github.com/tron-us/go-btfs-common/protos/protocol/core"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 
 	"github.com/gogo/protobuf/proto"
 	ic "github.com/libp2p/go-libp2p-core/crypto"
@@ -25,49 +24,56 @@ import (
 func TransferBTT(ctx context.Context, n *core.IpfsNode, cfg *config.Config, privKey ic.PrivKey,
 	from string, to string, amount int64) (*TronRet, error) {
 	var err error
+	// the wallet of the node is signed by the signer configured, if any
+	var signer Signer
 	if privKey == nil {
-		privKey, err = crypto.ToPrivKey(cfg.Identity.PrivKey)
+		signer = configuredSigner()
+	}
+	if signer == nil {
+		if privKey == nil {
+			privKey, err = crypto.ToPrivKey(cfg.Identity.PrivKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+		raw, err := privKey.Raw()
+		if err != nil {
+			return nil, err
+		}
+		ecdsa, err := crypto.HexToECDSA(hex.EncodeToString(raw))
 		if err != nil {
 			return nil, err
 		}
+		signer = KeySigner(ecdsa)
 	}
 	if from == "" {
-		keys, err := crypto.FromIcPrivateKey(privKey)
+		from, err = signerHexAddress(signer)
 		if err != nil {
 			return nil, err
 		}
-		from = keys.HexAddress
-	}
-	txId := ""
-
-	tx, err := PrepareTx(ctx, cfg, from, to, amount)
-	if err != nil {
-		return nil, err
-	}
-	raw, err := privKey.Raw()
-	if err != nil {
-		return nil, err
-	}
-	ecdsa, err := crypto.HexToECDSA(hex.EncodeToString(raw))
-	if err != nil {
-		return nil, err
-	}
-	bs, err := proto.Marshal(tx.Transaction.RawData)
-	if err != nil {
-		return nil, err
-	}
-	sig, err := crypto.EcdsaSign(ecdsa, bs)
-	if err != nil {
-		return nil, err
-	}
-	rawBytes, err := proto.Marshal(tx.Transaction.RawData)
-	if err != nil {
-		return nil, err
 	}
-	err = SendRawTransaction(ctx, cfg.Services.FullnodeDomain, rawBytes, sig)
+	// concurrent transfers would race for the reference block, each is
+	// prepared on the latest block when its turn comes
+	var tx *tronPb.TransactionExtention
+	err = withRetries(ctx, from, func() error {
+		tx, err = PrepareTx(ctx, cfg, from, to, amount)
+		if err != nil {
+			return err
+		}
+		rawBytes, err := proto.Marshal(tx.Transaction.RawData)
+		if err != nil {
+			return err
+		}
+		sig, err := signer.SignTron(rawBytes)
+		if err != nil {
+			return err
+		}
+		return SendRawTransaction(ctx, cfg.Services.FullnodeDomain, rawBytes, sig)
+	})
 	if err != nil {
 		return nil, err
 	}
+	txId := hex.EncodeToString(tx.Txid)
 	err = PersistTx(n.Repo.Datastore(), n.Identity.String(), txId, amount,
 		BttWallet, to, StatusPending, walletpb.TransactionV1_ON_CHAIN)
 	if err != nil {
@@ -75,7 +81,7 @@ func TransferBTT(ctx context.Context, n *core.IpfsNode, cfg *config.Config, priv
 	}
 	go func() {
 		// confirmed after 19 * 3 second/block
-		time.Sleep(1 * time.Minute)
+		time.Sleep(transferConfirmDelay)
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
 		status, err := GetStatus(ctx, cfg.Services.SolidityDomain, txId)
@@ -94,7 +100,7 @@ func TransferBTT(ctx context.Context, n *core.IpfsNode, cfg *config.Config, priv
 		Message: string(tx.Result.Message),
 		Result:  tx.Result.Result,
 		Code:    tx.Result.Code.String(),
-		TxId:    hex.EncodeToString(tx.Txid),
+		TxId:    txId,
 	}, nil
 }
 
@@ -146,24 +152,18 @@ func PrepareTx(ctx context.Context, cfg *config.Config, from string, to string,
 		strings.Contains(cfg.Services.EscrowDomain, "staging") {
 		tokenId = TokenIdDev
 	}
-	err = grpc.WalletClient(cfg.Services.FullnodeDomain).WithContext(ctx, func(ctx context.Context, client tronPb.WalletClient) error {
-		tx, err = client.TransferAsset2(ctx, &protocol_core.TransferAssetContract{
-			AssetName:    []byte(tokenId),
-			OwnerAddress: oa,
-			ToAddress:    ta,
-			Amount:       amount,
-		})
-		if err != nil {
-			return err
-		}
-		if !tx.Result.Result {
-			return errors.New(string(tx.Result.Message))
-		}
-		return nil
+	tx, err = chainBackend(cfg.Services).TransferAsset2(ctx, &protocol_core.TransferAssetContract{
+		AssetName:    []byte(tokenId),
+		OwnerAddress: oa,
+		ToAddress:    ta,
+		Amount:       amount,
 	})
 	if err != nil {
 		return nil, err
 	}
+	if !tx.Result.Result {
+		return nil, errors.New(string(tx.Result.Message))
+	}
 	return tx, nil
 }
 
@@ -177,10 +177,14 @@ func SendRawTransaction(ctx context.Context, url string, raw []byte, sig []byte)
 		RawData:   rawMsg,
 		Signature: [][]byte{sig},
 	}
-	return grpc.WalletClient(url).WithContext(ctx, func(ctx context.Context, client tronPb.WalletClient) error {
-		_, err = client.BroadcastTransaction(ctx, tx)
+	ret, err := chainBackend(config.Services{FullnodeDomain: url}).BroadcastTransaction(ctx, tx)
+	if err != nil {
 		return err
-	})
+	}
+	if !ret.Result {
+		return &BroadcastError{Code: ret.Code, Message: string(ret.Message)}
+	}
+	return nil
 }
 
 func GetStatus(ctx context.Context, url string, txId string) (string, error) {
@@ -188,20 +192,19 @@ func GetStatus(ctx context.Context, url string, txId string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	var info *protocol_core.Transaction
-	err = grpc.SolidityClient(url).WithContext(ctx, func(ctx context.Context, client tronPb.WalletSolidityClient) error {
-		info, err = client.GetTransactionById(ctx, &tronPb.BytesMessage{Value: txIdBytes})
-		return err
-	})
+	info, err := chainBackend(config.Services{SolidityDomain: url}).GetTransactionById(ctx,
+		&tronPb.BytesMessage{Value: txIdBytes})
 	if err != nil {
 		return StatusFailed, err
 	}
+	// A transaction is returned without result until it is confirmed.
 	status := StatusPending
-	if info != nil && info.Ret != nil && len(info.Ret) > 0 &&
-		info.Ret[0].ContractRet == protocol_core.Transaction_Result_SUCCESS {
-		status = StatusSuccess
-	} else {
-		status = StatusFailed
+	if len(info.GetRet()) > 0 {
+		if info.Ret[0].ContractRet == protocol_core.Transaction_Result_SUCCESS {
+			status = StatusSuccess
+		} else {
+			status = StatusFailed
+		}
 	}
 	return status, nil
 }
@@ -9,14 +9,14 @@ import (
 	"strings"
 
 	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/e"
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/escrow"
 
+	cmds "github.com/TRON-US/go-btfs-cmds"
 	config "github.com/TRON-US/go-btfs-config"
 	"github.com/tron-us/go-btfs-common/crypto"
 	"github.com/tron-us/go-btfs-common/ledger"
-	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
 	ledgerpb "github.com/tron-us/go-btfs-common/protos/ledger"
-	"github.com/tron-us/go-btfs-common/utils/grpc"
 	"github.com/tron-us/protobuf/proto"
 
 	logging "github.com/ipfs/go-log"
@@ -44,43 +44,56 @@ type Wallet struct {
 	privateKey    *ecdsa.PrivateKey
 	tronAddress   []byte // 41***
 	ledgerAddress []byte // address in ledger
+
+	// signer signs the deposits, withdrawals and transfers of the BTT and
+	// BTFS wallets of its key, at walletTron and walletLedger: the key of
+	// the node, or that of a hardware wallet, see SetSigner.
+	signer       Signer
+	walletTron   []byte
+	walletLedger []byte
 }
 
 // withdraw from ledger to tron
 func WalletWithdraw(ctx context.Context, configuration *config.Config, n *core.IpfsNode, amount int64) error {
-	err := Init(ctx, configuration)
+	channelId, id, err := WithdrawLedger(ctx, configuration, n, amount)
 	if err != nil {
 		return err
 	}
 
-	if hostWallet.privateKey == nil {
+	fmt.Println(fmt.Sprintf("Withdraw submitted! ChannelId: [%d], id [%d]\n", channelId, id))
+	return nil
+}
+
+// WithdrawLedger withdraws amount from the ledger to the BTT wallet and
+// returns the channel and the exchange transaction IDs of the withdrawal.
+func WithdrawLedger(ctx context.Context, configuration *config.Config, n *core.IpfsNode, amount int64) (int64, int64, error) {
+	err := Init(ctx, configuration)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if hostWallet.signer == nil {
 		log.Error("wallet is not initialized")
-		return errors.New("wallet is not initialized")
+		return 0, 0, errors.New("wallet is not initialized")
 	}
 
 	if amount < WithdrawMinAmount || amount > WithdrawMaxAmount {
-		return errors.New(fmt.Sprintf("withdraw amount should between %d ~ %d", WithdrawMinAmount, WithdrawMaxAmount))
+		return 0, 0, errors.New(fmt.Sprintf("withdraw amount should between %d ~ %d", WithdrawMinAmount, WithdrawMaxAmount))
 	}
 
 	// get ledger balance before withdraw
 	ledgerBalance, err := Balance(ctx, configuration)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Failed to get ledger balance, reason: %v", err))
+		return 0, 0, errors.New(fmt.Sprintf("Failed to get ledger balance, reason: %v", err))
 	}
 	log.Info(fmt.Sprintf("Get ledger account success, balance: [%d]", ledgerBalance))
 
 	if amount > ledgerBalance {
-		return errors.New(fmt.Sprintf("not enough ledger balance, current balance is %d", ledgerBalance))
+		return 0, 0, cmds.Errorf(e.ErrInsufficientFunds, "not enough ledger balance, current balance is %d", ledgerBalance)
 	}
 
 	// Doing withdraw request.
-	channelId, id, err := Withdraw(ctx, n, hostWallet.ledgerAddress, hostWallet.tronAddress, amount, hostWallet.privateKey)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println(fmt.Sprintf("Withdraw submitted! ChannelId: [%d], id [%d]\n", channelId, id))
-	return nil
+	return Withdraw(ctx, n, hostWallet.walletLedger, hostWallet.walletTron, amount, hostWallet.signer)
 }
 
 const (
@@ -99,7 +112,7 @@ func WalletDeposit(ctx context.Context, configuration *config.Config, n *core.Ip
 		return err
 	}
 
-	if hostWallet.privateKey == nil {
+	if hostWallet.signer == nil {
 		log.Error("wallet is not initialized")
 		return errors.New("wallet is not initialized")
 	}
@@ -113,7 +126,7 @@ func WalletDeposit(ctx context.Context, configuration *config.Config, n *core.Ip
 		return err
 	}
 
-	prepareResponse, err := Deposit(ctx, n, hostWallet.ledgerAddress, amount, hostWallet.privateKey, runDaemon, async)
+	prepareResponse, err := Deposit(ctx, n, hostWallet.walletLedger, amount, hostWallet.signer, runDaemon, async)
 	if err != nil {
 		log.Error("Failed to Deposit, ERR[%v]\n", err)
 		return err
@@ -130,7 +143,7 @@ func GetBalance(ctx context.Context, configuration *config.Config) (int64, int64
 		return 0, 0, err
 	}
 
-	if hostWallet.privateKey == nil {
+	if hostWallet.signer == nil {
 		log.Error("wallet is not initialized")
 		return 0, 0, errors.New("wallet is not initialized")
 	}
@@ -142,7 +155,7 @@ func GetBalance(ctx context.Context, configuration *config.Config) (int64, int64
 		tokenId = TokenIdDev
 	}
 
-	tronBalance, err := GetTokenBalance(ctx, hostWallet.tronAddress, tokenId)
+	tronBalance, err := GetTokenBalance(ctx, hostWallet.walletTron, tokenId)
 	if err != nil {
 		return 0, 0,
 			errors.New(fmt.Sprintf("Failed to get exchange tron balance, reason: %v", err))
@@ -216,10 +229,28 @@ func Init(ctx context.Context, configuration *config.Config) error {
 	}
 
 	hostWallet.ledgerAddress = ledgerAddress
-	return nil
+
+	hostWallet.signer = configuredSigner()
+	if hostWallet.signer == nil {
+		hostWallet.signer = KeySigner(privateKey)
+		hostWallet.walletTron, hostWallet.walletLedger = hostWallet.tronAddress, hostWallet.ledgerAddress
+		return nil
+	}
+	if hostWallet.walletTron, err = signerAddress(hostWallet.signer); err != nil {
+		return err
+	}
+	hostWallet.walletLedger, err = signerLedgerAddress(hostWallet.signer)
+	return err
 }
 
 func Balance(ctx context.Context, configuration *config.Config) (int64, error) {
+	if s := configuredSigner(); s != nil {
+		lgSignedPubKey, err := signedPublicKey(s)
+		if err != nil {
+			return 0, err
+		}
+		return BalanceHelper(ctx, configuration, false, nil, lgSignedPubKey)
+	}
 	privKey, err := configuration.Identity.DecodePrivateKey("")
 	if err != nil {
 		return 0, err
@@ -242,23 +273,16 @@ func BalanceHelper(ctx context.Context, configuration *config.Config, offsign bo
 		lgSignedPubKey = &ledgerSignedPubKey
 	}
 
-	var balance int64 = 0
-	err := grpc.EscrowClient(configuration.Services.EscrowDomain).WithContext(ctx,
-		func(ctx context.Context, client escrowpb.EscrowServiceClient) error {
-			res, err := client.BalanceOf(ctx, ledger.NewSignedCreateAccountRequest(lgSignedPubKey.Key, lgSignedPubKey.Signature))
-			if err != nil {
-				return err
-			}
-			err = escrow.VerifyEscrowRes(configuration, res.Result, res.EscrowSignature)
-			if err != nil {
-				return err
-			}
-			balance = res.Result.Balance
-			log.Debug("balanceof account is ", balance)
-			return nil
-		})
+	res, err := chainBackend(configuration.Services).BalanceOf(ctx,
+		ledger.NewSignedCreateAccountRequest(lgSignedPubKey.Key, lgSignedPubKey.Signature))
+	if err != nil {
+		return 0, err
+	}
+	err = escrow.VerifyEscrowRes(configuration, res.Result, res.EscrowSignature)
 	if err != nil {
 		return 0, err
 	}
+	balance := res.Result.Balance
+	log.Debug("balanceof account is ", balance)
 	return balance, nil
 }
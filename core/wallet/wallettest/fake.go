@@ -0,0 +1,599 @@
+// Package wallettest provides an in-memory ChainBackend of the wallet, for
+// deterministic tests of deposits, withdrawals and transfers.
+//
+// Use it with wallet.SetChainBackend:
+//
+//	f := wallettest.New()
+//	defer wallet.SetChainBackend(f)()
+//	cfg.Services.EscrowPubKeys = []string{f.EscrowPubKey()}
+//	f.SetTronBalance(tronAddr, 100)
+package wallettest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/tron-us/go-btfs-common/crypto"
+	escrowpb "github.com/tron-us/go-btfs-common/protos/escrow"
+	exPb "github.com/tron-us/go-btfs-common/protos/exchange"
+	ledgerPb "github.com/tron-us/go-btfs-common/protos/ledger"
+	tronPb "github.com/tron-us/go-btfs-common/protos/protocol/api"
+	corePb "github.com/tron-us/go-btfs-common/protos/protocol/core"
+
+	"github.com/gogo/protobuf/proto"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultTokenID is the id of the BTT token on the TRON mainnet.
+const DefaultTokenID = "1002000"
+
+// ExchangeAddress is the ledger address of the exchange of a Fake.
+var ExchangeAddress = []byte("exchange")
+
+// ErrUnavailable is an error to make calls fail with, see FailNext.
+var ErrUnavailable = status.Error(codes.Unavailable, "service unavailable")
+
+type txState int
+
+const (
+	txPending txState = iota
+	txSuccess
+	txFailed
+)
+
+// tx is a deposit, a withdrawal or a transfer.
+type tx struct {
+	// ledger and tron are the addresses of the user.
+	ledger, tron []byte
+	to           []byte // transfers only
+	amount       int64
+	submitted    bool
+	state        txState
+	// confirmations is the number of times the transaction is still
+	// reported pending.
+	confirmations int
+	channelID     int64 // deposits only
+}
+
+type channel struct {
+	from, to []byte
+	amount   int64
+	closed   bool
+}
+
+// Fake is an in-memory exchange, ledger and TRON network, implementing
+// wallet.ChainBackend.
+//
+// Transactions are settled when submitted, but are reported pending for
+// Confirmations queries of their status before their outcome. The balance of
+// the exchange is unlimited.
+type Fake struct {
+	// Confirmations is the number of times the status of a transaction is
+	// reported pending before it is confirmed.
+	Confirmations int
+	// TokenID is the id of the BTT token, defaults to DefaultTokenID.
+	TokenID string
+
+	mu        sync.Mutex
+	escrowKey ic.PrivKey
+	ledger    map[string]int64
+	tron      map[string]int64
+	txs       map[int64]*tx
+	transfers map[string]*tx
+	channels  map[int64]*channel
+	lastID    int64
+	failures  map[string][]error
+	reject    int
+	refused   []tronPb.ReturnResponseCode
+}
+
+// New returns an empty Fake.
+func New() *Fake {
+	key, _, err := crypto.GenKeyPairs()
+	if err != nil {
+		panic(err)
+	}
+	return &Fake{
+		TokenID:   DefaultTokenID,
+		escrowKey: key,
+		ledger:    make(map[string]int64),
+		tron:      make(map[string]int64),
+		txs:       make(map[int64]*tx),
+		transfers: make(map[string]*tx),
+		channels:  make(map[int64]*channel),
+		failures:  make(map[string][]error),
+	}
+}
+
+// EscrowPubKey returns the key signing the balances, to set in
+// Services.EscrowPubKeys.
+func (f *Fake) EscrowPubKey() string {
+	b, err := ic.MarshalPublicKey(f.escrowKey.GetPublic())
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// SetLedgerBalance sets the balance of the BTFS wallet at the ledger address.
+func (f *Fake) SetLedgerBalance(addr []byte, amount int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ledger[string(addr)] = amount
+}
+
+// LedgerBalance returns the balance of the BTFS wallet at the ledger address.
+func (f *Fake) LedgerBalance(addr []byte) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ledger[string(addr)]
+}
+
+// SetTronBalance sets the BTT balance of the TRON address.
+func (f *Fake) SetTronBalance(addr []byte, amount int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tron[string(addr)] = amount
+}
+
+// TronBalance returns the BTT balance of the TRON address.
+func (f *Fake) TronBalance(addr []byte) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tron[string(addr)]
+}
+
+// FailNext makes the next call to method, such as "CloseChannel", return
+// err. Calls to FailNext for the same method queue up.
+func (f *Fake) FailNext(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method] = append(f.failures[method], err)
+}
+
+// RejectNext makes the next submitted deposit, withdrawal or transfer fail,
+// once its confirmations are over. Nothing is moved.
+func (f *Fake) RejectNext() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reject++
+}
+
+// RefuseNextBroadcast makes the next broadcast transaction be refused with
+// code, such as Return_TAPOS_ERROR, as if it was never received. Calls queue
+// up.
+func (f *Fake) RefuseNextBroadcast(code tronPb.ReturnResponseCode) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refused = append(f.refused, code)
+}
+
+// fail pops the next failure of method, f.mu must be held.
+func (f *Fake) fail(method string) error {
+	errs := f.failures[method]
+	if len(errs) == 0 {
+		return nil
+	}
+	f.failures[method] = errs[1:]
+	return errs[0]
+}
+
+// rejected reports whether the submitted transaction is rejected, f.mu must
+// be held.
+func (f *Fake) rejected() bool {
+	if f.reject == 0 {
+		return false
+	}
+	f.reject--
+	return true
+}
+
+// confirm reports the state of t, counting down its confirmations.
+func (t *tx) confirm() txState {
+	if t.confirmations > 0 {
+		t.confirmations--
+		return txPending
+	}
+	return t.state
+}
+
+func response(code exPb.ResponseReturnCode, msg string) *exPb.Response {
+	return &exPb.Response{Code: code, ReturnMessage: []byte(msg)}
+}
+
+func tronAddress(ledgerAddr []byte) ([]byte, error) {
+	addr, err := crypto.AddressLedgerToTron(ledgerAddr)
+	if err != nil {
+		return nil, err
+	}
+	return addr.Bytes(), nil
+}
+
+func now() int64 {
+	return time.Now().UnixNano() / 1e6
+}
+
+func (f *Fake) PrepareDeposit(ctx context.Context, in *exPb.PrepareDepositRequest) (*exPb.PrepareDepositResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("PrepareDeposit"); err != nil {
+		return nil, err
+	}
+	tronAddr, err := tronAddress(in.UserAddress)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if f.tron[string(tronAddr)] < in.Amount {
+		return &exPb.PrepareDepositResponse{Response: response(exPb.Response_USER_TRON_BALANCE_INSUFFICIENT,
+			"User balance on tron network is not sufficient.")}, nil
+	}
+	f.lastID++
+	f.txs[f.lastID] = &tx{ledger: in.UserAddress, tron: tronAddr, amount: in.Amount}
+	return &exPb.PrepareDepositResponse{
+		Response: response(exPb.Response_SUCCESS, ""),
+		Id:       f.lastID,
+		TronTransaction: &exPb.TronTransaction{
+			RawData: &exPb.TronTransaction_TronRaw{
+				Contract:   []*exPb.TronTransaction_TronContract{{}},
+				Timestamp:  now(),
+				Expiration: now() + 10*60*1000,
+			},
+		},
+	}, nil
+}
+
+func (f *Fake) Deposit(ctx context.Context, in *exPb.DepositRequest) (*exPb.DepositResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("Deposit"); err != nil {
+		return nil, err
+	}
+	t, ok := f.txs[in.Id]
+	if !ok || t.submitted {
+		return &exPb.DepositResponse{Response: response(exPb.Response_TRANSACTION_NOT_EXIST, "deposit not found")}, nil
+	}
+	if len(in.GetSignedTronTransaction().GetSignature()) == 0 {
+		return &exPb.DepositResponse{Response: response(exPb.Response_SIGN_FAILED, "deposit not signed")}, nil
+	}
+	t.submitted = true
+	t.confirmations = f.Confirmations
+	if f.rejected() || f.tron[string(t.tron)] < t.amount {
+		t.state = txFailed
+	} else {
+		f.tron[string(t.tron)] -= t.amount
+		t.state = txSuccess
+	}
+	return &exPb.DepositResponse{Response: response(exPb.Response_SUCCESS, "")}, nil
+}
+
+func (f *Fake) ConfirmDeposit(ctx context.Context, in *exPb.ConfirmDepositRequest) (*exPb.ConfirmDepositResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ConfirmDeposit"); err != nil {
+		return nil, err
+	}
+	t, ok := f.txs[in.Id]
+	if !ok || !t.submitted {
+		return &exPb.ConfirmDepositResponse{Response: response(exPb.Response_TRANSACTION_NOT_EXIST, "deposit not found")}, nil
+	}
+	switch t.confirm() {
+	case txPending:
+		return &exPb.ConfirmDepositResponse{Response: response(exPb.Response_TRANSACTION_PENDING, "")}, nil
+	case txFailed:
+		return &exPb.ConfirmDepositResponse{Response: response(exPb.Response_TRANSACTION_FAILED, "")}, nil
+	}
+	if t.channelID == 0 {
+		f.lastID++
+		t.channelID = f.lastID
+		f.channels[t.channelID] = &channel{}
+	}
+	return &exPb.ConfirmDepositResponse{
+		Response: response(exPb.Response_SUCCESS, ""),
+		SuccessChannelState: &ledgerPb.SignedChannelState{
+			Channel: &ledgerPb.ChannelState{
+				Id:       &ledgerPb.ChannelID{Id: t.channelID},
+				Sequence: 1,
+				From:     &ledgerPb.Account{Address: &ledgerPb.PublicKey{Key: ExchangeAddress}},
+				To:       &ledgerPb.Account{Address: &ledgerPb.PublicKey{Key: t.ledger}, Balance: t.amount},
+			},
+		},
+	}, nil
+}
+
+func (f *Fake) PrepareWithdraw(ctx context.Context, in *exPb.PrepareWithdrawRequest) (*exPb.PrepareWithdrawResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("PrepareWithdraw"); err != nil {
+		return nil, err
+	}
+	f.lastID++
+	f.txs[f.lastID] = &tx{ledger: in.UserAddress, tron: in.UserExternalAddress, amount: in.Amount}
+	return &exPb.PrepareWithdrawResponse{
+		Response:              response(exPb.Response_SUCCESS, ""),
+		LedgerExchangeAddress: ExchangeAddress,
+		Id:                    f.lastID,
+	}, nil
+}
+
+func (f *Fake) Withdraw(ctx context.Context, in *exPb.WithdrawRequest) (*exPb.WithdrawResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("Withdraw"); err != nil {
+		return nil, err
+	}
+	t, ok := f.txs[in.Id]
+	if !ok || t.submitted {
+		return &exPb.WithdrawResponse{Response: response(exPb.Response_TRANSACTION_NOT_EXIST, "withdrawal not found")}, nil
+	}
+	t.submitted = true
+	t.confirmations = f.Confirmations
+	if f.rejected() {
+		t.state = txFailed
+		if err := f.closeChannel(in.FailureChannelState); err != nil {
+			return nil, err
+		}
+		return &exPb.WithdrawResponse{Response: response(exPb.Response_TRANSACTION_FAILED, "withdrawal rejected")}, nil
+	}
+	if err := f.closeChannel(in.SuccessChannelState); err != nil {
+		return nil, err
+	}
+	f.tron[string(t.tron)] += t.amount
+	t.state = txSuccess
+	return &exPb.WithdrawResponse{Response: response(exPb.Response_SUCCESS, "")}, nil
+}
+
+func (f *Fake) QueryTransaction(ctx context.Context, in *exPb.QueryTransactionRequest) (*exPb.QueryTransactionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("QueryTransaction"); err != nil {
+		return nil, err
+	}
+	t, ok := f.txs[in.Id]
+	if !ok || !t.submitted {
+		return &exPb.QueryTransactionResponse{Response: response(exPb.Response_TRANSACTION_NOT_EXIST, "")}, nil
+	}
+	code := exPb.Response_SUCCESS
+	switch t.confirm() {
+	case txPending:
+		code = exPb.Response_TRANSACTION_PENDING
+	case txFailed:
+		code = exPb.Response_TRANSACTION_FAILED
+	}
+	return &exPb.QueryTransactionResponse{
+		Response:    response(code, ""),
+		Amount:      t.amount,
+		UserAddress: t.ledger,
+	}, nil
+}
+
+func (f *Fake) BalanceOf(ctx context.Context, in *ledgerPb.SignedCreateAccountRequest) (*escrowpb.SignedBalanceResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("BalanceOf"); err != nil {
+		return nil, err
+	}
+	res := &escrowpb.BalanceResult{
+		Balance:          f.ledger[string(in.GetKey().GetKey())],
+		EscrowSignedTime: time.Now(),
+	}
+	sig, err := crypto.Sign(f.escrowKey, res)
+	if err != nil {
+		return nil, err
+	}
+	return &escrowpb.SignedBalanceResult{Result: res, EscrowSignature: sig}, nil
+}
+
+func (f *Fake) CreateChannel(ctx context.Context, in *ledgerPb.SignedChannelCommit) (*ledgerPb.ChannelID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreateChannel"); err != nil {
+		return nil, err
+	}
+	payer := string(in.GetChannel().GetPayer().GetKey())
+	if f.ledger[payer] < in.GetChannel().GetAmount() {
+		return nil, status.Error(codes.ResourceExhausted, "NSF")
+	}
+	f.ledger[payer] -= in.GetChannel().GetAmount()
+	f.lastID++
+	f.channels[f.lastID] = &channel{
+		from:   in.GetChannel().GetPayer().GetKey(),
+		to:     in.GetChannel().GetRecipient().GetKey(),
+		amount: in.GetChannel().GetAmount(),
+	}
+	return &ledgerPb.ChannelID{Id: f.lastID}, nil
+}
+
+func (f *Fake) CloseChannel(ctx context.Context, in *ledgerPb.SignedChannelState) (*ledgerPb.ChannelClosed, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CloseChannel"); err != nil {
+		return nil, err
+	}
+	if err := f.closeChannel(in); err != nil {
+		return nil, err
+	}
+	return &ledgerPb.ChannelClosed{State: in}, nil
+}
+
+// closeChannel pays out the final state of a channel, f.mu must be held.
+func (f *Fake) closeChannel(in *ledgerPb.SignedChannelState) error {
+	ch, ok := f.channels[in.GetChannel().GetId().GetId()]
+	if !ok {
+		return status.Error(codes.NotFound, "channel not found")
+	}
+	if ch.closed {
+		return status.Error(codes.FailedPrecondition, "channel closed")
+	}
+	ch.closed = true
+	for _, acc := range []*ledgerPb.Account{in.GetChannel().GetFrom(), in.GetChannel().GetTo()} {
+		f.ledger[string(acc.GetAddress().GetKey())] += acc.GetBalance()
+	}
+	return nil
+}
+
+func (f *Fake) ChannelInfo(ctx context.Context, in *ledgerPb.ChannelID) (*ledgerPb.ChannelInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ChannelInfo"); err != nil {
+		return nil, err
+	}
+	ch, ok := f.channels[in.GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "channel not found")
+	}
+	info := &ledgerPb.ChannelInfo{
+		Id:          &ledgerPb.ChannelID{Id: in.GetId()},
+		FromAccount: &ledgerPb.Account{Address: &ledgerPb.PublicKey{Key: ch.from}, Balance: ch.amount},
+		ToAccount:   &ledgerPb.Account{Address: &ledgerPb.PublicKey{Key: ch.to}},
+	}
+	if ch.closed {
+		info.CloseSequence = 1
+	}
+	return info, nil
+}
+
+func (f *Fake) GetAccount(ctx context.Context, in *corePb.Account) (*corePb.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetAccount"); err != nil {
+		return nil, err
+	}
+	return &corePb.Account{
+		Address: in.Address,
+		AssetV2: map[string]int64{f.TokenID: f.tron[string(in.Address)]},
+	}, nil
+}
+
+// txID returns the id of a TRON transaction, the hash of its raw data.
+func txID(raw *corePb.TransactionRaw) ([]byte, error) {
+	b, err := proto.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(b)
+	return sum[:], nil
+}
+
+func (f *Fake) TransferAsset2(ctx context.Context, in *corePb.TransferAssetContract) (*tronPb.TransactionExtention, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("TransferAsset2"); err != nil {
+		return nil, err
+	}
+	if string(in.AssetName) != f.TokenID {
+		return &tronPb.TransactionExtention{Result: &tronPb.Return{
+			Code: tronPb.Return_CONTRACT_VALIDATE_ERROR, Message: []byte("No asset!")}}, nil
+	}
+	if f.tron[string(in.OwnerAddress)] < in.Amount {
+		return &tronPb.TransactionExtention{Result: &tronPb.Return{
+			Code: tronPb.Return_CONTRACT_VALIDATE_ERROR, Message: []byte("assetBalance is not sufficient.")}}, nil
+	}
+	f.lastID++
+	raw := &corePb.TransactionRaw{
+		RefBlockNum: f.lastID,
+		Timestamp:   now(),
+		Expiration:  now() + 60*1000,
+	}
+	id, err := txID(raw)
+	if err != nil {
+		return nil, err
+	}
+	f.transfers[string(id)] = &tx{tron: in.OwnerAddress, to: in.ToAddress, amount: in.Amount}
+	return &tronPb.TransactionExtention{
+		Transaction: &corePb.Transaction{RawData: raw},
+		Txid:        id,
+		Result:      &tronPb.Return{Result: true, Code: tronPb.Return_SUCCESS},
+	}, nil
+}
+
+func (f *Fake) BroadcastTransaction(ctx context.Context, in *corePb.Transaction) (*tronPb.Return, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("BroadcastTransaction"); err != nil {
+		return nil, err
+	}
+	id, err := txID(in.RawData)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := f.transfers[string(id)]
+	if !ok || t.submitted {
+		return &tronPb.Return{Code: tronPb.Return_OTHER_ERROR, Message: []byte("transaction not found")}, nil
+	}
+	if len(f.refused) > 0 {
+		code := f.refused[0]
+		f.refused = f.refused[1:]
+		delete(f.transfers, string(id))
+		return &tronPb.Return{Code: code, Message: []byte("refused")}, nil
+	}
+	if len(in.Signature) == 0 {
+		return &tronPb.Return{Code: tronPb.Return_SIGERROR, Message: []byte("transaction not signed")}, nil
+	}
+	t.submitted = true
+	t.confirmations = f.Confirmations
+	if f.rejected() || f.tron[string(t.tron)] < t.amount {
+		t.state = txFailed
+	} else {
+		f.tron[string(t.tron)] -= t.amount
+		f.tron[string(t.to)] += t.amount
+		t.state = txSuccess
+	}
+	return &tronPb.Return{Result: true, Code: tronPb.Return_SUCCESS}, nil
+}
+
+// transfer returns the broadcast transfer with id, f.mu must be held.
+func (f *Fake) transfer(id []byte) (*tx, error) {
+	t, ok := f.transfers[string(id)]
+	if !ok || !t.submitted {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	return t, nil
+}
+
+// GetTransactionById returns the transaction without result while it is
+// pending, like a solidity node before the transaction is confirmed.
+func (f *Fake) GetTransactionById(ctx context.Context, in *tronPb.BytesMessage) (*corePb.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetTransactionById"); err != nil {
+		return nil, err
+	}
+	t, err := f.transfer(in.Value)
+	if err != nil {
+		return nil, err
+	}
+	ret := &corePb.Transaction_Result{ContractRet: corePb.Transaction_Result_SUCCESS}
+	switch t.confirm() {
+	case txPending:
+		return &corePb.Transaction{}, nil
+	case txFailed:
+		ret.ContractRet = corePb.Transaction_Result_REVERT
+	}
+	return &corePb.Transaction{Ret: []*corePb.Transaction_Result{ret}}, nil
+}
+
+// GetTransactionInfoById fails with NotFound while the transaction is
+// pending.
+func (f *Fake) GetTransactionInfoById(ctx context.Context, in *tronPb.BytesMessage) (*corePb.TransactionInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetTransactionInfoById"); err != nil {
+		return nil, err
+	}
+	t, err := f.transfer(in.Value)
+	if err != nil {
+		return nil, err
+	}
+	switch t.confirm() {
+	case txPending:
+		return nil, status.Error(codes.NotFound, "transaction not confirmed")
+	case txFailed:
+		return &corePb.TransactionInfo{Id: in.Value, Result: corePb.TransactionInfo_FAILED}, nil
+	}
+	return &corePb.TransactionInfo{Id: in.Value, Result: corePb.TransactionInfo_SUCESS}, nil
+}
@@ -0,0 +1,205 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+
+	config "github.com/TRON-US/go-btfs-config"
+
+	"github.com/google/uuid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// Stages of a withdrawal to an external address.
+const (
+	FlowWithdrawing  = "withdrawing"
+	FlowConfirming   = "confirming"
+	FlowTransferring = "transferring"
+	FlowDone         = "done"
+	FlowFailed       = "failed"
+)
+
+var (
+	// withdrawConfirmInterval is how often the withdrawal of a flow is
+	// checked until confirmed.
+	withdrawConfirmInterval = 5 * time.Second
+	// withdrawToTimeout bounds the confirmation and the transfer of a flow.
+	withdrawToTimeout = 30 * time.Minute
+)
+
+var walletWithdrawFlowKeyPrefix = "/btfs/%v/wallet/withdrawals/"
+
+// WithdrawFlow is a withdrawal from the ledger followed by a transfer of
+// the amount withdrawn to an external address.
+type WithdrawFlow struct {
+	ID           string
+	To           string
+	Amount       int64
+	Stage        string
+	WithdrawTxId string `json:",omitempty"`
+	TransferTxId string `json:",omitempty"`
+	Error        string `json:",omitempty"`
+	// Rollback tells where the amount is after a failure.
+	Rollback string `json:",omitempty"`
+	Created  time.Time
+	Updated  time.Time
+}
+
+// Final reports whether the flow is over.
+func (f *WithdrawFlow) Final() bool {
+	return f.Stage == FlowDone || f.Stage == FlowFailed
+}
+
+// runningFlows are the flows driven by this process.
+var runningFlows sync.Map
+
+// WithdrawTo withdraws amount from the ledger to the BTT wallet of n, then
+// transfers it to the address to once the withdrawal is confirmed. It
+// returns after the withdrawal, the transfer is made in the background and
+// tracked by the flow returned, see ListWithdrawFlows.
+func WithdrawTo(ctx context.Context, cfg *config.Config, n *core.IpfsNode, amount int64,
+	to string) (*WithdrawFlow, error) {
+	if _, err := toHex(to); err != nil {
+		return nil, fmt.Errorf("invalid address %s: %s", to, err)
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	d, peerId := n.Repo.Datastore(), n.Identity.Pretty()
+	f := &WithdrawFlow{ID: id.String(), To: to, Amount: amount, Stage: FlowWithdrawing, Created: time.Now()}
+	runningFlows.Store(f.ID, true)
+	if err := putWithdrawFlow(d, peerId, f); err != nil {
+		runningFlows.Delete(f.ID)
+		return nil, err
+	}
+	_, txId, err := WithdrawLedger(ctx, cfg, n, amount)
+	if err != nil {
+		defer runningFlows.Delete(f.ID)
+		f.fail(err, "nothing was withdrawn, the amount stays in the ledger")
+		if perr := putWithdrawFlow(d, peerId, f); perr != nil {
+			log.Errorf("record withdrawal %s: %s", f.ID, perr)
+		}
+		return nil, err
+	}
+	f.WithdrawTxId = strconv.FormatInt(txId, 10)
+	f.Stage = FlowConfirming
+	if err := putWithdrawFlow(d, peerId, f); err != nil {
+		runningFlows.Delete(f.ID)
+		return nil, err
+	}
+	go func() {
+		defer runningFlows.Delete(f.ID)
+		ctx, cancel := context.WithTimeout(context.Background(), withdrawToTimeout)
+		defer cancel()
+		c := *f
+		completeWithdrawFlow(ctx, cfg, n, &c)
+		if err := putWithdrawFlow(d, peerId, &c); err != nil {
+			log.Errorf("record withdrawal %s: %s", c.ID, err)
+		}
+	}()
+	return f, nil
+}
+
+// completeWithdrawFlow waits for the withdrawal of f to be confirmed, then
+// transfers its amount.
+func completeWithdrawFlow(ctx context.Context, cfg *config.Config, n *core.IpfsNode, f *WithdrawFlow) {
+	for {
+		status, err := getExchangeTxStatus(ctx, cfg, f.WithdrawTxId)
+		if err != nil {
+			log.Debugf("check withdrawal %s: %s", f.WithdrawTxId, err)
+		}
+		if err == nil && status == StatusSuccess {
+			break
+		}
+		if err == nil && status == StatusFailed {
+			f.fail(errors.New("withdrawal failed"),
+				"the exchange returns the amount to the ledger, nothing was transferred")
+			return
+		}
+		select {
+		case <-time.After(withdrawConfirmInterval):
+		case <-ctx.Done():
+			f.fail(errors.New("withdrawal not confirmed in time"), fmt.Sprintf(
+				"check transaction %s with 'btfs wallet transactions', once confirmed the amount is in the "+
+					"BTT wallet of the node", f.WithdrawTxId))
+			return
+		}
+	}
+
+	f.Stage = FlowTransferring
+	if err := putWithdrawFlow(n.Repo.Datastore(), n.Identity.Pretty(), f); err != nil {
+		log.Errorf("record withdrawal %s: %s", f.ID, err)
+	}
+	ret, err := TransferBTT(ctx, n, cfg, nil, "", f.To, f.Amount)
+	if err != nil {
+		f.fail(err, fmt.Sprintf("the amount withdrawn stays in the BTT wallet of the node, transfer it with "+
+			"'btfs wallet transfer %s %d' or deposit it back with 'btfs wallet deposit %d'", f.To, f.Amount,
+			f.Amount))
+		return
+	}
+	f.TransferTxId = ret.TxId
+	f.Stage = FlowDone
+}
+
+func (f *WithdrawFlow) fail(err error, rollback string) {
+	f.Error = fmt.Sprintf("%s: %s", f.Stage, err)
+	f.Rollback = rollback
+	f.Stage = FlowFailed
+}
+
+func putWithdrawFlow(d ds.Datastore, peerId string, f *WithdrawFlow) error {
+	f.Updated = time.Now()
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return d.Put(ds.NewKey(fmt.Sprintf(walletWithdrawFlowKeyPrefix, peerId)+f.ID), b)
+}
+
+// ListWithdrawFlows returns the withdrawals to external addresses of
+// peerId, the latest first. The flows interrupted by a restart of the
+// daemon are reported failed.
+func ListWithdrawFlows(d ds.Datastore, peerId string) ([]*WithdrawFlow, error) {
+	// a flow is recorded over before it stops running, those running
+	// before the query are not interrupted whatever their stage read
+	running := map[string]bool{}
+	runningFlows.Range(func(id, _ interface{}) bool {
+		running[id.(string)] = true
+		return true
+	})
+	results, err := d.Query(query.Query{Prefix: fmt.Sprintf(walletWithdrawFlowKeyPrefix, peerId)})
+	if err != nil {
+		return nil, err
+	}
+	fs := make([]*WithdrawFlow, 0)
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		f := &WithdrawFlow{}
+		if err := json.Unmarshal(r.Value, f); err != nil {
+			return nil, fmt.Errorf("invalid withdrawal %s: %s", r.Key, err)
+		}
+		if !running[f.ID] && !f.Final() {
+			f.fail(errors.New("interrupted by a restart of the daemon"), fmt.Sprintf(
+				"check transaction %s with 'btfs wallet transactions' and the balance of the BTT wallet "+
+					"with 'btfs wallet balance', then transfer the amount with 'btfs wallet transfer %s %d'",
+				f.WithdrawTxId, f.To, f.Amount))
+		}
+		fs = append(fs, f)
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		return fs[i].Created.After(fs[j].Created)
+	})
+	return fs, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/node/libp2p"
+	"github.com/TRON-US/go-btfs/core/nodeapi"
+	"github.com/TRON-US/go-btfs/plugin/loader"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	config "github.com/TRON-US/go-btfs-config"
+	files "github.com/TRON-US/go-btfs-files"
+	"github.com/TRON-US/interface-go-btfs-core/options"
+)
+
+// spawn starts an online node on the repo at repoPath.
+func spawn(ctx context.Context, repoPath string) (nodeapi.API, error) {
+	plugins, err := loader.NewPluginLoader(filepath.Join(repoPath, "plugins"))
+	if err != nil {
+		return nil, err
+	}
+	if err := plugins.Initialize(); err != nil {
+		return nil, err
+	}
+	if err := plugins.Inject(); err != nil {
+		return nil, err
+	}
+	r, err := fsrepo.Open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	n, err := core.NewNode(ctx, &core.BuildCfg{
+		Online:  true,
+		Routing: libp2p.DHTOption,
+		Repo:    r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodeapi.New(n, repoPath)
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatalf("usage: %s <file>", os.Args[0])
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The repo must have Experimental.StorageClientEnabled set and a
+	// funded wallet, see "btfs wallet deposit".
+	repoPath, err := config.PathRoot()
+	if err != nil {
+		log.Fatal(err)
+	}
+	api, err := spawn(ctx, repoPath)
+	if err != nil {
+		log.Fatalf("failed to spawn node: %s", err)
+	}
+
+	balance, err := api.Wallet().Balance(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("BTFS wallet balance: %d µBTT\n", balance.Ledger)
+
+	// Files are stored on hosts as reed-solomon shards.
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	p, err := api.Unixfs().Add(ctx, files.NewReaderFile(f),
+		options.Unixfs.Chunker("reed-solomon-10-20-262144"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("added %s\n", p.Cid())
+
+	id, err := api.Storage().Upload(ctx, p, &nodeapi.UploadOptions{StorageLength: 30})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("upload session %s started\n", id)
+	status, err := api.Storage().WaitUpload(ctx, id)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("stored on %d hosts\n", len(status.Shards))
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	core "github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/repo/configschema"
 
 	"github.com/blang/semver"
 	unix "golang.org/x/sys/unix"
@@ -18,6 +19,7 @@ import (
 func init() {
 	// this is a hack, but until we need to do it another way, this works.
 	platformFuseChecks = darwinFuseCheckVersion
+	configschema.RegisterSection(dontCheckOSXFUSEConfigKey, false)
 }
 
 // dontCheckOSXFUSEConfigKey is a key used to let the user tell us to
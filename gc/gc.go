@@ -44,6 +44,16 @@ type Result struct {
 // The routine then iterates over every block in the blockstore and
 // deletes any block that is not found in the marked set.
 func GC(ctx context.Context, bs bstore.GCBlockstore, dstor dstore.Datastore, pn pin.Pinner, bestEffortRoots []cid.Cid) <-chan Result {
+	return GCWithRoots(ctx, bs, dstor, pn, func(context.Context) ([]cid.Cid, error) {
+		return bestEffortRoots, nil
+	})
+}
+
+// GCWithRoots is GC with the best-effort roots returned by roots, called
+// once the GC lock is held, so that the roots recorded by the writers
+// holding the pin lock before are not missed.
+func GCWithRoots(ctx context.Context, bs bstore.GCBlockstore, dstor dstore.Datastore, pn pin.Pinner,
+	roots func(context.Context) ([]cid.Cid, error)) <-chan Result {
 	ctx, cancel := context.WithCancel(ctx)
 
 	unlocker := bs.GCLock()
@@ -58,6 +68,14 @@ func GC(ctx context.Context, bs bstore.GCBlockstore, dstor dstore.Datastore, pn
 		defer close(output)
 		defer unlocker.Unlock()
 
+		bestEffortRoots, err := roots(ctx)
+		if err != nil {
+			select {
+			case output <- Result{Error: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
 		gcs, err := ColoredSet(ctx, pn, ds, bestEffortRoots, output)
 		if err != nil {
 			select {
@@ -0,0 +1,212 @@
+package gc
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	pin "github.com/TRON-US/go-btfs-pinner"
+	bserv "github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-verifcid"
+)
+
+// OtherGroup and UnknownAge name the blocks of a report in no group and of
+// unknown age.
+const (
+	OtherGroup = "other"
+	UnknownAge = "unknown"
+)
+
+// AgeBuckets are the age buckets of the blocks a report counts, by upper
+// bound.
+var AgeBuckets = []struct {
+	Name string
+	Max  time.Duration
+}{
+	{"< 1 day", 24 * time.Hour},
+	{"1-7 days", 7 * 24 * time.Hour},
+	{"7-30 days", 30 * 24 * time.Hour},
+	{"> 30 days", 1<<63 - 1},
+}
+
+// Groups names sets of DAG roots. A block is in the group of the first
+// roots, in name order, reaching it.
+type Groups map[string][]cid.Cid
+
+// Bucket counts blocks and their size.
+type Bucket struct {
+	Name   string
+	Blocks uint64
+	Bytes  uint64
+}
+
+func (b *Bucket) add(size int) {
+	b.Blocks++
+	b.Bytes += uint64(size)
+}
+
+// Report is what a garbage collection would remove.
+type Report struct {
+	Removed Bucket
+	// ByAge breaks the removed blocks down by the time since they were
+	// written, see AgeBuckets.
+	ByAge []Bucket
+	// BySubsystem breaks the removed blocks down by the group of
+	// subsystem roots reaching them.
+	BySubsystem []Bucket
+	// Kept counts the blocks which are not pinned but kept by the best
+	// effort roots, by group.
+	Kept []Bucket
+}
+
+// DryRun computes what GC would remove with the same pins and best effort
+// roots, without removing anything or unpinning expired pins. The removed
+// blocks are broken down by the subsystems groups, and by age when written
+// returns when a block was written. onRemove, if not nil, is called with
+// every block GC would remove.
+func DryRun(ctx context.Context, bs bstore.Blockstore, pn pin.Pinner, bestEffort, subsystems Groups,
+	written func(cid.Cid) (time.Time, bool), onRemove func(cid.Cid)) (*Report, error) {
+	ng := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+
+	pinned := cid.NewSet()
+	rkeys, err := pn.RecursiveKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := reach(ctx, pn, ng, pinned, rkeys, false); err != nil {
+		return nil, err
+	}
+	dkeys, err := pn.DirectKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range dkeys {
+		if !pn.IsExpiredPin(ctx, k) {
+			pinned.Add(k)
+		}
+	}
+	ikeys, err := pn.InternalPins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := reach(ctx, pn, ng, pinned, ikeys, false); err != nil {
+		return nil, err
+	}
+
+	kept, keptSets, err := groupSets(ctx, pn, ng, bestEffort)
+	if err != nil {
+		return nil, err
+	}
+	bySubsystem, subsystemSets, err := groupSets(ctx, pn, ng, subsystems)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{
+		Removed:     Bucket{Name: "removed"},
+		BySubsystem: append(bySubsystem, Bucket{Name: OtherGroup}),
+		Kept:        kept,
+	}
+	for _, b := range AgeBuckets {
+		r.ByAge = append(r.ByAge, Bucket{Name: b.Name})
+	}
+	r.ByAge = append(r.ByAge, Bucket{Name: UnknownAge})
+
+	keys, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+blocks:
+	for k := range keys {
+		if pinned.Has(k) {
+			continue
+		}
+		size, err := bs.GetSize(k)
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range keptSets {
+			if s.Has(k) {
+				r.Kept[i].add(size)
+				continue blocks
+			}
+		}
+
+		r.Removed.add(size)
+		if onRemove != nil {
+			onRemove(k)
+		}
+		group := len(subsystemSets)
+		for i, s := range subsystemSets {
+			if s.Has(k) {
+				group = i
+				break
+			}
+		}
+		r.BySubsystem[group].add(size)
+		age := len(AgeBuckets)
+		if t, ok := written(k); ok {
+			for i, b := range AgeBuckets {
+				if now.Sub(t) < b.Max {
+					age = i
+					break
+				}
+			}
+		}
+		r.ByAge[age].add(size)
+	}
+	return r, ctx.Err()
+}
+
+// groupSets returns a bucket and the set of reached blocks of every group,
+// in name order.
+func groupSets(ctx context.Context, pn pin.Pinner, ng ipld.NodeGetter, groups Groups) ([]Bucket, []*cid.Set, error) {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buckets := make([]Bucket, len(names))
+	sets := make([]*cid.Set, len(names))
+	for i, name := range names {
+		buckets[i].Name = name
+		sets[i] = cid.NewSet()
+		if err := reach(ctx, pn, ng, sets[i], groups[name], true); err != nil {
+			return nil, nil, err
+		}
+	}
+	return buckets, sets, nil
+}
+
+// reach adds to set the blocks reachable from roots as Descendants does,
+// but leaves the expired pins in place. Missing blocks are skipped when
+// bestEffort is set.
+func reach(ctx context.Context, pn pin.Pinner, ng ipld.NodeGetter, set *cid.Set, roots []cid.Cid, bestEffort bool) error {
+	getLinks := func(ctx context.Context, c cid.Cid) ([]*ipld.Link, error) {
+		if err := verifcid.ValidateCid(c); err != nil {
+			return nil, err
+		}
+		if pn.IsExpiredPin(ctx, c) {
+			return nil, nil
+		}
+		links, err := ipld.GetLinks(ctx, ng, c)
+		if bestEffort && err == ipld.ErrNotFound {
+			return nil, nil
+		}
+		return links, err
+	}
+	for _, c := range roots {
+		if pn.IsExpiredPin(ctx, c) {
+			continue
+		}
+		if err := dag.Walk(ctx, getLinks, c, set.Visit, dag.Concurrent()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package gc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pin "github.com/TRON-US/go-btfs-pinner"
+	bserv "github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	dag "github.com/ipfs/go-merkledag"
+)
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	d := dssync.MutexWrap(ds.NewMapDatastore())
+	bs := bstore.NewBlockstore(d)
+	dserv := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+	pn := pin.NewPinner(d, dserv, dserv)
+
+	tree := func(name string) *dag.ProtoNode {
+		leaf := dag.NodeWithData([]byte(name + " leaf"))
+		root := dag.NodeWithData([]byte(name))
+		if err := root.AddNodeLink("leaf", leaf); err != nil {
+			t.Fatal(err)
+		}
+		for _, nd := range []*dag.ProtoNode{leaf, root} {
+			if err := dserv.Add(ctx, nd); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return root
+	}
+	pinned, mfs, shard, other := tree("pinned"), tree("mfs"), tree("shard"), tree("other")
+	if err := pn.Pin(ctx, pinned, true, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := pn.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	old := map[cid.Cid]bool{other.Cid(): true}
+	written := func(c cid.Cid) (time.Time, bool) {
+		if old[c] {
+			return time.Now().Add(-10 * 24 * time.Hour), true
+		}
+		return time.Time{}, false
+	}
+	var removed []cid.Cid
+	r, err := DryRun(ctx, bs, pn, Groups{"mfs": {mfs.Cid()}}, Groups{"shards": {shard.Cid()}},
+		written, func(c cid.Cid) { removed = append(removed, c) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Removed.Blocks != 4 || len(removed) != 4 {
+		t.Fatalf("would remove %d blocks, listed %d", r.Removed.Blocks, len(removed))
+	}
+	for _, c := range removed {
+		if has, err := bs.Has(c); err != nil || !has {
+			t.Fatalf("removed %s", c)
+		}
+	}
+	if b := r.BySubsystem[0]; b.Name != "shards" || b.Blocks != 2 {
+		t.Errorf("shards: %+v", b)
+	}
+	if b := r.BySubsystem[1]; b.Name != OtherGroup || b.Blocks != 2 {
+		t.Errorf("other: %+v", b)
+	}
+	if b := r.ByAge[2]; b.Blocks != 1 {
+		t.Errorf("7-30 days: %+v", b)
+	}
+	if b := r.ByAge[len(r.ByAge)-1]; b.Name != UnknownAge || b.Blocks != 3 {
+		t.Errorf("unknown age: %+v", b)
+	}
+	if b := r.Kept[0]; b.Name != "mfs" || b.Blocks != 2 {
+		t.Errorf("kept by mfs: %+v", b)
+	}
+}
@@ -1,2 +1,2 @@
-(function(t){function e(e){for(var n,o,r=e[0],c=e[1],l=e[2],d=0,p=[];d<r.length;d++)o=r[d],Object.prototype.hasOwnProperty.call(a,o)&&a[o]&&p.push(a[o][0]),a[o]=0;for(n in c)Object.prototype.hasOwnProperty.call(c,n)&&(t[n]=c[n]);u&&u(e);while(p.length)p.shift()();return i.push.apply(i,l||[]),s()}function s(){for(var t,e=0;e<i.length;e++){for(var s=i[e],n=!0,r=1;r<s.length;r++){var c=s[r];0!==a[c]&&(n=!1)}n&&(i.splice(e--,1),t=o(o.s=s[0]))}return t}var n={},a={app:0},i=[];function o(e){if(n[e])return n[e].exports;var s=n[e]={i:e,l:!1,exports:{}};return t[e].call(s.exports,s,s.exports,o),s.l=!0,s.exports}o.m=t,o.c=n,o.d=function(t,e,s){o.o(t,e)||Object.defineProperty(t,e,{enumerable:!0,get:s})},o.r=function(t){"undefined"!==typeof Symbol&&Symbol.toStringTag&&Object.defineProperty(t,Symbol.toStringTag,{value:"Module"}),Object.defineProperty(t,"__esModule",{value:!0})},o.t=function(t,e){if(1&e&&(t=o(t)),8&e)return t;if(4&e&&"object"===typeof t&&t&&t.__esModule)return t;var s=Object.create(null);if(o.r(s),Object.defineProperty(s,"default",{enumerable:!0,value:t}),2&e&&"string"!=typeof t)for(var n in t)o.d(s,n,function(e){return t[e]}.bind(null,n));return s},o.n=function(t){var e=t&&t.__esModule?function(){return t["default"]}:function(){return t};return o.d(e,"a",e),e},o.o=function(t,e){return Object.prototype.hasOwnProperty.call(t,e)},o.p="";var r=window["webpackJsonp"]=window["webpackJsonp"]||[],c=r.push.bind(r);r.push=e,r=r.slice();for(var l=0;l<r.length;l++)e(r[l]);var u=c;i.push([0,"chunk-vendors"]),s()})({0:function(t,e,s){t.exports=s("56d7")},"01f9":function(t,e){t.exports='<svg width="20" height="20" viewBox="0 0 20 20" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M10 0C4.48 0 0 4.48 0 10C0 15.52 4.48 20 10 20C15.52 20 20 15.52 20 10C20 4.48 15.52 0 10 0ZM9 17V15H11V17H9ZM12.17 10.17L13.07 9.25C13.64 8.68 14 7.88 14 7C14 4.79 12.21 3 10 3C7.79 3 6 4.79 6 7H8C8 5.9 8.9 5 10 5C11.1 5 12 5.9 12 7C12 7.55 11.78 8.05 11.41 8.41L10.17 9.67C9.45 10.4 9 11.4 9 12.5V13H11C11 11.5 11.45 10.9 12.17 10.17Z" fill="#3E7EFF"/>\n</svg>\n'},"0273":function(t,e,s){"use strict";var n=s("40c9"),a=s.n(n);a.a},"03c5":function(t,e,s){var n={"./BTFS-logo.svg":"0e0e","./btfs-logo-2.svg":"f328","./check.svg":"5254","./close.svg":"da2b","./copy.svg":"5d63","./down-arrow.svg":"2019","./edit.svg":"f37a","./error.svg":"aa20","./eye-invisible.svg":"6eaf","./eye-visible.svg":"96e2","./help.svg":"01f9","./info.svg":"d833","./refresh.svg":"2dcb","./spinner.svg":"794c"};function a(t){var e=i(t);return s(e)}function i(t){if(!s.o(n,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return n[t]}a.keys=function(){return Object.keys(n)},a.resolve=i,t.exports=a,a.id="03c5"},"06a9":function(t,e,s){"use strict";var n=s("6faf"),a=s.n(n);a.a},"0e0e":function(t,e){t.exports='<svg width="150" height="48" viewBox="0 0 150 48" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M52 4H59.7468C61.4084 4 62.6419 4.41713 63.4958 5.1326C64.4164 5.91934 64.8882 6.98066 64.8882 8.29806C64.8882 9.94019 64.0574 10.9804 62.6419 11.5348V11.6034C64.3933 12.1579 65.5164 13.6838 65.5164 15.6481C65.5164 17.3113 64.9318 18.5152 63.8779 19.3468C62.9342 20.1098 61.611 20.5269 59.9263 20.5269H52V4ZM59.1621 10.5421C60.3083 10.5421 61.093 9.96396 61.093 8.87888C61.093 7.83869 60.3519 7.23675 59.1852 7.23675H55.9285V10.5421H59.1621ZM59.4749 17.2453C60.7776 17.2453 61.5854 16.4823 61.5854 15.3049C61.5854 13.9874 60.6417 13.3169 59.4314 13.3169H55.9285V17.2453H59.4749ZM67.0191 4H70.6784V7.05194H67.0191V4ZM67.0191 8.69143H70.6784V20.5269H67.0191V8.69143ZM73.3965 17.4064V11.049H71.8708V8.69143H73.3965V4.94779H76.9661V8.69143H79.0534V11.049H76.9661V16.5959C76.9661 17.5199 77.461 17.7522 78.2457 17.7522C78.436 17.7522 78.8413 17.7436 79.1626 17.7367L79.1627 17.7367C79.3736 17.7322 79.5483 17.7284 79.6022 17.7284V20.4794C79.2868 20.5718 78.0456 20.6642 77.0584 20.6642C74.9223 20.6642 73.3965 19.9488 73.3965 17.4064ZM82.5229 7.46643H78.3995V4H90.6646V7.46643H86.4976V20.5269H82.5229V7.46643ZM88.208 14.6316C88.208 11.0728 90.6774 8.3667 94.3597 8.3667C98.0652 8.3667 100.488 11.0702 100.488 14.6316C100.488 18.1931 98.0626 20.8966 94.3597 20.8966C90.6774 20.8966 88.208 18.1931 88.208 14.6316ZM96.783 14.6316C96.783 12.4826 95.9291 11.0253 94.3367 11.0253C92.7417 11.0253 91.9108 12.4826 91.9108 14.6316C91.9108 16.7807 92.7417 18.2142 94.3367 18.2142C95.9317 18.2142 96.783 16.7833 96.783 14.6316ZM101.781 8.69143H105.284V10.5395H105.35C106.158 9.12969 107.179 8.54623 108.22 8.50663C108.556 8.49343 108.781 8.53039 108.938 8.59903V11.8358H108.848C106.912 11.5665 105.44 12.6436 105.44 15.2098V20.5269H101.781V8.69143ZM110.033 8.69143H113.536V10.5395H113.602C114.41 9.12969 115.431 8.54623 116.472 8.50663C116.808 8.49343 117.033 8.53039 117.19 8.59903V11.8358H117.1C115.164 11.5665 113.692 12.6436 113.692 15.2098V20.5269H110.033V8.69143ZM117.305 14.5867C117.305 11.049 119.708 8.34558 123.211 8.34558C124.849 8.34558 126.152 8.9 127.139 9.82403C128.509 11.1177 129.183 13.1769 129.16 15.5794H120.898C121.123 17.174 121.975 18.1693 123.48 18.1693C124.444 18.1693 125.075 17.7311 125.388 16.9892H128.957C128.711 18.0532 128.037 19.0696 127.026 19.7851C126.062 20.4794 124.893 20.8728 123.434 20.8728C119.639 20.8728 117.305 18.1693 117.305 14.5867ZM125.454 13.338C125.321 11.9282 124.467 11.0279 123.277 11.0279C121.885 11.0279 121.167 11.9282 120.921 13.338H125.454ZM130.419 8.69143H133.945V10.3098H134.012C134.865 9.03728 135.966 8.34558 137.558 8.34558C140.074 8.34558 141.553 10.1936 141.553 12.8311V20.5269H137.894V13.5914C137.894 12.3427 137.289 11.4873 136.099 11.4873C134.886 11.4873 134.078 12.5275 134.078 13.9848V20.5269H130.419V8.69143ZM143.794 17.4064V11.049H142.269V8.69143H143.794V4.94779H147.364V8.69143H149.451V11.049H147.364V16.5959C147.364 17.5199 147.859 17.7522 148.643 17.7522C148.834 17.7522 149.239 17.7436 149.56 17.7367L149.561 17.7367C149.771 17.7322 149.946 17.7284 150 17.7284V20.4794C149.685 20.5718 148.443 20.6642 147.456 20.6642C145.32 20.6642 143.794 19.9488 143.794 17.4064ZM97.0477 37.7082C97.0477 40.444 94.7976 41.9753 91.6185 41.9753C87.4692 41.9753 85.4874 39.6886 85.3223 36.7486H87.1389C87.3866 39.219 88.9142 40.5052 91.7011 40.5052C93.7448 40.5052 95.2105 39.5865 95.2105 37.7694C95.2105 35.7686 93.4351 35.299 90.7928 34.7273C88.1917 34.1557 85.9622 33.4207 85.9622 30.6644C85.9622 28.2552 87.9853 26.8057 90.9786 26.8057C94.6737 26.8057 96.2014 28.6636 96.5729 31.3586H94.8182C94.5499 29.2761 93.1874 28.2348 91.0199 28.2348C89.0174 28.2348 87.6963 29.0719 87.6963 30.5827C87.6963 32.2365 89.3477 32.7265 91.5566 33.1552C94.426 33.7269 97.0477 34.4211 97.0477 37.7082ZM52 41.6894H53.8579V34.809H60.6496V33.2573H53.8579V28.7044H62.0121V27.0915H52V41.6894ZM63.6868 41.6894H65.3589V31.134H63.6868V41.6894ZM63.6868 29.1332H65.3589V27.0915H63.6868V29.1332ZM69.3721 41.6894H67.7V27.0915H69.3721V41.6894ZM76.2725 41.9753C78.6878 41.9753 80.3599 40.689 80.8554 38.4227H79.2245C78.853 39.8519 77.8208 40.5869 76.2725 40.5869C74.1256 40.5869 72.9489 38.9536 72.8251 36.8098H81.0824C81.0824 33.3186 79.369 30.8686 76.1693 30.8686C73.1347 30.8686 71.1117 33.3594 71.1117 36.4219C71.1117 39.4844 73.0109 41.9753 76.2725 41.9753ZM76.1693 32.1752C78.0891 32.1752 79.1832 33.4615 79.2865 35.5644H72.8664C73.1141 33.6452 74.1875 32.1752 76.1693 32.1752ZM100.288 45.1807C101.589 45.1807 102.415 44.7315 103.282 42.5469L107.844 31.134H106.068L103.633 37.7082C103.302 38.5861 102.931 39.7702 102.931 39.7702H102.889C102.889 39.7702 102.497 38.5861 102.167 37.7082L99.6483 31.134H97.8317L102.002 41.3832L101.589 42.4244C101.176 43.4453 100.701 43.7107 99.9993 43.7107C99.4419 43.7107 99.091 43.6086 98.8639 43.4861H98.7813V44.9561C99.2355 45.1398 99.6483 45.1807 100.288 45.1807ZM117.182 38.7902C117.182 40.9136 115.428 41.9957 112.971 41.9957C110.04 41.9957 108.409 40.6277 108.244 38.2594H109.957C110.184 40.0969 111.423 40.6073 113.012 40.6073C114.767 40.6073 115.49 39.8519 115.49 38.8719C115.49 37.6877 114.664 37.3815 112.703 36.9732C110.597 36.5444 108.719 36.1157 108.719 33.8086C108.719 32.0527 110.081 30.9094 112.558 30.9094C115.201 30.9094 116.501 32.1548 116.749 34.2169H115.035C114.87 32.8286 114.107 32.2161 112.517 32.2161C110.989 32.2161 110.37 32.8898 110.37 33.7269C110.37 34.8702 111.402 35.0948 113.239 35.4623C115.386 35.8911 117.182 36.3607 117.182 38.7902ZM117.9 32.4815H119.448V39.709C119.448 41.1994 120.439 41.7507 121.802 41.7507C122.297 41.7507 122.751 41.6894 123.144 41.6077V40.1786H123.082C122.896 40.2398 122.524 40.3215 122.194 40.3215C121.513 40.3215 121.121 40.0765 121.121 39.3415V32.4815H123.185V31.134H121.121V27.8061H119.448V31.134H117.9V32.4815ZM133.956 38.4227C133.46 40.689 131.788 41.9753 129.373 41.9753C126.111 41.9753 124.212 39.4844 124.212 36.4219C124.212 33.3594 126.235 30.8686 129.27 30.8686C132.47 30.8686 134.183 33.3186 134.183 36.8098H125.926C126.049 38.9536 127.226 40.5869 129.373 40.5869C130.921 40.5869 131.953 39.8519 132.325 38.4227H133.956ZM132.387 35.5644C132.284 33.4615 131.19 32.1752 129.27 32.1752C127.288 32.1752 126.215 33.6452 125.967 35.5644H132.387ZM135.818 41.6894H137.49V35.1152C137.49 33.4615 138.935 32.2773 140.236 32.2773C141.33 32.2773 142.073 33.0532 142.073 34.3598V41.6894H143.745V35.1152C143.745 33.4615 144.984 32.2773 146.367 32.2773C147.44 32.2773 148.328 33.0532 148.328 34.3598V41.6894H150V34.2782C150 32.0936 148.658 30.889 146.759 30.889C145.458 30.889 144.179 31.5627 143.497 32.7673H143.456C143.002 31.5423 141.97 30.889 140.669 30.889C139.327 30.889 138.192 31.624 137.531 32.6448H137.49V31.134H135.818V41.6894Z" fill="#1A1A1A"/>\n<path fill-rule="evenodd" clip-rule="evenodd" d="M23.0207 36.1567V25.1698L32.5104 19.6763L37.9585 16.5224V23.6456C37.9585 23.8016 38.1269 23.8991 38.2617 23.8211L38.7306 23.5496L41.4948 21.9495C41.8074 21.7685 42 21.4341 42 21.0721V14.1828V13.0131C42 12.2892 41.6148 11.6203 40.9896 11.2584L39.9793 10.6735L34.0348 7.23229C33.7222 7.05133 33.337 7.05133 33.0244 7.23229L29.7913 9.10394C29.6566 9.18193 29.6566 9.37689 29.7913 9.45488L35.9378 13.0131L30.4896 16.167L21 21.6604L11.5104 16.167L6.26036 13.1278L6.06218 13.0131L12.2179 9.44955C12.3526 9.3716 12.3527 9.17677 12.2181 9.0987L8.9889 7.22548C8.67617 7.04407 8.29064 7.0439 7.97775 7.22503L2.02073 10.6735L1.01036 11.2584C0.385148 11.6203 0 12.2892 0 13.0131V14.1828V21.0635C0 21.4254 0.192574 21.7599 0.505181 21.9408L3.28428 23.5496L3.73834 23.8125C3.87306 23.8905 4.04145 23.793 4.04145 23.637V16.5224L4.23964 16.6371L9.48964 19.6763L18.9793 25.1698V36.1567L18.9793 42.4645L12.8239 38.9012C12.6892 38.8232 12.5208 38.9207 12.5208 39.0767V40.1009V42.82C12.5208 43.1819 12.7133 43.5164 13.0259 43.6973L18.9793 47.1437L19.9896 47.7285C20.6149 48.0905 21.3851 48.0905 22.0104 47.7285L23.0207 47.1437L28.9741 43.6973C29.2867 43.5164 29.4792 43.1819 29.4792 42.82V40.1009V39.0767C29.4792 38.9207 29.3108 38.8232 29.1761 38.9012L23.0207 42.4645L23.0207 36.1567ZM34.0259 36.0937C33.7133 36.2747 33.5207 36.6091 33.5207 36.9711V40.1009V40.7144C33.5207 40.8704 33.6891 40.9678 33.8238 40.8899L40.9896 36.7416C41.6148 36.3797 42 35.7108 42 34.9869V26.6871C42 26.5311 41.8316 26.4336 41.6969 26.5116L40.7514 27.059L38.4637 28.3833C38.1511 28.5642 37.9585 28.8987 37.9585 29.2606V33.8172L34.0259 36.0937ZM8.4793 40.7144C8.4793 40.8704 8.31091 40.9678 8.1762 40.8898L1.01036 36.7416C0.385148 36.3797 0 35.7108 0 34.9869V26.6784C0 26.5225 0.168394 26.425 0.303109 26.503L1.26356 27.059L3.53627 28.3746C3.84888 28.5556 4.04145 28.89 4.04145 29.2519V33.8172L7.9741 36.0937C8.2867 36.2747 8.47928 36.6091 8.47928 36.9711L8.4793 40.1009V40.7144ZM12.828 4.41725C12.6933 4.49524 12.6933 4.6902 12.828 4.76819L14.9593 6.00198L16.0612 6.63983C16.3738 6.8208 16.7589 6.8208 17.0715 6.63983L21 4.36568L24.9415 6.6474C25.2541 6.82837 25.6393 6.82837 25.9519 6.6474L27.0668 6.00198L29.1851 4.77575C29.3198 4.69777 29.3198 4.5028 29.1851 4.42482L22.0104 0.271449C21.3851 -0.090483 20.6149 -0.0904829 19.9896 0.271449L12.828 4.41725Z" fill="#3E7EFF"/>\n</svg>\n'},"0ff2":function(t,e,s){"use strict";var n=s("c563"),a=s.n(n);a.a},1:function(t,e){},10:function(t,e){},11:function(t,e){},12:function(t,e){},13:function(t,e){},1357:function(t,e,s){"use strict";var n=s("53a7"),a=s.n(n);a.a},14:function(t,e){},15:function(t,e){},1834:function(t,e,s){},1900:function(t,e,s){t.exports=s.p+"img/btfs-logo-2.7259a3f5.svg"},2:function(t,e){},2019:function(t,e){t.exports='<svg width="12" height="8" viewBox="0 0 12 8" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path d="M1.41 0.589996L6 5.17L10.59 0.589996L12 2L6 8L0 2L1.41 0.589996Z" fill="#3E7EFF"/>\n</svg>\n'},"215d":function(t,e,s){},"21d3":function(t,e,s){var n={"./svg-icon.vue":"6ee0"};function a(t){var e=i(t);return s(e)}function i(t){if(!s.o(n,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return n[t]}a.keys=function(){return Object.keys(n)},a.resolve=i,t.exports=a,a.id="21d3"},"277b":function(t,e,s){},"2a5e":function(t,e,s){"use strict";var n=s("215d"),a=s.n(n);a.a},"2b57":function(t,e,s){"use strict";var n=s("3185"),a=s.n(n);a.a},"2dcb":function(t,e){t.exports='<svg width="16" height="16" viewBox="0 0 16 16" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path d="M13.65 2.35C12.2 0.9 10.21 0 8.00001 0C3.58001 0 0.0100098 3.58 0.0100098 8C0.0100098 12.42 3.58001 16 8.00001 16C11.73 16 14.84 13.45 15.73 10H13.65C12.83 12.33 10.61 14 8.00001 14C4.69001 14 2.00001 11.31 2.00001 8C2.00001 4.69 4.69001 2 8.00001 2C9.66001 2 11.14 2.69 12.22 3.78L9.00001 7H16V0L13.65 2.35Z" fill="black" />\n</svg>\n'},3:function(t,e){},3016:function(t,e,s){"use strict";var n=s("651b"),a=s.n(n);a.a},3185:function(t,e,s){},"36e7":function(t,e,s){"use strict";var n=s("3822"),a=s.n(n);a.a},3822:function(t,e,s){},4:function(t,e){},4051:function(t,e,s){"use strict";var n=s("8979"),a=s.n(n);a.a},"40c9":function(t,e,s){},"41b8":function(t,e,s){"use strict";var n=s("1834"),a=s.n(n);a.a},4625:function(t,e,s){},"47fe":function(t,e,s){"use strict";var n=s("cd3d"),a=s.n(n);a.a},"4bb6":function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},"4f3a":function(t,e,s){"use strict";var n=s("bdbb"),a=s.n(n);a.a},5:function(t,e){},5254:function(t,e){t.exports='<svg width="14" height="14" viewBox="0 0 14 14" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M0.333313 7.00065C0.333313 3.32065 3.31998 0.333984 6.99998 0.333984C10.68 0.333984 13.6666 3.32065 13.6666 7.00065C13.6666 10.6807 10.68 13.6673 6.99998 13.6673C3.31998 13.6673 0.333313 10.6807 0.333313 7.00065ZM2.33331 7.00065L5.66665 10.334L11.6666 4.33398L10.7266 3.38732L5.66665 8.44732L3.27331 6.06065L2.33331 7.00065Z" fill="#27AE60"/>\n</svg>\n'},"53a7":function(t,e,s){},"56d7":function(t,e,s){"use strict";s.r(e);s("e260"),s("e6cf"),s("cca6"),s("a79d");var n=s("2b0e"),a=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{attrs:{id:"app"}},[n("Banner",{on:{showBanner:function(e){t.showbanner=e}}}),n("div",{staticClass:"header-container"},[n("bt-header",{scopedSlots:t._u([{key:"left",fn:function(){return[n("div",{staticClass:"logo-container"},[n("img",{attrs:{src:s("1900")}})])]},proxy:!0},{key:"right",fn:function(){return[n("router-link",{staticClass:"link",attrs:{to:"/settings"}},[n("div",{staticClass:"settings-container"},[n("svg-icon",{staticClass:"settings-icon",attrs:{icon:"settings",hasFill:!0}})],1)])]},proxy:!0}])})],1),n("div",{staticClass:"main",class:{"banner-present":t.showBanner}},[n("div",{staticClass:"nav"},[n("bt-sidebar",{attrs:{items:t.sidebarItems}})],1),t.isLoading?t._e():n("router-view",t._b({staticClass:"view"},"router-view",t.routeProps,!1))],1)],1)},i=[],o=(s("4de4"),s("caad"),s("13d5"),s("fb6a"),s("b0c0"),s("d3b7"),s("2532"),s("3ca3"),s("2ca0"),s("ddb0"),s("5530")),r=function(){var t=this,e=t.$createElement,s=t._self._c||e;return t.bannerData.length>0&&!0===t.showBanner?s("div",{staticClass:"banner"},[s("div",{staticClass:"arrow-container",class:{hidden:1===t.bannerData.length}},[s("div",{staticClass:"previous",on:{click:t.previous}},[s("svg-icon",{staticClass:"arrow-icon back",attrs:{icon:"arrow-back",hasFill:!0}}),t._v(" Previous ")],1),s("div",{staticClass:"next",on:{click:t.next}},[t._v(" Next "),s("svg-icon",{staticClass:"arrow-icon forward",attrs:{icon:"arrow-forward",hasFill:!0}})],1)]),s("div",{staticClass:"content",domProps:{innerHTML:t._s(t.bannerData[t.index].childNodes[1].nodeValue)}}),s("svg-icon",{staticClass:"close-icon",attrs:{icon:"close",hasFill:!0},on:{click:t.toggleBanner}})],1):t._e()},c=[],l={name:"Banner",props:{},data:function(){return{bannerData:[],index:0,showBanner:!1}},methods:{next:function(){this.index=(this.index+1)%this.bannerData.length},previous:function(){this.index=this.index-1<0?this.bannerData.length-1:this.index-1},toggleBanner:function(){this.showBanner=!this.showBanner,this.$emit("showBanner",this.showBanner)}},created:function(){var t=this,e="/hostui/banner.xml";this.axios.get(e).then((function(e){var s=new DOMParser,n=s.parseFromString(e.data,"text/xml"),a=n.getElementsByTagName("content");t.bannerData=a,t.toggleBanner()})).catch((function(){}))}},u=l,d=(s("8c04"),s("2877")),p=Object(d["a"])(u,r,c,!1,null,"6caa744b",null),h=p.exports,f={name:"App",components:{Banner:h},data:function(){return{isLoading:!0,showBanner:!1}},methods:{initSetup:function(){var t=this.$store.dispatch("getHostConfig"),e=this.$store.dispatch("getStorageHostEnabled"),s=this.$store.dispatch("getWalletConfig"),n=this.initMetaStores();return Promise.all([t,e,s,n])},initSync:function(){var t=this,e=this.$store.dispatch("storageStatsSync"),s=this.$store.dispatch("storageContractsSync");return Promise.all([e,s]).then((function(e){var s=e.reduce((function(t,e){return t&&e}));t.$store.dispatch("updateIsSynced",s)}))},initStorageStores:function(){var t=this.$store.dispatch("storageInfo"),e=this.$store.dispatch("storageStatsInfo"),s=this.$store.dispatch("storageContractsInfo"),n=this.$store.dispatch("storageContractsList");return Promise.all([t,e,s,n])},initMetaStores:function(){var t=this.$store.dispatch("metaRepoStat"),e=this.$store.dispatch("metaId"),s=this.$store.dispatch("metaVersion");return Promise.all([t,e,s])},initWalletStores:function(){var t=this.$store.dispatch("walletBalance"),e=this.$store.dispatch("walletTransactions");return Promise.all([t,e])},createStorageSpaceObj:function(){var t={used:0,cap:32,total:1e3,minimum:32};return this.isLoading||(t.used=this.storageSpaceUsed,t.cap=this.storageSpaceCap,t.total=this.storageSpaceTotal),t},isValidRoute:function(t){var e=this.$router.options.routes.reduce((function(e,s){return s.name===t||e}),!1);return e},isSetupRoute:function(t){return t.includes("Add")||t.includes("Setup")||t.includes("Import")},routeType:function(t){return t.startsWith("Host")?"host":t.startsWith("Wallet")?"wallet":t.startsWith("Settings")?"settings":null}},created:function(){var t=this;this.initSetup().then((function(){t.isValidRoute(t.route)||t.$router.push("/host");var e=t.isSetupRoute(t.route),s=t.routeType(t.route);switch(s){case"host":t.isHostSetup||e?t.isHostSetup&&e&&t.$router.push("/host"):t.$router.push("/host/add");break;case"wallet":t.isWalletSetup||e?t.isWalletSetup&&e&&t.$router.push("/wallet"):t.$router.push("/wallet/add");break}if(t.$router.beforeEach((function(e,s,n){if("WalletDashboard"!==s.name&&("WalletImport"!==s.name||"WalletImport"!==e.name&&"WalletSetup"!==e.name)){if(t.isValidRoute(e.name)){var a=t.isSetupRoute(e.name),i=t.routeType(e.name);switch(i){case"host":t.isHostSetup||a?t.isHostSetup&&a?n({name:"HostDashboard"}):n():n("/host/add");break;case"wallet":t.isWalletSetup||a?t.isWalletSetup&&a?n({name:"WalletDashboard"}):n():n("/wallet/add");break;default:n()}}}else n()})),!t.isHostSetup)return t.initSync(),t.initStorageStores(),t.isLoading=!1,Promise.reject("host not setup");t.initSync(),t.isLoading=!1})).catch((function(){}))},computed:{route:function(){return this.$route.name},isHostSetup:function(){return this.$store.state.config.host.initialized&&this.hostEnabled},isSynced:function(){return this.$store.state.storage.isSynced},isSyncedLocal:function(){return this.$store.state.storage.isSyncedLocal},isStorageLoading:function(){return this.$store.state.storage.isStorageLoading},isWalletSetup:function(){return this.$store.state.config.wallet.initialized},storageSpaceUsed:function(){var t=this.$store.state.storage.value,e=t.host_stats.storage_used/Math.pow(1e3,3);return e=e>=1?Math.floor(e):Math.floor(1e3*e)/1e3,e=parseFloat(e),e},storageSpaceCap:function(){var t=this.$store.state.storage.value;return v(t.host_stats.storage_cap)},storageSpaceTotal:function(){var t=this.$store.state.storage.value,e=null===this.proposedStorageAvailable?t.host_stats.storage_disk_available:this.proposedStorageAvailable,s=t.host_stats.storage_used,n=e+s;return v(n)},proposedStorageAvailable:function(){return this.$store.state.storage.proposedStorageAvailable},hostDirectoryPath:function(){return this.$store.state.meta.value.RepoPath},hostEnabled:function(){return this.$store.state.config.hostEnabled.value},hostOnline:function(){return this.$store.state.storage.value.host_stats.online},hostScore:function(){return this.$store.state.storage.value.host_stats.score},hostUptime:function(){return this.$store.state.storage.value.host_stats.uptime},hostContractsNum:function(){return this.$store.state.storage.contracts.active_contract_num},earnings:function(){return this.$store.state.storage.contracts.compensation_paid},potentialEarnings:function(){return this.$store.state.storage.contracts.compensation_outstanding},hostPricing:function(){return this.$store.state.storage.info.storage_price_ask},hostSuggestedPricing:function(){return 7680},hostSetupValues:function(){return this.$store.state.storage.setup},hostIsImporting:function(){return this.$store.state.storage.isImporting},nextPayment:function(){var t=this.$store.state.storage.contractsList.contracts,e=t.filter((function(t){return"0001-01-01T00:00:00Z"!==t.next_escrow_time&&"9999-12-31T23:59:59Z"!==t.next_escrow_time}));if(e.length>0){var s=e[0];return s=s.next_escrow_time,s=new Date(s),s=s.toDateString(),s=s.slice(3),s=s.slice(0,7)+","+s.slice(7),s}return null},nodeVersion:function(){return this.$store.state.meta.version.Version},notificationState:function(){return this.$store.state.notification},walletInAppBalance:function(){return this.$store.state.wallet.balance.BtfsWalletBalance},walletCryptoBalance:function(){return this.$store.state.wallet.balance.BttWalletBalance},walletRecoveryPhrase:function(){return this.$store.state.wallet.keys.Mnemonic},walletTransactions:function(){return this.$store.state.wallet.transactions},nodeID:function(){return this.$store.state.meta.id.ID},tronAddress:function(){return this.$store.state.meta.id.TronAddress},routeProps:function(){var t=this;if("HostAdd"===this.route){var e=this.storageSpaceTotal>32;return{hasEnoughSpace:e,hostEnabled:this.hostEnabled,hostDirectoryPath:this.hostDirectoryPath,updateHostSetupValues:function(e){return t.$store.dispatch("updateHostSetupValues",Object(o["a"])({},e))},checkOtherFolder:function(e){return t.$store.dispatch("storagePathCapacity",Object(o["a"])({},e))}}}if("HostDashboard"===this.route){var s=this.createStorageSpaceObj();return{online:this.hostOnline,score:this.hostScore,uptime:this.hostUptime,contractsNum:this.hostContractsNum,earnings:this.earnings,potentialEarnings:this.potentialEarnings,hostPricing:this.hostPricing,nextPayment:this.nextPayment,storageSpace:s,isSynced:this.isSynced,isSyncedLocal:this.isSyncedLocal,isStorageLoading:this.isStorageLoading,hostDirectoryPath:this.hostDirectoryPath,chooseOtherFolder:function(e){return t.$store.dispatch("storagePath",Object(o["a"])({},e))},checkOtherFolder:function(e){return t.$store.dispatch("storagePathCapacity",Object(o["a"])({},e))},notificationState:this.notificationState.host,notificationClose:function(){return t.$store.dispatch("notificationClose","host")},isImporting:this.hostIsImporting,handleRefresh:function(){t.initStorageStores()}}}if("HostSetup"===this.route){var n=this.createStorageSpaceObj();return{storageSpace:n,storageAnnounce:function(e){t.$store.dispatch("storageAnnounce",{hostStorageMax:e})},setInitializationFlag:function(){return t.initSync().then((function(){return t.initStorageStores()})),t.$store.dispatch("setHostConfig")},updateHostSetupValues:function(e){return t.$store.dispatch("updateHostSetupValues",Object(o["a"])({},e))},chooseOtherFolder:function(e){return t.$store.dispatch("storagePath",Object(o["a"])({},e))},hostSetupValues:this.hostSetupValues}}return"Settings"===this.route?{version:this.nodeVersion,id:this.nodeID,notificationState:this.notificationState.settings,notificationClose:function(){return t.$store.dispatch("notificationClose","settings")},hostPricing:this.hostPricing,hostSuggestedPricing:this.hostSuggestedPricing,storageAnnouncePrice:function(e){return t.$store.dispatch("storageAnnouncePrice",e)}}:"WalletDashboard"===this.route?{id:this.nodeID,tronAddress:this.tronAddress,transactions:this.walletTransactions,handleDeposit:function(e){return t.$store.dispatch("walletDeposit",e)},handleWithdraw:function(e){return t.$store.dispatch("walletWithdraw",e)},inAppBalance:this.walletInAppBalance,cryptoBalance:this.walletCryptoBalance,handleRefresh:function(){t.initWalletStores()},checkPassword:function(e){return t.$store.dispatch("walletCheckPassword",e)}}:"WalletSetup"===this.route?{setInitializationFlag:function(){return t.$store.dispatch("setWalletConfig")},setPassword:function(e){return t.$store.dispatch("walletPassword",e)},getKeys:function(e){return t.$store.dispatch("walletKeys",e)},recoveryPhrases:this.walletRecoveryPhrase}:"WalletImport"===this.route?{setPassword:function(e){return t.$store.dispatch("walletPassword",e)},importWallet:function(e){return t.$store.dispatch("walletImport",e)},setInitializationFlag:function(){return t.$store.dispatch("setWalletConfig")}}:{}},sidebarItems:function(){return{top:[{icon:"dns",path:"/host",name:"Host"},{icon:"wallet",target:"",path:"/wallet",name:"Wallet"}],bottom:[{icon:"mail",target:"_blank",href:"https://docs.google.com/forms/d/e/1FAIpQLSeH1Vhm4C6tcsI80gLzUQ58cuYtMHj3LEQFx_STAqWNoCP3Ew/viewform?usp=pp_url&entry.875205708=".concat(this.nodeID),name:"Contact us"}]}}}};function v(t){var e=t/Math.pow(1e3,3);return e=Math.floor(e),e}var g=f,m=(s("5c0b"),Object(d["a"])(g,a,i,!1,null,null,null)),C=m.exports,b=s("8c4f"),y=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"host-add"},[t.hostEnabled?n("div",[0===t.state?n("div",{staticClass:"host-add-container"},[t._m(0),n("div",{staticClass:"button-container"},[n("Button",{attrs:{cb:t.nextState}},[t._v("Host setup")])],1)]):t._e(),1===t.state?n("div",{staticClass:"host-add-container"},[n("div",{staticClass:"header-container"},[n("p",{staticClass:"header"},[t._v("Folder destination")]),t._m(1),n("div",{staticClass:"edit-host-folder-container"},[n("EditHostFolder",{attrs:{hostDirectoryPath:t.hostDirectoryPath,storageLocationContinueMessage:"Files will be moved to this folder after you click on ‘Confirm'",checkOtherFolder:t.checkOtherFolder},on:{inputChange:t.onEditFolder,isEditingFolder:function(e){t.isEditingFolder=e}}})],1)]),n("div",{staticClass:"button-container"},[n("Button",{attrs:{isDisabled:t.isEditingFolder,cb:t.handleNext}},[t._v("Confirm")])],1)]):t._e()]):t._e(),t.showPopup?n("div",{staticClass:"popup-bg"},[n("div",{staticClass:"popup-container"},[n("div",{staticClass:"close-container",on:{click:t.handleClose}},[n("img",{attrs:{src:s("bb7c")}})]),n("p",{staticClass:"header"},[t._v(" Not enough storage space ")]),n("p",{staticClass:"description"},[t._v(" 32 GB is the minimum available space needed to continue setting up your host. Please use another computer with at least 32 GB of available space. ")])])]):t._e(),t.hostEnabled?t._e():n("div",{staticClass:"host-not-enabled host-add-container"},[n("div",{staticClass:"container"},[n("p",{staticClass:"header"},[t._v(" Host profile not enabled ")]),n("p",{staticClass:"description"},[n("ul",[n("li",[t._v("1. In order to enable your host profile, use command:")]),n("li",{staticClass:"code-block"},[t._v(" btfs config profile apply storage-host "),n("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:"copied!",classes:["tooltip"],show:t.showCopyTooltip,trigger:"manual"},expression:"{ \n                            content: 'copied!', \n                            classes: ['tooltip'], \n                            show: showCopyTooltip,\n                            trigger: 'manual'\n                          }",modifiers:{bottom:!0}}],staticClass:"icon",attrs:{icon:"copy",hasFill:!0},on:{click:t.handleCopy}})],1),n("li",[t._v("2. Restart your daemon")]),n("li",[t._v("3. Refresh this page")])])])])])])},w=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Host setup")]),s("p",{staticClass:"description"},[t._v(" Commit your spare hard drive space to the BTFS network to earn BTT. "),s("br"),s("br"),t._v(" After clicking ‘Host setup’, select a folder where you would like files to be hosted. ")]),s("p",{staticClass:"sub-description"},[t._v(" Looking for more information? Read our "),s("a",{attrs:{href:"https://www.bittorrent.com/token/bittorrent-file-system/faq/",target:"_blank",rel:"noreferrer noopener"}},[t._v("FAQs")])])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("p",{staticClass:"description"},[t._v(" A default folder destination has been selected for you. "),s("br"),s("br"),t._v(" If you would like files hosted somewhere else, please enter a different folder destination with at least 32 GB of available storage space. ")])}],S=s("fac6"),_=s.n(S),x=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("button",{staticClass:"button",class:[t.type,{hasCopied:t.hasCopied},t.size],attrs:{disabled:t.isDisabled},on:{click:this.cb}},[s("span",{on:{click:t.handleClick}},[t.hasCopied?t._e():t._t("default"),t.hasCopied?s("span",[t._v(" Copied! ")]):t._e(),"text"===t.type&&void 0!==t.isOpen?s("svg-icon",{staticClass:"icon",class:{"up-arrow":t.isOpen},attrs:{icon:"down-arrow",hasFill:!0}}):t._e(),"copy"===t.type?s("svg-icon",{staticClass:"icon",attrs:{icon:"copy",hasFill:!0}}):t._e()],2)])},P=[],B=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",t._g({staticClass:"svg-container",domProps:{innerHTML:t._s(s("03c5")("./"+t.icon+".svg"))}},t.$listeners))},T=[];s("4160"),s("d81d"),s("a9e3"),s("b680"),s("ac1f"),s("1276");function V(t){t&&(t.removeAttribute("fill"),[].forEach.call(t.children,(function(t){V(t)})))}var H={name:"svg-icon",props:{icon:{type:String,default:null},hasFill:{type:Boolean,default:!1},growByHeight:{type:Boolean,default:!0}},mounted:function(){if("svg"===this.$el.firstElementChild.nodeName){var t=this.$el.firstElementChild,e=t.getAttribute("viewBox").split(" ").map((function(t){return Number(t)})),s=(e[2]/e[3]).toFixed(2);this.hasFill&&V(t),this.growByHeight?(t.setAttribute("height","1em"),t.setAttribute("width","".concat(s,"em"))):(t.setAttribute("width","1em"),t.setAttribute("height","".concat(1/s,"em"))),t.classList.add("svg")}}},k=H,E=(s("0ff2"),Object(d["a"])(k,B,T,!1,null,"7777e5e1",null)),L=E.exports,I={name:"Button",components:{"svg-icon":L},props:{isDisabled:{type:Boolean,default:!1},cb:{type:Function,default:function(){}},type:{type:String,default:"primary"},copyValue:{type:String,default:null},isOpen:{type:Boolean,required:!1,default:void 0},size:{type:String,default:"big"}},data:function(){return{hasCopied:!1}},methods:{handleClick:function(t){this.isDisabled&&t.preventDefault(),"copy"===this.type&&this.copyValue&&(navigator.clipboard.writeText(this.copyValue),this.hasCopied=!0)}}},O=I,F=(s("36e7"),Object(d["a"])(O,x,P,!1,null,"123553db",null)),D=F.exports,A=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"edit-host-folder"},[s("div",{staticClass:"current"},[s("div",{},[s("p",{staticClass:"subtitle"},[t._v(" Current host folder destination ")]),s("b",[t._v(" "+t._s(t.currentHostDirectoryPath)+" ")])]),s("div",[s("Button",{attrs:{type:"outline",isDisabled:t.isOpen,cb:t.toggleOpen}},[s("div",{staticClass:"edit-button"},[t._v(" Edit "),s("svg-icon",{staticClass:"icon",attrs:{icon:"edit",hasFill:!0}})],1)])],1)]),t.isOpen?s("div",{staticClass:"edit"},[s("p",{staticClass:"subtitle"},[t._v(" Edit host folder destination (case-sensitive) ")]),s("div",{staticClass:"input-container"},[s("InputBox",{attrs:{inputType:"text",borderColor:t.colors.btfsBlue500,placeholderColor:t.colors.gray500,invalidColor:t.colors.btfsRed,isInvalid:t.isStorageLocationInvalid,placeholder:t.hostDirectoryPath,message:t.storageLocationMessage},model:{value:t.newHostDirectoryPath,callback:function(e){t.newHostDirectoryPath=e},expression:"newHostDirectoryPath"}})],1),s("div",{staticClass:"button-container"},[s("Button",{staticClass:"cancel",attrs:{type:"outline",size:"small",cb:t.toggleOpen}},[t._v(" Cancel ")]),s("Button",{attrs:{type:"primary",size:"small",cb:t.handleUpdate,isDisabled:!t.isStorageLocationDifferent}},[t._v(" Update ")])],1)]):t._e()])},M=[],$=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{ref:"inputboxContainer",staticClass:"input-box-container",class:[t.inputType,{invalid:t.isInvalid},{inactive:t.isInactive}],style:t.inputStyle},[s("div",{staticClass:"input-box"},["textarea"===t.inputType?s("textarea",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{placeholder:t.placeholder,disabled:t.disabled},domProps:{value:t.inputVal},on:{change:t.handleChange,keydown:function(t){t.stopPropagation()},input:function(e){e.target.composing||(t.inputVal=e.target.value)}}}):"password"===t.inputType?s("input",{directives:[{name:"model",rawName:"v-model.trim",value:t.inputVal,expression:"inputVal",modifiers:{trim:!0}}],class:t.inputType,attrs:{type:t.showPassword?"text":"password",placeholder:t.placeholder,disabled:t.disabled},domProps:{value:t.inputVal},on:{change:t.handleChange,input:function(e){e.target.composing||(t.inputVal=e.target.value.trim())},blur:function(e){return t.$forceUpdate()}}}):"checkbox"===t.inputType?s("input",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{placeholder:t.placeholder,step:t.step,disabled:t.disabled,min:"0",type:"checkbox"},domProps:{checked:Array.isArray(t.inputVal)?t._i(t.inputVal,null)>-1:t.inputVal},on:{change:[function(e){var s=t.inputVal,n=e.target,a=!!n.checked;if(Array.isArray(s)){var i=null,o=t._i(s,i);n.checked?o<0&&(t.inputVal=s.concat([i])):o>-1&&(t.inputVal=s.slice(0,o).concat(s.slice(o+1)))}else t.inputVal=a},t.handleChange],keydown:function(t){t.stopPropagation()},keypress:function(e){return t.noNegativeKeystroke(e)}}}):"radio"===t.inputType?s("input",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{placeholder:t.placeholder,step:t.step,disabled:t.disabled,min:"0",type:"radio"},domProps:{checked:t._q(t.inputVal,null)},on:{change:[function(e){t.inputVal=null},t.handleChange],keydown:function(t){t.stopPropagation()},keypress:function(e){return t.noNegativeKeystroke(e)}}}):s("input",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{placeholder:t.placeholder,step:t.step,disabled:t.disabled,min:"0",type:t.inputType},domProps:{value:t.inputVal},on:{change:t.handleChange,keydown:function(t){t.stopPropagation()},keypress:function(e){return t.noNegativeKeystroke(e)},input:function(e){e.target.composing||(t.inputVal=e.target.value)}}}),t.isPassword&&!t.disabled?s("div",{staticClass:"eye-wink-icon",on:{click:t.handleShowPasswordChange}},[t.showPassword?s("svg-icon",{key:"eye-invisible",attrs:{icon:"eye-invisible",hasFill:null!==t.iconColor}}):s("svg-icon",{key:"eye-visible",attrs:{icon:"eye-visible",hasFill:null!==t.iconColor}})],1):t._e(),t.unit?s("div",{staticClass:"unit"},[t._v(t._s(t.unit))]):t._e()]),t.showMessage?s("div",{staticClass:"message"},[t._v(t._s(t.message))]):t._e()])},N=[],z={name:"bt-input-box",props:{value:[String,Number],inputType:{type:String,default:"text"},handleChange:{type:Function,default:function(){}},placeholder:{type:String,default:""},disabled:{type:Boolean,default:!1},step:{type:String,default:"0"},message:{type:String,default:""},isInvalid:{type:Boolean,default:!1},unit:{type:String,default:""},width:{type:Number,default:null},showMessage:{type:Boolean,default:!0},borderColor:{type:String,default:_.a.primary500Main},passwordPlaceholderColor:{type:String,default:_.a.primary200},placeholderColor:{type:String,default:_.a.gray400},disabledColor:{type:String,default:_.a.gray400},invalidColor:{type:String,default:_.a.accentsRed500},textareaColor:{type:String,default:_.a.gray800main},messageColor:{type:String,default:_.a.gray500},iconColor:{type:String,default:null},delayEmit:{type:Boolean,default:!1}},components:{"svg-icon":L},data:function(){return{inputVal:this.value?"number"===this.inputType?Number(this.value):this.value:null,showPassword:!1,intervalHandler:null}},computed:{isPassword:function(){return"password"===this.inputType},invalid:function(){return!1},inputStyle:function(){return{"--borderColor":this.borderColor,"--passwordPlaceholderColor":this.passwordPlaceholderColor,"--placeholderColor":this.placeholderColor,"--disabledColor":this.disabledColor,"--invalidColor":this.invalidColor,"--textareaColor":this.textareaColor,"--messageColor":this.messageColor,"--iconColor":this.iconColor}},isInactive:function(){return null===this.inputVal||""===this.inputVal}},methods:{handleShowPasswordChange:function(){this.showPassword=!this.showPassword},startInterval:function(t){var e=this;this.intervalHandler=setInterval((function(){e.$emit("input",t),clearInterval(e.intervalHandler)}),500)},noNegativeKeystroke:function(t){if("number"===this.inputType){var e=t.which?t.which:t.keyCode;if(!(e>31&&(e<48||e>57)&&46!==e))return!0;t.preventDefault()}}},watch:{inputVal:function(t){"number"===this.inputType&&t<0&&(t*=-1),this.delayEmit?(null===this.intervalHandler||clearInterval(this.intervalHandler),this.startInterval(t)):this.$emit("input",t)},value:function(t){this.inputVal=t}},mounted:function(){this.width&&(this.$refs.inputboxContainer.style.width=this.width+"px")}},j=z,R=(s("2a5e"),Object(d["a"])(j,$,N,!1,null,"43c35831",null)),W=R.exports,Z={name:"EditHostFolder",components:{Button:D,InputBox:W},props:{hostDirectoryPath:{type:String,default:null},storageLocationContinueMessage:{type:String,default:"Files will be moved to this folder after you click on ‘Save changes’"},checkOtherFolder:{type:Function,default:function(){}}},data:function(){return{newHostDirectoryPath:this.hostDirectoryPath,colors:_.a,isOpen:!1,storageLocationErrorMessage:"",isStorageLocationInvalid:!1,currentHostDirectoryPath:this.hostDirectoryPath}},methods:{toggleOpen:function(){this.newHostDirectoryPath=this.currentHostDirectoryPath,this.isOpen=!this.isOpen,this.$emit("isEditingFolder",this.isOpen)},handleUpdate:function(){var t=this;this.checkOtherFolder({path:this.newHostDirectoryPath}).then((function(){t.$emit("inputChange",t.newHostDirectoryPath),t.currentHostDirectoryPath=t.newHostDirectoryPath,t.toggleOpen()})).catch((function(e){console.log(e),t.isStorageLocationInvalid=!0,t.storageLocationErrorMessage=e}))}},computed:{storageLocationMessage:function(){return this.isStorageLocationInvalid?this.storageLocationErrorMessage:this.isStorageLocationDifferent&&""!==this.newHostDirectoryPath?this.storageLocationContinueMessage:"Please manually input your new Host file path"},isStorageLocationDifferent:function(){return this.hostDirectoryPath!==this.newHostDirectoryPath}},watch:{newHostDirectoryPath:function(){this.isStorageLocationInvalid=!1}}},q=Z,U=(s("af67"),Object(d["a"])(q,A,M,!1,null,"21af08ff",null)),G=U.exports,K={name:"HostAdd",props:{hasEnoughSpace:{type:Boolean,required:!0,default:!0},hostEnabled:{type:Boolean},hostDirectoryPath:{type:String,default:null},updateHostSetupValues:{type:Function,default:function(){}},checkOtherFolder:{type:Function,default:function(){}}},components:{Button:D,"svg-icon":L,EditHostFolder:G},data:function(){return{btfsBlue500:_.a.btfsBlue500,showPopup:!1,showCopyTooltip:!1,state:0,newHostDirectoryPath:null,isEditingFolder:!1}},methods:{handleClose:function(){this.showPopup=!this.showPopup},handleNext:function(t){this.hasEnoughSpace&&null!==this.hostDirectoryPath||(t.preventDefault(),this.showPopup=!0),null!==this.newHostDirectoryPath&&this.isStorageLocationDifferent&&this.updateHostSetupValues({path:this.newHostDirectoryPath}),this.$router.push("/host/setup")},handleCopy:function(){var t=this;navigator.clipboard.writeText("btfs config profile apply storage-host"),this.showCopyTooltip=!0,setTimeout((function(){t.showCopyTooltip=!1}),1e3)},nextState:function(){this.state=1},backState:function(){this.state=0},onEditFolder:function(t){this.newHostDirectoryPath=t}},computed:{isStorageLocationDifferent:function(){return this.hostDirectoryPath!==this.newHostDirectoryPath}},created:function(){this.$store.dispatch("updateProposedStorageAvailable",null)}},Y=K,J=(s("7a16"),Object(d["a"])(Y,y,w,!1,null,"3169077a",null)),Q=J.exports,X=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"host-setup"},[t._e(),2===t.current?s("div",{staticClass:"host-setup-2"},[s("p",{staticClass:"header"},[t._v("Host storage size")]),s("p",{staticClass:"description"},[t._v(" 32 GB is the minimum host storage commitment size. Try moving the slider below to see how much more you can earn by increasing your commitment size. ")]),s("div",{staticClass:"storage-size-container"},[s("SetStorageSize",{attrs:{storageSpace:t.storageSpace,isDisabled:!1},on:{slideChange:function(e){t.selectedStorageValue=e}}})],1),s("div",{staticClass:"button-container"},[s("Button",{attrs:{isDisabled:t.isDisabled,cb:t.handleCommitClick}},[t._v("Commit Storage")])],1)]):t._e(),3===t.current?s("div",{staticClass:"host-setup-3"},[s("p",{staticClass:"header"},[t._v("How to be a good host")]),t._m(0),s("div",{staticClass:"button-container"},[s("Button",{attrs:{cb:t.handleSetupComplete}},[t._v("Agree & Host")])],1)]):t._e()])},tt=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"description"},[s("ul",{staticClass:"list"},[s("li",[t._v(" Leave your computer and BTFS running at all times. ")]),s("li",[t._v(" Make sure your computer is plugged in a power source and connected to the internet. ")]),s("li",[t._v(" Do not manipulate the host folder on your computer. ")]),s("li",[t._v(" Keep calm and earn BTT! ")])])])}],et=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"checkbox-container"},[t.hasLeftSlot?s("div",{staticClass:"left-section",class:{"gray-text":t.isDisabled}},[t._t("left")],2):t._e(),s("label",{staticClass:"checkbox-control"},[s("input",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{id:t.id,type:"checkbox",disabled:t.isDisabled},domProps:{checked:Array.isArray(t.inputVal)?t._i(t.inputVal,null)>-1:t.inputVal},on:{click:function(e){return e.stopPropagation(),t.click(e)},change:[function(e){var s=t.inputVal,n=e.target,a=!!n.checked;if(Array.isArray(s)){var i=null,o=t._i(s,i);n.checked?o<0&&(t.inputVal=s.concat([i])):o>-1&&(t.inputVal=s.slice(0,o).concat(s.slice(o+1)))}else t.inputVal=a},function(e){return e.stopPropagation(),t.change(t.inputVal,e)}]}}),s("div",{staticClass:"checkbox-indicator",class:{isEnabled:!t.isDisabled}})]),t.hasRightSlot?s("div",{staticClass:"right-section",class:{"gray-text":t.isDisabled}},[t._t("right")],2):t._e()])},st=[],nt={name:"Checkbox",props:{value:{type:Boolean,default:!1},handleChange:{type:Function,default:function(){}},id:{type:String,default:null},isDisabled:{type:Boolean,default:!1}},data:function(){return{inputVal:this.value}},methods:{change:function(t,e){this.$emit("change",t,e)},click:function(t){this.$emit("click",t)}},computed:{hasRightSlot:function(){return!!this.$slots["right"]},hasLeftSlot:function(){return!!this.$slots["left"]}},watch:{value:function(t){this.inputVal=t},inputVal:function(t){this.handleChange(t)}}},at=nt,it=(s("b97e"),Object(d["a"])(at,et,st,!1,null,"2c695357",null)),ot=it.exports,rt=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"textbox-container",on:{scroll:t.handleScroll}},[t._t("default")],2)},ct=[],lt={name:"Textbox",props:{},data:function(){return{}},methods:{handleScroll:function(){this.$el.scrollHeight===this.$el.scrollTop+this.$el.clientHeight&&this.$emit("scrolled",!0)}}},ut=lt,dt=(s("0273"),Object(d["a"])(ut,rt,ct,!1,null,"4fa1fc6a",null)),pt=dt.exports,ht=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"set-storage-size-container"},[n("div",{staticClass:"storage"},[n("p",{staticClass:"title"},[t._v("Set Host Storage Size")]),n("div",{staticClass:"storage-container"},[n("div",{staticClass:"storage-space"},[n("div",{staticClass:"storage-space-used",class:{disabled:this.isDisabled}},[t._v(" "+t._s(this.current.used)+" GB used ")]),n("div",{staticClass:"storage-space-available",class:{disabled:this.isDisabled}},[t._v(" "+t._s(this.current.available)+" GB remaining ")])]),n("SlideBar",{attrs:{isDisabled:this.isDisabled,initialPercent:this.current.initialPercent},on:{slideChange:t.handleSlideChange}}),n("div",[this.isDisabled?n("p",{staticClass:"error"},[n("img",{attrs:{src:s("ec4e")}}),t._v(" 32 GB is the minimum host storage commitment size ")]):t._e()])],1)]),n("div",{staticClass:"earnings"},[n("p",{staticClass:"title"},[t._v("Potential earnings per month")]),n("p",{staticClass:"btt"},[t._v(t._s(this.earnings)+" BTT")])])])},ft=[],vt=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{ref:"slidebarBg",staticClass:"slidebar-bg",class:{"slidebar-disabled":this.isDisabled},style:t.bgStyle},[s("div",{staticClass:"slidebar-fill",class:{"slidebar-disabled":this.isDisabled},style:t.fillStyle}),!this.isDisabled&&this.slideable?s("div",{staticClass:"slidebar-knob",style:{left:this.knob.x-12+"px"},on:{mousedown:t.startDrag}}):t._e()])},gt=[],mt={name:"SlideBar",props:{isDisabled:{type:Boolean,required:!1,default:!1},initialPercent:{type:Number,required:!1,default:0},slideable:{type:Boolean,required:!1,default:!0},height:{type:Number,required:!1,default:8},fillColor:{type:String,required:!1,default:_.a.btfsBlue500},bgColor:{type:String,required:!1,default:_.a.btfsBlue100}},data:function(){return{isDragging:!1,knob:{x:0},parent:{width:0,left:0},slidebarPercent:this.initialPercent}},methods:{handleMouseMove:function(t){var e=t.x,s=e-this.parent.left;s<0?s=0:s>this.parent.width&&(s=this.parent.width),this.knob.x=s;var n=this.knob.x/this.parent.width||0;this.slidebarPercent=n.toFixed(4),this.$emit("slideChange",this.slidebarPercent)},handleMouseUp:function(){window.removeEventListener("mousemove",this.handleMouseMove),window.removeEventListener("mouseup",this.handleMouseUp)},startDrag:function(){this.isDragging=!0;var t=this.$refs.slidebarBg.getBoundingClientRect(),e=t.left,s=t.width;this.parent.width=s,this.parent.left=e,window.addEventListener("mousemove",this.handleMouseMove),window.addEventListener("mouseup",this.handleMouseUp)},handleResize:function(){var t=this.$refs.slidebarBg.getBoundingClientRect(),e=t.left,s=t.width;this.parent.width=s,this.parent.left=e,this.knob.x=(this.parent.width*this.slidebarPercent).toFixed(0)}},computed:{bgStyle:function(){return{height:"".concat(this.height,"px"),backgroundColor:this.bgColor}},fillStyle:function(){var t=(100*this.slidebarPercent).toFixed(0);return t="".concat(t,"%"),{width:t,backgroundColor:this.fillColor}}},watch:{initialPercent:function(){this.slidebarPercent=this.initialPercent,this.handleResize()}},mounted:function(){this.handleResize(),window.addEventListener("resize",this.handleResize)},beforeDestroy:function(){window.removeEventListener("resize",this.handleResize)}},Ct=mt,bt=(s("1357"),Object(d["a"])(Ct,vt,gt,!1,null,"46182f36",null)),yt=bt.exports,wt={name:"SetStorageSize",components:{SlideBar:yt},props:{isDisabled:{type:Boolean,required:!0,default:!0},storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},cap:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}}},data:function(){var t=this.initData();return{current:t,noSlideInput:this.storageSpace.cap,noSlideState:""}},methods:{handleSlideChange:function(t){var e=St(this.storageSpace.minimum,this.storageSpace.total,t);this.current.used=e.used,this.current.available=e.available,this.$emit("slideChange",this.current.used)},initData:function(){this.storageSpace.total<this.storageSpace.minimum&&(this.isDisabled=!0);var t=((this.storageSpace.cap-this.storageSpace.minimum)/(this.storageSpace.total-this.storageSpace.minimum)).toFixed(3);t=parseFloat(t);var e=St(this.storageSpace.minimum,this.storageSpace.total,t);return t<0?t=0:t>1&&(t=1),{used:e.used,available:e.available,initialPercent:t}}},computed:{earnings:function(){return _t(this.current.used)}},watch:{storageSpace:function(){var t=this.initData();this.current=Object(o["a"])(Object(o["a"])({},this.current),t)}}};function St(t,e,s){var n=(e-t)*s+t,a=e-n;return{used:parseInt(n.toFixed(0)),available:parseInt(a.toFixed(0))}}function _t(t){var e=8*t;return e=parseInt(e),e.toLocaleString()}var xt=wt,Pt=(s("41b8"),Object(d["a"])(xt,ht,ft,!1,null,"79e412d8",null)),Bt=Pt.exports,Tt={name:"HostSetup",components:{Button:D,Checkbox:ot,Textbox:pt,SetStorageSize:Bt},props:{storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},cap:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}},storageAnnounce:{type:Function,default:function(){}},setInitializationFlag:{type:Function,default:function(){}},updateHostSetupValues:{type:Function,default:function(){}},chooseOtherFolder:{type:Function,default:function(){}},hostSetupValues:{type:Object}},data:function(){return{steps:["1","2","3"],current:2,hasAgreed:!1,scrolledToBottom:!1,selectedStorageValue:this.storageSpace.cap}},computed:{isDisabled:function(){return this.selectedStorageValue<this.storageSpace.minimum}},methods:{handleScrolled:function(t){this.scrolledToBottom=t},handleCommitClick:function(){this.updateHostSetupValues({size:this.selectedStorageValue}),this.nextStep()},handleSetupComplete:function(){var t=this,e=this.hostSetupValues,s=e.path,n=e.size;this.newFolderPromise(s,n).then((function(){t.storageAnnounce(n)})),this.setInitializationFlag().then((function(){t.$router.push("/host")}))},newFolderPromise:function(t,e){var s=this;return new Promise((function(n){return null===t?n():s.chooseOtherFolder({path:t,size:e,suppressNotification:!0}).then((function(){return n()}))}))},nextStep:function(){this.current+=1},prevStep:function(){this.current-=1}},watch:{storageSpace:function(){this.selectedStorageValue=this.storageSpace.cap}},created:function(){this.storageAnnounce(32)}},Vt=Tt,Ht=(s("8a52"),Object(d["a"])(Vt,X,tt,!1,null,"041167aa",null)),kt=Ht.exports,Et=function(){var t=this,e=t.$createElement,s=t._self._c||e;return t.isStorageLoading?t._e():s("div",{staticClass:"dashboard"},[s("div",{staticClass:"dashboard-header"},[s("DashboardStat",{attrs:{title:"Status",tooltipText:t.onlineStatusTooltip}},[s("span",{staticClass:"online-status",class:{offline:!t.online,hidden:t.isSyncing}},[t._v(t._s(t.onlineStatusText))])]),s("DashboardStat",{attrs:{title:"Score",tooltipText:t.hostScoreTip}},[s("span",{class:{hidden:t.isSyncing}},[t._v(t._s(t.score)+"/10")])]),s("DashboardStat",{attrs:{title:"Uptime",tooltipText:"Percentage of time when a host is online and can prove the file replication."}},[s("span",{class:{hidden:t.isSyncing}},[t._v(t._s((100*t.uptime).toFixed(0))+"%")])]),s("DashboardStat",{attrs:{title:"Contracts",tooltipText:"Each contract represents a host’s commitment to a certain file shard’s storage length, size, and price."}},[s("span",{class:{hidden:t.isSyncing}},[t._v(t._s(t.contractsNum))])])],1),s("div",{staticClass:"dashboard-container"},[s("div",{staticClass:"earnings-container"},[s("div",{staticClass:"earnings"},[s("p",{staticClass:"title"},[t._v(" Earnings to-date ")]),s("p",{staticClass:"amount"},[s("span",{class:{hidden:t.isSyncing}},[t._v(t._s(t.earnings.toLocaleString("en-US",{maximumFractionDigits:6}))+" BTT ")])])]),s("div",{staticClass:"future-earnings"},[s("div",{staticClass:"future-potential-earnings"},[s("p",{staticClass:"secondary-title"},[t._v("Future potential earnings")]),s("p",{staticClass:"secondary-content"},[t._v(" "+t._s(t.potentialEarnings.toLocaleString("en-US",{maximumFractionDigits:6}))+" BTT ")])]),s("div",{staticClass:"next-payment-date"},[s("p",{staticClass:"secondary-title"},[t._v("Next payment date")]),s("p",{staticClass:"secondary-content"},[t._v(" "+t._s(t.nextPayment)+" ")])])]),s("div",{staticClass:"pricing"},[s("p",{staticClass:"secondary-title"},[t._v("Current host pricing")]),s("p",{staticClass:"secondary-content"},[t._v(" "+t._s(t.hostPricing.toLocaleString())+" BTT (TB/Month) ")])])]),t.isSyncing?t._e():s("ViewStorageSize",{attrs:{storageSpace:t.storageSpace,isDisabled:!1,handleEdit:t.toggleEditStorageSize,canEdit:"in-progress"!==t.notificationState}})],1),t.isSyncing?s("div",{staticClass:"syncing-bg"},[t._m(0)]):t._e(),t.showEditStorageSize?s("EditStorageSize",{attrs:{storageSpace:t.storageSpace,handleClose:t.toggleEditStorageSize,hostDirectoryPath:t.hostDirectoryPath,chooseOtherFolder:t.chooseOtherFolder,checkOtherFolder:t.checkOtherFolder}}):t._e(),t.showNotification?s("Notification",{attrs:{state:t.notificationState,handleClose:t.notificationClose},scopedSlots:t._u([{key:"in-progress",fn:function(){return[t._v(" Transferring files ("+t._s(t.storageSpace.used)+" GB).. ")]},proxy:!0},{key:"complete",fn:function(){return[t._v(" Transfer Complete ")]},proxy:!0}],null,!1,3949802468)}):t._e()],1)},Lt=[function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"syncing-container"},[n("p",{staticClass:"syncing-header"},[t._v("Validating...")]),n("p",{staticClass:"syncing-description"},[t._v("Please wait up to 180 minutes while the BTFS network is validating this node. A new node needs to demonstrate its online presence long enough to qualify as a valid storage host. Please refresh every 30 minutes to check for update. ")]),n("img",{staticClass:"spinner-icon",attrs:{src:s("9bfb")}})])}],It=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:t.tooltipText,classes:["tooltip",t.title],hideOnTargetClick:!1,autoHide:!1},expression:"{ content: tooltipText, classes: ['tooltip', title], hideOnTargetClick: false, autoHide: false}",modifiers:{bottom:!0}}],staticClass:"dashboard-stat"},[s("div",{staticClass:"title"},[t._v(" "+t._s(t.title)+" ")]),s("div",{staticClass:"content"},[t._t("default")],2)])},Ot=[],Ft={name:"DashboardStat",props:{title:{type:String,required:!0},tooltipText:{type:String,required:!0},tooltipClass:{type:String,default:""}},data:function(){return{}},methods:{}},Dt=Ft,At=(s("e8bc"),Object(d["a"])(Dt,It,Ot,!1,null,"0c2e0c26",null)),Mt=At.exports,$t=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"set-storage-size-container"},[s("div",{staticClass:"storage"},[t.canEdit?s("svg-icon",{staticClass:"edit-button",attrs:{icon:"edit",hasFill:!0},on:{click:t.handleEdit}}):t._e(),t.canEdit?t._e():s("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.left",value:{content:"Folder transfer in progress. Please wait until the transfer is done to make any changes.",classes:["tooltip"]},expression:"{ \n\t\t\t\tcontent: 'Folder transfer in progress. Please wait until the transfer is done to make any changes.', \n\t\t\t\tclasses: ['tooltip'], \n\t\t\t}",modifiers:{left:!0}}],staticClass:"edit-button disabled",attrs:{icon:"edit",hasFill:!0}}),s("p",{staticClass:"title"},[t._v("Host Storage Size")]),s("div",{staticClass:"storage-container"},[s("div",{staticClass:"storage-space"},[s("div",{staticClass:"storage-space-used",style:this.storageState.usedTextStyle},[t._v(" "+t._s(this.current.used)+" GB used ")]),s("div",{staticClass:"storage-space-available",style:this.storageState.remainingTextStyle},[t._v(" "+t._s(this.remainingSpace)+" GB remaining ")])]),s("SlideBar",{attrs:{initialPercent:this.current.initialPercent,slideable:!1,height:24,bgColor:this.storageState.bar.bgColor,fillColor:this.storageState.bar.fillColor}}),s("div",{staticClass:"error-container"},["warning"===this.storageState.state?s("p",{staticClass:"warning"},[t._v(" Your storage is almost full, please increase your storage commitment size. ")]):t._e(),"danger"===this.storageState.state?s("p",{staticClass:"danger"},[t._v(" Your storage is full, please increase your storage commitment size. ")]):t._e()])],1)],1)])},Nt=[],zt={name:"ViewStorageSize",components:{SlideBar:yt},props:{storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},cap:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}},handleEdit:{type:Function,default:function(){}},canEdit:{type:Boolean,default:!0}},data:function(){var t=this.initData();return{current:t,warningThreshold:10}},methods:{initData:function(){var t=(this.storageSpace.used/this.storageSpace.cap).toFixed(3);t=parseFloat(t);var e=jt(0,this.storageSpace.cap,t);return t<0?t=0:t>1&&(t=1),{used:this.storageSpace.used,available:e.available,initialPercent:t}}},computed:{remainingSpace:function(){return this.storageSpace.cap-this.storageSpace.used},storageState:function(){var t={bar:{bgColor:void 0,fillColor:void 0},usedTextStyle:{color:_.a.btfsBlue500},remainingTextStyle:{color:_.a.gray500},state:""};return this.remainingSpace<=this.warningThreshold&&(t.bar.bgColor="#FAEABF",t.bar.fillColor=void 0,t.remainingTextStyle={color:"#F7B500"},t.state="warning"),this.remainingSpace<=0&&(t.bar.bgColor="#EB5757",t.bar.fillColor="#EB5757",t.usedTextStyle={color:"#EB5757"},t.remainingTextStyle={color:"#EB5757"},t.state="danger"),t}},watch:{storageSpace:function(){var t=this.initData();this.current=Object(o["a"])(Object(o["a"])({},this.current),t)}}};function jt(t,e,s){var n=(e-t)*s+t,a=e-n;return{used:parseFloat(n.toFixed(3)),available:parseFloat(a.toFixed(3))}}var Rt=zt,Wt=(s("852b"),Object(d["a"])(Rt,$t,Nt,!1,null,"00770ab2",null)),Zt=Wt.exports,qt=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"edit-storage-size-bg"},[n("div",{staticClass:"edit-storage-size-container"},[n("img",{staticClass:"close-button",attrs:{src:s("bb7c")},on:{click:t.handleClose}}),n("p",{staticClass:"header"},[t._v("Edit host storage size")]),n("p",{staticClass:"description"},[t._v(" "+t._s(t.storageSpace.used)+" GB is your current storage used by BTFS. Try changing your storage commitment size in the input field below to see how much more you can earn. ")]),n("div",{staticClass:"no-slider-container"},[n("div",{},[n("p",{staticClass:"subtitle"},[t._v(" Local storage ")]),n("b",[t._v(" "+t._s(this.current.available)+" GB available of "+t._s(this.storageSpace.total)+" GB ")])]),n("EditHostFolder",{attrs:{hostDirectoryPath:t.hostDirectoryPath,checkOtherFolder:t.checkOtherFolder},on:{inputChange:t.onEditFolder,isEditingFolder:function(e){t.isEditingFolder=e}}}),n("div",{staticClass:"storage-commit-size"},[n("p",{staticClass:"subtitle"},[t._v(" Storage commitment size ")]),n("div",{staticClass:"storage-commit-size-container"},[n("div",[n("input",{directives:[{name:"model",rawName:"v-model",value:t.noSlideInput,expression:"noSlideInput"}],class:{unchanged:!t.isCommitSizeDifferent,invalid:""!==t.noSlideState||"commit-valid"===t.noSlideState},attrs:{type:"number"},domProps:{value:t.noSlideInput},on:{focus:t.handleInputFocus,blur:t.handleInputBlur,input:function(e){e.target.composing||(t.noSlideInput=e.target.value)}}}),t._v(" GB ")])]),n("div",{staticClass:"input-message-container"},["commit-invalid-low"===this.noSlideState||"commit-invalid-below"===this.noSlideState?n("div",{staticClass:"input-message invalid"},[n("svg-icon",{staticClass:"icon invalid",attrs:{icon:"info",hasFill:!0}}),"commit-invalid-low"===this.noSlideState?n("span",[t._v(" You cannot commit less than the current used storage size by BTFS ")]):t._e(),"commit-invalid-below"===this.noSlideState?n("span",[t._v(" You cannot commit less than 32 GB ")]):t._e()],1):t._e(),"commit-invalid-high"===this.noSlideState?n("div",{staticClass:"input-message invalid"},[n("svg-icon",{staticClass:"icon invalid",attrs:{icon:"info",hasFill:!0}}),t._v(" You do not have enough space on your hard drive ")],1):t._e(),""===this.noSlideState||"commit-valid"===this.noSlideState?n("div",{staticClass:"input-message earnings"},[t._v(" Monthly potential earnings: "+t._s(this.earnings)+" BTT ")]):t._e()])])],1),n("div",{staticClass:"button-container"},[n("Button",{attrs:{isDisabled:!t.canCommit,cb:t.handleCommitClick}},[t._v("Save changes")])],1)])])},Ut=[],Gt={name:"EditStorageSize",components:{Button:D,"svg-icon":L,EditHostFolder:G},props:{storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},cap:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}},handleClose:{type:Function,default:function(){}},hostDirectoryPath:{type:String,default:null},chooseOtherFolder:{type:Function,default:function(){}},checkOtherFolder:{type:Function,default:function(){}}},data:function(){var t=this.initData();return{noSlideInput:this.storageSpace.cap,current:t,noSlideState:"",newHostDirectoryPath:this.hostDirectoryPath,isEditingFolder:!1}},methods:{handleCommitClick:function(){var t=this;this.commitSizeCheck()&&(this.newFolderPromise().then((function(){t.isCommitSizeDifferent&&t.$store.dispatch("storageAnnounce",{hostStorageMax:t.noSlideInput,local:!1})})),this.handleClose())},newFolderPromise:function(){var t=this;return new Promise((function(e){return null!==t.newHostDirectoryPath&&t.isStorageLocationDifferent?t.chooseOtherFolder({path:t.newHostDirectoryPath,size:t.noSlideInput}).then((function(){return e()})):e()}))},commitSizeCheck:function(){var t=this.noSlideInput<this.storageSpace.minimum,e=this.noSlideInput<this.storageSpace.used,s=this.noSlideInput>this.storageSpace.total;return t?(this.noSlideState="commit-invalid-below",!1):e?(this.noSlideState="commit-invalid-low",!1):s?(this.noSlideState="commit-invalid-high",!1):(this.noSlideInput===this.storageSpace.cap||(this.noSlideState="commit-valid",this.current.used=this.noSlideInput,this.current.available=this.storageSpace.total-this.noSlideInput,this.noSlideInput=this.current.used),!0)},handleInputFocus:function(){this.noSlideState=""},handleInputBlur:function(){""===this.noSlideInput&&(this.noSlideInput=this.storageSpace.cap),this.noSlideInput=Math.round(this.noSlideInput)},initData:function(){this.storageSpace.total<this.storageSpace.minimum&&(this.isDisabled=!0);var t=((this.storageSpace.cap-this.storageSpace.minimum)/(this.storageSpace.total-this.storageSpace.minimum)).toFixed(3);t=parseFloat(t),t<0?t=0:t>1&&(t=1);var e=Kt(this.storageSpace.minimum,this.storageSpace.total,t);return{used:e.used,available:e.available,initialPercent:t}},onEditFolder:function(t){this.newHostDirectoryPath=t}},computed:{canCommit:function(){return this.isCommitSizeDifferent||this.isStorageLocationDifferent},earnings:function(){return Yt(this.noSlideInput)},isCommitSizeDifferent:function(){return this.storageSpace.cap!==this.noSlideInput},isStorageLocationDifferent:function(){return this.hostDirectoryPath!==this.newHostDirectoryPath}},watch:{storageSpace:function(){var t=this.initData();this.current=Object(o["a"])(Object(o["a"])({},this.current),t)}},created:function(){this.$store.dispatch("updateProposedStorageAvailable",null)}};function Kt(t,e,s){var n=(e-t)*s+t,a=e-n;return{used:parseInt(n.toFixed(3)),available:parseInt(a.toFixed(0))}}function Yt(t){var e=8*t;return e=parseInt(e),e.toLocaleString()}var Jt,Qt,Xt=Gt,te=(s("47fe"),Object(d["a"])(Xt,qt,Ut,!1,null,"6c02e0c0",null)),ee=te.exports,se=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"notification",class:{complete:"complete"===this.state}},["in-progress"===this.state?s("div",{staticClass:"notification-content"},[s("svg-icon",{staticClass:"icon check spinner-icon",attrs:{icon:"loading",hasFill:!0}}),t._t("in-progress")],2):t._e(),"complete"===this.state?s("div",{staticClass:"notification-content"},[s("div",{staticClass:"icon-container"},[s("svg-icon",{staticClass:"icon check",attrs:{icon:"check-circle-outline",hasFill:!0}})],1),t._t("complete"),s("div",{staticClass:"icon-container"},[s("svg-icon",{staticClass:"icon close",attrs:{icon:"close",hasFill:!0},on:{click:t.handleClose}})],1)],2):t._e()])},ne=[],ae={name:"Notification",components:{},props:{state:{type:String,default:""},handleClose:{type:Function,default:function(){}}},data:function(){return{}},methods:{}},ie=ae,oe=(s("f849"),Object(d["a"])(ie,se,ne,!1,null,"d8288616",null)),re=oe.exports,ce={name:"HostDashboard",props:{online:{type:Boolean,required:!1,default:!1},score:{type:Number,required:!1,default:0},uptime:{type:Number,required:!1,default:0},contractsNum:{type:Number,required:!1,default:0},earnings:{type:Number,required:!1,default:0},potentialEarnings:{type:Number,required:!1,default:0},hostPricing:{type:Number,required:!1,default:0},nextPayment:{type:String,required:!1,default:""},storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}},isSynced:{type:Boolean,required:!0,default:!1},isSyncedLocal:{type:Boolean,required:!0,default:!1},isStorageLoading:{type:Boolean,required:!0,default:!0},isImporting:{type:Boolean,required:!0,default:!1},hostDirectoryPath:{type:String,default:null},chooseOtherFolder:{type:Function,default:function(){}},checkOtherFolder:{type:Function,default:function(){}},notificationState:{type:String,default:""},notificationClose:{type:Function,default:function(){}},handleRefresh:{type:Function,default:function(){}}},components:{DashboardStat:Mt,ViewStorageSize:Zt,EditStorageSize:ee,Notification:re},methods:{onRefresh:function(){this.refresh()},toggleEditStorageSize:function(){this.showEditStorageSize=!this.showEditStorageSize}},data:function(){return{showEditStorageSize:!1}},computed:{onlineStatusText:function(){return this.online?"Online":"Offline"},onlineStatusTooltip:function(){return this.online?"You are connected to BTFS network and hosting.":"You are not connected to BTFS network, this could be due to many reasons, please check FAQs: <a href='https://docs.btfs.io/docs/faq' target='_blank' rel='noreferrer noopener'>https://docs.btfs.io/docs/faq</a>"},hostScoreTip:function(){return"This is an indicator of how reliable a host is, based on the following factors: <br/><br/>1. Uptime: Opted-in and enabled Host that is connected to the network; Please prevent interruptions from any network disconnection or client shut down, as this will reduce this value.<br/><br/>2. Host age: the historical time as a Host, manually opt-out as a Host will reduce this value.<br/><br/>3. Upload/download speed<br/><br/>4. Client version: Your client will be auto-updated to the latest node version, staying offline or disable this feature manually will reduce this value."},showNotification:function(){return""!==this.notificationState},isSyncing:function(){return!this.isSynced&&!this.isSyncedLocal||this.isImporting}},mounted:function(){this.handleRefresh()}},le=ce,ue=(s("2b57"),Object(d["a"])(le,Et,Lt,!1,null,"5ce58e42",null)),de=ue.exports,pe=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"settings"},[s("p",{staticClass:"title"},[t._v("Settings")]),s("div",{staticClass:"settings-nav"},t._l(t.navList,(function(e){return s("div",{key:e,staticClass:"settings-nav-item",class:{"settings-nav-item-selected":e===t.selectedNavItem},on:{click:function(){t.handleNavClick(e)}}})})),0),"host"===t.selectedNavItem?s("div",{staticClass:"settings-host"},[t._m(0),s("p",{staticClass:"content"},[t._v(" "+t._s(t.version)+" ")]),s("div",{staticClass:"subtitle-container"},[s("p",{staticClass:"subtitle"},[t._v(" host node id ")]),s("div",[s("Button",{attrs:{type:"copy",copyValue:t.id}},[t._v(" Copy ")])],1)]),s("div",{staticClass:"content node-id"},[s("p",{ref:"nodeId",attrs:{id:"node-id"}},[t._v(" "+t._s(t.id)+" ")])]),s("div",{staticClass:"subtitle-container"},[s("p",{staticClass:"subtitle"},[t._v(" Pricing tb/month ")]),s("div",[s("Button",{attrs:{type:"outline",isDisabled:t.showAdvanced,cb:t.toggleAdvanced}},[s("div",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:"Edit monthly host pricing",classes:["tooltip"]},expression:"{ \n\t\t\t\t\t\tcontent: 'Edit monthly host pricing', \n\t\t\t\t\t\tclasses: ['tooltip'], \n\t\t\t\t\t}",modifiers:{bottom:!0}}],staticClass:"button"},[t._v(" Edit "),s("svg-icon",{staticClass:"icon",attrs:{icon:"edit",hasFill:!0}})],1)])],1)]),s("div",{staticClass:"content"},[t._v(" "+t._s(t.hostPricing.toLocaleString())+" BTT "),t.equalSuggestedPricing||t.showAdvanced?t._e():s("p",{staticClass:"assistive-text"},[t._v(" Suggested price: "+t._s(this.hostSuggestedPricing.toLocaleString())+" BTT ")]),t.showAdvanced?s("div",[s("div",{staticClass:"pricing"},[s("div",{},[t._v(" Edit Pricing ")]),s("div",{staticClass:"pricing-input"},[s("input",{directives:[{name:"model",rawName:"v-model",value:t.newHostPricing,expression:"newHostPricing"}],class:{invalid:!t.isPricingValid},attrs:{type:"number"},domProps:{value:t.newHostPricing},on:{input:function(e){e.target.composing||(t.newHostPricing=e.target.value)}}}),t._v(" "),s("span",{staticClass:"subtitle"},[t._v("BTT")])])]),t.isPricingValid?t._e():s("p",{staticClass:"assistive-text invalid"},[t._v("Please enter a valid amount")]),t.isPricingValid?s("p",{staticClass:"assistive-text"},[t._v("Suggested price: "+t._s(this.hostSuggestedPricing.toLocaleString())+" BTT")]):t._e(),t._m(1),s("div",{staticClass:"button-container"},[s("Button",{staticClass:"cancel",attrs:{cb:t.toggleAdvanced,type:"outline"}},[t._v("Cancel")]),s("Button",{attrs:{cb:t.handlePriceUpdate,isDisabled:!t.isPricingValid}},[t._v("Save Changes")])],1)]):t._e()])]):t._e(),"wallet"===t.selectedNavItem?s("div",{staticClass:"settings-wallet"}):t._e(),t.showNotification?s("Notification",{attrs:{state:t.notificationState,handleClose:t.notificationClose},scopedSlots:t._u([{key:"complete",fn:function(){return[t._v(" Pricing updated ")]},proxy:!0}],null,!1,2867064329)}):t._e()],1)},he=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"subtitle-container"},[s("p",{staticClass:"subtitle"},[t._v(" current version ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"pricing-description"},[t._v(" Changes to advanced settings can impact your host earnings. Please make sure you understand the effects before making any changes. "),s("br"),s("br"),t._v(" Learn more on our "),s("a",{attrs:{href:"https://www.bittorrent.com/token/bittorrent-file-system/faq/",target:"_blank",rel:"noreferrer noopener"}},[t._v("FAQs page")]),t._v(". ")])}],fe={name:"Dashboard",props:{version:{type:String,required:!0},id:{type:String,required:!0},notificationState:{type:String,default:""},notificationClose:{type:Function,default:function(){}},hostPricing:{type:Number},hostSuggestedPricing:{type:Number},storageAnnouncePrice:{type:Function,default:function(){}}},components:{Button:D,Notification:re},methods:{handleNavClick:function(t){this.selectedNavItem=t},toggleAdvanced:function(){this.showAdvanced=!this.showAdvanced},notificationDone:function(){return this.$store.dispatch("notificationComplete","settings")},handlePriceUpdate:function(){var t=this;this.storageAnnouncePrice({hostStoragePrice:this.newHostPricing}).then((function(){return t.toggleAdvanced(),t.notificationDone()}))}},data:function(){return{navList:["host"],selectedNavItem:"host",showCopyTooltip:!1,showAdvanced:!1,newHostPricing:this.hostPricing}},computed:{showNotification:function(){return""!==this.notificationState},equalSuggestedPricing:function(){return this.hostPricing===this.hostSuggestedPricing},isPricingValid:function(){return this.newHostPricing>0&&this.newHostPricing<=304128e5}},mounted:function(){},beforeDestroy:function(){}},ve=fe,ge=(s("df8a"),Object(d["a"])(ve,pe,he,!1,null,"33a6a064",null)),me=ge.exports,Ce=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"wallet-add"},[t._m(0),s("div",{staticClass:"container"},[s("p",{staticClass:"text-lg"},[t._v("Create Wallet")]),s("p",{staticClass:"text-md"},[t._v("Click here if you don’t have an ID on BitTorrent Wallet.")]),s("div",{staticClass:"button-container"},[s("router-link",{attrs:{to:"/wallet/setup/"}},[s("Button",[t._v("Create")])],1)],1)]),s("div",{staticClass:"container"},[s("p",{staticClass:"text-lg"},[t._v("Import Wallet")]),s("p",{staticClass:"text-md"},[t._v("Click here if you already have a BitTorrent wallet ID.")]),s("div",{staticClass:"button-container"},[s("router-link",{attrs:{to:"/wallet/import/"}},[s("Button",[t._v("Import")])],1)],1)])])},be=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Wallet setup")]),s("p",{staticClass:"description"},[t._v(" Please follow the guide below to properly set up and protect your wallet. The wallet will allow you to manage your funds by transferring digital tokens. ")]),s("p",{staticClass:"sub-description"},[t._v(" Looking for more information? Read our "),s("a",{attrs:{href:"https://www.bittorrent.com/token/bittorrent-file-system/faq/",target:"_blank",rel:"noreferrer noopener"}},[t._v("FAQs")])])])}],ye={name:"WalletAdd",props:{},components:{Button:D},methods:{},data:function(){return{}},computed:{}},we=ye,Se=(s("8700"),Object(d["a"])(we,Ce,be,!1,null,"603bda8f",null)),_e=Se.exports,xe=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"wallet-setup"},[s("StepIndicator",{staticClass:"step-container",attrs:{steps:t.steps,current:t.current}}),1===t.current?s("div",{staticClass:"wallet-setup-1"},[t._m(0),s("div",{staticClass:"password-container"},[s("PasswordCreate",{on:{passwordsMatch:function(e){t.passwordsMatch=e},password:function(e){t.password=e}}})],1),t._m(1),s("div",{staticClass:"button-container"},[s("div"),s("div",[s("Button",{attrs:{disabled:!t.passwordsMatch,cb:t.handlePasswordSet}},[t._v("Continue")])],1)])]):t._e(),2===t.current?s("div",{staticClass:"wallet-setup-2"},[t._m(2),s("div",{staticClass:"recovery-right-container"},[s("div",{staticClass:"recovery-answer-key-container"},t._l(t.recoveryPhrases,(function(e,n){return s("div",{key:n,staticClass:"recovery-answer-key"},[t._v(t._s(e)+" ")])})),0)]),s("div",{staticClass:"button-container copy-container"},[s("Button",{attrs:{type:"copy",copyValue:t.recoveryPhrases.join(" ")}},[t._v("Copy recovery phrase to clipboard")])],1),s("div",{staticClass:"button-container"},[s("div",[s("Button",{attrs:{type:"text",cb:t.prevStep}},[t._v("Back")])],1),s("div",[s("Button",{attrs:{cb:t.nextStep}},[t._v("Continue")])],1)])]):t._e(),3===t.current?s("div",{staticClass:"wallet-setup-3"},[t._m(3),s("div",{staticClass:"recovery-match-container"},[s("RecoveryPhrase",{attrs:{choices:t.recoveryPhrases},on:{recoveryPhraseMatches:t.handleRecoveryPhraseMatches}}),s("p",{staticClass:"error-message",class:{hidden:!1!==t.recoveryPhraseMatches}},[t._v("The recovery phrase sequence is incorrect.")])],1),s("div",{staticClass:"button-container"},[s("div",[s("Button",{attrs:{type:"text",cb:t.prevStep}},[t._v("Back")])],1),s("div",[s("Button",{attrs:{disabled:!0!==t.recoveryPhraseMatches,cb:t.handleSetupComplete}},[t._v("Finish & create wallet")])],1)])]):t._e()],1)},Pe=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Wallet password")]),s("p",{staticClass:"description"},[t._v(" Create a strong password and save it in a safe place. We do not have access to your password and will not be able to restore it. Losing your password may result in losing access to your funds. ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"text-container"},[s("p",{staticClass:"text-sm"},[t._v(" By clicking “Continue” you agree to the "),s("a",{attrs:{href:"https://www.bittorrent.com/legal/terms-of-use/",target:"_blank",rel:"noreferrer noopener"}},[t._v("Terms of Service")]),t._v(" and "),s("a",{attrs:{href:"https://www.bittorrent.com/legal/privacy-policy/",target:"_blank",rel:"noreferrer noopener"}},[t._v("Privacy Policy")]),t._v(". ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Wallet recovery phrase")]),s("p",{staticClass:"description"},[t._v(" This is your wallet recovery phrase. Please copy this phrase and store it somewhere safe as there’s no way for us to help you recover this if it’s lost. ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Confirm recovery phrase")]),s("p",{staticClass:"description"},[t._v(" Confirm your recovery phrase by clicking on the words below and placing them in sequence in their corresponding tiles, starting from 1 and ending with 12. ")])])}],Be=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"step-indicator-container",style:t.stepIndicatorStyle},[s("ul",{staticClass:"step-list"},t._l(t.steps,(function(e,n){return s("li",{key:n,class:{active:n+1===t.currentClamped,filled:n+1<=t.currentClamped}},[s("div",{staticClass:"step-list-inner"},[t._v(" "+t._s(e)+" ")])])})),0)])},Te=[],Ve={name:"StepIndicator",props:{current:{type:Number,required:!0},steps:{type:Array,required:!0}},computed:{currentClamped:function(){return Math.max(Math.min(this.current,this.steps.length),1)},stepIndicatorStyle:function(){return{"--width":2===this.steps.length?"200%":"150%"}}}},He=Ve,ke=(s("06a9"),Object(d["a"])(He,Be,Te,!1,null,"7923a71f",null)),Ee=ke.exports,Le=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",[s("div",{staticClass:"password-container"},[s("bt-input-box",{attrs:{inputType:"password",placeholder:"Wallet Password",isInvalid:!t.passwordStrong,showMessage:!0,borderColor:t.colors.btfsBlue500,passwordPlaceholderColor:t.colors.gray500,messageColor:t.colors.btfsGreen,message:t.passwordState.message,iconColor:t.colors.gray500,invalidColor:t.passwordState.invalidColor},model:{value:t.password,callback:function(e){t.password=e},expression:"password"}}),s("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.left",value:{content:t.tooltip,classes:["tooltip"],hideOnTargetClick:!1,autoHide:!1},expression:"{ content: tooltip, classes: ['tooltip'], hideOnTargetClick: false, autoHide: false}",modifiers:{left:!0}}],staticClass:"icon",attrs:{icon:"help",hasFill:!1}})],1),s("div",{staticClass:"password-container"},[s("bt-input-box",{attrs:{inputType:"password",placeholder:"Confirm Password",isInvalid:!t.passwordsMatch&&""!==t.confirmPassword,showMessage:!0,borderColor:t.colors.btfsBlue500,passwordPlaceholderColor:t.colors.gray500,messageColor:t.colors.btfsGreen,message:t.confirmPasswordState.message,iconColor:t.colors.gray500,invalidColor:t.colors.btfsRed},model:{value:t.confirmPassword,callback:function(e){t.confirmPassword=e},expression:"confirmPassword"}})],1)])},Ie=[],Oe=s("580e"),Fe=s.n(Oe),De={name:"PasswordCreate",props:{},components:{"svg-icon":L,"bt-input-box":W},data:function(){return{togglePasswordCheck:!1,password:"",confirmPassword:"",colors:_.a,tooltip:"<div>We recommend including: <br/><br/>- One lower case letter<br/>- One upper case letter<br/>- One special character<br/>- One number<br/>- No spaces</div>"}},methods:{},computed:{passwordState:function(){switch(this.passwordStrength){case 1:return{message:"Very weak password",invalidColor:_.a.btfsRed};case 2:return{message:"Weak password",invalidColor:_.a.btfsOrange};case 3:return{message:"Strong password",invalidColor:_.a.btfsRed};default:return""}},confirmPasswordState:function(){return this.passwordStrong&&""!==this.confirmPassword?this.passwordsMatch?{message:"Passwords match"}:{message:"Passwords don't match",borderColor:_.a.btfsRed}:{message:"",borderColor:_.a.gray500}},passwordStrong:function(){return!this.passwordStrength||this.passwordStrength>2},passwordStrength:function(){return this.password?Math.max(Math.min(Fe()(this.password).score,3),1):null},passwordsMatch:function(){return this.$emit("passwordsMatch",this.password===this.confirmPassword&&this.passwordStrong&&""!==this.password),this.$emit("password",this.password),this.password===this.confirmPassword}}},Ae=De,Me=(s("91b4"),Object(d["a"])(Ae,Le,Ie,!1,null,"3bc160d8",null)),$e=Me.exports,Ne=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"recovery-phrase-container"},[s("div",{staticClass:"recovery-phrase-answer-container",class:{error:t.isError}},t._l(t.shuffledChoices,(function(e,n){e.choice;var a=e.originalIndex;return s("div",{key:a,staticClass:"recovery-phrase-answer",class:{empty:t.isAnswerEmpty(n),next:t.indexNextAnswer===n},on:{click:function(e){return t.removeAnswer(n)}}},[t._v(t._s(t.answers[n]))])})),0),s("transition-group",{staticClass:"recovery-phrase-choice-container",attrs:{tag:"div",name:"choice-container"}},t._l(t.shuffledChoices,(function(e,n){var a=e.choice,i=e.originalIndex;return s("div",{key:i,staticClass:"recovery-phrase-choice",class:{disabled:t.isChoiceDisabled(n),shuffled:t.shuffled},on:{click:function(e){return t.addAnswer(a,n)}}},[t._v(t._s(a))])})),0)],1)},ze=[],je=(s("cb29"),s("c740"),s("2ef0")),Re=s.n(je),We={name:"RecoveryPhrase",props:{choices:{type:Array,default:function(){return["test1","test2","test3","test4","test5","test6","test7","test8"]}},show:{type:Boolean,default:!0}},components:{},data:function(){return{shuffledChoices:[],shuffled:!1,answers:new Array(this.choices.length).fill(void 0),answerToShuffleIndexMapper:{}}},methods:{addAnswer:function(t,e){this.answerToShuffleIndexMapper[this.indexNextAnswer]=e,n["a"].set(this.answers,this.indexNextAnswer,t)},removeAnswer:function(t){delete this.answerToShuffleIndexMapper[t],n["a"].set(this.answers,t,void 0)},isAnswerEmpty:function(t){return!this.answers[t]},isChoiceDisabled:function(t){return Re.a.includes(this.answerToShuffleIndexMapper,t)},reset:function(){this.answers=new Array(this.choices.length).fill(void 0),this.$emit("reset")}},computed:{isError:function(){return this.validate&&!this.isCorrect},isCorrect:function(){return Re.a.isEqual(this.choices,this.answers)},indexNextAnswer:function(){return Re.a.findIndex(this.answers,(function(t){return void 0===t}))},validate:function(){return void 0!==this.answers[this.answers.length-1]}},watch:{validate:function(t){var e=t?this.isCorrect:null;this.$emit("recoveryPhraseMatches",e)}},created:function(){this.shuffledChoices=Re.a.reduce(this.choices,(function(t,e,s){return t.push({originalIndex:s,choice:e}),t}),[])},mounted:function(){var t=this;setTimeout((function(){t.shuffledChoices=Re.a.shuffle(t.shuffledChoices),t.shuffled=!0}),2e3)}},Ze=We,qe=(s("ff7d"),Object(d["a"])(Ze,Ne,ze,!1,null,"489feda0",null)),Ue=qe.exports,Ge={name:"WalletSetup",props:{recoveryPhrases:{type:Array,default:function(){return["Animated","Useful","Aunt","Grab","Visit","Sales","Brisk","Glory","Blind","Exotic","upset","valve"]}},setInitializationFlag:{type:Function,default:function(){}},setPassword:{type:Function,default:function(){}},getKeys:{type:Function,default:function(){}}},components:{StepIndicator:Ee,Button:D,PasswordCreate:$e,RecoveryPhrase:Ue},data:function(){return{steps:["1","2","3"],current:1,colors:_.a,passwordsMatch:!1,recoveryPhraseMatches:null,password:""}},computed:{},methods:{handleRecoveryPhraseMatches:function(t){this.recoveryPhraseMatches=t},nextStep:function(){this.current+=1,3===this.current&&(this.recoveryPhraseMatches=null)},prevStep:function(){this.current-=1},handleSetupComplete:function(){var t=this;this.setInitializationFlag().then((function(){t.$router.push("/wallet")}))},handlePasswordSet:function(){var t=this;this.getKeys({password:this.password}),this.setPassword({password:this.password}).then((function(){return t.getKeys({password:t.password})})).then((function(){void 0===t.recoveryPhrases||0==t.recoveryPhrases.length?t.handleSetupComplete():t.nextStep()}))}}},Ke=Ge,Ye=(s("85d8"),Object(d["a"])(Ke,xe,Pe,!1,null,"11c0b234",null)),Je=Ye.exports,Qe=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"wallet-dashboard"},[t.isAuth?t._e():s("div",{staticClass:"wallet-sign-in"},[s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Sign in to your BitTorrent Wallet")]),s("p",{staticClass:"description"},[t._v(" You are about to input some sensitive information, please DO NOT expose the password to strangers. ")]),s("p",{staticClass:"description"},[t._v(" We don’t have access to your password, and WILL NOT be able to restore it. ")]),s("bt-input-box",{attrs:{label:"Password",inputType:"password",isInvalid:t.passwordInvalid,message:t.passwordMessage},on:{input:function(e){return t.password=e}}}),s("div",{staticClass:"button-container"},[s("Button",{attrs:{cb:t.handleSignIn,isDisabled:null===t.password||t.passwordInvalid}},[t._v("Continue")])],1),s("div",{staticClass:"line"}),s("p",{staticClass:"sub-description"},[t._v(" Don't remember you password? You can "),s("router-link",{staticClass:"link",attrs:{to:"/wallet/import"}},[t._v("import")]),t._v(" your wallet using your recovery phrase and private key or create a new one "),s("router-link",{staticClass:"link",attrs:{to:"/wallet/setup"}},[t._v("here")]),t._v(". ")],1)],1)]),t.isAuth?s("div",[s("div",{staticClass:"wallet-dashboard-header"},[s("div",{staticClass:"recovery-info"},[s("Button",{attrs:{cb:t.toggleRecoveryInformation,type:"text"}},[t._v("Recovery information")])],1)]),s("div",{staticClass:"wallet-dashboard-container"},[s("WalletBalanceTile",{attrs:{title:"BTFS In-app Wallet Balance",balance:t.inAppBalance,defaultTargetAddress:"cryptoWalletAddress",dropdownOptions:["BTT Crypto Wallet","Other public address"],tooltipText:'This is the balance of your BTT in BTFS network, if you would like to deposit or withdraw using a crypto wallet or exchange, please use below "BTT Crypto Wallet" instead.',handleWalletTransfer:t.handleWithdraw,minTransferAmount:1e3,publicAddress:t.id}},[s("span",[t._v("Transferring to another public address is currently under development.")])]),s("WalletBalanceTile",{attrs:{title:"BTT Crypto Wallet Balance",balance:t.cryptoBalance,defaultTargetAddress:"inAppWalletAddress",dropdownOptions:["BTFS In-App Wallet","Other public address"],tooltipText:"BTT crypto wallet balance shows your BTT securely saved in an online wallet. You can exchange them with other tokens; transfer them to your In-app balance for use with BTFS; or send them to others",handleWalletTransfer:t.handleDeposit,minTransferAmount:10,publicAddress:t.tronAddress}},[s("span",[t._v(" Transferring to another public address is currently under development. However, you can still do it through your Command Line interface, for more information please refer to "),s("a",{staticClass:"link",attrs:{href:"https://docs.btfs.io/docs/withdraw-btt-from-btfs",target:"_blank",rel:"noreferrer noopener"}},[t._v("this guide.")])])]),s("div",{staticClass:"transaction-tile"},[s("div",{staticClass:"title"},[t._v(" transactions ")]),s("svg-icon",{staticClass:"refresh-icon",attrs:{hasFill:!0,icon:"refresh"},on:{click:t.onRefresh}}),s("bt-exchange-table",{attrs:{transactions:t.visibleTransactions}}),s("div",{staticClass:"pagination-container"},[t._v(" "+t._s(0===t.transactionsLength?0:t.pageItemStart+1)+"-"+t._s(t.pageItemEnd)+" of "+t._s(t.transactionsLength)+" "),s("svg-icon",{staticClass:"arrow-icon left",attrs:{hasFill:!0,icon:"chevron-left"},on:{click:t.paginationLeft}}),s("svg-icon",{staticClass:"arrow-icon",attrs:{hasFill:!0,icon:"chevron-right"},on:{click:t.paginationRight}})],1)],1)],1),t.showRecoveryInformation?s("RecoveryInformation",{attrs:{close:t.toggleRecoveryInformation,checkPassword:t.checkPassword}}):t._e()],1):t._e()])},Xe=[],ts=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"wallet-balance-tile"},[n("div",{staticClass:"upper"},[n("p",{staticClass:"title"},[t._v(" "+t._s(t.title)+" "),n("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:t.tooltipText,classes:["tooltip"],hideOnTargetClick:!1,autoHide:!1},expression:"{ content: tooltipText, classes: ['tooltip'], hideOnTargetClick: false, autoHide: false}",modifiers:{bottom:!0}}],staticClass:"help-icon",attrs:{hasFill:!0,icon:"help-outline"}})],1),t.isNumber?t._e():n("div",{staticClass:"balance-loading"},[n("img",{staticClass:"balance-loading-icon",attrs:{src:s("9bfb")}})]),t.isNumber?n("p",{staticClass:"balance"},[t._v(t._s(t.balance.toLocaleString("en-US",{maximumFractionDigits:6}))+" BTT")]):t._e(),n("div",{staticClass:"public-key-container"},[n("div",[t._v("Public Key ID")]),n("div",{staticClass:"public-key-id"},[n("span",{staticClass:"address"},[t._v(t._s(t.publicAddress))]),n("span",{staticClass:"address-abbreviate"},[t._v(t._s(t.abbreviatedPublicAddress))]),n("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:"copied!",classes:["tooltip"],show:t.showCopyTooltip,trigger:"manual"},expression:"{ \n                      content: 'copied!', \n                      classes: ['tooltip'], \n                      show: showCopyTooltip,\n                      trigger: 'manual'\n                    }",modifiers:{bottom:!0}}],staticClass:"copy-icon",attrs:{hasFill:!0,icon:"copy"},on:{click:t.handlePublicAddressCopy}})],1)]),t.isNumber?n("div",{staticClass:"transfer-button-container"},[n("Button",{attrs:{type:"text",cb:t.handleExpand,isOpen:t.isExpanded}},[t._v("Transfer")])],1):t._e()]),n("TransitionExpand",[t.isExpanded?n("div",{staticClass:"lower"},[n("div",{staticClass:"lower-inner-container"},["Other public address"!==t.dropdownSelection?n("bt-input-box",{attrs:{inputType:"number",placeholder:"Amount",showMessage:!0,messageColor:t.amountMessageColor,borderColor:t.colors.btfsBlue500,placeholderColor:t.colors.gray700,message:t.amountMessage,delayEmit:!0,isInvalid:!t.isAmountValid&&!t.isAmountEmpty},model:{value:t.amount,callback:function(e){t.amount=e},expression:"amount"}}):t._e(),n("Dropdown",{attrs:{parentId:t.title,placeholder:"Transfer to",options:t.dropdownOptions},on:{selection:function(e){return t.dropdownSelection=e}}}),"Other public address"===t.dropdownSelection?n("div",{staticClass:"coming-soon"},[t._t("default")],2):t._e(),n("div",{staticClass:"button-container"},[n("Button",{attrs:{disabled:!this.istargetAddressValid||this.istargetAddressEmpty||!this.isAmountValid,cb:t.handleSend}},[t._v("Send")])],1)],1)]):t._e()]),n("Popup",{attrs:{isOpen:t.showPopup,closeable:0===t.popupState,blur:!0},on:{onClose:t.handlePopoutClose}},[0===t.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("Transfer confirmation")]),n("p",{staticClass:"popup-description"},[t._v("You are about to send "+t._s(t.amount)+" BTT.")]),n("div",{staticClass:"popup-confirmation-buttons"},[n("Button",{attrs:{cb:t.handlePopoutClose,type:"text"}},[t._v("Cancel")]),n("Button",{attrs:{cb:t.handlePopoutConfirm}},[t._v("Continue")])],1)]):t._e(),1===t.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("Transfer processing")]),n("p",{staticClass:"popup-description"},[t._v("The transfer may take ~1 min before the balance change is shown.")]),n("img",{staticClass:"spinner-icon",attrs:{src:s("9bfb")}})]):t._e(),2===t.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("Transfer "+t._s(t.transferRes))]),n("p",{staticClass:"popup-description hide"}),n("div",{},[n("Button",{attrs:{cb:t.handlePopoutClose}},[t._v("Done")])],1)]):t._e()])],1)},es=[],ss={name:"TransitionExpand",functional:!0,render:function(t,e){var s={props:{name:"expand"},on:{afterEnter:function(t){t.style.height="auto"},enter:function(t){var e=getComputedStyle(t),s=e.width;t.style.width=s,t.style.position="absolute",t.style.visibility="hidden",t.style.height="auto";var n=getComputedStyle(t),a=n.height;t.style.width=null,t.style.position=null,t.style.visibility=null,t.style.height=0,getComputedStyle(t).height,requestAnimationFrame((function(){t.style.height=a}))},leave:function(t){var e=getComputedStyle(t),s=e.height;t.style.height=s,getComputedStyle(t).height,requestAnimationFrame((function(){t.style.height=0}))}}};return t("transition",s,e.children)}},ns=ss,as=(s("fd06"),s("4f3a"),Object(d["a"])(ns,Jt,Qt,!1,null,"421010e3",null)),is=as.exports,os=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"dropdown",class:{active:t.isExpanded}},[s("div",{staticClass:"selected",on:{click:t.handleExpand}},[s("p",[t._v(t._s(t.selected))]),s("svg-icon",{staticClass:"icon",class:{"up-arrow":t.isExpanded},attrs:{icon:"down-arrow",hasFill:!0}})],1),t.isExpanded?s("div",{staticClass:"expanded-container"},t._l(t.options,(function(e,n){return s("div",{key:e,staticClass:"option-container",class:{first:0===n},on:{click:function(s){return t.handleOptionClick(e)}}},[t._v(" "+t._s(e)+" ")])})),0):t._e()])},rs=[],cs={name:"Dropdown",components:{"svg-icon":L},props:{placeholder:{type:String,required:!0},options:{type:Array,default:function(){return["test","test2"]}},parentId:{type:String,required:!0}},data:function(){return{selected:this.placeholder}},methods:{handleExpand:function(){this.$store.dispatch("updateDropdownSync",this.parentId)},handleOptionClick:function(t){this.selected=t,this.$store.dispatch("updateDropdownSync",null),this.$emit("selection",this.selected)}},computed:{isExpanded:function(){return this.$store.state.wallet.dropdownSync===this.parentId}}},ls=cs,us=(s("4051"),Object(d["a"])(ls,os,rs,!1,null,"46f6b090",null)),ds=us.exports,ps=function(){var t=this,e=t.$createElement,n=t._self._c||e;return t.isOpen?n("div",{staticClass:"popup-bg",class:{blur:this.blur}},[n("div",{staticClass:"popup-container"},[n("div",{staticClass:"close-container"},[t.closeable?n("img",{attrs:{src:s("bb7c")},on:{click:t.onClose}}):t._e()]),t._t("default")],2)]):t._e()},hs=[],fs={name:"Popup",components:{},props:{isOpen:{type:Boolean,default:!1},closeable:{type:Boolean,default:!1},blur:{type:Boolean,default:!1}},data:function(){return{}},methods:{onClose:function(){this.$emit("onClose",!1)}}},vs=fs,gs=(s("3016"),Object(d["a"])(vs,ps,hs,!1,null,"b0c565c8",null)),ms=gs.exports,Cs={name:"WalletBalanceTile",props:{title:{type:String,required:!0},tooltipText:{type:String,default:""},balance:{type:Number,required:!0,default:0},defaultTargetAddress:{type:String,required:!0},dropdownOptions:{type:Array,required:!0,default:function(){return["Other public address"]}},handleWalletTransfer:{type:Function,required:!0,default:function(){}},minTransferAmount:{type:Number,default:1e3},publicAddress:{type:String,default:""}},components:{Button:D,TransitionExpand:is,Dropdown:ds,"bt-input-box":W,Popup:ms},data:function(){return{isExpanded:!1,colors:_.a,amount:"",targetAddress:"",dropdownSelection:null,showPopup:!1,popupState:0,transferRes:"",showCopyTooltip:!1}},methods:{handleExpand:function(){this.isExpanded=!this.isExpanded,!1===this.isExpanded&&(this.dropdownSelection=null)},handleSend:function(){this.showPopup=!0},handlePopoutConfirm:function(){var t=this;this.popupState=1,this.handleWalletTransfer({amount:this.amount}).then((function(e){setTimeout((function(){t.transferRes=e,t.popupState=2,t.amount=""}),500)}))},handlePopoutClose:function(){this.showPopup=!1,this.popupState=0},handlePublicAddressCopy:function(){var t=this;navigator.clipboard.writeText(this.publicAddress),this.showCopyTooltip=!0,setTimeout((function(){t.showCopyTooltip=!1}),1e3)}},computed:{isAmountValid:function(){return this.amount>=this.minTransferAmount&&this.amount<=this.balance},isAmountEmpty:function(){return null==this.amount||""===this.amount},amountMessage:function(){var t="The minimum transfer is ".concat(this.minTransferAmount," BTT");return this.amount<this.minTransferAmount&&!this.isAmountEmpty&&(t="Please enter at least ".concat(this.minTransferAmount," BTT")),this.amount>this.balance&&!this.isAmountEmpty&&(t="You don't have enough balance to create this transfer."),t},amountMessageColor:function(){return this.isAmountValid?_.a.btfsBlue500:_.a.gray700},istargetAddressValid:function(){return"test"===this.targetAddress||this.targetAddress===this.defaultTargetAddress},istargetAddressEmpty:function(){return null==this.targetAddress||""===this.targetAddress},targetAddressMessage:function(){return this.istargetAddressValid||this.istargetAddressEmpty?"":"The public address doesn't exist"},isNumber:function(){return!isNaN(this.balance)},abbreviatedPublicAddress:function(){return this.publicAddress.slice(0,12)+"..."+this.publicAddress.slice(-10)}},watch:{dropdownSelection:function(){"Transfer to"!==this.dropdownSelection&&null!==this.dropdownSelection?this.targetAddress="Other public address"===this.dropdownSelection?"":this.defaultTargetAddress:this.targetAddress=""}}},bs=Cs,ys=(s("9fd0"),Object(d["a"])(bs,ts,es,!1,null,"61f8ce1c",null)),ws=ys.exports,Ss=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("vue-good-table",{attrs:{styleClass:"vgt-table striped",columns:t.columns,rows:t.rows,"sort-options":{enabled:!1}},scopedSlots:t._u([{key:"table-row",fn:function(e){return["created"===e.column.field?s("div",[s("span",[t._v(t._s(t.formatDate(e.row.created).date))]),t._v(", "),s("span",{staticClass:"time"},[t._v(t._s(t.formatDate(e.row.created).time))])]):"status"===e.column.field?[s("span",{style:t.statusStyle(e.row.status)},[t._v(t._s(t.capitalize(e.row.status)))])]:"to"===e.column.field?[t.isBase64(e.row.to)?s("div",{ref:"toRef"+e.row.id,attrs:{title:e.row.to}},[t._v(" "+t._s(e.row.to)+" ")]):s("div",[t._v(" "+t._s(e.row.to)+" ")])]:"from"===e.column.field?[t.isBase64(e.row.from)?s("div",{ref:"fromRef"+e.row.id,attrs:{title:e.row.from}},[t._v(" "+t._s(e.row.from)+" ")]):s("div",[t._v(" "+t._s(e.row.from)+" ")])]:s("span",[t._v(t._s(e.formattedRow[e.column.field])+" "),s("span",{staticClass:"btt-suffix"},[t._v("BTT")])])]}}])})},_s=[],xs=(s("a623"),s("07ac"),s("4d63"),s("25f0"),s("159b"),s("96cf"),s("1da1")),Ps=s("3835"),Bs=s("f617"),Ts=s("ed08"),Vs=(s("dac4"),s("c191"),{name:"bt-exchange-table",components:{"vue-good-table":Bs["a"]},props:{transactions:{type:Array,default:function(){return[{id:1,created:"1579547824",amount:200,type:"Deposit",status:"complete",message:""}]},validator:function(t){var e=["id","created","amount","type","status"];return t.every((function(t){return e.every((function(e){return e in t}))}))}}},data:function(){return{columns:[{label:"Date & Time",field:"created",width:"160px",tdClass:"table-date-cell"},{label:"Amount",field:"amount",type:"number",formatFn:this.formatAmount,tdClass:"ellipsis"},{label:"From",field:"from"},{label:"To",field:"to"},{label:"Status",field:"status",width:"100px",tdClass:"status-cell"}],rows:[]}},methods:{statusStyle:function(t){var e="";return e="success"===t.toLowerCase()?_.a.accentsGreen500:"pending"===t.toLowerCase()?_.a.accentsYellow500:_.a.accentsRed500,{color:e}},formatDate:function(t){var e=new Date(t),s={year:"numeric",month:"2-digit",day:"numeric",hour:"2-digit",minute:"2-digit",hour12:!1},n=e.toLocaleDateString(void 0,s).split(", "),a=Object(Ps["a"])(n,2),i=a[0],o=a[1];return{date:i,time:o}},formatAmount:function(t){var e={style:"decimal",minimumFractionDigits:0,maximumFractionDigits:6},s=t.toString();return Intl.NumberFormat("en-US",e).format(parseFloat(s))},capitalize:function(t){return t[0].toUpperCase()+t.slice(1)},updateRows:function(){this.rows=this.transactions.map((function(t){return t})).sort((function(t,e){return e.created-t.created})).slice(0,10)},resizeColumns:function(){Object.values(this.$refs).forEach((function(t){return t&&Ts["a"].ellipsifyText(t,5)}))},formatTable:function(){var t=this;return Object(xs["a"])(regeneratorRuntime.mark((function e(){return regeneratorRuntime.wrap((function(e){while(1)switch(e.prev=e.next){case 0:return t.updateRows(),e.next=3,t.$nextTick();case 3:t.resizeColumns();case 4:case"end":return e.stop()}}),e)})))()},isBase64:function(t){var e=new RegExp("^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{2}==|[A-Za-z0-9+/]{3}=)?$");return e.test(t)}},mounted:function(){this.$refs&&(this.formatTable(),window.addEventListener("resize",this.resizeColumns))},beforeDestroy:function(){window.removeEventListener("resize",this.resizeColumns)},watch:{transactions:function(){this.formatTable()}}}),Hs=Vs,ks=Object(d["a"])(Hs,Ss,_s,!1,null,"2c5402d4",null),Es=ks.exports,Ls=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("Popup",{staticClass:"recovery-popup",attrs:{closeable:!0,blur:!1,isOpen:!0},on:{onClose:t.close}},[0===this.state?s("div",[s("div",{staticClass:"popup-header"},[t._v("Recovery")]),s("div",{staticClass:"popup-description"},[t._v("Enter your password and continue to the next page to be able to see the recovery information.")]),s("div",{staticClass:"password-container"},[s("InputBox",{attrs:{inputType:"password",placeholder:"Wallet password",showMessage:!0,borderColor:t.colors.btfsBlue500,passwordPlaceholderColor:t.colors.gray500,iconColor:t.colors.gray500,isInvalid:t.passwordInvalid,message:t.passwordMessage},model:{value:t.password,callback:function(e){t.password=e},expression:"password"}})],1),s("div",{staticClass:"button-container"},[s("Button",{attrs:{cb:t.handleCheckPassword,isDisabled:t.passwordInvalid}},[t._v("Continue")])],1)]):t._e(),1===this.state?s("div",[s("div",{staticClass:"popup-header"},[t._v("Important message")]),s("div",{staticClass:"popup-description popup-description-1"},[t._v("The recovery information is unique and only you should have access. We encourage you to write these words down and keep them in a safe place. Avoid saving it in a computer or online service, and do not take a screenshot of it. If you lose it or share it with others, you may lose your assets.")]),s("div",{staticClass:"text-sm"},[t._v(" Looking for more information? Read our "),s("a",{attrs:{href:"https://www.bittorrent.com/token/bittorrent-file-system/faq/",target:"_blank",rel:"noreferrer noopener"}},[t._v("FAQs")])]),s("div",{staticClass:"text-sm tos-container"},[s("bt-checkbox",{on:{change:function(e){t.hasAgreed=e}},scopedSlots:t._u([{key:"right",fn:function(){return[t._v(" I agree to the terms and conditions of BTFS. ")]},proxy:!0}],null,!1,3282184752)})],1),s("div",{staticClass:"button-container"},[s("Button",{attrs:{cb:t.nextStep,isDisabled:!t.hasAgreed}},[t._v("Continue")])],1)]):t._e(),2===this.state?s("div",[s("div",{staticClass:"subtitle"},[t._v(" Recovery phrase ")]),s("div",{staticClass:"recovery-phrase-container"},[t.showRecoveryPhrase?t._e():s("div",{staticClass:"light-container"},[s("p",[t._v("Not available")]),s("br"),s("a",{attrs:{href:"https://docs.btfs.io/docs/faq",target:"_blank"}},[t._v("Click here to learn more")])]),t.showRecoveryPhrase?s("div",[s("div",{staticClass:"recovery-right-container"},[s("div",{staticClass:"recovery-answer-key-container"},t._l(t.mnemonic,(function(e,n){return s("div",{key:n,staticClass:"recovery-answer-key"},[t._v(t._s(e)+" ")])})),0)]),s("div",{staticClass:"button-container copy-recovery-phrase"},[s("Button",{attrs:{type:"copy",copyValue:t.mnemonic.join(" ")}},[t._v("Copy recovery phrase to clipboard")])],1)]):t._e()]),s("div",{staticClass:"subtitle"},[t._v(" Private Key ")]),s("div",{staticClass:"private-key-container"},[s("div",{staticClass:"light-container"},[t._v(" "+t._s(t.privateKey)+" ")]),s("div",{staticClass:"button-container copy-private-key"},[s("Button",{attrs:{type:"copy",copyValue:t.privateKey}},[t._v("Copy private key to clipboard")])],1)])]):t._e()])},Is=[],Os={name:"RecoveryInformation",components:{Popup:ms,InputBox:W,Button:D},props:{close:{type:Function,deafult:function(){}},checkPassword:{type:Function,default:function(){}}},data:function(){return{state:0,colors:_.a,password:null,passwordInvalid:!1,mnemonic:"",privateKey:"",hasAgreed:!1}},computed:{passwordMessage:function(){return this.passwordInvalid?"The password is incorrect":""},showRecoveryPhrase:function(){return Array.isArray(this.mnemonic)&&""!==this.mnemonic[0]}},methods:{nextStep:function(){this.state=this.state+=1,this.state>=3&&(this.close(),this.state=0)},handleCheckPassword:function(){var t=this;this.checkPassword({password:this.password}).then((function(e){t.mnemonic=e.mnemonic.split(" "),t.privateKey=e.privateKey,t.nextStep()})).catch((function(){t.passwordInvalid=!0}))}},watch:{password:function(){this.passwordInvalid&&(this.passwordInvalid=!1)}}},Fs=Os,Ds=(s("c833"),Object(d["a"])(Fs,Ls,Is,!1,null,"602cbda9",null)),As=Ds.exports,Ms={name:"WalletDashboard",props:{id:{type:String,required:!0},tronAddress:{type:String,required:!0},handleRefresh:{type:Function,default:function(){}},transactions:{type:Array,default:function(){return[{id:1,created:"1579547824",amount:200,type:"Deposit",status:"complete"}]}},handleDeposit:{type:Function,default:function(){}},handleWithdraw:{type:Function,default:function(){}},inAppBalance:{type:Number,required:!0,default:0},cryptoBalance:{type:Number,required:!0,default:0},checkPassword:{type:Function,default:function(){}}},components:{Button:D,WalletBalanceTile:ws,"bt-exchange-table":Es,RecoveryInformation:As},methods:{paginationLeft:function(){var t=this.pageIndex-1;t>=0&&(this.pageIndex=t)},paginationRight:function(){var t=this.pageIndex+1;t<this.transactionsLength/this.pageSize&&(this.pageIndex=t)},onRefresh:function(){this.handleRefresh()},toggleRecoveryInformation:function(){this.showRecoveryInformation=!this.showRecoveryInformation},handleSignIn:function(){var t=this;this.checkPassword({password:this.password}).then((function(){t.$store.dispatch("walletAuth",t.password)})).catch((function(){t.passwordInvalid=!0}))}},data:function(){return{pageIndex:0,pageSize:10,showRecoveryInformation:!1,password:null,passwordInvalid:!1}},computed:{transactionsLength:function(){return this.transactions.length},pageItemStart:function(){return this.pageIndex*this.pageSize},pageItemEnd:function(){var t=this.pageItemStart+this.pageSize;return t>this.transactionsLength?this.transactionsLength:t},visibleTransactions:function(){return this.transactions.slice(this.pageItemStart,this.pageItemEnd)},isAuth:function(){return null!==this.$store.state.wallet.auth},passwordMessage:function(){return this.passwordInvalid?"Incorrent password":""}},watch:{password:function(){this.passwordInvalid=!1}},mounted:function(){this.handleRefresh()}},$s=Ms,Ns=(s("5dd7"),Object(d["a"])($s,Qe,Xe,!1,null,"0acb481e",null)),zs=Ns.exports,js=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"wallet-import"},[n("StepIndicator",{staticClass:"step-container",attrs:{steps:t.steps,current:t.current}}),1===t.current?n("div",{staticClass:"container"},[t._m(0),n("div",{staticClass:"nav"},t._l(t.navList,(function(e){return n("div",{key:e,staticClass:"nav-item",class:{"nav-item-selected":e===t.selectedNavItem},on:{click:function(){t.handleNavClick(e)}}},[t._v(" "+t._s(e)+" ")])})),0),n("div",{staticClass:"text-container"},[n("InputBox",{attrs:{inputType:"textarea",borderColor:t.colors.btfsBlue500,placeholderColor:t.colors.gray800,invalidColor:t.colors.btfsRed,placeholder:"recovery phrase"===this.selectedNavItem?"12 words in order separated by a single space (ex: word word word...)":"",delayEmit:!0,isInvalid:!t.isValidInput&&!t.isInputEmpty,message:t.inputMessage,showMessage:!0},model:{value:t.input,callback:function(e){t.input=e},expression:"input"}})],1)]):t._e(),2===t.current?n("div",{staticClass:"container"},[t._m(1),n("PasswordCreate",{on:{passwordsMatch:function(e){t.passwordsMatch=e},password:function(e){t.password=e}}})],1):t._e(),t._m(2),n("div",{staticClass:"button-container"},[n("router-link",{attrs:{to:"/wallet/setup"}},[n("Button",{attrs:{type:"text"}},[t._v("Create instead")])],1),n("Button",{attrs:{cb:t.handleContinue,isDisabled:!t.canContinue}},[t._v("Continue")])],1),n("Popup",{attrs:{isOpen:t.showPopup},on:{onClose:t.handlePopoutClose}},[0===this.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("Importing...")]),n("p",{staticClass:"popup-description"},[t._v("This transfer may take up to a minute.")]),n("img",{staticClass:"spinner-icon",attrs:{src:s("9bfb")}})]):t._e(),1===this.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("System Error")]),n("p",{staticClass:"popup-description"},[t._v("Please try again later")]),n("div",{staticClass:"button-container center"},[n("Button",{attrs:{cb:t.handlePopoutClose}},[t._v("Continue")])],1)]):t._e()])],1)},Rs=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Import Wallet")]),s("p",{staticClass:"description"},[t._v(" You are about to input highly sensitive information, please DO NOT expose to strangers. ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Wallet password")]),s("p",{staticClass:"description"},[t._v(" Create a strong password and save it in a safe place. We do not have access to your password and will not be able to restore it. Losing your password may result in losing access to your funds. ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"text-container"},[s("p",{staticClass:"text-sm"},[t._v(" By clicking “Continue” you agree to the "),s("a",{attrs:{href:"https://www.bittorrent.com/legal/terms-of-use/",target:"_blank",rel:"noreferrer noopener"}},[t._v("Terms of Service")]),t._v(" and "),s("a",{attrs:{href:"https://www.bittorrent.com/legal/privacy-policy/",target:"_blank",rel:"noreferrer noopener"}},[t._v("Privacy Policy")]),t._v(". ")])])}],Ws=(s("498a"),{name:"WalletImport",props:{setPassword:{type:Function,default:function(){}},importWallet:{type:Function,default:function(){}},setInitializationFlag:{type:Function,default:function(){}}},components:{Button:D,InputBox:W,StepIndicator:Ee,PasswordCreate:$e,Popup:ms},methods:{handleNavClick:function(t){this.selectedNavItem=t,this.input=null},nextStep:function(){this.current+=1},handlePopoutClose:function(){this.showPopup=!1,this.popupState=0},handleImportWallet:function(){var t=this;this.showPopup=!0;var e="private key"===this.selectedNavItem?"p":"m";this.importWallet({type:e,value:this.input}).then((function(){t.nextStep(),t.handlePopoutClose()})).catch((function(e){console.log(e),"private key"===t.selectedNavItem?(t.handlePopoutClose(),t.invalidPrivateKey=!0):t.popupState=1}))},handleSetPassword:function(){var t=this;this.setPassword({password:this.password}).then((function(){return t.setInitializationFlag()})).then((function(){t.$router.push("/wallet")}))},handleContinue:function(){switch(this.current){case 1:this.handleImportWallet();break;case 2:this.handleSetPassword();break}}},data:function(){return{colors:_.a,navList:["recovery phrase","private key"],selectedNavItem:"recovery phrase",steps:["1","2"],current:1,showPopup:!1,popupState:0,input:null,password:"",passwordsMatch:!1,invalidPrivateKey:!1}},computed:{isValidInput:function(){if("recovery phrase"===this.selectedNavItem){if(null===this.input)return!1;var t=this.input.trim().split(" ");return 12===t.length}return null!==this.input&&(!0!==this.invalidPrivateKey&&(48===this.input.length||64===this.input.length))},isInputEmpty:function(){return null===this.input},inputMessage:function(){return this.isInputEmpty||this.isValidInput?"":"private key"===this.selectedNavItem&&this.invalidPrivateKey?"The recovery phrase sequence is incorrect.":"recovery phrase"===this.selectedNavItem?"The recovery phrase must be 12 words":"The private key must be 48 or 64 characters"},canContinue:function(){return 1===this.current?this.isValidInput:this.passwordsMatch}},watch:{input:function(){this.invalidPrivateKey=!1}}}),Zs=Ws,qs=(s("ac0b"),Object(d["a"])(Zs,js,Rs,!1,null,"e2a011d6",null)),Us=qs.exports,Gs=(s("b64b"),s("99af"),s("c975"),s("a434"),s("53ca")),Ks="text/plain",Ys="application/json",Js={protocol:window.location.protocol.slice(0,-1),domain:window.location.hostname,port:void 0,base:"api",token:void 0,endpoints:{"v1/config":{post:{contentType:Ys,params:{arg:String,json:Boolean},defaultParams:{arg:"UI.Host.Initialized",json:!0}}},"v1/storage/announce":{post:{contentType:Ys,params:{"host-storage-max":Number,"host-storage-price":Number},defaultParams:{"host-storage-max":32,"host-storage-price":25e4}}},"v1/storage/info":{post:{contentType:Ks}},"v1/storage/stats/info":{post:{contentType:Ks,params:{l:Boolean},defaultParams:{l:!1}}},"v1/storage/stats/sync":{post:{contentType:Ks}},"v1/id":{post:{contentType:Ks}},"v1/version":{post:{contentType:Ks}},"v1/repo/stat":{post:{contentType:Ks,params:{human:Boolean},defaultParams:{human:!0}}},"v1/storage/contracts/stat":{post:{contentType:Ks,params:{arg:String},defaultParams:{arg:"host"}}},"v1/storage/contracts/sync":{post:{contentType:Ks,params:{arg:String},defaultParams:{arg:"host"}}},"v1/storage/contracts/list":{post:{contentType:Ks,params:{arg:String,order:String,status:String,size:Number},defaultParams:{arg:"host",order:"escrow_time,asc",status:"active",size:20}}},"v1/storage/path":{post:{contentType:Ys,params:{arg:String},defaultParams:{arg:""}}},"v1/storage/path/status":{post:{contentType:Ys}},"v1/storage/path/capacity":{post:{contentType:Ys,params:{arg:String},defaultParams:{arg:""}}},"v1/wallet/deposit":{post:{contentType:Ys,params:{arg:Number,a:String,password:String},defaultParams:{arg:"",a:"",password:""}}},"v1/wallet/withdraw":{post:{contentType:Ys,params:{arg:Number,password:String},defaultParams:{arg:"",password:""}}},"v1/wallet/balance":{post:{contentType:Ys}},"v1/wallet/password":{post:{contentType:Ys,params:{arg:String},defaultParams:{arg:""}}},"v1/wallet/keys":{post:{contentType:Ys}},"v1/wallet/transactions":{post:{contentType:Ys}},"v1/wallet/import":{post:{contentType:Ys,params:{p:String,m:String},defaultParams:{p:"",m:""}}}}},Qs={setToken:function(t,e){n["a"].set(t,"token",e)}};function Xs(t){var e=t.protocol,s=t.domain,n=t.port,a=t.base,i=t.paramsList,o=t.endpoint,r=t.params,c=Js.token?"?t=".concat(Js.token):"?";return i&&i.forEach((function(t){"?"!==c?null!==r[t]&&(c+="&".concat(t,"=").concat(r[t])):c+="".concat(t,"=").concat(r[t])})),"".concat(e,"://").concat(s,":").concat(n,"/").concat(a,"/").concat(o).concat(c)}function tn(t){var e,s,n=t.endpoint,a=t.type,i=void 0===a?"":a,o=t.method,r=void 0===o?"":o,c=t.body,l=t.params,u=void 0===l?{}:l,d=(i||r).toLowerCase(),p=Js.endpoints[n];if(!p||!p[d])throw e=new Error("Api method <".concat(d,"> doesn't exist for endpoint <").concat(n,">")),e.name="request",e;if(p[d].params&&(s=Object.keys(p[d].params),s.forEach((function(t){if(null===u[t]){var a=s.indexOf(t);a>-1&&s.splice(a,1)}if(!u[t]&&p[d].defaultParams[t]&&(u[t]=p[d].defaultParams[t]),Object(Gs["a"])(u[t])!==Object(Gs["a"])(p[d].params[t]()))throw e=new Error("Invalid param type <".concat(Object(Gs["a"])(u[t]),"> for @").concat(t," <").concat(Object(Gs["a"])(p[d].params[t]()),">")),e.name="request: endpoint ".concat(n,":").concat(d),e}))),p[d].body&&Object(Gs["a"])(c)!==Object(Gs["a"])(p[d].body()))throw e=new Error("Invalid body type <".concat(Object(Gs["a"])(c),"> expected @body <").concat(Object(Gs["a"])(p[d].body()),">")),e.name="request: endpoint ".concat(n,":").concat(d),e;var h=Js.protocol,f=Js.domain,v=Js.port,g=Js.base,m=Xs({protocol:h,domain:f,port:v,base:g,endpoint:n,paramsList:s,params:u}),C={method:d.toUpperCase(),headers:{"Content-Type":p[d].contentType}};return c&&(C.body=c),new Promise(function(){var t=Object(xs["a"])(regeneratorRuntime.mark((function t(e,s){var n,a;return regeneratorRuntime.wrap((function(t){while(1)switch(t.prev=t.next){case 0:return t.prev=0,t.next=3,fetch(m,C);case 3:if(n=t.sent,403!==n.status){t.next=6;break}throw"403";case 6:return t.next=8,n.text();case 8:a=t.sent,e(a),t.next=15;break;case 12:t.prev=12,t.t0=t["catch"](0),s(t.t0);case 15:case"end":return t.stop()}}),t,null,[[0,12]])})));return function(e,s){return t.apply(this,arguments)}}())}var en={state:Js,mutations:Qs,request:tn},sn=(s("5319"),en.request),nn={info:{storage_price_ask:7680},value:{host_stats:{online:!1,uptime:0,score:0,storage_used:0,storage_cap:0,storage_disk_total:0,storage_disk_available:0},renter_stats:{}},contracts:{active_contract_num:0,compensation_paid:0,compensation_outstanding:0,first_contract_start:"0001-01-01T00:00:00Z",last_contract_end:"0001-01-01T00:00:00Z",role:0},contractsList:{contracts:[]},setup:{path:null,size:null},isSynced:!1,isSyncedLocal:!1,isImporting:!1,proposedStorageAvailable:null,isStorageLoading:!0},an={setInfo:function(t,e){n["a"].set(t,"info",e)},setStatsInfo:function(t,e){n["a"].set(t,"value",e)},setContractsInfo:function(t,e){var s=e;s.compensation_outstanding=s.compensation_outstanding/1e6,s.compensation_paid=s.compensation_paid/1e6,n["a"].set(t,"contracts",s)},setContractsList:function(t,e){n["a"].set(t,"contractsList",e)},setSetupValues:function(t,e){n["a"].set(t,"setup",Object(o["a"])(Object(o["a"])({},t.setup),e))},setIsSynced:function(t,e){n["a"].set(t,"isSynced",e)},setIsSyncedLocal:function(t,e){n["a"].set(t,"isSyncedLocal",e)},setIsStorageLoading:function(t,e){n["a"].set(t,"isStorageLoading",e)},setIsImporting:function(t,e){n["a"].set(t,"isImporting",e)},setProposedStorageAvailable:function(t,e){n["a"].set(t,"proposedStorageAvailable",e)}},on={statsInfo:function(t){return t.value},contractInfo:function(t){return t.value}},rn={storageInfo:function(t){return sn({endpoint:"v1/storage/info",method:"POST"}).then((function(e){var s=JSON.parse(e),n=Math.round(s.storage_price_ask/1e6*30*1024);s.storage_price_ask=n,t.commit("setInfo",s)}))},storageStatsInfo:function(t){var e=!t.rootState.config.host.initialized,s={endpoint:"v1/storage/stats/info",method:"POST"};return s.params={l:e},sn(s).then((function(s){var n=JSON.parse(s);n.host_stats&&(t.commit("setStatsInfo",n),e||t.commit("setIsSyncedLocal",!0)),t.commit("setIsStorageLoading",!1)}))},storageStatsSync:function(){return sn({endpoint:"v1/storage/stats/sync",method:"POST"}).then((function(t){return""===t}))},storageAnnounce:function(t,e){return sn({endpoint:"v1/storage/announce",method:"POST",params:{"host-storage-price":null,"host-storage-max":e["hostStorageMax"]}}).then((function(){return t.dispatch("storageStatsInfo")}))},storageAnnouncePrice:function(t,e){var s=Math.round(1e6*e["hostStoragePrice"]/30/1024);return sn({endpoint:"v1/storage/announce",method:"POST",params:{"host-storage-price":s,"host-storage-max":null}}).then((function(){return t.dispatch("storageInfo")}))},storageContractsInfo:function(t){return sn({endpoint:"v1/storage/contracts/stat",method:"POST",params:{role:"host"}}).then((function(e){t.commit("setContractsInfo",JSON.parse(e))}))},storageContractsSync:function(){return sn({endpoint:"v1/storage/contracts/sync",method:"POST",params:{role:"host"}}).then((function(t){return""===t}))},storageContractsList:function(t){return sn({endpoint:"v1/storage/contracts/list",method:"POST",params:{role:"host",order:"escrow_time,asc",status:"active",size:20}}).then((function(e){t.commit("setContractsList",JSON.parse(e))}))},appReset:function(){return new Promise((function(t){var e=setInterval((function(){sn({endpoint:"v1/storage/path/status",method:"POST"}).then((function(){return clearInterval(e),t()})).catch((function(){}))}),2e3)}))},storagePath:function(t,e){return!0!==e.suppressNotification?t.dispatch("notificationInProgress","host"):t.commit("setIsImporting",!0),sn({endpoint:"v1/storage/path",method:"POST",params:{arg:"".concat(e.path,"&arg=").concat(e.size)}}).then((function(){return s(t,e.suppressNotification)})).catch((function(){return s(t,e.suppressNotification)}));function s(t,e){return t.dispatch("appReset").then((function(){t.dispatch("metaRepoStat"),!0!==e?t.dispatch("notificationComplete","host"):t.commit("setIsImporting",!1)}))}},storagePathCapacity:function(t,e){return sn({endpoint:"v1/storage/path/capacity",method:"POST",params:{arg:e.path}}).then((function(s){var n=JSON.parse(s),a=cn(n.FreeSpace);if("error"===n.Type)return Promise.reject(n.Message);if(a<32||!n.Valid){var i=n.Valid?"You do not have enough space on your hard drive":"".concat(e.path.replace(/\/+$/,""),"/.btfs is not empty");return Promise.reject(i)}return t.dispatch("updateProposedStorageAvailable",n.FreeSpace),Promise.resolve("[storagePathCapacity] pass")})).catch((function(t){return Promise.reject(t)}))},updateHostSetupValues:function(t,e){t.commit("setSetupValues",e)},updateIsSynced:function(t,e){t.commit("setIsSynced",e)},updateProposedStorageAvailable:function(t,e){t.commit("setProposedStorageAvailable",e)}};function cn(t){var e=t/Math.pow(1e3,3);return e=Math.floor(e),e}var ln={state:nn,getters:on,mutations:an,actions:rn},un=en.request,dn={value:{},id:{ID:"",PublicKey:"",TronAddress:""}},pn={setRepoStat:function(t,e){n["a"].set(t,"value",e)},setId:function(t,e){n["a"].set(t,"id",e)},setVersion:function(t,e){n["a"].set(t,"version",e)}},hn={repoStat:function(t){return t.value}},fn={metaRepoStat:function(t){return un({endpoint:"v1/repo/stat",method:"POST",params:{human:!0}}).then((function(e){t.commit("setRepoStat",JSON.parse(e))}))},metaId:function(t){return un({endpoint:"v1/id",method:"POST"}).then((function(e){t.commit("setId",JSON.parse(e))}))},metaVersion:function(t){return un({endpoint:"v1/version",method:"POST"}).then((function(e){t.commit("setVersion",JSON.parse(e))}))}},vn={state:dn,getters:hn,mutations:pn,actions:fn},gn=en.request,mn={host:{initialized:!1},wallet:{initialized:!1},hostEnabled:{value:!0}},Cn={setHostConfig:function(t,e){n["a"].set(t,"host",{initialized:e.Value})},setWalletConfig:function(t,e){n["a"].set(t,"wallet",{initialized:e.Value})},setStorageHostEnabled:function(t,e){n["a"].set(t,"hostEnabled",{value:e.Value})}},bn={config:function(t){return t}},yn={getHostConfig:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"UI.Host.Initialized"}}).then((function(e){t.commit("setHostConfig",JSON.parse(e))}))},setHostConfig:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"UI.Host.Initialized&arg=true"}}).then((function(){return t.dispatch("getHostConfig")}))},getWalletConfig:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"UI.Wallet.Initialized"}}).then((function(e){t.commit("setWalletConfig",JSON.parse(e))}))},setWalletConfig:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"UI.Wallet.Initialized&arg=true"}}).then((function(){return t.dispatch("getWalletConfig")}))},getStorageHostEnabled:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"Experimental.StorageHostEnabled"}}).then((function(e){t.commit("setStorageHostEnabled",JSON.parse(e))}))}},wn={state:mn,getters:bn,mutations:Cn,actions:yn},Sn=en.request,_n={balance:{BtfsWalletBalance:NaN,BttWalletBalance:NaN},transactions:[],keys:{PrivateKey:"",Mnemonic:[]},dropdownSync:null,auth:null},xn={setBalance:function(t,e){var s={BtfsWalletBalance:e.BtfsWalletBalance/1e6,BttWalletBalance:e.BttWalletBalance/1e6};n["a"].set(t,"balance",s)},setKeys:function(t,e){var s=e.res.Mnemonic,a=""===s?[]:s.split(" "),i={PrivateKey:e.res.PrivateKey,Mnemonic:a};n["a"].set(t,"keys",i)},setTransactions:function(t,e){var s=e.map((function(t,e){var s=new Date(t.time_create);return{id:e,created:s,amount:t.amount/1e6,type:"Deposit",status:t.status,from:t.from,to:t.to}}));s.sort((function(t,e){return new Date(e.created)-new Date(t.created)})),n["a"].set(t,"transactions",s)},setDropdownSync:function(t,e){n["a"].set(t,"dropdownSync",e)},setAuth:function(t,e){n["a"].set(t,"auth",e)}},Pn={balance:function(t){return t.balance},recoveryPhrase:function(t){return t.recoveryPhrase},transactions:function(t){return t.transactions},dropdownSync:function(t){return t.dropdownSync},auth:function(t){return t.auth}},Bn={walletBalance:function(t){return Sn({endpoint:"v1/wallet/balance",method:"POST"}).then((function(e){var s=JSON.parse(e);t.commit("setBalance",s)}))},walletPassword:function(t,e){return Sn({endpoint:"v1/wallet/password",method:"POST",params:{arg:e.password}})},walletKeys:function(t,e){return Sn({endpoint:"v1/wallet/keys",method:"POST"}).then((function(s){t.commit("setKeys",{res:JSON.parse(s),password:e.password})}))},walletTransactions:function(t){return Sn({endpoint:"v1/wallet/transactions",method:"POST"}).then((function(e){var s=JSON.parse(e);t.commit("setTransactions",s)}))},walletDeposit:function(t,e){return Sn({endpoint:"v1/wallet/deposit",method:"POST",params:{arg:1e6*e.amount,a:"",password:t.state.auth}}).then((function(e){t.dispatch("walletTransactions"),t.dispatch("walletBalance");var s=JSON.parse(e);return"Type"in s&&"error"===s.Type?"failed":"success"}))},walletWithdraw:function(t,e){return Sn({endpoint:"v1/wallet/withdraw",method:"POST",params:{arg:1e6*e.amount,password:t.state.auth}}).then((function(e){t.dispatch("walletTransactions"),t.dispatch("walletBalance");var s=JSON.parse(e);return"Type"in s&&"error"===s.Type?"failed":"success"}))},walletCheckPassword:function(t,e){return Sn({endpoint:"v1/wallet/keys",method:"POST"}).then((function(t){var s=JSON.parse(t),n=Ts["a"].decrypt_with_aes(e.password,s.Mnemonic),a=Ts["a"].decrypt_with_aes(e.password,s.PrivateKey);return{mnemonic:n,privateKey:a}})).catch((function(){return Promise.reject()}))},walletImport:function(t,e){var s="p"===e.type?{p:e.value,m:""}:{p:"",m:e.value.replace(/\s+/g,"+").toLowerCase()};return Sn({endpoint:"v1/wallet/import",method:"POST",params:s}).then((function(e){if(""!==e){var s=JSON.parse(e);if("Type"in s&&"error"===s.Type)return Promise.reject()}return t.dispatch("appReset")})).catch((function(){return Promise.reject()}))},updateDropdownSync:function(t,e){var s=t.state.dropdownSync;s===e?t.commit("setDropdownSync",null):t.commit("setDropdownSync",e)},walletAuth:function(t,e){t.commit("setAuth",e)}},Tn={state:_n,getters:Pn,mutations:xn,actions:Bn},Vn={host:"",settings:""},Hn={setHost:function(t,e){n["a"].set(t,e.page,e.state)}},kn={notification:function(t){return t}},En={notificationInProgress:function(t,e){t.commit("setHost",{page:e,state:"in-progress"})},notificationComplete:function(t,e){t.commit("setHost",{page:e,state:"complete"})},notificationClose:function(t,e){t.commit("setHost",{page:e,state:""})}},Ln={state:Vn,getters:kn,mutations:Hn,actions:En},In={storage:ln,meta:vn,config:wn,wallet:Tn,notification:Ln},On={modules:In,install:function(t,e){var s=e.port,n=e.token;en.state.port=s,en.state.token=n,Object.keys(In).forEach((function(e){t.registerModule(e,In[e])}))}},Fn=s("2f62"),Dn=s("e37d"),An=s("0284"),Mn=s.n(An),$n=s("bc3a"),Nn=s.n($n),zn=s("a7fe"),jn=s.n(zn);s("4bb6"),s("03c7"),s("ea40");n["a"].config.productionTip=!1,n["a"].use(b["a"]),n["a"].use(Fn["a"]),n["a"].use(Dn["a"]);var Rn=[{name:"HostAdd",path:"/host/add",component:Q},{name:"HostSetup",path:"/host/setup",component:kt},{name:"HostDashboard",path:"/host",component:de},{name:"Settings",path:"/settings",component:me},{name:"WalletAdd",path:"/wallet/add",component:_e},{name:"WalletSetup",path:"/wallet/setup",component:Je},{name:"WalletDashboard",path:"/wallet",component:zs},{name:"WalletImport",path:"/wallet/import",component:Us}],Wn=new b["a"]({routes:Rn}),Zn=location.port,qn=new Fn["a"].Store({strict:!1});On.install(qn,{port:Zn}),n["a"].use(Mn.a,{id:"UA-747528-14",router:Wn}),n["a"].use(jn.a,Nn.a),new n["a"]({router:Wn,store:qn,render:function(t){return t(C)}}).$mount("#app")},"5c0b":function(t,e,s){"use strict";var n=s("a90f"),a=s.n(n);a.a},"5d63":function(t,e){t.exports='<svg width="19" height="22" viewBox="0 0 19 22" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M14 0H2C0.9 0 0 0.9 0 2V16H2V2H14V0ZM17 4H6C4.9 4 4 4.9 4 6V20C4 21.1 4.9 22 6 22H17C18.1 22 19 21.1 19 20V6C19 4.9 18.1 4 17 4ZM6 20H17V6H6V20Z" fill="#9E9E9E"/>\n</svg>\n'},"5dd7":function(t,e,s){"use strict";var n=s("e11e"),a=s.n(n);a.a},6:function(t,e){},"651b":function(t,e,s){},"674b":function(t,e,s){},"68ae":function(t,e,s){},"6eaf":function(t,e){t.exports='<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" fill="none" viewBox="0 0 24 24">\n    <path fill="#7D5AE6" d="M12 4C7 4 2.73 7.11 1 11.5 2.73 15.89 7 19 12 19s9.27-3.11 11-7.5C21.27 7.11 17 4 12 4zm0 12.5c-2.76 0-5-2.24-5-5s2.24-5 5-5 5 2.24 5 5-2.24 5-5 5zm0-8c-1.66 0-3 1.34-3 3s1.34 3 3 3 3-1.34 3-3-1.34-3-3-3z"/>\n</svg>\n'},"6faf":function(t,e,s){},7:function(t,e){},"794c":function(t,e){t.exports='<svg width="46" height="46" viewBox="0 0 46 46" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path d="M16.7242 7.93709C16.4543 7.28941 16.7596 6.54089 17.425 6.31848C20.7299 5.21394 24.2988 5.11311 27.6763 6.04465C31.4633 7.08911 34.7916 9.37127 37.1306 12.5273C39.4697 15.6834 40.6848 19.5316 40.5824 23.4586C40.48 27.3857 39.0658 31.1653 36.5654 34.1951C34.065 37.225 30.6222 39.3305 26.786 40.1761C22.9497 41.0217 18.9408 40.5587 15.3983 38.8609C11.8558 37.1631 8.98368 34.3282 7.23974 30.8082C5.68434 27.6688 5.10624 24.1455 5.56373 20.6911C5.65585 19.9955 6.33287 19.5539 7.01998 19.6959C7.7071 19.838 8.14327 20.5101 8.05964 21.2067C7.71188 24.1036 8.21313 27.0494 9.51651 29.6802C11.0085 32.6917 13.4658 35.117 16.4965 36.5696C19.5272 38.0221 22.957 38.4182 26.2391 37.6948C29.5211 36.9713 32.4665 35.17 34.6057 32.5779C36.7449 29.9857 37.9547 26.7521 38.0424 23.3924C38.13 20.0327 37.0904 16.7404 35.0893 14.0402C33.0881 11.3401 30.2406 9.38765 27.0008 8.49407C24.1705 7.71347 21.1831 7.7802 18.4048 8.67125C17.7366 8.88552 16.994 8.58477 16.7242 7.93709Z" fill="url(#paint0_angular)"/>\n<defs>\n<radialGradient id="paint0_angular" cx="0" cy="0" r="1" gradientUnits="userSpaceOnUse" gradientTransform="translate(23 23) rotate(-176.532) scale(21.1448)">\n<stop offset="0.0215903" stop-color="#EEF3FE"/>\n<stop offset="0.166667" stop-color="#3E7EFF"/>\n<stop offset="0.764875" stop-color="#31529E"/>\n</radialGradient>\n</defs>\n</svg>\n'},"7a16":function(t,e,s){"use strict";var n=s("eaa6"),a=s.n(n);a.a},8:function(t,e){},"852b":function(t,e,s){"use strict";var n=s("ffe3"),a=s.n(n);a.a},"85d8":function(t,e,s){"use strict";var n=s("ac39"),a=s.n(n);a.a},8700:function(t,e,s){"use strict";var n=s("f819"),a=s.n(n);a.a},8979:function(t,e,s){},"8a52":function(t,e,s){"use strict";var n=s("8c7e"),a=s.n(n);a.a},"8a65":function(t,e,s){},"8c04":function(t,e,s){"use strict";var n=s("68ae"),a=s.n(n);a.a},"8c7e":function(t,e,s){},"8ea4":function(t,e,s){},"8ff8":function(t,e,s){},9:function(t,e){},"91b4":function(t,e,s){"use strict";var n=s("939b"),a=s.n(n);a.a},"939b":function(t,e,s){},"94b9":function(t,e,s){var n={"./access-time.svg":"7017","./add-circle-outline.svg":"f222","./add.svg":"045d","./arrow-back.svg":"048d","./arrow-downward.svg":"960b","./arrow-drop-down.svg":"7c90","./arrow-drop-up.svg":"4ddf","./arrow-forward.svg":"d70d","./arrow-left.svg":"dbdb","./arrow-right.svg":"2f6a","./arrow-upward.svg":"4b27","./autorenew.svg":"cad8","./bittorrent.svg":"2a66","./btfs.svg":"83f1","./btt-speed-color.svg":"b1a6","./check-box-outline-blank.svg":"d9b2","./check-box.svg":"daed","./check-circle-outline.svg":"3d20","./chevron-left.svg":"9aa2","./chevron-right.svg":"9203","./close.svg":"20bd","./closed-caption.svg":"7a23","./cloud-download.svg":"4572","./cloud-upload.svg":"5434","./cloud.svg":"4c0f","./copy.svg":"9548","./dashboard.svg":"66f8","./delete.svg":"bd63","./devices.svg":"9ca7","./dlive.svg":"73ed","./dns.svg":"cba7","./download.svg":"0443","./edit.svg":"41af","./error-outline.svg":"baa1","./exchange.svg":"b1df","./exit-to-app.svg":"877e","./expand-less.svg":"1d9d","./expand-more.svg":"b619","./fast-forward.svg":"1f01","./fast-rewind.svg":"d1d6","./folder-open.svg":"3d43","./fullscreen-exit.svg":"c1ac","./fullscreen.svg":"1f39","./golden-coin.svg":"51b4","./help-outline.svg":"22ec","./highlight-off.svg":"69f5","./import-export.svg":"02a8","./import.svg":"ccd3","./indeterminate-check-box.svg":"b2f2","./infinity.svg":"6ddd","./info-outline.svg":"b0a6","./insert-drive-file.svg":"ebd8","./label.svg":"3caa","./learnmore.svg":"c803","./link.svg":"691c","./list.svg":"0d70","./loading.svg":"947f","./mail.svg":"5f8b","./menu.svg":"360e","./message.svg":"5464","./more-horiz.svg":"69a2","./more-vert.svg":"5b9e","./msg-notification.svg":"9a7e","./no-save.svg":"70ab","./no-screenshot.svg":"518d","./no-share.svg":"cda3","./open-in-new.svg":"bc22","./pause.svg":"3693","./peer-active.svg":"a350","./peers.svg":"0ded","./play-arrow-filled.svg":"02bb","./play-arrow.svg":"9172","./playlist-add-check.svg":"075d","./purple-coin.svg":"a16d","./question-answer.svg":"ec38","./radio-button-checked.svg":"476e","./radio-button-unchecked.svg":"3f38","./record-voice-over.svg":"560f","./recovery.svg":"fd5b","./refresh.svg":"9167","./release-notes.svg":"a66d","./rss-feed.svg":"8f34","./search.svg":"0d36","./settings.svg":"0395","./share.svg":"39ce","./skip-next.svg":"dbad","./skip-previous.svg":"3c9f","./speaker-notes.svg":"9635","./speed-active.svg":"68b2","./speed-sync.svg":"5f06","./speed.svg":"3a5b","./stop-screen-share.svg":"3388","./theater-mode-off.svg":"0a9f","./theater.svg":"6164","./toggle-off.svg":"e338","./toggle-on.svg":"8b6a","./torrent-scanner.svg":"e5a8","./trontv.svg":"d245","./unfold-less.svg":"a1da","./unfold-more.svg":"45bd","./upload.svg":"3f01","./videocam.svg":"3627","./visibility-off.svg":"eaa8","./visibility.svg":"9a41","./volume-down.svg":"b4e1","./volume-mute.svg":"1a17","./volume-off.svg":"585a","./volume-up.svg":"e609","./wallet.svg":"e106","./warning-amber.svg":"bd0f","./warning.svg":"56bc","./write.svg":"87dd","./µtorrent.svg":"f91d"};function a(t){var e=i(t);return s(e)}function i(t){if(!s.o(n,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return n[t]}a.keys=function(){return Object.keys(n)},a.resolve=i,t.exports=a,a.id="94b9"},"96e2":function(t,e){t.exports='<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" fill="none" viewBox="0 0 24 24">\n    <path fill="#7D5AE6" d="M12 6.5c2.76 0 5 2.24 5 5 0 .51-.1 1-.24 1.46l3.06 3.06c1.39-1.23 2.49-2.77 3.18-4.53C21.27 7.11 17 4 12 4c-1.27 0-2.49.2-3.64.57l2.17 2.17c.47-.14.96-.24 1.47-.24zM2.71 3.16c-.39.39-.39 1.02 0 1.41l1.97 1.97C3.06 7.83 1.77 9.53 1 11.5 2.73 15.89 7 19 12 19c1.52 0 2.97-.3 4.31-.82l2.72 2.72c.39.39 1.02.39 1.41 0 .39-.39.39-1.02 0-1.41L4.13 3.16c-.39-.39-1.03-.39-1.42 0zM12 16.5c-2.76 0-5-2.24-5-5 0-.77.18-1.5.49-2.14l1.57 1.57c-.03.18-.06.37-.06.57 0 1.66 1.34 3 3 3 .2 0 .38-.03.57-.07L14.14 16c-.65.32-1.37.5-2.14.5zm2.97-5.33c-.15-1.4-1.25-2.49-2.64-2.64l2.64 2.64z"/>\n</svg>\n'},"9b17":function(t,e,s){var n={"./bt-button-icon.vue":"91fa","./bt-button-text.vue":"01db","./bt-button.vue":"dd4b","./bt-checkbox-section.vue":"0786","./bt-checkbox.vue":"9efb","./bt-dropdown.vue":"f723","./bt-header.vue":"4094","./bt-input-box.vue":"5905","./bt-link-card.vue":"5ab3","./bt-settings-header.vue":"6a6f","./bt-settings-section.vue":"9ee4","./bt-sidebar.vue":"36bf","./bt-text-box.vue":"36e8","./bt-toggle.vue":"46cc"};function a(t){var e=i(t);return s(e)}function i(t){if(!s.o(n,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return n[t]}a.keys=function(){return Object.keys(n)},a.resolve=i,t.exports=a,a.id="9b17"},"9bfb":function(t,e,s){t.exports=s.p+"img/spinner.24c1970e.svg"},"9c87":function(t,e,s){},"9fd0":function(t,e,s){"use strict";var n=s("4625"),a=s.n(n);a.a},a1e8:function(t,e,s){},a90f:function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},aa20:function(t,e){t.exports='<svg width="14" height="14" viewBox="0 0 14 14" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M7.00001 0.333008C3.31334 0.333008 0.333344 3.31301 0.333344 6.99967C0.333344 10.6863 3.31334 13.6663 7.00001 13.6663C10.6867 13.6663 13.6667 10.6863 13.6667 6.99967C13.6667 3.31301 10.6867 0.333008 7.00001 0.333008ZM8.72668 4.33301L7.00001 6.05967L5.27334 4.33301L4.33334 5.27301L6.06001 6.99967L4.33334 8.72634L5.27334 9.66634L7.00001 7.93967L8.72668 9.66634L9.66668 8.72634L7.94001 6.99967L9.66668 5.27301L8.72668 4.33301ZM1.66668 6.99967C1.66668 9.93967 4.06001 12.333 7.00001 12.333C9.94001 12.333 12.3333 9.93967 12.3333 6.99967C12.3333 4.05967 9.94001 1.66634 7.00001 1.66634C4.06001 1.66634 1.66668 4.05967 1.66668 6.99967Z" fill="#EB5757"/>\n</svg>\n'},ac0b:function(t,e,s){"use strict";var n=s("674b"),a=s.n(n);a.a},ac39:function(t,e,s){},af67:function(t,e,s){"use strict";var n=s("e8d8"),a=s.n(n);a.a},b97e:function(t,e,s){"use strict";var n=s("a1e8"),a=s.n(n);a.a},bb7c:function(t,e,s){t.exports=s.p+"img/close.bbb24fc2.svg"},bdbb:function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},c191:function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},c563:function(t,e,s){},c833:function(t,e,s){"use strict";var n=s("8ff8"),a=s.n(n);a.a},cd3d:function(t,e,s){},d833:function(t,e){t.exports='<svg width="14" height="14" viewBox="0 0 14 14" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M7.00004 0.333008C3.32004 0.333008 0.333374 3.31967 0.333374 6.99967C0.333374 10.6797 3.32004 13.6663 7.00004 13.6663C10.68 13.6663 13.6667 10.6797 13.6667 6.99967C13.6667 3.31967 10.68 0.333008 7.00004 0.333008ZM6.33337 10.333V6.33301H7.66671V10.333H6.33337ZM6.33337 3.66634V4.99967H7.66671V3.66634H6.33337Z" fill="#F7B500"/>\n</svg>\n'},da2b:function(t,e){t.exports='<svg width="14" height="14" viewBox="0 0 14 14" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path d="M14 1.41L12.59 0L7 5.59L1.41 0L0 1.41L5.59 7L0 12.59L1.41 14L7 8.41L12.59 14L14 12.59L8.41 7L14 1.41Z" fill="#9E9E9E"/>\n</svg>\n'},df8a:function(t,e,s){"use strict";var n=s("277b"),a=s.n(n);a.a},e11e:function(t,e,s){},e8bc:function(t,e,s){"use strict";var n=s("f2ea"),a=s.n(n);a.a},e8d8:function(t,e,s){},eaa6:function(t,e,s){},ec4e:function(t,e,s){t.exports=s.p+"img/error.5134a4d8.svg"},ed08:function(t,e,s){"use strict";(function(t){s("99af"),s("13d5"),s("fb6a"),s("38cf");var n=s("2ef0"),a=s.n(n),i=[2,0,1,6,0,8,1,4,2,0,1,6,0,8,1,4],o=s("1c46");function r(e,s){var n=o.createHash("md5").update(e).digest("hex"),a=o.createCipheriv("aes-128-cbc",new t(n,"hex"),new t(i)),r=a.update(s,"utf8","base64");return r+=a.final("base64"),console.log("encode message: "+r),r}function c(e,s){var n=o.createHash("md5").update(e).digest("hex"),a=o.createDecipheriv("aes-128-cbc",new t(n,"hex"),new t(i)),r=a.update(s,"base64","utf8");return r+=a.final("utf8"),r}function l(t,e){if(console.log("ellipsify",t),t.innerText<t.title&&(t.innerText=t.title),t.offsetWidth<t.scrollWidth){var s="",n=t.innerText.slice(-1*e);while(t.offsetWidth<t.scrollWidth)s=t.innerText.slice(0,-1),t.innerText=s;s=s.slice(0,-1*(e+1)),t.innerText="".concat(s,"...").concat(n)}return t}function u(t){var e=document.createElement("textarea");e.value=t,e.setAttribute("readonly",""),e.style.position="absolute",e.style.left="-9999px",document.body.appendChild(e),e.select(),document.execCommand("copy"),document.body.removeChild(e)}function d(t,e){var s="=".repeat(25);return s+=a.a.reduce(e,(function(t,e,s){return t+="".concat(s,": ").concat(e,"\n"),t}),"\n"),s+="=".repeat(25),{subject:encodeURI(t),body:encodeURI("\n\n".concat(s))}}function p(t,e,s){var n=d(e,s),a=n.subject,i=n.body;return"mailto:".concat(t,"?subject=").concat(a,"&body=").concat(i)}e["a"]={ellipsifyText:l,copyToClipboard:u,createEmailHref:p,encrypt_with_aes:r,decrypt_with_aes:c}}).call(this,s("b639").Buffer)},f2ea:function(t,e,s){},f328:function(t,e){t.exports='<svg width="213" height="40" viewBox="0 0 213 40" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M19.2655 29.1175V20.8773L26.3828 16.7572L30.4689 14.3918V19.7342C30.4689 19.8512 30.5952 19.9243 30.6962 19.8658L31.048 19.6622L33.1211 18.4621C33.3556 18.3264 33.5 18.0755 33.5 17.8041V12.6371V11.7598C33.5 11.2169 33.2111 10.7152 32.7422 10.4438L31.9845 10.0051L27.5261 7.42422C27.2916 7.2885 27.0028 7.2885 26.7683 7.42422L24.3435 8.82796C24.2424 8.88645 24.2424 9.03267 24.3435 9.09116L28.9534 11.7598L24.8672 14.1252L17.75 18.2453L10.6328 14.1252L6.69527 11.8458L6.54663 11.7598L11.1634 9.08716C11.2644 9.0287 11.2645 8.88258 11.1636 8.82403L8.74167 7.41911C8.50713 7.28306 8.21798 7.28293 7.98331 7.41877L3.51554 10.0051L2.75777 10.4438C2.28886 10.7152 2 11.2169 2 11.7598V12.6371V17.7976C2 18.0691 2.14443 18.3199 2.37889 18.4556L4.46321 19.6622L4.80376 19.8594C4.90479 19.9178 5.03109 19.8447 5.03109 19.7277V14.3918L5.17973 14.4778L9.11723 16.7572L16.2345 20.8773V29.1175L16.2345 33.8484L11.6179 31.1759C11.5169 31.1174 11.3906 31.1905 11.3906 31.3075V32.0757V34.115C11.3906 34.3864 11.535 34.6373 11.7695 34.773L16.2345 37.3577L16.9922 37.7964C17.4611 38.0679 18.0389 38.0679 18.5078 37.7964L19.2655 37.3577L23.7305 34.773C23.965 34.6373 24.1094 34.3864 24.1094 34.115V32.0757V31.3075C24.1094 31.1905 23.9831 31.1174 23.8821 31.1759L19.2655 33.8484L19.2655 29.1175ZM27.5194 29.0703C27.2849 29.206 27.1405 29.4569 27.1405 29.7283V32.0757V32.5358C27.1405 32.6528 27.2668 32.7259 27.3678 32.6674L32.7422 29.5562C33.2111 29.2848 33.5 28.7831 33.5 28.2402V22.0153C33.5 21.8983 33.3737 21.8252 33.2727 21.8837L32.5635 22.2942L30.8478 23.2874C30.6133 23.4232 30.4689 23.674 30.4689 23.9454V27.3629L27.5194 29.0703ZM8.35948 32.5358C8.35948 32.6528 8.23318 32.7259 8.13215 32.6674L2.75777 29.5562C2.28886 29.2848 2 28.7831 2 28.2402V22.0088C2 21.8918 2.1263 21.8187 2.22733 21.8772L2.94767 22.2942L4.6522 23.281C4.88666 23.4167 5.03109 23.6675 5.03109 23.939V27.3629L7.98057 29.0703C8.21503 29.206 8.35946 29.4568 8.35946 29.7283L8.35948 32.0757V32.5358ZM11.621 5.31294C11.52 5.37143 11.52 5.51765 11.621 5.57614L13.2195 6.50149L14.0459 6.97988C14.2803 7.1156 14.5692 7.1156 14.8037 6.97988L17.75 5.27426L20.7061 6.98555C20.9406 7.12128 21.2295 7.12128 21.4639 6.98555L22.3001 6.50149L23.8888 5.58181C23.9898 5.52333 23.9898 5.3771 23.8888 5.31861L18.5078 2.20359C18.0389 1.93214 17.4611 1.93214 16.9922 2.20359L11.621 5.31294Z" fill="#3477FF"/>\n<path d="M41.5 26.96H47.7756C49.1089 26.96 50.1578 26.64 50.9044 26.0533C51.74 25.4133 52.2022 24.4889 52.2022 23.2089C52.2022 21.6978 51.3133 20.5244 49.9267 20.0978V20.0444C51.0467 19.6178 51.7044 18.8178 51.7044 17.5556C51.7044 16.5422 51.3311 15.7244 50.6022 15.12C49.9267 14.5689 48.9489 14.2489 47.6333 14.2489H41.5V26.96ZM44.6111 24.4356V21.4133H47.3844C48.3444 21.4133 49.0911 21.9289 49.0911 22.9422C49.0911 23.8489 48.4511 24.4356 47.42 24.4356H44.6111ZM44.6111 19.28V16.7378H47.1889C48.1133 16.7378 48.7 17.2 48.7 18C48.7 18.8356 48.0778 19.28 47.1711 19.28H44.6111Z" fill="#303233"/>\n<path d="M53.2493 26.96H56.1471V17.8578H53.2493V26.96ZM53.2493 16.5956H56.1471V14.2489H53.2493V16.5956Z" fill="#303233"/>\n<path d="M61.1464 27.0667C61.9286 27.0667 62.4797 26.9956 62.7286 26.9244V24.8089C62.6219 24.8089 62.3375 24.8267 62.0886 24.8267C61.4664 24.8267 61.0753 24.6489 61.0753 23.9378V19.6711H62.7286V17.8578H61.0753V14.9778H58.2486V17.8578H57.0397V19.6711H58.2486V24.56C58.2486 26.5156 59.4575 27.0667 61.1464 27.0667Z" fill="#303233"/>\n<path d="M67.0768 26.96H70.2235V16.9156H73.9568V14.2489H63.379V16.9156H67.0768V26.96Z" fill="#303233"/>\n<path d="M77.2597 25.1822C75.9975 25.1822 75.3397 24.08 75.3397 22.4267C75.3397 20.7733 75.9975 19.6533 77.2597 19.6533C78.5219 19.6533 79.1975 20.7733 79.1975 22.4267C79.1975 24.08 78.5219 25.1822 77.2597 25.1822ZM77.2775 27.2444C80.2108 27.2444 82.1308 25.1644 82.1308 22.4267C82.1308 19.6889 80.2108 17.6089 77.2775 17.6089C74.3619 17.6089 72.4064 19.6889 72.4064 22.4267C72.4064 25.1644 74.3619 27.2444 77.2775 27.2444Z" fill="#303233"/>\n<path d="M83.1104 26.96H86.0082V22.8711C86.0082 20.8978 87.146 20.0267 88.9237 20.2756H88.9949V17.7867C88.8704 17.7333 88.6926 17.7156 88.426 17.7156C87.3237 17.7156 86.5771 18.1956 85.9371 19.28H85.8837V17.8578H83.1104V26.96Z" fill="#303233"/>\n<path d="M89.916 26.96H92.8137V22.8711C92.8137 20.8978 93.9515 20.0267 95.7293 20.2756H95.8004V17.7867C95.676 17.7333 95.4982 17.7156 95.2315 17.7156C94.1293 17.7156 93.3826 18.1956 92.7426 19.28H92.6893V17.8578H89.916V26.96Z" fill="#303233"/>\n<path d="M100.992 27.2267C102.148 27.2267 103.072 26.9244 103.837 26.3911C104.637 25.84 105.17 25.0578 105.366 24.24H102.539C102.29 24.8089 101.792 25.1467 101.028 25.1467C99.8368 25.1467 99.1612 24.3822 98.9835 23.1556H105.526C105.543 21.3067 105.01 19.7244 103.926 18.7289C103.143 18.0178 102.112 17.5911 100.815 17.5911C98.0412 17.5911 96.139 19.6711 96.139 22.3911C96.139 25.1467 97.9879 27.2267 100.992 27.2267ZM99.0012 21.4311C99.1968 20.3467 99.7657 19.6533 100.868 19.6533C101.81 19.6533 102.486 20.3467 102.592 21.4311H99.0012Z" fill="#303233"/>\n<path d="M106.496 26.96H109.394V21.9289C109.394 20.8089 110.034 20.0089 110.994 20.0089C111.936 20.0089 112.416 20.6667 112.416 21.6267V26.96H115.314V21.04C115.314 19.0133 114.14 17.5911 112.149 17.5911C110.887 17.5911 110.016 18.1244 109.34 19.1022H109.287V17.8578H106.496V26.96Z" fill="#303233"/>\n<path d="M120.07 27.0667C120.852 27.0667 121.403 26.9956 121.652 26.9244V24.8089C121.546 24.8089 121.261 24.8267 121.012 24.8267C120.39 24.8267 119.999 24.6489 119.999 23.9378V19.6711H121.652V17.8578H119.999V14.9778H117.172V17.8578H115.963V19.6711H117.172V24.56C117.172 26.5156 118.381 27.0667 120.07 27.0667Z" fill="#303233"/>\n<path d="M126.021 26.96H127.621V20.9689H133.47V19.6178H127.621V15.6533H134.644V14.2489H126.021V26.96Z" fill="#303233"/>\n<path d="M136.086 26.96H137.526V17.7689H136.086V26.96ZM136.086 16.0267H137.526V14.2489H136.086V16.0267Z" fill="#303233"/>\n<path d="M139.542 26.96H140.982V14.2489H139.542V26.96Z" fill="#303233"/>\n<path d="M146.924 27.2089C149.004 27.2089 150.444 26.0889 150.871 24.1156H149.467C149.147 25.36 148.258 26 146.924 26C145.076 26 144.062 24.5778 143.956 22.7111H151.067C151.067 19.6711 149.591 17.5378 146.836 17.5378C144.222 17.5378 142.48 19.7067 142.48 22.3733C142.48 25.04 144.116 27.2089 146.924 27.2089ZM146.836 18.6756C148.489 18.6756 149.431 19.7956 149.52 21.6267H143.991C144.204 19.9556 145.129 18.6756 146.836 18.6756Z" fill="#303233"/>\n<path d="M160.14 27.2089C162.878 27.2089 164.816 25.8756 164.816 23.4933C164.816 20.6311 162.558 20.0267 160.087 19.5289C158.185 19.1556 156.762 18.7289 156.762 17.2889C156.762 15.9733 157.9 15.2444 159.625 15.2444C161.491 15.2444 162.665 16.1511 162.896 17.9644H164.407C164.087 15.6178 162.771 14 159.589 14C157.011 14 155.269 15.2622 155.269 17.36C155.269 19.76 157.189 20.4 159.429 20.8978C161.705 21.3956 163.233 21.8044 163.233 23.5467C163.233 25.1289 161.971 25.9289 160.211 25.9289C157.811 25.9289 156.496 24.8089 156.282 22.6578H154.718C154.86 25.2178 156.567 27.2089 160.14 27.2089Z" fill="#303233"/>\n<path d="M167.606 30C168.726 30 169.437 29.6089 170.184 27.7067L174.113 17.7689H172.584L170.486 23.4933C170.202 24.2578 169.882 25.2889 169.882 25.2889H169.846C169.846 25.2889 169.509 24.2578 169.224 23.4933L167.055 17.7689H165.491L169.082 26.6933L168.726 27.6C168.371 28.4889 167.962 28.72 167.357 28.72C166.877 28.72 166.575 28.6311 166.38 28.5244H166.309V29.8044C166.7 29.9644 167.055 30 167.606 30Z" fill="#303233"/>\n<path d="M178.529 27.2267C180.644 27.2267 182.155 26.2844 182.155 24.4356C182.155 22.32 180.609 21.9111 178.76 21.5378C177.177 21.2178 176.289 21.0222 176.289 20.0267C176.289 19.2978 176.822 18.7111 178.137 18.7111C179.506 18.7111 180.164 19.2444 180.306 20.4533H181.782C181.569 18.6578 180.449 17.5733 178.173 17.5733C176.04 17.5733 174.866 18.5689 174.866 20.0978C174.866 22.1067 176.484 22.48 178.297 22.8533C179.986 23.2089 180.697 23.4756 180.697 24.5067C180.697 25.36 180.075 26.0178 178.564 26.0178C177.195 26.0178 176.129 25.5733 175.933 23.9733H174.457C174.6 26.0356 176.004 27.2267 178.529 27.2267Z" fill="#303233"/>\n<path d="M182.773 18.9422H184.107V25.2356C184.107 26.5333 184.96 27.0133 186.133 27.0133C186.56 27.0133 186.951 26.96 187.289 26.8889V25.6444H187.236C187.076 25.6978 186.756 25.7689 186.471 25.7689C185.885 25.7689 185.547 25.5556 185.547 24.9156V18.9422H187.325V17.7689H185.547V14.8711H184.107V17.7689H182.773V18.9422Z" fill="#303233"/>\n<path d="M192.654 27.2089C194.734 27.2089 196.174 26.0889 196.6 24.1156H195.196C194.876 25.36 193.987 26 192.654 26C190.805 26 189.791 24.5778 189.685 22.7111H196.796C196.796 19.6711 195.32 17.5378 192.565 17.5378C189.951 17.5378 188.209 19.7067 188.209 22.3733C188.209 25.04 189.845 27.2089 192.654 27.2089ZM192.565 18.6756C194.218 18.6756 195.16 19.7956 195.249 21.6267H189.72C189.934 19.9556 190.858 18.6756 192.565 18.6756Z" fill="#303233"/>\n<path d="M198.204 26.96H199.644V21.2356C199.644 19.7956 200.888 18.7644 202.008 18.7644C202.951 18.7644 203.591 19.44 203.591 20.5778V26.96H205.031V21.2356C205.031 19.7956 206.097 18.7644 207.288 18.7644C208.213 18.7644 208.977 19.44 208.977 20.5778V26.96H210.417V20.5067C210.417 18.6044 209.262 17.5556 207.626 17.5556C206.506 17.5556 205.404 18.1422 204.817 19.1911H204.782C204.391 18.1244 203.502 17.5556 202.382 17.5556C201.226 17.5556 200.248 18.1956 199.679 19.0844H199.644V17.7689H198.204V26.96Z" fill="#303233"/>\n</svg>\n'},f37a:function(t,e){t.exports='<svg width="24" height="24" viewBox="0 0 24 24" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M20.71 5.62957C21.1 6.01957 21.1 6.64957 20.71 7.03957L18.88 8.86957L15.13 5.11957L16.96 3.28957C17.35 2.89957 17.98 2.89957 18.37 3.28957L20.71 5.62957ZM3 20.9996V17.2496L14.06 6.18957L17.81 9.93957L6.75 20.9996H3Z" fill="#9E9E9E"/>\n</svg>\n'},f819:function(t,e,s){},f849:function(t,e,s){"use strict";var n=s("8ea4"),a=s.n(n);a.a},fac6:function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},fd06:function(t,e,s){"use strict";var n=s("8a65"),a=s.n(n);a.a},ff7d:function(t,e,s){"use strict";var n=s("9c87"),a=s.n(n);a.a},ffe3:function(t,e,s){}});
+(function(t){function e(e){for(var n,o,r=e[0],c=e[1],l=e[2],d=0,p=[];d<r.length;d++)o=r[d],Object.prototype.hasOwnProperty.call(a,o)&&a[o]&&p.push(a[o][0]),a[o]=0;for(n in c)Object.prototype.hasOwnProperty.call(c,n)&&(t[n]=c[n]);u&&u(e);while(p.length)p.shift()();return i.push.apply(i,l||[]),s()}function s(){for(var t,e=0;e<i.length;e++){for(var s=i[e],n=!0,r=1;r<s.length;r++){var c=s[r];0!==a[c]&&(n=!1)}n&&(i.splice(e--,1),t=o(o.s=s[0]))}return t}var n={},a={app:0},i=[];function o(e){if(n[e])return n[e].exports;var s=n[e]={i:e,l:!1,exports:{}};return t[e].call(s.exports,s,s.exports,o),s.l=!0,s.exports}o.m=t,o.c=n,o.d=function(t,e,s){o.o(t,e)||Object.defineProperty(t,e,{enumerable:!0,get:s})},o.r=function(t){"undefined"!==typeof Symbol&&Symbol.toStringTag&&Object.defineProperty(t,Symbol.toStringTag,{value:"Module"}),Object.defineProperty(t,"__esModule",{value:!0})},o.t=function(t,e){if(1&e&&(t=o(t)),8&e)return t;if(4&e&&"object"===typeof t&&t&&t.__esModule)return t;var s=Object.create(null);if(o.r(s),Object.defineProperty(s,"default",{enumerable:!0,value:t}),2&e&&"string"!=typeof t)for(var n in t)o.d(s,n,function(e){return t[e]}.bind(null,n));return s},o.n=function(t){var e=t&&t.__esModule?function(){return t["default"]}:function(){return t};return o.d(e,"a",e),e},o.o=function(t,e){return Object.prototype.hasOwnProperty.call(t,e)},o.p="";var r=window["webpackJsonp"]=window["webpackJsonp"]||[],c=r.push.bind(r);r.push=e,r=r.slice();for(var l=0;l<r.length;l++)e(r[l]);var u=c;i.push([0,"chunk-vendors"]),s()})({0:function(t,e,s){t.exports=s("56d7")},"01f9":function(t,e){t.exports='<svg width="20" height="20" viewBox="0 0 20 20" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M10 0C4.48 0 0 4.48 0 10C0 15.52 4.48 20 10 20C15.52 20 20 15.52 20 10C20 4.48 15.52 0 10 0ZM9 17V15H11V17H9ZM12.17 10.17L13.07 9.25C13.64 8.68 14 7.88 14 7C14 4.79 12.21 3 10 3C7.79 3 6 4.79 6 7H8C8 5.9 8.9 5 10 5C11.1 5 12 5.9 12 7C12 7.55 11.78 8.05 11.41 8.41L10.17 9.67C9.45 10.4 9 11.4 9 12.5V13H11C11 11.5 11.45 10.9 12.17 10.17Z" fill="#3E7EFF"/>\n</svg>\n'},"0273":function(t,e,s){"use strict";var n=s("40c9"),a=s.n(n);a.a},"03c5":function(t,e,s){var n={"./BTFS-logo.svg":"0e0e","./btfs-logo-2.svg":"f328","./check.svg":"5254","./close.svg":"da2b","./copy.svg":"5d63","./down-arrow.svg":"2019","./edit.svg":"f37a","./error.svg":"aa20","./eye-invisible.svg":"6eaf","./eye-visible.svg":"96e2","./help.svg":"01f9","./info.svg":"d833","./refresh.svg":"2dcb","./spinner.svg":"794c"};function a(t){var e=i(t);return s(e)}function i(t){if(!s.o(n,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return n[t]}a.keys=function(){return Object.keys(n)},a.resolve=i,t.exports=a,a.id="03c5"},"06a9":function(t,e,s){"use strict";var n=s("6faf"),a=s.n(n);a.a},"0e0e":function(t,e){t.exports='<svg width="150" height="48" viewBox="0 0 150 48" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M52 4H59.7468C61.4084 4 62.6419 4.41713 63.4958 5.1326C64.4164 5.91934 64.8882 6.98066 64.8882 8.29806C64.8882 9.94019 64.0574 10.9804 62.6419 11.5348V11.6034C64.3933 12.1579 65.5164 13.6838 65.5164 15.6481C65.5164 17.3113 64.9318 18.5152 63.8779 19.3468C62.9342 20.1098 61.611 20.5269 59.9263 20.5269H52V4ZM59.1621 10.5421C60.3083 10.5421 61.093 9.96396 61.093 8.87888C61.093 7.83869 60.3519 7.23675 59.1852 7.23675H55.9285V10.5421H59.1621ZM59.4749 17.2453C60.7776 17.2453 61.5854 16.4823 61.5854 15.3049C61.5854 13.9874 60.6417 13.3169 59.4314 13.3169H55.9285V17.2453H59.4749ZM67.0191 4H70.6784V7.05194H67.0191V4ZM67.0191 8.69143H70.6784V20.5269H67.0191V8.69143ZM73.3965 17.4064V11.049H71.8708V8.69143H73.3965V4.94779H76.9661V8.69143H79.0534V11.049H76.9661V16.5959C76.9661 17.5199 77.461 17.7522 78.2457 17.7522C78.436 17.7522 78.8413 17.7436 79.1626 17.7367L79.1627 17.7367C79.3736 17.7322 79.5483 17.7284 79.6022 17.7284V20.4794C79.2868 20.5718 78.0456 20.6642 77.0584 20.6642C74.9223 20.6642 73.3965 19.9488 73.3965 17.4064ZM82.5229 7.46643H78.3995V4H90.6646V7.46643H86.4976V20.5269H82.5229V7.46643ZM88.208 14.6316C88.208 11.0728 90.6774 8.3667 94.3597 8.3667C98.0652 8.3667 100.488 11.0702 100.488 14.6316C100.488 18.1931 98.0626 20.8966 94.3597 20.8966C90.6774 20.8966 88.208 18.1931 88.208 14.6316ZM96.783 14.6316C96.783 12.4826 95.9291 11.0253 94.3367 11.0253C92.7417 11.0253 91.9108 12.4826 91.9108 14.6316C91.9108 16.7807 92.7417 18.2142 94.3367 18.2142C95.9317 18.2142 96.783 16.7833 96.783 14.6316ZM101.781 8.69143H105.284V10.5395H105.35C106.158 9.12969 107.179 8.54623 108.22 8.50663C108.556 8.49343 108.781 8.53039 108.938 8.59903V11.8358H108.848C106.912 11.5665 105.44 12.6436 105.44 15.2098V20.5269H101.781V8.69143ZM110.033 8.69143H113.536V10.5395H113.602C114.41 9.12969 115.431 8.54623 116.472 8.50663C116.808 8.49343 117.033 8.53039 117.19 8.59903V11.8358H117.1C115.164 11.5665 113.692 12.6436 113.692 15.2098V20.5269H110.033V8.69143ZM117.305 14.5867C117.305 11.049 119.708 8.34558 123.211 8.34558C124.849 8.34558 126.152 8.9 127.139 9.82403C128.509 11.1177 129.183 13.1769 129.16 15.5794H120.898C121.123 17.174 121.975 18.1693 123.48 18.1693C124.444 18.1693 125.075 17.7311 125.388 16.9892H128.957C128.711 18.0532 128.037 19.0696 127.026 19.7851C126.062 20.4794 124.893 20.8728 123.434 20.8728C119.639 20.8728 117.305 18.1693 117.305 14.5867ZM125.454 13.338C125.321 11.9282 124.467 11.0279 123.277 11.0279C121.885 11.0279 121.167 11.9282 120.921 13.338H125.454ZM130.419 8.69143H133.945V10.3098H134.012C134.865 9.03728 135.966 8.34558 137.558 8.34558C140.074 8.34558 141.553 10.1936 141.553 12.8311V20.5269H137.894V13.5914C137.894 12.3427 137.289 11.4873 136.099 11.4873C134.886 11.4873 134.078 12.5275 134.078 13.9848V20.5269H130.419V8.69143ZM143.794 17.4064V11.049H142.269V8.69143H143.794V4.94779H147.364V8.69143H149.451V11.049H147.364V16.5959C147.364 17.5199 147.859 17.7522 148.643 17.7522C148.834 17.7522 149.239 17.7436 149.56 17.7367L149.561 17.7367C149.771 17.7322 149.946 17.7284 150 17.7284V20.4794C149.685 20.5718 148.443 20.6642 147.456 20.6642C145.32 20.6642 143.794 19.9488 143.794 17.4064ZM97.0477 37.7082C97.0477 40.444 94.7976 41.9753 91.6185 41.9753C87.4692 41.9753 85.4874 39.6886 85.3223 36.7486H87.1389C87.3866 39.219 88.9142 40.5052 91.7011 40.5052C93.7448 40.5052 95.2105 39.5865 95.2105 37.7694C95.2105 35.7686 93.4351 35.299 90.7928 34.7273C88.1917 34.1557 85.9622 33.4207 85.9622 30.6644C85.9622 28.2552 87.9853 26.8057 90.9786 26.8057C94.6737 26.8057 96.2014 28.6636 96.5729 31.3586H94.8182C94.5499 29.2761 93.1874 28.2348 91.0199 28.2348C89.0174 28.2348 87.6963 29.0719 87.6963 30.5827C87.6963 32.2365 89.3477 32.7265 91.5566 33.1552C94.426 33.7269 97.0477 34.4211 97.0477 37.7082ZM52 41.6894H53.8579V34.809H60.6496V33.2573H53.8579V28.7044H62.0121V27.0915H52V41.6894ZM63.6868 41.6894H65.3589V31.134H63.6868V41.6894ZM63.6868 29.1332H65.3589V27.0915H63.6868V29.1332ZM69.3721 41.6894H67.7V27.0915H69.3721V41.6894ZM76.2725 41.9753C78.6878 41.9753 80.3599 40.689 80.8554 38.4227H79.2245C78.853 39.8519 77.8208 40.5869 76.2725 40.5869C74.1256 40.5869 72.9489 38.9536 72.8251 36.8098H81.0824C81.0824 33.3186 79.369 30.8686 76.1693 30.8686C73.1347 30.8686 71.1117 33.3594 71.1117 36.4219C71.1117 39.4844 73.0109 41.9753 76.2725 41.9753ZM76.1693 32.1752C78.0891 32.1752 79.1832 33.4615 79.2865 35.5644H72.8664C73.1141 33.6452 74.1875 32.1752 76.1693 32.1752ZM100.288 45.1807C101.589 45.1807 102.415 44.7315 103.282 42.5469L107.844 31.134H106.068L103.633 37.7082C103.302 38.5861 102.931 39.7702 102.931 39.7702H102.889C102.889 39.7702 102.497 38.5861 102.167 37.7082L99.6483 31.134H97.8317L102.002 41.3832L101.589 42.4244C101.176 43.4453 100.701 43.7107 99.9993 43.7107C99.4419 43.7107 99.091 43.6086 98.8639 43.4861H98.7813V44.9561C99.2355 45.1398 99.6483 45.1807 100.288 45.1807ZM117.182 38.7902C117.182 40.9136 115.428 41.9957 112.971 41.9957C110.04 41.9957 108.409 40.6277 108.244 38.2594H109.957C110.184 40.0969 111.423 40.6073 113.012 40.6073C114.767 40.6073 115.49 39.8519 115.49 38.8719C115.49 37.6877 114.664 37.3815 112.703 36.9732C110.597 36.5444 108.719 36.1157 108.719 33.8086C108.719 32.0527 110.081 30.9094 112.558 30.9094C115.201 30.9094 116.501 32.1548 116.749 34.2169H115.035C114.87 32.8286 114.107 32.2161 112.517 32.2161C110.989 32.2161 110.37 32.8898 110.37 33.7269C110.37 34.8702 111.402 35.0948 113.239 35.4623C115.386 35.8911 117.182 36.3607 117.182 38.7902ZM117.9 32.4815H119.448V39.709C119.448 41.1994 120.439 41.7507 121.802 41.7507C122.297 41.7507 122.751 41.6894 123.144 41.6077V40.1786H123.082C122.896 40.2398 122.524 40.3215 122.194 40.3215C121.513 40.3215 121.121 40.0765 121.121 39.3415V32.4815H123.185V31.134H121.121V27.8061H119.448V31.134H117.9V32.4815ZM133.956 38.4227C133.46 40.689 131.788 41.9753 129.373 41.9753C126.111 41.9753 124.212 39.4844 124.212 36.4219C124.212 33.3594 126.235 30.8686 129.27 30.8686C132.47 30.8686 134.183 33.3186 134.183 36.8098H125.926C126.049 38.9536 127.226 40.5869 129.373 40.5869C130.921 40.5869 131.953 39.8519 132.325 38.4227H133.956ZM132.387 35.5644C132.284 33.4615 131.19 32.1752 129.27 32.1752C127.288 32.1752 126.215 33.6452 125.967 35.5644H132.387ZM135.818 41.6894H137.49V35.1152C137.49 33.4615 138.935 32.2773 140.236 32.2773C141.33 32.2773 142.073 33.0532 142.073 34.3598V41.6894H143.745V35.1152C143.745 33.4615 144.984 32.2773 146.367 32.2773C147.44 32.2773 148.328 33.0532 148.328 34.3598V41.6894H150V34.2782C150 32.0936 148.658 30.889 146.759 30.889C145.458 30.889 144.179 31.5627 143.497 32.7673H143.456C143.002 31.5423 141.97 30.889 140.669 30.889C139.327 30.889 138.192 31.624 137.531 32.6448H137.49V31.134H135.818V41.6894Z" fill="#1A1A1A"/>\n<path fill-rule="evenodd" clip-rule="evenodd" d="M23.0207 36.1567V25.1698L32.5104 19.6763L37.9585 16.5224V23.6456C37.9585 23.8016 38.1269 23.8991 38.2617 23.8211L38.7306 23.5496L41.4948 21.9495C41.8074 21.7685 42 21.4341 42 21.0721V14.1828V13.0131C42 12.2892 41.6148 11.6203 40.9896 11.2584L39.9793 10.6735L34.0348 7.23229C33.7222 7.05133 33.337 7.05133 33.0244 7.23229L29.7913 9.10394C29.6566 9.18193 29.6566 9.37689 29.7913 9.45488L35.9378 13.0131L30.4896 16.167L21 21.6604L11.5104 16.167L6.26036 13.1278L6.06218 13.0131L12.2179 9.44955C12.3526 9.3716 12.3527 9.17677 12.2181 9.0987L8.9889 7.22548C8.67617 7.04407 8.29064 7.0439 7.97775 7.22503L2.02073 10.6735L1.01036 11.2584C0.385148 11.6203 0 12.2892 0 13.0131V14.1828V21.0635C0 21.4254 0.192574 21.7599 0.505181 21.9408L3.28428 23.5496L3.73834 23.8125C3.87306 23.8905 4.04145 23.793 4.04145 23.637V16.5224L4.23964 16.6371L9.48964 19.6763L18.9793 25.1698V36.1567L18.9793 42.4645L12.8239 38.9012C12.6892 38.8232 12.5208 38.9207 12.5208 39.0767V40.1009V42.82C12.5208 43.1819 12.7133 43.5164 13.0259 43.6973L18.9793 47.1437L19.9896 47.7285C20.6149 48.0905 21.3851 48.0905 22.0104 47.7285L23.0207 47.1437L28.9741 43.6973C29.2867 43.5164 29.4792 43.1819 29.4792 42.82V40.1009V39.0767C29.4792 38.9207 29.3108 38.8232 29.1761 38.9012L23.0207 42.4645L23.0207 36.1567ZM34.0259 36.0937C33.7133 36.2747 33.5207 36.6091 33.5207 36.9711V40.1009V40.7144C33.5207 40.8704 33.6891 40.9678 33.8238 40.8899L40.9896 36.7416C41.6148 36.3797 42 35.7108 42 34.9869V26.6871C42 26.5311 41.8316 26.4336 41.6969 26.5116L40.7514 27.059L38.4637 28.3833C38.1511 28.5642 37.9585 28.8987 37.9585 29.2606V33.8172L34.0259 36.0937ZM8.4793 40.7144C8.4793 40.8704 8.31091 40.9678 8.1762 40.8898L1.01036 36.7416C0.385148 36.3797 0 35.7108 0 34.9869V26.6784C0 26.5225 0.168394 26.425 0.303109 26.503L1.26356 27.059L3.53627 28.3746C3.84888 28.5556 4.04145 28.89 4.04145 29.2519V33.8172L7.9741 36.0937C8.2867 36.2747 8.47928 36.6091 8.47928 36.9711L8.4793 40.1009V40.7144ZM12.828 4.41725C12.6933 4.49524 12.6933 4.6902 12.828 4.76819L14.9593 6.00198L16.0612 6.63983C16.3738 6.8208 16.7589 6.8208 17.0715 6.63983L21 4.36568L24.9415 6.6474C25.2541 6.82837 25.6393 6.82837 25.9519 6.6474L27.0668 6.00198L29.1851 4.77575C29.3198 4.69777 29.3198 4.5028 29.1851 4.42482L22.0104 0.271449C21.3851 -0.090483 20.6149 -0.0904829 19.9896 0.271449L12.828 4.41725Z" fill="#3E7EFF"/>\n</svg>\n'},"0ff2":function(t,e,s){"use strict";var n=s("c563"),a=s.n(n);a.a},1:function(t,e){},10:function(t,e){},11:function(t,e){},12:function(t,e){},13:function(t,e){},1357:function(t,e,s){"use strict";var n=s("53a7"),a=s.n(n);a.a},14:function(t,e){},15:function(t,e){},1834:function(t,e,s){},1900:function(t,e,s){t.exports=s.p+"img/btfs-logo-2.7259a3f5.svg"},2:function(t,e){},2019:function(t,e){t.exports='<svg width="12" height="8" viewBox="0 0 12 8" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path d="M1.41 0.589996L6 5.17L10.59 0.589996L12 2L6 8L0 2L1.41 0.589996Z" fill="#3E7EFF"/>\n</svg>\n'},"215d":function(t,e,s){},"21d3":function(t,e,s){var n={"./svg-icon.vue":"6ee0"};function a(t){var e=i(t);return s(e)}function i(t){if(!s.o(n,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return n[t]}a.keys=function(){return Object.keys(n)},a.resolve=i,t.exports=a,a.id="21d3"},"277b":function(t,e,s){},"2a5e":function(t,e,s){"use strict";var n=s("215d"),a=s.n(n);a.a},"2b57":function(t,e,s){"use strict";var n=s("3185"),a=s.n(n);a.a},"2dcb":function(t,e){t.exports='<svg width="16" height="16" viewBox="0 0 16 16" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path d="M13.65 2.35C12.2 0.9 10.21 0 8.00001 0C3.58001 0 0.0100098 3.58 0.0100098 8C0.0100098 12.42 3.58001 16 8.00001 16C11.73 16 14.84 13.45 15.73 10H13.65C12.83 12.33 10.61 14 8.00001 14C4.69001 14 2.00001 11.31 2.00001 8C2.00001 4.69 4.69001 2 8.00001 2C9.66001 2 11.14 2.69 12.22 3.78L9.00001 7H16V0L13.65 2.35Z" fill="black" />\n</svg>\n'},3:function(t,e){},3016:function(t,e,s){"use strict";var n=s("651b"),a=s.n(n);a.a},3185:function(t,e,s){},"36e7":function(t,e,s){"use strict";var n=s("3822"),a=s.n(n);a.a},3822:function(t,e,s){},4:function(t,e){},4051:function(t,e,s){"use strict";var n=s("8979"),a=s.n(n);a.a},"40c9":function(t,e,s){},"41b8":function(t,e,s){"use strict";var n=s("1834"),a=s.n(n);a.a},4625:function(t,e,s){},"47fe":function(t,e,s){"use strict";var n=s("cd3d"),a=s.n(n);a.a},"4bb6":function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},"4f3a":function(t,e,s){"use strict";var n=s("bdbb"),a=s.n(n);a.a},5:function(t,e){},5254:function(t,e){t.exports='<svg width="14" height="14" viewBox="0 0 14 14" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M0.333313 7.00065C0.333313 3.32065 3.31998 0.333984 6.99998 0.333984C10.68 0.333984 13.6666 3.32065 13.6666 7.00065C13.6666 10.6807 10.68 13.6673 6.99998 13.6673C3.31998 13.6673 0.333313 10.6807 0.333313 7.00065ZM2.33331 7.00065L5.66665 10.334L11.6666 4.33398L10.7266 3.38732L5.66665 8.44732L3.27331 6.06065L2.33331 7.00065Z" fill="#27AE60"/>\n</svg>\n'},"53a7":function(t,e,s){},"56d7":function(t,e,s){"use strict";s.r(e);s("e260"),s("e6cf"),s("cca6"),s("a79d");var n=s("2b0e"),a=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{attrs:{id:"app"}},[n("Banner",{on:{showBanner:function(e){t.showbanner=e}}}),n("div",{staticClass:"header-container"},[n("bt-header",{scopedSlots:t._u([{key:"left",fn:function(){return[n("div",{staticClass:"logo-container"},[n("img",{attrs:{src:s("1900")}})])]},proxy:!0},{key:"right",fn:function(){return[n("router-link",{staticClass:"link",attrs:{to:"/settings"}},[n("div",{staticClass:"settings-container"},[n("svg-icon",{staticClass:"settings-icon",attrs:{icon:"settings",hasFill:!0}})],1)])]},proxy:!0}])})],1),n("div",{staticClass:"main",class:{"banner-present":t.showBanner}},[n("div",{staticClass:"nav"},[n("bt-sidebar",{attrs:{items:t.sidebarItems}})],1),t.isLoading?t._e():n("router-view",t._b({staticClass:"view"},"router-view",t.routeProps,!1))],1)],1)},i=[],o=(s("4de4"),s("caad"),s("13d5"),s("fb6a"),s("b0c0"),s("d3b7"),s("2532"),s("3ca3"),s("2ca0"),s("ddb0"),s("5530")),r=function(){var t=this,e=t.$createElement,s=t._self._c||e;return t.bannerData.length>0&&!0===t.showBanner?s("div",{staticClass:"banner"},[s("div",{staticClass:"arrow-container",class:{hidden:1===t.bannerData.length}},[s("div",{staticClass:"previous",on:{click:t.previous}},[s("svg-icon",{staticClass:"arrow-icon back",attrs:{icon:"arrow-back",hasFill:!0}}),t._v(" Previous ")],1),s("div",{staticClass:"next",on:{click:t.next}},[t._v(" Next "),s("svg-icon",{staticClass:"arrow-icon forward",attrs:{icon:"arrow-forward",hasFill:!0}})],1)]),s("div",{staticClass:"content",domProps:{innerHTML:t._s(t.bannerData[t.index].childNodes[1].nodeValue)}}),s("svg-icon",{staticClass:"close-icon",attrs:{icon:"close",hasFill:!0},on:{click:t.toggleBanner}})],1):t._e()},c=[],l={name:"Banner",props:{},data:function(){return{bannerData:[],index:0,showBanner:!1}},methods:{next:function(){this.index=(this.index+1)%this.bannerData.length},previous:function(){this.index=this.index-1<0?this.bannerData.length-1:this.index-1},toggleBanner:function(){this.showBanner=!this.showBanner,this.$emit("showBanner",this.showBanner)}},created:function(){var t=this,e="/hostui/banner.xml";this.axios.get(e).then((function(e){var s=new DOMParser,n=s.parseFromString(e.data,"text/xml"),a=n.getElementsByTagName("content");t.bannerData=a,t.toggleBanner()})).catch((function(){}))}},u=l,d=(s("8c04"),s("2877")),p=Object(d["a"])(u,r,c,!1,null,"6caa744b",null),h=p.exports,f={name:"App",components:{Banner:h},data:function(){return{isLoading:!0,showBanner:!1}},methods:{initSetup:function(){var t=this.$store.dispatch("getHostConfig"),e=this.$store.dispatch("getStorageHostEnabled"),s=this.$store.dispatch("getWalletConfig"),n=this.initMetaStores();return Promise.all([t,e,s,n])},initSync:function(){var t=this,e=this.$store.dispatch("storageStatsSync"),s=this.$store.dispatch("storageContractsSync");return Promise.all([e,s]).then((function(e){var s=e.reduce((function(t,e){return t&&e}));t.$store.dispatch("updateIsSynced",s)}))},initStorageStores:function(){var t=this.$store.dispatch("storageInfo"),e=this.$store.dispatch("storageStatsInfo"),s=this.$store.dispatch("storageContractsInfo"),n=this.$store.dispatch("storageContractsList");return Promise.all([t,e,s,n])},initMetaStores:function(){var t=this.$store.dispatch("metaRepoStat"),e=this.$store.dispatch("metaId"),s=this.$store.dispatch("metaVersion");return Promise.all([t,e,s])},initWalletStores:function(){var t=this.$store.dispatch("walletBalance"),e=this.$store.dispatch("walletTransactions");return Promise.all([t,e])},createStorageSpaceObj:function(){var t={used:0,cap:32,total:1e3,minimum:32};return this.isLoading||(t.used=this.storageSpaceUsed,t.cap=this.storageSpaceCap,t.total=this.storageSpaceTotal),t},isValidRoute:function(t){var e=this.$router.options.routes.reduce((function(e,s){return s.name===t||e}),!1);return e},isSetupRoute:function(t){return t.includes("Add")||t.includes("Setup")||t.includes("Import")},routeType:function(t){return t.startsWith("Host")?"host":t.startsWith("Wallet")?"wallet":t.startsWith("Settings")?"settings":null}},created:function(){var t=this;this.initSetup().then((function(){t.isValidRoute(t.route)||t.$router.push("/host");var e=t.isSetupRoute(t.route),s=t.routeType(t.route);switch(s){case"host":t.isHostSetup||e?t.isHostSetup&&e&&t.$router.push("/host"):t.$router.push("/host/add");break;case"wallet":t.isWalletSetup||e?t.isWalletSetup&&e&&t.$router.push("/wallet"):t.$router.push("/wallet/add");break}if(t.$router.beforeEach((function(e,s,n){if("WalletDashboard"!==s.name&&("WalletImport"!==s.name||"WalletImport"!==e.name&&"WalletSetup"!==e.name)){if(t.isValidRoute(e.name)){var a=t.isSetupRoute(e.name),i=t.routeType(e.name);switch(i){case"host":t.isHostSetup||a?t.isHostSetup&&a?n({name:"HostDashboard"}):n():n("/host/add");break;case"wallet":t.isWalletSetup||a?t.isWalletSetup&&a?n({name:"WalletDashboard"}):n():n("/wallet/add");break;default:n()}}}else n()})),!t.isHostSetup)return t.initSync(),t.initStorageStores(),t.isLoading=!1,Promise.reject("host not setup");t.initSync(),t.isLoading=!1})).catch((function(){}))},computed:{route:function(){return this.$route.name},isHostSetup:function(){return this.$store.state.config.host.initialized&&this.hostEnabled},isSynced:function(){return this.$store.state.storage.isSynced},isSyncedLocal:function(){return this.$store.state.storage.isSyncedLocal},isStorageLoading:function(){return this.$store.state.storage.isStorageLoading},isWalletSetup:function(){return this.$store.state.config.wallet.initialized},storageSpaceUsed:function(){var t=this.$store.state.storage.value,e=t.host_stats.storage_used/Math.pow(1e3,3);return e=e>=1?Math.floor(e):Math.floor(1e3*e)/1e3,e=parseFloat(e),e},storageSpaceCap:function(){var t=this.$store.state.storage.value;return v(t.host_stats.storage_cap)},storageSpaceTotal:function(){var t=this.$store.state.storage.value,e=null===this.proposedStorageAvailable?t.host_stats.storage_disk_available:this.proposedStorageAvailable,s=t.host_stats.storage_used,n=e+s;return v(n)},proposedStorageAvailable:function(){return this.$store.state.storage.proposedStorageAvailable},hostDirectoryPath:function(){return this.$store.state.meta.value.RepoPath},hostEnabled:function(){return this.$store.state.config.hostEnabled.value},hostOnline:function(){return this.$store.state.storage.value.host_stats.online},hostScore:function(){return this.$store.state.storage.value.host_stats.score},hostUptime:function(){return this.$store.state.storage.value.host_stats.uptime},hostContractsNum:function(){return this.$store.state.storage.contracts.active_contract_num},earnings:function(){return this.$store.state.storage.contracts.compensation_paid},potentialEarnings:function(){return this.$store.state.storage.contracts.compensation_outstanding},hostPricing:function(){return this.$store.state.storage.info.storage_price_ask},hostSuggestedPricing:function(){return 7680},hostSetupValues:function(){return this.$store.state.storage.setup},hostIsImporting:function(){return this.$store.state.storage.isImporting},nextPayment:function(){var t=this.$store.state.storage.contractsList.contracts,e=t.filter((function(t){return"0001-01-01T00:00:00Z"!==t.next_escrow_time&&"9999-12-31T23:59:59Z"!==t.next_escrow_time}));if(e.length>0){var s=e[0];return s=s.next_escrow_time,s=new Date(s),s=s.toDateString(),s=s.slice(3),s=s.slice(0,7)+","+s.slice(7),s}return null},nodeVersion:function(){return this.$store.state.meta.version.Version},notificationState:function(){return this.$store.state.notification},walletInAppBalance:function(){return this.$store.state.wallet.balance.BtfsWalletBalance},walletCryptoBalance:function(){return this.$store.state.wallet.balance.BttWalletBalance},walletRecoveryPhrase:function(){return this.$store.state.wallet.keys.Mnemonic},walletTransactions:function(){return this.$store.state.wallet.transactions},nodeID:function(){return this.$store.state.meta.id.ID},tronAddress:function(){return this.$store.state.meta.id.TronAddress},routeProps:function(){var t=this;if("HostAdd"===this.route){var e=this.storageSpaceTotal>32;return{hasEnoughSpace:e,hostEnabled:this.hostEnabled,hostDirectoryPath:this.hostDirectoryPath,updateHostSetupValues:function(e){return t.$store.dispatch("updateHostSetupValues",Object(o["a"])({},e))},checkOtherFolder:function(e){return t.$store.dispatch("storagePathCapacity",Object(o["a"])({},e))}}}if("HostDashboard"===this.route){var s=this.createStorageSpaceObj();return{online:this.hostOnline,score:this.hostScore,uptime:this.hostUptime,contractsNum:this.hostContractsNum,earnings:this.earnings,potentialEarnings:this.potentialEarnings,hostPricing:this.hostPricing,nextPayment:this.nextPayment,storageSpace:s,isSynced:this.isSynced,isSyncedLocal:this.isSyncedLocal,isStorageLoading:this.isStorageLoading,hostDirectoryPath:this.hostDirectoryPath,chooseOtherFolder:function(e){return t.$store.dispatch("storagePath",Object(o["a"])({},e))},checkOtherFolder:function(e){return t.$store.dispatch("storagePathCapacity",Object(o["a"])({},e))},notificationState:this.notificationState.host,notificationClose:function(){return t.$store.dispatch("notificationClose","host")},isImporting:this.hostIsImporting,handleRefresh:function(){t.initStorageStores()}}}if("HostSetup"===this.route){var n=this.createStorageSpaceObj();return{storageSpace:n,storageAnnounce:function(e){t.$store.dispatch("storageAnnounce",{hostStorageMax:e})},setInitializationFlag:function(){return t.initSync().then((function(){return t.initStorageStores()})),t.$store.dispatch("setHostConfig")},updateHostSetupValues:function(e){return t.$store.dispatch("updateHostSetupValues",Object(o["a"])({},e))},chooseOtherFolder:function(e){return t.$store.dispatch("storagePath",Object(o["a"])({},e))},hostSetupValues:this.hostSetupValues}}return"Settings"===this.route?{version:this.nodeVersion,id:this.nodeID,notificationState:this.notificationState.settings,notificationClose:function(){return t.$store.dispatch("notificationClose","settings")},hostPricing:this.hostPricing,hostSuggestedPricing:this.hostSuggestedPricing,storageAnnouncePrice:function(e){return t.$store.dispatch("storageAnnouncePrice",e)}}:"WalletDashboard"===this.route?{id:this.nodeID,tronAddress:this.tronAddress,transactions:this.walletTransactions,handleDeposit:function(e){return t.$store.dispatch("walletDeposit",e)},handleWithdraw:function(e){return t.$store.dispatch("walletWithdraw",e)},inAppBalance:this.walletInAppBalance,cryptoBalance:this.walletCryptoBalance,handleRefresh:function(){t.initWalletStores()},checkPassword:function(e){return t.$store.dispatch("walletCheckPassword",e)}}:"WalletSetup"===this.route?{setInitializationFlag:function(){return t.$store.dispatch("setWalletConfig")},setPassword:function(e){return t.$store.dispatch("walletPassword",e)},getKeys:function(e){return t.$store.dispatch("walletKeys",e)},recoveryPhrases:this.walletRecoveryPhrase}:"WalletImport"===this.route?{setPassword:function(e){return t.$store.dispatch("walletPassword",e)},importWallet:function(e){return t.$store.dispatch("walletImport",e)},setInitializationFlag:function(){return t.$store.dispatch("setWalletConfig")}}:{}},sidebarItems:function(){return{top:[{icon:"dns",path:"/host",name:"Host"},{icon:"wallet",target:"",path:"/wallet",name:"Wallet"}],bottom:[{icon:"mail",target:"_blank",href:"https://docs.google.com/forms/d/e/1FAIpQLSeH1Vhm4C6tcsI80gLzUQ58cuYtMHj3LEQFx_STAqWNoCP3Ew/viewform?usp=pp_url&entry.875205708=".concat(this.nodeID),name:"Contact us"}]}}}};function v(t){var e=t/Math.pow(1e3,3);return e=Math.floor(e),e}var g=f,m=(s("5c0b"),Object(d["a"])(g,a,i,!1,null,null,null)),C=m.exports,b=s("8c4f"),y=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"host-add"},[t.hostEnabled?n("div",[0===t.state?n("div",{staticClass:"host-add-container"},[t._m(0),n("div",{staticClass:"button-container"},[n("Button",{attrs:{cb:t.nextState}},[t._v("Host setup")])],1)]):t._e(),1===t.state?n("div",{staticClass:"host-add-container"},[n("div",{staticClass:"header-container"},[n("p",{staticClass:"header"},[t._v("Folder destination")]),t._m(1),n("div",{staticClass:"edit-host-folder-container"},[n("EditHostFolder",{attrs:{hostDirectoryPath:t.hostDirectoryPath,storageLocationContinueMessage:"Files will be moved to this folder after you click on ‘Confirm'",checkOtherFolder:t.checkOtherFolder},on:{inputChange:t.onEditFolder,isEditingFolder:function(e){t.isEditingFolder=e}}})],1)]),n("div",{staticClass:"button-container"},[n("Button",{attrs:{isDisabled:t.isEditingFolder,cb:t.handleNext}},[t._v("Confirm")])],1)]):t._e()]):t._e(),t.showPopup?n("div",{staticClass:"popup-bg"},[n("div",{staticClass:"popup-container"},[n("div",{staticClass:"close-container",on:{click:t.handleClose}},[n("img",{attrs:{src:s("bb7c")}})]),n("p",{staticClass:"header"},[t._v(" Not enough storage space ")]),n("p",{staticClass:"description"},[t._v(" 32 GB is the minimum available space needed to continue setting up your host. Please use another computer with at least 32 GB of available space. ")])])]):t._e(),t.hostEnabled?t._e():n("div",{staticClass:"host-not-enabled host-add-container"},[n("div",{staticClass:"container"},[n("p",{staticClass:"header"},[t._v(" Host profile not enabled ")]),n("p",{staticClass:"description"},[n("ul",[n("li",[t._v("1. In order to enable your host profile, use command:")]),n("li",{staticClass:"code-block"},[t._v(" btfs config profile apply storage-host "),n("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:"copied!",classes:["tooltip"],show:t.showCopyTooltip,trigger:"manual"},expression:"{ \n                            content: 'copied!', \n                            classes: ['tooltip'], \n                            show: showCopyTooltip,\n                            trigger: 'manual'\n                          }",modifiers:{bottom:!0}}],staticClass:"icon",attrs:{icon:"copy",hasFill:!0},on:{click:t.handleCopy}})],1),n("li",[t._v("2. Restart your daemon")]),n("li",[t._v("3. Refresh this page")])])])])])])},w=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Host setup")]),s("p",{staticClass:"description"},[t._v(" Commit your spare hard drive space to the BTFS network to earn BTT. "),s("br"),s("br"),t._v(" After clicking ‘Host setup’, select a folder where you would like files to be hosted. ")]),s("p",{staticClass:"sub-description"},[t._v(" Looking for more information? Read our "),s("a",{attrs:{href:"https://www.bittorrent.com/token/bittorrent-file-system/faq/",target:"_blank",rel:"noreferrer noopener"}},[t._v("FAQs")])])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("p",{staticClass:"description"},[t._v(" A default folder destination has been selected for you. "),s("br"),s("br"),t._v(" If you would like files hosted somewhere else, please enter a different folder destination with at least 32 GB of available storage space. ")])}],S=s("fac6"),_=s.n(S),x=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("button",{staticClass:"button",class:[t.type,{hasCopied:t.hasCopied},t.size],attrs:{disabled:t.isDisabled},on:{click:this.cb}},[s("span",{on:{click:t.handleClick}},[t.hasCopied?t._e():t._t("default"),t.hasCopied?s("span",[t._v(" Copied! ")]):t._e(),"text"===t.type&&void 0!==t.isOpen?s("svg-icon",{staticClass:"icon",class:{"up-arrow":t.isOpen},attrs:{icon:"down-arrow",hasFill:!0}}):t._e(),"copy"===t.type?s("svg-icon",{staticClass:"icon",attrs:{icon:"copy",hasFill:!0}}):t._e()],2)])},P=[],B=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",t._g({staticClass:"svg-container",domProps:{innerHTML:t._s(s("03c5")("./"+t.icon+".svg"))}},t.$listeners))},T=[];s("4160"),s("d81d"),s("a9e3"),s("b680"),s("ac1f"),s("1276");function V(t){t&&(t.removeAttribute("fill"),[].forEach.call(t.children,(function(t){V(t)})))}var H={name:"svg-icon",props:{icon:{type:String,default:null},hasFill:{type:Boolean,default:!1},growByHeight:{type:Boolean,default:!0}},mounted:function(){if("svg"===this.$el.firstElementChild.nodeName){var t=this.$el.firstElementChild,e=t.getAttribute("viewBox").split(" ").map((function(t){return Number(t)})),s=(e[2]/e[3]).toFixed(2);this.hasFill&&V(t),this.growByHeight?(t.setAttribute("height","1em"),t.setAttribute("width","".concat(s,"em"))):(t.setAttribute("width","1em"),t.setAttribute("height","".concat(1/s,"em"))),t.classList.add("svg")}}},k=H,E=(s("0ff2"),Object(d["a"])(k,B,T,!1,null,"7777e5e1",null)),L=E.exports,I={name:"Button",components:{"svg-icon":L},props:{isDisabled:{type:Boolean,default:!1},cb:{type:Function,default:function(){}},type:{type:String,default:"primary"},copyValue:{type:String,default:null},isOpen:{type:Boolean,required:!1,default:void 0},size:{type:String,default:"big"}},data:function(){return{hasCopied:!1}},methods:{handleClick:function(t){this.isDisabled&&t.preventDefault(),"copy"===this.type&&this.copyValue&&(navigator.clipboard.writeText(this.copyValue),this.hasCopied=!0)}}},O=I,F=(s("36e7"),Object(d["a"])(O,x,P,!1,null,"123553db",null)),D=F.exports,A=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"edit-host-folder"},[s("div",{staticClass:"current"},[s("div",{},[s("p",{staticClass:"subtitle"},[t._v(" Current host folder destination ")]),s("b",[t._v(" "+t._s(t.currentHostDirectoryPath)+" ")])]),s("div",[s("Button",{attrs:{type:"outline",isDisabled:t.isOpen,cb:t.toggleOpen}},[s("div",{staticClass:"edit-button"},[t._v(" Edit "),s("svg-icon",{staticClass:"icon",attrs:{icon:"edit",hasFill:!0}})],1)])],1)]),t.isOpen?s("div",{staticClass:"edit"},[s("p",{staticClass:"subtitle"},[t._v(" Edit host folder destination (case-sensitive) ")]),s("div",{staticClass:"input-container"},[s("InputBox",{attrs:{inputType:"text",borderColor:t.colors.btfsBlue500,placeholderColor:t.colors.gray500,invalidColor:t.colors.btfsRed,isInvalid:t.isStorageLocationInvalid,placeholder:t.hostDirectoryPath,message:t.storageLocationMessage},model:{value:t.newHostDirectoryPath,callback:function(e){t.newHostDirectoryPath=e},expression:"newHostDirectoryPath"}})],1),s("div",{staticClass:"button-container"},[s("Button",{staticClass:"cancel",attrs:{type:"outline",size:"small",cb:t.toggleOpen}},[t._v(" Cancel ")]),s("Button",{attrs:{type:"primary",size:"small",cb:t.handleUpdate,isDisabled:!t.isStorageLocationDifferent}},[t._v(" Update ")])],1)]):t._e()])},M=[],$=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{ref:"inputboxContainer",staticClass:"input-box-container",class:[t.inputType,{invalid:t.isInvalid},{inactive:t.isInactive}],style:t.inputStyle},[s("div",{staticClass:"input-box"},["textarea"===t.inputType?s("textarea",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{placeholder:t.placeholder,disabled:t.disabled},domProps:{value:t.inputVal},on:{change:t.handleChange,keydown:function(t){t.stopPropagation()},input:function(e){e.target.composing||(t.inputVal=e.target.value)}}}):"password"===t.inputType?s("input",{directives:[{name:"model",rawName:"v-model.trim",value:t.inputVal,expression:"inputVal",modifiers:{trim:!0}}],class:t.inputType,attrs:{type:t.showPassword?"text":"password",placeholder:t.placeholder,disabled:t.disabled},domProps:{value:t.inputVal},on:{change:t.handleChange,input:function(e){e.target.composing||(t.inputVal=e.target.value.trim())},blur:function(e){return t.$forceUpdate()}}}):"checkbox"===t.inputType?s("input",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{placeholder:t.placeholder,step:t.step,disabled:t.disabled,min:"0",type:"checkbox"},domProps:{checked:Array.isArray(t.inputVal)?t._i(t.inputVal,null)>-1:t.inputVal},on:{change:[function(e){var s=t.inputVal,n=e.target,a=!!n.checked;if(Array.isArray(s)){var i=null,o=t._i(s,i);n.checked?o<0&&(t.inputVal=s.concat([i])):o>-1&&(t.inputVal=s.slice(0,o).concat(s.slice(o+1)))}else t.inputVal=a},t.handleChange],keydown:function(t){t.stopPropagation()},keypress:function(e){return t.noNegativeKeystroke(e)}}}):"radio"===t.inputType?s("input",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{placeholder:t.placeholder,step:t.step,disabled:t.disabled,min:"0",type:"radio"},domProps:{checked:t._q(t.inputVal,null)},on:{change:[function(e){t.inputVal=null},t.handleChange],keydown:function(t){t.stopPropagation()},keypress:function(e){return t.noNegativeKeystroke(e)}}}):s("input",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{placeholder:t.placeholder,step:t.step,disabled:t.disabled,min:"0",type:t.inputType},domProps:{value:t.inputVal},on:{change:t.handleChange,keydown:function(t){t.stopPropagation()},keypress:function(e){return t.noNegativeKeystroke(e)},input:function(e){e.target.composing||(t.inputVal=e.target.value)}}}),t.isPassword&&!t.disabled?s("div",{staticClass:"eye-wink-icon",on:{click:t.handleShowPasswordChange}},[t.showPassword?s("svg-icon",{key:"eye-invisible",attrs:{icon:"eye-invisible",hasFill:null!==t.iconColor}}):s("svg-icon",{key:"eye-visible",attrs:{icon:"eye-visible",hasFill:null!==t.iconColor}})],1):t._e(),t.unit?s("div",{staticClass:"unit"},[t._v(t._s(t.unit))]):t._e()]),t.showMessage?s("div",{staticClass:"message"},[t._v(t._s(t.message))]):t._e()])},N=[],z={name:"bt-input-box",props:{value:[String,Number],inputType:{type:String,default:"text"},handleChange:{type:Function,default:function(){}},placeholder:{type:String,default:""},disabled:{type:Boolean,default:!1},step:{type:String,default:"0"},message:{type:String,default:""},isInvalid:{type:Boolean,default:!1},unit:{type:String,default:""},width:{type:Number,default:null},showMessage:{type:Boolean,default:!0},borderColor:{type:String,default:_.a.primary500Main},passwordPlaceholderColor:{type:String,default:_.a.primary200},placeholderColor:{type:String,default:_.a.gray400},disabledColor:{type:String,default:_.a.gray400},invalidColor:{type:String,default:_.a.accentsRed500},textareaColor:{type:String,default:_.a.gray800main},messageColor:{type:String,default:_.a.gray500},iconColor:{type:String,default:null},delayEmit:{type:Boolean,default:!1}},components:{"svg-icon":L},data:function(){return{inputVal:this.value?"number"===this.inputType?Number(this.value):this.value:null,showPassword:!1,intervalHandler:null}},computed:{isPassword:function(){return"password"===this.inputType},invalid:function(){return!1},inputStyle:function(){return{"--borderColor":this.borderColor,"--passwordPlaceholderColor":this.passwordPlaceholderColor,"--placeholderColor":this.placeholderColor,"--disabledColor":this.disabledColor,"--invalidColor":this.invalidColor,"--textareaColor":this.textareaColor,"--messageColor":this.messageColor,"--iconColor":this.iconColor}},isInactive:function(){return null===this.inputVal||""===this.inputVal}},methods:{handleShowPasswordChange:function(){this.showPassword=!this.showPassword},startInterval:function(t){var e=this;this.intervalHandler=setInterval((function(){e.$emit("input",t),clearInterval(e.intervalHandler)}),500)},noNegativeKeystroke:function(t){if("number"===this.inputType){var e=t.which?t.which:t.keyCode;if(!(e>31&&(e<48||e>57)&&46!==e))return!0;t.preventDefault()}}},watch:{inputVal:function(t){"number"===this.inputType&&t<0&&(t*=-1),this.delayEmit?(null===this.intervalHandler||clearInterval(this.intervalHandler),this.startInterval(t)):this.$emit("input",t)},value:function(t){this.inputVal=t}},mounted:function(){this.width&&(this.$refs.inputboxContainer.style.width=this.width+"px")}},j=z,R=(s("2a5e"),Object(d["a"])(j,$,N,!1,null,"43c35831",null)),W=R.exports,Z={name:"EditHostFolder",components:{Button:D,InputBox:W},props:{hostDirectoryPath:{type:String,default:null},storageLocationContinueMessage:{type:String,default:"Files will be moved to this folder after you click on ‘Save changes’"},checkOtherFolder:{type:Function,default:function(){}}},data:function(){return{newHostDirectoryPath:this.hostDirectoryPath,colors:_.a,isOpen:!1,storageLocationErrorMessage:"",isStorageLocationInvalid:!1,currentHostDirectoryPath:this.hostDirectoryPath}},methods:{toggleOpen:function(){this.newHostDirectoryPath=this.currentHostDirectoryPath,this.isOpen=!this.isOpen,this.$emit("isEditingFolder",this.isOpen)},handleUpdate:function(){var t=this;this.checkOtherFolder({path:this.newHostDirectoryPath}).then((function(){t.$emit("inputChange",t.newHostDirectoryPath),t.currentHostDirectoryPath=t.newHostDirectoryPath,t.toggleOpen()})).catch((function(e){console.log(e),t.isStorageLocationInvalid=!0,t.storageLocationErrorMessage=e}))}},computed:{storageLocationMessage:function(){return this.isStorageLocationInvalid?this.storageLocationErrorMessage:this.isStorageLocationDifferent&&""!==this.newHostDirectoryPath?this.storageLocationContinueMessage:"Please manually input your new Host file path"},isStorageLocationDifferent:function(){return this.hostDirectoryPath!==this.newHostDirectoryPath}},watch:{newHostDirectoryPath:function(){this.isStorageLocationInvalid=!1}}},q=Z,U=(s("af67"),Object(d["a"])(q,A,M,!1,null,"21af08ff",null)),G=U.exports,K={name:"HostAdd",props:{hasEnoughSpace:{type:Boolean,required:!0,default:!0},hostEnabled:{type:Boolean},hostDirectoryPath:{type:String,default:null},updateHostSetupValues:{type:Function,default:function(){}},checkOtherFolder:{type:Function,default:function(){}}},components:{Button:D,"svg-icon":L,EditHostFolder:G},data:function(){return{btfsBlue500:_.a.btfsBlue500,showPopup:!1,showCopyTooltip:!1,state:0,newHostDirectoryPath:null,isEditingFolder:!1}},methods:{handleClose:function(){this.showPopup=!this.showPopup},handleNext:function(t){this.hasEnoughSpace&&null!==this.hostDirectoryPath||(t.preventDefault(),this.showPopup=!0),null!==this.newHostDirectoryPath&&this.isStorageLocationDifferent&&this.updateHostSetupValues({path:this.newHostDirectoryPath}),this.$router.push("/host/setup")},handleCopy:function(){var t=this;navigator.clipboard.writeText("btfs config profile apply storage-host"),this.showCopyTooltip=!0,setTimeout((function(){t.showCopyTooltip=!1}),1e3)},nextState:function(){this.state=1},backState:function(){this.state=0},onEditFolder:function(t){this.newHostDirectoryPath=t}},computed:{isStorageLocationDifferent:function(){return this.hostDirectoryPath!==this.newHostDirectoryPath}},created:function(){this.$store.dispatch("updateProposedStorageAvailable",null)}},Y=K,J=(s("7a16"),Object(d["a"])(Y,y,w,!1,null,"3169077a",null)),Q=J.exports,X=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"host-setup"},[t._e(),2===t.current?s("div",{staticClass:"host-setup-2"},[s("p",{staticClass:"header"},[t._v("Host storage size")]),s("p",{staticClass:"description"},[t._v(" 32 GB is the minimum host storage commitment size. Try moving the slider below to see how much more you can earn by increasing your commitment size. ")]),s("div",{staticClass:"storage-size-container"},[s("SetStorageSize",{attrs:{storageSpace:t.storageSpace,isDisabled:!1},on:{slideChange:function(e){t.selectedStorageValue=e}}})],1),s("div",{staticClass:"button-container"},[s("Button",{attrs:{isDisabled:t.isDisabled,cb:t.handleCommitClick}},[t._v("Commit Storage")])],1)]):t._e(),3===t.current?s("div",{staticClass:"host-setup-3"},[s("p",{staticClass:"header"},[t._v("How to be a good host")]),t._m(0),s("div",{staticClass:"button-container"},[s("Button",{attrs:{cb:t.handleSetupComplete}},[t._v("Agree & Host")])],1)]):t._e()])},tt=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"description"},[s("ul",{staticClass:"list"},[s("li",[t._v(" Leave your computer and BTFS running at all times. ")]),s("li",[t._v(" Make sure your computer is plugged in a power source and connected to the internet. ")]),s("li",[t._v(" Do not manipulate the host folder on your computer. ")]),s("li",[t._v(" Keep calm and earn BTT! ")])])])}],et=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"checkbox-container"},[t.hasLeftSlot?s("div",{staticClass:"left-section",class:{"gray-text":t.isDisabled}},[t._t("left")],2):t._e(),s("label",{staticClass:"checkbox-control"},[s("input",{directives:[{name:"model",rawName:"v-model",value:t.inputVal,expression:"inputVal"}],attrs:{id:t.id,type:"checkbox",disabled:t.isDisabled},domProps:{checked:Array.isArray(t.inputVal)?t._i(t.inputVal,null)>-1:t.inputVal},on:{click:function(e){return e.stopPropagation(),t.click(e)},change:[function(e){var s=t.inputVal,n=e.target,a=!!n.checked;if(Array.isArray(s)){var i=null,o=t._i(s,i);n.checked?o<0&&(t.inputVal=s.concat([i])):o>-1&&(t.inputVal=s.slice(0,o).concat(s.slice(o+1)))}else t.inputVal=a},function(e){return e.stopPropagation(),t.change(t.inputVal,e)}]}}),s("div",{staticClass:"checkbox-indicator",class:{isEnabled:!t.isDisabled}})]),t.hasRightSlot?s("div",{staticClass:"right-section",class:{"gray-text":t.isDisabled}},[t._t("right")],2):t._e()])},st=[],nt={name:"Checkbox",props:{value:{type:Boolean,default:!1},handleChange:{type:Function,default:function(){}},id:{type:String,default:null},isDisabled:{type:Boolean,default:!1}},data:function(){return{inputVal:this.value}},methods:{change:function(t,e){this.$emit("change",t,e)},click:function(t){this.$emit("click",t)}},computed:{hasRightSlot:function(){return!!this.$slots["right"]},hasLeftSlot:function(){return!!this.$slots["left"]}},watch:{value:function(t){this.inputVal=t},inputVal:function(t){this.handleChange(t)}}},at=nt,it=(s("b97e"),Object(d["a"])(at,et,st,!1,null,"2c695357",null)),ot=it.exports,rt=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"textbox-container",on:{scroll:t.handleScroll}},[t._t("default")],2)},ct=[],lt={name:"Textbox",props:{},data:function(){return{}},methods:{handleScroll:function(){this.$el.scrollHeight===this.$el.scrollTop+this.$el.clientHeight&&this.$emit("scrolled",!0)}}},ut=lt,dt=(s("0273"),Object(d["a"])(ut,rt,ct,!1,null,"4fa1fc6a",null)),pt=dt.exports,ht=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"set-storage-size-container"},[n("div",{staticClass:"storage"},[n("p",{staticClass:"title"},[t._v("Set Host Storage Size")]),n("div",{staticClass:"storage-container"},[n("div",{staticClass:"storage-space"},[n("div",{staticClass:"storage-space-used",class:{disabled:this.isDisabled}},[t._v(" "+t._s(this.current.used)+" GB used ")]),n("div",{staticClass:"storage-space-available",class:{disabled:this.isDisabled}},[t._v(" "+t._s(this.current.available)+" GB remaining ")])]),n("SlideBar",{attrs:{isDisabled:this.isDisabled,initialPercent:this.current.initialPercent},on:{slideChange:t.handleSlideChange}}),n("div",[this.isDisabled?n("p",{staticClass:"error"},[n("img",{attrs:{src:s("ec4e")}}),t._v(" 32 GB is the minimum host storage commitment size ")]):t._e()])],1)]),n("div",{staticClass:"earnings"},[n("p",{staticClass:"title"},[t._v("Potential earnings per month")]),n("p",{staticClass:"btt"},[t._v(t._s(this.earnings)+" BTT")])])])},ft=[],vt=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{ref:"slidebarBg",staticClass:"slidebar-bg",class:{"slidebar-disabled":this.isDisabled},style:t.bgStyle},[s("div",{staticClass:"slidebar-fill",class:{"slidebar-disabled":this.isDisabled},style:t.fillStyle}),!this.isDisabled&&this.slideable?s("div",{staticClass:"slidebar-knob",style:{left:this.knob.x-12+"px"},on:{mousedown:t.startDrag}}):t._e()])},gt=[],mt={name:"SlideBar",props:{isDisabled:{type:Boolean,required:!1,default:!1},initialPercent:{type:Number,required:!1,default:0},slideable:{type:Boolean,required:!1,default:!0},height:{type:Number,required:!1,default:8},fillColor:{type:String,required:!1,default:_.a.btfsBlue500},bgColor:{type:String,required:!1,default:_.a.btfsBlue100}},data:function(){return{isDragging:!1,knob:{x:0},parent:{width:0,left:0},slidebarPercent:this.initialPercent}},methods:{handleMouseMove:function(t){var e=t.x,s=e-this.parent.left;s<0?s=0:s>this.parent.width&&(s=this.parent.width),this.knob.x=s;var n=this.knob.x/this.parent.width||0;this.slidebarPercent=n.toFixed(4),this.$emit("slideChange",this.slidebarPercent)},handleMouseUp:function(){window.removeEventListener("mousemove",this.handleMouseMove),window.removeEventListener("mouseup",this.handleMouseUp)},startDrag:function(){this.isDragging=!0;var t=this.$refs.slidebarBg.getBoundingClientRect(),e=t.left,s=t.width;this.parent.width=s,this.parent.left=e,window.addEventListener("mousemove",this.handleMouseMove),window.addEventListener("mouseup",this.handleMouseUp)},handleResize:function(){var t=this.$refs.slidebarBg.getBoundingClientRect(),e=t.left,s=t.width;this.parent.width=s,this.parent.left=e,this.knob.x=(this.parent.width*this.slidebarPercent).toFixed(0)}},computed:{bgStyle:function(){return{height:"".concat(this.height,"px"),backgroundColor:this.bgColor}},fillStyle:function(){var t=(100*this.slidebarPercent).toFixed(0);return t="".concat(t,"%"),{width:t,backgroundColor:this.fillColor}}},watch:{initialPercent:function(){this.slidebarPercent=this.initialPercent,this.handleResize()}},mounted:function(){this.handleResize(),window.addEventListener("resize",this.handleResize)},beforeDestroy:function(){window.removeEventListener("resize",this.handleResize)}},Ct=mt,bt=(s("1357"),Object(d["a"])(Ct,vt,gt,!1,null,"46182f36",null)),yt=bt.exports,wt={name:"SetStorageSize",components:{SlideBar:yt},props:{isDisabled:{type:Boolean,required:!0,default:!0},storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},cap:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}}},data:function(){var t=this.initData();return{current:t,noSlideInput:this.storageSpace.cap,noSlideState:""}},methods:{handleSlideChange:function(t){var e=St(this.storageSpace.minimum,this.storageSpace.total,t);this.current.used=e.used,this.current.available=e.available,this.$emit("slideChange",this.current.used)},initData:function(){this.storageSpace.total<this.storageSpace.minimum&&(this.isDisabled=!0);var t=((this.storageSpace.cap-this.storageSpace.minimum)/(this.storageSpace.total-this.storageSpace.minimum)).toFixed(3);t=parseFloat(t);var e=St(this.storageSpace.minimum,this.storageSpace.total,t);return t<0?t=0:t>1&&(t=1),{used:e.used,available:e.available,initialPercent:t}}},computed:{earnings:function(){return _t(this.current.used)}},watch:{storageSpace:function(){var t=this.initData();this.current=Object(o["a"])(Object(o["a"])({},this.current),t)}}};function St(t,e,s){var n=(e-t)*s+t,a=e-n;return{used:parseInt(n.toFixed(0)),available:parseInt(a.toFixed(0))}}function _t(t){var e=8*t;return e=parseInt(e),e.toLocaleString()}var xt=wt,Pt=(s("41b8"),Object(d["a"])(xt,ht,ft,!1,null,"79e412d8",null)),Bt=Pt.exports,Tt={name:"HostSetup",components:{Button:D,Checkbox:ot,Textbox:pt,SetStorageSize:Bt},props:{storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},cap:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}},storageAnnounce:{type:Function,default:function(){}},setInitializationFlag:{type:Function,default:function(){}},updateHostSetupValues:{type:Function,default:function(){}},chooseOtherFolder:{type:Function,default:function(){}},hostSetupValues:{type:Object}},data:function(){return{steps:["1","2","3"],current:2,hasAgreed:!1,scrolledToBottom:!1,selectedStorageValue:this.storageSpace.cap}},computed:{isDisabled:function(){return this.selectedStorageValue<this.storageSpace.minimum}},methods:{handleScrolled:function(t){this.scrolledToBottom=t},handleCommitClick:function(){this.updateHostSetupValues({size:this.selectedStorageValue}),this.nextStep()},handleSetupComplete:function(){var t=this,e=this.hostSetupValues,s=e.path,n=e.size;this.newFolderPromise(s,n).then((function(){t.storageAnnounce(n)})),this.setInitializationFlag().then((function(){t.$router.push("/host")}))},newFolderPromise:function(t,e){var s=this;return new Promise((function(n){return null===t?n():s.chooseOtherFolder({path:t,size:e,suppressNotification:!0}).then((function(){return n()}))}))},nextStep:function(){this.current+=1},prevStep:function(){this.current-=1}},watch:{storageSpace:function(){this.selectedStorageValue=this.storageSpace.cap}},created:function(){this.storageAnnounce(32)}},Vt=Tt,Ht=(s("8a52"),Object(d["a"])(Vt,X,tt,!1,null,"041167aa",null)),kt=Ht.exports,Et=function(){var t=this,e=t.$createElement,s=t._self._c||e;return t.isStorageLoading?t._e():s("div",{staticClass:"dashboard"},[s("div",{staticClass:"dashboard-header"},[s("DashboardStat",{attrs:{title:"Status",tooltipText:t.onlineStatusTooltip}},[s("span",{staticClass:"online-status",class:{offline:!t.online,hidden:t.isSyncing}},[t._v(t._s(t.onlineStatusText))])]),s("DashboardStat",{attrs:{title:"Score",tooltipText:t.hostScoreTip}},[s("span",{class:{hidden:t.isSyncing}},[t._v(t._s(t.score)+"/10")])]),s("DashboardStat",{attrs:{title:"Uptime",tooltipText:"Percentage of time when a host is online and can prove the file replication."}},[s("span",{class:{hidden:t.isSyncing}},[t._v(t._s((100*t.uptime).toFixed(0))+"%")])]),s("DashboardStat",{attrs:{title:"Contracts",tooltipText:"Each contract represents a host’s commitment to a certain file shard’s storage length, size, and price."}},[s("span",{class:{hidden:t.isSyncing}},[t._v(t._s(t.contractsNum))])])],1),s("div",{staticClass:"dashboard-container"},[s("div",{staticClass:"earnings-container"},[s("div",{staticClass:"earnings"},[s("p",{staticClass:"title"},[t._v(" Earnings to-date ")]),s("p",{staticClass:"amount"},[s("span",{class:{hidden:t.isSyncing}},[t._v(t._s(t.earnings.toLocaleString("en-US",{maximumFractionDigits:6}))+" BTT ")])])]),s("div",{staticClass:"future-earnings"},[s("div",{staticClass:"future-potential-earnings"},[s("p",{staticClass:"secondary-title"},[t._v("Future potential earnings")]),s("p",{staticClass:"secondary-content"},[t._v(" "+t._s(t.potentialEarnings.toLocaleString("en-US",{maximumFractionDigits:6}))+" BTT ")])]),s("div",{staticClass:"next-payment-date"},[s("p",{staticClass:"secondary-title"},[t._v("Next payment date")]),s("p",{staticClass:"secondary-content"},[t._v(" "+t._s(t.nextPayment)+" ")])])]),s("div",{staticClass:"pricing"},[s("p",{staticClass:"secondary-title"},[t._v("Current host pricing")]),s("p",{staticClass:"secondary-content"},[t._v(" "+t._s(t.hostPricing.toLocaleString())+" BTT (TB/Month) ")])])]),t.isSyncing?t._e():s("ViewStorageSize",{attrs:{storageSpace:t.storageSpace,isDisabled:!1,handleEdit:t.toggleEditStorageSize,canEdit:"in-progress"!==t.notificationState}})],1),t.isSyncing?s("div",{staticClass:"syncing-bg"},[t._m(0)]):t._e(),t.showEditStorageSize?s("EditStorageSize",{attrs:{storageSpace:t.storageSpace,handleClose:t.toggleEditStorageSize,hostDirectoryPath:t.hostDirectoryPath,chooseOtherFolder:t.chooseOtherFolder,checkOtherFolder:t.checkOtherFolder}}):t._e(),t.showNotification?s("Notification",{attrs:{state:t.notificationState,handleClose:t.notificationClose},scopedSlots:t._u([{key:"in-progress",fn:function(){return[t._v(" Transferring files ("+t._s(t.storageSpace.used)+" GB).. ")]},proxy:!0},{key:"complete",fn:function(){return[t._v(" Transfer Complete ")]},proxy:!0}],null,!1,3949802468)}):t._e()],1)},Lt=[function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"syncing-container"},[n("p",{staticClass:"syncing-header"},[t._v("Validating...")]),n("p",{staticClass:"syncing-description"},[t._v("Please wait up to 180 minutes while the BTFS network is validating this node. A new node needs to demonstrate its online presence long enough to qualify as a valid storage host. Please refresh every 30 minutes to check for update. ")]),n("img",{staticClass:"spinner-icon",attrs:{src:s("9bfb")}})])}],It=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:t.tooltipText,classes:["tooltip",t.title],hideOnTargetClick:!1,autoHide:!1},expression:"{ content: tooltipText, classes: ['tooltip', title], hideOnTargetClick: false, autoHide: false}",modifiers:{bottom:!0}}],staticClass:"dashboard-stat"},[s("div",{staticClass:"title"},[t._v(" "+t._s(t.title)+" ")]),s("div",{staticClass:"content"},[t._t("default")],2)])},Ot=[],Ft={name:"DashboardStat",props:{title:{type:String,required:!0},tooltipText:{type:String,required:!0},tooltipClass:{type:String,default:""}},data:function(){return{}},methods:{}},Dt=Ft,At=(s("e8bc"),Object(d["a"])(Dt,It,Ot,!1,null,"0c2e0c26",null)),Mt=At.exports,$t=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"set-storage-size-container"},[s("div",{staticClass:"storage"},[t.canEdit?s("svg-icon",{staticClass:"edit-button",attrs:{icon:"edit",hasFill:!0},on:{click:t.handleEdit}}):t._e(),t.canEdit?t._e():s("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.left",value:{content:"Folder transfer in progress. Please wait until the transfer is done to make any changes.",classes:["tooltip"]},expression:"{ \n\t\t\t\tcontent: 'Folder transfer in progress. Please wait until the transfer is done to make any changes.', \n\t\t\t\tclasses: ['tooltip'], \n\t\t\t}",modifiers:{left:!0}}],staticClass:"edit-button disabled",attrs:{icon:"edit",hasFill:!0}}),s("p",{staticClass:"title"},[t._v("Host Storage Size")]),s("div",{staticClass:"storage-container"},[s("div",{staticClass:"storage-space"},[s("div",{staticClass:"storage-space-used",style:this.storageState.usedTextStyle},[t._v(" "+t._s(this.current.used)+" GB used ")]),s("div",{staticClass:"storage-space-available",style:this.storageState.remainingTextStyle},[t._v(" "+t._s(this.remainingSpace)+" GB remaining ")])]),s("SlideBar",{attrs:{initialPercent:this.current.initialPercent,slideable:!1,height:24,bgColor:this.storageState.bar.bgColor,fillColor:this.storageState.bar.fillColor}}),s("div",{staticClass:"error-container"},["warning"===this.storageState.state?s("p",{staticClass:"warning"},[t._v(" Your storage is almost full, please increase your storage commitment size. ")]):t._e(),"danger"===this.storageState.state?s("p",{staticClass:"danger"},[t._v(" Your storage is full, please increase your storage commitment size. ")]):t._e()])],1)],1)])},Nt=[],zt={name:"ViewStorageSize",components:{SlideBar:yt},props:{storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},cap:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}},handleEdit:{type:Function,default:function(){}},canEdit:{type:Boolean,default:!0}},data:function(){var t=this.initData();return{current:t,warningThreshold:10}},methods:{initData:function(){var t=(this.storageSpace.used/this.storageSpace.cap).toFixed(3);t=parseFloat(t);var e=jt(0,this.storageSpace.cap,t);return t<0?t=0:t>1&&(t=1),{used:this.storageSpace.used,available:e.available,initialPercent:t}}},computed:{remainingSpace:function(){return this.storageSpace.cap-this.storageSpace.used},storageState:function(){var t={bar:{bgColor:void 0,fillColor:void 0},usedTextStyle:{color:_.a.btfsBlue500},remainingTextStyle:{color:_.a.gray500},state:""};return this.remainingSpace<=this.warningThreshold&&(t.bar.bgColor="#FAEABF",t.bar.fillColor=void 0,t.remainingTextStyle={color:"#F7B500"},t.state="warning"),this.remainingSpace<=0&&(t.bar.bgColor="#EB5757",t.bar.fillColor="#EB5757",t.usedTextStyle={color:"#EB5757"},t.remainingTextStyle={color:"#EB5757"},t.state="danger"),t}},watch:{storageSpace:function(){var t=this.initData();this.current=Object(o["a"])(Object(o["a"])({},this.current),t)}}};function jt(t,e,s){var n=(e-t)*s+t,a=e-n;return{used:parseFloat(n.toFixed(3)),available:parseFloat(a.toFixed(3))}}var Rt=zt,Wt=(s("852b"),Object(d["a"])(Rt,$t,Nt,!1,null,"00770ab2",null)),Zt=Wt.exports,qt=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"edit-storage-size-bg"},[n("div",{staticClass:"edit-storage-size-container"},[n("img",{staticClass:"close-button",attrs:{src:s("bb7c")},on:{click:t.handleClose}}),n("p",{staticClass:"header"},[t._v("Edit host storage size")]),n("p",{staticClass:"description"},[t._v(" "+t._s(t.storageSpace.used)+" GB is your current storage used by BTFS. Try changing your storage commitment size in the input field below to see how much more you can earn. ")]),n("div",{staticClass:"no-slider-container"},[n("div",{},[n("p",{staticClass:"subtitle"},[t._v(" Local storage ")]),n("b",[t._v(" "+t._s(this.current.available)+" GB available of "+t._s(this.storageSpace.total)+" GB ")])]),n("EditHostFolder",{attrs:{hostDirectoryPath:t.hostDirectoryPath,checkOtherFolder:t.checkOtherFolder},on:{inputChange:t.onEditFolder,isEditingFolder:function(e){t.isEditingFolder=e}}}),n("div",{staticClass:"storage-commit-size"},[n("p",{staticClass:"subtitle"},[t._v(" Storage commitment size ")]),n("div",{staticClass:"storage-commit-size-container"},[n("div",[n("input",{directives:[{name:"model",rawName:"v-model",value:t.noSlideInput,expression:"noSlideInput"}],class:{unchanged:!t.isCommitSizeDifferent,invalid:""!==t.noSlideState||"commit-valid"===t.noSlideState},attrs:{type:"number"},domProps:{value:t.noSlideInput},on:{focus:t.handleInputFocus,blur:t.handleInputBlur,input:function(e){e.target.composing||(t.noSlideInput=e.target.value)}}}),t._v(" GB ")])]),n("div",{staticClass:"input-message-container"},["commit-invalid-low"===this.noSlideState||"commit-invalid-below"===this.noSlideState?n("div",{staticClass:"input-message invalid"},[n("svg-icon",{staticClass:"icon invalid",attrs:{icon:"info",hasFill:!0}}),"commit-invalid-low"===this.noSlideState?n("span",[t._v(" You cannot commit less than the current used storage size by BTFS ")]):t._e(),"commit-invalid-below"===this.noSlideState?n("span",[t._v(" You cannot commit less than 32 GB ")]):t._e()],1):t._e(),"commit-invalid-high"===this.noSlideState?n("div",{staticClass:"input-message invalid"},[n("svg-icon",{staticClass:"icon invalid",attrs:{icon:"info",hasFill:!0}}),t._v(" You do not have enough space on your hard drive ")],1):t._e(),""===this.noSlideState||"commit-valid"===this.noSlideState?n("div",{staticClass:"input-message earnings"},[t._v(" Monthly potential earnings: "+t._s(this.earnings)+" BTT ")]):t._e()])])],1),n("div",{staticClass:"button-container"},[n("Button",{attrs:{isDisabled:!t.canCommit,cb:t.handleCommitClick}},[t._v("Save changes")])],1)])])},Ut=[],Gt={name:"EditStorageSize",components:{Button:D,"svg-icon":L,EditHostFolder:G},props:{storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},cap:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}},handleClose:{type:Function,default:function(){}},hostDirectoryPath:{type:String,default:null},chooseOtherFolder:{type:Function,default:function(){}},checkOtherFolder:{type:Function,default:function(){}}},data:function(){var t=this.initData();return{noSlideInput:this.storageSpace.cap,current:t,noSlideState:"",newHostDirectoryPath:this.hostDirectoryPath,isEditingFolder:!1}},methods:{handleCommitClick:function(){var t=this;this.commitSizeCheck()&&(this.newFolderPromise().then((function(){t.isCommitSizeDifferent&&t.$store.dispatch("storageAnnounce",{hostStorageMax:t.noSlideInput,local:!1})})),this.handleClose())},newFolderPromise:function(){var t=this;return new Promise((function(e){return null!==t.newHostDirectoryPath&&t.isStorageLocationDifferent?t.chooseOtherFolder({path:t.newHostDirectoryPath,size:t.noSlideInput}).then((function(){return e()})):e()}))},commitSizeCheck:function(){var t=this.noSlideInput<this.storageSpace.minimum,e=this.noSlideInput<this.storageSpace.used,s=this.noSlideInput>this.storageSpace.total;return t?(this.noSlideState="commit-invalid-below",!1):e?(this.noSlideState="commit-invalid-low",!1):s?(this.noSlideState="commit-invalid-high",!1):(this.noSlideInput===this.storageSpace.cap||(this.noSlideState="commit-valid",this.current.used=this.noSlideInput,this.current.available=this.storageSpace.total-this.noSlideInput,this.noSlideInput=this.current.used),!0)},handleInputFocus:function(){this.noSlideState=""},handleInputBlur:function(){""===this.noSlideInput&&(this.noSlideInput=this.storageSpace.cap),this.noSlideInput=Math.round(this.noSlideInput)},initData:function(){this.storageSpace.total<this.storageSpace.minimum&&(this.isDisabled=!0);var t=((this.storageSpace.cap-this.storageSpace.minimum)/(this.storageSpace.total-this.storageSpace.minimum)).toFixed(3);t=parseFloat(t),t<0?t=0:t>1&&(t=1);var e=Kt(this.storageSpace.minimum,this.storageSpace.total,t);return{used:e.used,available:e.available,initialPercent:t}},onEditFolder:function(t){this.newHostDirectoryPath=t}},computed:{canCommit:function(){return this.isCommitSizeDifferent||this.isStorageLocationDifferent},earnings:function(){return Yt(this.noSlideInput)},isCommitSizeDifferent:function(){return this.storageSpace.cap!==this.noSlideInput},isStorageLocationDifferent:function(){return this.hostDirectoryPath!==this.newHostDirectoryPath}},watch:{storageSpace:function(){var t=this.initData();this.current=Object(o["a"])(Object(o["a"])({},this.current),t)}},created:function(){this.$store.dispatch("updateProposedStorageAvailable",null)}};function Kt(t,e,s){var n=(e-t)*s+t,a=e-n;return{used:parseInt(n.toFixed(3)),available:parseInt(a.toFixed(0))}}function Yt(t){var e=8*t;return e=parseInt(e),e.toLocaleString()}var Jt,Qt,Xt=Gt,te=(s("47fe"),Object(d["a"])(Xt,qt,Ut,!1,null,"6c02e0c0",null)),ee=te.exports,se=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"notification",class:{complete:"complete"===this.state}},["in-progress"===this.state?s("div",{staticClass:"notification-content"},[s("svg-icon",{staticClass:"icon check spinner-icon",attrs:{icon:"loading",hasFill:!0}}),t._t("in-progress")],2):t._e(),"complete"===this.state?s("div",{staticClass:"notification-content"},[s("div",{staticClass:"icon-container"},[s("svg-icon",{staticClass:"icon check",attrs:{icon:"check-circle-outline",hasFill:!0}})],1),t._t("complete"),s("div",{staticClass:"icon-container"},[s("svg-icon",{staticClass:"icon close",attrs:{icon:"close",hasFill:!0},on:{click:t.handleClose}})],1)],2):t._e()])},ne=[],ae={name:"Notification",components:{},props:{state:{type:String,default:""},handleClose:{type:Function,default:function(){}}},data:function(){return{}},methods:{}},ie=ae,oe=(s("f849"),Object(d["a"])(ie,se,ne,!1,null,"d8288616",null)),re=oe.exports,ce={name:"HostDashboard",props:{online:{type:Boolean,required:!1,default:!1},score:{type:Number,required:!1,default:0},uptime:{type:Number,required:!1,default:0},contractsNum:{type:Number,required:!1,default:0},earnings:{type:Number,required:!1,default:0},potentialEarnings:{type:Number,required:!1,default:0},hostPricing:{type:Number,required:!1,default:0},nextPayment:{type:String,required:!1,default:""},storageSpace:{type:Object,default:function(){return{minimum:{type:Number,required:!1,default:0},used:{type:Number,required:!0,default:0},total:{type:Number,required:!0,default:1e3}}}},isSynced:{type:Boolean,required:!0,default:!1},isSyncedLocal:{type:Boolean,required:!0,default:!1},isStorageLoading:{type:Boolean,required:!0,default:!0},isImporting:{type:Boolean,required:!0,default:!1},hostDirectoryPath:{type:String,default:null},chooseOtherFolder:{type:Function,default:function(){}},checkOtherFolder:{type:Function,default:function(){}},notificationState:{type:String,default:""},notificationClose:{type:Function,default:function(){}},handleRefresh:{type:Function,default:function(){}}},components:{DashboardStat:Mt,ViewStorageSize:Zt,EditStorageSize:ee,Notification:re},methods:{onRefresh:function(){this.refresh()},toggleEditStorageSize:function(){this.showEditStorageSize=!this.showEditStorageSize}},data:function(){return{showEditStorageSize:!1}},computed:{onlineStatusText:function(){return this.online?"Online":"Offline"},onlineStatusTooltip:function(){return this.online?"You are connected to BTFS network and hosting.":"You are not connected to BTFS network, this could be due to many reasons, please check FAQs: <a href='https://docs.btfs.io/docs/faq' target='_blank' rel='noreferrer noopener'>https://docs.btfs.io/docs/faq</a>"},hostScoreTip:function(){return"This is an indicator of how reliable a host is, based on the following factors: <br/><br/>1. Uptime: Opted-in and enabled Host that is connected to the network; Please prevent interruptions from any network disconnection or client shut down, as this will reduce this value.<br/><br/>2. Host age: the historical time as a Host, manually opt-out as a Host will reduce this value.<br/><br/>3. Upload/download speed<br/><br/>4. Client version: Your client will be auto-updated to the latest node version, staying offline or disable this feature manually will reduce this value."},showNotification:function(){return""!==this.notificationState},isSyncing:function(){return!this.isSynced&&!this.isSyncedLocal||this.isImporting}},mounted:function(){this.handleRefresh()}},le=ce,ue=(s("2b57"),Object(d["a"])(le,Et,Lt,!1,null,"5ce58e42",null)),de=ue.exports,pe=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"settings"},[s("p",{staticClass:"title"},[t._v("Settings")]),s("div",{staticClass:"settings-nav"},t._l(t.navList,(function(e){return s("div",{key:e,staticClass:"settings-nav-item",class:{"settings-nav-item-selected":e===t.selectedNavItem},on:{click:function(){t.handleNavClick(e)}}})})),0),"host"===t.selectedNavItem?s("div",{staticClass:"settings-host"},[t._m(0),s("p",{staticClass:"content"},[t._v(" "+t._s(t.version)+" ")]),s("div",{staticClass:"subtitle-container"},[s("p",{staticClass:"subtitle"},[t._v(" host node id ")]),s("div",[s("Button",{attrs:{type:"copy",copyValue:t.id}},[t._v(" Copy ")])],1)]),s("div",{staticClass:"content node-id"},[s("p",{ref:"nodeId",attrs:{id:"node-id"}},[t._v(" "+t._s(t.id)+" ")])]),s("div",{staticClass:"subtitle-container"},[s("p",{staticClass:"subtitle"},[t._v(" Pricing tb/month ")]),s("div",[s("Button",{attrs:{type:"outline",isDisabled:t.showAdvanced,cb:t.toggleAdvanced}},[s("div",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:"Edit monthly host pricing",classes:["tooltip"]},expression:"{ \n\t\t\t\t\t\tcontent: 'Edit monthly host pricing', \n\t\t\t\t\t\tclasses: ['tooltip'], \n\t\t\t\t\t}",modifiers:{bottom:!0}}],staticClass:"button"},[t._v(" Edit "),s("svg-icon",{staticClass:"icon",attrs:{icon:"edit",hasFill:!0}})],1)])],1)]),s("div",{staticClass:"content"},[t._v(" "+t._s(t.hostPricing.toLocaleString())+" BTT "),t.equalSuggestedPricing||t.showAdvanced?t._e():s("p",{staticClass:"assistive-text"},[t._v(" Suggested price: "+t._s(this.hostSuggestedPricing.toLocaleString())+" BTT ")]),t.showAdvanced?s("div",[s("div",{staticClass:"pricing"},[s("div",{},[t._v(" Edit Pricing ")]),s("div",{staticClass:"pricing-input"},[s("input",{directives:[{name:"model",rawName:"v-model",value:t.newHostPricing,expression:"newHostPricing"}],class:{invalid:!t.isPricingValid},attrs:{type:"number"},domProps:{value:t.newHostPricing},on:{input:function(e){e.target.composing||(t.newHostPricing=e.target.value)}}}),t._v(" "),s("span",{staticClass:"subtitle"},[t._v("BTT")])])]),t.isPricingValid?t._e():s("p",{staticClass:"assistive-text invalid"},[t._v("Please enter a valid amount")]),t.isPricingValid?s("p",{staticClass:"assistive-text"},[t._v("Suggested price: "+t._s(this.hostSuggestedPricing.toLocaleString())+" BTT")]):t._e(),t._m(1),s("div",{staticClass:"button-container"},[s("Button",{staticClass:"cancel",attrs:{cb:t.toggleAdvanced,type:"outline"}},[t._v("Cancel")]),s("Button",{attrs:{cb:t.handlePriceUpdate,isDisabled:!t.isPricingValid}},[t._v("Save Changes")])],1)]):t._e()])]):t._e(),"wallet"===t.selectedNavItem?s("div",{staticClass:"settings-wallet"}):t._e(),t.showNotification?s("Notification",{attrs:{state:t.notificationState,handleClose:t.notificationClose},scopedSlots:t._u([{key:"complete",fn:function(){return[t._v(" Pricing updated ")]},proxy:!0}],null,!1,2867064329)}):t._e()],1)},he=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"subtitle-container"},[s("p",{staticClass:"subtitle"},[t._v(" current version ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"pricing-description"},[t._v(" Changes to advanced settings can impact your host earnings. Please make sure you understand the effects before making any changes. "),s("br"),s("br"),t._v(" Learn more on our "),s("a",{attrs:{href:"https://www.bittorrent.com/token/bittorrent-file-system/faq/",target:"_blank",rel:"noreferrer noopener"}},[t._v("FAQs page")]),t._v(". ")])}],fe={name:"Dashboard",props:{version:{type:String,required:!0},id:{type:String,required:!0},notificationState:{type:String,default:""},notificationClose:{type:Function,default:function(){}},hostPricing:{type:Number},hostSuggestedPricing:{type:Number},storageAnnouncePrice:{type:Function,default:function(){}}},components:{Button:D,Notification:re},methods:{handleNavClick:function(t){this.selectedNavItem=t},toggleAdvanced:function(){this.showAdvanced=!this.showAdvanced},notificationDone:function(){return this.$store.dispatch("notificationComplete","settings")},handlePriceUpdate:function(){var t=this;this.storageAnnouncePrice({hostStoragePrice:this.newHostPricing}).then((function(){return t.toggleAdvanced(),t.notificationDone()}))}},data:function(){return{navList:["host"],selectedNavItem:"host",showCopyTooltip:!1,showAdvanced:!1,newHostPricing:this.hostPricing}},computed:{showNotification:function(){return""!==this.notificationState},equalSuggestedPricing:function(){return this.hostPricing===this.hostSuggestedPricing},isPricingValid:function(){return this.newHostPricing>0&&this.newHostPricing<=304128e5}},mounted:function(){},beforeDestroy:function(){}},ve=fe,ge=(s("df8a"),Object(d["a"])(ve,pe,he,!1,null,"33a6a064",null)),me=ge.exports,Ce=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"wallet-add"},[t._m(0),s("div",{staticClass:"container"},[s("p",{staticClass:"text-lg"},[t._v("Create Wallet")]),s("p",{staticClass:"text-md"},[t._v("Click here if you don’t have an ID on BitTorrent Wallet.")]),s("div",{staticClass:"button-container"},[s("router-link",{attrs:{to:"/wallet/setup/"}},[s("Button",[t._v("Create")])],1)],1)]),s("div",{staticClass:"container"},[s("p",{staticClass:"text-lg"},[t._v("Import Wallet")]),s("p",{staticClass:"text-md"},[t._v("Click here if you already have a BitTorrent wallet ID.")]),s("div",{staticClass:"button-container"},[s("router-link",{attrs:{to:"/wallet/import/"}},[s("Button",[t._v("Import")])],1)],1)])])},be=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Wallet setup")]),s("p",{staticClass:"description"},[t._v(" Please follow the guide below to properly set up and protect your wallet. The wallet will allow you to manage your funds by transferring digital tokens. ")]),s("p",{staticClass:"sub-description"},[t._v(" Looking for more information? Read our "),s("a",{attrs:{href:"https://www.bittorrent.com/token/bittorrent-file-system/faq/",target:"_blank",rel:"noreferrer noopener"}},[t._v("FAQs")])])])}],ye={name:"WalletAdd",props:{},components:{Button:D},methods:{},data:function(){return{}},computed:{}},we=ye,Se=(s("8700"),Object(d["a"])(we,Ce,be,!1,null,"603bda8f",null)),_e=Se.exports,xe=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"wallet-setup"},[s("StepIndicator",{staticClass:"step-container",attrs:{steps:t.steps,current:t.current}}),1===t.current?s("div",{staticClass:"wallet-setup-1"},[t._m(0),s("div",{staticClass:"password-container"},[s("PasswordCreate",{on:{passwordsMatch:function(e){t.passwordsMatch=e},password:function(e){t.password=e}}})],1),t._m(1),s("div",{staticClass:"button-container"},[s("div"),s("div",[s("Button",{attrs:{disabled:!t.passwordsMatch,cb:t.handlePasswordSet}},[t._v("Continue")])],1)])]):t._e(),2===t.current?s("div",{staticClass:"wallet-setup-2"},[t._m(2),s("div",{staticClass:"recovery-right-container"},[s("div",{staticClass:"recovery-answer-key-container"},t._l(t.recoveryPhrases,(function(e,n){return s("div",{key:n,staticClass:"recovery-answer-key"},[t._v(t._s(e)+" ")])})),0)]),s("div",{staticClass:"button-container copy-container"},[s("Button",{attrs:{type:"copy",copyValue:t.recoveryPhrases.join(" ")}},[t._v("Copy recovery phrase to clipboard")])],1),s("div",{staticClass:"button-container"},[s("div",[s("Button",{attrs:{type:"text",cb:t.prevStep}},[t._v("Back")])],1),s("div",[s("Button",{attrs:{cb:t.nextStep}},[t._v("Continue")])],1)])]):t._e(),3===t.current?s("div",{staticClass:"wallet-setup-3"},[t._m(3),s("div",{staticClass:"recovery-match-container"},[s("RecoveryPhrase",{attrs:{choices:t.recoveryPhrases},on:{recoveryPhraseMatches:t.handleRecoveryPhraseMatches}}),s("p",{staticClass:"error-message",class:{hidden:!1!==t.recoveryPhraseMatches}},[t._v("The recovery phrase sequence is incorrect.")])],1),s("div",{staticClass:"button-container"},[s("div",[s("Button",{attrs:{type:"text",cb:t.prevStep}},[t._v("Back")])],1),s("div",[s("Button",{attrs:{disabled:!0!==t.recoveryPhraseMatches,cb:t.handleSetupComplete}},[t._v("Finish & create wallet")])],1)])]):t._e()],1)},Pe=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Wallet password")]),s("p",{staticClass:"description"},[t._v(" Create a strong password and save it in a safe place. We do not have access to your password and will not be able to restore it. Losing your password may result in losing access to your funds. ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"text-container"},[s("p",{staticClass:"text-sm"},[t._v(" By clicking “Continue” you agree to the "),s("a",{attrs:{href:"https://www.bittorrent.com/legal/terms-of-use/",target:"_blank",rel:"noreferrer noopener"}},[t._v("Terms of Service")]),t._v(" and "),s("a",{attrs:{href:"https://www.bittorrent.com/legal/privacy-policy/",target:"_blank",rel:"noreferrer noopener"}},[t._v("Privacy Policy")]),t._v(". ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Wallet recovery phrase")]),s("p",{staticClass:"description"},[t._v(" This is your wallet recovery phrase. Please copy this phrase and store it somewhere safe as there’s no way for us to help you recover this if it’s lost. ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Confirm recovery phrase")]),s("p",{staticClass:"description"},[t._v(" Confirm your recovery phrase by clicking on the words below and placing them in sequence in their corresponding tiles, starting from 1 and ending with 12. ")])])}],Be=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"step-indicator-container",style:t.stepIndicatorStyle},[s("ul",{staticClass:"step-list"},t._l(t.steps,(function(e,n){return s("li",{key:n,class:{active:n+1===t.currentClamped,filled:n+1<=t.currentClamped}},[s("div",{staticClass:"step-list-inner"},[t._v(" "+t._s(e)+" ")])])})),0)])},Te=[],Ve={name:"StepIndicator",props:{current:{type:Number,required:!0},steps:{type:Array,required:!0}},computed:{currentClamped:function(){return Math.max(Math.min(this.current,this.steps.length),1)},stepIndicatorStyle:function(){return{"--width":2===this.steps.length?"200%":"150%"}}}},He=Ve,ke=(s("06a9"),Object(d["a"])(He,Be,Te,!1,null,"7923a71f",null)),Ee=ke.exports,Le=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",[s("div",{staticClass:"password-container"},[s("bt-input-box",{attrs:{inputType:"password",placeholder:"Wallet Password",isInvalid:!t.passwordStrong,showMessage:!0,borderColor:t.colors.btfsBlue500,passwordPlaceholderColor:t.colors.gray500,messageColor:t.colors.btfsGreen,message:t.passwordState.message,iconColor:t.colors.gray500,invalidColor:t.passwordState.invalidColor},model:{value:t.password,callback:function(e){t.password=e},expression:"password"}}),s("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.left",value:{content:t.tooltip,classes:["tooltip"],hideOnTargetClick:!1,autoHide:!1},expression:"{ content: tooltip, classes: ['tooltip'], hideOnTargetClick: false, autoHide: false}",modifiers:{left:!0}}],staticClass:"icon",attrs:{icon:"help",hasFill:!1}})],1),s("div",{staticClass:"password-container"},[s("bt-input-box",{attrs:{inputType:"password",placeholder:"Confirm Password",isInvalid:!t.passwordsMatch&&""!==t.confirmPassword,showMessage:!0,borderColor:t.colors.btfsBlue500,passwordPlaceholderColor:t.colors.gray500,messageColor:t.colors.btfsGreen,message:t.confirmPasswordState.message,iconColor:t.colors.gray500,invalidColor:t.colors.btfsRed},model:{value:t.confirmPassword,callback:function(e){t.confirmPassword=e},expression:"confirmPassword"}})],1)])},Ie=[],Oe=s("580e"),Fe=s.n(Oe),De={name:"PasswordCreate",props:{},components:{"svg-icon":L,"bt-input-box":W},data:function(){return{togglePasswordCheck:!1,password:"",confirmPassword:"",colors:_.a,tooltip:"<div>We recommend including: <br/><br/>- One lower case letter<br/>- One upper case letter<br/>- One special character<br/>- One number<br/>- No spaces</div>"}},methods:{},computed:{passwordState:function(){switch(this.passwordStrength){case 1:return{message:"Very weak password",invalidColor:_.a.btfsRed};case 2:return{message:"Weak password",invalidColor:_.a.btfsOrange};case 3:return{message:"Strong password",invalidColor:_.a.btfsRed};default:return""}},confirmPasswordState:function(){return this.passwordStrong&&""!==this.confirmPassword?this.passwordsMatch?{message:"Passwords match"}:{message:"Passwords don't match",borderColor:_.a.btfsRed}:{message:"",borderColor:_.a.gray500}},passwordStrong:function(){return!this.passwordStrength||this.passwordStrength>2},passwordStrength:function(){return this.password?Math.max(Math.min(Fe()(this.password).score,3),1):null},passwordsMatch:function(){return this.$emit("passwordsMatch",this.password===this.confirmPassword&&this.passwordStrong&&""!==this.password),this.$emit("password",this.password),this.password===this.confirmPassword}}},Ae=De,Me=(s("91b4"),Object(d["a"])(Ae,Le,Ie,!1,null,"3bc160d8",null)),$e=Me.exports,Ne=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"recovery-phrase-container"},[s("div",{staticClass:"recovery-phrase-answer-container",class:{error:t.isError}},t._l(t.shuffledChoices,(function(e,n){e.choice;var a=e.originalIndex;return s("div",{key:a,staticClass:"recovery-phrase-answer",class:{empty:t.isAnswerEmpty(n),next:t.indexNextAnswer===n},on:{click:function(e){return t.removeAnswer(n)}}},[t._v(t._s(t.answers[n]))])})),0),s("transition-group",{staticClass:"recovery-phrase-choice-container",attrs:{tag:"div",name:"choice-container"}},t._l(t.shuffledChoices,(function(e,n){var a=e.choice,i=e.originalIndex;return s("div",{key:i,staticClass:"recovery-phrase-choice",class:{disabled:t.isChoiceDisabled(n),shuffled:t.shuffled},on:{click:function(e){return t.addAnswer(a,n)}}},[t._v(t._s(a))])})),0)],1)},ze=[],je=(s("cb29"),s("c740"),s("2ef0")),Re=s.n(je),We={name:"RecoveryPhrase",props:{choices:{type:Array,default:function(){return["test1","test2","test3","test4","test5","test6","test7","test8"]}},show:{type:Boolean,default:!0}},components:{},data:function(){return{shuffledChoices:[],shuffled:!1,answers:new Array(this.choices.length).fill(void 0),answerToShuffleIndexMapper:{}}},methods:{addAnswer:function(t,e){this.answerToShuffleIndexMapper[this.indexNextAnswer]=e,n["a"].set(this.answers,this.indexNextAnswer,t)},removeAnswer:function(t){delete this.answerToShuffleIndexMapper[t],n["a"].set(this.answers,t,void 0)},isAnswerEmpty:function(t){return!this.answers[t]},isChoiceDisabled:function(t){return Re.a.includes(this.answerToShuffleIndexMapper,t)},reset:function(){this.answers=new Array(this.choices.length).fill(void 0),this.$emit("reset")}},computed:{isError:function(){return this.validate&&!this.isCorrect},isCorrect:function(){return Re.a.isEqual(this.choices,this.answers)},indexNextAnswer:function(){return Re.a.findIndex(this.answers,(function(t){return void 0===t}))},validate:function(){return void 0!==this.answers[this.answers.length-1]}},watch:{validate:function(t){var e=t?this.isCorrect:null;this.$emit("recoveryPhraseMatches",e)}},created:function(){this.shuffledChoices=Re.a.reduce(this.choices,(function(t,e,s){return t.push({originalIndex:s,choice:e}),t}),[])},mounted:function(){var t=this;setTimeout((function(){t.shuffledChoices=Re.a.shuffle(t.shuffledChoices),t.shuffled=!0}),2e3)}},Ze=We,qe=(s("ff7d"),Object(d["a"])(Ze,Ne,ze,!1,null,"489feda0",null)),Ue=qe.exports,Ge={name:"WalletSetup",props:{recoveryPhrases:{type:Array,default:function(){return["Animated","Useful","Aunt","Grab","Visit","Sales","Brisk","Glory","Blind","Exotic","upset","valve"]}},setInitializationFlag:{type:Function,default:function(){}},setPassword:{type:Function,default:function(){}},getKeys:{type:Function,default:function(){}}},components:{StepIndicator:Ee,Button:D,PasswordCreate:$e,RecoveryPhrase:Ue},data:function(){return{steps:["1","2","3"],current:1,colors:_.a,passwordsMatch:!1,recoveryPhraseMatches:null,password:""}},computed:{},methods:{handleRecoveryPhraseMatches:function(t){this.recoveryPhraseMatches=t},nextStep:function(){this.current+=1,3===this.current&&(this.recoveryPhraseMatches=null)},prevStep:function(){this.current-=1},handleSetupComplete:function(){var t=this;this.setInitializationFlag().then((function(){t.$router.push("/wallet")}))},handlePasswordSet:function(){var t=this;this.getKeys({password:this.password}),this.setPassword({password:this.password}).then((function(){return t.getKeys({password:t.password})})).then((function(){void 0===t.recoveryPhrases||0==t.recoveryPhrases.length?t.handleSetupComplete():t.nextStep()}))}}},Ke=Ge,Ye=(s("85d8"),Object(d["a"])(Ke,xe,Pe,!1,null,"11c0b234",null)),Je=Ye.exports,Qe=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"wallet-dashboard"},[t.isAuth?t._e():s("div",{staticClass:"wallet-sign-in"},[s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Sign in to your BitTorrent Wallet")]),s("p",{staticClass:"description"},[t._v(" You are about to input some sensitive information, please DO NOT expose the password to strangers. ")]),s("p",{staticClass:"description"},[t._v(" We don’t have access to your password, and WILL NOT be able to restore it. ")]),s("bt-input-box",{attrs:{label:"Password",inputType:"password",isInvalid:t.passwordInvalid,message:t.passwordMessage},on:{input:function(e){return t.password=e}}}),s("div",{staticClass:"button-container"},[s("Button",{attrs:{cb:t.handleSignIn,isDisabled:null===t.password||t.passwordInvalid}},[t._v("Continue")])],1),s("div",{staticClass:"line"}),s("p",{staticClass:"sub-description"},[t._v(" Don't remember you password? You can "),s("router-link",{staticClass:"link",attrs:{to:"/wallet/import"}},[t._v("import")]),t._v(" your wallet using your recovery phrase and private key or create a new one "),s("router-link",{staticClass:"link",attrs:{to:"/wallet/setup"}},[t._v("here")]),t._v(". ")],1)],1)]),t.isAuth?s("div",[s("div",{staticClass:"wallet-dashboard-header"},[s("div",{staticClass:"recovery-info"},[s("Button",{attrs:{cb:t.toggleRecoveryInformation,type:"text"}},[t._v("Recovery information")])],1)]),s("div",{staticClass:"wallet-dashboard-container"},[s("WalletBalanceTile",{attrs:{title:"BTFS In-app Wallet Balance",balance:t.inAppBalance,defaultTargetAddress:"cryptoWalletAddress",dropdownOptions:["BTT Crypto Wallet","Other public address"],tooltipText:'This is the balance of your BTT in BTFS network, if you would like to deposit or withdraw using a crypto wallet or exchange, please use below "BTT Crypto Wallet" instead.',handleWalletTransfer:t.handleWithdraw,minTransferAmount:1e3,publicAddress:t.id}},[s("span",[t._v("Transferring to another public address is currently under development.")])]),s("WalletBalanceTile",{attrs:{title:"BTT Crypto Wallet Balance",balance:t.cryptoBalance,defaultTargetAddress:"inAppWalletAddress",dropdownOptions:["BTFS In-App Wallet","Other public address"],tooltipText:"BTT crypto wallet balance shows your BTT securely saved in an online wallet. You can exchange them with other tokens; transfer them to your In-app balance for use with BTFS; or send them to others",handleWalletTransfer:t.handleDeposit,minTransferAmount:10,publicAddress:t.tronAddress}},[s("span",[t._v(" Transferring to another public address is currently under development. However, you can still do it through your Command Line interface, for more information please refer to "),s("a",{staticClass:"link",attrs:{href:"https://docs.btfs.io/docs/withdraw-btt-from-btfs",target:"_blank",rel:"noreferrer noopener"}},[t._v("this guide.")])])]),s("div",{staticClass:"transaction-tile"},[s("div",{staticClass:"title"},[t._v(" transactions ")]),s("svg-icon",{staticClass:"refresh-icon",attrs:{hasFill:!0,icon:"refresh"},on:{click:t.onRefresh}}),s("bt-exchange-table",{attrs:{transactions:t.visibleTransactions}}),s("div",{staticClass:"pagination-container"},[t._v(" "+t._s(0===t.transactionsLength?0:t.pageItemStart+1)+"-"+t._s(t.pageItemEnd)+" of "+t._s(t.transactionsLength)+" "),s("svg-icon",{staticClass:"arrow-icon left",attrs:{hasFill:!0,icon:"chevron-left"},on:{click:t.paginationLeft}}),s("svg-icon",{staticClass:"arrow-icon",attrs:{hasFill:!0,icon:"chevron-right"},on:{click:t.paginationRight}})],1)],1)],1),t.showRecoveryInformation?s("RecoveryInformation",{attrs:{close:t.toggleRecoveryInformation,checkPassword:t.checkPassword}}):t._e()],1):t._e()])},Xe=[],ts=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"wallet-balance-tile"},[n("div",{staticClass:"upper"},[n("p",{staticClass:"title"},[t._v(" "+t._s(t.title)+" "),n("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:t.tooltipText,classes:["tooltip"],hideOnTargetClick:!1,autoHide:!1},expression:"{ content: tooltipText, classes: ['tooltip'], hideOnTargetClick: false, autoHide: false}",modifiers:{bottom:!0}}],staticClass:"help-icon",attrs:{hasFill:!0,icon:"help-outline"}})],1),t.isNumber?t._e():n("div",{staticClass:"balance-loading"},[n("img",{staticClass:"balance-loading-icon",attrs:{src:s("9bfb")}})]),t.isNumber?n("p",{staticClass:"balance"},[t._v(t._s(t.balance.toLocaleString("en-US",{maximumFractionDigits:6}))+" BTT")]):t._e(),n("div",{staticClass:"public-key-container"},[n("div",[t._v("Public Key ID")]),n("div",{staticClass:"public-key-id"},[n("span",{staticClass:"address"},[t._v(t._s(t.publicAddress))]),n("span",{staticClass:"address-abbreviate"},[t._v(t._s(t.abbreviatedPublicAddress))]),n("svg-icon",{directives:[{name:"tooltip",rawName:"v-tooltip.bottom",value:{content:"copied!",classes:["tooltip"],show:t.showCopyTooltip,trigger:"manual"},expression:"{ \n                      content: 'copied!', \n                      classes: ['tooltip'], \n                      show: showCopyTooltip,\n                      trigger: 'manual'\n                    }",modifiers:{bottom:!0}}],staticClass:"copy-icon",attrs:{hasFill:!0,icon:"copy"},on:{click:t.handlePublicAddressCopy}})],1)]),t.isNumber?n("div",{staticClass:"transfer-button-container"},[n("Button",{attrs:{type:"text",cb:t.handleExpand,isOpen:t.isExpanded}},[t._v("Transfer")])],1):t._e()]),n("TransitionExpand",[t.isExpanded?n("div",{staticClass:"lower"},[n("div",{staticClass:"lower-inner-container"},["Other public address"!==t.dropdownSelection?n("bt-input-box",{attrs:{inputType:"number",placeholder:"Amount",showMessage:!0,messageColor:t.amountMessageColor,borderColor:t.colors.btfsBlue500,placeholderColor:t.colors.gray700,message:t.amountMessage,delayEmit:!0,isInvalid:!t.isAmountValid&&!t.isAmountEmpty},model:{value:t.amount,callback:function(e){t.amount=e},expression:"amount"}}):t._e(),n("Dropdown",{attrs:{parentId:t.title,placeholder:"Transfer to",options:t.dropdownOptions},on:{selection:function(e){return t.dropdownSelection=e}}}),"Other public address"===t.dropdownSelection?n("div",{staticClass:"coming-soon"},[t._t("default")],2):t._e(),n("div",{staticClass:"button-container"},[n("Button",{attrs:{disabled:!this.istargetAddressValid||this.istargetAddressEmpty||!this.isAmountValid,cb:t.handleSend}},[t._v("Send")])],1)],1)]):t._e()]),n("Popup",{attrs:{isOpen:t.showPopup,closeable:0===t.popupState,blur:!0},on:{onClose:t.handlePopoutClose}},[0===t.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("Transfer confirmation")]),n("p",{staticClass:"popup-description"},[t._v("You are about to send "+t._s(t.amount)+" BTT.")]),n("div",{staticClass:"popup-confirmation-buttons"},[n("Button",{attrs:{cb:t.handlePopoutClose,type:"text"}},[t._v("Cancel")]),n("Button",{attrs:{cb:t.handlePopoutConfirm}},[t._v("Continue")])],1)]):t._e(),1===t.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("Transfer processing")]),n("p",{staticClass:"popup-description"},[t._v("The transfer may take ~1 min before the balance change is shown.")]),n("img",{staticClass:"spinner-icon",attrs:{src:s("9bfb")}})]):t._e(),2===t.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("Transfer "+t._s(t.transferRes))]),n("p",{staticClass:"popup-description hide"}),n("div",{},[n("Button",{attrs:{cb:t.handlePopoutClose}},[t._v("Done")])],1)]):t._e()])],1)},es=[],ss={name:"TransitionExpand",functional:!0,render:function(t,e){var s={props:{name:"expand"},on:{afterEnter:function(t){t.style.height="auto"},enter:function(t){var e=getComputedStyle(t),s=e.width;t.style.width=s,t.style.position="absolute",t.style.visibility="hidden",t.style.height="auto";var n=getComputedStyle(t),a=n.height;t.style.width=null,t.style.position=null,t.style.visibility=null,t.style.height=0,getComputedStyle(t).height,requestAnimationFrame((function(){t.style.height=a}))},leave:function(t){var e=getComputedStyle(t),s=e.height;t.style.height=s,getComputedStyle(t).height,requestAnimationFrame((function(){t.style.height=0}))}}};return t("transition",s,e.children)}},ns=ss,as=(s("fd06"),s("4f3a"),Object(d["a"])(ns,Jt,Qt,!1,null,"421010e3",null)),is=as.exports,os=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"dropdown",class:{active:t.isExpanded}},[s("div",{staticClass:"selected",on:{click:t.handleExpand}},[s("p",[t._v(t._s(t.selected))]),s("svg-icon",{staticClass:"icon",class:{"up-arrow":t.isExpanded},attrs:{icon:"down-arrow",hasFill:!0}})],1),t.isExpanded?s("div",{staticClass:"expanded-container"},t._l(t.options,(function(e,n){return s("div",{key:e,staticClass:"option-container",class:{first:0===n},on:{click:function(s){return t.handleOptionClick(e)}}},[t._v(" "+t._s(e)+" ")])})),0):t._e()])},rs=[],cs={name:"Dropdown",components:{"svg-icon":L},props:{placeholder:{type:String,required:!0},options:{type:Array,default:function(){return["test","test2"]}},parentId:{type:String,required:!0}},data:function(){return{selected:this.placeholder}},methods:{handleExpand:function(){this.$store.dispatch("updateDropdownSync",this.parentId)},handleOptionClick:function(t){this.selected=t,this.$store.dispatch("updateDropdownSync",null),this.$emit("selection",this.selected)}},computed:{isExpanded:function(){return this.$store.state.wallet.dropdownSync===this.parentId}}},ls=cs,us=(s("4051"),Object(d["a"])(ls,os,rs,!1,null,"46f6b090",null)),ds=us.exports,ps=function(){var t=this,e=t.$createElement,n=t._self._c||e;return t.isOpen?n("div",{staticClass:"popup-bg",class:{blur:this.blur}},[n("div",{staticClass:"popup-container"},[n("div",{staticClass:"close-container"},[t.closeable?n("img",{attrs:{src:s("bb7c")},on:{click:t.onClose}}):t._e()]),t._t("default")],2)]):t._e()},hs=[],fs={name:"Popup",components:{},props:{isOpen:{type:Boolean,default:!1},closeable:{type:Boolean,default:!1},blur:{type:Boolean,default:!1}},data:function(){return{}},methods:{onClose:function(){this.$emit("onClose",!1)}}},vs=fs,gs=(s("3016"),Object(d["a"])(vs,ps,hs,!1,null,"b0c565c8",null)),ms=gs.exports,Cs={name:"WalletBalanceTile",props:{title:{type:String,required:!0},tooltipText:{type:String,default:""},balance:{type:Number,required:!0,default:0},defaultTargetAddress:{type:String,required:!0},dropdownOptions:{type:Array,required:!0,default:function(){return["Other public address"]}},handleWalletTransfer:{type:Function,required:!0,default:function(){}},minTransferAmount:{type:Number,default:1e3},publicAddress:{type:String,default:""}},components:{Button:D,TransitionExpand:is,Dropdown:ds,"bt-input-box":W,Popup:ms},data:function(){return{isExpanded:!1,colors:_.a,amount:"",targetAddress:"",dropdownSelection:null,showPopup:!1,popupState:0,transferRes:"",showCopyTooltip:!1}},methods:{handleExpand:function(){this.isExpanded=!this.isExpanded,!1===this.isExpanded&&(this.dropdownSelection=null)},handleSend:function(){this.showPopup=!0},handlePopoutConfirm:function(){var t=this;this.popupState=1,this.handleWalletTransfer({amount:this.amount}).then((function(e){setTimeout((function(){t.transferRes=e,t.popupState=2,t.amount=""}),500)}))},handlePopoutClose:function(){this.showPopup=!1,this.popupState=0},handlePublicAddressCopy:function(){var t=this;navigator.clipboard.writeText(this.publicAddress),this.showCopyTooltip=!0,setTimeout((function(){t.showCopyTooltip=!1}),1e3)}},computed:{isAmountValid:function(){return this.amount>=this.minTransferAmount&&this.amount<=this.balance},isAmountEmpty:function(){return null==this.amount||""===this.amount},amountMessage:function(){var t="The minimum transfer is ".concat(this.minTransferAmount," BTT");return this.amount<this.minTransferAmount&&!this.isAmountEmpty&&(t="Please enter at least ".concat(this.minTransferAmount," BTT")),this.amount>this.balance&&!this.isAmountEmpty&&(t="You don't have enough balance to create this transfer."),t},amountMessageColor:function(){return this.isAmountValid?_.a.btfsBlue500:_.a.gray700},istargetAddressValid:function(){return"test"===this.targetAddress||this.targetAddress===this.defaultTargetAddress},istargetAddressEmpty:function(){return null==this.targetAddress||""===this.targetAddress},targetAddressMessage:function(){return this.istargetAddressValid||this.istargetAddressEmpty?"":"The public address doesn't exist"},isNumber:function(){return!isNaN(this.balance)},abbreviatedPublicAddress:function(){return this.publicAddress.slice(0,12)+"..."+this.publicAddress.slice(-10)}},watch:{dropdownSelection:function(){"Transfer to"!==this.dropdownSelection&&null!==this.dropdownSelection?this.targetAddress="Other public address"===this.dropdownSelection?"":this.defaultTargetAddress:this.targetAddress=""}}},bs=Cs,ys=(s("9fd0"),Object(d["a"])(bs,ts,es,!1,null,"61f8ce1c",null)),ws=ys.exports,Ss=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("vue-good-table",{attrs:{styleClass:"vgt-table striped",columns:t.columns,rows:t.rows,"sort-options":{enabled:!1}},scopedSlots:t._u([{key:"table-row",fn:function(e){return["created"===e.column.field?s("div",[s("span",[t._v(t._s(t.formatDate(e.row.created).date))]),t._v(", "),s("span",{staticClass:"time"},[t._v(t._s(t.formatDate(e.row.created).time))])]):"status"===e.column.field?[s("span",{style:t.statusStyle(e.row.status)},[t._v(t._s(t.capitalize(e.row.status)))])]:"to"===e.column.field?[t.isBase64(e.row.to)?s("div",{ref:"toRef"+e.row.id,attrs:{title:e.row.to}},[t._v(" "+t._s(e.row.to)+" ")]):s("div",[t._v(" "+t._s(e.row.to)+" ")])]:"from"===e.column.field?[t.isBase64(e.row.from)?s("div",{ref:"fromRef"+e.row.id,attrs:{title:e.row.from}},[t._v(" "+t._s(e.row.from)+" ")]):s("div",[t._v(" "+t._s(e.row.from)+" ")])]:s("span",[t._v(t._s(e.formattedRow[e.column.field])+" "),s("span",{staticClass:"btt-suffix"},[t._v("BTT")])])]}}])})},_s=[],xs=(s("a623"),s("07ac"),s("4d63"),s("25f0"),s("159b"),s("96cf"),s("1da1")),Ps=s("3835"),Bs=s("f617"),Ts=s("ed08"),Vs=(s("dac4"),s("c191"),{name:"bt-exchange-table",components:{"vue-good-table":Bs["a"]},props:{transactions:{type:Array,default:function(){return[{id:1,created:"1579547824",amount:200,type:"Deposit",status:"complete",message:""}]},validator:function(t){var e=["id","created","amount","type","status"];return t.every((function(t){return e.every((function(e){return e in t}))}))}}},data:function(){return{columns:[{label:"Date & Time",field:"created",width:"160px",tdClass:"table-date-cell"},{label:"Amount",field:"amount",type:"number",formatFn:this.formatAmount,tdClass:"ellipsis"},{label:"From",field:"from"},{label:"To",field:"to"},{label:"Status",field:"status",width:"100px",tdClass:"status-cell"}],rows:[]}},methods:{statusStyle:function(t){var e="";return e="success"===t.toLowerCase()?_.a.accentsGreen500:"pending"===t.toLowerCase()?_.a.accentsYellow500:_.a.accentsRed500,{color:e}},formatDate:function(t){var e=new Date(t),s={year:"numeric",month:"2-digit",day:"numeric",hour:"2-digit",minute:"2-digit",hour12:!1},n=e.toLocaleDateString(void 0,s).split(", "),a=Object(Ps["a"])(n,2),i=a[0],o=a[1];return{date:i,time:o}},formatAmount:function(t){var e={style:"decimal",minimumFractionDigits:0,maximumFractionDigits:6},s=t.toString();return Intl.NumberFormat("en-US",e).format(parseFloat(s))},capitalize:function(t){return t[0].toUpperCase()+t.slice(1)},updateRows:function(){this.rows=this.transactions.map((function(t){return t})).sort((function(t,e){return e.created-t.created})).slice(0,10)},resizeColumns:function(){Object.values(this.$refs).forEach((function(t){return t&&Ts["a"].ellipsifyText(t,5)}))},formatTable:function(){var t=this;return Object(xs["a"])(regeneratorRuntime.mark((function e(){return regeneratorRuntime.wrap((function(e){while(1)switch(e.prev=e.next){case 0:return t.updateRows(),e.next=3,t.$nextTick();case 3:t.resizeColumns();case 4:case"end":return e.stop()}}),e)})))()},isBase64:function(t){var e=new RegExp("^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{2}==|[A-Za-z0-9+/]{3}=)?$");return e.test(t)}},mounted:function(){this.$refs&&(this.formatTable(),window.addEventListener("resize",this.resizeColumns))},beforeDestroy:function(){window.removeEventListener("resize",this.resizeColumns)},watch:{transactions:function(){this.formatTable()}}}),Hs=Vs,ks=Object(d["a"])(Hs,Ss,_s,!1,null,"2c5402d4",null),Es=ks.exports,Ls=function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("Popup",{staticClass:"recovery-popup",attrs:{closeable:!0,blur:!1,isOpen:!0},on:{onClose:t.close}},[0===this.state?s("div",[s("div",{staticClass:"popup-header"},[t._v("Recovery")]),s("div",{staticClass:"popup-description"},[t._v("Enter your password and continue to the next page to be able to see the recovery information.")]),s("div",{staticClass:"password-container"},[s("InputBox",{attrs:{inputType:"password",placeholder:"Wallet password",showMessage:!0,borderColor:t.colors.btfsBlue500,passwordPlaceholderColor:t.colors.gray500,iconColor:t.colors.gray500,isInvalid:t.passwordInvalid,message:t.passwordMessage},model:{value:t.password,callback:function(e){t.password=e},expression:"password"}})],1),s("div",{staticClass:"button-container"},[s("Button",{attrs:{cb:t.handleCheckPassword,isDisabled:t.passwordInvalid}},[t._v("Continue")])],1)]):t._e(),1===this.state?s("div",[s("div",{staticClass:"popup-header"},[t._v("Important message")]),s("div",{staticClass:"popup-description popup-description-1"},[t._v("The recovery information is unique and only you should have access. We encourage you to write these words down and keep them in a safe place. Avoid saving it in a computer or online service, and do not take a screenshot of it. If you lose it or share it with others, you may lose your assets.")]),s("div",{staticClass:"text-sm"},[t._v(" Looking for more information? Read our "),s("a",{attrs:{href:"https://www.bittorrent.com/token/bittorrent-file-system/faq/",target:"_blank",rel:"noreferrer noopener"}},[t._v("FAQs")])]),s("div",{staticClass:"text-sm tos-container"},[s("bt-checkbox",{on:{change:function(e){t.hasAgreed=e}},scopedSlots:t._u([{key:"right",fn:function(){return[t._v(" I agree to the terms and conditions of BTFS. ")]},proxy:!0}],null,!1,3282184752)})],1),s("div",{staticClass:"button-container"},[s("Button",{attrs:{cb:t.nextStep,isDisabled:!t.hasAgreed}},[t._v("Continue")])],1)]):t._e(),2===this.state?s("div",[s("div",{staticClass:"subtitle"},[t._v(" Recovery phrase ")]),s("div",{staticClass:"recovery-phrase-container"},[t.showRecoveryPhrase?t._e():s("div",{staticClass:"light-container"},[s("p",[t._v("Not available")]),s("br"),s("a",{attrs:{href:"https://docs.btfs.io/docs/faq",target:"_blank"}},[t._v("Click here to learn more")])]),t.showRecoveryPhrase?s("div",[s("div",{staticClass:"recovery-right-container"},[s("div",{staticClass:"recovery-answer-key-container"},t._l(t.mnemonic,(function(e,n){return s("div",{key:n,staticClass:"recovery-answer-key"},[t._v(t._s(e)+" ")])})),0)]),s("div",{staticClass:"button-container copy-recovery-phrase"},[s("Button",{attrs:{type:"copy",copyValue:t.mnemonic.join(" ")}},[t._v("Copy recovery phrase to clipboard")])],1)]):t._e()]),s("div",{staticClass:"subtitle"},[t._v(" Private Key ")]),s("div",{staticClass:"private-key-container"},[s("div",{staticClass:"light-container"},[t._v(" "+t._s(t.privateKey)+" ")]),s("div",{staticClass:"button-container copy-private-key"},[s("Button",{attrs:{type:"copy",copyValue:t.privateKey}},[t._v("Copy private key to clipboard")])],1)])]):t._e()])},Is=[],Os={name:"RecoveryInformation",components:{Popup:ms,InputBox:W,Button:D},props:{close:{type:Function,deafult:function(){}},checkPassword:{type:Function,default:function(){}}},data:function(){return{state:0,colors:_.a,password:null,passwordInvalid:!1,mnemonic:"",privateKey:"",hasAgreed:!1}},computed:{passwordMessage:function(){return this.passwordInvalid?"The password is incorrect":""},showRecoveryPhrase:function(){return Array.isArray(this.mnemonic)&&""!==this.mnemonic[0]}},methods:{nextStep:function(){this.state=this.state+=1,this.state>=3&&(this.close(),this.state=0)},handleCheckPassword:function(){var t=this;this.checkPassword({password:this.password}).then((function(e){t.mnemonic=e.mnemonic.split(" "),t.privateKey=e.privateKey,t.nextStep()})).catch((function(){t.passwordInvalid=!0}))}},watch:{password:function(){this.passwordInvalid&&(this.passwordInvalid=!1)}}},Fs=Os,Ds=(s("c833"),Object(d["a"])(Fs,Ls,Is,!1,null,"602cbda9",null)),As=Ds.exports,Ms={name:"WalletDashboard",props:{id:{type:String,required:!0},tronAddress:{type:String,required:!0},handleRefresh:{type:Function,default:function(){}},transactions:{type:Array,default:function(){return[{id:1,created:"1579547824",amount:200,type:"Deposit",status:"complete"}]}},handleDeposit:{type:Function,default:function(){}},handleWithdraw:{type:Function,default:function(){}},inAppBalance:{type:Number,required:!0,default:0},cryptoBalance:{type:Number,required:!0,default:0},checkPassword:{type:Function,default:function(){}}},components:{Button:D,WalletBalanceTile:ws,"bt-exchange-table":Es,RecoveryInformation:As},methods:{paginationLeft:function(){var t=this.pageIndex-1;t>=0&&(this.pageIndex=t)},paginationRight:function(){var t=this.pageIndex+1;t<this.transactionsLength/this.pageSize&&(this.pageIndex=t)},onRefresh:function(){this.handleRefresh()},toggleRecoveryInformation:function(){this.showRecoveryInformation=!this.showRecoveryInformation},handleSignIn:function(){var t=this;this.checkPassword({password:this.password}).then((function(){t.$store.dispatch("walletAuth",t.password)})).catch((function(){t.passwordInvalid=!0}))}},data:function(){return{pageIndex:0,pageSize:10,showRecoveryInformation:!1,password:null,passwordInvalid:!1}},computed:{transactionsLength:function(){return this.transactions.length},pageItemStart:function(){return this.pageIndex*this.pageSize},pageItemEnd:function(){var t=this.pageItemStart+this.pageSize;return t>this.transactionsLength?this.transactionsLength:t},visibleTransactions:function(){return this.transactions.slice(this.pageItemStart,this.pageItemEnd)},isAuth:function(){return null!==this.$store.state.wallet.auth},passwordMessage:function(){return this.passwordInvalid?"Incorrent password":""}},watch:{password:function(){this.passwordInvalid=!1}},mounted:function(){this.handleRefresh()}},$s=Ms,Ns=(s("5dd7"),Object(d["a"])($s,Qe,Xe,!1,null,"0acb481e",null)),zs=Ns.exports,js=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"wallet-import"},[n("StepIndicator",{staticClass:"step-container",attrs:{steps:t.steps,current:t.current}}),1===t.current?n("div",{staticClass:"container"},[t._m(0),n("div",{staticClass:"nav"},t._l(t.navList,(function(e){return n("div",{key:e,staticClass:"nav-item",class:{"nav-item-selected":e===t.selectedNavItem},on:{click:function(){t.handleNavClick(e)}}},[t._v(" "+t._s(e)+" ")])})),0),n("div",{staticClass:"text-container"},[n("InputBox",{attrs:{inputType:"textarea",borderColor:t.colors.btfsBlue500,placeholderColor:t.colors.gray800,invalidColor:t.colors.btfsRed,placeholder:"recovery phrase"===this.selectedNavItem?"12 words in order separated by a single space (ex: word word word...)":"",delayEmit:!0,isInvalid:!t.isValidInput&&!t.isInputEmpty,message:t.inputMessage,showMessage:!0},model:{value:t.input,callback:function(e){t.input=e},expression:"input"}})],1)]):t._e(),2===t.current?n("div",{staticClass:"container"},[t._m(1),n("PasswordCreate",{on:{passwordsMatch:function(e){t.passwordsMatch=e},password:function(e){t.password=e}}})],1):t._e(),t._m(2),n("div",{staticClass:"button-container"},[n("router-link",{attrs:{to:"/wallet/setup"}},[n("Button",{attrs:{type:"text"}},[t._v("Create instead")])],1),n("Button",{attrs:{cb:t.handleContinue,isDisabled:!t.canContinue}},[t._v("Continue")])],1),n("Popup",{attrs:{isOpen:t.showPopup},on:{onClose:t.handlePopoutClose}},[0===this.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("Importing...")]),n("p",{staticClass:"popup-description"},[t._v("This transfer may take up to a minute.")]),n("img",{staticClass:"spinner-icon",attrs:{src:s("9bfb")}})]):t._e(),1===this.popupState?n("div",{staticClass:"text-center"},[n("p",{staticClass:"popup-header"},[t._v("System Error")]),n("p",{staticClass:"popup-description"},[t._v("Please try again later")]),n("div",{staticClass:"button-container center"},[n("Button",{attrs:{cb:t.handlePopoutClose}},[t._v("Continue")])],1)]):t._e()])],1)},Rs=[function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Import Wallet")]),s("p",{staticClass:"description"},[t._v(" You are about to input highly sensitive information, please DO NOT expose to strangers. ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"header-container"},[s("p",{staticClass:"header"},[t._v("Wallet password")]),s("p",{staticClass:"description"},[t._v(" Create a strong password and save it in a safe place. We do not have access to your password and will not be able to restore it. Losing your password may result in losing access to your funds. ")])])},function(){var t=this,e=t.$createElement,s=t._self._c||e;return s("div",{staticClass:"text-container"},[s("p",{staticClass:"text-sm"},[t._v(" By clicking “Continue” you agree to the "),s("a",{attrs:{href:"https://www.bittorrent.com/legal/terms-of-use/",target:"_blank",rel:"noreferrer noopener"}},[t._v("Terms of Service")]),t._v(" and "),s("a",{attrs:{href:"https://www.bittorrent.com/legal/privacy-policy/",target:"_blank",rel:"noreferrer noopener"}},[t._v("Privacy Policy")]),t._v(". ")])])}],Ws=(s("498a"),{name:"WalletImport",props:{setPassword:{type:Function,default:function(){}},importWallet:{type:Function,default:function(){}},setInitializationFlag:{type:Function,default:function(){}}},components:{Button:D,InputBox:W,StepIndicator:Ee,PasswordCreate:$e,Popup:ms},methods:{handleNavClick:function(t){this.selectedNavItem=t,this.input=null},nextStep:function(){this.current+=1},handlePopoutClose:function(){this.showPopup=!1,this.popupState=0},handleImportWallet:function(){var t=this;this.showPopup=!0;var e="private key"===this.selectedNavItem?"p":"m";this.importWallet({type:e,value:this.input}).then((function(){t.nextStep(),t.handlePopoutClose()})).catch((function(e){console.log(e),"private key"===t.selectedNavItem?(t.handlePopoutClose(),t.invalidPrivateKey=!0):t.popupState=1}))},handleSetPassword:function(){var t=this;this.setPassword({password:this.password}).then((function(){return t.setInitializationFlag()})).then((function(){t.$router.push("/wallet")}))},handleContinue:function(){switch(this.current){case 1:this.handleImportWallet();break;case 2:this.handleSetPassword();break}}},data:function(){return{colors:_.a,navList:["recovery phrase","private key"],selectedNavItem:"recovery phrase",steps:["1","2"],current:1,showPopup:!1,popupState:0,input:null,password:"",passwordsMatch:!1,invalidPrivateKey:!1}},computed:{isValidInput:function(){if("recovery phrase"===this.selectedNavItem){if(null===this.input)return!1;var t=this.input.trim().split(" ");return 12===t.length}return null!==this.input&&(!0!==this.invalidPrivateKey&&(48===this.input.length||64===this.input.length))},isInputEmpty:function(){return null===this.input},inputMessage:function(){return this.isInputEmpty||this.isValidInput?"":"private key"===this.selectedNavItem&&this.invalidPrivateKey?"The recovery phrase sequence is incorrect.":"recovery phrase"===this.selectedNavItem?"The recovery phrase must be 12 words":"The private key must be 48 or 64 characters"},canContinue:function(){return 1===this.current?this.isValidInput:this.passwordsMatch}},watch:{input:function(){this.invalidPrivateKey=!1}}}),Zs=Ws,qs=(s("ac0b"),Object(d["a"])(Zs,js,Rs,!1,null,"e2a011d6",null)),Us=qs.exports,Gs=(s("b64b"),s("99af"),s("c975"),s("a434"),s("53ca")),Ks="text/plain",Ys="application/json",Js={protocol:window.location.protocol.slice(0,-1),domain:window.location.hostname,port:void 0,base:"api",token:void 0,endpoints:{"v1/config":{post:{contentType:Ys,params:{arg:String,json:Boolean},defaultParams:{arg:"UI.Host.Initialized",json:!0}}},"v1/storage/announce":{post:{contentType:Ys,params:{"host-storage-max":Number,"host-storage-price":Number},defaultParams:{"host-storage-max":32,"host-storage-price":25e4}}},"v1/storage/info":{post:{contentType:Ks}},"v1/storage/stats/info":{post:{contentType:Ks,params:{l:Boolean},defaultParams:{l:!1}}},"v1/storage/stats/sync":{post:{contentType:Ks}},"v1/id":{post:{contentType:Ks}},"v1/version":{post:{contentType:Ks}},"v1/repo/stat":{post:{contentType:Ks,params:{human:Boolean},defaultParams:{human:!0}}},"v1/storage/contracts/stat":{post:{contentType:Ks,params:{arg:String},defaultParams:{arg:"host"}}},"v1/storage/contracts/sync":{post:{contentType:Ks,params:{arg:String},defaultParams:{arg:"host"}}},"v1/storage/contracts/list":{post:{contentType:Ks,params:{arg:String,order:String,status:String,size:Number},defaultParams:{arg:"host",order:"escrow_time,asc",status:"active",size:20}}},"v1/storage/path":{post:{contentType:Ys,params:{arg:String},defaultParams:{arg:""}}},"v1/storage/path/status":{post:{contentType:Ys}},"v1/storage/path/capacity":{post:{contentType:Ys,params:{arg:String},defaultParams:{arg:""}}},"v1/wallet/deposit":{post:{contentType:Ys,params:{arg:Number,a:String,password:String},defaultParams:{arg:"",a:"",password:""}}},"v1/wallet/withdraw":{post:{contentType:Ys,params:{arg:Number,password:String},defaultParams:{arg:"",password:""}}},"v1/wallet/balance":{post:{contentType:Ys}},"v1/wallet/password":{post:{contentType:Ys,params:{arg:String},defaultParams:{arg:""}}},"v1/wallet/keys":{post:{contentType:Ys}},"v1/wallet/transactions":{post:{contentType:Ys}},"v1/wallet/import":{post:{contentType:Ys,params:{p:String,m:String},defaultParams:{p:"",m:""}}}}},Qs={setToken:function(t,e){n["a"].set(t,"token",e)}};function Xs(t){var e=t.protocol,s=t.domain,n=t.port,a=t.base,i=t.paramsList,o=t.endpoint,r=t.params,c=Js.token?"?t=".concat(Js.token):"?";return i&&i.forEach((function(t){"?"!==c?null!==r[t]&&(c+="&".concat(t,"=").concat(r[t])):c+="".concat(t,"=").concat(r[t])})),"".concat(e,"://").concat(s,":").concat(n,"/").concat(a,"/").concat(o).concat(c)}function tn(t){var e,s,n=t.endpoint,a=t.type,i=void 0===a?"":a,o=t.method,r=void 0===o?"":o,c=t.body,l=t.params,u=void 0===l?{}:l,d=(i||r).toLowerCase(),p=Js.endpoints[n];if(!p||!p[d])throw e=new Error("Api method <".concat(d,"> doesn't exist for endpoint <").concat(n,">")),e.name="request",e;if(p[d].params&&(s=Object.keys(p[d].params),s.forEach((function(t){if(null===u[t]){var a=s.indexOf(t);a>-1&&s.splice(a,1)}if(!u[t]&&p[d].defaultParams[t]&&(u[t]=p[d].defaultParams[t]),Object(Gs["a"])(u[t])!==Object(Gs["a"])(p[d].params[t]()))throw e=new Error("Invalid param type <".concat(Object(Gs["a"])(u[t]),"> for @").concat(t," <").concat(Object(Gs["a"])(p[d].params[t]()),">")),e.name="request: endpoint ".concat(n,":").concat(d),e}))),p[d].body&&Object(Gs["a"])(c)!==Object(Gs["a"])(p[d].body()))throw e=new Error("Invalid body type <".concat(Object(Gs["a"])(c),"> expected @body <").concat(Object(Gs["a"])(p[d].body()),">")),e.name="request: endpoint ".concat(n,":").concat(d),e;var h=Js.protocol,f=Js.domain,v=Js.port,g=Js.base,m=Xs({protocol:h,domain:f,port:v,base:g,endpoint:n,paramsList:s,params:u}),C={method:d.toUpperCase(),headers:{"Content-Type":p[d].contentType}};return c&&(C.body=c),new Promise(function(){var t=Object(xs["a"])(regeneratorRuntime.mark((function t(e,s){var n,a;return regeneratorRuntime.wrap((function(t){while(1)switch(t.prev=t.next){case 0:return t.prev=0,t.next=3,fetch(m,C);case 3:if(n=t.sent,403!==n.status){t.next=6;break}throw"403";case 6:return t.next=8,n.text();case 8:a=t.sent,e(a),t.next=15;break;case 12:t.prev=12,t.t0=t["catch"](0),s(t.t0);case 15:case"end":return t.stop()}}),t,null,[[0,12]])})));return function(e,s){return t.apply(this,arguments)}}())}var en={state:Js,mutations:Qs,request:tn},sn=(s("5319"),en.request),nn={info:{storage_price_ask:7680},value:{host_stats:{online:!1,uptime:0,score:0,storage_used:0,storage_cap:0,storage_disk_total:0,storage_disk_available:0},renter_stats:{}},contracts:{active_contract_num:0,compensation_paid:0,compensation_outstanding:0,first_contract_start:"0001-01-01T00:00:00Z",last_contract_end:"0001-01-01T00:00:00Z",role:0},contractsList:{contracts:[]},setup:{path:null,size:null},isSynced:!1,isSyncedLocal:!1,isImporting:!1,proposedStorageAvailable:null,isStorageLoading:!0},an={setInfo:function(t,e){n["a"].set(t,"info",e)},setStatsInfo:function(t,e){n["a"].set(t,"value",e)},setContractsInfo:function(t,e){var s=e;s.compensation_outstanding=s.compensation_outstanding/1e6,s.compensation_paid=s.compensation_paid/1e6,n["a"].set(t,"contracts",s)},setContractsList:function(t,e){n["a"].set(t,"contractsList",e)},setSetupValues:function(t,e){n["a"].set(t,"setup",Object(o["a"])(Object(o["a"])({},t.setup),e))},setIsSynced:function(t,e){n["a"].set(t,"isSynced",e)},setIsSyncedLocal:function(t,e){n["a"].set(t,"isSyncedLocal",e)},setIsStorageLoading:function(t,e){n["a"].set(t,"isStorageLoading",e)},setIsImporting:function(t,e){n["a"].set(t,"isImporting",e)},setProposedStorageAvailable:function(t,e){n["a"].set(t,"proposedStorageAvailable",e)}},on={statsInfo:function(t){return t.value},contractInfo:function(t){return t.value}},rn={storageInfo:function(t){return sn({endpoint:"v1/storage/info",method:"POST"}).then((function(e){var s=JSON.parse(e),n=Math.round(s.storage_price_ask/1e6*30*1024);s.storage_price_ask=n,t.commit("setInfo",s)}))},storageStatsInfo:function(t){var e=!t.rootState.config.host.initialized,s={endpoint:"v1/storage/stats/info",method:"POST"};return s.params={l:e},sn(s).then((function(s){var n=JSON.parse(s);n.host_stats&&(t.commit("setStatsInfo",n),e||t.commit("setIsSyncedLocal",!0)),t.commit("setIsStorageLoading",!1)}))},storageStatsSync:function(){return sn({endpoint:"v1/storage/stats/sync",method:"POST"}).then((function(t){return""===t}))},storageAnnounce:function(t,e){return sn({endpoint:"v1/storage/announce",method:"POST",params:{"host-storage-price":null,"host-storage-max":e["hostStorageMax"]}}).then((function(){return t.dispatch("storageStatsInfo")}))},storageAnnouncePrice:function(t,e){var s=Math.round(1e6*e["hostStoragePrice"]/30/1024);return sn({endpoint:"v1/storage/announce",method:"POST",params:{"host-storage-price":s,"host-storage-max":null}}).then((function(){return t.dispatch("storageInfo")}))},storageContractsInfo:function(t){return sn({endpoint:"v1/storage/contracts/stat",method:"POST",params:{role:"host"}}).then((function(e){t.commit("setContractsInfo",JSON.parse(e))}))},storageContractsSync:function(){return sn({endpoint:"v1/storage/contracts/sync",method:"POST",params:{role:"host"}}).then((function(t){return""===t}))},storageContractsList:function(t){return sn({endpoint:"v1/storage/contracts/list",method:"POST",params:{role:"host",order:"escrow_time,asc",status:"active",size:20}}).then((function(e){t.commit("setContractsList",JSON.parse(e))}))},appReset:function(){return new Promise((function(t){var e=setInterval((function(){sn({endpoint:"v1/storage/path/status",method:"POST"}).then((function(){return clearInterval(e),t()})).catch((function(){}))}),2e3)}))},storagePath:function(t,e){return!0!==e.suppressNotification?t.dispatch("notificationInProgress","host"):t.commit("setIsImporting",!0),sn({endpoint:"v1/storage/path",method:"POST",params:{arg:"".concat(e.path,"&arg=").concat(e.size)}}).then((function(){return s(t,e.suppressNotification)})).catch((function(){return s(t,e.suppressNotification)}));function s(t,e){return t.dispatch("appReset").then((function(){t.dispatch("metaRepoStat"),!0!==e?t.dispatch("notificationComplete","host"):t.commit("setIsImporting",!1)}))}},storagePathCapacity:function(t,e){return sn({endpoint:"v1/storage/path/capacity",method:"POST",params:{arg:e.path}}).then((function(s){var n=JSON.parse(s),a=cn(n.FreeSpace);if("error"===n.Type)return Promise.reject(n.Message);if(a<32||!n.Valid){var i=n.Valid?"You do not have enough space on your hard drive":"".concat(e.path.replace(/\/+$/,""),"/.btfs is not empty");return Promise.reject(i)}return t.dispatch("updateProposedStorageAvailable",n.FreeSpace),Promise.resolve("[storagePathCapacity] pass")})).catch((function(t){return Promise.reject(t)}))},updateHostSetupValues:function(t,e){t.commit("setSetupValues",e)},updateIsSynced:function(t,e){t.commit("setIsSynced",e)},updateProposedStorageAvailable:function(t,e){t.commit("setProposedStorageAvailable",e)}};function cn(t){var e=t/Math.pow(1e3,3);return e=Math.floor(e),e}var ln={state:nn,getters:on,mutations:an,actions:rn},un=en.request,dn={value:{},id:{ID:"",PublicKey:"",TronAddress:""}},pn={setRepoStat:function(t,e){n["a"].set(t,"value",e)},setId:function(t,e){n["a"].set(t,"id",e)},setVersion:function(t,e){n["a"].set(t,"version",e)}},hn={repoStat:function(t){return t.value}},fn={metaRepoStat:function(t){return un({endpoint:"v1/repo/stat",method:"POST",params:{human:!0}}).then((function(e){t.commit("setRepoStat",JSON.parse(e))}))},metaId:function(t){return un({endpoint:"v1/id",method:"POST"}).then((function(e){t.commit("setId",JSON.parse(e))}))},metaVersion:function(t){return un({endpoint:"v1/version",method:"POST"}).then((function(e){t.commit("setVersion",JSON.parse(e))}))}},vn={state:dn,getters:hn,mutations:pn,actions:fn},gn=en.request,mn={host:{initialized:!1},wallet:{initialized:!1},hostEnabled:{value:!0}},Cn={setHostConfig:function(t,e){n["a"].set(t,"host",{initialized:e.Value})},setWalletConfig:function(t,e){n["a"].set(t,"wallet",{initialized:e.Value})},setStorageHostEnabled:function(t,e){n["a"].set(t,"hostEnabled",{value:e.Value})}},bn={config:function(t){return t}},yn={getHostConfig:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"UI.Host.Initialized"}}).then((function(e){t.commit("setHostConfig",JSON.parse(e))}))},setHostConfig:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"UI.Host.Initialized&arg=true"}}).then((function(){return t.dispatch("getHostConfig")}))},getWalletConfig:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"UI.Wallet.Initialized"}}).then((function(e){t.commit("setWalletConfig",JSON.parse(e))}))},setWalletConfig:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"UI.Wallet.Initialized&arg=true"}}).then((function(){return t.dispatch("getWalletConfig")}))},getStorageHostEnabled:function(t){return gn({endpoint:"v1/config",method:"POST",params:{arg:"Experimental.StorageHostEnabled"}}).then((function(e){t.commit("setStorageHostEnabled",JSON.parse(e))}))}},wn={state:mn,getters:bn,mutations:Cn,actions:yn},Sn=en.request,_n={balance:{BtfsWalletBalance:NaN,BttWalletBalance:NaN},transactions:[],keys:{PrivateKey:"",Mnemonic:[]},dropdownSync:null,auth:null},xn={setBalance:function(t,e){var s={BtfsWalletBalance:e.BtfsWalletBalance/1e6,BttWalletBalance:e.BttWalletBalance/1e6};n["a"].set(t,"balance",s)},setKeys:function(t,e){var s=e.res.Mnemonic,a=""===s?[]:s.split(" "),i={PrivateKey:e.res.PrivateKey,Mnemonic:a};n["a"].set(t,"keys",i)},setTransactions:function(t,e){var s=e.map((function(t,e){var s=new Date(t.time_create);return{id:e,created:s,amount:t.amount/1e6,type:"Deposit",status:t.status,from:t.from,to:t.to}}));s.sort((function(t,e){return new Date(e.created)-new Date(t.created)})),n["a"].set(t,"transactions",s)},setDropdownSync:function(t,e){n["a"].set(t,"dropdownSync",e)},setAuth:function(t,e){n["a"].set(t,"auth",e)}},Pn={balance:function(t){return t.balance},recoveryPhrase:function(t){return t.recoveryPhrase},transactions:function(t){return t.transactions},dropdownSync:function(t){return t.dropdownSync},auth:function(t){return t.auth}},Bn={walletBalance:function(t){return Sn({endpoint:"v1/wallet/balance",method:"POST"}).then((function(e){var s=JSON.parse(e);t.commit("setBalance",s)}))},walletPassword:function(t,e){return Sn({endpoint:"v1/wallet/password",method:"POST",params:{arg:e.password}})},walletKeys:function(t,e){return Sn({endpoint:"v1/wallet/keys",method:"POST"}).then((function(s){t.commit("setKeys",{res:JSON.parse(s),password:e.password})}))},walletTransactions:function(t){return Sn({endpoint:"v1/wallet/transactions",method:"POST"}).then((function(e){var s=JSON.parse(e);t.commit("setTransactions",s.Transactions)}))},walletDeposit:function(t,e){return Sn({endpoint:"v1/wallet/deposit",method:"POST",params:{arg:1e6*e.amount,a:"",password:t.state.auth}}).then((function(e){t.dispatch("walletTransactions"),t.dispatch("walletBalance");var s=JSON.parse(e);return"Type"in s&&"error"===s.Type?"failed":"success"}))},walletWithdraw:function(t,e){return Sn({endpoint:"v1/wallet/withdraw",method:"POST",params:{arg:1e6*e.amount,password:t.state.auth}}).then((function(e){t.dispatch("walletTransactions"),t.dispatch("walletBalance");var s=JSON.parse(e);return"Type"in s&&"error"===s.Type?"failed":"success"}))},walletCheckPassword:function(t,e){return Sn({endpoint:"v1/wallet/keys",method:"POST"}).then((function(t){var s=JSON.parse(t),n=Ts["a"].decrypt_with_aes(e.password,s.Mnemonic),a=Ts["a"].decrypt_with_aes(e.password,s.PrivateKey);return{mnemonic:n,privateKey:a}})).catch((function(){return Promise.reject()}))},walletImport:function(t,e){var s="p"===e.type?{p:e.value,m:""}:{p:"",m:e.value.replace(/\s+/g,"+").toLowerCase()};return Sn({endpoint:"v1/wallet/import",method:"POST",params:s}).then((function(e){if(""!==e){var s=JSON.parse(e);if("Type"in s&&"error"===s.Type)return Promise.reject()}return t.dispatch("appReset")})).catch((function(){return Promise.reject()}))},updateDropdownSync:function(t,e){var s=t.state.dropdownSync;s===e?t.commit("setDropdownSync",null):t.commit("setDropdownSync",e)},walletAuth:function(t,e){t.commit("setAuth",e)}},Tn={state:_n,getters:Pn,mutations:xn,actions:Bn},Vn={host:"",settings:""},Hn={setHost:function(t,e){n["a"].set(t,e.page,e.state)}},kn={notification:function(t){return t}},En={notificationInProgress:function(t,e){t.commit("setHost",{page:e,state:"in-progress"})},notificationComplete:function(t,e){t.commit("setHost",{page:e,state:"complete"})},notificationClose:function(t,e){t.commit("setHost",{page:e,state:""})}},Ln={state:Vn,getters:kn,mutations:Hn,actions:En},In={storage:ln,meta:vn,config:wn,wallet:Tn,notification:Ln},On={modules:In,install:function(t,e){var s=e.port,n=e.token;en.state.port=s,en.state.token=n,Object.keys(In).forEach((function(e){t.registerModule(e,In[e])}))}},Fn=s("2f62"),Dn=s("e37d"),An=s("0284"),Mn=s.n(An),$n=s("bc3a"),Nn=s.n($n),zn=s("a7fe"),jn=s.n(zn);s("4bb6"),s("03c7"),s("ea40");n["a"].config.productionTip=!1,n["a"].use(b["a"]),n["a"].use(Fn["a"]),n["a"].use(Dn["a"]);var Rn=[{name:"HostAdd",path:"/host/add",component:Q},{name:"HostSetup",path:"/host/setup",component:kt},{name:"HostDashboard",path:"/host",component:de},{name:"Settings",path:"/settings",component:me},{name:"WalletAdd",path:"/wallet/add",component:_e},{name:"WalletSetup",path:"/wallet/setup",component:Je},{name:"WalletDashboard",path:"/wallet",component:zs},{name:"WalletImport",path:"/wallet/import",component:Us}],Wn=new b["a"]({routes:Rn}),Zn=location.port,qn=new Fn["a"].Store({strict:!1});On.install(qn,{port:Zn}),n["a"].use(Mn.a,{id:"UA-747528-14",router:Wn}),n["a"].use(jn.a,Nn.a),new n["a"]({router:Wn,store:qn,render:function(t){return t(C)}}).$mount("#app")},"5c0b":function(t,e,s){"use strict";var n=s("a90f"),a=s.n(n);a.a},"5d63":function(t,e){t.exports='<svg width="19" height="22" viewBox="0 0 19 22" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M14 0H2C0.9 0 0 0.9 0 2V16H2V2H14V0ZM17 4H6C4.9 4 4 4.9 4 6V20C4 21.1 4.9 22 6 22H17C18.1 22 19 21.1 19 20V6C19 4.9 18.1 4 17 4ZM6 20H17V6H6V20Z" fill="#9E9E9E"/>\n</svg>\n'},"5dd7":function(t,e,s){"use strict";var n=s("e11e"),a=s.n(n);a.a},6:function(t,e){},"651b":function(t,e,s){},"674b":function(t,e,s){},"68ae":function(t,e,s){},"6eaf":function(t,e){t.exports='<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" fill="none" viewBox="0 0 24 24">\n    <path fill="#7D5AE6" d="M12 4C7 4 2.73 7.11 1 11.5 2.73 15.89 7 19 12 19s9.27-3.11 11-7.5C21.27 7.11 17 4 12 4zm0 12.5c-2.76 0-5-2.24-5-5s2.24-5 5-5 5 2.24 5 5-2.24 5-5 5zm0-8c-1.66 0-3 1.34-3 3s1.34 3 3 3 3-1.34 3-3-1.34-3-3-3z"/>\n</svg>\n'},"6faf":function(t,e,s){},7:function(t,e){},"794c":function(t,e){t.exports='<svg width="46" height="46" viewBox="0 0 46 46" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path d="M16.7242 7.93709C16.4543 7.28941 16.7596 6.54089 17.425 6.31848C20.7299 5.21394 24.2988 5.11311 27.6763 6.04465C31.4633 7.08911 34.7916 9.37127 37.1306 12.5273C39.4697 15.6834 40.6848 19.5316 40.5824 23.4586C40.48 27.3857 39.0658 31.1653 36.5654 34.1951C34.065 37.225 30.6222 39.3305 26.786 40.1761C22.9497 41.0217 18.9408 40.5587 15.3983 38.8609C11.8558 37.1631 8.98368 34.3282 7.23974 30.8082C5.68434 27.6688 5.10624 24.1455 5.56373 20.6911C5.65585 19.9955 6.33287 19.5539 7.01998 19.6959C7.7071 19.838 8.14327 20.5101 8.05964 21.2067C7.71188 24.1036 8.21313 27.0494 9.51651 29.6802C11.0085 32.6917 13.4658 35.117 16.4965 36.5696C19.5272 38.0221 22.957 38.4182 26.2391 37.6948C29.5211 36.9713 32.4665 35.17 34.6057 32.5779C36.7449 29.9857 37.9547 26.7521 38.0424 23.3924C38.13 20.0327 37.0904 16.7404 35.0893 14.0402C33.0881 11.3401 30.2406 9.38765 27.0008 8.49407C24.1705 7.71347 21.1831 7.7802 18.4048 8.67125C17.7366 8.88552 16.994 8.58477 16.7242 7.93709Z" fill="url(#paint0_angular)"/>\n<defs>\n<radialGradient id="paint0_angular" cx="0" cy="0" r="1" gradientUnits="userSpaceOnUse" gradientTransform="translate(23 23) rotate(-176.532) scale(21.1448)">\n<stop offset="0.0215903" stop-color="#EEF3FE"/>\n<stop offset="0.166667" stop-color="#3E7EFF"/>\n<stop offset="0.764875" stop-color="#31529E"/>\n</radialGradient>\n</defs>\n</svg>\n'},"7a16":function(t,e,s){"use strict";var n=s("eaa6"),a=s.n(n);a.a},8:function(t,e){},"852b":function(t,e,s){"use strict";var n=s("ffe3"),a=s.n(n);a.a},"85d8":function(t,e,s){"use strict";var n=s("ac39"),a=s.n(n);a.a},8700:function(t,e,s){"use strict";var n=s("f819"),a=s.n(n);a.a},8979:function(t,e,s){},"8a52":function(t,e,s){"use strict";var n=s("8c7e"),a=s.n(n);a.a},"8a65":function(t,e,s){},"8c04":function(t,e,s){"use strict";var n=s("68ae"),a=s.n(n);a.a},"8c7e":function(t,e,s){},"8ea4":function(t,e,s){},"8ff8":function(t,e,s){},9:function(t,e){},"91b4":function(t,e,s){"use strict";var n=s("939b"),a=s.n(n);a.a},"939b":function(t,e,s){},"94b9":function(t,e,s){var n={"./access-time.svg":"7017","./add-circle-outline.svg":"f222","./add.svg":"045d","./arrow-back.svg":"048d","./arrow-downward.svg":"960b","./arrow-drop-down.svg":"7c90","./arrow-drop-up.svg":"4ddf","./arrow-forward.svg":"d70d","./arrow-left.svg":"dbdb","./arrow-right.svg":"2f6a","./arrow-upward.svg":"4b27","./autorenew.svg":"cad8","./bittorrent.svg":"2a66","./btfs.svg":"83f1","./btt-speed-color.svg":"b1a6","./check-box-outline-blank.svg":"d9b2","./check-box.svg":"daed","./check-circle-outline.svg":"3d20","./chevron-left.svg":"9aa2","./chevron-right.svg":"9203","./close.svg":"20bd","./closed-caption.svg":"7a23","./cloud-download.svg":"4572","./cloud-upload.svg":"5434","./cloud.svg":"4c0f","./copy.svg":"9548","./dashboard.svg":"66f8","./delete.svg":"bd63","./devices.svg":"9ca7","./dlive.svg":"73ed","./dns.svg":"cba7","./download.svg":"0443","./edit.svg":"41af","./error-outline.svg":"baa1","./exchange.svg":"b1df","./exit-to-app.svg":"877e","./expand-less.svg":"1d9d","./expand-more.svg":"b619","./fast-forward.svg":"1f01","./fast-rewind.svg":"d1d6","./folder-open.svg":"3d43","./fullscreen-exit.svg":"c1ac","./fullscreen.svg":"1f39","./golden-coin.svg":"51b4","./help-outline.svg":"22ec","./highlight-off.svg":"69f5","./import-export.svg":"02a8","./import.svg":"ccd3","./indeterminate-check-box.svg":"b2f2","./infinity.svg":"6ddd","./info-outline.svg":"b0a6","./insert-drive-file.svg":"ebd8","./label.svg":"3caa","./learnmore.svg":"c803","./link.svg":"691c","./list.svg":"0d70","./loading.svg":"947f","./mail.svg":"5f8b","./menu.svg":"360e","./message.svg":"5464","./more-horiz.svg":"69a2","./more-vert.svg":"5b9e","./msg-notification.svg":"9a7e","./no-save.svg":"70ab","./no-screenshot.svg":"518d","./no-share.svg":"cda3","./open-in-new.svg":"bc22","./pause.svg":"3693","./peer-active.svg":"a350","./peers.svg":"0ded","./play-arrow-filled.svg":"02bb","./play-arrow.svg":"9172","./playlist-add-check.svg":"075d","./purple-coin.svg":"a16d","./question-answer.svg":"ec38","./radio-button-checked.svg":"476e","./radio-button-unchecked.svg":"3f38","./record-voice-over.svg":"560f","./recovery.svg":"fd5b","./refresh.svg":"9167","./release-notes.svg":"a66d","./rss-feed.svg":"8f34","./search.svg":"0d36","./settings.svg":"0395","./share.svg":"39ce","./skip-next.svg":"dbad","./skip-previous.svg":"3c9f","./speaker-notes.svg":"9635","./speed-active.svg":"68b2","./speed-sync.svg":"5f06","./speed.svg":"3a5b","./stop-screen-share.svg":"3388","./theater-mode-off.svg":"0a9f","./theater.svg":"6164","./toggle-off.svg":"e338","./toggle-on.svg":"8b6a","./torrent-scanner.svg":"e5a8","./trontv.svg":"d245","./unfold-less.svg":"a1da","./unfold-more.svg":"45bd","./upload.svg":"3f01","./videocam.svg":"3627","./visibility-off.svg":"eaa8","./visibility.svg":"9a41","./volume-down.svg":"b4e1","./volume-mute.svg":"1a17","./volume-off.svg":"585a","./volume-up.svg":"e609","./wallet.svg":"e106","./warning-amber.svg":"bd0f","./warning.svg":"56bc","./write.svg":"87dd","./µtorrent.svg":"f91d"};function a(t){var e=i(t);return s(e)}function i(t){if(!s.o(n,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return n[t]}a.keys=function(){return Object.keys(n)},a.resolve=i,t.exports=a,a.id="94b9"},"96e2":function(t,e){t.exports='<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" fill="none" viewBox="0 0 24 24">\n    <path fill="#7D5AE6" d="M12 6.5c2.76 0 5 2.24 5 5 0 .51-.1 1-.24 1.46l3.06 3.06c1.39-1.23 2.49-2.77 3.18-4.53C21.27 7.11 17 4 12 4c-1.27 0-2.49.2-3.64.57l2.17 2.17c.47-.14.96-.24 1.47-.24zM2.71 3.16c-.39.39-.39 1.02 0 1.41l1.97 1.97C3.06 7.83 1.77 9.53 1 11.5 2.73 15.89 7 19 12 19c1.52 0 2.97-.3 4.31-.82l2.72 2.72c.39.39 1.02.39 1.41 0 .39-.39.39-1.02 0-1.41L4.13 3.16c-.39-.39-1.03-.39-1.42 0zM12 16.5c-2.76 0-5-2.24-5-5 0-.77.18-1.5.49-2.14l1.57 1.57c-.03.18-.06.37-.06.57 0 1.66 1.34 3 3 3 .2 0 .38-.03.57-.07L14.14 16c-.65.32-1.37.5-2.14.5zm2.97-5.33c-.15-1.4-1.25-2.49-2.64-2.64l2.64 2.64z"/>\n</svg>\n'},"9b17":function(t,e,s){var n={"./bt-button-icon.vue":"91fa","./bt-button-text.vue":"01db","./bt-button.vue":"dd4b","./bt-checkbox-section.vue":"0786","./bt-checkbox.vue":"9efb","./bt-dropdown.vue":"f723","./bt-header.vue":"4094","./bt-input-box.vue":"5905","./bt-link-card.vue":"5ab3","./bt-settings-header.vue":"6a6f","./bt-settings-section.vue":"9ee4","./bt-sidebar.vue":"36bf","./bt-text-box.vue":"36e8","./bt-toggle.vue":"46cc"};function a(t){var e=i(t);return s(e)}function i(t){if(!s.o(n,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return n[t]}a.keys=function(){return Object.keys(n)},a.resolve=i,t.exports=a,a.id="9b17"},"9bfb":function(t,e,s){t.exports=s.p+"img/spinner.24c1970e.svg"},"9c87":function(t,e,s){},"9fd0":function(t,e,s){"use strict";var n=s("4625"),a=s.n(n);a.a},a1e8:function(t,e,s){},a90f:function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},aa20:function(t,e){t.exports='<svg width="14" height="14" viewBox="0 0 14 14" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M7.00001 0.333008C3.31334 0.333008 0.333344 3.31301 0.333344 6.99967C0.333344 10.6863 3.31334 13.6663 7.00001 13.6663C10.6867 13.6663 13.6667 10.6863 13.6667 6.99967C13.6667 3.31301 10.6867 0.333008 7.00001 0.333008ZM8.72668 4.33301L7.00001 6.05967L5.27334 4.33301L4.33334 5.27301L6.06001 6.99967L4.33334 8.72634L5.27334 9.66634L7.00001 7.93967L8.72668 9.66634L9.66668 8.72634L7.94001 6.99967L9.66668 5.27301L8.72668 4.33301ZM1.66668 6.99967C1.66668 9.93967 4.06001 12.333 7.00001 12.333C9.94001 12.333 12.3333 9.93967 12.3333 6.99967C12.3333 4.05967 9.94001 1.66634 7.00001 1.66634C4.06001 1.66634 1.66668 4.05967 1.66668 6.99967Z" fill="#EB5757"/>\n</svg>\n'},ac0b:function(t,e,s){"use strict";var n=s("674b"),a=s.n(n);a.a},ac39:function(t,e,s){},af67:function(t,e,s){"use strict";var n=s("e8d8"),a=s.n(n);a.a},b97e:function(t,e,s){"use strict";var n=s("a1e8"),a=s.n(n);a.a},bb7c:function(t,e,s){t.exports=s.p+"img/close.bbb24fc2.svg"},bdbb:function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},c191:function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},c563:function(t,e,s){},c833:function(t,e,s){"use strict";var n=s("8ff8"),a=s.n(n);a.a},cd3d:function(t,e,s){},d833:function(t,e){t.exports='<svg width="14" height="14" viewBox="0 0 14 14" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M7.00004 0.333008C3.32004 0.333008 0.333374 3.31967 0.333374 6.99967C0.333374 10.6797 3.32004 13.6663 7.00004 13.6663C10.68 13.6663 13.6667 10.6797 13.6667 6.99967C13.6667 3.31967 10.68 0.333008 7.00004 0.333008ZM6.33337 10.333V6.33301H7.66671V10.333H6.33337ZM6.33337 3.66634V4.99967H7.66671V3.66634H6.33337Z" fill="#F7B500"/>\n</svg>\n'},da2b:function(t,e){t.exports='<svg width="14" height="14" viewBox="0 0 14 14" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path d="M14 1.41L12.59 0L7 5.59L1.41 0L0 1.41L5.59 7L0 12.59L1.41 14L7 8.41L12.59 14L14 12.59L8.41 7L14 1.41Z" fill="#9E9E9E"/>\n</svg>\n'},df8a:function(t,e,s){"use strict";var n=s("277b"),a=s.n(n);a.a},e11e:function(t,e,s){},e8bc:function(t,e,s){"use strict";var n=s("f2ea"),a=s.n(n);a.a},e8d8:function(t,e,s){},eaa6:function(t,e,s){},ec4e:function(t,e,s){t.exports=s.p+"img/error.5134a4d8.svg"},ed08:function(t,e,s){"use strict";(function(t){s("99af"),s("13d5"),s("fb6a"),s("38cf");var n=s("2ef0"),a=s.n(n),i=[2,0,1,6,0,8,1,4,2,0,1,6,0,8,1,4],o=s("1c46");function r(e,s){var n=o.createHash("md5").update(e).digest("hex"),a=o.createCipheriv("aes-128-cbc",new t(n,"hex"),new t(i)),r=a.update(s,"utf8","base64");return r+=a.final("base64"),console.log("encode message: "+r),r}function c(e,s){var n=o.createHash("md5").update(e).digest("hex"),a=o.createDecipheriv("aes-128-cbc",new t(n,"hex"),new t(i)),r=a.update(s,"base64","utf8");return r+=a.final("utf8"),r}function l(t,e){if(console.log("ellipsify",t),t.innerText<t.title&&(t.innerText=t.title),t.offsetWidth<t.scrollWidth){var s="",n=t.innerText.slice(-1*e);while(t.offsetWidth<t.scrollWidth)s=t.innerText.slice(0,-1),t.innerText=s;s=s.slice(0,-1*(e+1)),t.innerText="".concat(s,"...").concat(n)}return t}function u(t){var e=document.createElement("textarea");e.value=t,e.setAttribute("readonly",""),e.style.position="absolute",e.style.left="-9999px",document.body.appendChild(e),e.select(),document.execCommand("copy"),document.body.removeChild(e)}function d(t,e){var s="=".repeat(25);return s+=a.a.reduce(e,(function(t,e,s){return t+="".concat(s,": ").concat(e,"\n"),t}),"\n"),s+="=".repeat(25),{subject:encodeURI(t),body:encodeURI("\n\n".concat(s))}}function p(t,e,s){var n=d(e,s),a=n.subject,i=n.body;return"mailto:".concat(t,"?subject=").concat(a,"&body=").concat(i)}e["a"]={ellipsifyText:l,copyToClipboard:u,createEmailHref:p,encrypt_with_aes:r,decrypt_with_aes:c}}).call(this,s("b639").Buffer)},f2ea:function(t,e,s){},f328:function(t,e){t.exports='<svg width="213" height="40" viewBox="0 0 213 40" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M19.2655 29.1175V20.8773L26.3828 16.7572L30.4689 14.3918V19.7342C30.4689 19.8512 30.5952 19.9243 30.6962 19.8658L31.048 19.6622L33.1211 18.4621C33.3556 18.3264 33.5 18.0755 33.5 17.8041V12.6371V11.7598C33.5 11.2169 33.2111 10.7152 32.7422 10.4438L31.9845 10.0051L27.5261 7.42422C27.2916 7.2885 27.0028 7.2885 26.7683 7.42422L24.3435 8.82796C24.2424 8.88645 24.2424 9.03267 24.3435 9.09116L28.9534 11.7598L24.8672 14.1252L17.75 18.2453L10.6328 14.1252L6.69527 11.8458L6.54663 11.7598L11.1634 9.08716C11.2644 9.0287 11.2645 8.88258 11.1636 8.82403L8.74167 7.41911C8.50713 7.28306 8.21798 7.28293 7.98331 7.41877L3.51554 10.0051L2.75777 10.4438C2.28886 10.7152 2 11.2169 2 11.7598V12.6371V17.7976C2 18.0691 2.14443 18.3199 2.37889 18.4556L4.46321 19.6622L4.80376 19.8594C4.90479 19.9178 5.03109 19.8447 5.03109 19.7277V14.3918L5.17973 14.4778L9.11723 16.7572L16.2345 20.8773V29.1175L16.2345 33.8484L11.6179 31.1759C11.5169 31.1174 11.3906 31.1905 11.3906 31.3075V32.0757V34.115C11.3906 34.3864 11.535 34.6373 11.7695 34.773L16.2345 37.3577L16.9922 37.7964C17.4611 38.0679 18.0389 38.0679 18.5078 37.7964L19.2655 37.3577L23.7305 34.773C23.965 34.6373 24.1094 34.3864 24.1094 34.115V32.0757V31.3075C24.1094 31.1905 23.9831 31.1174 23.8821 31.1759L19.2655 33.8484L19.2655 29.1175ZM27.5194 29.0703C27.2849 29.206 27.1405 29.4569 27.1405 29.7283V32.0757V32.5358C27.1405 32.6528 27.2668 32.7259 27.3678 32.6674L32.7422 29.5562C33.2111 29.2848 33.5 28.7831 33.5 28.2402V22.0153C33.5 21.8983 33.3737 21.8252 33.2727 21.8837L32.5635 22.2942L30.8478 23.2874C30.6133 23.4232 30.4689 23.674 30.4689 23.9454V27.3629L27.5194 29.0703ZM8.35948 32.5358C8.35948 32.6528 8.23318 32.7259 8.13215 32.6674L2.75777 29.5562C2.28886 29.2848 2 28.7831 2 28.2402V22.0088C2 21.8918 2.1263 21.8187 2.22733 21.8772L2.94767 22.2942L4.6522 23.281C4.88666 23.4167 5.03109 23.6675 5.03109 23.939V27.3629L7.98057 29.0703C8.21503 29.206 8.35946 29.4568 8.35946 29.7283L8.35948 32.0757V32.5358ZM11.621 5.31294C11.52 5.37143 11.52 5.51765 11.621 5.57614L13.2195 6.50149L14.0459 6.97988C14.2803 7.1156 14.5692 7.1156 14.8037 6.97988L17.75 5.27426L20.7061 6.98555C20.9406 7.12128 21.2295 7.12128 21.4639 6.98555L22.3001 6.50149L23.8888 5.58181C23.9898 5.52333 23.9898 5.3771 23.8888 5.31861L18.5078 2.20359C18.0389 1.93214 17.4611 1.93214 16.9922 2.20359L11.621 5.31294Z" fill="#3477FF"/>\n<path d="M41.5 26.96H47.7756C49.1089 26.96 50.1578 26.64 50.9044 26.0533C51.74 25.4133 52.2022 24.4889 52.2022 23.2089C52.2022 21.6978 51.3133 20.5244 49.9267 20.0978V20.0444C51.0467 19.6178 51.7044 18.8178 51.7044 17.5556C51.7044 16.5422 51.3311 15.7244 50.6022 15.12C49.9267 14.5689 48.9489 14.2489 47.6333 14.2489H41.5V26.96ZM44.6111 24.4356V21.4133H47.3844C48.3444 21.4133 49.0911 21.9289 49.0911 22.9422C49.0911 23.8489 48.4511 24.4356 47.42 24.4356H44.6111ZM44.6111 19.28V16.7378H47.1889C48.1133 16.7378 48.7 17.2 48.7 18C48.7 18.8356 48.0778 19.28 47.1711 19.28H44.6111Z" fill="#303233"/>\n<path d="M53.2493 26.96H56.1471V17.8578H53.2493V26.96ZM53.2493 16.5956H56.1471V14.2489H53.2493V16.5956Z" fill="#303233"/>\n<path d="M61.1464 27.0667C61.9286 27.0667 62.4797 26.9956 62.7286 26.9244V24.8089C62.6219 24.8089 62.3375 24.8267 62.0886 24.8267C61.4664 24.8267 61.0753 24.6489 61.0753 23.9378V19.6711H62.7286V17.8578H61.0753V14.9778H58.2486V17.8578H57.0397V19.6711H58.2486V24.56C58.2486 26.5156 59.4575 27.0667 61.1464 27.0667Z" fill="#303233"/>\n<path d="M67.0768 26.96H70.2235V16.9156H73.9568V14.2489H63.379V16.9156H67.0768V26.96Z" fill="#303233"/>\n<path d="M77.2597 25.1822C75.9975 25.1822 75.3397 24.08 75.3397 22.4267C75.3397 20.7733 75.9975 19.6533 77.2597 19.6533C78.5219 19.6533 79.1975 20.7733 79.1975 22.4267C79.1975 24.08 78.5219 25.1822 77.2597 25.1822ZM77.2775 27.2444C80.2108 27.2444 82.1308 25.1644 82.1308 22.4267C82.1308 19.6889 80.2108 17.6089 77.2775 17.6089C74.3619 17.6089 72.4064 19.6889 72.4064 22.4267C72.4064 25.1644 74.3619 27.2444 77.2775 27.2444Z" fill="#303233"/>\n<path d="M83.1104 26.96H86.0082V22.8711C86.0082 20.8978 87.146 20.0267 88.9237 20.2756H88.9949V17.7867C88.8704 17.7333 88.6926 17.7156 88.426 17.7156C87.3237 17.7156 86.5771 18.1956 85.9371 19.28H85.8837V17.8578H83.1104V26.96Z" fill="#303233"/>\n<path d="M89.916 26.96H92.8137V22.8711C92.8137 20.8978 93.9515 20.0267 95.7293 20.2756H95.8004V17.7867C95.676 17.7333 95.4982 17.7156 95.2315 17.7156C94.1293 17.7156 93.3826 18.1956 92.7426 19.28H92.6893V17.8578H89.916V26.96Z" fill="#303233"/>\n<path d="M100.992 27.2267C102.148 27.2267 103.072 26.9244 103.837 26.3911C104.637 25.84 105.17 25.0578 105.366 24.24H102.539C102.29 24.8089 101.792 25.1467 101.028 25.1467C99.8368 25.1467 99.1612 24.3822 98.9835 23.1556H105.526C105.543 21.3067 105.01 19.7244 103.926 18.7289C103.143 18.0178 102.112 17.5911 100.815 17.5911C98.0412 17.5911 96.139 19.6711 96.139 22.3911C96.139 25.1467 97.9879 27.2267 100.992 27.2267ZM99.0012 21.4311C99.1968 20.3467 99.7657 19.6533 100.868 19.6533C101.81 19.6533 102.486 20.3467 102.592 21.4311H99.0012Z" fill="#303233"/>\n<path d="M106.496 26.96H109.394V21.9289C109.394 20.8089 110.034 20.0089 110.994 20.0089C111.936 20.0089 112.416 20.6667 112.416 21.6267V26.96H115.314V21.04C115.314 19.0133 114.14 17.5911 112.149 17.5911C110.887 17.5911 110.016 18.1244 109.34 19.1022H109.287V17.8578H106.496V26.96Z" fill="#303233"/>\n<path d="M120.07 27.0667C120.852 27.0667 121.403 26.9956 121.652 26.9244V24.8089C121.546 24.8089 121.261 24.8267 121.012 24.8267C120.39 24.8267 119.999 24.6489 119.999 23.9378V19.6711H121.652V17.8578H119.999V14.9778H117.172V17.8578H115.963V19.6711H117.172V24.56C117.172 26.5156 118.381 27.0667 120.07 27.0667Z" fill="#303233"/>\n<path d="M126.021 26.96H127.621V20.9689H133.47V19.6178H127.621V15.6533H134.644V14.2489H126.021V26.96Z" fill="#303233"/>\n<path d="M136.086 26.96H137.526V17.7689H136.086V26.96ZM136.086 16.0267H137.526V14.2489H136.086V16.0267Z" fill="#303233"/>\n<path d="M139.542 26.96H140.982V14.2489H139.542V26.96Z" fill="#303233"/>\n<path d="M146.924 27.2089C149.004 27.2089 150.444 26.0889 150.871 24.1156H149.467C149.147 25.36 148.258 26 146.924 26C145.076 26 144.062 24.5778 143.956 22.7111H151.067C151.067 19.6711 149.591 17.5378 146.836 17.5378C144.222 17.5378 142.48 19.7067 142.48 22.3733C142.48 25.04 144.116 27.2089 146.924 27.2089ZM146.836 18.6756C148.489 18.6756 149.431 19.7956 149.52 21.6267H143.991C144.204 19.9556 145.129 18.6756 146.836 18.6756Z" fill="#303233"/>\n<path d="M160.14 27.2089C162.878 27.2089 164.816 25.8756 164.816 23.4933C164.816 20.6311 162.558 20.0267 160.087 19.5289C158.185 19.1556 156.762 18.7289 156.762 17.2889C156.762 15.9733 157.9 15.2444 159.625 15.2444C161.491 15.2444 162.665 16.1511 162.896 17.9644H164.407C164.087 15.6178 162.771 14 159.589 14C157.011 14 155.269 15.2622 155.269 17.36C155.269 19.76 157.189 20.4 159.429 20.8978C161.705 21.3956 163.233 21.8044 163.233 23.5467C163.233 25.1289 161.971 25.9289 160.211 25.9289C157.811 25.9289 156.496 24.8089 156.282 22.6578H154.718C154.86 25.2178 156.567 27.2089 160.14 27.2089Z" fill="#303233"/>\n<path d="M167.606 30C168.726 30 169.437 29.6089 170.184 27.7067L174.113 17.7689H172.584L170.486 23.4933C170.202 24.2578 169.882 25.2889 169.882 25.2889H169.846C169.846 25.2889 169.509 24.2578 169.224 23.4933L167.055 17.7689H165.491L169.082 26.6933L168.726 27.6C168.371 28.4889 167.962 28.72 167.357 28.72C166.877 28.72 166.575 28.6311 166.38 28.5244H166.309V29.8044C166.7 29.9644 167.055 30 167.606 30Z" fill="#303233"/>\n<path d="M178.529 27.2267C180.644 27.2267 182.155 26.2844 182.155 24.4356C182.155 22.32 180.609 21.9111 178.76 21.5378C177.177 21.2178 176.289 21.0222 176.289 20.0267C176.289 19.2978 176.822 18.7111 178.137 18.7111C179.506 18.7111 180.164 19.2444 180.306 20.4533H181.782C181.569 18.6578 180.449 17.5733 178.173 17.5733C176.04 17.5733 174.866 18.5689 174.866 20.0978C174.866 22.1067 176.484 22.48 178.297 22.8533C179.986 23.2089 180.697 23.4756 180.697 24.5067C180.697 25.36 180.075 26.0178 178.564 26.0178C177.195 26.0178 176.129 25.5733 175.933 23.9733H174.457C174.6 26.0356 176.004 27.2267 178.529 27.2267Z" fill="#303233"/>\n<path d="M182.773 18.9422H184.107V25.2356C184.107 26.5333 184.96 27.0133 186.133 27.0133C186.56 27.0133 186.951 26.96 187.289 26.8889V25.6444H187.236C187.076 25.6978 186.756 25.7689 186.471 25.7689C185.885 25.7689 185.547 25.5556 185.547 24.9156V18.9422H187.325V17.7689H185.547V14.8711H184.107V17.7689H182.773V18.9422Z" fill="#303233"/>\n<path d="M192.654 27.2089C194.734 27.2089 196.174 26.0889 196.6 24.1156H195.196C194.876 25.36 193.987 26 192.654 26C190.805 26 189.791 24.5778 189.685 22.7111H196.796C196.796 19.6711 195.32 17.5378 192.565 17.5378C189.951 17.5378 188.209 19.7067 188.209 22.3733C188.209 25.04 189.845 27.2089 192.654 27.2089ZM192.565 18.6756C194.218 18.6756 195.16 19.7956 195.249 21.6267H189.72C189.934 19.9556 190.858 18.6756 192.565 18.6756Z" fill="#303233"/>\n<path d="M198.204 26.96H199.644V21.2356C199.644 19.7956 200.888 18.7644 202.008 18.7644C202.951 18.7644 203.591 19.44 203.591 20.5778V26.96H205.031V21.2356C205.031 19.7956 206.097 18.7644 207.288 18.7644C208.213 18.7644 208.977 19.44 208.977 20.5778V26.96H210.417V20.5067C210.417 18.6044 209.262 17.5556 207.626 17.5556C206.506 17.5556 205.404 18.1422 204.817 19.1911H204.782C204.391 18.1244 203.502 17.5556 202.382 17.5556C201.226 17.5556 200.248 18.1956 199.679 19.0844H199.644V17.7689H198.204V26.96Z" fill="#303233"/>\n</svg>\n'},f37a:function(t,e){t.exports='<svg width="24" height="24" viewBox="0 0 24 24" fill="none" xmlns="http://www.w3.org/2000/svg">\n<path fill-rule="evenodd" clip-rule="evenodd" d="M20.71 5.62957C21.1 6.01957 21.1 6.64957 20.71 7.03957L18.88 8.86957L15.13 5.11957L16.96 3.28957C17.35 2.89957 17.98 2.89957 18.37 3.28957L20.71 5.62957ZM3 20.9996V17.2496L14.06 6.18957L17.81 9.93957L6.75 20.9996H3Z" fill="#9E9E9E"/>\n</svg>\n'},f819:function(t,e,s){},f849:function(t,e,s){"use strict";var n=s("8ea4"),a=s.n(n);a.a},fac6:function(t,e,s){t.exports={primary500main:"#3e7eff",primary200:"#acc4fa",primary050:"#eef3fe",gray800main:"#424242",gray500:"#9E9E9E",gray400:"#BDBDBD",accentsRed500:"#EB5757",accentsGreen500:"#27AE60",accentsYellow500:"#F7B500",btfsBlue500:"#3e7eff",btfsBlue900:"#142344",btfsBlue800:"#233b70",btfsBlue700:"#31529e",btfsBlue600:"#3e6aca",btfsBlue400:"#6b96f8",btfsBlue300:"#8badf9",btfsBlue200:"#acc4fa",btfsBlue100:"#cddbfc",btfsBlue050:"#eef3fe",gray900:"#212121",gray800:"#424242",gray700:"#616161",gray600:"#757575",gray300:"#E0E0E0",gray050:"#FAFAFA",btfsRed:"#EB5757",btfsOrange:"#F7B500",btfsGreen:"#27AE60"}},fd06:function(t,e,s){"use strict";var n=s("8a65"),a=s.n(n);a.a},ff7d:function(t,e,s){"use strict";var n=s("9c87"),a=s.n(n);a.a},ffe3:function(t,e,s){}});
 //# sourceMappingURL=app.29c53470.js.map
\ No newline at end of file
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	base32 "encoding/base32"
 
@@ -40,9 +41,23 @@ var ErrKeyExists = fmt.Errorf("key by that name already exists, refusing to over
 
 const keyFilenamePrefix = "key_"
 
+// Sealer encrypts the keys a FSKeystore writes.
+type Sealer interface {
+	// Seal encrypts data, it returns data as is when keys are stored in the
+	// clear.
+	Seal(data []byte) ([]byte, error)
+	// Open decrypts data when it is sealed, and returns it as is otherwise.
+	Open(data []byte) ([]byte, error)
+	// Sealed tells whether data is sealed.
+	Sealed(data []byte) bool
+}
+
 // FSKeystore is a keystore backed by files in a given directory stored on disk.
 type FSKeystore struct {
 	dir string
+
+	lk     sync.RWMutex
+	sealer Sealer
 }
 
 // NewFSKeystore returns a new filesystem-backed keystore.
@@ -54,7 +69,22 @@ func NewFSKeystore(dir string) (*FSKeystore, error) {
 	default:
 		return nil, err
 	}
-	return &FSKeystore{dir}, nil
+	return &FSKeystore{dir: dir}, nil
+}
+
+// SetSealer makes the keystore seal the keys it writes with s, and open
+// the sealed keys it reads. Keys written before stay in the clear until
+// SealAll.
+func (ks *FSKeystore) SetSealer(s Sealer) {
+	ks.lk.Lock()
+	defer ks.lk.Unlock()
+	ks.sealer = s
+}
+
+func (ks *FSKeystore) getSealer() Sealer {
+	ks.lk.RLock()
+	defer ks.lk.RUnlock()
+	return ks.sealer
 }
 
 // Has returns whether or not a key exists in the Keystore
@@ -85,6 +115,11 @@ func (ks *FSKeystore) Put(name string, k ci.PrivKey) error {
 	if err != nil {
 		return err
 	}
+	if s := ks.getSealer(); s != nil {
+		if b, err = s.Seal(b); err != nil {
+			return err
+		}
+	}
 
 	kp := filepath.Join(ks.dir, name)
 
@@ -119,6 +154,11 @@ func (ks *FSKeystore) Get(name string) (ci.PrivKey, error) {
 		}
 		return nil, err
 	}
+	if s := ks.getSealer(); s != nil {
+		if data, err = s.Open(data); err != nil {
+			return nil, err
+		}
+	}
 
 	return ci.UnmarshalPrivateKey(data)
 }
@@ -161,6 +201,84 @@ func (ks *FSKeystore) List() ([]string, error) {
 	return list, nil
 }
 
+// SealAll seals the keys stored in the clear with the sealer of the
+// keystore, and returns the number of keys it sealed.
+func (ks *FSKeystore) SealAll() (int, error) {
+	s := ks.getSealer()
+	if s == nil {
+		return 0, fmt.Errorf("the keystore has no sealer")
+	}
+	names, err := ks.keyFiles()
+	if err != nil {
+		return 0, err
+	}
+	sealed := 0
+	for _, name := range names {
+		kp := filepath.Join(ks.dir, name)
+		data, err := ioutil.ReadFile(kp)
+		if err != nil {
+			return sealed, err
+		}
+		if s.Sealed(data) {
+			continue
+		}
+		if data, err = s.Seal(data); err != nil {
+			return sealed, err
+		}
+		// the key is replaced at once, it is never lost half written
+		tmp := kp + ".sealing"
+		if err := ioutil.WriteFile(tmp, data, 0400); err != nil {
+			os.Remove(tmp)
+			return sealed, err
+		}
+		if err := os.Rename(tmp, kp); err != nil {
+			os.Remove(tmp)
+			return sealed, err
+		}
+		sealed++
+	}
+	return sealed, nil
+}
+
+// CountSealed returns the number of sealed keys and of all keys.
+func (ks *FSKeystore) CountSealed() (sealed int, total int, err error) {
+	s := ks.getSealer()
+	names, err := ks.keyFiles()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(ks.dir, name))
+		if err != nil {
+			return 0, 0, err
+		}
+		if s != nil && s.Sealed(data) {
+			sealed++
+		}
+	}
+	return sealed, len(names), nil
+}
+
+// keyFiles returns the names of the files of the keys.
+func (ks *FSKeystore) keyFiles() ([]string, error) {
+	dir, err := os.Open(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	names, err := dir.Readdirnames(0)
+	if err != nil {
+		return nil, err
+	}
+	files := names[:0]
+	for _, name := range names {
+		if _, err := decode(name); err == nil {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
 func encode(name string) (string, error) {
 	if name == "" {
 		return "", fmt.Errorf("key name must be at least one character")
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/TRON-US/go-btfs/plugin"
 	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/durability"
 	"github.com/TRON-US/go-btfs/repo/fsrepo"
 
 	humanize "github.com/dustin/go-humanize"
+	ds "github.com/ipfs/go-datastore"
 	badgerds "github.com/ipfs/go-ds-badger"
 )
 
@@ -44,6 +47,8 @@ type datastoreConfig struct {
 	truncate   bool
 
 	vlogFileSize int64
+	// syncInterval syncs the writes periodically when syncWrites is off.
+	syncInterval time.Duration
 }
 
 // BadgerdsDatastoreConfig returns a configuration stub for a badger datastore
@@ -96,6 +101,15 @@ func (*badgerdsPlugin) DatastoreConfigParser() fsrepo.ConfigFromMap {
 			}
 		}
 
+		var err error
+		c.syncInterval, err = durability.ParseSyncInterval(params)
+		if err != nil {
+			return nil, err
+		}
+		if c.syncInterval > 0 && c.syncWrites {
+			return nil, fmt.Errorf("'syncInterval' is set but 'syncWrites' syncs every write")
+		}
+
 		return &c, nil
 	}
 }
@@ -123,5 +137,26 @@ func (c *datastoreConfig) Create(path string) (repo.Datastore, error) {
 	defopts.Truncate = c.truncate
 	defopts.ValueLogFileSize = c.vlogFileSize
 
-	return badgerds.NewDatastore(p, &defopts)
+	d, err := badgerds.NewDatastore(p, &defopts)
+	if err != nil || c.syncInterval == 0 {
+		return d, err
+	}
+	return &syncedDatastore{
+		Datastore: d,
+		syncer: durability.StartSyncer(p, c.syncInterval, func() error {
+			return d.Sync(ds.NewKey("/"))
+		}),
+	}, nil
+}
+
+// syncedDatastore is a badger datastore not syncing every write, synced
+// periodically.
+type syncedDatastore struct {
+	*badgerds.Datastore
+	syncer *durability.Syncer
+}
+
+func (d *syncedDatastore) Close() error {
+	d.syncer.Stop()
+	return d.Datastore.Close()
 }
@@ -3,11 +3,14 @@ package flatfs
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/TRON-US/go-btfs/plugin"
 	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/durability"
 	"github.com/TRON-US/go-btfs/repo/fsrepo"
 
+	ds "github.com/ipfs/go-datastore"
 	flatfs "github.com/ipfs/go-ds-flatfs"
 )
 
@@ -40,6 +43,9 @@ type datastoreConfig struct {
 	path      string
 	shardFun  *flatfs.ShardIdV1
 	syncField bool
+	// syncInterval syncs the filesystem of the datastore periodically
+	// instead of every write.
+	syncInterval time.Duration
 }
 
 // BadgerdsDatastoreConfig returns a configuration stub for a badger datastore
@@ -68,6 +74,19 @@ func (*flatfsPlugin) DatastoreConfigParser() fsrepo.ConfigFromMap {
 		if !ok {
 			return nil, fmt.Errorf("'sync' field is missing or not boolean")
 		}
+
+		c.syncInterval, err = durability.ParseSyncInterval(params)
+		if err != nil {
+			return nil, err
+		}
+		if c.syncInterval > 0 {
+			if c.syncField {
+				return nil, fmt.Errorf("'syncInterval' is set but 'sync' syncs every write")
+			}
+			if !durability.SyncFSSupported {
+				return nil, fmt.Errorf("'syncInterval' is not supported on this platform")
+			}
+		}
 		return &c, nil
 	}
 }
@@ -86,5 +105,38 @@ func (c *datastoreConfig) Create(path string) (repo.Datastore, error) {
 		p = filepath.Join(path, p)
 	}
 
-	return flatfs.CreateOrOpen(p, c.shardFun, c.syncField)
+	d, err := flatfs.CreateOrOpen(p, c.shardFun, c.syncField)
+	if err != nil || c.syncInterval == 0 {
+		return d, err
+	}
+	return &syncedDatastore{
+		Datastore: d,
+		dir:       p,
+		syncer: durability.StartSyncer(p, c.syncInterval, func() error {
+			return durability.SyncFS(p)
+		}),
+	}, nil
+}
+
+// syncedDatastore is a flatfs datastore not syncing every write, whose
+// filesystem is synced periodically and on Sync.
+type syncedDatastore struct {
+	*flatfs.Datastore
+	dir    string
+	syncer *durability.Syncer
+}
+
+func (d *syncedDatastore) Sync(prefix ds.Key) error {
+	if err := d.Datastore.Sync(prefix); err != nil {
+		return err
+	}
+	return durability.SyncFS(d.dir)
+}
+
+func (d *syncedDatastore) Close() error {
+	d.syncer.Stop()
+	if err := durability.SyncFS(d.dir); err != nil {
+		return err
+	}
+	return d.Datastore.Close()
 }
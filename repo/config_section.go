@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"encoding/json"
+
+	"github.com/TRON-US/go-btfs/repo/common"
+)
+
+// GetConfigSection decodes the config entry stored under key into v. It
+// is meant for sections that are not part of go-btfs-config: the repo keeps
+// unknown keys intact on disk, so they can be read back raw and decoded
+// here. The returned bool is false when the section is absent.
+func GetConfigSection(r Repo, key string, v interface{}) (bool, error) {
+	raw, err := r.GetConfigKey(key)
+	if err != nil {
+		if _, ok := err.(*common.KeyNotFoundError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	if raw == nil {
+		return false, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetConfigSection encodes v and stores it under key in the config.
+func SetConfigSection(r Repo, key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	return r.SetConfigKey(key, raw)
+}
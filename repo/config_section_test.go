@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"testing"
+
+	config "github.com/TRON-US/go-btfs-config"
+)
+
+func TestGetConfigSection(t *testing.T) {
+	r := &Mock{C: config.Config{Gateway: config.Gateway{RootRedirect: "/btfs/root"}}}
+
+	var gw struct {
+		RootRedirect string
+	}
+	found, err := GetConfigSection(r, "Gateway", &gw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || gw.RootRedirect != "/btfs/root" {
+		t.Fatalf("unexpected section %v (found: %v)", gw, found)
+	}
+
+	var missing map[string]interface{}
+	found, err = GetConfigSection(r, "NoSuchSection.Key", &missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("expected missing section to be reported as absent")
+	}
+}
@@ -0,0 +1,97 @@
+package configschema
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	config "github.com/TRON-US/go-btfs-config"
+
+	humanize "github.com/dustin/go-humanize"
+	ma "github.com/multiformats/go-multiaddr"
+	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
+)
+
+// checkConflicts checks values that decode fine but cannot work, alone or
+// together with other options.
+func checkConflicts(cfg *config.Config) []Problem {
+	var ps []Problem
+	errorf := func(path, format string, args ...interface{}) {
+		ps = append(ps, Problem{Path: path, Level: Error, Message: fmt.Sprintf(format, args...)})
+	}
+	warnf := func(path, format string, args ...interface{}) {
+		ps = append(ps, Problem{Path: path, Level: Warning, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(cfg.Addresses.API) == 0 {
+		errorf("Addresses.API", "at least one API address is required")
+	}
+	checkAddrs := func(path string, addrs []string) {
+		for i, a := range addrs {
+			if _, err := ma.NewMultiaddr(a); err != nil {
+				errorf(fmt.Sprintf("%s[%d]", path, i), "invalid multiaddr %q: %s", a, err)
+			}
+		}
+	}
+	checkAddrs("Addresses.Swarm", cfg.Addresses.Swarm)
+	checkAddrs("Addresses.Announce", cfg.Addresses.Announce)
+	checkAddrs("Addresses.NoAnnounce", cfg.Addresses.NoAnnounce)
+	checkAddrs("Addresses.API", cfg.Addresses.API)
+	checkAddrs("Addresses.Gateway", cfg.Addresses.Gateway)
+	checkAddrs("Addresses.RemoteAPI", cfg.Addresses.RemoteAPI)
+	checkAddrs("Bootstrap", cfg.Bootstrap)
+
+	if cfg.Datastore.StorageMax != "" {
+		if _, err := humanize.ParseBytes(cfg.Datastore.StorageMax); err != nil {
+			errorf("Datastore.StorageMax", "invalid size %q: %s", cfg.Datastore.StorageMax, err)
+		}
+	}
+	if cfg.Datastore.GCPeriod != "" {
+		if _, err := time.ParseDuration(cfg.Datastore.GCPeriod); err != nil {
+			errorf("Datastore.GCPeriod", "invalid duration %q: %s", cfg.Datastore.GCPeriod, err)
+		}
+	}
+	if w := cfg.Datastore.StorageGCWatermark; w < 0 || w > 100 {
+		errorf("Datastore.StorageGCWatermark", "must be a percentage between 0 and 100, got %d", w)
+	}
+
+	switch cfg.Routing.Type {
+	case "", "dht", "dhtclient", "dhtserver", "none":
+	default:
+		errorf("Routing.Type", "unknown routing type %q, expected one of dht, dhtclient, dhtserver or none", cfg.Routing.Type)
+	}
+
+	exp := cfg.Experimental
+	if exp.StorageHostEnabled {
+		switch cfg.Routing.Type {
+		case "none":
+			errorf("Routing.Type", "storage hosts need the DHT, but routing is disabled")
+		case "dhtclient":
+			warnf("Routing.Type", "storage hosts in DHT client mode cannot be found by renters")
+		}
+	}
+	if !exp.StorageHostEnabled {
+		if exp.HostRepairEnabled {
+			warnf("Experimental.HostRepairEnabled", "has no effect without Experimental.StorageHostEnabled")
+		}
+		if exp.HostChallengeEnabled {
+			warnf("Experimental.HostChallengeEnabled", "has no effect without Experimental.StorageHostEnabled")
+		}
+	}
+	if exp.StorageClientEnabled && cfg.Routing.Type == "none" {
+		errorf("Routing.Type", "storage clients need the DHT, but routing is disabled")
+	}
+	if exp.HostsSyncMode != "" {
+		if _, ok := hubpb.HostsReq_Mode_value[strings.ToUpper(exp.HostsSyncMode)]; !ok {
+			errorf("Experimental.HostsSyncMode", "unknown hosts sync mode %q", exp.HostsSyncMode)
+		}
+	}
+	if cfg.Gateway.Writable && len(cfg.Addresses.Gateway) > 0 {
+		for _, a := range cfg.Addresses.Gateway {
+			if strings.HasPrefix(a, "/ip4/0.0.0.0/") || strings.HasPrefix(a, "/ip6/::/") {
+				warnf("Gateway.Writable", "the writable gateway listens on all interfaces (%s)", a)
+			}
+		}
+	}
+	return ps
+}
@@ -0,0 +1,96 @@
+package configschema
+
+import (
+	"fmt"
+	"reflect"
+
+	config "github.com/TRON-US/go-btfs-config"
+)
+
+// Version is the config layout version written by this build.
+const Version = 2
+
+// layoutMigration upgrades a raw config from layout to-1 to layout to.
+type layoutMigration struct {
+	to          int
+	description string
+	apply       func(raw map[string]interface{})
+}
+
+var layoutMigrations = []layoutMigration{
+	{
+		to:          1,
+		description: "remove the obsolete Tour, SupernodeRouting and Log sections",
+		apply: func(raw map[string]interface{}) {
+			delete(raw, "Tour")
+			delete(raw, "SupernodeRouting")
+			delete(raw, "Log")
+		},
+	},
+	{
+		to:          2,
+		description: "drop the deprecated Datastore Type, Path, NoSync and Params fields superseded by Datastore.Spec",
+		apply: func(raw map[string]interface{}) {
+			ds, ok := raw["Datastore"].(map[string]interface{})
+			if !ok {
+				return
+			}
+			if spec, ok := ds["Spec"].(map[string]interface{}); !ok || len(spec) == 0 {
+				return
+			}
+			for _, k := range []string{"Type", "Path", "NoSync", "Params"} {
+				delete(ds, k)
+			}
+		},
+	},
+}
+
+// Migrate upgrades the raw config in place to the current layout version
+// and applies the go-btfs-config setting migrations. It returns a
+// description of every change made; an empty list means the config was
+// already current.
+func Migrate(raw map[string]interface{}) ([]string, error) {
+	from, err := LayoutVersion(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", VersionKey, err)
+	}
+	if from > Version {
+		return nil, fmt.Errorf("config layout version %d was written by a newer btfs (this one supports %d)", from, Version)
+	}
+
+	var applied []string
+	for _, m := range layoutMigrations {
+		if m.to <= from {
+			continue
+		}
+		m.apply(raw)
+		applied = append(applied, fmt.Sprintf("layout %d: %s", m.to, m.description))
+	}
+
+	cfg, err := config.FromMap(raw)
+	if err != nil {
+		return nil, err
+	}
+	before, err := config.ToMap(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// MigrateConfig reports some migrations as applied even when they
+	// change nothing, so compare the results instead.
+	config.MigrateConfig(cfg, false, false)
+	after, err := config.ToMap(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(before, after) {
+		// Keep sections unknown to go-btfs-config, like SetConfig does.
+		for k, v := range after {
+			raw[k] = v
+		}
+		applied = append(applied, "update service endpoints, bootstrap peers and storage settings")
+	}
+	if from != Version {
+		raw[VersionKey] = Version
+	}
+	return applied, nil
+}
@@ -0,0 +1,372 @@
+// Package configschema checks a raw repo config against the config schema
+// of this build (types, unknown keys, conflicting options) and upgrades
+// configs written with older layouts.
+package configschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	config "github.com/TRON-US/go-btfs-config"
+	serialize "github.com/TRON-US/go-btfs-config/serialize"
+)
+
+// VersionKey is the top level config key holding the layout version.
+const VersionKey = "SchemaVersion"
+
+// Problem severities.
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// Problem is a single finding of Validate.
+type Problem struct {
+	Path    string
+	Level   string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("%s: %s", p.Level, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Level, p.Path, p.Message)
+}
+
+// HasErrors reports whether any of ps is an error.
+func HasErrors(ps []Problem) bool {
+	for _, p := range ps {
+		if p.Level == Error {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	sectionsLk sync.Mutex
+	sections   = map[string]reflect.Type{}
+)
+
+// RegisterSection declares a top level config section that is not part of
+// go-btfs-config, so that Validate knows its type instead of reporting it
+// as unknown. v is a value of the type the section decodes into.
+func RegisterSection(key string, v interface{}) {
+	sectionsLk.Lock()
+	defer sectionsLk.Unlock()
+	sections[key] = reflect.TypeOf(v)
+}
+
+// topLevel returns the json keys allowed at the top of the config and the
+// type of each.
+func topLevel() map[string]reflect.Type {
+	fields := fieldsOf(reflect.TypeOf(config.Config{}))
+	sectionsLk.Lock()
+	for k, t := range sections {
+		fields[k] = t
+	}
+	sectionsLk.Unlock()
+	fields[VersionKey] = reflect.TypeOf(0)
+	return fields
+}
+
+// ReadConfig reads the config file of the repo at cfgRoot without decoding
+// it into config.Config, so that it can be checked even when it does not
+// decode.
+func ReadConfig(cfgRoot string) (map[string]interface{}, error) {
+	filename, err := config.Filename(cfgRoot)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := serialize.ReadConfigFile(filename, &raw); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %s", filename, err)
+	}
+	return raw, nil
+}
+
+// Validate checks the raw config read from disk. Type mismatches and
+// conflicting options are errors; unknown keys and outdated layouts are
+// warnings.
+func Validate(raw map[string]interface{}) []Problem {
+	var ps []Problem
+	ps = append(ps, checkVersion(raw)...)
+	walkStruct("", topLevel(), raw, &ps)
+	if HasErrors(ps) {
+		// Conflicts are only meaningful on a config that decodes.
+		return ps
+	}
+	cfg, err := decode(raw)
+	if err != nil {
+		return append(ps, Problem{Level: Error, Message: err.Error()})
+	}
+	return append(ps, checkConflicts(cfg)...)
+}
+
+func checkVersion(raw map[string]interface{}) []Problem {
+	v, err := LayoutVersion(raw)
+	if err != nil {
+		return []Problem{{Path: VersionKey, Level: Error, Message: err.Error()}}
+	}
+	switch {
+	case v < Version:
+		return []Problem{{Path: VersionKey, Level: Warning,
+			Message: fmt.Sprintf("config layout version %d is older than %d, run 'btfs config migrate'", v, Version)}}
+	case v > Version:
+		return []Problem{{Path: VersionKey, Level: Error,
+			Message: fmt.Sprintf("config layout version %d was written by a newer btfs (this one supports %d)", v, Version)}}
+	}
+	return nil
+}
+
+// LayoutVersion returns the layout version of the raw config; configs from
+// before versioning are version 0.
+func LayoutVersion(raw map[string]interface{}) (int, error) {
+	v, ok := raw[VersionKey]
+	if !ok || v == nil {
+		return 0, nil
+	}
+	if i, ok := v.(int); ok && i >= 0 {
+		return i, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f != float64(int(f)) || f < 0 {
+		return 0, fmt.Errorf("expected a non-negative integer, got %s", jsonKind(v))
+	}
+	return int(f), nil
+}
+
+func decode(raw map[string]interface{}) (*config.Config, error) {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(config.Config)
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// walk checks that v, decoded from json, fits type t.
+func walk(path string, t reflect.Type, v interface{}, ps *[]Problem) {
+	if v == nil {
+		return
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) || t.Kind() == reflect.Interface {
+		checkDecode(path, t, v, ps)
+		return
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		walk(path, t.Elem(), v, ps)
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			typeProblem(path, "an object", v, ps)
+			return
+		}
+		walkStruct(path, fieldsOf(t), m, ps)
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			typeProblem(path, "an object", v, ps)
+			return
+		}
+		for _, k := range sortedKeys(m) {
+			walk(join(path, k), t.Elem(), m[k], ps)
+		}
+	case reflect.Slice, reflect.Array:
+		l, ok := v.([]interface{})
+		if !ok {
+			typeProblem(path, "a list", v, ps)
+			return
+		}
+		for i, e := range l {
+			walk(fmt.Sprintf("%s[%d]", path, i), t.Elem(), e, ps)
+		}
+	default:
+		checkDecode(path, t, v, ps)
+	}
+}
+
+func walkStruct(path string, fields map[string]reflect.Type, m map[string]interface{}, ps *[]Problem) {
+	for _, k := range sortedKeys(m) {
+		if ft, ok := fields[k]; ok {
+			walk(join(path, k), ft, m[k], ps)
+			continue
+		}
+		if name := matchFold(fields, k); name != "" {
+			*ps = append(*ps, Problem{Path: join(path, k), Level: Warning,
+				Message: fmt.Sprintf("key should be spelled %q", name)})
+			walk(join(path, k), fields[name], m[k], ps)
+			continue
+		}
+		msg := "unknown key, it is ignored"
+		if name := suggest(fields, k); name != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", name)
+		}
+		*ps = append(*ps, Problem{Path: join(path, k), Level: Warning, Message: msg})
+	}
+}
+
+func checkDecode(path string, t reflect.Type, v interface{}, ps *[]Problem) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		*ps = append(*ps, Problem{Path: path, Level: Error, Message: err.Error()})
+		return
+	}
+	if err := json.Unmarshal(b, reflect.New(t).Interface()); err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			typeProblem(path, typeName(t), v, ps)
+			return
+		}
+		*ps = append(*ps, Problem{Path: path, Level: Error, Message: err.Error()})
+	}
+}
+
+func typeProblem(path, want string, v interface{}, ps *[]Problem) {
+	*ps = append(*ps, Problem{Path: path, Level: Error,
+		Message: fmt.Sprintf("expected %s, got %s", want, jsonKind(v))})
+}
+
+// fieldsOf returns the json keys of struct type t and their types.
+func fieldsOf(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for k, ft := range fieldsOf(f.Type) {
+				fields[k] = ft
+			}
+			continue
+		}
+		name := f.Name
+		if tag != "" {
+			name = tag
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// matchFold returns the field spelled like k up to case. encoding/json
+// accepts these, but they are usually typos.
+func matchFold(fields map[string]reflect.Type, k string) string {
+	for name := range fields {
+		if strings.EqualFold(name, k) {
+			return name
+		}
+	}
+	return ""
+}
+
+// suggest returns the closest field name to k, if any is close enough.
+func suggest(fields map[string]reflect.Type, k string) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	best, bestDist := "", 3
+	for _, name := range names {
+		if d := distance(strings.ToLower(name), strings.ToLower(k)); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance of a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j] + 1
+			if d := cur[j-1] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if d := prev[j-1] + cost; d < cur[j] {
+				cur[j] = d
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return t.String()
+}
+
+func jsonKind(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprintf("boolean %t", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func join(path, k string) string {
+	if path == "" {
+		return k
+	}
+	return path + "." + k
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package configschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	config "github.com/TRON-US/go-btfs-config"
+)
+
+func rawConfig(t *testing.T, mutate func(raw map[string]interface{})) map[string]interface{} {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Addresses.API = config.Strings{"/ip4/127.0.0.1/tcp/5001"}
+	cfg.Addresses.Swarm = []string{"/ip4/0.0.0.0/tcp/4001"}
+	cfg.Datastore.StorageMax = "10GB"
+	cfg.Datastore.StorageGCWatermark = 90
+	cfg.Datastore.GCPeriod = "1h"
+	raw, err := config.ToMap(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Round trip like a config read from disk.
+	b, _ := json.Marshal(raw)
+	raw = nil
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	raw[VersionKey] = float64(Version)
+	if mutate != nil {
+		mutate(raw)
+	}
+	return raw
+}
+
+func findProblem(ps []Problem, path string) *Problem {
+	for i := range ps {
+		if ps[i].Path == path {
+			return &ps[i]
+		}
+	}
+	return nil
+}
+
+func TestValidateClean(t *testing.T) {
+	if ps := Validate(rawConfig(t, nil)); len(ps) != 0 {
+		t.Fatalf("unexpected problems: %v", ps)
+	}
+}
+
+func TestValidateTypes(t *testing.T) {
+	ps := Validate(rawConfig(t, func(raw map[string]interface{}) {
+		raw["Experimental"].(map[string]interface{})["StorageHostEnabled"] = "yes"
+		raw["Datastore"].(map[string]interface{})["StorageGCWatermark"] = "90"
+	}))
+	for _, path := range []string{"Experimental.StorageHostEnabled", "Datastore.StorageGCWatermark"} {
+		p := findProblem(ps, path)
+		if p == nil || p.Level != Error {
+			t.Errorf("%s: expected a type error, got %v", path, ps)
+		}
+	}
+}
+
+func TestValidateUnknownKeys(t *testing.T) {
+	RegisterSection("TestSection", map[string]string{})
+	ps := Validate(rawConfig(t, func(raw map[string]interface{}) {
+		raw["Experimental"].(map[string]interface{})["StorageHostEnable"] = true
+		raw["experimental"] = map[string]interface{}{}
+		raw["TestSection"] = map[string]interface{}{"a": "b"}
+	}))
+	p := findProblem(ps, "Experimental.StorageHostEnable")
+	if p == nil || p.Level != Warning || !strings.Contains(p.Message, "StorageHostEnabled") {
+		t.Errorf("expected an unknown key suggestion, got %v", ps)
+	}
+	if p := findProblem(ps, "experimental"); p == nil || !strings.Contains(p.Message, "Experimental") {
+		t.Errorf("expected a case mismatch warning, got %v", ps)
+	}
+	if p := findProblem(ps, "TestSection"); p != nil {
+		t.Errorf("registered section reported: %v", p)
+	}
+	if HasErrors(ps) {
+		t.Errorf("unknown keys must not be errors: %v", ps)
+	}
+}
+
+func TestValidateConflicts(t *testing.T) {
+	ps := Validate(rawConfig(t, func(raw map[string]interface{}) {
+		raw["Experimental"].(map[string]interface{})["StorageHostEnabled"] = true
+		raw["Routing"].(map[string]interface{})["Type"] = "none"
+		raw["Datastore"].(map[string]interface{})["GCPeriod"] = "often"
+	}))
+	if p := findProblem(ps, "Routing.Type"); p == nil || p.Level != Error {
+		t.Errorf("expected a routing conflict, got %v", ps)
+	}
+	if p := findProblem(ps, "Datastore.GCPeriod"); p == nil || p.Level != Error {
+		t.Errorf("expected an invalid duration, got %v", ps)
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	ps := Validate(rawConfig(t, func(raw map[string]interface{}) {
+		delete(raw, VersionKey)
+	}))
+	if p := findProblem(ps, VersionKey); p == nil || p.Level != Warning {
+		t.Errorf("expected an outdated layout warning, got %v", ps)
+	}
+	ps = Validate(rawConfig(t, func(raw map[string]interface{}) {
+		raw[VersionKey] = float64(Version + 1)
+	}))
+	if p := findProblem(ps, VersionKey); p == nil || p.Level != Error {
+		t.Errorf("expected a newer layout error, got %v", ps)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	raw := rawConfig(t, func(raw map[string]interface{}) {
+		delete(raw, VersionKey)
+		raw["Tour"] = map[string]interface{}{"Last": ""}
+		raw["Custom"] = "kept"
+	})
+	applied, err := Migrate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) < len(layoutMigrations) {
+		t.Errorf("expected every layout migration to apply, got %v", applied)
+	}
+	if _, ok := raw["Tour"]; ok {
+		t.Error("Tour was not removed")
+	}
+	if raw["Custom"] != "kept" {
+		t.Error("unknown section was dropped")
+	}
+	if v, _ := LayoutVersion(raw); v != Version {
+		t.Errorf("got layout version %d, want %d", v, Version)
+	}
+	if _, err := Migrate(rawConfig(t, func(raw map[string]interface{}) {
+		raw[VersionKey] = float64(Version + 1)
+	})); err == nil {
+		t.Error("expected migrating a newer config to fail")
+	}
+}
@@ -0,0 +1,222 @@
+package durability
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	badgerds "github.com/ipfs/go-ds-badger"
+	flatfs "github.com/ipfs/go-ds-flatfs"
+)
+
+// Backends benchmarked.
+const (
+	Flatfs   = "flatfs"
+	Badgerds = "badgerds"
+)
+
+// benchSyncInterval is the syncInterval benchmarked.
+const benchSyncInterval = time.Second
+
+// BenchOptions configures a benchmark of the datastore backends.
+type BenchOptions struct {
+	// Backends are the backends benchmarked, all when empty.
+	Backends  []string
+	Blocks    int
+	BlockSize int
+	// BatchNodes is the number of blocks written at once by the batched
+	// settings.
+	BatchNodes int
+}
+
+// BenchResult is the write throughput of a backend with some settings.
+type BenchResult struct {
+	Backend  string
+	Settings string
+	// Durability tells when the writes are synced.
+	Durability string
+	Blocks     int
+	Bytes      int64
+	Duration   time.Duration
+	// Throughput is in bytes per second.
+	Throughput float64
+	Error      string `json:",omitempty"`
+}
+
+type benchCase struct {
+	backend  string
+	sync     bool
+	interval time.Duration
+	batch    int
+}
+
+func (c benchCase) settings() string {
+	s := fmt.Sprintf("sync=%t", c.sync)
+	if c.backend == Badgerds {
+		s = fmt.Sprintf("syncWrites=%t", c.sync)
+	}
+	if c.interval > 0 {
+		s += fmt.Sprintf(" syncInterval=%s", c.interval)
+	}
+	return s + fmt.Sprintf(" batch=%d", c.batch)
+}
+
+func (c benchCase) durability() string {
+	switch {
+	case c.sync:
+		return "every write"
+	case c.interval > 0:
+		return "every " + c.interval.String()
+	case c.backend == Badgerds:
+		return "on close"
+	}
+	return "never"
+}
+
+func benchCases(opts BenchOptions) []benchCase {
+	backends := opts.Backends
+	if len(backends) == 0 {
+		backends = []string{Flatfs, Badgerds}
+	}
+	var cases []benchCase
+	for _, b := range backends {
+		cases = append(cases,
+			benchCase{backend: b, sync: true, batch: 1},
+			benchCase{backend: b, sync: true, batch: opts.BatchNodes},
+		)
+		if b != Flatfs || SyncFSSupported {
+			cases = append(cases, benchCase{backend: b, interval: benchSyncInterval, batch: opts.BatchNodes})
+		}
+		cases = append(cases, benchCase{backend: b, batch: opts.BatchNodes})
+	}
+	return cases
+}
+
+// Bench writes opts.Blocks blocks of opts.BlockSize to each backend with
+// the settings worth comparing, in temporary directories in dir, and
+// passes the results to out.
+func Bench(ctx context.Context, dir string, opts BenchOptions, out func(*BenchResult) error) error {
+	for _, b := range opts.Backends {
+		if b != Flatfs && b != Badgerds {
+			return fmt.Errorf("unknown backend %q", b)
+		}
+	}
+	if opts.Blocks <= 0 || opts.BlockSize < 8 || opts.BatchNodes <= 0 {
+		return fmt.Errorf("invalid benchmark size")
+	}
+	data := make([]byte, opts.BlockSize)
+	if _, err := rand.Read(data); err != nil {
+		return err
+	}
+	for _, c := range benchCases(opts) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r := &BenchResult{
+			Backend:    c.backend,
+			Settings:   c.settings(),
+			Durability: c.durability(),
+			Blocks:     opts.Blocks,
+			Bytes:      int64(opts.Blocks) * int64(opts.BlockSize),
+		}
+		d, err := benchCaseRun(ctx, dir, c, opts.Blocks, data)
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Duration = d
+			r.Throughput = float64(r.Bytes) / d.Seconds()
+		}
+		if err := out(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type benchStore interface {
+	ds.Batching
+	Close() error
+}
+
+func benchCaseRun(ctx context.Context, dir string, c benchCase, blocks int, data []byte) (time.Duration, error) {
+	tmp, err := ioutil.TempDir(dir, "bench-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(tmp)
+
+	var (
+		d    benchStore
+		sync func() error
+	)
+	switch c.backend {
+	case Flatfs:
+		fd, err := flatfs.CreateOrOpen(tmp, flatfs.NextToLast(2), c.sync)
+		if err != nil {
+			return 0, err
+		}
+		d, sync = fd, func() error { return SyncFS(tmp) }
+	case Badgerds:
+		opts := badgerds.DefaultOptions
+		opts.SyncWrites = c.sync
+		bd, err := badgerds.NewDatastore(tmp, &opts)
+		if err != nil {
+			return 0, err
+		}
+		d, sync = bd, func() error { return bd.Sync(ds.NewKey("/")) }
+	}
+	defer d.Close()
+
+	start := time.Now()
+	var syncer *Syncer
+	if c.interval > 0 {
+		syncer = StartSyncer(tmp, c.interval, sync)
+	}
+	for i := 0; i < blocks; i += c.batch {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		n := c.batch
+		if i+n > blocks {
+			n = blocks - i
+		}
+		if err := benchWrite(d, i, n, data); err != nil {
+			return 0, err
+		}
+	}
+	if syncer != nil {
+		syncer.Stop()
+		// the last writes are synced on close
+		if err := sync(); err != nil {
+			return 0, err
+		}
+	}
+	return time.Since(start), nil
+}
+
+// benchWrite writes the blocks first to first+n, at once when n > 1.
+func benchWrite(d ds.Batching, first, n int, data []byte) error {
+	put := func(i int) (ds.Key, []byte) {
+		// distinct blocks of the same size
+		binary.BigEndian.PutUint64(data, uint64(i))
+		return ds.NewKey(fmt.Sprintf("BENCH%010d", i)), append([]byte(nil), data...)
+	}
+	if n == 1 {
+		return d.Put(put(first))
+	}
+	b, err := d.Batch()
+	if err != nil {
+		return err
+	}
+	for i := first; i < first+n; i++ {
+		if err := b.Put(put(i)); err != nil {
+			return err
+		}
+	}
+	return b.Commit()
+}
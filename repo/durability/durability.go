@@ -0,0 +1,148 @@
+// Package durability holds the write batching and fsync controls of the
+// datastores of the repo, and a benchmark to choose them.
+//
+// The datastore backends take their fsync policy from Datastore.Spec:
+// "sync" for flatfs and "syncWrites" for badgerds sync every write, and
+// "syncInterval" syncs the writes of a backend not syncing every write
+// periodically instead, bounding the writes lost on a crash. The
+// DatastoreWrites config section sizes the batches 'btfs add' writes the
+// blocks in.
+//
+// None of the backends can bypass the page cache: flatfs and badger open
+// their files themselves, without O_DIRECT.
+package durability
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	"github.com/dustin/go-humanize"
+	ipld "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log"
+)
+
+var log = logging.Logger("durability")
+
+// ConfigKey is the config section of the write batching.
+const ConfigKey = "DatastoreWrites"
+
+// Defaults of the batches, those of go-ipld-format.
+const (
+	DefaultBatchNodes = 128
+	DefaultBatchSize  = "8MiB"
+)
+
+// MinSyncInterval is the shortest syncInterval of a backend.
+const MinSyncInterval = 10 * time.Millisecond
+
+// Config configures the batches the blocks are written in.
+type Config struct {
+	// BatchNodes is the number of blocks 'btfs add' buffers before writing
+	// them at once, 128 by default. Flatfs opens as many files at once.
+	BatchNodes int `json:",omitempty"`
+	// BatchSize is the size of the blocks buffered, "8MiB" by default.
+	BatchSize string `json:",omitempty"`
+
+	batchSize int
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+}
+
+// Load returns the write batching config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	if c.BatchNodes < 0 {
+		return fmt.Errorf("negative BatchNodes")
+	}
+	if c.BatchNodes == 0 {
+		c.BatchNodes = DefaultBatchNodes
+	}
+	if c.BatchSize == "" {
+		c.BatchSize = DefaultBatchSize
+	}
+	size, err := humanize.ParseBytes(c.BatchSize)
+	if err != nil || size == 0 {
+		return fmt.Errorf("invalid BatchSize %q", c.BatchSize)
+	}
+	c.batchSize = int(size)
+	return nil
+}
+
+// BatchOptions returns the options of the batches of c.
+func (c *Config) BatchOptions() []ipld.BatchOption {
+	return []ipld.BatchOption{
+		ipld.MaxNodesBatchOption(c.BatchNodes),
+		ipld.MaxSizeBatchOption(c.batchSize),
+	}
+}
+
+// ParseSyncInterval returns the "syncInterval" parameter of a datastore
+// spec, 0 when not set.
+func ParseSyncInterval(params map[string]interface{}) (time.Duration, error) {
+	v, ok := params["syncInterval"]
+	if !ok {
+		return 0, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("'syncInterval' field was not a string")
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < MinSyncInterval {
+		return 0, fmt.Errorf("invalid 'syncInterval' %q, at least %s", s, MinSyncInterval)
+	}
+	return d, nil
+}
+
+// Syncer syncs a datastore periodically.
+type Syncer struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// StartSyncer calls sync every interval until stopped. The errors are
+// logged for the datastore name.
+func StartSyncer(name string, interval time.Duration, sync func() error) *Syncer {
+	s := &Syncer{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if err := sync(); err != nil {
+					log.Errorf("cannot sync datastore %s: %s", name, err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// Stop stops the syncer and waits for a sync in progress.
+func (s *Syncer) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
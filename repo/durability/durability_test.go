@@ -0,0 +1,92 @@
+package durability
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfig(t *testing.T) {
+	c := &Config{}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+	if c.BatchNodes != DefaultBatchNodes || c.batchSize != 8<<20 {
+		t.Fatalf("unexpected defaults %+v", c)
+	}
+	for _, c := range []*Config{{BatchNodes: -1}, {BatchSize: "big"}, {BatchSize: "0"}} {
+		if err := c.compile(); err == nil {
+			t.Errorf("accepted %+v", c)
+		}
+	}
+}
+
+func TestParseSyncInterval(t *testing.T) {
+	if d, err := ParseSyncInterval(map[string]interface{}{}); d != 0 || err != nil {
+		t.Fatalf("unexpected interval %s, %v", d, err)
+	}
+	if d, err := ParseSyncInterval(map[string]interface{}{"syncInterval": "2s"}); d != 2*time.Second || err != nil {
+		t.Fatalf("unexpected interval %s, %v", d, err)
+	}
+	for _, v := range []interface{}{true, "1ns", "soon"} {
+		if _, err := ParseSyncInterval(map[string]interface{}{"syncInterval": v}); err == nil {
+			t.Errorf("accepted %v", v)
+		}
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	var syncs int32
+	s := StartSyncer("test", MinSyncInterval, func() error {
+		atomic.AddInt32(&syncs, 1)
+		return nil
+	})
+	time.Sleep(10 * MinSyncInterval)
+	s.Stop()
+	s.Stop()
+	n := atomic.LoadInt32(&syncs)
+	if n == 0 {
+		t.Fatal("never synced")
+	}
+	time.Sleep(3 * MinSyncInterval)
+	if atomic.LoadInt32(&syncs) != n {
+		t.Fatal("synced after being stopped")
+	}
+}
+
+func TestBench(t *testing.T) {
+	dir, err := ioutil.TempDir("", "durability")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var results []*BenchResult
+	opts := BenchOptions{Blocks: 20, BlockSize: 1024, BatchNodes: 8}
+	err = Bench(context.Background(), dir, opts, func(r *BenchResult) error {
+		results = append(results, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(benchCases(opts)) {
+		t.Fatalf("expected %d results, got %d", len(benchCases(opts)), len(results))
+	}
+	for _, r := range results {
+		if r.Error != "" || r.Throughput <= 0 || r.Bytes != 20*1024 {
+			t.Errorf("unexpected result %+v", r)
+		}
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 0 {
+		t.Fatal("benchmark datastores left behind")
+	}
+
+	opts.Backends = []string{"leveldb"}
+	if err := Bench(context.Background(), dir, opts, nil); err == nil {
+		t.Fatal("benchmarked an unknown backend")
+	}
+}
@@ -0,0 +1,20 @@
+package durability
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// SyncFSSupported tells whether SyncFS is supported.
+const SyncFSSupported = true
+
+// SyncFS flushes the writes of the filesystem of the directory dir.
+func SyncFS(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return unix.Syncfs(int(f.Fd()))
+}
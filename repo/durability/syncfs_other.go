@@ -0,0 +1,17 @@
+// +build !linux
+
+package durability
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// SyncFSSupported tells whether SyncFS is supported.
+const SyncFSSupported = false
+
+// SyncFS flushes the writes of the filesystem of the directory dir, only
+// on linux.
+func SyncFS(dir string) error {
+	return fmt.Errorf("syncing a filesystem is not supported on %s", runtime.GOOS)
+}
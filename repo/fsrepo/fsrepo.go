@@ -13,6 +13,7 @@ import (
 	keystore "github.com/TRON-US/go-btfs/keystore"
 	repo "github.com/TRON-US/go-btfs/repo"
 	"github.com/TRON-US/go-btfs/repo/common"
+	"github.com/TRON-US/go-btfs/repo/configschema"
 	mfsr "github.com/TRON-US/go-btfs/repo/fsrepo/migrations"
 	dir "github.com/TRON-US/go-btfs/thirdparty/dir"
 
@@ -244,7 +245,13 @@ func initConfig(path string, conf *config.Config) error {
 	// initialization is the one time when it's okay to write to the config
 	// without reading the config from disk and merging any user-provided keys
 	// that may exist.
-	if err := serialize.WriteConfigFile(configFilename, conf); err != nil {
+	m, err := config.ToMap(conf)
+	if err != nil {
+		return err
+	}
+	// New configs start out with the current layout.
+	m[configschema.VersionKey] = configschema.Version
+	if err := serialize.WriteConfigFile(configFilename, m); err != nil {
 		return err
 	}
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	keystore "github.com/TRON-US/go-btfs/keystore"
+	"github.com/TRON-US/go-btfs/repo/common"
 
 	config "github.com/TRON-US/go-btfs-config"
 	filestore "github.com/ipfs/go-filestore"
@@ -40,7 +41,11 @@ func (m *Mock) SetConfigKey(key string, value interface{}) error {
 }
 
 func (m *Mock) GetConfigKey(key string) (interface{}, error) {
-	return nil, errTODO
+	mapconf, err := config.ToMap(&m.C)
+	if err != nil {
+		return nil, err
+	}
+	return common.MapGetKV(mapconf, key)
 }
 
 func (m *Mock) Datastore() Datastore { return m.D }
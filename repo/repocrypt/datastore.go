@@ -0,0 +1,88 @@
+package repocrypt
+
+import (
+	ds "github.com/ipfs/go-datastore"
+	dsq "github.com/ipfs/go-datastore/query"
+)
+
+// Datastore seals the values put into a datastore with a Crypt, and opens
+// the values it gets. The blockstore is built on one.
+type Datastore struct {
+	ds.Batching
+	c *Crypt
+}
+
+// NewDatastore returns d sealing its values with c.
+func NewDatastore(d ds.Batching, c *Crypt) *Datastore {
+	return &Datastore{Batching: d, c: c}
+}
+
+func (d *Datastore) Get(k ds.Key) ([]byte, error) {
+	v, err := d.Batching.Get(k)
+	if err != nil {
+		return nil, err
+	}
+	return d.c.Open(v)
+}
+
+// GetSize returns the size of the value of k in the clear, reading it when
+// it is sealed.
+func (d *Datastore) GetSize(k ds.Key) (int, error) {
+	if !d.c.Unlocked() {
+		return d.Batching.GetSize(k)
+	}
+	v, err := d.Get(k)
+	if err != nil {
+		return -1, err
+	}
+	return len(v), nil
+}
+
+func (d *Datastore) Put(k ds.Key, v []byte) error {
+	v, err := d.c.Seal(v)
+	if err != nil {
+		return err
+	}
+	return d.Batching.Put(k, v)
+}
+
+func (d *Datastore) Query(q dsq.Query) (dsq.Results, error) {
+	res, err := d.Batching.Query(q)
+	if err != nil || q.KeysOnly {
+		return res, err
+	}
+	return dsq.ResultsFromIterator(q, dsq.Iterator{
+		Next: func() (dsq.Result, bool) {
+			r, ok := res.NextSync()
+			if ok && r.Error == nil {
+				r.Value, r.Error = d.c.Open(r.Value)
+				if r.Error == nil {
+					r.Size = len(r.Value)
+				}
+			}
+			return r, ok
+		},
+		Close: res.Close,
+	}), nil
+}
+
+func (d *Datastore) Batch() (ds.Batch, error) {
+	b, err := d.Batching.Batch()
+	if err != nil {
+		return nil, err
+	}
+	return &batch{Batch: b, c: d.c}, nil
+}
+
+type batch struct {
+	ds.Batch
+	c *Crypt
+}
+
+func (b *batch) Put(k ds.Key, v []byte) error {
+	v, err := b.c.Seal(v)
+	if err != nil {
+		return err
+	}
+	return b.Batch.Put(k, v)
+}
@@ -0,0 +1,143 @@
+package repocrypt
+
+import (
+	"context"
+
+	"github.com/TRON-US/go-btfs/keystore"
+	"github.com/TRON-US/go-btfs/repo"
+
+	ds "github.com/ipfs/go-datastore"
+	dsq "github.com/ipfs/go-datastore/query"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+)
+
+// progressEvery is the number of blocks between the progress reports of a
+// migration.
+const progressEvery = 1000
+
+// Progress reports a migration of the contents of the repo.
+type Progress struct {
+	// Blocks is the number of blocks visited, Sealed the number of them
+	// sealed by the migration.
+	Blocks int
+	Sealed int
+	// Bytes is the size of the blocks sealed.
+	Bytes int64
+	// Keys is the number of keys sealed, once the blocks are.
+	Keys int
+	Done bool
+}
+
+// Status is the encryption of the contents of the repo.
+type Status struct {
+	Enabled      bool
+	KeySource    string
+	Unlocked     bool
+	Blocks       int
+	SealedBlocks int
+	Keys         int
+	SealedKeys   int
+}
+
+// Migrate seals the blocks and the keys of r still in the clear with c, in
+// place while the node runs. progress is called every thousand blocks and
+// once done.
+func Migrate(ctx context.Context, r repo.Repo, c *Crypt, progress func(Progress) error) error {
+	if !c.Unlocked() {
+		return ErrLocked
+	}
+	p := Progress{}
+	err := forEachBlock(ctx, r.Datastore(), func(k ds.Key) error {
+		p.Blocks++
+		v, err := r.Datastore().Get(k)
+		if err == ds.ErrNotFound {
+			// collected meanwhile
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !c.Sealed(v) {
+			sealed, err := c.Seal(v)
+			if err != nil {
+				return err
+			}
+			if err := r.Datastore().Put(k, sealed); err != nil {
+				return err
+			}
+			p.Sealed++
+			p.Bytes += int64(len(v))
+		}
+		if p.Blocks%progressEvery == 0 {
+			return progress(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := r.Datastore().Sync(ds.NewKey(blockstore.BlockPrefix.String())); err != nil {
+		return err
+	}
+	if ks, ok := r.Keystore().(*keystore.FSKeystore); ok {
+		if p.Keys, err = ks.SealAll(); err != nil {
+			return err
+		}
+	}
+	log.Infof("repo migrated: %d blocks and %d keys sealed", p.Sealed, p.Keys)
+	p.Done = true
+	return progress(p)
+}
+
+// GetStatus counts the blocks and the keys of r sealed by c.
+func GetStatus(ctx context.Context, r repo.Repo, c *Crypt) (*Status, error) {
+	cfg, err := Load(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Status{Enabled: cfg.Enabled, KeySource: cfg.KeySource, Unlocked: c.Unlocked()}
+	err = forEachBlock(ctx, r.Datastore(), func(k ds.Key) error {
+		v, err := r.Datastore().Get(k)
+		if err == ds.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.Blocks++
+		if c.Sealed(v) {
+			s.SealedBlocks++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ks, ok := r.Keystore().(*keystore.FSKeystore); ok {
+		if s.SealedKeys, s.Keys, err = ks.CountSealed(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// forEachBlock calls f with the datastore key of each block.
+func forEachBlock(ctx context.Context, d ds.Datastore, f func(ds.Key) error) error {
+	res, err := d.Query(dsq.Query{Prefix: blockstore.BlockPrefix.String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f(ds.RawKey(r.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repocrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PasswordWrapperName is the name of the wrapper of the wallet password.
+const PasswordWrapperName = "password"
+
+// scrypt parameters of the key derived from the password.
+const (
+	scryptN   = 1 << 15
+	scryptR   = 8
+	scryptP   = 1
+	saltSize  = 16
+	kekLength = 32
+)
+
+type passwordWrapper struct {
+	password []byte
+}
+
+// NewPasswordWrapper returns a wrapper whose key encryption key is derived
+// from the wallet password with scrypt. The random salt is stored with the
+// wrapped key.
+func NewPasswordWrapper(password string) (Wrapper, error) {
+	if password == "" {
+		return nil, errors.New("the wallet password is empty")
+	}
+	return &passwordWrapper{password: []byte(password)}, nil
+}
+
+func (w *passwordWrapper) Name() string {
+	return PasswordWrapperName
+}
+
+func (w *passwordWrapper) gcm(salt []byte) (cipher.AEAD, error) {
+	kek, err := scrypt.Key(w.password, salt, scryptN, scryptR, scryptP, kekLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (w *passwordWrapper) Wrap(_ context.Context, key []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := w.gcm(salt)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, saltSize+gcm.NonceSize())
+	copy(out, salt)
+	nonce := out[saltSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(out, nonce, key, nil), nil
+}
+
+func (w *passwordWrapper) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < saltSize {
+		return nil, ErrDecrypt
+	}
+	gcm, err := w.gcm(wrapped[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	sealed := wrapped[saltSize:]
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	key, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("wrong wallet password")
+	}
+	return key, nil
+}
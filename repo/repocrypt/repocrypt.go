@@ -0,0 +1,338 @@
+// Package repocrypt encrypts the blocks and the keys of the repo at rest.
+//
+// The contents are sealed with AES-GCM under a random repo key, which is
+// stored in the datastore wrapped by a key encryption key: one derived from
+// the wallet password, or one kept by the KMS of the Keys config section.
+// Sealed contents start with a magic header, so that a repo is encrypted in
+// place: the contents written in the clear before are read as they are until
+// Migrate seals them.
+package repocrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TRON-US/go-btfs/keystore"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/configschema"
+
+	ds "github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var log = logging.Logger("repocrypt")
+
+// ConfigKey is the config section of the repo encryption.
+const ConfigKey = "RepoEncryption"
+
+// Sources of the key encryption key.
+const (
+	PasswordSource = "password"
+	KMSSource      = "kms"
+)
+
+// PasswordEnv is the environment variable the wallet password of an
+// encrypted repo is read from when the daemon starts.
+const PasswordEnv = "BTFS_WALLET_PASSWORD"
+
+// keyKey is where the wrapped repo key is stored in the datastore.
+var keyKey = ds.NewKey("/btfs/repocrypt/key")
+
+// magicHeader starts the sealed contents.
+const magicHeader = "\x00BTFSENC\x01"
+
+var magic = []byte(magicHeader)
+
+// Overhead is the size sealing adds to contents: the header, the nonce and
+// the tag.
+const Overhead = len(magicHeader) + 12 + 16
+
+var (
+	// ErrLocked is returned when sealed contents are read before the repo
+	// key is unlocked.
+	ErrLocked = errors.New("the repo is encrypted and its key is not unlocked")
+	// ErrNoKey is returned when unlocking a repo that was never encrypted.
+	ErrNoKey = errors.New("the repo has no encryption key")
+	// ErrDecrypt is returned when sealed contents do not decrypt, they
+	// were altered or sealed with another key.
+	ErrDecrypt = errors.New("cannot decrypt repo contents, wrong key or altered contents")
+)
+
+// Config configures the repo encryption.
+type Config struct {
+	Enabled bool
+	// KeySource is where the key encryption key comes from: "password",
+	// the default, for the wallet password, or "kms" for the KMS of the Keys
+	// config section.
+	KeySource string `json:",omitempty"`
+}
+
+func init() {
+	configschema.RegisterSection(ConfigKey, Config{})
+	prometheus.MustRegister(operations, opBytes, opDuration)
+}
+
+var (
+	operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "repocrypt",
+		Name:      "operations_total",
+		Help:      "Repo contents sealed and opened, by operation.",
+	}, []string{"op"})
+	opBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "btfs",
+		Subsystem: "repocrypt",
+		Name:      "bytes_total",
+		Help:      "Size of the repo contents sealed and opened, by operation.",
+	}, []string{"op"})
+	opDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "btfs",
+		Subsystem: "repocrypt",
+		Name:      "duration_seconds",
+		Help:      "Time spent sealing and opening repo contents, by operation.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 8),
+	}, []string{"op"})
+)
+
+// Load returns the repo encryption config of r, with its defaults.
+func Load(r repo.Repo) (*Config, error) {
+	c := &Config{}
+	if _, err := repo.GetConfigSection(r, ConfigKey, c); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %s", ConfigKey, err)
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	switch c.KeySource {
+	case "":
+		c.KeySource = PasswordSource
+	case PasswordSource, KMSSource:
+	default:
+		return fmt.Errorf("unknown KeySource %q", c.KeySource)
+	}
+	return nil
+}
+
+// Wrapper encrypts and decrypts the repo key with a key encryption key it
+// keeps. The wrappers of the dek package are Wrappers.
+type Wrapper interface {
+	// Name identifies the key encryption key, the repo key can only be
+	// unwrapped by a wrapper of the same name.
+	Name() string
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// keyRecord is the repo key as stored in the datastore.
+type keyRecord struct {
+	Wrapper string
+	Wrapped []byte
+	Created time.Time
+}
+
+// Crypt seals and opens the contents of a repo. It passes the contents
+// through as they are until the repo key is unlocked.
+type Crypt struct {
+	lk      sync.RWMutex
+	aead    cipher.AEAD
+	wrapper string
+}
+
+// Default is the Crypt of the repo of the node.
+var Default = &Crypt{}
+
+// Unlocked tells whether the repo key is unlocked, and the contents sealed.
+func (c *Crypt) Unlocked() bool {
+	return c.getAEAD() != nil
+}
+
+// Wrapper returns the name of the wrapper of the unlocked repo key.
+func (c *Crypt) Wrapper() string {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
+	return c.wrapper
+}
+
+func (c *Crypt) getAEAD() cipher.AEAD {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
+	return c.aead
+}
+
+// Sealed tells whether data is sealed.
+func (c *Crypt) Sealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Seal encrypts data, it returns data as is while the repo key is locked.
+func (c *Crypt) Seal(data []byte) ([]byte, error) {
+	aead := c.getAEAD()
+	if aead == nil {
+		return data, nil
+	}
+	defer observe("seal", len(data), time.Now())
+	out := make([]byte, len(magic)+aead.NonceSize(), len(data)+Overhead)
+	copy(out, magic)
+	nonce := out[len(magic):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, data, nil), nil
+}
+
+// Open decrypts data when it is sealed, and returns it as is otherwise.
+func (c *Crypt) Open(data []byte) ([]byte, error) {
+	if !c.Sealed(data) {
+		return data, nil
+	}
+	aead := c.getAEAD()
+	if aead == nil {
+		return nil, ErrLocked
+	}
+	defer observe("open", len(data), time.Now())
+	sealed := data[len(magic):]
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	out, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return out, nil
+}
+
+func observe(op string, size int, start time.Time) {
+	operations.WithLabelValues(op).Inc()
+	opBytes.WithLabelValues(op).Add(float64(size))
+	opDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
+
+// Unlock unwraps the repo key of r with w, and seals the contents of r from
+// then on.
+func (c *Crypt) Unlock(ctx context.Context, r repo.Repo, w Wrapper) error {
+	b, err := r.Datastore().Get(keyKey)
+	if err == ds.ErrNotFound {
+		return ErrNoKey
+	}
+	if err != nil {
+		return err
+	}
+	rec := &keyRecord{}
+	if err := json.Unmarshal(b, rec); err != nil {
+		return fmt.Errorf("invalid repo key: %s", err)
+	}
+	if rec.Wrapper != w.Name() {
+		return fmt.Errorf("the repo key is wrapped by %q, not %q", rec.Wrapper, w.Name())
+	}
+	key, err := w.Unwrap(ctx, rec.Wrapped)
+	if err != nil {
+		return fmt.Errorf("cannot unwrap the repo key: %s", err)
+	}
+	return c.set(r, key, rec.Wrapper)
+}
+
+// Enable creates the repo key of r, wraps it with w and turns the
+// encryption of r on. It only unlocks the repo key when r already has one.
+// The contents already in r stay in the clear until Migrate.
+func (c *Crypt) Enable(ctx context.Context, r repo.Repo, w Wrapper, source string) error {
+	if err := c.Unlock(ctx, r, w); err != ErrNoKey {
+		if err != nil {
+			return err
+		}
+		return repo.SetConfigSection(r, ConfigKey, &Config{Enabled: true, KeySource: source})
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	wrapped, err := w.Wrap(ctx, key)
+	if err != nil {
+		return fmt.Errorf("cannot wrap the repo key: %s", err)
+	}
+	b, err := json.Marshal(&keyRecord{Wrapper: w.Name(), Wrapped: wrapped, Created: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := putKey(r, b); err != nil {
+		return err
+	}
+	if err := repo.SetConfigSection(r, ConfigKey, &Config{Enabled: true, KeySource: source}); err != nil {
+		return err
+	}
+	log.Infof("repo encryption enabled, key wrapped by %s", w.Name())
+	return c.set(r, key, w.Name())
+}
+
+// Rewrap wraps the repo key of r, unwrapped with old, with w instead, e.g.
+// when the wallet password changes. It returns the function storing the
+// key wrapped with old back, to roll the change back.
+func Rewrap(ctx context.Context, r repo.Repo, old, w Wrapper) (rollback func() error, err error) {
+	prev, err := r.Datastore().Get(keyKey)
+	if err == ds.ErrNotFound {
+		return nil, ErrNoKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec := &keyRecord{}
+	if err := json.Unmarshal(prev, rec); err != nil {
+		return nil, fmt.Errorf("invalid repo key: %s", err)
+	}
+	if rec.Wrapper != old.Name() {
+		return nil, fmt.Errorf("the repo key is wrapped by %q, not %q", rec.Wrapper, old.Name())
+	}
+	key, err := old.Unwrap(ctx, rec.Wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unwrap the repo key: %s", err)
+	}
+	if rec.Wrapped, err = w.Wrap(ctx, key); err != nil {
+		return nil, fmt.Errorf("cannot wrap the repo key: %s", err)
+	}
+	rec.Wrapper = w.Name()
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := putKey(r, b); err != nil {
+		return nil, err
+	}
+	return func() error { return putKey(r, prev) }, nil
+}
+
+func putKey(r repo.Repo, b []byte) error {
+	if err := r.Datastore().Put(keyKey, b); err != nil {
+		return err
+	}
+	return r.Datastore().Sync(keyKey)
+}
+
+func (c *Crypt) set(r repo.Repo, key []byte, wrapper string) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	c.lk.Lock()
+	c.aead, c.wrapper = aead, wrapper
+	c.lk.Unlock()
+	if ks, ok := r.Keystore().(*keystore.FSKeystore); ok {
+		ks.SetSealer(c)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package repocrypt
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/TRON-US/go-btfs/keystore"
+	"github.com/TRON-US/go-btfs/repo"
+	"github.com/TRON-US/go-btfs/repo/common"
+
+	blocks "github.com/ipfs/go-block-format"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// testRepo keeps the config sections the mock repo cannot set.
+type testRepo struct {
+	repo.Mock
+	sections map[string]interface{}
+}
+
+func (r *testRepo) SetConfigKey(key string, value interface{}) error {
+	r.sections[key] = value
+	return nil
+}
+
+func (r *testRepo) GetConfigKey(key string) (interface{}, error) {
+	if v, ok := r.sections[key]; ok {
+		return v, nil
+	}
+	return nil, &common.KeyNotFoundError{}
+}
+
+func newTestRepo(t *testing.T) (*testRepo, func()) {
+	dir, err := ioutil.TempDir("", "repocrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := keystore.NewFSKeystore(dir + "/keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &testRepo{sections: map[string]interface{}{}}
+	r.D = dssync.MutexWrap(ds.NewMapDatastore())
+	r.K = ks
+	return r, func() { os.RemoveAll(dir) }
+}
+
+func TestCrypt(t *testing.T) {
+	r, cleanup := newTestRepo(t)
+	defer cleanup()
+	c := &Crypt{}
+	data := []byte("some block")
+	if out, err := c.Seal(data); err != nil || !bytes.Equal(out, data) {
+		t.Fatal("sealed while locked")
+	}
+	if err := c.set(r, make([]byte, 32), "test"); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := c.Seal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Sealed(sealed) || len(sealed) != len(data)+Overhead {
+		t.Fatalf("unexpected sealed contents %x", sealed)
+	}
+	if out, err := c.Open(sealed); err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("opened %q, %v", out, err)
+	}
+	if out, err := c.Open(data); err != nil || !bytes.Equal(out, data) {
+		t.Fatal("contents in the clear not read as they are")
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := c.Open(sealed); err != ErrDecrypt {
+		t.Fatalf("opened altered contents: %v", err)
+	}
+	if _, err := (&Crypt{}).Open(sealed); err != ErrLocked {
+		t.Fatalf("opened sealed contents while locked: %v", err)
+	}
+}
+
+func TestEnableUnlock(t *testing.T) {
+	r, cleanup := newTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+	w, err := NewPasswordWrapper("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Crypt{}).Unlock(ctx, r, w); err != ErrNoKey {
+		t.Fatalf("unlocked a repo never encrypted: %v", err)
+	}
+	c := &Crypt{}
+	if err := c.Enable(ctx, r, w, PasswordSource); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(r)
+	if err != nil || !cfg.Enabled || cfg.KeySource != PasswordSource {
+		t.Fatalf("unexpected config %+v, %v", cfg, err)
+	}
+	sealed, err := c.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again := &Crypt{}
+	if err := again.Unlock(ctx, r, w); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := again.Open(sealed); err != nil || string(out) != "data" {
+		t.Fatal("the repo key changed")
+	}
+	wrong, _ := NewPasswordWrapper("wrong")
+	if err := (&Crypt{}).Unlock(ctx, r, wrong); err == nil {
+		t.Fatal("unlocked with the wrong password")
+	}
+	if err := (&Crypt{}).Unlock(ctx, r, otherWrapper{}); err == nil {
+		t.Fatal("unlocked with another wrapper")
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	r, cleanup := newTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+	old, _ := NewPasswordWrapper("old")
+	w, _ := NewPasswordWrapper("new")
+	if _, err := Rewrap(ctx, r, old, w); err != ErrNoKey {
+		t.Fatalf("rewrapped a repo never encrypted: %v", err)
+	}
+	c := &Crypt{}
+	if err := c.Enable(ctx, r, old, PasswordSource); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := c.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Rewrap(ctx, r, w, old); err == nil {
+		t.Fatal("rewrapped with the wrong password")
+	}
+
+	rollback, err := Rewrap(ctx, r, old, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := &Crypt{}
+	if err := again.Unlock(ctx, r, w); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := again.Open(sealed); err != nil || string(out) != "data" {
+		t.Fatal("the repo key changed")
+	}
+	if err := (&Crypt{}).Unlock(ctx, r, old); err == nil {
+		t.Fatal("unlocked with the old password")
+	}
+
+	if err := rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Crypt{}).Unlock(ctx, r, old); err != nil {
+		t.Fatalf("old password not restored: %v", err)
+	}
+}
+
+type otherWrapper struct{}
+
+func (otherWrapper) Name() string { return "other" }
+
+func (otherWrapper) Wrap(_ context.Context, key []byte) ([]byte, error) { return key, nil }
+
+func (otherWrapper) Unwrap(_ context.Context, key []byte) ([]byte, error) { return key, nil }
+
+func TestMigrate(t *testing.T) {
+	r, cleanup := newTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+	c := &Crypt{}
+	bs := blockstore.NewBlockstore(NewDatastore(r.D, c))
+
+	old := blocks.NewBlock([]byte("written in the clear"))
+	if err := bs.Put(old); err != nil {
+		t.Fatal(err)
+	}
+	priv, _, err := ic.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.K.Put("old", priv); err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ := NewPasswordWrapper("secret")
+	if err := c.Enable(ctx, r, w, PasswordSource); err != nil {
+		t.Fatal(err)
+	}
+	fresh := blocks.NewBlock([]byte("written sealed"))
+	if err := bs.Put(fresh); err != nil {
+		t.Fatal(err)
+	}
+	s, err := GetStatus(ctx, r, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Blocks != 2 || s.SealedBlocks != 1 || s.Keys != 1 || s.SealedKeys != 0 {
+		t.Fatalf("unexpected status %+v", s)
+	}
+
+	var last Progress
+	if err := Migrate(ctx, r, c, func(p Progress) error {
+		last = p
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !last.Done || last.Blocks != 2 || last.Sealed != 1 || last.Keys != 1 {
+		t.Fatalf("unexpected progress %+v", last)
+	}
+	if s, _ = GetStatus(ctx, r, c); s.SealedBlocks != 2 || s.SealedKeys != 1 {
+		t.Fatalf("contents left in the clear %+v", s)
+	}
+	for _, b := range []blocks.Block{old, fresh} {
+		got, err := bs.Get(b.Cid())
+		if err != nil || !bytes.Equal(got.RawData(), b.RawData()) {
+			t.Fatalf("cannot read %s back: %v", b.Cid(), err)
+		}
+		if size, err := bs.GetSize(b.Cid()); err != nil || size != len(b.RawData()) {
+			t.Fatalf("unexpected size %d, %v", size, err)
+		}
+	}
+	got, err := r.K.Get("old")
+	if err != nil || !got.Equals(priv) {
+		t.Fatalf("cannot read the key back: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/access"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+
+	"github.com/ipfs/go-blockservice"
+	cidlib "github.com/ipfs/go-cid"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	"github.com/ipfs/go-merkledag"
+)
+
+const (
+	accessFlushPeriod  = time.Minute
+	accessFlushTimeout = 10 * time.Minute
+	// the index follows the contracts, synced hourly
+	accessIndexPeriod = time.Hour
+)
+
+// Access records the retrievals of the files stored or owned by the node,
+// see 'btfs storage files stats'.
+func Access(node *core.IpfsNode) {
+	var indexed time.Time
+	go periodicHostSync(accessFlushPeriod, accessFlushTimeout, "access stats",
+		func(ctx context.Context) error {
+			d := node.Repo.Datastore()
+			self := node.Identity.Pretty()
+			if err := access.Default.Load(d, self); err != nil {
+				return err
+			}
+			if time.Since(indexed) >= accessIndexPeriod {
+				if err := indexAccess(ctx, node); err != nil {
+					return err
+				}
+				indexed = time.Now()
+			}
+			return access.Default.Flush(d, self)
+		})
+}
+
+// indexAccess indexes the blocks of the files of the contracts of the node
+// found in its blockstore.
+func indexAccess(ctx context.Context, node *core.IpfsNode) error {
+	d := node.Repo.Datastore()
+	self := node.Identity.Pretty()
+	ng := merkledag.NewDAGService(blockservice.New(node.Blockstore, offline.Exchange(node.Blockstore)))
+	index := access.NewIndex()
+	for role, r := range map[string]nodepb.ContractStat_Role{
+		access.RoleHost:   nodepb.ContractStat_HOST,
+		access.RoleRenter: nodepb.ContractStat_RENTER,
+	} {
+		cs, err := contracts.ListContracts(d, self, r.String())
+		if err != nil {
+			return err
+		}
+		added := map[string]bool{}
+		for _, c := range cs {
+			// hosts serve their shards, renters the whole file
+			root := c.FileHash
+			if role == access.RoleHost {
+				root = c.ShardHash
+			}
+			if added[root] {
+				continue
+			}
+			added[root] = true
+			rc, err := cidlib.Parse(root)
+			if err != nil {
+				continue
+			}
+			if ok, err := node.Blockstore.Has(rc); err != nil || !ok {
+				continue
+			}
+			if err := index.Add(ctx, ng, role, c.FileHash, rc); err != nil {
+				log.Debugf("index the blocks of %s: %s", root, err)
+			}
+		}
+	}
+	access.Default.SetIndex(index)
+	return nil
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/storage/announce"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 
 	config "github.com/TRON-US/go-btfs-config"
@@ -25,9 +27,13 @@ import (
 )
 
 type dcWrap struct {
-	node   *core.IpfsNode
-	pn     *nodepb.Node
-	config *config.Config
+	// mu guards pn, updated by the heartbeats and the announcements forced
+	// with 'btfs storage announce --now'
+	mu      sync.Mutex
+	node    *core.IpfsNode
+	pn      *nodepb.Node
+	config  *config.Config
+	cfgRoot string
 }
 
 //Server URL for data collection
@@ -78,6 +84,7 @@ func Analytics(cfgRoot string, node *core.IpfsNode, BTFSVersion, hValue string)
 	dc.node = node
 	dc.pn = new(nodepb.Node)
 	dc.config = configuration
+	dc.cfgRoot = cfgRoot
 
 	if isAnalyticsEnabled(dc.config) {
 		if dc.config.Experimental.Analytics != dc.config.Experimental.StorageHostEnabled {
@@ -125,6 +132,16 @@ func Analytics(cfgRoot string, node *core.IpfsNode, BTFSVersion, hValue string)
 	}
 
 	dc.setRoles()
+	announce.RegisterAnnouncer(func(ctx context.Context) (*announce.Announcement, error) {
+		config, err := dc.node.Repo.Config()
+		if err != nil {
+			return nil, err
+		}
+		if !isAnalyticsEnabled(config) {
+			return nil, fmt.Errorf("announcements are disabled, enable the storage host or analytics")
+		}
+		return dc.announce(ctx, config)
+	})
 	go dc.collectionAgent(node)
 }
 
@@ -175,6 +192,22 @@ func (dc *dcWrap) update(node *core.IpfsNode) []error {
 		dc.pn.ChallengeCustomizedPricing = ns.ChallengeCustomizedPricing
 	}
 
+	// the capacity and roles may have changed since the daemon started,
+	// see 'btfs storage announce'
+	if cfg, err := node.Repo.Config(); err == nil {
+		dc.pn.StorageClientEnabled = cfg.Experimental.StorageClientEnabled
+		dc.pn.StorageHostEnabled = cfg.Experimental.StorageHostEnabled
+		dc.pn.RepairHostEnabled = cfg.Experimental.HostRepairEnabled
+		dc.pn.ChallengeHostEnabled = cfg.Experimental.HostChallengeEnabled
+		dc.setRoles()
+	}
+	if dc.cfgRoot != "" {
+		if storageMax, err := helper.CheckAndValidateHostStorageMax(ctx, dc.cfgRoot,
+			node.Repo, nil, true); err == nil {
+			dc.pn.StorageVolumeCap = storageMax
+		}
+	}
+
 	dc.pn.UpTime = durationToSeconds(time.Since(dc.pn.TimeCreated))
 	if cpus, err := cpu.Percent(0, false); err != nil {
 		res = append(res, fmt.Errorf("failed to get uptime: %s", err.Error()))
@@ -213,7 +246,10 @@ func (dc *dcWrap) update(node *core.IpfsNode) []error {
 }
 
 func (dc *dcWrap) sendData(node *core.IpfsNode, config *config.Config) {
+	dc.mu.Lock()
 	sm, errs, err := dc.doPrepData(node)
+	a := dc.announcement()
+	dc.mu.Unlock()
 	if errs == nil {
 		errs = make([]error, 0)
 	}
@@ -239,11 +275,45 @@ func (dc *dcWrap) sendData(node *core.IpfsNode, config *config.Config) {
 			log.Error("failed to send data to status server: ", err)
 		} else {
 			log.Debug("sent analytics to status server")
+			dc.saveAnnouncement(a)
 		}
 		return err
 	}, bo)
 }
 
+// announce sends the latest settings to the status server once, for
+// 'btfs storage announce --now'.
+func (dc *dcWrap) announce(ctx context.Context, config *config.Config) (*announce.Announcement, error) {
+	dc.mu.Lock()
+	sm, _, err := dc.doPrepData(dc.node)
+	a := dc.announcement()
+	dc.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := dc.doSendData(ctx, config, sm); err != nil {
+		return nil, fmt.Errorf("failed to announce to status server: %s", err)
+	}
+	dc.saveAnnouncement(a)
+	return a, nil
+}
+
+// announcement returns what the last prepared data announces.
+func (dc *dcWrap) announcement() *announce.Announcement {
+	ns := dc.pn.Node_Settings
+	return &announce.Announcement{
+		Time:             time.Now(),
+		StorageVolumeCap: dc.pn.StorageVolumeCap,
+		Settings:         &ns,
+	}
+}
+
+func (dc *dcWrap) saveAnnouncement(a *announce.Announcement) {
+	if err := announce.SaveAnnouncement(dc.node.Repo.Datastore(), dc.node.Identity.Pretty(), a); err != nil {
+		log.Errorf("failed to save the last announcement: %s", err)
+	}
+}
+
 // doPrepData gathers the latest analytics and returns (signed object, list of reporting errors, failure)
 func (dc *dcWrap) doPrepData(btfsNode *core.IpfsNode) (*pb.SignedMetrics, []error, error) {
 	errs := dc.update(btfsNode)
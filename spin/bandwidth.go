@@ -0,0 +1,38 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/bandwidth"
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	bandwidthPeriod  = time.Minute
+	bandwidthTimeout = 10 * time.Minute
+)
+
+// Bandwidth pays the hosts of the renter bandwidth channels for the bytes
+// retrieved, and closes the host bandwidth channels due, see
+// 'btfs storage bandwidth'.
+func Bandwidth(req *cmds.Request, env cmds.Environment) {
+	go periodicHostSync(bandwidthPeriod, bandwidthTimeout, "bandwidth channels",
+		func(ctx context.Context) error {
+			return syncBandwidth(ctx, req, env, time.Now())
+		})
+}
+
+func syncBandwidth(ctx context.Context, req *cmds.Request, env cmds.Environment, now time.Time) error {
+	params, err := uh.ExtractContextParams(req, env)
+	if err != nil {
+		return err
+	}
+	paid, closed, err := bandwidth.Sync(ctx, params.N, params.Api, params.Cfg, now)
+	if paid+closed > 0 {
+		log.Infof("bandwidth channels: %d paid, %d closed", paid, closed)
+	}
+	return err
+}
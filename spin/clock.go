@@ -0,0 +1,46 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/clock"
+)
+
+const (
+	// clockPeriod is below clock.MaxAge so that the contracts are signed
+	// without waiting for a check.
+	clockPeriod  = 5 * time.Minute
+	clockTimeout = time.Minute
+)
+
+// Clock checks the system clock against NTP servers on start and
+// periodically, warning when it is skewed, see 'btfs diag clock'.
+func Clock(node *core.IpfsNode) {
+	go periodicHostSync(clockPeriod, clockTimeout, "clock",
+		func(ctx context.Context) error {
+			return checkClock(ctx, node)
+		})
+}
+
+func checkClock(ctx context.Context, node *core.IpfsNode) error {
+	if !node.IsOnline {
+		return nil
+	}
+	cfg, err := clock.Load(node.Repo)
+	if err != nil {
+		return err
+	}
+	if cfg.Disabled {
+		return nil
+	}
+	r := clock.Check(ctx, cfg, time.Now())
+	switch {
+	case !r.Synced:
+		log.Debugf("cannot check the system clock: no NTP server answered")
+	case r.Skewed():
+		log.Warnf("%s; contracts will not be signed until it is fixed", r.Err())
+	}
+	return nil
+}
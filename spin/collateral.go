@@ -0,0 +1,54 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	escrowclient "github.com/TRON-US/go-btfs/core/clients/escrow"
+	"github.com/TRON-US/go-btfs/core/commands/storage/collateral"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+
+	guardpb "github.com/tron-us/go-btfs-common/protos/guard"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+const (
+	collateralPeriod  = time.Hour
+	collateralTimeout = 10 * time.Minute
+)
+
+// Collateral releases the collateral of the host contracts once they
+// ended, and slashes the collateral of the renter contracts the guard
+// found lost, see 'btfs storage collateral'.
+func Collateral(node *core.IpfsNode) {
+	go periodicHostSync(collateralPeriod, collateralTimeout, "collateral",
+		func(ctx context.Context) error {
+			return settleCollateral(ctx, node, time.Now())
+		})
+}
+
+func settleCollateral(ctx context.Context, node *core.IpfsNode, now time.Time) error {
+	conf, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	d, self := node.Repo.Datastore(), node.Identity.Pretty()
+	lost := make(map[string]bool)
+	for _, role := range []nodepb.ContractStat_Role{nodepb.ContractStat_HOST, nodepb.ContractStat_RENTER} {
+		cs, err := contracts.ListContracts(d, self, role.String())
+		if err != nil {
+			return err
+		}
+		for _, c := range cs {
+			if c.Status == guardpb.Contract_LOST {
+				lost[c.ContractId] = true
+			}
+		}
+	}
+	released, slashed, err := collateral.Run(ctx, d, self, escrowclient.New(conf.Services.EscrowDomain), lost, now)
+	if released+slashed > 0 {
+		log.Infof("collateral of %d contracts released, %d slashed", released, slashed)
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/storage/digest"
+)
+
+const (
+	// digests are checked hourly for a restart not to delay one by a day
+	digestPeriod  = time.Hour
+	digestTimeout = 5 * time.Minute
+)
+
+// Digests sends the daily digest of the renter contracts needing
+// attention when ContractDigest.Enabled is set, see 'btfs storage digest'.
+func Digests(node *core.IpfsNode) {
+	go periodicHostSync(digestPeriod, digestTimeout, "contract digest",
+		func(ctx context.Context) error {
+			return sendDigest(ctx, node, time.Now())
+		})
+}
+
+func sendDigest(ctx context.Context, node *core.IpfsNode, now time.Time) error {
+	conf, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	if !conf.Experimental.StorageClientEnabled {
+		return nil
+	}
+	cfg, err := digest.Load(node.Repo)
+	if err != nil {
+		return err
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	last, err := digest.Last(node.Repo.Datastore(), node.Identity.Pretty())
+	if err != nil {
+		return err
+	}
+	if last != nil && now.Sub(last.Time) < digest.Period {
+		return nil
+	}
+	dg, err := digest.Compute(node, cfg.Days, now)
+	if err != nil {
+		return err
+	}
+	if dg.Empty() {
+		// nothing to report, checked again tomorrow
+		return digest.SaveLast(node.Repo.Datastore(), node.Identity.Pretty(), dg)
+	}
+	return digest.Deliver(ctx, node, cfg, dg)
+}
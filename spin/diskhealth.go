@@ -0,0 +1,128 @@
+package spin
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/alerts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/path"
+	"github.com/TRON-US/go-btfs/core/diskhealth"
+	"github.com/TRON-US/go-btfs/repo/fsrepo"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	diskHealthPeriod  = time.Hour
+	diskHealthTimeout = 5 * time.Minute
+)
+
+// DiskHealth checks the disk storing the shards of a host and raises an
+// alert when it is likely to fail, see 'btfs storage health'.
+func DiskHealth(node *core.IpfsNode) {
+	go periodicHostSync(diskHealthPeriod, diskHealthTimeout, "disk health",
+		func(ctx context.Context) error {
+			return checkDiskHealth(ctx, node, time.Now())
+		})
+}
+
+func checkDiskHealth(ctx context.Context, node *core.IpfsNode, now time.Time) error {
+	conf, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	if !conf.Experimental.StorageHostEnabled {
+		return nil
+	}
+	cfg, err := diskhealth.Load(node.Repo)
+	if err != nil {
+		return err
+	}
+	repoPath, err := fsrepo.BestKnownPath()
+	if err != nil {
+		return err
+	}
+	d := node.Repo.Datastore()
+	self := node.Identity.Pretty()
+
+	r, err := diskhealth.Check(ctx, d, self, cfg, repoPath, now)
+	if err != nil {
+		return err
+	}
+	prev, err := diskhealth.LastReport(d, self)
+	if err != nil {
+		return err
+	}
+	if err := diskhealth.SaveReport(d, self, r); err != nil {
+		return err
+	}
+	prevLevel := diskhealth.OK
+	if prev != nil {
+		prevLevel = prev.Level
+	}
+	if !r.Level.Worse(prevLevel) {
+		return nil
+	}
+	log.Warnf("disk storing the shards is %s: %s", r.Level, strings.Join(r.Reasons, "; "))
+	a := &diskhealth.Alert{Time: now, Level: r.Level, Reasons: r.Reasons}
+	if r.Level == diskhealth.Failing && cfg.MigrateTo != "" {
+		if a.MigrateTo, err = migrationPath(repoPath, cfg.MigrateTo); err != nil {
+			return err
+		}
+	}
+	// recorded first as the migration restarts the daemon
+	if err := diskhealth.PutAlert(d, self, a); err != nil {
+		return err
+	}
+	severity := alerts.Warning
+	if r.Level == diskhealth.Failing {
+		severity = alerts.Critical
+	}
+	alerts.Raise(node.Repo, &alerts.Alert{
+		Time:     now,
+		Peer:     self,
+		Source:   "diskhealth",
+		Severity: severity,
+		Title:    fmt.Sprintf("disk storing the shards is %s", r.Level),
+		Text:     strings.Join(r.Reasons, "\n"),
+	})
+	if a.MigrateTo == "" {
+		return nil
+	}
+	return migrateRepo(ctx, node, a.MigrateTo)
+}
+
+// migrationPath returns the absolute path of dst, empty when the repo at
+// repoPath already is there.
+func migrationPath(repoPath, dst string) (string, error) {
+	dst, err := homedir.Expand(dst)
+	if err != nil {
+		return "", err
+	}
+	if dst, err = filepath.Abs(dst); err != nil {
+		return "", err
+	}
+	if filepath.Clean(repoPath) == dst {
+		return "", nil
+	}
+	return dst, nil
+}
+
+// migrateRepo moves the repo, with the shards it stores, to dst and
+// restarts the daemon there.
+func migrateRepo(ctx context.Context, node *core.IpfsNode, dst string) error {
+	size, err := node.Repo.GetStorageUsage()
+	if err != nil {
+		return err
+	}
+	// the read errors were those of the failing disk
+	if err := diskhealth.ResetReads(node.Repo.Datastore(), node.Identity.Pretty()); err != nil {
+		return err
+	}
+	log.Warnf("migrating the repo off the failing disk to %s", dst)
+	return path.Relocate(ctx, dst, size)
+}
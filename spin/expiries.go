@@ -0,0 +1,39 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/upload"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const (
+	expiriesPeriod  = time.Hour
+	expiriesTimeout = 2 * time.Hour
+)
+
+// Expiries retrieves and deletes the files whose storage expires, see
+// 'btfs storage upload expiry'.
+func Expiries(req *cmds.Request, env cmds.Environment) {
+	go periodicHostSync(expiriesPeriod, expiriesTimeout, "storage expiries",
+		func(ctx context.Context) error {
+			params, err := uh.ExtractContextParams(req, env)
+			if err != nil {
+				return err
+			}
+			n, err := upload.RunExpiries(params.N.Repo.Datastore(), params.N.Identity.Pretty(), time.Now(),
+				func(e *upload.Expiry) error {
+					return upload.RetrieveFile(ctx, params.Api, e.FileHash, e.RetrieveTo)
+				},
+				func(e *upload.Expiry) error {
+					return upload.RemoveFile(ctx, params.Api, e.FileHash)
+				})
+			if n > 0 {
+				log.Infof("%d files expired", n)
+			}
+			return err
+		})
+}
@@ -0,0 +1,41 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/guard"
+)
+
+const (
+	guardQueuePeriod  = time.Minute
+	guardQueueTimeout = 30 * time.Minute
+)
+
+// GuardQueue retries the submissions to the guard failed or interrupted,
+// see 'btfs storage guard queue'.
+func GuardQueue(node *core.IpfsNode) {
+	go periodicHostSync(guardQueuePeriod, guardQueueTimeout, "guard queue",
+		func(ctx context.Context) error {
+			return retryGuardQueue(ctx, node)
+		})
+}
+
+func retryGuardQueue(ctx context.Context, node *core.IpfsNode) error {
+	conf, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	if !conf.Experimental.StorageClientEnabled {
+		return nil
+	}
+	r, err := guard.RetryQueued(ctx, node, false)
+	if err != nil {
+		return err
+	}
+	if r.Submitted > 0 || r.Failed > 0 {
+		log.Infof("retried the guard submissions: %d submitted, %d failed, %d pending", r.Submitted, r.Failed, r.Pending)
+	}
+	return nil
+}
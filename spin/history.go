@@ -0,0 +1,78 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/corerepo"
+	"github.com/TRON-US/go-btfs/core/history"
+
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+const historySampleTimeout = time.Minute
+
+// History samples the node statistics into the history kept in the
+// datastore, see 'btfs stats history'.
+func History(node *core.IpfsNode) {
+	go periodicHostSync(history.Interval, historySampleTimeout, "stats history",
+		func(ctx context.Context) error {
+			return sampleHistory(ctx, node, time.Now())
+		})
+}
+
+func sampleHistory(ctx context.Context, node *core.IpfsNode, now time.Time) error {
+	cfg, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	samples := map[string]map[string]float64{
+		history.Peers: {"count": float64(len(node.PeerHost.Network().Peers()))},
+	}
+	if node.Reporter != nil {
+		bw := node.Reporter.GetBandwidthTotals()
+		samples[history.Bandwidth] = map[string]float64{
+			"total_in":  float64(bw.TotalIn),
+			"total_out": float64(bw.TotalOut),
+			"rate_in":   bw.RateIn,
+			"rate_out":  bw.RateOut,
+		}
+	}
+	stat, err := corerepo.RepoStat(ctx, node)
+	if err != nil {
+		return err
+	}
+	samples[history.Repo] = map[string]float64{
+		"size":    float64(stat.RepoSize),
+		"objects": float64(stat.NumObjects),
+	}
+	if cfg.Experimental.StorageHostEnabled {
+		cs, err := contracts.ListContracts(node.Repo.Datastore(), node.Identity.Pretty(),
+			nodepb.ContractStat_HOST.String())
+		if err != nil {
+			return err
+		}
+		var active, paid, outstanding float64
+		for _, c := range cs {
+			if helper.ContractFilterMap["active"][c.Status] {
+				active++
+			}
+			paid += float64(c.CompensationPaid)
+			outstanding += float64(c.CompensationOutstanding)
+		}
+		samples[history.Contracts] = map[string]float64{"active": active}
+		samples[history.Earnings] = map[string]float64{"paid": paid, "outstanding": outstanding}
+	}
+
+	d := node.Repo.Datastore()
+	for metric, values := range samples {
+		err := history.Record(d, node.Identity.Pretty(), metric, &history.Sample{Time: now, Values: values})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
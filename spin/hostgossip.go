@@ -0,0 +1,32 @@
+package spin
+
+import (
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/hostgossip"
+)
+
+// HostGossip shares the availability of the hosts observed by the node with
+// its peers when HostGossip.Enabled is set, see
+// 'btfs storage hosts availability'.
+func HostGossip(node *core.IpfsNode) {
+	c, err := hostgossip.Load(node.Repo)
+	if err != nil {
+		log.Errorf("host gossip: %s", err)
+		return
+	}
+	if !c.Enabled || !node.IsOnline {
+		return
+	}
+	if node.PubSub == nil {
+		log.Errorf("host gossip: pubsub is disabled")
+		return
+	}
+	t := hostgossip.NewTable(node.Identity.Pretty(), c)
+	hostgossip.SetDefault(t)
+	go func() {
+		defer hostgossip.SetDefault(nil)
+		if err := hostgossip.Run(node.Context(), node.PubSub, node.PrivateKey, t); err != nil {
+			log.Errorf("host gossip: %s", err)
+		}
+	}()
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/TRON-US/go-btfs/core/commands/storage/announce"
 	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
 	"github.com/TRON-US/go-btfs/core/commands/storage/hosts"
 	"github.com/TRON-US/go-btfs/core/commands/storage/stats"
 
 	cmds "github.com/TRON-US/go-btfs-cmds"
 	"github.com/TRON-US/go-btfs/core"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
 )
 
 const (
@@ -32,26 +34,45 @@ func Hosts(node *core.IpfsNode, env cmds.Environment) {
 		m := cfg.Experimental.HostsSyncMode
 		fmt.Printf("Storage host info will be synced at [%s] mode\n", m)
 		go periodicHostSync(hostSyncPeriod, hostSyncTimeout+hostSortTimeout, "hosts",
-			func(ctx context.Context) error {
+			lowPower(node, hostSyncPeriod, func(ctx context.Context) error {
 				_, err := hosts.SyncHosts(ctx, node, m)
 				return err
-			})
+			}))
 	}
 	if cfg.Experimental.StorageHostEnabled {
 		fmt.Println("Current host stats will be synced")
 		go periodicHostSync(hostStatsSyncPeriod, hostSyncTimeout, "host stats",
-			func(ctx context.Context) error {
+			lowPower(node, hostStatsSyncPeriod, func(ctx context.Context) error {
 				return stats.SyncStats(ctx, cfg, node, env)
-			})
+			}))
 		fmt.Println("Current host settings will be synced")
 		go periodicHostSync(hostSettingsSyncPeriod, hostSyncTimeout, "host settings",
 			func(ctx context.Context) error {
-				_, err = helper.GetHostStorageConfigHelper(ctx, node, true)
-				return err
+				ns, err := helper.GetHostStorageConfigHelper(ctx, node, true)
+				if err != nil {
+					return err
+				}
+				return reannounce(ctx, node, ns)
 			})
 	}
 }
 
+// reannounce announces the host settings ns right away when they differ
+// from the last announcement, e.g. after a restart picked up new network
+// prices, instead of leaving renters with stale offers until the next
+// heartbeat.
+func reannounce(ctx context.Context, node *core.IpfsNode, ns *nodepb.Node_Settings) error {
+	last, err := announce.LastAnnouncement(node.Repo.Datastore(), node.Identity.Pretty())
+	if err != nil {
+		return err
+	}
+	if !last.Stale(ns) {
+		return nil
+	}
+	_, err = announce.Now(ctx)
+	return err
+}
+
 func periodicHostSync(period, timeout time.Duration, msg string, syncFunc func(context.Context) error) {
 	tick := time.NewTicker(period)
 	defer tick.Stop()
@@ -0,0 +1,117 @@
+package spin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/latency"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ping "github.com/libp2p/go-libp2p/p2p/protocol/ping"
+)
+
+const (
+	latencyPeriod  = 10 * time.Minute
+	latencyTimeout = 2 * time.Minute
+	// latencyProbeTimeout bounds a single measure.
+	latencyProbeTimeout = 10 * time.Second
+)
+
+// Latency measures the round-trip time to the bootstrap peers and the hub,
+// see 'btfs diag latency'. The node dials the fastest bootstrap peers
+// first on the next start.
+func Latency(node *core.IpfsNode) {
+	go periodicHostSync(latencyPeriod, latencyTimeout, "latency",
+		lowPower(node, latencyPeriod, func(ctx context.Context) error {
+			return measureLatency(ctx, node)
+		}))
+}
+
+func measureLatency(ctx context.Context, node *core.IpfsNode) error {
+	if !node.IsOnline {
+		return nil
+	}
+	cfg, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	d := node.Repo.Datastore()
+	self := node.Identity.Pretty()
+
+	peers, err := cfg.BootstrapPeers()
+	if err != nil {
+		return err
+	}
+	for _, p := range peers {
+		node.Peerstore.AddAddrs(p.ID, p.Addrs, peerstore.TempAddrTTL)
+		rtt, err := pingOnce(ctx, node, p.ID)
+		if err != nil {
+			log.Debugf("cannot measure the latency of bootstrap peer %s: %s", p.ID, err)
+		}
+		if err := latency.Record(d, self, latency.Bootstrap, p.ID.Pretty(), rtt, err, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Services.HubDomain != "" {
+		addr, err := hostPort(cfg.Services.HubDomain)
+		if err != nil {
+			return err
+		}
+		rtt, err := dialOnce(ctx, addr)
+		if err != nil {
+			log.Debugf("cannot measure the latency of hub %s: %s", addr, err)
+		}
+		if err := latency.Record(d, self, latency.Hub, addr, rtt, err, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pingOnce returns the RTT of a libp2p ping to the peer id, dialing it if
+// needed.
+func pingOnce(ctx context.Context, node *core.IpfsNode, id peer.ID) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, latencyProbeTimeout)
+	defer cancel()
+	r, ok := <-ping.Ping(ctx, node.PeerHost, id)
+	if !ok {
+		return 0, ctx.Err()
+	}
+	return r.RTT, r.Error
+}
+
+// dialOnce returns the time to open a TCP connection to addr, about one
+// round trip.
+func dialOnce(ctx context.Context, addr string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, latencyProbeTimeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	conn.Close()
+	return rtt, nil
+}
+
+// hostPort returns the host:port of a service URL.
+func hostPort(service string) (string, error) {
+	u, err := url.Parse(service)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid service url %q", service)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "http" {
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	}
+	return net.JoinHostPort(u.Hostname(), "443"), nil
+}
@@ -0,0 +1,85 @@
+package spin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/maintenance"
+)
+
+const (
+	maintenanceCheckPeriod = 10 * time.Minute
+	// the run is bounded by the window, this only bounds the check
+	maintenanceTimeout = 24 * time.Hour
+)
+
+// Maintenance runs the repo maintenance once in every configured window,
+// see 'btfs repo maintenance schedule'.
+func Maintenance(node *core.IpfsNode) {
+	go periodicHostSync(maintenanceCheckPeriod, maintenanceTimeout, "repo maintenance",
+		func(ctx context.Context) error {
+			return maintain(ctx, node, time.Now())
+		})
+}
+
+func maintain(ctx context.Context, node *core.IpfsNode, now time.Time) error {
+	cfg, err := maintenance.Load(node.Repo)
+	if err != nil {
+		return err
+	}
+	if cfg.Window == "" {
+		return nil
+	}
+	w, err := maintenance.ParseWindow(cfg.Window)
+	if err != nil {
+		return err
+	}
+	start, end, ok := w.Open(now)
+	if !ok {
+		return nil
+	}
+	d := node.Repo.Datastore()
+	last, err := maintenance.GetLastRun(d, node.Identity.Pretty())
+	if err != nil {
+		return err
+	}
+	if last != nil && !last.Start.Before(start) {
+		return nil
+	}
+	tasks, err := maintenance.ParseTasks(cfg.Tasks)
+	if err != nil {
+		return err
+	}
+	latency, err := cfg.ReadLatency()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, end)
+	defer cancel()
+	run := &maintenance.LastRun{Start: now, Tasks: tasks}
+	log.Infof("starting repo maintenance: %v", tasks)
+	result, err := maintenance.ForNode(node, latency).Run(ctx, tasks, func(p maintenance.Progress) {
+		if p.Msg != "" {
+			log.Infof("repo maintenance %s: %s", p.Task, p.Msg)
+		}
+	})
+	run.End = time.Now()
+	if err == context.DeadlineExceeded {
+		err = fmt.Errorf("maintenance window closed before the end of the run")
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if perr := maintenance.PutLastRun(d, node.Identity.Pretty(), run); perr != nil {
+		return perr
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("repo maintenance done: %d contracts archived, %d bytes reclaimed, %d corrupt blocks, %d filestore entries dropped",
+		result.Archived, result.Reclaimed, len(result.Corrupt), result.Dropped)
+	return nil
+}
@@ -0,0 +1,26 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/power"
+)
+
+// lowPower runs syncFunc, ticking every period, power.Config.Factor times
+// less often in low-power mode, see 'btfs power-mode'.
+func lowPower(node *core.IpfsNode, period time.Duration,
+	syncFunc func(context.Context) error) func(context.Context) error {
+	th := &power.Throttle{}
+	return func(ctx context.Context) error {
+		cfg, err := power.Load(node.Repo)
+		if err != nil {
+			return err
+		}
+		if !th.Due(cfg, period, time.Now()) {
+			return nil
+		}
+		return syncFunc(ctx)
+	}
+}
@@ -0,0 +1,65 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	"github.com/TRON-US/go-btfs/core/commands/storage/helper"
+	"github.com/TRON-US/go-btfs/core/priorityprov"
+
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+const (
+	priorityProvidingPeriod  = priorityprov.MinInterval
+	priorityProvidingTimeout = 30 * time.Minute
+	priorityProvideTimeout   = time.Minute
+)
+
+// PriorityProviding provides the priority records, the roots of the host
+// contracts among them, on their own intervals when
+// PriorityProviding.Enabled is set, see 'btfs dht priority'.
+func PriorityProviding(node *core.IpfsNode) {
+	go periodicHostSync(priorityProvidingPeriod, priorityProvidingTimeout, "priority providing",
+		lowPower(node, priorityProvidingPeriod, func(ctx context.Context) error {
+			return providePriority(ctx, node, time.Now())
+		}))
+}
+
+func providePriority(ctx context.Context, node *core.IpfsNode, now time.Time) error {
+	cfg, err := priorityprov.Load(node.Repo)
+	if err != nil {
+		return err
+	}
+	if !cfg.Enabled || node.DHT == nil {
+		return nil
+	}
+	d, self := node.Repo.Datastore(), node.Identity.Pretty()
+	conf, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	// the records of the contracts go once skipped
+	roots := make(map[string]string)
+	if conf.Experimental.StorageHostEnabled && !cfg.SkipContracts {
+		cs, err := contracts.ListContracts(d, self, nodepb.ContractStat_HOST.String())
+		if err != nil {
+			return err
+		}
+		for _, c := range cs {
+			if helper.ContractFilterMap["active"][c.Status] {
+				roots[c.ContractId] = c.ShardHash
+			}
+		}
+	}
+	if err := priorityprov.SyncContracts(d, self, roots, now); err != nil {
+		return err
+	}
+	n, err := priorityprov.Run(ctx, d, self, cfg, priorityprov.DHTRouter(node.DHT), priorityProvideTimeout, now)
+	if n > 0 {
+		log.Debugf("%d priority records provided", n)
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/probe"
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+)
+
+const probesTimeout = 30 * time.Minute
+
+// Probes probes the hosts of the files stored by a renter for their
+// retrieval SLA when RetrievalProbes.Enabled is set, see
+// 'btfs storage probe'.
+func Probes(req *cmds.Request, env cmds.Environment) {
+	period := probe.DefaultPeriod
+	if params, err := uh.ExtractContextParams(req, env); err == nil {
+		if cfg, err := probe.Load(params.N.Repo); err == nil {
+			period = cfg.PeriodDuration()
+		}
+	}
+	go periodicHostSync(period, probesTimeout, "retrieval probes",
+		func(ctx context.Context) error {
+			return runProbes(ctx, req, env, time.Now())
+		})
+}
+
+func runProbes(ctx context.Context, req *cmds.Request, env cmds.Environment, now time.Time) error {
+	params, err := uh.ExtractContextParams(req, env)
+	if err != nil {
+		return err
+	}
+	n := params.N
+	conf, err := n.Repo.Config()
+	if err != nil {
+		return err
+	}
+	if !conf.Experimental.StorageClientEnabled {
+		return nil
+	}
+	cfg, err := probe.Load(n.Repo)
+	if err != nil {
+		return err
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	_, err = probe.Run(ctx, n, params.Api, cfg, now)
+	return err
+}
@@ -0,0 +1,81 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core/commands/storage/contracts"
+	uh "github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/sessions"
+	"github.com/TRON-US/go-btfs/core/commands/storage/upload/upload"
+
+	cmds "github.com/TRON-US/go-btfs-cmds"
+	nodepb "github.com/tron-us/go-btfs-common/protos/node"
+)
+
+const (
+	renegotiationPeriod  = 6 * time.Hour
+	renegotiationTimeout = 30 * time.Minute
+)
+
+// Renegotiations renegotiates the renter contracts ending soon when
+// Renegotiation.Auto is set, see 'btfs storage contracts renegotiate'.
+func Renegotiations(req *cmds.Request, env cmds.Environment) {
+	go periodicHostSync(renegotiationPeriod, renegotiationTimeout, "contract renegotiations",
+		func(ctx context.Context) error {
+			return renegotiateDue(req, env, time.Now())
+		})
+}
+
+func renegotiateDue(req *cmds.Request, env cmds.Environment, now time.Time) error {
+	// the renewal sessions outlive the sync, they run in the context of
+	// the daemon
+	params, err := uh.ExtractContextParams(req, env)
+	if err != nil {
+		return err
+	}
+	n := params.N
+	cfg, err := contracts.LoadRenegotiationConfig(n.Repo)
+	if err != nil {
+		return err
+	}
+	if !cfg.Auto {
+		return nil
+	}
+	window, err := cfg.RenewalWindow()
+	if err != nil {
+		return err
+	}
+	d := n.Repo.Datastore()
+	self := n.Identity.Pretty()
+	cs, err := sessions.ListShardsContracts(d, self, nodepb.ContractStat_RENTER.String())
+	if err != nil {
+		return err
+	}
+	for _, sc := range cs {
+		c := sc.SignedGuardContract
+		if c == nil || c.RentEnd.Before(now) || c.RentEnd.After(now.Add(window)) {
+			continue
+		}
+		// the contracts of an expiring file lapse
+		if expiring, err := upload.Expiring(d, self, c.FileHash); err != nil {
+			return err
+		} else if expiring {
+			continue
+		}
+		rounds, err := contracts.Negotiation(d, self, c.ContractId)
+		if err != nil {
+			return err
+		}
+		if contracts.Decided(rounds) {
+			continue
+		}
+		out, err := upload.Renegotiate(params, c.ContractId, 0)
+		if err != nil {
+			log.Errorf("failed to renegotiate contract %s: %s", c.ContractId, err)
+			continue
+		}
+		log.Infof("contract %s renegotiated: %s, %s", c.ContractId, out.Action, out.Reason)
+	}
+	return nil
+}
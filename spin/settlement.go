@@ -0,0 +1,42 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/commands/storage/settlement"
+)
+
+const (
+	settlementPeriod  = 10 * time.Minute
+	settlementTimeout = 5 * time.Minute
+)
+
+// Settlements withdraws the payouts of the host contracts in batches when
+// Settlement.Enabled is set, see 'btfs storage settlement'.
+func Settlements(node *core.IpfsNode) {
+	go periodicHostSync(settlementPeriod, settlementTimeout, "settlement",
+		func(ctx context.Context) error {
+			return settle(ctx, node, time.Now())
+		})
+}
+
+func settle(ctx context.Context, node *core.IpfsNode, now time.Time) error {
+	conf, err := node.Repo.Config()
+	if err != nil {
+		return err
+	}
+	if !conf.Experimental.StorageHostEnabled {
+		return nil
+	}
+	cfg, err := settlement.Load(node.Repo)
+	if err != nil {
+		return err
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	_, err = settlement.Run(ctx, node, cfg, false, now)
+	return err
+}
@@ -0,0 +1,38 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/wallet"
+)
+
+const (
+	transfersPeriod  = time.Minute
+	transfersTimeout = 5 * time.Minute
+)
+
+// Transfers executes the scheduled transfers of the wallet when due, see
+// 'btfs wallet schedules'.
+func Transfers(node *core.IpfsNode) {
+	go periodicHostSync(transfersPeriod, transfersTimeout, "scheduled transfers",
+		func(ctx context.Context) error {
+			cfg, err := node.Repo.Config()
+			if err != nil {
+				return err
+			}
+			n, err := wallet.RunSchedules(node.Repo.Datastore(), node.Identity.Pretty(), time.Now(),
+				func(to string, amount int64) (string, error) {
+					ret, err := wallet.TransferBTT(ctx, node, cfg, nil, "", to, amount)
+					if err != nil {
+						return "", err
+					}
+					return ret.TxId, nil
+				})
+			if n > 0 {
+				log.Infof("executed %d scheduled transfers", n)
+			}
+			return err
+		})
+}
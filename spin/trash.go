@@ -0,0 +1,40 @@
+package spin
+
+import (
+	"context"
+	"time"
+
+	"github.com/TRON-US/go-btfs/core"
+	"github.com/TRON-US/go-btfs/core/trash"
+)
+
+const (
+	trashPurgePeriod  = time.Hour
+	trashPurgeTimeout = 10 * time.Minute
+)
+
+// Trash purges the files removed with 'btfs files rm' once they expire.
+func Trash(node *core.IpfsNode) {
+	go periodicHostSync(trashPurgePeriod, trashPurgeTimeout, "trash purge",
+		func(ctx context.Context) error {
+			return purgeTrash(ctx, node, time.Now())
+		})
+}
+
+func purgeTrash(ctx context.Context, node *core.IpfsNode, now time.Time) error {
+	cfg, err := trash.Load(node.Repo)
+	if err != nil {
+		return err
+	}
+	ttl, err := cfg.Retention()
+	if err != nil {
+		return err
+	}
+	// the entries removed while enabled still expire once it is disabled
+	bin := trash.New(node.FilesRoot, node.Repo.Datastore(), node.Identity.Pretty())
+	purged, err := bin.Purge(ctx, trash.Expired(now.Add(-ttl)))
+	if len(purged) > 0 {
+		log.Infof("purged %d expired files from the trash", len(purged))
+	}
+	return err
+}
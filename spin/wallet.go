@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/TRON-US/go-btfs/core/commands/storage/upload/helper"
+	"github.com/TRON-US/go-btfs/core/power"
 	"github.com/TRON-US/go-btfs/core/wallet"
 )
 
@@ -27,9 +28,14 @@ func (wt *walletWrap) UpdateStatus() {
 	go func() {
 		tick := time.NewTicker(period)
 		defer tick.Stop()
+		// in low-power mode the pending transactions pile up and are
+		// updated in one batch every few periods
+		th := &power.Throttle{}
 		for {
-			sc, ec, err := wallet.UpdatePendingTransactions(wt.Ctx, wt.N.Repo.Datastore(), wt.Cfg, wt.N.Identity.String())
-			log.Debugf("update pending tx, success: %v, error: %v, err: %v", sc, ec, err)
+			if pcfg, err := power.Load(wt.N.Repo); err != nil || th.Due(pcfg, period, time.Now()) {
+				sc, ec, err := wallet.UpdatePendingTransactions(wt.Ctx, wt.N.Repo.Datastore(), wt.Cfg, wt.N.Identity.String())
+				log.Debugf("update pending tx, success: %v, error: %v, err: %v", sc, ec, err)
+			}
 			select {
 			case <-tick.C:
 				continue
@@ -38,7 +38,8 @@ test_expect_success "btfs version --all has all required fields" '
   grep "go-btfs version" version_all.txt &&
   grep "Repo version" version_all.txt &&
   grep "System version" version_all.txt &&
-  grep "Golang version" version_all.txt
+  grep "Golang version" version_all.txt &&
+  grep "Protocol renter-host versions" version_all.txt
 '
 
 test_expect_success "btfs version deps succeeds" '